@@ -0,0 +1,69 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// ADF state
+
+package abstract
+
+import "errors"
+
+// ADFState represents the state of the ADF (Automatic Document Feeder).
+type ADFState int
+
+// Known ADF states
+const (
+	ADFStateUnknown   ADFState = iota // State is not known
+	ADFStateEmpty                     // ADF is empty
+	ADFStateLoaded                    // ADF is loaded with sheets
+	ADFStateJam                       // Paper jam in the ADF
+	ADFStateMultipick                 // Multiple sheets picked at once
+)
+
+// ScannerADFState is the optional interface, that may be implemented
+// by the [Scanner] which knows the actual state of its ADF (i.e.,
+// has the paper sensor).
+//
+// It allows scanner servers to report the ADF state to the clients
+// between scan jobs.
+type ScannerADFState interface {
+	Scanner
+
+	// ADFState returns the current state of the ADF.
+	ADFState() ADFState
+}
+
+// ADFStateOfError returns the ADFState, reported by the error
+// returned by the Scanner.Scan or Document.Next, or ADFStateUnknown
+// if error doesn't indicate the ADF problem.
+func ADFStateOfError(err error) ADFState {
+	switch {
+	case errors.Is(err, ErrADFEmpty):
+		return ADFStateEmpty
+	case errors.Is(err, ErrADFJam):
+		return ADFStateJam
+	case errors.Is(err, ErrADFMultipick):
+		return ADFStateMultipick
+	}
+
+	return ADFStateUnknown
+}
+
+// Err returns the error, that indicates the ADF problem, reported
+// by the ADFState, or nil, if ADFState doesn't indicate a problem.
+//
+// It is the inverse of the [ADFStateOfError].
+func (state ADFState) Err() error {
+	switch state {
+	case ADFStateEmpty:
+		return ErrADFEmpty
+	case ADFStateJam:
+		return ErrADFJam
+	case ADFStateMultipick:
+		return ErrADFMultipick
+	}
+
+	return nil
+}
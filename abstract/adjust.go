@@ -0,0 +1,399 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scan request auto-adjustment
+
+package abstract
+
+import (
+	"fmt"
+
+	"github.com/OpenPrinting/go-mfp/util/generic"
+)
+
+// Adjustment describes a single change of the [ScannerRequest]
+// parameter, made by the [ScannerRequest.Adjust].
+//
+// Name is the same as used by the [ErrParam], returned by the
+// [ScannerRequest.Validate] for this parameter.
+type Adjustment struct {
+	Name string // Parameter name
+	From any    // Original value
+	To   any    // Adjusted value
+}
+
+// String returns the string representation of the [Adjustment],
+// for logging.
+func (adj Adjustment) String() string {
+	return fmt.Sprintf("%s: %v -> %v", adj.Name, adj.From, adj.To)
+}
+
+// adjustColorModeFallback defines, in order of preference, the
+// fallback color modes for the unsupported ColorMode.
+var adjustColorModeFallback = [colorModeMax][]ColorMode{
+	ColorModeBinary: {ColorModeMono, ColorModeColor},
+	ColorModeMono:   {ColorModeColor, ColorModeBinary},
+	ColorModeColor:  {ColorModeMono, ColorModeBinary},
+}
+
+// Adjust modifies the request in place to fit the [ScannerCapabilities]
+// and returns the list of changes made, nil if request was left as is.
+//
+// Unlike [ScannerRequest.Validate], which rejects the request with the
+// first unsupported parameter, Adjust replaces unsupported values
+// with the nearest supported ones:
+//   - unsupported Input and ADFMode are replaced with the available ones
+//   - unsupported ColorMode falls back to the closest supported mode
+//     (i.e., Color->Mono->Binary)
+//   - Resolution is replaced with the closest supported one
+//   - Region is clamped to the input dimensions
+//   - image processing parameters are clamped to their ranges
+//   - unsupported hints (Intent, CCDChannel, BinaryRendering and
+//     similar) are reset to their default (unset) values
+//
+// If scanner has no inputs at all, there is nothing to adjust to,
+// and request is returned as is.
+//
+// Note, Preview requests are never adjusted for Resolution, as
+// it is just a hint for them (see [ScannerRequest.PreviewResolution]).
+func (req *ScannerRequest) Adjust(
+	scancaps *ScannerCapabilities) []Adjustment {
+
+	var adjs []Adjustment
+	add := func(name string, from, to any) {
+		adjs = append(adjs, Adjustment{name, from, to})
+	}
+
+	if scancaps.Platen == nil && scancaps.Camera == nil &&
+		scancaps.ADFSimplex == nil && scancaps.ADFDuplex == nil {
+		return nil
+	}
+
+	// Adjust Input and ADFMode
+	input := req.adjustInput(scancaps)
+	if input != req.Input {
+		add("Input", req.Input, input)
+		req.Input = input
+	}
+
+	if req.Input == InputADF {
+		mode := req.ADFMode
+		switch {
+		case mode < ADFModeUnset || mode >= adfModeMax:
+			mode = ADFModeUnset
+		case mode == ADFModeSimplex && scancaps.ADFSimplex == nil:
+			mode = ADFModeDuplex
+		case mode == ADFModeDuplex && scancaps.ADFDuplex == nil:
+			mode = ADFModeSimplex
+		}
+
+		if mode != req.ADFMode {
+			add("ADFMode", req.ADFMode, mode)
+			req.ADFMode = mode
+		}
+	}
+
+	// Gather overall scanner parameters
+	inputs := req.adjustInputs(scancaps)
+
+	var intents generic.Bitset[Intent]
+	var colorModes generic.Bitset[ColorMode]
+	var depths generic.Bitset[ColorDepth]
+	var binrend generic.Bitset[BinaryRendering]
+	var ccdChannels generic.Bitset[CCDChannel]
+
+	for _, inp := range inputs {
+		intents = intents.Union(inp.Intents)
+		for _, prof := range inp.Profiles {
+			colorModes = colorModes.Union(prof.ColorModes)
+			depths = depths.Union(prof.Depths)
+			binrend = binrend.Union(prof.BinaryRenderings)
+			ccdChannels = ccdChannels.Union(prof.CCDChannels)
+		}
+	}
+
+	// Adjust ColorMode
+	if req.ColorMode != ColorModeUnset &&
+		!colorModes.Contains(req.ColorMode) {
+		var fallbacks []ColorMode
+		if req.ColorMode > ColorModeUnset &&
+			req.ColorMode < colorModeMax {
+			fallbacks = adjustColorModeFallback[req.ColorMode]
+		}
+
+		cm := ColorModeUnset
+		for _, fallback := range fallbacks {
+			if colorModes.Contains(fallback) {
+				cm = fallback
+				break
+			}
+		}
+
+		add("ColorMode", req.ColorMode, cm)
+		req.ColorMode = cm
+	}
+
+	// Adjust BinaryRendering and Threshold
+	switch req.ColorMode {
+	case ColorModeUnset, ColorModeBinary:
+		if req.BinaryRendering != BinaryRenderingUnset &&
+			!binrend.Contains(req.BinaryRendering) {
+			add("BinaryRendering", req.BinaryRendering,
+				BinaryRenderingUnset)
+			req.BinaryRendering = BinaryRenderingUnset
+		}
+
+		req.Threshold = scancaps.ThresholdRange.adjust(
+			"Threshold", req.Threshold, add)
+	}
+
+	// Adjust ColorDepth. The nearest supported depth is chosen.
+	switch req.ColorMode {
+	case ColorModeUnset, ColorModeMono, ColorModeColor:
+		if req.ColorDepth != ColorDepthUnset &&
+			!depths.Contains(req.ColorDepth) {
+			depth := req.adjustColorDepth(depths)
+			add("ColorDepth", req.ColorDepth, depth)
+			req.ColorDepth = depth
+		}
+	}
+
+	// Adjust CCDChannel
+	switch req.ColorMode {
+	case ColorModeUnset, ColorModeBinary, ColorModeMono:
+		if req.CCDChannel != CCDChannelUnset &&
+			!ccdChannels.Contains(req.CCDChannel) {
+			add("CCDChannel", req.CCDChannel, CCDChannelUnset)
+			req.CCDChannel = CCDChannelUnset
+		}
+	}
+
+	// Adjust Intent
+	if req.Intent != IntentUnset && !intents.Contains(req.Intent) {
+		add("Intent", req.Intent, IntentUnset)
+		req.Intent = IntentUnset
+	}
+
+	// Adjust Region
+	if !req.Region.IsZero() {
+		reg := req.adjustRegion(inputs)
+		if reg != req.Region {
+			add("Region", req.Region, reg)
+			req.Region = reg
+		}
+	}
+
+	// Adjust Resolution
+	if !req.Resolution.IsZero() && !req.Preview {
+		res := req.adjustResolution(inputs)
+		if res != req.Resolution {
+			add("Resolution", req.Resolution, res)
+			req.Resolution = res
+		}
+	}
+
+	// Adjust blank page detection and removal
+	if req.BlankPageRemoval && !scancaps.BlankPageRemoval {
+		add("BlankPageRemoval", true, false)
+		req.BlankPageRemoval = false
+	}
+
+	if req.BlankPageDetection && !scancaps.BlankPageDetection {
+		add("BlankPageDetection", true, false)
+		req.BlankPageDetection = false
+	}
+
+	// Adjust image processing parameters
+	req.Brightness = scancaps.BrightnessRange.adjust(
+		"Brightness", req.Brightness, add)
+	req.Contrast = scancaps.ContrastRange.adjust(
+		"Contrast", req.Contrast, add)
+	req.Gamma = scancaps.GammaRange.adjust(
+		"Gamma", req.Gamma, add)
+	req.Highlight = scancaps.HighlightRange.adjust(
+		"Highlight", req.Highlight, add)
+	req.NoiseRemoval = scancaps.NoiseRemovalRange.adjust(
+		"NoiseRemoval", req.NoiseRemoval, add)
+	req.Shadow = scancaps.ShadowRange.adjust(
+		"Shadow", req.Shadow, add)
+	req.Sharpen = scancaps.SharpenRange.adjust(
+		"Sharpen", req.Sharpen, add)
+	req.Compression = scancaps.CompressionRange.adjust(
+		"Compression", req.Compression, add)
+
+	return adjs
+}
+
+// adjustInput returns the Input, supported by the scanner, closest
+// to the requested one. Scanner must have at least one input.
+func (req *ScannerRequest) adjustInput(scancaps *ScannerCapabilities) Input {
+	hasADF := scancaps.ADFSimplex != nil || scancaps.ADFDuplex != nil
+
+	switch {
+	case req.Input == InputUnset:
+		return InputUnset
+	case req.Input == InputPlaten && scancaps.Platen != nil:
+		return InputPlaten
+	case req.Input == InputADF && hasADF:
+		return InputADF
+	case req.Input == InputCamera && scancaps.Camera != nil:
+		return InputCamera
+	}
+
+	switch {
+	case scancaps.Platen != nil:
+		return InputPlaten
+	case hasADF:
+		return InputADF
+	}
+
+	return InputCamera
+}
+
+// adjustInputs returns InputCapabilities, relevant to the request
+// with already adjusted Input and ADFMode. The choice is consistent
+// with the [ScannerRequest.Validate].
+func (req *ScannerRequest) adjustInputs(
+	scancaps *ScannerCapabilities) []*InputCapabilities {
+
+	var inputs []*InputCapabilities
+
+	switch req.Input {
+	case InputUnset:
+		for _, inp := range []*InputCapabilities{scancaps.Platen,
+			scancaps.ADFSimplex, scancaps.ADFDuplex, scancaps.Camera} {
+			if inp != nil {
+				inputs = append(inputs, inp)
+			}
+		}
+
+	case InputPlaten:
+		inputs = append(inputs, scancaps.Platen)
+
+	case InputCamera:
+		inputs = append(inputs, scancaps.Camera)
+
+	case InputADF:
+		switch {
+		case req.ADFMode == ADFModeDuplex:
+			inputs = append(inputs, scancaps.ADFDuplex)
+		case scancaps.ADFSimplex != nil:
+			inputs = append(inputs, scancaps.ADFSimplex)
+		default:
+			inputs = append(inputs, scancaps.ADFDuplex)
+		}
+	}
+
+	return inputs
+}
+
+// adjustRegion returns the Region, clamped to dimensions of the
+// first of inputs, unless it already fits any of them.
+// Invalid Region is reset to zero (i.e., the entire input).
+func (req *ScannerRequest) adjustRegion(inputs []*InputCapabilities) Region {
+	if !req.Region.Valid() {
+		return Region{}
+	}
+
+	for _, inp := range inputs {
+		if req.Region.FitsCapabilities(inp) {
+			return req.Region
+		}
+	}
+
+	inp := inputs[0]
+	reg := req.Region
+
+	reg.Width = max(min(reg.Width, inp.MaxWidth), inp.MinWidth)
+	reg.Height = max(min(reg.Height, inp.MaxHeight), inp.MinHeight)
+	reg.XOffset = max(min(reg.XOffset, inp.MaxXOffset,
+		inp.MaxWidth-reg.Width), 0)
+	reg.YOffset = max(min(reg.YOffset, inp.MaxYOffset,
+		inp.MaxHeight-reg.Height), 0)
+
+	if !reg.FitsCapabilities(inp) {
+		// Capabilities are inconsistent
+		return Region{}
+	}
+
+	return reg
+}
+
+// adjustResolution returns the supported Resolution, closest to
+// the requested one, for the requested ColorMode and CCDChannel.
+// If there is no suitable resolution, or requested Resolution is
+// invalid, it returns zero Resolution (i.e., scanner's default).
+//
+// Like [SettingsProfile.AllowsResolution], it considers only the
+// discrete resolutions.
+func (req *ScannerRequest) adjustResolution(
+	inputs []*InputCapabilities) Resolution {
+
+	if !req.Resolution.Valid() {
+		return Resolution{}
+	}
+
+	target := req.Resolution
+	best := Resolution{}
+	bestDist := 0
+
+	for _, inp := range inputs {
+		for _, prof := range inp.Profiles {
+			ok := prof.AllowsColorMode(req.ColorMode,
+				req.ColorDepth, req.BinaryRendering)
+			ok = ok && prof.AllowsCCDChannel(req.CCDChannel)
+			if !ok {
+				continue
+			}
+
+			for _, res := range prof.Resolutions {
+				dist := adjustDistance(res.XResolution,
+					target.XResolution)
+				dist += adjustDistance(res.YResolution,
+					target.YResolution)
+
+				// On tie, prefer the higher resolution
+				switch {
+				case best.IsZero(), dist < bestDist,
+					dist == bestDist &&
+						previewDots(res) > previewDots(best):
+					best, bestDist = res, dist
+				}
+			}
+		}
+	}
+
+	return best
+}
+
+// adjustColorDepth returns the supported ColorDepth, nearest to
+// the requested one, or ColorDepthUnset, if there is no such depth.
+func (req *ScannerRequest) adjustColorDepth(
+	depths generic.Bitset[ColorDepth]) ColorDepth {
+
+	depth := ColorDepthUnset
+	if req.ColorDepth <= ColorDepthUnset || req.ColorDepth >= colorDepthMax {
+		return depth
+	}
+
+	for d := ColorDepthUnset + 1; d < colorDepthMax; d++ {
+		if depths.Contains(d) && (depth == ColorDepthUnset ||
+			adjustDistance(int(d), int(req.ColorDepth)) <
+				adjustDistance(int(depth), int(req.ColorDepth))) {
+			depth = d
+		}
+	}
+
+	return depth
+}
+
+// adjustDistance returns the distance between two integers.
+func adjustDistance(v1, v2 int) int {
+	if v1 < v2 {
+		return v2 - v1
+	}
+	return v1 - v2
+}
@@ -0,0 +1,362 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scan request auto-adjustment tests
+
+package abstract
+
+import (
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// TestScannerRequestAdjust tests ScannerRequest.Adjust function.
+func TestScannerRequestAdjust(t *testing.T) {
+	type testData struct {
+		comment  string
+		scancaps *ScannerCapabilities
+		req      ScannerRequest
+		expected ScannerRequest
+		adjs     []Adjustment
+	}
+
+	platen := testPlatenInputCapabilities
+
+	tests := []testData{
+		{
+			comment:  "all-default request",
+			scancaps: testScannerCapabilities,
+		},
+
+		{
+			comment:  "no input supported",
+			scancaps: testScannerCapabilitiesNoInput,
+			req:      ScannerRequest{Input: InputPlaten},
+			expected: ScannerRequest{Input: InputPlaten},
+		},
+
+		{
+			comment:  "valid request left as is",
+			scancaps: testScannerCapabilities,
+			req: ScannerRequest{
+				Input:      InputADF,
+				ADFMode:    ADFModeDuplex,
+				ColorMode:  ColorModeColor,
+				Resolution: Resolution{300, 300},
+				Brightness: optional.New(10),
+			},
+			expected: ScannerRequest{
+				Input:      InputADF,
+				ADFMode:    ADFModeDuplex,
+				ColorMode:  ColorModeColor,
+				Resolution: Resolution{300, 300},
+				Brightness: optional.New(10),
+			},
+		},
+
+		{
+			comment:  "InputPlaten, unsupported",
+			scancaps: testScannerCapabilitiesNoPlaten,
+			req:      ScannerRequest{Input: InputPlaten},
+			expected: ScannerRequest{Input: InputADF},
+			adjs:     []Adjustment{{"Input", InputPlaten, InputADF}},
+		},
+
+		{
+			comment:  "InputADF, unsupported",
+			scancaps: testScannerCapabilitiesNoADF,
+			req: ScannerRequest{
+				Input:   InputADF,
+				ADFMode: ADFModeDuplex,
+			},
+			expected: ScannerRequest{
+				Input:   InputPlaten,
+				ADFMode: ADFModeDuplex,
+			},
+			adjs: []Adjustment{{"Input", InputADF, InputPlaten}},
+		},
+
+		{
+			comment:  "InputCamera, unsupported",
+			scancaps: testScannerCapabilities,
+			req:      ScannerRequest{Input: InputCamera},
+			expected: ScannerRequest{Input: InputPlaten},
+			adjs:     []Adjustment{{"Input", InputCamera, InputPlaten}},
+		},
+
+		{
+			comment:  "ADFModeDuplex, unsupported",
+			scancaps: testScannerCapabilitiesNoADFDuplex,
+			req: ScannerRequest{
+				Input:   InputADF,
+				ADFMode: ADFModeDuplex,
+			},
+			expected: ScannerRequest{
+				Input:   InputADF,
+				ADFMode: ADFModeSimplex,
+			},
+			adjs: []Adjustment{
+				{"ADFMode", ADFModeDuplex, ADFModeSimplex},
+			},
+		},
+
+		{
+			comment:  "ColorModeColor, unsupported",
+			scancaps: testScannerCapabilitiesNoColor,
+			req:      ScannerRequest{ColorMode: ColorModeColor},
+			expected: ScannerRequest{ColorMode: ColorModeMono},
+			adjs: []Adjustment{
+				{"ColorMode", ColorModeColor, ColorModeMono},
+			},
+		},
+
+		{
+			comment:  "ColorDepth16, unsupported",
+			scancaps: testScannerCapabilities,
+			req:      ScannerRequest{ColorDepth: ColorDepth16},
+			expected: ScannerRequest{ColorDepth: ColorDepth8},
+			adjs: []Adjustment{
+				{"ColorDepth", ColorDepth16, ColorDepth8},
+			},
+		},
+
+		{
+			comment:  "BinaryRenderingHalftone, unsupported",
+			scancaps: testScannerCapabilitiesNoHalftone,
+			req: ScannerRequest{
+				ColorMode:       ColorModeBinary,
+				BinaryRendering: BinaryRenderingHalftone,
+			},
+			expected: ScannerRequest{
+				ColorMode: ColorModeBinary,
+			},
+			adjs: []Adjustment{
+				{"BinaryRendering", BinaryRenderingHalftone,
+					BinaryRenderingUnset},
+			},
+		},
+
+		{
+			comment:  "Intent and CCDChannel, invalid",
+			scancaps: testScannerCapabilities,
+			req: ScannerRequest{
+				Intent:     intentMax,
+				CCDChannel: ccdChannelMax,
+			},
+			expected: ScannerRequest{},
+			adjs: []Adjustment{
+				{"CCDChannel", ccdChannelMax, CCDChannelUnset},
+				{"Intent", intentMax, IntentUnset},
+			},
+		},
+
+		{
+			comment:  "Resolution, closest",
+			scancaps: testScannerCapabilities,
+			req: ScannerRequest{
+				ColorMode:  ColorModeColor,
+				Resolution: Resolution{500, 500},
+			},
+			expected: ScannerRequest{
+				ColorMode:  ColorModeColor,
+				Resolution: Resolution{600, 600},
+			},
+			adjs: []Adjustment{
+				{"Resolution", Resolution{500, 500},
+					Resolution{600, 600}},
+			},
+		},
+
+		{
+			comment:  "Resolution, above all, depends on ColorMode",
+			scancaps: testScannerCapabilities,
+			req: ScannerRequest{
+				ColorMode:  ColorModeMono,
+				Resolution: Resolution{5000, 5000},
+			},
+			expected: ScannerRequest{
+				ColorMode:  ColorModeMono,
+				Resolution: Resolution{2400, 2400},
+			},
+			adjs: []Adjustment{
+				{"Resolution", Resolution{5000, 5000},
+					Resolution{2400, 2400}},
+			},
+		},
+
+		{
+			comment:  "Resolution, preview",
+			scancaps: testScannerCapabilities,
+			req: ScannerRequest{
+				Preview:    true,
+				Resolution: Resolution{5000, 5000},
+			},
+			expected: ScannerRequest{
+				Preview:    true,
+				Resolution: Resolution{5000, 5000},
+			},
+		},
+
+		{
+			comment:  "Region, too large",
+			scancaps: testScannerCapabilities,
+			req: ScannerRequest{
+				Input: InputPlaten,
+				Region: Region{
+					XOffset: 10,
+					Width:   platen.MaxWidth + 100,
+					Height:  platen.MaxHeight + 100,
+				},
+			},
+			expected: ScannerRequest{
+				Input: InputPlaten,
+				Region: Region{
+					Width:  platen.MaxWidth,
+					Height: platen.MaxHeight,
+				},
+			},
+			adjs: []Adjustment{
+				{"Region",
+					Region{
+						XOffset: 10,
+						Width:   platen.MaxWidth + 100,
+						Height:  platen.MaxHeight + 100,
+					},
+					Region{
+						Width:  platen.MaxWidth,
+						Height: platen.MaxHeight,
+					},
+				},
+			},
+		},
+
+		{
+			comment:  "Region, invalid",
+			scancaps: testScannerCapabilities,
+			req: ScannerRequest{
+				Region: Region{Width: -1, Height: 100},
+			},
+			expected: ScannerRequest{},
+			adjs: []Adjustment{
+				{"Region", Region{Width: -1, Height: 100},
+					Region{}},
+			},
+		},
+
+		{
+			comment:  "BlankPageDetection, unsupported",
+			scancaps: testScannerCapabilitiesNoBlankPage,
+			req: ScannerRequest{
+				BlankPageDetection: true,
+				BlankPageRemoval:   true,
+			},
+			expected: ScannerRequest{},
+			adjs: []Adjustment{
+				{"BlankPageRemoval", true, false},
+				{"BlankPageDetection", true, false},
+			},
+		},
+
+		{
+			comment:  "image processing parameters",
+			scancaps: testScannerCapabilities,
+			req: ScannerRequest{
+				ColorMode:   ColorModeBinary,
+				Threshold:   optional.New(200),
+				Brightness:  optional.New(-500),
+				Compression: optional.New(3),
+			},
+			expected: ScannerRequest{
+				ColorMode:   ColorModeBinary,
+				Threshold:   optional.New(100),
+				Brightness:  optional.New(-100),
+				Compression: optional.New(3),
+			},
+			adjs: []Adjustment{
+				{"Threshold", 200, 100},
+				{"Brightness", -500, -100},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		req := test.req
+		adjs := req.Adjust(test.scancaps)
+
+		diff := testutils.Diff(test.expected, req)
+		if diff != "" {
+			t.Errorf("%s: request mismatch:\n%s", test.comment, diff)
+		}
+
+		diff = testutils.Diff(test.adjs, adjs)
+		if diff != "" {
+			t.Errorf("%s: adjustments mismatch:\n%s",
+				test.comment, diff)
+		}
+
+		if test.scancaps != testScannerCapabilitiesNoInput {
+			err := req.Validate(test.scancaps)
+			if err != nil {
+				t.Errorf("%s: adjusted request: %s",
+					test.comment, err)
+			}
+		}
+	}
+}
+
+// TestRangeAdjust tests Range.adjust function.
+func TestRangeAdjust(t *testing.T) {
+	type testData struct {
+		r        Range
+		param    optional.Val[int]
+		expected optional.Val[int]
+	}
+
+	tests := []testData{
+		{Range{Min: 0, Max: 100}, nil, nil},
+		{Range{Min: 0, Max: 100}, optional.New(50), optional.New(50)},
+		{Range{Min: 0, Max: 100}, optional.New(-5), optional.New(0)},
+		{Range{Min: 0, Max: 100}, optional.New(105), optional.New(100)},
+		{Range{}, optional.New(5), nil},
+		{Range{Min: 0, Max: 100, Step: 10}, optional.New(44),
+			optional.New(40)},
+		{Range{Min: 0, Max: 100, Step: 10}, optional.New(45),
+			optional.New(50)},
+		{Range{Min: 0, Max: 95, Step: 10}, optional.New(95),
+			optional.New(90)},
+	}
+
+	for _, test := range tests {
+		var adjs []Adjustment
+		add := func(name string, from, to any) {
+			adjs = append(adjs, Adjustment{name, from, to})
+		}
+
+		v := test.r.adjust("Param", test.param, add)
+		if diff := testutils.Diff(test.expected, v); diff != "" {
+			t.Errorf("%#v: %v:\n%s", test.r,
+				optional.Get(test.param), diff)
+		}
+
+		changed := optional.Get(test.param) != optional.Get(v) ||
+			(test.param == nil) != (v == nil)
+		if changed != (len(adjs) != 0) {
+			t.Errorf("%#v: %v: change not reported properly",
+				test.r, optional.Get(test.param))
+		}
+	}
+}
+
+// TestAdjustmentString tests Adjustment.String function.
+func TestAdjustmentString(t *testing.T) {
+	adj := Adjustment{"Brightness", 200, 100}
+	s := adj.String()
+	if s != "Brightness: 200 -> 100" {
+		t.Errorf("Adjustment.String: %q", s)
+	}
+}
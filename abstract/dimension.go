@@ -8,6 +8,8 @@
 
 package abstract
 
+import "math"
+
 // Dimension represents image coordinates and sizes.
 //
 // Coordinates are 0-based, with the (0,0) point placed at the
@@ -115,3 +117,14 @@ func DimensionFromDots(dpi, dots int) Dimension {
 
 	return Dimension(tmp)
 }
+
+// Millimeters returns Dimension value in millimeters.
+func (dim Dimension) Millimeters() float64 {
+	return float64(dim) / float64(Millimeter)
+}
+
+// DimensionFromMillimeters decodes Dimension value from millimeters,
+// rounding to the nearest integer value.
+func DimensionFromMillimeters(mm float64) Dimension {
+	return Dimension(math.Round(mm * float64(Millimeter)))
+}
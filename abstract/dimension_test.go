@@ -129,3 +129,25 @@ func TestDimensionBoundDots(t *testing.T) {
 		}
 	}
 }
+
+// TestDimensionMillimeters tests Dimension.Millimeters and
+// DimensionFromMillimeters
+func TestDimensionMillimeters(t *testing.T) {
+	if mm := A4Width.Millimeters(); mm != 210 {
+		t.Errorf("A4Width.Millimeters: expected 210, present %g", mm)
+	}
+
+	if mm := Inch.Millimeters(); mm != 25.4 {
+		t.Errorf("Inch.Millimeters: expected 25.4, present %g", mm)
+	}
+
+	if dim := DimensionFromMillimeters(25.4); dim != Inch {
+		t.Errorf("DimensionFromMillimeters(25.4): "+
+			"expected %d, present %d", Inch, dim)
+	}
+
+	if dim := DimensionFromMillimeters(0.004); dim != 0 {
+		t.Errorf("DimensionFromMillimeters(0.004): "+
+			"expected 0, present %d", dim)
+	}
+}
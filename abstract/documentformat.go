@@ -12,12 +12,13 @@ import "bytes"
 
 // MIME types for known document formats:
 const (
-	DocumentFormatBMP  = "image/bmp"
-	DocumentFormatJPEG = "image/jpeg"
-	DocumentFormatPDF  = "application/pdf"
-	DocumentFormatPNG  = "image/png"
-	DocumentFormatTIFF = "image/tiff"
-	DocumentFormatData = "application/octet-stream"
+	DocumentFormatBMP       = "image/bmp"
+	DocumentFormatJPEG      = "image/jpeg"
+	DocumentFormatPDF       = "application/pdf"
+	DocumentFormatPNG       = "image/png"
+	DocumentFormatPWGRaster = "image/pwg-raster"
+	DocumentFormatTIFF      = "image/tiff"
+	DocumentFormatData      = "application/octet-stream"
 )
 
 // DocumentFormatDetect detects document type by its few starting bytes
@@ -45,6 +46,7 @@ var formatTable = []struct {
 	{[]byte{'%', 'P', 'D', 'F', '-'}, DocumentFormatPDF},
 	{[]byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a},
 		DocumentFormatPNG},
+	{[]byte{'R', 'a', 'S', '2'}, DocumentFormatPWGRaster},
 	{[]byte{'I', 'I', '*', 0}, DocumentFormatTIFF},
 	{[]byte{'M', 'M', 0, '*'}, DocumentFormatTIFF},
 }
@@ -47,6 +47,11 @@ func TestDocumentFormatDetect(t *testing.T) {
 			format: DocumentFormatPNG,
 		},
 
+		{
+			data:   []byte("RaS2PwgRaster\000"),
+			format: DocumentFormatPWGRaster,
+		},
+
 		{
 			data:   testutils.Images.TIFF100x75,
 			format: DocumentFormatTIFF,
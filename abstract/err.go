@@ -19,6 +19,9 @@ const (
 	ErrInvalidParam
 	ErrUnsupportedParam
 	ErrDocumentClosed
+	ErrADFEmpty
+	ErrADFJam
+	ErrADFMultipick
 )
 
 // Error returns error string. It implements the [error] interface.
@@ -30,6 +33,12 @@ func (e ErrCode) Error() string {
 		return "Unsupported parameter"
 	case ErrDocumentClosed:
 		return "Document is closed"
+	case ErrADFEmpty:
+		return "ADF is empty"
+	case ErrADFJam:
+		return "Paper jam in the ADF"
+	case ErrADFMultipick:
+		return "ADF multiple pick detected"
 	}
 	return ""
 }
@@ -11,6 +11,7 @@ package abstract
 import (
 	"bytes"
 	"image"
+	"io"
 
 	"github.com/OpenPrinting/go-mfp/imgconv"
 )
@@ -176,8 +177,24 @@ func (file *filterDocumentFile) Read(buf []byte) (int, error) {
 		// Don't let buffer to grow indefinitely
 		file.output.Reset()
 
-		// Read and encode the next image Row
+		// Image already finished?
+		if file.encoder == nil {
+			return 0, io.EOF
+		}
+
+		// Read and encode the next image Row. At the end of
+		// image, close the encoder, so it flushes the buffered
+		// data and writes the image trailer.
 		_, err := file.pipeline.Read(file.row)
+		if err == io.EOF {
+			err = file.encoder.Close()
+			file.encoder = nil
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+
 		if err != nil {
 			return 0, err
 		}
@@ -194,5 +211,9 @@ func (file *filterDocumentFile) Read(buf []byte) (int, error) {
 
 // close closes the filterDocumentFile.
 func (file *filterDocumentFile) close() {
+	if file.encoder != nil {
+		file.encoder.Close()
+		file.encoder = nil
+	}
 	file.pipeline.Close()
 }
@@ -27,6 +27,10 @@ type InputCapabilities struct {
 	RiskyTopMargins       Dimension // Risky top margins, 0 - unknown
 	RiskyBottomMargins    Dimension // Risky bottom margins, 0 - unknown
 
+	// ADF options (ADFSimplex and ADFDuplex only)
+	ADFDetectPaperLoaded bool // ADF can detect if paper is loaded
+	ADFSelectSinglePage  bool // ADF can scan part of loaded pages
+
 	// Scanning parameters
 	Intents generic.Bitset[Intent] // Supported intents
 
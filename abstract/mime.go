@@ -30,4 +30,8 @@ const (
 	MIMETypeTIFF = "image/tiff"
 	MIMETypePNG  = "image/png"
 	MIMETypePDF  = "application/pdf"
+
+	// MIMETypeOctetStream is used by print clients, when document
+	// format is not known and must be auto-detected by the printer.
+	MIMETypeOctetStream = "application/octet-stream"
 )
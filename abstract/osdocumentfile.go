@@ -0,0 +1,109 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// DocumentFile on a top of os.File
+
+package abstract
+
+import (
+	"io"
+	"os"
+)
+
+// DocumentOSFile is the optional interface, that may be implemented
+// by the [DocumentFile], backed by the [os.File] (for example, when
+// image is spooled into the temporary file).
+//
+// It allows consumers of the DocumentFile to use the zero-copy I/O
+// fast paths, like sendfile(2), instead of copying data through
+// the userspace buffers.
+type DocumentOSFile interface {
+	DocumentFile
+
+	// OSFile returns the underlying os.File, positioned at the
+	// current read position, and count of the remaining bytes.
+	//
+	// If the count of remaining bytes cannot be determined,
+	// it returns nil.
+	OSFile() (*os.File, int64)
+}
+
+// DocumentSHA256 is the optional interface, that may be implemented
+// by the [DocumentFile], which SHA-256 digest is known in advance
+// (for example, computed when image was spooled into the temporary
+// file).
+//
+// It allows consumers to send the digest (i.e., in the HTTP
+// Content-Digest header) without reading the content twice.
+type DocumentSHA256 interface {
+	DocumentFile
+
+	// SHA256 returns SHA-256 digest of the remaining content
+	// of the DocumentFile. If digest is not known, it returns nil.
+	SHA256() []byte
+}
+
+// osDocumentFile is the [DocumentFile], created by the
+// [NewOSDocumentFile].
+type osDocumentFile struct {
+	format string   // Returned by DocumentFile.Format
+	file   *os.File // Underlying file
+	sum    []byte   // SHA-256 of the whole file, nil if unknown
+}
+
+// NewOSDocumentFile returns the [DocumentFile], that reads the
+// image of the specified format from the [os.File], starting
+// from its current position.
+//
+// The returned DocumentFile implements the [DocumentOSFile]
+// interface. Caller is responsible for closing the file.
+func NewOSDocumentFile(format string, file *os.File) DocumentFile {
+	return &osDocumentFile{format: format, file: file}
+}
+
+// Format returns the MIME type of the image format used by
+// the document file.
+func (file *osDocumentFile) Format() string {
+	return file.format
+}
+
+// Read reads the document file content as a sequence of bytes.
+// It implements the [io.Reader] interface.
+func (file *osDocumentFile) Read(buf []byte) (int, error) {
+	return file.file.Read(buf)
+}
+
+// OSFile returns the underlying os.File and count of the
+// remaining bytes.
+func (file *osDocumentFile) OSFile() (*os.File, int64) {
+	info, err := file.file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, 0
+	}
+
+	off, err := file.file.Seek(0, io.SeekCurrent)
+	if err != nil || off > info.Size() {
+		return nil, 0
+	}
+
+	return file.file, info.Size() - off
+}
+
+// SHA256 returns SHA-256 digest of the remaining content,
+// if known. Digest is only known for the spooled files
+// (see [NewSpoolDocument]) that were not read yet.
+func (file *osDocumentFile) SHA256() []byte {
+	if file.sum == nil {
+		return nil
+	}
+
+	off, err := file.file.Seek(0, io.SeekCurrent)
+	if err != nil || off != 0 {
+		return nil
+	}
+
+	return file.sum
+}
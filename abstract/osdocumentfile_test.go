@@ -0,0 +1,55 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// DocumentFile on a top of os.File test
+
+package abstract
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOSDocumentFile tests NewOSDocumentFile
+func TestOSDocumentFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "page.jpg")
+	err := os.WriteFile(name, []byte("0123456789"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	file := NewOSDocumentFile(DocumentFormatJPEG, fp)
+	if file.Format() != DocumentFormatJPEG {
+		t.Errorf("Format: %q", file.Format())
+	}
+
+	osfile, ok := file.(DocumentOSFile)
+	if !ok {
+		t.Fatalf("DocumentOSFile not implemented")
+	}
+
+	// Remaining size must track the read position
+	buf := make([]byte, 4)
+	io.ReadFull(file, buf)
+
+	f, size := osfile.OSFile()
+	if f != fp || size != 6 {
+		t.Errorf("OSFile: %p, %d, expected %p, %d", f, size, fp, 6)
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(f, size))
+	if string(data) != "456789" {
+		t.Errorf("remaining data: %q", data)
+	}
+}
@@ -0,0 +1,140 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Preview scan
+
+package abstract
+
+// DefaultPreviewResolution is the preview scan resolution, used
+// when [ScannerRequest.Resolution] is not set.
+var DefaultPreviewResolution = Resolution{XResolution: 75, YResolution: 75}
+
+// PreviewResolution returns resolution for the preview scan.
+//
+// The target resolution is the req.Resolution, if set, or
+// [DefaultPreviewResolution] otherwise.
+//
+// It chooses the lowest resolution, supported by the scanner for
+// the requested Input and ColorMode, that is not below the target.
+// If all supported resolutions are below the target, the highest
+// of them is chosen. If scanner doesn't report any suitable
+// resolution, the target resolution is returned as is.
+//
+// Note, it doesn't check whether ScannerRequest is preview
+// request or not.
+func (req *ScannerRequest) PreviewResolution(
+	scancaps *ScannerCapabilities) Resolution {
+
+	target := req.Resolution
+	if target.IsZero() {
+		target = DefaultPreviewResolution
+	}
+
+	// Gather candidates
+	var candidates []Resolution
+
+	for _, inp := range req.previewInputs(scancaps) {
+		for _, prof := range inp.Profiles {
+			ok := prof.AllowsColorMode(req.ColorMode,
+				req.ColorDepth, req.BinaryRendering)
+			ok = ok && prof.AllowsCCDChannel(req.CCDChannel)
+			if !ok {
+				continue
+			}
+
+			candidates = append(candidates, prof.Resolutions...)
+
+			rr := prof.ResolutionRange
+			if !rr.IsZero() {
+				candidates = append(candidates, Resolution{
+					XResolution: previewRange(target.XResolution,
+						rr.XMin, rr.XMax, rr.XStep),
+					YResolution: previewRange(target.YResolution,
+						rr.YMin, rr.YMax, rr.YStep),
+				})
+			}
+		}
+	}
+
+	// Choose the best one
+	var above, below Resolution
+	for _, res := range candidates {
+		if res.XResolution >= target.XResolution &&
+			res.YResolution >= target.YResolution {
+			if above.IsZero() || previewDots(res) < previewDots(above) {
+				above = res
+			}
+		} else if previewDots(res) > previewDots(below) {
+			below = res
+		}
+	}
+
+	switch {
+	case !above.IsZero():
+		return above
+	case !below.IsZero():
+		return below
+	}
+
+	return target
+}
+
+// previewInputs returns InputCapabilities, relevant to the
+// preview request.
+func (req *ScannerRequest) previewInputs(
+	scancaps *ScannerCapabilities) []*InputCapabilities {
+
+	var inputs []*InputCapabilities
+
+	if req.Input == InputCamera {
+		if scancaps.Camera != nil {
+			inputs = append(inputs, scancaps.Camera)
+		}
+		return inputs
+	}
+
+	if req.Input != InputADF && scancaps.Platen != nil {
+		inputs = append(inputs, scancaps.Platen)
+	}
+
+	if req.Input != InputPlaten {
+		if req.ADFMode != ADFModeDuplex && scancaps.ADFSimplex != nil {
+			inputs = append(inputs, scancaps.ADFSimplex)
+		}
+		if req.ADFMode != ADFModeSimplex && scancaps.ADFDuplex != nil {
+			inputs = append(inputs, scancaps.ADFDuplex)
+		}
+	}
+
+	return inputs
+}
+
+// previewRange returns the lowest value within the range
+// which is not below the target, or the range maximum,
+// if target is above the range.
+func previewRange(target, min, max, step int) int {
+	switch {
+	case target <= min:
+		return min
+	case target >= max:
+		return max
+	case step <= 0:
+		return target
+	}
+
+	v := min + (target-min+step-1)/step*step
+	if v > max {
+		v = max
+	}
+
+	return v
+}
+
+// previewDots returns "square" of the resolution, used to compare
+// resolutions.
+func previewDots(res Resolution) int {
+	return res.XResolution * res.YResolution
+}
@@ -0,0 +1,134 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Preview scan tests
+
+package abstract
+
+import "testing"
+
+// TestScannerRequestPreviewResolution tests
+// ScannerRequest.PreviewResolution
+func TestScannerRequestPreviewResolution(t *testing.T) {
+	rangeCaps := &ScannerCapabilities{
+		Platen: &InputCapabilities{
+			Profiles: []SettingsProfile{
+				{
+					ColorModes: testColorModes,
+					Depths:     testDepth,
+					ResolutionRange: ResolutionRange{
+						XMin: 50, XMax: 600, XStep: 25,
+						YMin: 50, YMax: 600, YStep: 25,
+					},
+				},
+			},
+		},
+	}
+
+	type testData struct {
+		comment  string               // Comment for the test
+		req      ScannerRequest       // The request
+		caps     *ScannerCapabilities // Scanner capabilities
+		expected Resolution           // Expected resolution
+	}
+
+	tests := []testData{
+		{
+			comment:  "default target, lowest resolution",
+			req:      ScannerRequest{Preview: true},
+			caps:     testScannerCapabilities,
+			expected: Resolution{200, 100},
+		},
+
+		{
+			comment: "explicit target",
+			req: ScannerRequest{
+				Preview:    true,
+				Resolution: Resolution{250, 250},
+			},
+			caps:     testScannerCapabilities,
+			expected: Resolution{300, 300},
+		},
+
+		{
+			comment: "target above all, any color mode",
+			req: ScannerRequest{
+				Preview:    true,
+				Resolution: Resolution{5000, 5000},
+			},
+			caps:     testScannerCapabilities,
+			expected: Resolution{2400, 2400},
+		},
+
+		{
+			comment: "target above all, color",
+			req: ScannerRequest{
+				Preview:    true,
+				ColorMode:  ColorModeColor,
+				Resolution: Resolution{5000, 5000},
+			},
+			caps:     testScannerCapabilities,
+			expected: Resolution{1200, 1200},
+		},
+
+		{
+			comment: "target above all, ADF",
+			req: ScannerRequest{
+				Preview:    true,
+				Input:      InputADF,
+				Resolution: Resolution{5000, 5000},
+			},
+			caps:     testScannerCapabilities,
+			expected: Resolution{600, 600},
+		},
+
+		{
+			comment:  "resolution range, default target",
+			req:      ScannerRequest{Preview: true},
+			caps:     rangeCaps,
+			expected: Resolution{75, 75},
+		},
+
+		{
+			comment: "resolution range, rounded up to step",
+			req: ScannerRequest{
+				Preview:    true,
+				Resolution: Resolution{80, 120},
+			},
+			caps:     rangeCaps,
+			expected: Resolution{100, 125},
+		},
+
+		{
+			comment: "resolution range, missed input",
+			req: ScannerRequest{
+				Preview: true,
+				Input:   InputADF,
+			},
+			caps:     rangeCaps,
+			expected: DefaultPreviewResolution,
+		},
+	}
+
+	for _, test := range tests {
+		res := test.req.PreviewResolution(test.caps)
+		if res != test.expected {
+			t.Errorf("%s:\nexpected: %v\npresent:  %v",
+				test.comment, test.expected, res)
+		}
+	}
+
+	// Preview resolution is not validated
+	req := ScannerRequest{
+		Preview:    true,
+		Resolution: Resolution{75, 75},
+	}
+
+	err := req.Validate(testScannerCapabilities)
+	if err != nil {
+		t.Errorf("preview Validate: unexpected error %s", err)
+	}
+}
@@ -0,0 +1,43 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The printer interface
+
+package abstract
+
+import (
+	"context"
+)
+
+// Printer represents the abstract (implementation-independent)
+// interface to the printer.
+//
+// The interface is intentionally minimal. It is the common part of
+// the [IPP] and [WS-Print] printing models: the printer accepts
+// documents, one by one, with the job parameters defined by the
+// [PrinterRequest]. Job management (queueing, status, cancellation)
+// is left to the protocol servers, built on a top of the Printer.
+//
+// [IPP]: https://www.rfc-editor.org/rfc/rfc8011.html
+// [WS-Print]: https://learn.microsoft.com/en-us/windows-hardware/drivers/print/ws-print-v1-1
+type Printer interface {
+	// Capabilities returns the [PrinterCapabilities].
+	// Caller should not modify the returned structure.
+	Capabilities() *PrinterCapabilities
+
+	// Print prints the document. Request parameters are
+	// defined via [PrinterRequest] structure.
+	//
+	// Print consumes the document file data until [io.EOF]
+	// and may block until the document is printed.
+	//
+	// Request processing can be canceled via provided
+	// [context.Context].
+	Print(context.Context, PrinterRequest, DocumentFile) error
+
+	// Close closes the printer connection.
+	Close() error
+}
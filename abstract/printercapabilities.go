@@ -0,0 +1,37 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Printer capabilities
+
+package abstract
+
+import (
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+)
+
+// PrinterCapabilities defines the printer capabilities.
+type PrinterCapabilities struct {
+	// General information
+	UUID         uuid.UUID // Device UUID
+	MakeAndModel string    // Device make and model
+	SerialNumber string    // Device-unique serial number
+	Manufacturer string    // Device manufacturer
+	AdminURI     string    // Configuration mage URL
+	IconURI      string    // Device icon URL
+
+	// Printing parameters
+	DocumentFormats []string // Supported document formats
+	MediaSizes      []string // Supported media, PWG 5101.1 names
+	Color           bool     // Color printing supported
+	Duplex          bool     // Duplex printing supported
+	MaxCopies       int      // Max copies, 0 means 1
+}
+
+// Clone makes a shallow copy of the [PrinterCapabilities].
+func (prncaps *PrinterCapabilities) Clone() *PrinterCapabilities {
+	clone := *prncaps
+	return &clone
+}
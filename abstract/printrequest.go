@@ -0,0 +1,57 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Print request parameters
+
+package abstract
+
+import "slices"
+
+// PrinterRequest specifies print request parameters
+//
+// All parameters are optional. Use zero value to to indicate
+// that parameter is missed.
+type PrinterRequest struct {
+	JobName        string // Job name
+	UserName       string // Originating user name
+	DocumentFormat string // Document format (MIME type)
+	MediaSize      string // PWG 5101.1 media size name
+	Copies         int    // Number of copies
+	Duplex         bool   // Print on both sides
+}
+
+// Validate checks request validity against the [PrinterCapabilities]
+// and reports found error, if any.
+//
+// The "application/octet-stream" DocumentFormat means "auto-detect"
+// and always accepted.
+func (req *PrinterRequest) Validate(prncaps *PrinterCapabilities) error {
+	switch {
+	case req.DocumentFormat == "",
+		req.DocumentFormat == MIMETypeOctetStream:
+	case !slices.Contains(prncaps.DocumentFormats, req.DocumentFormat):
+		return ErrParam{ErrUnsupportedParam,
+			"DocumentFormat", req.DocumentFormat}
+	}
+
+	if req.MediaSize != "" &&
+		!slices.Contains(prncaps.MediaSizes, req.MediaSize) {
+		return ErrParam{ErrUnsupportedParam, "MediaSize", req.MediaSize}
+	}
+
+	switch {
+	case req.Copies < 0:
+		return ErrParam{ErrInvalidParam, "Copies", req.Copies}
+	case req.Copies > max(prncaps.MaxCopies, 1):
+		return ErrParam{ErrUnsupportedParam, "Copies", req.Copies}
+	}
+
+	if req.Duplex && !prncaps.Duplex {
+		return ErrParam{ErrUnsupportedParam, "Duplex", req.Duplex}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Print request tests
+
+package abstract
+
+import (
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+)
+
+// testPrinterCapabilities contains the test PrinterCapabilities
+var testPrinterCapabilities = &PrinterCapabilities{
+	UUID:            testUUID,
+	MakeAndModel:    "Virtual Printer",
+	DocumentFormats: []string{MIMETypePDF, MIMETypeJPEG},
+	MediaSizes:      []string{"iso_a4_210x297mm", "na_letter_8.5x11in"},
+	Duplex:          true,
+	MaxCopies:       99,
+}
+
+// testPrinterCapabilitiesSimplex contains the test PrinterCapabilities
+// without duplex and copies support
+var testPrinterCapabilitiesSimplex = &PrinterCapabilities{
+	UUID:            testUUID,
+	MakeAndModel:    "Virtual Printer",
+	DocumentFormats: []string{MIMETypePDF},
+}
+
+// TestPrinterRequestValidate tests PrinterRequest.Validate
+func TestPrinterRequestValidate(t *testing.T) {
+	type testData struct {
+		comment string
+		prncaps *PrinterCapabilities
+		req     *PrinterRequest
+		err     error
+	}
+
+	tests := []testData{
+		{
+			comment: "all-default request",
+			prncaps: testPrinterCapabilities,
+			req:     &PrinterRequest{},
+		},
+
+		{
+			comment: "all parameters set",
+			prncaps: testPrinterCapabilities,
+			req: &PrinterRequest{
+				JobName:        "test",
+				UserName:       "user",
+				DocumentFormat: MIMETypeJPEG,
+				MediaSize:      "na_letter_8.5x11in",
+				Copies:         99,
+				Duplex:         true,
+			},
+		},
+
+		{
+			comment: "auto-detected DocumentFormat",
+			prncaps: testPrinterCapabilitiesSimplex,
+			req: &PrinterRequest{
+				DocumentFormat: MIMETypeOctetStream,
+			},
+		},
+
+		{
+			comment: "unsupported DocumentFormat",
+			prncaps: testPrinterCapabilitiesSimplex,
+			req: &PrinterRequest{
+				DocumentFormat: MIMETypeJPEG,
+			},
+			err: ErrParam{
+				ErrUnsupportedParam, "DocumentFormat",
+				MIMETypeJPEG,
+			},
+		},
+
+		{
+			comment: "unsupported MediaSize",
+			prncaps: testPrinterCapabilities,
+			req: &PrinterRequest{
+				MediaSize: "iso_a3_297x420mm",
+			},
+			err: ErrParam{
+				ErrUnsupportedParam, "MediaSize",
+				"iso_a3_297x420mm",
+			},
+		},
+
+		{
+			comment: "invalid Copies",
+			prncaps: testPrinterCapabilities,
+			req: &PrinterRequest{
+				Copies: -1,
+			},
+			err: ErrParam{ErrInvalidParam, "Copies", -1},
+		},
+
+		{
+			comment: "single copy, MaxCopies not set",
+			prncaps: testPrinterCapabilitiesSimplex,
+			req: &PrinterRequest{
+				Copies: 1,
+			},
+		},
+
+		{
+			comment: "unsupported Copies",
+			prncaps: testPrinterCapabilitiesSimplex,
+			req: &PrinterRequest{
+				Copies: 2,
+			},
+			err: ErrParam{ErrUnsupportedParam, "Copies", 2},
+		},
+
+		{
+			comment: "unsupported Duplex",
+			prncaps: testPrinterCapabilitiesSimplex,
+			req: &PrinterRequest{
+				Duplex: true,
+			},
+			err: ErrParam{ErrUnsupportedParam, "Duplex", true},
+		},
+	}
+
+	for _, test := range tests {
+		err := test.req.Validate(test.prncaps)
+		diff := testutils.Diff(test.err, err)
+		if diff != "" {
+			t.Errorf("failed: %q:\n%s", test.comment, diff)
+		}
+	}
+}
@@ -60,3 +60,33 @@ func (r Range) validate(name string, param optional.Val[int]) error {
 
 	return nil
 }
+
+// adjust clamps the parameter to the Range. If Range is zero
+// (parameter not supported), the parameter is reset to nil.
+// Changes are reported via the add callback.
+//
+// Used by the [ScannerRequest.Adjust].
+func (r Range) adjust(name string, param optional.Val[int],
+	add func(name string, from, to any)) optional.Val[int] {
+
+	if param == nil || r.Within(*param) {
+		return param
+	}
+
+	if r.IsZero() {
+		add(name, *param, nil)
+		return nil
+	}
+
+	v := max(min(*param, r.Max), r.Min)
+	if r.Step > 1 {
+		// Round to the nearest step, staying within the range
+		v = r.Min + (v-r.Min+r.Step/2)/r.Step*r.Step
+		if v > r.Max {
+			v -= r.Step
+		}
+	}
+
+	add(name, *param, v)
+	return optional.New(v)
+}
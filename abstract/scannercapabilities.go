@@ -37,10 +37,15 @@ type ScannerCapabilities struct {
 	SharpenRange      Range // Image sharpen
 	ThresholdRange    Range // ColorModeBinary+BinaryRenderingThreshold
 
+	// Blank page detection and removal (ADF only)
+	BlankPageDetection bool // Blank page detection supported
+	BlankPageRemoval   bool // Blank page removal supported
+
 	// Input capabilities (nil if input not suppored)
 	Platen     *InputCapabilities // InputPlaten capabilities
 	ADFSimplex *InputCapabilities // InputADF+ADFModeSimplex
 	ADFDuplex  *InputCapabilities // InputADF+ADFModeDuplex
+	Camera     *InputCapabilities // InputCamera capabilities
 }
 
 // Clone makes a shallow copy of the [ScannerCapabilities].
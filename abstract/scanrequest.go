@@ -30,6 +30,23 @@ type ScannerRequest struct {
 	Resolution      Resolution      // Scanner resolution
 	Intent          Intent          // Scan intent hint
 
+	// Preview requests a rapid low-resolution preview scan.
+	//
+	// If set, Resolution is interpreted as a hint for the desired
+	// preview resolution and scanner is free to choose the closest
+	// suitable value (see [ScannerRequest.PreviewResolution]).
+	// Scanner which cannot scan at a low resolution may scan at
+	// the supported one and downsample the image.
+	Preview bool
+
+	// Blank page detection and removal (ADF only).
+	//
+	// If BlankPageDetection is requested, scanner reports detected
+	// blank pages. If BlankPageRemoval is requested, scanner skips
+	// them; it implies BlankPageDetection.
+	BlankPageDetection bool // Detect blank pages
+	BlankPageRemoval   bool // Skip detected blank pages
+
 	// Image processing parameters.
 	//
 	// As zero value is the legal value of these parameters,
@@ -63,6 +80,9 @@ func (req *ScannerRequest) Validate(scancaps *ScannerCapabilities) error {
 		if scancaps.ADFDuplex != nil {
 			inputs = append(inputs, scancaps.ADFDuplex)
 		}
+		if scancaps.Camera != nil {
+			inputs = append(inputs, scancaps.Camera)
+		}
 		if len(inputs) == 0 {
 			return ErrParam{ErrUnsupportedParam, "Input", req.Input}
 		}
@@ -73,6 +93,12 @@ func (req *ScannerRequest) Validate(scancaps *ScannerCapabilities) error {
 		}
 		inputs = []*InputCapabilities{scancaps.Platen}
 
+	case InputCamera:
+		if scancaps.Camera == nil {
+			return ErrParam{ErrUnsupportedParam, "Input", req.Input}
+		}
+		inputs = []*InputCapabilities{scancaps.Camera}
+
 	case InputADF:
 		if scancaps.ADFSimplex == nil && scancaps.ADFDuplex == nil {
 			return ErrParam{ErrUnsupportedParam, "Input", req.Input}
@@ -212,8 +238,9 @@ func (req *ScannerRequest) Validate(scancaps *ScannerCapabilities) error {
 		}
 	}
 
-	// Check Resolution
-	if !req.Resolution.IsZero() {
+	// Check Resolution. Note, for the preview requests
+	// Resolution is just a hint.
+	if !req.Resolution.IsZero() && !req.Preview {
 		if !req.Resolution.Valid() {
 			return ErrParam{ErrInvalidParam,
 				"Resolution", req.Resolution}
@@ -237,6 +264,16 @@ func (req *ScannerRequest) Validate(scancaps *ScannerCapabilities) error {
 		}
 	}
 
+	// Check blank page detection and removal
+	switch {
+	case req.BlankPageRemoval && !scancaps.BlankPageRemoval:
+		return ErrParam{ErrUnsupportedParam,
+			"BlankPageRemoval", req.BlankPageRemoval}
+	case req.BlankPageDetection && !scancaps.BlankPageDetection:
+		return ErrParam{ErrUnsupportedParam,
+			"BlankPageDetection", req.BlankPageDetection}
+	}
+
 	// Check image processing parameters.
 	err := scancaps.BrightnessRange.validate("Brightness", req.Brightness)
 	if err == nil {
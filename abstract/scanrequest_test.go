@@ -136,6 +136,8 @@ var testScannerCapabilities = &ScannerCapabilities{
 	Platen:            testPlatenInputCapabilities,
 	ADFSimplex:        testADFenInputCapabilities,
 	ADFDuplex:         testADFenInputCapabilities,
+
+	BlankPageDetection: true,
 }
 
 // Variations of the initialized ScannerCapabilities structure:
@@ -146,6 +148,9 @@ var testScannerCapabilities = &ScannerCapabilities{
 //   - testScannerCapabilitiesNoInput      - no inputs at all
 //   - testScannerCapabilitiesNoColor      - no ColorModeColor support
 //   - testScannerCapabilitiesNoHalftone   - no BinaryRenderingHalftone
+//   - testScannerCapabilitiesNoBlankPage  - no BlankPageDetection
+//   - testScannerCapabilitiesCamera       - with Camera
+//   - testScannerCapabilitiesCameraOnly   - Camera is the only input
 var testScannerCapabilitiesNoPlaten *ScannerCapabilities
 var testScannerCapabilitiesNoADF *ScannerCapabilities
 var testScannerCapabilitiesNoADFSimplex *ScannerCapabilities
@@ -153,6 +158,9 @@ var testScannerCapabilitiesNoADFDuplex *ScannerCapabilities
 var testScannerCapabilitiesNoInput *ScannerCapabilities
 var testScannerCapabilitiesNoColor *ScannerCapabilities
 var testScannerCapabilitiesNoHalftone *ScannerCapabilities
+var testScannerCapabilitiesNoBlankPage *ScannerCapabilities
+var testScannerCapabilitiesCamera *ScannerCapabilities
+var testScannerCapabilitiesCameraOnly *ScannerCapabilities
 
 func init() {
 	testScannerCapabilitiesNoPlaten = testScannerCapabilities.Clone()
@@ -201,6 +209,14 @@ func init() {
 		}
 	}
 
+	testScannerCapabilitiesNoBlankPage = testScannerCapabilities.Clone()
+	testScannerCapabilitiesNoBlankPage.BlankPageDetection = false
+
+	testScannerCapabilitiesCamera = testScannerCapabilities.Clone()
+	testScannerCapabilitiesCamera.Camera = testPlatenInputCapabilities
+
+	testScannerCapabilitiesCameraOnly = testScannerCapabilitiesNoInput.Clone()
+	testScannerCapabilitiesCameraOnly.Camera = testPlatenInputCapabilities
 }
 
 // TestScannerRequestValidate tests ScannerRequest.Validate function.
@@ -249,6 +265,32 @@ func TestScannerRequestValidate(t *testing.T) {
 			},
 		},
 
+		// InputCamera tests
+		{
+			comment:  "InputCamera",
+			scancaps: testScannerCapabilitiesCamera,
+			req: &ScannerRequest{
+				Input: InputCamera,
+			},
+		},
+
+		{
+			comment:  "InputCamera, unsupported",
+			scancaps: testScannerCapabilities,
+			req: &ScannerRequest{
+				Input: InputCamera,
+			},
+			err: ErrParam{
+				ErrUnsupportedParam, "Input", InputCamera,
+			},
+		},
+
+		{
+			comment:  "all-default request, camera only",
+			scancaps: testScannerCapabilitiesCameraOnly,
+			req:      &ScannerRequest{},
+		},
+
 		// InputADF/ADFModeUnset tests
 		{
 			comment:  "InputADF/ADFModeUnset",
@@ -895,6 +937,38 @@ func TestScannerRequestValidate(t *testing.T) {
 				ErrUnsupportedParam, "Compression", 200,
 			},
 		},
+
+		// Blank page detection and removal
+		{
+			comment:  "BlankPageDetection",
+			scancaps: testScannerCapabilities,
+			req: &ScannerRequest{
+				BlankPageDetection: true,
+			},
+		},
+
+		{
+			comment:  "BlankPageRemoval, unsupported",
+			scancaps: testScannerCapabilities,
+			req: &ScannerRequest{
+				BlankPageDetection: true,
+				BlankPageRemoval:   true,
+			},
+			err: ErrParam{
+				ErrUnsupportedParam, "BlankPageRemoval", true,
+			},
+		},
+
+		{
+			comment:  "BlankPageDetection, unsupported",
+			scancaps: testScannerCapabilitiesNoBlankPage,
+			req: &ScannerRequest{
+				BlankPageDetection: true,
+			},
+			err: ErrParam{
+				ErrUnsupportedParam, "BlankPageDetection", true,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,89 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Document, spooled into temporary files
+
+package abstract
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+)
+
+// spoolDocument is the [Document], created by the [NewSpoolDocument].
+type spoolDocument struct {
+	Document          // Underlying document
+	dir      string   // Directory for temporary files
+	file     *os.File // Current spooled file, nil if none
+}
+
+// NewSpoolDocument wraps the [Document] and spools each of its
+// files into the temporary file in the specified directory
+// (or in the [os.TempDir], if dir is ""), before it is returned
+// by the Next.
+//
+// Files, returned by the spooled Document, are backed by the
+// [os.File] and implement the [DocumentOSFile] interface, so
+// consumers may use zero-copy I/O fast paths. SHA-256 digest
+// of each file is computed while spooling and available via
+// the [DocumentSHA256] interface.
+//
+// Temporary files are removed when the next file is requested
+// or the Document is closed.
+func NewSpoolDocument(doc Document, dir string) Document {
+	return &spoolDocument{Document: doc, dir: dir}
+}
+
+// Next returns the next [DocumentFile].
+func (doc *spoolDocument) Next() (DocumentFile, error) {
+	doc.release()
+
+	file, err := doc.Document.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	fp, err := os.CreateTemp(doc.dir, "mfp-spool-*")
+	if err != nil {
+		return nil, err
+	}
+
+	doc.file = fp
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(fp, hash), file)
+	if err == nil {
+		_, err = fp.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		doc.release()
+		return nil, err
+	}
+
+	return &osDocumentFile{
+		format: file.Format(),
+		file:   fp,
+		sum:    hash.Sum(nil),
+	}, nil
+}
+
+// Close closes the Document and removes the current
+// temporary file, if any.
+func (doc *spoolDocument) Close() error {
+	doc.release()
+	return doc.Document.Close()
+}
+
+// release closes and removes the current temporary file.
+func (doc *spoolDocument) release() {
+	if doc.file != nil {
+		doc.file.Close()
+		os.Remove(doc.file.Name())
+		doc.file = nil
+	}
+}
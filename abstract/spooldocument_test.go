@@ -0,0 +1,79 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Document, spooled into temporary files test
+
+package abstract
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"testing"
+)
+
+// TestSpoolDocument tests NewSpoolDocument
+func TestSpoolDocument(t *testing.T) {
+	dir := t.TempDir()
+	images := [][]byte{[]byte("page 1"), []byte("page 2, longer")}
+
+	res := Resolution{XResolution: 300, YResolution: 300}
+	doc := NewSpoolDocument(NewVirtualDocument(res, images...), dir)
+
+	if doc.Resolution() != res {
+		t.Errorf("Resolution: %v", doc.Resolution())
+	}
+
+	for i, image := range images {
+		file, err := doc.Next()
+		if err != nil {
+			t.Fatalf("page %d: %s", i+1, err)
+		}
+
+		osfile, ok := file.(DocumentOSFile)
+		if !ok {
+			t.Fatalf("page %d: DocumentOSFile not implemented", i+1)
+		}
+
+		fp, size := osfile.OSFile()
+		if fp == nil || size != int64(len(image)) {
+			t.Errorf("page %d: OSFile: %p, %d", i+1, fp, size)
+		}
+
+		sum := sha256.Sum256(image)
+		digest, ok := file.(DocumentSHA256)
+		if !ok || !bytes.Equal(digest.SHA256(), sum[:]) {
+			t.Errorf("page %d: SHA256 mismatch", i+1)
+		}
+
+		data, _ := io.ReadAll(file)
+		if !bytes.Equal(data, image) {
+			t.Errorf("page %d: data mismatch", i+1)
+		}
+
+		// Digest is unknown, once file is read
+		if digest.SHA256() != nil {
+			t.Errorf("page %d: SHA256 after read", i+1)
+		}
+
+		// Only the current page is kept on disk
+		ents, _ := os.ReadDir(dir)
+		if len(ents) != 1 {
+			t.Errorf("page %d: %d temporary files", i+1, len(ents))
+		}
+	}
+
+	if _, err := doc.Next(); err != io.EOF {
+		t.Errorf("Next: expected io.EOF, present %v", err)
+	}
+
+	doc.Close()
+
+	if ents, _ := os.ReadDir(dir); len(ents) != 0 {
+		t.Errorf("Close: %d temporary files left", len(ents))
+	}
+}
@@ -0,0 +1,61 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The virtual printer
+
+package abstract
+
+import (
+	"context"
+	"io"
+)
+
+// VirtualPrinter implements the [Printer] interface for the virtual
+// (simulated) printer.
+//
+// Printed documents are passed to the Output callback or discarded,
+// if Output is nil.
+type VirtualPrinter struct {
+	PrintCaps *PrinterCapabilities // Printer capabilities
+
+	// Output, if not nil, is called for each printed document.
+	// It must consume the document file data. If it returns an
+	// error, the error is returned by the Print.
+	Output func(req PrinterRequest, file DocumentFile) error
+}
+
+// Capabilities returns the [PrinterCapabilities].
+// Caller should not modify the returned structure.
+func (vprn *VirtualPrinter) Capabilities() *PrinterCapabilities {
+	return vprn.PrintCaps
+}
+
+// Print prints the document.
+func (vprn *VirtualPrinter) Print(ctx context.Context, req PrinterRequest,
+	file DocumentFile) error {
+
+	err := req.Validate(vprn.PrintCaps)
+	if err != nil {
+		return err
+	}
+
+	if vprn.Output != nil {
+		err = vprn.Output(req, file)
+	} else {
+		_, err = io.Copy(io.Discard, file)
+	}
+
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	return err
+}
+
+// Close closes the VirtualPrinter.
+func (vprn *VirtualPrinter) Close() error {
+	return nil
+}
@@ -12,11 +12,29 @@ import "context"
 
 // VirtualScanner implements the [Scanner] interface for the virtual
 // (simulated) scanner.
+//
+// If ADFImages is empty, the ADF is considered empty, and ADF scan
+// requests fail with the [ErrADFEmpty] error.
 type VirtualScanner struct {
 	ScanCaps    *ScannerCapabilities // Scanner capabilities
 	Resolution  Resolution           // Images resolution
-	PlatenImage []byte               // Image "loaded" into Platen
+	PlatenImage []byte               // Image on Platen or Camera
 	ADFImages   [][]byte             // Images "loaded" into ADF
+
+	// ADFFault, if not nil, is called before each ADF page is
+	// returned, with the zero-based page number. If it returns
+	// an error, the error is returned by the Document.Next
+	// instead of the page.
+	//
+	// It allows to simulate ADF failures, like jams
+	// ([ErrADFJam]) and multipick ([ErrADFMultipick]).
+	ADFFault func(page int) error
+
+	// Spool, if true, makes Scan to spool each page into the
+	// temporary file (see [NewSpoolDocument]), so returned files
+	// are backed by the [os.File] and consumers may use zero-copy
+	// I/O when sending them.
+	Spool bool
 }
 
 // Capabilities returns the [ScannerCapabilities].
@@ -25,6 +43,15 @@ func (vscan *VirtualScanner) Capabilities() *ScannerCapabilities {
 	return vscan.ScanCaps
 }
 
+// ADFState returns the current state of the ADF.
+// It implements the [ScannerADFState] interface.
+func (vscan *VirtualScanner) ADFState() ADFState {
+	if len(vscan.ADFImages) == 0 {
+		return ADFStateEmpty
+	}
+	return ADFStateLoaded
+}
+
 // Scan supplies the scan request.
 func (vscan *VirtualScanner) Scan(ctx context.Context, req ScannerRequest) (
 	Document, error) {
@@ -36,13 +63,37 @@ func (vscan *VirtualScanner) Scan(ctx context.Context, req ScannerRequest) (
 
 	images := [][]byte{vscan.PlatenImage}
 	if req.Input == InputADF {
+		if len(vscan.ADFImages) == 0 {
+			return nil, ErrADFEmpty
+		}
 		images = vscan.ADFImages
 	}
 
-	doc := NewVirtualDocument(vscan.Resolution, images...)
+	var doc Document = NewVirtualDocument(vscan.Resolution, images...)
+	if req.Input == InputADF && vscan.ADFFault != nil {
+		doc = &virtualADFDocument{
+			Document: doc,
+			fault:    vscan.ADFFault,
+			pages:    len(images),
+		}
+	}
 
+	// Virtual scanner has no fast low-resolution pass, so
+	// preview is made by downsampling the image.
 	filter := NewFilter(doc)
-	filter.SetResolution(req.Resolution)
+	if req.Preview {
+		filter.SetResolution(req.PreviewResolution(vscan.ScanCaps))
+	} else {
+		filter.SetResolution(req.Resolution)
+	}
+
+	if !req.Region.IsZero() {
+		filter.SetRegion(req.Region)
+	}
+
+	if vscan.Spool {
+		return NewSpoolDocument(filter, ""), nil
+	}
 
 	return filter, nil
 }
@@ -51,3 +102,29 @@ func (vscan *VirtualScanner) Scan(ctx context.Context, req ScannerRequest) (
 func (vscan *VirtualScanner) Close() error {
 	return nil
 }
+
+// virtualADFDocument wraps the Document, returned by the
+// VirtualScanner for ADF scans, and calls the fault
+// injection hook before each page.
+type virtualADFDocument struct {
+	Document                      // Underlying document
+	fault    func(page int) error // Fault injection hook
+	page     int                  // Next page number
+	pages    int                  // Total count of pages
+}
+
+// Next returns the next [DocumentFile].
+func (doc *virtualADFDocument) Next() (DocumentFile, error) {
+	if doc.page < doc.pages {
+		if err := doc.fault(doc.page); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := doc.Document.Next()
+	if err == nil {
+		doc.page++
+	}
+
+	return file, err
+}
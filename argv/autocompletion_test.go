@@ -690,3 +690,49 @@ func testDiffCompletion(expected, received []Completion) []string {
 
 	return out
 }
+
+// TestCompletionGuard tests InCompletion and CompletionGuard
+func TestCompletionGuard(t *testing.T) {
+	var inCompletion, guarded bool
+
+	cmd := Command{
+		Name: "test",
+		Parameters: []Parameter{
+			{
+				Name: "param",
+				Complete: func(string) []Completion {
+					inCompletion = InCompletion()
+					guarded = CompletionGuard(nil, func() {})
+					return nil
+				},
+			},
+		},
+	}
+
+	cmd.Complete([]string{""})
+	if !inCompletion {
+		t.Errorf("InCompletion: false within Command.Complete")
+	}
+	if guarded {
+		t.Errorf("CompletionGuard: fn called within Command.Complete")
+	}
+
+	if InCompletion() {
+		t.Errorf("InCompletion: true after Command.Complete")
+	}
+
+	inv, err := cmd.Parse([]string{"value"})
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+
+	if inv.IsCompletion() {
+		t.Errorf("Invocation.IsCompletion: true for Command.Parse")
+	}
+
+	called := false
+	CompletionGuard(inv, func() { called = true })
+	if !called {
+		t.Errorf("CompletionGuard: fn not called for Command.Parse")
+	}
+}
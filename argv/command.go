@@ -10,6 +10,7 @@ package argv
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -215,6 +216,12 @@ func (cmd *Command) ParseWithParent(parent *Invocation,
 }
 
 // Run parses the command, then calls its handler.
+//
+// If the first argument is "--bash-completion", Run prints completion
+// suggestions for the rest of arguments and exits. If it is the
+// "--argv-dump", Run prints the parsed [Invocation] as JSON (see
+// [Invocation.MarshalJSON]) without executing the command and exits.
+// These hidden modes are not shown in the help pages.
 func (cmd *Command) Run(ctx context.Context, argv []string) error {
 	if len(argv) > 0 && argv[0] == "--bash-completion" {
 		compl := cmd.Complete(argv[1:])
@@ -236,6 +243,21 @@ func (cmd *Command) Run(ctx context.Context, argv []string) error {
 		os.Exit(0)
 	}
 
+	if len(argv) > 0 && argv[0] == "--argv-dump" {
+		inv, err := cmd.Parse(argv[1:])
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(inv, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "%s\n", data)
+		os.Exit(0)
+	}
+
 	return cmd.RunWithParent(ctx, nil, argv)
 }
 
@@ -314,8 +336,14 @@ func (cmd *Command) handler(ctx context.Context, inv *Invocation) error {
 //
 //	prompt> hello    ->  ["hello", ""]
 //	  Cursor      ^
+//
+// While Complete is active, [InCompletion] returns true.
 func (cmd *Command) Complete(argv []string) []Completion {
+	completionActive.Add(1)
+	defer completionActive.Add(-1)
+
 	prs := newParser(cmd, argv)
+	prs.inv.completion = true
 	return prs.complete()
 }
 
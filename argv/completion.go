@@ -8,9 +8,42 @@
 
 package argv
 
+import "sync/atomic"
+
 // Completion is the output of Command.Complete. It contains suggested
 // completion string and [CompletionFlags]
 type Completion struct {
 	String  string // Suggested completion string
 	NoSpace bool   // Don't append space after completion
 }
+
+// completionActive counts currently active [Command.Complete] calls.
+var completionActive atomic.Int32
+
+// InCompletion reports whether auto-completion is currently in
+// progress, i.e., some [Command.Complete] call is active.
+//
+// [Completer] callbacks don't receive the [Invocation], so this
+// function is the way for them (and for the code they share with
+// command handlers) to learn that the parser is invoked purely for
+// auto-completion rather than for the command execution.
+func InCompletion() bool {
+	return completionActive.Load() != 0
+}
+
+// CompletionGuard calls fn, unless the auto-completion is in progress.
+//
+// It is intended to protect expensive or state-changing actions
+// (network probes, cache writes and so on), which are not appropriate
+// when the command line is parsed only for auto-completion.
+//
+// If inv is not nil, [Invocation.IsCompletion] is checked as well.
+// It returns true if fn was called.
+func CompletionGuard(inv *Invocation, fn func()) bool {
+	if InCompletion() || (inv != nil && inv.IsCompletion()) {
+		return false
+	}
+
+	fn()
+	return true
+}
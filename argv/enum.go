@@ -0,0 +1,87 @@
+// MFP  - Miulti-Function Printers and scanners toolkit
+// argv - Argv parsing mini-library
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Options for enumeration types
+
+package argv
+
+import "strings"
+
+// Enum is the constraint for enumeration types, that can be used
+// with [EnumOption], [ValidateEnum] and [CompleteEnum].
+//
+// Enum values are expected to be consecutive integers, starting
+// from 1. Zero value is reserved for the "unknown" value, returned
+// by the decode function for unknown strings.
+//
+// This is the pattern used by the protocol enums, i.e.:
+//
+//	type ColorMode int
+//	func (cm ColorMode) String() string
+//	func DecodeColorMode(s string) ColorMode
+type Enum interface {
+	~int
+	String() string
+}
+
+// EnumStrings returns string representation of all values of the
+// enumeration type, defined by its decode function and String method.
+//
+// Values are enumerated starting from 1, until the value that
+// doesn't survive the String/decode round trip.
+func EnumStrings[T Enum](decode func(string) T) []string {
+	var s []string
+	for v := T(1); decode(v.String()) == v; v++ {
+		s = append(s, v.String())
+	}
+	return s
+}
+
+// ValidateEnum creates the Option.Validate and Parameter.Validate
+// callback.
+//
+// It returns validator that accepts any known value of the
+// enumeration type. See [EnumStrings] for details.
+func ValidateEnum[T Enum](decode func(string) T) func(string) error {
+	return ValidateStrings(EnumStrings(decode))
+}
+
+// CompleteEnum returns a [Completer], that performs auto-completion,
+// choosing from the known values of the enumeration type.
+// See [EnumStrings] for details.
+func CompleteEnum[T Enum](decode func(string) T) Completer {
+	return CompleteStrings(EnumStrings(decode))
+}
+
+// EnumOption returns a copy of the Option with the Validate
+// and Complete callbacks, generated from the enumeration type.
+//
+// The list of possible values is appended to the opt.Help, as
+// a separate line.
+//
+// So options, based on the protocol enums, automatically stay
+// in sync with the protocol implementation:
+//
+//	argv.EnumOption(argv.Option{
+//		Name: "--color-mode",
+//		Help: "Color mode",
+//	}, escl.DecodeColorMode)
+func EnumOption[T Enum](opt Option, decode func(string) T) Option {
+	values := EnumStrings(decode)
+
+	opt.Validate = ValidateStrings(values)
+	opt.Complete = CompleteStrings(values)
+
+	if opt.HelpArg == "" {
+		opt.HelpArg = "value"
+	}
+
+	if len(values) != 0 {
+		opt.Help += "\nOne of: " + strings.Join(values, ", ")
+	}
+
+	return opt
+}
@@ -0,0 +1,113 @@
+// MFP  - Miulti-Function Printers and scanners toolkit
+// argv - Argv parsing mini-library
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Options for enumeration types test
+
+package argv
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testEnum is the enumeration type for testing
+type testEnum int
+
+// testEnum values
+const (
+	testEnumUnknown testEnum = iota
+	testEnumRed
+	testEnumGreen
+	testEnumBlue
+)
+
+// String returns string representation of testEnum
+func (v testEnum) String() string {
+	switch v {
+	case testEnumRed:
+		return "Red"
+	case testEnumGreen:
+		return "Green"
+	case testEnumBlue:
+		return "Blue"
+	}
+	return "Unknown"
+}
+
+// decodeTestEnum decodes testEnum from string
+func decodeTestEnum(s string) testEnum {
+	switch s {
+	case "Red":
+		return testEnumRed
+	case "Green":
+		return testEnumGreen
+	case "Blue":
+		return testEnumBlue
+	}
+	return testEnumUnknown
+}
+
+// TestEnumOption tests EnumOption and related functions
+func TestEnumOption(t *testing.T) {
+	values := EnumStrings(decodeTestEnum)
+	expected := []string{"Red", "Green", "Blue"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("EnumStrings:\nexpected: %q\npresent:  %q",
+			expected, values)
+	}
+
+	opt := EnumOption(Option{
+		Name: "--color",
+		Help: "Color",
+	}, decodeTestEnum)
+
+	if opt.HelpArg != "value" {
+		t.Errorf("EnumOption: HelpArg: %q", opt.HelpArg)
+	}
+
+	help := "Color\nOne of: Red, Green, Blue"
+	if opt.Help != help {
+		t.Errorf("EnumOption: Help:\nexpected: %q\npresent:  %q",
+			help, opt.Help)
+	}
+
+	for _, s := range expected {
+		if err := opt.Validate(s); err != nil {
+			t.Errorf("Validate(%q): %s", s, err)
+		}
+	}
+
+	for _, s := range []string{"", "Unknown", "red"} {
+		if err := opt.Validate(s); err == nil {
+			t.Errorf("Validate(%q): error expected", s)
+		}
+	}
+
+	compl := opt.Complete("G")
+	complExpected := []Completion{{"Green", false}}
+	if !reflect.DeepEqual(compl, complExpected) {
+		t.Errorf("Complete(%q):\nexpected: %v\npresent:  %v",
+			"G", complExpected, compl)
+	}
+
+	// Test within the Command
+	cmd := Command{
+		Name:    "test",
+		Options: []Option{opt},
+	}
+
+	inv, err := cmd.Parse([]string{"--color", "Blue"})
+	if err != nil {
+		t.Errorf("Parse: %s", err)
+	} else if v, _ := inv.Get("--color"); v != "Blue" {
+		t.Errorf("Parse: --color: %q", v)
+	}
+
+	_, err = cmd.Parse([]string{"--color", "Black"})
+	if err == nil {
+		t.Errorf("Parse: error expected")
+	}
+}
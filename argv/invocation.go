@@ -8,7 +8,10 @@
 
 package argv
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
 // Invocation represents a particular [Command] invocation.
 //
@@ -55,6 +58,14 @@ type Invocation struct {
 
 	// immediate is the first Option's Immediate callback, if any
 	immediate func(context.Context, *Invocation) error
+
+	// inherited contains Persistent options, inherited from
+	// the parent Commands.
+	inherited []*Option
+
+	// completion is true if Invocation is created for the
+	// auto-completion rather than for the command execution.
+	completion bool
 }
 
 // Parent returns Invocation's parent, which is the upper-level
@@ -79,6 +90,15 @@ func (inv *Invocation) IsImmediate() bool {
 	return inv.immediate != nil
 }
 
+// IsCompletion returns true, if Invocation is created for the
+// auto-completion (see [Command.Complete]) rather than for the
+// command execution.
+//
+// See also [InCompletion] and [CompletionGuard].
+func (inv *Invocation) IsCompletion() bool {
+	return inv.completion
+}
+
 // Cmd returns a reference to [Command], invoked by this Invocation
 func (inv *Invocation) Cmd() *Command {
 	return inv.cmd
@@ -105,8 +125,11 @@ func (inv *Invocation) Argv() []string {
 //
 // The value of flag options (options that don't expect explicit
 // value) considered to be an empty string.
+//
+// Values of the Persistent options, used with the parent commands,
+// are visible here as well (see [Option] for details).
 func (inv *Invocation) Get(name string) (val string, found bool) {
-	vals, found := inv.byName[name]
+	vals, found := inv.lookup(name)
 	if found && len(vals) > 0 {
 		val = vals[0]
 	}
@@ -130,8 +153,64 @@ func (inv *Invocation) Get(name string) (val string, found bool) {
 //
 // For repeated flag options, the returned slice will contain one
 // empty string per each occurrence.
+//
+// Values of the Persistent options, used with the parent commands,
+// are visible here as well (see [Option] for details).
 func (inv *Invocation) Values(name string) []string {
-	return inv.byName[name]
+	vals, _ := inv.lookup(name)
+	return vals
+}
+
+// lookup returns values of option or parameter by its name.
+//
+// If name is not found and it belongs to the inherited option,
+// the lookup continues with the parent Invocation.
+func (inv *Invocation) lookup(name string) ([]string, bool) {
+	vals, found := inv.byName[name]
+	if !found && inv.parent != nil && inv.inheritedOption(name) != nil {
+		return inv.parent.lookup(name)
+	}
+
+	return vals, found
+}
+
+// inheritedOption returns inherited option by name, or nil if
+// there is no such option or it is shadowed by the Command's
+// own option with the same name.
+func (inv *Invocation) inheritedOption(name string) *Option {
+	for i := range inv.cmd.Options {
+		for _, n := range inv.cmd.Options[i].names() {
+			if n == name {
+				return nil
+			}
+		}
+	}
+
+	for _, opt := range inv.inherited {
+		for _, n := range opt.names() {
+			if n == name {
+				return opt
+			}
+		}
+	}
+
+	return nil
+}
+
+// persistent returns Persistent options to be inherited by the
+// sub-commands: own Persistent options of the Command, followed
+// by options inherited by the Command itself.
+func (inv *Invocation) persistent() []*Option {
+	var persistent []*Option
+
+	for i := range inv.cmd.Options {
+		opt := &inv.cmd.Options[i]
+		if opt.Persistent {
+			persistent = append(persistent, opt)
+		}
+	}
+
+	return append(persistent, inv.inherited...)
 }
 
 // ParamCount returns count of positional parameters.
@@ -161,3 +240,105 @@ func (inv *Invocation) ParamGet(n int) (param string) {
 func (inv *Invocation) SubCommand() (*Command, []string) {
 	return inv.subcmd, inv.subargv
 }
+
+// MarshalJSON exports the Invocation as JSON.
+//
+// The exported JSON contains the fully resolved command path (from
+// the root Invocation down to the deepest sub-command), and, for each
+// command in the path, its options (by the Option.Name, regardless of
+// actually used alias) and parameters (by name):
+//
+//	{
+//	  "path": ["mfp", "cups", "get-default"],
+//	  "command": "mfp",
+//	  "options": {"-d": [""]},
+//	  "parameters": {},
+//	  "subcommand": {
+//	    "command": "cups",
+//	    ...
+//	  }
+//	}
+//
+// Sub-commands are parsed as needed. If parsing fails, MarshalJSON
+// returns an error.
+//
+// It is intended for external wrappers and tests to verify how
+// the command line is interpreted.
+func (inv *Invocation) MarshalJSON() ([]byte, error) {
+	exp, err := inv.export()
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the command path
+	for p := inv.parent; p != nil; p = p.parent {
+		exp.Path = append(exp.Path, p.cmd.Name)
+	}
+
+	for i, j := 0, len(exp.Path)-1; i < j; i, j = i+1, j-1 {
+		exp.Path[i], exp.Path[j] = exp.Path[j], exp.Path[i]
+	}
+
+	for sub := exp; sub != nil; sub = sub.SubCommand {
+		exp.Path = append(exp.Path, sub.Command)
+	}
+
+	return json.Marshal(exp)
+}
+
+// invocationJSON is the JSON representation of the Invocation,
+// used by the Invocation.MarshalJSON.
+type invocationJSON struct {
+	Path       []string            `json:"path,omitempty"`
+	Command    string              `json:"command"`
+	Options    map[string][]string `json:"options"`
+	Parameters map[string][]string `json:"parameters"`
+	SubCommand *invocationJSON     `json:"subcommand,omitempty"`
+}
+
+// export exports Invocation into the invocationJSON
+func (inv *Invocation) export() (*invocationJSON, error) {
+	exp := &invocationJSON{
+		Command:    inv.cmd.Name,
+		Options:    make(map[string][]string),
+		Parameters: make(map[string][]string),
+	}
+
+	for i := range inv.cmd.Options {
+		opt := &inv.cmd.Options[i]
+		if vals, found := inv.byName[opt.Name]; found {
+			exp.Options[opt.Name] = vals
+		}
+	}
+
+	for _, opt := range inv.inherited {
+		if _, found := exp.Options[opt.Name]; found {
+			continue
+		}
+		if vals, found := inv.byName[opt.Name]; found {
+			exp.Options[opt.Name] = vals
+		}
+	}
+
+	for i := range inv.cmd.Parameters {
+		param := &inv.cmd.Parameters[i]
+		name := param.name()
+		if vals, found := inv.byName[name]; found {
+			exp.Parameters[name] = vals
+		}
+	}
+
+	if inv.subcmd != nil {
+		subinv, err := inv.subcmd.ParseWithParent(inv, inv.subargv)
+		if err != nil {
+			return nil, err
+		}
+
+		exp.SubCommand, err = subinv.export()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return exp, nil
+}
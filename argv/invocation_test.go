@@ -0,0 +1,77 @@
+// MFP  - Miulti-Function Printers and scanners toolkit
+// argv - Argv parsing mini-library
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// (*Invocation) MarshalJSON() test
+
+package argv
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestInvocationMarshalJSON is a test for (*Invocation) MarshalJSON()
+func TestInvocationMarshalJSON(t *testing.T) {
+	type testData struct {
+		argv []string // Command's arguments
+		cmd  *Command // The Command
+		json string   // Expected JSON
+		err  string   // Expected error
+	}
+
+	tests := []testData{
+		{
+			argv: []string{"-c", "in1", "in2", "out"},
+			cmd:  &testCommandWithParameters,
+			json: `{"path":["copy-files"],` +
+				`"command":"copy-files",` +
+				`"options":{"-c":[""]},` +
+				`"parameters":{"input-file":["in1","in2"],` +
+				`"output-file":["out"]}}`,
+		},
+
+		{
+			argv: []string{"--verbose", "conn", "--timeout=5"},
+			cmd:  &testCommandWithSubCommands,
+			json: `{"path":["test","connect"],` +
+				`"command":"test",` +
+				`"options":{"-v":[""]},` +
+				`"parameters":{},` +
+				`"subcommand":{"command":"connect",` +
+				`"options":{"--timeout":["5"]},` +
+				`"parameters":{}}}`,
+		},
+
+		{
+			argv: []string{"connect", "--unknown"},
+			cmd:  &testCommandWithSubCommands,
+			err:  `json: error calling MarshalJSON for type *argv.Invocation: unknown option: "--unknown"`,
+		},
+	}
+
+	for _, test := range tests {
+		inv, err := test.cmd.Parse(test.argv)
+		if err != nil {
+			t.Errorf("%q: %s", test.argv, err)
+			continue
+		}
+
+		data, err := json.Marshal(inv)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%q: error mismatch:\n"+
+					"expected: %s\npresent:  %s",
+					test.argv, test.err, err)
+			}
+			continue
+		}
+
+		if string(data) != test.json {
+			t.Errorf("%q: JSON mismatch:\nexpected: %s\npresent:  %s",
+				test.argv, test.json, data)
+		}
+	}
+}
@@ -86,6 +86,22 @@ type Option struct {
 	// more that once.
 	Singleton bool
 
+	// Persistent flag, if set, makes option inherited by all
+	// sub-commands of the Command that defines it, recursively.
+	//
+	// Inherited option is accepted and auto-completed by the
+	// sub-command the same way as its own options. Sub-command's
+	// own options with the same name take precedence.
+	//
+	// The value can be obtained via [Invocation.Get] and
+	// [Invocation.Values] of the Invocation of the defining
+	// Command or any of its sub-commands. If option is used
+	// at the multiple levels, the deepest one wins.
+	//
+	// Note, Required, Conflicts and Requires are checked only
+	// at the level where option is actually used.
+	Persistent bool
+
 	// Validate callback called to validate parameter.
 	//
 	// Use nil to indicate that this option has no value.
@@ -70,6 +70,11 @@ func newParser(cmd *Command, argv []string) *parser {
 
 // parse parses the argv and returns parsed Invocation
 func (prs *parser) parse(parent *Invocation) (*Invocation, error) {
+	// Inherit Persistent options
+	if parent != nil {
+		prs.inv.inherited = parent.persistent()
+	}
+
 	// Parse arguments, one by one.
 	var doneOptions bool
 	var paramValues []string
@@ -452,7 +457,10 @@ func (prs *parser) complete() (compl []Completion) {
 			// complete self
 			if subcmd != nil && !prs.done() {
 				argv := prs.inv.argv[prs.nextarg:]
-				return subcmd.Complete(argv)
+				subprs := newParser(subcmd, argv)
+				subprs.inv.inherited = prs.inv.persistent()
+				subprs.inv.completion = true
+				return subprs.complete()
 			}
 
 			// If we are at the end of argv, complete
@@ -484,7 +492,7 @@ func (prs *parser) complete() (compl []Completion) {
 	case prs.inv.cmd.hasSubCommands():
 		compl = prs.completeSubCommandName("")
 
-	case prs.inv.cmd.hasOptions():
+	case prs.inv.cmd.hasOptions() || len(prs.inv.inherited) != 0:
 		compl = prs.completeOptionName("")
 	}
 
@@ -494,10 +502,13 @@ func (prs *parser) complete() (compl []Completion) {
 // completeOptionName returns slice of completion candidates for
 // Option name
 func (prs *parser) completeOptionName(arg string) (compl []Completion) {
-	for i := range prs.inv.cmd.Options {
-		opt := &prs.inv.cmd.Options[i]
-
+	for _, opt := range prs.allOptions() {
 		for _, name := range opt.names() {
+			if prs.findOption(name) != opt {
+				// Inherited option, shadowed by own
+				continue
+			}
+
 			if strings.HasPrefix(name, arg) {
 				c := Completion{name, false}
 				if opt.withValue() && prs.isLongOption(name) {
@@ -654,9 +665,11 @@ func (prs *parser) splitOptVal(arg string) (name, val string, novalue bool) {
 }
 
 // findOption finds Command's Option by name.
+//
+// Command's own Options are searched first, then
+// options, inherited from the parent Commands.
 func (prs *parser) findOption(name string) *Option {
-	for i := range prs.inv.cmd.Options {
-		opt := &prs.inv.cmd.Options[i]
+	for _, opt := range prs.allOptions() {
 		for _, n := range opt.names() {
 			if name == n {
 				return opt
@@ -667,6 +680,19 @@ func (prs *parser) findOption(name string) *Option {
 	return nil
 }
 
+// allOptions returns all Options, acceptable by the Command:
+// its own Options, followed by the inherited ones.
+func (prs *parser) allOptions() []*Option {
+	opts := make([]*Option, 0,
+		len(prs.inv.cmd.Options)+len(prs.inv.inherited))
+
+	for i := range prs.inv.cmd.Options {
+		opts = append(opts, &prs.inv.cmd.Options[i])
+	}
+
+	return append(opts, prs.inv.inherited...)
+}
+
 // paramsInfo returns information on a command parameters:
 //
 //	paramsMin - minimal count of parameters
@@ -592,6 +592,158 @@ func TestParser(t *testing.T) {
 	}
 }
 
+// TestPersistentOptions tests Persistent options inheritance
+func TestPersistentOptions(t *testing.T) {
+	cmdList := Command{
+		Name: "list",
+		Options: []Option{
+			{Name: "-l", Aliases: []string{"--long"}},
+		},
+	}
+
+	cmdPrinter := Command{
+		Name:        "printer",
+		SubCommands: []Command{cmdList},
+	}
+
+	cmd := Command{
+		Name: "test",
+		Options: []Option{
+			{
+				Name:       "-s",
+				Aliases:    []string{"--server"},
+				Validate:   ValidateAny,
+				Complete:   CompleteStrings([]string{"localhost"}),
+				Persistent: true,
+			},
+			{Name: "-d"},
+		},
+		SubCommands: []Command{cmdPrinter},
+	}
+
+	type testData struct {
+		argv   []string // Command's arguments
+		server string   // Expected --server value at the leaf
+		found  bool     // Expected --server presence
+		err    string   // Expected error
+	}
+
+	tests := []testData{
+		{
+			argv: []string{"printer", "list"},
+		},
+
+		{
+			argv:   []string{"--server=a", "printer", "list"},
+			server: "a",
+			found:  true,
+		},
+
+		{
+			argv:   []string{"printer", "-s", "b", "list"},
+			server: "b",
+			found:  true,
+		},
+
+		{
+			argv:   []string{"printer", "list", "-l", "--server", "c"},
+			server: "c",
+			found:  true,
+		},
+
+		{
+			argv:   []string{"-s", "a", "printer", "-sb", "list"},
+			server: "b",
+			found:  true,
+		},
+
+		{
+			argv: []string{"printer", "list", "-d"},
+			err:  `unknown option: "-d"`,
+		},
+	}
+
+	for _, test := range tests {
+		inv, err := cmd.Parse(test.argv)
+		for err == nil && inv.subcmd != nil {
+			inv, err = inv.subcmd.ParseWithParent(inv, inv.subargv)
+		}
+
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%q: error mismatch:\n"+
+					"expected: %s\npresent:  %s",
+					test.argv, test.err, err)
+			}
+			continue
+		}
+
+		if test.err != "" {
+			t.Errorf("%q: error not detected", test.argv)
+			continue
+		}
+
+		server, found := inv.Get("--server")
+		if server != test.server || found != test.found {
+			t.Errorf("%q: --server mismatch:\n"+
+				"expected: %q %v\npresent:  %q %v",
+				test.argv, test.server, test.found, server, found)
+		}
+
+		if alias, _ := inv.Get("-s"); alias != server {
+			t.Errorf("%q: -s/--server mismatch: %q vs %q",
+				test.argv, alias, server)
+		}
+	}
+
+	// Test auto-completion of inherited options
+	compl := cmd.Complete([]string{"printer", "list", "--se"})
+	expected := []Completion{{"--server=", true}}
+	if !reflect.DeepEqual(compl, expected) {
+		t.Errorf("completion mismatch:\nexpected: %v\npresent:  %v",
+			expected, compl)
+	}
+
+	compl = cmd.Complete([]string{"printer", "list", "--server=l"})
+	expected = []Completion{{"--server=localhost", false}}
+	if !reflect.DeepEqual(compl, expected) {
+		t.Errorf("completion mismatch:\nexpected: %v\npresent:  %v",
+			expected, compl)
+	}
+
+	// Sub-command's own option shadows the inherited one,
+	// including its value, used with the parent command
+	cmdPrint := Command{
+		Name: "print",
+		Options: []Option{
+			{Name: "--format", Validate: ValidateAny},
+		},
+	}
+
+	cmd = Command{
+		Name: "mfp",
+		Options: []Option{
+			{
+				Name:       "--format",
+				Validate:   ValidateAny,
+				Persistent: true,
+			},
+		},
+		SubCommands: []Command{cmdPrint},
+	}
+
+	inv, err := cmd.Parse([]string{"--format", "json", "print"})
+	if err == nil {
+		inv, err = inv.subcmd.ParseWithParent(inv, inv.subargv)
+	}
+
+	if err != nil {
+		t.Errorf("shadowing: %s", err)
+	} else if format, found := inv.Get("--format"); found {
+		t.Errorf("shadowing: inherited value visible: %q", format)
+	}
+}
+
 // testDiffValues compares two maps of named values and returns formatted
 // diff as slice of strings
 func testDiffValues(m1, m2 map[string][]string) []string {
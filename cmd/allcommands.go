@@ -10,22 +10,52 @@ package cmd
 
 import (
 	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-benchmark/benchmark"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-completion/completion"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-convert/convert"
 	"github.com/OpenPrinting/go-mfp/cmd/mfp-cups/cups"
 	"github.com/OpenPrinting/go-mfp/cmd/mfp-discover/discover"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-doctor/doctor"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-emulate/emulate"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-escl/escl"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-fax/fax"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-info/info"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-ipp/ipp"
 	"github.com/OpenPrinting/go-mfp/cmd/mfp-proxy/proxy"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-scan/scan"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-snmp/snmp"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-status/status"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-trace/trace"
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-wsd/wsd"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
 )
 
 // AllCommands is the argv.Command, that includes all other commands
-// as sub-commands.
-var AllCommands = &argv.Command{
+// as sub-commands. Common options (see [cmdopt.Options]) are defined
+// here and inherited by all sub-commands.
+var AllCommands = cmdopt.Root(argv.Command{
 	Name: "mfp",
 	Options: []argv.Option{
 		argv.HelpOption,
 	},
 	SubCommands: []argv.Command{
+		benchmark.Command,
+		completion.Command,
+		convert.Command,
 		cups.Command,
+		ipp.Command,
 		proxy.Command,
 		discover.Command,
+		doctor.Command,
+		emulate.Command,
+		escl.Command,
+		fax.Command,
+		info.Command,
+		scan.Command,
+		snmp.Command,
+		status.Command,
+		trace.Command,
+		wsd.Command,
 		argv.HelpCommand,
 	},
-}
+})
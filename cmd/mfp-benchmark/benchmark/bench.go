@@ -0,0 +1,74 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "benchmark" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Common benchmark loop
+
+package benchmark
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// benchRetryDelay is the delay before retrying a request, rejected
+// by the device as busy (i.e., eSCL 503 Service Unavailable).
+const benchRetryDelay = 500 * time.Millisecond
+
+// benchMaxRetries is the maximum number of such retries.
+const benchMaxRetries = 60
+
+// benchPollInterval is the interval of the print job status polling.
+const benchPollInterval = 500 * time.Millisecond
+
+// benchRun runs count jobs, using the job callback, and collects
+// statistics.
+//
+// Failed jobs are counted but don't stop the benchmark. If all
+// jobs failed, the last error is returned.
+func benchRun(ctx context.Context, count int,
+	job func(st *stats) error) (*stats, error) {
+
+	st := newStats()
+
+	var lastErr error
+	start := time.Now()
+
+	for i := 0; i < count; i++ {
+		log.Debug(ctx, "job %d of %d", i+1, count)
+
+		err := job(st)
+		if err != nil {
+			log.Debug(ctx, "job %d: %s", i+1, err)
+			st.Failures++
+			lastErr = err
+		} else {
+			st.Jobs++
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	st.Elapsed = time.Since(start)
+
+	if st.Jobs == 0 {
+		return nil, lastErr
+	}
+
+	return st, nil
+}
+
+// benchSleep sleeps for the specified duration or until context
+// is canceled.
+func benchSleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,309 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "benchmark" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/internal/output"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// Default values of the benchmark parameters
+const (
+	DefaultCount      = 5
+	DefaultResolution = 300
+	DefaultFormat     = "image/jpeg"
+)
+
+// description is printed as a command description text
+const description = "" +
+	"This command runs a series of scan or print jobs against\n" +
+	"a device and reports pages per minute, transfer throughput\n" +
+	"and per-request latency percentiles.\n" +
+	"\n" +
+	"Scanning uses eSCL (default) or WS-Scan (--wsd). With\n" +
+	"--print, the file is printed using IPP (default) or WS-Print\n" +
+	"(--wsd). Print jobs are waited for completion, so pages per\n" +
+	"minute reflects the actual printing speed.\n" +
+	"\n" +
+	"For WS-Scan and WS-Print, URL is the service endpoint URL,\n" +
+	"as reported by the \"mfp wsd resolve\" command.\n" +
+	"\n" +
+	"It is intended to compare protocols and network paths, so\n" +
+	"use the same parameters for all compared runs.\n"
+
+// Command is the 'benchmark' command description
+var Command = argv.Command{
+	Name:        "benchmark",
+	Help:        "Measure scan and print throughput",
+	Description: description,
+	Options: []argv.Option{
+		argv.Option{
+			Name:      "--escl",
+			Help:      "Benchmark eSCL scanning (default)",
+			Conflicts: []string{"--wsd", "--ipp", "--print"},
+		},
+		argv.Option{
+			Name:      "--ipp",
+			Help:      "Benchmark IPP printing (default with --print)",
+			Conflicts: []string{"--escl", "--wsd"},
+			Requires:  []string{"--print"},
+		},
+		argv.Option{
+			Name:      "--wsd",
+			Help:      "Benchmark WS-Scan or WS-Print (with --print)",
+			Conflicts: []string{"--escl", "--ipp"},
+		},
+		argv.Option{
+			Name:     "-p",
+			Aliases:  []string{"--print"},
+			HelpArg:  "file",
+			Help:     "Benchmark printing of the file",
+			Validate: argv.ValidateAny,
+			Complete: argv.CompleteOSPath,
+		},
+		argv.Option{
+			Name:    "-n",
+			Aliases: []string{"--count"},
+			HelpArg: "N",
+			Help: fmt.Sprintf("Number of jobs. Default: %d",
+				DefaultCount),
+			Validate: argv.ValidateUintRange(10, 1, 10000),
+		},
+		argv.Option{
+			Name:    "-r",
+			Aliases: []string{"--resolution"},
+			HelpArg: "DPI",
+			Help: fmt.Sprintf("Scan resolution. Default: %d",
+				DefaultResolution),
+			Validate: argv.ValidateUintRange(10, 1, 9600),
+		},
+		argv.Option{
+			Name:     "-s",
+			Aliases:  []string{"--source"},
+			HelpArg:  "source",
+			Help:     "Input source: platen, adf or duplex",
+			Validate: argv.ValidateStrings(sources),
+			Complete: argv.CompleteStrings(sources),
+		},
+		argv.Option{
+			Name:     "-m",
+			Aliases:  []string{"--mode"},
+			HelpArg:  "mode",
+			Help:     "Color mode: color, gray or bw",
+			Validate: argv.ValidateStrings(modes),
+			Complete: argv.CompleteStrings(modes),
+		},
+		argv.Option{
+			Name:    "--image-format",
+			HelpArg: "MIME",
+			Help: fmt.Sprintf("Image format. Default: %s",
+				DefaultFormat),
+			Validate: argv.ValidateAny,
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "URL",
+			Help:     "device URL (i.e., http://host/eSCL)",
+			Validate: transport.ValidateURL,
+		},
+	},
+	Handler: cmdBenchmarkHandler,
+}
+
+// sources and modes are the valid values of the
+// --source and --mode options
+var (
+	sources = []string{"platen", "adf", "duplex"}
+	modes   = []string{"color", "gray", "bw"}
+)
+
+// cmdBenchmarkHandler is the handler for the 'benchmark' command.
+func cmdBenchmarkHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	// Parse options
+	param, _ := inv.Get("URL")
+	u := transport.MustParseURL(param)
+
+	count := DefaultCount
+	if s, ok := inv.Get("-n"); ok {
+		count, _ = strconv.Atoi(s)
+	}
+
+	_, wsd := inv.Get("--wsd")
+	file, printing := inv.Get("--print")
+
+	// Run the benchmark
+	var proto string
+	var st *stats
+	var err error
+
+	switch {
+	case printing:
+		var doc printDocument
+		doc, err = printDocumentLoad(file)
+		if err != nil {
+			return err
+		}
+
+		if wsd {
+			proto = "WS-Print"
+			st, err = benchWSPrint(ctx, u, doc, count)
+		} else {
+			proto = "IPP"
+			st, err = benchIPP(ctx, u, doc, count)
+		}
+
+	case wsd:
+		proto = "WS-Scan"
+		st, err = benchWSScan(ctx, u, scanParamsGet(inv), count)
+
+	default:
+		proto = "eSCL"
+		st, err = benchESCL(ctx, u, scanSettings(scanParamsGet(inv)),
+			count)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Format output
+	pager := env.NewPager()
+	err = output.Render(pager, output.OptionGet(inv),
+		benchRecordMake(param, proto, st),
+		func(io.Writer) { statsFormat(pager, param, proto, st) })
+
+	if err != nil {
+		return err
+	}
+
+	return pager.Display()
+}
+
+// scanParams are the scan parameters, common for all
+// scan protocols.
+type scanParams struct {
+	Resolution int    // Resolution, DPI
+	Format     string // Image format (MIME type)
+	Source     string // Input source: "platen", "adf" or "duplex"
+	Mode       string // Color mode: "color", "gray" or "bw"
+}
+
+// scanParamsGet returns scanParams, requested by options.
+func scanParamsGet(inv *argv.Invocation) scanParams {
+	params := scanParams{
+		Resolution: DefaultResolution,
+		Format:     DefaultFormat,
+		Source:     "platen",
+		Mode:       "color",
+	}
+
+	if s, ok := inv.Get("-r"); ok {
+		params.Resolution, _ = strconv.Atoi(s)
+	}
+
+	if s, ok := inv.Get("--image-format"); ok {
+		params.Format = s
+	}
+
+	if s, ok := inv.Get("-s"); ok {
+		params.Source = s
+	}
+
+	if s, ok := inv.Get("-m"); ok {
+		params.Mode = s
+	}
+
+	return params
+}
+
+// scanSettings returns escl.ScanSettings for the benchmark.
+func scanSettings(params scanParams) escl.ScanSettings {
+	ss := escl.ScanSettings{
+		Version:        escl.DefaultVersion,
+		XResolution:    optional.New(params.Resolution),
+		YResolution:    optional.New(params.Resolution),
+		DocumentFormat: optional.New(params.Format),
+		InputSource:    optional.New(escl.InputPlaten),
+		ColorMode:      optional.New(escl.RGB24),
+	}
+
+	switch params.Source {
+	case "adf":
+		ss.InputSource = optional.New(escl.InputFeeder)
+	case "duplex":
+		ss.InputSource = optional.New(escl.InputFeeder)
+		ss.Duplex = optional.New(true)
+	}
+
+	switch params.Mode {
+	case "gray":
+		ss.ColorMode = optional.New(escl.Grayscale8)
+	case "bw":
+		ss.ColorMode = optional.New(escl.BlackAndWhite1)
+	}
+
+	return ss
+}
+
+// latencyRound is the rounding precision of the printed latencies
+const latencyRound = 100 * time.Microsecond
+
+// statsFormat pretty-prints the benchmark results
+func statsFormat(pager *env.Pager, url, proto string, st *stats) {
+	pager.Printf("Device:       %s", url)
+	pager.Printf("Protocol:     %s", proto)
+	pager.Printf("Jobs:         %d (%d failed)", st.Jobs, st.Failures)
+	pager.Printf("Pages:        %d", st.Pages)
+	pager.Printf("Transferred:  %s", bytesFormat(float64(st.Bytes)))
+	pager.Printf("Elapsed:      %s", st.Elapsed.Round(time.Millisecond))
+	pager.Printf("Pages/minute: %.1f", st.PagesPerMinute())
+	pager.Printf("Throughput:   %s/s", bytesFormat(st.Throughput()))
+
+	pager.Printf("")
+	pager.Printf("Latency:")
+	pager.Printf("  %-14s %6s %10s %10s %10s %10s %10s",
+		"request", "count", "min", "p50", "p90", "p99", "max")
+
+	for _, l := range st.LatencySummary() {
+		pager.Printf("  %-14s %6d %10s %10s %10s %10s %10s",
+			l.Request, l.Count,
+			l.Min.Round(latencyRound), l.P50.Round(latencyRound),
+			l.P90.Round(latencyRound), l.P99.Round(latencyRound),
+			l.Max.Round(latencyRound))
+	}
+}
+
+// bytesFormat formats amount of bytes in a human-readable form.
+func bytesFormat(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
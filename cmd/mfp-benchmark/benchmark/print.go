@@ -0,0 +1,227 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "benchmark" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// IPP and WS-Print print benchmark
+
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/proto/wsprint"
+)
+
+// benchIPPStatusAttrs are the Job attributes, polled while
+// waiting for the IPP job completion.
+var benchIPPStatusAttrs = []string{
+	"job-state",
+	"job-state-reasons",
+	"job-impressions-completed",
+}
+
+// printDocument is the document, printed by the benchmark.
+//
+// Document is loaded into memory in advance, so the local disk
+// I/O doesn't affect the results.
+type printDocument struct {
+	Name   string // Document name
+	Format string // Document format (MIME type)
+	Data   []byte // Document data
+}
+
+// printDocumentLoad loads the printDocument from the file.
+func printDocumentLoad(file string) (printDocument, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return printDocument{}, err
+	}
+
+	format := mime.TypeByExtension(filepath.Ext(file))
+	if format == "" {
+		format = abstract.MIMETypeOctetStream
+	}
+
+	doc := printDocument{
+		Name:   filepath.Base(file),
+		Format: format,
+		Data:   data,
+	}
+
+	return doc, nil
+}
+
+// benchIPP runs count print jobs against the IPP printer and
+// collects statistics.
+//
+// Each job is waited for completion, so pages per minute reflects
+// the actual printing speed.
+func benchIPP(ctx context.Context, u *url.URL, doc printDocument,
+	count int) (*stats, error) {
+
+	clnt := ipp.NewClient(u, nil)
+	return benchRun(ctx, count, func(st *stats) error {
+		return benchIPPJob(ctx, clnt, doc, st)
+	})
+}
+
+// benchIPPJob runs a single print job.
+func benchIPPJob(ctx context.Context, clnt *ipp.Client,
+	doc printDocument, st *stats) error {
+
+	// Submit the job
+	t := time.Now()
+	job, err := clnt.PrintJob(ctx, "benchmark", nil, ipp.Document{
+		Name:   doc.Name,
+		Format: doc.Format,
+		Body:   bytes.NewReader(doc.Data),
+	})
+	st.AddLatency("Print-Job", time.Since(t))
+
+	if err != nil {
+		return err
+	}
+
+	st.Transfer += time.Since(t)
+	st.Bytes += int64(len(doc.Data))
+
+	// Wait for completion
+	jobID := job.JobID
+	for {
+		t = time.Now()
+		job, err = clnt.GetJobAttributes(ctx, jobID,
+			benchIPPStatusAttrs)
+		st.AddLatency("Get-Job-Attributes", time.Since(t))
+
+		switch {
+		case err != nil:
+			return err
+
+		case !job.IsTerminated():
+			benchSleep(ctx, benchPollInterval)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+
+		case job.JobState != ipp.JobStateCompleted:
+			return fmt.Errorf("job %d: not completed: %v",
+				jobID, job.JobStateReasons)
+		}
+
+		st.Pages += job.JobImpressionsCompleted
+		return nil
+	}
+}
+
+// benchWSPrint runs count print jobs against the WS-Print printer
+// and collects statistics.
+//
+// Each job is waited for completion, if printer supports the
+// job status requests. Otherwise, only submission is measured.
+func benchWSPrint(ctx context.Context, u *url.URL, doc printDocument,
+	count int) (*stats, error) {
+
+	clnt := wsprint.NewClient(u, nil)
+	ticket := wsprint.PrintTicket{JobName: "benchmark"}
+	if usr, err := user.Current(); err == nil {
+		ticket.JobOriginatingUserName = usr.Username
+	}
+
+	return benchRun(ctx, count, func(st *stats) error {
+		return benchWSPrintJob(ctx, clnt, ticket, doc, st)
+	})
+}
+
+// benchWSPrintJob runs a single print job.
+func benchWSPrintJob(ctx context.Context, clnt *wsprint.Client,
+	ticket wsprint.PrintTicket, doc printDocument, st *stats) error {
+
+	// Create the job
+	var job *wsprint.CreatePrintJobResponse
+	var err error
+
+	for retry := 0; ; retry++ {
+		t := time.Now()
+		job, err = clnt.CreatePrintJob(ctx, ticket)
+		st.AddLatency("CreatePrintJob", time.Since(t))
+
+		if !benchWSPrintBusy(err) || retry == benchMaxRetries {
+			break
+		}
+
+		benchSleep(ctx, benchRetryDelay)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Send the document
+	t := time.Now()
+	err = clnt.SendDocument(ctx, job.JobID, 1, wsprint.Document{
+		Name:   doc.Name,
+		Format: doc.Format,
+		Body:   bytes.NewReader(doc.Data),
+	}, true)
+	st.AddLatency("SendDocument", time.Since(t))
+
+	if err != nil {
+		return err
+	}
+
+	st.Transfer += time.Since(t)
+	st.Bytes += int64(len(doc.Data))
+
+	// Wait for completion
+	for {
+		t = time.Now()
+		status, err := clnt.GetJobStatus(ctx, job.JobID)
+		st.AddLatency("GetJobElements", time.Since(t))
+
+		switch {
+		case err != nil:
+			// Not all printers implement GetJobElements,
+			// and the job may be already gone.
+			log.Debug(ctx, "job %d: GetJobElements: %s",
+				job.JobID, err)
+			return nil
+
+		case status.JobState == wsprint.JobCompleted:
+			st.Pages += status.MediaSheetsCompleted
+			return nil
+
+		case status.JobState == wsprint.JobAborted ||
+			status.JobState == wsprint.JobCanceled:
+			return fmt.Errorf("job %d: %s: %v", job.JobID,
+				status.JobState, status.JobStateReasons)
+		}
+
+		benchSleep(ctx, benchPollInterval)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// benchWSPrintBusy reports if printer responded as busy.
+func benchWSPrintBusy(err error) bool {
+	var fault wsd.Fault
+	return errors.As(err, &fault) &&
+		fault.Subcode == wsprint.FaultServerErrorNotAcceptingJobs
+}
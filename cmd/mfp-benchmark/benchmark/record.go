@@ -0,0 +1,71 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "benchmark" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Benchmark result record for the --format output
+
+package benchmark
+
+import "time"
+
+// benchRecord is the benchmark result record for the --format output.
+type benchRecord struct {
+	URL            string          `json:"url"`
+	Protocol       string          `json:"protocol"`
+	Jobs           int             `json:"jobs"`
+	Failures       int             `json:"failures"`
+	Pages          int             `json:"pages"`
+	Bytes          int64           `json:"bytes"`
+	Elapsed        float64         `json:"elapsed-sec"`
+	PagesPerMinute float64         `json:"pages-per-minute"`
+	Throughput     float64         `json:"throughput-bytes-per-sec"`
+	Latencies      []latencyRecord `json:"latencies"`
+}
+
+// latencyRecord is the per-request latency record.
+type latencyRecord struct {
+	Request string  `json:"request"`
+	Count   int     `json:"count"`
+	Min     float64 `json:"min-ms"`
+	P50     float64 `json:"p50-ms"`
+	P90     float64 `json:"p90-ms"`
+	P99     float64 `json:"p99-ms"`
+	Max     float64 `json:"max-ms"`
+}
+
+// benchRecordMake makes benchRecord from the collected stats
+func benchRecordMake(url, proto string, st *stats) benchRecord {
+	rec := benchRecord{
+		URL:            url,
+		Protocol:       proto,
+		Jobs:           st.Jobs,
+		Failures:       st.Failures,
+		Pages:          st.Pages,
+		Bytes:          st.Bytes,
+		Elapsed:        st.Elapsed.Seconds(),
+		PagesPerMinute: st.PagesPerMinute(),
+		Throughput:     st.Throughput(),
+		Latencies:      []latencyRecord{},
+	}
+
+	for _, l := range st.LatencySummary() {
+		rec.Latencies = append(rec.Latencies, latencyRecord{
+			Request: l.Request,
+			Count:   l.Count,
+			Min:     durationMs(l.Min),
+			P50:     durationMs(l.P50),
+			P90:     durationMs(l.P90),
+			P99:     durationMs(l.P99),
+			Max:     durationMs(l.Max),
+		})
+	}
+
+	return rec
+}
+
+// durationMs returns duration in milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,95 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "benchmark" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// eSCL scan benchmark
+
+package benchmark
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+)
+
+// benchESCL runs count scan jobs against the eSCL scanner and
+// collects statistics.
+func benchESCL(ctx context.Context, u *url.URL, ss escl.ScanSettings,
+	count int) (*stats, error) {
+
+	clnt := escl.NewClient(u, nil)
+	return benchRun(ctx, count, func(st *stats) error {
+		return benchESCLJob(ctx, clnt, ss, st)
+	})
+}
+
+// benchESCLJob runs a single scan job.
+func benchESCLJob(ctx context.Context, clnt *escl.Client,
+	ss escl.ScanSettings, st *stats) error {
+
+	// Start the job
+	var joburl string
+	var details *escl.HTTPDetails
+	var err error
+
+	for retry := 0; ; retry++ {
+		t := time.Now()
+		joburl, details, err = clnt.Scan(ctx, ss)
+		st.AddLatency("ScanJobs", time.Since(t))
+
+		if !benchBusy(details) || retry == benchMaxRetries {
+			break
+		}
+
+		benchSleep(ctx, benchRetryDelay)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Receive all pages
+	for retry := 0; ; {
+		t := time.Now()
+		doc, details, err := clnt.NextDocument(ctx, joburl)
+		st.AddLatency("NextDocument", time.Since(t))
+
+		switch {
+		case err == io.EOF:
+			return nil
+
+		case benchBusy(details) && retry < benchMaxRetries:
+			retry++
+			benchSleep(ctx, benchRetryDelay)
+			continue
+
+		case err != nil:
+			return err
+		}
+
+		n, err := io.Copy(io.Discard, doc)
+		doc.Close()
+
+		st.Transfer += time.Since(t)
+		st.Bytes += n
+
+		if err != nil {
+			return err
+		}
+
+		st.Pages++
+		retry = 0
+	}
+}
+
+// benchBusy reports if scanner responded as busy.
+func benchBusy(details *escl.HTTPDetails) bool {
+	return details != nil &&
+		details.StatusCode == http.StatusServiceUnavailable
+}
@@ -0,0 +1,103 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "benchmark" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Benchmark statistics
+
+package benchmark
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// stats collects the benchmark statistics.
+type stats struct {
+	Elapsed   time.Duration              // Total elapsed time
+	Transfer  time.Duration              // Total time spent on transfer
+	Jobs      int                        // Count of completed jobs
+	Failures  int                        // Count of failed jobs
+	Pages     int                        // Count of received pages
+	Bytes     int64                      // Count of received bytes
+	Latencies map[string][]time.Duration // Per-request latencies
+}
+
+// latency summarizes latencies of the particular request.
+type latency struct {
+	Request string        // Request name
+	Count   int           // Count of requests
+	Min     time.Duration // Minimal latency
+	P50     time.Duration // 50th percentile (median)
+	P90     time.Duration // 90th percentile
+	P99     time.Duration // 99th percentile
+	Max     time.Duration // Maximal latency
+}
+
+// newStats creates a new stats.
+func newStats() *stats {
+	return &stats{Latencies: make(map[string][]time.Duration)}
+}
+
+// AddLatency records latency of the request.
+func (st *stats) AddLatency(request string, d time.Duration) {
+	st.Latencies[request] = append(st.Latencies[request], d)
+}
+
+// PagesPerMinute returns count of pages per minute.
+func (st *stats) PagesPerMinute() float64 {
+	if st.Elapsed <= 0 {
+		return 0
+	}
+	return float64(st.Pages) / st.Elapsed.Minutes()
+}
+
+// Throughput returns transfer throughput, in bytes per second.
+func (st *stats) Throughput() float64 {
+	if st.Transfer <= 0 {
+		return 0
+	}
+	return float64(st.Bytes) / st.Transfer.Seconds()
+}
+
+// LatencySummary returns per-request latencies summary,
+// ordered by request name.
+func (st *stats) LatencySummary() []latency {
+	var summary []latency
+
+	for request, durations := range st.Latencies {
+		sorted := make([]time.Duration, len(durations))
+		copy(sorted, durations)
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i] < sorted[j]
+		})
+
+		summary = append(summary, latency{
+			Request: request,
+			Count:   len(sorted),
+			Min:     sorted[0],
+			P50:     percentile(sorted, 50),
+			P90:     percentile(sorted, 90),
+			P99:     percentile(sorted, 99),
+			Max:     sorted[len(sorted)-1],
+		})
+	}
+
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Request < summary[j].Request
+	})
+
+	return summary
+}
+
+// percentile returns the p-th percentile of the sorted non-empty
+// slice of durations, using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,184 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "benchmark" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Scan scan benchmark
+
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/proto/wsscan"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// benchWSScan runs count scan jobs against the WS-Scan scanner and
+// collects statistics.
+func benchWSScan(ctx context.Context, u *url.URL, params scanParams,
+	count int) (*stats, error) {
+
+	clnt := wsscan.NewClient(u, nil)
+
+	// Scanner configuration is required to choose the
+	// document format
+	elements, err := clnt.GetScannerElements(ctx,
+		wsscan.ElementScannerConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := scanTicket(params, elements.Configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return benchRun(ctx, count, func(st *stats) error {
+		return benchWSScanJob(ctx, clnt, ticket, st)
+	})
+}
+
+// benchWSScanJob runs a single scan job.
+func benchWSScanJob(ctx context.Context, clnt *wsscan.Client,
+	ticket wsscan.ScanTicket, st *stats) error {
+
+	// Start the job
+	var job *wsscan.CreateScanJobResponse
+	var err error
+
+	for retry := 0; ; retry++ {
+		t := time.Now()
+		job, err = clnt.CreateScanJob(ctx, ticket)
+		st.AddLatency("CreateScanJob", time.Since(t))
+
+		if !benchWSScanBusy(err) || retry == benchMaxRetries {
+			break
+		}
+
+		benchSleep(ctx, benchRetryDelay)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Receive all images
+	images := ticket.DocumentParameters.ImagesToTransfer
+	for received := 0; images == nil || *images == 0 ||
+		received < *images; received++ {
+
+		t := time.Now()
+		img, err := clnt.RetrieveImage(ctx, job.JobID, job.JobToken)
+		st.AddLatency("RetrieveImage", time.Since(t))
+
+		// Some scanners forget the job as soon as the last
+		// image is retrieved
+		var fault wsd.Fault
+		if received > 0 && errors.As(err, &fault) &&
+			fault.Subcode == wsscan.FaultClientErrorJobIDNotFound {
+			err = io.EOF
+		}
+
+		switch {
+		case err == io.EOF:
+			return nil
+
+		case err != nil:
+			return err
+		}
+
+		n, err := io.Copy(io.Discard, img)
+		img.Close()
+
+		st.Transfer += time.Since(t)
+		st.Bytes += n
+
+		if err != nil {
+			return err
+		}
+
+		st.Pages++
+	}
+
+	return nil
+}
+
+// benchWSScanBusy reports if scanner responded as busy.
+func benchWSScanBusy(err error) bool {
+	var fault wsd.Fault
+	if !errors.As(err, &fault) {
+		return false
+	}
+
+	switch fault.Subcode {
+	case wsscan.FaultServerErrorNotAcceptingJobs,
+		wsscan.FaultServerErrorTemporaryError:
+		return true
+	}
+
+	return false
+}
+
+// scanTicket returns wsscan.ScanTicket for the benchmark.
+func scanTicket(params scanParams,
+	conf *wsscan.ScannerConfiguration) (wsscan.ScanTicket, error) {
+
+	var supported []string
+	if conf != nil {
+		supported = conf.DeviceSettings.FormatsSupported
+	}
+
+	format := wsscan.FormatFromMIME(params.Format, supported)
+	if format == "" {
+		err := fmt.Errorf("%s: format not supported by scanner",
+			params.Format)
+		return wsscan.ScanTicket{}, err
+	}
+
+	side := wsscan.MediaSide{
+		ColorProcessing: optional.New(wsscan.RGB24),
+		Resolution: optional.New(wsscan.Dimensions{
+			Width:  params.Resolution,
+			Height: params.Resolution,
+		}),
+	}
+
+	switch params.Mode {
+	case "gray":
+		side.ColorProcessing = optional.New(wsscan.Grayscale8)
+	case "bw":
+		side.ColorProcessing = optional.New(wsscan.BlackAndWhite1)
+	}
+
+	docParams := wsscan.DocumentParameters{
+		Format:           optional.New(format),
+		ImagesToTransfer: optional.New(1),
+		InputSource:      optional.New(wsscan.InputPlaten),
+		MediaFront:       optional.New(side),
+	}
+
+	switch params.Source {
+	case "adf":
+		docParams.ImagesToTransfer = optional.New(0)
+		docParams.InputSource = optional.New(wsscan.InputADF)
+	case "duplex":
+		docParams.ImagesToTransfer = optional.New(0)
+		docParams.InputSource = optional.New(wsscan.InputADFDuplex)
+		docParams.MediaBack = optional.New(side)
+	}
+
+	ticket := wsscan.ScanTicket{
+		JobName:            "benchmark",
+		DocumentParameters: docParams,
+	}
+
+	return ticket, nil
+}
@@ -0,0 +1,149 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "completion" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package completion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+)
+
+// description is printed as a command description text
+const description = "" +
+	"This command generates and installs shell completion scripts.\n" +
+	"Supported shells are bash, zsh and fish.\n" +
+	"\n" +
+	"Usually, the following is enough to make completion working:\n" +
+	"\n" +
+	"  mfp completion install\n"
+
+// Command is the 'completion' command description
+var Command = argv.Command{
+	Name:        "completion",
+	Help:        "Shell completion scripts",
+	Description: description,
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	SubCommands: []argv.Command{
+		cmdInstall,
+		cmdScript,
+		argv.HelpCommand,
+	},
+}
+
+// paramShell is the shell parameter, common for sub-commands
+var paramShell = argv.Parameter{
+	Name:     "[shell]",
+	Help:     "bash, zsh or fish. Default: from $SHELL",
+	Validate: argv.ValidateStrings(shells),
+	Complete: argv.CompleteStrings(shells),
+}
+
+// cmdInstall defines the "install" sub-command.
+var cmdInstall = argv.Command{
+	Name: "install",
+	Help: "Install completion script for the current user",
+	Options: []argv.Option{
+		argv.Option{
+			Name:     "--dir",
+			HelpArg:  "dir",
+			Help:     "Install into the specified directory",
+			Validate: argv.ValidateAny,
+			Complete: argv.CompleteOSPath,
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{paramShell},
+	Handler:    cmdInstallHandler,
+}
+
+// cmdScript defines the "script" sub-command.
+var cmdScript = argv.Command{
+	Name: "script",
+	Help: "Print completion script to the standard output",
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{paramShell},
+	Handler:    cmdScriptHandler,
+}
+
+// cmdInstallHandler is the "install" command handler
+func cmdInstallHandler(ctx context.Context, inv *argv.Invocation) error {
+	shell, err := optShell(inv)
+	if err != nil {
+		return err
+	}
+
+	dir := scriptDir(shell)
+	if s, ok := inv.Get("--dir"); ok {
+		dir = s
+	}
+
+	files, err := scriptFiles(shell, scriptPrograms(inv.Root().Cmd()))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		err = os.WriteFile(filepath.Join(dir, file.name),
+			file.data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%s completion installed into %s\n\n", shell, dir)
+	fmt.Print(scriptInstructions(shell, dir))
+
+	return nil
+}
+
+// cmdScriptHandler is the "script" command handler
+func cmdScriptHandler(ctx context.Context, inv *argv.Invocation) error {
+	shell, err := optShell(inv)
+	if err != nil {
+		return err
+	}
+
+	script, err := scriptGenerate(shell, scriptPrograms(inv.Root().Cmd()))
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(script)
+	return err
+}
+
+// optShell returns the shell name, either specified explicitly,
+// or detected from the $SHELL environment variable.
+func optShell(inv *argv.Invocation) (string, error) {
+	if shell, ok := inv.Get("shell"); ok {
+		return shell, nil
+	}
+
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if !slices.Contains(shells, shell) {
+		return "", errors.New("can't detect shell; " +
+			"please specify bash, zsh or fish")
+	}
+
+	return shell, nil
+}
@@ -0,0 +1,16 @@
+# {{.Root}} fish completion script.
+#
+# Generated by "{{.Root}} completion script fish".
+
+function __mfp_complete
+	set -l tokens (commandline -opc) (commandline -ct)
+
+	# Command prints suggestions, one per line, escaped for
+	# the bash. Unescape them and strip trailing spaces, as
+	# fish handles it by itself.
+	$tokens[1] --bash-completion $tokens[2..-1] 2>/dev/null |
+		string replace -r ' $' '' |
+		string replace -ra '\\\\(.)' '$1'
+end
+{{range .Programs}}
+complete -c {{.}} -f -a '(__mfp_complete)'{{end}}
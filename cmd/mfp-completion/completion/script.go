@@ -0,0 +1,192 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "completion" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Completion scripts generation
+
+package completion
+
+import (
+	"bytes"
+	_ "embed" // For go:embed to work
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+)
+
+// Completion script templates. All scripts use the hidden
+// --bash-completion mode of the argv.Command.Run.
+var (
+	//go:embed completion.bash
+	scriptBash string
+
+	//go:embed completion.zsh
+	scriptZsh string
+
+	//go:embed completion.fish
+	scriptFish string
+)
+
+// shells contains names of supported shells
+var shells = []string{"bash", "zsh", "fish"}
+
+// scriptParams contains parameters for the script templates
+type scriptParams struct {
+	Root     string   // Root command name (i.e., "mfp")
+	Programs []string // All programs to complete
+}
+
+// scriptPrograms returns scriptParams for the command.
+//
+// Programs include the root command itself, and for the "mfp" command,
+// also standalone mfp-xxx programs, installed for each sub-command.
+func scriptPrograms(root *argv.Command) scriptParams {
+	params := scriptParams{
+		Root:     root.Name,
+		Programs: []string{root.Name},
+	}
+
+	if root.Name == "mfp" {
+		for _, sub := range root.SubCommands {
+			if sub.Name != argv.HelpCommand.Name {
+				params.Programs = append(params.Programs,
+					"mfp-"+sub.Name)
+			}
+		}
+	}
+
+	return params
+}
+
+// scriptGenerate generates the completion script for the shell.
+func scriptGenerate(shell string, params scriptParams) ([]byte, error) {
+	var text string
+	switch shell {
+	case "bash":
+		text = scriptBash
+	case "zsh":
+		text = scriptZsh
+	case "fish":
+		text = scriptFish
+	default:
+		return nil, fmt.Errorf("%s: unsupported shell", shell)
+	}
+
+	tmpl := template.Must(template.New(shell).Parse(text))
+
+	buf := &bytes.Buffer{}
+	err := tmpl.Execute(buf, params)
+	return buf.Bytes(), err
+}
+
+// scriptFile is the single file to be installed.
+type scriptFile struct {
+	name string // File name
+	data []byte // File content
+}
+
+// scriptFiles returns files to be installed for the shell.
+//
+// Shells load completions lazily, by the command name, so
+// each program needs its own file. The complete script is
+// installed for the root command, and for other programs
+// the small stub is installed, that sources the main script.
+func scriptFiles(shell string, params scriptParams) ([]scriptFile, error) {
+	script, err := scriptGenerate(shell, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []scriptFile
+
+	switch shell {
+	case "bash":
+		files = append(files, scriptFile{params.Root, script})
+		stub := fmt.Sprintf(". \"${BASH_SOURCE[0]%%/*}/%s\"\n",
+			params.Root)
+		for _, prog := range params.Programs[1:] {
+			files = append(files, scriptFile{prog, []byte(stub)})
+		}
+
+	case "zsh":
+		// The single file covers all programs, listed
+		// in the #compdef line.
+		files = append(files, scriptFile{"_" + params.Root, script})
+
+	case "fish":
+		files = append(files, scriptFile{params.Root + ".fish", script})
+		stub := fmt.Sprintf("source (status dirname)/%s.fish\n",
+			params.Root)
+		for _, prog := range params.Programs[1:] {
+			files = append(files,
+				scriptFile{prog + ".fish", []byte(stub)})
+		}
+	}
+
+	return files, nil
+}
+
+// scriptDir returns the per-user completion directory for the shell.
+func scriptDir(shell string) string {
+	home := env.PathHomeDir()
+
+	switch shell {
+	case "bash":
+		if dir := os.Getenv("BASH_COMPLETION_USER_DIR"); dir != "" {
+			return filepath.Join(dir, "completions")
+		}
+
+		data := os.Getenv("XDG_DATA_HOME")
+		if data == "" {
+			data = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(data, "bash-completion", "completions")
+
+	case "zsh":
+		zdot := os.Getenv("ZDOTDIR")
+		if zdot == "" {
+			zdot = home
+		}
+		return filepath.Join(zdot, ".zfunc")
+
+	case "fish":
+		conf := os.Getenv("XDG_CONFIG_HOME")
+		if conf == "" {
+			conf = filepath.Join(home, ".config")
+		}
+		return filepath.Join(conf, "fish", "completions")
+	}
+
+	return ""
+}
+
+// scriptInstructions returns post-installation instructions
+// for the shell.
+func scriptInstructions(shell, dir string) string {
+	switch shell {
+	case "bash":
+		return "" +
+			"Completion requires the bash-completion package and\n" +
+			"takes effect in the new shell sessions.\n"
+
+	case "zsh":
+		return "" +
+			"Add the following lines to your ~/.zshrc, if not yet:\n" +
+			"\n" +
+			"  fpath=(" + dir + " $fpath)\n" +
+			"  autoload -Uz compinit && compinit\n" +
+			"\n" +
+			"Completion takes effect in the new shell sessions.\n"
+
+	case "fish":
+		return "Completion takes effect in the new shell sessions.\n"
+	}
+
+	return ""
+}
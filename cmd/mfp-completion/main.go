@@ -0,0 +1,16 @@
+// MFP                - Miulti-Function Printers and scanners toolkit
+// cmd/mfp-completion - Shell completion installer
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The main() function.
+
+package main
+
+import "github.com/OpenPrinting/go-mfp/cmd/mfp-completion/completion"
+
+// main function for the mfp-completion command
+func main() {
+	completion.Command.Main(nil)
+}
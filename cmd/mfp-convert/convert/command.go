@@ -0,0 +1,141 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "convert" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package convert
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
+
+// DefaultResolution is the default image resolution, in DPI,
+// used when input doesn't specify it.
+const DefaultResolution = 300
+
+// description is printed as a command description text
+const description = "" +
+	"This command converts images between formats, used as scan\n" +
+	"and print payloads: JPEG, PNG, TIFF, PDF and PWG Raster.\n" +
+	"\n" +
+	"Input format is detected by the file content. Each input file\n" +
+	"contributes one page (PWG Raster files may contain many pages).\n" +
+	"PDF input is not supported.\n" +
+	"\n" +
+	"Output format is guessed by the output file extension or\n" +
+	"specified explicitly with --format. PDF and PWG Raster outputs\n" +
+	"receive all pages in a single file. For other formats, if there\n" +
+	"are multiple pages, each page is written into the separate file,\n" +
+	"named as NAME-1.EXT, NAME-2.EXT and so on.\n" +
+	"\n" +
+	"When JPEG images are converted into PDF, they are embedded\n" +
+	"as is, without re-compression.\n" +
+	"\n" +
+	"Examples:\n" +
+	"\n" +
+	"  mfp convert -o scan.pdf page1.jpg page2.jpg\n" +
+	"  mfp convert -o page.png job.pwg\n"
+
+// Command is the 'convert' command description
+var Command = argv.Command{
+	Name:        "convert",
+	Help:        "Convert images between scan and print formats",
+	Description: description,
+	Options: []argv.Option{
+		argv.Option{
+			Name:     "-o",
+			Aliases:  []string{"--output"},
+			HelpArg:  "file",
+			Help:     "Output file",
+			Required: true,
+			Validate: argv.ValidateAny,
+			Complete: argv.CompleteOSPath,
+		},
+		argv.Option{
+			Name:     "-f",
+			Aliases:  []string{"--format"},
+			HelpArg:  "format",
+			Help:     "Output format: " + strings.Join(formatNames, ", "),
+			Validate: argv.ValidateStrings(formatNames),
+			Complete: argv.CompleteStrings(formatNames),
+		},
+		argv.Option{
+			Name:    "-r",
+			Aliases: []string{"--resolution"},
+			HelpArg: "DPI",
+			Help: fmt.Sprintf("Resolution of input images. "+
+				"Default: from input or %d", DefaultResolution),
+			Validate: argv.ValidateUintRange(10, 1, 9600),
+		},
+		argv.Option{
+			Name:     "-q",
+			Aliases:  []string{"--quality"},
+			HelpArg:  "1...100",
+			Help:     "JPEG quality",
+			Validate: argv.ValidateUintRange(10, 1, 100),
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "file...",
+			Help:     "input files",
+			Complete: argv.CompleteOSPath,
+		},
+	},
+	Handler: cmdConvertHandler,
+}
+
+// cmdConvertHandler is the handler for the 'convert' command.
+func cmdConvertHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	// Parse options
+	file, _ := inv.Get("-o")
+
+	name, ok := inv.Get("-f")
+	if !ok {
+		ext := strings.ToLower(filepath.Ext(file))
+		name = formatByExt[ext]
+		if name == "" {
+			return fmt.Errorf("%s: can't guess output format, "+
+				"use --format", file)
+		}
+	}
+
+	params := convertParams{
+		format: formats[name],
+	}
+
+	if s, ok := inv.Get("-r"); ok {
+		params.resolution, _ = strconv.Atoi(s)
+	}
+
+	if s, ok := inv.Get("-q"); ok {
+		params.quality, _ = strconv.Atoi(s)
+	}
+
+	// Convert the files
+	out := newOutput(ctx, file, params)
+
+	for _, in := range inv.Values("file") {
+		err := readPages(ctx, in, params, out.AddPage)
+		if err != nil {
+			out.Abort()
+			return err
+		}
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,9 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "convert" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Package documentation
+
+package convert
@@ -0,0 +1,55 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "convert" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Output formats
+
+package convert
+
+// convertFormat describes the output format
+type convertFormat struct {
+	name      string // Format name, for --format
+	multiPage bool   // All pages are written into the single file
+}
+
+// Output formats
+var (
+	formatJPEG = &convertFormat{name: "jpeg"}
+	formatPDF  = &convertFormat{name: "pdf", multiPage: true}
+	formatPNG  = &convertFormat{name: "png"}
+	formatPWG  = &convertFormat{name: "pwg", multiPage: true}
+	formatTIFF = &convertFormat{name: "tiff"}
+)
+
+// formats contains all output formats, indexed by name
+var formats = map[string]*convertFormat{
+	formatJPEG.name: formatJPEG,
+	formatPDF.name:  formatPDF,
+	formatPNG.name:  formatPNG,
+	formatPWG.name:  formatPWG,
+	formatTIFF.name: formatTIFF,
+}
+
+// formatNames contains names of all output formats
+var formatNames = []string{"jpeg", "pdf", "png", "pwg", "tiff"}
+
+// formatByExt maps file extensions into format names
+var formatByExt = map[string]string{
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".pdf":  "pdf",
+	".png":  "png",
+	".pwg":  "pwg",
+	".ras":  "pwg",
+	".tif":  "tiff",
+	".tiff": "tiff",
+}
+
+// convertParams contains conversion parameters
+type convertParams struct {
+	format     *convertFormat // Output format
+	resolution int            // Forced resolution, 0 if not set
+	quality    int            // JPEG quality, 0 for default
+}
@@ -0,0 +1,114 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "convert" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Input files decoding
+
+package convert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/imgconv"
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// page represents a single input page
+type page struct {
+	source     string         // Source file name, for logging
+	reader     imgconv.Reader // Image reader
+	jpeg       []byte         // Original JPEG data, for pass-through
+	xres, yres int            // Resolution, DPI
+}
+
+// readPages decodes the input file and calls fn for each page.
+func readPages(ctx context.Context, file string, params convertParams,
+	fn func(*page) error) error {
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	format := abstract.DocumentFormatDetect(data)
+	log.Debug(ctx, "%s: format: %s", file, format)
+
+	// Choose the page resolution
+	resolution := func(xres, yres int) (int, int) {
+		switch {
+		case params.resolution != 0:
+			return params.resolution, params.resolution
+		case xres > 0 && yres > 0:
+			return xres, yres
+		}
+		return DefaultResolution, DefaultResolution
+	}
+
+	// Decode single-page formats
+	pg := &page{source: file}
+	pg.xres, pg.yres = resolution(0, 0)
+
+	switch format {
+	case abstract.DocumentFormatJPEG:
+		pg.reader, err = imgconv.NewJPEGReader(bytes.NewReader(data))
+		pg.jpeg = data
+
+	case abstract.DocumentFormatPNG:
+		pg.reader, err = imgconv.NewPNGReader(bytes.NewReader(data))
+
+	case abstract.DocumentFormatTIFF:
+		pg.reader, err = imgconv.NewTIFFReader(bytes.NewReader(data))
+
+	case abstract.DocumentFormatPWGRaster:
+		return readPagesPWG(file, data, resolution, fn)
+
+	case abstract.DocumentFormatPDF:
+		return fmt.Errorf("%s: PDF input is not supported", file)
+
+	default:
+		return fmt.Errorf("%s: unknown image format", file)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	defer pg.reader.Close()
+	return fn(pg)
+}
+
+// readPagesPWG decodes the PWG Raster file and calls fn for each page.
+func readPagesPWG(file string, data []byte,
+	resolution func(xres, yres int) (int, int),
+	fn func(*page) error) error {
+
+	dec, err := imgconv.NewPWGDecoder(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	for {
+		reader, err := dec.Next()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		pg := &page{source: file, reader: reader}
+		pg.xres, pg.yres = resolution(dec.Resolution())
+
+		err = fn(pg)
+		if err != nil {
+			return err
+		}
+	}
+}
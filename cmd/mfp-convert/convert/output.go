@@ -0,0 +1,262 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "convert" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Output files encoding
+
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/imgconv"
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// output writes pages into the output file(s)
+type output struct {
+	ctx    context.Context     // Logging context
+	file   string              // Output file name
+	params convertParams       // Conversion parameters
+	fp     *os.File            // Multi-page output file
+	pdf    *imgconv.PDFEncoder // PDF encoder
+	pwg    *imgconv.PWGEncoder // PWG Raster encoder
+	pages  int                 // Count of written pages
+	files  []string            // Created files
+}
+
+// newOutput creates a new output.
+func newOutput(ctx context.Context, file string,
+	params convertParams) *output {
+	return &output{
+		ctx:    ctx,
+		file:   file,
+		params: params,
+	}
+}
+
+// AddPage writes the next page.
+func (out *output) AddPage(pg *page) error {
+	out.pages++
+
+	wid, hei := pg.reader.Size()
+	log.Debug(out.ctx, "%s: page %d: %dx%d, %dx%d DPI",
+		pg.source, out.pages, wid, hei, pg.xres, pg.yres)
+
+	if out.params.format.multiPage {
+		return out.addMultiPage(pg)
+	}
+
+	return out.addSinglePage(pg)
+}
+
+// addMultiPage writes the next page into the multi-page
+// output file.
+func (out *output) addMultiPage(pg *page) error {
+	// Create output file on demand
+	if out.fp == nil {
+		fp, err := out.create(out.file)
+		if err != nil {
+			return err
+		}
+
+		out.fp = fp
+
+		switch out.params.format {
+		case formatPDF:
+			out.pdf = imgconv.NewPDFEncoder(fp)
+		case formatPWG:
+			out.pwg = imgconv.NewPWGEncoder(fp)
+		}
+	}
+
+	// Embed JPEG into PDF as is
+	if out.pdf != nil && pg.jpeg != nil {
+		err := out.pdf.AddJPEGPage(pg.jpeg, pg.xres, pg.yres)
+		if err == nil {
+			return nil
+		}
+
+		// Not all JPEG images can be embedded (i.e., CMYK);
+		// if so, fall back to decoding.
+		log.Debug(out.ctx, "%s: %s", pg.source, err)
+	}
+
+	// Write the page
+	wid, hei := pg.reader.Size()
+	model := pg.reader.ColorModel()
+
+	var writer imgconv.Writer
+	var err error
+
+	switch {
+	case out.pdf != nil:
+		writer, err = out.pdf.NewPage(wid, hei, model,
+			pg.xres, pg.yres)
+	case out.pwg != nil:
+		writer, err = out.pwg.NewPage(wid, hei, model,
+			pg.xres, pg.yres)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", out.file, err)
+	}
+
+	return out.copy(writer, pg, out.file)
+}
+
+// addSinglePage writes the next page into the separate file.
+func (out *output) addSinglePage(pg *page) error {
+	// If we have more that one page, all files are numbered.
+	file := out.file
+	if out.pages > 1 {
+		if out.pages == 2 {
+			first := out.numbered(1)
+			err := os.Rename(out.file, first)
+			if err != nil {
+				return err
+			}
+			out.files[0] = first
+		}
+
+		file = out.numbered(out.pages)
+	}
+
+	fp, err := out.create(file)
+	if err != nil {
+		return err
+	}
+
+	defer fp.Close()
+
+	// Write the page
+	wid, hei := pg.reader.Size()
+	model := pg.reader.ColorModel()
+
+	var writer imgconv.Writer
+
+	switch out.params.format {
+	case formatJPEG:
+		writer, err = imgconv.NewJPEGWriter(fp, wid, hei, model,
+			out.params.quality)
+	case formatPNG:
+		writer, err = imgconv.NewPNGWriter(fp, wid, hei, model)
+	case formatTIFF:
+		writer, err = imgconv.NewTIFFWriter(fp, wid, hei, model)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	err = out.copy(writer, pg, file)
+	if err == nil {
+		err = fp.Close()
+	}
+
+	return err
+}
+
+// Close finishes the output.
+func (out *output) Close() error {
+	if out.pages == 0 {
+		out.Abort()
+		return errors.New("no pages to write")
+	}
+
+	var err error
+	if out.pdf != nil {
+		err = out.pdf.Close()
+	}
+
+	if out.fp != nil {
+		err2 := out.fp.Close()
+		if err == nil {
+			err = err2
+		}
+	}
+
+	if err != nil {
+		out.Abort()
+		return fmt.Errorf("%s: %w", out.file, err)
+	}
+
+	switch len(out.files) {
+	case 1:
+		log.Info(out.ctx, "%s: %d page(s) written",
+			out.files[0], out.pages)
+	default:
+		log.Info(out.ctx, "%s...%s: %d page(s) written",
+			out.files[0], out.files[len(out.files)-1], out.pages)
+	}
+
+	return nil
+}
+
+// Abort closes and removes all created files.
+func (out *output) Abort() {
+	if out.fp != nil {
+		out.fp.Close()
+	}
+
+	for _, file := range out.files {
+		os.Remove(file)
+	}
+}
+
+// create creates the output file and remembers it for Abort.
+func (out *output) create(file string) (*os.File, error) {
+	fp, err := os.Create(file)
+	if err == nil {
+		out.files = append(out.files, file)
+	}
+	return fp, err
+}
+
+// copy copies the page image into the writer and closes
+// the writer. The file parameter is used for error messages.
+func (out *output) copy(writer imgconv.Writer, pg *page,
+	file string) error {
+	row := pg.reader.NewRow()
+
+	for {
+		_, err := pg.reader.Read(row)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			writer.Close()
+			return fmt.Errorf("%s: %w", pg.source, err)
+		}
+
+		err = writer.Write(row)
+		if err != nil {
+			writer.Close()
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	return nil
+}
+
+// numbered returns the output file name with the page number
+// inserted before the extension.
+func (out *output) numbered(n int) string {
+	ext := filepath.Ext(out.file)
+	base := strings.TrimSuffix(out.file, ext)
+	return fmt.Sprintf("%s-%d%s", base, n, ext)
+}
@@ -0,0 +1,19 @@
+// MFP             - Miulti-Function Printers and scanners toolkit
+// cmd/mfp-convert - Image format conversion
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The main() function.
+
+package main
+
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-convert/convert"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
+
+// main function for the mfp-convert command
+func main() {
+	cmdopt.Root(convert.Command).Main(nil)
+}
@@ -25,3 +25,25 @@ func devAttrsFormat(w io.Writer, dev *ipp.DeviceAttributes) {
 	fmt.Fprintf(w, "  IEEE-1284 ID:   %s\n", dev.DeviceID)
 	fmt.Fprintf(w, "  Location:       %s\n", dev.DeviceLocation)
 }
+
+// devRecord is the device record for the --format output.
+type devRecord struct {
+	Class        string `json:"class"`
+	Info         string `json:"info"`
+	MakeAndModel string `json:"make-and-model"`
+	URI          string `json:"uri"`
+	ID           string `json:"ieee-1284-id"`
+	Location     string `json:"location"`
+}
+
+// devRecordMake makes devRecord from [ipp.DeviceAttributes]
+func devRecordMake(dev *ipp.DeviceAttributes) devRecord {
+	return devRecord{
+		Class:        string(dev.DeviceClass),
+		Info:         dev.DeviceInfo,
+		MakeAndModel: dev.DeviceMakeAndModel,
+		URI:          dev.DeviceURI,
+		ID:           dev.DeviceID,
+		Location:     dev.DeviceLocation,
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/OpenPrinting/go-mfp/argv"
 	"github.com/OpenPrinting/go-mfp/cups"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
 	"github.com/OpenPrinting/go-mfp/internal/env"
 )
 
@@ -29,6 +30,9 @@ var cmdGetDefault = argv.Command{
 
 // cmdGetDefaultHandler is the "get-default" command handler
 func cmdGetDefaultHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
 	dest := optCUPSURL(inv)
 
 	attrList := optAttrsGet(inv)
@@ -10,10 +10,14 @@ package cups
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	"github.com/OpenPrinting/go-mfp/argv"
 	"github.com/OpenPrinting/go-mfp/cups"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
 	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/internal/output"
 )
 
 // cmdGetPrinters defines the "get-printers" sub-command.
@@ -32,6 +36,9 @@ var cmdGetDevices = argv.Command{
 
 // cmdGetPrintersHandler is the "get-printers" command handler
 func cmdGetDevicesHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
 	// Prepare arguments
 	dest := optCUPSURL(inv)
 
@@ -50,12 +57,23 @@ func cmdGetDevicesHandler(ctx context.Context, inv *argv.Invocation) error {
 	}
 
 	// Format output
+	records := make([]devRecord, len(devices))
+	for i, dev := range devices {
+		records[i] = devRecordMake(dev)
+	}
+
 	pager := env.NewPager()
+	err = output.Render(pager, output.OptionGet(inv), records,
+		func(w io.Writer) {
+			fmt.Fprintf(w, "CUPS: %s\n", dest)
+			for _, dev := range devices {
+				fmt.Fprintf(w, "\n")
+				devAttrsFormat(w, dev)
+			}
+		})
 
-	pager.Printf("CUPS: %s", dest)
-	for _, dev := range devices {
-		pager.Printf("")
-		devAttrsFormat(pager, dev)
+	if err != nil {
+		return err
 	}
 
 	return pager.Display()
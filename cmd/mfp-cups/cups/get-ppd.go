@@ -15,6 +15,7 @@ import (
 
 	"github.com/OpenPrinting/go-mfp/argv"
 	"github.com/OpenPrinting/go-mfp/cups"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
 	"github.com/OpenPrinting/go-mfp/internal/env"
 )
 
@@ -32,6 +33,9 @@ var cmdGetPPD = argv.Command{
 
 // cmdGetPPDHandler is the "get-ppd" command handler
 func cmdGetPPDHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
 	// Validate options
 	printerURI := optPrinterURIGet(inv)
 	ppdName := optPPDNameGet(inv)
@@ -10,10 +10,14 @@ package cups
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	"github.com/OpenPrinting/go-mfp/argv"
 	"github.com/OpenPrinting/go-mfp/cups"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
 	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/internal/output"
 )
 
 // cmdGetPrinters defines the "get-printers" sub-command.
@@ -23,9 +27,12 @@ var cmdGetPrinters = argv.Command{
 	Handler: cmdGetPrintersHandler,
 	Options: []argv.Option{
 		optAttrs,
+		optDefault,
 		optID,
 		optLimit,
 		optLocation,
+		optMakeModel,
+		optState,
 		optUser,
 		argv.HelpOption,
 	},
@@ -33,33 +40,65 @@ var cmdGetPrinters = argv.Command{
 
 // cmdGetPrintersHandler is the "get-printers" command handler
 func cmdGetPrintersHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
 	// Prepare arguments
 	dest := optCUPSURL(inv)
 
+	filter := prnFilterGet(inv)
+	limit := optLimitGet(inv)
+
 	sel := &cups.GetPrintersSelection{
-		PrinterID:       optIDGet(inv),
-		Limit:           optLimitGet(inv),
-		PrinterLocation: optLocationGet(inv),
-		User:            optUserGet(inv),
+		PrinterID: optIDGet(inv),
+		User:      optUserGet(inv),
 	}
 
 	attrList := optAttrsGet(inv)
 	attrList = append(attrList, prnAttrsRequested...)
 
+	// With the client-side filtering, limit is applied after
+	// the filter
+	if filter.Active() {
+		attrList = append(attrList, prnFilterAttrs...)
+	} else {
+		sel.Limit = limit
+	}
+
 	// Perform the query
 	clnt := cups.NewClient(dest, nil)
+	err := filter.Prepare(ctx, clnt)
+	if err != nil {
+		return err
+	}
+
 	printers, err := clnt.CUPSGetPrinters(ctx, sel, attrList)
 	if err != nil {
 		return err
 	}
 
+	if filter.Active() {
+		printers = filter.Apply(printers, limit)
+	}
+
 	// Format output
+	records := make([]prnRecord, len(printers))
+	for i, prn := range printers {
+		records[i] = prnRecordMake(prn)
+	}
+
 	pager := env.NewPager()
+	err = output.Render(pager, output.OptionGet(inv), records,
+		func(w io.Writer) {
+			fmt.Fprintf(w, "CUPS: %s\n", dest)
+			for _, prn := range printers {
+				fmt.Fprintf(w, "\n")
+				prnAttrsFormat(w, prn)
+			}
+		})
 
-	pager.Printf("CUPS: %s", dest)
-	for _, prn := range printers {
-		pager.Printf("")
-		prnAttrsFormat(pager, prn)
+	if err != nil {
+		return err
 	}
 
 	return pager.Display()
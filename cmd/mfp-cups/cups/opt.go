@@ -28,7 +28,7 @@ var optAttrs = argv.Option{
 	Help:     "Additional attributes",
 	HelpArg:  "attr,...",
 	Validate: argv.ValidateAny,
-	Complete: optAttrsComplete,
+	Complete: ipp.ArgvPrinterAttrsCompleter,
 }
 
 // optAttrsGet returns --attrs option (list of requested attributes).
@@ -45,31 +45,6 @@ func optAttrsGet(inv *argv.Invocation) (attrs []string) {
 	return
 }
 
-// optAttrsGet is the completion callback for the --attrs option.
-func optAttrsComplete(arg string) (compl []argv.Completion) {
-	infos := ((*ipp.PrinterAttributes)(nil)).KnownAttrs()
-
-	attrName := arg
-	prefix := ""
-
-	if i := strings.LastIndex(attrName, ","); i >= 0 {
-		attrName = arg[i+1:]
-		prefix = arg[:i+1]
-	}
-
-	for _, info := range infos {
-		if strings.HasPrefix(info.Name, attrName) {
-			c := argv.Completion{
-				String:  prefix + info.Name + ",",
-				NoSpace: true,
-			}
-			compl = append(compl, c)
-		}
-	}
-
-	return
-}
-
 // optID describes the --id option.
 // It specifies the printer-id.
 var optID = argv.Option{
@@ -106,13 +81,27 @@ func optLimitGet(inv *argv.Invocation) int {
 	return lim
 }
 
+// optDefault describes the --default option.
+// It limits output to the default destination only.
+var optDefault = argv.Option{
+	Name: "--default",
+	Help: "Show only the default printer",
+}
+
+// optDefaultGet returns --default option value.
+func optDefaultGet(inv *argv.Invocation) bool {
+	_, ok := inv.Get("--default")
+	return ok
+}
+
 // optLocation describes the --location option.
 // It specified the desired printer location (e.g. "2nd Floor Computer Lab")
+// The location is matched as case-insensitive substring.
 var optLocation = argv.Option{
 	Name: "--location",
 	Help: "" +
-		`Printer location ` +
-		`(e.g., "2nd Floor Computer Lab")`,
+		`Printer location substring ` +
+		`(e.g., "2nd Floor")`,
 	HelpArg:  "where",
 	Validate: argv.ValidateAny,
 }
@@ -123,6 +112,22 @@ func optLocationGet(inv *argv.Invocation) string {
 	return opt
 }
 
+// optMakeModel describes the --make-and-model option.
+// It specifies the printer make and model substring to match
+// (case-insensitive).
+var optMakeModel = argv.Option{
+	Name:     "--make-and-model",
+	Help:     `Printer make and model substring (e.g., "HP")`,
+	HelpArg:  "model",
+	Validate: argv.ValidateAny,
+}
+
+// optMakeModelGet returns --make-and-model option value.
+func optMakeModelGet(inv *argv.Invocation) string {
+	opt, _ := inv.Get("--make-and-model")
+	return opt
+}
+
 // optSchemesExclude describes the --exclude-schemes=scheme,... option
 // It specifies URL schemes to be excluded
 var optSchemesExclude = argv.Option{
@@ -200,6 +205,36 @@ func optSchemesComplete(arg string) (compl []argv.Completion) {
 	return
 }
 
+// optState describes the --state option.
+// It specifies the printer state. May be used multiple times.
+var optState = argv.Option{
+	Name:     "--state",
+	Help:     "Printer state: idle, processing or stopped",
+	HelpArg:  "state",
+	Validate: argv.ValidateStrings(optStateNames),
+	Complete: argv.CompleteStrings(optStateNames),
+}
+
+// optStateNames contains valid values of the --state option.
+var optStateNames = []string{"idle", "processing", "stopped"}
+
+// optStateValues maps --state option values into the
+// "printer-state" attribute values (RFC8011, 5.4.11).
+var optStateValues = map[string]int{
+	"idle":       3,
+	"processing": 4,
+	"stopped":    5,
+}
+
+// optStateGet returns --state option values as the "printer-state"
+// attribute values.
+func optStateGet(inv *argv.Invocation) (states []int) {
+	for _, opt := range inv.Values("--state") {
+		states = append(states, optStateValues[opt])
+	}
+	return
+}
+
 // optTimeout describes the --timeout=seconds option.
 // It specifies operation timeout
 var optTimeout = argv.Option{
@@ -270,7 +305,7 @@ func optPPDNameGet(inv *argv.Invocation) string {
 func optCUPSURL(inv *argv.Invocation) *url.URL {
 	dest := transport.DefaultCupsUNIX
 
-	if addr, ok := inv.Parent().Get("-u"); ok {
+	if addr, ok := inv.Get("-u"); ok {
 		dest = transport.MustParseAddr(addr, "ipp://localhost/")
 	}
 
@@ -47,7 +47,43 @@ func prnAttrsFormat(w io.Writer, prn *ipp.PrinterAttributes) {
 
 	f := goipp.NewFormatter()
 	f.SetIndent(4)
-	f.FmtAttributes(prn.RawAttrs().All().Clone())
+	f.FmtAttributes(ipp.NamedEnumAttrs(prn.RawAttrs().All()))
 
 	f.WriteTo(w)
 }
+
+// prnRecord is the printer record for the --format output.
+type prnRecord struct {
+	Name      string              `json:"name"`
+	ID        int                 `json:"id"`
+	URI       []string            `json:"uri"`
+	DeviceURI []string            `json:"device-uri"`
+	Shared    bool                `json:"shared"`
+	Temporary bool                `json:"temporary"`
+	Type      string              `json:"type"`
+	Attrs     map[string][]string `json:"attrs"`
+}
+
+// prnRecordMake makes prnRecord from [ipp.PrinterAttributes]
+func prnRecordMake(prn *ipp.PrinterAttributes) prnRecord {
+	rec := prnRecord{
+		Name:      prn.PrinterName,
+		ID:        prn.PrinterID,
+		URI:       prn.PrinterURISupported,
+		DeviceURI: prn.DeviceURI,
+		Shared:    prn.PrinterIsShared,
+		Temporary: prn.PrinterIsTemporary,
+		Type:      prn.PrinterType.String(),
+		Attrs:     make(map[string][]string),
+	}
+
+	for _, attr := range prn.RawAttrs().All() {
+		vals := make([]string, len(attr.Values))
+		for i, v := range attr.Values {
+			vals[i] = v.V.String()
+		}
+		rec.Attrs[attr.Name] = vals
+	}
+
+	return rec
+}
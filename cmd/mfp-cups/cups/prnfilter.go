@@ -0,0 +1,112 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "cups" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Client-side printers filter
+
+package cups
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/cups"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+)
+
+// prnFilterAttrs lists attributes, required by the prnFilter.
+var prnFilterAttrs = []string{
+	"printer-location",
+	"printer-make-and-model",
+	"printer-state",
+}
+
+// prnFilter filters printers, returned by CUPS-Get-Printers,
+// at the client side.
+//
+// CUPS matches printer-location exactly and has no means to
+// select printers by state or by make and model, so these
+// filters are applied after the query.
+type prnFilter struct {
+	states    []int  // Printer states, nil if any
+	location  string // Location substring, lowercase
+	makeModel string // Make and model substring, lowercase
+	dflt      bool   // Default printer only
+	dfltName  string // Default printer name, if dflt
+}
+
+// prnFilterGet creates prnFilter from the command options.
+func prnFilterGet(inv *argv.Invocation) *prnFilter {
+	return &prnFilter{
+		states:    optStateGet(inv),
+		location:  strings.ToLower(optLocationGet(inv)),
+		makeModel: strings.ToLower(optMakeModelGet(inv)),
+		dflt:      optDefaultGet(inv),
+	}
+}
+
+// Active reports whether any filter is active.
+func (f *prnFilter) Active() bool {
+	return f.states != nil || f.location != "" || f.makeModel != "" ||
+		f.dflt
+}
+
+// Prepare queries information, required by the filter,
+// from the CUPS server.
+func (f *prnFilter) Prepare(ctx context.Context, clnt *cups.Client) error {
+	if !f.dflt {
+		return nil
+	}
+
+	prn, err := clnt.CUPSGetDefault(ctx, []string{"printer-name"})
+	if err != nil {
+		return err
+	}
+
+	f.dfltName = prn.PrinterName
+	return nil
+}
+
+// Match reports whether printer matches the filter.
+func (f *prnFilter) Match(prn *ipp.PrinterAttributes) bool {
+	switch {
+	case f.states != nil && !slices.Contains(f.states, prn.PrinterState):
+		return false
+
+	case !strings.Contains(strings.ToLower(prn.PrinterLocation),
+		f.location):
+		return false
+
+	case !strings.Contains(strings.ToLower(prn.PrinterMakeAndModel),
+		f.makeModel):
+		return false
+
+	case f.dflt && prn.PrinterName != f.dfltName:
+		return false
+	}
+
+	return true
+}
+
+// Apply returns printers that match the filter.
+// If limit is not 0, no more than limit printers are returned.
+func (f *prnFilter) Apply(printers []*ipp.PrinterAttributes,
+	limit int) []*ipp.PrinterAttributes {
+
+	matched := []*ipp.PrinterAttributes{}
+	for _, prn := range printers {
+		if limit > 0 && len(matched) == limit {
+			break
+		}
+
+		if f.Match(prn) {
+			matched = append(matched, prn)
+		}
+	}
+
+	return matched
+}
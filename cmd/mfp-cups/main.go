@@ -8,9 +8,12 @@
 
 package main
 
-import "github.com/OpenPrinting/go-mfp/cmd/mfp-cups/cups"
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-cups/cups"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
 
 // main function for the mfp-cups command
 func main() {
-	cups.Command.Main(nil)
+	cmdopt.Root(cups.Command).Main(nil)
 }
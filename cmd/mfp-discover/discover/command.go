@@ -10,6 +10,8 @@ package discover
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"strings"
 
 	"github.com/OpenPrinting/go-mfp/abstract"
@@ -18,24 +20,36 @@ import (
 	"github.com/OpenPrinting/go-mfp/discovery/dnssd"
 	"github.com/OpenPrinting/go-mfp/discovery/wsdd"
 	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/internal/output"
 	"github.com/OpenPrinting/go-mfp/log"
 )
 
+// description is printed as a command description text
+const description = "" +
+	"The --filter option selects devices by the expression, made of\n" +
+	"terms, joined with the && operator. Each term is either the\n" +
+	"comparison (=, != - case-insensitive, ~, !~ - regular expression)\n" +
+	"or the Boolean attribute, optionally negated with '!'.\n" +
+	"\n" +
+	"Device attributes: model, location, name, uuid, serial, addr,\n" +
+	"verified.\n" +
+	"\n" +
+	"Unit attributes: type (printer, scanner, faxout), proto (ipp,\n" +
+	"escl, lpd, appsocket, wsd, usb), pdl, duplex, color, adf, platen,\n" +
+	"bind, collate, copies, punch, sort, staple. All unit attributes\n" +
+	"must be satisfied by the same unit.\n" +
+	"\n" +
+	"Examples:\n" +
+	"\n" +
+	"  mfp discover --filter \"type=scanner && proto=escl && duplex\"\n" +
+	"  mfp discover --filter \"model~'Kyocera.*'\"\n"
+
 // Command is the 'cups' command description
 var Command = argv.Command{
-	Name: "discover",
-	Help: "search for printers and scanners",
+	Name:        "discover",
+	Help:        "search for printers and scanners",
+	Description: description,
 	Options: []argv.Option{
-		argv.Option{
-			Name:    "-d",
-			Aliases: []string{"--debug"},
-			Help:    "Enable debug output",
-		},
-		argv.Option{
-			Name:    "-v",
-			Aliases: []string{"--verbose"},
-			Help:    "Enable verbose debug output",
-		},
 		argv.Option{
 			Name:    "-p",
 			Aliases: []string{"--printers"},
@@ -46,6 +60,17 @@ var Command = argv.Command{
 			Aliases: []string{"--scanners"},
 			Help:    "Search for scanners",
 		},
+		argv.Option{
+			Name:    "-f",
+			Aliases: []string{"--filter"},
+			HelpArg: "expr",
+			Help: "Select devices by expression. " +
+				"E.g., \"type=scanner && duplex\"",
+			Validate: func(s string) error {
+				_, err := discovery.ParseFilter(s)
+				return err
+			},
+		},
 		argv.HelpOption,
 	},
 	Handler: cmdDiscoverHandler,
@@ -70,6 +95,16 @@ func cmdDiscoverHandler(ctx context.Context, inv *argv.Invocation) error {
 	logger := log.NewLogger(level, log.Console)
 	ctx = log.NewContext(ctx, logger)
 
+	// Parse filter
+	var filter *discovery.Filter
+	if expr, ok := inv.Get("-f"); ok {
+		var err error
+		filter, err = discovery.ParseFilter(expr)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Prepare discovery.Client
 	clnt := discovery.NewClient(ctx)
 
@@ -96,10 +131,29 @@ func cmdDiscoverHandler(ctx context.Context, inv *argv.Invocation) error {
 		return err
 	}
 
+	if filter != nil {
+		devices = filter.Apply(devices)
+	}
+
 	// Format output
+	records := make([]devRecord, len(devices))
+	for i, dev := range devices {
+		records[i] = devRecordMake(dev)
+	}
+
 	pager := env.NewPager()
-	defer pager.Display()
+	err = output.Render(pager, output.OptionGet(inv), records,
+		func(io.Writer) { devicesFormat(pager, devices) })
+
+	if err != nil {
+		return err
+	}
+
+	return pager.Display()
+}
 
+// devicesFormat pretty-prints discovered devices
+func devicesFormat(pager *env.Pager, devices []discovery.Device) {
 	if len(devices) == 0 {
 		pager.Printf("No devices found.")
 	}
@@ -124,8 +178,20 @@ func cmdDiscoverHandler(ctx context.Context, inv *argv.Invocation) error {
 			s = append(s, addr.String())
 		}
 		pager.Printf("  IP addresses: %s", strings.Join(s, ", "))
+
+		s = s[:0]
+		for _, realm := range dev.Realms {
+			s = append(s, realm.String())
+		}
+		pager.Printf("  First seen:   %s", devTimeFormat(dev.FirstSeen))
+		pager.Printf("  Last seen:    %s", devTimeFormat(dev.LastSeen))
+		pager.Printf("  Backends:     %s", strings.Join(s, ", "))
+		pager.Printf("  Verified:     %v", dev.Verified)
+		pager.Printf("  Confidence:   %s", dev.Confidence())
 		pager.Printf("")
 
+		devMatrixFormat(pager, dev)
+
 		if len(dev.PrintUnits) != 0 {
 			pager.Printf("  Print units:")
 			for i, un := range dev.PrintUnits {
@@ -243,6 +309,41 @@ func cmdDiscoverHandler(ctx context.Context, inv *argv.Invocation) error {
 			pager.Printf("")
 		}
 	}
+}
+
+// devMatrixFormat pretty-prints the service-protocol capability
+// matrix of the device
+func devMatrixFormat(pager *env.Pager, dev discovery.Device) {
+	m := dev.ProtoMatrix()
+
+	widths := make([]int, len(discovery.MatrixProtos))
+	hdr := fmt.Sprintf("    %-8s", "")
+	for i, proto := range discovery.MatrixProtos {
+		widths[i] = max(len(proto.String()), len("verified"))
+		hdr += fmt.Sprintf(" %-*s", widths[i], proto)
+	}
+
+	pager.Printf("  Protocols:")
+	pager.Printf("%s", strings.TrimRight(hdr, " "))
+
+	for _, svc := range discovery.MatrixServices {
+		line := fmt.Sprintf("    %-8s", svc)
+		for i, proto := range discovery.MatrixProtos {
+			cell := ""
+			if proto.Applicable(svc) {
+				switch m.Get(svc, proto) {
+				case discovery.MatrixVerified:
+					cell = "verified"
+				case discovery.MatrixDiscovered:
+					cell = "found"
+				default:
+					cell = "-"
+				}
+			}
+			line += fmt.Sprintf(" %-*s", widths[i], cell)
+		}
+		pager.Printf("%s", strings.TrimRight(line, " "))
+	}
 
-	return nil
+	pager.Printf("")
 }
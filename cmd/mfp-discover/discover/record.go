@@ -0,0 +1,199 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "discover" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Device records for the --format output
+
+package discover
+
+import (
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/discovery"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+)
+
+// devRecord is the device record for the --format output.
+type devRecord struct {
+	MakeModel       string                       `json:"make-model"`
+	Location        string                       `json:"location"`
+	DNSSDName       string                       `json:"dnssd-name"`
+	DNSSDUUID       string                       `json:"dnssd-uuid"`
+	PrintAdminURL   string                       `json:"print-admin-url"`
+	ScanAdminURL    string                       `json:"scan-admin-url"`
+	FaxoutAdminURL  string                       `json:"faxout-admin-url"`
+	IconURL         string                       `json:"icon-url"`
+	PPDManufacturer string                       `json:"ppd-manufacturer"`
+	PPDModel        string                       `json:"ppd-model"`
+	USBSerial       string                       `json:"usb-serial"`
+	Addrs           []string                     `json:"addrs"`
+	FirstSeen       string                       `json:"first-seen"`
+	LastSeen        string                       `json:"last-seen"`
+	Backends        []string                     `json:"backends"`
+	Verified        bool                         `json:"verified"`
+	Confidence      string                       `json:"confidence"`
+	Protocols       map[string]map[string]string `json:"protocols"`
+	PrintUnits      []prnUnitRecord              `json:"print-units"`
+	ScanUnits       []scanUnitRecord             `json:"scan-units"`
+	FaxoutUnits     []prnUnitRecord              `json:"faxout-units"`
+}
+
+// prnUnitRecord is the print or faxout unit record.
+type prnUnitRecord struct {
+	Proto     string   `json:"proto"`
+	Auth      string   `json:"auth"`
+	Paper     string   `json:"paper"`
+	Media     string   `json:"media"`
+	Flags     string   `json:"flags"`
+	PSProduct string   `json:"psproduct"`
+	PDL       []string `json:"pdl"`
+	Priority  int      `json:"priority"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// scanUnitRecord is the scan unit record.
+type scanUnitRecord struct {
+	Proto      string   `json:"proto"`
+	Duplex     *bool    `json:"duplex"`
+	Sources    string   `json:"sources"`
+	ColorModes []string `json:"color-modes"`
+	PDL        []string `json:"pdl"`
+	Endpoints  []string `json:"endpoints"`
+}
+
+// devMatrixRecordMake makes the service-protocol matrix record
+// from the [discovery.Device]. Only applicable protocols are included.
+func devMatrixRecordMake(dev discovery.Device) map[string]map[string]string {
+	m := dev.ProtoMatrix()
+	rec := make(map[string]map[string]string)
+
+	for _, svc := range discovery.MatrixServices {
+		row := make(map[string]string)
+		for _, proto := range discovery.MatrixProtos {
+			if proto.Applicable(svc) {
+				row[proto.String()] = m.Get(svc, proto).String()
+			}
+		}
+		rec[svc.String()] = row
+	}
+
+	return rec
+}
+
+// devRecordMake makes devRecord from the [discovery.Device]
+func devRecordMake(dev discovery.Device) devRecord {
+	rec := devRecord{
+		MakeModel:       dev.MakeModel,
+		Location:        dev.Location,
+		DNSSDName:       dev.DNSSDName,
+		PrintAdminURL:   dev.PrintAdminURL,
+		ScanAdminURL:    dev.ScanAdminURL,
+		FaxoutAdminURL:  dev.FaxoutAdminURL,
+		IconURL:         dev.IconURL,
+		PPDManufacturer: dev.PPDManufacturer,
+		PPDModel:        dev.PPDModel,
+		USBSerial:       dev.USBSerial,
+		Addrs:           []string{},
+		FirstSeen:       devTimeFormat(dev.FirstSeen),
+		LastSeen:        devTimeFormat(dev.LastSeen),
+		Backends:        []string{},
+		Verified:        dev.Verified,
+		Confidence:      dev.Confidence().String(),
+		Protocols:       devMatrixRecordMake(dev),
+		PrintUnits:      []prnUnitRecord{},
+		ScanUnits:       []scanUnitRecord{},
+		FaxoutUnits:     []prnUnitRecord{},
+	}
+
+	if dev.DNSSDUUID != uuid.NilUUID {
+		rec.DNSSDUUID = dev.DNSSDUUID.String()
+	}
+
+	for _, addr := range dev.Addrs {
+		rec.Addrs = append(rec.Addrs, addr.String())
+	}
+
+	for _, realm := range dev.Realms {
+		rec.Backends = append(rec.Backends, realm.String())
+	}
+
+	for _, un := range dev.PrintUnits {
+		rec.PrintUnits = append(rec.PrintUnits,
+			prnUnitRecordMake(un.Proto, un.Params, un.Endpoints))
+	}
+
+	for _, un := range dev.FaxoutUnits {
+		rec.FaxoutUnits = append(rec.FaxoutUnits,
+			prnUnitRecordMake(un.Proto, un.Params, un.Endpoints))
+	}
+
+	for _, un := range dev.ScanUnits {
+		p := un.Params
+		unrec := scanUnitRecord{
+			Proto:      un.Proto.String(),
+			ColorModes: []string{},
+			PDL:        p.PDL,
+			Endpoints:  un.Endpoints,
+		}
+
+		if p.Duplex != discovery.OptUnknown {
+			duplex := p.Duplex == discovery.OptTrue
+			unrec.Duplex = &duplex
+		}
+
+		if p.Sources != 0 {
+			unrec.Sources = p.Sources.String()
+		}
+
+		if p.Colors.Contains(abstract.ColorModeColor) {
+			unrec.ColorModes = append(unrec.ColorModes, "color")
+		}
+		if p.Colors.Contains(abstract.ColorModeMono) {
+			unrec.ColorModes = append(unrec.ColorModes, "mono")
+		}
+		if p.Colors.Contains(abstract.ColorModeBinary) {
+			unrec.ColorModes = append(unrec.ColorModes, "bin")
+		}
+
+		rec.ScanUnits = append(rec.ScanUnits, unrec)
+	}
+
+	return rec
+}
+
+// prnUnitRecordMake makes prnUnitRecord for print or faxout unit
+func prnUnitRecordMake(proto discovery.ServiceProto,
+	p discovery.PrinterParameters, endpoints []string) prnUnitRecord {
+
+	rec := prnUnitRecord{
+		Proto:     proto.String(),
+		Auth:      p.Auth.String(),
+		Flags:     p.Flags(),
+		PSProduct: p.PSProduct,
+		PDL:       p.PDL,
+		Priority:  p.Priority,
+		Endpoints: endpoints,
+	}
+
+	if p.Paper != discovery.PaperUnknown {
+		rec.Paper = p.Paper.String()
+	}
+
+	if p.Media != 0 {
+		rec.Media = p.Media.String()
+	}
+
+	return rec
+}
+
+// devTimeFormat formats device FirstSeen/LastSeen time.
+func devTimeFormat(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -8,9 +8,12 @@
 
 package main
 
-import "github.com/OpenPrinting/go-mfp/cmd/mfp-discover/discover"
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-discover/discover"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
 
 // main function for the mfp-discover command
 func main() {
-	discover.Command.Main(nil)
+	cmdopt.Root(discover.Command).Main(nil)
 }
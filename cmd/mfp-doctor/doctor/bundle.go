@@ -0,0 +1,114 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "doctor" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Diagnostics bundle
+
+package doctor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"sync"
+	"time"
+)
+
+// bundle is the diagnostics bundle being collected.
+//
+// Files are collected in memory and written all together
+// by the bundle.Write, so redaction may be applied to all
+// files, including these collected before the sensitive
+// information was learned.
+type bundle struct {
+	files   []bundleFile        // Collected files
+	serials map[string]struct{} // Serial numbers seen
+	lock    sync.Mutex          // Access lock
+}
+
+// bundleFile is the single file of the bundle.
+type bundleFile struct {
+	name string // File name
+	data []byte // File content
+}
+
+// newBundle creates a new bundle.
+func newBundle() *bundle {
+	return &bundle{serials: make(map[string]struct{})}
+}
+
+// Add adds a file to the bundle.
+func (b *bundle) Add(name string, data []byte) {
+	b.lock.Lock()
+	b.files = append(b.files, bundleFile{name, data})
+	b.lock.Unlock()
+}
+
+// AddSerial remembers the device serial number for redaction.
+func (b *bundle) AddSerial(serial string) {
+	if serial != "" {
+		b.lock.Lock()
+		b.serials[serial] = struct{}{}
+		b.lock.Unlock()
+	}
+}
+
+// Write writes the bundle as gzip-compressed TAR archive.
+//
+// If redact is true, all seen serial numbers are replaced
+// in all files.
+func (b *bundle) Write(name string, redact bool) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var serials []string
+	if redact {
+		for serial := range b.serials {
+			serials = append(serials, serial)
+		}
+	}
+
+	fp, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(fp)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	for _, file := range b.files {
+		data := file.data
+		if redact {
+			data = redactSerials(data, serials)
+		}
+
+		hdr := tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.name,
+			Size:     int64(len(data)),
+			Mode:     0644,
+			ModTime:  now,
+		}
+
+		err = tw.WriteHeader(&hdr)
+		if err == nil {
+			_, err = tw.Write(data)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	for _, closer := range []interface{ Close() error }{tw, gz, fp} {
+		err2 := closer.Close()
+		if err == nil {
+			err = err2
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,220 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "doctor" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Information collectors
+
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/OpenPrinting/go-mfp/discovery"
+	"github.com/OpenPrinting/go-mfp/discovery/dnssd"
+	"github.com/OpenPrinting/go-mfp/discovery/wsdd"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/goipp"
+)
+
+// collectNetwork collects network interfaces state.
+func collectNetwork(ctx context.Context, b *bundle) {
+	log.Info(ctx, "collecting network interfaces state")
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		log.Error(ctx, "network interfaces: %s", err)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	for _, ifi := range ifaces {
+		fmt.Fprintf(buf, "%d: %s: <%s> mtu %d\n",
+			ifi.Index, ifi.Name, ifi.Flags, ifi.MTU)
+		if len(ifi.HardwareAddr) != 0 {
+			fmt.Fprintf(buf, "    link %s\n", ifi.HardwareAddr)
+		}
+
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			fmt.Fprintf(buf, "    error: %s\n", err)
+		}
+
+		for _, addr := range addrs {
+			fmt.Fprintf(buf, "    addr %s\n", addr)
+		}
+	}
+
+	b.Add("network.txt", buf.Bytes())
+}
+
+// collectDiscovery performs devices discovery and collects results.
+// It returns the discovered devices.
+func collectDiscovery(ctx context.Context, b *bundle) []discovery.Device {
+	log.Info(ctx, "discovering devices")
+
+	clnt := discovery.NewClient(ctx)
+	defer clnt.Close()
+
+	backend, err := dnssd.NewBackend(ctx, "", 0)
+	if err != nil {
+		log.Error(ctx, "DNS-SD: %s", err)
+	} else {
+		defer backend.Close()
+		clnt.AddBackend(backend)
+	}
+
+	backend, err = wsdd.NewBackend(ctx)
+	if err != nil {
+		log.Error(ctx, "WSD: %s", err)
+	} else {
+		defer backend.Close()
+		clnt.AddBackend(backend)
+	}
+
+	devices, err := clnt.GetDevices(ctx, discovery.ModeNormal)
+	if err != nil {
+		log.Error(ctx, "discovery: %s", err)
+		return nil
+	}
+
+	for _, dev := range devices {
+		b.AddSerial(dev.USBSerial)
+	}
+
+	data, _ := json.MarshalIndent(devices, "", "  ")
+	b.Add("discovery.json", data)
+
+	log.Info(ctx, "%d devices found", len(devices))
+
+	return devices
+}
+
+// collectTransport creates the HTTP transport for device queries.
+// Diagnostics must be collected even from devices with broken firmware, so malformed responses are salvaged.
+func collectTransport() *transport.Transport {
+	tr := transport.NewTransport(nil)
+	tr.Tolerant = true
+	return tr
+}
+
+// collectESCL collects eSCL scanner capabilities and status.
+func collectESCL(ctx context.Context, b *bundle, dir string, u *url.URL) {
+	log.Info(ctx, "eSCL: %s: collecting information", u)
+
+	clnt := escl.NewClient(u, collectTransport())
+	b.Add(dir+"/url.txt", []byte(u.String()+"\n"))
+
+	caps, _, err := clnt.GetScannerCapabilities(ctx)
+	if err != nil {
+		log.Error(ctx, "eSCL: %s: %s", u, err)
+	} else {
+		b.AddSerial(optional.Get(caps.SerialNumber))
+
+		xml := caps.ToXML().EncodeIndentString(escl.NsMap, "  ")
+		b.Add(dir+"/ScannerCapabilities.xml", []byte(xml))
+	}
+
+	status, _, err := clnt.GetScannerStatus(ctx)
+	if err != nil {
+		log.Error(ctx, "eSCL: %s: %s", u, err)
+	} else {
+		xml := status.ToXML().EncodeIndentString(escl.NsMap, "  ")
+		b.Add(dir+"/ScannerStatus.xml", []byte(xml))
+	}
+}
+
+// collectIPP collects IPP printer attributes.
+func collectIPP(ctx context.Context, b *bundle, dir string, u *url.URL) {
+	log.Info(ctx, "IPP: %s: collecting information", u)
+
+	clnt := ipp.NewClient(u, collectTransport())
+	b.Add(dir+"/url.txt", []byte(u.String()+"\n"))
+
+	prn, err := clnt.GetPrinterAttributes(ctx, []string{"all"})
+	if err != nil {
+		log.Error(ctx, "IPP: %s: %s", u, err)
+		return
+	}
+
+	attrs := prn.RawAttrs().All()
+	for _, attr := range attrs {
+		if attr.Name == "printer-device-id" && len(attr.Values) != 0 {
+			b.AddSerial(ieee1284Serial(attr.Values[0].V.String()))
+		}
+	}
+
+	f := goipp.NewFormatter()
+	f.FmtAttributes(attrs.Clone())
+	b.Add(dir+"/printer-attributes.txt", f.Bytes())
+}
+
+// collectLogs collects log files.
+func collectLogs(ctx context.Context, b *bundle, files []string) {
+	for _, file := range files {
+		log.Info(ctx, "%s: collecting log file", file)
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Error(ctx, "%s", err)
+			continue
+		}
+
+		b.Add("logs/"+filepath.Base(file), data)
+	}
+}
+
+// collectDir returns directory name within bundle for the
+// n-th device of the particular kind.
+func collectDir(kind string, n int, u *url.URL) string {
+	host := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z',
+			c >= '0' && c <= '9', c == '.', c == '-':
+			return c
+		}
+		return '_'
+	}, u.Host)
+
+	return fmt.Sprintf("%s/%2.2d-%s", kind, n, host)
+}
+
+// logBuffer is the log.Backend that collects log into
+// the memory buffer.
+type logBuffer struct {
+	buf  bytes.Buffer // Log content
+	lock sync.Mutex   // Access lock
+}
+
+// Send implements [log.Backend.Send] method.
+func (lb *logBuffer) Send(levels []log.Level, lines [][]byte) {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	for _, line := range lines {
+		lb.buf.Write(line)
+		lb.buf.WriteByte('\n')
+	}
+}
+
+// Bytes returns collected log content.
+func (lb *logBuffer) Bytes() []byte {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	return bytes.Clone(lb.buf.Bytes())
+}
@@ -0,0 +1,193 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "doctor" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/discovery"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// DefaultOutput is the default name of the diagnostics bundle.
+const DefaultOutput = "mfp-doctor.tar.gz"
+
+// description is printed as a command description text
+const description = "" +
+	"This command collects diagnostics information into the single\n" +
+	"compressed tarball, suitable for attaching to bug reports.\n" +
+	"\n" +
+	"The following information is collected:\n" +
+	"\n" +
+	"  - network interfaces state\n" +
+	"  - devices discovery results (unless --no-discovery)\n" +
+	"  - eSCL scanner capabilities and status\n" +
+	"  - IPP printer attributes\n" +
+	"  - log files, specified with --log\n" +
+	"  - log of the collection itself\n" +
+	"\n" +
+	"eSCL and IPP information is collected from the devices,\n" +
+	"specified with --escl and --ipp, and from all discovered\n" +
+	"devices.\n" +
+	"\n" +
+	"With --redact, device serial numbers are replaced with\n" +
+	"\"REDACTED\" in all collected files.\n"
+
+// Command is the 'doctor' command description
+var Command = argv.Command{
+	Name:        "doctor",
+	Help:        "Collect diagnostics bundle for bug reports",
+	Description: description,
+	Options: []argv.Option{
+		argv.Option{
+			Name:    "-o",
+			Aliases: []string{"--output"},
+			HelpArg: "file",
+			Help: fmt.Sprintf("Output file. Default: %s",
+				DefaultOutput),
+			Validate: argv.ValidateAny,
+			Complete: argv.CompleteOSPath,
+		},
+		argv.Option{
+			Name:     "--escl",
+			HelpArg:  "URL",
+			Help:     "eSCL scanner URL (i.e., http://host/eSCL)",
+			Validate: transport.ValidateURL,
+		},
+		argv.Option{
+			Name:     "--ipp",
+			HelpArg:  "URL",
+			Help:     "IPP printer URL (i.e., ipp://host/ipp/print)",
+			Validate: transport.ValidateURL,
+		},
+		argv.Option{
+			Name:     "--log",
+			HelpArg:  "file",
+			Help:     "Log file to include",
+			Validate: argv.ValidateAny,
+			Complete: argv.CompleteOSPath,
+		},
+		argv.Option{
+			Name: "--no-discovery",
+			Help: "Don't perform devices discovery",
+		},
+		argv.Option{
+			Name: "--redact",
+			Help: "Redact device serial numbers",
+		},
+		argv.HelpOption,
+	},
+	Handler: cmdDoctorHandler,
+}
+
+// cmdDoctorHandler is the handler for the 'doctor' command.
+func cmdDoctorHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging. The complete log is always collected
+	// into the bundle.
+	logbuf := &logBuffer{}
+	logger := log.NewLogger(cmdopt.LogLevel(inv), log.Console)
+	logger.Attach(log.LevelTrace, logbuf)
+	ctx = log.NewContext(ctx, logger)
+
+	// Parse options
+	output := DefaultOutput
+	if s, ok := inv.Get("-o"); ok {
+		output = s
+	}
+
+	_, nodiscovery := inv.Get("--no-discovery")
+	_, redact := inv.Get("--redact")
+
+	escls := []string{}
+	for _, s := range inv.Values("--escl") {
+		escls = append(escls, transport.MustParseURL(s).String())
+	}
+
+	ipps := []string{}
+	for _, s := range inv.Values("--ipp") {
+		ipps = append(ipps, transport.MustParseURL(s).String())
+	}
+
+	// Collect information
+	b := newBundle()
+	log.Info(ctx, "mfp doctor started at %s",
+		time.Now().Format(time.RFC3339))
+
+	collectNetwork(ctx, b)
+
+	if !nodiscovery {
+		devices := collectDiscovery(ctx, b)
+		escls, ipps = doctorEndpoints(devices, escls, ipps)
+	}
+
+	for i, s := range escls {
+		u := transport.MustParseURL(s)
+		collectESCL(ctx, b, collectDir("escl", i+1, u), u)
+	}
+
+	for i, s := range ipps {
+		u := transport.MustParseURL(s)
+		collectIPP(ctx, b, collectDir("ipp", i+1, u), u)
+	}
+
+	collectLogs(ctx, b, inv.Values("--log"))
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	log.Info(ctx, "%s: writing bundle", output)
+	b.Add("doctor.log", logbuf.Bytes())
+
+	return b.Write(output, redact)
+}
+
+// doctorEndpoints appends eSCL and IPP endpoints of the discovered
+// devices to the lists of endpoints to be examined.
+//
+// Only the first endpoint of each unit is used, and duplicates
+// are skipped.
+func doctorEndpoints(devices []discovery.Device,
+	escls, ipps []string) ([]string, []string) {
+
+	add := func(list []string, endpoints []string) []string {
+		if len(endpoints) == 0 {
+			return list
+		}
+
+		for _, s := range list {
+			if s == endpoints[0] {
+				return list
+			}
+		}
+
+		return append(list, endpoints[0])
+	}
+
+	for _, dev := range devices {
+		for _, un := range dev.ScanUnits {
+			if un.Proto == discovery.ServiceESCL {
+				escls = add(escls, un.Endpoints)
+			}
+		}
+
+		for _, un := range dev.PrintUnits {
+			if un.Proto == discovery.ServiceIPP {
+				ipps = add(ipps, un.Endpoints)
+			}
+		}
+	}
+
+	return escls, ipps
+}
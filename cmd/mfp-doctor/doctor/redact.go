@@ -0,0 +1,61 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "doctor" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Redaction of sensitive information
+
+package doctor
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// redactedSerial replaces serial numbers in the redacted bundle.
+const redactedSerial = "REDACTED"
+
+// redactMinLen is the minimal length of the serial number to be
+// redacted. Shorter strings are too likely to be found in
+// unrelated places.
+const redactMinLen = 4
+
+// redactSerials replaces all occurrences of the serial numbers
+// in data with the redactedSerial.
+func redactSerials(data []byte, serials []string) []byte {
+	// Replace longer strings first, so if some serial number
+	// is a substring of another, both will be properly replaced.
+	sort.Slice(serials, func(i, j int) bool {
+		return len(serials[i]) > len(serials[j])
+	})
+
+	for _, serial := range serials {
+		if len(serial) >= redactMinLen {
+			data = bytes.ReplaceAll(data, []byte(serial),
+				[]byte(redactedSerial))
+		}
+	}
+
+	return data
+}
+
+// ieee1284Serial returns the serial number from the IEEE 1284
+// device ID string (as reported by the "printer-device-id" IPP
+// attribute), or "" if there is no serial number.
+func ieee1284Serial(id string) string {
+	for _, field := range strings.Split(id, ";") {
+		name, value, found := strings.Cut(field, ":")
+		if !found {
+			continue
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(name)) {
+		case "SN", "SERN", "SERIALNUMBER":
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,367 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "emulate" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package emulate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// DefaultTCPPort is the default TCP port for the emulator
+const DefaultTCPPort = 8080
+
+// DefaultName is the default DNS-SD instance name
+const DefaultName = "MFP Emulator"
+
+// description is printed as a command description text
+const description = "" +
+	"This command runs a virtual device, backed by the synthetic\n" +
+	"scanner, so applications can be tested against a fake MFP\n" +
+	"without real hardware.\n" +
+	"\n" +
+	"Scanner capabilities are built-in by default, or can be loaded\n" +
+	"from the eSCL ScannerCapabilities XML file, captured from the\n" +
+	"real device (i.e., by the 'mfp proxy' command), or from the JSON\n" +
+	"file, that describes inputs, resolutions, color modes and formats\n" +
+	"of the device being modeled, for example:\n" +
+	"\n" +
+	"  {\n" +
+	"    \"make-and-model\": \"Example Scanner\",\n" +
+	"    \"platen\": {\n" +
+	"      \"resolutions\": [150, 300, 600],\n" +
+	"      \"color-modes\": [\"Grayscale8\", \"RGB24\"],\n" +
+	"      \"document-formats\": [\"image/jpeg\"]\n" +
+	"    }\n" +
+	"  }\n" +
+	"\n" +
+	"With the --dnssd option, device is registered via DNS-SD, so\n" +
+	"it becomes visible to the network scanning clients.\n" +
+	"\n" +
+	"To mimic the locked-down enterprise devices, emulator may require\n" +
+	"TLS (--tls) and HTTP authentication (--user). With --tls, both\n" +
+	"plain and TLS connections are accepted on the same port, but\n" +
+	"eSCL requests over the plain connections are rejected. The\n" +
+	"self-signed certificate is generated on startup.\n" +
+	"\n" +
+	"With --stored-jobs, emulator lists jobs in response to the\n" +
+	"GET ScanJobs request, and keeps documents of recent jobs, so\n" +
+	"they can be retrieved again after job completion.\n" +
+	"\n" +
+	"With --compress, large XML responses (i.e., ScannerCapabilities)\n" +
+	"are gzip-compressed for clients that accept it.\n" +
+	"\n" +
+	"With --count, emulator presents a fleet of devices, served\n" +
+	"at /eSCL1, /eSCL2 and so on, each with its own UUID and DNS-SD\n" +
+	"instance name, so discovery and multi-device setups can be\n" +
+	"tested with the single emulator process.\n" +
+	"\n" +
+	"On multi-homed hosts, --interface restricts the emulator to\n" +
+	"the single network interface. In managed networks, --dscp\n" +
+	"sets the QoS marking of the emulator's traffic.\n" +
+	"\n" +
+	"The emulator runs until termination signal is received.\n"
+
+// Command is the 'emulate' command description
+var Command = argv.Command{
+	Name:        "emulate",
+	Help:        "Run virtual device emulator",
+	Description: description,
+	Options: []argv.Option{
+		argv.Option{
+			Name: "--escl",
+			Help: "Emulate eSCL scanner",
+		},
+		argv.Option{
+			Name:    "-p",
+			Aliases: []string{"--port"},
+			HelpArg: "port",
+			Help: fmt.Sprintf("TCP port. Default: %d",
+				DefaultTCPPort),
+			Validate: argv.ValidateUint16,
+		},
+		argv.Option{
+			Name:     "--caps",
+			Help:     "load scanner capabilities from XML or JSON file",
+			HelpArg:  "file",
+			Validate: argv.ValidateAny,
+			Complete: argv.CompleteOSPath,
+		},
+		argv.Option{
+			Name: "--camera",
+			Help: "Add document camera to built-in capabilities",
+		},
+		argv.Option{
+			Name:     "--count",
+			HelpArg:  "1...256",
+			Help:     "Count of emulated devices. Default: 1",
+			Validate: argv.ValidateUintRange(10, 1, 256),
+		},
+		argv.Option{
+			Name: "--dnssd",
+			Help: "Register device via DNS-SD",
+		},
+		argv.Option{
+			Name: "--tls",
+			Help: "Require TLS",
+		},
+		argv.Option{
+			Name: "--cors",
+			Help: "Allow cross-origin requests from browsers",
+		},
+		argv.Option{
+			Name:    "--cors-origin",
+			HelpArg: "origin",
+			Help: "Allow credentialed cross-origin requests " +
+				"from origin. May be repeated. Implies --cors",
+		},
+		argv.Option{
+			Name: "--stored-jobs",
+			Help: "Enable the stored jobs eSCL extension",
+		},
+		argv.Option{
+			Name: "--compress",
+			Help: "Compress XML responses with gzip",
+		},
+		argv.Option{
+			Name:     "--user",
+			HelpArg:  "user:password",
+			Help:     "Require HTTP authentication. May be repeated",
+			Validate: validateUser,
+		},
+		argv.Option{
+			Name:     "--auth",
+			HelpArg:  "scheme",
+			Help:     "HTTP authentication scheme. Default: basic",
+			Validate: argv.ValidateStrings(authSchemes),
+			Complete: argv.CompleteStrings(authSchemes),
+		},
+		argv.Option{
+			Name:     "--interface",
+			HelpArg:  "name",
+			Help:     "Accept connections only via this interface",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--dscp",
+			HelpArg:  "0...63",
+			Help:     "DSCP marking of the outgoing traffic",
+			Validate: argv.ValidateUintRange(10, 0, 63),
+		},
+		argv.Option{
+			Name:    "-n",
+			Aliases: []string{"--name"},
+			HelpArg: "name",
+			Help: fmt.Sprintf("DNS-SD instance name. Default: %q",
+				DefaultName),
+			Validate: argv.ValidateAny,
+		},
+		argv.HelpOption,
+	},
+	Handler: cmdEmulateHandler,
+}
+
+// cmdEmulateHandler is the top-level handler for the 'emulate' command.
+func cmdEmulateHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	// Check options
+	if _, ok := inv.Get("--escl"); !ok {
+		return errors.New("no protocol specified (use --escl)")
+	}
+
+	port := DefaultTCPPort
+	if portname, ok := inv.Get("-p"); ok {
+		port, _ = strconv.Atoi(portname)
+	}
+
+	name := DefaultName
+	if s, ok := inv.Get("-n"); ok {
+		name = s
+	}
+
+	count := 1
+	if s, ok := inv.Get("--count"); ok {
+		count, _ = strconv.Atoi(s)
+	}
+
+	// Prepare scanner capabilities
+	_, camera := inv.Get("--camera")
+	caps := defaultCapabilities(camera)
+	if file, ok := inv.Get("--caps"); ok {
+		var err error
+		caps, err = loadCapabilities(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Create the eSCL server and admin pages
+	_, secure := inv.Get("--tls")
+	_, cors := inv.Get("--cors")
+	origins := inv.Values("--cors-origin")
+	cors = cors || len(origins) != 0
+	_, stored := inv.Get("--stored-jobs")
+	_, compress := inv.Get("--compress")
+
+	auth := newAuth(inv)
+	devices := newDevices(name, caps, count)
+
+	group := escl.NewAbstractServerGroup(ctx)
+	defer group.Close()
+
+	router := transport.NewRouter(ctx)
+	router.Mount("/metrics", router.Metrics())
+
+	for _, dev := range devices {
+		options := escl.AbstractServerOptions{
+			Scanner:     newScanner(dev.caps),
+			BasePath:    dev.basepath,
+			RequireTLS:  secure,
+			Auth:        auth,
+			AllowCORS:   cors,
+			CORSOrigins: origins,
+			StoredJobs:  stored,
+			CompressXML: compress,
+		}
+
+		esclServer, err := group.Add("", options)
+		if err != nil {
+			return err
+		}
+
+		router.Mount(dev.basepath, group)
+		router.Mount(dev.history, esclServer.JobHistoryHandler())
+	}
+
+	template := &http.Server{}
+	if secure {
+		tlsconf, err := transport.NewSelfSignedTLSConfig()
+		if err != nil {
+			return fmt.Errorf("TLS: %w", err)
+		}
+		template.TLSConfig = tlsconf
+	}
+
+	server := transport.NewServer(template, router)
+
+	sockopts := transport.SocketOptions{}
+	sockopts.Interface, _ = inv.Get("--interface")
+	if s, ok := inv.Get("--dscp"); ok {
+		sockopts.DSCP, _ = strconv.Atoi(s)
+	}
+
+	ln, err := sockopts.Listen(ctx, "tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+
+	// Register via DNS-SD
+	if _, ok := inv.Get("--dnssd"); ok {
+		for _, dev := range devices {
+			pub, err := newPublisher(ctx, dev.name, port,
+				dev.basepath, dev.caps, secure)
+			if err != nil {
+				ln.Close()
+				return fmt.Errorf("DNS-SD: %w", err)
+			}
+
+			defer pub.Close()
+		}
+	}
+
+	// Serve requests until termination signal
+	go func() {
+		<-ctx.Done()
+		log.Info(ctx, "Exiting...")
+		server.Close()
+	}()
+
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+
+	for _, dev := range devices {
+		log.Info(ctx, "eSCL scanner: %s://localhost:%d%s",
+			scheme, port, dev.basepath)
+		log.Info(ctx, "job history:  http://localhost:%d%s",
+			port, dev.history)
+	}
+	log.Info(ctx, "metrics:      http://localhost:%d/metrics", port)
+
+	if secure {
+		err = server.ServeAutoTLS(ln)
+	} else {
+		err = server.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+
+	return err
+}
+
+// newScanner creates a synthetic abstract.Scanner
+func newScanner(caps *abstract.ScannerCapabilities) abstract.Scanner {
+	return &abstract.VirtualScanner{
+		ScanCaps:    caps,
+		Resolution:  syntheticResolution,
+		PlatenImage: syntheticImage,
+		ADFImages: [][]byte{
+			syntheticImage,
+			syntheticImage,
+			syntheticImage,
+		},
+	}
+}
+
+// authSchemes lists names of the supported HTTP authentication schemes
+var authSchemes = []string{"basic", "digest"}
+
+// validateUser validates the --user option
+func validateUser(s string) error {
+	user, _, found := strings.Cut(s, ":")
+	if !found || user == "" {
+		return errors.New("must be user:password")
+	}
+	return nil
+}
+
+// newAuth returns transport.HTTPAuth, configured by the --user
+// and --auth options, or nil, if authentication is not required.
+func newAuth(inv *argv.Invocation) *transport.HTTPAuth {
+	users := make(map[string]string)
+	for _, s := range inv.Values("--user") {
+		user, password, _ := strings.Cut(s, ":")
+		users[user] = password
+	}
+
+	if len(users) == 0 {
+		return nil
+	}
+
+	scheme := transport.HTTPAuthBasic
+	if s, _ := inv.Get("--auth"); s == "digest" {
+		scheme = transport.HTTPAuthDigest
+	}
+
+	return transport.NewHTTPAuth(scheme, DefaultName, users)
+}
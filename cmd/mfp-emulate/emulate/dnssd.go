@@ -0,0 +1,178 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "emulate" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// DNS-SD registration
+
+package emulate
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/OpenPrinting/go-avahi"
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// publisher registers the emulated device via DNS-SD.
+type publisher struct {
+	clnt *avahi.Client     // Avahi client
+	egrp *avahi.EntryGroup // Entry group with our services
+}
+
+// newPublisher registers eSCL scanner with the specified instance
+// name, TCP port and eSCL base path via DNS-SD.
+//
+// If secure is true, scanner is registered as _uscans._tcp
+// (eSCL over TLS) service.
+func newPublisher(ctx context.Context, name string, port int,
+	basepath string, caps *abstract.ScannerCapabilities,
+	secure bool) (*publisher, error) {
+
+	clnt, err := avahi.NewClient(avahi.ClientLoopbackWorkarounds)
+	if err != nil {
+		return nil, err
+	}
+
+	egrp, err := avahi.NewEntryGroup(clnt)
+	if err != nil {
+		clnt.Close()
+		return nil, err
+	}
+
+	pub := &publisher{clnt: clnt, egrp: egrp}
+
+	svctype := "_uscan._tcp"
+	if secure {
+		svctype = "_uscans._tcp"
+	}
+
+	svc := &avahi.EntryGroupService{
+		IfIdx:        avahi.IfIndexUnspec,
+		Proto:        avahi.ProtocolUnspec,
+		InstanceName: name,
+		SvcType:      svctype,
+		Port:         port,
+		Txt:          publisherTxtESCL(caps, basepath),
+	}
+
+	err = egrp.AddService(svc, 0)
+	if err == nil {
+		err = egrp.Commit()
+	}
+
+	if err != nil {
+		pub.Close()
+		return nil, err
+	}
+
+	// Wait until registration is established
+	for {
+		select {
+		case <-ctx.Done():
+			pub.Close()
+			return nil, ctx.Err()
+
+		case evnt := <-egrp.Chan():
+			switch evnt.State {
+			case avahi.EntryGroupStateEstablished:
+				log.Info(ctx, "DNS-SD: %q registered", name)
+				return pub, nil
+
+			case avahi.EntryGroupStateCollision:
+				pub.Close()
+				return nil, errors.New("name collision")
+
+			case avahi.EntryGroupStateFailure:
+				pub.Close()
+				return nil, evnt.Err
+			}
+		}
+	}
+}
+
+// Close removes DNS-SD registration.
+func (pub *publisher) Close() {
+	pub.egrp.Close()
+	pub.clnt.Close()
+}
+
+// publisherColorModes maps abstract.ColorMode into the "cs"
+// TXT record values.
+var publisherColorModes = []struct {
+	mode abstract.ColorMode
+	name string
+}{
+	{abstract.ColorModeColor, "color"},
+	{abstract.ColorModeMono, "grayscale"},
+	{abstract.ColorModeBinary, "binary"},
+}
+
+// publisherTxtESCL returns TXT record for the _uscan._tcp service.
+func publisherTxtESCL(caps *abstract.ScannerCapabilities,
+	basepath string) []string {
+
+	txt := []string{
+		"txtvers=1",
+		"ty=" + caps.MakeAndModel,
+		"rs=" + strings.TrimPrefix(basepath, "/"),
+		"vers=2.63",
+		"uuid=" + caps.UUID.String(),
+		"pdl=" + strings.Join(caps.DocumentFormats, ","),
+	}
+
+	// Collect color modes and input sources
+	var colorModes []string
+	var sources []string
+	duplex := "F"
+
+	inputs := []struct {
+		caps   *abstract.InputCapabilities
+		source string
+	}{
+		{caps.Platen, "platen"},
+		{caps.ADFSimplex, "adf"},
+		{caps.ADFDuplex, "adf"},
+		{caps.Camera, "camera"},
+	}
+
+	for _, input := range inputs {
+		if input.caps == nil {
+			continue
+		}
+
+		if !slices.Contains(sources, input.source) {
+			sources = append(sources, input.source)
+		}
+
+		for _, prof := range input.caps.Profiles {
+			for _, cm := range publisherColorModes {
+				if prof.ColorModes.Contains(cm.mode) &&
+					!slices.Contains(colorModes, cm.name) {
+					colorModes = append(colorModes, cm.name)
+				}
+			}
+		}
+	}
+
+	if caps.ADFDuplex != nil {
+		duplex = "T"
+	}
+
+	if len(colorModes) != 0 {
+		txt = append(txt, "cs="+strings.Join(colorModes, ","))
+	}
+
+	if len(sources) != 0 {
+		txt = append(txt, "is="+strings.Join(sources, ","))
+	}
+
+	txt = append(txt, "duplex="+duplex)
+
+	return txt
+}
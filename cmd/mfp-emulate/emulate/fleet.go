@@ -0,0 +1,65 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "emulate" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Fleet of emulated devices
+
+package emulate
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+)
+
+// device describes the single emulated device.
+type device struct {
+	name     string                        // DNS-SD instance name
+	basepath string                        // eSCL base path
+	history  string                        // Job history path
+	caps     *abstract.ScannerCapabilities // Scanner capabilities
+}
+
+// newDevices returns descriptions of count emulated devices.
+//
+// The single device uses the name and capabilities as is. For the
+// fleet of devices, each device gets its own name, base path, UUID
+// and serial number, derived from the originals, so devices are
+// distinguishable by clients and discovery.
+func newDevices(name string, caps *abstract.ScannerCapabilities,
+	count int) []device {
+
+	if count == 1 {
+		return []device{{
+			name:     name,
+			basepath: "/eSCL",
+			history:  "/history",
+			caps:     caps,
+		}}
+	}
+
+	devices := make([]device, count)
+	for i := range devices {
+		n := i + 1
+		basepath := fmt.Sprintf("/eSCL%d", n)
+
+		devcaps := caps.Clone()
+		devcaps.UUID = uuid.SHA1(caps.UUID, strconv.Itoa(n))
+		if devcaps.SerialNumber != "" {
+			devcaps.SerialNumber += fmt.Sprintf("-%d", n)
+		}
+
+		devices[i] = device{
+			name:     fmt.Sprintf("%s #%d", name, n),
+			basepath: basepath,
+			history:  "/history" + basepath,
+			caps:     devcaps,
+		}
+	}
+
+	return devices
+}
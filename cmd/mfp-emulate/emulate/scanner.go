@@ -0,0 +1,106 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "emulate" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Synthetic scanner
+
+package emulate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// syntheticImage is the A4 image, returned by the synthetic scanner.
+// syntheticResolution is its resolution.
+var (
+	syntheticImage      = testutils.Images.PNG5100x7016
+	syntheticResolution = abstract.Resolution{
+		XResolution: 600,
+		YResolution: 600,
+	}
+)
+
+// defaultCapabilities returns the built-in scanner capabilities.
+// If camera is true, the document camera input is added.
+func defaultCapabilities(camera bool) *abstract.ScannerCapabilities {
+	b := escl.NewCapabilitiesBuilder().
+		WithUUID(uuid.Must(uuid.Parse(
+			"5a1c4dc6-3f0e-4b8e-9c55-1e0b3ad0e1f7"))).
+		WithMakeAndModel("OpenPrinting MFP Emulator").
+		WithSerialNumber("OP-EMU-0001").
+		WithManufacturer("OpenPrinting").
+		WithCompressionFactor(escl.Range{Min: 2, Normal: 5, Max: 10}).
+		WithBrightness(escl.Range{Min: -100, Normal: 0, Max: 100}).
+		WithContrast(escl.Range{Min: -100, Normal: 0, Max: 100})
+
+	inputs := []func() *escl.CapabilitiesBuilder{
+		b.WithPlaten,
+		b.WithADFSimplex,
+		b.WithADFDuplex,
+	}
+
+	if camera {
+		inputs = append(inputs, b.WithCamera)
+	}
+
+	for _, input := range inputs {
+		input().
+			WithIntents(escl.Document, escl.TextAndGraphic,
+				escl.Photo, escl.Preview).
+			WithResolutions(75, 150, 300, 600).
+			WithColorModes(escl.BlackAndWhite1, escl.Grayscale8,
+				escl.RGB24).
+			WithBinaryRenderings(escl.Halftone, escl.Threshold).
+			WithDocumentFormats("image/jpeg", "application/pdf")
+	}
+
+	caps, err := b.WithFeederCapacity(50).Build()
+	if err != nil {
+		// Built-in capabilities must be valid
+		panic(err)
+	}
+
+	return caps.ToAbstract()
+}
+
+// loadCapabilities loads scanner capabilities from file. The file
+// may contain either eSCL ScannerCapabilities XML or the declarative
+// JSON description (see escl.CapabilitiesConfig).
+func loadCapabilities(file string) (*abstract.ScannerCapabilities, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var caps *escl.ScannerCapabilities
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var cfg *escl.CapabilitiesConfig
+		cfg, err = escl.DecodeCapabilitiesConfig(data)
+		if err == nil {
+			caps, err = cfg.Build()
+		}
+	} else {
+		var xml xmldoc.Element
+		xml, err = xmldoc.DecodeNormalized(escl.NsMap,
+			escl.NsNormalize, bytes.NewReader(data))
+		if err == nil {
+			caps, err = escl.DecodeScannerCapabilities(xml)
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return caps.ToAbstract(), nil
+}
@@ -0,0 +1,24 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "escl" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package escl
+
+import "github.com/OpenPrinting/go-mfp/argv"
+
+// Command is the 'escl' command description
+var Command = argv.Command{
+	Name: "escl",
+	Help: "eSCL scanner tools",
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	SubCommands: []argv.Command{
+		cmdConformance,
+		argv.HelpCommand,
+	},
+}
@@ -0,0 +1,80 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "escl" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "conformance" command.
+
+package escl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/proto/escl/conformance"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// cmdConformance defines the "conformance" sub-command
+var cmdConformance = argv.Command{
+	Name: "conformance",
+	Help: "Run eSCL conformance test suite against the scanner",
+	Description: "" +
+		"Runs a battery of checks (capabilities consistency, HTTP\n" +
+		"status codes, ADF behavior, handling of invalid settings)\n" +
+		"and prints the pass/fail report.\n" +
+		"\n" +
+		"Checks that perform the actual scanning are disabled by\n" +
+		"default. Use --scan to enable them; the document must be\n" +
+		"placed on the platen.\n" +
+		"\n" +
+		"Exit status is non-zero, if any check fails.\n",
+	Handler: cmdConformanceHandler,
+	Options: []argv.Option{
+		argv.Option{
+			Name: "--scan",
+			Help: "Enable checks that perform scanning",
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "URL",
+			Help:     "scanner URL (i.e., http://host/eSCL)",
+			Validate: transport.ValidateURL,
+		},
+	},
+}
+
+// cmdConformanceHandler is the "conformance" command handler
+func cmdConformanceHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	param, _ := inv.Get("URL")
+	u := transport.MustParseURL(param)
+
+	_, scan := inv.Get("--scan")
+
+	report := conformance.Run(ctx, u, conformance.Options{Scan: scan})
+
+	// Format output
+	pager := env.NewPager()
+	report.Format(pager)
+
+	err := pager.Display()
+	if err != nil {
+		return err
+	}
+
+	if !report.Passed() {
+		_, failed, _ := report.Counts()
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+// MFP          - Miulti-Function Printers and scanners toolkit
+// cmd/mfp-escl - eSCL scanner tools
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The main() function.
+
+package main
+
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-escl/escl"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
+
+// main function for the mfp-escl command
+func main() {
+	cmdopt.Root(escl.Command).Main(nil)
+}
@@ -0,0 +1,62 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "fax" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package fax
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// description is printed as a command description text
+const description = "" +
+	"This command sends faxes, using the IPP FaxOut service\n" +
+	"of the network MFP.\n" +
+	"\n" +
+	"If fax URL is not specified with -u, the FaxOut unit is located\n" +
+	"using the device discovery. If there are multiple fax devices\n" +
+	"in the network, -u must be used to choose one. Use the list\n" +
+	"sub-command to see all available fax devices.\n"
+
+// Command is the 'fax' command description
+var Command = argv.Command{
+	Name:        "fax",
+	Help:        "Send faxes via IPP FaxOut",
+	Description: description,
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	SubCommands: []argv.Command{
+		cmdList,
+		cmdSend,
+		cmdStatus,
+		argv.HelpCommand,
+	},
+}
+
+// optURL is the -u option, common for sub-commands
+var optURL = argv.Option{
+	Name:     "-u",
+	Aliases:  []string{"--url"},
+	HelpArg:  "URL",
+	Help:     "FaxOut URL (i.e., ipp://host/ipp/faxout)",
+	Validate: transport.ValidateURL,
+}
+
+// faxURL returns the FaxOut URL, either specified with the
+// -u option, or located using the device discovery.
+func faxURL(ctx context.Context, inv *argv.Invocation) (*url.URL, error) {
+	if param, ok := inv.Get("-u"); ok {
+		return transport.MustParseURL(param), nil
+	}
+
+	return faxDiscover(ctx)
+}
@@ -0,0 +1,114 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "fax" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// FaxOut unit discovery
+
+package fax
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/discovery"
+	"github.com/OpenPrinting/go-mfp/discovery/dnssd"
+	"github.com/OpenPrinting/go-mfp/discovery/wsdd"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// faxUnit is the discovered FaxOut unit
+type faxUnit struct {
+	name      string   // Device name, for messages
+	makeModel string   // Device make and model
+	location  string   // Device location
+	url       *url.URL // FaxOut URL
+}
+
+// faxDiscover locates the IPP FaxOut unit using the device
+// discovery and returns its URL.
+//
+// It fails, if there are no FaxOut units or if there are many.
+func faxDiscover(ctx context.Context) (*url.URL, error) {
+	log.Info(ctx, "searching for fax devices")
+
+	units, err := faxDiscoverUnits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(units) {
+	case 0:
+		return nil, errors.New("no fax devices found, use -u to specify")
+
+	case 1:
+		log.Info(ctx, "using %s: %s", units[0].name, units[0].url)
+		return units[0].url, nil
+	}
+
+	msg := &strings.Builder{}
+	msg.WriteString("multiple fax devices found, use -u to choose one:")
+	for _, un := range units {
+		msg.WriteString("\n  " + un.name + ": " + un.url.String())
+	}
+
+	return nil, errors.New(msg.String())
+}
+
+// faxDiscoverUnits returns all discovered IPP FaxOut units
+func faxDiscoverUnits(ctx context.Context) ([]faxUnit, error) {
+	clnt := discovery.NewClient(ctx)
+	defer clnt.Close()
+
+	backend, err := dnssd.NewBackend(ctx, "", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer backend.Close()
+	clnt.AddBackend(backend)
+
+	backend, err = wsdd.NewBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer backend.Close()
+	clnt.AddBackend(backend)
+
+	devices, err := clnt.GetDevices(ctx, discovery.ModeNormal)
+	if err != nil {
+		return nil, err
+	}
+
+	var units []faxUnit
+	for _, dev := range devices {
+		name := dev.MakeModel
+		if dev.DNSSDName != "" {
+			name = dev.DNSSDName
+		}
+
+		for _, un := range dev.FaxoutUnits {
+			if un.Proto != discovery.ServiceIPP ||
+				len(un.Endpoints) == 0 {
+				continue
+			}
+
+			u, err := transport.ParseURL(un.Endpoints[0])
+			if err == nil {
+				units = append(units, faxUnit{
+					name:      name,
+					makeModel: dev.MakeModel,
+					location:  dev.Location,
+					url:       u,
+				})
+			}
+		}
+	}
+
+	return units, nil
+}
@@ -0,0 +1,65 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "fax" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "list" command.
+
+package fax
+
+import (
+	"context"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// cmdList defines the "list" sub-command
+var cmdList = argv.Command{
+	Name: "list",
+	Help: "List fax devices",
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	Handler: cmdListHandler,
+}
+
+// cmdListHandler is the "list" command handler
+func cmdListHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	log.Info(ctx, "searching for fax devices")
+
+	units, err := faxDiscoverUnits(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(units) == 0 {
+		log.Info(ctx, "no fax devices found")
+		return nil
+	}
+
+	pager := env.NewPager()
+
+	for i, un := range units {
+		if i != 0 {
+			pager.Printf("")
+		}
+
+		pager.Printf("%s:", un.name)
+		if un.makeModel != "" && un.makeModel != un.name {
+			pager.Printf("  Model:    %s", un.makeModel)
+		}
+		if un.location != "" {
+			pager.Printf("  Location: %s", un.location)
+		}
+		pager.Printf("  URL:      %s", un.url)
+	}
+
+	return pager.Display()
+}
@@ -0,0 +1,259 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "fax" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "send" command.
+
+package fax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+)
+
+// sendDefaultFormat is the document format, used when format
+// cannot be guessed from the file name.
+const sendDefaultFormat = "application/octet-stream"
+
+// cmdSend defines the "send" sub-command
+var cmdSend = argv.Command{
+	Name: "send",
+	Help: "Send document as fax",
+	Description: "" +
+		"Phone number may contain digits, the leading '+' and the\n" +
+		"visual separators (spaces, '-', '.', '(' and ')').\n" +
+		"\n" +
+		"If any of the --cover-xxx options is specified, the cover\n" +
+		"sheet is generated by the device and sent before the document.\n" +
+		"\n" +
+		"Unless --retries and --retry-xxx options are specified,\n" +
+		"the device default retry settings are used.\n" +
+		"\n" +
+		"After submission, the command waits until fax transmission\n" +
+		"is completed or failed, unless --no-wait is specified.\n" +
+		"If interrupted with Ctrl-C while waiting, the fax job\n" +
+		"is canceled.\n",
+	Options: []argv.Option{
+		optURL,
+		argv.Option{
+			Name:     "--format",
+			HelpArg:  "MIME",
+			Help:     "Document format. Default: guessed by file name",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--cover-from",
+			HelpArg:  "name",
+			Help:     "Cover sheet: sender name",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--cover-to",
+			HelpArg:  "name",
+			Help:     "Cover sheet: recipient name",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--cover-organization",
+			HelpArg:  "name",
+			Help:     "Cover sheet: sender organization",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--cover-subject",
+			HelpArg:  "text",
+			Help:     "Cover sheet: subject",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--cover-message",
+			HelpArg:  "text",
+			Help:     "Cover sheet: message",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--retries",
+			HelpArg:  "count",
+			Help:     "Number of retries, if line is busy or no answer",
+			Validate: argv.ValidateUintRange(10, 1, math.MaxInt32),
+		},
+		argv.Option{
+			Name:     "--retry-interval",
+			HelpArg:  "seconds",
+			Help:     "Interval between retries",
+			Validate: argv.ValidateUintRange(10, 1, math.MaxInt32),
+		},
+		argv.Option{
+			Name:     "--retry-timeout",
+			HelpArg:  "seconds",
+			Help:     "Timeout of each transmission attempt",
+			Validate: argv.ValidateUintRange(10, 1, math.MaxInt32),
+		},
+		argv.Option{
+			Name: "--confirmation",
+			Help: "Print the confirmation sheet after transmission",
+		},
+		argv.Option{
+			Name: "--no-wait",
+			Help: "Don't wait for fax transmission",
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "NUMBER",
+			Help:     "recipient phone number",
+			Validate: sendNumberValidate,
+		},
+		{
+			Name:     "FILE",
+			Help:     "document to send",
+			Complete: argv.CompleteOSPath,
+		},
+	},
+	Handler: cmdSendHandler,
+}
+
+// cmdSendHandler is the "send" command handler
+func cmdSendHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	// Parse parameters
+	number, _ := inv.Get("NUMBER")
+	file, _ := inv.Get("FILE")
+
+	format, ok := inv.Get("--format")
+	if !ok {
+		format = mime.TypeByExtension(filepath.Ext(file))
+		if format == "" {
+			format = sendDefaultFormat
+		}
+	}
+
+	fp, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	// Locate the FaxOut unit
+	u, err := faxURL(ctx, inv)
+	if err != nil {
+		return err
+	}
+
+	// Submit the job
+	attrs := &ipp.JobAttributes{
+		CoverSheetInfo: sendCoverSheet(inv),
+		DestinationUris: []ipp.DestinationURI{
+			{DestinationURI: sendNumberURI(number)},
+		},
+	}
+
+	sendRetrySettings(inv, attrs)
+
+	if _, ok := inv.Get("--confirmation"); ok {
+		attrs.ConfirmationSheetPrint = true
+	}
+
+	doc := ipp.Document{
+		Name:   filepath.Base(file),
+		Format: format,
+		Body:   fp,
+	}
+
+	clnt := ipp.NewClient(u, nil)
+	job, err := clnt.SendFax(ctx, filepath.Base(file), attrs, doc)
+	if err != nil {
+		return err
+	}
+
+	log.Info(ctx, "job %d: sending fax to %s", job.JobID, number)
+
+	// Wait for completion
+	if _, noWait := inv.Get("--no-wait"); noWait {
+		return nil
+	}
+
+	return statusWait(ctx, clnt, job.JobID)
+}
+
+// sendCoverSheet returns the cover sheet information.
+// If no --cover-xxx options are specified, the zero
+// value is returned, so no cover sheet is requested.
+func sendCoverSheet(inv *argv.Invocation) ipp.CoverSheetInfo {
+	var cover ipp.CoverSheetInfo
+
+	cover.FromName, _ = inv.Get("--cover-from")
+	cover.ToName, _ = inv.Get("--cover-to")
+	cover.OrganizationName, _ = inv.Get("--cover-organization")
+	cover.Subject, _ = inv.Get("--cover-subject")
+	cover.Message, _ = inv.Get("--cover-message")
+
+	return cover
+}
+
+// sendRetrySettings applies the --retry-xxx options to the attrs.
+// Options not specified are left to the device defaults.
+func sendRetrySettings(inv *argv.Invocation, attrs *ipp.JobAttributes) {
+	if s, ok := inv.Get("--retries"); ok {
+		attrs.NumberOfRetries, _ = strconv.Atoi(s)
+	}
+
+	if s, ok := inv.Get("--retry-interval"); ok {
+		attrs.RetryInterval, _ = strconv.Atoi(s)
+	}
+
+	if s, ok := inv.Get("--retry-timeout"); ok {
+		attrs.RetryTimeOut, _ = strconv.Atoi(s)
+	}
+}
+
+// sendNumberValidate validates the phone number.
+func sendNumberValidate(s string) error {
+	digits := 0
+	for i, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '+' && i == 0:
+		case strings.ContainsRune(" -.()", c):
+		default:
+			return fmt.Errorf("invalid character %q in phone number", c)
+		}
+	}
+
+	if digits == 0 {
+		return errors.New("phone number has no digits")
+	}
+
+	return nil
+}
+
+// sendNumberURI converts the phone number into the "tel:" URI,
+// dropping visual separators.
+func sendNumberURI(number string) string {
+	uri := "tel:"
+	for _, c := range number {
+		if c == '+' || (c >= '0' && c <= '9') {
+			uri += string(c)
+		}
+	}
+
+	return uri
+}
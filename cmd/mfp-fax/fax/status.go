@@ -0,0 +1,161 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "fax" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "status" command.
+
+package fax
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+)
+
+const (
+	// statusPollInterval is the interval between job state queries
+	statusPollInterval = 2 * time.Second
+
+	// statusCancelTimeout is the timeout for the job cancel request
+	statusCancelTimeout = 5 * time.Second
+)
+
+// cmdStatus defines the "status" sub-command
+var cmdStatus = argv.Command{
+	Name: "status",
+	Help: "Query fax job status",
+	Options: []argv.Option{
+		optURL,
+		argv.Option{
+			Name: "--wait",
+			Help: "Wait until fax transmission is completed or failed",
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "JOB-ID",
+			Help:     "fax job ID, as reported by the send command",
+			Validate: argv.ValidateUintRange(10, 1, math.MaxInt32),
+		},
+	},
+	Handler: cmdStatusHandler,
+}
+
+// cmdStatusHandler is the "status" command handler
+func cmdStatusHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	param, _ := inv.Get("JOB-ID")
+	jobID, _ := strconv.Atoi(param)
+
+	u, err := faxURL(ctx, inv)
+	if err != nil {
+		return err
+	}
+
+	clnt := ipp.NewClient(u, nil)
+	job, err := clnt.GetJobAttributes(ctx, jobID, ipp.FaxStatusAttrs)
+	if err != nil {
+		return err
+	}
+
+	job.JobID = jobID
+
+	if _, wait := inv.Get("--wait"); wait {
+		return statusWait(ctx, clnt, jobID)
+	}
+
+	log.Info(ctx, "job %d: %s", job.JobID, statusDescribe(job))
+	return nil
+}
+
+// statusWait waits until fax transmission is completed or failed,
+// logging the job state changes.
+//
+// If ctx is canceled, the job is canceled at the device side.
+func statusWait(ctx context.Context, clnt *ipp.Client, jobID int) error {
+	job, err := clnt.WaitFax(ctx, jobID, statusPollInterval,
+		func(job *ipp.JobStatus) {
+			log.Info(ctx, "job %d: %s", job.JobID,
+				statusDescribe(job))
+		})
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		statusCancel(ctx, clnt, jobID)
+		return err
+	case err != nil:
+		return err
+	case !job.FaxSucceeded():
+		return fmt.Errorf("fax failed: %s", statusDescribe(job))
+	}
+
+	return nil
+}
+
+// statusCancel cancels the fax job.
+//
+// It is called when ctx is already canceled, so request
+// is performed with the separate context with timeout.
+func statusCancel(ctx context.Context, clnt *ipp.Client, jobID int) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx),
+		statusCancelTimeout)
+	defer cancel()
+
+	err := clnt.CancelJob(ctx, jobID)
+	if err != nil {
+		log.Error(ctx, "job cancel: %s", err)
+		return
+	}
+
+	log.Info(ctx, "job canceled")
+}
+
+// statusDescribe returns human-readable description
+// of the job state.
+func statusDescribe(job *ipp.JobStatus) string {
+	s, ok := statusNames[job.JobState]
+	if !ok {
+		s = fmt.Sprintf("state %d", job.JobState)
+	}
+
+	var reasons []string
+	for _, r := range job.JobStateReasons {
+		if r != ipp.KwJobStateReasonsNone {
+			reasons = append(reasons, string(r))
+		}
+	}
+
+	if len(reasons) != 0 {
+		s += " (" + strings.Join(reasons, ", ") + ")"
+	}
+
+	if job.JobStateMessage != "" {
+		s += ": " + job.JobStateMessage
+	}
+
+	return s
+}
+
+// statusNames contains names of the job states
+var statusNames = map[int]string{
+	ipp.JobStatePending:           "pending",
+	ipp.JobStatePendingHeld:       "held",
+	ipp.JobStateProcessing:        "sending",
+	ipp.JobStateProcessingStopped: "stopped",
+	ipp.JobStateCanceled:          "canceled",
+	ipp.JobStateAborted:           "aborted",
+	ipp.JobStateCompleted:         "completed",
+}
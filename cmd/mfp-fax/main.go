@@ -0,0 +1,19 @@
+// MFP         - Miulti-Function Printers and scanners toolkit
+// cmd/mfp-fax - Fax sending utility
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The main() function.
+
+package main
+
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-fax/fax"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
+
+// main function for the mfp-fax command
+func main() {
+	cmdopt.Root(fax.Command).Main(nil)
+}
@@ -0,0 +1,172 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "info" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package info
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/internal/output"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// description is printed as a command description text
+const description = "" +
+	"This command queries the device identity over IPP and WSD\n" +
+	"and prints consolidated manufacturer, model, firmware, serial\n" +
+	"number and admin URL information:\n" +
+	"\n" +
+	"  IPP  printer description attributes, including the\n" +
+	"       IEEE 1284 device ID\n" +
+	"  WSD  ThisDevice and ThisModel metadata (WS-Transfer Get)\n" +
+	"\n" +
+	"By default, the IPP endpoint is derived from the DEVICE\n" +
+	"address (ipp://DEVICE/ipp/print) and the WSD device is searched\n" +
+	"by the WS-Discovery Probe. Use --ipp and --wsd to specify\n" +
+	"endpoints explicitly (for WSD, use the device XAddr URL).\n" +
+	"\n" +
+	"If protocols report different values, all of them are shown.\n"
+
+// Command is the 'info' command description
+var Command = argv.Command{
+	Name:        "info",
+	Help:        "Device identity information",
+	Description: description,
+	Options: []argv.Option{
+		argv.Option{
+			Name:     "--ipp",
+			HelpArg:  "URL",
+			Help:     "IPP printer URL",
+			Validate: transport.ValidateURL,
+		},
+		argv.Option{
+			Name:     "--wsd",
+			HelpArg:  "URL",
+			Help:     "WSD device XAddr URL",
+			Validate: transport.ValidateURL,
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name: "DEVICE",
+			Help: "device address, host or host:port",
+		},
+	},
+	Handler: cmdInfoHandler,
+}
+
+// cmdInfoHandler is the handler for the 'info' command.
+func cmdInfoHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	// Prepare endpoints
+	device, _ := inv.Get("DEVICE")
+	if u, err := transport.ParseURL(device); err == nil &&
+		strings.Contains(device, "://") {
+		device = u.Host
+	}
+
+	ippURL := "ipp://" + device + "/ipp/print"
+	if s, ok := inv.Get("--ipp"); ok {
+		ippURL = s
+	}
+
+	wsdHost := device
+	if host, _, err := net.SplitHostPort(device); err == nil {
+		wsdHost = host
+	}
+
+	wsdXAddr, _ := inv.Get("--wsd")
+
+	// Query all protocols in parallel
+	infos := []*protoInfo{nil, nil}
+	var wait sync.WaitGroup
+
+	wait.Add(2)
+	go func() {
+		infos[0] = queryIPP(ctx, transport.MustParseURL(ippURL))
+		wait.Done()
+	}()
+	go func() {
+		if wsdXAddr != "" {
+			infos[1] = queryWSD(ctx, wsdHost,
+				transport.MustParseURL(wsdXAddr))
+		} else {
+			infos[1] = queryWSD(ctx, wsdHost, nil)
+		}
+		wait.Done()
+	}()
+
+	wait.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	responding := false
+	for _, pi := range infos {
+		if pi.ok() {
+			responding = true
+		} else {
+			log.Debug(ctx, "%s: %s", pi.proto, pi.err)
+		}
+	}
+
+	if !responding {
+		return errors.New(device + ": device is not responding")
+	}
+
+	// Merge and format output
+	rec := infoRecordMake(device, infos)
+
+	pager := env.NewPager()
+	err := output.Render(pager, output.OptionGet(inv), rec,
+		func(io.Writer) { infoFormat(pager, rec) })
+
+	if err != nil {
+		return err
+	}
+
+	return pager.Display()
+}
+
+// infoFormat pretty-prints the consolidated identity information
+func infoFormat(pager *env.Pager, rec infoRecord) {
+	pager.Printf("%-14s %s", "Device:", rec.Device)
+
+	for _, field := range rec.Fields {
+		name := field.Field + ":"
+		for _, val := range field.Values {
+			pager.Printf("%-14s %s (%s)", name, val.Value,
+				strings.Join(val.Sources, ", "))
+			name = ""
+		}
+	}
+
+	pager.Printf("")
+	pager.Printf("Sources:")
+	for _, proto := range rec.Protocols {
+		if proto.Error != "" {
+			pager.Printf("  %-4s error: %s", proto.Protocol,
+				proto.Error)
+		} else {
+			pager.Printf("  %-4s %s", proto.Protocol, proto.Endpoint)
+		}
+	}
+}
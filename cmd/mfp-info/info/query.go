@@ -0,0 +1,228 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "info" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Per-protocol identity queries
+
+package info
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/discovery/wsdd"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// Identity fields, in the output order
+const (
+	fieldName = iota
+	fieldManufacturer
+	fieldModel
+	fieldModelNumber
+	fieldFirmware
+	fieldSerial
+	fieldUUID
+	fieldAdminURL
+	fieldCount
+)
+
+// fieldNames contains field names, indexed by field number
+var fieldNames = [fieldCount]string{
+	fieldName:         "Name",
+	fieldManufacturer: "Manufacturer",
+	fieldModel:        "Model",
+	fieldModelNumber:  "Model number",
+	fieldFirmware:     "Firmware",
+	fieldSerial:       "Serial number",
+	fieldUUID:         "UUID",
+	fieldAdminURL:     "Admin URL",
+}
+
+// protoInfo is the device identity, reported by the single protocol.
+type protoInfo struct {
+	proto    string             // "IPP" or "WSD"
+	endpoint string             // Endpoint URL
+	fields   [fieldCount]string // Identity fields
+	err      error              // Query error
+}
+
+// ok returns true if protocol query was successful.
+func (pi *protoInfo) ok() bool {
+	return pi.err == nil
+}
+
+// queryTransport creates the HTTP transport for device queries.
+// Device must be identified even if its firmware is broken, so malformed responses are salvaged.
+func queryTransport() *transport.Transport {
+	tr := transport.NewTransport(nil)
+	tr.Tolerant = true
+	return tr
+}
+
+// queryIPP queries device identity via IPP.
+func queryIPP(ctx context.Context, u *url.URL) *protoInfo {
+	pi := &protoInfo{proto: "IPP", endpoint: u.String()}
+
+	clnt := ipp.NewClient(u, queryTransport())
+	attrs, err := clnt.GetPrinterAttributes(ctx, []string{
+		"printer-description",
+		"printer-device-id",
+		"printer-firmware-string-version",
+		"printer-uuid",
+		"device-uuid",
+	})
+
+	if err != nil {
+		pi.err = err
+		return pi
+	}
+
+	devid := ""
+	if attr, found := attrs.RawAttrs().Get("printer-device-id"); found &&
+		len(attr.Values) != 0 {
+		devid = attr.Values[0].V.String()
+	}
+
+	pi.fields[fieldName] = attrs.PrinterInfo
+	if pi.fields[fieldName] == "" {
+		pi.fields[fieldName] = attrs.PrinterName
+	}
+
+	pi.fields[fieldManufacturer] = ieee1284Field(devid,
+		"MFG", "MANUFACTURER")
+	pi.fields[fieldModel] = attrs.PrinterMakeAndModel
+	pi.fields[fieldFirmware] = strings.Join(
+		attrs.PrinterFirmwareStringVersion, ", ")
+	pi.fields[fieldSerial] = ieee1284Field(devid,
+		"SN", "SERN", "SERIALNUMBER")
+
+	pi.fields[fieldUUID] = attrs.PrinterUUID
+	if pi.fields[fieldUUID] == "" {
+		pi.fields[fieldUUID] = attrs.DeviceUUID
+	}
+
+	pi.fields[fieldAdminURL] = attrs.PrinterMoreInfo
+
+	return pi
+}
+
+// queryWSD queries device identity via WSD.
+//
+// If xaddr is not nil, metadata is requested directly from this URL.
+// Otherwise, the device is searched by the WS-Discovery Probe,
+// and matches are selected by the host address.
+func queryWSD(ctx context.Context, host string, xaddr *url.URL) *protoInfo {
+	pi := &protoInfo{proto: "WSD"}
+
+	q := wsdd.Query{}
+
+	if xaddr != nil {
+		pi.endpoint = xaddr.String()
+
+		meta, err := q.Get(ctx, "", xaddr)
+		if err != nil {
+			pi.err = err
+			return pi
+		}
+
+		wsdFields(pi, "", meta.Metadata)
+		return pi
+	}
+
+	// Resolve the host name, so we can match responders
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		pi.err = err
+		return pi
+	}
+
+	matches, err := q.Probe(ctx, nil)
+	if err != nil {
+		pi.err = err
+		return pi
+	}
+
+	for _, m := range matches {
+		if !wsdMatchHost(m, host, ips) || len(m.Metadata) == 0 {
+			continue
+		}
+
+		log.Debug(ctx, "WSD: %s: device found",
+			m.EndpointReference.Address)
+
+		meta := m.Metadata[0]
+		pi.endpoint = meta.From.String()
+		wsdFields(pi, m.EndpointReference.Address, meta.Metadata)
+
+		return pi
+	}
+
+	pi.err = errors.New("device not found")
+	return pi
+}
+
+// wsdMatchHost reports whether WS-Discovery match belongs to the host.
+func wsdMatchHost(m wsdd.QueryMatch, host string, ips []netip.Addr) bool {
+	from := m.From.Addr().Unmap().WithZone("")
+	for _, ip := range ips {
+		if ip.Unmap().WithZone("") == from {
+			return true
+		}
+	}
+
+	for _, xaddr := range m.XAddrs {
+		if u, err := url.Parse(xaddr); err == nil &&
+			strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wsdFields fills protoInfo fields from the WSD metadata.
+// The target is the device endpoint address, if known.
+func wsdFields(pi *protoInfo, target wsd.AnyURI, meta wsd.Metadata) {
+	dev := meta.ThisDevice
+	model := meta.ThisModel
+
+	pi.fields[fieldName] = dev.FriendlyName.NeutralLang().String
+	pi.fields[fieldManufacturer] = model.Manufacturer.NeutralLang().String
+	pi.fields[fieldModel] = model.ModelName.NeutralLang().String
+	pi.fields[fieldModelNumber] = model.ModelNumber
+	pi.fields[fieldFirmware] = dev.FirmwareVersion
+	pi.fields[fieldSerial] = dev.SerialNumber
+	pi.fields[fieldUUID] = string(target)
+	pi.fields[fieldAdminURL] = optional.Get(model.PresentationURL)
+}
+
+// ieee1284Field returns the value of the IEEE 1284 device ID field.
+// Field may be known under several names.
+func ieee1284Field(id string, names ...string) string {
+	for _, field := range strings.Split(id, ";") {
+		name, value, found := strings.Cut(field, ":")
+		if !found {
+			continue
+		}
+
+		name = strings.ToUpper(strings.TrimSpace(name))
+		for _, n := range names {
+			if name == n {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,105 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "info" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Consolidated identity record for the --format output
+
+package info
+
+import (
+	"strings"
+)
+
+// infoRecord is the consolidated identity record for the
+// --format output.
+type infoRecord struct {
+	Device    string        `json:"device"`
+	Fields    []fieldRecord `json:"fields"`
+	Protocols []protoRecord `json:"protocols"`
+}
+
+// fieldRecord is the single identity field with all its values,
+// reported by different protocols.
+type fieldRecord struct {
+	Field  string        `json:"field"`
+	Values []valueRecord `json:"values"`
+}
+
+// valueRecord is the field value with the list of protocols that
+// reported it.
+type valueRecord struct {
+	Value   string   `json:"value"`
+	Sources []string `json:"sources"`
+}
+
+// protoRecord is the per-protocol query record.
+type protoRecord struct {
+	Protocol string `json:"protocol"`
+	Endpoint string `json:"endpoint"`
+	Error    string `json:"error,omitempty"`
+}
+
+// infoRecordMake makes infoRecord from the per-protocol results.
+//
+// Values of the same field, reported by different protocols, are
+// merged if they are equal, ignoring case and surrounding spaces.
+// Fields not reported by any protocol are omitted.
+func infoRecordMake(device string, infos []*protoInfo) infoRecord {
+	rec := infoRecord{
+		Device:    device,
+		Fields:    []fieldRecord{},
+		Protocols: []protoRecord{},
+	}
+
+	for field := 0; field < fieldCount; field++ {
+		frec := fieldRecord{
+			Field:  fieldNames[field],
+			Values: []valueRecord{},
+		}
+
+		for _, pi := range infos {
+			val := strings.TrimSpace(pi.fields[field])
+			if !pi.ok() || val == "" {
+				continue
+			}
+
+			merged := false
+			for i := range frec.Values {
+				v := &frec.Values[i]
+				if strings.EqualFold(v.Value, val) {
+					v.Sources = append(v.Sources, pi.proto)
+					merged = true
+					break
+				}
+			}
+
+			if !merged {
+				frec.Values = append(frec.Values, valueRecord{
+					Value:   val,
+					Sources: []string{pi.proto},
+				})
+			}
+		}
+
+		if len(frec.Values) != 0 {
+			rec.Fields = append(rec.Fields, frec)
+		}
+	}
+
+	for _, pi := range infos {
+		prec := protoRecord{
+			Protocol: pi.proto,
+			Endpoint: pi.endpoint,
+		}
+
+		if !pi.ok() {
+			prec.Error = pi.err.Error()
+		}
+
+		rec.Protocols = append(rec.Protocols, prec)
+	}
+
+	return rec
+}
@@ -0,0 +1,132 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "ipp" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "attrs" command.
+
+package ipp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// cmdAttrs defines the "attrs" sub-command
+var cmdAttrs = argv.Command{
+	Name:    "attrs",
+	Help:    "Get printer attributes",
+	Handler: cmdAttrsHandler,
+	Options: []argv.Option{
+		argv.Option{
+			Name:     "--attrs",
+			Help:     "Requested attributes (default: all)",
+			HelpArg:  "attr,...",
+			Validate: argv.ValidateAny,
+			Complete: ipp.ArgvPrinterAttrsCompleter,
+		},
+		optSaveFixtures,
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "URL",
+			Help:     "printer URL (i.e., ipp://host/ipp/print)",
+			Validate: transport.ValidateURL,
+		},
+	},
+}
+
+// cmdAttrsHandler is the "attrs" command handler
+func cmdAttrsHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	param, _ := inv.Get("URL")
+	u := transport.MustParseURL(param)
+
+	var attrList []string
+	for _, val := range inv.Values("--attrs") {
+		for _, name := range strings.Split(val, ",") {
+			if name != "" {
+				attrList = append(attrList, name)
+			}
+		}
+	}
+
+	if len(attrList) == 0 {
+		attrList = []string{"all"}
+	}
+
+	// Perform the query
+	rq := &ipp.GetPrinterAttributesRequest{
+		RequestHeader:       ipp.DefaultRequestHeader,
+		PrinterURI:          u.String(),
+		RequestedAttributes: attrList,
+	}
+
+	rsp := &ipp.GetPrinterAttributesResponse{}
+
+	clnt := newClient(inv, u)
+	err := clnt.Do(ctx, rq, rsp)
+	if err != nil {
+		return err
+	}
+
+	// Format output
+	pager := env.NewPager()
+
+	pager.Printf("Printer: %s", u)
+	pager.Printf("Status:  %s", rsp.Status)
+
+	f := goipp.NewFormatter()
+	for _, grp := range rsp.IPPMessage.AttrGroups() {
+		if len(grp.Attrs) == 0 {
+			continue
+		}
+
+		f.Reset()
+		f.SetIndent(2)
+		f.FmtAttributes(ipp.NamedEnumAttrs(grp.Attrs))
+
+		pager.Printf("")
+		pager.Printf("%s:", attrsGroupName(grp.Tag))
+		pager.Write(f.Bytes())
+	}
+
+	return pager.Display()
+}
+
+// attrsGroupName returns human-readable name of the attributes group.
+func attrsGroupName(tag goipp.Tag) string {
+	switch tag {
+	case goipp.TagOperationGroup:
+		return "Operation attributes"
+	case goipp.TagJobGroup:
+		return "Job attributes"
+	case goipp.TagPrinterGroup:
+		return "Printer attributes"
+	case goipp.TagUnsupportedGroup:
+		return "Unsupported attributes"
+	case goipp.TagSubscriptionGroup:
+		return "Subscription attributes"
+	case goipp.TagEventNotificationGroup:
+		return "Event notification attributes"
+	case goipp.TagResourceGroup:
+		return "Resource attributes"
+	case goipp.TagDocumentGroup:
+		return "Document attributes"
+	case goipp.TagSystemGroup:
+		return "System attributes"
+	}
+
+	return tag.String()
+}
@@ -0,0 +1,52 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "ipp" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package ipp
+
+import (
+	"net/url"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+)
+
+// Command is the 'ipp' command description
+var Command = argv.Command{
+	Name: "ipp",
+	Help: "IPP client",
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	SubCommands: []argv.Command{
+		cmdAttrs,
+		cmdPrint,
+		argv.HelpCommand,
+	},
+}
+
+// optSaveFixtures is the --save-fixtures option, common for
+// all sub-commands, that talk to the printer.
+var optSaveFixtures = argv.Option{
+	Name:     "--save-fixtures",
+	HelpArg:  "dir",
+	Help:     "Save anonymized IPP responses as test fixtures",
+	Validate: argv.ValidateAny,
+	Complete: argv.CompleteOSPath,
+}
+
+// newClient creates a new IPP client for the printer URL,
+// taking the common options into account.
+func newClient(inv *argv.Invocation, u *url.URL) *ipp.Client {
+	clnt := ipp.NewClient(u, nil)
+
+	if dir, ok := inv.Get("--save-fixtures"); ok {
+		clnt.Fixtures = ipp.NewFixtureWriter(dir)
+	}
+
+	return clnt
+}
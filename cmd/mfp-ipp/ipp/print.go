@@ -0,0 +1,223 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "ipp" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "print" command.
+
+package ipp
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/media"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// cmdPrint defines the "print" sub-command
+var cmdPrint = argv.Command{
+	Name: "print",
+	Help: "Print one or more documents as a single job",
+	Description: "" +
+		"All files are printed as a single multi-document job.\n" +
+		"Document format of each file is guessed from its name,\n" +
+		"unless specified explicitly with --format.\n" +
+		"\n" +
+		"If sending of any document fails, the entire job is canceled.\n",
+	Handler: cmdPrintHandler,
+	Options: []argv.Option{
+		argv.Option{
+			Name:     "--format",
+			HelpArg:  "MIME",
+			Help:     "Document format of all files",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--job-name",
+			HelpArg:  "name",
+			Help:     "Job name. Default: name of the first file",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--color-mode",
+			HelpArg:  "mode",
+			Help:     "Print color mode (print-color-mode)",
+			Validate: argv.ValidateStrings(ipp.KwPrintColorModeNames()),
+			Complete: argv.CompleteStrings(ipp.KwPrintColorModeNames()),
+		},
+		argv.Option{
+			Name:     "--media",
+			HelpArg:  "size",
+			Help:     "Media size, i.e., a4, letter or 100x150mm",
+			Validate: printValidateMedia,
+			Complete: argv.CompleteStrings(media.ShortNames()),
+		},
+		argv.Option{
+			Name:     "--media-source",
+			HelpArg:  "source",
+			Help:     "Media source (media-source)",
+			Validate: argv.ValidateStrings(ipp.KwMediaSourceNames()),
+			Complete: argv.CompleteStrings(ipp.KwMediaSourceNames()),
+		},
+		argv.Option{
+			Name:     "--output-bin",
+			HelpArg:  "bin",
+			Help:     "Output bin (output-bin)",
+			Validate: argv.ValidateStrings(ipp.KwOutputBinNames()),
+			Complete: argv.CompleteStrings(ipp.KwOutputBinNames()),
+		},
+		argv.Option{
+			Name:     "--finishings",
+			HelpArg:  "finishing",
+			Help:     "Finishing (finishings), may be repeated",
+			Validate: printValidateFinishings,
+			Complete: argv.CompleteStrings(ipp.EnFinishingsNames()),
+		},
+		optSaveFixtures,
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "URL",
+			Help:     "printer URL (i.e., ipp://host/ipp/print)",
+			Validate: transport.ValidateURL,
+		},
+		{
+			Name:     "file...",
+			Help:     "files to print",
+			Complete: argv.CompleteOSPath,
+		},
+	},
+}
+
+// cmdPrintHandler is the "print" command handler
+func cmdPrintHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	param, _ := inv.Get("URL")
+	u := transport.MustParseURL(param)
+
+	files := inv.Values("file")
+	format, _ := inv.Get("--format")
+
+	name, ok := inv.Get("--job-name")
+	if !ok {
+		name = filepath.Base(files[0])
+	}
+
+	// Open all files in advance, so missed file will not
+	// cause the partially submitted job.
+	docs := make([]ipp.Document, 0, len(files))
+	for _, file := range files {
+		fp, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+
+		doc := ipp.Document{
+			Name:   filepath.Base(file),
+			Format: format,
+			Body:   fp,
+		}
+
+		if doc.Format == "" {
+			doc.Format = printGuessFormat(file)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	// Submit the job
+	clnt := newClient(inv, u)
+	job, err := clnt.SubmitJob(ctx, name, printJobAttrs(inv), docs)
+	if err != nil {
+		return err
+	}
+
+	log.Info(ctx, "job %d: %d document(s) submitted", job.JobID, len(docs))
+	if job.JobURI != "" {
+		log.Info(ctx, "job URI: %s", job.JobURI)
+	}
+
+	return nil
+}
+
+// printJobAttrs returns Job Template attributes, specified by
+// the command-line options, or nil if there are none.
+func printJobAttrs(inv *argv.Invocation) *ipp.JobAttributes {
+	attrs := &ipp.JobAttributes{}
+	set := false
+
+	if mode, ok := inv.Get("--color-mode"); ok {
+		attrs.PrintColorMode = ipp.DecodeKwPrintColorMode(mode)
+		set = true
+	}
+
+	if name, ok := inv.Get("--media"); ok {
+		m, _ := media.Lookup(name)
+		attrs.MediaCol.MediaSize = m.MediaSize()
+		attrs.MediaCol.MediaSizeName = m.Name
+		set = true
+	}
+
+	if source, ok := inv.Get("--media-source"); ok {
+		attrs.MediaCol.MediaSource = ipp.DecodeKwMediaSource(source)
+		set = true
+	}
+
+	if bin, ok := inv.Get("--output-bin"); ok {
+		attrs.OutputBin = ipp.DecodeKwOutputBin(bin)
+		set = true
+	}
+
+	for _, fin := range inv.Values("--finishings") {
+		attrs.Finishings = append(attrs.Finishings,
+			ipp.DecodeEnFinishings(fin))
+		set = true
+	}
+
+	if !set {
+		return nil
+	}
+
+	return attrs
+}
+
+// printValidateFinishings validates the --finishings option.
+// It accepts either symbolic name or numeric value.
+func printValidateFinishings(s string) error {
+	if ipp.DecodeEnFinishings(s) == 0 {
+		return errors.New("invalid finishing")
+	}
+	return nil
+}
+
+// printValidateMedia validates the --media option.
+func printValidateMedia(s string) error {
+	if _, ok := media.Lookup(s); !ok {
+		return errors.New("unknown media size")
+	}
+	return nil
+}
+
+// printGuessFormat guesses document format by the file name.
+func printGuessFormat(file string) string {
+	format := mime.TypeByExtension(filepath.Ext(file))
+	if format == "" {
+		format = abstract.MIMETypeOctetStream
+	}
+
+	return format
+}
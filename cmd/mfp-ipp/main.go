@@ -0,0 +1,19 @@
+// MFP         - Miulti-Function Printers and scanners toolkit
+// cmd/mfp-ipp - IPP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The main() function.
+
+package main
+
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-ipp/ipp"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
+
+// main function for the mfp-ipp command
+func main() {
+	cmdopt.Root(ipp.Command).Main(nil)
+}
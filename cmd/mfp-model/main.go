@@ -8,9 +8,12 @@
 
 package main
 
-import "github.com/OpenPrinting/go-mfp/cmd/mfp-model/model"
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-model/model"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
 
 // main function for the mfp-cups command
 func main() {
-	model.Command.Main(nil)
+	cmdopt.Root(model.Command).Main(nil)
 }
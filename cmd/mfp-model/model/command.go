@@ -14,7 +14,7 @@ import (
 
 	"github.com/OpenPrinting/go-mfp/argv"
 	"github.com/OpenPrinting/go-mfp/discovery/dnssd"
-	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
 	"github.com/OpenPrinting/go-mfp/transport"
 )
 
@@ -71,16 +71,6 @@ var Command = argv.Command{
 			Validate: argv.ValidateAny,
 			Complete: argv.CompleteOSPath,
 		},
-		argv.Option{
-			Name:    "-d",
-			Aliases: []string{"--debug"},
-			Help:    "Enable debug output",
-		},
-		argv.Option{
-			Name:    "-v",
-			Aliases: []string{"--verbose"},
-			Help:    "Enable verbose debug output",
-		},
 		argv.HelpOption,
 	},
 	Handler: cmdModelHandler,
@@ -89,19 +79,7 @@ var Command = argv.Command{
 // cmdModelHandler is the top-level handler for the 'model' command.
 func cmdModelHandler(ctx context.Context, inv *argv.Invocation) error {
 	// Setup logging
-	_, dbg := inv.Get("-d")
-	_, vrb := inv.Get("-v")
-
-	level := log.LevelInfo
-	if dbg {
-		level = log.LevelDebug
-	}
-	if vrb {
-		level = log.LevelTrace
-	}
-
-	logger := log.NewLogger(level, log.Console)
-	ctx = log.NewContext(ctx, logger)
+	ctx = cmdopt.LogContext(ctx, inv)
 
 	_ = ctx
 
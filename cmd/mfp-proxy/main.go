@@ -8,9 +8,12 @@
 
 package main
 
-import "github.com/OpenPrinting/go-mfp/cmd/mfp-proxy/proxy"
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-proxy/proxy"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
 
 // main function for the mfp-cups command
 func main() {
-	proxy.Command.Main(nil)
+	cmdopt.Root(proxy.Command).Main(nil)
 }
@@ -13,6 +13,7 @@ import (
 	"errors"
 
 	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
 	"github.com/OpenPrinting/go-mfp/internal/env"
 	"github.com/OpenPrinting/go-mfp/log"
 )
@@ -26,6 +27,12 @@ const description = "" +
 	"  - to logically bring the device into the different IP address\n" +
 	"    or port\n" +
 	"\n" +
+	"For eSCL, target-url is the device's eSCL root (for example,\n" +
+	"http://192.168.1.10/eSCL), and it is mapped to the\n" +
+	"http://localhost:local-port/eSCL. Decoded IPP messages and eSCL\n" +
+	"XML bodies are written to the debug log (-d) in both directions;\n" +
+	"with --trace, the message bodies are dumped into the file.tar\n" +
+	"\n" +
 	"If optional command is specified, the CUPS_SERVER and the\n" +
 	"SANE_AIRSCAN_DEVICE environment variables will be set properly\n" +
 	"and the command will be executed, The simulator will exit when\n" +
@@ -46,9 +53,6 @@ var Command = argv.Command{
 			Singleton: true,
 			Validate: func(s string) error {
 				_, err := parseMapping(protoESCL, s)
-				if err == nil {
-					err = errors.New("not implemented")
-				}
 				return err
 			},
 		},
@@ -81,16 +85,6 @@ var Command = argv.Command{
 			Validate: argv.ValidateAny,
 			Complete: argv.CompleteOSPath,
 		},
-		argv.Option{
-			Name:    "-d",
-			Aliases: []string{"--debug"},
-			Help:    "Enable debug output",
-		},
-		argv.Option{
-			Name:    "-v",
-			Aliases: []string{"--verbose"},
-			Help:    "Enable verbose debug output",
-		},
 		argv.HelpOption,
 	},
 	Parameters: []argv.Parameter{
@@ -109,19 +103,7 @@ var Command = argv.Command{
 // cmdProxyHandler is the top-level handler for the 'proxy' command.
 func cmdProxyHandler(ctx context.Context, inv *argv.Invocation) error {
 	// Setup logging
-	_, dbg := inv.Get("-d")
-	_, vrb := inv.Get("-v")
-
-	level := log.LevelInfo
-	if dbg {
-		level = log.LevelDebug
-	}
-	if vrb {
-		level = log.LevelTrace
-	}
-
-	logger := log.NewLogger(level, log.Console)
-	ctx = log.NewContext(ctx, logger)
+	ctx = cmdopt.LogContext(ctx, inv)
 
 	// Setup trace
 	var trace *traceWriter
@@ -0,0 +1,223 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "proxy" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// eSCL proxy
+
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// esclLocalRoot is the eSCL root path at the local side of the proxy.
+//
+// Requests to the http://localhost:port/eSCL/xxx are forwarded
+// to the target-url/xxx.
+const esclLocalRoot = "/eSCL"
+
+// doESCL implements proxy for eSCL requests
+func (p *proxy) doESCL(w http.ResponseWriter, in *http.Request) {
+	rqnum := p.rqnum.Add(1)
+
+	// Dump request HTTP headers
+	p.httpLogRequest("eSCL", in)
+
+	// Create URL translator
+	urlxlat, err := p.esclURLXlat(in)
+	if err != nil {
+		p.httpReject(w, in, http.StatusBadGateway, err)
+		return
+	}
+
+	local := &url.URL{
+		Scheme:   "http",
+		Host:     in.Host,
+		Path:     in.URL.Path,
+		RawQuery: in.URL.RawQuery,
+	}
+
+	target := urlxlat.Forward(local)
+	if target == local {
+		err = fmt.Errorf("%s: not under %s", in.URL.Path, esclLocalRoot)
+		p.httpReject(w, in, http.StatusNotFound, err)
+		return
+	}
+
+	// Fetch and log request body
+	body, err := io.ReadAll(in.Body)
+	if err != nil {
+		log.Debug(p.ctx, "eSCL: %s", err)
+		p.httpReject(w, in, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(body) != 0 {
+		p.esclLogBody("request", in.Header, body, rqnum)
+	}
+
+	// Prepare outgoing request
+	out, _ := transport.NewRequest(p.ctx, in.Method, target,
+		io.NopCloser(bytes.NewReader(body)))
+	out.Header = in.Header.Clone()
+	transport.RemoveHopByHopHeaders(out.Header)
+	out.Host = out.URL.Host
+	out.ContentLength = int64(len(body))
+
+	// Execute outgoing request
+	log.Debug(p.ctx, "eSCL: forward request to: %s", out.URL)
+
+	rsp, err := p.clnt.Do(out)
+	if err != nil {
+		log.Debug(p.ctx, "eSCL: %s", err)
+		p.httpReject(w, in, http.StatusBadGateway, err)
+		return
+	}
+
+	defer rsp.Body.Close()
+
+	// Dump response HTTP headers
+	p.httpLogResponse("eSCL", rsp)
+
+	// Translate Location, returned by POST /ScanJobs
+	if loc := rsp.Header.Get("Location"); loc != "" {
+		u, err := url.Parse(loc)
+		if err == nil && u.IsAbs() {
+			loc2 := urlxlat.Reverse(u).String()
+			if loc2 != loc {
+				log.Debug(p.ctx, "eSCL: Location: %s->%s",
+					loc, loc2)
+				rsp.Header.Set("Location", loc2)
+			}
+		}
+	}
+
+	// Copy response headers and status to the client
+	transport.RemoveHopByHopHeaders(rsp.Header)
+	p.httpCopyHeaders(w.Header(), rsp.Header)
+
+	// XML responses are small, so we read them entirely, for
+	// logging. Other responses (i.e., images) are streamed.
+	if esclIsXML(rsp.Header) {
+		body, err = io.ReadAll(rsp.Body)
+		if err != nil {
+			log.Debug(p.ctx, "eSCL: %s", err)
+			p.httpReject(w, in, http.StatusBadGateway, err)
+			return
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(rsp.StatusCode)
+		w.Write(body)
+
+		if len(body) != 0 {
+			p.esclLogBody("response", rsp.Header, body, rqnum)
+		}
+
+		return
+	}
+
+	if rsp.ContentLength >= 0 {
+		w.Header().Set("Content-Length",
+			strconv.FormatInt(rsp.ContentLength, 10))
+	}
+
+	w.WriteHeader(rsp.StatusCode)
+
+	var sniffBuff bytes.Buffer
+	var src io.Reader = rsp.Body
+	if p.trace != nil {
+		src = io.TeeReader(rsp.Body, &sniffBuff)
+	}
+
+	io.Copy(w, src)
+
+	if sniffBuff.Len() != 0 {
+		data := sniffBuff.Bytes()
+		name := fmt.Sprintf("%8.8d-data.%s", rqnum, magic(data))
+		p.trace.Send(name, data)
+	}
+}
+
+// esclURLXlat returns URL translator for the eSCL request.
+func (p *proxy) esclURLXlat(in *http.Request) (*transport.URLXlat, error) {
+	s := "http://" + in.Host + esclLocalRoot
+	u, err := transport.ParseURL(s)
+	if err != nil {
+		err = fmt.Errorf("%q: can't parse local URL", s)
+		return nil, err
+	}
+
+	return transport.NewURLXlat(u, p.m.targetURL), nil
+}
+
+// esclLogBody writes eSCL request or response body into the log
+// and into the trace.
+//
+// XML bodies are decoded and pretty-printed. If XML cannot be
+// decoded, it is logged as is.
+func (p *proxy) esclLogBody(dir string, hdr http.Header,
+	body []byte, rqnum uint32) {
+
+	if !esclIsXML(hdr) {
+		log.Debug(p.ctx, "eSCL: %s body: %d bytes", dir, len(body))
+		if p.trace != nil {
+			name := fmt.Sprintf("%8.8d-data.%s", rqnum, magic(body))
+			p.trace.Send(name, body)
+		}
+		return
+	}
+
+	name := dir
+	xml, err := xmldoc.DecodeNormalized(escl.NsMap, escl.NsNormalize,
+		bytes.NewReader(body))
+	if err == nil {
+		name = esclXMLName(xml)
+		log.Debug(p.ctx, "eSCL: %s message:", dir)
+		log.Debug(p.ctx, "%s",
+			xml.EncodeIndentString(escl.NsMap, "  "))
+	} else {
+		log.Debug(p.ctx, "eSCL: %s message (%s):", dir, err)
+		log.Debug(p.ctx, "%s", body)
+	}
+
+	if p.trace != nil {
+		name = fmt.Sprintf("%8.8d-%s.xml", rqnum, name)
+		p.trace.Send(name, body)
+	}
+}
+
+// esclXMLName returns name of the XML root element without
+// namespace prefix, for naming trace files.
+func esclXMLName(xml xmldoc.Element) string {
+	name := xml.Name
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// esclIsXML reports if HTTP message has XML body.
+func esclIsXML(hdr http.Header) bool {
+	ct := strings.ToLower(hdr.Get("Content-Type"))
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+
+	ct = strings.TrimSpace(ct)
+	return ct == "text/xml" || ct == "application/xml" ||
+		strings.HasSuffix(ct, "+xml")
+}
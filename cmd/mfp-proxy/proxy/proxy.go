@@ -23,6 +23,7 @@ import (
 	"sync/atomic"
 
 	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
 	"github.com/OpenPrinting/go-mfp/transport"
 	"github.com/OpenPrinting/goipp"
 )
@@ -36,6 +37,7 @@ type proxy struct {
 	l         net.Listener      // TCP listener for incoming connections
 	srv       *transport.Server // HTTP server for incoming connections
 	clnt      *transport.Client // HTTP client part of proxy
+	ipp       *ipp.Proxy        // IPP proxy, nil if not IPP
 	closeWait sync.WaitGroup    // Wait for proxy.Close completion
 	rqnum     atomic.Uint32     // Request number, for logging
 }
@@ -64,6 +66,12 @@ func newProxy(ctx context.Context, m mapping, trace *traceWriter) (
 		clnt:   transport.NewClient(nil),
 	}
 
+	if m.proto == protoIPP {
+		p.ipp = ipp.NewProxy(ctx, ipp.ProxyOptions{
+			Target: m.targetURL,
+		})
+	}
+
 	// Ensure cancellation propagation
 	p.closeWait.Add(1)
 	go p.kill()
@@ -120,6 +128,9 @@ func (p *proxy) ServeHTTP(w http.ResponseWriter, in *http.Request) {
 		ct == "application/ipp":
 		p.doIPP(w, in)
 
+	case p.m.proto == protoESCL:
+		p.doESCL(w, in)
+
 	case in.Method == "GET":
 		p.doHTTP(w, in)
 
@@ -135,7 +146,7 @@ func (p *proxy) outreq(in *http.Request, body io.ReadCloser) *http.Request {
 	// Create request
 	out, _ := transport.NewRequest(p.ctx, in.Method, in.URL, body)
 	out.Header = in.Header.Clone()
-	p.httpRemoveHopByHopHeaders(out.Header)
+	transport.RemoveHopByHopHeaders(out.Header)
 
 	// Adjust target URL
 	prq := httputil.ProxyRequest{
@@ -147,24 +158,6 @@ func (p *proxy) outreq(in *http.Request, body io.ReadCloser) *http.Request {
 	return out
 }
 
-// msgxlat returns goipp.Message translator that rewrites message
-// attributes when message is being forwarded via proxy.
-//
-// Currently, only URLs embedded into the message are translated.
-func (p *proxy) msgxlat(in *http.Request) (*msgXlat, error) {
-	s := "http://" + in.Host
-	u, err := transport.ParseURL(s)
-	if err != nil {
-		err = fmt.Errorf("%q: can't parse local URL", s)
-		return nil, err
-	}
-
-	urlxlat := transport.NewURLXlat(u, p.m.targetURL)
-	msgxlat := newMsgXlat(urlxlat)
-
-	return msgxlat, nil
-}
-
 // doHTTP implements proxy for the bare HTTP requests
 func (p *proxy) doHTTP(w http.ResponseWriter, in *http.Request) {
 	// Dump request headers
@@ -185,7 +178,7 @@ func (p *proxy) doHTTP(w http.ResponseWriter, in *http.Request) {
 	}
 
 	// Copy response headers and status to the client
-	p.httpRemoveHopByHopHeaders(rsp.Header)
+	transport.RemoveHopByHopHeaders(rsp.Header)
 	p.httpCopyHeaders(w.Header(), rsp.Header)
 
 	if rsp.ContentLength >= 0 {
@@ -203,209 +196,64 @@ func (p *proxy) doHTTP(w http.ResponseWriter, in *http.Request) {
 	rsp.Body.Close()
 }
 
-// doIPP implements proxy for IPP requests
+// doIPP implements proxy for IPP requests.
+//
+// The actual forwarding, including URL translation, is performed
+// by the [ipp.Proxy]. If trace is active, the request and response
+// bodies are sniffed on their way and saved into the trace.
 func (p *proxy) doIPP(w http.ResponseWriter, in *http.Request) {
 	rqnum := p.rqnum.Add(1)
 
-	// Create goipp.Message translator
-	msgxlat, err := p.msgxlat(in)
-	if err != nil {
-		p.httpReject(w, in, http.StatusBadGateway, err)
-		return
-	}
-
-	// Prepare outgoing request
-	out, ipplen, err := p.doIPPreq(in, msgxlat, rqnum)
-	if err != nil {
-		err = fmt.Errorf("IPP error: %w", err)
-		p.httpReject(w, in, http.StatusBadGateway, err)
-		return
-	}
-
-	// Shiff outgoing data, if trace is active
-	var sniffBuff bytes.Buffer
-	if p.trace != nil {
-		out.Body = transport.TeeReadCloser(out.Body, &sniffBuff)
-	}
-
-	// Execute outgoing request
-	log.Debug(p.ctx, "IPP: forward request to: %s", out.URL)
-
-	rsp, err := p.clnt.Do(out)
-	if err != nil {
-		log.Debug(p.ctx, "IPP: %s", err)
-		p.httpReject(w, in, http.StatusBadGateway, err)
-		return
-	}
-
-	// Save sniffed request data
-	if p.trace != nil && sniffBuff.Len() > ipplen {
-		data := sniffBuff.Bytes()[ipplen:]
-		name := fmt.Sprintf("%8.8d-data.%s", rqnum, magic(data))
-		p.trace.Send(name, data)
-	}
-
-	// Dump response HTTP headers
-	p.httpLogResponse("IPP", rsp)
-
-	// Translate IPP response
-	ct := strings.ToLower(rsp.Header.Get("Content-Type"))
-	if ct == "application/ipp" {
-		err = p.doIPPrsp(rsp, msgxlat, rqnum)
-		if err != nil {
-			log.Debug(p.ctx, "IPP: %s", err)
-			p.httpReject(w, in, http.StatusBadGateway, err)
-			return
-		}
-	}
-
-	// Copy response headers and status to the client
-	p.httpRemoveHopByHopHeaders(rsp.Header)
-	p.httpCopyHeaders(w.Header(), rsp.Header)
-
-	if rsp.ContentLength >= 0 {
-		rsp.Header.Set("Content-Length",
-			strconv.FormatInt(rsp.ContentLength, 10))
-	}
-
-	w.WriteHeader(rsp.StatusCode)
-
-	// Forward response body
-	io.Copy(w, rsp.Body)
-	rsp.Body.Close()
-}
-
-// doIPPreq performs (client->server) part of the IPP request handling
-//
-// It returns modified request ready to be send to the server,
-// length of the IPP part of that request and error, if any.
-func (p *proxy) doIPPreq(in *http.Request,
-	msgxlat *msgXlat, rqnum uint32) (*http.Request, int, error) {
-
-	ops := goipp.DecoderOptions{EnableWorkarounds: true}
-
 	// Dump request HTTP headers
 	p.httpLogRequest("IPP", in)
 
-	// Fetch IPP Request message
-	peeker := transport.NewPeeker(in.Body)
-	var msg goipp.Message
-	err := msg.DecodeEx(peeker, ops)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// Write trace
-	if p.trace != nil {
-		name := fmt.Sprintf("%8.8d-%s.ipp",
-			rqnum, goipp.Op(msg.Code))
-		p.trace.Send(name, peeker.Bytes())
-	}
-
-	// Translate IPP message
-	msg2, chg := msgxlat.Forward(&msg)
-
-	// Log the message
-	var buf bytes.Buffer
-	msg2.Print(&buf, true)
-	log.Debug(p.ctx, "IPP: request message:")
-	log.Debug(p.ctx, buf.String())
-
-	if !chg.Empty() {
-		log.Debug(p.ctx, "IPP: translated attributes:")
-		log.Object(p.ctx, log.LevelDebug, 4, chg)
+	if p.trace == nil {
+		p.ipp.ServeHTTP(w, in)
+		return
 	}
 
-	// Setup outgoing request
-	msg2bytes, _ := msg2.EncodeBytes()
-	peeker.Replace(msg2bytes)
-
-	out := p.outreq(in, peeker)
-	out.ContentLength = in.ContentLength
-	if out.ContentLength >= 0 {
-		out.ContentLength += int64(len(msg2bytes))
-		out.ContentLength -= peeker.Count()
-	}
+	// Forward request, sniffing data in both directions
+	var rqBuff, rspBuff bytes.Buffer
+	in.Body = transport.TeeReadCloser(in.Body, &rqBuff)
+	p.ipp.ServeHTTP(&sniffResponseWriter{w, &rspBuff}, in)
 
-	return out, len(msg2bytes), nil
+	// Write trace
+	p.traceIPP(rqnum, rqBuff.Bytes(), rspBuff.Bytes())
 }
 
-// doIPPreq performs (client->server) part of the IPP request handling
-func (p *proxy) doIPPrsp(rsp *http.Response,
-	msgxlat *msgXlat, rqnum uint32) error {
-
+// traceIPP saves the sniffed IPP request and response into the trace.
+//
+// The request, as received from the client, is saved as the IPP
+// message, followed by the document data (if any). The response
+// is saved as returned to the client.
+func (p *proxy) traceIPP(rqnum uint32, rq, rsp []byte) {
 	ops := goipp.DecoderOptions{EnableWorkarounds: true}
 
-	// Fetch IPP response message
-	peeker := transport.NewPeeker(rsp.Body)
+	// Save request and data
 	var msg goipp.Message
-	err := msg.DecodeEx(peeker, ops)
-	if err != nil {
-		peeker.Rewind()
-		return err
-	}
-
-	// Translate IPP response
-	msg2, chg := msgxlat.Reverse(&msg)
-
-	// Log the message
-	var buf bytes.Buffer
-	msg2.Print(&buf, false)
-	log.Debug(p.ctx, "IPP: response message (translated):")
-	log.Debug(p.ctx, buf.String())
-
-	if !chg.Empty() {
-		log.Debug(p.ctx, "IPP: translated attributes:")
-		log.Object(p.ctx, log.LevelDebug, 4, chg)
+	r := bytes.NewReader(rq)
+	if msg.DecodeEx(r, ops) != nil {
+		return
 	}
 
-	// Replace http.Response body
-	msg2bytes, _ := msg2.EncodeBytes()
-	peeker.Replace(msg2bytes)
-	rsp.Body = peeker
-
-	// Adjust rsp.ContentLength
-	if rsp.ContentLength >= 0 {
-		rsp.ContentLength += int64(len(msg2bytes))
-		rsp.ContentLength -= peeker.Count()
-	}
+	ipplen := len(rq) - r.Len()
+	name := fmt.Sprintf("%8.8d-%s.ipp", rqnum, goipp.Op(msg.Code))
+	p.trace.Send(name, rq[:ipplen])
 
-	// Write trace
-	if p.trace != nil {
-		name := fmt.Sprintf("%8.8d-%s.ipp",
-			rqnum, goipp.Status(msg.Code))
-		p.trace.Send(name, msg2bytes)
+	if data := rq[ipplen:]; len(data) > 0 {
+		name := fmt.Sprintf("%8.8d-data.%s", rqnum, magic(data))
+		p.trace.Send(name, data)
 	}
 
-	return nil
-}
-
-// httpRemoveHopByHopHeaders removes HTTP hop-by-hop headers,
-// RFC 7230, section 6.1
-func (p *proxy) httpRemoveHopByHopHeaders(hdr http.Header) {
-	// Per RFC 7230, section 6.1:
-	//
-	// Hence, the Connection header field provides a declarative way of
-	// distinguishing header fields that are only intended for the immediate
-	// recipient ("hop-by-hop") from those fields that are intended for all
-	// recipients on the chain ("end-to-end"), enabling the message to be
-	// self-descriptive and allowing future connection-specific extensions
-	// to be deployed without fear that they will be blindly forwarded by
-	// older intermediaries.
-	if c := hdr.Get("Connection"); c != "" {
-		for _, f := range strings.Split(c, ",") {
-			if f = strings.TrimSpace(f); f != "" {
-				hdr.Del(f)
-			}
-		}
+	// Save response
+	r = bytes.NewReader(rsp)
+	if msg.DecodeEx(r, ops) != nil {
+		return
 	}
 
-	// These headers are always considered hop-by-hop.
-	for _, c := range []string{"Connection", "Keep-Alive",
-		"Proxy-Authenticate", "Proxy-Connection",
-		"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding"} {
-		hdr.Del(c)
-	}
+	ipplen = len(rsp) - r.Len()
+	name = fmt.Sprintf("%8.8d-%s.ipp", rqnum, goipp.Status(msg.Code))
+	p.trace.Send(name, rsp[:ipplen])
 }
 
 // httpCopyHeaders copies HTTP headers from src to dst
@@ -450,3 +298,16 @@ func (p *proxy) httpNoCache(w http.ResponseWriter) {
 	w.Header().Set("Pragma", "no-cache")
 	w.Header().Set("Expires", "0")
 }
+
+// sniffResponseWriter wraps http.ResponseWriter and copies
+// the response body to the sniff buffer.
+type sniffResponseWriter struct {
+	http.ResponseWriter               // Underlying http.ResponseWriter
+	sniff               *bytes.Buffer // Sniff buffer
+}
+
+// Write writes the response body.
+func (w *sniffResponseWriter) Write(data []byte) (int, error) {
+	w.sniff.Write(data)
+	return w.ResponseWriter.Write(data)
+}
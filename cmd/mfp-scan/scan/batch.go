@@ -0,0 +1,65 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "scan" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// ADF batch scanning
+
+package scan
+
+import (
+	"context"
+
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+)
+
+// batchFailures lists ADF states, that may be fixed by the user,
+// so the batch may be resumed.
+var batchFailures = map[escl.ADFState]string{
+	escl.ScannerAdfJam:                "paper jam",
+	escl.ScannerAdfMispick:            "sheet mispick",
+	escl.ScannerAdfHatchOpen:          "hatch is open",
+	escl.ScannerAdfDuplexPageTooShort: "sheet is too short",
+	escl.ScannerAdfDuplexPageTooLong:  "sheet is too long",
+	escl.ScannerAdfMultipickDetected:  "multiple sheets picked",
+}
+
+// batchResume is called, when the batch scan job fails.
+//
+// If failure is caused by the ADF problem, that may be fixed by
+// the user, it asks the user whether to resume or abort the batch.
+//
+// It returns true, if batch needs to be resumed.
+func batchResume(ctx context.Context, clnt *escl.Client, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	status, _, err2 := clnt.GetScannerStatus(ctx)
+	if err2 != nil {
+		log.Debug(ctx, "can't get scanner status: %s", err2)
+		return false
+	}
+
+	state := status.ADFState
+	if state == nil || batchFailures[*state] == "" {
+		return false
+	}
+
+	log.Error(ctx, "%s", err)
+	log.Error(ctx, "ADF: %s", batchFailures[*state])
+
+	answer, err2 := env.Prompt(ctx,
+		"Fix the problem and reload unscanned sheets. Resume or abort?",
+		[]string{"resume", "abort"})
+
+	if err2 != nil {
+		log.Debug(ctx, "%s", err2)
+		return false
+	}
+
+	return answer == "resume"
+}
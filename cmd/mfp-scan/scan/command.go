@@ -0,0 +1,536 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "scan" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/media"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/filename"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// Default values of the scan parameters
+const (
+	DefaultResolution    = 300
+	DefaultFormat        = "image/jpeg"
+	DefaultOutput        = "scan-%d{datetime}"
+	DefaultPreviewOutput = "preview-%d{datetime}"
+	DefaultPreviewFormat = "image/png"
+)
+
+// scanCancelTimeout is the timeout for the job cancel request
+const scanCancelTimeout = 5 * time.Second
+
+// description is printed as a command description text
+const description = "" +
+	"This command scans documents from the eSCL scanner and saves\n" +
+	"each received page into the separate file.\n" +
+	"\n" +
+	"Output file names are generated from the template (see -o).\n" +
+	"Template may contain the following directives:\n" +
+	"\n" +
+	"  %d        page number (printf-style width is allowed, i.e., %03d)\n" +
+	"  %d{name}  date/time (date, time, datetime or Go time layout)\n" +
+	"  %j        job number\n" +
+	"  %e        file extension, chosen by the image format\n" +
+	"  %%        the '%' character\n" +
+	"\n" +
+	"If template has no extension, it is chosen automatically.\n" +
+	"Existent files are never overwritten.\n" +
+	"\n" +
+	"With --preview, the quick low-resolution scan of the entire\n" +
+	"scan area is made. It is useful to check the document before\n" +
+	"committing to the full-resolution scan. If --resolution is\n" +
+	"specified with --preview, it is used as a hint.\n" +
+	"\n" +
+	"With --batch, all sheets loaded into the ADF are scanned,\n" +
+	"one file per page. If paper jam or similar ADF failure occurs\n" +
+	"in the middle of the batch, the user is asked whether to resume\n" +
+	"after fixing the problem, or to abort the batch.\n" +
+	"\n" +
+	"With --wait, if scanner is busy (i.e., used from the device\n" +
+	"panel), scan is queued until scanner becomes ready, instead\n" +
+	"of failing immediately. For the ADF, the scanner is considered\n" +
+	"ready when it is idle and paper is loaded.\n" +
+	"\n" +
+	"With --wsd, URL is the WS-Scan service endpoint of the WSD\n" +
+	"scanner, as found in the device metadata. Only the basic\n" +
+	"options (-r, -s, -m, --image-format and -o) are supported\n" +
+	"in this mode.\n" +
+	"\n" +
+	"If scan is interrupted with Ctrl-C, the scan job is canceled.\n"
+
+// Command is the 'scan' command description
+var Command = argv.Command{
+	Name:        "scan",
+	Help:        "Scan documents",
+	Description: description,
+	Options: []argv.Option{
+		argv.Option{
+			Name:    "-r",
+			Aliases: []string{"--resolution"},
+			HelpArg: "DPI",
+			Help: fmt.Sprintf("Scan resolution. Default: %d",
+				DefaultResolution),
+			Validate: argv.ValidateUintRange(10, 1, 9600),
+		},
+		argv.Option{
+			Name:     "-s",
+			Aliases:  []string{"--source"},
+			HelpArg:  "source",
+			Help:     "Input source: platen, adf, duplex or camera",
+			Validate: argv.ValidateStrings(sources),
+			Complete: argv.CompleteStrings(sources),
+		},
+		argv.Option{
+			Name:     "-m",
+			Aliases:  []string{"--mode"},
+			HelpArg:  "mode",
+			Help:     "Color mode: color, gray or bw",
+			Validate: argv.ValidateStrings(modes),
+			Complete: argv.CompleteStrings(modes),
+		},
+		argv.EnumOption(argv.Option{
+			Name:      "--color-mode",
+			HelpArg:   "mode",
+			Help:      "Exact eSCL color mode, instead of --mode",
+			Conflicts: []string{"-m"},
+		}, escl.DecodeColorMode),
+		argv.EnumOption(argv.Option{
+			Name:    "--intent",
+			HelpArg: "intent",
+			Help:    "Scan intent",
+		}, escl.DecodeIntent),
+		argv.EnumOption(argv.Option{
+			Name:    "--ccd-channel",
+			HelpArg: "channel",
+			Help:    "CCD channel for grayscale and b/w scans",
+		}, escl.DecodeCCDChannel),
+		argv.Option{
+			Name:     "--media",
+			HelpArg:  "size",
+			Help:     "Scan region size, i.e., a4, letter or 100x150mm",
+			Validate: optMediaValidate,
+			Complete: argv.CompleteStrings(media.ShortNames()),
+		},
+		argv.Option{
+			Name:    "--image-format",
+			HelpArg: "MIME",
+			Help: fmt.Sprintf("Image format. Default: %s "+
+				"(%s for preview)",
+				DefaultFormat, DefaultPreviewFormat),
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:    "-o",
+			Aliases: []string{"--output"},
+			HelpArg: "template",
+			Help: fmt.Sprintf("Output file name template.\n"+
+				"Default: %s (%s for preview)",
+				DefaultOutput, DefaultPreviewOutput),
+			Validate: optOutputValidate,
+			Complete: argv.CompleteOSPath,
+		},
+		argv.Option{
+			Name: "--preview",
+			Help: "Make a quick low-resolution preview",
+		},
+		argv.Option{
+			Name:      "--batch",
+			Help:      "Scan all sheets, loaded into the ADF",
+			Conflicts: []string{"--preview"},
+		},
+		argv.Option{
+			Name:     "-w",
+			Aliases:  []string{"--wait"},
+			HelpArg:  "seconds",
+			Help:     "Wait for busy scanner up to the specified time",
+			Validate: argv.ValidateUintRange(10, 1, 86400),
+		},
+		argv.Option{
+			Name: "--progress",
+			Help: "Display download progress of each page",
+		},
+		argv.Option{
+			Name: "--wsd",
+			Help: "Scan via WS-Scan instead of eSCL",
+			Conflicts: []string{"--preview", "--batch", "-w",
+				"--progress", "--color-mode", "--intent",
+				"--ccd-channel", "--media"},
+		},
+		argv.Option{
+			Name:     "--interface",
+			HelpArg:  "name",
+			Help:     "Connect to scanner via this network interface",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--dscp",
+			HelpArg:  "0...63",
+			Help:     "DSCP marking of the scan traffic",
+			Validate: argv.ValidateUintRange(10, 0, 63),
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "URL",
+			Help:     "scanner URL (i.e., http://host/eSCL)",
+			Validate: transport.ValidateURL,
+		},
+	},
+	Handler: cmdScanHandler,
+}
+
+// sources and modes are the valid values of the
+// --source and --mode options
+var (
+	sources = []string{"platen", "adf", "duplex", "camera"}
+	modes   = []string{"color", "gray", "bw"}
+)
+
+// optOutputValidate validates the --output option
+func optOutputValidate(s string) error {
+	_, err := filename.Parse(s)
+	return err
+}
+
+// optMediaValidate validates the --media option
+func optMediaValidate(s string) error {
+	if _, ok := media.Lookup(s); !ok {
+		return errors.New("unknown media size")
+	}
+	return nil
+}
+
+// cmdScanHandler is the handler for the 'scan' command.
+func cmdScanHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	// Parse options
+	param, _ := inv.Get("URL")
+	u := transport.MustParseURL(param)
+
+	_, preview := inv.Get("--preview")
+
+	output := DefaultOutput
+	if preview {
+		output = DefaultPreviewOutput
+	}
+	if s, ok := inv.Get("-o"); ok {
+		output = s
+	}
+
+	tmpl := filename.MustParse(output)
+	ss := scanSettings(inv, preview)
+
+	// Create eSCL client
+	var tr *transport.Transport
+	iface, ifaceOk := inv.Get("--interface")
+	dscp, dscpOk := inv.Get("--dscp")
+
+	if ifaceOk || dscpOk {
+		sockopts := transport.SocketOptions{Interface: iface}
+		sockopts.DSCP, _ = strconv.Atoi(dscp)
+
+		tr = transport.NewTransport(nil)
+		tr.SetSocketOptions(sockopts)
+	}
+
+	vars := filename.Vars{
+		Time: time.Now(),
+		Job:  1,
+		Page: 1,
+	}
+
+	if _, wsd := inv.Get("--wsd"); wsd {
+		return wsdScan(ctx, inv, u, tr, tmpl, &vars)
+	}
+
+	var options escl.ClientOptions
+	if _, ok := inv.Get("--progress"); ok {
+		options.OnProgress = func(p escl.Progress) {
+			progressShow(os.Stderr, vars.Page, p)
+		}
+	}
+
+	clnt := escl.NewClientWithOptions(u, tr, options)
+
+	// Prepare preview settings, if requested
+
+	if preview {
+		caps, _, err := clnt.GetScannerCapabilities(ctx)
+		if err != nil {
+			return err
+		}
+
+		ss = escl.PreviewSettings(caps, ss)
+		log.Debug(ctx, "preview resolution: %dx%d",
+			optional.Get(ss.XResolution),
+			optional.Get(ss.YResolution))
+	}
+
+	// Perform the scan
+	_, batch := inv.Get("--batch")
+	if batch && optional.Get(ss.InputSource) != escl.InputFeeder {
+		if _, ok := inv.Get("-s"); ok {
+			return errors.New("--batch requires adf or duplex source")
+		}
+		ss.InputSource = optional.New(escl.InputFeeder)
+	}
+
+	var wait time.Duration
+	if s, ok := inv.Get("-w"); ok {
+		secs, _ := strconv.Atoi(s)
+		wait = time.Duration(secs) * time.Second
+	}
+
+	for {
+		err := scanJob(ctx, clnt, ss, wait, tmpl, &vars)
+		if err == nil || !batch {
+			return err
+		}
+
+		if !batchResume(ctx, clnt, err) {
+			return err
+		}
+
+		// Let scanner to recover from the failure before
+		// the next batch
+		if _, err = clnt.WaitIdle(ctx); err != nil {
+			return err
+		}
+
+		vars.Job++
+	}
+}
+
+// scanJob performs a single scan job and saves all received pages.
+// vars.Page is incremented for each saved page.
+//
+// If scanner is busy, scanJob waits up to the wait duration
+// for it to become ready (see scanStart).
+//
+// If ctx is canceled in the middle of the job, the job
+// is canceled at the scanner side.
+func scanJob(ctx context.Context, clnt *escl.Client, ss escl.ScanSettings,
+	wait time.Duration, tmpl *filename.Template, vars *filename.Vars) error {
+
+	joburl, err := scanStart(ctx, clnt, ss, wait)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(ctx, "job started: %s", joburl)
+
+	for {
+		doc, details, err := clnt.NextDocument(ctx, joburl)
+		if err == nil {
+			vars.MIMEType = details.Header.Get("Content-Type")
+
+			var name string
+			name, err = savePage(tmpl, *vars, doc)
+			doc.Close()
+
+			if err == nil {
+				log.Info(ctx, "%s: saved", name)
+				vars.Page++
+				continue
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			scanCancel(ctx, clnt, joburl)
+		}
+
+		return err
+	}
+}
+
+// scanStart starts the scan job and returns its URL.
+//
+// If scanner responds with 503 Service Unavailable, which means
+// it is busy, scanStart waits for it to become ready, using
+// escl.WaitReady, and retries, until the wait duration expires.
+// Zero wait means don't wait.
+func scanStart(ctx context.Context, clnt *escl.Client,
+	ss escl.ScanSettings, wait time.Duration) (string, error) {
+
+	deadline := time.Now().Add(wait)
+	options := escl.WaitReadyOptions{
+		ADFLoaded: optional.Get(ss.InputSource) == escl.InputFeeder,
+	}
+
+	for {
+		joburl, details, err := clnt.Scan(ctx, ss)
+		if err == nil || details == nil ||
+			details.StatusCode != http.StatusServiceUnavailable {
+			return joburl, err
+		}
+
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return "", err
+		}
+
+		log.Info(ctx, "scanner is busy, waiting...")
+
+		// Don't hammer the scanner, if its status is Idle
+		// but it still refuses to start the job
+		select {
+		case <-time.After(escl.WaitReadyMinInterval):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+
+		_, err = escl.WaitReady(ctx, clnt, timeout, options)
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// scanCancel cancels the scan job.
+//
+// It is called when ctx is already canceled, so request
+// is performed with the separate context with timeout.
+// Within that timeout, scanCancel waits for the scanner
+// to become Idle again.
+func scanCancel(ctx context.Context, clnt *escl.Client, joburl string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx),
+		scanCancelTimeout)
+	defer cancel()
+
+	_, err := clnt.Cancel(ctx, joburl)
+	if err != nil && err != io.EOF {
+		log.Error(ctx, "job cancel: %s", err)
+		return
+	}
+
+	log.Info(ctx, "job canceled")
+
+	// Wait until scanner finishes the cancellation, so the next
+	// request will not find it busy
+	if _, err = clnt.WaitIdle(ctx); err != nil {
+		log.Debug(ctx, "scanner not idle after cancel: %s", err)
+	}
+}
+
+// savePage saves the received page into the file, named
+// by the template. It returns the actual file name.
+//
+// On error, partially written file is removed.
+func savePage(tmpl *filename.Template, vars filename.Vars,
+	doc io.Reader) (string, error) {
+
+	file, err := tmpl.Create(vars)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(file, doc)
+	err2 := file.Close()
+	if err == nil {
+		err = err2
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+	}
+
+	return file.Name(), err
+}
+
+// scanSettings returns escl.ScanSettings for the scan.
+//
+// For preview, resolution is only set when explicitly requested,
+// as it is used as a hint for the preview resolution.
+func scanSettings(inv *argv.Invocation, preview bool) escl.ScanSettings {
+	format := DefaultFormat
+	if preview {
+		format = DefaultPreviewFormat
+	}
+	if s, ok := inv.Get("--image-format"); ok {
+		format = s
+	}
+
+	ss := escl.ScanSettings{
+		Version:        escl.DefaultVersion,
+		DocumentFormat: optional.New(format),
+		InputSource:    optional.New(escl.InputPlaten),
+		ColorMode:      optional.New(escl.RGB24),
+	}
+
+	res := DefaultResolution
+	s, ok := inv.Get("-r")
+	if ok {
+		res, _ = strconv.Atoi(s)
+	}
+
+	if ok || !preview {
+		ss.XResolution = optional.New(res)
+		ss.YResolution = optional.New(res)
+	}
+
+	switch s, _ := inv.Get("-s"); s {
+	case "adf":
+		ss.InputSource = optional.New(escl.InputFeeder)
+	case "duplex":
+		ss.InputSource = optional.New(escl.InputFeeder)
+		ss.Duplex = optional.New(true)
+	case "camera":
+		ss.InputSource = optional.New(escl.InputCamera)
+	}
+
+	switch s, _ := inv.Get("-m"); s {
+	case "gray":
+		ss.ColorMode = optional.New(escl.Grayscale8)
+	case "bw":
+		ss.ColorMode = optional.New(escl.BlackAndWhite1)
+	}
+
+	if s, ok := inv.Get("--color-mode"); ok {
+		ss.ColorMode = optional.New(escl.DecodeColorMode(s))
+	}
+
+	if s, ok := inv.Get("--intent"); ok {
+		ss.Intent = optional.New(escl.DecodeIntent(s))
+	}
+
+	if s, ok := inv.Get("--ccd-channel"); ok {
+		ss.CCDChannel = optional.New(escl.DecodeCCDChannel(s))
+	}
+
+	if s, ok := inv.Get("--media"); ok {
+		m, _ := media.Lookup(s)
+		ss.ScanRegions = []escl.ScanRegion{
+			m.ScanRegion(escl.ThreeHundredthsOfInches),
+		}
+	}
+
+	return ss
+}
@@ -0,0 +1,49 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "scan" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Download progress display
+
+package scan
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+)
+
+// progressShow displays the download progress of the page.
+//
+// Progress is displayed in a single line, updated in place,
+// and the line is terminated when page is received completely.
+func progressShow(w io.Writer, page int, p escl.Progress) {
+	kib := func(n int64) int64 { return (n + 1023) / 1024 }
+
+	s := fmt.Sprintf("page %d: %d KiB", page, kib(p.Received))
+
+	if percent := p.Percent(); percent >= 0 {
+		approx := ""
+		if p.Estimated && !p.Done {
+			approx = "~"
+		}
+
+		total := p.Total
+		if p.Done {
+			total = p.Received
+		}
+
+		s = fmt.Sprintf("page %d: %d of %s%d KiB (%d%%)",
+			page, kib(p.Received), approx, kib(total), percent)
+	}
+
+	end := ""
+	if p.Done {
+		end = "\n"
+	}
+
+	// Trailing spaces erase the rest of the previous, longer line
+	fmt.Fprintf(w, "\r%-40s%s", s, end)
+}
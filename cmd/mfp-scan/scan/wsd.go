@@ -0,0 +1,116 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "scan" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scanning via WS-Scan
+
+package scan
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/wsscan"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/filename"
+)
+
+// wsdScan performs the scan job via the WS-Scan protocol and
+// saves all received pages. vars.Page is incremented for each
+// saved page.
+//
+// u is the URL of the WS-Scan service endpoint.
+func wsdScan(ctx context.Context, inv *argv.Invocation, u *url.URL,
+	tr *transport.Transport, tmpl *filename.Template,
+	vars *filename.Vars) error {
+
+	ac, err := wsscan.NewAbstractClient(ctx, u,
+		wsscan.AbstractClientOptions{Transport: tr})
+	if err != nil {
+		return err
+	}
+
+	defer ac.Close()
+
+	if caps := ac.Capabilities(); caps.MakeAndModel != "" {
+		log.Debug(ctx, "scanner: %s", caps.MakeAndModel)
+	}
+
+	doc, err := ac.Scan(ctx, wsdRequest(inv))
+	if err != nil {
+		return err
+	}
+
+	defer doc.Close()
+
+	for {
+		file, err := doc.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		vars.MIMEType = file.Format()
+
+		name, err := savePage(tmpl, *vars, file)
+		if err != nil {
+			return err
+		}
+
+		log.Info(ctx, "%s: saved", name)
+		vars.Page++
+	}
+}
+
+// wsdRequest returns abstract.ScannerRequest for the WS-Scan scan.
+func wsdRequest(inv *argv.Invocation) abstract.ScannerRequest {
+	format := DefaultFormat
+	if s, ok := inv.Get("--image-format"); ok {
+		format = s
+	}
+
+	res := DefaultResolution
+	if s, ok := inv.Get("-r"); ok {
+		res, _ = strconv.Atoi(s)
+	}
+
+	req := abstract.ScannerRequest{
+		Input:          abstract.InputPlaten,
+		ColorMode:      abstract.ColorModeColor,
+		ColorDepth:     abstract.ColorDepth8,
+		DocumentFormat: format,
+		Resolution: abstract.Resolution{
+			XResolution: res,
+			YResolution: res,
+		},
+	}
+
+	switch s, _ := inv.Get("-s"); s {
+	case "adf":
+		req.Input = abstract.InputADF
+		req.ADFMode = abstract.ADFModeSimplex
+	case "duplex":
+		req.Input = abstract.InputADF
+		req.ADFMode = abstract.ADFModeDuplex
+	case "camera":
+		req.Input = abstract.InputCamera
+	}
+
+	switch s, _ := inv.Get("-m"); s {
+	case "gray":
+		req.ColorMode = abstract.ColorModeMono
+	case "bw":
+		req.ColorMode = abstract.ColorModeBinary
+		req.ColorDepth = abstract.ColorDepthUnset
+	}
+
+	return req
+}
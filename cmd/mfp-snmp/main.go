@@ -0,0 +1,19 @@
+// MFP          - Miulti-Function Printers and scanners toolkit
+// cmd/mfp-snmp - SNMP printer status query
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The main() function.
+
+package main
+
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-snmp/snmp"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
+
+// main function for the mfp-snmp command
+func main() {
+	cmdopt.Root(snmp.Command).Main(nil)
+}
@@ -0,0 +1,134 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "snmp" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package snmp
+
+import (
+	"context"
+	"io"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/internal/output"
+	"github.com/OpenPrinting/go-mfp/proto/snmp"
+)
+
+// description is printed as a command description text
+const description = "" +
+	"This command queries printer status via SNMP, using the\n" +
+	"Host Resources MIB (RFC 2790) and the Printer MIB (RFC 3805).\n" +
+	"\n" +
+	"It prints the printer state, toner and other supplies levels,\n" +
+	"page counters and active alerts.\n"
+
+// Command is the 'snmp' command description
+var Command = argv.Command{
+	Name:        "snmp",
+	Help:        "Query printer status via SNMP",
+	Description: description,
+	Options: []argv.Option{
+		argv.Option{
+			Name:     "-c",
+			Aliases:  []string{"--community"},
+			Help:     "SNMP community (default: public)",
+			HelpArg:  "name",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name: "--v1",
+			Help: "Use SNMPv1 instead of SNMPv2c",
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name: "HOST",
+			Help: "printer address, host or host:port",
+		},
+	},
+	Handler: cmdSnmpHandler,
+}
+
+// cmdSnmpHandler is the handler for the 'snmp' command.
+func cmdSnmpHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	// Prepare the client
+	host, _ := inv.Get("HOST")
+	community, _ := inv.Get("-c")
+
+	clnt := snmp.NewClient(host, community)
+	if _, v1 := inv.Get("--v1"); v1 {
+		clnt.Version = snmp.Version1
+	}
+
+	// Perform the query
+	status, err := clnt.GetPrinterStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Format output
+	pager := env.NewPager()
+	err = output.Render(pager, output.OptionGet(inv),
+		statusRecordMake(host, status),
+		func(io.Writer) { statusFormat(pager, host, status) })
+
+	if err != nil {
+		return err
+	}
+
+	return pager.Display()
+}
+
+// statusFormat pretty-prints the printer status
+func statusFormat(pager *env.Pager, host string, status *snmp.PrinterStatus) {
+	pager.Printf("Printer:     %s", host)
+	pager.Printf("Description: %q", status.Description)
+	pager.Printf("Name:        %q", status.Name)
+	pager.Printf("State:       %s", status.State)
+
+	if status.Errors != 0 {
+		pager.Printf("Errors:      %s", status.Errors)
+	}
+
+	pager.Printf("")
+	pager.Printf("Supplies:")
+	if len(status.Supplies) == 0 {
+		pager.Printf("  none reported")
+	}
+
+	for _, supply := range status.Supplies {
+		pager.Printf("  %-24s %-16s %s", supply.Description,
+			supply.TypeString(), supply.LevelString())
+	}
+
+	pager.Printf("")
+	pager.Printf("Counters:")
+	if len(status.Counters) == 0 {
+		pager.Printf("  none reported")
+	}
+
+	for _, counter := range status.Counters {
+		pager.Printf("  marker %d: %d %s", counter.Index,
+			counter.LifeCount, counter.UnitString())
+	}
+
+	pager.Printf("")
+	pager.Printf("Alerts:")
+	if len(status.Alerts) == 0 {
+		pager.Printf("  none")
+	}
+
+	for _, alert := range status.Alerts {
+		pager.Printf("  %-8s %q (code %d)", alert.SeverityString(),
+			alert.Description, alert.Code)
+	}
+}
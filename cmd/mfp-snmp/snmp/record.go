@@ -0,0 +1,101 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "snmp" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Printer status record for the --format output
+
+package snmp
+
+import (
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/proto/snmp"
+)
+
+// statusRecord is the printer status record for the --format output.
+type statusRecord struct {
+	Host        string          `json:"host"`
+	Description string          `json:"description"`
+	Name        string          `json:"name"`
+	State       string          `json:"state"`
+	Errors      []string        `json:"errors"`
+	Supplies    []supplyRecord  `json:"supplies"`
+	Counters    []counterRecord `json:"counters"`
+	Alerts      []alertRecord   `json:"alerts"`
+}
+
+// supplyRecord is the marker supply record.
+type supplyRecord struct {
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Level       int    `json:"level"`
+	MaxCapacity int    `json:"max-capacity"`
+	Percent     *int   `json:"percent"`
+}
+
+// counterRecord is the page counter record.
+type counterRecord struct {
+	Marker int    `json:"marker"`
+	Count  int    `json:"count"`
+	Unit   string `json:"unit"`
+}
+
+// alertRecord is the alert record.
+type alertRecord struct {
+	Severity    string `json:"severity"`
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+}
+
+// statusRecordMake makes statusRecord from the [snmp.PrinterStatus]
+func statusRecordMake(host string, status *snmp.PrinterStatus) statusRecord {
+	rec := statusRecord{
+		Host:        host,
+		Description: status.Description,
+		Name:        status.Name,
+		State:       status.State.String(),
+		Errors:      []string{},
+		Supplies:    []supplyRecord{},
+		Counters:    []counterRecord{},
+		Alerts:      []alertRecord{},
+	}
+
+	if status.Errors != 0 {
+		rec.Errors = strings.Split(status.Errors.String(), ",")
+	}
+
+	for _, supply := range status.Supplies {
+		suprec := supplyRecord{
+			Description: supply.Description,
+			Type:        supply.TypeString(),
+			Level:       supply.Level,
+			MaxCapacity: supply.MaxCapacity,
+		}
+
+		if pct := supply.Percent(); pct >= 0 {
+			suprec.Percent = &pct
+		}
+
+		rec.Supplies = append(rec.Supplies, suprec)
+	}
+
+	for _, counter := range status.Counters {
+		rec.Counters = append(rec.Counters, counterRecord{
+			Marker: counter.Index,
+			Count:  counter.LifeCount,
+			Unit:   counter.UnitString(),
+		})
+	}
+
+	for _, alert := range status.Alerts {
+		rec.Alerts = append(rec.Alerts, alertRecord{
+			Severity:    alert.SeverityString(),
+			Code:        alert.Code,
+			Description: alert.Description,
+		})
+	}
+
+	return rec
+}
@@ -0,0 +1,19 @@
+// MFP            - Miulti-Function Printers and scanners toolkit
+// cmd/mfp-status - Consolidated device status
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The main() function.
+
+package main
+
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-status/status"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
+
+// main function for the mfp-status command
+func main() {
+	cmdopt.Root(status.Command).Main(nil)
+}
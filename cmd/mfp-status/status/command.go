@@ -0,0 +1,216 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "status" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package status
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/internal/output"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/snmp"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// description is printed as a command description text
+const description = "" +
+	"This command queries the device status over multiple protocols\n" +
+	"and merges results into the single consolidated report:\n" +
+	"\n" +
+	"  IPP   printer-state and printer-state-reasons\n" +
+	"  eSCL  ScannerStatus, including the ADF state\n" +
+	"  SNMP  printer state, errors and supplies (with --snmp)\n" +
+	"\n" +
+	"By default, IPP and eSCL endpoints are derived from the DEVICE\n" +
+	"address (ipp://DEVICE/ipp/print and http://DEVICE/eSCL).\n" +
+	"Use --ipp and --escl to specify them explicitly.\n" +
+	"\n" +
+	"Discrepancies between protocols (e.g., paper jam reported by\n" +
+	"SNMP but not by IPP, or one protocol not responding) are\n" +
+	"highlighted at the end of the report.\n"
+
+// Command is the 'status' command description
+var Command = argv.Command{
+	Name:        "status",
+	Help:        "Consolidated device status",
+	Description: description,
+	Options: []argv.Option{
+		argv.Option{
+			Name:     "--ipp",
+			HelpArg:  "URL",
+			Help:     "IPP printer URL",
+			Validate: transport.ValidateURL,
+		},
+		argv.Option{
+			Name:     "--escl",
+			HelpArg:  "URL",
+			Help:     "eSCL scanner URL",
+			Validate: transport.ValidateURL,
+		},
+		argv.Option{
+			Name: "--snmp",
+			Help: "Query SNMP status and supplies",
+		},
+		argv.Option{
+			Name:     "-c",
+			Aliases:  []string{"--community"},
+			Help:     "SNMP community (default: public)",
+			HelpArg:  "name",
+			Validate: argv.ValidateAny,
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name: "DEVICE",
+			Help: "device address, host or host:port",
+		},
+	},
+	Handler: cmdStatusHandler,
+}
+
+// cmdStatusHandler is the handler for the 'status' command.
+func cmdStatusHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	// Prepare endpoints
+	device, _ := inv.Get("DEVICE")
+	if u, err := transport.ParseURL(device); err == nil &&
+		strings.Contains(device, "://") {
+		device = u.Host
+	}
+
+	ippURL := "ipp://" + device + "/ipp/print"
+	if s, ok := inv.Get("--ipp"); ok {
+		ippURL = s
+	}
+
+	esclURL := "http://" + device + "/eSCL"
+	if s, ok := inv.Get("--escl"); ok {
+		esclURL = s
+	}
+
+	_, useSNMP := inv.Get("--snmp")
+	community, _ := inv.Get("-c")
+
+	snmpHost := device
+	if host, _, err := net.SplitHostPort(device); err == nil {
+		snmpHost = host
+	}
+
+	// Query all protocols in parallel
+	statuses := []*protoStatus{nil, nil}
+	var snmpStatus *snmp.PrinterStatus
+	var wait sync.WaitGroup
+
+	wait.Add(2)
+	go func() {
+		statuses[0] = queryIPP(ctx, transport.MustParseURL(ippURL))
+		wait.Done()
+	}()
+	go func() {
+		statuses[1] = queryESCL(ctx, transport.MustParseURL(esclURL))
+		wait.Done()
+	}()
+
+	if useSNMP {
+		statuses = append(statuses, nil)
+		wait.Add(1)
+		go func() {
+			statuses[2], snmpStatus = querySNMP(ctx, snmpHost,
+				community)
+			wait.Done()
+		}()
+	}
+
+	wait.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	responding := false
+	for _, ps := range statuses {
+		if ps.ok() {
+			responding = true
+		} else {
+			log.Debug(ctx, "%s: %s", ps.proto, ps.err)
+		}
+	}
+
+	if !responding {
+		return errors.New(device + ": device is not responding")
+	}
+
+	// Merge and format output
+	rec := statusRecordMake(device, statuses, snmpStatus)
+
+	pager := env.NewPager()
+	err := output.Render(pager, output.OptionGet(inv), rec,
+		func(io.Writer) { statusFormat(pager, rec) })
+
+	if err != nil {
+		return err
+	}
+
+	return pager.Display()
+}
+
+// statusFormat pretty-prints the consolidated status
+func statusFormat(pager *env.Pager, rec statusRecord) {
+	pager.Printf("Device:  %s", rec.Device)
+	pager.Printf("Overall: %s", rec.Overall)
+
+	for _, proto := range rec.Protocols {
+		pager.Printf("")
+		pager.Printf("%s: %s", proto.Protocol, proto.Endpoint)
+
+		if proto.Error != "" {
+			pager.Printf("  error:      %s", proto.Error)
+			continue
+		}
+
+		pager.Printf("  state:      %s (%s)", proto.State, proto.Native)
+		if len(proto.Conditions) != 0 {
+			pager.Printf("  conditions: %s",
+				strings.Join(proto.Conditions, ", "))
+		}
+
+		for _, d := range proto.Details {
+			pager.Printf("  %s", d)
+		}
+	}
+
+	if len(rec.Supplies) != 0 {
+		pager.Printf("")
+		pager.Printf("Supplies:")
+		for _, supply := range rec.Supplies {
+			pager.Printf("  %-24s %-16s %s", supply.Description,
+				supply.Type, supply.Level)
+		}
+	}
+
+	pager.Printf("")
+	pager.Printf("Discrepancies:")
+	if len(rec.Discrepancies) == 0 {
+		pager.Printf("  none")
+	}
+
+	for _, d := range rec.Discrepancies {
+		pager.Printf("  ! %s", d)
+	}
+}
@@ -0,0 +1,104 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "status" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Merging of per-protocol statuses
+
+package status
+
+import (
+	"fmt"
+	"slices"
+)
+
+// mergeOverall returns the overall device state, which is the
+// most severe of the states, reported by the protocols.
+func mergeOverall(statuses []*protoStatus) devState {
+	overall := devStateUnknown
+	for _, ps := range statuses {
+		if ps.ok() && ps.state > overall {
+			overall = ps.state
+		}
+	}
+	return overall
+}
+
+// mergeDiscrepancies returns human-readable descriptions of
+// discrepancies between statuses, reported by different protocols.
+//
+// The following is considered discrepancy:
+//   - one protocol responds while other doesn't
+//   - one protocol reports stopped or down state, while
+//     other reports device as operational
+//   - IPP and SNMP, which both describe the print engine,
+//     report different conditions (jam, no-paper, ...)
+func mergeDiscrepancies(statuses []*protoStatus) []string {
+	var out []string
+
+	for i, ps1 := range statuses {
+		for _, ps2 := range statuses[i+1:] {
+			out = append(out, mergeCompare(ps1, ps2)...)
+		}
+	}
+
+	return out
+}
+
+// mergeCompare compares two protocol statuses.
+func mergeCompare(ps1, ps2 *protoStatus) []string {
+	var out []string
+
+	// Check reachability
+	switch {
+	case !ps1.ok() && !ps2.ok():
+		return nil
+	case !ps1.ok():
+		return []string{fmt.Sprintf("%s is not responding, while %s is",
+			ps1.proto, ps2.proto)}
+	case !ps2.ok():
+		return []string{fmt.Sprintf("%s is not responding, while %s is",
+			ps2.proto, ps1.proto)}
+	}
+
+	// Check states
+	if mergeStateFailed(ps1.state) != mergeStateFailed(ps2.state) &&
+		ps1.state != devStateUnknown && ps2.state != devStateUnknown {
+		out = append(out, fmt.Sprintf("%s reports %s, %s reports %s",
+			ps1.proto, ps1.state, ps2.proto, ps2.state))
+	}
+
+	// Check conditions. Only statuses of the print engine
+	// are comparable.
+	if mergePrintEngine(ps1) && mergePrintEngine(ps2) {
+		for _, cond := range ps1.conditions {
+			if !slices.Contains(ps2.conditions, cond) {
+				out = append(out, fmt.Sprintf(
+					"%s: reported by %s, but not by %s",
+					cond, ps1.proto, ps2.proto))
+			}
+		}
+
+		for _, cond := range ps2.conditions {
+			if !slices.Contains(ps1.conditions, cond) {
+				out = append(out, fmt.Sprintf(
+					"%s: reported by %s, but not by %s",
+					cond, ps2.proto, ps1.proto))
+			}
+		}
+	}
+
+	return out
+}
+
+// mergeStateFailed tells if state means non-operational device.
+func mergeStateFailed(state devState) bool {
+	return state == devStateStopped || state == devStateDown
+}
+
+// mergePrintEngine tells if protocol status describes
+// the print engine.
+func mergePrintEngine(ps *protoStatus) bool {
+	return ps.proto == "IPP" || ps.proto == "SNMP"
+}
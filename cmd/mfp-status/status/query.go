@@ -0,0 +1,263 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "status" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Per-protocol status queries
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/go-mfp/proto/snmp"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// devState is the protocol-independent device state.
+//
+// States are ordered by severity, so the overall state
+// is the maximum of the states, reported by protocols.
+type devState int
+
+// devState values:
+const (
+	devStateUnknown devState = iota // State is not known
+	devStateIdle                    // Device is idle
+	devStateBusy                    // Device is doing some work
+	devStateStopped                 // Stopped: error condition occurred
+	devStateDown                    // Down: unit is unavailable
+)
+
+// String returns devState name.
+func (state devState) String() string {
+	switch state {
+	case devStateIdle:
+		return "idle"
+	case devStateBusy:
+		return "busy"
+	case devStateStopped:
+		return "stopped"
+	case devStateDown:
+		return "down"
+	}
+
+	return "unknown"
+}
+
+// protoStatus is the device status, reported by the single protocol.
+type protoStatus struct {
+	proto      string   // "IPP", "eSCL" or "SNMP"
+	endpoint   string   // Endpoint URL or address
+	state      devState // Normalized state
+	native     string   // Protocol-specific state
+	conditions []string // Normalized conditions (jam, no-paper, ...)
+	details    []string // Other protocol-specific details
+	err        error    // Query error
+}
+
+// ok returns true if protocol query was successful.
+func (ps *protoStatus) ok() bool {
+	return ps.err == nil
+}
+
+// addCondition adds the normalized condition, if not added yet.
+func (ps *protoStatus) addCondition(cond string) {
+	for _, c := range ps.conditions {
+		if c == cond {
+			return
+		}
+	}
+	ps.conditions = append(ps.conditions, cond)
+}
+
+// queryTransport creates the HTTP transport for device queries.
+// Status must be reported even by devices with broken firmware, so malformed responses are salvaged.
+func queryTransport() *transport.Transport {
+	tr := transport.NewTransport(nil)
+	tr.Tolerant = true
+	return tr
+}
+
+// queryIPP queries printer status via IPP.
+func queryIPP(ctx context.Context, u *url.URL) *protoStatus {
+	ps := &protoStatus{proto: "IPP", endpoint: u.String()}
+
+	clnt := ipp.NewClient(u, queryTransport())
+	attrs, err := clnt.GetPrinterAttributes(ctx, []string{
+		"printer-state",
+		"printer-state-reasons",
+		"printer-state-message",
+	})
+
+	if err != nil {
+		ps.err = err
+		return ps
+	}
+
+	switch attrs.PrinterState {
+	case 3:
+		ps.native = "idle"
+		ps.state = devStateIdle
+	case 4:
+		ps.native = "processing"
+		ps.state = devStateBusy
+	case 5:
+		ps.native = "stopped"
+		ps.state = devStateStopped
+	default:
+		ps.native = fmt.Sprintf("unknown(%d)", attrs.PrinterState)
+	}
+
+	for _, reason := range attrs.PrinterStateReasons {
+		s := string(reason)
+		if s == string(ipp.KwPrinterStateNone) ||
+			strings.HasSuffix(s, string(ipp.KwPrinterStateReport)) {
+			continue
+		}
+
+		s = strings.TrimSuffix(s, string(ipp.KwPrinterStateWarning))
+		s = strings.TrimSuffix(s, string(ipp.KwPrinterStateError))
+
+		if cond := ippConditions[ipp.KwPrinterStateReasons(s)]; cond != "" {
+			ps.addCondition(cond)
+		} else {
+			ps.details = append(ps.details, "reason: "+string(reason))
+		}
+	}
+
+	if attrs.PrinterStateMessage != "" {
+		ps.details = append(ps.details,
+			fmt.Sprintf("message: %q", attrs.PrinterStateMessage))
+	}
+
+	return ps
+}
+
+// ippConditions maps printer-state-reasons into normalized conditions
+var ippConditions = map[ipp.KwPrinterStateReasons]string{
+	ipp.KwPrinterStateMediaJam:          "jam",
+	ipp.KwPrinterStateMediaEmpty:        "no-paper",
+	ipp.KwPrinterStateMediaNeeded:       "no-paper",
+	ipp.KwPrinterStateMediaLow:          "low-paper",
+	ipp.KwPrinterStateDoorOpen:          "door-open",
+	ipp.KwPrinterStateCoverOpen:         "door-open",
+	ipp.KwPrinterStateTonerEmpty:        "no-toner",
+	ipp.KwPrinterStateMarkerSupplyEmpty: "no-toner",
+	ipp.KwPrinterStateTonerLow:          "low-toner",
+	ipp.KwPrinterStateMarkerSupplyLow:   "low-toner",
+	ipp.KwPrinterStateInputTrayMissing:  "input-tray-missing",
+	ipp.KwPrinterStateOutputTrayMissing: "output-tray-missing",
+	ipp.KwPrinterStateOutputAreaFull:    "output-full",
+	ipp.KwPrinterStateShutdown:          "offline",
+}
+
+// queryESCL queries scanner status via eSCL.
+func queryESCL(ctx context.Context, u *url.URL) *protoStatus {
+	ps := &protoStatus{proto: "eSCL", endpoint: u.String()}
+
+	clnt := escl.NewClient(u, queryTransport())
+	status, _, err := clnt.GetScannerStatus(ctx)
+	if err != nil {
+		ps.err = err
+		return ps
+	}
+
+	ps.native = status.State.String()
+
+	switch status.State {
+	case escl.ScannerIdle:
+		ps.state = devStateIdle
+	case escl.ScannerProcessing, escl.ScannerTesting:
+		ps.state = devStateBusy
+	case escl.ScannerStopped:
+		ps.state = devStateStopped
+	case escl.ScannerDown:
+		ps.state = devStateDown
+	}
+
+	if status.ADFState != nil {
+		adf := optional.Get(status.ADFState)
+		ps.details = append(ps.details, "ADF: "+adf.String())
+
+		switch adf {
+		case escl.ScannerAdfJam, escl.ScannerAdfMispick,
+			escl.ScannerAdfMultipickDetected:
+			ps.addCondition("adf-jam")
+		case escl.ScannerAdfHatchOpen:
+			ps.addCondition("adf-open")
+		}
+	}
+
+	return ps
+}
+
+// querySNMP queries printer status via SNMP.
+//
+// Besides the protoStatus, it returns the complete SNMP status,
+// for supplies reporting. On error, the returned
+// snmp.PrinterStatus is nil.
+func querySNMP(ctx context.Context, host, community string) (
+	*protoStatus, *snmp.PrinterStatus) {
+
+	ps := &protoStatus{proto: "SNMP", endpoint: host}
+
+	clnt := snmp.NewClient(host, community)
+	status, err := clnt.GetPrinterStatus(ctx)
+	if err != nil {
+		ps.err = err
+		return ps, nil
+	}
+
+	ps.native = status.State.String()
+
+	for _, c := range snmpConditions {
+		if status.Errors&c.bit != 0 {
+			ps.addCondition(c.cond)
+		}
+	}
+
+	switch status.State {
+	case snmp.PrinterStateIdle:
+		ps.state = devStateIdle
+	case snmp.PrinterStatePrinting, snmp.PrinterStateWarmup:
+		ps.state = devStateBusy
+	default:
+		// hrPrinterStatus is "other" when printer is stopped
+		// due to error condition
+		if status.Errors != 0 {
+			ps.state = devStateStopped
+		}
+	}
+
+	if status.Errors != 0 {
+		ps.details = append(ps.details, "errors: "+status.Errors.String())
+	}
+
+	return ps, status
+}
+
+// snmpConditions maps hrPrinterDetectedErrorState bits into
+// normalized conditions
+var snmpConditions = []struct {
+	bit  snmp.PrinterErrors
+	cond string
+}{
+	{snmp.PrinterErrJammed, "jam"},
+	{snmp.PrinterErrNoPaper, "no-paper"},
+	{snmp.PrinterErrLowPaper, "low-paper"},
+	{snmp.PrinterErrDoorOpen, "door-open"},
+	{snmp.PrinterErrNoToner, "no-toner"},
+	{snmp.PrinterErrLowToner, "low-toner"},
+	{snmp.PrinterErrInputTrayMissing, "input-tray-missing"},
+	{snmp.PrinterErrOutputTrayMissing, "output-tray-missing"},
+	{snmp.PrinterErrOutputFull, "output-full"},
+	{snmp.PrinterErrOffline, "offline"},
+}
@@ -0,0 +1,91 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "status" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Consolidated status record for the --format output
+
+package status
+
+import (
+	"github.com/OpenPrinting/go-mfp/proto/snmp"
+)
+
+// statusRecord is the consolidated status record for the
+// --format output.
+type statusRecord struct {
+	Device        string         `json:"device"`
+	Overall       string         `json:"overall"`
+	Protocols     []protoRecord  `json:"protocols"`
+	Supplies      []supplyRecord `json:"supplies"`
+	Discrepancies []string       `json:"discrepancies"`
+}
+
+// protoRecord is the per-protocol status record.
+type protoRecord struct {
+	Protocol   string   `json:"protocol"`
+	Endpoint   string   `json:"endpoint"`
+	State      string   `json:"state,omitempty"`
+	Native     string   `json:"native-state,omitempty"`
+	Conditions []string `json:"conditions"`
+	Details    []string `json:"details"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// supplyRecord is the marker supply record.
+type supplyRecord struct {
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Level       string `json:"level"`
+}
+
+// statusRecordMake makes statusRecord from the per-protocol statuses.
+// The snmpStatus may be nil.
+func statusRecordMake(device string, statuses []*protoStatus,
+	snmpStatus *snmp.PrinterStatus) statusRecord {
+
+	rec := statusRecord{
+		Device:        device,
+		Overall:       mergeOverall(statuses).String(),
+		Protocols:     []protoRecord{},
+		Supplies:      []supplyRecord{},
+		Discrepancies: mergeDiscrepancies(statuses),
+	}
+
+	if rec.Discrepancies == nil {
+		rec.Discrepancies = []string{}
+	}
+
+	for _, ps := range statuses {
+		prec := protoRecord{
+			Protocol:   ps.proto,
+			Endpoint:   ps.endpoint,
+			Conditions: []string{},
+			Details:    []string{},
+		}
+
+		if ps.ok() {
+			prec.State = ps.state.String()
+			prec.Native = ps.native
+			prec.Conditions = append(prec.Conditions, ps.conditions...)
+			prec.Details = append(prec.Details, ps.details...)
+		} else {
+			prec.Error = ps.err.Error()
+		}
+
+		rec.Protocols = append(rec.Protocols, prec)
+	}
+
+	if snmpStatus != nil {
+		for _, supply := range snmpStatus.Supplies {
+			rec.Supplies = append(rec.Supplies, supplyRecord{
+				Description: supply.Description,
+				Type:        supply.TypeString(),
+				Level:       supply.LevelString(),
+			})
+		}
+	}
+
+	return rec
+}
@@ -0,0 +1,19 @@
+// MFP           - Miulti-Function Printers and scanners toolkit
+// cmd/mfp-trace - Protocol traffic recorder
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The main() function.
+
+package main
+
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-trace/trace"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
+
+// main function for the mfp-trace command
+func main() {
+	cmdopt.Root(trace.Command).Main(nil)
+}
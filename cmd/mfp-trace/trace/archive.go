@@ -0,0 +1,313 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "trace" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Trace archive
+
+package trace
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// archiveIndexName is the name of the archive index file.
+const archiveIndexName = "index.json"
+
+// archiveVersion is the current version of the archive format.
+const archiveVersion = 1
+
+// The trace archive is the TAR file with the following content:
+//
+//	NNNN-request.http   - raw HTTP request of the N-th exchange
+//	NNNN-response.http  - raw HTTP response of the N-th exchange
+//	index.json          - the archiveIndex
+//
+// Raw HTTP requests and responses always have the Content-Length
+// header, so they can be parsed with http.ReadRequest and
+// http.ReadResponse.
+//
+// The index.json file is written last.
+//
+// As archives are intended to be attached to bug reports, the
+// credentials are redacted before writing: values of the headers,
+// listed in archiveRedactHeaders, are replaced with the
+// archiveRedacted string, and userinfo is dropped from URLs.
+
+// archiveRedactHeaders are the HTTP headers, redacted
+// in the archive.
+var archiveRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// archiveRedacted replaces values of the redacted headers.
+const archiveRedacted = "[REDACTED]"
+
+// archiveIndex is the archive index.
+type archiveIndex struct {
+	Version   int            `json:"version"`
+	Command   []string       `json:"command"`
+	Start     time.Time      `json:"start"`
+	Exchanges []archiveEntry `json:"exchanges"`
+}
+
+// archiveEntry is the archive index entry, one per exchange.
+type archiveEntry struct {
+	Seq      int     `json:"seq"`
+	Method   string  `json:"method"`
+	URL      string  `json:"url"`
+	Start    float64 `json:"start-ms"`
+	Elapsed  float64 `json:"elapsed-ms"`
+	Status   int     `json:"status,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Request  string  `json:"request"`
+	Response string  `json:"response,omitempty"`
+}
+
+// archiveWriter writes the trace archive.
+// It implements the transport.Recorder interface.
+type archiveWriter struct {
+	fp    *os.File     // Underlying file
+	tar   *tar.Writer  // TAR writer
+	index archiveIndex // Archive index
+	lock  sync.Mutex   // Access lock
+	err   error        // First error
+}
+
+// newArchiveWriter creates a new archiveWriter.
+func newArchiveWriter(name string, command []string) (
+	*archiveWriter, error) {
+
+	const flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	fp, err := os.OpenFile(name, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &archiveWriter{
+		fp:  fp,
+		tar: tar.NewWriter(fp),
+		index: archiveIndex{
+			Version:   archiveVersion,
+			Command:   command,
+			Start:     time.Now(),
+			Exchanges: []archiveEntry{},
+		},
+	}
+
+	return w, nil
+}
+
+// Record records the exchange.
+func (w *archiveWriter) Record(xchg *transport.Exchange) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	seq := len(w.index.Exchanges) + 1
+	ent := archiveEntry{
+		Seq:     seq,
+		Method:  xchg.Method,
+		URL:     archiveRedactURL(xchg.URL),
+		Start:   durationMs(xchg.Start.Sub(w.index.Start)),
+		Elapsed: durationMs(xchg.Elapsed),
+		Status:  xchg.StatusCode,
+		Request: fmt.Sprintf("%4.4d-request.http", seq),
+	}
+
+	// Save request
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s %s HTTP/1.1\r\n",
+		xchg.Method, xchg.URL.RequestURI())
+	fmt.Fprintf(buf, "Host: %s\r\n", xchg.URL.Host)
+	archiveWriteBody(buf, xchg.RequestHeader, xchg.RequestBody)
+	w.writeFile(ent.Request, buf.Bytes())
+
+	// Save response
+	if xchg.Err != nil {
+		ent.Error = xchg.Err.Error()
+	} else {
+		ent.Response = fmt.Sprintf("%4.4d-response.http", seq)
+
+		buf.Reset()
+		fmt.Fprintf(buf, "HTTP/1.1 %s\r\n", xchg.Status)
+		archiveWriteBody(buf, xchg.ResponseHeader, xchg.ResponseBody)
+		w.writeFile(ent.Response, buf.Bytes())
+	}
+
+	w.index.Exchanges = append(w.index.Exchanges, ent)
+}
+
+// Count returns count of recorded exchanges.
+func (w *archiveWriter) Count() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return len(w.index.Exchanges)
+}
+
+// Close writes the archive index and closes the archive.
+func (w *archiveWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	data, err := json.MarshalIndent(w.index, "", "  ")
+	if err != nil {
+		w.setError(err)
+	}
+
+	w.writeFile(archiveIndexName, data)
+	w.setError(w.tar.Close())
+	w.setError(w.fp.Close())
+
+	return w.err
+}
+
+// writeFile writes a file into the archive.
+//
+// This function must be called under w.lock
+func (w *archiveWriter) writeFile(name string, data []byte) {
+	if w.err != nil {
+		return
+	}
+
+	hdr := tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0644,
+		ModTime:  time.Now(),
+	}
+
+	err := w.tar.WriteHeader(&hdr)
+	if err == nil {
+		_, err = w.tar.Write(data)
+	}
+
+	w.setError(err)
+}
+
+// setError sets w.err, when error occurs for the first time.
+//
+// This function must be called under w.lock
+func (w *archiveWriter) setError(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// archiveRedactURL returns URL as string, with userinfo removed.
+func archiveRedactURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+
+	u2 := *u
+	u2.User = nil
+	return u2.String()
+}
+
+// archiveWriteBody writes HTTP header and body. Content-Length
+// header is always written and Transfer-Encoding is dropped, as
+// body is saved as a whole. Credentials are redacted.
+func archiveWriteBody(buf *bytes.Buffer, hdr http.Header, body []byte) {
+	hdr = hdr.Clone()
+	if hdr == nil {
+		hdr = make(http.Header)
+	}
+
+	for _, name := range archiveRedactHeaders {
+		if hdr.Get(name) != "" {
+			hdr.Set(name, archiveRedacted)
+		}
+	}
+
+	hdr.Del("Transfer-Encoding")
+	hdr.Set("Content-Length", strconv.Itoa(len(body)))
+
+	hdr.Write(buf)
+	buf.WriteString("\r\n")
+	buf.Write(body)
+}
+
+// archive is the loaded trace archive.
+type archive struct {
+	index archiveIndex      // Archive index
+	files map[string][]byte // Files by name
+}
+
+// loadArchive loads the trace archive.
+//
+// Exchanges in the loaded archive index are sorted by
+// their start time.
+func loadArchive(name string) (*archive, error) {
+	fp, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	arc := &archive{files: make(map[string][]byte)}
+	rd := tar.NewReader(fp)
+
+	for {
+		hdr, err := rd.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		data, err := io.ReadAll(rd)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		arc.files[hdr.Name] = data
+	}
+
+	data, ok := arc.files[archiveIndexName]
+	if !ok {
+		err := errors.New("missed " + archiveIndexName)
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	err = json.Unmarshal(data, &arc.index)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", name, archiveIndexName, err)
+	}
+
+	if arc.index.Version != archiveVersion {
+		err := fmt.Errorf("unsupported archive version %d",
+			arc.index.Version)
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	sort.SliceStable(arc.index.Exchanges, func(i, j int) bool {
+		return arc.index.Exchanges[i].Start <
+			arc.index.Exchanges[j].Start
+	})
+
+	return arc, nil
+}
+
+// durationMs returns duration in milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,39 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "trace" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package trace
+
+import "github.com/OpenPrinting/go-mfp/argv"
+
+// description is printed as a command description text
+const description = "" +
+	"This command records HTTP, IPP and eSCL traffic of other\n" +
+	"commands into the self-contained archive and replays the\n" +
+	"recorded traffic against the device or emulator.\n" +
+	"\n" +
+	"Recorded archives are useful for bug reports and regression\n" +
+	"tests. For example:\n" +
+	"\n" +
+	"  mfp trace record -o scan.tar -- scan http://host/eSCL\n" +
+	"  mfp emulate --escl --port 8080 &\n" +
+	"  mfp trace replay scan.tar http://localhost:8080\n"
+
+// Command is the 'trace' command description
+var Command = argv.Command{
+	Name:        "trace",
+	Help:        "Record and replay protocol traffic",
+	Description: description,
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	SubCommands: []argv.Command{
+		cmdRecord,
+		cmdReplay,
+		argv.HelpCommand,
+	},
+}
@@ -0,0 +1,94 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "trace" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "record" sub-command
+
+package trace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// DefaultOutput is the default name of the trace archive
+const DefaultOutput = "mfp-trace.tar"
+
+// cmdRecord defines the "record" sub-command.
+var cmdRecord = argv.Command{
+	Name: "record",
+	Help: "Record traffic of the command",
+	Description: "" +
+		"The command is executed as a sub-command of the top-level\n" +
+		"command (i.e., \"mfp trace record -- cups get-printers\"\n" +
+		"records traffic of the \"mfp cups get-printers\"), and all\n" +
+		"its HTTP exchanges (requests, responses and timings) are\n" +
+		"saved into the archive.\n",
+	NoOptionsAfterParameters: true,
+	Options: []argv.Option{
+		argv.Option{
+			Name:    "-o",
+			Aliases: []string{"--output"},
+			HelpArg: "file",
+			Help: fmt.Sprintf("Output archive. Default: %s",
+				DefaultOutput),
+			Validate: argv.ValidateAny,
+			Complete: argv.CompleteOSPath,
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name: "command",
+			Help: "command to execute",
+		},
+		{
+			Name: "[argument...]",
+			Help: "command arguments",
+		},
+	},
+	Handler: cmdRecordHandler,
+}
+
+// cmdRecordHandler is the "record" command handler
+func cmdRecordHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	output := DefaultOutput
+	if s, ok := inv.Get("-o"); ok {
+		output = s
+	}
+
+	cmdline := append(inv.Values("command"), inv.Values("argument")...)
+
+	// Run the command with the Recorder attached
+	arc, err := newArchiveWriter(output, cmdline)
+	if err != nil {
+		return err
+	}
+
+	root := inv.Root().Cmd()
+	err = root.RunWithParent(transport.NewRecorderContext(ctx, arc),
+		nil, cmdline)
+
+	err2 := arc.Close()
+	if err2 != nil {
+		err2 = fmt.Errorf("%s: %w", output, err2)
+	} else {
+		log.Info(ctx, "%s: %d exchanges recorded", output, arc.Count())
+	}
+
+	if err == nil {
+		err = err2
+	}
+
+	return err
+}
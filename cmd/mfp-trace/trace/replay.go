@@ -0,0 +1,242 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "trace" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "replay" sub-command
+
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// cmdReplay defines the "replay" sub-command.
+var cmdReplay = argv.Command{
+	Name: "replay",
+	Help: "Replay recorded traffic against the device or emulator",
+	Description: "" +
+		"Recorded requests are sent to the target URL in the original\n" +
+		"order, and the response status and Content-Type are compared\n" +
+		"with the recorded ones.\n" +
+		"\n" +
+		"Only the scheme and host of the target URL are used; paths\n" +
+		"are taken from the archive. If server returns a Location\n" +
+		"different from the recorded one (i.e., the scan job URL),\n" +
+		"subsequent requests are adjusted accordingly.\n",
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "archive",
+			Help:     "trace archive, created by the \"record\" command",
+			Complete: argv.CompleteOSPath,
+		},
+		{
+			Name:     "URL",
+			Help:     "target URL (i.e., http://localhost:8080)",
+			Validate: transport.ValidateURL,
+		},
+	},
+	Handler: cmdReplayHandler,
+}
+
+// replayResult is the result of the single exchange replay.
+type replayResult struct {
+	recorded int    // Recorded status code
+	replayed int    // Replayed status code
+	diff     string // Difference, "" if none
+}
+
+// cmdReplayHandler is the "replay" command handler
+func cmdReplayHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	name, _ := inv.Get("archive")
+	param, _ := inv.Get("URL")
+	target := transport.MustParseURL(param)
+
+	arc, err := loadArchive(name)
+	if err != nil {
+		return err
+	}
+
+	// Replay all exchanges
+	clnt := transport.NewClient(nil)
+	remap := make(map[string]string)
+	pager := env.NewPager()
+	total, diffs := 0, 0
+
+	pager.Printf("%-4s %-6s %-40s %-8s %-8s %s",
+		"SEQ", "METHOD", "PATH", "RECORDED", "REPLAYED", "RESULT")
+
+	for _, ent := range arc.index.Exchanges {
+		if ent.Error != "" {
+			log.Debug(ctx, "%4.4d: skipped: %s", ent.Seq, ent.Error)
+			continue
+		}
+
+		total++
+		path, res, err := replayExchange(ctx, clnt, target,
+			arc, ent, remap)
+
+		status := "OK"
+		switch {
+		case err != nil:
+			status = err.Error()
+			diffs++
+		case res.diff != "":
+			status = "DIFF: " + res.diff
+			diffs++
+		}
+
+		pager.Printf("%4.4d %-6s %-40s %-8d %-8d %s",
+			ent.Seq, ent.Method, path,
+			res.recorded, res.replayed, status)
+	}
+
+	err = pager.Display()
+	if err == nil && diffs != 0 {
+		err = fmt.Errorf("%d of %d exchanges differ", diffs, total)
+	}
+
+	return err
+}
+
+// replayExchange replays the single exchange.
+//
+// remap contains recorded->replayed mapping of path prefixes,
+// learned from the Location headers. It is updated as needed.
+//
+// It returns the actual request path and result.
+func replayExchange(ctx context.Context, clnt *transport.Client,
+	target *url.URL, arc *archive, ent archiveEntry,
+	remap map[string]string) (string, replayResult, error) {
+
+	var res replayResult
+
+	// Load recorded request and response
+	rqRec, err := http.ReadRequest(replayReader(arc, ent.Request))
+	if err != nil {
+		return "", res, fmt.Errorf("%s: %w", ent.Request, err)
+	}
+
+	rqBody, err := io.ReadAll(rqRec.Body)
+	if err != nil {
+		return "", res, fmt.Errorf("%s: %w", ent.Request, err)
+	}
+
+	rspRec, err := http.ReadResponse(replayReader(arc, ent.Response),
+		rqRec)
+	if err != nil {
+		return "", res, fmt.Errorf("%s: %w", ent.Response, err)
+	}
+
+	res.recorded = rspRec.StatusCode
+
+	// Build the request
+	path := rqRec.URL.Path
+	if u, err := url.Parse(ent.URL); err == nil && u.Scheme == "unix" {
+		// The unix socket path is not the HTTP path
+		path = "/"
+	}
+
+	path = replayRemap(path, remap)
+
+	u := transport.URLClone(target)
+	u.Path = path
+	u.RawPath = ""
+	u.RawQuery = rqRec.URL.RawQuery
+
+	rq, err := transport.NewRequest(ctx, rqRec.Method, u,
+		bytes.NewReader(rqBody))
+	if err != nil {
+		return path, res, err
+	}
+
+	for name, values := range rqRec.Header {
+		rq.Header[name] = values
+	}
+
+	// Execute the request
+	rsp, err := clnt.Do(rq)
+	if err != nil {
+		return path, res, err
+	}
+
+	io.Copy(io.Discard, rsp.Body)
+	rsp.Body.Close()
+
+	res.replayed = rsp.StatusCode
+
+	// Compare results
+	recType := replayMediaType(rspRec.Header)
+	repType := replayMediaType(rsp.Header)
+
+	switch {
+	case res.recorded != res.replayed:
+		res.diff = "status"
+	case recType != repType:
+		res.diff = fmt.Sprintf("Content-Type %q vs %q",
+			recType, repType)
+	}
+
+	// Learn the path mapping
+	recLoc, err1 := rspRec.Location()
+	repLoc, err2 := rsp.Location()
+	if err1 == nil && err2 == nil && recLoc.Path != repLoc.Path {
+		remap[recLoc.Path] = repLoc.Path
+	}
+
+	return path, res, nil
+}
+
+// replayReader returns bufio.Reader for the archived file.
+// Missed file reads as empty.
+func replayReader(arc *archive, name string) *bufio.Reader {
+	return bufio.NewReader(bytes.NewReader(arc.files[name]))
+}
+
+// replayRemap applies the path prefix mapping to the path.
+func replayRemap(path string, remap map[string]string) string {
+	for from, to := range remap {
+		if path == from || strings.HasPrefix(path, from+"/") {
+			return to + path[len(from):]
+		}
+	}
+
+	return path
+}
+
+// replayMediaType returns media type of the Content-Type header,
+// without parameters.
+func replayMediaType(hdr http.Header) string {
+	ct := hdr.Get("Content-Type")
+	if ct == "" {
+		return ""
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ct
+	}
+
+	return mt
+}
@@ -8,9 +8,12 @@
 
 package main
 
-import "github.com/OpenPrinting/go-mfp/cmd/mfp-virtual/virtual"
+import (
+	"github.com/OpenPrinting/go-mfp/cmd/mfp-virtual/virtual"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+)
 
 // main function for the mfp-cups command
 func main() {
-	virtual.Command.Main(nil)
+	cmdopt.Root(virtual.Command).Main(nil)
 }
@@ -14,7 +14,7 @@ import (
 	"strconv"
 
 	"github.com/OpenPrinting/go-mfp/argv"
-	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
 )
 
 // DefaultTCPPort is the default TCP port for the MFP simulator
@@ -24,6 +24,10 @@ const DefaultTCPPort = 50000
 const description = "" +
 	"This command runs the MFP simulator\n" +
 	"\n" +
+	"The simulated scanner is served via eSCL and WS-Scan. The\n" +
+	"latter is announced via WS-Discovery, so WSD clients, like\n" +
+	"Windows, can find it.\n" +
+	"\n" +
 	"If optional command is specified, the CUPS_SERVER and the\n" +
 	"SANE_AIRSCAN_DEVICE environment variables will be set properly\n" +
 	"and the command will be executed, The simulator will exit when\n" +
@@ -39,16 +43,6 @@ var Command = argv.Command{
 	Description:              description,
 	NoOptionsAfterParameters: true,
 	Options: []argv.Option{
-		argv.Option{
-			Name:    "-d",
-			Aliases: []string{"--debug"},
-			Help:    "Enable debug output",
-		},
-		argv.Option{
-			Name:    "-v",
-			Aliases: []string{"--verbose"},
-			Help:    "Enable verbose debug output",
-		},
 		argv.Option{
 			Name:    "-p",
 			Aliases: []string{"--port"},
@@ -75,19 +69,7 @@ var Command = argv.Command{
 // cmdVirtualHandler is the top-level handler for the 'cups' command.
 func cmdVirtualHandler(ctx context.Context, inv *argv.Invocation) error {
 	// Setup logging
-	_, dbg := inv.Get("-d")
-	_, vrb := inv.Get("-v")
-
-	level := log.LevelInfo
-	if dbg {
-		level = log.LevelDebug
-	}
-	if vrb {
-		level = log.LevelTrace
-	}
-
-	logger := log.NewLogger(level, log.Console)
-	ctx = log.NewContext(ctx, logger)
+	ctx = cmdopt.LogContext(ctx, inv)
 
 	port := DefaultTCPPort
 	if portname, ok := inv.Get("-p"); ok {
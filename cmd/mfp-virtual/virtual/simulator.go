@@ -12,17 +12,27 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 
 	"github.com/OpenPrinting/go-mfp/abstract"
 	"github.com/OpenPrinting/go-mfp/internal/env"
 	"github.com/OpenPrinting/go-mfp/internal/testutils"
 	"github.com/OpenPrinting/go-mfp/log"
 	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/proto/wsscan"
 	"github.com/OpenPrinting/go-mfp/transport"
 	"github.com/OpenPrinting/go-mfp/util/generic"
 	"github.com/OpenPrinting/go-mfp/util/uuid"
 )
 
+// HTTP paths of the simulated services
+const (
+	esclPath   = "/eSCL"       // eSCL scanner
+	wsdPath    = "/wsd"        // WSD device metadata
+	wsscanPath = "/WSDScanner" // WS-Scan scanner
+)
+
 func scannerCapabilities() *abstract.ScannerCapabilities {
 	colorModes := generic.MakeBitset(
 		abstract.ColorModeBinary,
@@ -106,23 +116,43 @@ func simulate(ctx context.Context, port int, argv []string) error {
 			testutils.Images.PNG5100x7016,
 			testutils.Images.PNG5100x7016,
 		},
+		Spool: true,
 	}
 
-	// Create a virtual server
-	options := escl.AbstractServerOptions{
+	// Create virtual servers
+	esclServer := escl.NewAbstractServer(ctx, escl.AbstractServerOptions{
 		Scanner:  s,
-		BasePath: "/eSCL",
-	}
+		BasePath: esclPath,
+	})
 
-	handler := escl.NewAbstractServer(ctx, options)
-	server := transport.NewServer(nil, handler)
+	wsscanServer := wsscan.NewAbstractServer(ctx,
+		wsscan.AbstractServerOptions{Scanner: s})
+	defer wsscanServer.Close()
 
 	addr := fmt.Sprintf("localhost:%d", port)
+	responder := wsd.NewResponder(wsdResponderOptions(s.ScanCaps, addr))
+
+	mux := http.NewServeMux()
+	mux.Handle(esclPath+"/", esclServer)
+	mux.Handle(wsdPath, responder)
+	mux.Handle(wsscanPath, wsscanServer)
+
+	server := transport.NewServer(nil, mux)
+
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		panic(err)
 	}
 
+	// Announce the scanner via WS-Discovery. Without multicast,
+	// the scanner is still reachable by the directed probes,
+	// so failure here is not fatal.
+	if err := responder.Start(ctx); err != nil {
+		log.Error(ctx, "WSD: %s", err)
+	} else {
+		defer responder.Close(ctx)
+	}
+
 	// Run external command if specified
 	if len(argv) != 0 {
 		runner := env.Runner{
@@ -148,3 +178,48 @@ func simulate(ctx context.Context, port int, argv []string) error {
 
 	return err
 }
+
+// wsdResponderOptions returns the [wsd.ResponderOptions], that make
+// the simulated scanner discoverable by the WSD clients (i.e., Windows).
+func wsdResponderOptions(caps *abstract.ScannerCapabilities,
+	addr string) wsd.ResponderOptions {
+
+	base := "http://" + addr
+
+	return wsd.ResponderOptions{
+		EndpointReference: wsd.EndpointReference{
+			Address: wsd.AnyURI(caps.UUID.URN()),
+		},
+		Types:           wsd.Types{wsd.Device, wsd.ScannerServiceType},
+		XAddrs:          wsd.XAddrs{base + wsdPath},
+		MetadataVersion: 1,
+		Metadata: wsd.Metadata{
+			ThisDevice: wsd.ThisDeviceMetadata{
+				FriendlyName: wsd.LocalizedStringList{
+					{String: caps.MakeAndModel},
+				},
+				SerialNumber: caps.SerialNumber,
+			},
+			ThisModel: wsd.ThisModelMetadata{
+				Manufacturer: wsd.LocalizedStringList{
+					{String: caps.Manufacturer},
+				},
+				ModelName: wsd.LocalizedStringList{
+					{String: caps.MakeAndModel},
+				},
+			},
+			Relationship: wsd.Relationship{
+				Hosted: []wsd.ServiceMetadata{
+					{
+						EndpointReference: []wsd.EndpointReference{
+							{Address: wsd.AnyURI(base + wsscanPath)},
+						},
+						Types: wsd.Types{wsd.ScannerServiceType},
+						ServiceID: wsd.AnyURI(uuid.SHA1(caps.UUID,
+							wsscanPath).URN()),
+					},
+				},
+			},
+		},
+	}
+}
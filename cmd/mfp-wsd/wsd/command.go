@@ -0,0 +1,26 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "wsd" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Command description.
+
+package wsd
+
+import "github.com/OpenPrinting/go-mfp/argv"
+
+// Command is the 'wsd' command description
+var Command = argv.Command{
+	Name: "wsd",
+	Help: "WS-Discovery diagnostics and WS-Print",
+	Options: []argv.Option{
+		argv.HelpOption,
+	},
+	SubCommands: []argv.Command{
+		cmdPrint,
+		cmdProbe,
+		cmdResolve,
+		argv.HelpCommand,
+	},
+}
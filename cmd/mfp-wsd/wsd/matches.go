@@ -0,0 +1,70 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "wsd" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Matches pretty-printer
+
+package wsd
+
+import (
+	"github.com/OpenPrinting/go-mfp/discovery/wsdd"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+)
+
+// matchesFormat formats received matches.
+func matchesFormat(pager *env.Pager, matches []wsdd.QueryMatch) {
+	if len(matches) == 0 {
+		pager.Printf("No devices found.")
+		return
+	}
+
+	for _, m := range matches {
+		pager.Printf("================================")
+		pager.Printf("  Address:         %s", m.EndpointReference.Address)
+		pager.Printf("  From:            %s%%%s", m.From, m.IfName)
+		pager.Printf("  Types:           %s", m.Types)
+		pager.Printf("  MetadataVersion: %d", m.MetadataVersion)
+
+		pager.Printf("  XAddrs:")
+		for _, xaddr := range m.XAddrs {
+			pager.Printf("    %s", xaddr)
+		}
+
+		for _, meta := range m.Metadata {
+			dev := meta.ThisDevice
+			model := meta.ThisModel
+
+			pager.Printf("")
+			pager.Printf("  Metadata from %s:", meta.From)
+			pager.Printf("    FriendlyName:    %q",
+				dev.FriendlyName.NeutralLang().String)
+			pager.Printf("    FirmwareVersion: %q", dev.FirmwareVersion)
+			pager.Printf("    SerialNumber:    %q", dev.SerialNumber)
+			pager.Printf("    Manufacturer:    %q",
+				model.Manufacturer.NeutralLang().String)
+			pager.Printf("    ModelName:       %q",
+				model.ModelName.NeutralLang().String)
+			pager.Printf("    ModelNumber:     %q", model.ModelNumber)
+
+			if model.PresentationURL != nil {
+				pager.Printf("    PresentationURL: %s",
+					*model.PresentationURL)
+			}
+
+			for _, svc := range meta.Relationship.Hosted {
+				pager.Printf("    Hosted service:")
+				pager.Printf("      ServiceID: %s", svc.ServiceID)
+				pager.Printf("      Types:     %s",
+					svc.Types.MetadataString())
+				for _, ep := range svc.EndpointReference {
+					pager.Printf("      Endpoint:  %s",
+						ep.Address)
+				}
+			}
+		}
+
+		pager.Printf("")
+	}
+}
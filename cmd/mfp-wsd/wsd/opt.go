@@ -0,0 +1,91 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "wsd" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Common options
+
+package wsd
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/discovery/wsdd"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// optIface describes the --iface option
+var optIface = argv.Option{
+	Name:     "-i",
+	Aliases:  []string{"--iface"},
+	Help:     "Network interface to use (default: all)",
+	HelpArg:  "name",
+	Validate: argv.ValidateAny,
+	Complete: optIfaceComplete,
+}
+
+// optIfaceGet returns --iface option value
+func optIfaceGet(inv *argv.Invocation) string {
+	opt, _ := inv.Get("--iface")
+	return opt
+}
+
+// optIfaceComplete is the Completer for the --iface option
+func optIfaceComplete(arg string) []argv.Completion {
+	ifaces, _ := net.Interfaces()
+	names := make([]string, 0, len(ifaces))
+	for _, ifi := range ifaces {
+		names = append(names, ifi.Name)
+	}
+
+	return argv.CompleteStrings(names)(arg)
+}
+
+// optTimeout describes the --timeout option
+var optTimeout = argv.Option{
+	Name:     "-t",
+	Aliases:  []string{"--timeout"},
+	Help:     "Time to wait for responses, seconds (default: 3)",
+	HelpArg:  "seconds",
+	Validate: argv.ValidateIntRange(10, 1, 60),
+}
+
+// optTimeoutGet returns --timeout option value
+func optTimeoutGet(inv *argv.Invocation) time.Duration {
+	opt, found := inv.Get("--timeout")
+	if !found {
+		return wsdd.DefaultQueryTimeout
+	}
+
+	secs, _ := strconv.Atoi(opt)
+	return time.Duration(secs) * time.Second
+}
+
+// optProxy describes the --proxy option
+var optProxy = argv.Option{
+	Name:     "--proxy",
+	Help:     "Query the Discovery Proxy instead of multicast",
+	HelpArg:  "url",
+	Validate: optProxyValidate,
+}
+
+// optProxyValidate validates the --proxy option
+func optProxyValidate(s string) error {
+	_, err := transport.ParseURL(s)
+	return err
+}
+
+// optProxyGet returns --proxy option value, nil if not set
+func optProxyGet(inv *argv.Invocation) *url.URL {
+	opt, found := inv.Get("--proxy")
+	if !found {
+		return nil
+	}
+
+	return transport.MustParseURL(opt)
+}
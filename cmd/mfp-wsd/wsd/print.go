@@ -0,0 +1,191 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "wsd" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "print" command.
+
+package wsd
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/media"
+	"github.com/OpenPrinting/go-mfp/proto/wsprint"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// printSides are the valid values of the --sides option
+var printSides = []string{
+	wsprint.SidesOneSided.String(),
+	wsprint.SidesTwoSidedLongEdge.String(),
+	wsprint.SidesTwoSidedShortEdge.String(),
+}
+
+// cmdPrint defines the "print" sub-command
+var cmdPrint = argv.Command{
+	Name: "print",
+	Help: "Print documents via WS-Print",
+	Description: "" +
+		"This command prints documents on the WSD printer, that\n" +
+		"doesn't support IPP. URL is the WS-Print service endpoint\n" +
+		"of the printer, as found in the device metadata.\n" +
+		"\n" +
+		"All files are printed as a single multi-document job.\n" +
+		"Document format of each file is guessed from its name,\n" +
+		"unless specified explicitly with --format.\n" +
+		"\n" +
+		"If sending of any document fails, the entire job is canceled.\n",
+	Handler: cmdPrintHandler,
+	Options: []argv.Option{
+		argv.Option{
+			Name:     "--format",
+			HelpArg:  "MIME",
+			Help:     "Document format of all files",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--job-name",
+			HelpArg:  "name",
+			Help:     "Job name. Default: name of the first file",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:     "--copies",
+			HelpArg:  "N",
+			Help:     "Number of copies",
+			Validate: argv.ValidateUintRange(10, 1, 999),
+		},
+		argv.Option{
+			Name:     "--sides",
+			HelpArg:  "sides",
+			Help:     "Simplex/duplex: OneSided, TwoSidedLongEdge, ...",
+			Validate: argv.ValidateStrings(printSides),
+			Complete: argv.CompleteStrings(printSides),
+		},
+		argv.Option{
+			Name:     "--media",
+			HelpArg:  "size",
+			Help:     "Media size, i.e., a4, letter or iso_a4_210x297mm",
+			Validate: printValidateMedia,
+			Complete: argv.CompleteStrings(media.ShortNames()),
+		},
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name:     "URL",
+			Help:     "WS-Print service URL",
+			Validate: transport.ValidateURL,
+		},
+		{
+			Name:     "file...",
+			Help:     "files to print",
+			Complete: argv.CompleteOSPath,
+		},
+	},
+}
+
+// cmdPrintHandler is the "print" command handler
+func cmdPrintHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	param, _ := inv.Get("URL")
+	u := transport.MustParseURL(param)
+
+	files := inv.Values("file")
+	format, _ := inv.Get("--format")
+
+	ticket := wsprint.PrintTicket{JobName: filepath.Base(files[0])}
+	if name, ok := inv.Get("--job-name"); ok {
+		ticket.JobName = name
+	}
+
+	if s, ok := inv.Get("--copies"); ok {
+		copies, _ := strconv.Atoi(s)
+		ticket.Copies = optional.New(copies)
+	}
+
+	if s, ok := inv.Get("--sides"); ok {
+		ticket.Sides = optional.New(wsprint.DecodeSides(s))
+	}
+
+	if s, ok := inv.Get("--media"); ok {
+		m, _ := media.Lookup(s)
+		ticket.MediaSizeName = optional.New(m.Name)
+	}
+
+	// Open all files in advance, so missed file will not
+	// cause the partially submitted job.
+	docs := make([]wsprint.Document, 0, len(files))
+	for _, file := range files {
+		fp, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+
+		doc := wsprint.Document{
+			Name:   filepath.Base(file),
+			Format: format,
+			Body:   fp,
+		}
+
+		if doc.Format == "" {
+			doc.Format = printGuessFormat(file)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	// Submit the job
+	clnt := wsprint.NewClient(u, nil)
+	jobID, err := clnt.SubmitJob(ctx, ticket, docs)
+	if err != nil {
+		return err
+	}
+
+	log.Info(ctx, "job %d: %d document(s) submitted", jobID, len(docs))
+
+	// Not all printers implement GetJobElements, so failure
+	// here is not fatal.
+	status, err := clnt.GetJobStatus(ctx, jobID)
+	if err != nil {
+		log.Debug(ctx, "job %d: GetJobElements: %s", jobID, err)
+		return nil
+	}
+
+	log.Info(ctx, "job %d: state: %s", jobID, status.JobState)
+
+	return nil
+}
+
+// printValidateMedia validates the --media option.
+func printValidateMedia(s string) error {
+	if _, ok := media.Lookup(s); !ok {
+		return errors.New("unknown media size")
+	}
+	return nil
+}
+
+// printGuessFormat guesses document format by the file name.
+func printGuessFormat(file string) string {
+	format := mime.TypeByExtension(filepath.Ext(file))
+	if format == "" {
+		format = abstract.MIMETypeOctetStream
+	}
+
+	return format
+}
@@ -0,0 +1,109 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "wsd" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "probe" command.
+
+package wsd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/discovery/wsdd"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+)
+
+// cmdProbe defines the "probe" sub-command
+var cmdProbe = argv.Command{
+	Name:    "probe",
+	Help:    "Send WS-Discovery Probe and print matches",
+	Handler: cmdProbeHandler,
+	Options: []argv.Option{
+		optIface,
+		optTimeout,
+		optProxy,
+		argv.Option{
+			Name:     "--types",
+			Help:     "Device types to probe for",
+			HelpArg:  "scan|print",
+			Validate: argv.ValidateStrings([]string{"scan", "print"}),
+			Complete: argv.CompleteStrings([]string{"scan", "print"}),
+		},
+		argv.Option{
+			Name:     "--scope",
+			Help:     "Scope devices must match (may be repeated)",
+			HelpArg:  "uri",
+			Validate: argv.ValidateAny,
+		},
+		argv.Option{
+			Name:      "--match-by",
+			Help:      "Scope matching rule (default: rfc2396)",
+			HelpArg:   "rfc2396|uuid|ldap|strcmp0",
+			Validate:  argv.ValidateStrings(cmdProbeMatchByNames),
+			Complete:  argv.CompleteStrings(cmdProbeMatchByNames),
+			Requires:  []string{"--scope"},
+			Singleton: true,
+		},
+		argv.HelpOption,
+	},
+}
+
+// cmdProbeMatchBy maps names of the --match-by option values
+// into the WS-Discovery MatchBy rules
+var cmdProbeMatchBy = map[string]string{
+	"rfc2396": wsd.MatchByRFC2396,
+	"uuid":    wsd.MatchByUUID,
+	"ldap":    wsd.MatchByLDAP,
+	"strcmp0": wsd.MatchByStrcmp0,
+}
+
+// cmdProbeMatchByNames contains names of the --match-by option values
+var cmdProbeMatchByNames = []string{"rfc2396", "uuid", "ldap", "strcmp0"}
+
+// cmdProbeHandler is the "probe" command handler
+func cmdProbeHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	q := wsdd.Query{
+		Interface: optIfaceGet(inv),
+		Timeout:   optTimeoutGet(inv),
+		Proxy:     optProxyGet(inv),
+	}
+
+	for _, scope := range inv.Values("--scope") {
+		q.Scopes = append(q.Scopes, wsd.AnyURI(scope))
+	}
+
+	if matchBy, found := inv.Get("--match-by"); found {
+		q.MatchBy = cmdProbeMatchBy[strings.ToLower(matchBy)]
+	}
+
+	types := wsd.Types{}
+	for _, t := range inv.Values("--types") {
+		switch strings.ToLower(t) {
+		case "scan":
+			types = append(types, wsd.ScannerServiceType)
+		case "print":
+			types = append(types, wsd.PrinterServiceType)
+		}
+	}
+
+	// Perform the query
+	matches, err := q.Probe(ctx, types)
+	if err != nil {
+		return err
+	}
+
+	// Format output
+	pager := env.NewPager()
+	matchesFormat(pager, matches)
+
+	return pager.Display()
+}
@@ -0,0 +1,64 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// The "wsd" command
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// The "resolve" command.
+
+package wsd
+
+import (
+	"context"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/discovery/wsdd"
+	"github.com/OpenPrinting/go-mfp/internal/cmdopt"
+	"github.com/OpenPrinting/go-mfp/internal/env"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+)
+
+// cmdResolve defines the "resolve" sub-command
+var cmdResolve = argv.Command{
+	Name:    "resolve",
+	Help:    "Send WS-Discovery Resolve and print matches",
+	Handler: cmdResolveHandler,
+	Options: []argv.Option{
+		optIface,
+		optTimeout,
+		optProxy,
+		argv.HelpOption,
+	},
+	Parameters: []argv.Parameter{
+		{
+			Name: "addr",
+			Help: "device endpoint address (i.e., urn:uuid:...)",
+		},
+	},
+}
+
+// cmdResolveHandler is the "resolve" command handler
+func cmdResolveHandler(ctx context.Context, inv *argv.Invocation) error {
+	// Setup logging
+	ctx = cmdopt.LogContext(ctx, inv)
+
+	q := wsdd.Query{
+		Interface: optIfaceGet(inv),
+		Timeout:   optTimeoutGet(inv),
+		Proxy:     optProxyGet(inv),
+	}
+
+	addr, _ := inv.Get("addr")
+
+	// Perform the query
+	matches, err := q.Resolve(ctx, wsd.AnyURI(addr))
+	if err != nil {
+		return err
+	}
+
+	// Format output
+	pager := env.NewPager()
+	matchesFormat(pager, matches)
+
+	return pager.Display()
+}
@@ -13,8 +13,11 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/OpenPrinting/go-mfp/log"
 	"github.com/OpenPrinting/go-mfp/proto/ipp"
 	"github.com/OpenPrinting/go-mfp/transport"
 	"github.com/OpenPrinting/goipp"
@@ -23,6 +26,10 @@ import (
 // Client represents the CUPS client.
 type Client struct {
 	IPPClient *ipp.Client // Underlying IPP client
+
+	features    *Features             // Server features, probed once
+	unsupported map[goipp.Op]struct{} // Operations rejected by server
+	lock        sync.Mutex            // Access lock
 }
 
 // NewClient creates a new CUPS client.
@@ -47,7 +54,7 @@ func (c *Client) CUPSGetDefault(ctx context.Context,
 
 	rsp := &ipp.CUPSGetDefaultResponse{}
 
-	err := c.IPPClient.Do(ctx, rq, rsp)
+	err := c.do(ctx, rq, rsp)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +91,7 @@ func (c *Client) CUPSGetPrinters(ctx context.Context,
 
 	rsp := &ipp.CUPSGetPrintersResponse{}
 
-	err := c.IPPClient.Do(ctx, rq, rsp)
+	err := c.do(ctx, rq, rsp)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +130,7 @@ func (c *Client) CUPSGetDevices(ctx context.Context,
 
 	rsp := &ipp.CUPSGetDevicesResponse{}
 
-	err := c.IPPClient.Do(ctx, rq, rsp)
+	err := c.do(ctx, rq, rsp)
 	if err != nil {
 		return nil, err
 	}
@@ -152,11 +159,22 @@ func (c *Client) CUPSGetPPD(ctx context.Context,
 
 	rsp := &ipp.CUPSGetPPDResponse{}
 
+	err = c.check(ctx, rq.GetOp())
+	if err != nil {
+		return
+	}
+
 	err = c.IPPClient.DoWithBody(ctx, rq, rsp)
 	if err != nil {
 		return
 	}
 
+	err = c.learn(rq.GetOp(), rsp)
+	if err != nil {
+		rsp.Body.Close()
+		return
+	}
+
 	if rsp.Status == goipp.StatusOk {
 		return rsp.Body, "", nil
 	}
@@ -168,3 +186,124 @@ func (c *Client) CUPSGetPPD(ctx context.Context,
 
 	return nil, "", fmt.Errorf("IPP: %s", rsp.Status)
 }
+
+// Features returns features of the CUPS server.
+//
+// Server is probed on a first call, and result is cached in
+// the Client, so subsequent calls are cheap.
+func (c *Client) Features(ctx context.Context) (*Features, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.features != nil {
+		return c.features, nil
+	}
+
+	// Probe the server. CUPS-Get-Printers is supported by all
+	// CUPS versions and returns operations-supported of the
+	// first printer, which for CUPS includes CUPS-specific
+	// operations.
+	rq := &ipp.CUPSGetPrintersRequest{
+		RequestHeader:       ipp.DefaultRequestHeader,
+		Limit:               1,
+		RequestedAttributes: []string{"operations-supported"},
+	}
+
+	rsp := &ipp.CUPSGetPrintersResponse{}
+	err := c.IPPClient.Do(ctx, rq, rsp)
+	if err != nil {
+		return nil, err
+	}
+
+	features := &Features{
+		Server: rsp.HTTPHeader.Get("Server"),
+	}
+	features.Version = featuresServerVersion(features.Server)
+
+	switch {
+	case rsp.Status == goipp.StatusOk && len(rsp.Printer) != 0:
+		features.Operations = rsp.Printer[0].OperationsSupported
+
+	case rsp.Status == goipp.StatusErrorOperationNotSupported:
+		// Not a CUPS server. Operations are not known, but
+		// at least we know that CUPS-Get-Printers is not
+		// supported.
+		c.markUnsupported(goipp.OpCupsGetPrinters)
+	}
+
+	log.Debug(ctx, "CUPS: server=%q version=%q operations=%d",
+		features.Server, features.Version, len(features.Operations))
+
+	c.features = features
+	return features, nil
+}
+
+// do performs the IPP request, with features checking.
+func (c *Client) do(ctx context.Context,
+	rq ipp.Request, rsp ipp.Response) error {
+
+	err := c.check(ctx, rq.GetOp())
+	if err != nil {
+		return err
+	}
+
+	err = c.IPPClient.Do(ctx, rq, rsp)
+	if err != nil {
+		return err
+	}
+
+	return c.learn(rq.GetOp(), rsp)
+}
+
+// check returns ErrNotSupported, if operation is known to be
+// not supported by the server.
+func (c *Client) check(ctx context.Context, op goipp.Op) error {
+	features, err := c.Features(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	_, unsupported := c.unsupported[op]
+	c.lock.Unlock()
+
+	if unsupported || !features.Supports(op) {
+		return ErrNotSupported{Op: op, Server: features.Server}
+	}
+
+	return nil
+}
+
+// learn examines the response and, if server has rejected
+// the operation as unsupported, remembers it and returns
+// ErrNotSupported.
+//
+// CUPS reports missed helper programs (i.e., cups-deviced, when
+// CUPS-Get-Devices is disabled) as internal error with the
+// "failed to execute" message. It is handled the same way.
+func (c *Client) learn(op goipp.Op, rsp ipp.Response) error {
+	hdr := rsp.Header()
+
+	switch {
+	case hdr.Status == goipp.StatusErrorOperationNotSupported:
+	case hdr.Status == goipp.StatusErrorInternal &&
+		strings.Contains(hdr.StatusMessage, "failed to execute"):
+	default:
+		return nil
+	}
+
+	c.lock.Lock()
+	c.markUnsupported(op)
+	c.lock.Unlock()
+
+	return ErrNotSupported{Op: op, Server: hdr.HTTPHeader.Get("Server")}
+}
+
+// markUnsupported marks operation as unsupported by the server.
+// It must be called under the Client.lock.
+func (c *Client) markUnsupported(op goipp.Op) {
+	if c.unsupported == nil {
+		c.unsupported = make(map[goipp.Op]struct{})
+	}
+	c.unsupported[op] = struct{}{}
+}
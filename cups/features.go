@@ -0,0 +1,120 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// CUPS Client and Server
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// CUPS server features detection
+
+package cups
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// Features describes capabilities of the CUPS server, as detected
+// by the [Client.Features].
+type Features struct {
+	// Server is the value of the HTTP Server header, as returned
+	// by the server (i.e., "CUPS/2.4 IPP/2.1"). May be empty.
+	Server string
+
+	// Version is the CUPS version (i.e., "2.4"). Empty if server
+	// is not CUPS or version is not known.
+	Version string
+
+	// Operations is the list of supported operations.
+	// It is nil, if this information is not available.
+	Operations []goipp.Op
+}
+
+// ErrNotSupported is returned when operation is not supported
+// by the server.
+type ErrNotSupported struct {
+	Op     goipp.Op // The operation
+	Server string   // Server identification, if known
+}
+
+// Error returns an error string. It implements [error] interface.
+func (e ErrNotSupported) Error() string {
+	s := fmt.Sprintf("%s: not supported by this server", e.Op)
+	if e.Server != "" {
+		s += fmt.Sprintf(" (%s)", e.Server)
+	}
+	return s
+}
+
+// IsCUPS reports if server is known to be CUPS.
+func (f *Features) IsCUPS() bool {
+	return f.Version != ""
+}
+
+// Supports reports if operation is supported by the server.
+//
+// If list of supported operations is not known, it optimistically
+// assumes that operation is supported.
+func (f *Features) Supports(op goipp.Op) bool {
+	return f.Operations == nil || slices.Contains(f.Operations, op)
+}
+
+// VersionAtLeast reports if CUPS version is at least major.minor.
+// If version is not known, it returns false.
+func (f *Features) VersionAtLeast(major, minor int) bool {
+	maj, mnr, ok := featuresParseVersion(f.Version)
+	if !ok {
+		return false
+	}
+
+	return maj > major || (maj == major && mnr >= minor)
+}
+
+// featuresServerVersion extracts CUPS version from the HTTP
+// Server header (i.e., "CUPS/2.4 IPP/2.1" -> "2.4").
+// It returns "" if server is not CUPS.
+func featuresServerVersion(server string) string {
+	for _, product := range strings.Fields(server) {
+		name, ver, _ := strings.Cut(product, "/")
+		if strings.EqualFold(name, "CUPS") {
+			return ver
+		}
+	}
+
+	return ""
+}
+
+// featuresParseVersion parses CUPS version string into major
+// and minor numbers.
+func featuresParseVersion(ver string) (major, minor int, ok bool) {
+	if ver == "" {
+		return
+	}
+
+	parts := strings.SplitN(ver, ".", 3)
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return
+	}
+
+	if len(parts) > 1 {
+		// Ignore suffixes like "5b1"
+		digits := parts[1]
+		if i := strings.IndexFunc(digits, func(c rune) bool {
+			return c < '0' || c > '9'
+		}); i >= 0 {
+			digits = digits[:i]
+		}
+
+		minor, err = strconv.Atoi(digits)
+		if err != nil {
+			return
+		}
+	}
+
+	ok = true
+	return
+}
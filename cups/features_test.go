@@ -0,0 +1,162 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// CUPS Client and Server
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// CUPS server features detection test
+
+package cups
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// TestFeaturesVersion tests CUPS version detection and comparison
+func TestFeaturesVersion(t *testing.T) {
+	type testData struct {
+		server  string // HTTP Server header
+		version string // Expected version
+		major   int    // Version to compare with
+		minor   int    // Version to compare with
+		atLeast bool   // Expected VersionAtLeast result
+	}
+
+	tests := []testData{
+		{"CUPS/2.4 IPP/2.1", "2.4", 2, 4, true},
+		{"CUPS/2.4 IPP/2.1", "2.4", 2, 5, false},
+		{"CUPS/2.4 IPP/2.1", "2.4", 1, 7, true},
+		{"CUPS/2.5b1 IPP/2.1", "2.5b1", 2, 5, true},
+		{"cups/3 IPP/2.0", "3", 2, 9, true},
+		{"PAPPL/1.4 IPP/2.0", "", 1, 0, false},
+		{"", "", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		f := &Features{Server: test.server}
+		f.Version = featuresServerVersion(test.server)
+
+		if f.Version != test.version {
+			t.Errorf("%q: version mismatch:\n"+
+				"expected: %q\n"+
+				"present:  %q",
+				test.server, test.version, f.Version)
+		}
+
+		if f.IsCUPS() != (test.version != "") {
+			t.Errorf("%q: IsCUPS() = %v", test.server, f.IsCUPS())
+		}
+
+		atLeast := f.VersionAtLeast(test.major, test.minor)
+		if atLeast != test.atLeast {
+			t.Errorf("%q: VersionAtLeast(%d,%d):\n"+
+				"expected: %v\n"+
+				"present:  %v",
+				test.server, test.major, test.minor,
+				test.atLeast, atLeast)
+		}
+	}
+}
+
+// TestFeaturesGating tests gating of client operations by
+// the server features
+func TestFeaturesGating(t *testing.T) {
+	var probes, devices atomic.Int32
+
+	handler := func(w http.ResponseWriter, rq *http.Request) {
+		var msg goipp.Message
+		err := msg.Decode(rq.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		hdr := ipp.ResponseHeader{
+			Version:                   msg.Version,
+			RequestID:                 msg.RequestID,
+			Status:                    goipp.StatusOk,
+			AttributesCharset:         ipp.DefaultCharset,
+			AttributesNaturalLanguage: ipp.DefaultNaturalLanguage,
+		}
+
+		var rsp ipp.Response
+		switch goipp.Op(msg.Code) {
+		case goipp.OpCupsGetPrinters:
+			probes.Add(1)
+			prn := &ipp.PrinterAttributes{}
+			prn.OperationsSupported = []goipp.Op{
+				goipp.OpGetPrinterAttributes,
+				goipp.OpCupsGetPrinters,
+				goipp.OpCupsGetDevices,
+			}
+			rsp = &ipp.CUPSGetPrintersResponse{
+				ResponseHeader: hdr,
+				Printer:        []*ipp.PrinterAttributes{prn},
+			}
+
+		case goipp.OpCupsGetDevices:
+			devices.Add(1)
+			hdr.Status = goipp.StatusErrorInternal
+			hdr.StatusMessage = "cups-deviced failed to execute."
+			rsp = &ipp.CUPSGetDevicesResponse{ResponseHeader: hdr}
+
+		default:
+			hdr.Status = goipp.StatusErrorOperationNotSupported
+			rsp = &ipp.CUPSGetDefaultResponse{ResponseHeader: hdr}
+		}
+
+		w.Header().Set("Content-Type", "application/ipp")
+		w.Header().Set("Server", "CUPS/2.4 IPP/2.1")
+		rsp.Encode().Encode(w)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	defer srv.Close()
+
+	ctx := context.Background()
+	clnt := NewClient(transport.MustParseURL(srv.URL), nil)
+
+	// Check probed features
+	features, err := clnt.Features(ctx)
+	if err != nil {
+		t.Fatalf("Features: %s", err)
+	}
+
+	if features.Version != "2.4" {
+		t.Errorf("Features: version %q", features.Version)
+	}
+
+	// CUPS-Get-PPD is not in operations-supported, so it must be
+	// rejected without contacting the server
+	_, _, err = clnt.CUPSGetPPD(ctx, "", "test.ppd")
+	if !errors.As(err, &ErrNotSupported{}) {
+		t.Errorf("CUPSGetPPD: unexpected error %v", err)
+	}
+
+	// CUPS-Get-Devices is listed, but cups-deviced is missed.
+	// The first call hits the server, the second is rejected
+	// locally.
+	for i := 0; i < 2; i++ {
+		_, err = clnt.CUPSGetDevices(ctx, nil, nil)
+		if !errors.As(err, &ErrNotSupported{}) {
+			t.Errorf("CUPSGetDevices: unexpected error %v", err)
+		}
+	}
+
+	if n := probes.Load(); n != 1 {
+		t.Errorf("server probed %d times, expected 1", n)
+	}
+
+	if n := devices.Load(); n != 1 {
+		t.Errorf("CUPS-Get-Devices sent %d times, expected 1", n)
+	}
+}
@@ -31,14 +31,20 @@ type cacheEnt struct {
 	stagingDoneAt    time.Time // End of staging time. Zero if no staging.
 }
 
-// newCache creates the new discovery cache
-func newCache() *cache {
+// newCache creates the new discovery cache.
+// readyAt is the time when cache is initially warmed up.
+func newCache(readyAt time.Time) *cache {
 	return &cache{
-		readyAt: time.Now().Add(WarmUpTime),
+		readyAt: readyAt,
 		entries: make(map[UnitID]*cacheEnt),
 	}
 }
 
+// SetReadyAt sets time when cache is warmed up.
+func (c *cache) SetReadyAt(readyAt time.Time) {
+	c.readyAt = readyAt
+}
+
 // ReadyAt returns time when cache is ready to be exported, according to
 // the cache state and export Mode
 func (c *cache) ReadyAt(m Mode) time.Time {
@@ -113,7 +119,10 @@ func (c *cache) AddUnit(evnt *EventAddUnit) error {
 		return errors.New("unit already added")
 	}
 
-	c.entries[evnt.ID] = &cacheEnt{unit: unit{ID: evnt.ID}}
+	now := time.Now()
+	c.entries[evnt.ID] = &cacheEnt{
+		unit: unit{ID: evnt.ID, FirstSeen: now, LastSeen: now},
+	}
 	c.out.Invalidate()
 
 	return nil
@@ -223,6 +232,7 @@ func (c *cache) setParametersBegin(id UnitID,
 // setParametersCommit finishes operation of setting unit parameters
 func (c *cache) setParametersCommit(ent *cacheEnt) {
 	ent.hasParams = true
+	ent.LastSeen = time.Now()
 	c.out.Invalidate()
 }
 
@@ -241,6 +251,8 @@ func (c *cache) AddEndpoint(evnt *EventAddEndpoint) error {
 
 	ent.stagingBegin()
 	ent.stagingEndpoints, _ = endpointsAdd(ent.stagingEndpoints, endpoint)
+	ent.Verified = ent.Verified || evnt.Verified
+	ent.LastSeen = time.Now()
 
 	c.out.Invalidate()
 
@@ -21,32 +21,58 @@ import (
 type Client struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
+	opts     ClientOptions
 	queue    *Eventqueue
-	backends map[Backend]struct{}
+	backends []*clientBackend
+	active   bool
+	timer    *time.Timer
 	cache    *cache
 	lock     sync.Mutex
 	done     sync.WaitGroup
 }
 
-// NewClient creates a new discovery [Client].
+// clientBackend represents a Backend, attached to the Client.
+type clientBackend struct {
+	Backend                  // The Backend
+	opts    BackendOptions   // Backend options
+	after   []*clientBackend // Backends to wait for before start
+	started bool             // Backend is started
+	readyAt time.Time        // Initial scan end time, if started
+}
+
+// NewClient creates a new discovery [Client] with default options.
 //
 // The provided [context.Context] is used for two purposes:
 //   - For logging
 //   - Client will terminate its operations, if context is canceled.
 func NewClient(ctx context.Context) *Client {
+	return NewClientWithOptions(ctx, ClientOptions{})
+}
+
+// NewClientWithOptions creates a new discovery [Client] with
+// the specified options.
+//
+// See [NewClient] for the ctx usage.
+func NewClientWithOptions(ctx context.Context, opts ClientOptions) *Client {
 	// Set log prefix
 	ctx = log.WithPrefix(ctx, "discovery")
 
 	// Create cancelable context
 	ctx, cancel := context.WithCancel(ctx)
 
+	// Apply defaults
+	if opts.WarmUpTime == 0 {
+		opts.WarmUpTime = WarmUpTime
+	}
+
 	// Create client structure
 	clnt := &Client{
-		ctx:      ctx,
-		cancel:   cancel,
-		queue:    NewEventqueue(),
-		cache:    newCache(),
-		backends: make(map[Backend]struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+		opts:   opts,
+		queue:  NewEventqueue(),
+		active: !opts.Lazy,
+		cache:  newCache(time.Now().Add(opts.WarmUpTime)),
 	}
 
 	// Start work thread
@@ -59,23 +85,153 @@ func NewClient(ctx context.Context) *Client {
 // Close closes all attached backends and then closes the Client
 // and releases all resources it holds.
 func (clnt *Client) Close() {
+	clnt.lock.Lock()
+	if clnt.timer != nil {
+		clnt.timer.Stop()
+	}
+	clnt.lock.Unlock()
+
 	clnt.cancel()
 	clnt.done.Wait()
 }
 
-// AddBackend adds a discovery [Backend] to the [Client].
+// AddBackend adds a discovery [Backend] to the [Client]
+// with default options.
 func (clnt *Client) AddBackend(bk Backend) {
+	clnt.AddBackendWithOptions(bk, BackendOptions{})
+}
+
+// AddBackendWithOptions adds a discovery [Backend] to the [Client]
+// with the specified options.
+//
+// Backend is started immediately, unless its start is postponed
+// by the [ClientOptions.Lazy] or [BackendOptions.After].
+func (clnt *Client) AddBackendWithOptions(bk Backend, opts BackendOptions) {
 	clnt.lock.Lock()
 	defer clnt.lock.Unlock()
 
-	if _, found := clnt.backends[bk]; found {
-		err := fmt.Errorf("backend %s already added", bk.Name())
-		panic(err)
+	for _, cbk := range clnt.backends {
+		if cbk.Backend == bk {
+			err := fmt.Errorf("backend %s already added", bk.Name())
+			panic(err)
+		}
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = clnt.opts.WarmUpTime
+	}
+
+	cbk := &clientBackend{Backend: bk, opts: opts}
+	for _, name := range opts.After {
+		dep := clnt.backendByName(name)
+		if dep == nil {
+			err := fmt.Errorf("backend %s: %s must be added first",
+				bk.Name(), name)
+			panic(err)
+		}
+
+		cbk.after = append(cbk.after, dep)
 	}
 
 	log.Debug(clnt.ctx, "%s: backend added", bk.Name())
-	clnt.backends[bk] = struct{}{}
-	bk.Start(clnt.queue)
+	clnt.backends = append(clnt.backends, cbk)
+	clnt.schedule()
+}
+
+// backendByName returns clientBackend by name or nil, if not found.
+//
+// Must be called under clnt.lock.
+func (clnt *Client) backendByName(name string) *clientBackend {
+	for _, cbk := range clnt.backends {
+		if cbk.Name() == name {
+			return cbk
+		}
+	}
+	return nil
+}
+
+// schedule starts backends, which are ready to be started, arms
+// the timer for backends that wait for their dependencies and
+// updates the cache readiness time.
+//
+// Must be called under clnt.lock.
+func (clnt *Client) schedule() {
+	if !clnt.active || clnt.ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	var ready, wakeup time.Time
+
+	for _, cbk := range clnt.backends {
+		if !cbk.started {
+			startAt := cbk.startAt()
+			if startAt.After(now) {
+				if wakeup.IsZero() || startAt.Before(wakeup) {
+					wakeup = startAt
+				}
+			} else {
+				log.Debug(clnt.ctx, "%s: backend started",
+					cbk.Name())
+				cbk.started = true
+				cbk.readyAt = now.Add(cbk.opts.Timeout)
+				cbk.Start(clnt.queue)
+			}
+		}
+
+		ready = timeLatest(ready, cbk.plannedReadyAt())
+	}
+
+	if len(clnt.backends) != 0 {
+		clnt.cache.SetReadyAt(ready)
+	}
+
+	if !wakeup.IsZero() {
+		if clnt.timer != nil {
+			clnt.timer.Stop()
+		}
+
+		clnt.timer = time.AfterFunc(wakeup.Sub(now), func() {
+			clnt.lock.Lock()
+			clnt.schedule()
+			clnt.lock.Unlock()
+		})
+	}
+}
+
+// activate starts backends, postponed by the ClientOptions.Lazy.
+//
+// Must be called under clnt.lock.
+func (clnt *Client) activate() {
+	if !clnt.active {
+		log.Debug(clnt.ctx, "activating backends")
+		clnt.active = true
+		clnt.cache.SetReadyAt(time.Now().Add(clnt.opts.WarmUpTime))
+		clnt.schedule()
+	}
+}
+
+// startAt returns the time when backend can be started.
+// For the started backend, result is undefined.
+func (cbk *clientBackend) startAt() time.Time {
+	var t time.Time
+	for _, dep := range cbk.after {
+		t = timeLatest(t, dep.plannedReadyAt())
+	}
+	return t
+}
+
+// plannedReadyAt returns the time when initial scan of the
+// backend is expected to be finished.
+//
+// For not started backend, it is estimated from the dependencies,
+// assuming the backend will be started as soon as possible.
+func (cbk *clientBackend) plannedReadyAt() time.Time {
+	if cbk.started {
+		return cbk.readyAt
+	}
+
+	return timeLatest(cbk.startAt(), time.Now()).Add(cbk.opts.Timeout)
 }
 
 // GetDevices returns a list of discovered devices.
@@ -93,6 +249,9 @@ func (clnt *Client) GetDevices(ctx context.Context, m Mode) ([]Device, error) {
 	clnt.lock.Lock()
 	defer clnt.lock.Unlock()
 
+	// Start backends, if lazy activation is used
+	clnt.activate()
+
 	// If snapshot is requested, take it immediately
 	if m == ModeSnapshot {
 		return clnt.cache.Snapshot(), nil
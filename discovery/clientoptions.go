@@ -0,0 +1,53 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Client and backend options
+
+package discovery
+
+import "time"
+
+// ClientOptions represents the [Client] creation options.
+//
+// Zero value is valid and gives the default behavior.
+type ClientOptions struct {
+	// WarmUpTime is the initial scan timeout of backends, that
+	// don't specify their own [BackendOptions.Timeout].
+	// If zero, the [WarmUpTime] constant is used.
+	WarmUpTime time.Duration
+
+	// Lazy, if true, postpones start of all backends until the
+	// first call to the [Client.GetDevices].
+	//
+	// It saves resources, if devices may never be requested,
+	// at the cost of latency of the first request.
+	Lazy bool
+}
+
+// BackendOptions represents per-backend options, used by
+// the [Client.AddBackendWithOptions].
+//
+// Zero value is valid and gives the default behavior.
+type BackendOptions struct {
+	// Timeout is the initial scan timeout of the backend.
+	//
+	// [Client.GetDevices] in the [ModeNormal] waits until initial
+	// scan of all backends is finished. Shorter timeout gives
+	// lower latency, longer timeout gives more complete results.
+	//
+	// If zero, [ClientOptions.WarmUpTime] is used.
+	Timeout time.Duration
+
+	// After contains names of backends (see [Backend.Name]), that
+	// must finish their initial scan before this backend is started.
+	//
+	// For example, backend that depends on the network state
+	// may be started after the addresses enumeration is done.
+	//
+	// All backends, listed here, must be added to the [Client]
+	// before this backend.
+	After []string
+}
@@ -0,0 +1,43 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Device confidence level
+
+package discovery
+
+// Confidence estimates, how much the discovered [Device] information
+// can be trusted. See [Device.Confidence] for details.
+type Confidence int
+
+// Confidence levels:
+const (
+	// ConfidenceLow means that device is reported by a single
+	// backend and none of its endpoints verified reachable.
+	ConfidenceLow Confidence = iota
+
+	// ConfidenceMedium means that device either is reported
+	// by multiple backends or some of its endpoints verified
+	// reachable.
+	ConfidenceMedium
+
+	// ConfidenceHigh means that device is reported by multiple
+	// backends and some of its endpoints verified reachable.
+	ConfidenceHigh
+)
+
+// String returns Confidence name.
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	}
+
+	return "unknown"
+}
@@ -10,6 +10,8 @@ package discovery
 
 import (
 	"net/netip"
+	"slices"
+	"time"
 
 	"github.com/OpenPrinting/go-mfp/util/generic"
 	"github.com/OpenPrinting/go-mfp/util/uuid"
@@ -52,6 +54,13 @@ type Device struct {
 	// Connectivity
 	Addrs []netip.Addr // Device's IP addresses
 
+	// Liveness. Use [Device.Confidence] to estimate, how much
+	// the device information can be trusted.
+	FirstSeen time.Time     // When device was first discovered
+	LastSeen  time.Time     // When device was last updated
+	Realms    []SearchRealm // Realms (backends) that report the device
+	Verified  bool          // Some endpoints verified reachable
+
 	// Device units
 	PrintUnits  []PrintUnit  // Print units
 	ScanUnits   []ScanUnit   // Scan units
@@ -239,5 +248,39 @@ func (dev device) Export() Device {
 		}
 	}
 
+	// Compute liveness
+	for _, un := range allUnits {
+		if out.FirstSeen.IsZero() {
+			out.FirstSeen = un.FirstSeen
+		}
+
+		out.FirstSeen = timeEarliest(out.FirstSeen, un.FirstSeen)
+		out.LastSeen = timeLatest(out.LastSeen, un.LastSeen)
+		out.Verified = out.Verified || un.Verified
+
+		if !slices.Contains(out.Realms, un.ID.Realm) {
+			out.Realms = append(out.Realms, un.ID.Realm)
+		}
+	}
+
+	slices.Sort(out.Realms)
+
 	return out
 }
+
+// Confidence estimates, how much the device information can be
+// trusted, based on number of backends that report the device and
+// reachability of its endpoints.
+//
+// It helps to distinguish live devices from the lingering cache
+// entries. See also [Device.FirstSeen] and [Device.LastSeen].
+func (dev Device) Confidence() Confidence {
+	switch {
+	case len(dev.Realms) > 1 && dev.Verified:
+		return ConfidenceHigh
+	case len(dev.Realms) > 1 || dev.Verified:
+		return ConfidenceMedium
+	}
+
+	return ConfidenceLow
+}
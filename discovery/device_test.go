@@ -0,0 +1,192 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Device liveness and confidence test
+
+package discovery
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDeviceConfidence tests Device.Confidence
+func TestDeviceConfidence(t *testing.T) {
+	type testData struct {
+		realms   []SearchRealm
+		verified bool
+		expect   Confidence
+	}
+
+	tests := []testData{
+		{nil, false, ConfidenceLow},
+		{[]SearchRealm{RealmDNSSD}, false, ConfidenceLow},
+		{[]SearchRealm{RealmWSD}, true, ConfidenceMedium},
+		{[]SearchRealm{RealmDNSSD, RealmWSD}, false, ConfidenceMedium},
+		{[]SearchRealm{RealmDNSSD, RealmWSD}, true, ConfidenceHigh},
+		{[]SearchRealm{RealmDNSSD, RealmWSD, RealmUSB}, true,
+			ConfidenceHigh},
+	}
+
+	for _, test := range tests {
+		dev := Device{Realms: test.realms, Verified: test.verified}
+		present := dev.Confidence()
+		if present != test.expect {
+			t.Errorf("%v verified=%v: expected %s, present %s",
+				test.realms, test.verified, test.expect, present)
+		}
+	}
+}
+
+// TestDeviceLiveness tests merging of FirstSeen, LastSeen, Realms
+// and Verified across units, reported by different backends.
+func TestDeviceLiveness(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time {
+		return t0.Add(time.Duration(sec) * time.Second)
+	}
+
+	printer := func(realm SearchRealm, proto ServiceProto,
+		first, last time.Time, verified bool) unit {
+		return unit{
+			ID: UnitID{
+				Realm:    realm,
+				SvcType:  ServicePrinter,
+				SvcProto: proto,
+			},
+			Params:    PrinterParameters{},
+			Verified:  verified,
+			FirstSeen: first,
+			LastSeen:  last,
+		}
+	}
+
+	scanner := func(realm SearchRealm, proto ServiceProto,
+		first, last time.Time, verified bool) unit {
+		return unit{
+			ID: UnitID{
+				Realm:    realm,
+				SvcType:  ServiceScanner,
+				SvcProto: proto,
+			},
+			Params:    ScannerParameters{},
+			Verified:  verified,
+			FirstSeen: first,
+			LastSeen:  last,
+		}
+	}
+
+	type testData struct {
+		name      string
+		units     []unit
+		firstSeen time.Time
+		lastSeen  time.Time
+		realms    []SearchRealm
+		verified  bool
+	}
+
+	tests := []testData{
+		{
+			name: "single unit",
+			units: []unit{
+				printer(RealmDNSSD, ServiceIPP, at(10), at(20), false),
+			},
+			firstSeen: at(10),
+			lastSeen:  at(20),
+			realms:    []SearchRealm{RealmDNSSD},
+		},
+
+		{
+			name: "same realm",
+			units: []unit{
+				printer(RealmDNSSD, ServiceIPP, at(10), at(20), false),
+				scanner(RealmDNSSD, ServiceESCL, at(5), at(15), true),
+			},
+			firstSeen: at(5),
+			lastSeen:  at(20),
+			realms:    []SearchRealm{RealmDNSSD},
+			verified:  true,
+		},
+
+		{
+			name: "cross realm",
+			units: []unit{
+				printer(RealmWSD, ServiceWSD, at(1), at(30), false),
+				printer(RealmDNSSD, ServiceIPP, at(10), at(20), false),
+				scanner(RealmWSD, ServiceWSD, at(3), at(40), false),
+			},
+			firstSeen: at(1),
+			lastSeen:  at(40),
+			realms:    []SearchRealm{RealmDNSSD, RealmWSD},
+		},
+
+		{
+			name: "three realms",
+			units: []unit{
+				printer(RealmUSB, ServiceUSB, at(7), at(7), true),
+				printer(RealmWSD, ServiceWSD, at(8), at(9), false),
+				printer(RealmDNSSD, ServiceIPP, at(6), at(8), false),
+			},
+			firstSeen: at(6),
+			lastSeen:  at(9),
+			realms: []SearchRealm{
+				RealmDNSSD, RealmWSD, RealmUSB,
+			},
+			verified: true,
+		},
+	}
+
+	for _, test := range tests {
+		dev := device{units: test.units}.Export()
+
+		if !dev.FirstSeen.Equal(test.firstSeen) {
+			t.Errorf("%s: FirstSeen: expected %s, present %s",
+				test.name, test.firstSeen, dev.FirstSeen)
+		}
+
+		if !dev.LastSeen.Equal(test.lastSeen) {
+			t.Errorf("%s: LastSeen: expected %s, present %s",
+				test.name, test.lastSeen, dev.LastSeen)
+		}
+
+		if !reflect.DeepEqual(dev.Realms, test.realms) {
+			t.Errorf("%s: Realms: expected %v, present %v",
+				test.name, test.realms, dev.Realms)
+		}
+
+		if dev.Verified != test.verified {
+			t.Errorf("%s: Verified: expected %v, present %v",
+				test.name, test.verified, dev.Verified)
+		}
+	}
+}
+
+// TestUnitMergeLiveness tests merging of liveness information
+// by unit.Merge
+func TestUnitMergeLiveness(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	un := unit{FirstSeen: t0.Add(time.Minute), LastSeen: t0.Add(time.Hour)}
+	un.Merge(unit{
+		FirstSeen: t0,
+		LastSeen:  t0.Add(time.Minute),
+		Verified:  true,
+	})
+
+	if !un.FirstSeen.Equal(t0) {
+		t.Errorf("FirstSeen: expected %s, present %s", t0, un.FirstSeen)
+	}
+
+	if !un.LastSeen.Equal(t0.Add(time.Hour)) {
+		t.Errorf("LastSeen: expected %s, present %s",
+			t0.Add(time.Hour), un.LastSeen)
+	}
+
+	if !un.Verified {
+		t.Errorf("Verified: expected true, present false")
+	}
+}
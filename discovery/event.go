@@ -144,9 +144,15 @@ func (evnt *EventFaxoutParameters) GetID() UnitID {
 // Backend responsibilities:
 //   - Unit MUST exist
 //   - The same endpoint MUST NOT be added multiple times.
+//
+// Verified should be set, if backend has actually reached the device
+// while obtaining the endpoint (for example, endpoint comes from the
+// metadata, fetched from the device), so endpoint is known to be
+// reachable.
 type EventAddEndpoint struct {
 	ID       UnitID // Unit identity
 	Endpoint string // URLs of added endpoints
+	Verified bool   // Endpoint reachability verified
 }
 
 // Name returns the Event name.
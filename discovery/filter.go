@@ -0,0 +1,537 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Devices filtering
+
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+)
+
+// Filter selects devices by their attributes.
+//
+// Filter is created from the textual expression by the [ParseFilter].
+// Expression is the sequence of terms, joined with the && operator:
+//
+//	type=scanner && proto=escl && duplex
+//	model~'Kyocera.*' && !color
+//
+// Each term is either the comparison or the Boolean attribute,
+// optionally negated with the '!' prefix. Comparison operators are:
+//
+//	=   equal, case-insensitive
+//	!=  not equal, case-insensitive
+//	~   matches regular expression (entire value must match)
+//	!~  doesn't match regular expression
+//
+// Values may be quoted with single or double quotes, if they contain
+// spaces or operator characters.
+//
+// Device attributes:
+//
+//	model     - device make and model
+//	location  - device location
+//	name      - DNS-SD name
+//	uuid      - DNS-SD UUID
+//	serial    - USB serial number
+//	addr      - any of device IP addresses
+//	verified  - Boolean, some endpoints verified reachable
+//
+// Unit attributes:
+//
+//	type      - unit type: printer, scanner or faxout
+//	proto     - unit protocol: ipp, escl, lpd, appsocket, wsd or usb
+//	pdl       - any of supported MIME types
+//	duplex    - Boolean, duplex supported
+//	color     - Boolean, color supported
+//	adf       - Boolean, scanner has ADF
+//	platen    - Boolean, scanner has platen
+//	bind, collate, copies, punch, sort, staple - Boolean, printer flags
+//
+// All unit attributes of the expression must be satisfied by the same
+// device unit, so "type=scanner && duplex" selects devices that have
+// the duplex scanner, not just any duplex unit.
+type Filter struct {
+	terms []filterTerm // Terms, joined with &&
+}
+
+// filterTerm is the single term of the Filter expression
+type filterTerm struct {
+	attr  *filterAttr    // Attribute
+	op    string         // Operator, "" for Boolean attribute
+	not   bool           // Negation of Boolean attribute
+	value string         // Value for comparison
+	re    *regexp.Regexp // Compiled regexp for ~ and !~
+}
+
+// filterAttr describes the attribute, used in Filter expressions.
+//
+// Only one of the callbacks is set, depending on the attribute
+// kind (string or Boolean) and scope (device or unit).
+type filterAttr struct {
+	name     string                        // Attribute name
+	devStr   func(dev *Device) []string    // Device string attribute
+	devBool  func(dev *Device) bool        // Device Boolean attribute
+	unitStr  func(un *filterUnit) []string // Unit string attribute
+	unitBool func(un *filterUnit) bool     // Unit Boolean attribute
+}
+
+// filterUnit is the common representation of PrintUnit, ScanUnit
+// and FaxoutUnit for filtering purposes.
+type filterUnit struct {
+	svcType ServiceType        // Unit type
+	proto   ServiceProto       // Unit protocol
+	prn     *PrinterParameters // Printer or faxout parameters
+	scan    *ScannerParameters // Scanner parameters
+}
+
+// filterAttrs contains all known filter attributes
+var filterAttrs = []*filterAttr{
+	{
+		name:   "model",
+		devStr: func(dev *Device) []string { return []string{dev.MakeModel} },
+	},
+	{
+		name:   "location",
+		devStr: func(dev *Device) []string { return []string{dev.Location} },
+	},
+	{
+		name:   "name",
+		devStr: func(dev *Device) []string { return []string{dev.DNSSDName} },
+	},
+	{
+		name: "uuid",
+		devStr: func(dev *Device) []string {
+			return []string{dev.DNSSDUUID.String()}
+		},
+	},
+	{
+		name:   "serial",
+		devStr: func(dev *Device) []string { return []string{dev.USBSerial} },
+	},
+	{
+		name: "addr",
+		devStr: func(dev *Device) []string {
+			s := make([]string, len(dev.Addrs))
+			for i, addr := range dev.Addrs {
+				s[i] = addr.String()
+			}
+			return s
+		},
+	},
+	{
+		name:    "verified",
+		devBool: func(dev *Device) bool { return dev.Verified },
+	},
+	{
+		name: "type",
+		unitStr: func(un *filterUnit) []string {
+			return []string{un.svcType.String()}
+		},
+	},
+	{
+		name: "proto",
+		unitStr: func(un *filterUnit) []string {
+			return []string{un.proto.String()}
+		},
+	},
+	{
+		name: "pdl",
+		unitStr: func(un *filterUnit) []string {
+			if un.scan != nil {
+				return un.scan.PDL
+			}
+			return un.prn.PDL
+		},
+	},
+	{
+		name: "duplex",
+		unitBool: func(un *filterUnit) bool {
+			if un.scan != nil {
+				return un.scan.Duplex == OptTrue
+			}
+			return un.prn.Duplex == OptTrue
+		},
+	},
+	{
+		name: "color",
+		unitBool: func(un *filterUnit) bool {
+			if un.scan != nil {
+				return un.scan.Colors.Contains(
+					abstract.ColorModeColor)
+			}
+			return un.prn.Color == OptTrue
+		},
+	},
+	{
+		name: "adf",
+		unitBool: func(un *filterUnit) bool {
+			return un.scan != nil && un.scan.Sources&ScanADF != 0
+		},
+	},
+	{
+		name: "platen",
+		unitBool: func(un *filterUnit) bool {
+			return un.scan != nil && un.scan.Sources&ScanPlaten != 0
+		},
+	},
+	filterPrinterFlag("bind", func(p *PrinterParameters) Option {
+		return p.Bind
+	}),
+	filterPrinterFlag("collate", func(p *PrinterParameters) Option {
+		return p.Collate
+	}),
+	filterPrinterFlag("copies", func(p *PrinterParameters) Option {
+		return p.Copies
+	}),
+	filterPrinterFlag("punch", func(p *PrinterParameters) Option {
+		return p.Punch
+	}),
+	filterPrinterFlag("sort", func(p *PrinterParameters) Option {
+		return p.Sort
+	}),
+	filterPrinterFlag("staple", func(p *PrinterParameters) Option {
+		return p.Staple
+	}),
+}
+
+// filterPrinterFlag makes filterAttr for the Boolean flag of
+// the PrinterParameters.
+func filterPrinterFlag(name string,
+	get func(p *PrinterParameters) Option) *filterAttr {
+
+	return &filterAttr{
+		name: name,
+		unitBool: func(un *filterUnit) bool {
+			return un.prn != nil && get(un.prn) == OptTrue
+		},
+	}
+}
+
+// filterAttrByName returns filterAttr by name or nil, if not found.
+func filterAttrByName(name string) *filterAttr {
+	for _, attr := range filterAttrs {
+		if attr.name == name {
+			return attr
+		}
+	}
+	return nil
+}
+
+// isBool reports whether attribute is Boolean.
+func (attr *filterAttr) isBool() bool {
+	return attr.devBool != nil || attr.unitBool != nil
+}
+
+// isUnit reports whether attribute belongs to the device unit.
+func (attr *filterAttr) isUnit() bool {
+	return attr.unitStr != nil || attr.unitBool != nil
+}
+
+// ParseFilter parses the filter expression.
+// See [Filter] for the expression syntax.
+func ParseFilter(expr string) (*Filter, error) {
+	tokens, err := filterTokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("filter: empty expression")
+	}
+
+	f := &Filter{}
+	for len(tokens) > 0 {
+		if len(f.terms) > 0 {
+			if tokens[0] != "&&" {
+				return nil, fmt.Errorf("filter: %q: && expected",
+					tokens[0])
+			}
+			tokens = tokens[1:]
+		}
+
+		var term filterTerm
+		term, tokens, err = filterParseTerm(tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		f.terms = append(f.terms, term)
+	}
+
+	return f, nil
+}
+
+// filterParseTerm parses the next term of the filter expression.
+// It returns the parsed term and the remaining tokens.
+func filterParseTerm(tokens []string) (filterTerm, []string, error) {
+	var term filterTerm
+
+	if len(tokens) > 0 && tokens[0] == "!" {
+		term.not = true
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) == 0 || filterIsOperator(tokens[0]) {
+		return term, nil, errors.New("filter: attribute name expected")
+	}
+
+	name := tokens[0]
+	tokens = tokens[1:]
+
+	term.attr = filterAttrByName(strings.ToLower(name))
+	if term.attr == nil {
+		return term, nil, fmt.Errorf("filter: unknown attribute %q",
+			name)
+	}
+
+	// Boolean attribute
+	if term.attr.isBool() {
+		if len(tokens) > 0 && tokens[0] != "&&" {
+			return term, nil, fmt.Errorf(
+				"filter: %s: Boolean attribute can't be compared",
+				name)
+		}
+		return term, tokens, nil
+	}
+
+	// String comparison
+	if term.not {
+		return term, nil, fmt.Errorf("filter: !%s: use != or !~", name)
+	}
+
+	if len(tokens) < 2 {
+		return term, nil, fmt.Errorf("filter: %s: incomplete comparison",
+			name)
+	}
+
+	term.op, term.value = tokens[0], tokens[1]
+	tokens = tokens[2:]
+
+	switch term.op {
+	case "=", "!=":
+	case "~", "!~":
+		re, err := regexp.Compile("^(?:" + term.value + ")$")
+		if err != nil {
+			return term, nil, fmt.Errorf("filter: %s: %s", name, err)
+		}
+		term.re = re
+	default:
+		return term, nil, fmt.Errorf("filter: %s: operator expected",
+			name)
+	}
+
+	return term, tokens, nil
+}
+
+// filterTokenize splits the filter expression into tokens.
+func filterTokenize(expr string) ([]string, error) {
+	var tokens []string
+
+	for expr != "" {
+		c := rune(expr[0])
+		switch {
+		case unicode.IsSpace(c):
+			expr = expr[1:]
+
+		case strings.HasPrefix(expr, "&&"),
+			strings.HasPrefix(expr, "!="),
+			strings.HasPrefix(expr, "!~"):
+			tokens = append(tokens, expr[:2])
+			expr = expr[2:]
+
+		case c == '!' || c == '=' || c == '~':
+			tokens = append(tokens, expr[:1])
+			expr = expr[1:]
+
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expr[1:], expr[0])
+			if end < 0 {
+				return nil, errors.New("filter: unterminated string")
+			}
+			tokens = append(tokens, expr[1:end+1])
+			expr = expr[end+2:]
+
+		case c == '&':
+			return nil, errors.New("filter: '&' is not allowed, use &&")
+
+		default:
+			end := strings.IndexFunc(expr, func(c rune) bool {
+				return unicode.IsSpace(c) ||
+					strings.ContainsRune("&!=~'\"", c)
+			})
+			if end < 0 {
+				end = len(expr)
+			}
+			tokens = append(tokens, expr[:end])
+			expr = expr[end:]
+		}
+	}
+
+	return tokens, nil
+}
+
+// filterIsOperator reports whether token is operator.
+func filterIsOperator(token string) bool {
+	switch token {
+	case "&&", "!", "=", "!=", "~", "!~":
+		return true
+	}
+	return false
+}
+
+// String returns the Filter expression in the canonical form.
+func (f *Filter) String() string {
+	s := make([]string, len(f.terms))
+	for i, term := range f.terms {
+		switch {
+		case term.attr.isBool() && term.not:
+			s[i] = "!" + term.attr.name
+		case term.attr.isBool():
+			s[i] = term.attr.name
+		default:
+			q := "'"
+			if strings.Contains(term.value, q) {
+				q = `"`
+			}
+			s[i] = term.attr.name + term.op + q + term.value + q
+		}
+	}
+
+	return strings.Join(s, " && ")
+}
+
+// Match reports whether the Device matches the Filter.
+func (f *Filter) Match(dev Device) bool {
+	unitTerms := false
+
+	// Check device attributes first
+	for _, term := range f.terms {
+		switch {
+		case term.attr.isUnit():
+			unitTerms = true
+		case term.attr.devBool != nil:
+			if term.attr.devBool(&dev) == term.not {
+				return false
+			}
+		default:
+			if !term.matchStrings(term.attr.devStr(&dev)) {
+				return false
+			}
+		}
+	}
+
+	if !unitTerms {
+		return true
+	}
+
+	// Now look for the unit, that satisfies all unit terms
+	for _, un := range filterUnits(&dev) {
+		if f.matchUnit(&un) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply returns devices that match the Filter.
+func (f *Filter) Apply(devices []Device) []Device {
+	var out []Device
+	for _, dev := range devices {
+		if f.Match(dev) {
+			out = append(out, dev)
+		}
+	}
+	return out
+}
+
+// matchUnit reports whether the unit satisfies all unit terms
+// of the Filter.
+func (f *Filter) matchUnit(un *filterUnit) bool {
+	for _, term := range f.terms {
+		switch {
+		case term.attr.unitBool != nil:
+			if term.attr.unitBool(un) == term.not {
+				return false
+			}
+		case term.attr.unitStr != nil:
+			if !term.matchStrings(term.attr.unitStr(un)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchStrings reports whether the string term matches values of
+// the multi-valued attribute.
+//
+// The positive operators (= and ~) require any of values to match,
+// the negative operators (!= and !~) require none of values to match.
+func (term *filterTerm) matchStrings(values []string) bool {
+	neg := term.op == "!=" || term.op == "!~"
+
+	if len(values) == 0 {
+		values = []string{""}
+	}
+
+	for _, v := range values {
+		var match bool
+		if term.re != nil {
+			match = term.re.MatchString(v)
+		} else {
+			match = strings.EqualFold(v, term.value)
+		}
+
+		if match {
+			return !neg
+		}
+	}
+
+	return neg
+}
+
+// filterUnits returns all device units as a slice of filterUnit.
+func filterUnits(dev *Device) []filterUnit {
+	var units []filterUnit
+
+	for i := range dev.PrintUnits {
+		un := &dev.PrintUnits[i]
+		units = append(units, filterUnit{
+			svcType: ServicePrinter,
+			proto:   un.Proto,
+			prn:     &un.Params,
+		})
+	}
+
+	for i := range dev.ScanUnits {
+		un := &dev.ScanUnits[i]
+		units = append(units, filterUnit{
+			svcType: ServiceScanner,
+			proto:   un.Proto,
+			scan:    &un.Params,
+		})
+	}
+
+	for i := range dev.FaxoutUnits {
+		un := &dev.FaxoutUnits[i]
+		units = append(units, filterUnit{
+			svcType: ServiceFaxout,
+			proto:   un.Proto,
+			prn:     &un.Params,
+		})
+	}
+
+	return units
+}
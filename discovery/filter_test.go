@@ -0,0 +1,203 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Devices filtering test
+
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/generic"
+)
+
+// TestFilterParse tests ParseFilter
+func TestFilterParse(t *testing.T) {
+	type testData struct {
+		expr string // Input expression
+		out  string // Expected canonical form
+		err  string // Expected error
+	}
+
+	tests := []testData{
+		{
+			expr: "type=scanner && proto=escl && duplex",
+			out:  "type='scanner' && proto='escl' && duplex",
+		},
+		{
+			expr: "model~'Kyocera.*'",
+			out:  "model~'Kyocera.*'",
+		},
+		{
+			expr: `  Location != "2nd Floor" &&!color&&adf`,
+			out:  "location!='2nd Floor' && !color && adf",
+		},
+		{
+			expr: `model!~"HP's.*"`,
+			out:  `model!~"HP's.*"`,
+		},
+		{
+			expr: "",
+			err:  "filter: empty expression",
+		},
+		{
+			expr: "type=scanner &&",
+			err:  "filter: attribute name expected",
+		},
+		{
+			expr: "type=scanner duplex",
+			err:  `filter: "duplex": && expected`,
+		},
+		{
+			expr: "type=scanner & duplex",
+			err:  "filter: '&' is not allowed, use &&",
+		},
+		{
+			expr: "vendor=HP",
+			err:  `filter: unknown attribute "vendor"`,
+		},
+		{
+			expr: "duplex=true",
+			err:  "filter: duplex: Boolean attribute can't be compared",
+		},
+		{
+			expr: "!model",
+			err:  "filter: !model: use != or !~",
+		},
+		{
+			expr: "model",
+			err:  "filter: model: incomplete comparison",
+		},
+		{
+			expr: "model HP Inc",
+			err:  "filter: model: operator expected",
+		},
+		{
+			expr: "model='HP",
+			err:  "filter: unterminated string",
+		},
+		{
+			expr: "model~'('",
+			err: "filter: model: error parsing regexp: " +
+				"missing closing ): `^(?:()$`",
+		},
+	}
+
+	for _, test := range tests {
+		f, err := ParseFilter(test.expr)
+
+		errstr := ""
+		if err != nil {
+			errstr = err.Error()
+		}
+
+		if errstr != test.err {
+			t.Errorf("%q: error mismatch:\n"+
+				"expected: %s\n"+
+				"present:  %s",
+				test.expr, test.err, errstr)
+			continue
+		}
+
+		if err == nil && f.String() != test.out {
+			t.Errorf("%q: output mismatch:\n"+
+				"expected: %s\n"+
+				"present:  %s",
+				test.expr, test.out, f.String())
+		}
+	}
+}
+
+// TestFilterMatch tests Filter.Match
+func TestFilterMatch(t *testing.T) {
+	kyocera := Device{
+		MakeModel: "Kyocera ECOSYS M2040dn",
+		Addrs:     []netip.Addr{netip.MustParseAddr("192.168.0.10")},
+		Verified:  true,
+		PrintUnits: []PrintUnit{
+			{
+				Proto: ServiceIPP,
+				Params: PrinterParameters{
+					Duplex: OptTrue,
+					PDL:    []string{"application/pdf"},
+				},
+			},
+		},
+		ScanUnits: []ScanUnit{
+			{
+				Proto: ServiceESCL,
+				Params: ScannerParameters{
+					Sources: ScanPlaten | ScanADF,
+					Colors: generic.MakeBitset(
+						abstract.ColorModeColor),
+				},
+			},
+		},
+	}
+
+	hp := Device{
+		MakeModel: "HP LaserJet",
+		ScanUnits: []ScanUnit{
+			{
+				Proto: ServiceWSD,
+				Params: ScannerParameters{
+					Duplex:  OptTrue,
+					Sources: ScanPlaten,
+				},
+			},
+		},
+	}
+
+	type testData struct {
+		expr    string // Filter expression
+		matched []bool // Expected results for kyocera, hp
+	}
+
+	tests := []testData{
+		{"model~'Kyocera.*'", []bool{true, false}},
+		{"model~'Kyocera'", []bool{false, false}},
+		{"model='hp laserjet'", []bool{false, true}},
+		{"model!='hp laserjet'", []bool{true, false}},
+		{"type=scanner", []bool{true, true}},
+		{"type=printer", []bool{true, false}},
+		{"type=scanner && proto=escl", []bool{true, false}},
+		{"type=scanner && duplex", []bool{false, true}},
+		{"duplex", []bool{true, true}},
+		{"type=scanner && !duplex", []bool{true, false}},
+		{"adf && color", []bool{true, false}},
+		{"verified", []bool{true, false}},
+		{"!verified", []bool{false, true}},
+		{"addr=192.168.0.10", []bool{true, false}},
+		{"addr!=192.168.0.10", []bool{false, true}},
+		{"pdl=application/pdf", []bool{true, false}},
+		{"pdl!~'.*pdf'", []bool{true, true}},
+	}
+
+	for _, test := range tests {
+		f, err := ParseFilter(test.expr)
+		if err != nil {
+			t.Errorf("%q: %s", test.expr, err)
+			continue
+		}
+
+		for i, dev := range []Device{kyocera, hp} {
+			matched := f.Match(dev)
+			if matched != test.matched[i] {
+				t.Errorf("%q: %q: expected %v, present %v",
+					test.expr, dev.MakeModel,
+					test.matched[i], matched)
+			}
+		}
+	}
+
+	f, _ := ParseFilter("type=scanner && duplex")
+	devices := f.Apply([]Device{kyocera, hp})
+	if len(devices) != 1 || devices[0].MakeModel != hp.MakeModel {
+		t.Errorf("Filter.Apply: unexpected result: %v", devices)
+	}
+}
@@ -0,0 +1,212 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Service-protocol capability matrix
+
+package discovery
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MatrixProto is the column of the [ProtoMatrix].
+//
+// Unlike [ServiceProto], it distinguishes plain and TLS variants
+// of the same protocol, and WSD print and scan services, as they
+// are different integration paths for the client.
+type MatrixProto int
+
+// MatrixProto constants:
+const (
+	MatrixIPP      MatrixProto = iota // IPP over plain HTTP
+	MatrixIPPS                        // IPP over TLS
+	MatrixESCL                        // eSCL over plain HTTP
+	MatrixESCLTLS                     // eSCL over TLS
+	MatrixWSDPrint                    // WSD print service
+	MatrixWSDScan                     // WSD scan service
+	MatrixLPD                         // LPD
+	matrixProtoMax
+)
+
+// MatrixProtos lists all MatrixProto values in the display order.
+var MatrixProtos = []MatrixProto{
+	MatrixIPP,
+	MatrixIPPS,
+	MatrixESCL,
+	MatrixESCLTLS,
+	MatrixWSDPrint,
+	MatrixWSDScan,
+	MatrixLPD,
+}
+
+// MatrixServices lists all rows of the [ProtoMatrix] in the display order.
+var MatrixServices = []ServiceType{
+	ServicePrinter,
+	ServiceScanner,
+	ServiceFaxout,
+}
+
+// String returns MatrixProto name.
+func (p MatrixProto) String() string {
+	switch p {
+	case MatrixIPP:
+		return "IPP"
+	case MatrixIPPS:
+		return "IPPS"
+	case MatrixESCL:
+		return "eSCL"
+	case MatrixESCLTLS:
+		return "eSCL-TLS"
+	case MatrixWSDPrint:
+		return "WSD-print"
+	case MatrixWSDScan:
+		return "WSD-scan"
+	case MatrixLPD:
+		return "LPD"
+	}
+
+	return fmt.Sprintf("unknown (%d)", int(p))
+}
+
+// Applicable reports whether protocol may provide the service
+// of the specified type at all.
+func (p MatrixProto) Applicable(svc ServiceType) bool {
+	switch p {
+	case MatrixIPP, MatrixIPPS:
+		return true
+	case MatrixESCL, MatrixESCLTLS, MatrixWSDScan:
+		return svc == ServiceScanner
+	case MatrixWSDPrint, MatrixLPD:
+		return svc == ServicePrinter
+	}
+
+	return false
+}
+
+// MatrixState is the state of the [ProtoMatrix] cell.
+type MatrixState int
+
+// MatrixState constants, in order of increasing confidence:
+const (
+	MatrixNotFound   MatrixState = iota // Not discovered
+	MatrixDiscovered                    // Discovered, not verified
+	MatrixVerified                      // Discovered and verified
+)
+
+// String returns MatrixState name.
+func (st MatrixState) String() string {
+	switch st {
+	case MatrixNotFound:
+		return "not-found"
+	case MatrixDiscovered:
+		return "discovered"
+	case MatrixVerified:
+		return "verified"
+	}
+
+	return fmt.Sprintf("unknown (%d)", int(st))
+}
+
+// ProtoMatrix shows, which protocols were discovered and verified
+// for each function (print, scan, faxout) of the device.
+//
+// It helps to understand, why particular integration path
+// is not offered for the device.
+type ProtoMatrix struct {
+	cells [ServiceFaxout + 1][matrixProtoMax]MatrixState
+}
+
+// ProtoMatrix returns the service-protocol capability matrix
+// of the Device.
+//
+// Plain and TLS variants are distinguished by the schemes of
+// the unit endpoints. Protocols that don't fit the matrix (i.e.,
+// AppSocket and USB) are not shown.
+func (dev Device) ProtoMatrix() ProtoMatrix {
+	var m ProtoMatrix
+
+	for _, un := range dev.PrintUnits {
+		m.add(ServicePrinter, un.Proto, un.Endpoints, un.Verified)
+	}
+
+	for _, un := range dev.ScanUnits {
+		m.add(ServiceScanner, un.Proto, un.Endpoints, un.Verified)
+	}
+
+	for _, un := range dev.FaxoutUnits {
+		m.add(ServiceFaxout, un.Proto, un.Endpoints, un.Verified)
+	}
+
+	return m
+}
+
+// Get returns the state of the matrix cell.
+func (m ProtoMatrix) Get(svc ServiceType, proto MatrixProto) MatrixState {
+	if svc < 0 || svc > ServiceFaxout || proto < 0 || proto >= matrixProtoMax {
+		return MatrixNotFound
+	}
+
+	return m.cells[svc][proto]
+}
+
+// add adds the unit to the matrix.
+func (m *ProtoMatrix) add(svc ServiceType, proto ServiceProto,
+	endpoints []string, verified bool) {
+
+	state := MatrixDiscovered
+	if verified {
+		state = MatrixVerified
+	}
+
+	var plain, secure MatrixProto
+
+	switch {
+	case proto == ServiceIPP:
+		plain, secure = MatrixIPP, MatrixIPPS
+	case proto == ServiceESCL:
+		plain, secure = MatrixESCL, MatrixESCLTLS
+	case proto == ServiceWSD && svc == ServicePrinter:
+		plain, secure = MatrixWSDPrint, MatrixWSDPrint
+	case proto == ServiceWSD && svc == ServiceScanner:
+		plain, secure = MatrixWSDScan, MatrixWSDScan
+	case proto == ServiceLPD:
+		plain, secure = MatrixLPD, MatrixLPD
+	default:
+		return
+	}
+
+	if len(endpoints) == 0 {
+		m.set(svc, plain, state)
+		return
+	}
+
+	for _, ep := range endpoints {
+		u, err := url.Parse(ep)
+		if err == nil && matrixSchemeSecure(u.Scheme) {
+			m.set(svc, secure, state)
+		} else {
+			m.set(svc, plain, state)
+		}
+	}
+}
+
+// set updates the matrix cell, if state is better that
+// the current one.
+func (m *ProtoMatrix) set(svc ServiceType, proto MatrixProto,
+	state MatrixState) {
+	m.cells[svc][proto] = max(m.cells[svc][proto], state)
+}
+
+// matrixSchemeSecure reports whether URL scheme implies TLS.
+func matrixSchemeSecure(scheme string) bool {
+	switch strings.ToLower(scheme) {
+	case "https", "ipps":
+		return true
+	}
+	return false
+}
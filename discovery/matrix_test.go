@@ -0,0 +1,73 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Service-protocol capability matrix test
+
+package discovery
+
+import "testing"
+
+// TestProtoMatrix tests Device.ProtoMatrix
+func TestProtoMatrix(t *testing.T) {
+	dev := Device{
+		PrintUnits: []PrintUnit{
+			{
+				Proto:     ServiceIPP,
+				Endpoints: []string{"ipp://127.0.0.1/ipp/print"},
+				Verified:  true,
+			},
+			{
+				Proto:     ServiceIPP,
+				Endpoints: []string{"ipps://127.0.0.1/ipp/print"},
+			},
+			{
+				Proto: ServiceLPD,
+			},
+			{
+				Proto:     ServiceAppSocket,
+				Endpoints: []string{"socket://127.0.0.1:9100"},
+			},
+		},
+		ScanUnits: []ScanUnit{
+			{
+				Proto:     ServiceESCL,
+				Endpoints: []string{"https://127.0.0.1/eSCL"},
+				Verified:  true,
+			},
+			{
+				Proto:     ServiceWSD,
+				Endpoints: []string{"http://127.0.0.1/wsd"},
+			},
+		},
+	}
+
+	type testData struct {
+		svc   ServiceType
+		proto MatrixProto
+		state MatrixState
+	}
+
+	tests := []testData{
+		{ServicePrinter, MatrixIPP, MatrixVerified},
+		{ServicePrinter, MatrixIPPS, MatrixDiscovered},
+		{ServicePrinter, MatrixLPD, MatrixDiscovered},
+		{ServicePrinter, MatrixWSDPrint, MatrixNotFound},
+		{ServiceScanner, MatrixESCL, MatrixNotFound},
+		{ServiceScanner, MatrixESCLTLS, MatrixVerified},
+		{ServiceScanner, MatrixWSDScan, MatrixDiscovered},
+		{ServiceScanner, MatrixWSDPrint, MatrixNotFound},
+		{ServiceFaxout, MatrixIPP, MatrixNotFound},
+	}
+
+	m := dev.ProtoMatrix()
+	for _, test := range tests {
+		state := m.Get(test.svc, test.proto)
+		if state != test.state {
+			t.Errorf("%s %s: expected %s, present %s",
+				test.svc, test.proto, test.state, state)
+		}
+	}
+}
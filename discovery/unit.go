@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"net/netip"
 	"strings"
+	"time"
 
 	"github.com/OpenPrinting/go-mfp/util/uuid"
 )
@@ -21,6 +22,7 @@ type PrintUnit struct {
 	Proto     ServiceProto      // Printing protocol
 	Params    PrinterParameters // Printer parameters
 	Endpoints []string          // URLs of printer endpoints
+	Verified  bool              // Some endpoints verified reachable
 }
 
 // ScanUnit represents a scan unit.
@@ -28,6 +30,7 @@ type ScanUnit struct {
 	Proto     ServiceProto      // Scanning protocol
 	Params    ScannerParameters // Scanner parameters
 	Endpoints []string          // URLs of printer endpoints
+	Verified  bool              // Some endpoints verified reachable
 }
 
 // FaxoutUnit represents a fax unit.
@@ -35,6 +38,7 @@ type FaxoutUnit struct {
 	Proto     ServiceProto      // Faxing protocol
 	Params    PrinterParameters // Printer parameters
 	Endpoints []string          // URLs of printer endpoints
+	Verified  bool              // Some endpoints verified reachable
 }
 
 // unit is the internal representation of the PrintUnit, ScanUnit
@@ -50,12 +54,18 @@ type unit struct {
 	Params          any          // PrinterParameters or ScannerParameters
 	Endpoints       []string     // Unit endpoints
 	Addrs           []netip.Addr // Addresses that unit use
+	Verified        bool         // Some endpoints verified reachable
+	FirstSeen       time.Time    // When unit was first discovered
+	LastSeen        time.Time    // When unit was last updated
 }
 
 // Merge merges two units
 func (un *unit) Merge(un2 unit) {
 	un.Endpoints = endpointsMerge(un.Endpoints, un2.Endpoints)
 	un.Addrs = addrsMerge(un.Addrs, un2.Addrs)
+	un.Verified = un.Verified || un2.Verified
+	un.FirstSeen = timeEarliest(un.FirstSeen, un2.FirstSeen)
+	un.LastSeen = timeLatest(un.LastSeen, un2.LastSeen)
 }
 
 // Export exports unit ad PrintUnit, ScanUnit or FaxoutUnit
@@ -70,12 +80,14 @@ func (un unit) Export() any {
 				Proto:     un.ID.SvcProto,
 				Params:    params,
 				Endpoints: un.Endpoints,
+				Verified:  un.Verified,
 			}
 		case ServiceFaxout:
 			return FaxoutUnit{
 				Proto:     un.ID.SvcProto,
 				Params:    params,
 				Endpoints: un.Endpoints,
+				Verified:  un.Verified,
 			}
 		}
 
@@ -84,6 +96,7 @@ func (un unit) Export() any {
 			Proto:     un.ID.SvcProto,
 			Params:    params,
 			Endpoints: un.Endpoints,
+			Verified:  un.Verified,
 		}
 	}
 
@@ -11,6 +11,7 @@ package wsdd
 import (
 	"context"
 	"net/netip"
+	"time"
 
 	"github.com/OpenPrinting/go-mfp/discovery"
 	"github.com/OpenPrinting/go-mfp/log"
@@ -19,27 +20,87 @@ import (
 
 // backend is the [discovery.Backend] for WSD device discovery.
 type backend struct {
-	ctx   context.Context       // For logging and backend.Close
-	queue *discovery.Eventqueue // Event queue
-	links *links                // Per-local address links
-	units *units                // Discovered units
-	mex   *mexGetter            // Metadata getter
-	res   *urlResolver          // URL resolver
+	ctx   context.Context         // For logging and backend.Close
+	queue *discovery.Eventqueue   // Event queue
+	links *links                  // Per-local address links
+	units *units                  // Discovered units
+	mex   *mexGetter              // Metadata getter
+	res   *urlResolver            // URL resolver
+	dups  *wsd.DupFilter          // Filters retransmitted messages
+	seqs  *wsd.AppSequenceTracker // Orders Hello/Bye per device
+	meta  *wsd.MetadataCache      // Tracks MetadataVersion per device
+	keys  wsd.KeyStore            // Verifies signatures, if not nil
+	probe wsd.Probe               // Probe template (Scopes and MatchBy)
+	proxy *proxies                // Discovery Proxies (managed mode)
+	dumps *wsd.Dumper             // Semantic dump of messages, for log
+}
+
+// Options represents the WSD backend creation options.
+//
+// Zero value is valid and gives the default behavior.
+type Options struct {
+	// FlapGracePeriod is the time, the network interface must
+	// remain stable after rapid down/up cycle (VPN reconnect,
+	// WiFi roaming and so on), before probing on that interface
+	// is started.
+	//
+	// If zero, [DefaultFlapGracePeriod] is used. Negative value
+	// disables flaps detection.
+	FlapGracePeriod time.Duration
+
+	// KeyStore, if not nil, enables authenticated discovery.
+	// Received messages must be signed by one of its keys,
+	// using the WS-Discovery compact signature, otherwise they
+	// are dropped.
+	KeyStore wsd.KeyStore
+
+	// Scopes, if not empty, narrow the discovery to devices
+	// with matching scopes. They are sent with Probe requests,
+	// so other devices don't respond, and announces of devices
+	// with non-matching scopes (i.e., Hello) are ignored.
+	//
+	// MatchBy is the scope matching rule, "" means the default
+	// rule (see [wsd.Scopes.Match]).
+	Scopes  wsd.Scopes
+	MatchBy string
 }
 
 // NewBackend creates a new [discovery.Backend] for WSD device discovery.
 func NewBackend(ctx context.Context) (discovery.Backend, error) {
+	return NewBackendWithOptions(ctx, Options{})
+}
+
+// NewBackendWithOptions creates a new [discovery.Backend] for WSD
+// device discovery with the specified [Options].
+func NewBackendWithOptions(ctx context.Context, opts Options) (
+	discovery.Backend, error) {
+
 	// Set log prefix
 	ctx = log.WithPrefix(ctx, "wsdd")
 
 	// Create backend structure
 	back := &backend{
-		ctx: ctx,
+		ctx:   ctx,
+		dups:  wsd.NewDupFilter(0),
+		seqs:  wsd.NewAppSequenceTracker(0),
+		meta:  wsd.NewMetadataCache(),
+		keys:  opts.KeyStore,
+		dumps: wsd.NewDumper(),
+		probe: wsd.Probe{
+			Types:   wsd.Types{wsd.Device},
+			Scopes:  opts.Scopes,
+			MatchBy: opts.MatchBy,
+		},
 	}
 
 	// Create links
+	grace := opts.FlapGracePeriod
+	if grace == 0 {
+		grace = DefaultFlapGracePeriod
+	}
+
 	var err error
-	back.links, err = newLinks(back)
+	back.links, err = newLinks(back, grace)
 	if err != nil {
 		return nil, err
 	}
@@ -48,6 +109,7 @@ func NewBackend(ctx context.Context) (discovery.Backend, error) {
 	back.units = newUnits(back)
 	back.mex = newMexGetter(back)
 	back.res = newURLResolver(back)
+	back.proxy = newProxies(back)
 
 	return back, nil
 }
@@ -68,6 +130,7 @@ func (back *backend) Start(queue *discovery.Eventqueue) {
 // Close closes the backend
 func (back *backend) Close() {
 	back.links.Close()
+	back.proxy.Close()
 	back.units.Close()
 	back.res.Close()
 }
@@ -82,12 +145,32 @@ func (back *backend) input(data []byte, from, to netip.AddrPort, ifidx int) {
 	// Decode the message
 	back.debug("%d bytes received from %s%%%d", len(data), from, ifidx)
 
-	msg, err := wsd.DecodeMsg(data)
+	var msg wsd.Msg
+	var err error
+
+	if back.keys != nil {
+		msg, err = wsd.DecodeMsgSigned(data, back.keys)
+	} else {
+		msg, err = wsd.DecodeMsg(data)
+	}
+
 	if err != nil {
 		back.warning("%s", err)
 		return
 	}
 
+	// Drop retransmitted duplicates
+	if back.dups.Duplicate(msg.Header.MessageID) {
+		back.debug("%s message: duplicate dropped", msg.Header.Action)
+		return
+	}
+
+	// Drop stale announces
+	if back.order(msg) == wsd.AppSequenceStale {
+		back.debug("%s message: stale, dropped", msg.Header.Action)
+		return
+	}
+
 	// Fill Msg.From, Msg.To and Msg.IfIdx
 	msg.From = from
 	msg.To = to
@@ -95,6 +178,11 @@ func (back *backend) input(data []byte, from, to netip.AddrPort, ifidx int) {
 
 	// Dispatch the message
 	back.debug("%s message received", msg.Header.Action)
+	back.dump(msg)
+
+	if back.proxy.Input(msg) {
+		return
+	}
 
 	switch msg.Header.Action {
 	case wsd.ActHello, wsd.ActBye, wsd.ActProbeMatches,
@@ -103,6 +191,43 @@ func (back *backend) input(data []byte, from, to netip.AddrPort, ifidx int) {
 	}
 }
 
+// order checks the order of the received Hello or Bye message,
+// relative to previous messages from the same device, using
+// the message AppSequence.
+//
+// Messages of other types and messages without AppSequence
+// are always [wsd.AppSequenceNext].
+func (back *backend) order(msg wsd.Msg) wsd.AppSequenceOrder {
+	if msg.Header.AppSequence == nil {
+		return wsd.AppSequenceNext
+	}
+
+	var addr wsd.AnyURI
+	switch body := msg.Body.(type) {
+	case wsd.Hello:
+		addr = body.EndpointReference.Address
+	case wsd.Bye:
+		addr = body.EndpointReference.Address
+	default:
+		return wsd.AppSequenceNext
+	}
+
+	seq := *msg.Header.AppSequence
+	order := back.seqs.Check(addr, seq)
+	if order == wsd.AppSequenceRestart {
+		back.debug("%s: device restarted (InstanceId=%d)",
+			addr, seq.InstanceID)
+	}
+
+	return order
+}
+
+// dump writes the semantic dump of the sent or received message
+// to the log, at the LevelTrace.
+func (back *backend) dump(msg wsd.Msg) {
+	log.Object(back.ctx, log.LevelTrace, 2, back.dumps.Dump(msg))
+}
+
 // Debug writes a LevelDebug message on behalf of the backend.
 func (back *backend) debug(format string, args ...any) {
 	log.Debug(back.ctx, format, args...)
@@ -0,0 +1,85 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Network interfaces flaps detection
+
+package wsdd
+
+import (
+	"sync"
+	"time"
+)
+
+// flaps detects rapid up/down cycles ("flaps") of network interfaces,
+// which happen, for example, on VPN reconnects or WiFi roaming.
+//
+// When interface goes up shortly after it went down, the full probe
+// burst is postponed until interface remains stable for the grace
+// period. Otherwise, each flap would cause multicast storm and
+// duplicate devices churn.
+//
+// Interfaces are identified by name rather than by index, as
+// interface index often changes when, say, VPN interface is
+// recreated.
+type flaps struct {
+	grace time.Duration        // Grace period, 0 if disabled
+	down  map[string]time.Time // Last down time, by interface name
+	lock  sync.Mutex           // Access lock
+}
+
+// newFlaps creates a new flaps detector with the specified
+// grace period. Zero or negative grace period disables detection.
+func newFlaps(grace time.Duration) *flaps {
+	return &flaps{
+		grace: max(grace, 0),
+		down:  make(map[string]time.Time),
+	}
+}
+
+// Down notifies flaps detector that interface went down.
+func (f *flaps) Down(ifname string, now time.Time) {
+	if f.grace == 0 {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.expire(now)
+	f.down[ifname] = now
+}
+
+// Up notifies flaps detector that interface went up.
+//
+// It returns the delay, the probing on the interface must be
+// postponed for. If interface is not flapping, it returns 0.
+func (f *flaps) Up(ifname string, now time.Time) time.Duration {
+	if f.grace == 0 {
+		return 0
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.expire(now)
+	if _, found := f.down[ifname]; found {
+		return f.grace
+	}
+
+	return 0
+}
+
+// expire forgets interfaces, that went down earlier than
+// the wsddFlapWindow ago.
+//
+// Must be called under the f.lock.
+func (f *flaps) expire(now time.Time) {
+	for ifname, t := range f.down {
+		if now.Sub(t) >= wsddFlapWindow {
+			delete(f.down, ifname)
+		}
+	}
+}
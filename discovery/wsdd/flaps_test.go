@@ -0,0 +1,51 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Network interfaces flaps detection test
+
+package wsdd
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFlaps tests flaps detector
+func TestFlaps(t *testing.T) {
+	const grace = 10 * time.Second
+
+	f := newFlaps(grace)
+	now := time.Now()
+
+	// Initial interface appearance is not a flap
+	if d := f.Up("wlan0", now); d != 0 {
+		t.Errorf("initial Up: expected 0, present %s", d)
+	}
+
+	// Rapid down/up cycle is a flap
+	f.Down("wlan0", now.Add(time.Second))
+	if d := f.Up("wlan0", now.Add(2*time.Second)); d != grace {
+		t.Errorf("flap Up: expected %s, present %s", grace, d)
+	}
+
+	// Other interfaces are not affected
+	if d := f.Up("eth0", now.Add(2*time.Second)); d != 0 {
+		t.Errorf("other Up: expected 0, present %s", d)
+	}
+
+	// Up long after down is not a flap
+	f.Down("tun0", now)
+	if d := f.Up("tun0", now.Add(wsddFlapWindow)); d != 0 {
+		t.Errorf("late Up: expected 0, present %s", d)
+	}
+
+	// Disabled detector never postpones probing
+	f = newFlaps(-1)
+	f.Down("wlan0", now)
+	if d := f.Up("wlan0", now); d != 0 {
+		t.Errorf("disabled Up: expected 0, present %s", d)
+	}
+}
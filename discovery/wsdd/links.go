@@ -12,12 +12,11 @@ import (
 	"context"
 	"net/netip"
 	"sync"
+	"time"
 
 	"github.com/OpenPrinting/go-mfp/internal/netstate"
 	"github.com/OpenPrinting/go-mfp/proto/wsd"
 	"github.com/OpenPrinting/go-mfp/util/generic"
-	"github.com/OpenPrinting/go-mfp/util/optional"
-	"github.com/OpenPrinting/go-mfp/util/uuid"
 )
 
 // links dynamically manages per-local-address UDP links.
@@ -29,6 +28,7 @@ type links struct {
 	table  map[netip.Addr]*link               // Per-local address links
 	lock   sync.Mutex                         // links.table lock
 	ports  *generic.LockedSet[netip.AddrPort] // Set of Local ports
+	flaps  *flaps                             // Interface flaps detector
 
 	// querier.procNetmon closing synchronization
 	ctxNetmon    context.Context    // Cancelable context for procNetmon
@@ -39,8 +39,11 @@ type links struct {
 	doneMconn sync.WaitGroup // Wait for procMconn termination
 }
 
-// newLinks creates a new links structure
-func newLinks(back *backend) (*links, error) {
+// newLinks creates a new links structure.
+//
+// The grace parameter specifies, how long interface must remain
+// stable after flap before probing is started (see flaps for details).
+func newLinks(back *backend, grace time.Duration) (*links, error) {
 	// Create multicast sockets
 	mconn4, err := newMconn(wsddMulticastIP4)
 	if err != nil {
@@ -61,6 +64,7 @@ func newLinks(back *backend) (*links, error) {
 		mconn6: mconn6,
 		table:  make(map[netip.Addr]*link),
 		ports:  generic.NewLockedSet[netip.AddrPort](),
+		flaps:  newFlaps(grace),
 	}
 
 	return lt, nil
@@ -110,8 +114,16 @@ func (lt *links) add(addr netstate.Addr) {
 		return
 	}
 
+	// Postpone probing, if interface is flapping
+	ifname := addr.Interface().Name()
+	delay := lt.flaps.Up(ifname, time.Now())
+	if delay != 0 {
+		lt.back.debug("%s: interface flaps, probing postponed for %s",
+			ifname, delay)
+	}
+
 	// Add link
-	l := newLink(lt, addr)
+	l := newLink(lt, addr, delay)
 
 	lt.lock.Lock()
 	lt.table[addr.Addr()] = l
@@ -126,6 +138,8 @@ func (lt *links) del(addr netstate.Addr) {
 		return
 	}
 
+	lt.flaps.Down(addr.Interface().Name(), time.Now())
+
 	// Del link
 	lt.lock.Lock()
 	l := lt.table[addr.Addr()]
@@ -191,7 +205,8 @@ func (lt *links) procMconn(mc *mconn) {
 type link struct {
 	parent     *links         // Parent links table
 	addr       netstate.Addr  // Local address
-	probeMsg   []byte         // Probe message
+	probe      wsd.Msg        // Probe message
+	probeMsg   []byte         // Probe message, encoded
 	probeSched *sched         // Probe scheduler
 	dest       netip.AddrPort // Destination (multicast) address
 	conn       *uconn         // Connection for sending UDP multicasts
@@ -199,12 +214,15 @@ type link struct {
 	doneReader sync.WaitGroup // Wait for procReader termination
 }
 
-// newLink creates a new link
-func newLink(lt *links, addr netstate.Addr) *link {
+// newLink creates a new link.
+//
+// Probing is started after the specified delay. If link is
+// closed before, nothing is sent.
+func newLink(lt *links, addr netstate.Addr, delay time.Duration) *link {
 	l := &link{
 		addr:       addr,
 		parent:     lt,
-		probeSched: newSched(false),
+		probeSched: newSched(false, delay),
 	}
 
 	if addr.Is4() {
@@ -267,11 +285,19 @@ func (l *link) procProber() {
 			l.updateProbeMsg()
 
 		case schedSend:
+			// In the managed mode, Discovery Proxy is
+			// probed instead (see proxies)
+			ifidx := l.addr.Interface().Index()
+			if back.proxy.Managed(ifidx) {
+				continue
+			}
+
 			if l.conn != nil {
 				l.conn.WriteToUDPAddrPort(l.probeMsg, l.dest)
 				back.debug("%s message sent to %s%%%s",
 					wsd.ActProbe, l.dest,
 					l.addr.Interface().Name())
+				back.dump(l.probe)
 			}
 		}
 	}
@@ -306,16 +332,10 @@ func (l *link) procReader() {
 
 // updateProbeMsg updates l.probeMsg
 func (l *link) updateProbeMsg() {
-	msgid := wsd.AnyURI(uuid.Must(uuid.Random()).URN())
 	msg := wsd.Msg{
-		Header: wsd.Header{
-			Action:    wsd.ActProbe,
-			MessageID: msgid,
-			To:        optional.New(wsd.ToDiscovery),
-		},
-		Body: wsd.Probe{
-			Types: []wsd.Type{wsd.Device},
-		},
+		Header: wsd.NewRequestHeader(wsd.ActProbe, wsd.ToDiscovery),
+		Body:   l.parent.back.probe,
 	}
+	l.probe = msg
 	l.probeMsg = msg.Encode()
 }
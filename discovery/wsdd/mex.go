@@ -9,18 +9,11 @@
 package wsdd
 
 import (
-	"bytes"
 	"context"
-	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"sync"
-	"time"
 
 	"github.com/OpenPrinting/go-mfp/proto/wsd"
-	"github.com/OpenPrinting/go-mfp/util/optional"
-	"github.com/OpenPrinting/go-mfp/util/uuid"
 )
 
 // mexData wraps wsd.Metadata and adds few additional fields
@@ -32,7 +25,7 @@ type mexData struct {
 // mexGetter retrieves WSD metadata by XAddr URL.
 type mexGetter struct {
 	back  *backend                    // Parent backend
-	http  http.Client                 // HTTP client
+	clnt  *wsd.Client                 // WSD metadata client
 	cache map[mexCacheID]*mexCacheEnt // Cached metadata
 	lock  sync.Mutex                  // Access lock
 }
@@ -40,10 +33,8 @@ type mexGetter struct {
 // newMexgetter creates a new mexGetter
 func newMexGetter(back *backend) *mexGetter {
 	mg := &mexGetter{
-		back: back,
-		http: http.Client{
-			Timeout: 5 * time.Second,
-		},
+		back:  back,
+		clnt:  wsd.NewClient(nil),
 		cache: make(map[mexCacheID]*mexCacheEnt),
 	}
 
@@ -58,8 +49,8 @@ func newMexGetter(back *backend) *mexGetter {
 //   - ver is the MetadataVersion. It comes together with the
 //     XAddr URLs as a part of the wsd.Announce structure.
 //
-// The MetadataVersion affects metadata caching. The never metadata
-// overrides the cached version.
+// The MetadataVersion affects metadata caching. The cached metadata
+// of the different version is not used and fetched again.
 func (mg *mexGetter) Get(ctx context.Context,
 	ifidx int, target wsd.AnyURI,
 	xaddr *url.URL, ver uint64) []mexData {
@@ -99,7 +90,7 @@ func (mg *mexGetter) Get(ctx context.Context,
 
 	var metadata []mexData
 	if len(xaddrs) > 0 {
-		metadata = mg.fetch(ctx, xaddrs, target)
+		metadata = mg.fetch(ctx, xaddrs, target, ver)
 	}
 
 	// Update the cache entry
@@ -113,6 +104,9 @@ func (mg *mexGetter) Get(ctx context.Context,
 //
 // It returns new or existing cache entry and 'true' as a seconf
 // returned value, if existent cache entry was found for this if.
+//
+// Completed entry of the different MetadataVersion is considered
+// outdated and replaced with the new one.
 func (mg *mexGetter) cacheLookup(id mexCacheID,
 	ver uint64) (*mexCacheEnt, bool) {
 
@@ -120,7 +114,7 @@ func (mg *mexGetter) cacheLookup(id mexCacheID,
 	defer mg.lock.Unlock()
 
 	ent := mg.cache[id]
-	if ent != nil {
+	if ent != nil && (ent.ver == ver || !ent.isDone()) {
 		return ent, true
 	}
 
@@ -142,7 +136,7 @@ func (mg *mexGetter) cacheUpdate(id mexCacheID,
 
 	if len(metadata) > 0 {
 		ent.metadata = metadata
-	} else {
+	} else if mg.cache[id] == ent {
 		delete(mg.cache, id)
 	}
 
@@ -151,7 +145,7 @@ func (mg *mexGetter) cacheUpdate(id mexCacheID,
 
 // fetch fetches the metadata
 func (mg *mexGetter) fetch(ctx context.Context,
-	xaddrs []*url.URL, target wsd.AnyURI) []mexData {
+	xaddrs []*url.URL, target wsd.AnyURI, ver uint64) []mexData {
 
 	// Fetch metadata
 	var wait sync.WaitGroup
@@ -164,6 +158,7 @@ func (mg *mexGetter) fetch(ctx context.Context,
 		go func(xaddr2 *url.URL) {
 			meta, err := mg.fetchHTTP(ctx, target, xaddr2)
 			if err == nil {
+				mg.back.meta.Put(target, ver, meta.Metadata)
 				lock.Lock()
 				metadata = append(metadata, meta)
 				lock.Unlock()
@@ -182,78 +177,17 @@ func (mg *mexGetter) fetch(ctx context.Context,
 func (mg *mexGetter) fetchHTTP(ctx context.Context,
 	target wsd.AnyURI, xaddr *url.URL) (meta mexData, err error) {
 
-	// Create a request
-	msgid := wsd.AnyURI(uuid.Must(uuid.Random()).URN())
-	msg := wsd.Msg{
-		Header: wsd.Header{
-			Action:    wsd.ActGet,
-			MessageID: msgid,
-			To:        optional.New(target),
-		},
-		Body: wsd.Get{},
-	}
+	ctx, cancel := context.WithTimeout(ctx, wsddMetadataGetTimeout)
+	defer cancel()
 
-	data := msg.Encode()
-
-	rq := &http.Request{
-		Method: "POST",
-		URL:    xaddr,
-		Body:   io.NopCloser(bytes.NewReader(data)),
-		Close:  true,
-	}
-
-	rq = rq.WithContext(ctx)
-
-	// Perform HTTP query
 	mg.back.debug("POST %s", xaddr)
 
-	rsp, err := mg.http.Do(rq)
+	metadata, err := mg.clnt.Get(ctx, xaddr, target)
 	if err != nil {
 		mg.back.warning("POST %s: %s", xaddr, err)
 		return
 	}
 
-	defer rsp.Body.Close()
-
-	if rsp.StatusCode/100 != 2 {
-		err = fmt.Errorf("Unexpected HTTP status: %s", rsp.Status)
-		mg.back.warning("POST %s: %s", xaddr, err)
-		return
-	}
-
-	// Fetch HTTP response body
-	data, err = io.ReadAll(io.LimitReader(rsp.Body,
-		int64(wsddMetadataGetMaxResponse+1)))
-
-	if err != nil {
-		mg.back.warning("POST %s: %s", xaddr, err)
-		return
-	}
-
-	if len(data) > wsddMetadataGetMaxResponse {
-		err = fmt.Errorf("HTTP response too large")
-		mg.back.warning("POST %s: %s", xaddr, err)
-		return
-	}
-
-	mg.back.debug("POST %s: %s", xaddr, rsp.Status)
-
-	// Decode response
-	msg, err = wsd.DecodeMsg(data)
-	if err != nil {
-		mg.back.warning("POST %s: %s", xaddr, err)
-		return
-	}
-
-	metadata, ok := msg.Body.(wsd.Metadata)
-	if !ok {
-		err = fmt.Errorf("Unexpected WSD response: %s",
-			msg.Header.Action)
-
-		mg.back.warning("POST %s: %s", xaddr, err)
-		return
-	}
-
 	meta.Metadata = metadata
 	meta.from = xaddr
 
@@ -0,0 +1,239 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Discovery Proxy (managed mode)
+
+package wsdd
+
+import (
+	"context"
+	"net/netip"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/internal/zone"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+)
+
+// proxies tracks Discovery Proxies and implements the managed
+// mode of WS-Discovery.
+//
+// Discovery Proxy announces itself with Hello of the d:DiscoveryProxy
+// type, either multicast or unicast, in response to our Probe. On
+// enterprise networks multicast is often blocked between segments,
+// and proxy is the only way to find devices.
+//
+// While proxy is known on the network interface, multicast probing
+// on this interface is suppressed, and Probe requests are sent
+// directly to the proxy via HTTP. When proxy says Bye or becomes
+// unreachable, probing returns to the multicast (ad hoc) mode.
+type proxies struct {
+	back    *backend              // Parent backend
+	clnt    *wsd.Client           // Client for unicast requests
+	table   map[wsd.AnyURI]*proxy // Known proxies, by address
+	closing bool                  // Close in progress
+	lock    sync.Mutex            // Access lock
+	wait    sync.WaitGroup        // Wait for proxies.proc termination
+}
+
+// proxy represents a single Discovery Proxy.
+type proxy struct {
+	addr   wsd.AnyURI         // Proxy endpoint address
+	xaddrs []*url.URL         // Proxy transport addresses
+	from   netip.AddrPort     // Where Hello came from
+	ifidx  int                // Network interface index
+	cancel context.CancelFunc // Cancels proxies.proc
+}
+
+// newProxies creates a new proxies table.
+func newProxies(back *backend) *proxies {
+	return &proxies{
+		back:  back,
+		clnt:  wsd.NewClient(nil),
+		table: make(map[wsd.AnyURI]*proxy),
+	}
+}
+
+// Close closes the proxies table and stops all activity.
+func (px *proxies) Close() {
+	px.lock.Lock()
+	px.closing = true
+	for _, p := range px.table {
+		p.cancel()
+	}
+	px.lock.Unlock()
+
+	px.wait.Wait()
+}
+
+// Managed reports if the network interface is in the managed mode,
+// i.e., some Discovery Proxy is known on it and multicast probing
+// must be suppressed.
+func (px *proxies) Managed(ifidx int) bool {
+	px.lock.Lock()
+	defer px.lock.Unlock()
+
+	for _, p := range px.table {
+		if p.ifidx == ifidx {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Input handles the received message. It returns true, if message
+// was consumed, i.e., it was Hello or Bye of the Discovery Proxy.
+func (px *proxies) Input(msg wsd.Msg) bool {
+	switch body := msg.Body.(type) {
+	case wsd.Hello:
+		if body.Types.Contains(wsd.DiscoveryProxy) {
+			px.hello(msg, body)
+			return true
+		}
+
+	case wsd.Bye:
+		return px.bye(body.EndpointReference.Address)
+	}
+
+	return false
+}
+
+// hello handles Hello of the Discovery Proxy.
+func (px *proxies) hello(msg wsd.Msg, body wsd.Hello) {
+	addr := body.EndpointReference.Address
+	xaddrs := body.XAddrs.URLs(wsd.XAddrsFilter{
+		Zone: zone.Name(msg.IfIdx),
+	})
+
+	if len(xaddrs) == 0 {
+		px.back.debug("Discovery Proxy %s: no usable XAddrs", addr)
+		return
+	}
+
+	px.lock.Lock()
+	defer px.lock.Unlock()
+
+	if px.closing || px.table[addr] != nil {
+		return
+	}
+
+	px.back.debug("Discovery Proxy %s found at %s%%%d: managed mode",
+		addr, msg.From, msg.IfIdx)
+
+	ctx, cancel := context.WithCancel(px.back.ctx)
+	p := &proxy{
+		addr:   addr,
+		xaddrs: xaddrs,
+		from:   msg.From,
+		ifidx:  msg.IfIdx,
+		cancel: cancel,
+	}
+
+	px.table[addr] = p
+
+	px.wait.Add(1)
+	go px.proc(ctx, p)
+}
+
+// bye handles Bye. It returns true, if Bye came from the
+// known Discovery Proxy.
+func (px *proxies) bye(addr wsd.AnyURI) bool {
+	px.lock.Lock()
+	defer px.lock.Unlock()
+
+	p := px.table[addr]
+	if p == nil {
+		return false
+	}
+
+	px.back.debug("Discovery Proxy %s: Bye received: ad hoc mode", addr)
+	px.del(p)
+
+	return true
+}
+
+// del removes the proxy from the table and cancels its proc.
+//
+// Must be called under px.lock.
+func (px *proxies) del(p *proxy) {
+	if px.table[p.addr] == p {
+		delete(px.table, p.addr)
+	}
+
+	p.cancel()
+}
+
+// proc periodically sends Probe requests to the proxy.
+// It runs on its own goroutine.
+func (px *proxies) proc(ctx context.Context, p *proxy) {
+	defer px.wait.Done()
+
+	for {
+		err := px.probe(ctx, p)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			// Proxy doesn't respond. Return to the ad hoc mode.
+			px.back.warning("Discovery Proxy %s: %s: ad hoc mode",
+				p.addr, err)
+
+			px.lock.Lock()
+			px.del(p)
+			px.lock.Unlock()
+			return
+		}
+
+		t := time.NewTimer(wsddProxyProbeInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// probe sends the Probe request to the proxy and dispatches
+// received matches the same way as multicast ProbeMatches.
+//
+// XAddrs are tried in order, until the first success.
+func (px *proxies) probe(ctx context.Context, p *proxy) error {
+	var matches []wsd.ProbeMatch
+	var err error
+
+	for _, u := range p.xaddrs {
+		matches, err = px.clnt.Probe(ctx, u, p.addr, px.back.probe)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+
+		px.back.debug("Discovery Proxy %s: %s: %s", p.addr, u, err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	px.back.debug("Discovery Proxy %s: %d matches received",
+		p.addr, len(matches))
+
+	if len(matches) != 0 {
+		msg := wsd.Msg{
+			Header: wsd.Header{Action: wsd.ActProbeMatches},
+			Body:   wsd.ProbeMatches{ProbeMatch: matches},
+			From:   p.from,
+			IfIdx:  p.ifidx,
+		}
+
+		px.back.units.InputFromUDP(msg)
+	}
+
+	return nil
+}
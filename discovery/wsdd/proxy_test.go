@@ -0,0 +1,139 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Discovery Proxy (managed mode) test
+
+package wsdd
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// TestProxies tests the managed mode
+func TestProxies(t *testing.T) {
+	// Setup the proxy. Responder answers unicast Probe
+	// the same way as Discovery Proxy does.
+	const device = wsd.AnyURI("urn:uuid:1b8a6b2c-6d62-4c8e-8e48-3f4d5b1a0010")
+	const proxyAddr = wsd.AnyURI("urn:uuid:1b8a6b2c-6d62-4c8e-8e48-3f4d5b1a0020")
+	const ifidx = 1
+
+	mux := http.NewServeMux()
+	mux.Handle("/proxy", wsd.NewResponder(wsd.ResponderOptions{
+		EndpointReference: wsd.EndpointReference{Address: device},
+		Types:             wsd.Types{wsd.Device},
+		MetadataVersion:   1,
+	}))
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, mux)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	back := &backend{
+		ctx:   context.Background(),
+		meta:  wsd.NewMetadataCache(),
+		probe: wsd.Probe{Types: wsd.Types{wsd.Device}},
+	}
+
+	back.units = newUnits(back)
+	defer back.units.Close()
+
+	px := newProxies(back)
+	px.clnt = wsd.NewClient(tr)
+	defer px.Close()
+
+	hello := func(addr wsd.AnyURI, xaddr string) wsd.Msg {
+		return wsd.Msg{
+			Header: wsd.Header{Action: wsd.ActHello},
+			Body: wsd.Hello{
+				EndpointReference: wsd.EndpointReference{
+					Address: addr,
+				},
+				Types:  wsd.Types{wsd.DiscoveryProxy},
+				XAddrs: wsd.XAddrs{xaddr},
+			},
+			IfIdx: ifidx,
+		}
+	}
+
+	// wait waits until cond becomes true
+	wait := func(cond func() bool) bool {
+		for i := 0; i < 100; i++ {
+			if cond() {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	// Device Hello is not consumed
+	msg := hello(device, "http://localhost/device")
+	msg.Body = wsd.Hello{
+		EndpointReference: wsd.EndpointReference{Address: device},
+		Types:             wsd.Types{wsd.Device},
+	}
+
+	if px.Input(msg) {
+		t.Errorf("device Hello consumed by proxies")
+	}
+
+	// Device is discovered via proxy and registered in the
+	// MetadataCache, so the same version is not new anymore.
+	err := px.probe(context.Background(), &proxy{
+		addr:   proxyAddr,
+		xaddrs: []*url.URL{transport.MustParseURL("http://localhost/proxy")},
+		ifidx:  ifidx,
+	})
+
+	if err != nil {
+		t.Errorf("probe: %s", err)
+	}
+
+	if back.meta.Announce(device, 1) {
+		t.Errorf("device not discovered via proxy")
+	}
+
+	// Proxy Hello enters the managed mode
+	if !px.Input(hello(proxyAddr, "http://localhost/proxy")) {
+		t.Fatalf("proxy Hello not consumed")
+	}
+
+	if !px.Managed(ifidx) || px.Managed(ifidx+1) {
+		t.Errorf("Managed: wrong mode")
+	}
+
+	// Bye returns to the ad hoc mode
+	bye := wsd.Msg{
+		Header: wsd.Header{Action: wsd.ActBye},
+		Body: wsd.Bye{
+			EndpointReference: wsd.EndpointReference{
+				Address: proxyAddr,
+			},
+		},
+	}
+
+	if !px.Input(bye) {
+		t.Errorf("proxy Bye not consumed")
+	}
+
+	if px.Managed(ifidx) {
+		t.Errorf("Managed after Bye")
+	}
+
+	// Unreachable proxy is dropped
+	px.Input(hello(proxyAddr, "http://localhost/broken"))
+	if !wait(func() bool { return !px.Managed(ifidx) }) {
+		t.Errorf("Managed with unreachable proxy")
+	}
+}
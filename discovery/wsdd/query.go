@@ -0,0 +1,500 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// One-shot Probe and Resolve queries (for diagnostics)
+
+package wsdd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/internal/netstate"
+	"github.com/OpenPrinting/go-mfp/internal/zone"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+)
+
+// DefaultQueryTimeout is the default time, the [Query] waits
+// for responses.
+const DefaultQueryTimeout = 3 * time.Second
+
+// Query performs one-shot WS-Discovery Probe and Resolve queries.
+//
+// Unlike the discovery backend, created by [NewBackend], Query
+// doesn't maintain any state and doesn't track network changes.
+// It sends a single multicast request (with retransmissions),
+// collects all matches, received within the timeout and fetches
+// metadata of the discovered devices.
+//
+// It is intended for diagnostics of the discovery problems.
+type Query struct {
+	Interface string        // Network interface name, "" for all
+	Timeout   time.Duration // Time to wait for matches, 0 for default
+
+	// Scopes and MatchBy, if set, are sent with the Probe
+	// request, so only devices with matching scopes respond
+	// (see [wsd.Probe]). Received matches are not filtered,
+	// so devices that ignore scopes remain visible.
+	Scopes  wsd.Scopes
+	MatchBy string
+
+	// Proxy, if set, is the URL of the Discovery Proxy. Probe
+	// and Resolve requests are sent directly to the proxy via
+	// HTTP (the managed mode of WS-Discovery) instead of multicast,
+	// and Interface is ignored.
+	Proxy *url.URL
+}
+
+// QueryMatch represents a single ProbeMatch or ResolveMatch,
+// received by the [Query].
+type QueryMatch struct {
+	wsd.Announce                // Received announce
+	From         netip.AddrPort // Address of the responder
+	IfName       string         // Interface where match was received
+	Metadata     []QueryMeta    // Metadata, fetched from XAddrs
+}
+
+// QueryMeta represents the [wsd.Metadata], fetched by the [Query].
+type QueryMeta struct {
+	wsd.Metadata          // The metadata itself
+	From         *url.URL // URL it comes from
+}
+
+// queryRetransmit defines the intervals between retransmissions
+// of the multicast query. SOAP-over-UDP recommends to repeat
+// multicast messages, as UDP delivery is not reliable.
+var queryRetransmit = []time.Duration{
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+}
+
+// Probe sends the Probe request for the specified device types
+// and [Query.Scopes] and returns received matches.
+//
+// If types is empty, wsd.Device is assumed.
+func (q Query) Probe(ctx context.Context,
+	types wsd.Types) ([]QueryMatch, error) {
+
+	if len(types) == 0 {
+		types = wsd.Types{wsd.Device}
+	}
+
+	msg := wsd.Msg{
+		Header: wsd.NewRequestHeader(wsd.ActProbe, wsd.ToDiscovery),
+		Body: wsd.Probe{
+			Types:   types,
+			Scopes:  q.Scopes,
+			MatchBy: q.MatchBy,
+		},
+	}
+
+	return q.do(ctx, msg)
+}
+
+// Resolve sends the Resolve request for the target endpoint address
+// and returns received matches.
+func (q Query) Resolve(ctx context.Context,
+	target wsd.AnyURI) ([]QueryMatch, error) {
+
+	msg := wsd.Msg{
+		Header: wsd.NewRequestHeader(wsd.ActResolve, wsd.ToDiscovery),
+		Body: wsd.Resolve{
+			EndpointReference: wsd.EndpointReference{
+				Address: target,
+			},
+		},
+	}
+
+	return q.do(ctx, msg)
+}
+
+// Get fetches the device metadata directly from the known XAddr URL,
+// using the WS-Transfer Get request.
+//
+// The target is the device endpoint address (i.e., urn:uuid:...).
+// If not known, it may be empty; the XAddr URL is used instead,
+// which is accepted by most devices.
+//
+// Unlike Probe and Resolve, Get doesn't use multicast, and
+// the Interface parameter is ignored.
+func (q Query) Get(ctx context.Context,
+	target wsd.AnyURI, xaddr *url.URL) (QueryMeta, error) {
+
+	ctx = log.WithPrefix(ctx, "wsdd")
+	back := &backend{ctx: ctx, meta: wsd.NewMetadataCache()}
+	mg := newMexGetter(back)
+
+	if target == "" {
+		target = wsd.AnyURI(xaddr.String())
+	}
+
+	timeout := q.Timeout
+	if timeout == 0 {
+		timeout = wsddMetadataGetTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	data, err := mg.fetchHTTP(ctx, target, xaddr)
+	if err != nil {
+		return QueryMeta{}, err
+	}
+
+	return QueryMeta{data.Metadata, data.from}, nil
+}
+
+// do performs the query.
+func (q Query) do(ctx context.Context, msg wsd.Msg) ([]QueryMatch, error) {
+	ctx = log.WithPrefix(ctx, "wsdd")
+	back := &backend{ctx: ctx, meta: wsd.NewMetadataCache()}
+	back.mex = newMexGetter(back)
+	back.res = newURLResolver(back)
+	defer back.res.Close()
+
+	if q.Proxy != nil {
+		return q.doProxy(ctx, back, msg)
+	}
+
+	// Open connections
+	addrs, err := q.addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]*uconn, 0, len(addrs))
+	defer func() {
+		for _, uc := range conns {
+			uc.Close()
+		}
+	}()
+
+	for _, addr := range addrs {
+		uc, err := newUconn(addr, 0)
+		if err != nil {
+			back.debug("%s", err)
+			continue
+		}
+
+		conns = append(conns, uc)
+	}
+
+	if len(conns) == 0 {
+		return nil, fmt.Errorf("no suitable network interfaces")
+	}
+
+	// Prepare the message
+	corr := wsd.NewCorrelator(0)
+	corr.Add(msg)
+	data := msg.Encode()
+
+	// Start receivers
+	timeout := q.Timeout
+	if timeout == 0 {
+		timeout = DefaultQueryTimeout
+	}
+
+	qctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lock sync.Mutex
+	var wait sync.WaitGroup
+	var matches []QueryMatch
+	seen := make(map[string]struct{})
+
+	for _, uc := range conns {
+		wait.Add(1)
+		go func(uc *uconn) {
+			defer wait.Done()
+			for _, m := range q.recv(back, uc, corr) {
+				key := fmt.Sprintf("%s %s",
+					m.EndpointReference.Address, m.From)
+
+				lock.Lock()
+				if _, dup := seen[key]; !dup {
+					seen[key] = struct{}{}
+					matches = append(matches, m)
+				}
+				lock.Unlock()
+			}
+		}(uc)
+	}
+
+	// Send the request, with retransmissions
+	q.send(back, conns, msg.Header.Action, data)
+	for _, delay := range queryRetransmit {
+		select {
+		case <-qctx.Done():
+		case <-time.After(delay):
+			q.send(back, conns, msg.Header.Action, data)
+		}
+	}
+
+	// Wait for responses
+	<-qctx.Done()
+	for _, uc := range conns {
+		uc.Close()
+	}
+	wait.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Fetch metadata. Discovery Proxies, if any, answer with
+	// Hello and don't have device metadata.
+	for i := range matches {
+		if !matches[i].Types.Contains(wsd.DiscoveryProxy) {
+			matches[i].Metadata = q.metadata(ctx, back, matches[i])
+		}
+	}
+
+	return matches, nil
+}
+
+// doProxy performs the query via the Discovery Proxy.
+func (q Query) doProxy(ctx context.Context, back *backend,
+	msg wsd.Msg) ([]QueryMatch, error) {
+
+	timeout := q.Timeout
+	if timeout == 0 {
+		timeout = DefaultQueryTimeout
+	}
+
+	qctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Send the request
+	clnt := wsd.NewClient(nil)
+	var body wsd.AnnouncesBody
+
+	switch rq := msg.Body.(type) {
+	case wsd.Probe:
+		matches, err := clnt.Probe(qctx, q.Proxy, "", rq)
+		if err != nil {
+			return nil, err
+		}
+		body = wsd.ProbeMatches{ProbeMatch: matches}
+
+	case wsd.Resolve:
+		matches, err := clnt.Resolve(qctx, q.Proxy, "",
+			rq.EndpointReference.Address)
+		if err != nil {
+			return nil, err
+		}
+		body = wsd.ResolveMatches{ResolveMatch: matches}
+	}
+
+	back.debug("%s message received from %s", body.Action(), q.Proxy)
+
+	// Collect matches and fetch metadata
+	from, _ := netip.ParseAddrPort(q.Proxy.Host)
+
+	var matches []QueryMatch
+	for _, ann := range body.Announces() {
+		m := QueryMatch{Announce: ann, From: from}
+		m.Metadata = q.metadata(ctx, back, m)
+		matches = append(matches, m)
+	}
+
+	return matches, nil
+}
+
+// send sends the request via all connections.
+func (q Query) send(back *backend, conns []*uconn,
+	action wsd.Action, data []byte) {
+
+	for _, uc := range conns {
+		dest := wsddMulticastIP4
+		if uc.Is6() {
+			dest = wsddMulticastIP6
+		}
+
+		_, err := uc.WriteToUDPAddrPort(data, dest)
+		if err != nil {
+			back.warning("%s%%%s: %s", dest,
+				uc.local.Interface().Name(), err)
+			continue
+		}
+
+		back.debug("%s message sent to %s%%%s",
+			action, dest, uc.local.Interface().Name())
+	}
+}
+
+// recv receives matches from the connection until it is closed
+// or a non-temporary receive error occurs (see queryRecvTemporary).
+// Only responses to the query request, known to the Correlator,
+// are accepted.
+func (q Query) recv(back *backend, uc *uconn,
+	corr *wsd.Correlator) []QueryMatch {
+
+	var matches []QueryMatch
+	var buf [65536]byte
+	ifname := uc.local.Interface().Name()
+
+	for {
+		n, from, err := uc.RecvFrom(buf[:])
+
+		if uc.IsClosed() {
+			return matches
+		}
+
+		if err != nil {
+			back.error("UDP recv: %s", err)
+			if queryRecvTemporary(err) {
+				continue
+			}
+			return matches
+		}
+
+		back.debug("%d bytes received from %s%%%s", n, from, ifname)
+
+		msg, err := wsd.DecodeMsg(buf[:n])
+		if err != nil {
+			back.warning("%s", err)
+			continue
+		}
+
+		// Drop unrelated messages
+		exch, err := corr.Match(msg)
+		if err != nil {
+			if err != wsd.ErrNotRelated {
+				back.warning("%s", err)
+			}
+			continue
+		}
+
+		body, ok := exch.Response.Body.(wsd.AnnouncesBody)
+		if !ok {
+			continue
+		}
+
+		back.debug("%s message received", msg.Header.Action)
+
+		for _, ann := range body.Announces() {
+			matches = append(matches, QueryMatch{
+				Announce: ann,
+				From:     from,
+				IfName:   ifname,
+			})
+		}
+	}
+}
+
+// queryRecvTemporary reports if UDP receive error is temporary,
+// so receiving may continue. Other errors are persistent and
+// retrying will only cause the busy loop.
+func queryRecvTemporary(err error) bool {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case syscall.EINTR, syscall.EAGAIN, syscall.ENOBUFS,
+			syscall.ENOMEM, syscall.ECONNREFUSED:
+			return true
+		}
+	}
+
+	return false
+}
+
+// metadata fetches metadata for the QueryMatch.
+func (q Query) metadata(ctx context.Context, back *backend,
+	m QueryMatch) []QueryMeta {
+
+	ctx, cancel := context.WithTimeout(ctx, wsddMetadataGetTimeout)
+	defer cancel()
+
+	ifidx := 0
+	if ifi, err := net.InterfaceByName(m.IfName); err == nil {
+		ifidx = ifi.Index
+	}
+
+	var meta []QueryMeta
+	for _, s := range m.XAddrs {
+		u := urlParse(s)
+		if u == nil {
+			back.warning("%s: bad XAddr", s)
+			continue
+		}
+
+		u = urlWithZone(u, zone.Name(ifidx))
+		target := m.EndpointReference.Address
+		for _, data := range back.mex.Get(ctx, ifidx, target, u,
+			m.MetadataVersion) {
+			meta = append(meta, QueryMeta{data.Metadata, data.from})
+		}
+	}
+
+	return meta
+}
+
+// addrs returns local addresses, the query will be sent from.
+//
+// For each suitable interface, the first IPv4 address and the
+// first IPv6 link-local address are returned.
+func (q Query) addrs() ([]netstate.Addr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []netstate.Addr
+	found := false
+
+	for _, ifi := range ifaces {
+		if q.Interface != "" && q.Interface != ifi.Name {
+			continue
+		}
+
+		found = true
+		const flags = net.FlagUp | net.FlagMulticast
+		if ifi.Flags&flags != flags || ifi.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		ifaddrs, err := ifi.Addrs()
+		if err != nil {
+			continue
+		}
+
+		nif := netstate.NetIfFromInterface(ifi)
+		var have4, have6 bool
+
+		for _, ifaddr := range ifaddrs {
+			ipn, ok := ifaddr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			addr := netstate.AddrFromIPNet(*ipn, nif)
+			ip := addr.Addr()
+
+			switch {
+			case ip.Is4() && !have4:
+				have4 = true
+				addrs = append(addrs, addr)
+			case ip.Is6() && ip.IsLinkLocalUnicast() && !have6:
+				have6 = true
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+
+	if q.Interface != "" && !found {
+		return nil, fmt.Errorf("%s: interface not found", q.Interface)
+	}
+
+	return addrs, nil
+}
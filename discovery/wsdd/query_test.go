@@ -0,0 +1,169 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// One-shot Probe and Resolve queries test
+
+package wsdd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/internal/netstate"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+)
+
+// testQueryUconn creates the uconn, bound to the loopback address,
+// bypassing the multicast setup, done by newUconn.
+func testQueryUconn(t *testing.T) *uconn {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("net.ListenUDP: %s", err)
+	}
+
+	ipn := net.IPNet{IP: net.IPv4(127, 0, 0, 1), Mask: net.CIDRMask(8, 32)}
+	nif := netstate.MakeNetIf(1, "lo", 0)
+
+	return &uconn{
+		UDPConn: conn,
+		local:   netstate.AddrFromIPNet(ipn, nif),
+	}
+}
+
+// testQueryRecv runs Query.recv in the separate goroutine
+// and returns the channel, where its result will be sent.
+func testQueryRecv(back *backend, uc *uconn,
+	corr *wsd.Correlator) <-chan []QueryMatch {
+
+	done := make(chan []QueryMatch, 1)
+	go func() {
+		done <- Query{}.recv(back, uc, corr)
+	}()
+
+	return done
+}
+
+// TestQueryRecv tests Query.recv over the loopback UDP pair
+func TestQueryRecv(t *testing.T) {
+	const device = wsd.AnyURI("urn:uuid:b8310cdf-157f-4e5b-a042-4588f7149ec0")
+
+	back := &backend{ctx: context.Background()}
+
+	// Prepare the query and the matching response
+	probe := wsd.Msg{
+		Header: wsd.NewRequestHeader(wsd.ActProbe, wsd.ToDiscovery),
+		Body:   wsd.Probe{Types: wsd.Types{wsd.Device}},
+	}
+
+	corr := wsd.NewCorrelator(0)
+	corr.Add(probe)
+
+	matches := wsd.Msg{
+		Header: wsd.NewResponseHeader(wsd.ActProbeMatches, probe.Header),
+		Body: wsd.ProbeMatches{
+			ProbeMatch: []wsd.ProbeMatch{
+				{
+					EndpointReference: wsd.EndpointReference{
+						Address: device,
+					},
+					Types:           wsd.Types{wsd.Device},
+					MetadataVersion: 1,
+				},
+			},
+		},
+	}
+
+	unrelated := wsd.Msg{
+		Header: wsd.NewRequestHeader(wsd.ActProbe, wsd.ToDiscovery),
+		Body:   wsd.Probe{Types: wsd.Types{wsd.Device}},
+	}
+
+	// Setup the loopback pair
+	uc := testQueryUconn(t)
+	defer uc.Close()
+
+	sender, err := net.DialUDP("udp4", nil, uc.UDPConn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("net.DialUDP: %s", err)
+	}
+	defer sender.Close()
+
+	done := testQueryRecv(back, uc, corr)
+
+	// Garbage and unrelated messages must be ignored
+	for _, data := range [][]byte{
+		[]byte("garbage"),
+		unrelated.Encode(),
+		matches.Encode(),
+	} {
+		if _, err = sender.Write(data); err != nil {
+			t.Fatalf("send: %s", err)
+		}
+	}
+
+	// Wait until everything is received, then close the connection
+	time.Sleep(100 * time.Millisecond)
+	uc.Close()
+
+	var received []QueryMatch
+	select {
+	case received = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("recv: not finished after Close")
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("recv: %d matches received, 1 expected", len(received))
+	}
+
+	m := received[0]
+	if m.EndpointReference.Address != device || m.IfName != "lo" ||
+		m.From != sender.LocalAddr().(*net.UDPAddr).AddrPort() {
+		t.Errorf("recv: unexpected match: %+v", m)
+	}
+
+	// Persistent error must terminate the receive loop,
+	// not cause a busy loop.
+	uc = testQueryUconn(t)
+	defer uc.Close()
+
+	uc.SetReadDeadline(time.Now().Add(-time.Second))
+	done = testQueryRecv(back, uc, corr)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("recv: not finished on persistent error")
+	}
+}
+
+// TestQueryRecvTemporary tests queryRecvTemporary
+func TestQueryRecvTemporary(t *testing.T) {
+	type testData struct {
+		err    error
+		expect bool
+	}
+
+	tests := []testData{
+		{syscall.EINTR, true},
+		{fmt.Errorf("read: %w", syscall.ECONNREFUSED), true},
+		{syscall.EBADF, false},
+		{net.ErrClosed, false},
+		{context.DeadlineExceeded, false},
+	}
+
+	for _, test := range tests {
+		present := queryRecvTemporary(test.err)
+		if present != test.expect {
+			t.Errorf("%v: expected %v, present %v",
+				test.err, test.expect, present)
+		}
+	}
+}
@@ -75,6 +75,7 @@ const (
 // probes ("browsing") or to find some particular peer ("resolving").
 type sched struct {
 	resolve bool            // Resolve mode
+	delay   time.Duration   // Initial delay
 	timer   timer           // Underlying timer
 	c       chan schedEvent // Event channel
 	done    sync.WaitGroup  // For sched.Close synchronization
@@ -89,10 +90,13 @@ const (
 	schedSend                         // Send current message
 )
 
-// newSched creates a new scheduler
-func newSched(resolve bool) *sched {
+// newSched creates a new scheduler.
+//
+// The first message is generated after the specified delay.
+func newSched(resolve bool, delay time.Duration) *sched {
 	s := &sched{
 		resolve: resolve,
+		delay:   delay,
 		timer:   newTimer(),
 		c:       make(chan schedEvent, 4),
 	}
@@ -131,6 +135,10 @@ func (s *sched) proc() {
 	defer s.done.Done()
 	defer close(s.c)
 
+	if s.delay > 0 && !s.timer.Sleep(s.delay) {
+		return
+	}
+
 	start := time.Now()
 
 	for {
@@ -38,12 +38,20 @@ import (
 //     service endpoints, suitable for printing or scanning,
 //     depending on a service type.
 //
-// Every unit represents a discovered unit (the "hosted service"
-// in the WSD terms), localized to the particular network interface.
+// Every host represents a discovered device, localized to the
+// particular network interface. It fetches device metadata via XAddrs.
+//
+// Every unit represents a "hosted service", found in the device
+// metadata. If device hosts multiple services of the same type,
+// each of them becomes a separate unit with its own endpoints,
+// distinguished by the [discovery.UnitID.Queue], derived from the
+// hosted ServiceId.
+//
 // If the same device is visible over multiple network interfaces,
 // the discovery system when merge them together.
 type units struct {
 	back  *backend                   // Parent backend
+	hosts map[hostID]*host           // Discovered hosts
 	table map[discovery.UnitID]*unit // Discovered units
 	lock  sync.Mutex                 // units.table lock
 }
@@ -53,6 +61,7 @@ func newUnits(back *backend) *units {
 	// Create units structure
 	ut := &units{
 		back:  back,
+		hosts: make(map[hostID]*host),
 		table: make(map[discovery.UnitID]*unit),
 	}
 
@@ -62,8 +71,15 @@ func newUnits(back *backend) *units {
 // Close closes the unit table and cancels all ongoing discovery activity,
 // like fetching unit's metadata
 func (ut *units) Close() {
-	for _, un := range ut.table {
-		un.close()
+	ut.lock.Lock()
+	hosts := make([]*host, 0, len(ut.hosts))
+	for _, h := range ut.hosts {
+		hosts = append(hosts, h)
+	}
+	ut.lock.Unlock()
+
+	for _, h := range hosts {
+		h.close()
 	}
 }
 
@@ -84,6 +100,11 @@ func (ut *units) InputFromUDP(msg wsd.Msg) {
 //
 // Called under units.lock.
 func (ut *units) handleBye(msg wsd.Msg) {
+	body := msg.Body.(wsd.Bye)
+
+	// Device leaves the network. Forget its MetadataVersion,
+	// so metadata will be re-fetched when it returns.
+	ut.back.meta.Forget(body.EndpointReference.Address)
 }
 
 // handleAnnounce is the common handler for WSD announce messages
@@ -113,10 +134,19 @@ func (ut *units) handleAnnounces(msg wsd.Msg) {
 		logmsg.Debug("  Types           %s", ann.Types)
 		logmsg.Debug("  MetadataVersion %d", ver)
 
-		printUnitID := ut.makeUnitID(msg.IfIdx,
-			discovery.ServicePrinter, target)
-		scanUnitID := ut.makeUnitID(msg.IfIdx,
-			discovery.ServiceScanner, target)
+		// Ignore devices out of requested scopes
+		probe := ut.back.probe
+		if !ann.Scopes.Match(probe.Scopes, probe.MatchBy) {
+			logmsg.Debug("  Scopes          %s (not matched)",
+				ann.Scopes)
+			continue
+		}
+
+		// If MetadataVersion has changed, XAddrs must be
+		// re-fetched, even if already seen.
+		if ut.back.meta.Announce(target, ver) {
+			ut.forgetXaddrs(target)
+		}
 
 		if len(ann.XAddrs) != 0 {
 			logmsg.Debug("  Xaddrs:")
@@ -134,29 +164,35 @@ func (ut *units) handleAnnounces(msg wsd.Msg) {
 			}
 
 			// Dispatch XAddrs
-			if len(xaddrs) != 0 {
-				if ann.Types.Contains(wsd.PrinterServiceType) {
-					un := ut.getUnit(printUnitID, true)
-					un.handleXaddrs(ifidx, target,
-						xaddrs, ver)
-				}
-
-				if ann.Types.Contains(wsd.ScannerServiceType) {
-					un := ut.getUnit(scanUnitID, true)
-					un.handleXaddrs(ifidx, target,
-						xaddrs, ver)
-				}
+			if len(xaddrs) != 0 &&
+				(ann.Types.Contains(wsd.PrinterServiceType) ||
+					ann.Types.Contains(wsd.ScannerServiceType)) {
+				h := ut.getHost(ifidx, target)
+				h.handleXaddrs(xaddrs, ver)
 			}
 		}
 	}
 }
 
+// forgetXaddrs forgets XAddrs, seen so far at all hosts with
+// the specified target address, so they will be fetched again.
+//
+// Called under units.lock.
+func (ut *units) forgetXaddrs(target wsd.AnyURI) {
+	for id, h := range ut.hosts {
+		if id.target == target {
+			h.xaddrsSeen.Clear()
+		}
+	}
+}
+
 // makeUnitID creates a discovery.UnitID for the discovered
 // service
 func (ut *units) makeUnitID(ifidx int, svctype discovery.ServiceType,
-	addr wsd.AnyURI) discovery.UnitID {
+	addr wsd.AnyURI, queue string) discovery.UnitID {
 	return discovery.UnitID{
 		UUID:     addr.UUID(),
+		Queue:    queue,
 		Realm:    discovery.RealmWSD,
 		Zone:     zone.Name(ifidx),
 		SvcType:  svctype,
@@ -164,6 +200,20 @@ func (ut *units) makeUnitID(ifidx int, svctype discovery.ServiceType,
 	}
 }
 
+// getHost returns a host by its address and network interface.
+// If host is not known yet, it is created.
+//
+// Called under units.lock.
+func (ut *units) getHost(ifidx int, target wsd.AnyURI) *host {
+	id := hostID{ifidx, target}
+	h := ut.hosts[id]
+	if h == nil {
+		h = newHost(id, ut)
+		ut.hosts[id] = h
+	}
+	return h
+}
+
 // getUnit returns an unit by id. If unit is not known yet,
 // it can be created on demand.
 //
@@ -181,141 +231,172 @@ func (ut *units) getUnit(id discovery.UnitID,
 	return un
 }
 
-// unit represents a discovered unit (a device)
-type unit struct {
-	parent        *units                     // Parent units table
-	ctx           context.Context            // Cancelable context
-	cancel        context.CancelFunc         // Its cancel function
-	id            discovery.UnitID           // Unit ID
-	types         wsd.Types                  // WSD service types
-	xaddrsSeen    *generic.LockedSet[string] // Known XAddrs
-	endpointsSeen *generic.LockedSet[string] // Known endpoints
-	paramsSent    atomic.Bool                // EventXXXParameters reported
-	closing       atomic.Bool                // unit.close in progress
-	closewait     sync.WaitGroup             // for unit.close
+// hostID identifies the host
+type hostID struct {
+	ifidx  int        // Network interface index
+	target wsd.AnyURI // Device address
 }
 
-// newUnit creates a new unit
-func newUnit(id discovery.UnitID, parent *units) *unit {
+// host represents a discovered device, localized to the particular
+// network interface.
+type host struct {
+	parent     *units                     // Parent units table
+	ctx        context.Context            // Cancelable context
+	cancel     context.CancelFunc         // Its cancel function
+	id         hostID                     // Host ID
+	xaddrsSeen *generic.LockedSet[string] // Known XAddrs
+	closewait  sync.WaitGroup             // for host.close
+}
+
+// newHost creates a new host
+func newHost(id hostID, parent *units) *host {
 	ctx, cancel := context.WithCancel(parent.back.ctx)
 
-	un := &unit{
-		parent:        parent,
-		ctx:           ctx,
-		cancel:        cancel,
-		id:            id,
-		xaddrsSeen:    generic.NewLockedSet[string](),
-		endpointsSeen: generic.NewLockedSet[string](),
+	h := &host{
+		parent:     parent,
+		ctx:        ctx,
+		cancel:     cancel,
+		id:         id,
+		xaddrsSeen: generic.NewLockedSet[string](),
 	}
 
-	return un
+	return h
 }
 
-// close closes the unit.
-func (un *unit) close() {
-	un.closing.Store(true)
-
-	un.parent.lock.Lock()
-	delete(un.parent.table, un.id)
-	un.parent.lock.Unlock()
+// close closes the host and cancels its metadata fetching.
+func (h *host) close() {
+	h.parent.lock.Lock()
+	delete(h.parent.hosts, h.id)
+	h.parent.lock.Unlock()
 
-	un.cancel()
-	un.closewait.Wait()
+	h.cancel()
+	h.closewait.Wait()
 }
 
 // handleXaddrs handles newly discovered XAddrs
 //
 // Called under units.lock.
-func (un *unit) handleXaddrs(ifidx int, target wsd.AnyURI,
-	xaddrs []*url.URL, ver uint64) {
-
-	back := un.parent.back
+func (h *host) handleXaddrs(xaddrs []*url.URL, ver uint64) {
+	back := h.parent.back
 
 	for _, xaddr := range xaddrs {
-		if !un.xaddrsSeen.TestAndAdd(xaddr.String()) {
+		if !h.xaddrsSeen.TestAndAdd(xaddr.String()) {
 			continue
 		}
 
-		un.closewait.Add(1)
+		h.closewait.Add(1)
 		go func(xaddr2 *url.URL) {
-			meta := back.mex.Get(un.ctx, ifidx, target, xaddr2, ver)
-			un.handleMetadata(meta)
-			un.closewait.Done()
+			meta := back.mex.Get(h.ctx, h.id.ifidx, h.id.target,
+				xaddr2, ver)
+			h.handleMetadata(meta)
+			h.closewait.Done()
 		}(xaddr)
 	}
 }
 
-// handleMetadata handles the WSD metadata for the unit
-func (un *unit) handleMetadata(metadata []mexData) {
-	zone := un.id.Zone
+// handleMetadata handles the WSD metadata for the host.
+//
+// Each hosted printer or scanner service is mapped to its own unit.
+func (h *host) handleMetadata(metadata []mexData) {
+	ut := h.parent
 
 	for _, meta := range metadata {
-		mfg := meta.ThisModel.Manufacturer.NeutralLang().String
-		mdl := meta.ThisModel.ModelName.NeutralLang().String
-		adm := meta.ThisModel.PresentationURL
-
-		endpoints := un.extractMetadataEndpoints(meta)
-
-		logmsg := log.Begin(un.ctx)
-		logmsg.Debug("Got %s metadata (from %s)",
-			un.id.SvcType, meta.from)
-		logmsg.Debug("  Manufacturer: %q", mfg)
-		logmsg.Debug("  Model:        %q", mdl)
-		if adm != nil {
-			logmsg.Debug("  Admin URL:    %q", *adm)
-		}
-
-		if len(endpoints) > 0 {
-			logmsg.Debug("  Endpoints:")
-
-			for _, endpoint := range endpoints {
-				u := urlParse(endpoint)
-				if u == nil {
-					logmsg.Warning("    %s (bad)", endpoint)
+		for _, hosted := range meta.Relationship.Hosted {
+			for _, svctype := range []discovery.ServiceType{
+				discovery.ServicePrinter,
+				discovery.ServiceScanner,
+			} {
+				if !hosted.Types.Contains(wsdType(svctype)) {
 					continue
 				}
 
-				u = urlWithZone(u, zone)
-				s := u.String()
+				queue := serviceQueue(hosted.ServiceID)
+				id := ut.makeUnitID(h.id.ifidx, svctype,
+					h.id.target, queue)
 
-				if !un.xaddrsSeen.TestAndAdd(s) {
-					logmsg.Debug("    %s (dup)", s)
-					continue
-				}
+				ut.lock.Lock()
+				un := ut.getUnit(id, true)
+				ut.lock.Unlock()
 
-				logmsg.Debug("    %s", s)
-				un.sendEndpoint(u)
+				un.handleService(h.ctx, meta, hosted)
 			}
 		}
+	}
+}
 
-		logmsg.Commit()
+// unit represents a discovered unit (the hosted service)
+type unit struct {
+	parent        *units                     // Parent units table
+	id            discovery.UnitID           // Unit ID
+	endpointsSeen *generic.LockedSet[string] // Known endpoints
+	paramsSent    atomic.Bool                // EventXXXParameters reported
+}
 
-		un.sendParameters(mfg, mdl, adm)
+// newUnit creates a new unit
+func newUnit(id discovery.UnitID, parent *units) *unit {
+	un := &unit{
+		parent:        parent,
+		id:            id,
+		endpointsSeen: generic.NewLockedSet[string](),
 	}
+
+	return un
 }
 
-// extractMetadataEndpoints extract and returns service endpoint URLs
-func (un *unit) extractMetadataEndpoints(meta mexData) []string {
-	t := un.wsdType()
+// handleService handles the hosted service metadata for the unit
+func (un *unit) handleService(ctx context.Context, meta mexData,
+	hosted wsd.ServiceMetadata) {
 
-	var endpoints []string
+	zone := un.id.Zone
 
-	for _, hosted := range meta.Relationship.Hosted {
-		if !hosted.Types.Contains(t) {
-			continue
-		}
+	mfg := meta.ThisModel.Manufacturer.NeutralLang().String
+	mdl := meta.ThisModel.ModelName.NeutralLang().String
+	adm := meta.ThisModel.PresentationURL
+
+	logmsg := log.Begin(ctx)
+	logmsg.Debug("Got %s metadata (from %s)", un.id.SvcType, meta.from)
+	logmsg.Debug("  Manufacturer: %q", mfg)
+	logmsg.Debug("  Model:        %q", mdl)
+	logmsg.Debug("  ServiceId:    %q", hosted.ServiceID)
+	logmsg.Debug("  Queue:        %q", un.id.Queue)
+	if adm != nil {
+		logmsg.Debug("  Admin URL:    %q", *adm)
+	}
+
+	if len(hosted.EndpointReference) > 0 {
+		logmsg.Debug("  Endpoints:")
 
 		for _, endpoint := range hosted.EndpointReference {
-			endpoints = append(endpoints, string(endpoint.Address))
+			u := urlParse(string(endpoint.Address))
+			if u == nil {
+				logmsg.Warning("    %s (bad)", endpoint.Address)
+				continue
+			}
+
+			u = urlWithZone(u, zone)
+
+			// Metadata was fetched from the device,
+			// so if endpoint refers the same host,
+			// it is known to be reachable.
+			verified := meta.from != nil &&
+				u.Hostname() == meta.from.Hostname()
+
+			if un.sendEndpoint(u, verified) {
+				logmsg.Debug("    %s", u)
+			} else {
+				logmsg.Debug("    %s (dup)", u)
+			}
 		}
 	}
 
-	return endpoints
+	logmsg.Commit()
+
+	un.sendParameters(mfg, mdl, adm)
 }
 
-// wsdType returns WSD service type for the unit
-func (un *unit) wsdType() wsd.Type {
-	switch un.id.SvcType {
+// wsdType returns WSD service type for the discovery.ServiceType
+func wsdType(svctype discovery.ServiceType) wsd.Type {
+	switch svctype {
 	case discovery.ServicePrinter:
 		return wsd.PrinterServiceType
 	case discovery.ServiceScanner:
@@ -324,6 +405,19 @@ func (un *unit) wsdType() wsd.Type {
 	return wsd.UnknownType
 }
 
+// serviceQueue derives the queue name from the hosted ServiceId.
+//
+// ServiceId is usually looks like "uri:<device-uuid>/WSDPrinter",
+// so the last path segment is used as the queue name. If ServiceId
+// has no path, it is used as is.
+func serviceQueue(id wsd.AnyURI) string {
+	s := strings.TrimRight(string(id), "/")
+	if i := strings.LastIndexByte(s, '/'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
+
 // sendParameters sends EventPrinterParameters or EventScannerParameters
 // to the discovery system.
 func (un *unit) sendParameters(mfg, mdl string, adm optional.Val[string]) {
@@ -353,6 +447,7 @@ func (un *unit) sendParameters(mfg, mdl string, adm optional.Val[string]) {
 			PPDModel:        mdl,
 			Printer: discovery.PrinterParameters{
 				PSProduct: "(" + mdl + ")",
+				Queue:     un.id.Queue,
 			},
 		}
 
@@ -370,16 +465,21 @@ func (un *unit) sendParameters(mfg, mdl string, adm optional.Val[string]) {
 }
 
 // sendEndpoint sends EventAddEndpoint to the discovery system.
-func (un *unit) sendEndpoint(u *url.URL) {
+// It returns false, if endpoint is already known.
+//
+// If verified is true, endpoint is known to be reachable.
+func (un *unit) sendEndpoint(u *url.URL, verified bool) bool {
 	s := u.String()
 	if !un.endpointsSeen.TestAndAdd(s) {
-		return
+		return false
 	}
 
 	evnt := &discovery.EventAddEndpoint{
 		ID:       un.id,
 		Endpoint: s,
+		Verified: verified,
 	}
 
 	un.parent.back.queue.Push(evnt)
+	return true
 }
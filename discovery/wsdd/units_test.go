@@ -0,0 +1,123 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD device discovery
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Table of discovered units tests
+
+package wsdd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+)
+
+// TestServiceQueue tests serviceQueue function
+func TestServiceQueue(t *testing.T) {
+	type testData struct {
+		id    wsd.AnyURI // Hosted ServiceId
+		queue string     // Expected queue name
+	}
+
+	tests := []testData{
+		{"uri:4509a320-00a0-008f-00b6-002507510eca/WSDPrinter",
+			"WSDPrinter"},
+		{"uri:4509a320-00a0-008f-00b6-002507510eca/WSDScanner",
+			"WSDScanner"},
+		{"http://192.168.0.10/wsd/print/", "print"},
+		{"urn:uuid:4509a320-00a0-008f-00b6-002507510eca",
+			"urn:uuid:4509a320-00a0-008f-00b6-002507510eca"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		queue := serviceQueue(test.id)
+		if queue != test.queue {
+			t.Errorf("%q: expected %q, present %q",
+				test.id, test.queue, queue)
+		}
+	}
+}
+
+// TestUnitsScopes tests filtering of announces by Options.Scopes
+func TestUnitsScopes(t *testing.T) {
+	back := &backend{
+		ctx:  context.Background(),
+		meta: wsd.NewMetadataCache(),
+		probe: wsd.Probe{
+			Types:  wsd.Types{wsd.Device},
+			Scopes: wsd.Scopes{"http://example.com/office"},
+		},
+	}
+
+	ut := newUnits(back)
+	defer ut.Close()
+
+	type testData struct {
+		target wsd.AnyURI // Device address
+		scopes wsd.Scopes // Device scopes
+		match  bool       // Announce must be accepted
+	}
+
+	tests := []testData{
+		{
+			target: "urn:uuid:1b8a6b2c-6d62-4c8e-8e48-3f4d5b1a0001",
+			scopes: wsd.Scopes{"http://example.com/office/floor2"},
+			match:  true,
+		},
+		{
+			target: "urn:uuid:1b8a6b2c-6d62-4c8e-8e48-3f4d5b1a0002",
+			scopes: wsd.Scopes{"http://example.com/lab"},
+			match:  false,
+		},
+		{
+			target: "urn:uuid:1b8a6b2c-6d62-4c8e-8e48-3f4d5b1a0003",
+			scopes: nil,
+			match:  false,
+		},
+	}
+
+	for _, test := range tests {
+		ut.InputFromUDP(wsd.Msg{
+			Header: wsd.Header{Action: wsd.ActHello},
+			Body: wsd.Hello{
+				EndpointReference: wsd.EndpointReference{
+					Address: test.target,
+				},
+				Types:           wsd.Types{wsd.Device},
+				Scopes:          test.scopes,
+				MetadataVersion: 1,
+			},
+		})
+
+		// Accepted announce is registered in the MetadataCache,
+		// so the same version is not new anymore.
+		accepted := !back.meta.Announce(test.target, 1)
+		if accepted != test.match {
+			t.Errorf("%s: accepted expected %v, present %v",
+				test.scopes, test.match, accepted)
+		}
+	}
+
+	// Probe message must include Scopes
+	l := &link{parent: &links{back: back}}
+	l.updateProbeMsg()
+
+	msg, err := wsd.DecodeMsg(l.probeMsg)
+	if err != nil {
+		t.Fatalf("Probe: %s", err)
+	}
+
+	probe, ok := msg.Body.(wsd.Probe)
+	if !ok {
+		t.Fatalf("Probe: unexpected body %T", msg.Body)
+	}
+
+	if probe.Scopes.String() != back.probe.Scopes.String() {
+		t.Errorf("Probe: Scopes expected %q, present %q",
+			back.probe.Scopes, probe.Scopes)
+	}
+}
@@ -24,7 +24,15 @@ var (
 	// Timeout for the metadata Get request (performed via HTTP)
 	wsddMetadataGetTimeout = 5 * time.Second
 
-	// Response size limit for the metadata Get request (to mitigate
-	// possible DOS attack)
-	wsddMetadataGetMaxResponse = 64536
+	// If interface goes up earlier than this time after it went
+	// down, it is considered flapping
+	wsddFlapWindow = 30 * time.Second
+
+	// Interval between Probe requests, sent to the Discovery
+	// Proxy in the managed mode
+	wsddProxyProbeInterval = 60 * time.Second
 )
+
+// DefaultFlapGracePeriod is the default value of the
+// [Options.FlapGracePeriod].
+const DefaultFlapGracePeriod = 5 * time.Second
@@ -0,0 +1,686 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// JPEG Reader and Writer
+
+package imgconv
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/OpenPrinting/go-mfp/util/generic"
+)
+
+// #cgo pkg-config: libjpeg
+//
+// #include <stdio.h>
+// #include <stdint.h>
+// #include <stdlib.h>
+// #include <setjmp.h>
+// #include <jpeglib.h>
+//
+// void   jpegErrorCallback(uintptr_t handle, char *msg);
+// size_t jpegReadCallback(uintptr_t handle, void *data, size_t size);
+// int    jpegWriteCallback(uintptr_t handle, void *data, size_t size);
+//
+// // jpegBufSize is the size of I/O buffers
+// #define jpegBufSize 16384
+//
+// // jpegErrorMgr extends jpeg_error_mgr with the jmp_buf,
+// // used to return control to the caller in a case of error.
+// typedef struct {
+//     struct jpeg_error_mgr pub;
+//     jmp_buf               jmp;
+// } jpegErrorMgr;
+//
+// // jpegDecoder contains all the libjpeg decompressor state.
+// // It is allocated in the C memory, so libjpeg can safely keep
+// // pointers to it.
+// typedef struct {
+//     struct jpeg_decompress_struct cinfo;
+//     jpegErrorMgr                  err;
+//     struct jpeg_source_mgr        src;
+//     uintptr_t                     handle;
+//     JOCTET                        buf[jpegBufSize];
+// } jpegDecoder;
+//
+// // jpegEncoder contains all the libjpeg compressor state.
+// // It is allocated in the C memory, so libjpeg can safely keep
+// // pointers to it.
+// typedef struct {
+//     struct jpeg_compress_struct cinfo;
+//     jpegErrorMgr                err;
+//     struct jpeg_destination_mgr dst;
+//     uintptr_t                   handle;
+//     JOCTET                      buf[jpegBufSize];
+// } jpegEncoder;
+//
+// // jpeg_error_exit is the libjpeg error_exit callback.
+// // It reports error to Go and returns control to the caller.
+// static void
+// jpeg_error_exit(j_common_ptr cinfo) {
+//     jpegErrorMgr *err = (jpegErrorMgr*) cinfo->err;
+//     char         msg[JMSG_LENGTH_MAX];
+//
+//     (*cinfo->err->format_message)(cinfo, msg);
+//     jpegErrorCallback((uintptr_t) cinfo->client_data, msg);
+//     longjmp(err->jmp, 1);
+// }
+//
+// // jpeg_output_message is the libjpeg output_message callback.
+// // Warnings are silently ignored.
+// static void
+// jpeg_output_message(j_common_ptr cinfo) {
+// }
+//
+// // jpeg_err_init initializes jpegErrorMgr
+// static struct jpeg_error_mgr*
+// jpeg_err_init(jpegErrorMgr *err) {
+//     jpeg_std_error(&err->pub);
+//     err->pub.error_exit = jpeg_error_exit;
+//     err->pub.output_message = jpeg_output_message;
+//     return &err->pub;
+// }
+//
+// // jpeg_src_init is the jpeg_source_mgr init_source callback.
+// static void
+// jpeg_src_init(j_decompress_ptr cinfo) {
+// }
+//
+// // jpeg_src_fill is the jpeg_source_mgr fill_input_buffer callback.
+// static boolean
+// jpeg_src_fill(j_decompress_ptr cinfo) {
+//     jpegDecoder *dec = (jpegDecoder*) cinfo;
+//     size_t      n = jpegReadCallback(dec->handle, dec->buf, jpegBufSize);
+//
+//     if (n == 0) {
+//         longjmp(dec->err.jmp, 1);
+//     }
+//
+//     dec->src.next_input_byte = dec->buf;
+//     dec->src.bytes_in_buffer = n;
+//
+//     return TRUE;
+// }
+//
+// // jpeg_src_skip is the jpeg_source_mgr skip_input_data callback.
+// static void
+// jpeg_src_skip(j_decompress_ptr cinfo, long num_bytes) {
+//     jpegDecoder *dec = (jpegDecoder*) cinfo;
+//
+//     while (num_bytes > (long) dec->src.bytes_in_buffer) {
+//         num_bytes -= (long) dec->src.bytes_in_buffer;
+//         jpeg_src_fill(cinfo);
+//     }
+//
+//     if (num_bytes > 0) {
+//         dec->src.next_input_byte += num_bytes;
+//         dec->src.bytes_in_buffer -= num_bytes;
+//     }
+// }
+//
+// // jpeg_src_term is the jpeg_source_mgr term_source callback.
+// static void
+// jpeg_src_term(j_decompress_ptr cinfo) {
+// }
+//
+// // jpeg_decoder_new creates a new jpegDecoder
+// static jpegDecoder*
+// jpeg_decoder_new(uintptr_t handle) {
+//     jpegDecoder *dec = calloc(1, sizeof(jpegDecoder));
+//
+//     dec->handle = handle;
+//     dec->cinfo.err = jpeg_err_init(&dec->err);
+//     dec->cinfo.client_data = (void*) handle;
+//
+//     dec->src.init_source = jpeg_src_init;
+//     dec->src.fill_input_buffer = jpeg_src_fill;
+//     dec->src.skip_input_data = jpeg_src_skip;
+//     dec->src.resync_to_restart = jpeg_resync_to_restart;
+//     dec->src.term_source = jpeg_src_term;
+//
+//     if (setjmp(dec->err.jmp)) {
+//         free(dec);
+//         return NULL;
+//     }
+//
+//     jpeg_create_decompress(&dec->cinfo);
+//     dec->cinfo.src = &dec->src;
+//
+//     return dec;
+// }
+//
+// // jpeg_decoder_start reads the JPEG header and starts decompression.
+// // It returns 0 in a case of error.
+// static int
+// jpeg_decoder_start(jpegDecoder *dec) {
+//     if (setjmp(dec->err.jmp)) {
+//         return 0;
+//     }
+//
+//     jpeg_read_header(&dec->cinfo, TRUE);
+//
+//     switch (dec->cinfo.jpeg_color_space) {
+//     case JCS_CMYK:
+//     case JCS_YCCK:
+//         // Let the caller to reject it
+//         return 1;
+//
+//     case JCS_GRAYSCALE:
+//         dec->cinfo.out_color_space = JCS_GRAYSCALE;
+//         break;
+//
+//     default:
+//         dec->cinfo.out_color_space = JCS_RGB;
+//     }
+//
+//     jpeg_start_decompress(&dec->cinfo);
+//
+//     return 1;
+// }
+//
+// // jpeg_decoder_read reads the next scanline.
+// // It returns 0 in a case of error.
+// static int
+// jpeg_decoder_read(jpegDecoder *dec, void *row) {
+//     JSAMPROW rows[1] = {row};
+//
+//     if (setjmp(dec->err.jmp)) {
+//         return 0;
+//     }
+//
+//     jpeg_read_scanlines(&dec->cinfo, rows, 1);
+//     return 1;
+// }
+//
+// // jpeg_decoder_free destroys the jpegDecoder
+// static void
+// jpeg_decoder_free(jpegDecoder *dec) {
+//     jpeg_destroy_decompress(&dec->cinfo);
+//     free(dec);
+// }
+//
+// // jpeg_dst_init is the jpeg_destination_mgr init_destination callback.
+// static void
+// jpeg_dst_init(j_compress_ptr cinfo) {
+//     jpegEncoder *enc = (jpegEncoder*) cinfo;
+//
+//     enc->dst.next_output_byte = enc->buf;
+//     enc->dst.free_in_buffer = jpegBufSize;
+// }
+//
+// // jpeg_dst_empty is the jpeg_destination_mgr empty_output_buffer
+// // callback.
+// static boolean
+// jpeg_dst_empty(j_compress_ptr cinfo) {
+//     jpegEncoder *enc = (jpegEncoder*) cinfo;
+//
+//     if (!jpegWriteCallback(enc->handle, enc->buf, jpegBufSize)) {
+//         longjmp(enc->err.jmp, 1);
+//     }
+//
+//     enc->dst.next_output_byte = enc->buf;
+//     enc->dst.free_in_buffer = jpegBufSize;
+//
+//     return TRUE;
+// }
+//
+// // jpeg_dst_term is the jpeg_destination_mgr term_destination callback.
+// static void
+// jpeg_dst_term(j_compress_ptr cinfo) {
+//     jpegEncoder *enc = (jpegEncoder*) cinfo;
+//     size_t      n = jpegBufSize - enc->dst.free_in_buffer;
+//
+//     if (n > 0 && !jpegWriteCallback(enc->handle, enc->buf, n)) {
+//         longjmp(enc->err.jmp, 1);
+//     }
+// }
+//
+// // jpeg_encoder_new creates a new jpegEncoder
+// static jpegEncoder*
+// jpeg_encoder_new(uintptr_t handle) {
+//     jpegEncoder *enc = calloc(1, sizeof(jpegEncoder));
+//
+//     enc->handle = handle;
+//     enc->cinfo.err = jpeg_err_init(&enc->err);
+//     enc->cinfo.client_data = (void*) handle;
+//
+//     enc->dst.init_destination = jpeg_dst_init;
+//     enc->dst.empty_output_buffer = jpeg_dst_empty;
+//     enc->dst.term_destination = jpeg_dst_term;
+//
+//     if (setjmp(enc->err.jmp)) {
+//         free(enc);
+//         return NULL;
+//     }
+//
+//     jpeg_create_compress(&enc->cinfo);
+//     enc->cinfo.dest = &enc->dst;
+//
+//     return enc;
+// }
+//
+// // jpeg_encoder_start sets compression parameters and
+// // starts compression. It returns 0 in a case of error.
+// static int
+// jpeg_encoder_start(jpegEncoder *enc, int wid, int hei,
+//                    int components, int quality) {
+//     if (setjmp(enc->err.jmp)) {
+//         return 0;
+//     }
+//
+//     enc->cinfo.image_width = wid;
+//     enc->cinfo.image_height = hei;
+//     enc->cinfo.input_components = components;
+//     enc->cinfo.in_color_space = components == 1 ? JCS_GRAYSCALE : JCS_RGB;
+//
+//     jpeg_set_defaults(&enc->cinfo);
+//     jpeg_set_quality(&enc->cinfo, quality, TRUE);
+//     jpeg_start_compress(&enc->cinfo, TRUE);
+//
+//     return 1;
+// }
+//
+// // jpeg_encoder_write writes the next scanline.
+// // It returns 0 in a case of error.
+// static int
+// jpeg_encoder_write(jpegEncoder *enc, void *row) {
+//     JSAMPROW rows[1] = {row};
+//
+//     if (setjmp(enc->err.jmp)) {
+//         return 0;
+//     }
+//
+//     jpeg_write_scanlines(&enc->cinfo, rows, 1);
+//     return 1;
+// }
+//
+// // jpeg_encoder_finish finishes compression.
+// // It returns 0 in a case of error.
+// static int
+// jpeg_encoder_finish(jpegEncoder *enc) {
+//     if (setjmp(enc->err.jmp)) {
+//         return 0;
+//     }
+//
+//     jpeg_finish_compress(&enc->cinfo);
+//     return 1;
+// }
+//
+// // jpeg_encoder_free destroys the jpegEncoder
+// static void
+// jpeg_encoder_free(jpegEncoder *enc) {
+//     jpeg_destroy_compress(&enc->cinfo);
+//     free(enc);
+// }
+import "C"
+
+// JPEGDefaultQuality is the default JPEG compression quality,
+// used by the [NewJPEGWriter], if quality is not specified.
+const JPEGDefaultQuality = 90
+
+// jpegReader implements the [Reader] interface for reading JPEG images.
+type jpegReader struct {
+	handle   cgo.Handle     // Handle to self
+	dec      *C.jpegDecoder // Underlying libjpeg decoder
+	err      error          // Error from the libjpeg
+	input    io.Reader      // Underlying io.Reader
+	model    color.Model    // Image color mode
+	wid, hei int            // Image size
+	rowBytes []byte         // Row decoding buffer
+	y        int            // Current y-coordinate
+}
+
+// NewJPEGReader creates a new [Reader] for JPEG images.
+//
+// Grayscale images are decoded as color.GrayModel, all other images
+// are decoded as color.RGBAModel. CMYK images are not supported.
+func NewJPEGReader(input io.Reader) (Reader, error) {
+	// Create reader structure. Initialize libjpeg stuff
+	reader := &jpegReader{input: input}
+	reader.handle = cgo.NewHandle(reader)
+
+	reader.dec = C.jpeg_decoder_new(C.uintptr_t(reader.handle))
+	if reader.dec == nil {
+		reader.handle.Delete()
+		reader.setError(errors.New("JPEG: can't create decoder"))
+		return nil, reader.err
+	}
+
+	// Read image header
+	if C.jpeg_decoder_start(reader.dec) == 0 {
+		reader.Close()
+		return nil, reader.err
+	}
+
+	cinfo := &reader.dec.cinfo
+
+	switch cinfo.jpeg_color_space {
+	case C.JCS_CMYK, C.JCS_YCCK:
+		reader.Close()
+		err := errors.New("JPEG: CMYK images not supported")
+		return nil, err
+	}
+
+	reader.wid = int(cinfo.output_width)
+	reader.hei = int(cinfo.output_height)
+
+	bytesPerPixel := 3
+	reader.model = color.RGBAModel
+	if cinfo.out_color_space == C.JCS_GRAYSCALE {
+		bytesPerPixel = 1
+		reader.model = color.GrayModel
+	}
+
+	// Allocate buffers
+	reader.rowBytes = make([]byte, bytesPerPixel*reader.wid)
+
+	return reader, nil
+}
+
+// Close closes the reader.
+func (reader *jpegReader) Close() {
+	C.jpeg_decoder_free(reader.dec)
+	reader.handle.Delete()
+}
+
+// ColorModel returns the [color.Model] of image being decoded.
+func (reader *jpegReader) ColorModel() color.Model {
+	return reader.model
+}
+
+// Size returns the image size.
+func (reader *jpegReader) Size() (wid, hei int) {
+	return reader.wid, reader.hei
+}
+
+// NewRow allocates a [Row] of the appropriate type and width for
+// use with the [Reader.Read] function.
+func (reader *jpegReader) NewRow() Row {
+	return NewRow(reader.model, reader.wid)
+}
+
+// Read returns the next image [Row].
+func (reader *jpegReader) Read(row Row) (int, error) {
+	// Read the next row
+	if reader.err == nil &&
+		C.jpeg_decoder_read(reader.dec,
+			unsafe.Pointer(&reader.rowBytes[0])) == 0 {
+		reader.setError(errors.New("JPEG: read error"))
+	}
+
+	if reader.err != nil {
+		return 0, reader.err
+	}
+
+	// Decode the row
+	wid := generic.Min(row.Width(), reader.wid)
+
+	switch reader.model {
+	case color.GrayModel:
+		bytesGray8toRow(row, reader.rowBytes)
+
+	case color.RGBAModel:
+		bytesRGB8toRow(row, reader.rowBytes)
+	}
+
+	// Update current y
+	reader.y++
+	if reader.y == reader.hei {
+		reader.setError(io.EOF)
+	}
+
+	return wid, nil
+}
+
+// setError sets the reader.err, if it is not set yet
+func (reader *jpegReader) setError(err error) {
+	if reader.err == nil {
+		reader.err = err
+	}
+}
+
+// jpegWriter implements the [Writer] interface for writing JPEG images
+type jpegWriter struct {
+	handle   cgo.Handle     // Handle to self
+	enc      *C.jpegEncoder // Underlying libjpeg encoder
+	err      error          // Error from the libjpeg
+	output   io.Writer      // Underlying io.Writer
+	wid, hei int            // Image size
+	model    color.Model    // Color model
+	rowBytes []byte         // Row encoding buffer
+	y        int            // Current y-coordinate
+}
+
+// NewJPEGWriter creates a new [Writer] for JPEG images.
+//
+// Quality is the JPEG compression quality, in range 1...100.
+// If 0, [JPEGDefaultQuality] is used.
+//
+// Supported color models are following:
+//   - color.GrayModel
+//   - color.Gray16Model (written as 8-bit)
+//   - color.RGBAModel
+//   - color.RGBA64Model (written as 8-bit)
+//
+// JPEG doesn't support 16-bit images, so the 16-bit models
+// are reduced to 8 bits, and Writer.ColorModel reports the
+// resulting 8-bit model.
+func NewJPEGWriter(output io.Writer,
+	wid, hei int, model color.Model, quality int) (Writer, error) {
+
+	// Translate model into libjpeg terms
+	var components int
+
+	switch model {
+	case color.GrayModel, color.Gray16Model:
+		model = color.GrayModel
+		components = 1
+	case color.RGBAModel, color.RGBA64Model:
+		model = color.RGBAModel
+		components = 3
+	default:
+		err := errors.New("JPEG: unsupported color model")
+		return nil, err
+	}
+
+	switch {
+	case quality == 0:
+		quality = JPEGDefaultQuality
+	case quality < 1 || quality > 100:
+		err := fmt.Errorf("JPEG: invalid quality %d", quality)
+		return nil, err
+	}
+
+	// Create writer structure. Initialize libjpeg stuff
+	writer := &jpegWriter{
+		output:   output,
+		wid:      wid,
+		hei:      hei,
+		model:    model,
+		rowBytes: make([]byte, components*wid),
+	}
+
+	writer.handle = cgo.NewHandle(writer)
+
+	writer.enc = C.jpeg_encoder_new(C.uintptr_t(writer.handle))
+	if writer.enc == nil {
+		writer.handle.Delete()
+		writer.setError(errors.New("JPEG: can't create encoder"))
+		return nil, writer.err
+	}
+
+	// Start compression
+	if C.jpeg_encoder_start(writer.enc, C.int(wid), C.int(hei),
+		C.int(components), C.int(quality)) == 0 {
+
+		C.jpeg_encoder_free(writer.enc)
+		writer.handle.Delete()
+		return nil, writer.err
+	}
+
+	return writer, nil
+}
+
+// Size returns the image size.
+func (writer *jpegWriter) Size() (wid, hei int) {
+	return writer.wid, writer.hei
+}
+
+// ColorModel returns the [color.Model] of image being written.
+func (writer *jpegWriter) ColorModel() color.Model {
+	return writer.model
+}
+
+// Write writes the next image [Row].
+func (writer *jpegWriter) Write(row Row) error {
+	// Check for pending error
+	if writer.err != nil {
+		return writer.err
+	}
+
+	// Silently ignore excessive rows
+	if writer.y == writer.hei {
+		return nil
+	}
+
+	// Encode the row
+	wid := generic.Min(row.Width(), writer.wid)
+
+	var bytesPerPixel int
+
+	switch writer.model {
+	case color.GrayModel:
+		bytesPerPixel = 1
+		bytesGray8fromRow(writer.rowBytes, row)
+	case color.RGBAModel:
+		bytesPerPixel = 3
+		bytesRGB8fromRow(writer.rowBytes, row)
+	}
+
+	// Fill the tail
+	if wid < writer.wid {
+		end := writer.wid * bytesPerPixel
+		for x := wid * bytesPerPixel; x < end; x++ {
+			writer.rowBytes[x] = 0xff
+		}
+	}
+
+	// Write the row
+	if C.jpeg_encoder_write(writer.enc,
+		unsafe.Pointer(&writer.rowBytes[0])) == 0 {
+		writer.setError(errors.New("JPEG: write error"))
+	}
+
+	if writer.err == nil {
+		writer.y++
+	}
+
+	return writer.err
+}
+
+// Close flushes the buffered data and then closes the Writer
+func (writer *jpegWriter) Close() error {
+	// Write missed lines
+	for writer.err == nil && writer.y < writer.hei {
+		writer.Write(RowEmpty{})
+	}
+
+	// Finish JPEG image
+	if writer.err == nil && C.jpeg_encoder_finish(writer.enc) == 0 {
+		writer.setError(errors.New("JPEG: write error"))
+	}
+
+	// Release allocated resources
+	C.jpeg_encoder_free(writer.enc)
+	writer.handle.Delete()
+
+	return writer.err
+}
+
+// setError sets the writer.err, if it is not set yet
+func (writer *jpegWriter) setError(err error) {
+	if writer.err == nil {
+		writer.err = err
+	}
+}
+
+// jpegErrorCallback is called by the libjpeg to report a error
+// This is the common callback for jpegReader and jpegWriter
+//
+//export jpegErrorCallback
+func jpegErrorCallback(handle C.uintptr_t, msg *C.char) {
+	p := cgo.Handle(handle).Value()
+	out := p.(interface{ setError(error) })
+	err := fmt.Errorf("JPEG: %s", C.GoString(msg))
+	out.setError(err)
+}
+
+// jpegReadCallback is called by libjpeg to read from the input stream.
+// It returns amount of bytes read, 0 in a case of error.
+//
+//export jpegReadCallback
+func jpegReadCallback(handle C.uintptr_t,
+	data unsafe.Pointer, size C.size_t) C.size_t {
+
+	const max = math.MaxInt32
+
+	sz := max
+	if C.size_t(sz) > size {
+		sz = int(size)
+	}
+
+	reader := cgo.Handle(handle).Value().(*jpegReader)
+
+	buf := (*[max]byte)(data)[:sz:sz]
+	for {
+		n, err := reader.input.Read(buf)
+		if n > 0 {
+			return C.size_t(n)
+		} else if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			reader.setError(err)
+			return 0
+		}
+	}
+}
+
+// jpegWriteCallback is called by libjpeg to write into the output stream
+//
+//export jpegWriteCallback
+func jpegWriteCallback(handle C.uintptr_t,
+	data unsafe.Pointer, size C.size_t) C.int {
+
+	const max = math.MaxInt32
+
+	sz := max
+	if C.size_t(sz) > size {
+		sz = int(size)
+	}
+
+	writer := cgo.Handle(handle).Value().(*jpegWriter)
+
+	buf := (*[max]byte)(data)[:sz:sz]
+	for len(buf) > 0 {
+		n, err := writer.output.Write(buf)
+		if n > 0 {
+			buf = buf[n:]
+		} else if err != nil {
+			writer.setError(err)
+			return 0
+		}
+	}
+
+	return 1
+}
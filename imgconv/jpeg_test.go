@@ -0,0 +1,247 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// JPEG Reader and Writer test
+
+package imgconv
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/util/generic"
+)
+
+// jpegMaxDistance is the maximum acceptable euclidean distance
+// between images, decoded by the libjpeg and the reference decoder.
+// Decoders are not required to be bit-exact, and JPEG compression
+// is lossy, so we only check that images are similar.
+const jpegMaxDistance = 0.05
+
+// TestJPEGDecode tests JPEG reader
+func TestJPEGDecode(t *testing.T) {
+	data := testutils.Images.JPEG100x75
+	reference, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+
+	// Test image decoding
+	reader, err := NewJPEGReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewJPEGReader: %s", err)
+	}
+
+	if reader.ColorModel() != color.RGBAModel {
+		t.Errorf("Reader.ColorModel mismatch")
+	}
+
+	img, err := decodeImage(reader)
+	if err != nil {
+		t.Fatalf("decodeImage: %s", err)
+	}
+
+	if img.Bounds() != reference.Bounds() {
+		t.Fatalf("Image.Bounds:\n%s",
+			testutils.Diff(reference.Bounds(), img.Bounds()))
+	}
+
+	dist := imageEuclideanDistance(reference, img)
+	if dist > jpegMaxDistance {
+		t.Errorf("images differ too much: %g", dist)
+	}
+
+	// Read after the last line must return io.EOF
+	_, err = reader.Read(reader.NewRow())
+	if err != io.EOF {
+		t.Errorf("Read after end: expected io.EOF, present %v", err)
+	}
+
+	reader.Close()
+
+	// Test handling of truncated data. Note, libjpeg doesn't
+	// need the trailing EOI marker to decode the image, so
+	// truncation only of this marker is not detected.
+	for trunc := 0; trunc < len(data)-2; trunc += 16 {
+		reader, err := NewJPEGReader(bytes.NewReader(data[:trunc]))
+		if err != nil {
+			continue // Error is expected
+		}
+
+		_, err = decodeImage(reader)
+		if err == nil {
+			t.Errorf("truncated at %d: error not detected", trunc)
+		}
+
+		reader.Close()
+	}
+
+	// Test handling of I/O errors
+	expectedErr := errors.New("I/O error, for testing")
+	for off := 0; off < len(data)-2; off += 16 {
+		rd := newIoReaderWithError(data[:off], expectedErr)
+		reader, err := NewJPEGReader(rd)
+		if err == nil {
+			_, err = decodeImage(reader)
+			reader.Close()
+		}
+
+		if err != expectedErr {
+			t.Errorf("I/O error at %d:\n"+
+				"error expected: %s\n"+
+				"error present:  %v\n",
+				off, expectedErr, err)
+			break
+		}
+	}
+
+	// Test handling of damaged header
+	damaged := generic.CopySlice(data)
+	damaged[0] = ^damaged[0]
+	_, err = NewJPEGReader(bytes.NewReader(damaged))
+	if err == nil {
+		t.Errorf("damaged header: error not detected")
+	}
+}
+
+// TestJPEGEncode tests JPEG writer
+func TestJPEGEncode(t *testing.T) {
+	type testData struct {
+		name  string      // Image name, for logging
+		data  []byte      // Source PNG image data
+		model color.Model // Expected Writer model
+	}
+
+	tests := []testData{
+		{
+			name:  "PNG100x75rgb8",
+			data:  testutils.Images.PNG100x75rgb8,
+			model: color.RGBAModel,
+		},
+		{
+			name:  "PNG100x75gray8",
+			data:  testutils.Images.PNG100x75gray8,
+			model: color.GrayModel,
+		},
+		{
+			name:  "PNG100x75gray16",
+			data:  testutils.Images.PNG100x75gray16,
+			model: color.GrayModel,
+		},
+		{
+			name:  "PNG100x75rgb16",
+			data:  testutils.Images.PNG100x75rgb16,
+			model: color.RGBAModel,
+		},
+	}
+
+	for _, test := range tests {
+		reader, err := NewPNGReader(bytes.NewReader(test.data))
+		if err != nil {
+			panic(err)
+		}
+
+		reference, err := decodeImage(reader)
+		reader.Close()
+		if err != nil {
+			panic(err)
+		}
+
+		// Encode the image
+		reader, _ = NewPNGReader(bytes.NewReader(test.data))
+		rows := mustDecodeImageRows(reader)
+		reader.Close()
+
+		buf := &bytes.Buffer{}
+		wid, hei := reader.Size()
+		writer, err := NewJPEGWriter(buf, wid, hei,
+			reader.ColorModel(), 0)
+
+		if err != nil {
+			t.Errorf("%s: NewJPEGWriter: %s", test.name, err)
+			continue
+		}
+
+		if writer.ColorModel() != test.model {
+			t.Errorf("%s: Writer.ColorModel mismatch", test.name)
+		}
+
+		mustEncodeImageRows(writer, rows)
+		err = writer.Close()
+		if err != nil {
+			t.Errorf("%s: Writer.Close: %s", test.name, err)
+			continue
+		}
+
+		// Decode by the reference decoder and compare
+		img, err := jpeg.Decode(buf)
+		if err != nil {
+			t.Errorf("%s: error in encoded image: %s",
+				test.name, err)
+			continue
+		}
+
+		if img.Bounds() != image.Rect(0, 0, wid, hei) {
+			t.Errorf("%s: Image.Bounds mismatch", test.name)
+			continue
+		}
+
+		dist := imageEuclideanDistance(reference, img)
+		if dist > jpegMaxDistance {
+			t.Errorf("%s: images differ too much: %g",
+				test.name, dist)
+		}
+	}
+}
+
+// TestJPEGEncodeErrors tests JPEG writer errors handling
+func TestJPEGEncodeErrors(t *testing.T) {
+	// Unsupported color model
+	_, err := NewJPEGWriter(io.Discard, 100, 75, color.CMYKModel, 0)
+	if err == nil {
+		t.Errorf("unsupported color model: error not detected")
+	}
+
+	// Invalid quality
+	_, err = NewJPEGWriter(io.Discard, 100, 75, color.GrayModel, 101)
+	if err == nil {
+		t.Errorf("invalid quality: error not detected")
+	}
+
+	// I/O errors
+	reader, err := NewPNGReader(
+		bytes.NewReader(testutils.Images.PNG100x75rgb8))
+	if err != nil {
+		panic(err)
+	}
+
+	rows := mustDecodeImageRows(reader)
+	reader.Close()
+
+	expectedErr := errors.New("I/O error, for testing")
+	for lim := 0; lim < 4096; lim += 256 {
+		w := newIoWriterWithError(io.Discard, lim, expectedErr)
+		writer, err := NewJPEGWriter(w, 100, 75, color.RGBAModel, 0)
+		if err == nil {
+			encodeImageRows(writer, rows)
+			err = writer.Close()
+		}
+
+		if err != expectedErr {
+			t.Errorf("I/O error at %d:\n"+
+				"error expected: %s\n"+
+				"error present:  %v\n",
+				lim, expectedErr, err)
+			break
+		}
+	}
+}
@@ -0,0 +1,421 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PDF Writer
+
+package imgconv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	"github.com/OpenPrinting/go-mfp/util/generic"
+)
+
+// PDF object numbers, reserved for the document catalog
+// and the page tree root
+const (
+	pdfObjCatalog = 1
+	pdfObjPages   = 2
+)
+
+// PDFEncoder writes PDF documents, where each page contains
+// a single raster image, covering the whole page.
+//
+// Page size is computed from the image size and resolution.
+// Pages are written sequentially, as they are added, so
+// memory consumption doesn't depend on the document size.
+//
+// PDF reading is not supported.
+type PDFEncoder struct {
+	output  *pdfOutput // Underlying output
+	offsets []int64    // Objects offsets, by number-1
+	pages   []int      // Page objects numbers
+	page    *pdfWriter // Current page, nil if none
+	closed  bool       // Encoder is closed
+}
+
+// NewPDFEncoder creates a new [PDFEncoder].
+func NewPDFEncoder(output io.Writer) *PDFEncoder {
+	enc := &PDFEncoder{
+		output:  &pdfOutput{output: output},
+		offsets: make([]int64, 2),
+	}
+
+	enc.output.printf("%%PDF-1.5\n%%\xe2\xe3\xcf\xd3\n")
+
+	return enc
+}
+
+// NewPage starts a new page and returns its [Writer].
+// Image is compressed with the Flate (deflate) compression.
+//
+// Supported color models are following:
+//   - color.GrayModel
+//   - color.Gray16Model
+//   - color.RGBAModel
+//   - color.RGBA64Model
+//
+// Resolution is in DPI.
+func (enc *PDFEncoder) NewPage(wid, hei int, model color.Model,
+	xres, yres int) (Writer, error) {
+
+	err := enc.checkState()
+	if err != nil {
+		return nil, err
+	}
+
+	var colorSpace string
+	var bits, bpp int
+
+	switch model {
+	case color.GrayModel:
+		colorSpace, bits, bpp = "DeviceGray", 8, 1
+	case color.Gray16Model:
+		colorSpace, bits, bpp = "DeviceGray", 16, 2
+	case color.RGBAModel:
+		colorSpace, bits, bpp = "DeviceRGB", 8, 3
+	case color.RGBA64Model:
+		colorSpace, bits, bpp = "DeviceRGB", 16, 6
+	default:
+		err := errors.New("PDF: unsupported color model")
+		return nil, err
+	}
+
+	if wid <= 0 || hei <= 0 || xres <= 0 || yres <= 0 {
+		return nil, errors.New("PDF: invalid page geometry")
+	}
+
+	// Start image object
+	image := enc.beginImage(wid, hei, colorSpace, bits, "FlateDecode")
+	compressor, _ := zlib.NewWriterLevel(enc.output, zlib.BestSpeed)
+
+	enc.page = &pdfWriter{
+		enc:        enc,
+		compressor: compressor,
+		image:      image,
+		wid:        wid,
+		hei:        hei,
+		xres:       xres,
+		yres:       yres,
+		model:      model,
+		rowBytes:   make([]byte, wid*bpp),
+	}
+
+	if enc.output.err != nil {
+		return nil, enc.output.err
+	}
+
+	return enc.page, nil
+}
+
+// AddJPEGPage adds a page, containing the JPEG image.
+//
+// JPEG image is embedded into the PDF document as is, without
+// decoding and re-compression. Only grayscale and YCbCr (RGB)
+// images are supported.
+//
+// Resolution is in DPI.
+func (enc *PDFEncoder) AddJPEGPage(data []byte, xres, yres int) error {
+	err := enc.checkState()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	var colorSpace string
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		colorSpace = "DeviceGray"
+	case color.YCbCrModel:
+		colorSpace = "DeviceRGB"
+	default:
+		return errors.New("PDF: unsupported JPEG color space")
+	}
+
+	if xres <= 0 || yres <= 0 {
+		return errors.New("PDF: invalid page geometry")
+	}
+
+	image := enc.beginImage(cfg.Width, cfg.Height, colorSpace, 8,
+		"DCTDecode")
+	enc.output.write(data)
+	enc.endImage(image)
+
+	enc.addPage(image, cfg.Width, cfg.Height, xres, yres)
+
+	return enc.output.err
+}
+
+// Close finishes the PDF document.
+//
+// If the current page Writer is not closed yet, it is closed
+// automatically.
+func (enc *PDFEncoder) Close() error {
+	if enc.closed {
+		return enc.output.err
+	}
+
+	if enc.page != nil {
+		enc.page.Close()
+	}
+
+	enc.closed = true
+
+	if enc.output.err == nil && len(enc.pages) == 0 {
+		return errors.New("PDF: document has no pages")
+	}
+
+	// Write page tree and catalog
+	enc.beginObject(pdfObjPages)
+	enc.output.printf("<< /Type /Pages /Kids [")
+	for _, page := range enc.pages {
+		enc.output.printf(" %d 0 R", page)
+	}
+	enc.output.printf(" ] /Count %d >>\n", len(enc.pages))
+	enc.endObject()
+
+	enc.beginObject(pdfObjCatalog)
+	enc.output.printf("<< /Type /Catalog /Pages %d 0 R >>\n",
+		pdfObjPages)
+	enc.endObject()
+
+	// Write xref table and trailer
+	xref := enc.output.off
+	enc.output.printf("xref\n0 %d\n", len(enc.offsets)+1)
+	enc.output.printf("0000000000 65535 f \n")
+	for _, off := range enc.offsets {
+		enc.output.printf("%10.10d 00000 n \n", off)
+	}
+
+	enc.output.printf("trailer\n<< /Size %d /Root %d 0 R >>\n",
+		len(enc.offsets)+1, pdfObjCatalog)
+	enc.output.printf("startxref\n%d\n%%%%EOF\n", xref)
+
+	return enc.output.err
+}
+
+// checkState checks that the new page can be started.
+func (enc *PDFEncoder) checkState() error {
+	switch {
+	case enc.closed:
+		return errors.New("PDF: encoder is closed")
+	case enc.page != nil:
+		return errors.New("PDF: previous page is not closed")
+	}
+
+	return enc.output.err
+}
+
+// beginImage starts the image XObject stream.
+// It returns the image object number.
+func (enc *PDFEncoder) beginImage(wid, hei int,
+	colorSpace string, bits int, filter string) int {
+
+	image := enc.newObject()
+	length := enc.newObject()
+
+	enc.beginObject(image)
+	enc.output.printf("<< /Type /XObject /Subtype /Image"+
+		" /Width %d /Height %d /ColorSpace /%s"+
+		" /BitsPerComponent %d /Filter /%s /Length %d 0 R >>\n",
+		wid, hei, colorSpace, bits, filter, length)
+	enc.output.printf("stream\n")
+	enc.output.start = enc.output.off
+
+	return image
+}
+
+// endImage finishes the image XObject stream, started by
+// the beginImage.
+func (enc *PDFEncoder) endImage(image int) {
+	size := enc.output.off - enc.output.start
+
+	enc.output.printf("\nendstream\n")
+	enc.endObject()
+
+	enc.beginObject(image + 1)
+	enc.output.printf("%d\n", size)
+	enc.endObject()
+}
+
+// addPage writes the page object, that displays the image,
+// and its content stream.
+func (enc *PDFEncoder) addPage(image, wid, hei, xres, yres int) {
+	w := float64(wid) * 72 / float64(xres)
+	h := float64(hei) * 72 / float64(yres)
+
+	content := fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q", w, h)
+	contentObj := enc.newObject()
+	enc.beginObject(contentObj)
+	enc.output.printf("<< /Length %d >>\nstream\n%s\nendstream\n",
+		len(content), content)
+	enc.endObject()
+
+	page := enc.newObject()
+	enc.beginObject(page)
+	enc.output.printf("<< /Type /Page /Parent %d 0 R"+
+		" /MediaBox [0 0 %.2f %.2f]"+
+		" /Resources << /XObject << /Im0 %d 0 R >> >>"+
+		" /Contents %d 0 R >>\n",
+		pdfObjPages, w, h, image, contentObj)
+	enc.endObject()
+
+	enc.pages = append(enc.pages, page)
+}
+
+// newObject allocates the new object number.
+func (enc *PDFEncoder) newObject() int {
+	enc.offsets = append(enc.offsets, 0)
+	return len(enc.offsets)
+}
+
+// beginObject starts the object with the given number.
+func (enc *PDFEncoder) beginObject(obj int) {
+	enc.offsets[obj-1] = enc.output.off
+	enc.output.printf("%d 0 obj\n", obj)
+}
+
+// endObject finishes the current object.
+func (enc *PDFEncoder) endObject() {
+	enc.output.printf("endobj\n")
+}
+
+// pdfWriter implements the [Writer] interface for the single
+// PDF page.
+type pdfWriter struct {
+	enc        *PDFEncoder  // Encoder that owns the page
+	compressor *zlib.Writer // Image data compressor
+	image      int          // Image object number
+	wid, hei   int          // Image size
+	xres, yres int          // Image resolution
+	model      color.Model  // Color model
+	rowBytes   []byte       // Row encoding buffer
+	y          int          // Current y-coordinate
+	closed     bool         // Writer is closed
+}
+
+// Size returns the image size.
+func (writer *pdfWriter) Size() (wid, hei int) {
+	return writer.wid, writer.hei
+}
+
+// ColorModel returns the [color.Model] of image being written.
+func (writer *pdfWriter) ColorModel() color.Model {
+	return writer.model
+}
+
+// Write writes the next image [Row].
+func (writer *pdfWriter) Write(row Row) error {
+	// Check for pending error
+	output := writer.enc.output
+	if output.err != nil {
+		return output.err
+	}
+
+	// Silently ignore excessive rows
+	if writer.y == writer.hei {
+		return nil
+	}
+
+	// Encode the row
+	wid := generic.Min(row.Width(), writer.wid)
+
+	switch writer.model {
+	case color.GrayModel:
+		bytesGray8fromRow(writer.rowBytes, row)
+	case color.Gray16Model:
+		bytesGray16BEfromRow(writer.rowBytes, row)
+	case color.RGBAModel:
+		bytesRGB8fromRow(writer.rowBytes, row)
+	case color.RGBA64Model:
+		bytesRGB16BEfromRow(writer.rowBytes, row)
+	}
+
+	// Fill the tail
+	bpp := len(writer.rowBytes) / writer.wid
+	for x := wid * bpp; x < len(writer.rowBytes); x++ {
+		writer.rowBytes[x] = 0xff
+	}
+
+	writer.compressor.Write(writer.rowBytes)
+	writer.y++
+
+	return output.err
+}
+
+// Close writes missed rows and finishes the page.
+func (writer *pdfWriter) Close() error {
+	output := writer.enc.output
+	if writer.closed {
+		return output.err
+	}
+
+	// Write missed lines
+	for output.err == nil && writer.y < writer.hei {
+		writer.Write(RowEmpty{})
+	}
+
+	writer.compressor.Close()
+
+	enc := writer.enc
+	enc.endImage(writer.image)
+	enc.addPage(writer.image, writer.wid, writer.hei,
+		writer.xres, writer.yres)
+
+	writer.closed = true
+	enc.page = nil
+
+	return output.err
+}
+
+// pdfOutput wraps the io.Writer. It counts written bytes and
+// keeps the first write error.
+type pdfOutput struct {
+	output io.Writer // Underlying io.Writer
+	off    int64     // Current offset
+	start  int64     // Current stream start offset
+	err    error     // Sticky error
+}
+
+// Write implements the io.Writer interface.
+func (out *pdfOutput) Write(data []byte) (int, error) {
+	out.write(data)
+	if out.err != nil {
+		return 0, out.err
+	}
+	return len(data), nil
+}
+
+// write writes data, if there is no pending error.
+func (out *pdfOutput) write(data []byte) {
+	if out.err != nil {
+		return
+	}
+
+	n, err := out.output.Write(data)
+	out.off += int64(n)
+
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+
+	out.err = err
+}
+
+// printf writes formatted output.
+func (out *pdfOutput) printf(format string, args ...any) {
+	out.write([]byte(fmt.Sprintf(format, args...)))
+}
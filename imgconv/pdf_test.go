@@ -0,0 +1,364 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PDF Writer test
+
+package imgconv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+)
+
+// pdfTestDocument is the minimal parser of PDF documents, produced
+// by the PDFEncoder, for testing purposes.
+type pdfTestDocument struct {
+	objects map[int][]byte // Object bodies, by number
+}
+
+// pdfTestParse parses the PDF document.
+func pdfTestParse(data []byte) (*pdfTestDocument, error) {
+	doc := &pdfTestDocument{objects: make(map[int][]byte)}
+
+	// Locate xref table
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).
+		FindSubmatch(data)
+	if m == nil {
+		return nil, errors.New("startxref not found")
+	}
+
+	xref, _ := strconv.Atoi(string(m[1]))
+	if xref >= len(data) {
+		return nil, errors.New("startxref out of range")
+	}
+
+	// Parse xref table
+	var cnt int
+	_, err := fmt.Sscanf(string(data[xref:]), "xref\n0 %d\n", &cnt)
+	if err != nil {
+		return nil, fmt.Errorf("xref: %s", err)
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).
+		FindAllSubmatch(data[xref:], -1)
+	if len(entries) != cnt-1 {
+		return nil, fmt.Errorf("xref: %d entries expected, %d found",
+			cnt-1, len(entries))
+	}
+
+	for i, ent := range entries {
+		obj := i + 1
+		off, _ := strconv.Atoi(string(ent[1]))
+		hdr := fmt.Sprintf("%d 0 obj\n", obj)
+
+		if !bytes.HasPrefix(data[off:], []byte(hdr)) {
+			return nil, fmt.Errorf("obj %d: invalid offset", obj)
+		}
+
+		body := data[off+len(hdr):]
+		end := bytes.Index(body, []byte("endobj\n"))
+		if end < 0 {
+			return nil, fmt.Errorf("obj %d: endobj not found", obj)
+		}
+
+		doc.objects[obj] = body[:end]
+	}
+
+	return doc, nil
+}
+
+// pages returns page object numbers.
+func (doc *pdfTestDocument) pages() []int {
+	var pages []int
+	re := regexp.MustCompile(`(\d+) 0 R`)
+	kids := regexp.MustCompile(`/Kids \[([^\]]*)\]`).
+		FindSubmatch(doc.objects[pdfObjPages])
+
+	if kids != nil {
+		for _, m := range re.FindAllSubmatch(kids[1], -1) {
+			page, _ := strconv.Atoi(string(m[1]))
+			pages = append(pages, page)
+		}
+	}
+
+	return pages
+}
+
+// image returns the image object dictionary and the stream data
+// for the page.
+func (doc *pdfTestDocument) image(page int) (string, []byte, error) {
+	m := regexp.MustCompile(`/Im0 (\d+) 0 R`).
+		FindSubmatch(doc.objects[page])
+	if m == nil {
+		return "", nil, fmt.Errorf("page %d: image not found", page)
+	}
+
+	obj, _ := strconv.Atoi(string(m[1]))
+	body := doc.objects[obj]
+
+	m = regexp.MustCompile(`/Length (\d+) 0 R`).FindSubmatch(body)
+	if m == nil {
+		return "", nil, fmt.Errorf("obj %d: length not found", obj)
+	}
+
+	lenObj, _ := strconv.Atoi(string(m[1]))
+	length, err := strconv.Atoi(string(bytes.TrimSpace(
+		doc.objects[lenObj])))
+	if err != nil {
+		return "", nil, fmt.Errorf("obj %d: invalid length", lenObj)
+	}
+
+	dict, stream, ok := bytes.Cut(body, []byte("stream\n"))
+	if !ok || len(stream) < length ||
+		!bytes.HasPrefix(stream[length:], []byte("\nendstream\n")) {
+		return "", nil, fmt.Errorf("obj %d: invalid stream", obj)
+	}
+
+	return string(dict), stream[:length], nil
+}
+
+// TestPDFEncode tests PDF writer
+func TestPDFEncode(t *testing.T) {
+	type testData struct {
+		name       string // Image name, for logging
+		data       []byte // PNG image data
+		colorSpace string // Expected PDF color space
+		bits       int    // Expected bits per component
+	}
+
+	tests := []testData{
+		{
+			name:       "PNG100x75rgb8",
+			data:       testutils.Images.PNG100x75rgb8,
+			colorSpace: "DeviceRGB",
+			bits:       8,
+		},
+		{
+			name:       "PNG100x75rgb16",
+			data:       testutils.Images.PNG100x75rgb16,
+			colorSpace: "DeviceRGB",
+			bits:       16,
+		},
+		{
+			name:       "PNG100x75gray8",
+			data:       testutils.Images.PNG100x75gray8,
+			colorSpace: "DeviceGray",
+			bits:       8,
+		},
+		{
+			name:       "PNG100x75gray16",
+			data:       testutils.Images.PNG100x75gray16,
+			colorSpace: "DeviceGray",
+			bits:       16,
+		},
+	}
+
+	// Encode all images as pages, plus the JPEG page
+	buf := &bytes.Buffer{}
+	enc := NewPDFEncoder(buf)
+	expected := [][]byte{}
+
+	for _, test := range tests {
+		reader, err := NewPNGReader(bytes.NewReader(test.data))
+		if err != nil {
+			panic(err)
+		}
+
+		rows := mustDecodeImageRows(reader)
+		reader.Close()
+
+		wid, hei := reader.Size()
+		writer, err := enc.NewPage(wid, hei, reader.ColorModel(),
+			300, 300)
+		if err != nil {
+			t.Fatalf("%s: NewPage: %s", test.name, err)
+		}
+
+		mustEncodeImageRows(writer, rows)
+		err = writer.Close()
+		if err != nil {
+			t.Fatalf("%s: Writer.Close: %s", test.name, err)
+		}
+
+		// Build expected raw image data
+		bpp := test.bits / 8
+		if test.colorSpace == "DeviceRGB" {
+			bpp *= 3
+		}
+
+		raw := make([]byte, wid*bpp)
+		var data []byte
+		for _, row := range rows {
+			switch reader.ColorModel() {
+			case color.GrayModel:
+				bytesGray8fromRow(raw, row)
+			case color.Gray16Model:
+				bytesGray16BEfromRow(raw, row)
+			case color.RGBAModel:
+				bytesRGB8fromRow(raw, row)
+			case color.RGBA64Model:
+				bytesRGB16BEfromRow(raw, row)
+			}
+			data = append(data, raw...)
+		}
+
+		expected = append(expected, data)
+	}
+
+	jpegData := testutils.Images.JPEG100x75
+	err := enc.AddJPEGPage(jpegData, 150, 150)
+	if err != nil {
+		t.Fatalf("AddJPEGPage: %s", err)
+	}
+
+	err = enc.Close()
+	if err != nil {
+		t.Fatalf("PDFEncoder.Close: %s", err)
+	}
+
+	// Parse and check the document
+	doc, err := pdfTestParse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	pages := doc.pages()
+	if len(pages) != len(tests)+1 {
+		t.Fatalf("%d pages expected, %d present",
+			len(tests)+1, len(pages))
+	}
+
+	for i, test := range tests {
+		page := pages[i]
+
+		if !bytes.Contains(doc.objects[page],
+			[]byte("/MediaBox [0 0 24.00 18.00]")) {
+			t.Errorf("%s: MediaBox mismatch:\n%s",
+				test.name, doc.objects[page])
+		}
+
+		dict, stream, err := doc.image(page)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		params := fmt.Sprintf("/Width 100 /Height 75 /ColorSpace /%s"+
+			" /BitsPerComponent %d /Filter /FlateDecode",
+			test.colorSpace, test.bits)
+		if !bytes.Contains([]byte(dict), []byte(params)) {
+			t.Errorf("%s: image parameters mismatch:\n"+
+				"expected: %s\n"+
+				"present:  %s",
+				test.name, params, dict)
+		}
+
+		zr, err := zlib.NewReader(bytes.NewReader(stream))
+		if err != nil {
+			t.Errorf("%s: zlib: %s", test.name, err)
+			continue
+		}
+
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Errorf("%s: zlib: %s", test.name, err)
+			continue
+		}
+
+		if !bytes.Equal(data, expected[i]) {
+			t.Errorf("%s: image data mismatch", test.name)
+		}
+	}
+
+	// Check JPEG page
+	page := pages[len(tests)]
+	if !bytes.Contains(doc.objects[page],
+		[]byte("/MediaBox [0 0 48.00 36.00]")) {
+		t.Errorf("JPEG: MediaBox mismatch:\n%s", doc.objects[page])
+	}
+
+	dict, stream, err := doc.image(page)
+	switch {
+	case err != nil:
+		t.Errorf("JPEG: %s", err)
+	case !bytes.Contains([]byte(dict), []byte("/Filter /DCTDecode")):
+		t.Errorf("JPEG: filter mismatch:\n%s", dict)
+	case !bytes.Equal(stream, jpegData):
+		t.Errorf("JPEG: image data mismatch")
+	}
+}
+
+// TestPDFEncodeErrors tests PDF writer errors handling
+func TestPDFEncodeErrors(t *testing.T) {
+	enc := NewPDFEncoder(io.Discard)
+
+	// Unsupported color model
+	_, err := enc.NewPage(100, 75, color.CMYKModel, 300, 300)
+	if err == nil {
+		t.Errorf("unsupported color model: error not detected")
+	}
+
+	// Invalid resolution
+	_, err = enc.NewPage(100, 75, color.GrayModel, 0, 300)
+	if err == nil {
+		t.Errorf("invalid resolution: error not detected")
+	}
+
+	// Invalid JPEG data
+	err = enc.AddJPEGPage([]byte("not a JPEG"), 300, 300)
+	if err == nil {
+		t.Errorf("invalid JPEG data: error not detected")
+	}
+
+	// Document without pages
+	err = enc.Close()
+	if err == nil {
+		t.Errorf("document without pages: error not detected")
+	}
+
+	// Previous page is not closed
+	enc = NewPDFEncoder(io.Discard)
+	_, err = enc.NewPage(100, 75, color.GrayModel, 300, 300)
+	if err != nil {
+		t.Fatalf("NewPage: %s", err)
+	}
+
+	err = enc.AddJPEGPage(testutils.Images.JPEG100x75, 300, 300)
+	if err == nil {
+		t.Errorf("page not closed: error not detected")
+	}
+
+	// Unclosed page must be closed by the PDFEncoder.Close
+	err = enc.Close()
+	if err != nil {
+		t.Errorf("PDFEncoder.Close: %s", err)
+	}
+
+	// I/O errors
+	expectedErr := errors.New("I/O error, for testing")
+	for lim := 0; lim < 4096; lim += 64 {
+		w := newIoWriterWithError(io.Discard, lim, expectedErr)
+		enc := NewPDFEncoder(w)
+		err := enc.AddJPEGPage(testutils.Images.JPEG100x75, 300, 300)
+		if err == nil {
+			err = enc.Close()
+		}
+
+		if err == nil {
+			t.Errorf("I/O error at %d: error not detected", lim)
+			break
+		}
+	}
+}
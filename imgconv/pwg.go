@@ -0,0 +1,647 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PWG Raster Reader and Writer
+
+package imgconv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+
+	"github.com/OpenPrinting/go-mfp/util/generic"
+)
+
+// PWG Raster stream layout constants (see PWG 5102.4)
+const (
+	pwgSyncWord   = "RaS2" // Stream synchronization word
+	pwgHeaderSize = 1796   // Page header size
+	pwgMaxRepeat  = 256    // Max line or pixel repeat count
+)
+
+// PWG Raster page header field offsets
+const (
+	pwgOffPwgRaster      = 0
+	pwgOffHWResolution   = 276
+	pwgOffNumCopies      = 340
+	pwgOffPageSize       = 352
+	pwgOffWidth          = 372
+	pwgOffHeight         = 376
+	pwgOffBitsPerColor   = 384
+	pwgOffBitsPerPixel   = 388
+	pwgOffBytesPerLine   = 392
+	pwgOffColorOrder     = 396
+	pwgOffColorSpace     = 400
+	pwgOffNumColors      = 420
+	pwgOffCrossFeedXform = 456
+	pwgOffFeedXform      = 460
+	pwgOffImageBoxRight  = 472
+	pwgOffImageBoxBottom = 476
+)
+
+// PWG Raster color spaces
+const (
+	pwgColorSpaceBlack    = 3
+	pwgColorSpaceSGray    = 18
+	pwgColorSpaceSRGB     = 19
+	pwgColorSpaceAdobeRGB = 20
+)
+
+// PWGDecoder decodes PWG Raster stream, which may contain
+// multiple pages.
+//
+// Each page is returned as a separate [Reader].
+type PWGDecoder struct {
+	input      *bufio.Reader // Underlying input
+	page       *pwgReader    // Current page, nil if none
+	xres, yres int           // Current page resolution
+	err        error         // Sticky error
+}
+
+// NewPWGDecoder creates a new [PWGDecoder].
+// It reads and checks the PWG Raster synchronization word.
+func NewPWGDecoder(input io.Reader) (*PWGDecoder, error) {
+	dec := &PWGDecoder{input: bufio.NewReader(input)}
+
+	var sync [len(pwgSyncWord)]byte
+	_, err := io.ReadFull(dec.input, sync[:])
+	if err != nil {
+		return nil, pwgReadError(err)
+	}
+
+	if string(sync[:]) != pwgSyncWord {
+		return nil, errors.New("PWG: invalid synchronization word")
+	}
+
+	return dec, nil
+}
+
+// Next returns the [Reader] for the next page.
+// At the end of stream it returns [io.EOF].
+//
+// Unread rows of the previous page are skipped. The previous
+// page Reader becomes invalid and must not be used anymore.
+func (dec *PWGDecoder) Next() (Reader, error) {
+	if dec.err != nil {
+		return nil, dec.err
+	}
+
+	// Skip the rest of the current page
+	if dec.page != nil {
+		for dec.page.err == nil {
+			dec.page.Read(RowEmpty{})
+		}
+
+		if dec.page.err != io.EOF {
+			dec.err = dec.page.err
+			return nil, dec.err
+		}
+
+		dec.page = nil
+	}
+
+	// Read the next page header
+	var hdr [pwgHeaderSize]byte
+	_, err := io.ReadFull(dec.input, hdr[:])
+	switch {
+	case err == io.EOF:
+		dec.err = io.EOF
+		return nil, dec.err
+	case err != nil:
+		dec.err = pwgReadError(err)
+		return nil, dec.err
+	}
+
+	page, err := newPWGReader(dec.input, hdr[:])
+	if err != nil {
+		dec.err = err
+		return nil, err
+	}
+
+	dec.page = page
+	dec.xres = int(binary.BigEndian.Uint32(hdr[pwgOffHWResolution:]))
+	dec.yres = int(binary.BigEndian.Uint32(hdr[pwgOffHWResolution+4:]))
+
+	return page, nil
+}
+
+// Resolution returns the resolution of the current page, in DPI.
+func (dec *PWGDecoder) Resolution() (xres, yres int) {
+	return dec.xres, dec.yres
+}
+
+// pwgReader implements the [Reader] interface for the single
+// PWG Raster page.
+type pwgReader struct {
+	input      *bufio.Reader // Underlying input
+	model      color.Model   // Image color mode
+	wid, hei   int           // Image size
+	bpp        int           // Bytes per compression unit (pixel)
+	colorSpace int           // PWG color space
+	bits       int           // Bits per color
+	lineBytes  []byte        // Decoded line buffer
+	repeat     int           // Remaining line repeat count
+	y          int           // Current y-coordinate
+	err        error         // Sticky error
+}
+
+// newPWGReader creates a new pwgReader for the page.
+func newPWGReader(input *bufio.Reader, hdr []byte) (*pwgReader, error) {
+	get := func(off int) int {
+		return int(binary.BigEndian.Uint32(hdr[off:]))
+	}
+
+	reader := &pwgReader{
+		input:      input,
+		wid:        get(pwgOffWidth),
+		hei:        get(pwgOffHeight),
+		colorSpace: get(pwgOffColorSpace),
+		bits:       get(pwgOffBitsPerColor),
+	}
+
+	bitsPerPixel := get(pwgOffBitsPerPixel)
+	bytesPerLine := get(pwgOffBytesPerLine)
+
+	if !bytes.HasPrefix(hdr, []byte("PwgRaster\000")) {
+		return nil, errors.New("PWG: invalid page header")
+	}
+
+	if get(pwgOffColorOrder) != 0 {
+		return nil, errors.New("PWG: unsupported color order")
+	}
+
+	switch {
+	case reader.colorSpace == pwgColorSpaceBlack &&
+		reader.bits == 1 && bitsPerPixel == 1:
+		reader.model = color.GrayModel
+		reader.bpp = 1
+
+	case reader.colorSpace == pwgColorSpaceBlack &&
+		reader.bits == 8 && bitsPerPixel == 8:
+		reader.model = color.GrayModel
+		reader.bpp = 1
+
+	case reader.colorSpace == pwgColorSpaceSGray &&
+		reader.bits == 8 && bitsPerPixel == 8:
+		reader.model = color.GrayModel
+		reader.bpp = 1
+
+	case reader.colorSpace == pwgColorSpaceSGray &&
+		reader.bits == 16 && bitsPerPixel == 16:
+		reader.model = color.Gray16Model
+		reader.bpp = 2
+
+	case (reader.colorSpace == pwgColorSpaceSRGB ||
+		reader.colorSpace == pwgColorSpaceAdobeRGB) &&
+		reader.bits == 8 && bitsPerPixel == 24:
+		reader.model = color.RGBAModel
+		reader.bpp = 3
+
+	case (reader.colorSpace == pwgColorSpaceSRGB ||
+		reader.colorSpace == pwgColorSpaceAdobeRGB) &&
+		reader.bits == 16 && bitsPerPixel == 48:
+		reader.model = color.RGBA64Model
+		reader.bpp = 6
+
+	default:
+		err := fmt.Errorf("PWG: unsupported color space %d/%d bits",
+			reader.colorSpace, bitsPerPixel)
+		return nil, err
+	}
+
+	if reader.wid <= 0 || reader.hei <= 0 ||
+		bytesPerLine != (reader.wid*bitsPerPixel+7)/8 ||
+		bytesPerLine%reader.bpp != 0 {
+		return nil, errors.New("PWG: invalid page geometry")
+	}
+
+	reader.lineBytes = make([]byte, bytesPerLine)
+
+	return reader, nil
+}
+
+// Close closes the reader.
+func (reader *pwgReader) Close() {
+}
+
+// ColorModel returns the [color.Model] of image being decoded.
+func (reader *pwgReader) ColorModel() color.Model {
+	return reader.model
+}
+
+// Size returns the image size.
+func (reader *pwgReader) Size() (wid, hei int) {
+	return reader.wid, reader.hei
+}
+
+// NewRow allocates a [Row] of the appropriate type and width for
+// use with the [Reader.Read] function.
+func (reader *pwgReader) NewRow() Row {
+	return NewRow(reader.model, reader.wid)
+}
+
+// Read returns the next image [Row].
+func (reader *pwgReader) Read(row Row) (int, error) {
+	// Read the next line
+	reader.readLine()
+	if reader.err != nil {
+		return 0, reader.err
+	}
+
+	// Decode the row
+	wid := generic.Min(row.Width(), reader.wid)
+	line := reader.lineBytes
+
+	switch {
+	case reader.colorSpace == pwgColorSpaceBlack && reader.bits == 1:
+		for x := 0; x < wid; x++ {
+			v := uint8(0xff)
+			if line[x/8]&(0x80>>(x%8)) != 0 {
+				v = 0
+			}
+			row.Set(x, color.Gray{v})
+		}
+
+	case reader.colorSpace == pwgColorSpaceBlack:
+		for x := 0; x < wid; x++ {
+			row.Set(x, color.Gray{^line[x]})
+		}
+
+	case reader.model == color.GrayModel:
+		bytesGray8toRow(row, line)
+
+	case reader.model == color.Gray16Model:
+		bytesGray16BEtoRow(row, line)
+
+	case reader.model == color.RGBAModel:
+		bytesRGB8toRow(row, line)
+
+	case reader.model == color.RGBA64Model:
+		bytesRGB16BEtoRow(row, line)
+	}
+
+	// Update current y
+	reader.y++
+	if reader.y == reader.hei {
+		reader.setError(io.EOF)
+	}
+
+	return wid, nil
+}
+
+// readLine decodes the next line into the reader.lineBytes.
+func (reader *pwgReader) readLine() {
+	if reader.err != nil {
+		return
+	}
+
+	// Repeat the previous line?
+	if reader.repeat > 0 {
+		reader.repeat--
+		return
+	}
+
+	// Read the line repeat count
+	c, err := reader.input.ReadByte()
+	if err != nil {
+		reader.setError(pwgReadError(err))
+		return
+	}
+
+	reader.repeat = int(c)
+
+	// Decode the line
+	line := reader.lineBytes
+	bpp := reader.bpp
+
+	for len(line) > 0 {
+		c, err := reader.input.ReadByte()
+		if err != nil {
+			reader.setError(pwgReadError(err))
+			return
+		}
+
+		switch {
+		case c == 128:
+			// Fill the rest of the line with white
+			fill := byte(0xff)
+			if reader.colorSpace == pwgColorSpaceBlack {
+				fill = 0
+			}
+
+			for i := range line {
+				line[i] = fill
+			}
+			line = line[:0]
+
+		case c < 128:
+			// Repeated pixel
+			n := (int(c) + 1) * bpp
+			if n > len(line) {
+				reader.setError(errors.New("PWG: line overflow"))
+				return
+			}
+
+			_, err = io.ReadFull(reader.input, line[:bpp])
+			if err != nil {
+				reader.setError(pwgReadError(err))
+				return
+			}
+
+			for i := bpp; i < n; i++ {
+				line[i] = line[i-bpp]
+			}
+			line = line[n:]
+
+		default:
+			// Literal pixels
+			n := (257 - int(c)) * bpp
+			if n > len(line) {
+				reader.setError(errors.New("PWG: line overflow"))
+				return
+			}
+
+			_, err = io.ReadFull(reader.input, line[:n])
+			if err != nil {
+				reader.setError(pwgReadError(err))
+				return
+			}
+			line = line[n:]
+		}
+	}
+}
+
+// setError sets the reader.err, if it is not set yet
+func (reader *pwgReader) setError(err error) {
+	if reader.err == nil {
+		reader.err = err
+	}
+}
+
+// PWGEncoder encodes the PWG Raster stream, which may contain
+// multiple pages.
+//
+// Each page is written using a separate [Writer], returned by
+// the [PWGEncoder.NewPage]. Page Writer must be closed before
+// the next page is started.
+type PWGEncoder struct {
+	output  io.Writer  // Underlying output
+	started bool       // Synchronization word is written
+	page    *pwgWriter // Current page, nil if none
+}
+
+// NewPWGEncoder creates a new [PWGEncoder].
+func NewPWGEncoder(output io.Writer) *PWGEncoder {
+	return &PWGEncoder{output: output}
+}
+
+// NewPage starts a new page and returns its [Writer].
+//
+// Supported color models are following:
+//   - color.GrayModel (written as sGray 8-bit)
+//   - color.Gray16Model (written as sGray 16-bit)
+//   - color.RGBAModel (written as sRGB 8-bit)
+//   - color.RGBA64Model (written as sRGB 16-bit)
+//
+// Resolution is in DPI.
+func (enc *PWGEncoder) NewPage(wid, hei int, model color.Model,
+	xres, yres int) (Writer, error) {
+
+	if enc.page != nil && !enc.page.closed {
+		return nil, errors.New("PWG: previous page is not closed")
+	}
+
+	// Translate model into PWG terms
+	var colorSpace, bits, numColors int
+
+	switch model {
+	case color.GrayModel:
+		colorSpace, bits, numColors = pwgColorSpaceSGray, 8, 1
+	case color.Gray16Model:
+		colorSpace, bits, numColors = pwgColorSpaceSGray, 16, 1
+	case color.RGBAModel:
+		colorSpace, bits, numColors = pwgColorSpaceSRGB, 8, 3
+	case color.RGBA64Model:
+		colorSpace, bits, numColors = pwgColorSpaceSRGB, 16, 3
+	default:
+		err := errors.New("PWG: unsupported color model")
+		return nil, err
+	}
+
+	if wid <= 0 || hei <= 0 || xres <= 0 || yres <= 0 {
+		return nil, errors.New("PWG: invalid page geometry")
+	}
+
+	// Build page header
+	var hdr [pwgHeaderSize]byte
+	put := func(off, v int) {
+		binary.BigEndian.PutUint32(hdr[off:], uint32(v))
+	}
+
+	bpp := bits * numColors / 8
+
+	copy(hdr[pwgOffPwgRaster:], "PwgRaster")
+	put(pwgOffHWResolution, xres)
+	put(pwgOffHWResolution+4, yres)
+	put(pwgOffNumCopies, 1)
+	put(pwgOffPageSize, wid*72/xres)
+	put(pwgOffPageSize+4, hei*72/yres)
+	put(pwgOffWidth, wid)
+	put(pwgOffHeight, hei)
+	put(pwgOffBitsPerColor, bits)
+	put(pwgOffBitsPerPixel, bits*numColors)
+	put(pwgOffBytesPerLine, wid*bpp)
+	put(pwgOffColorSpace, colorSpace)
+	put(pwgOffNumColors, numColors)
+	put(pwgOffCrossFeedXform, 1)
+	put(pwgOffFeedXform, 1)
+	put(pwgOffImageBoxRight, wid)
+	put(pwgOffImageBoxBottom, hei)
+
+	// Write synchronization word and header
+	var err error
+	if !enc.started {
+		_, err = enc.output.Write([]byte(pwgSyncWord))
+		enc.started = true
+	}
+
+	if err == nil {
+		_, err = enc.output.Write(hdr[:])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	enc.page = &pwgWriter{
+		output:   enc.output,
+		wid:      wid,
+		hei:      hei,
+		model:    model,
+		bpp:      bpp,
+		rowBytes: make([]byte, wid*bpp),
+		prev:     make([]byte, wid*bpp),
+	}
+
+	return enc.page, nil
+}
+
+// pwgWriter implements the [Writer] interface for the single
+// PWG Raster page.
+type pwgWriter struct {
+	output   io.Writer    // Underlying io.Writer
+	wid, hei int          // Image size
+	model    color.Model  // Color model
+	bpp      int          // Bytes per pixel
+	rowBytes []byte       // Row encoding buffer
+	prev     []byte       // Previous row, not written yet
+	repeat   int          // Count of prev rows, 0 if none
+	buf      bytes.Buffer // Compressed data buffer
+	y        int          // Current y-coordinate
+	closed   bool         // Writer is closed
+	err      error        // Sticky error
+}
+
+// Size returns the image size.
+func (writer *pwgWriter) Size() (wid, hei int) {
+	return writer.wid, writer.hei
+}
+
+// ColorModel returns the [color.Model] of image being written.
+func (writer *pwgWriter) ColorModel() color.Model {
+	return writer.model
+}
+
+// Write writes the next image [Row].
+func (writer *pwgWriter) Write(row Row) error {
+	// Check for pending error
+	if writer.err != nil {
+		return writer.err
+	}
+
+	// Silently ignore excessive rows
+	if writer.y == writer.hei {
+		return nil
+	}
+
+	// Encode the row
+	wid := generic.Min(row.Width(), writer.wid)
+
+	switch writer.model {
+	case color.GrayModel:
+		bytesGray8fromRow(writer.rowBytes, row)
+	case color.Gray16Model:
+		bytesGray16BEfromRow(writer.rowBytes, row)
+	case color.RGBAModel:
+		bytesRGB8fromRow(writer.rowBytes, row)
+	case color.RGBA64Model:
+		bytesRGB16BEfromRow(writer.rowBytes, row)
+	}
+
+	// Fill the tail
+	for x := wid * writer.bpp; x < len(writer.rowBytes); x++ {
+		writer.rowBytes[x] = 0xff
+	}
+
+	writer.y++
+
+	// Merge with the previous row, if possible
+	if writer.repeat > 0 && writer.repeat < pwgMaxRepeat &&
+		bytes.Equal(writer.prev, writer.rowBytes) {
+		writer.repeat++
+		return nil
+	}
+
+	writer.flush()
+	copy(writer.prev, writer.rowBytes)
+	writer.repeat = 1
+
+	return writer.err
+}
+
+// Close writes missed rows and finishes the page.
+func (writer *pwgWriter) Close() error {
+	// Write missed lines
+	for writer.err == nil && writer.y < writer.hei {
+		writer.Write(RowEmpty{})
+	}
+
+	writer.flush()
+	writer.closed = true
+
+	return writer.err
+}
+
+// flush writes pending repeated rows.
+func (writer *pwgWriter) flush() {
+	if writer.err != nil || writer.repeat == 0 {
+		return
+	}
+
+	buf := &writer.buf
+	buf.Reset()
+	buf.WriteByte(byte(writer.repeat - 1))
+
+	line := writer.prev
+	bpp := writer.bpp
+
+	for len(line) > 0 {
+		// Count repeated pixels
+		n := 1
+		for n < 128 && (n+1)*bpp <= len(line) &&
+			bytes.Equal(line[:bpp], line[n*bpp:(n+1)*bpp]) {
+			n++
+		}
+
+		if n > 1 {
+			buf.WriteByte(byte(n - 1))
+			buf.Write(line[:bpp])
+			line = line[n*bpp:]
+			continue
+		}
+
+		// Count literal pixels, until next repeat
+		n = 1
+		for n < 128 && (n+1)*bpp <= len(line) {
+			if (n+2)*bpp <= len(line) &&
+				bytes.Equal(line[n*bpp:(n+1)*bpp],
+					line[(n+1)*bpp:(n+2)*bpp]) {
+				break
+			}
+			n++
+		}
+
+		if n == 1 {
+			buf.WriteByte(0)
+		} else {
+			buf.WriteByte(byte(257 - n))
+		}
+
+		buf.Write(line[:n*bpp])
+		line = line[n*bpp:]
+	}
+
+	_, err := writer.output.Write(buf.Bytes())
+	if err != nil {
+		writer.err = err
+	}
+
+	writer.repeat = 0
+}
+
+// pwgReadError translates io.EOF into io.ErrUnexpectedEOF,
+// as at the middle of stream the EOF is unexpected.
+func pwgReadError(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
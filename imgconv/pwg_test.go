@@ -0,0 +1,324 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PWG Raster Reader and Writer test
+
+package imgconv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+)
+
+// pwgTestImages contains PNG images, used for PWG tests
+var pwgTestImages = []struct {
+	name string // Image name, for logging
+	data []byte // PNG image data
+}{
+	{"PNG100x75rgb8", testutils.Images.PNG100x75rgb8},
+	{"PNG100x75rgb16", testutils.Images.PNG100x75rgb16},
+	{"PNG100x75gray8", testutils.Images.PNG100x75gray8},
+	{"PNG100x75gray16", testutils.Images.PNG100x75gray16},
+}
+
+// pwgEncodeTestImages encodes all pwgTestImages as pages of
+// the single PWG Raster stream. It returns encoded stream
+// and decoded source images.
+func pwgEncodeTestImages() ([]byte, []image.Image) {
+	buf := &bytes.Buffer{}
+	enc := NewPWGEncoder(buf)
+	images := []image.Image{}
+
+	for _, test := range pwgTestImages {
+		reader, err := NewPNGReader(bytes.NewReader(test.data))
+		if err != nil {
+			panic(err)
+		}
+
+		img, err := decodeImage(reader)
+		reader.Close()
+		if err != nil {
+			panic(err)
+		}
+
+		reader, _ = NewPNGReader(bytes.NewReader(test.data))
+		rows := mustDecodeImageRows(reader)
+		reader.Close()
+
+		wid, hei := reader.Size()
+		writer, err := enc.NewPage(wid, hei, reader.ColorModel(),
+			300, 300)
+		if err != nil {
+			panic(err)
+		}
+
+		mustEncodeImageRows(writer, rows)
+		err = writer.Close()
+		if err != nil {
+			panic(err)
+		}
+
+		images = append(images, img)
+	}
+
+	return buf.Bytes(), images
+}
+
+// TestPWGEncodeDecode tests PWG Raster encoder and decoder
+func TestPWGEncodeDecode(t *testing.T) {
+	data, images := pwgEncodeTestImages()
+
+	dec, err := NewPWGDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewPWGDecoder: %s", err)
+	}
+
+	for i, test := range pwgTestImages {
+		reader, err := dec.Next()
+		if err != nil {
+			t.Fatalf("%s: PWGDecoder.Next: %s", test.name, err)
+		}
+
+		xres, yres := dec.Resolution()
+		if xres != 300 || yres != 300 {
+			t.Errorf("%s: resolution mismatch: %dx%d",
+				test.name, xres, yres)
+		}
+
+		img, err := decodeImage(reader)
+		if err != nil {
+			t.Errorf("%s: decodeImage: %s", test.name, err)
+			continue
+		}
+
+		diff := imageDiff(images[i], img)
+		if diff != "" {
+			t.Errorf("%s: %s", test.name, diff)
+		}
+
+		_, err = reader.Read(reader.NewRow())
+		if err != io.EOF {
+			t.Errorf("%s: Read after end: expected io.EOF, "+
+				"present %v", test.name, err)
+		}
+	}
+
+	_, err = dec.Next()
+	if err != io.EOF {
+		t.Errorf("Next after end: expected io.EOF, present %v", err)
+	}
+}
+
+// TestPWGSkipPage tests skipping of unread pages
+func TestPWGSkipPage(t *testing.T) {
+	data, images := pwgEncodeTestImages()
+
+	dec, err := NewPWGDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewPWGDecoder: %s", err)
+	}
+
+	// Read only one row from the first page
+	reader, err := dec.Next()
+	if err != nil {
+		t.Fatalf("PWGDecoder.Next: %s", err)
+	}
+
+	reader.Read(reader.NewRow())
+
+	// The second page must be decoded correctly
+	reader, err = dec.Next()
+	if err != nil {
+		t.Fatalf("PWGDecoder.Next: %s", err)
+	}
+
+	img, err := decodeImage(reader)
+	if err != nil {
+		t.Fatalf("decodeImage: %s", err)
+	}
+
+	diff := imageDiff(images[1], img)
+	if diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+// TestPWGDecodeBlack tests decoding of 1-bit Black images
+func TestPWGDecodeBlack(t *testing.T) {
+	// Build 10x2 page with the following content:
+	//   line 0: X.X.X.X.XX
+	//   line 1: X.X.X.X.XX
+	var hdr [pwgHeaderSize]byte
+	put := func(off, v int) {
+		binary.BigEndian.PutUint32(hdr[off:], uint32(v))
+	}
+
+	copy(hdr[:], "PwgRaster")
+	put(pwgOffWidth, 10)
+	put(pwgOffHeight, 2)
+	put(pwgOffBitsPerColor, 1)
+	put(pwgOffBitsPerPixel, 1)
+	put(pwgOffBytesPerLine, 2)
+	put(pwgOffColorSpace, pwgColorSpaceBlack)
+	put(pwgOffNumColors, 1)
+
+	data := []byte(pwgSyncWord)
+	data = append(data, hdr[:]...)
+	data = append(data, 1, 255, 0xaa, 0xc0)
+
+	dec, err := NewPWGDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewPWGDecoder: %s", err)
+	}
+
+	reader, err := dec.Next()
+	if err != nil {
+		t.Fatalf("PWGDecoder.Next: %s", err)
+	}
+
+	img, err := decodeImage(reader)
+	if err != nil {
+		t.Fatalf("decodeImage: %s", err)
+	}
+
+	expected := image.NewGray(image.Rect(0, 0, 10, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 10; x++ {
+			v := uint8(0xff)
+			if x%2 == 0 || x == 9 {
+				v = 0
+			}
+			expected.SetGray(x, y, color.Gray{v})
+		}
+	}
+
+	diff := imageDiff(expected, img)
+	if diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+// TestPWGDecodeErrors tests PWG Raster decoder errors handling
+func TestPWGDecodeErrors(t *testing.T) {
+	data, _ := pwgEncodeTestImages()
+
+	// Invalid synchronization word
+	_, err := NewPWGDecoder(bytes.NewReader([]byte("RaS3")))
+	if err == nil {
+		t.Errorf("invalid sync word: error not detected")
+	}
+
+	// Truncated data
+	for trunc := 0; trunc < len(data); trunc += 101 {
+		err := pwgDecodeAll(bytes.NewReader(data[:trunc]))
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("truncated at %d:\n"+
+				"error expected: %s\n"+
+				"error present:  %v\n",
+				trunc, io.ErrUnexpectedEOF, err)
+			break
+		}
+	}
+
+	// I/O errors
+	expectedErr := errors.New("I/O error, for testing")
+	for off := 0; off < len(data); off += 101 {
+		rd := newIoReaderWithError(data[:off], expectedErr)
+		err := pwgDecodeAll(rd)
+		if err != expectedErr {
+			t.Errorf("I/O error at %d:\n"+
+				"error expected: %s\n"+
+				"error present:  %v\n",
+				off, expectedErr, err)
+			break
+		}
+	}
+}
+
+// TestPWGEncodeErrors tests PWG Raster encoder errors handling
+func TestPWGEncodeErrors(t *testing.T) {
+	enc := NewPWGEncoder(io.Discard)
+
+	// Unsupported color model
+	_, err := enc.NewPage(100, 75, color.CMYKModel, 300, 300)
+	if err == nil {
+		t.Errorf("unsupported color model: error not detected")
+	}
+
+	// Invalid resolution
+	_, err = enc.NewPage(100, 75, color.GrayModel, 0, 300)
+	if err == nil {
+		t.Errorf("invalid resolution: error not detected")
+	}
+
+	// Previous page is not closed
+	_, err = enc.NewPage(100, 75, color.GrayModel, 300, 300)
+	if err != nil {
+		t.Fatalf("NewPage: %s", err)
+	}
+
+	_, err = enc.NewPage(100, 75, color.GrayModel, 300, 300)
+	if err == nil {
+		t.Errorf("page not closed: error not detected")
+	}
+
+	// I/O errors
+	reader, err := NewPNGReader(
+		bytes.NewReader(testutils.Images.PNG100x75rgb8))
+	if err != nil {
+		panic(err)
+	}
+
+	rows := mustDecodeImageRows(reader)
+	reader.Close()
+
+	expectedErr := errors.New("I/O error, for testing")
+	for lim := 0; lim < 8192; lim += 256 {
+		w := newIoWriterWithError(io.Discard, lim, expectedErr)
+		enc := NewPWGEncoder(w)
+		writer, err := enc.NewPage(100, 75, color.RGBAModel, 300, 300)
+		if err == nil {
+			encodeImageRows(writer, rows)
+			err = writer.Close()
+		}
+
+		if err != expectedErr {
+			t.Errorf("I/O error at %d:\n"+
+				"error expected: %s\n"+
+				"error present:  %v\n",
+				lim, expectedErr, err)
+			break
+		}
+	}
+}
+
+// pwgDecodeAll decodes all pages of the PWG Raster stream
+// and returns the first error.
+func pwgDecodeAll(input io.Reader) error {
+	dec, err := NewPWGDecoder(input)
+	if err != nil {
+		return err
+	}
+
+	for {
+		reader, err := dec.Next()
+		if err != nil {
+			return err
+		}
+
+		_, err = decodeImage(reader)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// TIFF Reader and Writer
+
+package imgconv
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/OpenPrinting/go-mfp/util/generic"
+	"golang.org/x/image/tiff"
+)
+
+// tiffReader implements the [Reader] interface for reading TIFF images.
+//
+// TIFF is not a streaming format (strips and tiles may be placed
+// anywhere in the file), so the whole image is decoded at once
+// and then returned row by row.
+type tiffReader struct {
+	img      image.Image // Decoded image
+	model    color.Model // Image color mode
+	wid, hei int         // Image size
+	y        int         // Current y-coordinate
+	err      error       // Sticky error
+}
+
+// NewTIFFReader creates a new [Reader] for TIFF images.
+//
+// Only the first image of the multi-image TIFF file is decoded.
+//
+// Gray and Gray16 images are returned with the color.GrayModel
+// and color.Gray16Model, 16-bit RGB images are returned with the
+// color.RGBA64Model and all other images (8-bit RGB, paletted,
+// CMYK) are returned with the color.RGBAModel.
+func NewTIFFReader(input io.Reader) (Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := tiff.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	reader := &tiffReader{
+		img: img,
+		wid: bounds.Dx(),
+		hei: bounds.Dy(),
+	}
+
+	switch img.ColorModel() {
+	case color.GrayModel:
+		reader.model = color.GrayModel
+	case color.Gray16Model:
+		reader.model = color.Gray16Model
+	case color.RGBA64Model, color.NRGBA64Model:
+		reader.model = color.RGBA64Model
+	default:
+		reader.model = color.RGBAModel
+	}
+
+	return reader, nil
+}
+
+// Close closes the reader.
+func (reader *tiffReader) Close() {
+	reader.img = nil
+}
+
+// ColorModel returns the [color.Model] of image being decoded.
+func (reader *tiffReader) ColorModel() color.Model {
+	return reader.model
+}
+
+// Size returns the image size.
+func (reader *tiffReader) Size() (wid, hei int) {
+	return reader.wid, reader.hei
+}
+
+// NewRow allocates a [Row] of the appropriate type and width for
+// use with the [Reader.Read] function.
+func (reader *tiffReader) NewRow() Row {
+	return NewRow(reader.model, reader.wid)
+}
+
+// Read returns the next image [Row].
+func (reader *tiffReader) Read(row Row) (int, error) {
+	if reader.err != nil {
+		return 0, reader.err
+	}
+
+	wid := generic.Min(row.Width(), reader.wid)
+	bounds := reader.img.Bounds()
+	y := bounds.Min.Y + reader.y
+
+	for x := 0; x < wid; x++ {
+		row.Set(x, reader.img.At(bounds.Min.X+x, y))
+	}
+
+	reader.y++
+	if reader.y == reader.hei {
+		reader.err = io.EOF
+	}
+
+	return wid, nil
+}
+
+// tiffImage is the image.Image that can be modified
+type tiffImage interface {
+	image.Image
+	Set(x, y int, c color.Color)
+}
+
+// tiffWriter implements the [Writer] interface for writing TIFF images.
+//
+// The image is accumulated in memory and actually encoded
+// when Writer is closed.
+type tiffWriter struct {
+	output   io.Writer   // Underlying io.Writer
+	img      tiffImage   // Image being accumulated
+	wid, hei int         // Image size
+	model    color.Model // Color model
+	y        int         // Current y-coordinate
+	closed   bool        // Writer is closed
+}
+
+// NewTIFFWriter creates a new [Writer] for TIFF images.
+//
+// Supported color models are following:
+//   - color.GrayModel
+//   - color.Gray16Model
+//   - color.RGBAModel
+//   - color.RGBA64Model
+//
+// Images are written with the Deflate compression.
+func NewTIFFWriter(output io.Writer,
+	wid, hei int, model color.Model) (Writer, error) {
+
+	writer := &tiffWriter{
+		output: output,
+		wid:    wid,
+		hei:    hei,
+		model:  model,
+	}
+
+	rect := image.Rect(0, 0, wid, hei)
+
+	switch model {
+	case color.GrayModel:
+		writer.img = image.NewGray(rect)
+	case color.Gray16Model:
+		writer.img = image.NewGray16(rect)
+	case color.RGBAModel:
+		writer.img = image.NewRGBA(rect)
+	case color.RGBA64Model:
+		writer.img = image.NewRGBA64(rect)
+	default:
+		err := errors.New("TIFF: unsupported color model")
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// Size returns the image size.
+func (writer *tiffWriter) Size() (wid, hei int) {
+	return writer.wid, writer.hei
+}
+
+// ColorModel returns the [color.Model] of image being written.
+func (writer *tiffWriter) ColorModel() color.Model {
+	return writer.model
+}
+
+// Write writes the next image [Row].
+func (writer *tiffWriter) Write(row Row) error {
+	// Silently ignore excessive rows
+	if writer.y == writer.hei {
+		return nil
+	}
+
+	wid := generic.Min(row.Width(), writer.wid)
+	for x := 0; x < wid; x++ {
+		writer.img.Set(x, writer.y, row.At(x))
+	}
+
+	for x := wid; x < writer.wid; x++ {
+		writer.img.Set(x, writer.y, color.White)
+	}
+
+	writer.y++
+
+	return nil
+}
+
+// Close encodes the accumulated image and writes it to the output.
+func (writer *tiffWriter) Close() error {
+	if writer.closed {
+		return nil
+	}
+
+	writer.closed = true
+
+	// Write missed rows
+	for writer.y < writer.hei {
+		writer.Write(RowEmpty{})
+	}
+
+	opts := &tiff.Options{Compression: tiff.Deflate}
+	return tiff.Encode(writer.output, writer.img, opts)
+}
@@ -0,0 +1,184 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Abstract definition for printer and scanner interfaces
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// TIFF Reader and Writer test
+
+package imgconv
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/util/generic"
+)
+
+// TestTIFFEncodeDecode tests TIFF writer and reader
+func TestTIFFEncodeDecode(t *testing.T) {
+	type testData struct {
+		name string // Image name, for logging
+		data []byte // Source PNG image data
+	}
+
+	tests := []testData{
+		{"PNG100x75rgb8", testutils.Images.PNG100x75rgb8},
+		{"PNG100x75rgb16", testutils.Images.PNG100x75rgb16},
+		{"PNG100x75gray8", testutils.Images.PNG100x75gray8},
+		{"PNG100x75gray16", testutils.Images.PNG100x75gray16},
+	}
+
+	for _, test := range tests {
+		reader, err := NewPNGReader(bytes.NewReader(test.data))
+		if err != nil {
+			panic(err)
+		}
+
+		reference, err := decodeImage(reader)
+		reader.Close()
+		if err != nil {
+			panic(err)
+		}
+
+		// Encode the image
+		reader, _ = NewPNGReader(bytes.NewReader(test.data))
+		rows := mustDecodeImageRows(reader)
+		reader.Close()
+
+		buf := &bytes.Buffer{}
+		wid, hei := reader.Size()
+		model := reader.ColorModel()
+		writer, err := NewTIFFWriter(buf, wid, hei, model)
+		if err != nil {
+			t.Errorf("%s: NewTIFFWriter: %s", test.name, err)
+			continue
+		}
+
+		mustEncodeImageRows(writer, rows)
+		err = writer.Close()
+		if err != nil {
+			t.Errorf("%s: Writer.Close: %s", test.name, err)
+			continue
+		}
+
+		// Decode and compare
+		reader, err = NewTIFFReader(buf)
+		if err != nil {
+			t.Errorf("%s: NewTIFFReader: %s", test.name, err)
+			continue
+		}
+
+		if reader.ColorModel() != model {
+			t.Errorf("%s: Reader.ColorModel mismatch", test.name)
+		}
+
+		img, err := decodeImage(reader)
+		if err != nil {
+			t.Errorf("%s: decodeImage: %s", test.name, err)
+			continue
+		}
+
+		diff := imageDiff(reference, img)
+		if diff != "" {
+			t.Errorf("%s: %s", test.name, diff)
+		}
+
+		// Read after the last line must return io.EOF
+		_, err = reader.Read(reader.NewRow())
+		if err != io.EOF {
+			t.Errorf("%s: Read after end: expected io.EOF, "+
+				"present %v", test.name, err)
+		}
+
+		reader.Close()
+	}
+}
+
+// TestTIFFDecode tests TIFF reader with the sample image
+func TestTIFFDecode(t *testing.T) {
+	reader, err := NewPNGReader(
+		bytes.NewReader(testutils.Images.PNG100x75rgb8))
+	if err != nil {
+		panic(err)
+	}
+
+	reference, err := decodeImage(reader)
+	reader.Close()
+	if err != nil {
+		panic(err)
+	}
+
+	reader, err = NewTIFFReader(
+		bytes.NewReader(testutils.Images.TIFF100x75))
+	if err != nil {
+		t.Fatalf("NewTIFFReader: %s", err)
+	}
+
+	img, err := decodeImage(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatalf("decodeImage: %s", err)
+	}
+
+	diff := imageDiff(reference, img)
+	if diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+// TestTIFFErrors tests TIFF reader and writer errors handling
+func TestTIFFErrors(t *testing.T) {
+	// Unsupported color model
+	_, err := NewTIFFWriter(io.Discard, 100, 75, color.CMYKModel)
+	if err == nil {
+		t.Errorf("unsupported color model: error not detected")
+	}
+
+	// Encode the test image
+	buf := &bytes.Buffer{}
+	writer, err := NewTIFFWriter(buf, 100, 75, color.GrayModel)
+	if err != nil {
+		panic(err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		panic(err)
+	}
+
+	data := buf.Bytes()
+
+	// Damaged header
+	damaged := generic.CopySlice(data)
+	damaged[0] = ^damaged[0]
+	_, err = NewTIFFReader(bytes.NewReader(damaged))
+	if err == nil {
+		t.Errorf("damaged header: error not detected")
+	}
+
+	// I/O errors
+	expectedErr := errors.New("I/O error, for testing")
+	rd := newIoReaderWithError(data[:len(data)/2], expectedErr)
+	_, err = NewTIFFReader(rd)
+	if err != expectedErr {
+		t.Errorf("I/O error:\n"+
+			"error expected: %s\n"+
+			"error present:  %v\n",
+			expectedErr, err)
+	}
+
+	w := newIoWriterWithError(io.Discard, 0, expectedErr)
+	writer, _ = NewTIFFWriter(w, 100, 75, color.GrayModel)
+	err = writer.Close()
+	if err != expectedErr {
+		t.Errorf("I/O error:\n"+
+			"error expected: %s\n"+
+			"error present:  %v\n",
+			expectedErr, err)
+	}
+}
@@ -0,0 +1,88 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Common options for commands
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Common options and logging setup
+
+package cmdopt
+
+import (
+	"context"
+	"slices"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/internal/output"
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+var (
+	// Debug is the -d/--debug option.
+	Debug = argv.Option{
+		Name:       "-d",
+		Aliases:    []string{"--debug"},
+		Help:       "Enable debug output",
+		Persistent: true,
+	}
+
+	// Verbose is the -v/--verbose option.
+	Verbose = argv.Option{
+		Name:       "-v",
+		Aliases:    []string{"--verbose"},
+		Help:       "Enable verbose debug output",
+		Persistent: true,
+	}
+
+	// Options contains all common options, to be added to the
+	// root command.
+	Options = []argv.Option{Debug, Verbose, output.Option}
+)
+
+// Root returns the copy of the Command with the common [Options]
+// added. It is used to run the command as the standalone program.
+//
+// Like with the inherited options, Command's own options take
+// precedence, so common options with the same name are skipped.
+func Root(cmd argv.Command) *argv.Command {
+	own := make(map[string]struct{})
+	for _, opt := range cmd.Options {
+		for _, name := range append([]string{opt.Name}, opt.Aliases...) {
+			own[name] = struct{}{}
+		}
+	}
+
+	var opts []argv.Option
+	for _, opt := range Options {
+		if _, found := own[opt.Name]; !found {
+			opts = append(opts, opt)
+		}
+	}
+
+	cmd.Options = slices.Concat(opts, cmd.Options)
+	return &cmd
+}
+
+// LogLevel returns the logging level, requested by the -d
+// and -v options.
+func LogLevel(inv *argv.Invocation) log.Level {
+	_, dbg := inv.Get(Debug.Name)
+	_, vrb := inv.Get(Verbose.Name)
+
+	level := log.LevelInfo
+	if dbg {
+		level = log.LevelDebug
+	}
+	if vrb {
+		level = log.LevelTrace
+	}
+
+	return level
+}
+
+// LogContext returns the new [context.Context] with the console
+// logger, configured according to the -d and -v options.
+func LogContext(ctx context.Context, inv *argv.Invocation) context.Context {
+	logger := log.NewLogger(LogLevel(inv), log.Console)
+	return log.NewContext(ctx, logger)
+}
@@ -0,0 +1,97 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Common options for commands
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Common options test
+
+package cmdopt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// TestRoot tests Root
+func TestRoot(t *testing.T) {
+	names := func(cmd *argv.Command) []string {
+		var s []string
+		for _, opt := range cmd.Options {
+			s = append(s, opt.Name)
+		}
+		return s
+	}
+
+	cmd := argv.Command{
+		Name:    "test",
+		Options: []argv.Option{argv.HelpOption},
+	}
+
+	expected := []string{"-d", "-v", "--format", "-h"}
+	present := names(Root(cmd))
+	if !reflect.DeepEqual(expected, present) {
+		t.Errorf("Root:\nexpected: %v\npresent:  %v", expected, present)
+	}
+
+	if len(cmd.Options) != 1 {
+		t.Errorf("Root: original Command modified")
+	}
+
+	// Own options take precedence
+	cmd.Options = append(cmd.Options, argv.Option{
+		Name:     "-f",
+		Aliases:  []string{"--format"},
+		Validate: argv.ValidateAny,
+	})
+
+	expected = []string{"-d", "-v", "-h", "-f"}
+	present = names(Root(cmd))
+	if !reflect.DeepEqual(expected, present) {
+		t.Errorf("Root:\nexpected: %v\npresent:  %v", expected, present)
+	}
+}
+
+// TestLogLevel tests LogLevel
+func TestLogLevel(t *testing.T) {
+	type testData struct {
+		argv   []string
+		expect log.Level
+	}
+
+	tests := []testData{
+		{[]string{"sub"}, log.LevelInfo},
+		{[]string{"-d", "sub"}, log.LevelDebug},
+		{[]string{"-v", "sub"}, log.LevelTrace},
+		{[]string{"--debug", "sub", "--verbose"}, log.LevelTrace},
+		{[]string{"sub", "-d"}, log.LevelDebug},
+	}
+
+	sub := argv.Command{Name: "sub"}
+	cmd := Root(argv.Command{
+		Name:        "test",
+		SubCommands: []argv.Command{sub},
+	})
+
+	for _, test := range tests {
+		inv, err := cmd.Parse(test.argv)
+		if err == nil {
+			if subcmd, subargv := inv.SubCommand(); subcmd != nil {
+				inv, err = subcmd.ParseWithParent(inv, subargv)
+			}
+		}
+
+		if err != nil {
+			t.Errorf("%q: %s", test.argv, err)
+			continue
+		}
+
+		if level := LogLevel(inv); level != test.expect {
+			t.Errorf("%q: expected %v, present %v",
+				test.argv, test.expect, level)
+		}
+	}
+}
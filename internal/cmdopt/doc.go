@@ -0,0 +1,24 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Common options for commands
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Package documentation
+
+/*
+Package cmdopt contains options, common for all commands.
+
+These options are defined once, at the root command, as Persistent
+options (see [argv.Option]), so they are accepted by every command
+and sub-command:
+
+  - -d, --debug   - enable debug output
+  - -v, --verbose - enable verbose debug output
+  - --format      - output format (see the output package)
+
+The "mfp" universal command defines them at its top level.
+Standalone commands (i.e., "mfp-cups") use [Root] to get the
+same options.
+*/
+package cmdopt
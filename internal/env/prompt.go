@@ -0,0 +1,88 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Execution environment
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Interactive prompt
+
+package env
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNotInteractive is returned by [Prompt], if standard input
+// is not a terminal.
+var ErrNotInteractive = errors.New("not an interactive terminal")
+
+// Prompt asks user a question and waits for answer.
+//
+// The answer must be one of choices or its first letter,
+// case-insensitive. Question is repeated until valid answer
+// is received. It returns the matched choice.
+//
+// If stdin is not a terminal, it returns [ErrNotInteractive].
+// If ctx is canceled while waiting, it returns ctx.Err().
+func Prompt(ctx context.Context, question string,
+	choices []string) (string, error) {
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", ErrNotInteractive
+	}
+
+	hints := make([]string, len(choices))
+	for i, choice := range choices {
+		hints[i] = choice[:1]
+	}
+
+	// Read answers in a separate goroutine, so ctx can
+	// interrupt waiting.
+	lines := make(chan string)
+	go func() {
+		rd := bufio.NewReader(os.Stdin)
+		for {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				close(lines)
+				return
+			}
+
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question,
+			strings.Join(hints, "/"))
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(os.Stderr, "\n")
+			return "", ctx.Err()
+
+		case line, ok := <-lines:
+			if !ok {
+				return "", ErrNotInteractive
+			}
+
+			answer := strings.ToLower(strings.TrimSpace(line))
+			for _, choice := range choices {
+				if answer == choice || answer == choice[:1] {
+					return choice, nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Output formatting for commands
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Package documentation
+
+/*
+Package output implements the common output rendering for commands.
+
+Listing commands (like "cups get-printers" or "discover") produce
+a slice of records, where each record is a structure with the `json`
+tags. The output package renders these records according to the
+--format option:
+
+  - "text" - the command-specific human-readable output (default)
+  - "table" - a table with a column per scalar field of the record
+  - "json" - JSON, using the records' `json` tags as the schema
+  - "yaml" - YAML, using the same schema as for JSON
+
+JSON field names, defined by records, are considered to be the
+stable output schema and should not be changed without a reason.
+*/
+package output
@@ -0,0 +1,69 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Output formatting for commands
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Output formats and the --format option
+
+package output
+
+import (
+	"fmt"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+)
+
+// Format is the output format.
+type Format int
+
+// Output formats:
+const (
+	FormatText  Format = iota // Command-specific text (default)
+	FormatTable               // Table
+	FormatJSON                // JSON
+	FormatYAML                // YAML
+)
+
+// formatNames contains names of all formats
+var formatNames = []string{"text", "table", "json", "yaml"}
+
+// String returns the Format name.
+func (f Format) String() string {
+	if 0 <= int(f) && int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+
+	return fmt.Sprintf("unknown (%d)", int(f))
+}
+
+// ParseFormat parses Format name.
+func ParseFormat(s string) (Format, error) {
+	for i, name := range formatNames {
+		if s == name {
+			return Format(i), nil
+		}
+	}
+
+	return FormatText, fmt.Errorf("%q: unknown output format", s)
+}
+
+// Option is the --format option, common for all listing commands.
+//
+// It is Persistent and defined at the root command, so it is
+// accepted by every command.
+var Option = argv.Option{
+	Name:       "--format",
+	Help:       "Output format (default: text)",
+	HelpArg:    "text|table|json|yaml",
+	Validate:   argv.ValidateStrings(formatNames),
+	Complete:   argv.CompleteStrings(formatNames),
+	Persistent: true,
+}
+
+// OptionGet returns the --format option value.
+func OptionGet(inv *argv.Invocation) Format {
+	opt, _ := inv.Get(Option.Name)
+	f, _ := ParseFormat(opt)
+	return f
+}
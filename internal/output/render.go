@@ -0,0 +1,364 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Output formatting for commands
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Output rendering
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Render writes records into w in the requested format.
+//
+// Records are expected to be a slice of structures, suitable for
+// JSON encoding. Their `json` tags define the output schema for
+// all formats except FormatText.
+//
+// For FormatText the text callback is called to produce
+// the command-specific output.
+func Render(w io.Writer, f Format, records any, text func(io.Writer)) error {
+	if f == FormatText {
+		text(w)
+		return nil
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	if f == FormatJSON {
+		buf := &bytes.Buffer{}
+		json.Indent(buf, data, "", "  ")
+		buf.WriteByte('\n')
+		_, err = w.Write(buf.Bytes())
+		return err
+	}
+
+	// Decode JSON into the generic, order-preserving, representation
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	root, err := decodeNode(dec)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+
+	switch f {
+	case FormatTable:
+		renderTable(buf, root)
+	case FormatYAML:
+		renderYAML(buf, root, 0, false)
+	default:
+		return fmt.Errorf("%s: unsupported output format", f)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// object is the order-preserving representation of the JSON object.
+type object struct {
+	keys []string // Keys, in order of appearance
+	vals []any    // Values, by keys index
+}
+
+// decodeNode decodes the next JSON value from the json.Decoder.
+//
+// Objects are decoded into the *object, arrays into the []any,
+// and scalars into string, json.Number, bool or nil.
+func decodeNode(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := &object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			val, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			obj.keys = append(obj.keys, key.(string))
+			obj.vals = append(obj.vals, val)
+		}
+
+		_, err = dec.Token()
+		return obj, err
+
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			val, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			arr = append(arr, val)
+		}
+
+		_, err = dec.Token()
+		return arr, err
+	}
+
+	return tok, nil
+}
+
+// isComplex reports if node is a non-empty object or array.
+func isComplex(node any) bool {
+	switch v := node.(type) {
+	case *object:
+		return len(v.keys) != 0
+	case []any:
+		return len(v) != 0
+	}
+
+	return false
+}
+
+// renderTable renders the root node as a table.
+//
+// Each element of the root array becomes a row. Scalar fields
+// and arrays of scalars become columns, other fields are skipped.
+func renderTable(buf *bytes.Buffer, root any) {
+	rows, ok := root.([]any)
+	if !ok {
+		rows = []any{root}
+	}
+
+	// Collect columns
+	var columns []string
+	seen := make(map[string]bool) // true for usable columns
+
+	for _, row := range rows {
+		obj, ok := row.(*object)
+		if !ok {
+			continue
+		}
+
+		for i, key := range obj.keys {
+			usable, found := seen[key]
+			if !found {
+				columns = append(columns, key)
+				usable = true
+			}
+
+			seen[key] = usable && tableCell(obj.vals[i]) != nil
+		}
+	}
+
+	// Render the table
+	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	hdr := []string{}
+	for _, col := range columns {
+		if seen[col] {
+			hdr = append(hdr, strings.ToUpper(col))
+		}
+	}
+
+	fmt.Fprintf(tw, "%s\n", strings.Join(hdr, "\t"))
+
+	for _, row := range rows {
+		obj, _ := row.(*object)
+		if obj == nil {
+			obj = &object{}
+		}
+
+		cells := []string{}
+		for _, col := range columns {
+			if !seen[col] {
+				continue
+			}
+
+			cell := ""
+			for i, key := range obj.keys {
+				if key == col {
+					cell = *tableCell(obj.vals[i])
+				}
+			}
+
+			cells = append(cells, cell)
+		}
+
+		fmt.Fprintf(tw, "%s\n", strings.Join(cells, "\t"))
+	}
+
+	tw.Flush()
+}
+
+// tableCell returns text of the table cell for the node.
+// If node cannot be represented as a table cell, it returns nil.
+func tableCell(node any) *string {
+	var s string
+
+	switch v := node.(type) {
+	case nil:
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	case bool:
+		s = strconv.FormatBool(v)
+	case []any:
+		items := make([]string, len(v))
+		for i := range v {
+			if isComplex(v[i]) {
+				return nil
+			}
+
+			item := tableCell(v[i])
+			if item == nil {
+				return nil
+			}
+
+			items[i] = *item
+		}
+		s = strings.Join(items, ",")
+	default:
+		if isComplex(node) {
+			return nil
+		}
+	}
+
+	return &s
+}
+
+// renderYAML renders node as YAML block with the specified indentation.
+//
+// If nopad is true, the first line of the block is not indented,
+// as the caller already written the sequence entry indicator ("- ").
+func renderYAML(buf *bytes.Buffer, node any, indent int, nopad bool) {
+	pad := func(first bool) {
+		if !(first && nopad) {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+	}
+
+	switch v := node.(type) {
+	case *object:
+		if len(v.keys) == 0 {
+			pad(true)
+			buf.WriteString("{}\n")
+			return
+		}
+
+		for i, key := range v.keys {
+			pad(i == 0)
+			buf.WriteString(yamlScalar(key))
+			buf.WriteString(":")
+
+			val := v.vals[i]
+			if isComplex(val) {
+				buf.WriteString("\n")
+				renderYAML(buf, val, indent+2, false)
+			} else {
+				buf.WriteString(" ")
+				buf.WriteString(yamlScalar(val))
+				buf.WriteString("\n")
+			}
+		}
+
+	case []any:
+		if len(v) == 0 {
+			pad(true)
+			buf.WriteString("[]\n")
+			return
+		}
+
+		for i, val := range v {
+			pad(i == 0)
+			buf.WriteString("- ")
+
+			if isComplex(val) {
+				renderYAML(buf, val, indent+2, true)
+			} else {
+				buf.WriteString(yamlScalar(val))
+				buf.WriteString("\n")
+			}
+		}
+
+	default:
+		pad(true)
+		buf.WriteString(yamlScalar(node))
+		buf.WriteString("\n")
+	}
+}
+
+// yamlScalar returns YAML representation of the scalar node.
+// Empty objects and arrays are considered scalars here.
+func yamlScalar(node any) string {
+	switch v := node.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case *object:
+		return "{}"
+	case []any:
+		return "[]"
+	case string:
+		if yamlIsPlain(v) {
+			return v
+		}
+		return strconv.Quote(v)
+	}
+
+	return fmt.Sprint(node)
+}
+
+// yamlIsPlain reports if string can be written as YAML
+// plain (unquoted) scalar without change of its meaning.
+func yamlIsPlain(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+
+	switch strings.ToLower(s) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off":
+		return false
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return false
+	}
+
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.HasSuffix(s, ":") {
+		return false
+	}
+
+	for _, c := range s {
+		if c < ' ' || c == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,149 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Output formatting for commands
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Output rendering test
+
+package output
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// TestRender tests Render
+func TestRender(t *testing.T) {
+	type unit struct {
+		Proto     string   `json:"proto"`
+		Endpoints []string `json:"endpoints"`
+	}
+
+	type record struct {
+		Name   string   `json:"name"`
+		ID     int      `json:"id"`
+		Shared bool     `json:"shared"`
+		Addrs  []string `json:"addrs"`
+		Units  []unit   `json:"units"`
+	}
+
+	records := []record{
+		{
+			Name:   "Printer",
+			ID:     1,
+			Shared: true,
+			Addrs:  []string{"192.168.0.1", "fe80::1"},
+			Units: []unit{
+				{"IPP", []string{"ipp://192.168.0.1/ipp/print"}},
+			},
+		},
+		{
+			Name:  "yes",
+			ID:    2,
+			Addrs: []string{},
+		},
+	}
+
+	tests := []struct {
+		format Format
+		output string
+	}{
+		{
+			format: FormatText,
+			output: "text\n",
+		},
+
+		{
+			format: FormatTable,
+			output: "" +
+				"NAME     ID  SHARED  ADDRS\n" +
+				"Printer  1   true    192.168.0.1,fe80::1\n" +
+				"yes      2   false   \n",
+		},
+
+		{
+			format: FormatJSON,
+			output: `[
+  {
+    "name": "Printer",
+    "id": 1,
+    "shared": true,
+    "addrs": [
+      "192.168.0.1",
+      "fe80::1"
+    ],
+    "units": [
+      {
+        "proto": "IPP",
+        "endpoints": [
+          "ipp://192.168.0.1/ipp/print"
+        ]
+      }
+    ]
+  },
+  {
+    "name": "yes",
+    "id": 2,
+    "shared": false,
+    "addrs": [],
+    "units": null
+  }
+]
+`,
+		},
+
+		{
+			format: FormatYAML,
+			output: `- name: Printer
+  id: 1
+  shared: true
+  addrs:
+    - 192.168.0.1
+    - fe80::1
+  units:
+    - proto: IPP
+      endpoints:
+        - ipp://192.168.0.1/ipp/print
+- name: "yes"
+  id: 2
+  shared: false
+  addrs: []
+  units: null
+`,
+		},
+	}
+
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		err := Render(buf, test.format, records, func(w io.Writer) {
+			w.Write([]byte("text\n"))
+		})
+
+		if err != nil {
+			t.Errorf("%s: %s", test.format, err)
+			continue
+		}
+
+		if buf.String() != test.output {
+			t.Errorf("%s: output mismatch:\n"+
+				"expected:\n%s\npresent:\n%s",
+				test.format, test.output, buf.String())
+		}
+	}
+}
+
+// TestParseFormat tests ParseFormat
+func TestParseFormat(t *testing.T) {
+	for _, name := range formatNames {
+		f, err := ParseFormat(name)
+		if err != nil || f.String() != name {
+			t.Errorf("%q: parsed as %s (%v)", name, f, err)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("%q: error not reported", "xml")
+	}
+}
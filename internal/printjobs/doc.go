@@ -0,0 +1,16 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Print jobs tracker
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Package documentation
+
+// Package printjobs implements the protocol-independent print jobs
+// tracking on a top of the [abstract.Printer].
+//
+// It creates jobs, passes their documents to the printer, one at
+// a time, keeps the job status, aborts abandoned jobs and keeps
+// the limited history of finished jobs. Protocol servers (IPP,
+// WS-Print) translate the job [Status] into their own terms.
+package printjobs
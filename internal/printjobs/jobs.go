@@ -0,0 +1,327 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Print jobs tracker
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Print jobs tracker
+
+package printjobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// Jobs parameters:
+const (
+	// History is the number of finished jobs, kept by the
+	// Jobs, so clients can query their final status.
+	History = 10
+
+	// Timeout is the inactivity timeout of the print job.
+	// Abandoned job, that doesn't receive documents for this
+	// time, is aborted.
+	Timeout = 2 * time.Minute
+)
+
+// Errors, returned by the Jobs methods:
+var (
+	ErrNotAccepting = errors.New("job is not accepting documents")
+	ErrFinished     = errors.New("job is already finished")
+)
+
+// Jobs tracks print jobs, printed on the [abstract.Printer].
+//
+// Printer prints one document at a time. The job is completed,
+// when its last document is printed.
+//
+// T is the protocol-specific job data, attached to each job.
+type Jobs[T any] struct {
+	ctx       context.Context  // Logging context
+	proto     string           // Protocol name, for logging
+	printer   abstract.Printer // Underlying printer
+	jobs      []*Job[T]        // Active and finished jobs
+	nextID    int              // Next job ID
+	lock      sync.Mutex       // Access lock
+	printLock sync.Mutex       // Serializes Printer.Print
+}
+
+// Job represents the print job.
+type Job[T any] struct {
+	Data    T                       // Protocol-specific data
+	Request abstract.PrinterRequest // Job parameters
+
+	jobs    *Jobs[T]           // Jobs the job belongs to
+	status  Status             // Job status
+	ctx     context.Context    // Job context
+	cancel  context.CancelFunc // Cancels the job context
+	last    bool               // Last document received
+	touched time.Time          // Time of the last activity
+}
+
+// Status is the snapshot of the job status.
+type Status struct {
+	ID         int       // Job ID
+	State      State     // Job state
+	Reason     Reason    // Job state reason
+	Documents  int       // Documents printed
+	Octets     int64     // Document bytes processed
+	Created    time.Time // Job creation time
+	Processing time.Time // Processing start time, zero if none
+	Finished   time.Time // Job finish time, zero if none
+}
+
+// document is the [abstract.DocumentFile], passed to the
+// [abstract.Printer]. It counts consumed bytes.
+type document struct {
+	format string    // Document format
+	body   io.Reader // Document data
+	count  int64     // Bytes consumed
+}
+
+// New creates a new [Jobs] on a top of the [abstract.Printer].
+// The proto is the protocol name, used for logging.
+func New[T any](ctx context.Context, proto string,
+	printer abstract.Printer) *Jobs[T] {
+
+	return &Jobs[T]{
+		ctx:     ctx,
+		proto:   proto,
+		printer: printer,
+		nextID:  1,
+	}
+}
+
+// Close cancels all active jobs.
+func (jobs *Jobs[T]) Close() {
+	jobs.lock.Lock()
+	defer jobs.lock.Unlock()
+
+	for _, job := range jobs.jobs {
+		if !job.status.State.IsFinal() {
+			jobs.finish(job, Canceled, ReasonCanceledAtDevice)
+		}
+	}
+}
+
+// Create creates a new job with the specified parameters.
+// The request must be validated by the caller.
+func (jobs *Jobs[T]) Create(req abstract.PrinterRequest, data T) *Job[T] {
+	jobs.lock.Lock()
+	defer jobs.lock.Unlock()
+
+	jobs.expire()
+
+	ctx, cancel := context.WithCancel(jobs.ctx)
+	now := time.Now()
+	job := &Job[T]{
+		Data:    data,
+		Request: req,
+		jobs:    jobs,
+		status: Status{
+			ID:      jobs.nextID,
+			State:   Pending,
+			Created: now,
+		},
+		ctx:     ctx,
+		cancel:  cancel,
+		touched: now,
+	}
+
+	jobs.nextID++
+	jobs.jobs = append(jobs.jobs, job)
+
+	log.Debug(jobs.ctx, "%s: job %d created", jobs.proto, job.status.ID)
+
+	return job
+}
+
+// Lookup returns the job by its ID, or nil if job is not found.
+func (jobs *Jobs[T]) Lookup(id int) *Job[T] {
+	jobs.lock.Lock()
+	defer jobs.lock.Unlock()
+
+	for _, job := range jobs.jobs {
+		if job.status.ID == id {
+			return job
+		}
+	}
+
+	return nil
+}
+
+// List returns all known jobs, active and finished, in the order
+// of creation.
+func (jobs *Jobs[T]) List() []*Job[T] {
+	jobs.lock.Lock()
+	defer jobs.lock.Unlock()
+
+	jobs.expire()
+
+	list := make([]*Job[T], len(jobs.jobs))
+	copy(list, jobs.jobs)
+
+	return list
+}
+
+// Print prints the next document of the job and updates the job
+// status. The document format must be validated by the caller.
+// Empty format means application/octet-stream.
+//
+// If last is true, the job is completed when the document is
+// printed. If job doesn't accept documents anymore, it returns
+// [ErrNotAccepting]. Errors, returned by the [abstract.Printer],
+// abort the job and returned as is.
+func (jobs *Jobs[T]) Print(job *Job[T], format string, body io.Reader,
+	last bool) error {
+
+	req := job.Request
+	req.DocumentFormat = format
+	if req.DocumentFormat == "" {
+		req.DocumentFormat = abstract.MIMETypeOctetStream
+	}
+
+	jobs.lock.Lock()
+	ok := !job.status.State.IsFinal() && !job.last
+	if ok {
+		job.status.State = Processing
+		job.status.Reason = ReasonPrinting
+		if job.status.Processing.IsZero() {
+			job.status.Processing = time.Now()
+		}
+		job.last = last
+		job.touched = time.Now()
+
+		log.Debug(jobs.ctx, "%s: job %d: printing document %d",
+			jobs.proto, job.status.ID, job.status.Documents+1)
+	}
+	jobs.lock.Unlock()
+
+	if !ok {
+		return ErrNotAccepting
+	}
+
+	// Print the document
+	doc := &document{format: req.DocumentFormat, body: body}
+
+	jobs.printLock.Lock()
+	err := jobs.printer.Print(job.ctx, req, doc)
+	jobs.printLock.Unlock()
+
+	// Update the job status
+	jobs.lock.Lock()
+	defer jobs.lock.Unlock()
+
+	job.status.Documents++
+	job.status.Octets += doc.count
+	job.touched = time.Now()
+
+	switch {
+	case job.status.State.IsFinal():
+		// Canceled while printing
+
+	case err != nil:
+		jobs.finish(job, Aborted, ReasonCompletedWithErrors)
+
+	case last:
+		jobs.finish(job, Completed, ReasonCompletedSuccessfully)
+
+	default:
+		job.status.Reason = ReasonIncoming
+	}
+
+	if err != nil {
+		log.Debug(jobs.ctx, "%s: job %d: %s",
+			jobs.proto, job.status.ID, err)
+	}
+
+	return err
+}
+
+// Cancel cancels the job. If job is already finished, it
+// returns [ErrFinished].
+func (jobs *Jobs[T]) Cancel(job *Job[T]) error {
+	jobs.lock.Lock()
+	defer jobs.lock.Unlock()
+
+	if job.status.State.IsFinal() {
+		return ErrFinished
+	}
+
+	jobs.finish(job, Canceled, ReasonCanceledByUser)
+	return nil
+}
+
+// finish moves the job into the final state and cancels its context.
+//
+// Must be called under the jobs.lock.
+func (jobs *Jobs[T]) finish(job *Job[T], state State, reason Reason) {
+	job.status.State = state
+	job.status.Reason = reason
+	job.status.Finished = time.Now()
+	job.cancel()
+
+	log.Debug(jobs.ctx, "%s: job %d %s", jobs.proto, job.status.ID, state)
+}
+
+// expire aborts abandoned jobs and purges the oldest finished
+// jobs, keeping at most History of them.
+//
+// Must be called under the jobs.lock.
+func (jobs *Jobs[T]) expire() {
+	finished := 0
+	for _, job := range jobs.jobs {
+		if !job.status.State.IsFinal() &&
+			time.Since(job.touched) >= Timeout {
+			jobs.finish(job, Aborted, ReasonTimedOut)
+		}
+
+		if job.status.State.IsFinal() {
+			finished++
+		}
+	}
+
+	list := jobs.jobs[:0]
+	for _, job := range jobs.jobs {
+		if job.status.State.IsFinal() && finished > History {
+			finished--
+			continue
+		}
+
+		list = append(list, job)
+	}
+
+	clear(jobs.jobs[len(list):])
+	jobs.jobs = list
+}
+
+// ID returns the job ID.
+func (job *Job[T]) ID() int {
+	return job.status.ID
+}
+
+// Status returns the snapshot of the job status.
+func (job *Job[T]) Status() Status {
+	job.jobs.lock.Lock()
+	defer job.jobs.lock.Unlock()
+	return job.status
+}
+
+// Format returns the MIME type of the document format.
+func (doc *document) Format() string {
+	return doc.format
+}
+
+// Read reads the document data.
+func (doc *document) Read(buf []byte) (int, error) {
+	n, err := doc.body.Read(buf)
+	doc.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,103 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Print jobs tracker
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Print jobs tracker test
+
+package printjobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+)
+
+// TestJobs tests the job life cycle and the history of finished jobs.
+func TestJobs(t *testing.T) {
+	printer := &abstract.VirtualPrinter{
+		PrintCaps: &abstract.PrinterCapabilities{},
+	}
+
+	jobs := New[string](context.Background(), "Test", printer)
+	defer jobs.Close()
+
+	// Two-document job
+	job := jobs.Create(abstract.PrinterRequest{}, "data")
+	if job.Data != "data" || jobs.Lookup(job.ID()) != job {
+		t.Fatalf("Create: job %d not found", job.ID())
+	}
+
+	err := jobs.Print(job, "", strings.NewReader("DOC1"), false)
+	if err != nil {
+		t.Fatalf("Print: %s", err)
+	}
+
+	st := job.Status()
+	if st.State != Processing || st.Reason != ReasonIncoming {
+		t.Errorf("Status: %+v", st)
+	}
+
+	err = jobs.Print(job, "", strings.NewReader("DOC2"), true)
+	if err != nil {
+		t.Fatalf("Print: %s", err)
+	}
+
+	st = job.Status()
+	if st.State != Completed || st.Documents != 2 || st.Octets != 8 ||
+		st.Finished.IsZero() {
+		t.Errorf("Status: %+v", st)
+	}
+
+	err = jobs.Print(job, "", strings.NewReader("DOC3"), true)
+	if !errors.Is(err, ErrNotAccepting) {
+		t.Errorf("Print:\nexpected: %v\npresent:  %v",
+			ErrNotAccepting, err)
+	}
+
+	// Canceled job
+	job = jobs.Create(abstract.PrinterRequest{}, "")
+	err = jobs.Cancel(job)
+	if err != nil {
+		t.Fatalf("Cancel: %s", err)
+	}
+
+	st = job.Status()
+	if st.State != Canceled || st.Reason != ReasonCanceledByUser {
+		t.Errorf("Status: %+v", st)
+	}
+
+	err = jobs.Cancel(job)
+	if !errors.Is(err, ErrFinished) {
+		t.Errorf("Cancel:\nexpected: %v\npresent:  %v",
+			ErrFinished, err)
+	}
+
+	// History of finished jobs
+	for i := 0; i < History; i++ {
+		job = jobs.Create(abstract.PrinterRequest{}, "")
+		jobs.Cancel(job)
+	}
+
+	list := jobs.List()
+	if len(list) != History {
+		t.Errorf("List: %d jobs, expected %d", len(list), History)
+	}
+
+	if jobs.Lookup(1) != nil {
+		t.Errorf("Lookup: expired job 1 still present")
+	}
+
+	// Active job is canceled by Close
+	job = jobs.Create(abstract.PrinterRequest{}, "")
+	jobs.Close()
+
+	st = job.Status()
+	if st.State != Canceled || st.Reason != ReasonCanceledAtDevice {
+		t.Errorf("Status: %+v", st)
+	}
+}
@@ -0,0 +1,59 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Print jobs tracker
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Job state and state reasons
+
+package printjobs
+
+// State represents the job state.
+type State int
+
+// Job states:
+const (
+	Pending    State = iota // Waiting for documents
+	Processing              // Document is being printed
+	Completed               // Completed successfully
+	Canceled                // Canceled by user or at device
+	Aborted                 // Aborted due to error or timeout
+)
+
+// IsFinal reports if State is final (the job is finished).
+func (state State) IsFinal() bool {
+	return state >= Completed
+}
+
+// String returns the State name, for logging.
+func (state State) String() string {
+	switch state {
+	case Pending:
+		return "pending"
+	case Processing:
+		return "processing"
+	case Completed:
+		return "completed"
+	case Canceled:
+		return "canceled"
+	case Aborted:
+		return "aborted"
+	}
+
+	return "unknown"
+}
+
+// Reason explains the job State.
+type Reason int
+
+// Job state reasons:
+const (
+	ReasonNone                  Reason = iota // No particular reason
+	ReasonPrinting                            // Document is printing
+	ReasonIncoming                            // Waiting for next document
+	ReasonCompletedSuccessfully               // Job completed
+	ReasonCompletedWithErrors                 // Printer returned error
+	ReasonCanceledByUser                      // Job canceled by user
+	ReasonCanceledAtDevice                    // Server closed
+	ReasonTimedOut                            // Job abandoned by client
+)
@@ -8,10 +8,14 @@
 
 package log
 
+import "os"
+
 // Standard backends:
 var (
-	// Console writes output to console.
-	Console Backend = &backendConsole{}
+	// Console writes output to console (os.Stdout).
+	Console Backend = NewConsoleBackend(ConsoleOptions{
+		Output: os.Stdout,
+	})
 
 	// Discard silently discards any output.
 	Discard Backend = &backendDiscard{}
@@ -9,40 +9,95 @@
 package log
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/term"
 )
 
+// ConsoleOptions defines the console [Backend] options.
+type ConsoleOptions struct {
+	// Output is the output file. If nil, os.Stderr is used.
+	Output *os.File
+
+	// Timestamps, if set, prepends each line with the time stamp.
+	Timestamps bool
+
+	// Levels, if set, prepends each line with the fixed-width
+	// level tag (i.e., "DEBUG", "INFO ", ...), so messages of
+	// different levels remain aligned.
+	Levels bool
+}
+
 // backendConsole is the Backend that writes logs to console
 type backendConsole struct {
-	color int32      // No: -1, Yes: +1, Unknown: 0
-	mutex sync.Mutex // Send lock
+	options ConsoleOptions // Backend options
+	color   int32          // No: -1, Yes: +1, Unknown: 0
+	mutex   sync.Mutex     // Send lock
+}
+
+// NewConsoleBackend returns a Backend that writes log to console.
+//
+// When output is a terminal, lines are colored according to their
+// levels. Color is automatically disabled, if output is redirected
+// into the file or pipe, or if the NO_COLOR environment variable
+// is set to non-empty string (see https://no-color.org/).
+func NewConsoleBackend(options ConsoleOptions) Backend {
+	if options.Output == nil {
+		options.Output = os.Stderr
+	}
+
+	return &backendConsole{options: options}
 }
 
 // Line implements [Backend.Send] method
 func (bk *backendConsole) Send(levels []Level, lines [][]byte) {
 	// Color auto-detection
 	if atomic.LoadInt32(&bk.color) == 0 {
-		isatty := term.IsTerminal(int(os.Stdout.Fd()))
-		if isatty {
-			atomic.CompareAndSwapInt32(&bk.color, 0, +1)
+		color := int32(-1)
+		if consoleColorAllowed(bk.options.Output) {
+			color = +1
 		}
+		atomic.CompareAndSwapInt32(&bk.color, 0, color)
 	}
 
 	// Build the entire message in the buffer
 	buf := bufAlloc()
 	defer bufFree(buf)
 
+	bk.format(buf, levels, lines, atomic.LoadInt32(&bk.color) > 0,
+		time.Now())
+
+	// Now send buffer to the output
+	bk.mutex.Lock()
+	buf.WriteTo(bk.options.Output)
+	bk.mutex.Unlock()
+}
+
+// format formats log lines into the buffer.
+func (bk *backendConsole) format(buf *bytes.Buffer,
+	levels []Level, lines [][]byte, color bool, now time.Time) {
+
+	// Format time prefix
+	var stamp string
+	if bk.options.Timestamps {
+		hour, min, sec := now.Clock()
+		msec := now.Nanosecond() / int(time.Millisecond)
+		stamp = fmt.Sprintf("%2.2d:%2.2d:%2.2d.%3.3d ",
+			hour, min, sec, msec)
+	}
+
 	for i := range lines {
 		level := levels[i]
 		line := lines[i]
 
 		var beg, end string
 
-		if atomic.LoadInt32(&bk.color) > 0 {
+		if color {
 			switch level {
 			case LevelTrace:
 				beg, end = "\033[37m", "\033[0m" // Gray
@@ -57,13 +112,44 @@ func (bk *backendConsole) Send(levels []Level, lines [][]byte) {
 			}
 		}
 
-		buf.Write([]byte(beg))
+		buf.WriteString(stamp)
+		buf.WriteString(beg)
+		if bk.options.Levels {
+			buf.WriteString(consoleLevelTag(level))
+			buf.WriteByte(' ')
+		}
 		buf.Write(line)
-		buf.Write([]byte(end + "\n"))
+		buf.WriteString(end)
+		buf.WriteByte('\n')
 	}
+}
 
-	// Now send buffer to the os.Stdout
-	bk.mutex.Lock()
-	buf.WriteTo(os.Stdout)
-	bk.mutex.Unlock()
+// consoleColorAllowed reports whether colored output is allowed
+// for the given output file.
+func consoleColorAllowed(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	return term.IsTerminal(int(file.Fd()))
+}
+
+// consoleLevelTag returns the fixed-width level tag.
+func consoleLevelTag(level Level) string {
+	switch level {
+	case LevelTrace:
+		return "TRACE"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO "
+	case LevelWarning:
+		return "WARN "
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "FATAL"
+	}
+
+	return "?????"
 }
@@ -0,0 +1,95 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Logging facilities
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Console backend test
+
+package log
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestConsoleFormat tests console backend formatting
+func TestConsoleFormat(t *testing.T) {
+	levels := []Level{LevelDebug, LevelInfo, LevelError}
+	lines := [][]byte{
+		[]byte("debug"),
+		[]byte("info"),
+		[]byte("error"),
+	}
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.Local)
+
+	type testData struct {
+		options ConsoleOptions
+		color   bool
+		out     string
+	}
+
+	tests := []testData{
+		{
+			options: ConsoleOptions{},
+			out:     "debug\ninfo\nerror\n",
+		},
+		{
+			options: ConsoleOptions{Levels: true, Timestamps: true},
+			out: "" +
+				"03:04:05.006 DEBUG debug\n" +
+				"03:04:05.006 INFO  info\n" +
+				"03:04:05.006 ERROR error\n",
+		},
+		{
+			options: ConsoleOptions{Levels: true},
+			color:   true,
+			out: "" +
+				"\033[37;1mDEBUG debug\033[0m\n" +
+				"\033[32;1mINFO  info\033[0m\n" +
+				"\033[31;1mERROR error\033[0m\n",
+		},
+	}
+
+	for _, test := range tests {
+		bk := NewConsoleBackend(test.options).(*backendConsole)
+		buf := &bytes.Buffer{}
+		bk.format(buf, levels, lines, test.color, now)
+
+		if buf.String() != test.out {
+			t.Errorf("%+v, color=%v: output mismatch:\n"+
+				"expected: %q\n"+
+				"present:  %q",
+				test.options, test.color, test.out, buf.String())
+		}
+	}
+}
+
+// TestConsoleColor tests console backend color auto-detection
+func TestConsoleColor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer file.Close()
+
+	// Output to file must never be colored
+	bk := NewConsoleBackend(ConsoleOptions{Output: file})
+	bk.Send([]Level{LevelError}, [][]byte{[]byte("error")})
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "error\n" {
+		t.Errorf("output to file: %q", data)
+	}
+
+	// NO_COLOR disables colors
+	t.Setenv("NO_COLOR", "1")
+	if consoleColorAllowed(os.Stderr) {
+		t.Errorf("NO_COLOR ignored")
+	}
+}
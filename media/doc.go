@@ -0,0 +1,12 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Media sizes
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Package documentation
+
+// Package media provides the database of the standard media sizes,
+// identified by the PWG 5101.1 self-describing names, and conversions
+// between media sizes and IPP "media-col" and eSCL scan regions.
+package media
@@ -0,0 +1,328 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Media sizes
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PWG media size database
+
+package media
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/goipp"
+)
+
+// Tolerance is the maximal difference between dimensions of
+// two media sizes, that are considered equal by [FromSize].
+const Tolerance = abstract.Millimeter / 2
+
+// Media represents the media size.
+type Media struct {
+	Name   string             // PWG 5101.1 self-describing name
+	Width  abstract.Dimension // Media width
+	Height abstract.Dimension // Media height
+}
+
+// String returns the PWG 5101.1 self-describing media name.
+func (m Media) String() string {
+	return m.Name
+}
+
+// Short returns the short media name (i.e., "a4" for the
+// "iso_a4_210x297mm").
+func (m Media) Short() string {
+	_, name, _, _ := parseName(m.Name)
+	return name
+}
+
+// IsCustom reports if Media is a custom media size, not found
+// in the database.
+func (m Media) IsCustom() bool {
+	_, known := mediaByName[m.Name]
+	return !known
+}
+
+// Region returns media size as [abstract.Region] with the zero offset.
+func (m Media) Region() abstract.Region {
+	return abstract.Region{Width: m.Width, Height: m.Height}
+}
+
+// MediaSize returns media size as IPP "media-size" collection.
+func (m Media) MediaSize() ipp.MediaSize {
+	return ipp.MediaSize{
+		XDimension: goipp.Integer(m.Width),
+		YDimension: goipp.Integer(m.Height),
+	}
+}
+
+// MediaCol returns IPP "media-col" collection with the
+// "media-size" and "media-size-name" members filled.
+func (m Media) MediaCol() ipp.MediaCol {
+	return ipp.MediaCol{
+		MediaSize:     m.MediaSize(),
+		MediaSizeName: m.Name,
+	}
+}
+
+// ScanRegion returns media size as eSCL [escl.ScanRegion] with
+// the zero offset, in the specified units.
+func (m Media) ScanRegion(units escl.Units) escl.ScanRegion {
+	return escl.ScanRegion{
+		Width:              units.FromDimension(m.Width),
+		Height:             units.FromDimension(m.Height),
+		ContentRegionUnits: units,
+	}
+}
+
+// Lookup returns Media by name. The name may be:
+//   - PWG 5101.1 self-describing name (i.e., "iso_a4_210x297mm")
+//   - short name (i.e., "a4" or "letter")
+//   - explicit size in millimeters or inches (i.e., "100x150mm"
+//     or "4x6in"), that yields the custom media
+//
+// Names are case-insensitive. If the same short name is used by
+// several media classes, ISO sizes are preferred, then North
+// American sizes, then the rest.
+func Lookup(name string) (Media, bool) {
+	name = strings.ToLower(name)
+
+	if m, ok := mediaByName[name]; ok {
+		return m, true
+	}
+
+	if m, ok := mediaByShort[name]; ok {
+		return m, true
+	}
+
+	if wid, hei, ok := parseSize(name); ok {
+		return FromSize(wid, hei), true
+	}
+
+	if _, _, wid, hei := parseName(name); wid > 0 {
+		return Media{Name: name, Width: wid, Height: hei}, true
+	}
+
+	return Media{}, false
+}
+
+// FromSize returns Media by its dimensions.
+//
+// If there is a known media of this size (with the [Tolerance]),
+// it is returned. Otherwise, the custom media is returned,
+// named per PWG 5101.1 (i.e., "custom_100x150mm_100x150mm").
+func FromSize(wid, hei abstract.Dimension) Media {
+	var best *Media
+	var bestDiff abstract.Dimension
+
+	for i := range mediaAll {
+		m := &mediaAll[i]
+		dw, dh := dimAbs(m.Width-wid), dimAbs(m.Height-hei)
+		if dw <= Tolerance && dh <= Tolerance &&
+			(best == nil || dw+dh < bestDiff) {
+			best, bestDiff = m, dw+dh
+		}
+	}
+
+	if best != nil {
+		return *best
+	}
+
+	size := formatSize(wid, hei)
+	return Media{Name: "custom_" + size + "_" + size,
+		Width: wid, Height: hei}
+}
+
+// FromMediaSize returns Media by the IPP "media-size" collection.
+//
+// It returns false, if the media-size doesn't define the exact
+// dimensions (i.e., uses ranges).
+func FromMediaSize(size ipp.MediaSize) (Media, bool) {
+	x, ok1 := size.XDimension.(goipp.Integer)
+	y, ok2 := size.YDimension.(goipp.Integer)
+	if !ok1 || !ok2 || x <= 0 || y <= 0 {
+		return Media{}, false
+	}
+
+	return FromSize(abstract.Dimension(x), abstract.Dimension(y)), true
+}
+
+// FromMediaCol returns Media by the IPP "media-col" collection.
+//
+// The "media-size" member takes precedence, then "media-size-name"
+// and "media-key" are consulted.
+func FromMediaCol(col ipp.MediaCol) (Media, bool) {
+	if m, ok := FromMediaSize(col.MediaSize); ok {
+		return m, true
+	}
+
+	for _, name := range []string{col.MediaSizeName, string(col.MediaKey)} {
+		if name != "" {
+			if m, ok := Lookup(name); ok {
+				return m, true
+			}
+		}
+	}
+
+	return Media{}, false
+}
+
+// FromScanRegion returns Media by the size of the eSCL scan region.
+// Region offset is ignored.
+func FromScanRegion(reg escl.ScanRegion) Media {
+	units := reg.ContentRegionUnits
+	return FromSize(units.ToDimension(reg.Width),
+		units.ToDimension(reg.Height))
+}
+
+// All returns all known media sizes, sorted by name.
+func All() []Media {
+	return slices.Clone(mediaAll)
+}
+
+// ShortNames returns short names of all known media sizes,
+// sorted alphabetically.
+func ShortNames() []string {
+	names := make([]string, 0, len(mediaByShort))
+	for name := range mediaByShort {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// mediaClassPriority defines priority of media classes, when the
+// same short name is used by the multiple classes. Classes not
+// listed here have the lowest priority.
+var mediaClassPriority = []string{"iso", "na", "jis"}
+
+// Media database, built at initialization from the
+// standard IPP media names:
+//   - mediaAll contains all media sizes, sorted by name
+//   - mediaByName indexes it by the full name
+//   - mediaByShort indexes it by the short name
+var (
+	mediaAll     []Media
+	mediaByName  = make(map[string]Media)
+	mediaByShort = make(map[string]Media)
+)
+
+// init builds the media database
+func init() {
+	for _, name := range ipp.KwMediaNames() {
+		wid, hei := ipp.KwMedia(name).Size()
+		m := Media{
+			Name:   name,
+			Width:  abstract.Dimension(wid),
+			Height: abstract.Dimension(hei),
+		}
+
+		mediaAll = append(mediaAll, m)
+		mediaByName[name] = m
+	}
+
+	// Short names are assigned in order of class priority
+	prio := func(m Media) int {
+		class, _, _, _ := parseName(m.Name)
+		if i := slices.Index(mediaClassPriority, class); i >= 0 {
+			return i
+		}
+		return len(mediaClassPriority)
+	}
+
+	byPrio := slices.Clone(mediaAll)
+	slices.SortStableFunc(byPrio, func(m1, m2 Media) int {
+		return prio(m1) - prio(m2)
+	})
+
+	for _, m := range byPrio {
+		short := m.Short()
+		if _, found := mediaByShort[short]; !found && short != "" {
+			mediaByShort[short] = m
+		}
+	}
+}
+
+// parseName parses the PWG 5101.1 self-describing media name
+// into class, short name and dimensions. On error, it returns
+// empty strings and zero dimensions.
+func parseName(name string) (class, short string,
+	wid, hei abstract.Dimension) {
+
+	parts := strings.Split(name, "_")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return
+	}
+
+	var ok bool
+	wid, hei, ok = parseSize(parts[2])
+	if !ok {
+		return "", "", 0, 0
+	}
+
+	return parts[0], parts[1], wid, hei
+}
+
+// parseSize parses media size in the PWG 5101.1 format,
+// i.e., "210x297mm" or "8.5x11in".
+func parseSize(s string) (wid, hei abstract.Dimension, ok bool) {
+	var unit abstract.Dimension
+	switch {
+	case strings.HasSuffix(s, "mm"):
+		unit = abstract.Millimeter
+	case strings.HasSuffix(s, "in"):
+		unit = abstract.Inch
+	default:
+		return
+	}
+
+	w, h, found := strings.Cut(s[:len(s)-2], "x")
+	if !found {
+		return
+	}
+
+	fw, err1 := strconv.ParseFloat(w, 64)
+	fh, err2 := strconv.ParseFloat(h, 64)
+	if err1 != nil || err2 != nil || !(fw > 0) || !(fh > 0) ||
+		math.IsInf(fw, 0) || math.IsInf(fh, 0) {
+		return
+	}
+
+	wid = abstract.Dimension(math.Round(fw * float64(unit)))
+	hei = abstract.Dimension(math.Round(fh * float64(unit)))
+
+	return wid, hei, true
+}
+
+// formatSize formats media size in the PWG 5101.1 format.
+// Like CUPS, it uses inches if both dimensions are multiple
+// of 1/4 inch, and millimeters otherwise.
+func formatSize(wid, hei abstract.Dimension) string {
+	unit, suffix := abstract.Millimeter, "mm"
+	if wid%(abstract.Inch/4) == 0 && hei%(abstract.Inch/4) == 0 {
+		unit, suffix = abstract.Inch, "in"
+	}
+
+	f := func(v abstract.Dimension) string {
+		return strconv.FormatFloat(float64(v)/float64(unit),
+			'f', -1, 64)
+	}
+
+	return f(wid) + "x" + f(hei) + suffix
+}
+
+// dimAbs returns absolute value of the Dimension
+func dimAbs(v abstract.Dimension) abstract.Dimension {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
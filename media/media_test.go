@@ -0,0 +1,174 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Media sizes
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PWG media size database test
+
+package media
+
+import (
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/proto/ipp"
+	"github.com/OpenPrinting/goipp"
+)
+
+// TestLookup tests Lookup
+func TestLookup(t *testing.T) {
+	type testData struct {
+		name   string
+		expect string
+		wid    abstract.Dimension
+		hei    abstract.Dimension
+	}
+
+	tests := []testData{
+		{"iso_a4_210x297mm", "iso_a4_210x297mm",
+			abstract.A4Width, abstract.A4Height},
+		{"A4", "iso_a4_210x297mm",
+			abstract.A4Width, abstract.A4Height},
+		{"letter", "na_letter_8.5x11in",
+			abstract.LetterWidth, abstract.LetterHeight},
+		{"b5", "iso_b5_176x250mm", 17600, 25000},
+		{"210x297mm", "iso_a4_210x297mm",
+			abstract.A4Width, abstract.A4Height},
+		{"4x6in", "na_index-4x6_4x6in", 10160, 15240},
+		{"100x123mm", "custom_100x123mm_100x123mm", 10000, 12300},
+		{"5x7.5in", "custom_5x7.5in_5x7.5in", 12700, 19050},
+		{"vendor_foo_80x200mm", "vendor_foo_80x200mm", 8000, 20000},
+		{"unknown", "", 0, 0},
+		{"0x297mm", "", 0, 0},
+		{"iso_a4_axbmm", "", 0, 0},
+	}
+
+	for _, test := range tests {
+		m, ok := Lookup(test.name)
+		if test.expect == "" {
+			if ok {
+				t.Errorf("Lookup(%q): error expected, present %+v",
+					test.name, m)
+			}
+			continue
+		}
+
+		expect := Media{test.expect, test.wid, test.hei}
+		if !ok || m != expect {
+			t.Errorf("Lookup(%q):\nexpected: %+v\npresent:  %+v",
+				test.name, expect, m)
+		}
+	}
+}
+
+// TestFromSize tests FromSize
+func TestFromSize(t *testing.T) {
+	type testData struct {
+		wid, hei abstract.Dimension
+		expect   string
+	}
+
+	tests := []testData{
+		{abstract.A4Width, abstract.A4Height, "iso_a4_210x297mm"},
+		{abstract.A4Width + 30, abstract.A4Height - 30,
+			"iso_a4_210x297mm"},
+		{abstract.A4Width + 100, abstract.A4Height,
+			"custom_211x297mm_211x297mm"},
+		{abstract.LetterWidth, abstract.LetterHeight,
+			"na_letter_8.5x11in"},
+	}
+
+	for _, test := range tests {
+		m := FromSize(test.wid, test.hei)
+		if m.Name != test.expect {
+			t.Errorf("FromSize(%d,%d): expected %q, present %q",
+				test.wid, test.hei, test.expect, m.Name)
+		}
+
+		if m.IsCustom() != (test.expect[:7] == "custom_") {
+			t.Errorf("%s: IsCustom(): %v", m, m.IsCustom())
+		}
+	}
+}
+
+// TestConversions tests conversions to/from IPP and eSCL
+func TestConversions(t *testing.T) {
+	a4, _ := Lookup("a4")
+
+	// IPP media-col
+	col := a4.MediaCol()
+	if col.MediaSize.XDimension != goipp.Integer(21000) ||
+		col.MediaSize.YDimension != goipp.Integer(29700) ||
+		col.MediaSizeName != a4.Name {
+		t.Errorf("MediaCol: %+v", col)
+	}
+
+	m, ok := FromMediaCol(col)
+	if !ok || m != a4 {
+		t.Errorf("FromMediaCol: %+v", m)
+	}
+
+	m, ok = FromMediaCol(ipp.MediaCol{MediaKey: ipp.KwMediaNaLetter})
+	if !ok || m.Short() != "letter" {
+		t.Errorf("FromMediaCol(media-key): %+v", m)
+	}
+
+	_, ok = FromMediaSize(ipp.MediaSize{
+		XDimension: goipp.Range{Lower: 10000, Upper: 30000},
+		YDimension: goipp.Integer(29700),
+	})
+	if ok {
+		t.Errorf("FromMediaSize: range accepted")
+	}
+
+	// eSCL scan region
+	reg := a4.ScanRegion(escl.ThreeHundredthsOfInches)
+	expect := escl.ScanRegion{
+		Width:              2480,
+		Height:             3508,
+		ContentRegionUnits: escl.ThreeHundredthsOfInches,
+	}
+
+	if reg != expect {
+		t.Errorf("ScanRegion:\nexpected: %+v\npresent:  %+v",
+			expect, reg)
+	}
+
+	if m = FromScanRegion(reg); m != a4 {
+		t.Errorf("FromScanRegion: %+v", m)
+	}
+
+	if a4.Region() != (abstract.Region{
+		Width: abstract.A4Width, Height: abstract.A4Height}) {
+		t.Errorf("Region: %+v", a4.Region())
+	}
+}
+
+// TestAll tests All and ShortNames
+func TestAll(t *testing.T) {
+	all := All()
+	if len(all) != len(ipp.KwMediaNames()) {
+		t.Errorf("All: %d entries, expected %d",
+			len(all), len(ipp.KwMediaNames()))
+	}
+
+	for _, m := range all {
+		if m.Width <= 0 || m.Height <= 0 {
+			t.Errorf("%s: invalid size %dx%d", m, m.Width, m.Height)
+		}
+
+		if _, _, wid, hei := parseName(m.Name); wid != m.Width ||
+			hei != m.Height {
+			t.Errorf("%s: size %dx%d doesn't match name",
+				m, m.Width, m.Height)
+		}
+	}
+
+	for _, short := range ShortNames() {
+		if _, ok := Lookup(short); !ok {
+			t.Errorf("ShortNames: %q not found", short)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// abstract.Scanner on a top of eSCL client
+
+package escl
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// AbstractClient defaults
+const (
+	// AbstractClientDefaultRetries is the default count of retries
+	// after transient network errors.
+	AbstractClientDefaultRetries = 3
+
+	// AbstractClientDefaultRetryDelay is the default delay before
+	// retry after a transient network error.
+	AbstractClientDefaultRetryDelay = time.Second
+
+	// AbstractClientDefaultPollInterval is the default interval
+	// between NextDocument requests while scanner is busy and
+	// doesn't suggest the interval with the Retry-After header.
+	AbstractClientDefaultPollInterval = 500 * time.Millisecond
+
+	// AbstractClientDefaultMaxRetryAfter is the default upper
+	// limit of the Retry-After interval, suggested by the scanner.
+	AbstractClientDefaultMaxRetryAfter = 10 * time.Second
+
+	// abstractClientCancelTimeout is the timeout for the DELETE
+	// request, used to cancel the job.
+	abstractClientCancelTimeout = 5 * time.Second
+)
+
+// AbstractClient implements [abstract.Scanner] on a top of the
+// eSCL [Client].
+//
+// Unlike the low-level Client, it handles the entire scan job
+// lifecycle:
+//   - POST ScanJobs, retried while scanner responds with the
+//     503 Service Unavailable status (i.e., busy)
+//   - NextDocument polling, paced according to the Retry-After
+//     header or [AbstractClientOptions.PollInterval]
+//   - retries after transient network errors
+//   - DELETE of the job, if document is closed before all
+//     pages are received or the context is canceled
+type AbstractClient struct {
+	options AbstractClientOptions         // Client options
+	clnt    *Client                       // Underlying eSCL client
+	caps    *ScannerCapabilities          // eSCL capabilities
+	abscaps *abstract.ScannerCapabilities // Abstract capabilities
+}
+
+// AbstractClientOptions represents the [AbstractClient] creation
+// options. Zero values of the numeric parameters mean defaults.
+type AbstractClientOptions struct {
+	// Transport used for HTTP requests. If nil,
+	// [transport.NewTransport] will be used.
+	Transport *transport.Transport
+
+	// Retries is the maximum count of retries after the transient
+	// network errors. Use negative value to disable retries.
+	Retries int
+
+	// RetryDelay is the delay before retry after the network error.
+	RetryDelay time.Duration
+
+	// PollInterval is the interval between NextDocument and
+	// ScanJobs requests, while scanner is busy.
+	PollInterval time.Duration
+
+	// MaxRetryAfter is the upper limit for the Retry-After
+	// interval, suggested by the scanner.
+	MaxRetryAfter time.Duration
+}
+
+// NewAbstractClient creates a new [AbstractClient].
+//
+// It fetches [ScannerCapabilities] from the scanner, so scanner
+// must be reachable.
+func NewAbstractClient(ctx context.Context, u *url.URL,
+	options AbstractClientOptions) (*AbstractClient, error) {
+
+	// Apply defaults
+	switch {
+	case options.Retries == 0:
+		options.Retries = AbstractClientDefaultRetries
+	case options.Retries < 0:
+		options.Retries = 0
+	}
+
+	if options.RetryDelay == 0 {
+		options.RetryDelay = AbstractClientDefaultRetryDelay
+	}
+
+	if options.PollInterval == 0 {
+		options.PollInterval = AbstractClientDefaultPollInterval
+	}
+
+	if options.MaxRetryAfter == 0 {
+		options.MaxRetryAfter = AbstractClientDefaultMaxRetryAfter
+	}
+
+	// Create AbstractClient
+	ac := &AbstractClient{
+		options: options,
+		clnt:    NewClient(u, options.Transport),
+	}
+
+	// Fetch capabilities
+	var caps *ScannerCapabilities
+	err := ac.retry(ctx, func() (*HTTPDetails, error) {
+		var details *HTTPDetails
+		var err error
+		caps, details, err = ac.clnt.GetScannerCapabilities(ctx)
+		return details, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	ac.caps = caps
+	ac.abscaps = caps.ToAbstract()
+
+	return ac, nil
+}
+
+// Client returns the underlying low-level eSCL [Client].
+func (ac *AbstractClient) Client() *Client {
+	return ac.clnt
+}
+
+// Capabilities returns the [abstract.ScannerCapabilities].
+// Caller should not modify the returned structure.
+func (ac *AbstractClient) Capabilities() *abstract.ScannerCapabilities {
+	return ac.abscaps
+}
+
+// Scan validates the request against scanner capabilities,
+// starts the scan job and returns the [abstract.Document],
+// that returns scanned pages.
+//
+// The ctx covers the entire job lifetime, including consuming the
+// returned document. If ctx is canceled, the job is canceled at the
+// scanner side.
+func (ac *AbstractClient) Scan(ctx context.Context,
+	req abstract.ScannerRequest) (abstract.Document, error) {
+
+	err := req.Validate(ac.abscaps)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := fromAbstractScanSettings(ac.caps.Version, &req)
+
+	// Send ScanJobs request. Retry while scanner is busy.
+	var joburl string
+	err = ac.retry(ctx, func() (*HTTPDetails, error) {
+		var details *HTTPDetails
+		var err error
+		joburl, details, err = ac.clnt.Scan(ctx, *ss)
+		return details, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug(ctx, "eSCL: job started: %s", joburl)
+
+	doc := &abstractClientDocument{
+		ac:     ac,
+		ctx:    ctx,
+		joburl: joburl,
+		res:    req.Resolution,
+	}
+
+	return doc, nil
+}
+
+// Close closes the AbstractClient.
+func (ac *AbstractClient) Close() error {
+	return nil
+}
+
+// retry calls the request function until it succeeds or
+// fails with the non-retryable error.
+//
+// It retries after transient network errors, up to the
+// configured limit, and while scanner responds with the
+// 503 Service Unavailable (busy) status, until ctx is canceled.
+func (ac *AbstractClient) retry(ctx context.Context,
+	request func() (*HTTPDetails, error)) error {
+
+	retries := 0
+
+	for {
+		details, err := request()
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		}
+
+		var delay time.Duration
+
+		switch {
+		case details == nil:
+			// Network error
+			if retries >= ac.options.Retries {
+				return err
+			}
+
+			retries++
+			delay = ac.options.RetryDelay
+
+		case details.StatusCode == http.StatusServiceUnavailable:
+			// Scanner is busy
+			delay = ac.retryAfter(details)
+
+		default:
+			return err
+		}
+
+		log.Debug(ctx, "eSCL: %s, retry in %s", err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// retryAfter returns the delay before the next attempt, as suggested
+// by the Retry-After header, clamped to the configured limit. If
+// header is missed, it returns the configured PollInterval.
+func (ac *AbstractClient) retryAfter(details *HTTPDetails) time.Duration {
+	delay, ok := details.RetryAfter()
+	if !ok {
+		delay = ac.options.PollInterval
+	}
+
+	return min(delay, ac.options.MaxRetryAfter)
+}
+
+// abstractClientDocument implements the [abstract.Document]
+// interface for the AbstractClient.
+type abstractClientDocument struct {
+	ac     *AbstractClient        // Owning AbstractClient
+	ctx    context.Context        // Job context
+	joburl string                 // Job URL
+	res    abstract.Resolution    // Document resolution
+	file   *abstractClientDocFile // Current file, nil if none
+	done   bool                   // All pages received or job canceled
+	lock   sync.Mutex             // Access lock
+}
+
+// Resolution returns the document's rendering resolution in DPI.
+func (doc *abstractClientDocument) Resolution() abstract.Resolution {
+	return doc.res
+}
+
+// Next returns the next [abstract.DocumentFile].
+func (doc *abstractClientDocument) Next() (abstract.DocumentFile, error) {
+	doc.lock.Lock()
+	defer doc.lock.Unlock()
+
+	doc.closeFile()
+
+	if doc.done {
+		return nil, io.EOF
+	}
+
+	var body io.ReadCloser
+	var format string
+	var status int
+
+	err := doc.ac.retry(doc.ctx, func() (*HTTPDetails, error) {
+		var details *HTTPDetails
+		var err error
+		body, details, err = doc.ac.clnt.NextDocument(doc.ctx,
+			doc.joburl)
+		if details != nil {
+			status = details.StatusCode
+		}
+		if err == nil {
+			format = details.Header.Get("Content-Type")
+			format, _, _ = strings.Cut(format, ";")
+			format = strings.TrimSpace(format)
+		}
+		return details, err
+	})
+
+	switch {
+	case err == io.EOF:
+		doc.done = true
+		return nil, io.EOF
+
+	case err != nil:
+		if status == http.StatusConflict {
+			err = doc.adfError(err)
+		}
+		doc.cancel()
+		return nil, err
+	}
+
+	doc.file = &abstractClientDocFile{
+		body:   body,
+		format: format,
+	}
+
+	return doc.file, nil
+}
+
+// adfError is called when NextDocument fails with the 409 Conflict
+// status, which typically means the ADF failure (jam, multipick and
+// so on), with details available in the ScannerStatus.
+//
+// If ScannerStatus reports the ADF problem, it returns the
+// corresponding abstract error (i.e., [abstract.ErrADFJam]).
+// Otherwise, err is returned as is.
+func (doc *abstractClientDocument) adfError(err error) error {
+	status, _, err2 := doc.ac.clnt.GetScannerStatus(doc.ctx)
+	if err2 != nil || status.ADFState == nil {
+		return err
+	}
+
+	if adferr := (*status.ADFState).toAbstract().Err(); adferr != nil {
+		return adferr
+	}
+
+	return err
+}
+
+// Close closes the document. If not all pages are consumed,
+// the job is canceled.
+func (doc *abstractClientDocument) Close() error {
+	doc.lock.Lock()
+	defer doc.lock.Unlock()
+
+	doc.closeFile()
+	doc.cancel()
+
+	return nil
+}
+
+// closeFile closes the current file, if any.
+// Must be called under the doc.lock.
+func (doc *abstractClientDocument) closeFile() {
+	if doc.file != nil {
+		doc.file.body.Close()
+		doc.file = nil
+	}
+}
+
+// cancel cancels the job, if it is not done yet.
+// Must be called under the doc.lock.
+//
+// The DELETE request is performed with the separate context,
+// as the job context may be already canceled.
+func (doc *abstractClientDocument) cancel() {
+	if doc.done {
+		return
+	}
+
+	doc.done = true
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(doc.ctx),
+		abstractClientCancelTimeout)
+	defer cancel()
+
+	_, err := doc.ac.clnt.Cancel(ctx, doc.joburl)
+	if err != nil && err != io.EOF {
+		log.Debug(ctx, "eSCL: job cancel: %s", err)
+		return
+	}
+
+	log.Debug(ctx, "eSCL: job canceled: %s", doc.joburl)
+}
+
+// abstractClientDocFile implements the [abstract.DocumentFile]
+// interface for the AbstractClient.
+type abstractClientDocFile struct {
+	body   io.ReadCloser // Response body
+	format string        // MIME type of the image
+}
+
+// Format returns the MIME type of the image format used by
+// the document file.
+func (file *abstractClientDocFile) Format() string {
+	return file.format
+}
+
+// Read reads the document file content as a sequence of bytes.
+func (file *abstractClientDocFile) Read(buf []byte) (int, error) {
+	return file.body.Read(buf)
+}
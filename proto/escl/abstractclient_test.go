@@ -0,0 +1,355 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AbstractClient test
+
+package escl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/assert"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// abstractClientTestHandler wraps the http.Handler and injects
+// failures into the requests of the specified kind.
+type abstractClientTestHandler struct {
+	handler http.Handler   // Underlying handler
+	busy    map[string]int // Count of 503 responses, by request
+	abort   map[string]int // Count of aborted requests, by request
+	seen    map[string]int // Count of received requests, by request
+	lock    sync.Mutex     // Access lock
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *abstractClientTestHandler) ServeHTTP(w http.ResponseWriter,
+	rq *http.Request) {
+
+	kind := rq.Method + " " + path.Base(rq.URL.Path)
+	if rq.Method == "DELETE" {
+		kind = "DELETE"
+	}
+
+	h.lock.Lock()
+	h.seen[kind]++
+	busy := h.busy[kind] > 0
+	abort := !busy && h.abort[kind] > 0
+
+	if busy {
+		h.busy[kind]--
+	}
+	if abort {
+		h.abort[kind]--
+	}
+	h.lock.Unlock()
+
+	switch {
+	case busy:
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case abort:
+		panic(http.ErrAbortHandler)
+	default:
+		h.handler.ServeHTTP(w, rq)
+	}
+}
+
+// count returns count of received requests of the specified kind.
+func (h *abstractClientTestHandler) count(kind string) int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.seen[kind]
+}
+
+// abstractClientTestSetup creates AbstractServer with the virtual
+// scanner, wrapped by the abstractClientTestHandler, and returns
+// the handler, transport to reach it and server URL.
+func abstractClientTestSetup(t *testing.T) (
+	*abstractClientTestHandler, *abstract.VirtualScanner,
+	*transport.Transport, func()) {
+
+	xml, err := xmldoc.Decode(
+		NsMap,
+		bytes.NewReader(testutils.
+			Kyocera.ECOSYS.M2040dn.ESCL.ScannerCapabilities))
+	assert.NoError(err)
+
+	caps, err := DecodeScannerCapabilities(xml)
+	assert.NoError(err)
+
+	s := &abstract.VirtualScanner{
+		ScanCaps: caps.ToAbstract(),
+		Resolution: abstract.Resolution{
+			XResolution: 300,
+			YResolution: 300,
+		},
+		PlatenImage: testutils.Images.PNG100x75rgb8,
+		ADFImages: [][]byte{
+			testutils.Images.PNG100x75rgb8,
+			testutils.Images.PNG100x75gray8,
+			testutils.Images.PNG100x75rgb16,
+		},
+	}
+
+	options := AbstractServerOptions{
+		Version:  caps.Version,
+		Scanner:  s,
+		BasePath: "/eSCL",
+	}
+
+	h := &abstractClientTestHandler{
+		handler: NewAbstractServer(context.TODO(), options),
+		busy:    make(map[string]int),
+		abort:   make(map[string]int),
+		seen:    make(map[string]int),
+	}
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, h)
+	go server.Serve(loopback)
+
+	return h, s, tr, func() { server.Close() }
+}
+
+// abstractClientTestOptions returns AbstractClientOptions for tests
+func abstractClientTestOptions(tr *transport.Transport) AbstractClientOptions {
+	return AbstractClientOptions{
+		Transport:    tr,
+		RetryDelay:   time.Millisecond,
+		PollInterval: time.Millisecond,
+	}
+}
+
+// TestAbstractClientScan tests AbstractClient scan job lifecycle,
+// including handling of busy scanner and transient network errors.
+func TestAbstractClientScan(t *testing.T) {
+	h, s, tr, done := abstractClientTestSetup(t)
+	defer done()
+
+	u := transport.MustParseURL("http://localhost/eSCL")
+	ac, err := NewAbstractClient(context.TODO(), u,
+		abstractClientTestOptions(tr))
+	if err != nil {
+		t.Fatalf("NewAbstractClient: %s", err)
+	}
+
+	defer ac.Close()
+
+	// Inject some failures
+	h.lock.Lock()
+	h.busy["POST ScanJobs"] = 2
+	h.busy["GET NextDocument"] = 3
+	h.abort["GET NextDocument"] = 2
+	h.lock.Unlock()
+
+	// Scan all pages from ADF
+	req := abstract.ScannerRequest{
+		Input:      abstract.InputADF,
+		ADFMode:    abstract.ADFModeSimplex,
+		Resolution: s.Resolution,
+	}
+
+	doc, err := ac.Scan(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("AbstractClient.Scan: %s", err)
+	}
+
+	if doc.Resolution() != s.Resolution {
+		t.Errorf("Document.Resolution mismatch:\n"+
+			"expected: %v\n"+
+			"present:  %v\n",
+			s.Resolution, doc.Resolution())
+	}
+
+	pages := 0
+	for {
+		file, err := doc.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Document.Next: %s", err)
+		}
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatalf("DocumentFile.Read: %s", err)
+		}
+
+		format := abstract.DocumentFormatDetect(data)
+		if format != abstract.DocumentFormatPNG ||
+			file.Format() != format {
+			t.Errorf("page %d: format mismatch:\n"+
+				"expected: %s\n"+
+				"received: %s\n"+
+				"reported: %s\n",
+				pages, abstract.DocumentFormatPNG,
+				format, file.Format())
+		}
+
+		pages++
+	}
+
+	doc.Close()
+
+	if pages != len(s.ADFImages) {
+		t.Errorf("%d pages expected, %d received",
+			len(s.ADFImages), pages)
+	}
+
+	if n := h.count("POST ScanJobs"); n != 3 {
+		t.Errorf("POST ScanJobs: 3 requests expected, %d seen", n)
+	}
+
+	// Completed job must not be canceled
+	if n := h.count("DELETE"); n != 0 {
+		t.Errorf("DELETE: 0 requests expected, %d seen", n)
+	}
+}
+
+// TestAbstractClientCancel tests job cancellation
+func TestAbstractClientCancel(t *testing.T) {
+	h, s, tr, done := abstractClientTestSetup(t)
+	defer done()
+
+	u := transport.MustParseURL("http://localhost/eSCL")
+	ac, err := NewAbstractClient(context.TODO(), u,
+		abstractClientTestOptions(tr))
+	if err != nil {
+		t.Fatalf("NewAbstractClient: %s", err)
+	}
+
+	req := abstract.ScannerRequest{
+		Input:      abstract.InputADF,
+		ADFMode:    abstract.ADFModeSimplex,
+		Resolution: s.Resolution,
+	}
+
+	// Document closed before all pages are consumed
+	doc, err := ac.Scan(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("AbstractClient.Scan: %s", err)
+	}
+
+	_, err = doc.Next()
+	if err != nil {
+		t.Fatalf("Document.Next: %s", err)
+	}
+
+	doc.Close()
+
+	if n := h.count("DELETE"); n != 1 {
+		t.Errorf("DELETE: 1 request expected, %d seen", n)
+	}
+
+	status, _, err := ac.Client().GetScannerStatus(context.TODO())
+	if err != nil {
+		t.Fatalf("GetScannerStatus: %s", err)
+	}
+
+	if len(status.Jobs) == 0 || status.Jobs[0].JobState != JobCanceled {
+		t.Errorf("job is not canceled")
+	}
+
+	// Context canceled while scanner is busy
+	ctx, cancel := context.WithCancel(context.Background())
+	doc, err = ac.Scan(ctx, req)
+	if err != nil {
+		t.Fatalf("AbstractClient.Scan: %s", err)
+	}
+
+	h.lock.Lock()
+	h.busy["GET NextDocument"] = 1000000
+	h.lock.Unlock()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err = doc.Next()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Document.Next: expected %v, present %v",
+			context.Canceled, err)
+	}
+
+	if n := h.count("DELETE"); n != 2 {
+		t.Errorf("DELETE: 2 requests expected, %d seen", n)
+	}
+
+	doc.Close()
+
+	// Network errors above the limit
+	h.lock.Lock()
+	h.busy["GET NextDocument"] = 0
+	h.abort["POST ScanJobs"] = AbstractClientDefaultRetries + 1
+	h.lock.Unlock()
+
+	_, err = ac.Scan(context.TODO(), req)
+	if err == nil {
+		t.Errorf("AbstractClient.Scan: network error not reported")
+	}
+}
+
+// TestAbstractClientADFError tests reporting of the ADF failures
+func TestAbstractClientADFError(t *testing.T) {
+	_, s, tr, done := abstractClientTestSetup(t)
+	defer done()
+
+	u := transport.MustParseURL("http://localhost/eSCL")
+	ac, err := NewAbstractClient(context.TODO(), u,
+		abstractClientTestOptions(tr))
+	if err != nil {
+		t.Fatalf("NewAbstractClient: %s", err)
+	}
+
+	defer ac.Close()
+
+	req := abstract.ScannerRequest{
+		Input:      abstract.InputADF,
+		ADFMode:    abstract.ADFModeSimplex,
+		Resolution: s.Resolution,
+	}
+
+	for _, fault := range []error{
+		abstract.ErrADFMultipick, abstract.ErrADFJam} {
+
+		s.ADFFault = func(page int) error {
+			if page == 1 {
+				return fault
+			}
+			return nil
+		}
+
+		doc, err := ac.Scan(context.TODO(), req)
+		if err != nil {
+			t.Fatalf("AbstractClient.Scan: %s", err)
+		}
+
+		_, err = doc.Next()
+		if err != nil {
+			t.Errorf("%s: page 0: %s", fault, err)
+		}
+
+		_, err = doc.Next()
+		if !errors.Is(err, fault) {
+			t.Errorf("%s: page 1: expected %v, present %v",
+				fault, fault, err)
+		}
+
+		doc.Close()
+	}
+}
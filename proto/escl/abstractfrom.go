@@ -44,6 +44,39 @@ func fromAbstractOptionalRange(absrange abstract.Range) optional.Val[Range] {
 	return nil
 }
 
+// fromAbstractOptionalFloatRange converts abstract.Range into the
+// escl FloatRange if Range is not zero, nil otherwise
+func fromAbstractOptionalFloatRange(
+	absrange abstract.Range) optional.Val[FloatRange] {
+
+	if absrange.IsZero() {
+		return nil
+	}
+
+	r := optional.New(
+		FloatRange{
+			Min:    float64(absrange.Min),
+			Max:    float64(absrange.Max),
+			Normal: float64(absrange.Normal),
+		},
+	)
+
+	if absrange.Step > 1 {
+		r.Step = optional.New(float64(absrange.Step))
+	}
+
+	return r
+}
+
+// fromAbstractFloat converts optional integer value, used by
+// the abstract.ScannerRequest, into the optional fractional value.
+func fromAbstractFloat(v optional.Val[int]) optional.Val[float64] {
+	if v == nil {
+		return nil
+	}
+	return optional.New(float64(*v))
+}
+
 // fromAbstractIntent translates [abstract.Intent] into the
 // Intent.
 //
@@ -176,6 +209,25 @@ func fromAbstractCCDChannels(
 	return out
 }
 
+// fromAbstractADFState translates abstract.ADFState into the
+// eSCL ADFState.
+//
+// For invalid or unknown ADFState, UnknownADFState is returned.
+func fromAbstractADFState(absstate abstract.ADFState) ADFState {
+	switch absstate {
+	case abstract.ADFStateEmpty:
+		return ScannerAdfEmpty
+	case abstract.ADFStateLoaded:
+		return ScannerAdfLoaded
+	case abstract.ADFStateJam:
+		return ScannerAdfJam
+	case abstract.ADFStateMultipick:
+		return ScannerAdfMultipickDetected
+	}
+
+	return UnknownADFState
+}
+
 // fromAbstractColorModes translates abstract color modes into
 // the []ColorMode slice
 //
@@ -311,12 +363,14 @@ func fromAbstractInputSourceCaps(
 	version Version, docFormats []string,
 	abscaps *abstract.InputCapabilities) InputSourceCaps {
 
-	// Fill InputSourceCaps structure
+	// Fill InputSourceCaps structure. InputSourceCaps are always
+	// in the ThreeHundredthsOfInches units.
+	dpi := ThreeHundredthsOfInches.DPI()
 	caps := InputSourceCaps{
-		MinWidth:       abscaps.MinWidth.LowerBoundDots(300),
-		MaxWidth:       abscaps.MaxWidth.UpperBoundDots(300),
-		MinHeight:      abscaps.MinHeight.LowerBoundDots(300),
-		MaxHeight:      abscaps.MaxHeight.UpperBoundDots(300),
+		MinWidth:       abscaps.MinWidth.LowerBoundDots(dpi),
+		MaxWidth:       abscaps.MaxWidth.UpperBoundDots(dpi),
+		MinHeight:      abscaps.MinHeight.LowerBoundDots(dpi),
+		MaxHeight:      abscaps.MaxHeight.UpperBoundDots(dpi),
 		MaxScanRegions: optional.New(1),
 
 		MaxOpticalXResolution: fromAbstractOptionalInt(
@@ -325,23 +379,23 @@ func fromAbstractInputSourceCaps(
 			abscaps.MaxOpticalYResolution),
 
 		RiskyLeftMargins: fromAbstractOptionalInt(
-			abscaps.RiskyLeftMargins.Dots(300)),
+			abscaps.RiskyLeftMargins.Dots(dpi)),
 		RiskyRightMargins: fromAbstractOptionalInt(
-			abscaps.RiskyRightMargins.Dots(300)),
+			abscaps.RiskyRightMargins.Dots(dpi)),
 		RiskyTopMargins: fromAbstractOptionalInt(
-			abscaps.RiskyTopMargins.Dots(300)),
+			abscaps.RiskyTopMargins.Dots(dpi)),
 		RiskyBottomMargins: fromAbstractOptionalInt(
-			abscaps.RiskyBottomMargins.Dots(300)),
+			abscaps.RiskyBottomMargins.Dots(dpi)),
 	}
 
 	if abscaps.MaxXOffset != 0 {
 		caps.MaxXOffset = optional.New(
-			abscaps.MaxXOffset.UpperBoundDots(300))
+			abscaps.MaxXOffset.UpperBoundDots(dpi))
 	}
 
 	if abscaps.MaxYOffset != 0 {
 		caps.MaxYOffset = optional.New(
-			abscaps.MaxYOffset.UpperBoundDots(300))
+			abscaps.MaxYOffset.UpperBoundDots(dpi))
 	}
 
 	// Translate intents
@@ -389,7 +443,7 @@ func fromAbstractScannerCapabilities(
 		abscaps.BrightnessRange)
 	scancaps.ContrastSupport = fromAbstractOptionalRange(
 		abscaps.ContrastRange)
-	scancaps.GammaSupport = fromAbstractOptionalRange(
+	scancaps.GammaSupport = fromAbstractOptionalFloatRange(
 		abscaps.GammaRange)
 	scancaps.HighlightSupport = fromAbstractOptionalRange(
 		abscaps.HighlightRange)
@@ -404,6 +458,14 @@ func fromAbstractScannerCapabilities(
 	scancaps.CompressionFactorSupport = fromAbstractOptionalRange(
 		abscaps.CompressionRange)
 
+	// Translate blank page detection and removal
+	if abscaps.BlankPageDetection || abscaps.BlankPageRemoval {
+		scancaps.BlankPageDetection = optional.New(true)
+	}
+	if abscaps.BlankPageRemoval {
+		scancaps.BlankPageDetectionAndRemoval = optional.New(true)
+	}
+
 	// Translate input capabilities
 	if abscaps.Platen != nil {
 		caps := fromAbstractInputSourceCaps(version,
@@ -411,6 +473,12 @@ func fromAbstractScannerCapabilities(
 		scancaps.Platen = optional.New(Platen{optional.New(caps)})
 	}
 
+	if abscaps.Camera != nil {
+		caps := fromAbstractInputSourceCaps(version,
+			abscaps.DocumentFormats, abscaps.Camera)
+		scancaps.Camera = optional.New(Camera{optional.New(caps)})
+	}
+
 	if abscaps.ADFSimplex != nil || abscaps.ADFDuplex != nil {
 		adf := ADF{
 			FeederCapacity: fromAbstractOptionalInt(
@@ -423,6 +491,26 @@ func fromAbstractScannerCapabilities(
 			adf.ADFSimplexInputCaps = optional.New(caps)
 		}
 
+		// ADF options are common for both ADF inputs
+		var detect, single bool
+		for _, inp := range []*abstract.InputCapabilities{
+			abscaps.ADFSimplex, abscaps.ADFDuplex} {
+			if inp != nil {
+				detect = detect || inp.ADFDetectPaperLoaded
+				single = single || inp.ADFSelectSinglePage
+			}
+		}
+
+		if detect {
+			adf.ADFOptions = append(adf.ADFOptions,
+				DetectPaperLoaded)
+		}
+
+		if single {
+			adf.ADFOptions = append(adf.ADFOptions,
+				SelectSinglePage)
+		}
+
 		if abscaps.ADFDuplex != nil {
 			caps := fromAbstractInputSourceCaps(version,
 				abscaps.DocumentFormats, abscaps.ADFDuplex)
@@ -433,6 +521,22 @@ func fromAbstractScannerCapabilities(
 		scancaps.ADF = optional.New(adf)
 	}
 
+	// eSCL 2.9+: advertise JobSources
+	if version >= MakeVersion(2, 9) {
+		if scancaps.Platen != nil {
+			scancaps.JobSources = append(scancaps.JobSources,
+				InputPlaten)
+		}
+		if scancaps.ADF != nil {
+			scancaps.JobSources = append(scancaps.JobSources,
+				InputFeeder)
+		}
+		if scancaps.Camera != nil {
+			scancaps.JobSources = append(scancaps.JobSources,
+				InputCamera)
+		}
+	}
+
 	return scancaps
 }
 
@@ -448,7 +552,7 @@ func fromAbstractScanSettings(
 		Version:           version,
 		Brightness:        absreq.Brightness,
 		Contrast:          absreq.Contrast,
-		Gamma:             absreq.Gamma,
+		Gamma:             fromAbstractFloat(absreq.Gamma),
 		Highlight:         absreq.Highlight,
 		NoiseRemoval:      absreq.NoiseRemoval,
 		Shadow:            absreq.Shadow,
@@ -456,21 +560,21 @@ func fromAbstractScanSettings(
 		CompressionFactor: absreq.Compression,
 	}
 
-	// Translate intent
+	// Translate intent. eSCL has no special preview flag,
+	// so preview requests are expressed via the Preview
+	// intent, unless intent is set explicitly.
 	intent := fromAbstractIntent(absreq.Intent)
+	if intent == UnknownIntent && absreq.Preview {
+		intent = Preview
+	}
 	if intent != UnknownIntent {
 		ss.Intent = optional.New(intent)
 	}
 
 	// Translate ScanRegions
 	if !absreq.Region.IsZero() {
-		reg := ScanRegion{
-			XOffset:            absreq.Region.XOffset.Dots(300),
-			YOffset:            absreq.Region.YOffset.Dots(300),
-			Width:              absreq.Region.Width.Dots(300),
-			Height:             absreq.Region.Height.Dots(300),
-			ContentRegionUnits: ThreeHundredthsOfInches,
-		}
+		reg := fromAbstractScanRegion(ThreeHundredthsOfInches,
+			absreq.Region)
 		ss.ScanRegions = []ScanRegion{reg}
 	}
 
@@ -492,6 +596,8 @@ func fromAbstractScanSettings(
 		if absreq.ADFMode == abstract.ADFModeDuplex {
 			ss.Duplex = optional.New(true)
 		}
+	case abstract.InputCamera:
+		ss.InputSource = optional.New(InputCamera)
 	}
 
 	// Translate XResolution and YResolution
@@ -531,5 +637,13 @@ func fromAbstractScanSettings(
 		ss.CCDChannel = optional.New(ccd)
 	}
 
+	// Translate blank page detection and removal
+	switch {
+	case absreq.BlankPageRemoval:
+		ss.BlankPageDetectionAndRemoval = optional.New(true)
+	case absreq.BlankPageDetection:
+		ss.BlankPageDetection = optional.New(true)
+	}
+
 	return ss
 }
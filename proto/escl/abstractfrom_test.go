@@ -757,6 +757,19 @@ func TestFromAbstractScannerCapabilities(t *testing.T) {
 		ADFOptions:          []ADFOption{Duplex},
 	}
 
+	adfinpOptions := testAbstractInputCapabilities.Clone()
+	adfinpOptions.ADFDetectPaperLoaded = true
+	adfinpOptions.ADFSelectSinglePage = true
+
+	adfOptions := ADF{
+		ADFSimplexInputCaps: optional.New(abscaps),
+		ADFDuplexInputCaps:  optional.New(abscaps),
+		FeederCapacity:      optional.New(capacity),
+		ADFOptions: []ADFOption{
+			DetectPaperLoaded, SelectSinglePage, Duplex,
+		},
+	}
+
 	tests := []testData{
 		{
 			comment: "Bare minimum",
@@ -780,6 +793,9 @@ func TestFromAbstractScannerCapabilities(t *testing.T) {
 				Version: DefaultVersion,
 				UUID:    optional.New(testAbstractUUID),
 				Platen:  optional.New(platen),
+				JobSources: []InputSource{
+					InputPlaten,
+				},
 			},
 		},
 
@@ -797,6 +813,9 @@ func TestFromAbstractScannerCapabilities(t *testing.T) {
 				UUID:    optional.New(testAbstractUUID),
 				Platen:  optional.New(platen),
 				ADF:     optional.New(adfSimplex),
+				JobSources: []InputSource{
+					InputPlaten, InputFeeder,
+				},
 			},
 		},
 
@@ -815,6 +834,30 @@ func TestFromAbstractScannerCapabilities(t *testing.T) {
 				UUID:    optional.New(testAbstractUUID),
 				Platen:  optional.New(platen),
 				ADF:     optional.New(adfDuplex),
+				JobSources: []InputSource{
+					InputPlaten, InputFeeder,
+				},
+			},
+		},
+
+		{
+			comment: "ADF Duplex with ADF options",
+			in: &abstract.ScannerCapabilities{
+				UUID:            testAbstractUUID,
+				DocumentFormats: formats,
+				ADFCapacity:     capacity,
+				Platen:          testAbstractInputCapabilities,
+				ADFSimplex:      testAbstractInputCapabilities,
+				ADFDuplex:       adfinpOptions,
+			},
+			out: &ScannerCapabilities{
+				Version: DefaultVersion,
+				UUID:    optional.New(testAbstractUUID),
+				Platen:  optional.New(platen),
+				ADF:     optional.New(adfOptions),
+				JobSources: []InputSource{
+					InputPlaten, InputFeeder,
+				},
 			},
 		},
 
@@ -837,7 +880,7 @@ func TestFromAbstractScannerCapabilities(t *testing.T) {
 				ContrastSupport: optional.New(
 					Range{Min: 0, Max: 100, Normal: 75}),
 				GammaSupport: optional.New(
-					Range{Min: 1, Max: 40, Normal: 20}),
+					FloatRange{Min: 1, Max: 40, Normal: 20}),
 				HighlightSupport: optional.New(
 					Range{Min: 0, Max: 100, Normal: 60}),
 				NoiseRemovalSupport: optional.New(
@@ -848,6 +891,41 @@ func TestFromAbstractScannerCapabilities(t *testing.T) {
 					Range{Min: 0, Max: 100, Normal: 15}),
 				ThresholdSupport: optional.New(
 					Range{Min: 0, Max: 100, Normal: 50}),
+				JobSources: []InputSource{
+					InputPlaten,
+				},
+			},
+		},
+
+		{
+			comment: "Camera source",
+			in: &abstract.ScannerCapabilities{
+				UUID:            testAbstractUUID,
+				DocumentFormats: formats,
+				Camera:          testAbstractInputCapabilities,
+			},
+			out: &ScannerCapabilities{
+				Version: DefaultVersion,
+				UUID:    optional.New(testAbstractUUID),
+				Camera: optional.New(
+					Camera{optional.New(abscaps)}),
+				JobSources: []InputSource{
+					InputCamera,
+				},
+			},
+		},
+
+		{
+			comment: "Blank page detection and removal",
+			in: &abstract.ScannerCapabilities{
+				UUID:             testAbstractUUID,
+				BlankPageRemoval: true,
+			},
+			out: &ScannerCapabilities{
+				Version:                      DefaultVersion,
+				UUID:                         optional.New(testAbstractUUID),
+				BlankPageDetection:           optional.New(true),
+				BlankPageDetectionAndRemoval: optional.New(true),
 			},
 		},
 	}
@@ -921,6 +999,18 @@ func TestFromAbstractScanSettings(t *testing.T) {
 			},
 		},
 
+		{
+			comment: "InputCamera",
+			ver:     DefaultVersion,
+			in: &abstract.ScannerRequest{
+				Input: abstract.InputCamera,
+			},
+			out: &ScanSettings{
+				Version:     DefaultVersion,
+				InputSource: optional.New(InputCamera),
+			},
+		},
+
 		{
 			comment: "InputFeeder+Simplex(by default)",
 			ver:     DefaultVersion,
@@ -960,6 +1050,32 @@ func TestFromAbstractScanSettings(t *testing.T) {
 			},
 		},
 
+		// Blank page detection and removal
+		{
+			comment: "BlankPageDetection",
+			ver:     DefaultVersion,
+			in: &abstract.ScannerRequest{
+				BlankPageDetection: true,
+			},
+			out: &ScanSettings{
+				Version:            DefaultVersion,
+				BlankPageDetection: optional.New(true),
+			},
+		},
+
+		{
+			comment: "BlankPageRemoval",
+			ver:     DefaultVersion,
+			in: &abstract.ScannerRequest{
+				BlankPageDetection: true,
+				BlankPageRemoval:   true,
+			},
+			out: &ScanSettings{
+				Version:                      DefaultVersion,
+				BlankPageDetectionAndRemoval: optional.New(true),
+			},
+		},
+
 		// Color modes support
 		{
 			comment: "BlackAndWhite1",
@@ -1176,6 +1292,32 @@ func TestFromAbstractScanSettings(t *testing.T) {
 			},
 		},
 
+		// Preview
+		{
+			comment: "Preview",
+			ver:     DefaultVersion,
+			in: &abstract.ScannerRequest{
+				Preview: true,
+			},
+			out: &ScanSettings{
+				Version: DefaultVersion,
+				Intent:  optional.New(Preview),
+			},
+		},
+
+		{
+			comment: "Preview with Intent",
+			ver:     DefaultVersion,
+			in: &abstract.ScannerRequest{
+				Intent:  abstract.IntentPhoto,
+				Preview: true,
+			},
+			out: &ScanSettings{
+				Version: DefaultVersion,
+				Intent:  optional.New(Photo),
+			},
+		},
+
 		// Image processing parameters
 		{
 			comment: "CCDChannel",
@@ -1194,7 +1336,7 @@ func TestFromAbstractScanSettings(t *testing.T) {
 				Version:           DefaultVersion,
 				Brightness:        optional.New(100),
 				Contrast:          optional.New(80),
-				Gamma:             optional.New(20),
+				Gamma:             optional.New(20.0),
 				Highlight:         optional.New(85),
 				NoiseRemoval:      optional.New(30),
 				Shadow:            optional.New(15),
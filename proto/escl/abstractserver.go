@@ -9,15 +9,19 @@
 package escl
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/OpenPrinting/go-mfp/abstract"
 	"github.com/OpenPrinting/go-mfp/log"
@@ -32,14 +36,60 @@ import (
 // [AbstractServer] keeps on its history.
 const AbstractServerHistorySize = 10
 
+// AbstractServerQueueSize specifies how many scan jobs, including
+// the active one, the [AbstractServer] keeps in its queue. When queue
+// is full, new ScanJobs requests are rejected with the 503 Service
+// Unavailable status.
+//
+// It must not exceed the AbstractServerHistorySize, so all queued
+// jobs remain visible in the ScannerStatus.
+const AbstractServerQueueSize = 4
+
+// AbstractServerDefaultRetryAfter is the default retry interval,
+// suggested by the [AbstractServer] to clients with the Retry-After
+// header of the 503 Service Unavailable responses.
+const AbstractServerDefaultRetryAfter = 2 * time.Second
+
 // AbstractServer implements eSCL server on a top of [abstract.Scanner].
+//
+// ScannerStatus requests don't wait for the scan job operations,
+// which may block for a long time. Instead, they are served from
+// the cached status snapshot, which is invalidated on each status
+// change and regenerated on demand. When many clients poll the status
+// simultaneously, only one of them regenerates the snapshot, and
+// others just wait for the result.
+//
+// Scan jobs are queued and served in order, one at a time. While job
+// is waiting in the queue, it is reported in the ScannerStatus as
+// Pending, with the JobQueued reason, and its NextDocument requests
+// are rejected with the 503 Service Unavailable status, so clients
+// will retry later. These responses, as well as rejects of new jobs
+// due to the queue overflow, include the Retry-After header.
 type AbstractServer struct {
-	ctx      context.Context               // Logging context
-	options  AbstractServerOptions         // Server options
-	caps     *abstract.ScannerCapabilities // Scanner capabilities
-	status   ScannerStatus                 // Scanner status
-	document abstract.Document             // Document being server
-	lock     sync.Mutex                    // Access lock
+	ctx          context.Context               // Logging context
+	options      AbstractServerOptions         // Server options
+	caps         *abstract.ScannerCapabilities // Scanner capabilities
+	status       ScannerStatus                 // Scanner status
+	statusLock   sync.Mutex                    // Protects status
+	statusCache  atomic.Pointer[[]byte]        // Cached status, nil if none
+	statusFlight sync.Mutex                    // Status regeneration lock
+	jobs         []*abstractServerJob          // Job queue, active first
+	history      []*abstractServerJob          // Job history, newest first
+	closed       atomic.Bool                   // Server is closed
+	lock         sync.Mutex                    // Access lock
+}
+
+// abstractServerJob represents a scan job in the AbstractServer queue.
+type abstractServerJob struct {
+	uri      string                  // Job URI
+	req      abstract.ScannerRequest // Scan request
+	document abstract.Document       // Document being served, nil if pending
+	finished bool                    // Job is finished and out of queue
+	stats    AbstractServerJobStats  // Job statistics
+
+	// Documents sent to the client, for the stored jobs
+	// extension. Protected by AbstractServer.lock.
+	documents []abstractServerDocument
 }
 
 // AbstractServerOptions represents the [AbstractServerOptions]
@@ -55,61 +105,176 @@ type AbstractServerOptions struct {
 	// typical hardware eSCL scanner, the URL should be something like
 	// "/eSCL".
 	BasePath string
+
+	// RequireTLS, if set, makes server to reject requests, received
+	// over the non-encrypted connections, with the 403 Forbidden
+	// status.
+	//
+	// Note, the AbstractServer doesn't accept connections by itself,
+	// so TLS must be provided by the caller (for example, using
+	// [transport.Server.ServeAutoTLS]).
+	RequireTLS bool
+
+	// RetryAfter is the retry interval, suggested to clients with
+	// the Retry-After header, when request is rejected because
+	// scanner is busy. It is rounded up to the whole seconds.
+	//
+	// If zero, AbstractServerDefaultRetryAfter is used.
+	RetryAfter time.Duration
+
+	// Auth, if not nil, requires clients to authenticate, using
+	// the HTTP Basic or Digest authentication.
+	Auth *transport.HTTPAuth
+
+	// AllowCORS, if set, enables Cross-Origin Resource Sharing,
+	// so the server can be used directly from the browser-based
+	// clients.
+	//
+	// If enabled, responses to requests with the Origin header
+	// include the Access-Control-Allow-XXX headers, and OPTIONS
+	// preflight requests are answered accordingly. Otherwise,
+	// OPTIONS requests only report allowed methods.
+	AllowCORS bool
+
+	// CORSOrigins, if not empty, lists origins (i.e.,
+	// "https://example.com"), allowed to make credentialed
+	// cross-origin requests. Only these origins are echoed back,
+	// together with Access-Control-Allow-Credentials.
+	//
+	// If empty, any origin is allowed via the "*" wildcard, which
+	// browsers never combine with credentials.
+	//
+	// Ignored, unless AllowCORS is set.
+	CORSOrigins []string
+
+	// StoredJobs, if set, enables the stored jobs extension (see
+	// [ScanJobs]): the GET ScanJobs request returns the listing of
+	// jobs, and documents of jobs, kept in the history, can be
+	// retrieved after job completion with the GET
+	// {JobUri}/Documents/{N} requests (see [Client.GetStoredDocument]).
+	//
+	// Note, documents are kept in memory, for up to the
+	// AbstractServerHistorySize recent jobs.
+	StoredJobs bool
+
+	// CompressXML, if set, enables gzip compression of the XML
+	// responses (i.e., ScannerCapabilities), for clients that
+	// accept it with the Accept-Encoding header. Small responses
+	// are always sent uncompressed.
+	//
+	// Images are never compressed, as image formats are already
+	// compressed or intended to be sent as is.
+	CompressXML bool
+
+	// OnRequest, if not nil, is called for each incoming request,
+	// before it is dispatched.
+	//
+	// It may inspect the request, inject latency or complete the
+	// request by itself (for example, using [AbstractServerQuery.Reject]).
+	// If request is completed by the hook, it is not processed further.
+	OnRequest func(query *AbstractServerQuery)
+
+	// OnScanSettings, if not nil, is called for each ScanJobs
+	// and ScanBufferInfo request, after the [ScanSettings] is
+	// decoded.
+	//
+	// It may modify the ScanSettings or veto the request by
+	// completing it, like OnRequest does.
+	OnScanSettings func(query *AbstractServerQuery, ss *ScanSettings)
+
+	// OnResponse, if not nil, is called when request processing
+	// is finished. [AbstractServerQuery.Status] returns the HTTP
+	// status of the response.
+	OnResponse func(query *AbstractServerQuery)
 }
 
-// abstractServerQuery maintains an AbstractServer query processing
+// AbstractServerQuery maintains an AbstractServer query processing
 // context, allowing per-request centralized logging and hooking.
 //
 // It keeps the reference to the original [http.Request] and wraps
 // the corresponding [http.ResponseWriter], passed to the
-// AbstractServer.ServeHTTP
-type abstractServerQuery struct {
+// AbstractServer.ServeHTTP.
+//
+// It is passed to the hooks, defined in the [AbstractServerOptions].
+type AbstractServerQuery struct {
 	log                 *log.Record  // Log record for the query
 	*http.Request                    // Incoming request
 	http.ResponseWriter              // Underlying http.ResponseWriter
 	status              atomic.Int32 // HTTP status, 0 if not known yet
+	sent                atomic.Int64 // Response body bytes sent
+	compress            bool         // Compress XML responses
 }
 
-// newAbstractServerQuery returns the new abstractServerQuery
+// newAbstractServerQuery returns the new AbstractServerQuery
 func newAbstractServerQuery(srv *AbstractServer,
-	w http.ResponseWriter, rq *http.Request) *abstractServerQuery {
+	w http.ResponseWriter, rq *http.Request) *AbstractServerQuery {
 
-	query := &abstractServerQuery{
+	query := &AbstractServerQuery{
 		log:            log.Begin(srv.ctx),
 		Request:        rq,
 		ResponseWriter: w,
+		compress:       srv.options.CompressXML,
 	}
 
 	return query
 }
 
+// Status returns HTTP status of the response, or 0, if response
+// header is not written yet.
+func (query *AbstractServerQuery) Status() int {
+	return int(query.status.Load())
+}
+
 // RequestHeader returns http.Header of the request
-func (query *abstractServerQuery) RequestHeader() http.Header {
+func (query *AbstractServerQuery) RequestHeader() http.Header {
 	return query.Request.Header
 }
 
 // Finish must be called when query processing is finished
-func (query *abstractServerQuery) Finish() {
+func (query *AbstractServerQuery) Finish() {
 	query.log.Commit()
 }
 
 // RequestBody returns body of the http.Request
-func (query *abstractServerQuery) RequestBody() io.ReadCloser {
+func (query *AbstractServerQuery) RequestBody() io.ReadCloser {
 	return query.Request.Body
 }
 
 // ResponseHeader returns http.Header of the response
-func (query *abstractServerQuery) ResponseHeader() http.Header {
+func (query *AbstractServerQuery) ResponseHeader() http.Header {
 	return query.ResponseWriter.Header()
 }
 
 // Write writes response body bytes.
-func (query *abstractServerQuery) Write(data []byte) (int, error) {
-	return query.ResponseWriter.Write(data)
+//
+// For the HEAD requests, body is silently discarded.
+func (query *AbstractServerQuery) Write(data []byte) (int, error) {
+	if query.Method == "HEAD" {
+		return len(data), nil
+	}
+
+	n, err := query.ResponseWriter.Write(data)
+	query.sent.Add(int64(n))
+	return n, err
+}
+
+// ReadFrom copies response body bytes from r. It implements the
+// [io.ReaderFrom] interface and allows the underlying
+// http.ResponseWriter to use the zero-copy fast path.
+//
+// For the HEAD requests, body is silently discarded.
+func (query *AbstractServerQuery) ReadFrom(r io.Reader) (int64, error) {
+	if query.Method == "HEAD" {
+		return io.Copy(io.Discard, r)
+	}
+
+	n, err := transport.ResponseReadFrom(query.ResponseWriter, r)
+	query.sent.Add(n)
+	return n, err
 }
 
 // WriteHeader writes HTTP response header.
-func (query *abstractServerQuery) WriteHeader(status int) {
+func (query *AbstractServerQuery) WriteHeader(status int) {
 	if query.status.CompareAndSwap(0, int32(status)) {
 		query.ResponseWriter.WriteHeader(status)
 		query.log.Debug("HTTP %s %s -- %d %s",
@@ -120,7 +285,7 @@ func (query *abstractServerQuery) WriteHeader(status int) {
 }
 
 // NoCache set response headers to disable client-side response cacheing.
-func (query *abstractServerQuery) NoCache() {
+func (query *AbstractServerQuery) NoCache() {
 	hdr := query.ResponseHeader()
 	hdr.Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	hdr.Set("Pragma", "no-cache")
@@ -128,7 +293,7 @@ func (query *abstractServerQuery) NoCache() {
 }
 
 // Reject completes request with a error.
-func (query *abstractServerQuery) Reject(status int, err error) {
+func (query *AbstractServerQuery) Reject(status int, err error) {
 	query.ResponseHeader().Set("Content-Type", "text/plain; charset=utf-8")
 	query.NoCache()
 	query.WriteHeader(status)
@@ -144,7 +309,7 @@ func (query *abstractServerQuery) Reject(status int, err error) {
 
 // Created completes request with the http.StatusCreated
 // status and Location: URL
-func (query *abstractServerQuery) Created(joburi string) {
+func (query *AbstractServerQuery) Created(joburi string) {
 	scheme := "http"
 	if query.TLS != nil {
 		scheme = "https"
@@ -157,17 +322,73 @@ func (query *abstractServerQuery) Created(joburi string) {
 }
 
 // SendXML sends the XML response.
-func (query *abstractServerQuery) SendXML(xml xmldoc.Element) {
-	query.ResponseHeader().Set("Content-Type", HTTPContentType)
+//
+// If enabled by the [AbstractServerOptions], response is compressed,
+// if client accepts it.
+func (query *AbstractServerQuery) SendXML(xml xmldoc.Element) {
+	data := []byte(xml.EncodeIndentString(NsMap, "  "))
+
+	hdr := query.ResponseHeader()
+	hdr.Set("Content-Type", HTTPContentType)
+
+	if query.compress {
+		hdr.Add("Vary", "Accept-Encoding")
+		if len(data) >= compressMinSize &&
+			compressAcceptsGzip(query.RequestHeader()) {
+			data = compressGzip(data)
+			hdr.Set("Content-Encoding", "gzip")
+		}
+	}
+
+	hdr.Set("Content-Length", strconv.Itoa(len(data)))
 	query.WriteHeader(http.StatusOK)
-	xml.EncodeIndent(query, NsMap, "  ")
+	query.Write(data)
 }
 
-// SendImage sends the scanned image
-func (query *abstractServerQuery) SendImage(file abstract.DocumentFile) {
-	query.ResponseHeader().Set("Content-Type", file.Format())
+// SendImage sends the scanned image.
+//
+// If image is backed by the os.File (see [abstract.DocumentOSFile]),
+// Content-Length is set and image is sent using the zero-copy
+// fast path, if possible.
+//
+// The image digest is sent in the Content-Digest header (RFC 9530),
+// so clients can detect truncated or corrupted transfers. If image
+// is streamed, the digest is computed on the fly and sent in the
+// HTTP trailer. For the os.File-backed images, the digest is sent
+// only if it is known in advance (see [abstract.DocumentSHA256]),
+// so the file is never read twice.
+func (query *AbstractServerQuery) SendImage(file abstract.DocumentFile) {
+	hdr := query.ResponseHeader()
+	hdr.Set("Content-Type", file.Format())
+
+	// Send image from os.File
+	if osfile, ok := file.(abstract.DocumentOSFile); ok {
+		if fp, size := osfile.OSFile(); fp != nil {
+			hdr.Set("Content-Length", strconv.FormatInt(size, 10))
+
+			if digest, ok := file.(abstract.DocumentSHA256); ok {
+				if sum := digest.SHA256(); sum != nil {
+					hdr.Set(transport.ContentDigestHeader,
+						transport.FormatContentDigest(sum))
+				}
+			}
+
+			query.WriteHeader(http.StatusOK)
+			query.ReadFrom(&io.LimitedReader{R: fp, N: size})
+			return
+		}
+	}
+
+	// Stream the image
+	hdr.Set("Trailer", transport.ContentDigestHeader)
 	query.WriteHeader(http.StatusOK)
-	io.Copy(query, file)
+
+	hash := transport.NewContentDigest()
+	_, err := query.ReadFrom(io.TeeReader(file, hash))
+	if err == nil {
+		hdr.Set(transport.ContentDigestHeader,
+			transport.FormatContentDigest(hash.Sum(nil)))
+	}
 }
 
 // NewAbstractServer returns a new [AbstractServer].
@@ -179,6 +400,10 @@ func NewAbstractServer(ctx context.Context,
 		options.Version = DefaultVersion
 	}
 
+	if options.RetryAfter == 0 {
+		options.RetryAfter = AbstractServerDefaultRetryAfter
+	}
+
 	// Canonicalize the base path
 	options.BasePath = transport.CleanURLPath(options.BasePath + "/")
 
@@ -195,7 +420,8 @@ func NewAbstractServer(ctx context.Context,
 	}
 
 	if srv.caps.ADFSimplex != nil || srv.caps.ADFDuplex != nil {
-		srv.status.ADFState = optional.New(ScannerAdfProcessing)
+		adfstate := srv.adfIdleState(ScannerAdfLoaded)
+		srv.status.ADFState = optional.New(adfstate)
 	}
 
 	return srv
@@ -204,10 +430,57 @@ func NewAbstractServer(ctx context.Context,
 // ServeHTTP serves incoming HTTP requests.
 // It implements the [http.Handler] interface.
 func (srv *AbstractServer) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
-	// Create a abstractServerQuery
+	// Create a AbstractServerQuery
 	query := newAbstractServerQuery(srv, w, rq)
 	defer query.Finish()
 
+	// Call hooks
+	if srv.options.OnResponse != nil {
+		defer srv.options.OnResponse(query)
+	}
+
+	if srv.options.OnRequest != nil {
+		srv.options.OnRequest(query)
+		if query.Status() != 0 {
+			return
+		}
+	}
+
+	// Add CORS headers
+	if srv.options.AllowCORS {
+		srv.corsHeaders(query)
+	}
+
+	// Reject requests to the closed server
+	if srv.closed.Load() {
+		err := errors.New("Server closed")
+		query.Reject(http.StatusServiceUnavailable, err)
+		return
+	}
+
+	// Check security requirements
+	if srv.options.RequireTLS && query.TLS == nil {
+		err := errors.New("TLS required")
+		query.Reject(http.StatusForbidden, err)
+		return
+	}
+
+	// OPTIONS requests are served before authentication,
+	// as CORS preflight requests never carry credentials.
+	if rq.Method == "OPTIONS" {
+		srv.serveOptions(query)
+		return
+	}
+
+	if srv.options.Auth != nil {
+		_, ok := srv.options.Auth.Authenticate(query.ResponseWriter,
+			query.Request)
+		if !ok {
+			query.Reject(http.StatusUnauthorized, nil)
+			return
+		}
+	}
+
 	// Dispatch the request
 	if !strings.HasPrefix(query.URL.Path, srv.options.BasePath) {
 		query.Reject(http.StatusNotFound, nil)
@@ -217,48 +490,71 @@ func (srv *AbstractServer) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 	path, _ := missed.StringsCutPrefix(query.URL.Path,
 		srv.options.BasePath)
 
+	// ScannerStatus is served without taking srv.lock, so status
+	// polls are not serialized behind the scan job operations.
+	if path == "ScannerStatus" &&
+		(rq.Method == "GET" || rq.Method == "HEAD") {
+		srv.getScannerStatus(query)
+		return
+	}
+
 	// Handle {root}-relative requests
-	var action func(*abstractServerQuery)
+	var action func(*AbstractServerQuery)
 
 	srv.lock.Lock()
 
 	switch path {
 	case "ScannerCapabilities":
-		if query.Method == "GET" {
+		if query.Method == "GET" || query.Method == "HEAD" {
 			action = srv.getScannerCapabilities
 		}
 
-	case "ScannerStatus":
-		if rq.Method == "GET" {
-			action = srv.getScannerStatus
-		}
-
 	case "ScanJobs":
-		if rq.Method == "POST" {
+		switch {
+		case rq.Method == "POST":
 			action = srv.postScanJobs
+		case rq.Method == "GET" && srv.options.StoredJobs:
+			action = srv.getScanJobs
+		}
+
+	case "ScanBufferInfo":
+		// eSCL specifies PUT, but some clients use GET
+		// with the request body.
+		if rq.Method == "PUT" || rq.Method == "GET" {
+			action = srv.putScanBufferInfo
 		}
 	}
 
 	// Handle {JobUri}-relative requests
-	if action == nil && srv.document != nil {
-		joburi := srv.status.Jobs[0].JobURI
+	for i := 0; action == nil && i < len(srv.jobs); i++ {
+		job := srv.jobs[i]
 
 		switch rq.Method {
 		case "GET":
 			switch query.URL.Path {
-			case joburi + "/NextDocument":
-				action = srv.getJobURINextDocument
-			case joburi + "/ScanImageInfo":
-				action = srv.getJobURIScanImageInfo
+			case job.uri + "/NextDocument":
+				action = func(query *AbstractServerQuery) {
+					srv.getJobURINextDocument(query, job)
+				}
+			case job.uri + "/ScanImageInfo":
+				action = func(query *AbstractServerQuery) {
+					srv.getJobURIScanImageInfo(query, job)
+				}
 			}
 
 		case "DELETE":
-			if query.URL.Path == joburi {
-				action = srv.deleteJobURI
+			if query.URL.Path == job.uri {
+				action = func(query *AbstractServerQuery) {
+					srv.deleteJobURI(query, job)
+				}
 			}
 		}
 	}
 
+	if action == nil {
+		action = srv.storedAction(query)
+	}
+
 	srv.lock.Unlock()
 
 	if action != nil {
@@ -268,49 +564,293 @@ func (srv *AbstractServer) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 	}
 }
 
-// getScannerCapabilities handles GET /{root}/ScannerCapabilities request
-func (srv *AbstractServer) getScannerCapabilities(query *abstractServerQuery) {
+// Close closes the AbstractServer.
+//
+// Active and queued jobs are aborted, and all subsequent requests
+// are rejected with the 503 Service Unavailable status. The
+// underlying abstract.Scanner is not closed, it is owned by the
+// caller.
+func (srv *AbstractServer) Close() {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if srv.closed.Swap(true) {
+		return
+	}
+
+	for len(srv.jobs) != 0 {
+		srv.finishLocked(srv.jobs[0], JobAborted, AbortedBySystem)
+	}
+
+	srv.statusUpdate(func(status *ScannerStatus) {
+		status.State = ScannerDown
+	})
+}
+
+// serveOptions handles OPTIONS requests.
+//
+// It reports methods, allowed for the requested path, in the
+// Allow header and, if CORS is enabled, in the preflight response
+// headers.
+func (srv *AbstractServer) serveOptions(query *AbstractServerQuery) {
+	methods := srv.allowedMethods(query.URL.Path)
+	if methods == nil {
+		query.Reject(http.StatusNotFound, nil)
+		return
+	}
+
+	allow := strings.Join(methods, ", ")
+	hdr := query.ResponseHeader()
+	hdr.Set("Allow", allow)
+
+	if srv.corsAllowed(query.RequestHeader().Get("Origin")) {
+		hdr.Set("Access-Control-Allow-Methods", allow)
+
+		headers := query.RequestHeader().Get(
+			"Access-Control-Request-Headers")
+		if headers == "" {
+			headers = "Authorization, Content-Type"
+		}
+		hdr.Set("Access-Control-Allow-Headers", headers)
+		hdr.Set("Access-Control-Max-Age", "600")
+	}
+
+	query.WriteHeader(http.StatusNoContent)
+}
+
+// corsHeaders adds CORS headers to the response, if request
+// contains the Origin header, allowed by the server options.
+//
+// Origins from the CORSOrigins list are echoed back, with the
+// credentials allowed. Otherwise, the wildcard is used, and
+// credentials are not allowed.
+func (srv *AbstractServer) corsHeaders(query *AbstractServerQuery) {
+	origin := query.RequestHeader().Get("Origin")
+	if !srv.corsAllowed(origin) {
+		return
+	}
+
+	hdr := query.ResponseHeader()
+	if len(srv.options.CORSOrigins) != 0 {
+		hdr.Set("Access-Control-Allow-Origin", origin)
+		hdr.Set("Access-Control-Allow-Credentials", "true")
+		hdr.Add("Vary", "Origin")
+	} else {
+		hdr.Set("Access-Control-Allow-Origin", "*")
+	}
+
+	hdr.Set("Access-Control-Expose-Headers", "Location")
+}
+
+// corsAllowed reports if cross-origin requests from the origin
+// are allowed.
+func (srv *AbstractServer) corsAllowed(origin string) bool {
+	switch {
+	case !srv.options.AllowCORS || origin == "":
+		return false
+	case len(srv.options.CORSOrigins) == 0:
+		return true
+	}
+
+	return slices.Contains(srv.options.CORSOrigins, origin)
+}
+
+// allowedMethods returns HTTP methods, allowed for the URL path,
+// or nil, if path is not known.
+func (srv *AbstractServer) allowedMethods(urlpath string) []string {
+	path, ok := missed.StringsCutPrefix(urlpath, srv.options.BasePath)
+	if !ok {
+		return nil
+	}
+
+	switch path {
+	case "ScannerCapabilities", "ScannerStatus":
+		return []string{"GET", "HEAD", "OPTIONS"}
+	case "ScanJobs":
+		if srv.options.StoredJobs {
+			return []string{"GET", "POST", "OPTIONS"}
+		}
+		return []string{"POST", "OPTIONS"}
+	case "ScanBufferInfo":
+		return []string{"GET", "PUT", "OPTIONS"}
+	}
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	for _, job := range srv.jobs {
+		switch urlpath {
+		case job.uri:
+			return []string{"DELETE", "OPTIONS"}
+		case job.uri + "/NextDocument", job.uri + "/ScanImageInfo":
+			return []string{"GET", "OPTIONS"}
+		}
+	}
+
+	return srv.storedAllowedMethods(urlpath)
+}
+
+// getScannerCapabilities handles GET and HEAD /{root}/ScannerCapabilities
+// requests
+func (srv *AbstractServer) getScannerCapabilities(query *AbstractServerQuery) {
 	ver := srv.status.Version
 	xml := fromAbstractScannerCapabilities(ver, srv.caps).ToXML()
 	query.SendXML(xml)
 }
 
-// getScannerStatus handles GET /{root}/ScannerStatus request
-func (srv *AbstractServer) getScannerStatus(query *abstractServerQuery) {
-	srv.lock.Lock()
-	xml := srv.status.ToXML()
-	srv.lock.Unlock()
+// getScannerStatus handles GET and HEAD /{root}/ScannerStatus requests
+func (srv *AbstractServer) getScannerStatus(query *AbstractServerQuery) {
+	data := srv.statusSnapshot()
 
-	query.SendXML(xml)
+	hdr := query.ResponseHeader()
+	hdr.Set("Content-Type", HTTPContentType)
+	hdr.Set("Content-Length", strconv.Itoa(len(data)))
+	query.WriteHeader(http.StatusOK)
+	query.Write(data)
 }
 
-// postScanJobs handles POST /{root}/ScanJobs
-func (srv *AbstractServer) postScanJobs(query *abstractServerQuery) {
-	srv.lock.Lock()
-	defer srv.lock.Unlock()
+// statusSnapshot returns the encoded ScannerStatus XML.
+//
+// If cached snapshot is valid, it is returned immediately. Otherwise,
+// the snapshot is regenerated. Only one goroutine at a time performs
+// regeneration; concurrent callers wait and use its result.
+func (srv *AbstractServer) statusSnapshot() []byte {
+	if data := srv.statusCache.Load(); data != nil {
+		return *data
+	}
+
+	srv.statusFlight.Lock()
+	defer srv.statusFlight.Unlock()
+
+	// Recheck: snapshot may be regenerated while we were waiting
+	if data := srv.statusCache.Load(); data != nil {
+		return *data
+	}
+
+	// Encode and save under the statusLock, so concurrent
+	// statusUpdate will not be lost.
+	srv.statusLock.Lock()
+	defer srv.statusLock.Unlock()
+
+	buf := &bytes.Buffer{}
+	srv.status.ToXML().EncodeIndent(buf, NsMap, "  ")
+	data := buf.Bytes()
+	srv.statusCache.Store(&data)
+
+	return data
+}
+
+// statusUpdate updates the scanner status and invalidates the
+// cached status snapshot.
+//
+// The update callback is called under the statusLock. Callers are
+// also expected to hold srv.lock, so srv.status can be safely read
+// while holding either of these locks.
+func (srv *AbstractServer) statusUpdate(update func(*ScannerStatus)) {
+	srv.statusLock.Lock()
+	update(&srv.status)
+	srv.statusCache.Store(nil)
+	srv.statusLock.Unlock()
+}
+
+// jobUpdate updates the JobInfo of the job in the scanner status.
+// Like statusUpdate, it must be called under srv.lock.
+func (srv *AbstractServer) jobUpdate(job *abstractServerJob,
+	update func(*JobInfo)) {
+
+	srv.statusUpdate(func(status *ScannerStatus) {
+		for i := range status.Jobs {
+			if status.Jobs[i].JobURI == job.uri {
+				update(&status.Jobs[i])
+				return
+			}
+		}
+	})
+}
+
+// scanSettings fetches and validates the ScanSettings from the
+// body of the ScanJobs or ScanBufferInfo request.
+//
+// On error, the request is rejected and nil is returned.
+func (srv *AbstractServer) scanSettings(query *AbstractServerQuery) (
+	*ScanSettings, *ScannerCapabilities) {
 
 	// Fetch the XML request body
-	xml, err := xmldoc.Decode(NsMap, query.RequestBody())
+	xml, err := nsDecode(query.RequestBody())
 	if err != nil {
 		query.Reject(http.StatusBadRequest, err)
-		return
+		return nil, nil
 	}
 
 	// Decode ScanSettings request
 	ss, err := DecodeScanSettings(xml)
 	if err != nil {
 		query.Reject(http.StatusBadRequest, err)
+		return nil, nil
+	}
+
+	// Call the hook. Do it before srv.lock is taken, as hook
+	// may take a long time.
+	if srv.options.OnScanSettings != nil {
+		srv.options.OnScanSettings(query, ss)
+		if query.Status() != 0 {
+			return nil, nil
+		}
+	}
+
+	// Validate ScanSettings (possibly, modified by the hook)
+	// against our capabilities, so client will know exactly
+	// what is wrong with the request.
+	scancaps := fromAbstractScannerCapabilities(srv.options.Version,
+		srv.caps)
+	err = ss.Validate(scancaps)
+	if err != nil {
+		query.Reject(http.StatusConflict, err)
+		return nil, nil
+	}
+
+	return ss, scancaps
+}
+
+// putScanBufferInfo handles PUT /{root}/ScanBufferInfo
+//
+// It reports the estimated image parameters for the ScanSettings,
+// sent in the request body, without starting the scan job.
+func (srv *AbstractServer) putScanBufferInfo(query *AbstractServerQuery) {
+	ss, scancaps := srv.scanSettings(query)
+	if ss == nil {
+		return
+	}
+
+	info := EstimateScanBufferInfo(scancaps, *ss)
+	query.SendXML(info.ToXML())
+}
+
+// postScanJobs handles POST /{root}/ScanJobs
+func (srv *AbstractServer) postScanJobs(query *AbstractServerQuery) {
+	ss, _ := srv.scanSettings(query)
+	if ss == nil {
 		return
 	}
 
-	// Check if previous request already in progress
-	if srv.document != nil {
-		err := errors.New("Device is busy with the previous request")
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	// Server may be closed while request was dispatched
+	if srv.closed.Load() {
+		err := errors.New("Server closed")
 		query.Reject(http.StatusServiceUnavailable, err)
 		return
 	}
 
-	// Convert it into the abstract.ScannerRequest and validate
+	// Check if queue is full
+	if len(srv.jobs) >= AbstractServerQueueSize {
+		err := errors.New("Device is busy with the previous requests")
+		srv.rejectBusy(query, err)
+		return
+	}
+
+	// Convert it into the abstract.ScannerRequest
 	absreq := ss.ToAbstract()
 
 	// Generate a new Job UUID. Do it now, because in theory
@@ -322,73 +862,262 @@ func (srv *AbstractServer) postScanJobs(query *abstractServerQuery) {
 		return
 	}
 
-	// Send request to the underlying abstract.Scanner
-	document, err := srv.options.Scanner.Scan(srv.ctx, absreq)
+	jobuuid := uu.URN()
+	joburi := path.Join(srv.options.BasePath, "ScanJobs", jobuuid)
+
+	job := &abstractServerJob{
+		uri: joburi,
+		req: absreq,
+		stats: AbstractServerJobStats{
+			Created: time.Now(),
+		},
+	}
+
+	info := JobInfo{
+		JobURI:          joburi,
+		JobUUID:         optional.New(jobuuid),
+		JobState:        JobPending,
+		JobStateReasons: []JobStateReason{JobQueued},
+	}
+
+	if len(srv.jobs) == 0 {
+		// Queue is empty. Send request to the underlying
+		// abstract.Scanner immediately.
+		job.document, err = srv.options.Scanner.Scan(srv.ctx, absreq)
+		job.stats.Started = job.stats.Created
+		info.JobState = JobProcessing
+		info.JobStateReasons = nil
+		srv.adfStarted(job, err)
+	} else {
+		// Job will wait in the queue. Validate request now,
+		// so client will know about problems immediately.
+		err = absreq.Validate(srv.caps)
+	}
+
 	if err != nil {
 		query.Reject(http.StatusConflict, err)
 		return
 	}
 
 	// Update server status
-	srv.document = document
-	srv.status.State = ScannerProcessing
-
-	jobuuid := uu.URN()
-	joburi := path.Join(srv.options.BasePath, "ScanJobs", jobuuid)
-
-	info := JobInfo{
-		JobURI:   joburi,
-		JobUUID:  optional.New(jobuuid),
-		JobState: JobProcessing,
-	}
+	srv.jobs = append(srv.jobs, job)
+	srv.historyPush(job)
 
-	srv.status.PushJobInfo(info, AbstractServerHistorySize)
+	srv.statusUpdate(func(status *ScannerStatus) {
+		status.State = ScannerProcessing
+		status.PushJobInfo(info, AbstractServerHistorySize)
+	})
 
 	// Complete the request
 	query.Created(joburi)
 }
 
 // getJobURINextDocument handles GET /{JobUri}/NextDocument
-func (srv *AbstractServer) getJobURINextDocument(query *abstractServerQuery) {
+func (srv *AbstractServer) getJobURINextDocument(query *AbstractServerQuery,
+	job *abstractServerJob) {
+
 	srv.lock.Lock()
-	file, err := srv.document.Next()
+
+	switch {
+	case job.finished:
+		// Job is finished while request was dispatched
+		srv.lock.Unlock()
+		query.Reject(http.StatusNotFound, nil)
+		return
+
+	case job.document == nil:
+		// Job is waiting in the queue
+		srv.lock.Unlock()
+		err := errors.New("Job is queued")
+		srv.rejectBusy(query, err)
+		return
+	}
+
+	file, err := job.document.Next()
 	srv.lock.Unlock()
 
 	switch {
 	case err == io.EOF:
-		srv.finish(JobCompleted, JobCompletedSuccessfully)
+		srv.finish(job, JobCompleted, JobCompletedSuccessfully, nil)
 		query.Reject(http.StatusNotFound, nil)
 
+	case abstract.ADFStateOfError(err) != abstract.ADFStateUnknown:
+		// ADF failure. Client will find details in the
+		// ScannerStatus, so don't ask it to retry.
+		srv.finish(job, JobAborted, AbortedBySystem, err)
+		query.Reject(http.StatusConflict, err)
+
 	case err != nil:
-		srv.finish(JobCanceled, AbortedBySystem)
+		srv.finish(job, JobCanceled, AbortedBySystem, err)
 		query.Reject(http.StatusServiceUnavailable, err)
 
+	case srv.options.StoredJobs:
+		stored := &abstractServerStoredFile{DocumentFile: file}
+		query.SendImage(stored)
+		srv.jobStore(job, stored)
+		srv.jobPageSent(job, query.sent.Load())
+
 	default:
 		query.SendImage(file)
+		srv.jobPageSent(job, query.sent.Load())
 	}
 }
 
+// rejectBusy completes request with the 503 Service Unavailable
+// status and the Retry-After header, so client will retry later.
+func (srv *AbstractServer) rejectBusy(query *AbstractServerQuery,
+	err error) {
+
+	secs := (srv.options.RetryAfter + time.Second - 1) / time.Second
+	query.ResponseHeader().Set("Retry-After", strconv.Itoa(int(secs)))
+	query.Reject(http.StatusServiceUnavailable, err)
+}
+
 // getJobURIScanImageInfo handles GET /{JobUri}/ScanImageInfo
-func (srv *AbstractServer) getJobURIScanImageInfo(query *abstractServerQuery) {
+func (srv *AbstractServer) getJobURIScanImageInfo(query *AbstractServerQuery,
+	job *abstractServerJob) {
 	query.Reject(http.StatusNotImplemented, nil)
 }
 
 // deleteJobURI handles DELETE /{JobUri}
-func (srv *AbstractServer) deleteJobURI(query *abstractServerQuery) {
-	srv.finish(JobCanceled, JobCanceledByUser)
+func (srv *AbstractServer) deleteJobURI(query *AbstractServerQuery,
+	job *abstractServerJob) {
+	srv.finish(job, JobCanceled, JobCanceledByUser, nil)
 	query.WriteHeader(http.StatusOK)
 }
 
-// finish finishes the current job and updates server state
-func (srv *AbstractServer) finish(state JobState, reason JobStateReason) {
+// finish finishes the job, removes it from the queue, starts the
+// next queued job, if any, and updates server state.
+//
+// If job is finished due to error, err must be not nil. It is
+// used to update the ADF state.
+func (srv *AbstractServer) finish(job *abstractServerJob,
+	state JobState, reason JobStateReason, err error) {
+
 	srv.lock.Lock()
 	defer srv.lock.Unlock()
 
-	srv.document.Close()
-	srv.document = nil
-	srv.status.State = ScannerIdle
-	srv.status.Jobs[0].JobState = state
-	if reason != UnknownJobStateReason {
-		srv.status.Jobs[0].JobStateReasons = []JobStateReason{reason}
+	srv.finishLocked(job, state, reason)
+	srv.adfError(err)
+	srv.startNext()
+
+	if len(srv.jobs) == 0 {
+		srv.statusUpdate(func(status *ScannerStatus) {
+			status.State = ScannerIdle
+		})
+	}
+}
+
+// finishLocked finishes the job and removes it from the queue.
+// Must be called under srv.lock.
+func (srv *AbstractServer) finishLocked(job *abstractServerJob,
+	state JobState, reason JobStateReason) {
+
+	if job.finished {
+		return
+	}
+
+	job.finished = true
+	job.stats.Finished = time.Now()
+	if job.document != nil {
+		job.document.Close()
+
+		// If ADF was in use, it is not processing anymore.
+		// If all pages are received, ADF is most likely empty.
+		if job.req.Input == abstract.InputADF {
+			dflt := ScannerAdfLoaded
+			if state == JobCompleted {
+				dflt = ScannerAdfEmpty
+			}
+			srv.adfUpdate(srv.adfIdleState(dflt))
+		}
+	}
+
+	srv.jobs = slices.DeleteFunc(srv.jobs, func(j *abstractServerJob) bool {
+		return j == job
+	})
+
+	srv.jobUpdate(job, func(info *JobInfo) {
+		info.JobState = state
+		info.JobStateReasons = nil
+		if reason != UnknownJobStateReason {
+			info.JobStateReasons = []JobStateReason{reason}
+		}
+	})
+}
+
+// startNext starts the first queued job, if there is no active job.
+// Jobs that cannot be started are aborted.
+// Must be called under srv.lock.
+func (srv *AbstractServer) startNext() {
+	for len(srv.jobs) != 0 && srv.jobs[0].document == nil {
+		job := srv.jobs[0]
+
+		document, err := srv.options.Scanner.Scan(srv.ctx, job.req)
+		srv.adfStarted(job, err)
+
+		if err != nil {
+			log.Debug(srv.ctx, "eSCL: %s: %s", job.uri, err)
+			srv.finishLocked(job, JobAborted, AbortedBySystem)
+			continue
+		}
+
+		job.document = document
+		job.stats.Started = time.Now()
+		srv.jobUpdate(job, func(info *JobInfo) {
+			info.JobState = JobProcessing
+			info.JobStateReasons = nil
+		})
+	}
+}
+
+// adfStarted updates the ADF state when the job is started.
+// The err is the error, returned by the abstract.Scanner.Scan.
+// Must be called under srv.lock.
+func (srv *AbstractServer) adfStarted(job *abstractServerJob, err error) {
+	switch {
+	case err != nil:
+		srv.adfError(err)
+	case job.req.Input == abstract.InputADF:
+		srv.adfUpdate(ScannerAdfProcessing)
+	}
+}
+
+// adfError updates the ADF state, if err indicates the ADF failure
+// (jam, empty feeder and so on).
+// Must be called under srv.lock.
+func (srv *AbstractServer) adfError(err error) {
+	adfstate := fromAbstractADFState(abstract.ADFStateOfError(err))
+	if adfstate != UnknownADFState {
+		srv.adfUpdate(adfstate)
+	}
+}
+
+// adfUpdate sets the ADF state in the scanner status.
+// It does nothing, if scanner doesn't have ADF.
+// Must be called under srv.lock.
+func (srv *AbstractServer) adfUpdate(adfstate ADFState) {
+	if srv.status.ADFState == nil || *srv.status.ADFState == adfstate {
+		return
+	}
+
+	srv.statusUpdate(func(status *ScannerStatus) {
+		status.ADFState = optional.New(adfstate)
+	})
+}
+
+// adfIdleState returns the ADF state while ADF is not in use.
+//
+// If the underlying abstract.Scanner knows the actual ADF state
+// (implements the [abstract.ScannerADFState] interface), this
+// state is returned. Otherwise, dflt is returned.
+func (srv *AbstractServer) adfIdleState(dflt ADFState) ADFState {
+	if s, ok := srv.options.Scanner.(abstract.ScannerADFState); ok {
+		adfstate := fromAbstractADFState(s.ADFState())
+		if adfstate != UnknownADFState {
+			return adfstate
+		}
 	}
+
+	return dflt
 }
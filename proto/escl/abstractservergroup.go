@@ -0,0 +1,194 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Multiple scanners, served by the single handler
+
+package escl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// AbstractServerGroup serves multiple [abstract.Scanner] instances,
+// each by its own [AbstractServer], from the single [http.Handler].
+//
+// Servers are distinguished by the base path (i.e., "/eSCL1" and
+// "/eSCL2") and, optionally, by the host name, the request is
+// addressed to (the Host header), so the single process may present
+// a fleet of devices to the network.
+//
+// All servers of the group share the same logging context, with the
+// per-server log prefix, and the lifecycle: when the group is closed,
+// all its servers are closed as well.
+//
+// AbstractServerGroup implements the [http.Handler] interface.
+type AbstractServerGroup struct {
+	ctx     context.Context             // Logging context
+	members []*abstractServerGroupEntry // Members, in lookup order
+	closed  bool                        // Group is closed
+	lock    sync.RWMutex                // Access lock
+}
+
+// abstractServerGroupEntry represents a member of the
+// AbstractServerGroup.
+type abstractServerGroupEntry struct {
+	host string          // Host name, "" for any
+	srv  *AbstractServer // The server
+}
+
+// NewAbstractServerGroup creates a new, empty, AbstractServerGroup.
+func NewAbstractServerGroup(ctx context.Context) *AbstractServerGroup {
+	return &AbstractServerGroup{ctx: ctx}
+}
+
+// Add creates a new [AbstractServer] with the given options and
+// adds it to the group.
+//
+// If host is not empty, the server serves only requests addressed
+// to this host (port is ignored, comparison is case-insensitive).
+// Otherwise, it serves requests addressed to any host, if there is
+// no more specific server for the request's host.
+//
+// The pair of host and BasePath must be unique within the group.
+func (grp *AbstractServerGroup) Add(host string,
+	options AbstractServerOptions) (*AbstractServer, error) {
+
+	basepath := transport.CleanURLPath(options.BasePath + "/")
+
+	grp.lock.Lock()
+	defer grp.lock.Unlock()
+
+	if grp.closed {
+		return nil, errors.New("AbstractServerGroup: closed")
+	}
+
+	for _, member := range grp.members {
+		if strings.EqualFold(member.host, host) &&
+			member.srv.options.BasePath == basepath {
+			return nil, fmt.Errorf(
+				"AbstractServerGroup: %s%s: already in use",
+				host, basepath)
+		}
+	}
+
+	ctx := log.WithPrefix(grp.ctx, host+basepath)
+	srv := NewAbstractServer(ctx, options)
+
+	grp.members = append(grp.members, &abstractServerGroupEntry{
+		host: host,
+		srv:  srv,
+	})
+
+	// Host-specific servers go first, then longer base paths
+	slices.SortStableFunc(grp.members,
+		func(m1, m2 *abstractServerGroupEntry) int {
+			if (m1.host != "") != (m2.host != "") {
+				if m1.host != "" {
+					return -1
+				}
+				return 1
+			}
+			return len(m2.srv.options.BasePath) -
+				len(m1.srv.options.BasePath)
+		})
+
+	log.Debug(grp.ctx, "eSCL: %s%s: server added", host, basepath)
+
+	return srv, nil
+}
+
+// Remove removes the [AbstractServer] from the group and closes it.
+// If server is not a member of the group, it does nothing.
+func (grp *AbstractServerGroup) Remove(srv *AbstractServer) {
+	grp.lock.Lock()
+	defer grp.lock.Unlock()
+
+	for i, member := range grp.members {
+		if member.srv == srv {
+			grp.members = slices.Delete(grp.members, i, i+1)
+			srv.Close()
+			return
+		}
+	}
+}
+
+// Servers returns all servers of the group, in the lookup order.
+func (grp *AbstractServerGroup) Servers() []*AbstractServer {
+	grp.lock.RLock()
+	defer grp.lock.RUnlock()
+
+	servers := make([]*AbstractServer, len(grp.members))
+	for i, member := range grp.members {
+		servers[i] = member.srv
+	}
+
+	return servers
+}
+
+// Close closes the group and all its servers.
+// Requests, received after Close, are rejected with the
+// 404 Not Found status.
+func (grp *AbstractServerGroup) Close() {
+	grp.lock.Lock()
+	defer grp.lock.Unlock()
+
+	grp.closed = true
+	for _, member := range grp.members {
+		member.srv.Close()
+	}
+
+	grp.members = nil
+}
+
+// ServeHTTP dispatches the incoming HTTP request to the
+// appropriate server.
+// It implements the [http.Handler] interface.
+func (grp *AbstractServerGroup) ServeHTTP(w http.ResponseWriter,
+	rq *http.Request) {
+
+	srv := grp.lookup(rq.Host, rq.URL.Path)
+	if srv == nil {
+		log.Debug(grp.ctx, "HTTP %s %s -- no server", rq.Method, rq.URL)
+		http.NotFound(w, rq)
+		return
+	}
+
+	srv.ServeHTTP(w, rq)
+}
+
+// lookup returns the server for the request host and path,
+// or nil, if there is no matching server.
+func (grp *AbstractServerGroup) lookup(host, path string) *AbstractServer {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+	grp.lock.RLock()
+	defer grp.lock.RUnlock()
+
+	for _, member := range grp.members {
+		if member.host != "" && !strings.EqualFold(member.host, host) {
+			continue
+		}
+
+		if strings.HasPrefix(path, member.srv.options.BasePath) {
+			return member.srv
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Multiple scanners, served by the single handler test
+
+package escl
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/assert"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestAbstractServerGroup tests AbstractServerGroup
+func TestAbstractServerGroup(t *testing.T) {
+	xml, err := xmldoc.Decode(
+		NsMap,
+		bytes.NewReader(testutils.
+			Kyocera.ECOSYS.M2040dn.ESCL.ScannerCapabilities))
+	assert.NoError(err)
+
+	caps, err := DecodeScannerCapabilities(xml)
+	assert.NoError(err)
+
+	// newScanner creates the VirtualScanner with the given
+	// MakeAndModel, so scanners can be distinguished
+	newScanner := func(model string) *abstract.VirtualScanner {
+		abscaps := caps.ToAbstract()
+		abscaps.MakeAndModel = model
+		return &abstract.VirtualScanner{
+			ScanCaps: abscaps,
+			Resolution: abstract.Resolution{
+				XResolution: 300,
+				YResolution: 300,
+			},
+			PlatenImage: testutils.Images.PNG100x75rgb8,
+		}
+	}
+
+	grp := NewAbstractServerGroup(context.TODO())
+
+	type member struct {
+		host  string // Server host
+		path  string // Server base path
+		model string // Scanner MakeAndModel
+	}
+
+	members := []member{
+		{"", "/eSCL", "Scanner 0"},
+		{"", "/eSCL/1", "Scanner 1"},
+		{"", "/eSCL2", "Scanner 2"},
+		{"scanner3.local", "/eSCL", "Scanner 3"},
+	}
+
+	servers := make(map[string]*AbstractServer)
+	for _, m := range members {
+		srv, err := grp.Add(m.host, AbstractServerOptions{
+			Version:  caps.Version,
+			Scanner:  newScanner(m.model),
+			BasePath: m.path,
+		})
+
+		if err != nil {
+			t.Fatalf("Add(%q, %q): %s", m.host, m.path, err)
+		}
+
+		servers[m.model] = srv
+	}
+
+	// Duplicates must be rejected
+	_, err = grp.Add("SCANNER3.local", AbstractServerOptions{
+		Scanner:  newScanner("Duplicate"),
+		BasePath: "/eSCL/",
+	})
+
+	if err == nil {
+		t.Errorf("Add: duplicate not detected")
+	}
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, grp)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	// Check requests dispatching
+	type testData struct {
+		url   string // Scanner URL
+		model string // Expected MakeAndModel, "" if not found
+	}
+
+	tests := []testData{
+		{"http://localhost/eSCL", "Scanner 0"},
+		{"http://localhost/eSCL/1", "Scanner 1"},
+		{"http://localhost/eSCL2", "Scanner 2"},
+		{"http://scanner3.local/eSCL", "Scanner 3"},
+		{"http://Scanner3.Local:8080/eSCL", "Scanner 3"},
+		{"http://scanner3.local/eSCL2", "Scanner 2"},
+		{"http://localhost/eSCL3", ""},
+	}
+
+	check := func(tests []testData) {
+		for _, test := range tests {
+			clnt := NewClient(transport.MustParseURL(test.url), tr)
+			scancaps, _, err := clnt.GetScannerCapabilities(
+				context.TODO())
+
+			model := ""
+			if err == nil {
+				model = optional.Get(scancaps.MakeAndModel)
+			}
+
+			if model != test.model {
+				t.Errorf("%s: expected %q, present %q (%v)",
+					test.url, test.model, model, err)
+			}
+		}
+	}
+
+	check(tests)
+
+	// Remove the server, with the active job
+	u := transport.MustParseURL("http://localhost/eSCL2")
+	clnt := NewClient(u, tr)
+	_, _, err = clnt.Scan(context.TODO(), ScanSettings{
+		Version:     caps.Version,
+		InputSource: optional.New(InputPlaten),
+	})
+	if err != nil {
+		t.Fatalf("Scan: %s", err)
+	}
+
+	srv := servers["Scanner 2"]
+	grp.Remove(srv)
+
+	check([]testData{
+		{"http://localhost/eSCL2", ""},
+		{"http://scanner3.local/eSCL2", ""},
+		{"http://localhost/eSCL/1", "Scanner 1"},
+	})
+
+	if len(grp.Servers()) != len(members)-1 {
+		t.Errorf("Servers: %d expected, %d present",
+			len(members)-1, len(grp.Servers()))
+	}
+
+	history := srv.JobHistory()
+	if len(history) != 1 || history[0].JobState != JobAborted {
+		t.Errorf("Remove: active job not aborted")
+	}
+
+	// Close the group
+	grp.Close()
+
+	check([]testData{
+		{"http://localhost/eSCL", ""},
+		{"http://scanner3.local/eSCL", ""},
+	})
+
+	_, err = grp.Add("", AbstractServerOptions{
+		Scanner:  newScanner("Late"),
+		BasePath: "/eSCL",
+	})
+
+	if err == nil {
+		t.Errorf("Add: closed group not detected")
+	}
+}
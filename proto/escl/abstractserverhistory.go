@@ -0,0 +1,190 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AbstractServer job history
+
+package escl
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// AbstractServerJobStats contains per-job statistics, collected
+// by the [AbstractServer].
+type AbstractServerJobStats struct {
+	Pages    int       // Pages (images) sent to the client
+	Bytes    int64     // Image bytes sent to the client
+	Created  time.Time // Job creation time
+	Started  time.Time // Scan start time, zero if not started
+	Finished time.Time // Job finish time, zero if not finished
+}
+
+// Duration returns the job duration, from creation until finish.
+// For unfinished jobs it returns zero.
+func (stats AbstractServerJobStats) Duration() time.Duration {
+	if stats.Finished.IsZero() {
+		return 0
+	}
+	return stats.Finished.Sub(stats.Created)
+}
+
+// AbstractServerJob represents the job in the [AbstractServer]
+// job history.
+type AbstractServerJob struct {
+	JobInfo                         // Job info, as in ScannerStatus
+	Request abstract.ScannerRequest // Scan request
+	Stats   AbstractServerJobStats  // Job statistics
+}
+
+// JobHistory returns the [AbstractServer] job history, newest
+// jobs first.
+//
+// History contains up to the [AbstractServerHistorySize] most
+// recent jobs, including the active and queued ones. It lets
+// test code and administrative tools to verify what the server
+// actually did.
+func (srv *AbstractServer) JobHistory() []AbstractServerJob {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.statusLock.Lock()
+	defer srv.statusLock.Unlock()
+
+	history := make([]AbstractServerJob, 0, len(srv.history))
+	for _, job := range srv.history {
+		ent := AbstractServerJob{
+			JobInfo: JobInfo{JobURI: job.uri},
+			Request: job.req,
+			Stats:   job.stats,
+		}
+
+		for _, info := range srv.status.Jobs {
+			if info.JobURI == job.uri {
+				ent.JobInfo = info
+				ent.JobStateReasons = append([]JobStateReason(nil),
+					info.JobStateReasons...)
+				break
+			}
+		}
+
+		history = append(history, ent)
+	}
+
+	return history
+}
+
+// JobHistoryHandler returns the [http.Handler] that serves the
+// job history (see [AbstractServer.JobHistory]) in the JSON format.
+//
+// It is not part of the eSCL protocol and not served by the
+// [AbstractServer.ServeHTTP]. Caller may mount it at any
+// convenient path, i.e., for the administrative UI.
+func (srv *AbstractServer) JobHistoryHandler() http.Handler {
+	return http.HandlerFunc(srv.serveJobHistory)
+}
+
+// serveJobHistory serves the job history in the JSON format
+func (srv *AbstractServer) serveJobHistory(w http.ResponseWriter,
+	rq *http.Request) {
+
+	if rq.Method != "GET" && rq.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	type jsonJob struct {
+		JobURI   string   `json:"job-uri"`
+		JobUUID  string   `json:"job-uuid,omitempty"`
+		State    string   `json:"state"`
+		Reasons  []string `json:"reasons,omitempty"`
+		Input    string   `json:"input"`
+		Format   string   `json:"format,omitempty"`
+		Pages    int      `json:"pages"`
+		Bytes    int64    `json:"bytes"`
+		Created  string   `json:"created"`
+		Started  string   `json:"started,omitempty"`
+		Finished string   `json:"finished,omitempty"`
+		Duration string   `json:"duration,omitempty"`
+	}
+
+	timeFormat := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+
+	history := srv.JobHistory()
+	jobs := make([]jsonJob, len(history))
+	for i, ent := range history {
+		ss := fromAbstractScanSettings(srv.options.Version,
+			&ent.Request)
+		job := jsonJob{
+			JobURI:   ent.JobURI,
+			JobUUID:  optional.Get(ent.JobUUID),
+			State:    ent.JobState.String(),
+			Input:    optional.Get(ss.InputSource).String(),
+			Format:   ent.Request.DocumentFormat,
+			Pages:    ent.Stats.Pages,
+			Bytes:    ent.Stats.Bytes,
+			Created:  timeFormat(ent.Stats.Created),
+			Started:  timeFormat(ent.Stats.Started),
+			Finished: timeFormat(ent.Stats.Finished),
+		}
+
+		for _, reason := range ent.JobStateReasons {
+			job.Reasons = append(job.Reasons, reason.String())
+		}
+
+		if d := ent.Stats.Duration(); d != 0 {
+			job.Duration = d.String()
+		}
+
+		jobs[i] = job
+	}
+
+	data, _ := json.MarshalIndent(jobs, "", "  ")
+	data = append(data, '\n')
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if rq.Method == "GET" {
+		w.Write(data)
+	}
+}
+
+// historyPush adds the new job to the job history.
+// Must be called under srv.lock.
+func (srv *AbstractServer) historyPush(job *abstractServerJob) {
+	if len(srv.history) >= AbstractServerHistorySize {
+		srv.history = srv.history[:AbstractServerHistorySize-1]
+	}
+
+	srv.history = append([]*abstractServerJob{job}, srv.history...)
+}
+
+// jobPageSent updates job statistics, when the page image
+// of the specified size is sent to the client.
+func (srv *AbstractServer) jobPageSent(job *abstractServerJob, size int64) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	job.stats.Pages++
+	job.stats.Bytes += size
+
+	pages := job.stats.Pages
+	srv.jobUpdate(job, func(info *JobInfo) {
+		info.ImagesCompleted = optional.New(pages)
+	})
+}
@@ -0,0 +1,154 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AbstractServer stored jobs extension
+
+package escl
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/missed"
+)
+
+// abstractServerDocument is the document (page image), stored
+// by the AbstractServer for the stored jobs extension.
+type abstractServerDocument struct {
+	format string // MIME type of the image
+	data   []byte // Image data
+}
+
+// abstractServerStoredFile wraps abstract.DocumentFile and keeps
+// the copy of data being read.
+type abstractServerStoredFile struct {
+	abstract.DocumentFile              // Underlying file
+	buf                   bytes.Buffer // Data read so far
+	eof                   bool         // File is read till the end
+}
+
+// Read reads the document file content as a sequence of bytes.
+func (file *abstractServerStoredFile) Read(buf []byte) (int, error) {
+	n, err := file.DocumentFile.Read(buf)
+	file.buf.Write(buf[:n])
+	if err == io.EOF {
+		file.eof = true
+	}
+	return n, err
+}
+
+// storedAction returns handler for the stored jobs extension
+// request, or nil, if request is not recognized.
+// Must be called under srv.lock.
+func (srv *AbstractServer) storedAction(
+	query *AbstractServerQuery) func(*AbstractServerQuery) {
+
+	if !srv.options.StoredJobs || query.Method != "GET" {
+		return nil
+	}
+
+	for _, job := range srv.history {
+		s, ok := missed.StringsCutPrefix(query.URL.Path,
+			job.uri+"/Documents/")
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil
+		}
+
+		return func(query *AbstractServerQuery) {
+			srv.getJobURIDocument(query, job, n)
+		}
+	}
+
+	return nil
+}
+
+// storedAllowedMethods returns HTTP methods, allowed for the
+// stored jobs extension URL path, or nil, if path is not known.
+// Must be called under srv.lock.
+func (srv *AbstractServer) storedAllowedMethods(urlpath string) []string {
+	if !srv.options.StoredJobs {
+		return nil
+	}
+
+	for _, job := range srv.history {
+		if strings.HasPrefix(urlpath, job.uri+"/Documents/") {
+			return []string{"GET", "OPTIONS"}
+		}
+	}
+
+	return nil
+}
+
+// getScanJobs handles GET /{root}/ScanJobs
+func (srv *AbstractServer) getScanJobs(query *AbstractServerQuery) {
+	srv.statusLock.Lock()
+	jobs := ScanJobs{
+		Version: srv.status.Version,
+		Jobs:    make([]JobInfo, len(srv.status.Jobs)),
+	}
+	copy(jobs.Jobs, srv.status.Jobs)
+	srv.statusLock.Unlock()
+
+	query.NoCache()
+	query.SendXML(jobs.ToXML())
+}
+
+// getJobURIDocument handles GET /{JobUri}/Documents/{N}
+func (srv *AbstractServer) getJobURIDocument(query *AbstractServerQuery,
+	job *abstractServerJob, n int) {
+
+	srv.lock.Lock()
+	var doc abstractServerDocument
+	ok := n >= 1 && n <= len(job.documents)
+	if ok {
+		doc = job.documents[n-1]
+	}
+	srv.lock.Unlock()
+
+	if !ok {
+		query.Reject(http.StatusNotFound, nil)
+		return
+	}
+
+	hash := transport.NewContentDigest()
+	hash.Write(doc.data)
+
+	hdr := query.ResponseHeader()
+	hdr.Set("Content-Type", doc.format)
+	hdr.Set("Content-Length", strconv.Itoa(len(doc.data)))
+	hdr.Set(transport.ContentDigestHeader,
+		transport.FormatContentDigest(hash.Sum(nil)))
+
+	query.WriteHeader(http.StatusOK)
+	query.Write(doc.data)
+}
+
+// jobStore stores the document, sent to the client, if it was
+// sent completely.
+func (srv *AbstractServer) jobStore(job *abstractServerJob,
+	file *abstractServerStoredFile) {
+
+	if !file.eof {
+		return
+	}
+
+	srv.lock.Lock()
+	job.documents = append(job.documents, abstractServerDocument{
+		format: file.Format(),
+		data:   file.buf.Bytes(),
+	})
+	srv.lock.Unlock()
+}
@@ -9,6 +9,9 @@
 package escl
 
 import (
+	"math"
+	"slices"
+
 	"github.com/OpenPrinting/go-mfp/abstract"
 	"github.com/OpenPrinting/go-mfp/util/generic"
 	"github.com/OpenPrinting/go-mfp/util/optional"
@@ -35,12 +38,22 @@ func (scancaps *ScannerCapabilities) ToAbstract() *abstract.ScannerCapabilities
 		ShadowRange:       optional.Get(scancaps.ShadowSupport).toAbstract(),
 		SharpenRange:      optional.Get(scancaps.SharpenSupport).toAbstract(),
 		ThresholdRange:    optional.Get(scancaps.ThresholdSupport).toAbstract(),
+
+		BlankPageRemoval: optional.Get(scancaps.BlankPageDetectionAndRemoval),
 	}
 
+	// Blank page removal implies detection
+	abscaps.BlankPageDetection = optional.Get(scancaps.BlankPageDetection) ||
+		abscaps.BlankPageRemoval
+
 	if scancaps.Platen != nil {
 		abscaps.Platen = (*scancaps.Platen.PlatenInputCaps).toAbstract()
 	}
 
+	if scancaps.Camera != nil && scancaps.Camera.CameraInputCaps != nil {
+		abscaps.Camera = (*scancaps.Camera.CameraInputCaps).toAbstract()
+	}
+
 	if scancaps.ADF != nil {
 		abscaps.ADFCapacity = optional.Get(scancaps.ADF.FeederCapacity)
 
@@ -53,6 +66,20 @@ func (scancaps *ScannerCapabilities) ToAbstract() *abstract.ScannerCapabilities
 			abscaps.ADFDuplex =
 				(*scancaps.ADF.ADFDuplexInputCaps).toAbstract()
 		}
+
+		// ADF options apply to both ADF inputs
+		detect := slices.Contains(scancaps.ADF.ADFOptions,
+			DetectPaperLoaded)
+		single := slices.Contains(scancaps.ADF.ADFOptions,
+			SelectSinglePage)
+
+		for _, inp := range []*abstract.InputCapabilities{
+			abscaps.ADFSimplex, abscaps.ADFDuplex} {
+			if inp != nil {
+				inp.ADFDetectPaperLoaded = detect
+				inp.ADFSelectSinglePage = single
+			}
+		}
 	}
 
 	return abscaps
@@ -63,14 +90,20 @@ func (ss *ScanSettings) ToAbstract() abstract.ScannerRequest {
 	absreq := abstract.ScannerRequest{
 		Brightness:   ss.Brightness,
 		Contrast:     ss.Contrast,
-		Gamma:        ss.Gamma,
+		Gamma:        toAbstractFloat(ss.Gamma),
 		Highlight:    ss.Highlight,
 		NoiseRemoval: ss.NoiseRemoval,
 		Shadow:       ss.Shadow,
 		Sharpen:      ss.Sharpen,
 		Compression:  ss.CompressionFactor,
+
+		BlankPageRemoval: optional.Get(ss.BlankPageDetectionAndRemoval),
 	}
 
+	// Translate BlankPageDetection. Removal implies detection.
+	absreq.BlankPageDetection = optional.Get(ss.BlankPageDetection) ||
+		absreq.BlankPageRemoval
+
 	// Translate Input and ADFMode
 	if ss.InputSource != nil {
 		switch *ss.InputSource {
@@ -82,6 +115,8 @@ func (ss *ScanSettings) ToAbstract() abstract.ScannerRequest {
 			if ss.Duplex != nil && *ss.Duplex {
 				absreq.ADFMode = abstract.ADFModeDuplex
 			}
+		case InputCamera:
+			absreq.Input = abstract.InputCamera
 		}
 	}
 
@@ -121,13 +156,7 @@ func (ss *ScanSettings) ToAbstract() abstract.ScannerRequest {
 
 	// Translate ScanRegions. Note, we only handle the first one.
 	if len(ss.ScanRegions) > 0 {
-		reg := ss.ScanRegions[0]
-		absreq.Region = abstract.Region{
-			XOffset: abstract.DimensionFromDots(300, reg.XOffset),
-			YOffset: abstract.DimensionFromDots(300, reg.YOffset),
-			Width:   abstract.DimensionFromDots(300, reg.Width),
-			Height:  abstract.DimensionFromDots(300, reg.Height),
-		}
+		absreq.Region = ss.ScanRegions[0].ToAbstract()
 	}
 
 	// Translate Resolution
@@ -149,6 +178,7 @@ func (ss *ScanSettings) ToAbstract() abstract.ScannerRequest {
 			absreq.Intent = abstract.IntentPhoto
 		case Preview:
 			absreq.Intent = abstract.IntentPreview
+			absreq.Preview = true
 		case Object:
 			absreq.Intent = abstract.IntentObject
 		case BusinessCard:
@@ -161,22 +191,23 @@ func (ss *ScanSettings) ToAbstract() abstract.ScannerRequest {
 
 // toAbstract converts [InputSourceCaps] to *[anstract.InputCapabilities].
 func (caps InputSourceCaps) toAbstract() *abstract.InputCapabilities {
+	units := ThreeHundredthsOfInches
 	abscaps := &abstract.InputCapabilities{
-		MinWidth:  abstract.DimensionFromDots(300, caps.MinWidth),
-		MaxWidth:  abstract.DimensionFromDots(300, caps.MaxWidth),
-		MinHeight: abstract.DimensionFromDots(300, caps.MinHeight),
-		MaxHeight: abstract.DimensionFromDots(300, caps.MaxHeight),
+		MinWidth:  units.ToDimension(caps.MinWidth),
+		MaxWidth:  units.ToDimension(caps.MaxWidth),
+		MinHeight: units.ToDimension(caps.MinHeight),
+		MaxHeight: units.ToDimension(caps.MaxHeight),
 
-		MaxXOffset: abstract.DimensionFromDots(300, optional.Get(caps.MaxXOffset)),
-		MaxYOffset: abstract.DimensionFromDots(300, optional.Get(caps.MaxYOffset)),
+		MaxXOffset: units.ToDimension(optional.Get(caps.MaxXOffset)),
+		MaxYOffset: units.ToDimension(optional.Get(caps.MaxYOffset)),
 
 		MaxOpticalXResolution: optional.Get(caps.MaxOpticalXResolution),
 		MaxOpticalYResolution: optional.Get(caps.MaxOpticalYResolution),
 
-		RiskyLeftMargins:   abstract.DimensionFromDots(300, optional.Get(caps.RiskyLeftMargins)),
-		RiskyRightMargins:  abstract.DimensionFromDots(300, optional.Get(caps.RiskyRightMargins)),
-		RiskyTopMargins:    abstract.DimensionFromDots(300, optional.Get(caps.RiskyTopMargins)),
-		RiskyBottomMargins: abstract.DimensionFromDots(300, optional.Get(caps.RiskyBottomMargins)),
+		RiskyLeftMargins:   units.ToDimension(optional.Get(caps.RiskyLeftMargins)),
+		RiskyRightMargins:  units.ToDimension(optional.Get(caps.RiskyRightMargins)),
+		RiskyTopMargins:    units.ToDimension(optional.Get(caps.RiskyTopMargins)),
+		RiskyBottomMargins: units.ToDimension(optional.Get(caps.RiskyBottomMargins)),
 	}
 
 	for _, intent := range caps.SupportedIntents {
@@ -319,6 +350,35 @@ func (r Range) toAbstract() abstract.Range {
 	}
 }
 
+// toAbstract converts [FloatRange] to [abstract.Range].
+//
+// As abstract.Range is integer, the range is narrowed to the
+// integer values within it. Fractional Step is dropped.
+func (r FloatRange) toAbstract() abstract.Range {
+	absrange := abstract.Range{
+		Min: int(math.Ceil(r.Min)),
+		Max: int(math.Floor(r.Max)),
+	}
+
+	absrange.Normal = int(math.Round(r.Normal))
+	absrange.Normal = max(min(absrange.Normal, absrange.Max), absrange.Min)
+
+	if step := optional.Get(r.Step); step == math.Trunc(step) {
+		absrange.Step = int(step)
+	}
+
+	return absrange
+}
+
+// toAbstractFloat converts optional fractional value into
+// the optional integer value, used by the abstract.ScannerRequest.
+func toAbstractFloat(v optional.Val[float64]) optional.Val[int] {
+	if v == nil {
+		return nil
+	}
+	return optional.New(int(math.Round(*v)))
+}
+
 // toAbstract converts [CCDChannel] to [abstract.CCDChannel]
 func (rnd BinaryRendering) toAbstract() abstract.BinaryRendering {
 	switch rnd {
@@ -351,6 +411,25 @@ func (ccd CCDChannel) toAbstract() abstract.CCDChannel {
 	return abstract.CCDChannelUnset
 }
 
+// toAbstract converts [ADFState] to [abstract.ADFState].
+//
+// eSCL ADF states, not known to the abstract.ADFState, are
+// converted to abstract.ADFStateUnknown.
+func (state ADFState) toAbstract() abstract.ADFState {
+	switch state {
+	case ScannerAdfEmpty:
+		return abstract.ADFStateEmpty
+	case ScannerAdfLoaded:
+		return abstract.ADFStateLoaded
+	case ScannerAdfJam:
+		return abstract.ADFStateJam
+	case ScannerAdfMultipickDetected:
+		return abstract.ADFStateMultipick
+	}
+
+	return abstract.ADFStateUnknown
+}
+
 // toAbstract converts [ColorMode] into the combination of the
 // [abstract.ColorMode] and [abstract.ColorDepth].
 func (cm ColorMode) toAbstract() (abstract.ColorMode, abstract.ColorDepth) {
@@ -51,6 +51,17 @@ func TestScanSettingsToAbstract(t *testing.T) {
 			},
 		},
 
+		{
+			comment: "InputCamera",
+			ss: ScanSettings{
+				Version:     DefaultVersion,
+				InputSource: optional.New(InputCamera),
+			},
+			out: abstract.ScannerRequest{
+				Input: abstract.InputCamera,
+			},
+		},
+
 		{
 			comment: "InputFeeder,simplex",
 			ss: ScanSettings{
@@ -76,6 +87,30 @@ func TestScanSettingsToAbstract(t *testing.T) {
 			},
 		},
 
+		// Blank page detection and removal
+		{
+			comment: "BlankPageDetection",
+			ss: ScanSettings{
+				Version:            DefaultVersion,
+				BlankPageDetection: optional.New(true),
+			},
+			out: abstract.ScannerRequest{
+				BlankPageDetection: true,
+			},
+		},
+
+		{
+			comment: "BlankPageDetectionAndRemoval",
+			ss: ScanSettings{
+				Version:                      DefaultVersion,
+				BlankPageDetectionAndRemoval: optional.New(true),
+			},
+			out: abstract.ScannerRequest{
+				BlankPageDetection: true,
+				BlankPageRemoval:   true,
+			},
+		},
+
 		// ColorMode, ColorDepth, BinaryRendering and Threshold
 		{
 			comment: "BlackAndWhite1",
@@ -378,7 +413,8 @@ func TestScanSettingsToAbstract(t *testing.T) {
 				Intent:  optional.New(Preview),
 			},
 			out: abstract.ScannerRequest{
-				Intent: abstract.IntentPreview,
+				Intent:  abstract.IntentPreview,
+				Preview: true,
 			},
 		},
 
@@ -487,10 +523,12 @@ func TestScannerCapabilitiesToAbstract(t *testing.T) {
 			},
 		},
 		ADFSimplex: &abstract.InputCapabilities{
-			MinWidth:  5004,
-			MaxWidth:  21598,
-			MinHeight: 5004,
-			MaxHeight: 35602,
+			MinWidth:             5004,
+			MaxWidth:             21598,
+			MinHeight:            5004,
+			MaxHeight:            35602,
+			ADFDetectPaperLoaded: true,
+			ADFSelectSinglePage:  true,
 			Intents: generic.MakeBitset(
 				abstract.IntentDocument,
 				abstract.IntentTextAndGraphic,
@@ -519,10 +557,12 @@ func TestScannerCapabilitiesToAbstract(t *testing.T) {
 			},
 		},
 		ADFDuplex: &abstract.InputCapabilities{
-			MinWidth:  5004,
-			MaxWidth:  21598,
-			MinHeight: 5004,
-			MaxHeight: 35602,
+			MinWidth:             5004,
+			MaxWidth:             21598,
+			MinHeight:            5004,
+			MaxHeight:            35602,
+			ADFDetectPaperLoaded: true,
+			ADFSelectSinglePage:  true,
 			Intents: generic.MakeBitset(
 				abstract.IntentDocument,
 				abstract.IntentTextAndGraphic,
@@ -0,0 +1,537 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// ScannerCapabilities builder
+
+package escl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+)
+
+// Default input size of the CapabilitiesBuilder inputs,
+// in ThreeHundredthsOfInches (A4).
+const (
+	CapabilitiesBuilderMaxWidth  = 2480
+	CapabilitiesBuilderMaxHeight = 3508
+)
+
+// CapabilitiesBuilder builds [ScannerCapabilities] in the fluent style:
+//
+//	caps, err := escl.NewCapabilitiesBuilder().
+//		WithMakeAndModel("Virtual Scanner").
+//		WithPlaten().
+//		WithResolutions(150, 300, 600).
+//		WithColorModes(escl.Grayscale8, escl.RGB24).
+//		WithDocumentFormats("image/jpeg", "application/pdf").
+//		Build()
+//
+// Input-specific methods (WithSize, WithIntents, WithResolutions and
+// so on) apply to the input, most recently selected by WithPlaten,
+// WithADFSimplex, WithADFDuplex or WithCamera. Each input gets
+// the single [SettingProfile].
+//
+// Errors are remembered and reported by the Build, which also
+// verifies the resulting tree for consistency.
+type CapabilitiesBuilder struct {
+	caps  ScannerCapabilities // Capabilities being built
+	input *InputSourceCaps    // Current input, nil if none
+	err   error               // First error
+}
+
+// NewCapabilitiesBuilder creates a new [CapabilitiesBuilder].
+// The eSCL version is initially set to [DefaultVersion].
+func NewCapabilitiesBuilder() *CapabilitiesBuilder {
+	return &CapabilitiesBuilder{
+		caps: ScannerCapabilities{Version: DefaultVersion},
+	}
+}
+
+// Build verifies and returns the [ScannerCapabilities].
+//
+// If any of the builder methods has failed, or the resulting
+// capabilities are inconsistent, the error is returned.
+//
+// The returned ScannerCapabilities shares memory with the builder,
+// so the builder should not be used after the Build.
+func (b *CapabilitiesBuilder) Build() (*ScannerCapabilities, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	caps := b.caps
+
+	type namedInput struct {
+		name string
+		caps optional.Val[InputSourceCaps]
+	}
+
+	var inputs []namedInput
+	if caps.Platen != nil {
+		inputs = append(inputs,
+			namedInput{"Platen", caps.Platen.PlatenInputCaps})
+	}
+	if caps.ADF != nil {
+		inputs = append(inputs,
+			namedInput{"ADFSimplex", caps.ADF.ADFSimplexInputCaps})
+		inputs = append(inputs,
+			namedInput{"ADFDuplex", caps.ADF.ADFDuplexInputCaps})
+	}
+	if caps.Camera != nil {
+		inputs = append(inputs,
+			namedInput{"Camera", caps.Camera.CameraInputCaps})
+	}
+
+	if len(inputs) == 0 {
+		return nil, errors.New("ScannerCapabilities: no inputs defined")
+	}
+
+	for _, inp := range inputs {
+		if inp.caps == nil {
+			continue
+		}
+
+		err := b.verifyInput(inp.caps)
+		if err != nil {
+			err = fmt.Errorf("ScannerCapabilities: %s: %w",
+				inp.name, err)
+			return nil, err
+		}
+	}
+
+	ranges := []struct {
+		name string
+		rng  optional.Val[Range]
+	}{
+		{"BrightnessSupport", caps.BrightnessSupport},
+		{"CompressionFactorSupport", caps.CompressionFactorSupport},
+		{"ContrastSupport", caps.ContrastSupport},
+		{"HighlightSupport", caps.HighlightSupport},
+		{"NoiseRemovalSupport", caps.NoiseRemovalSupport},
+		{"ShadowSupport", caps.ShadowSupport},
+		{"SharpenSupport", caps.SharpenSupport},
+		{"ThresholdSupport", caps.ThresholdSupport},
+	}
+
+	for _, r := range ranges {
+		if r.rng != nil {
+			rng := *r.rng
+			if rng.Min > rng.Normal || rng.Normal > rng.Max {
+				err := fmt.Errorf("ScannerCapabilities: %s: "+
+					"invalid range %d...%d (normal %d)",
+					r.name, rng.Min, rng.Max, rng.Normal)
+				return nil, err
+			}
+		}
+	}
+
+	if caps.GammaSupport != nil {
+		rng := *caps.GammaSupport
+		if rng.Min > rng.Normal || rng.Normal > rng.Max {
+			err := fmt.Errorf("ScannerCapabilities: GammaSupport: "+
+				"invalid range %s...%s (normal %s)",
+				formatFloat(rng.Min), formatFloat(rng.Max),
+				formatFloat(rng.Normal))
+			return nil, err
+		}
+	}
+
+	return &caps, nil
+}
+
+// verifyInput verifies the input capabilities.
+func (b *CapabilitiesBuilder) verifyInput(inp *InputSourceCaps) error {
+	switch {
+	case inp.MaxWidth <= 0 || inp.MaxHeight <= 0:
+		return errors.New("invalid max size")
+	case inp.MinWidth < 0 || inp.MinWidth > inp.MaxWidth:
+		return errors.New("invalid min width")
+	case inp.MinHeight < 0 || inp.MinHeight > inp.MaxHeight:
+		return errors.New("invalid min height")
+	}
+
+	prof := &inp.SettingProfiles[0]
+
+	switch {
+	case len(prof.ColorModes) == 0:
+		return errors.New("no color modes defined")
+	case len(prof.DocumentFormats) == 0:
+		return errors.New("no document formats defined")
+	case len(prof.SupportedResolutions[0].DiscreteResolutions) == 0 &&
+		prof.SupportedResolutions[0].ResolutionRange == nil:
+		return errors.New("no resolutions defined")
+	}
+
+	if b.caps.Version >= MakeVersion(2, 1) {
+		// Since eSCL 2.1...
+		prof.DocumentFormatsExt = prof.DocumentFormats
+	}
+
+	return nil
+}
+
+// WithVersion sets the eSCL version.
+func (b *CapabilitiesBuilder) WithVersion(ver Version) *CapabilitiesBuilder {
+	b.caps.Version = ver
+	return b
+}
+
+// WithMakeAndModel sets the device make and model.
+func (b *CapabilitiesBuilder) WithMakeAndModel(
+	s string) *CapabilitiesBuilder {
+	b.caps.MakeAndModel = optional.New(s)
+	return b
+}
+
+// WithManufacturer sets the device manufacturer.
+func (b *CapabilitiesBuilder) WithManufacturer(
+	s string) *CapabilitiesBuilder {
+	b.caps.Manufacturer = optional.New(s)
+	return b
+}
+
+// WithSerialNumber sets the device serial number.
+func (b *CapabilitiesBuilder) WithSerialNumber(
+	s string) *CapabilitiesBuilder {
+	b.caps.SerialNumber = optional.New(s)
+	return b
+}
+
+// WithUUID sets the device UUID.
+func (b *CapabilitiesBuilder) WithUUID(uu uuid.UUID) *CapabilitiesBuilder {
+	b.caps.UUID = optional.New(uu)
+	return b
+}
+
+// WithPlaten adds the Platen input, if not added yet, and makes
+// it current.
+func (b *CapabilitiesBuilder) WithPlaten() *CapabilitiesBuilder {
+	if b.caps.Platen == nil {
+		b.caps.Platen = optional.New(
+			Platen{PlatenInputCaps: b.newInput()})
+	}
+
+	b.input = b.caps.Platen.PlatenInputCaps
+	return b
+}
+
+// WithADFSimplex adds the ADF simplex input, if not added yet,
+// and makes it current.
+func (b *CapabilitiesBuilder) WithADFSimplex() *CapabilitiesBuilder {
+	adf := b.adf()
+	if adf.ADFSimplexInputCaps == nil {
+		adf.ADFSimplexInputCaps = b.newInput()
+	}
+
+	b.input = adf.ADFSimplexInputCaps
+	return b
+}
+
+// WithADFDuplex adds the ADF duplex input, if not added yet,
+// and makes it current.
+func (b *CapabilitiesBuilder) WithADFDuplex() *CapabilitiesBuilder {
+	adf := b.adf()
+	if adf.ADFDuplexInputCaps == nil {
+		adf.ADFDuplexInputCaps = b.newInput()
+		adf.ADFOptions = append(adf.ADFOptions, Duplex)
+	}
+
+	b.input = adf.ADFDuplexInputCaps
+	return b
+}
+
+// WithCamera adds the Camera input, if not added yet, and makes
+// it current.
+func (b *CapabilitiesBuilder) WithCamera() *CapabilitiesBuilder {
+	if b.caps.Camera == nil {
+		b.caps.Camera = optional.New(
+			Camera{CameraInputCaps: b.newInput()})
+	}
+
+	b.input = b.caps.Camera.CameraInputCaps
+	return b
+}
+
+// WithFeederCapacity sets the ADF capacity. ADF input must be
+// added before.
+func (b *CapabilitiesBuilder) WithFeederCapacity(
+	n int) *CapabilitiesBuilder {
+
+	switch {
+	case b.caps.ADF == nil:
+		b.fail("WithFeederCapacity: no ADF defined")
+	case n <= 0:
+		b.fail("WithFeederCapacity: invalid capacity %d", n)
+	default:
+		b.caps.ADF.FeederCapacity = optional.New(n)
+	}
+
+	return b
+}
+
+// WithADFOptions adds the ADF options (i.e., DetectPaperLoaded).
+// ADF input must be added before.
+//
+// The Duplex option is added automatically by the WithADFDuplex,
+// and cannot be used here.
+func (b *CapabilitiesBuilder) WithADFOptions(
+	opts ...ADFOption) *CapabilitiesBuilder {
+
+	if b.caps.ADF == nil {
+		b.fail("WithADFOptions: no ADF defined")
+		return b
+	}
+
+	for _, opt := range opts {
+		switch {
+		case opt == UnknownADFOption || opt == Duplex:
+			b.fail("WithADFOptions: invalid option %s", opt)
+		case !slices.Contains(b.caps.ADF.ADFOptions, opt):
+			b.caps.ADF.ADFOptions = append(b.caps.ADF.ADFOptions,
+				opt)
+		}
+	}
+
+	return b
+}
+
+// WithSize sets min and max scan size of the current input,
+// in ThreeHundredthsOfInches. MaxXOffset and MaxYOffset are
+// set so any region within the max size can be scanned.
+//
+// New inputs are created with zero min size and A4 max size.
+func (b *CapabilitiesBuilder) WithSize(minWidth, minHeight,
+	maxWidth, maxHeight int) *CapabilitiesBuilder {
+
+	if inp := b.current("WithSize"); inp != nil {
+		inp.MinWidth = minWidth
+		inp.MinHeight = minHeight
+		inp.MaxWidth = maxWidth
+		inp.MaxHeight = maxHeight
+		inp.MaxXOffset = optional.New(maxWidth - minWidth)
+		inp.MaxYOffset = optional.New(maxHeight - minHeight)
+	}
+
+	return b
+}
+
+// WithIntents adds supported intents to the current input.
+func (b *CapabilitiesBuilder) WithIntents(
+	intents ...Intent) *CapabilitiesBuilder {
+
+	if inp := b.current("WithIntents"); inp != nil {
+		inp.SupportedIntents = append(inp.SupportedIntents, intents...)
+	}
+
+	return b
+}
+
+// WithResolutions adds supported discrete resolutions to the
+// current input. Each resolution is used for both X and Y.
+func (b *CapabilitiesBuilder) WithResolutions(
+	resolutions ...int) *CapabilitiesBuilder {
+
+	prof := b.profile("WithResolutions")
+	if prof == nil {
+		return b
+	}
+
+	supp := &prof.SupportedResolutions[0]
+	for _, res := range resolutions {
+		if res <= 0 {
+			b.fail("WithResolutions: invalid resolution %d", res)
+			return b
+		}
+
+		supp.DiscreteResolutions = append(supp.DiscreteResolutions,
+			DiscreteResolution{XResolution: res, YResolution: res})
+	}
+
+	return b
+}
+
+// WithResolutionRange sets the supported range of resolutions
+// of the current input. The range is used for both X and Y.
+func (b *CapabilitiesBuilder) WithResolutionRange(
+	minRes, maxRes, normal int) *CapabilitiesBuilder {
+
+	prof := b.profile("WithResolutionRange")
+	if prof == nil {
+		return b
+	}
+
+	if minRes <= 0 || minRes > normal || normal > maxRes {
+		b.fail("WithResolutionRange: invalid range %d...%d (normal %d)",
+			minRes, maxRes, normal)
+		return b
+	}
+
+	rng := Range{Min: minRes, Max: maxRes, Normal: normal}
+	prof.SupportedResolutions[0].ResolutionRange = optional.New(
+		ResolutionRange{XResolutionRange: rng, YResolutionRange: rng})
+
+	return b
+}
+
+// WithColorModes adds supported color modes to the current input.
+func (b *CapabilitiesBuilder) WithColorModes(
+	modes ...ColorMode) *CapabilitiesBuilder {
+
+	if prof := b.profile("WithColorModes"); prof != nil {
+		prof.ColorModes = append(prof.ColorModes, modes...)
+	}
+
+	return b
+}
+
+// WithDocumentFormats adds supported document formats (MIME types)
+// to the current input.
+func (b *CapabilitiesBuilder) WithDocumentFormats(
+	formats ...string) *CapabilitiesBuilder {
+
+	if prof := b.profile("WithDocumentFormats"); prof != nil {
+		prof.DocumentFormats = append(prof.DocumentFormats, formats...)
+	}
+
+	return b
+}
+
+// WithBinaryRenderings adds supported binary renderings to the
+// current input.
+func (b *CapabilitiesBuilder) WithBinaryRenderings(
+	renderings ...BinaryRendering) *CapabilitiesBuilder {
+
+	if prof := b.profile("WithBinaryRenderings"); prof != nil {
+		prof.BinaryRenderings = append(prof.BinaryRenderings,
+			renderings...)
+	}
+
+	return b
+}
+
+// WithBrightness sets the BrightnessSupport range.
+func (b *CapabilitiesBuilder) WithBrightness(r Range) *CapabilitiesBuilder {
+	b.caps.BrightnessSupport = optional.New(r)
+	return b
+}
+
+// WithCompressionFactor sets the CompressionFactorSupport range.
+func (b *CapabilitiesBuilder) WithCompressionFactor(
+	r Range) *CapabilitiesBuilder {
+	b.caps.CompressionFactorSupport = optional.New(r)
+	return b
+}
+
+// WithContrast sets the ContrastSupport range.
+func (b *CapabilitiesBuilder) WithContrast(r Range) *CapabilitiesBuilder {
+	b.caps.ContrastSupport = optional.New(r)
+	return b
+}
+
+// WithGamma sets the GammaSupport range.
+func (b *CapabilitiesBuilder) WithGamma(
+	r FloatRange) *CapabilitiesBuilder {
+	b.caps.GammaSupport = optional.New(r)
+	return b
+}
+
+// WithHighlight sets the HighlightSupport range.
+func (b *CapabilitiesBuilder) WithHighlight(r Range) *CapabilitiesBuilder {
+	b.caps.HighlightSupport = optional.New(r)
+	return b
+}
+
+// WithNoiseRemoval sets the NoiseRemovalSupport range.
+func (b *CapabilitiesBuilder) WithNoiseRemoval(
+	r Range) *CapabilitiesBuilder {
+	b.caps.NoiseRemovalSupport = optional.New(r)
+	return b
+}
+
+// WithShadow sets the ShadowSupport range.
+func (b *CapabilitiesBuilder) WithShadow(r Range) *CapabilitiesBuilder {
+	b.caps.ShadowSupport = optional.New(r)
+	return b
+}
+
+// WithSharpen sets the SharpenSupport range.
+func (b *CapabilitiesBuilder) WithSharpen(r Range) *CapabilitiesBuilder {
+	b.caps.SharpenSupport = optional.New(r)
+	return b
+}
+
+// WithThreshold sets the ThresholdSupport range.
+func (b *CapabilitiesBuilder) WithThreshold(r Range) *CapabilitiesBuilder {
+	b.caps.ThresholdSupport = optional.New(r)
+	return b
+}
+
+// WithBlankPageDetection enables blank page detection and,
+// optionally, removal.
+func (b *CapabilitiesBuilder) WithBlankPageDetection(
+	removal bool) *CapabilitiesBuilder {
+
+	b.caps.BlankPageDetection = optional.New(true)
+	if removal {
+		b.caps.BlankPageDetectionAndRemoval = optional.New(true)
+	}
+
+	return b
+}
+
+// newInput creates a new InputSourceCaps with defaults.
+func (b *CapabilitiesBuilder) newInput() *InputSourceCaps {
+	return &InputSourceCaps{
+		MaxWidth:   CapabilitiesBuilderMaxWidth,
+		MaxHeight:  CapabilitiesBuilderMaxHeight,
+		MaxXOffset: optional.New(CapabilitiesBuilderMaxWidth),
+		MaxYOffset: optional.New(CapabilitiesBuilderMaxHeight),
+		SettingProfiles: []SettingProfile{
+			{
+				SupportedResolutions: []SupportedResolutions{{}},
+			},
+		},
+	}
+}
+
+// adf returns ADF capabilities, creating them if needed.
+func (b *CapabilitiesBuilder) adf() *ADF {
+	if b.caps.ADF == nil {
+		b.caps.ADF = optional.New(ADF{})
+	}
+	return b.caps.ADF
+}
+
+// current returns the current input. If there is no current input,
+// it records the error and returns nil.
+func (b *CapabilitiesBuilder) current(method string) *InputSourceCaps {
+	if b.input == nil {
+		b.fail("%s: no input selected", method)
+	}
+	return b.input
+}
+
+// profile returns the SettingProfile of the current input.
+// If there is no current input, it records the error and returns nil.
+func (b *CapabilitiesBuilder) profile(method string) *SettingProfile {
+	inp := b.current(method)
+	if inp == nil {
+		return nil
+	}
+	return &inp.SettingProfiles[0]
+}
+
+// fail records the error, if no error was recorded before.
+func (b *CapabilitiesBuilder) fail(format string, args ...any) {
+	if b.err == nil {
+		b.err = fmt.Errorf("ScannerCapabilities: "+format, args...)
+	}
+}
@@ -0,0 +1,208 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// ScannerCapabilities builder test
+
+package escl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// TestCapabilitiesBuilder tests CapabilitiesBuilder
+func TestCapabilitiesBuilder(t *testing.T) {
+	b := NewCapabilitiesBuilder().
+		WithVersion(MakeVersion(2, 0)).
+		WithMakeAndModel("Virtual Scanner").
+		WithBrightness(Range{Min: -10, Normal: 0, Max: 10}).
+		WithPlaten().
+		WithSize(16, 16, 2550, 3300).
+		WithIntents(Document, Photo).
+		WithResolutions(150, 300).
+		WithColorModes(Grayscale8, RGB24).
+		WithDocumentFormats("image/jpeg").
+		WithADFDuplex().
+		WithResolutionRange(75, 600, 300).
+		WithColorModes(RGB24).
+		WithDocumentFormats("application/pdf").
+		WithFeederCapacity(30).
+		WithADFOptions(DetectPaperLoaded, DetectPaperLoaded)
+
+	caps, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build: %s", err)
+	}
+
+	expected := &ScannerCapabilities{
+		Version:           MakeVersion(2, 0),
+		MakeAndModel:      optional.New("Virtual Scanner"),
+		BrightnessSupport: optional.New(Range{Min: -10, Max: 10}),
+		Platen: optional.New(Platen{
+			PlatenInputCaps: optional.New(InputSourceCaps{
+				MinWidth:         16,
+				MinHeight:        16,
+				MaxWidth:         2550,
+				MaxHeight:        3300,
+				MaxXOffset:       optional.New(2550 - 16),
+				MaxYOffset:       optional.New(3300 - 16),
+				SupportedIntents: []Intent{Document, Photo},
+				SettingProfiles: []SettingProfile{{
+					ColorModes:      []ColorMode{Grayscale8, RGB24},
+					DocumentFormats: []string{"image/jpeg"},
+					SupportedResolutions: []SupportedResolutions{{
+						DiscreteResolutions: DiscreteResolutions{
+							{150, 150},
+							{300, 300},
+						},
+					}},
+				}},
+			}),
+		}),
+		ADF: optional.New(ADF{
+			ADFDuplexInputCaps: optional.New(InputSourceCaps{
+				MaxWidth:   CapabilitiesBuilderMaxWidth,
+				MaxHeight:  CapabilitiesBuilderMaxHeight,
+				MaxXOffset: optional.New(CapabilitiesBuilderMaxWidth),
+				MaxYOffset: optional.New(CapabilitiesBuilderMaxHeight),
+				SettingProfiles: []SettingProfile{{
+					ColorModes:      []ColorMode{RGB24},
+					DocumentFormats: []string{"application/pdf"},
+					SupportedResolutions: []SupportedResolutions{{
+						ResolutionRange: optional.New(
+							ResolutionRange{
+								XResolutionRange: Range{
+									Min: 75, Max: 600,
+									Normal: 300},
+								YResolutionRange: Range{
+									Min: 75, Max: 600,
+									Normal: 300},
+							}),
+					}},
+				}},
+			}),
+			FeederCapacity: optional.New(30),
+			ADFOptions:     []ADFOption{Duplex, DetectPaperLoaded},
+		}),
+	}
+
+	if !reflect.DeepEqual(caps, expected) {
+		t.Errorf("CapabilitiesBuilder: result mismatch\n"+
+			"expected: %#v\n"+
+			"present:  %#v",
+			expected.ToXML().EncodeString(NsMap),
+			caps.ToXML().EncodeString(NsMap))
+	}
+
+	// The result must survive XML round trip
+	decoded, err := DecodeScannerCapabilities(caps.ToXML())
+	if err != nil {
+		t.Fatalf("DecodeScannerCapabilities: %s", err)
+	}
+
+	if !reflect.DeepEqual(caps, decoded) {
+		t.Errorf("CapabilitiesBuilder: XML round trip mismatch")
+	}
+}
+
+// TestCapabilitiesBuilderErrors tests CapabilitiesBuilder errors
+func TestCapabilitiesBuilderErrors(t *testing.T) {
+	type testData struct {
+		b   *CapabilitiesBuilder // Builder
+		err string               // Expected error
+	}
+
+	tests := []testData{
+		{
+			b:   NewCapabilitiesBuilder(),
+			err: "ScannerCapabilities: no inputs defined",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithResolutions(300),
+			err: "ScannerCapabilities: WithResolutions: " +
+				"no input selected",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithPlaten().
+				WithResolutions(0),
+			err: "ScannerCapabilities: WithResolutions: " +
+				"invalid resolution 0",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithPlaten().
+				WithFeederCapacity(50),
+			err: "ScannerCapabilities: WithFeederCapacity: " +
+				"no ADF defined",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithPlaten().
+				WithADFOptions(DetectPaperLoaded),
+			err: "ScannerCapabilities: WithADFOptions: " +
+				"no ADF defined",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithADFSimplex().
+				WithADFOptions(Duplex),
+			err: "ScannerCapabilities: WithADFOptions: " +
+				"invalid option Duplex",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithPlaten().
+				WithResolutions(300).
+				WithDocumentFormats("image/jpeg"),
+			err: "ScannerCapabilities: Platen: " +
+				"no color modes defined",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithADFSimplex().
+				WithColorModes(RGB24).
+				WithDocumentFormats("image/jpeg"),
+			err: "ScannerCapabilities: ADFSimplex: " +
+				"no resolutions defined",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithCamera().
+				WithSize(100, 100, 50, 50),
+			err: "ScannerCapabilities: Camera: " +
+				"invalid min width",
+		},
+		{
+			b: NewCapabilitiesBuilder().
+				WithContrast(Range{Min: 0, Normal: 10, Max: 5}).
+				WithPlaten().
+				WithResolutions(300).
+				WithColorModes(RGB24).
+				WithDocumentFormats("image/jpeg"),
+			err: "ScannerCapabilities: ContrastSupport: " +
+				"invalid range 0...5 (normal 10)",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := test.b.Build()
+		if err == nil {
+			t.Errorf("%s: error not returned", test.err)
+			continue
+		}
+
+		if err.Error() != test.err {
+			t.Errorf("error mismatch:\n"+
+				"expected: %s\n"+
+				"present:  %s\n",
+				test.err, err)
+		}
+	}
+}
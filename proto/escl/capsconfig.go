@@ -0,0 +1,281 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Declarative ScannerCapabilities description
+
+package escl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+)
+
+// CapabilitiesConfig is the declarative description of the
+// [ScannerCapabilities], intended to be written by hand (i.e., for
+// modeling the particular device with the emulator) and stored
+// as JSON:
+//
+//	{
+//	  "make-and-model": "Example Scanner",
+//	  "feeder-capacity": 50,
+//	  "adf-options": ["DetectPaperLoaded"],
+//	  "brightness": {"min": -100, "max": 100, "normal": 0},
+//	  "platen": {
+//	    "resolutions": [150, 300, 600],
+//	    "color-modes": ["Grayscale8", "RGB24"],
+//	    "document-formats": ["image/jpeg", "application/pdf"]
+//	  },
+//	  "adf-simplex": {
+//	    "size": {"min-width": 591, "min-height": 591,
+//	             "max-width": 2550, "max-height": 4200},
+//	    "resolutions": [150, 300],
+//	    "color-modes": ["RGB24"],
+//	    "document-formats": ["image/jpeg"]
+//	  }
+//	}
+//
+// Enumerated values (intents, color modes, binary renderings and
+// ADF options) use the eSCL XML names. Sizes are in
+// ThreeHundredthsOfInches, as in eSCL.
+//
+// All fields are optional, but at least one input must be defined.
+// Use [CapabilitiesConfig.Build] to build the ScannerCapabilities.
+type CapabilitiesConfig struct {
+	Version            string                        `json:"version,omitempty"`
+	MakeAndModel       string                        `json:"make-and-model,omitempty"`
+	Manufacturer       string                        `json:"manufacturer,omitempty"`
+	SerialNumber       string                        `json:"serial-number,omitempty"`
+	UUID               string                        `json:"uuid,omitempty"`
+	FeederCapacity     int                           `json:"feeder-capacity,omitempty"`
+	ADFOptions         []string                      `json:"adf-options,omitempty"`
+	BlankPageDetection bool                          `json:"blank-page-detection,omitempty"`
+	BlankPageRemoval   bool                          `json:"blank-page-removal,omitempty"`
+	Brightness         *CapabilitiesConfigRange      `json:"brightness,omitempty"`
+	CompressionFactor  *CapabilitiesConfigRange      `json:"compression-factor,omitempty"`
+	Contrast           *CapabilitiesConfigRange      `json:"contrast,omitempty"`
+	Gamma              *CapabilitiesConfigFloatRange `json:"gamma,omitempty"`
+	Highlight          *CapabilitiesConfigRange      `json:"highlight,omitempty"`
+	NoiseRemoval       *CapabilitiesConfigRange      `json:"noise-removal,omitempty"`
+	Shadow             *CapabilitiesConfigRange      `json:"shadow,omitempty"`
+	Sharpen            *CapabilitiesConfigRange      `json:"sharpen,omitempty"`
+	Threshold          *CapabilitiesConfigRange      `json:"threshold,omitempty"`
+	Platen             *CapabilitiesConfigInput      `json:"platen,omitempty"`
+	ADFSimplex         *CapabilitiesConfigInput      `json:"adf-simplex,omitempty"`
+	ADFDuplex          *CapabilitiesConfigInput      `json:"adf-duplex,omitempty"`
+	Camera             *CapabilitiesConfigInput      `json:"camera,omitempty"`
+}
+
+// CapabilitiesConfigInput describes the single input source
+// within the [CapabilitiesConfig].
+type CapabilitiesConfigInput struct {
+	Size             *CapabilitiesConfigSize  `json:"size,omitempty"`
+	Intents          []string                 `json:"intents,omitempty"`
+	Resolutions      []int                    `json:"resolutions,omitempty"`
+	ResolutionRange  *CapabilitiesConfigRange `json:"resolution-range,omitempty"`
+	ColorModes       []string                 `json:"color-modes,omitempty"`
+	BinaryRenderings []string                 `json:"binary-renderings,omitempty"`
+	DocumentFormats  []string                 `json:"document-formats,omitempty"`
+}
+
+// CapabilitiesConfigSize describes the input size within
+// the [CapabilitiesConfigInput], in ThreeHundredthsOfInches.
+type CapabilitiesConfigSize struct {
+	MinWidth  int `json:"min-width"`
+	MinHeight int `json:"min-height"`
+	MaxWidth  int `json:"max-width"`
+	MaxHeight int `json:"max-height"`
+}
+
+// CapabilitiesConfigRange describes the [Range] within
+// the [CapabilitiesConfig].
+type CapabilitiesConfigRange struct {
+	Min    int `json:"min"`
+	Max    int `json:"max"`
+	Normal int `json:"normal"`
+}
+
+// CapabilitiesConfigFloatRange describes the [FloatRange] within
+// the [CapabilitiesConfig].
+type CapabilitiesConfigFloatRange struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Normal float64 `json:"normal"`
+}
+
+// DecodeCapabilitiesConfig decodes [CapabilitiesConfig] from JSON.
+// Unknown fields are rejected, so typos are not silently ignored.
+func DecodeCapabilitiesConfig(data []byte) (*CapabilitiesConfig, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg CapabilitiesConfig
+	err := dec.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("CapabilitiesConfig: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// Build builds the [ScannerCapabilities] out of the [CapabilitiesConfig].
+func (cfg *CapabilitiesConfig) Build() (*ScannerCapabilities, error) {
+	b := NewCapabilitiesBuilder()
+
+	// Device identification
+	if cfg.Version != "" {
+		ver, err := DecodeVersion(cfg.Version)
+		if err != nil {
+			return nil, fmt.Errorf("CapabilitiesConfig: version: %w",
+				err)
+		}
+		b.WithVersion(ver)
+	}
+
+	if cfg.UUID != "" {
+		uu, err := uuid.Parse(cfg.UUID)
+		if err != nil {
+			return nil, fmt.Errorf("CapabilitiesConfig: uuid: %w",
+				err)
+		}
+		b.WithUUID(uu)
+	}
+
+	if cfg.MakeAndModel != "" {
+		b.WithMakeAndModel(cfg.MakeAndModel)
+	}
+
+	if cfg.Manufacturer != "" {
+		b.WithManufacturer(cfg.Manufacturer)
+	}
+
+	if cfg.SerialNumber != "" {
+		b.WithSerialNumber(cfg.SerialNumber)
+	}
+
+	// Image processing ranges
+	ranges := []struct {
+		r    *CapabilitiesConfigRange
+		with func(Range) *CapabilitiesBuilder
+	}{
+		{cfg.Brightness, b.WithBrightness},
+		{cfg.CompressionFactor, b.WithCompressionFactor},
+		{cfg.Contrast, b.WithContrast},
+		{cfg.Highlight, b.WithHighlight},
+		{cfg.NoiseRemoval, b.WithNoiseRemoval},
+		{cfg.Shadow, b.WithShadow},
+		{cfg.Sharpen, b.WithSharpen},
+		{cfg.Threshold, b.WithThreshold},
+	}
+
+	for _, rng := range ranges {
+		if rng.r != nil {
+			rng.with(Range{
+				Min:    rng.r.Min,
+				Max:    rng.r.Max,
+				Normal: rng.r.Normal,
+			})
+		}
+	}
+
+	if cfg.Gamma != nil {
+		b.WithGamma(FloatRange{
+			Min:    cfg.Gamma.Min,
+			Max:    cfg.Gamma.Max,
+			Normal: cfg.Gamma.Normal,
+		})
+	}
+
+	if cfg.BlankPageDetection || cfg.BlankPageRemoval {
+		b.WithBlankPageDetection(cfg.BlankPageRemoval)
+	}
+
+	// Inputs
+	inputs := []struct {
+		name string
+		inp  *CapabilitiesConfigInput
+		with func() *CapabilitiesBuilder
+	}{
+		{"platen", cfg.Platen, b.WithPlaten},
+		{"adf-simplex", cfg.ADFSimplex, b.WithADFSimplex},
+		{"adf-duplex", cfg.ADFDuplex, b.WithADFDuplex},
+		{"camera", cfg.Camera, b.WithCamera},
+	}
+
+	for _, input := range inputs {
+		if input.inp != nil {
+			input.with()
+			err := input.inp.build(b)
+			if err != nil {
+				return nil, fmt.Errorf("CapabilitiesConfig: %s: %w",
+					input.name, err)
+			}
+		}
+	}
+
+	if cfg.FeederCapacity != 0 {
+		b.WithFeederCapacity(cfg.FeederCapacity)
+	}
+
+	for _, s := range cfg.ADFOptions {
+		opt := DecodeADFOption(s)
+		if opt == UnknownADFOption {
+			return nil, fmt.Errorf(
+				"CapabilitiesConfig: unknown ADF option %q", s)
+		}
+		b.WithADFOptions(opt)
+	}
+
+	return b.Build()
+}
+
+// build applies the CapabilitiesConfigInput to the current input
+// of the CapabilitiesBuilder.
+func (inp *CapabilitiesConfigInput) build(b *CapabilitiesBuilder) error {
+	if sz := inp.Size; sz != nil {
+		b.WithSize(sz.MinWidth, sz.MinHeight, sz.MaxWidth, sz.MaxHeight)
+	}
+
+	for _, s := range inp.Intents {
+		intent := DecodeIntent(s)
+		if intent == UnknownIntent {
+			return fmt.Errorf("unknown intent %q", s)
+		}
+		b.WithIntents(intent)
+	}
+
+	if len(inp.Resolutions) != 0 {
+		b.WithResolutions(inp.Resolutions...)
+	}
+
+	if rr := inp.ResolutionRange; rr != nil {
+		b.WithResolutionRange(rr.Min, rr.Max, rr.Normal)
+	}
+
+	for _, s := range inp.ColorModes {
+		cm := DecodeColorMode(s)
+		if cm == UnknownColorMode {
+			return fmt.Errorf("unknown color mode %q", s)
+		}
+		b.WithColorModes(cm)
+	}
+
+	for _, s := range inp.BinaryRenderings {
+		rend := DecodeBinaryRendering(s)
+		if rend == UnknownBinaryRendering {
+			return fmt.Errorf("unknown binary rendering %q", s)
+		}
+		b.WithBinaryRenderings(rend)
+	}
+
+	if len(inp.DocumentFormats) != 0 {
+		b.WithDocumentFormats(inp.DocumentFormats...)
+	}
+
+	return nil
+}
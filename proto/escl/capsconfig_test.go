@@ -0,0 +1,157 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Declarative ScannerCapabilities description test
+
+package escl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+)
+
+// TestCapabilitiesConfig tests CapabilitiesConfig decoding and building
+func TestCapabilitiesConfig(t *testing.T) {
+	const config = `{
+	  "version": "2.0",
+	  "make-and-model": "Virtual Scanner",
+	  "uuid": "5a1c4dc6-3f0e-4b8e-9c55-1e0b3ad0e1f7",
+	  "feeder-capacity": 30,
+	  "adf-options": ["DetectPaperLoaded", "SelectSinglePage"],
+	  "blank-page-detection": true,
+	  "brightness": {"min": -10, "max": 10, "normal": 0},
+	  "platen": {
+	    "size": {"min-width": 16, "min-height": 16,
+	             "max-width": 2550, "max-height": 3300},
+	    "intents": ["Document", "Photo"],
+	    "resolutions": [150, 300],
+	    "color-modes": ["Grayscale8", "RGB24"],
+	    "document-formats": ["image/jpeg"]
+	  },
+	  "adf-duplex": {
+	    "resolution-range": {"min": 75, "max": 600, "normal": 300},
+	    "color-modes": ["BlackAndWhite1", "RGB24"],
+	    "binary-renderings": ["Halftone"],
+	    "document-formats": ["application/pdf"]
+	  }
+	}`
+
+	cfg, err := DecodeCapabilitiesConfig([]byte(config))
+	if err != nil {
+		t.Fatalf("DecodeCapabilitiesConfig: %s", err)
+	}
+
+	caps, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Build: %s", err)
+	}
+
+	expected, err := NewCapabilitiesBuilder().
+		WithVersion(MakeVersion(2, 0)).
+		WithMakeAndModel("Virtual Scanner").
+		WithUUID(uuid.Must(uuid.Parse(
+			"5a1c4dc6-3f0e-4b8e-9c55-1e0b3ad0e1f7"))).
+		WithBrightness(Range{Min: -10, Normal: 0, Max: 10}).
+		WithBlankPageDetection(false).
+		WithPlaten().
+		WithSize(16, 16, 2550, 3300).
+		WithIntents(Document, Photo).
+		WithResolutions(150, 300).
+		WithColorModes(Grayscale8, RGB24).
+		WithDocumentFormats("image/jpeg").
+		WithADFDuplex().
+		WithResolutionRange(75, 600, 300).
+		WithColorModes(BlackAndWhite1, RGB24).
+		WithBinaryRenderings(Halftone).
+		WithDocumentFormats("application/pdf").
+		WithFeederCapacity(30).
+		WithADFOptions(DetectPaperLoaded, SelectSinglePage).
+		Build()
+
+	if err != nil {
+		t.Fatalf("CapabilitiesBuilder: %s", err)
+	}
+
+	if !reflect.DeepEqual(caps, expected) {
+		t.Errorf("ScannerCapabilities mismatch:\n"+
+			"expected: %s\n"+
+			"present:  %s",
+			expected.ToXML().EncodeString(NsMap),
+			caps.ToXML().EncodeString(NsMap))
+	}
+}
+
+// TestCapabilitiesConfigErrors tests CapabilitiesConfig error handling
+func TestCapabilitiesConfigErrors(t *testing.T) {
+	type testData struct {
+		config string
+		err    string
+	}
+
+	tests := []testData{
+		{
+			config: `{"platen": {"colour-modes": ["RGB24"]}}`,
+			err:    `CapabilitiesConfig: json: unknown field "colour-modes"`,
+		},
+
+		{
+			config: `{"platen": {"color-modes": ["RGB32"]}}`,
+			err:    `CapabilitiesConfig: platen: unknown color mode "RGB32"`,
+		},
+
+		{
+			config: `{"camera": {"intents": ["Selfie"]}}`,
+			err:    `CapabilitiesConfig: camera: unknown intent "Selfie"`,
+		},
+
+		{
+			config: `{"adf-simplex": {"binary-renderings": ["Dither"]}}`,
+			err:    `CapabilitiesConfig: adf-simplex: unknown binary rendering "Dither"`,
+		},
+
+		{
+			config: `{"adf-options": ["Stapler"], "adf-simplex": {}}`,
+			err:    `CapabilitiesConfig: unknown ADF option "Stapler"`,
+		},
+
+		{
+			config: `{"version": "two", "platen": {}}`,
+			err:    `CapabilitiesConfig: version: `,
+		},
+
+		{
+			config: `{"uuid": "bad", "platen": {}}`,
+			err:    `CapabilitiesConfig: uuid: `,
+		},
+
+		{
+			config: `{"make-and-model": "No Inputs"}`,
+			err:    `ScannerCapabilities: no inputs defined`,
+		},
+
+		{
+			config: `{"feeder-capacity": 10, "platen": {}}`,
+			err:    `ScannerCapabilities: WithFeederCapacity: no ADF defined`,
+		},
+	}
+
+	for _, test := range tests {
+		cfg, err := DecodeCapabilitiesConfig([]byte(test.config))
+		if err == nil {
+			_, err = cfg.Build()
+		}
+
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%s:\n"+
+				"error expected: %s\n"+
+				"error present:  %v",
+				test.config, test.err, err)
+		}
+	}
+}
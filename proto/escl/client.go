@@ -17,17 +17,72 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/OpenPrinting/go-mfp/log"
 	"github.com/OpenPrinting/go-mfp/transport"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
+// ClientDefaultMaxBusyWait is the default upper limit of the
+// total time, the [Client] waits for the busy scanner, while
+// retrying a single request.
+const ClientDefaultMaxBusyWait = 30 * time.Second
+
+// clientMinBusyDelay is the lower limit of the delay between
+// retries, so scanner that responds with the "Retry-After: 0"
+// will not be flooded with requests.
+const clientMinBusyDelay = 250 * time.Millisecond
+
 // Client implements a low-level eSCL client.
 type Client struct {
 	url        *url.URL          // Destination URL (http://...)
 	httpClient *transport.Client // HTTP Client
+	options    ClientOptions     // Client options
+	caps       clientCaps        // Cached ScannerCapabilities
+}
+
+// ClientOptions represents the [Client] creation options.
+//
+// Zero value of ClientOptions gives the default behavior:
+// requests, rejected by the busy scanner, fail immediately.
+type ClientOptions struct {
+	// RetryBusy, if set, makes Client to retry requests, rejected
+	// with the 503 Service Unavailable status, if scanner suggests
+	// the retry interval with the Retry-After header.
+	//
+	// Responses without Retry-After are returned to the caller
+	// as is, because they may mean the permanent failure of the
+	// job, not just the busy scanner.
+	RetryBusy bool
+
+	// MaxBusyWait is the upper limit of the total time, Client
+	// waits for the busy scanner, while retrying a single request.
+	// If the next retry doesn't fit this limit, the last 503
+	// response is returned to the caller.
+	//
+	// If zero, ClientDefaultMaxBusyWait is used.
+	MaxBusyWait time.Duration
+
+	// OnProgress, if not nil, is called to report the download
+	// progress of documents, returned by the NextDocument. It
+	// is called first by the NextDocument, before any data is
+	// received, then from the Read method of the document, after
+	// each chunk of data, and finally with the Progress.Done set,
+	// when the entire document is received.
+	//
+	// Total size is taken from the Content-Length. If scanner
+	// doesn't send it (i.e., uses chunked encoding), Client
+	// requests the ScanImageInfo to estimate the size.
+	OnProgress func(Progress)
+
+	// DisableCompression, if set, makes Client to request the
+	// uncompressed responses from the scanner. Otherwise, Client
+	// accepts gzip-compressed responses and transparently
+	// decompresses them.
+	DisableCompression bool
 }
 
 // NewClient creates a new eSCL client.
@@ -35,9 +90,25 @@ type Client struct {
 // If tr is nil, [transport.NewTransport] will be used to create
 // a new transport.
 func NewClient(u *url.URL, tr *transport.Transport) *Client {
+	return NewClientWithOptions(u, tr, ClientOptions{})
+}
+
+// NewClientWithOptions creates a new eSCL client with
+// the [ClientOptions].
+//
+// If tr is nil, [transport.NewTransport] will be used to create
+// a new transport.
+func NewClientWithOptions(u *url.URL, tr *transport.Transport,
+	options ClientOptions) *Client {
+
+	if options.MaxBusyWait == 0 {
+		options.MaxBusyWait = ClientDefaultMaxBusyWait
+	}
+
 	c := &Client{
 		url:        transport.URLClone(u),
 		httpClient: transport.NewClient(tr),
+		options:    options,
 	}
 
 	return c
@@ -53,6 +124,10 @@ func (c *Client) GetScannerCapabilities(ctx context.Context) (
 		caps, err = DecodeScannerCapabilities(xml)
 	}
 
+	if err == nil {
+		c.capsSave(caps)
+	}
+
 	return
 }
 
@@ -65,6 +140,10 @@ func (c *Client) GetScannerStatus(ctx context.Context) (
 		status, err = DecodeScannerStatus(xml)
 	}
 
+	if err == nil {
+		c.capsCheckStatus(ctx, status)
+	}
+
 	return
 }
 
@@ -85,6 +164,7 @@ func (c *Client) Scan(ctx context.Context, rq ScanSettings) (
 	// Send the request
 	details, err = c.post(ctx, "POST", "ScanJobs", rq.ToXML())
 	if err != nil {
+		c.capsCheckScan(ctx, details)
 		return
 	}
 
@@ -118,11 +198,40 @@ func (c *Client) Scan(ctx context.Context, rq ScanSettings) (
 	return
 }
 
+// GetScanBufferInfo requests the [ScanBufferInfo] from the eSCL
+// scanner. It returns the scanning parameters, as adjusted by the
+// scanner, and the estimated image size for the [ScanSettings],
+// without starting the scan job.
+//
+// This request is optional, and not all scanners support it.
+func (c *Client) GetScanBufferInfo(ctx context.Context, rq ScanSettings) (
+	info *ScanBufferInfo, details *HTTPDetails, err error) {
+
+	body, details, err := c.postBody(ctx, "PUT", "ScanBufferInfo",
+		rq.ToXML())
+	if err != nil {
+		return
+	}
+
+	xml, err := nsDecode(body)
+	body.Close()
+
+	if err == nil {
+		info, err = DecodeScanBufferInfo(xml)
+	}
+
+	return
+}
+
 // NextDocument retrieves the next document.
 //
 // If all scanned documents are consumed, it returns [io.EOF] error,
 // but please note that false positives are possible if there were
 // no preceding [Client.Scan] request or joburl is invalud.
+//
+// If scanner sends the document digest (Content-Digest header or
+// trailer), the document is verified while being read, and if
+// verification fails, reading ends with [transport.ErrContentDigest].
 func (c *Client) NextDocument(ctx context.Context, joburl string) (
 	doc io.ReadCloser, details *HTTPDetails, err error) {
 
@@ -131,6 +240,25 @@ func (c *Client) NextDocument(ctx context.Context, joburl string) (
 		err = io.EOF
 	}
 
+	if err == nil && c.options.OnProgress != nil {
+		doc = c.progress(ctx, joburl, doc, details)
+	}
+
+	return
+}
+
+// GetScanImageInfo requests the [ScanImageInfo] of the last
+// document, retrieved via the [Client.NextDocument].
+//
+// This request is optional, and not all scanners support it.
+func (c *Client) GetScanImageInfo(ctx context.Context, joburl string) (
+	info *ScanImageInfo, details *HTTPDetails, err error) {
+
+	xml, details, err := c.getXML(ctx, joburl+"/ScanImageInfo")
+	if err == nil {
+		info, err = DecodeScanImageInfo(xml)
+	}
+
 	return
 }
 
@@ -151,6 +279,76 @@ func (c *Client) Cancel(ctx context.Context, joburl string) (
 	return
 }
 
+// GetScanJobs requests the [ScanJobs] listing from the eSCL scanner.
+//
+// This is the vendor extension, and not all scanners support it.
+func (c *Client) GetScanJobs(ctx context.Context) (
+	jobs *ScanJobs, details *HTTPDetails, err error) {
+
+	xml, details, err := c.getXML(ctx, "ScanJobs")
+	if err == nil {
+		jobs, err = DecodeScanJobs(xml)
+	}
+
+	return
+}
+
+// GetStoredDocument retrieves the document of the job, stored by
+// the scanner. Documents are numbered from 1, in order of scanning.
+//
+// Unlike [Client.NextDocument], it may be used after the job
+// completion, and the same document may be retrieved many times.
+// If document is not stored, it returns [io.EOF] error.
+//
+// This is the vendor extension, and not all scanners support it.
+func (c *Client) GetStoredDocument(ctx context.Context, joburl string,
+	n int) (doc io.ReadCloser, details *HTTPDetails, err error) {
+
+	subpath := joburl + "/Documents/" + strconv.Itoa(n)
+	doc, details, err = c.get(ctx, "GET", subpath)
+	if details != nil && details.StatusCode == http.StatusNotFound {
+		err = io.EOF
+	}
+
+	return
+}
+
+// progress wraps the document, returned by the NextDocument,
+// to report the download progress via the ClientOptions.OnProgress
+// callback.
+func (c *Client) progress(ctx context.Context, joburl string,
+	doc io.ReadCloser, details *HTTPDetails) io.ReadCloser {
+
+	p := Progress{JobURL: joburl, Total: -1}
+
+	// Note, for compressed documents, Content-Length is the
+	// compressed size, while progress counts decompressed bytes.
+	length := details.Header.Get("Content-Length")
+	if details.Header.Get("Content-Encoding") != "" {
+		length = ""
+	}
+
+	if total, err := strconv.ParseInt(length, 10, 64); err == nil {
+		p.Total = total
+	} else {
+		info, _, err := c.GetScanImageInfo(ctx, joburl)
+		if err == nil && info.ActualBytesPerLine > 0 &&
+			info.ActualHeight > 0 {
+			p.Total = int64(info.ActualBytesPerLine) *
+				int64(info.ActualHeight)
+			p.Estimated = true
+		}
+	}
+
+	c.options.OnProgress(p)
+
+	return &progressReader{
+		ReadCloser: doc,
+		progress:   p,
+		callback:   c.options.OnProgress,
+	}
+}
+
 // getXML performs GET request, then decodes returned XML.
 func (c *Client) getXML(ctx context.Context, subpath string) (
 	xml xmldoc.Element, details *HTTPDetails, err error) {
@@ -162,7 +360,7 @@ func (c *Client) getXML(ctx context.Context, subpath string) (
 	}
 
 	// Decode the body
-	xml, err = xmldoc.Decode(NsMap, body)
+	xml, err = nsDecode(body)
 	body.Close()
 
 	return
@@ -194,12 +392,7 @@ func (c *Client) get(ctx context.Context, method, subpath string) (
 	log.Debug(ctx, "eSCL request: %s %s", method, u)
 
 	// Perform HTTP request
-	httpRq, err := transport.NewRequest(ctx, method, u, nil)
-	if err != nil {
-		return
-	}
-
-	httpRsp, err := c.httpClient.Do(httpRq)
+	httpRsp, err := c.do(ctx, method, u, nil)
 	if err != nil {
 		return
 	}
@@ -217,7 +410,8 @@ func (c *Client) get(ctx context.Context, method, subpath string) (
 		return
 	}
 
-	body = httpRsp.Body
+	body = transport.VerifyContentDigest(httpRsp)
+	body, err = c.decompress(httpRsp, body)
 	return
 }
 
@@ -226,6 +420,20 @@ func (c *Client) get(ctx context.Context, method, subpath string) (
 func (c *Client) post(ctx context.Context, method, subpath string,
 	xml xmldoc.Element) (details *HTTPDetails, err error) {
 
+	body, details, err := c.postBody(ctx, method, subpath, xml)
+	if body != nil {
+		body.Close()
+	}
+
+	return
+}
+
+// postBody is like post, but returns the response body.
+// On success, the caller is responsible for closing it.
+func (c *Client) postBody(ctx context.Context, method, subpath string,
+	xml xmldoc.Element) (body io.ReadCloser, details *HTTPDetails,
+	err error) {
+
 	// Prepare destination URL
 	u := c.dest(subpath)
 
@@ -236,20 +444,11 @@ func (c *Client) post(ctx context.Context, method, subpath string,
 	var buf bytes.Buffer
 	xml.Encode(&buf, NsMap)
 
-	httpRq, err := transport.NewRequest(ctx, method, u, &buf)
-	if err != nil {
-		return
-	}
-
-	httpRq.Header.Set("Content-Type", "text/xml")
-
-	httpRsp, err := c.httpClient.Do(httpRq)
+	httpRsp, err := c.do(ctx, method, u, buf.Bytes())
 	if err != nil {
 		return
 	}
 
-	defer httpRsp.Body.Close()
-
 	// Decode the response
 	details = &HTTPDetails{
 		Status:     httpRsp.Status,
@@ -259,8 +458,88 @@ func (c *Client) post(ctx context.Context, method, subpath string,
 
 	if httpRsp.StatusCode/100 != http.StatusOK/100 {
 		err = fmt.Errorf("HTTP: %s", httpRsp.Status)
+		httpRsp.Body.Close()
 		return
 	}
 
+	body, err = c.decompress(httpRsp, httpRsp.Body)
 	return
 }
+
+// decompress wraps the response body for decompression, according
+// to the Content-Encoding of the response. On error, body is closed.
+func (c *Client) decompress(httpRsp *http.Response, body io.ReadCloser) (
+	io.ReadCloser, error) {
+
+	decompressed, err := decompressBody(httpRsp.Header, body)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("HTTP: %w", err)
+	}
+
+	return decompressed, nil
+}
+
+// do performs the HTTP request. If xml is not nil, it is sent
+// as the request body.
+//
+// If enabled by the ClientOptions, request is retried while scanner
+// is busy, as suggested by the Retry-After header, within the
+// configured time limit.
+func (c *Client) do(ctx context.Context, method string, u *url.URL,
+	xml []byte) (*http.Response, error) {
+
+	deadline := time.Now().Add(c.options.MaxBusyWait)
+
+	for {
+		var body io.Reader
+		if xml != nil {
+			body = bytes.NewReader(xml)
+		}
+
+		httpRq, err := transport.NewRequest(ctx, method, u, body)
+		if err != nil {
+			return nil, err
+		}
+
+		if xml != nil {
+			httpRq.Header.Set("Content-Type", "text/xml")
+		}
+
+		// Note, if Accept-Encoding is not set explicitly,
+		// http.Transport requests gzip by itself and
+		// decompresses the response before Content-Digest
+		// is verified, so we always set it.
+		if c.options.DisableCompression {
+			httpRq.Header.Set("Accept-Encoding", "identity")
+		} else {
+			httpRq.Header.Set("Accept-Encoding", "gzip")
+		}
+
+		httpRsp, err := c.httpClient.Do(httpRq)
+		if err != nil ||
+			!c.options.RetryBusy ||
+			httpRsp.StatusCode != http.StatusServiceUnavailable {
+			return httpRsp, err
+		}
+
+		// Scanner is busy. Check if we can retry.
+		details := HTTPDetails{Header: httpRsp.Header}
+		delay, ok := details.RetryAfter()
+		delay = max(delay, clientMinBusyDelay)
+		if !ok || time.Now().Add(delay).After(deadline) {
+			return httpRsp, nil
+		}
+
+		httpRsp.Body.Close()
+
+		log.Debug(ctx, "eSCL: %s %s: %s, retry in %s",
+			method, u, httpRsp.Status, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -12,6 +12,9 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"net/http"
+	"path"
+	"sync"
 	"testing"
 
 	"github.com/OpenPrinting/go-mfp/abstract"
@@ -140,3 +143,169 @@ func TestClient(t *testing.T) {
 		return
 	}
 }
+
+// TestClientCachedCapabilities tests automatic invalidation of the
+// cached ScannerCapabilities
+func TestClientCachedCapabilities(t *testing.T) {
+	// Create test server
+	var lock sync.Mutex
+	capsVersion := MakeVersion(2, 0)
+	status := ScannerStatus{Version: capsVersion, State: ScannerIdle}
+	fetches := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter,
+		rq *http.Request) {
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		var xml xmldoc.Element
+		switch path.Base(rq.URL.Path) {
+		case "ScannerCapabilities":
+			fetches++
+			caps := ScannerCapabilities{Version: capsVersion}
+			xml = caps.ToXML()
+		case "ScannerStatus":
+			xml = status.ToXML()
+		default:
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		xml.Encode(w, NsMap)
+	})
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, handler)
+
+	go server.Serve(loopback)
+	defer server.Close()
+
+	clnt := NewClient(transport.MustParseURL("http://localhost/eSCL"), tr)
+	ctx := context.TODO()
+
+	// check performs the next step of the test
+	check := func(step string, expected int) {
+		_, err := clnt.CachedScannerCapabilities(ctx)
+		if err != nil {
+			t.Errorf("%s: %s", step, err)
+		}
+
+		lock.Lock()
+		present := fetches
+		lock.Unlock()
+
+		if present != expected {
+			t.Errorf("%s: capabilities fetched %d times, expected %d",
+				step, present, expected)
+		}
+	}
+
+	check("initial", 1)
+	check("cached", 1)
+
+	// Status without changes
+	clnt.GetScannerStatus(ctx)
+	check("status unchanged", 1)
+
+	// Version change
+	lock.Lock()
+	capsVersion = MakeVersion(2, 1)
+	status.Version = capsVersion
+	lock.Unlock()
+
+	clnt.GetScannerStatus(ctx)
+	check("version changed", 2)
+
+	// Scanner down and up
+	lock.Lock()
+	status.State = ScannerDown
+	lock.Unlock()
+
+	clnt.GetScannerStatus(ctx)
+	check("scanner down", 2)
+
+	lock.Lock()
+	status.State = ScannerIdle
+	lock.Unlock()
+
+	clnt.GetScannerStatus(ctx)
+	check("scanner up", 3)
+
+	// ScanJobs conflict
+	clnt.Scan(ctx, ScanSettings{Version: capsVersion})
+	check("scan conflict", 4)
+
+	// Explicit invalidation
+	clnt.InvalidateScannerCapabilities()
+	check("invalidated", 5)
+}
+
+// TestClientRetryBusy tests Client retries of requests, rejected
+// by the busy scanner.
+func TestClientRetryBusy(t *testing.T) {
+	h, _, tr, done := abstractClientTestSetup(t)
+	defer done()
+
+	u := transport.MustParseURL("http://localhost/eSCL")
+	ctx := context.TODO()
+
+	// By default, busy responses are returned to the caller
+	clnt := NewClient(u, tr)
+
+	h.lock.Lock()
+	h.busy["GET ScannerStatus"] = 1
+	h.lock.Unlock()
+
+	_, details, err := clnt.GetScannerStatus(ctx)
+	if err == nil || details == nil ||
+		details.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("RetryBusy disabled: 503 expected, present %v", err)
+	}
+
+	// With RetryBusy, requests are retried
+	clnt = NewClientWithOptions(u, tr, ClientOptions{RetryBusy: true})
+
+	h.lock.Lock()
+	h.busy["GET ScannerStatus"] = 2
+	h.busy["POST ScanJobs"] = 1
+	h.lock.Unlock()
+
+	_, _, err = clnt.GetScannerStatus(ctx)
+	if err != nil {
+		t.Errorf("GetScannerStatus: %s", err)
+	}
+
+	if n := h.count("GET ScannerStatus"); n != 4 {
+		t.Errorf("GetScannerStatus: expected %d requests, present %d",
+			4, n)
+	}
+
+	// POST request body must survive retries
+	joburl, _, err := clnt.Scan(ctx, ScanSettings{
+		Version:     MakeVersion(2, 0),
+		InputSource: optional.New(InputPlaten),
+	})
+	if err != nil {
+		t.Errorf("Scan: %s", err)
+	}
+
+	clnt.Cancel(ctx, joburl)
+
+	// Retries are limited by the MaxBusyWait
+	clnt = NewClientWithOptions(u, tr, ClientOptions{
+		RetryBusy:   true,
+		MaxBusyWait: clientMinBusyDelay / 2,
+	})
+
+	h.lock.Lock()
+	h.busy["GET ScannerStatus"] = 1
+	h.lock.Unlock()
+
+	_, details, err = clnt.GetScannerStatus(ctx)
+	if err == nil || details == nil ||
+		details.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("MaxBusyWait exceeded: 503 expected, present %v", err)
+	}
+}
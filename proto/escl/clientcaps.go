@@ -0,0 +1,123 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Client-side ScannerCapabilities cache
+
+package escl
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// clientCaps is the [ScannerCapabilities] cache, maintained by
+// the [Client].
+//
+// Capabilities may change during the device lifetime (for example,
+// user may change device settings from its front panel, or firmware
+// may be updated). The Client watches for the following indications
+// of such a change and invalidates the cache automatically:
+//   - ScannerStatus reports eSCL Version, different from the
+//     Version of the cached ScannerCapabilities
+//   - scanner returns to operation after the ScannerDown state
+//     (i.e., after reboot)
+//   - ScanJobs request is rejected with the 409 Conflict status,
+//     which means that cached capabilities are not accurate
+type clientCaps struct {
+	caps  *ScannerCapabilities // Cached capabilities, nil if none
+	state ScannerState         // Last known scanner state
+	fetch sync.Mutex           // Serializes capabilities fetching
+	lock  sync.Mutex           // Access lock
+}
+
+// CachedScannerCapabilities returns the [ScannerCapabilities],
+// cached by the Client.
+//
+// If capabilities are not cached yet or cache was invalidated,
+// capabilities are requested from the scanner.
+//
+// See also [Client.InvalidateScannerCapabilities].
+func (c *Client) CachedScannerCapabilities(ctx context.Context) (
+	*ScannerCapabilities, error) {
+
+	// Serialize fetches, so simultaneous callers will
+	// share the single request
+	c.caps.fetch.Lock()
+	defer c.caps.fetch.Unlock()
+
+	c.caps.lock.Lock()
+	caps := c.caps.caps
+	c.caps.lock.Unlock()
+
+	if caps != nil {
+		return caps, nil
+	}
+
+	caps, _, err := c.GetScannerCapabilities(ctx)
+	return caps, err
+}
+
+// InvalidateScannerCapabilities invalidates the [ScannerCapabilities],
+// cached by the Client, so they will be re-fetched on next call to the
+// [Client.CachedScannerCapabilities].
+//
+// Normally, Client invalidates the cache automatically, so this call
+// is required only if caller knows about changes in some other way.
+func (c *Client) InvalidateScannerCapabilities() {
+	c.caps.lock.Lock()
+	c.caps.caps = nil
+	c.caps.lock.Unlock()
+}
+
+// capsSave saves the freshly received ScannerCapabilities.
+func (c *Client) capsSave(caps *ScannerCapabilities) {
+	c.caps.lock.Lock()
+	c.caps.caps = caps
+	c.caps.lock.Unlock()
+}
+
+// capsCheckStatus checks the ScannerStatus for the capabilities
+// change indications and invalidates the cache, if needed.
+func (c *Client) capsCheckStatus(ctx context.Context, status *ScannerStatus) {
+	c.caps.lock.Lock()
+	defer c.caps.lock.Unlock()
+
+	prev := c.caps.state
+	c.caps.state = status.State
+
+	switch {
+	case c.caps.caps == nil:
+		// Nothing to invalidate
+
+	case status.Version != 0 && status.Version != c.caps.caps.Version:
+		log.Debug(ctx, "eSCL: version changed %s->%s: "+
+			"capabilities invalidated",
+			c.caps.caps.Version, status.Version)
+		c.caps.caps = nil
+
+	case prev == ScannerDown && status.State != ScannerDown:
+		log.Debug(ctx, "eSCL: scanner is up again: "+
+			"capabilities invalidated")
+		c.caps.caps = nil
+	}
+}
+
+// capsCheckScan checks the ScanJobs response for the capabilities
+// change indications and invalidates the cache, if needed.
+func (c *Client) capsCheckScan(ctx context.Context, details *HTTPDetails) {
+	if details != nil && details.StatusCode == http.StatusConflict {
+		c.caps.lock.Lock()
+		if c.caps.caps != nil {
+			log.Debug(ctx, "eSCL: ScanJobs conflict: "+
+				"capabilities invalidated")
+			c.caps.caps = nil
+		}
+		c.caps.lock.Unlock()
+	}
+}
@@ -57,6 +57,25 @@ func (cm ColorMode) String() string {
 	return "Unknown"
 }
 
+// BitsPerPixel returns number of bits per image pixel for the
+// [ColorMode], or 0 for UnknownColorMode.
+func (cm ColorMode) BitsPerPixel() int {
+	switch cm {
+	case BlackAndWhite1:
+		return 1
+	case Grayscale8:
+		return 8
+	case Grayscale16:
+		return 16
+	case RGB24:
+		return 24
+	case RGB48:
+		return 48
+	}
+
+	return 0
+}
+
 // DecodeColorMode decodes [ColorMode] out of its XML string representation.
 func DecodeColorMode(s string) ColorMode {
 	switch s {
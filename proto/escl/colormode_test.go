@@ -26,3 +26,22 @@ var testColorMode = testEnum[ColorMode]{
 func TestColorMode(t *testing.T) {
 	testColorMode.run(t)
 }
+
+// TestColorModeBitsPerPixel tests ColorMode.BitsPerPixel
+func TestColorModeBitsPerPixel(t *testing.T) {
+	tests := map[ColorMode]int{
+		UnknownColorMode: 0,
+		BlackAndWhite1:   1,
+		Grayscale8:       8,
+		Grayscale16:      16,
+		RGB24:            24,
+		RGB48:            48,
+	}
+
+	for cm, bpp := range tests {
+		if cm.BitsPerPixel() != bpp {
+			t.Errorf("%s.BitsPerPixel: expected %d, present %d",
+				cm, bpp, cm.BitsPerPixel())
+		}
+	}
+}
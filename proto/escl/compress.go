@@ -0,0 +1,111 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP content compression
+
+package escl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressMinSize is the minimal size of the response body,
+// worth compression. Smaller responses are sent uncompressed,
+// as gain is negligible.
+const compressMinSize = 1024
+
+// compressAcceptsGzip reports if the HTTP request, with the given
+// Accept-Encoding header, allows the gzip content coding in response.
+//
+// Codings, explicitly disabled with "q=0", are not accepted.
+func compressAcceptsGzip(hdr http.Header) bool {
+	wildcard := false
+
+	for _, field := range hdr.Values("Accept-Encoding") {
+		for _, item := range strings.Split(field, ",") {
+			coding, params, _ := strings.Cut(item, ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+
+			ok := compressQValue(params) > 0
+
+			switch coding {
+			case "gzip", "x-gzip":
+				return ok
+			case "*":
+				wildcard = ok
+			}
+		}
+	}
+
+	return wildcard
+}
+
+// compressQValue returns the quality value (the "q=" parameter)
+// of the Accept-Encoding item. If missed or invalid, 1 is returned.
+func compressQValue(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(param, "=")
+		if strings.EqualFold(strings.TrimSpace(name), "q") {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err == nil {
+				return q
+			}
+		}
+	}
+
+	return 1
+}
+
+// compressGzip compresses data with gzip.
+func compressGzip(data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// decompressBody wraps the HTTP response body for decompression,
+// according to the Content-Encoding header.
+//
+// Only gzip content coding is supported. If Content-Encoding is
+// not set, body is returned as is.
+func decompressBody(hdr http.Header, body io.ReadCloser) (
+	io.ReadCloser, error) {
+
+	coding := strings.ToLower(hdr.Get("Content-Encoding"))
+	switch coding {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		r, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		return &decompressReader{Reader: r, body: body}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported Content-Encoding: %q", coding)
+}
+
+// decompressReader is the io.ReadCloser, returned by
+// the decompressBody.
+type decompressReader struct {
+	*gzip.Reader               // Decompressor
+	body         io.ReadCloser // Underlying body
+}
+
+// Close closes the decompressReader.
+func (r *decompressReader) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
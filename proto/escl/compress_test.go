@@ -0,0 +1,106 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP content compression test
+
+package escl
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// TestCompressAcceptsGzip tests compressAcceptsGzip
+func TestCompressAcceptsGzip(t *testing.T) {
+	type testData struct {
+		accept   string
+		expected bool
+	}
+
+	tests := []testData{
+		{"", false},
+		{"gzip", true},
+		{"GZip", true},
+		{"x-gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"*, gzip;q=0", false},
+		{"gzip;q=0, *", false},
+		{"deflate, br", false},
+		{"identity", false},
+	}
+
+	for _, test := range tests {
+		hdr := http.Header{}
+		if test.accept != "" {
+			hdr.Set("Accept-Encoding", test.accept)
+		}
+
+		accepts := compressAcceptsGzip(hdr)
+		if accepts != test.expected {
+			t.Errorf("%q: expected %v, present %v",
+				test.accept, test.expected, accepts)
+		}
+	}
+}
+
+// TestDecompressBody tests decompressBody
+func TestDecompressBody(t *testing.T) {
+	data := bytes.Repeat([]byte("<scan:ScannerCapabilities/>"), 100)
+
+	type testData struct {
+		encoding string // Content-Encoding
+		body     []byte // Response body
+		err      string // Expected error, "" if none
+	}
+
+	tests := []testData{
+		{"", data, ""},
+		{"identity", data, ""},
+		{"gzip", compressGzip(data), ""},
+		{"x-gzip", compressGzip(data), ""},
+		{"gzip", data, "gzip: gzip: invalid header"},
+		{"br", data, `unsupported Content-Encoding: "br"`},
+	}
+
+	for _, test := range tests {
+		hdr := http.Header{}
+		if test.encoding != "" {
+			hdr.Set("Content-Encoding", test.encoding)
+		}
+
+		body := io.NopCloser(bytes.NewReader(test.body))
+		rd, err := decompressBody(hdr, body)
+
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if estr != test.err {
+			t.Errorf("%q: error mismatch:\n"+
+				"expected: %s\n"+
+				"present:  %s", test.encoding, test.err, estr)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		received, err := io.ReadAll(rd)
+		rd.Close()
+
+		if err != nil || !bytes.Equal(received, data) {
+			t.Errorf("%q: data mismatch (%v)", test.encoding, err)
+		}
+	}
+}
@@ -0,0 +1,588 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL conformance test suite
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conformance checks
+
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// checks contains all conformance checks, in order of execution
+var checks = []check{
+	{
+		name: "capabilities",
+		help: "ScannerCapabilities can be fetched and decoded",
+		run:  checkCapabilities,
+	},
+	{
+		name: "caps-inputs",
+		help: "Input sources capabilities are consistent",
+		run:  checkCapsInputs,
+	},
+	{
+		name: "caps-profiles",
+		help: "Setting profiles are complete and consistent",
+		run:  checkCapsProfiles,
+	},
+	{
+		name: "status",
+		help: "ScannerStatus can be fetched and decoded",
+		run:  checkStatus,
+	},
+	{
+		name: "not-found",
+		help: "Unknown resources are rejected with 404",
+		run:  checkNotFound,
+	},
+	{
+		name: "unknown-job",
+		help: "NextDocument of unknown job is rejected with 404",
+		run:  checkUnknownJob,
+	},
+	{
+		name: "invalid-resolution",
+		help: "Unsupported resolution is rejected",
+		run:  checkInvalidResolution,
+	},
+	{
+		name: "invalid-input",
+		help: "Unsupported input source is rejected",
+		run:  checkInvalidInput,
+	},
+	{
+		name: "adf-empty",
+		help: "Scan from the empty ADF is rejected",
+		run:  checkADFEmpty,
+	},
+	{
+		name: "scan",
+		help: "Platen scan job lifecycle",
+		scan: true,
+		run:  checkScan,
+	},
+	{
+		name: "cancel",
+		help: "Scan job can be canceled",
+		scan: true,
+		run:  checkCancel,
+	},
+}
+
+// rejectStatuses contains HTTP statuses, acceptable for rejection
+// of the invalid ScanJobs request.
+//
+// eSCL specification doesn't strictly define the status, but
+// 409 Conflict is commonly used, and 400 Bad Request is
+// reasonable as well.
+var rejectStatuses = []int{http.StatusConflict, http.StatusBadRequest}
+
+// checkCapabilities fetches and decodes ScannerCapabilities
+func checkCapabilities(ctx context.Context, s *suite) error {
+	caps, _, err := s.clnt.GetScannerCapabilities(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.caps = caps
+	return nil
+}
+
+// checkCapsInputs checks consistency of the input sources
+// capabilities
+func checkCapsInputs(ctx context.Context, s *suite) error {
+	if err := s.needCaps(); err != nil {
+		return err
+	}
+
+	inputs := 0
+	for _, input := range []escl.InputSource{
+		escl.InputPlaten, escl.InputFeeder, escl.InputCamera} {
+
+		inp := s.inputCaps(input)
+		if inp == nil {
+			continue
+		}
+
+		inputs++
+
+		switch {
+		case inp.MaxWidth <= 0 || inp.MaxHeight <= 0:
+			return fmt.Errorf("%s: invalid max size %dx%d",
+				input, inp.MaxWidth, inp.MaxHeight)
+
+		case inp.MinWidth > inp.MaxWidth:
+			return fmt.Errorf("%s: MinWidth (%d) > MaxWidth (%d)",
+				input, inp.MinWidth, inp.MaxWidth)
+
+		case inp.MinHeight > inp.MaxHeight:
+			return fmt.Errorf("%s: MinHeight (%d) > MaxHeight (%d)",
+				input, inp.MinHeight, inp.MaxHeight)
+
+		case len(inp.SettingProfiles) == 0:
+			return fmt.Errorf("%s: no SettingProfiles", input)
+		}
+	}
+
+	if inputs == 0 {
+		return errors.New("no input sources")
+	}
+
+	for _, input := range s.caps.JobSources {
+		if s.inputCaps(input) == nil {
+			return fmt.Errorf("JobSources: %s: capabilities missed",
+				input)
+		}
+	}
+
+	return nil
+}
+
+// checkCapsProfiles checks the setting profiles
+func checkCapsProfiles(ctx context.Context, s *suite) error {
+	if err := s.needCaps(); err != nil {
+		return err
+	}
+
+	for _, input := range []escl.InputSource{
+		escl.InputPlaten, escl.InputFeeder, escl.InputCamera} {
+
+		inp := s.inputCaps(input)
+		if inp == nil {
+			continue
+		}
+
+		for i, prof := range inp.SettingProfiles {
+			where := fmt.Sprintf("%s: SettingProfile %d", input, i)
+
+			switch {
+			case len(prof.ColorModes) == 0:
+				return fmt.Errorf("%s: no ColorModes", where)
+
+			case len(prof.DocumentFormats) == 0 &&
+				len(prof.DocumentFormatsExt) == 0:
+				return fmt.Errorf("%s: no DocumentFormats", where)
+
+			case len(prof.SupportedResolutions) == 0:
+				return fmt.Errorf("%s: no SupportedResolutions",
+					where)
+			}
+
+			for _, res := range prof.SupportedResolutions {
+				err := checkResolutions(res)
+				if err != nil {
+					return fmt.Errorf("%s: %w", where, err)
+				}
+			}
+
+			for _, format := range prof.DocumentFormats {
+				_, _, err := mime.ParseMediaType(format)
+				if err != nil {
+					return fmt.Errorf("%s: DocumentFormat %q: %w",
+						where, format, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkResolutions checks the SupportedResolutions
+func checkResolutions(res escl.SupportedResolutions) error {
+	if len(res.DiscreteResolutions) == 0 && res.ResolutionRange == nil {
+		return errors.New("empty SupportedResolutions")
+	}
+
+	for _, dr := range res.DiscreteResolutions {
+		if dr.XResolution <= 0 || dr.YResolution <= 0 {
+			return fmt.Errorf("invalid DiscreteResolution %dx%d",
+				dr.XResolution, dr.YResolution)
+		}
+	}
+
+	if rr := res.ResolutionRange; rr != nil {
+		for _, r := range []escl.Range{
+			rr.XResolutionRange, rr.YResolutionRange} {
+
+			if r.Min <= 0 || r.Min > r.Max ||
+				r.Normal < r.Min || r.Normal > r.Max ||
+				optional.Get(r.Step) < 0 {
+				return fmt.Errorf("invalid ResolutionRange %+v",
+					r)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkStatus fetches and decodes ScannerStatus
+func checkStatus(ctx context.Context, s *suite) error {
+	status, _, err := s.clnt.GetScannerStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status.State == escl.UnknownScannerState {
+		return errors.New("unknown scanner state")
+	}
+
+	return nil
+}
+
+// checkNotFound checks that unknown resources are rejected with 404
+func checkNotFound(ctx context.Context, s *suite) error {
+	u := transport.URLClone(s.url)
+	u.Path = path.Join(u.Path, "NoSuchResource")
+
+	rq, err := transport.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := s.http.Do(rq)
+	if err != nil {
+		return err
+	}
+
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("GET %s: expected 404, received %s",
+			u.Path, rsp.Status)
+	}
+
+	return nil
+}
+
+// checkUnknownJob checks that NextDocument for the unknown job
+// is rejected with 404.
+func checkUnknownJob(ctx context.Context, s *suite) error {
+	joburl := path.Join(s.url.Path, "ScanJobs",
+		"urn:uuid:00000000-0000-0000-0000-000000000000")
+
+	doc, details, err := s.clnt.NextDocument(ctx, joburl)
+	switch {
+	case err == io.EOF:
+		return nil
+	case err == nil:
+		doc.Close()
+		return errors.New("expected 404, received 200 OK")
+	case details != nil:
+		return fmt.Errorf("expected 404, received %s", details.Status)
+	}
+
+	return err
+}
+
+// checkInvalidResolution checks that unsupported resolution
+// is rejected.
+func checkInvalidResolution(ctx context.Context, s *suite) error {
+	if err := s.needCaps(); err != nil {
+		return err
+	}
+
+	input := s.anyInput()
+	ss := escl.ScanSettings{
+		Version:     s.caps.Version,
+		InputSource: optional.New(input),
+		XResolution: optional.New(1),
+		YResolution: optional.New(1),
+	}
+
+	if ss.Validate(s.caps) == nil {
+		return skip("1 DPI resolution is supported")
+	}
+
+	return s.expectReject(ctx, ss)
+}
+
+// checkInvalidInput checks that unsupported input source
+// is rejected.
+func checkInvalidInput(ctx context.Context, s *suite) error {
+	if err := s.needCaps(); err != nil {
+		return err
+	}
+
+	for _, input := range []escl.InputSource{
+		escl.InputCamera, escl.InputFeeder, escl.InputPlaten} {
+
+		if s.inputCaps(input) == nil {
+			ss := escl.ScanSettings{
+				Version:     s.caps.Version,
+				InputSource: optional.New(input),
+			}
+
+			return s.expectReject(ctx, ss)
+		}
+	}
+
+	return skip("all input sources are supported")
+}
+
+// checkADFEmpty checks that scan from the empty ADF is rejected.
+func checkADFEmpty(ctx context.Context, s *suite) error {
+	if err := s.needCaps(); err != nil {
+		return err
+	}
+
+	if s.caps.ADF == nil {
+		return skip("no ADF")
+	}
+
+	status, _, err := s.clnt.GetScannerStatus(ctx)
+	switch {
+	case err != nil:
+		return err
+	case status.ADFState == nil:
+		return skip("ADF state not reported")
+	case *status.ADFState != escl.ScannerAdfEmpty:
+		return skip("ADF is not empty (%s)", *status.ADFState)
+	}
+
+	ss := escl.ScanSettings{
+		Version:     s.caps.Version,
+		InputSource: optional.New(escl.InputFeeder),
+	}
+
+	// Scanners may report empty ADF as "busy"
+	statuses := append(slices.Clone(rejectStatuses),
+		http.StatusServiceUnavailable)
+
+	return s.expectReject(ctx, ss, statuses...)
+}
+
+// checkScan runs the complete platen scan job.
+func checkScan(ctx context.Context, s *suite) error {
+	if err := s.needCaps(); err != nil {
+		return err
+	}
+
+	if s.inputCaps(escl.InputPlaten) == nil {
+		return skip("no platen")
+	}
+
+	if err := s.waitIdle(ctx); err != nil {
+		return err
+	}
+
+	ss := s.scanSettings()
+	joburl, _, err := s.clnt.Scan(ctx, ss)
+	if err != nil {
+		return fmt.Errorf("ScanJobs: %w", err)
+	}
+
+	// Fetch all pages
+	formats := s.caps.DocumentFormats()
+	pages := 0
+
+	for {
+		doc, details, err := s.clnt.NextDocument(ctx, joburl)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			s.clnt.Cancel(ctx, joburl)
+			return fmt.Errorf("NextDocument: %w", err)
+		}
+
+		data, err := io.ReadAll(doc)
+		doc.Close()
+
+		if err != nil {
+			s.clnt.Cancel(ctx, joburl)
+			return fmt.Errorf("NextDocument: %w", err)
+		}
+
+		pages++
+
+		contentType := details.Header.Get("Content-Type")
+		ct, _, _ := mime.ParseMediaType(contentType)
+
+		switch {
+		case len(data) == 0:
+			err = fmt.Errorf("page %d: empty image", pages)
+		case !slices.Contains(formats, ct):
+			err = fmt.Errorf("page %d: unexpected Content-Type %q",
+				pages, ct)
+		default:
+			err = checkImage(data, contentType, ss)
+			if err != nil {
+				err = fmt.Errorf("page %d: %w", pages, err)
+			}
+		}
+
+		if err != nil {
+			s.clnt.Cancel(ctx, joburl)
+			return err
+		}
+	}
+
+	if pages == 0 {
+		return errors.New("no pages received")
+	}
+
+	// Check job state
+	state, found, err := s.jobState(ctx, joburl)
+	switch {
+	case err != nil:
+		return err
+	case found && state != escl.JobCompleted:
+		return fmt.Errorf("job state: expected %s, present %s",
+			escl.JobCompleted, state)
+	}
+
+	return nil
+}
+
+// checkImage checks the received image against the scan request.
+func checkImage(data []byte, contentType string, ss escl.ScanSettings) error {
+	mismatches, err := escl.CheckImage(data, contentType, ss)
+	switch {
+	case err != nil:
+		return err
+	case len(mismatches) != 0:
+		msgs := make([]string, len(mismatches))
+		for i, m := range mismatches {
+			msgs[i] = m.String()
+		}
+		return errors.New(strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
+
+// checkCancel starts the platen scan job and cancels it.
+func checkCancel(ctx context.Context, s *suite) error {
+	if err := s.needCaps(); err != nil {
+		return err
+	}
+
+	if s.inputCaps(escl.InputPlaten) == nil {
+		return skip("no platen")
+	}
+
+	if err := s.waitIdle(ctx); err != nil {
+		return err
+	}
+
+	ss := s.scanSettings()
+	joburl, _, err := s.clnt.Scan(ctx, ss)
+	if err != nil {
+		return fmt.Errorf("ScanJobs: %w", err)
+	}
+
+	_, err = s.clnt.Cancel(ctx, joburl)
+	if err != nil {
+		return fmt.Errorf("DELETE: %w", err)
+	}
+
+	// Canceled job must not return any more pages
+	doc, details, err := s.clnt.NextDocument(ctx, joburl)
+	switch {
+	case err == nil:
+		doc.Close()
+		return errors.New("NextDocument: canceled job returns pages")
+	case err != io.EOF && details == nil:
+		return fmt.Errorf("NextDocument: %w", err)
+	}
+
+	// Check job state
+	state, found, err := s.jobState(ctx, joburl)
+	switch {
+	case err != nil:
+		return err
+	case found && state != escl.JobCanceled && state != escl.JobAborted:
+		return fmt.Errorf("job state: expected %s, present %s",
+			escl.JobCanceled, state)
+	}
+
+	return s.waitIdle(ctx)
+}
+
+// anyInput returns the first supported input source.
+func (s *suite) anyInput() escl.InputSource {
+	for _, input := range []escl.InputSource{
+		escl.InputPlaten, escl.InputFeeder, escl.InputCamera} {
+		if s.inputCaps(input) != nil {
+			return input
+		}
+	}
+
+	return escl.InputPlaten
+}
+
+// scanSettings returns ScanSettings for the scanning checks.
+// Preview settings are used to make scanning fast.
+func (s *suite) scanSettings() escl.ScanSettings {
+	ss := escl.ScanSettings{
+		Version:     s.caps.Version,
+		InputSource: optional.New(escl.InputPlaten),
+	}
+
+	return escl.PreviewSettings(s.caps, ss)
+}
+
+// expectReject sends ScanJobs request, which must be rejected with
+// one of the specified HTTP statuses (rejectStatuses by default).
+// If scanner accepts the request, the job is canceled immediately.
+func (s *suite) expectReject(ctx context.Context, ss escl.ScanSettings,
+	statuses ...int) error {
+
+	if len(statuses) == 0 {
+		statuses = rejectStatuses
+	}
+
+	joburl, details, err := s.clnt.Scan(ctx, ss)
+	switch {
+	case err == nil:
+		s.clnt.Cancel(ctx, joburl)
+		return errors.New("request accepted")
+	case details == nil:
+		return err
+	case !slices.Contains(statuses, details.StatusCode):
+		return fmt.Errorf("unexpected status %s", details.Status)
+	}
+
+	return nil
+}
+
+// jobState returns the job state, as reported by the ScannerStatus.
+// If job is not listed in the ScannerStatus, found is false.
+//
+// Note, some scanners report JobUri as absolute URL, so only
+// the path part is compared.
+func (s *suite) jobState(ctx context.Context,
+	joburl string) (state escl.JobState, found bool, err error) {
+
+	status, _, err := s.clnt.GetScannerStatus(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, info := range status.Jobs {
+		if strings.HasSuffix(info.JobURI, joburl) {
+			return info.JobState, true, nil
+		}
+	}
+
+	return
+}
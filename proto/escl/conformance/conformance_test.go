@@ -0,0 +1,146 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL conformance test suite
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conformance test suite test
+
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// conformanceTestRun runs the test suite against the AbstractServer
+// with the virtual scanner.
+func conformanceTestRun(t *testing.T,
+	hook func(query *escl.AbstractServerQuery)) *Report {
+
+	xml, err := xmldoc.Decode(escl.NsMap, bytes.NewReader(testutils.
+		Kyocera.ECOSYS.M2040dn.ESCL.ScannerCapabilities))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	caps, err := escl.DecodeScannerCapabilities(xml)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	// VirtualScanner always returns PNG images, so add PNG
+	// to the supported formats.
+	prof := &caps.Platen.PlatenInputCaps.SettingProfiles[0]
+	prof.DocumentFormats = append(prof.DocumentFormats,
+		abstract.DocumentFormatPNG)
+
+	scanner := &abstract.VirtualScanner{
+		ScanCaps: caps.ToAbstract(),
+		Resolution: abstract.Resolution{
+			XResolution: 300,
+			YResolution: 300,
+		},
+		PlatenImage: testutils.Images.PNG100x75rgb8,
+	}
+
+	tr, loopback := transport.NewLoopback()
+	base := transport.MustParseURL("http://localhost/eSCL")
+	options := escl.AbstractServerOptions{
+		Version:   caps.Version,
+		Scanner:   scanner,
+		BasePath:  base.Path,
+		OnRequest: hook,
+	}
+
+	handler := escl.NewAbstractServer(context.TODO(), options)
+	server := transport.NewServer(nil, handler)
+
+	go server.Serve(loopback)
+	defer server.Close()
+
+	return Run(context.TODO(), base, Options{Transport: tr, Scan: true})
+}
+
+// TestConformance runs the test suite against the AbstractServer,
+// which is expected to pass it.
+func TestConformance(t *testing.T) {
+	report := conformanceTestRun(t, nil)
+
+	if len(report.Results) != len(checks) {
+		t.Errorf("expected %d results, present %d",
+			len(checks), len(report.Results))
+	}
+
+	for _, res := range report.Results {
+		if res.Status != Pass {
+			t.Errorf("%s: %s (%s)", res.Name, res.Status, res.Details)
+		}
+	}
+
+	if !report.Passed() {
+		buf := &bytes.Buffer{}
+		report.Format(buf)
+		t.Errorf("report:\n%s", buf)
+	}
+}
+
+// TestConformanceFailures tests that non-conforming behavior
+// is detected.
+func TestConformanceFailures(t *testing.T) {
+	// Server that accepts everything and answers 200 OK
+	// for unknown resources.
+	hook := func(query *escl.AbstractServerQuery) {
+		if strings.HasSuffix(query.URL.Path, "/NoSuchResource") {
+			query.WriteHeader(http.StatusOK)
+		}
+	}
+
+	report := conformanceTestRun(t, hook)
+
+	failed := map[string]bool{}
+	for _, res := range report.Results {
+		if res.Status == Fail {
+			failed[res.Name] = true
+		}
+	}
+
+	if !failed["not-found"] || len(failed) != 1 {
+		t.Errorf("expected only not-found to fail, present %v",
+			failed)
+	}
+
+	if report.Passed() {
+		t.Errorf("Report.Passed: expected false")
+	}
+
+	passed, fails, skipped := report.Counts()
+	if passed+fails+skipped != len(checks) || fails != 1 {
+		t.Errorf("Report.Counts: %d/%d/%d", passed, fails, skipped)
+	}
+}
+
+// TestConformanceNoScan tests that scanning checks are skipped,
+// if not enabled.
+func TestConformanceNoScan(t *testing.T) {
+	report := Run(context.TODO(),
+		transport.MustParseURL("http://localhost/eSCL"),
+		Options{Transport: transport.NewTransport(nil)})
+
+	for i, chk := range checks {
+		res := report.Results[i]
+		if chk.scan && res.Status != Skip {
+			t.Errorf("%s: expected %s, present %s",
+				chk.name, Skip, res.Status)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL conformance test suite
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Package documentation
+
+// Package conformance implements the battery of programmatic
+// checks of the eSCL scanner behavior, runnable against the real
+// devices: capabilities consistency, HTTP status codes, ADF
+// behavior and handling of the invalid scan settings.
+//
+// Checks are executed by the [Run] function, that returns the
+// pass/fail [Report].
+package conformance
@@ -0,0 +1,89 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL conformance test suite
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conformance report
+
+package conformance
+
+import (
+	"fmt"
+	"io"
+)
+
+// Status is the outcome of the single check.
+type Status int
+
+// Status values:
+const (
+	Pass Status = iota // Check passed
+	Fail               // Check failed
+	Skip               // Check not applicable or disabled
+)
+
+// String returns the string representation of the [Status].
+func (status Status) String() string {
+	switch status {
+	case Pass:
+		return "PASS"
+	case Fail:
+		return "FAIL"
+	case Skip:
+		return "SKIP"
+	}
+
+	return fmt.Sprintf("Unknown (%d)", int(status))
+}
+
+// Result is the result of the single check.
+type Result struct {
+	Name    string // Check name
+	Help    string // Check description
+	Status  Status // Check status
+	Details string // Explanation of failure or skip
+}
+
+// Report is the result of the conformance test suite run.
+type Report struct {
+	URL     string   // Scanner URL
+	Results []Result // Results of individual checks, in order
+}
+
+// Counts returns count of passed, failed and skipped checks.
+func (report *Report) Counts() (passed, failed, skipped int) {
+	for _, res := range report.Results {
+		switch res.Status {
+		case Pass:
+			passed++
+		case Fail:
+			failed++
+		case Skip:
+			skipped++
+		}
+	}
+	return
+}
+
+// Passed reports whether all not skipped checks are passed.
+func (report *Report) Passed() bool {
+	_, failed, _ := report.Counts()
+	return failed == 0
+}
+
+// Format writes the human-readable report into the io.Writer.
+func (report *Report) Format(w io.Writer) {
+	fmt.Fprintf(w, "eSCL conformance report for %s\n\n", report.URL)
+
+	for _, res := range report.Results {
+		fmt.Fprintf(w, "%s  %-20s %s\n", res.Status, res.Name, res.Help)
+		if res.Details != "" {
+			fmt.Fprintf(w, "      %s\n", res.Details)
+		}
+	}
+
+	passed, failed, skipped := report.Counts()
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n",
+		passed, failed, skipped)
+}
@@ -0,0 +1,165 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL conformance test suite
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Test suite runner
+
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/escl"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// IdleTimeout is the maximum time the test suite waits for the
+// scanner to become idle before the checks that start scan jobs.
+const IdleTimeout = 30 * time.Second
+
+// Options defines the conformance test suite options.
+type Options struct {
+	// Transport used for HTTP requests. If nil,
+	// [transport.NewTransport] will be used.
+	Transport *transport.Transport
+
+	// Scan, if set, enables checks that perform the actual
+	// scanning. They require the document to be placed on
+	// the platen and take some time.
+	//
+	// Other checks may start scan jobs as well, but only with
+	// settings that scanner must reject, and these jobs are
+	// canceled immediately, if scanner accepts them.
+	Scan bool
+}
+
+// suite maintains the state of the running test suite
+type suite struct {
+	url  *url.URL                  // Scanner URL
+	clnt *escl.Client              // eSCL client
+	http *transport.Client         // Raw HTTP client
+	caps *escl.ScannerCapabilities // Capabilities, nil if unknown
+}
+
+// check is the single conformance check
+type check struct {
+	name string                                    // Check name
+	help string                                    // Check description
+	scan bool                                      // Performs scanning
+	run  func(ctx context.Context, s *suite) error // Check function
+}
+
+// skipError is returned by the check function, if check is
+// not applicable.
+type skipError struct {
+	reason string
+}
+
+// Error returns the error string. It implements the error interface.
+func (err skipError) Error() string {
+	return err.reason
+}
+
+// skip returns the skipError.
+func skip(format string, args ...any) error {
+	return skipError{fmt.Sprintf(format, args...)}
+}
+
+// Run runs the conformance test suite against the eSCL scanner
+// at the specified URL (i.e., http://host/eSCL) and returns
+// the [Report].
+//
+// Checks are executed sequentially. Failure of the individual
+// check doesn't stop the suite, but checks that depend on the
+// ScannerCapabilities are skipped, if capabilities cannot be
+// obtained.
+func Run(ctx context.Context, u *url.URL, options Options) *Report {
+	s := &suite{
+		url:  transport.URLClone(u),
+		clnt: escl.NewClient(u, options.Transport),
+		http: transport.NewClient(options.Transport),
+	}
+
+	report := &Report{URL: u.String()}
+
+	for _, chk := range checks {
+		res := Result{Name: chk.name, Help: chk.help}
+
+		var err error
+		if chk.scan && !options.Scan {
+			err = skip("scanning checks are disabled")
+		} else {
+			log.Debug(ctx, "conformance: running %s", chk.name)
+			err = chk.run(ctx, s)
+		}
+
+		var skipErr skipError
+		switch {
+		case err == nil:
+			res.Status = Pass
+		case errors.As(err, &skipErr):
+			res.Status = Skip
+			res.Details = skipErr.reason
+		default:
+			res.Status = Fail
+			res.Details = err.Error()
+		}
+
+		log.Debug(ctx, "conformance: %s: %s", chk.name, res.Status)
+		report.Results = append(report.Results, res)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return report
+}
+
+// needCaps returns the skipError, if ScannerCapabilities
+// are not available.
+func (s *suite) needCaps() error {
+	if s.caps == nil {
+		return skip("ScannerCapabilities not available")
+	}
+	return nil
+}
+
+// waitIdle waits until scanner becomes idle, within the IdleTimeout.
+func (s *suite) waitIdle(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, IdleTimeout)
+	defer cancel()
+
+	_, err := s.clnt.WaitIdle(ctx)
+	if err != nil {
+		return fmt.Errorf("scanner not idle: %w", err)
+	}
+
+	return nil
+}
+
+// inputCaps returns InputSourceCaps for the input source,
+// or nil, if input source is not supported.
+func (s *suite) inputCaps(input escl.InputSource) *escl.InputSourceCaps {
+	caps := s.caps
+	switch {
+	case input == escl.InputPlaten && caps.Platen != nil:
+		return caps.Platen.PlatenInputCaps
+	case input == escl.InputCamera && caps.Camera != nil:
+		return caps.Camera.CameraInputCaps
+	case input == escl.InputFeeder && caps.ADF != nil:
+		if caps.ADF.ADFSimplexInputCaps != nil {
+			return caps.ADF.ADFSimplexInputCaps
+		}
+		return caps.ADF.ADFDuplexInputCaps
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@
 package escl
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
@@ -59,6 +60,38 @@ func decodeNonNegativeInt(root xmldoc.Element) (v int, err error) {
 	return int(v64), nil
 }
 
+// decodeFloat decodes floating-point number from the XML tree.
+func decodeFloat(root xmldoc.Element) (v float64, err error) {
+	v, err = strconv.ParseFloat(root.Text, 64)
+
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		err = fmt.Errorf("float out of range: %s", root.Text)
+	case err != nil, math.IsInf(v, 0), math.IsNaN(v):
+		err = fmt.Errorf("invalid float: %q", root.Text)
+	}
+
+	if err != nil {
+		err = xmldoc.XMLErrWrap(root, err)
+		return 0, err
+	}
+
+	return v, nil
+}
+
+// decodeNonNegativeFloat decodes non-negative floating-point number
+// from the XML tree.
+func decodeNonNegativeFloat(root xmldoc.Element) (v float64, err error) {
+	v, err = decodeFloat(root)
+	if err == nil && v < 0 {
+		err = fmt.Errorf("float out of range: %s", root.Text)
+		err = xmldoc.XMLErrWrap(root, err)
+		return 0, err
+	}
+
+	return
+}
+
 // decodeBool decodes boolean from the XML tree.
 func decodeBool(root xmldoc.Element) (v bool, err error) {
 	switch root.Text {
@@ -0,0 +1,116 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Range of the fractional value.
+
+package escl
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// floatRangeEpsilon is the relative tolerance, used when checking
+// if value is a multiple of the FloatRange.Step, to compensate
+// the rounding errors of the floating-point arithmetic.
+const floatRangeEpsilon = 1e-9
+
+// FloatRange is like [Range], but for parameters, where fractional
+// values are allowed (i.e., gamma, which is commonly expressed as
+// 1.8 or 2.2).
+//
+// In XML, it uses the same elements as [Range]. Integer values are
+// formatted without the fractional part, so FloatRange with integer
+// values is encoded exactly as the equivalent Range.
+type FloatRange struct {
+	Min    float64               // Minimal supported value
+	Max    float64               // Maximal supported value
+	Normal float64               // Normal value
+	Step   optional.Val[float64] // Step between the subsequent values
+}
+
+// decodeFloatRange decodes [FloatRange] from the XML tree
+func decodeFloatRange(root xmldoc.Element) (r FloatRange, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	// Lookup relevant XML elements
+	min := xmldoc.Lookup{Name: NsScan + ":Min", Required: true}
+	max := xmldoc.Lookup{Name: NsScan + ":Max", Required: true}
+	normal := xmldoc.Lookup{Name: NsScan + ":Normal", Required: true}
+	step := xmldoc.Lookup{Name: NsScan + ":Step"}
+
+	missed := root.Lookup(&min, &max, &normal, &step)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	// Decode elements
+	r.Min, err = decodeFloat(min.Elem)
+	if err == nil {
+		r.Max, err = decodeFloat(max.Elem)
+	}
+	if err == nil {
+		r.Normal, err = decodeFloat(normal.Elem)
+	}
+	if err == nil && step.Found {
+		var tmp float64
+		tmp, err = decodeNonNegativeFloat(step.Elem)
+		r.Step = optional.New(tmp)
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [FloatRange].
+func (r FloatRange) toXML(name string) xmldoc.Element {
+	elm := xmldoc.Element{
+		Name: name,
+		Children: []xmldoc.Element{
+			{Name: NsScan + ":" + "Min", Text: formatFloat(r.Min)},
+			{Name: NsScan + ":" + "Max", Text: formatFloat(r.Max)},
+			{Name: NsScan + ":" + "Normal", Text: formatFloat(r.Normal)},
+		},
+	}
+
+	if r.Step != nil {
+		step := xmldoc.Element{
+			Name: NsScan + ":" + "Step",
+			Text: formatFloat(*r.Step),
+		}
+		elm.Children = append(elm.Children, step)
+	}
+
+	return elm
+}
+
+// Within reports if value is within the [FloatRange].
+//
+// If Step is set, value must be a multiple of Step, counting
+// from Min, within the small tolerance, that compensates the
+// floating-point rounding errors.
+func (r FloatRange) Within(v float64) bool {
+	if v < r.Min || v > r.Max || math.IsNaN(v) {
+		return false
+	}
+
+	step := optional.Get(r.Step)
+	if step <= 0 {
+		return true
+	}
+
+	n := (v - r.Min) / step
+	return math.Abs(n-math.Round(n)) <= floatRangeEpsilon*max(1, n)
+}
+
+// formatFloat formats the floating-point value for XML.
+// Integer values are formatted without the fractional part.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,174 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Range of the fractional value test.
+
+package escl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestFloatRange tests FloatRange
+func TestFloatRange(t *testing.T) {
+	type testData struct {
+		rng FloatRange
+		xml xmldoc.Element
+	}
+
+	tests := []testData{
+		{
+			rng: FloatRange{
+				Min:    1.0,
+				Max:    2.6,
+				Normal: 2.2,
+				Step:   optional.New(0.1),
+			},
+			xml: xmldoc.Element{
+				Name: NsScan + ":Range",
+				Children: []xmldoc.Element{
+					{Name: NsScan + ":Min", Text: "1"},
+					{Name: NsScan + ":Max", Text: "2.6"},
+					{Name: NsScan + ":Normal", Text: "2.2"},
+					{Name: NsScan + ":Step", Text: "0.1"},
+				},
+			},
+		},
+
+		{
+			rng: FloatRange{
+				Min:    -0.5,
+				Max:    40,
+				Normal: 20,
+			},
+			xml: xmldoc.Element{
+				Name: NsScan + ":Range",
+				Children: []xmldoc.Element{
+					{Name: NsScan + ":Min", Text: "-0.5"},
+					{Name: NsScan + ":Max", Text: "40"},
+					{Name: NsScan + ":Normal", Text: "20"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		xml := test.rng.toXML(NsScan + ":Range")
+		if !reflect.DeepEqual(xml, test.xml) {
+			t.Errorf("ToXML:\nexpected: %s\npresent: %s\n",
+				test.xml.EncodeString(NsMap),
+				xml.EncodeString(NsMap))
+		}
+
+		rng, err := decodeFloatRange(xml)
+		if err != nil {
+			t.Errorf("decodeFloatRange: %s", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(rng, test.rng) {
+			t.Errorf("decodeFloatRange:\n"+
+				"expected: %#v\npresent:  %#v\n",
+				test.rng, rng)
+		}
+	}
+}
+
+// TestFloatRangeWithin tests FloatRange.Within
+func TestFloatRangeWithin(t *testing.T) {
+	type testData struct {
+		rng      FloatRange
+		v        float64
+		expected bool
+	}
+
+	stepped := FloatRange{Min: 1.0, Max: 3.0, Step: optional.New(0.1)}
+	plain := FloatRange{Min: 1.0, Max: 3.0}
+
+	tests := []testData{
+		{plain, 1.0, true},
+		{plain, 2.25, true},
+		{plain, 3.0, true},
+		{plain, 0.99, false},
+		{plain, 3.01, false},
+		{stepped, 1.0, true},
+		{stepped, 2.2, true},
+		{stepped, 2.9, true},
+		{stepped, 3.0, true},
+		{stepped, 2.25, false},
+		{stepped, 3.1, false},
+	}
+
+	for _, test := range tests {
+		within := test.rng.Within(test.v)
+		if within != test.expected {
+			t.Errorf("%#v: Within(%g): expected %v, present %v",
+				test.rng, test.v, test.expected, within)
+		}
+	}
+}
+
+// TestFloatRangeErrors tests FloatRange decode errors
+func TestFloatRangeErrors(t *testing.T) {
+	type testData struct {
+		xml  xmldoc.Element
+		estr string
+	}
+
+	tests := []testData{
+		{
+			xml: xmldoc.Element{
+				Name: NsScan + ":Range",
+				Children: []xmldoc.Element{
+					{Name: NsScan + ":Min", Text: "1"},
+					{Name: NsScan + ":Normal", Text: "2.2"},
+				},
+			},
+			estr: `/scan:Range/scan:Max: missed`,
+		},
+		{
+			xml: xmldoc.Element{
+				Name: NsScan + ":Range",
+				Children: []xmldoc.Element{
+					{Name: NsScan + ":Min", Text: "1"},
+					{Name: NsScan + ":Max", Text: "NaN"},
+					{Name: NsScan + ":Normal", Text: "2.2"},
+				},
+			},
+			estr: `/scan:Range/scan:Max: invalid float: "NaN"`,
+		},
+		{
+			xml: xmldoc.Element{
+				Name: NsScan + ":Range",
+				Children: []xmldoc.Element{
+					{Name: NsScan + ":Min", Text: "1"},
+					{Name: NsScan + ":Max", Text: "2.6"},
+					{Name: NsScan + ":Normal", Text: "2.2"},
+					{Name: NsScan + ":Step", Text: "-0.1"},
+				},
+			},
+			estr: `/scan:Range/scan:Step: `,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := decodeFloatRange(test.xml)
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if len(estr) < len(test.estr) ||
+			estr[:len(test.estr)] != test.estr {
+			t.Errorf("%s\nerror expected: %s\nerror present:  %s",
+				test.xml.EncodeString(NsMap), test.estr, estr)
+		}
+	}
+}
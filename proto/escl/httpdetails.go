@@ -8,7 +8,12 @@
 
 package escl
 
-import "net/http"
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // HTTPDetails contains HTTP-level details on the [Client]'s operation.
 type HTTPDetails struct {
@@ -16,3 +21,24 @@ type HTTPDetails struct {
 	StatusCode int         // HTTP status code
 	Header     http.Header // HTTP response header
 }
+
+// RetryAfter returns the delay before the next attempt, as suggested
+// by the Retry-After response header, and reports if header is present
+// and valid.
+//
+// Both delay-seconds and HTTP-date forms are supported. If the
+// suggested time is already passed, zero delay is returned.
+func (details *HTTPDetails) RetryAfter() (time.Duration, bool) {
+	var delay time.Duration
+
+	s := strings.TrimSpace(details.Header.Get("Retry-After"))
+	if secs, err := strconv.ParseUint(s, 10, 32); err == nil {
+		delay = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(s); err == nil {
+		delay = time.Until(t)
+	} else {
+		return 0, false
+	}
+
+	return max(delay, 0), true
+}
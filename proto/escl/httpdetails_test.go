@@ -0,0 +1,56 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP-level detais test
+
+package escl
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestHTTPDetailsRetryAfter tests HTTPDetails.RetryAfter
+func TestHTTPDetailsRetryAfter(t *testing.T) {
+	type testData struct {
+		header string        // Retry-After header value
+		delay  time.Duration // Expected delay
+		ok     bool          // Expected status
+	}
+
+	tests := []testData{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-1", 0, false},
+		{"garbage", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+	}
+
+	for _, test := range tests {
+		details := &HTTPDetails{Header: http.Header{}}
+		if test.header != "" {
+			details.Header.Set("Retry-After", test.header)
+		}
+
+		delay, ok := details.RetryAfter()
+		if delay != test.delay || ok != test.ok {
+			t.Errorf("%q: expected %s/%v, present %s/%v",
+				test.header, test.delay, test.ok, delay, ok)
+		}
+	}
+
+	// HTTP-date in the future
+	details := &HTTPDetails{Header: http.Header{}}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	details.Header.Set("Retry-After", date)
+
+	delay, ok := details.RetryAfter()
+	if !ok || delay <= 59*time.Minute || delay > time.Hour {
+		t.Errorf("%q: expected ~1h, present %s/%v", date, delay, ok)
+	}
+}
@@ -0,0 +1,306 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Validation of images, returned by scanner
+
+package escl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// imageCheckTolerance is the relative tolerance of the image
+// dimensions check. Devices commonly round image size to the
+// multiple of some block size, so small differences are allowed.
+const imageCheckTolerance = 0.02
+
+// ImageInfo contains image parameters, obtained by the [SniffImage]
+// from the image headers.
+//
+// Zero value of any field means that parameter cannot be determined.
+type ImageInfo struct {
+	Format      string // Image format (MIME type)
+	Width       int    // Image width, in pixels
+	Height      int    // Image height, in pixels
+	XResolution int    // Horizontal resolution, DPI
+	YResolution int    // Vertical resolution, DPI
+}
+
+// ImageMismatch describes the single mismatch between the
+// image, returned by scanner, and parameters of the scan request.
+type ImageMismatch struct {
+	Param    string // Parameter name (i.e., "Width")
+	Expected string // Expected value
+	Present  string // Actual value
+}
+
+// String returns the string representation of the [ImageMismatch],
+// for logging and error messages.
+func (m ImageMismatch) String() string {
+	return fmt.Sprintf("%s: expected %s, present %s",
+		m.Param, m.Expected, m.Present)
+}
+
+// SniffImage obtains [ImageInfo] from the image headers.
+//
+// JPEG, PNG and PDF formats are recognized. For JPEG and PNG, image
+// dimensions and, if present in the image, resolution are returned.
+// For other formats only the Format is returned, if known, and
+// Format is empty, if image format is not recognized at all.
+//
+// Error is returned, if image format is recognized, but image
+// headers are truncated or corrupted.
+func SniffImage(data []byte) (ImageInfo, error) {
+	info := ImageInfo{Format: abstract.DocumentFormatDetect(data)}
+
+	var err error
+	switch info.Format {
+	case abstract.DocumentFormatJPEG:
+		err = sniffJPEG(data, &info)
+	case abstract.DocumentFormatPNG:
+		err = sniffPNG(data, &info)
+	}
+
+	return info, err
+}
+
+// sniffJPEG obtains JPEG image parameters.
+//
+// Image size comes from the SOFn (start of frame) marker, resolution,
+// if available, from the JFIF APP0 marker.
+func sniffJPEG(data []byte, info *ImageInfo) error {
+	off := 2 // Skip SOI
+	for off+4 <= len(data) {
+		if data[off] != 0xff {
+			return errors.New("JPEG: invalid marker")
+		}
+
+		marker := data[off+1]
+		switch {
+		case marker == 0xff:
+			// Fill byte
+			off++
+			continue
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd8):
+			// Markers without payload (TEM, RSTn, SOI)
+			off += 2
+			continue
+		case marker == 0xd9 || marker == 0xda:
+			// EOI or SOS: no SOFn seen so far
+			return errors.New("JPEG: missed SOF marker")
+		}
+
+		end := off + 2 + int(binary.BigEndian.Uint16(data[off+2:]))
+		if end > len(data) || end < off+4 {
+			break
+		}
+
+		seg := data[off+4 : end]
+		switch {
+		case marker == 0xe0:
+			sniffJFIF(seg, info)
+
+		case marker >= 0xc0 && marker <= 0xcf &&
+			marker != 0xc4 && marker != 0xc8 && marker != 0xcc:
+			// SOFn: precision(1), height(2), width(2)
+			if len(seg) < 5 {
+				return errors.New("JPEG: SOF marker truncated")
+			}
+			info.Height = int(binary.BigEndian.Uint16(seg[1:]))
+			info.Width = int(binary.BigEndian.Uint16(seg[3:]))
+			return nil
+		}
+
+		off = end
+	}
+
+	return errors.New("JPEG: truncated image header")
+}
+
+// sniffJFIF obtains image resolution from the JFIF APP0 segment.
+// Segments of other types (i.e., JFXX) are silently ignored.
+func sniffJFIF(seg []byte, info *ImageInfo) {
+	if len(seg) < 12 || !bytes.HasPrefix(seg, []byte("JFIF\x00")) {
+		return
+	}
+
+	xdens := float64(binary.BigEndian.Uint16(seg[8:]))
+	ydens := float64(binary.BigEndian.Uint16(seg[10:]))
+
+	switch seg[7] {
+	case 1: // Dots per inch
+		info.XResolution = int(xdens)
+		info.YResolution = int(ydens)
+	case 2: // Dots per cm
+		info.XResolution = int(math.Round(xdens * 2.54))
+		info.YResolution = int(math.Round(ydens * 2.54))
+	}
+}
+
+// sniffPNG obtains PNG image parameters.
+//
+// Image size comes from the IHDR chunk, resolution, if available,
+// from the pHYs chunk.
+func sniffPNG(data []byte, info *ImageInfo) error {
+	off := 8 // Skip signature
+	for off+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[off:]))
+		typ := string(data[off+4 : off+8])
+		end := off + 8 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+
+		chunk := data[off+8 : end]
+		switch typ {
+		case "IHDR":
+			if len(chunk) < 8 {
+				return errors.New("PNG: IHDR chunk truncated")
+			}
+			info.Width = int(binary.BigEndian.Uint32(chunk))
+			info.Height = int(binary.BigEndian.Uint32(chunk[4:]))
+
+		case "pHYs":
+			// Pixels per unit X(4), Y(4), unit(1), 1 means meter
+			if len(chunk) >= 9 && chunk[8] == 1 {
+				x := float64(binary.BigEndian.Uint32(chunk))
+				y := float64(binary.BigEndian.Uint32(chunk[4:]))
+				info.XResolution = int(math.Round(x * 0.0254))
+				info.YResolution = int(math.Round(y * 0.0254))
+			}
+
+		case "IDAT", "IEND":
+			// pHYs must precede the image data
+			if info.Width == 0 {
+				return errors.New("PNG: missed IHDR chunk")
+			}
+			return nil
+		}
+
+		off = end + 4 // Skip CRC
+	}
+
+	if info.Width == 0 {
+		return errors.New("PNG: truncated image header")
+	}
+
+	return nil
+}
+
+// CheckImage checks the image, returned by the NextDocument request,
+// against the [ScanSettings] of the scan request and the Content-Type
+// of the response. It returns the list of found mismatches, if any.
+//
+// The following parameters are checked, if they are known for the
+// particular image:
+//   - the actual image format against the Content-Type
+//   - the actual image format against the requested DocumentFormatExt
+//     or DocumentFormat
+//   - image resolution, if image specifies it, against the
+//     requested XResolution and YResolution
+//   - image dimensions, in pixels, against the requested
+//     ScanRegions and resolution
+//
+// The image height is not checked for the ADF scans, as many
+// devices detect the actual paper length when scanning from ADF.
+//
+// Error is returned, if image headers cannot be parsed.
+func CheckImage(data []byte, contentType string,
+	ss ScanSettings) ([]ImageMismatch, error) {
+
+	info, err := SniffImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []ImageMismatch
+	mismatch := func(param, expected, present string) {
+		mismatches = append(mismatches, ImageMismatch{
+			Param:    param,
+			Expected: expected,
+			Present:  present,
+		})
+	}
+
+	// Check image format
+	format := info.Format
+	if format == "" {
+		format = "unknown"
+	}
+
+	ct, _, _ := mime.ParseMediaType(contentType)
+	if ct != "" && !strings.EqualFold(ct, format) {
+		mismatch("Content-Type", ct, format)
+	}
+
+	requested := optional.Get(ss.DocumentFormatExt)
+	if requested == "" {
+		requested = optional.Get(ss.DocumentFormat)
+	}
+
+	if requested != "" && !strings.EqualFold(requested, format) {
+		mismatch("DocumentFormat", requested, format)
+	}
+
+	// Check image resolution
+	xres := optional.Get(ss.XResolution)
+	yres := optional.Get(ss.YResolution)
+
+	if xres != 0 && info.XResolution != 0 && xres != info.XResolution {
+		mismatch("XResolution",
+			strconv.Itoa(xres), strconv.Itoa(info.XResolution))
+	}
+
+	if yres != 0 && info.YResolution != 0 && yres != info.YResolution {
+		mismatch("YResolution",
+			strconv.Itoa(yres), strconv.Itoa(info.YResolution))
+	}
+
+	// Check image dimensions. Only the single region in
+	// the known units is supported.
+	if len(ss.ScanRegions) != 1 ||
+		ss.ScanRegions[0].ContentRegionUnits != ThreeHundredthsOfInches {
+		return mismatches, nil
+	}
+
+	reg := ss.ScanRegions[0]
+
+	if xres != 0 && info.Width != 0 {
+		expected := reg.Width * xres / 300
+		if !imageCheckDimension(expected, info.Width) {
+			mismatch("Width",
+				strconv.Itoa(expected), strconv.Itoa(info.Width))
+		}
+	}
+
+	feeder := optional.Get(ss.InputSource) == InputFeeder
+	if yres != 0 && info.Height != 0 && !feeder {
+		expected := reg.Height * yres / 300
+		if !imageCheckDimension(expected, info.Height) {
+			mismatch("Height",
+				strconv.Itoa(expected), strconv.Itoa(info.Height))
+		}
+	}
+
+	return mismatches, nil
+}
+
+// imageCheckDimension reports if actual image dimension, in pixels,
+// matches the expected, within the imageCheckTolerance.
+func imageCheckDimension(expected, present int) bool {
+	diff := math.Abs(float64(expected - present))
+	return diff <= math.Max(1, float64(expected)*imageCheckTolerance)
+}
@@ -0,0 +1,283 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Validation of images, returned by scanner, test
+
+package escl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// TestSniffImage tests SniffImage
+func TestSniffImage(t *testing.T) {
+	type testData struct {
+		name string
+		data []byte
+		info ImageInfo
+		err  string
+	}
+
+	tests := []testData{
+		{
+			name: "JPEG100x75",
+			data: testutils.Images.JPEG100x75,
+			info: ImageInfo{
+				Format: abstract.DocumentFormatJPEG,
+				Width:  100,
+				Height: 75,
+			},
+		},
+		{
+			name: "JPEG100x75, 200 DPI",
+			data: imageCheckJFIFDensity(testutils.Images.JPEG100x75,
+				1, 200, 200),
+			info: ImageInfo{
+				Format:      abstract.DocumentFormatJPEG,
+				Width:       100,
+				Height:      75,
+				XResolution: 200,
+				YResolution: 200,
+			},
+		},
+		{
+			name: "JPEG100x75, 118 dots per cm",
+			data: imageCheckJFIFDensity(testutils.Images.JPEG100x75,
+				2, 118, 118),
+			info: ImageInfo{
+				Format:      abstract.DocumentFormatJPEG,
+				Width:       100,
+				Height:      75,
+				XResolution: 300,
+				YResolution: 300,
+			},
+		},
+		{
+			name: "PNG100x75rgb8",
+			data: testutils.Images.PNG100x75rgb8,
+			info: ImageInfo{
+				Format: abstract.DocumentFormatPNG,
+				Width:  100,
+				Height: 75,
+			},
+		},
+		{
+			name: "PNG100x75gray8, 600 DPI",
+			data: imageCheckPNGPhys(testutils.Images.PNG100x75gray8,
+				23622, 23622),
+			info: ImageInfo{
+				Format:      abstract.DocumentFormatPNG,
+				Width:       100,
+				Height:      75,
+				XResolution: 600,
+				YResolution: 600,
+			},
+		},
+		{
+			name: "PDF100x75",
+			data: testutils.Images.PDF100x75,
+			info: ImageInfo{Format: abstract.DocumentFormatPDF},
+		},
+		{
+			name: "garbage",
+			data: []byte("hello, world"),
+			info: ImageInfo{},
+		},
+		{
+			name: "JPEG truncated",
+			data: testutils.Images.JPEG100x75[:30],
+			info: ImageInfo{Format: abstract.DocumentFormatJPEG},
+			err:  "JPEG: truncated image header",
+		},
+		{
+			name: "PNG truncated",
+			data: testutils.Images.PNG100x75rgb8[:20],
+			info: ImageInfo{Format: abstract.DocumentFormatPNG},
+			err:  "PNG: truncated image header",
+		},
+	}
+
+	for _, test := range tests {
+		info, err := SniffImage(test.data)
+
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if estr != test.err {
+			t.Errorf("%s: error mismatch:\n"+
+				"expected: %q\npresent:  %q",
+				test.name, test.err, estr)
+			continue
+		}
+
+		if !reflect.DeepEqual(info, test.info) {
+			t.Errorf("%s: ImageInfo mismatch:\n"+
+				"expected: %#v\npresent:  %#v",
+				test.name, test.info, info)
+		}
+	}
+}
+
+// TestCheckImage tests CheckImage
+func TestCheckImage(t *testing.T) {
+	type testData struct {
+		name       string
+		data       []byte
+		ct         string
+		ss         ScanSettings
+		mismatches []ImageMismatch
+	}
+
+	// 100x75 pixels at 300 DPI
+	region := []ScanRegion{{
+		Width:              100,
+		Height:             75,
+		ContentRegionUnits: ThreeHundredthsOfInches,
+	}}
+
+	png300 := imageCheckPNGPhys(testutils.Images.PNG100x75rgb8,
+		11811, 11811)
+
+	tests := []testData{
+		{
+			name: "all match",
+			data: png300,
+			ct:   "image/png",
+			ss: ScanSettings{
+				DocumentFormatExt: optional.New("image/png"),
+				XResolution:       optional.New(300),
+				YResolution:       optional.New(300),
+				ScanRegions:       region,
+			},
+		},
+		{
+			name: "no parameters requested",
+			data: testutils.Images.PDF100x75,
+			ss:   ScanSettings{},
+		},
+		{
+			name: "wrong format",
+			data: testutils.Images.JPEG100x75,
+			ct:   "application/pdf; charset=binary",
+			ss: ScanSettings{
+				DocumentFormat: optional.New("image/PNG"),
+			},
+			mismatches: []ImageMismatch{
+				{"Content-Type", "application/pdf", "image/jpeg"},
+				{"DocumentFormat", "image/PNG", "image/jpeg"},
+			},
+		},
+		{
+			name: "unknown format",
+			data: []byte("hello, world"),
+			ct:   "image/jpeg",
+			mismatches: []ImageMismatch{
+				{"Content-Type", "image/jpeg", "unknown"},
+			},
+		},
+		{
+			name: "wrong resolution",
+			data: png300,
+			ss: ScanSettings{
+				XResolution: optional.New(600),
+				YResolution: optional.New(300),
+				ScanRegions: region,
+			},
+			mismatches: []ImageMismatch{
+				{"XResolution", "600", "300"},
+				{"Width", "200", "100"},
+			},
+		},
+		{
+			name: "wrong size",
+			data: testutils.Images.JPEG100x75,
+			ss: ScanSettings{
+				XResolution: optional.New(150),
+				YResolution: optional.New(150),
+				ScanRegions: region,
+			},
+			mismatches: []ImageMismatch{
+				{"Width", "50", "100"},
+				{"Height", "37", "75"},
+			},
+		},
+		{
+			name: "wrong height, ADF",
+			data: testutils.Images.JPEG100x75,
+			ss: ScanSettings{
+				InputSource: optional.New(InputFeeder),
+				XResolution: optional.New(300),
+				YResolution: optional.New(150),
+				ScanRegions: region,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		mismatches, err := CheckImage(test.data, test.ct, test.ss)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(mismatches, test.mismatches) {
+			t.Errorf("%s: mismatches:\n"+
+				"expected: %v\npresent:  %v",
+				test.name, test.mismatches, mismatches)
+		}
+	}
+
+	// Corrupted image must return an error
+	_, err := CheckImage(testutils.Images.PNG100x75rgb8[:20],
+		"image/png", ScanSettings{})
+	if err == nil {
+		t.Errorf("corrupted image: error not detected")
+	}
+}
+
+// imageCheckJFIFDensity returns copy of the JPEG image with
+// density in the JFIF APP0 segment modified.
+func imageCheckJFIFDensity(jpeg []byte, units, x, y int) []byte {
+	data := bytes.Clone(jpeg)
+
+	// SOI(2) + APP0 marker(2) + length(2) + "JFIF\0"(5) + version(2)
+	data[13] = byte(units)
+	binary.BigEndian.PutUint16(data[14:], uint16(x))
+	binary.BigEndian.PutUint16(data[16:], uint16(y))
+
+	return data
+}
+
+// imageCheckPNGPhys returns copy of the PNG image with the pHYs
+// chunk, in pixels per meter, inserted after the IHDR chunk.
+func imageCheckPNGPhys(png []byte, x, y int) []byte {
+	// Signature(8) + IHDR length(4) + type(4) + data(13) + CRC(4)
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+
+	chunk := make([]byte, 4+4+9+4)
+	binary.BigEndian.PutUint32(chunk, 9)
+	copy(chunk[4:], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:], uint32(x))
+	binary.BigEndian.PutUint32(chunk[12:], uint32(y))
+	chunk[16] = 1
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+
+	data := append([]byte{}, png[:ihdrEnd]...)
+	data = append(data, chunk...)
+	data = append(data, png[ihdrEnd:]...)
+
+	return data
+}
@@ -0,0 +1,379 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// End-to-end integration tests: Client against AbstractServer
+
+package escl
+
+import (
+	"bytes"
+	"context"
+	"image"
+	_ "image/png" // For image.Decode
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// testHarness wires the abstract.Scanner into the AbstractServer
+// over the in-memory transport and connects the Client to it,
+// so the full eSCL job lifecycle can be tested in-process.
+type testHarness struct {
+	t      *testing.T           // Test context
+	ctx    context.Context      // Context for requests
+	caps   *ScannerCapabilities // Scanner capabilities, as seen by Client
+	srv    *AbstractServer      // The eSCL server
+	server *transport.Server    // The HTTP server
+	clnt   *Client              // The eSCL client
+}
+
+// testHarnessCaps returns ScannerCapabilities, used by the
+// integration tests.
+func testHarnessCaps(t *testing.T) *ScannerCapabilities {
+	xml, err := xmldoc.Decode(NsMap, bytes.NewReader(testutils.
+		Kyocera.ECOSYS.M2040dn.ESCL.ScannerCapabilities))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	caps, err := DecodeScannerCapabilities(xml)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	return caps
+}
+
+// newTestHarness creates a new testHarness on a top of the
+// abstract.Scanner.
+//
+// The harness is automatically closed when test finishes.
+func newTestHarness(t *testing.T, scanner abstract.Scanner) *testHarness {
+	tr, loopback := transport.NewLoopback()
+	base := transport.MustParseURL("http://localhost/eSCL")
+
+	options := AbstractServerOptions{
+		Scanner:  scanner,
+		BasePath: base.Path,
+	}
+
+	h := &testHarness{
+		t:   t,
+		ctx: context.Background(),
+		srv: NewAbstractServer(context.Background(), options),
+	}
+
+	h.server = transport.NewServer(nil, h.srv)
+	go h.server.Serve(loopback)
+	t.Cleanup(func() { h.server.Close() })
+
+	h.clnt = NewClient(base, tr)
+
+	caps, _, err := h.clnt.GetScannerCapabilities(h.ctx)
+	if err != nil {
+		t.Fatalf("GetScannerCapabilities: %s", err)
+	}
+
+	h.caps = caps
+
+	return h
+}
+
+// scan starts the scan job and returns its JobUri.
+func (h *testHarness) scan(ss ScanSettings) string {
+	h.t.Helper()
+
+	ss.Version = h.caps.Version
+	joburl, _, err := h.clnt.Scan(h.ctx, ss)
+	if err != nil {
+		h.t.Fatalf("Scan: %s", err)
+	}
+
+	return joburl
+}
+
+// pages fetches all pages of the job, until io.EOF or error.
+// Content-Digest of each page is verified by the Client.
+func (h *testHarness) pages(joburl string) ([][]byte, error) {
+	var pages [][]byte
+	for {
+		doc, _, err := h.clnt.NextDocument(h.ctx, joburl)
+		if err == io.EOF {
+			return pages, nil
+		} else if err != nil {
+			return pages, err
+		}
+
+		data, err := io.ReadAll(doc)
+		doc.Close()
+
+		if err != nil {
+			return pages, err
+		}
+
+		pages = append(pages, data)
+	}
+}
+
+// status returns the ScannerStatus
+func (h *testHarness) status() *ScannerStatus {
+	h.t.Helper()
+
+	status, _, err := h.clnt.GetScannerStatus(h.ctx)
+	if err != nil {
+		h.t.Fatalf("GetScannerStatus: %s", err)
+	}
+
+	return status
+}
+
+// checkJob checks the job state, as reported by the ScannerStatus,
+// and number of images, reported by the job history.
+func (h *testHarness) checkJob(joburl string, state JobState, images int) {
+	h.t.Helper()
+
+	status := h.status()
+	if status.State != ScannerIdle {
+		h.t.Errorf("%s: scanner state: expected %s, present %s",
+			joburl, ScannerIdle, status.State)
+	}
+
+	var info *JobInfo
+	for i := range status.Jobs {
+		if status.Jobs[i].JobURI == joburl {
+			info = &status.Jobs[i]
+		}
+	}
+
+	switch {
+	case info == nil:
+		h.t.Errorf("%s: job missed in ScannerStatus", joburl)
+	case info.JobState != state:
+		h.t.Errorf("%s: job state: expected %s, present %s",
+			joburl, state, info.JobState)
+	}
+
+	for _, ent := range h.srv.JobHistory() {
+		if ent.JobURI == joburl && ent.Stats.Pages != images {
+			h.t.Errorf("%s: images: expected %d, present %d",
+				joburl, images, ent.Stats.Pages)
+		}
+	}
+}
+
+// checkImage checks that image is decodable and has expected size.
+func (h *testHarness) checkImage(data []byte, wid, hei int) {
+	h.t.Helper()
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		h.t.Errorf("image.Decode: %s", err)
+		return
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() != wid || bounds.Dy() != hei {
+		h.t.Errorf("image size: expected %dx%d, present %dx%d",
+			wid, hei, bounds.Dx(), bounds.Dy())
+	}
+}
+
+// TestIntegrationPlaten tests the platen scan job lifecycle
+func TestIntegrationPlaten(t *testing.T) {
+	// The 100x75 image at 75 DPI is 400x300 at 300 DPI. Note,
+	// the scan region must be not less than MinWidth/MinHeight.
+	caps := testHarnessCaps(t)
+	h := newTestHarness(t, &abstract.VirtualScanner{
+		ScanCaps:    caps.ToAbstract(),
+		Resolution:  abstract.Resolution{XResolution: 75, YResolution: 75},
+		PlatenImage: testutils.Images.PNG100x75rgb8,
+	})
+
+	// Full image
+	joburl := h.scan(ScanSettings{
+		InputSource: optional.New(InputPlaten),
+		XResolution: optional.New(300),
+		YResolution: optional.New(300),
+	})
+
+	pages, err := h.pages(joburl)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if len(pages) != 1 {
+		t.Fatalf("expected 1 page, present %d", len(pages))
+	}
+
+	h.checkImage(pages[0], 400, 300)
+	h.checkJob(joburl, JobCompleted, 1)
+
+	// Scan region. Note, Kyocera doesn't report MaxXOffset and
+	// MaxYOffset, so region is anchored at the top-left corner.
+	joburl = h.scan(ScanSettings{
+		InputSource: optional.New(InputPlaten),
+		XResolution: optional.New(300),
+		YResolution: optional.New(300),
+		ScanRegions: []ScanRegion{
+			{
+				Width:              150,
+				Height:             120,
+				ContentRegionUnits: ThreeHundredthsOfInches,
+			},
+		},
+	})
+
+	pages, err = h.pages(joburl)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if len(pages) != 1 {
+		t.Fatalf("expected 1 page, present %d", len(pages))
+	}
+
+	h.checkImage(pages[0], 150, 120)
+	h.checkJob(joburl, JobCompleted, 1)
+}
+
+// TestIntegrationADF tests the ADF scan job lifecycle
+func TestIntegrationADF(t *testing.T) {
+	caps := testHarnessCaps(t)
+	images := [][]byte{
+		testutils.Images.PNG100x75rgb8,
+		testutils.Images.PNG100x75gray8,
+		testutils.Images.PNG100x75rgb16,
+	}
+
+	h := newTestHarness(t, &abstract.VirtualScanner{
+		ScanCaps:   caps.ToAbstract(),
+		Resolution: abstract.Resolution{XResolution: 300, YResolution: 300},
+		ADFImages:  images,
+	})
+
+	joburl := h.scan(ScanSettings{
+		InputSource: optional.New(InputFeeder),
+		XResolution: optional.New(300),
+		YResolution: optional.New(300),
+	})
+
+	pages, err := h.pages(joburl)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if len(pages) != len(images) {
+		t.Fatalf("expected %d pages, present %d",
+			len(images), len(pages))
+	}
+
+	for _, page := range pages {
+		h.checkImage(page, 100, 75)
+	}
+
+	h.checkJob(joburl, JobCompleted, len(images))
+
+	if adf := h.status().ADFState; adf == nil || *adf != ScannerAdfLoaded {
+		t.Errorf("ADF state: expected %s, present %v",
+			ScannerAdfLoaded, adf)
+	}
+}
+
+// TestIntegrationErrors tests the error paths
+func TestIntegrationErrors(t *testing.T) {
+	caps := testHarnessCaps(t)
+	scanner := &abstract.VirtualScanner{
+		ScanCaps:    caps.ToAbstract(),
+		Resolution:  abstract.Resolution{XResolution: 300, YResolution: 300},
+		PlatenImage: testutils.Images.PNG100x75rgb8,
+		ADFImages: [][]byte{
+			testutils.Images.PNG100x75rgb8,
+			testutils.Images.PNG100x75rgb8,
+		},
+	}
+
+	h := newTestHarness(t, scanner)
+
+	// Unsupported settings are rejected with 409 Conflict
+	_, details, err := h.clnt.Scan(h.ctx, ScanSettings{
+		Version:     h.caps.Version,
+		InputSource: optional.New(InputPlaten),
+		XResolution: optional.New(123),
+		YResolution: optional.New(123),
+	})
+
+	if err == nil || details == nil ||
+		details.StatusCode != http.StatusConflict {
+		t.Errorf("unsupported resolution: 409 expected, present %v",
+			err)
+	}
+
+	// ADF jam in the middle of the job
+	scanner.ADFFault = func(page int) error {
+		if page == 1 {
+			return abstract.ErrADFJam
+		}
+		return nil
+	}
+
+	joburl := h.scan(ScanSettings{InputSource: optional.New(InputFeeder)})
+	pages, err := h.pages(joburl)
+	if err == nil {
+		t.Errorf("ADF jam: error expected")
+	}
+
+	if len(pages) != 1 {
+		t.Errorf("ADF jam: expected 1 page, present %d", len(pages))
+	}
+
+	h.checkJob(joburl, JobAborted, 1)
+
+	if adf := h.status().ADFState; adf == nil || *adf != ScannerAdfJam {
+		t.Errorf("ADF state: expected %s, present %v",
+			ScannerAdfJam, adf)
+	}
+
+	scanner.ADFFault = nil
+
+	// Job canceled by the client
+	joburl = h.scan(ScanSettings{InputSource: optional.New(InputPlaten)})
+	_, err = h.clnt.Cancel(h.ctx, joburl)
+	if err != nil {
+		t.Errorf("Cancel: %s", err)
+	}
+
+	pages, err = h.pages(joburl)
+	if err != nil || len(pages) != 0 {
+		t.Errorf("canceled job: expected no pages, present %d (%v)",
+			len(pages), err)
+	}
+
+	h.checkJob(joburl, JobCanceled, 0)
+
+	// Unknown job
+	_, _, err = h.clnt.NextDocument(h.ctx, "/eSCL/ScanJobs/unknown")
+	if err != io.EOF {
+		t.Errorf("unknown job: io.EOF expected, present %v", err)
+	}
+
+	// Empty ADF
+	scanner.ADFImages = nil
+	_, details, err = h.clnt.Scan(h.ctx, ScanSettings{
+		Version:     h.caps.Version,
+		InputSource: optional.New(InputFeeder),
+	})
+
+	if err == nil || details == nil ||
+		details.StatusCode != http.StatusConflict {
+		t.Errorf("empty ADF: 409 expected, present %v", err)
+	}
+}
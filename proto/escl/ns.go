@@ -8,7 +8,12 @@
 
 package escl
 
-import "github.com/OpenPrinting/go-mfp/util/xmldoc"
+import (
+	"io"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
 
 // Namespace prefixes:
 const (
@@ -21,3 +26,57 @@ var NsMap = xmldoc.Namespace{
 	{Prefix: NsScan, URL: "http://schemas.hp.com/imaging/escl/2011/05/03"},
 	{Prefix: NsPWG, URL: "http://www.pwg.org/schemas/2010/12/sm"},
 }
+
+// nsAliases maps known wrong namespace URLs, used by some devices,
+// into the correct ones.
+//
+// Keys are in the form, returned by the nsKey function.
+var nsAliases = map[string]string{
+	"http://schemas.hp.com/imaging/escl/2011/05":        "http://schemas.hp.com/imaging/escl/2011/05/03",
+	"http://www.hp.com/schemas/imaging/escl/2011/05/03": "http://schemas.hp.com/imaging/escl/2011/05/03",
+	"http://pwg.org/schemas/2010/12/sm":                 "http://www.pwg.org/schemas/2010/12/sm",
+}
+
+// NsNormalize normalizes namespace URL, so slightly wrong URLs,
+// used by some devices, will match the [NsMap].
+//
+// It tolerates the following deviations:
+//   - http vs https scheme and letters case
+//   - trailing slash
+//   - version suffixes (i.e., http://www.pwg.org/schemas/2010/12/sm/1.0)
+//   - known wrong URLs, listed in the alias table
+//
+// URLs that cannot be normalized are returned as is.
+func NsNormalize(u string) string {
+	key := nsKey(u)
+
+	if alias, found := nsAliases[key]; found {
+		return alias
+	}
+
+	for _, ent := range NsMap {
+		entKey := nsKey(ent.URL)
+		if key == entKey || strings.HasPrefix(key, entKey+"/") {
+			return ent.URL
+		}
+	}
+
+	return u
+}
+
+// nsKey returns the namespace URL in the form, suitable for
+// comparison: lower-case, with the http scheme and without
+// trailing slash.
+func nsKey(u string) string {
+	key := strings.ToLower(strings.TrimSpace(u))
+	if strings.HasPrefix(key, "https:") {
+		key = "http:" + key[6:]
+	}
+
+	return strings.TrimRight(key, "/")
+}
+
+// nsDecode decodes XML document, using [NsMap] and [NsNormalize].
+func nsDecode(in io.Reader) (xmldoc.Element, error) {
+	return xmldoc.DecodeNormalized(NsMap, NsNormalize, in)
+}
@@ -0,0 +1,76 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// eSCL namespace test
+
+package escl
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNsNormalize tests NsNormalize
+func TestNsNormalize(t *testing.T) {
+	const (
+		scan = "http://schemas.hp.com/imaging/escl/2011/05/03"
+		pwg  = "http://www.pwg.org/schemas/2010/12/sm"
+	)
+
+	tests := []struct {
+		in, out string
+	}{
+		{scan, scan},
+		{pwg, pwg},
+		{"https://schemas.hp.com/imaging/escl/2011/05/03", scan},
+		{"http://schemas.hp.com/imaging/escl/2011/05/03/", scan},
+		{"HTTP://Schemas.HP.com/imaging/eSCL/2011/05/03", scan},
+		{"http://schemas.hp.com/imaging/escl/2011/05", scan},
+		{"http://www.pwg.org/schemas/2010/12/sm/1.0", pwg},
+		{"https://pwg.org/schemas/2010/12/sm/", pwg},
+		{"http://example.com/unknown", "http://example.com/unknown"},
+		{"http://www.pwg.org/schemas/2010/12/smx",
+			"http://www.pwg.org/schemas/2010/12/smx"},
+	}
+
+	for _, test := range tests {
+		out := NsNormalize(test.in)
+		if out != test.out {
+			t.Errorf("%q:\nexpected: %q\npresent:  %q",
+				test.in, test.out, out)
+		}
+	}
+}
+
+// TestNsDecode tests decoding of documents with non-standard
+// namespace URLs
+func TestNsDecode(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerStatus
+  xmlns:scan="https://schemas.hp.com/imaging/escl/2011/05/03/"
+  xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm/1.0">
+  <pwg:Version>2.0</pwg:Version>
+  <pwg:State>Idle</pwg:State>
+</scan:ScannerStatus>
+`
+
+	xml, err := nsDecode(strings.NewReader(in))
+	if err != nil {
+		t.Errorf("%s", err)
+		return
+	}
+
+	status, err := DecodeScannerStatus(xml)
+	if err != nil {
+		t.Errorf("%s", err)
+		return
+	}
+
+	if status.State != ScannerIdle {
+		t.Errorf("State: expected %s, present %s",
+			ScannerIdle, status.State)
+	}
+}
@@ -0,0 +1,58 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Preview scan settings
+
+package escl
+
+import (
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// PreviewSettings returns [ScanSettings] for the preview scan,
+// derived from the ss.
+//
+// Preview is the rapid low-resolution scan of the entire scan area,
+// typically performed before the full-resolution scan, so the user
+// can choose the scan region and other parameters.
+//
+// The returned ScanSettings differs from the ss as follows:
+//   - resolution is chosen by the [abstract.ScannerRequest.PreviewResolution]
+//     using the ss resolution, if set, as a hint
+//   - ScanRegions are removed
+//   - Intent is set to Preview, if not set and supported by the scanner
+func PreviewSettings(caps *ScannerCapabilities, ss ScanSettings) ScanSettings {
+	abscaps := caps.ToAbstract()
+	absreq := ss.ToAbstract()
+	absreq.Preview = true
+
+	res := absreq.PreviewResolution(abscaps)
+
+	preview := ss
+	preview.XResolution = optional.New(res.XResolution)
+	preview.YResolution = optional.New(res.YResolution)
+	preview.ScanRegions = nil
+
+	var inp *abstract.InputCapabilities
+	switch {
+	case absreq.Input == abstract.InputCamera:
+		inp = abscaps.Camera
+	case absreq.Input != abstract.InputADF:
+		inp = abscaps.Platen
+	case absreq.ADFMode == abstract.ADFModeDuplex:
+		inp = abscaps.ADFDuplex
+	default:
+		inp = abscaps.ADFSimplex
+	}
+
+	if preview.Intent == nil && inp != nil &&
+		inp.Intents.Contains(abstract.IntentPreview) {
+		preview.Intent = optional.New(Preview)
+	}
+
+	return preview
+}
@@ -0,0 +1,102 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Preview scan settings tests
+
+package escl
+
+import (
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// TestPreviewSettings tests PreviewSettings
+func TestPreviewSettings(t *testing.T) {
+	// Scanner without Preview intent
+	caps := fromAbstractScannerCapabilities(DefaultVersion,
+		testAbstractScannerCapabilities)
+
+	// Scanner with Preview intent
+	abscaps := testAbstractScannerCapabilities.Clone()
+	abscaps.Platen = abscaps.Platen.Clone()
+	abscaps.Platen.Intents.Add(abstract.IntentPreview)
+	capsIntent := fromAbstractScannerCapabilities(DefaultVersion, abscaps)
+
+	reg := ScanRegion{
+		XOffset:            0,
+		YOffset:            0,
+		Width:              2551,
+		Height:             3508,
+		ContentRegionUnits: ThreeHundredthsOfInches,
+	}
+
+	type testData struct {
+		comment string               // Comment for the test
+		caps    *ScannerCapabilities // Scanner capabilities
+		ss      ScanSettings         // Input settings
+		out     ScanSettings         // Expected output
+	}
+
+	tests := []testData{
+		{
+			comment: "no Preview intent, resolution hint",
+			caps:    caps,
+			ss: ScanSettings{
+				Version:     DefaultVersion,
+				InputSource: optional.New(InputPlaten),
+				XResolution: optional.New(100),
+				YResolution: optional.New(100),
+				ScanRegions: []ScanRegion{reg},
+			},
+			out: ScanSettings{
+				Version:     DefaultVersion,
+				InputSource: optional.New(InputPlaten),
+				XResolution: optional.New(150),
+				YResolution: optional.New(150),
+			},
+		},
+
+		{
+			comment: "Preview intent",
+			caps:    capsIntent,
+			ss: ScanSettings{
+				Version:   DefaultVersion,
+				ColorMode: optional.New(RGB24),
+			},
+			out: ScanSettings{
+				Version:     DefaultVersion,
+				ColorMode:   optional.New(RGB24),
+				Intent:      optional.New(Preview),
+				XResolution: optional.New(75),
+				YResolution: optional.New(75),
+			},
+		},
+
+		{
+			comment: "explicit Intent",
+			caps:    capsIntent,
+			ss: ScanSettings{
+				Version: DefaultVersion,
+				Intent:  optional.New(Photo),
+			},
+			out: ScanSettings{
+				Version:     DefaultVersion,
+				Intent:      optional.New(Photo),
+				XResolution: optional.New(75),
+				YResolution: optional.New(75),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out := PreviewSettings(test.caps, test.ss)
+		testutils.CheckConvertionTest(t, "PreviewSettings",
+			test.comment, test.out, out)
+	}
+}
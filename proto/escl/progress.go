@@ -0,0 +1,71 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Document download progress
+
+package escl
+
+import (
+	"io"
+)
+
+// Progress reports the progress of the document (page image)
+// download, received via the [Client.NextDocument].
+//
+// See [ClientOptions] for details.
+type Progress struct {
+	JobURL   string // Job URL, as passed to the NextDocument
+	Received int64  // Bytes received so far
+	Total    int64  // Expected total size, -1 if unknown
+	Done     bool   // Document is received completely
+
+	// Estimated, if set, indicates that Total is not known
+	// exactly (the Content-Length is missed), and estimated
+	// from the [ScanImageInfo]. Estimation is based on the
+	// uncompressed image size, so for the compressed formats
+	// it is the upper limit, not the actual size.
+	Estimated bool
+}
+
+// Percent returns the download progress in percents, in
+// range 0...100, or -1, if Total is unknown.
+func (p Progress) Percent() int {
+	switch {
+	case p.Done:
+		return 100
+	case p.Total <= 0:
+		return -1
+	}
+
+	return int(min(p.Received*100/p.Total, 100))
+}
+
+// progressReader wraps the document body and reports
+// the download progress while being read.
+type progressReader struct {
+	io.ReadCloser                // Underlying body
+	progress      Progress       // Current progress
+	callback      func(Progress) // Progress callback
+}
+
+// Read reads the document body.
+func (r *progressReader) Read(buf []byte) (int, error) {
+	n, err := r.ReadCloser.Read(buf)
+
+	switch {
+	case r.progress.Done:
+		// Already reported
+	case err == io.EOF:
+		r.progress.Received += int64(n)
+		r.progress.Done = true
+		r.callback(r.progress)
+	case n > 0:
+		r.progress.Received += int64(n)
+		r.callback(r.progress)
+	}
+
+	return n, err
+}
@@ -0,0 +1,179 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Document download progress test
+
+package escl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// progressTestHandler serves documents for the progress test:
+//   - /eSCL/ScanJobs/1 sends document with the Content-Length
+//   - /eSCL/ScanJobs/2 sends chunked document and ScanImageInfo
+//   - /eSCL/ScanJobs/3 sends chunked document only
+type progressTestHandler struct {
+	data []byte // Document data
+}
+
+// ServeHTTP handles HTTP requests.
+func (h progressTestHandler) ServeHTTP(w http.ResponseWriter,
+	rq *http.Request) {
+
+	sendChunked := func() {
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < len(h.data); i += 100 {
+			w.Write(h.data[i:min(i+100, len(h.data))])
+			w.(http.Flusher).Flush()
+		}
+	}
+
+	switch rq.URL.Path {
+	case "/eSCL/ScanJobs/1/NextDocument":
+		w.Header().Set("Content-Length", strconv.Itoa(len(h.data)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(h.data)
+
+	case "/eSCL/ScanJobs/2/NextDocument",
+		"/eSCL/ScanJobs/3/NextDocument":
+		sendChunked()
+
+	case "/eSCL/ScanJobs/2/ScanImageInfo":
+		info := ScanImageInfo{
+			JobURI:             "/eSCL/ScanJobs/2",
+			ActualWidth:        100,
+			ActualHeight:       75,
+			ActualBytesPerLine: 300,
+		}
+
+		var buf bytes.Buffer
+		info.ToXML().Encode(&buf, NsMap)
+
+		w.Header().Set("Content-Type", HTTPContentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestClientProgress tests download progress reporting
+func TestClientProgress(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, progressTestHandler{data})
+	go server.Serve(loopback)
+	defer server.Close()
+
+	var reports []Progress
+	clnt := NewClientWithOptions(
+		transport.MustParseURL("http://localhost/eSCL"), tr,
+		ClientOptions{
+			OnProgress: func(p Progress) {
+				reports = append(reports, p)
+			},
+		})
+
+	type testData struct {
+		joburl    string // Job URL
+		total     int64  // Expected Progress.Total
+		estimated bool   // Expected Progress.Estimated
+	}
+
+	tests := []testData{
+		{"/eSCL/ScanJobs/1", int64(len(data)), false},
+		{"/eSCL/ScanJobs/2", 300 * 75, true},
+		{"/eSCL/ScanJobs/3", -1, false},
+	}
+
+	for _, test := range tests {
+		reports = nil
+
+		doc, _, err := clnt.NextDocument(context.TODO(), test.joburl)
+		if err != nil {
+			t.Errorf("%s: NextDocument: %s", test.joburl, err)
+			continue
+		}
+
+		if len(reports) != 1 || reports[0].Received != 0 {
+			t.Errorf("%s: initial progress not reported: %#v",
+				test.joburl, reports)
+		}
+
+		received, err := io.ReadAll(doc)
+		doc.Close()
+
+		if err != nil || !bytes.Equal(received, data) {
+			t.Errorf("%s: document data mismatch (%v)",
+				test.joburl, err)
+		}
+
+		if len(reports) < 2 {
+			t.Errorf("%s: progress not reported", test.joburl)
+			continue
+		}
+
+		prev := int64(0)
+		for i, p := range reports {
+			switch {
+			case p.JobURL != test.joburl:
+				t.Errorf("%s: JobURL: %q", test.joburl, p.JobURL)
+			case p.Total != test.total:
+				t.Errorf("%s: Total: expected %d, present %d",
+					test.joburl, test.total, p.Total)
+			case p.Estimated != test.estimated:
+				t.Errorf("%s: Estimated: expected %v, present %v",
+					test.joburl, test.estimated, p.Estimated)
+			case p.Received < prev:
+				t.Errorf("%s: Received decreased", test.joburl)
+			case p.Done != (i == len(reports)-1):
+				t.Errorf("%s: Done reported at %d of %d",
+					test.joburl, i, len(reports))
+			}
+
+			prev = p.Received
+		}
+
+		last := reports[len(reports)-1]
+		if last.Received != int64(len(data)) || last.Percent() != 100 {
+			t.Errorf("%s: final progress: %#v", test.joburl, last)
+		}
+	}
+}
+
+// TestProgressPercent tests Progress.Percent
+func TestProgressPercent(t *testing.T) {
+	type testData struct {
+		p        Progress
+		expected int
+	}
+
+	tests := []testData{
+		{Progress{Received: 50, Total: 200}, 25},
+		{Progress{Received: 50, Total: -1}, -1},
+		{Progress{Received: 50, Total: -1, Done: true}, 100},
+		{Progress{Received: 500, Total: 200, Estimated: true}, 100},
+		{Progress{Received: 0, Total: 0}, -1},
+	}
+
+	for _, test := range tests {
+		percent := test.p.Percent()
+		if percent != test.expected {
+			t.Errorf("%#v: expected %d, present %d",
+				test.p, test.expected, percent)
+		}
+	}
+}
@@ -11,6 +11,7 @@ package escl
 import (
 	"strconv"
 
+	"github.com/OpenPrinting/go-mfp/util/optional"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
@@ -83,3 +84,71 @@ func (info *ScanBufferInfo) ToXML() xmldoc.Element {
 
 	return elm
 }
+
+// EstimateScanBufferInfo estimates the image parameters, the scanner
+// with the specified [ScannerCapabilities] will produce for the
+// [ScanSettings].
+//
+// ScanSettings are expected to be validated against the capabilities
+// (see [ScanSettings.Validate]). Parameters, omitted in the request,
+// are filled with the values the scanner is expected to use by
+// default: the first available input source, the entire scan area,
+// the 300 DPI resolution and the RGB24 color mode. Returned
+// ScanBufferInfo.ScanSettings reflect these choices.
+//
+// The estimation is made for the uncompressed image.
+func EstimateScanBufferInfo(scancaps *ScannerCapabilities,
+	ss ScanSettings) *ScanBufferInfo {
+
+	// Choose the input source
+	input, inpcaps, _ := ss.validateInput(scancaps)
+	if input != UnknownInputSource {
+		ss.InputSource = optional.New(input)
+	}
+
+	// Choose the scan region
+	var reg ScanRegion
+	switch {
+	case len(ss.ScanRegions) != 0:
+		reg = ss.ScanRegions[0].InUnits(ThreeHundredthsOfInches)
+	case inpcaps != nil:
+		reg = ScanRegion{
+			Width:              inpcaps.MaxWidth,
+			Height:             inpcaps.MaxHeight,
+			ContentRegionUnits: ThreeHundredthsOfInches,
+		}
+		ss.ScanRegions = []ScanRegion{reg}
+	}
+
+	// Choose resolution
+	xres, yres := 300, 300
+	switch {
+	case ss.XResolution != nil && ss.YResolution != nil:
+		xres, yres = *ss.XResolution, *ss.YResolution
+	case ss.XResolution != nil:
+		xres, yres = *ss.XResolution, *ss.XResolution
+	case ss.YResolution != nil:
+		xres, yres = *ss.YResolution, *ss.YResolution
+	}
+
+	ss.XResolution = optional.New(xres)
+	ss.YResolution = optional.New(yres)
+
+	// Choose color mode
+	if ss.ColorMode == nil {
+		ss.ColorMode = optional.New(RGB24)
+	}
+
+	// Estimate image parameters
+	units := reg.ContentRegionUnits
+	info := &ScanBufferInfo{
+		ScanSettings: ss,
+		ImageWidth:   units.ToPixels(reg.Width, xres),
+		ImageHeight:  units.ToPixels(reg.Height, yres),
+	}
+
+	bpp := (*ss.ColorMode).BitsPerPixel()
+	info.BytesPerLine = (info.ImageWidth*bpp + 7) / 8
+
+	return info
+}
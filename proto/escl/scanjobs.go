@@ -0,0 +1,84 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scan jobs listing (stored jobs extension)
+
+package escl
+
+import (
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// ScanJobs is the scanner response, that lists the current and
+// recent scan jobs.
+//
+// This is not the part of the eSCL Technical Specification, which
+// defines only the POST request to the ScanJobs endpoint. Some vendors
+// implement GET as well, to allow clients to find jobs, started by
+// somebody else, and to retrieve documents of the completed jobs
+// (see [Client.GetStoredDocument]).
+//
+// Jobs are ordered newest first, like in the [ScannerStatus].
+//
+// GET /{root}/ScanJobs
+type ScanJobs struct {
+	Version Version   // eSCL protocol version
+	Jobs    []JobInfo // Listed jobs
+}
+
+// DecodeScanJobs decodes [ScanJobs] from the XML tree.
+func DecodeScanJobs(root xmldoc.Element) (ret *ScanJobs, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	var jobs ScanJobs
+
+	// Lookup relevant XML elements
+	ver := xmldoc.Lookup{Name: NsPWG + ":Version", Required: true}
+
+	missed := root.Lookup(&ver)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	// Decode elements
+	jobs.Version, err = decodeVersion(ver.Elem)
+	if err != nil {
+		return
+	}
+
+	for _, elem := range root.Children {
+		if elem.Name == NsScan+":JobInfo" {
+			var info JobInfo
+			info, err = decodeJobInfo(elem)
+			if err != nil {
+				return
+			}
+
+			jobs.Jobs = append(jobs.Jobs, info)
+		}
+	}
+
+	ret = &jobs
+	return
+}
+
+// ToXML generates XML tree for the [ScanJobs].
+func (jobs *ScanJobs) ToXML() xmldoc.Element {
+	elm := xmldoc.Element{
+		Name: NsScan + ":ScanJobs",
+		Children: []xmldoc.Element{
+			jobs.Version.toXML(NsPWG + ":Version"),
+		},
+	}
+
+	for _, job := range jobs.Jobs {
+		elm.Children = append(elm.Children,
+			job.toXML(NsScan+":JobInfo"))
+	}
+
+	return elm
+}
@@ -0,0 +1,123 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scan jobs listing test
+
+package escl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestScanJobs tests [ScanJobs] conversion to and from the XML
+func TestScanJobs(t *testing.T) {
+	type testData struct {
+		jobs *ScanJobs
+		xml  xmldoc.Element
+	}
+
+	tests := []testData{
+		{
+			// Full data test
+			jobs: &ScanJobs{
+				Version: MakeVersion(2, 0),
+				Jobs:    testScannerStatus.Jobs,
+			},
+			xml: xmldoc.WithChildren(
+				NsScan+":ScanJobs",
+				append([]xmldoc.Element{
+					xmldoc.WithText(NsPWG+":Version", "2.0"),
+				}, func() (jobs []xmldoc.Element) {
+					for _, job := range testScannerStatus.Jobs {
+						jobs = append(jobs,
+							job.toXML(NsScan+":JobInfo"))
+					}
+					return
+				}()...)...,
+			),
+		},
+
+		{
+			// Empty list
+			jobs: &ScanJobs{
+				Version: MakeVersion(2, 1),
+			},
+			xml: xmldoc.WithChildren(
+				NsScan+":ScanJobs",
+				xmldoc.WithText(NsPWG+":Version", "2.1"),
+			),
+		},
+	}
+
+	for _, test := range tests {
+		xml := test.jobs.ToXML()
+		if !xml.Similar(test.xml) {
+			t.Errorf("encode mismatch:\n"+
+				"expected: %s\n"+
+				"present:  %s\n",
+				test.xml.EncodeString(nil),
+				xml.EncodeString(nil))
+		}
+
+		jobs, err := DecodeScanJobs(test.xml)
+		if err != nil {
+			t.Errorf("decode error:\n"+
+				"input: %s\n"+
+				"error:  %s\n",
+				test.xml.EncodeString(nil), err)
+			continue
+		}
+
+		if !reflect.DeepEqual(jobs, test.jobs) {
+			t.Errorf("decode mismatch:\n"+
+				"expected: %#v\n"+
+				"present:  %#v\n",
+				test.jobs, jobs)
+		}
+	}
+}
+
+// TestScanJobsDecodeErrors tests [ScanJobs] XML decode
+// errors handling
+func TestScanJobsDecodeErrors(t *testing.T) {
+	type testData struct {
+		xml xmldoc.Element
+		err string
+	}
+
+	tests := []testData{
+		// Missed ScanJobs.Version
+		{
+			xml: xmldoc.WithChildren(
+				NsScan + ":ScanJobs",
+			),
+			err: `/scan:ScanJobs/pwg:Version: missed`,
+		},
+
+		// Bad JobInfo
+		{
+			xml: xmldoc.WithChildren(
+				NsScan+":ScanJobs",
+				xmldoc.WithText(NsPWG+":Version", "2.0"),
+				xmldoc.WithChildren(NsScan+":JobInfo"),
+			),
+			err: `/scan:ScanJobs/scan:JobInfo/pwg:JobUri: missed`,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeScanJobs(test.xml)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("error mismatch:\n"+
+				"expected: %s\n"+
+				"present:  %v\n",
+				test.err, err)
+		}
+	}
+}
@@ -41,19 +41,23 @@ type ScannerCapabilities struct {
 	ADF    optional.Val[ADF]    // ADF capabilities
 
 	// Image transform ranges
-	BrightnessSupport        optional.Val[Range] // Brightness
-	CompressionFactorSupport optional.Val[Range] // Lower num, better image
-	ContrastSupport          optional.Val[Range] // Contrast
-	GammaSupport             optional.Val[Range] // Gamma (y = x^(1/g))
-	HighlightSupport         optional.Val[Range] // Image Highlight
-	NoiseRemovalSupport      optional.Val[Range] // Noise removal level
-	ShadowSupport            optional.Val[Range] // The lower, the darker
-	SharpenSupport           optional.Val[Range] // Image sharpen
-	ThresholdSupport         optional.Val[Range] // For BlackAndWhite1
+	BrightnessSupport        optional.Val[Range]      // Brightness
+	CompressionFactorSupport optional.Val[Range]      // Lower num, better image
+	ContrastSupport          optional.Val[Range]      // Contrast
+	GammaSupport             optional.Val[FloatRange] // Gamma (y = x^(1/g))
+	HighlightSupport         optional.Val[Range]      // Image Highlight
+	NoiseRemovalSupport      optional.Val[Range]      // Noise removal level
+	ShadowSupport            optional.Val[Range]      // The lower, the darker
+	SharpenSupport           optional.Val[Range]      // Image sharpen
+	ThresholdSupport         optional.Val[Range]      // For BlackAndWhite1
 
 	// Automatic detection and removal of the blank pages
 	BlankPageDetection           optional.Val[bool] // Detection supported
 	BlankPageDetectionAndRemoval optional.Val[bool] // Auto-remove supported
+
+	// JobSources, eSCL 2.9+, lists input sources, the scan jobs
+	// may be started from.
+	JobSources []InputSource
 }
 
 // DecodeScannerCapabilities decodes [ScannerCapabilities] from the
@@ -89,12 +93,13 @@ func DecodeScannerCapabilities(root xmldoc.Element) (
 	blankDetection := xmldoc.Lookup{Name: NsScan + ":BlankPageDetection"}
 	blankRemoval := xmldoc.Lookup{
 		Name: NsScan + ":BlankPageDetectionAndRemoval"}
+	jobSources := xmldoc.Lookup{Name: NsScan + ":JobSources"}
 
 	missed := root.Lookup(&ver, &mdl, &ser, &mfg, &uu, &admin, &icon,
 		&profiles, &platen, &camera, &adf,
 		&brightness, &compression, &contrast, &gamma, &highlight,
 		&noiseRemoval, &shadow, &sharpen, &threshold,
-		&blankDetection, &blankRemoval)
+		&blankDetection, &blankRemoval, &jobSources)
 
 	if missed != nil {
 		err = xmldoc.XMLErrMissed(missed.Name)
@@ -218,8 +223,8 @@ func DecodeScannerCapabilities(root xmldoc.Element) (
 	}
 
 	if gamma.Found {
-		var r Range
-		r, err = decodeRange(gamma.Elem)
+		var r FloatRange
+		r, err = decodeFloatRange(gamma.Elem)
 		if err != nil {
 			return
 		}
@@ -297,6 +302,24 @@ func DecodeScannerCapabilities(root xmldoc.Element) (
 		scancaps.BlankPageDetectionAndRemoval = optional.New(flg)
 	}
 
+	if jobSources.Found {
+		scancaps.JobSources = make([]InputSource, 0, 3)
+		for _, elem := range jobSources.Elem.Children {
+			if elem.Name == NsScan+":JobSource" {
+				var src InputSource
+				src, err = decodeInputSource(elem)
+				if err != nil {
+					err = xmldoc.XMLErrWrap(
+						jobSources.Elem, err)
+					return
+				}
+
+				scancaps.JobSources = append(
+					scancaps.JobSources, src)
+			}
+		}
+	}
+
 	ret = &scancaps
 	return
 }
@@ -501,5 +524,14 @@ func (scancaps *ScannerCapabilities) ToXML() xmldoc.Element {
 		elm.Children = append(elm.Children, chld)
 	}
 
+	if scancaps.JobSources != nil {
+		chld := xmldoc.Element{Name: NsScan + ":JobSources"}
+		for _, src := range scancaps.JobSources {
+			chld2 := src.toXML(NsScan + ":JobSource")
+			chld.Children = append(chld.Children, chld2)
+		}
+		elm.Children = append(elm.Children, chld)
+	}
+
 	return elm
 }
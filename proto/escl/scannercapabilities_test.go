@@ -36,7 +36,7 @@ var testScannerCapabilities = &ScannerCapabilities{
 	BrightnessSupport:            optional.New(Range{0, 100, 80, nil}),
 	CompressionFactorSupport:     optional.New(Range{1, 5, 3, nil}),
 	ContrastSupport:              optional.New(Range{0, 100, 50, nil}),
-	GammaSupport:                 optional.New(Range{1, 40, 20, nil}),
+	GammaSupport:                 optional.New(FloatRange{1, 40, 20, nil}),
 	HighlightSupport:             optional.New(Range{0, 100, 60, nil}),
 	NoiseRemovalSupport:          optional.New(Range{0, 10, 2, nil}),
 	ShadowSupport:                optional.New(Range{0, 100, 10, nil}),
@@ -44,6 +44,7 @@ var testScannerCapabilities = &ScannerCapabilities{
 	ThresholdSupport:             optional.New(Range{0, 100, 50, nil}),
 	BlankPageDetection:           optional.New(true),
 	BlankPageDetectionAndRemoval: optional.New(true),
+	JobSources:                   []InputSource{InputPlaten, InputFeeder},
 }
 
 // TestScannerCapabilities tests [ScannerCapabilities] conversion
@@ -86,7 +87,7 @@ func TestScannerCapabilities(t *testing.T) {
 					NsScan+":CompressionFactorSupport"),
 				Range{0, 100, 50, nil}.toXML(
 					NsScan+":ContrastSupport"),
-				Range{1, 40, 20, nil}.toXML(
+				FloatRange{1, 40, 20, nil}.toXML(
 					NsScan+":GammaSupport"),
 				Range{0, 100, 60, nil}.toXML(
 					NsScan+":HighlightSupport"),
@@ -102,6 +103,12 @@ func TestScannerCapabilities(t *testing.T) {
 					"true"),
 				xmldoc.WithText(NsScan+":BlankPageDetectionAndRemoval",
 					"true"),
+				xmldoc.WithChildren(NsScan+":JobSources",
+					xmldoc.WithText(NsScan+":JobSource",
+						"Platen"),
+					xmldoc.WithText(NsScan+":JobSource",
+						"Feeder"),
+				),
 			),
 		},
 
@@ -353,6 +360,18 @@ func TestScannerCapabilitiesDecodeErrors(t *testing.T) {
 			),
 			err: `/scan:ScannerCapabilities/scan:BlankPageDetectionAndRemoval: invalid bool: "bad"`,
 		},
+
+		{
+			xml: xmldoc.WithChildren(
+				NsScan+":ScannerCapabilities",
+				xmldoc.WithText(NsPWG+":Version", "2.0"),
+				xmldoc.WithChildren(NsScan+":JobSources",
+					xmldoc.WithText(
+						NsScan+":JobSource", "bad"),
+				),
+			),
+			err: `/scan:ScannerCapabilities/scan:JobSources/scan:JobSource: invalid InputSource: "bad"`,
+		},
 	}
 
 	for _, test := range tests {
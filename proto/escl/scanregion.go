@@ -11,6 +11,7 @@ package escl
 import (
 	"strconv"
 
+	"github.com/OpenPrinting/go-mfp/abstract"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
@@ -20,7 +21,42 @@ type ScanRegion struct {
 	YOffset            int   // Vertical offset, 0-based
 	Width              int   // Region width
 	Height             int   // Region height
-	ContentRegionUnits Units // Always ThreeHundredthsOfInches
+	ContentRegionUnits Units // Units of all of the above
+}
+
+// fromAbstractScanRegion converts [abstract.Region] into the
+// [ScanRegion], in the specified units.
+func fromAbstractScanRegion(units Units, absreg abstract.Region) ScanRegion {
+	return ScanRegion{
+		XOffset:            units.FromDimension(absreg.XOffset),
+		YOffset:            units.FromDimension(absreg.YOffset),
+		Width:              units.FromDimension(absreg.Width),
+		Height:             units.FromDimension(absreg.Height),
+		ContentRegionUnits: units,
+	}
+}
+
+// ToAbstract converts [ScanRegion] into the [abstract.Region],
+// taking ContentRegionUnits into account.
+func (r ScanRegion) ToAbstract() abstract.Region {
+	units := r.ContentRegionUnits
+	return abstract.Region{
+		XOffset: units.ToDimension(r.XOffset),
+		YOffset: units.ToDimension(r.YOffset),
+		Width:   units.ToDimension(r.Width),
+		Height:  units.ToDimension(r.Height),
+	}
+}
+
+// InUnits returns a copy of the [ScanRegion], converted
+// into the specified units.
+func (r ScanRegion) InUnits(units Units) ScanRegion {
+	if r.ContentRegionUnits.DPI() == units.DPI() {
+		r.ContentRegionUnits = units
+		return r
+	}
+
+	return fromAbstractScanRegion(units, r.ToAbstract())
 }
 
 // decodeScanRegion decodes [ScanRegion] from the XML tree
@@ -60,7 +96,15 @@ func decodeScanRegion(root xmldoc.Element) (reg ScanRegion, err error) {
 }
 
 // toXML generates XML tree for the [ScanRegion].
+//
+// UnknownUnits are encoded as ThreeHundredthsOfInches, as
+// ContentRegionUnits element is required.
 func (r ScanRegion) toXML(name string) xmldoc.Element {
+	units := r.ContentRegionUnits
+	if units == UnknownUnits {
+		units = ThreeHundredthsOfInches
+	}
+
 	elm := xmldoc.Element{
 		Name: name,
 		Children: []xmldoc.Element{
@@ -80,8 +124,7 @@ func (r ScanRegion) toXML(name string) xmldoc.Element {
 				Name: NsPWG + ":Height",
 				Text: strconv.FormatUint(uint64(r.Height), 10),
 			},
-			ThreeHundredthsOfInches.toXML(
-				NsPWG + ":ContentRegionUnits"),
+			units.toXML(NsPWG + ":ContentRegionUnits"),
 		},
 	}
 
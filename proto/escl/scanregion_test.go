@@ -13,6 +13,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/OpenPrinting/go-mfp/abstract"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
@@ -229,3 +230,67 @@ func TestScanRegionDecodeErrors(t *testing.T) {
 		}
 	}
 }
+
+// TestScanRegionAbstract tests conversion between ScanRegion
+// and abstract.Region
+func TestScanRegionAbstract(t *testing.T) {
+	absreg := abstract.Region{
+		XOffset: abstract.Inch,
+		YOffset: 2 * abstract.Inch,
+		Width:   abstract.A4Width,
+		Height:  abstract.A4Height,
+	}
+
+	expected := ScanRegion{
+		XOffset:            300,
+		YOffset:            600,
+		Width:              2480,
+		Height:             3508,
+		ContentRegionUnits: ThreeHundredthsOfInches,
+	}
+
+	reg := fromAbstractScanRegion(ThreeHundredthsOfInches, absreg)
+	if reg != expected {
+		t.Errorf("fromAbstractScanRegion:\n"+
+			"expected: %#v\npresent:  %#v", expected, reg)
+	}
+
+	// Note, A4 is not precisely representable in 1/300 inch,
+	// so compare with the rounded value
+	absreg2 := reg.ToAbstract()
+	expabs := abstract.Region{
+		XOffset: abstract.Inch,
+		YOffset: 2 * abstract.Inch,
+		Width:   abstract.DimensionFromDots(300, 2480),
+		Height:  abstract.DimensionFromDots(300, 3508),
+	}
+
+	if absreg2 != expabs {
+		t.Errorf("ScanRegion.ToAbstract:\n"+
+			"expected: %#v\npresent:  %#v", expabs, absreg2)
+	}
+
+	// UnknownUnits are treated as ThreeHundredthsOfInches
+	reg.ContentRegionUnits = UnknownUnits
+	if reg.ToAbstract() != expabs {
+		t.Errorf("ScanRegion.ToAbstract with UnknownUnits:\n"+
+			"expected: %#v\npresent:  %#v", expabs, reg.ToAbstract())
+	}
+
+	reg = reg.InUnits(ThreeHundredthsOfInches)
+	if reg != expected {
+		t.Errorf("ScanRegion.InUnits:\n"+
+			"expected: %#v\npresent:  %#v", expected, reg)
+	}
+
+	// UnknownUnits are encoded as ThreeHundredthsOfInches
+	reg.ContentRegionUnits = UnknownUnits
+	xml := reg.toXML(NsPWG + ":ScanRegion")
+	reg, err := decodeScanRegion(xml)
+	if err != nil {
+		t.Errorf("decodeScanRegion: %s", err)
+	} else if reg != expected {
+		t.Errorf("ScanRegion with UnknownUnits:\n"+
+			"expected: %#v\npresent:  %#v", expected, reg)
+	}
+}
@@ -42,15 +42,15 @@ type ScanSettings struct {
 	FeedDirection     optional.Val[FeedDirection]   // Desired feed dir
 
 	// Image transform parameters
-	Brightness        optional.Val[int] // Brightness
-	CompressionFactor optional.Val[int] // Lower num, better image
-	Contrast          optional.Val[int] // Contrast
-	Gamma             optional.Val[int] // Gamma (y=x^(1/g)
-	Highlight         optional.Val[int] // Image Highlight
-	NoiseRemoval      optional.Val[int] // Noise removal level
-	Shadow            optional.Val[int] // The lower, the darger
-	Sharpen           optional.Val[int] // Image sharpen
-	Threshold         optional.Val[int] // For BlackAndWhite1
+	Brightness        optional.Val[int]     // Brightness
+	CompressionFactor optional.Val[int]     // Lower num, better image
+	Contrast          optional.Val[int]     // Contrast
+	Gamma             optional.Val[float64] // Gamma (y=x^(1/g)
+	Highlight         optional.Val[int]     // Image Highlight
+	NoiseRemoval      optional.Val[int]     // Noise removal level
+	Shadow            optional.Val[int]     // The lower, the darger
+	Sharpen           optional.Val[int]     // Image sharpen
+	Threshold         optional.Val[int]     // For BlackAndWhite1
 
 	// Blank page detection and removal (ADF only).
 	//
@@ -265,7 +265,8 @@ func DecodeScanSettings(root xmldoc.Element) (
 	}
 
 	if gamma.Found {
-		ss.Gamma, err = decodeOptional(gamma.Elem, decodeNonNegativeInt)
+		ss.Gamma, err = decodeOptional(gamma.Elem,
+			decodeNonNegativeFloat)
 		if err != nil {
 			return
 		}
@@ -446,7 +447,7 @@ func (ss *ScanSettings) ToXML() xmldoc.Element {
 
 	if ss.Gamma != nil {
 		chld := xmldoc.WithText(NsScan+":Gamma",
-			strconv.FormatUint(uint64(*ss.Gamma), 10))
+			formatFloat(*ss.Gamma))
 		elm.Children = append(elm.Children, chld)
 	}
 
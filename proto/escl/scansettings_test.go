@@ -38,7 +38,7 @@ var testScanSettings = &ScanSettings{
 	Brightness:                   optional.New(80),
 	CompressionFactor:            optional.New(3),
 	Contrast:                     optional.New(50),
-	Gamma:                        optional.New(20),
+	Gamma:                        optional.New(20.0),
 	Highlight:                    optional.New(60),
 	NoiseRemoval:                 optional.New(2),
 	Shadow:                       optional.New(10),
@@ -306,7 +306,7 @@ func TestScanSettingsDecodeErrors(t *testing.T) {
 				xmldoc.WithText(NsPWG+":Version", "2.0"),
 				xmldoc.WithText(NsScan+":Gamma", "bad"),
 			),
-			err: `/scan:ScanSettings/scan:Gamma: invalid int: "bad"`,
+			err: `/scan:ScanSettings/scan:Gamma: invalid float: "bad"`,
 		},
 
 		{
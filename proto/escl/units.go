@@ -10,7 +10,10 @@ package escl
 
 import (
 	"fmt"
+	"math"
+	"strings"
 
+	"github.com/OpenPrinting/go-mfp/abstract"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
@@ -27,9 +30,13 @@ const (
 )
 
 // decodeUnits decodes [Units] from the XML tree.
+//
+// Namespace prefix is ignored, as some devices use a different
+// one or omit it at all.
 func decodeUnits(root xmldoc.Element) (units Units, err error) {
-	if root.Text == "escl:ThreeHundredthsOfInches" {
-		return ThreeHundredthsOfInches, nil
+	units = DecodeUnits(root.Text)
+	if units != UnknownUnits {
+		return
 	}
 
 	err = fmt.Errorf("invalid Units: %q", root.Text)
@@ -58,11 +65,63 @@ func (units Units) String() string {
 
 // DecodeUnits decodes [Units] out of its XML
 // string representation.
+//
+// Namespace prefix, if any, is ignored.
 func DecodeUnits(s string) Units {
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		s = s[i+1:]
+	}
+
 	switch s {
-	case "escl:ThreeHundredthsOfInches":
+	case "ThreeHundredthsOfInches":
 		return ThreeHundredthsOfInches
 	}
 
 	return UnknownUnits
 }
+
+// DPI returns number of units per inch.
+//
+// UnknownUnits are assumed to be ThreeHundredthsOfInches,
+// as it is the only units, defined by eSCL.
+func (units Units) DPI() int {
+	return 300
+}
+
+// ToDimension converts value in units into the [abstract.Dimension].
+func (units Units) ToDimension(v int) abstract.Dimension {
+	return abstract.DimensionFromDots(units.DPI(), v)
+}
+
+// FromDimension converts [abstract.Dimension] into the value in units,
+// rounding to the nearest integer.
+func (units Units) FromDimension(dim abstract.Dimension) int {
+	return dim.Dots(units.DPI())
+}
+
+// ToMillimeters converts value in units into millimeters.
+func (units Units) ToMillimeters(v int) float64 {
+	return float64(v) * 25.4 / float64(units.DPI())
+}
+
+// FromMillimeters converts millimeters into the value in units,
+// rounding to the nearest integer.
+func (units Units) FromMillimeters(mm float64) int {
+	return int(math.Round(mm * float64(units.DPI()) / 25.4))
+}
+
+// ToPixels converts value in units into number of image pixels
+// at the specified resolution (in DPI), rounding to the nearest
+// integer.
+func (units Units) ToPixels(v, dpi int) int {
+	udpi := units.DPI()
+	return (v*dpi + udpi/2) / udpi
+}
+
+// FromPixels converts number of image pixels at the specified
+// resolution (in DPI) into the value in units, rounding to the
+// nearest integer.
+func (units Units) FromPixels(px, dpi int) int {
+	udpi := units.DPI()
+	return (px*udpi + dpi/2) / dpi
+}
@@ -8,7 +8,11 @@
 
 package escl
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+)
 
 var testUnits = testEnum[Units]{
 	decodeStr: DecodeUnits,
@@ -22,3 +26,58 @@ var testUnits = testEnum[Units]{
 func TestUnits(t *testing.T) {
 	testUnits.run(t)
 }
+
+// TestUnitsDecodePrefix tests that decoding of [Units] ignores
+// the namespace prefix.
+func TestUnitsDecodePrefix(t *testing.T) {
+	for _, s := range []string{
+		"escl:ThreeHundredthsOfInches",
+		"pwg:ThreeHundredthsOfInches",
+		"ThreeHundredthsOfInches",
+	} {
+		units := DecodeUnits(s)
+		if units != ThreeHundredthsOfInches {
+			t.Errorf("DecodeUnits(%q): expected %s, present %s",
+				s, ThreeHundredthsOfInches, units)
+		}
+	}
+}
+
+// TestUnitsConversion tests [Units] conversion helpers
+func TestUnitsConversion(t *testing.T) {
+	units := ThreeHundredthsOfInches
+
+	if dim := units.ToDimension(300); dim != abstract.Inch {
+		t.Errorf("ToDimension(300): expected %d, present %d",
+			abstract.Inch, dim)
+	}
+
+	if v := units.FromDimension(abstract.Inch); v != 300 {
+		t.Errorf("FromDimension(Inch): expected 300, present %d", v)
+	}
+
+	if mm := units.ToMillimeters(300); mm != 25.4 {
+		t.Errorf("ToMillimeters(300): expected 25.4, present %g", mm)
+	}
+
+	if v := units.FromMillimeters(210); v != 2480 {
+		t.Errorf("FromMillimeters(210): expected 2480, present %d", v)
+	}
+
+	if px := units.ToPixels(2550, 600); px != 5100 {
+		t.Errorf("ToPixels(2550, 600): expected 5100, present %d", px)
+	}
+
+	if px := units.ToPixels(2550, 75); px != 638 {
+		t.Errorf("ToPixels(2550, 75): expected 638, present %d", px)
+	}
+
+	if v := units.FromPixels(638, 75); v != 2552 {
+		t.Errorf("FromPixels(638, 75): expected 2552, present %d", v)
+	}
+
+	if v := UnknownUnits.FromPixels(1200, 600); v != 600 {
+		t.Errorf("UnknownUnits.FromPixels(1200, 600): "+
+			"expected 600, present %d", v)
+	}
+}
@@ -0,0 +1,504 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// ScanSettings validation against ScannerCapabilities
+
+package escl
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// ErrScanSettings is returned by the [ScanSettings.Validate], when
+// ScanSettings contains the value, not supported by the scanner.
+//
+// It unwraps to [abstract.ErrUnsupportedParam].
+type ErrScanSettings struct {
+	Element   string   // Offending element, i.e. "scan:ColorMode"
+	Value     string   // Requested value
+	Supported []string // Supported values, if known
+}
+
+// Error returns error string. It implements the [error] interface.
+func (e ErrScanSettings) Error() string {
+	s := fmt.Sprintf("%s: %q not supported", e.Element, e.Value)
+	if len(e.Supported) != 0 {
+		s += " (supported: " + strings.Join(e.Supported, ", ") + ")"
+	}
+	return s
+}
+
+// Unwrap returns the underlying error.
+func (e ErrScanSettings) Unwrap() error {
+	return abstract.ErrUnsupportedParam
+}
+
+// Validate cross-checks ScanSettings against the [ScannerCapabilities].
+//
+// On success it returns nil. Otherwise it returns the [ErrScanSettings]
+// error, that names the first offending element and lists its
+// supported values.
+//
+// Parameters, omitted in the ScanSettings, as well as capabilities,
+// not reported by the scanner, are not checked.
+func (ss *ScanSettings) Validate(scancaps *ScannerCapabilities) error {
+	// Choose input capabilities
+	input, inpcaps, err := ss.validateInput(scancaps)
+	if err != nil {
+		return err
+	}
+
+	// Gather setting profiles. Profiles of the input take
+	// precedence over the global ones.
+	profs := scancaps.SettingProfiles
+	if inpcaps != nil && len(inpcaps.SettingProfiles) != 0 {
+		profs = inpcaps.SettingProfiles
+	}
+
+	// Check general parameters
+	if inpcaps != nil {
+		err = validateEnum(NsScan+":Intent", ss.Intent,
+			inpcaps.SupportedIntents)
+		if err == nil {
+			err = validateEnum(NsScan+":FeedDirection",
+				ss.FeedDirection, inpcaps.FeedDirections)
+		}
+		if err == nil {
+			err = ss.validateRegions(*inpcaps)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if input == InputFeeder && optional.Get(ss.Duplex) {
+		adf := optional.Get(scancaps.ADF)
+		if adf.ADFDuplexInputCaps == nil &&
+			!slices.Contains(adf.ADFOptions, Duplex) {
+			return ErrScanSettings{
+				Element: NsScan + ":Duplex",
+				Value:   "true",
+			}
+		}
+	}
+
+	// Check SettingProfile parameters
+	err = validateEnum(NsScan+":ColorMode", ss.ColorMode,
+		validateGather(profs, func(prof SettingProfile) []ColorMode {
+			return prof.ColorModes
+		}))
+	if err == nil {
+		err = validateEnum(NsPWG+":ContentType", ss.ContentType,
+			validateGather(profs, func(prof SettingProfile) []ContentType {
+				return prof.ContentTypes
+			}))
+	}
+	if err == nil {
+		err = validateEnum(NsScan+":ColorSpace", ss.ColorSpace,
+			validateGather(profs, func(prof SettingProfile) []ColorSpace {
+				return prof.ColorSpaces
+			}))
+	}
+	if err == nil {
+		err = validateEnum(NsScan+":CcdChannel", ss.CCDChannel,
+			validateGather(profs, func(prof SettingProfile) []CCDChannel {
+				return prof.CCDChannels
+			}))
+	}
+	if err == nil {
+		err = validateEnum(NsScan+":BinaryRendering",
+			ss.BinaryRendering,
+			validateGather(profs,
+				func(prof SettingProfile) []BinaryRendering {
+					return prof.BinaryRenderings
+				}))
+	}
+	if err == nil {
+		err = ss.validateFormats(profs)
+	}
+	if err == nil {
+		err = ss.validateResolution(profs)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Check image transform parameters
+	ranges := []struct {
+		name string
+		val  optional.Val[int]
+		rng  optional.Val[Range]
+	}{
+		{"Brightness", ss.Brightness, scancaps.BrightnessSupport},
+		{"CompressionFactor", ss.CompressionFactor,
+			scancaps.CompressionFactorSupport},
+		{"Contrast", ss.Contrast, scancaps.ContrastSupport},
+		{"Highlight", ss.Highlight, scancaps.HighlightSupport},
+		{"NoiseRemoval", ss.NoiseRemoval, scancaps.NoiseRemovalSupport},
+		{"Shadow", ss.Shadow, scancaps.ShadowSupport},
+		{"Sharpen", ss.Sharpen, scancaps.SharpenSupport},
+		{"Threshold", ss.Threshold, scancaps.ThresholdSupport},
+	}
+
+	for _, r := range ranges {
+		if r.val != nil && r.rng != nil && !validateRange(*r.rng, *r.val) {
+			return ErrScanSettings{
+				Element:   NsScan + ":" + r.name,
+				Value:     strconv.Itoa(*r.val),
+				Supported: []string{validateRangeString(*r.rng)},
+			}
+		}
+	}
+
+	if ss.Gamma != nil && scancaps.GammaSupport != nil &&
+		!(*scancaps.GammaSupport).Within(*ss.Gamma) {
+		return ErrScanSettings{
+			Element: NsScan + ":Gamma",
+			Value:   formatFloat(*ss.Gamma),
+			Supported: []string{
+				validateFloatRangeString(*scancaps.GammaSupport),
+			},
+		}
+	}
+
+	// Check blank page detection
+	if optional.Get(ss.BlankPageDetection) &&
+		!optional.Get(scancaps.BlankPageDetection) {
+		return ErrScanSettings{
+			Element: NsScan + ":BlankPageDetection",
+			Value:   "true",
+		}
+	}
+
+	if optional.Get(ss.BlankPageDetectionAndRemoval) &&
+		!optional.Get(scancaps.BlankPageDetectionAndRemoval) {
+		return ErrScanSettings{
+			Element: NsScan + ":BlankPageDetectionAndRemoval",
+			Value:   "true",
+		}
+	}
+
+	return nil
+}
+
+// validateInput checks the InputSource and returns the effective
+// input and its capabilities.
+//
+// If InputSource is not specified, the first available input is
+// assumed. Returned capabilities may be nil, if scanner doesn't
+// report them.
+func (ss *ScanSettings) validateInput(scancaps *ScannerCapabilities) (
+	InputSource, *InputSourceCaps, error) {
+
+	type inputCaps struct {
+		input InputSource
+		caps  optional.Val[InputSourceCaps]
+	}
+
+	var available []inputCaps
+
+	if scancaps.Platen != nil {
+		available = append(available,
+			inputCaps{InputPlaten, scancaps.Platen.PlatenInputCaps})
+	}
+
+	if scancaps.ADF != nil {
+		caps := scancaps.ADF.ADFSimplexInputCaps
+		if optional.Get(ss.Duplex) &&
+			scancaps.ADF.ADFDuplexInputCaps != nil {
+			caps = scancaps.ADF.ADFDuplexInputCaps
+		}
+		available = append(available, inputCaps{InputFeeder, caps})
+	}
+
+	if scancaps.Camera != nil {
+		available = append(available,
+			inputCaps{InputCamera, scancaps.Camera.CameraInputCaps})
+	}
+
+	if len(available) == 0 {
+		// Nothing to check against
+		return optional.Get(ss.InputSource), nil, nil
+	}
+
+	if ss.InputSource == nil {
+		return available[0].input, available[0].caps, nil
+	}
+
+	for _, avail := range available {
+		if avail.input == *ss.InputSource {
+			return avail.input, avail.caps, nil
+		}
+	}
+
+	supported := make([]string, len(available))
+	for i := range available {
+		supported[i] = available[i].input.String()
+	}
+
+	err := ErrScanSettings{
+		Element:   NsPWG + ":InputSource",
+		Value:     (*ss.InputSource).String(),
+		Supported: supported,
+	}
+
+	return UnknownInputSource, nil, err
+}
+
+// validateFormats checks DocumentFormat and DocumentFormatExt.
+func (ss *ScanSettings) validateFormats(profs []SettingProfile) error {
+	formats := validateGather(profs, func(prof SettingProfile) []string {
+		return prof.DocumentFormats
+	})
+
+	formatsExt := validateGather(profs, func(prof SettingProfile) []string {
+		return prof.DocumentFormatsExt
+	})
+
+	if len(formatsExt) == 0 {
+		formatsExt = formats
+	}
+
+	err := validateString(NsPWG+":DocumentFormat",
+		ss.DocumentFormat, formats)
+	if err == nil {
+		err = validateString(NsScan+":DocumentFormatExt",
+			ss.DocumentFormatExt, formatsExt)
+	}
+
+	return err
+}
+
+// validateResolution checks XResolution and YResolution.
+//
+// If only one of them is specified, the same value is assumed
+// for the another.
+func (ss *ScanSettings) validateResolution(profs []SettingProfile) error {
+	if ss.XResolution == nil && ss.YResolution == nil {
+		return nil
+	}
+
+	x := optional.Get(ss.XResolution)
+	y := optional.Get(ss.YResolution)
+
+	switch {
+	case ss.XResolution == nil:
+		x = y
+	case ss.YResolution == nil:
+		y = x
+	}
+
+	// Gather resolutions, applicable for the requested ColorMode
+	var supported []string
+
+	for _, prof := range profs {
+		for _, res := range prof.SupportedResolutions {
+			if res.ColorMode != nil && ss.ColorMode != nil &&
+				*res.ColorMode != *ss.ColorMode {
+				continue
+			}
+
+			for _, d := range res.DiscreteResolutions {
+				if d.XResolution == x && d.YResolution == y {
+					return nil
+				}
+
+				supported = append(supported,
+					fmt.Sprintf("%dx%d",
+						d.XResolution, d.YResolution))
+			}
+
+			if res.ResolutionRange != nil {
+				rng := *res.ResolutionRange
+				if validateRange(rng.XResolutionRange, x) &&
+					validateRange(rng.YResolutionRange, y) {
+					return nil
+				}
+
+				supported = append(supported,
+					fmt.Sprintf("%sx%s",
+						validateRangeString(
+							rng.XResolutionRange),
+						validateRangeString(
+							rng.YResolutionRange)))
+			}
+		}
+	}
+
+	if supported == nil {
+		// Scanner doesn't report resolutions
+		return nil
+	}
+
+	element, val := NsScan+":XResolution", x
+	if ss.XResolution == nil {
+		element, val = NsScan+":YResolution", y
+	}
+
+	return ErrScanSettings{
+		Element:   element,
+		Value:     strconv.Itoa(val),
+		Supported: validateUnique(supported),
+	}
+}
+
+// validateRegions checks ScanRegions against the input capabilities.
+//
+// Note, InputSourceCaps are always in the ThreeHundredthsOfInches
+// units, so ScanRegions are converted into these units before checks.
+func (ss *ScanSettings) validateRegions(inpcaps InputSourceCaps) error {
+	if maxreg := inpcaps.MaxScanRegions; maxreg != nil &&
+		len(ss.ScanRegions) > *maxreg {
+		return ErrScanSettings{
+			Element:   NsPWG + ":ScanRegions",
+			Value:     strconv.Itoa(len(ss.ScanRegions)),
+			Supported: []string{"1-" + strconv.Itoa(*maxreg)},
+		}
+	}
+
+	for _, reg := range ss.ScanRegions {
+		reg = reg.InUnits(ThreeHundredthsOfInches)
+
+		// Zero MaxWidth or MaxHeight means "not reported"
+		checks := []struct {
+			name     string
+			val      int
+			min, max int
+			known    bool
+		}{
+			{"Width", reg.Width, inpcaps.MinWidth, inpcaps.MaxWidth,
+				inpcaps.MaxWidth > 0},
+			{"Height", reg.Height, inpcaps.MinHeight, inpcaps.MaxHeight,
+				inpcaps.MaxHeight > 0},
+			{"XOffset", reg.XOffset, 0, inpcaps.MaxWidth - reg.Width,
+				inpcaps.MaxWidth > 0},
+			{"YOffset", reg.YOffset, 0, inpcaps.MaxHeight - reg.Height,
+				inpcaps.MaxHeight > 0},
+		}
+
+		for _, chk := range checks {
+			if chk.known && (chk.val < chk.min || chk.val > chk.max) {
+				return ErrScanSettings{
+					Element: NsPWG + ":" + chk.name,
+					Value:   strconv.Itoa(chk.val),
+					Supported: []string{fmt.Sprintf("%d-%d",
+						chk.min, chk.max)},
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateEnum checks the optional enum value against the list of
+// supported values. Empty list means "not reported", and any value
+// is accepted.
+func validateEnum[T interface {
+	comparable
+	String() string
+}](element string, val optional.Val[T], supported []T) error {
+
+	if val == nil || len(supported) == 0 ||
+		slices.Contains(supported, *val) {
+		return nil
+	}
+
+	names := make([]string, len(supported))
+	for i := range supported {
+		names[i] = supported[i].String()
+	}
+
+	return ErrScanSettings{
+		Element:   element,
+		Value:     (*val).String(),
+		Supported: names,
+	}
+}
+
+// validateString is the validateEnum for strings.
+// Strings are compared case-insensitively.
+func validateString(element string, val optional.Val[string],
+	supported []string) error {
+
+	if val == nil || len(supported) == 0 {
+		return nil
+	}
+
+	for _, s := range supported {
+		if strings.EqualFold(s, *val) {
+			return nil
+		}
+	}
+
+	return ErrScanSettings{
+		Element:   element,
+		Value:     *val,
+		Supported: supported,
+	}
+}
+
+// validateGather gathers values from all SettingProfiles
+// and removes duplicates.
+func validateGather[T comparable](profs []SettingProfile,
+	get func(SettingProfile) []T) []T {
+
+	var gathered []T
+	for _, prof := range profs {
+		gathered = append(gathered, get(prof)...)
+	}
+
+	return validateUnique(gathered)
+}
+
+// validateUnique removes duplicates from the slice, preserving order.
+func validateUnique[T comparable](s []T) []T {
+	var unique []T
+	for _, v := range s {
+		if !slices.Contains(unique, v) {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// validateRange reports whether value is within the Range and
+// matches its Step, if any.
+func validateRange(r Range, v int) bool {
+	if v < r.Min || v > r.Max {
+		return false
+	}
+
+	if step := optional.Get(r.Step); step > 1 {
+		return (v-r.Min)%step == 0
+	}
+
+	return true
+}
+
+// validateRangeString formats the Range for diagnostics.
+func validateRangeString(r Range) string {
+	s := fmt.Sprintf("%d-%d", r.Min, r.Max)
+	if step := optional.Get(r.Step); step > 1 {
+		s += fmt.Sprintf("/%d", step)
+	}
+	return s
+}
+
+// validateFloatRangeString formats the FloatRange for diagnostics.
+func validateFloatRangeString(r FloatRange) string {
+	s := formatFloat(r.Min) + "-" + formatFloat(r.Max)
+	if step := optional.Get(r.Step); step > 0 {
+		s += "/" + formatFloat(step)
+	}
+	return s
+}
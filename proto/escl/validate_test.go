@@ -0,0 +1,248 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// ScanSettings validation test
+
+package escl
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/assert"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestScanSettingsValidate tests ScanSettings.Validate
+func TestScanSettingsValidate(t *testing.T) {
+	xml, err := xmldoc.Decode(
+		NsMap,
+		bytes.NewReader(testutils.
+			Kyocera.ECOSYS.M2040dn.ESCL.ScannerCapabilities))
+	assert.NoError(err)
+
+	caps, err := DecodeScannerCapabilities(xml)
+	assert.NoError(err)
+
+	caps.GammaSupport = optional.New(FloatRange{
+		Min: 1.0, Max: 3.0, Normal: 2.2, Step: optional.New(0.1)})
+
+	type testData struct {
+		name    string       // Test name
+		ss      ScanSettings // Scan settings
+		element string       // Expected offending element, "" if OK
+	}
+
+	tests := []testData{
+		{
+			name: "empty",
+			ss:   ScanSettings{},
+		},
+
+		{
+			name: "valid",
+			ss: ScanSettings{
+				Intent:         optional.New(Document),
+				DocumentFormat: optional.New("image/JPEG"),
+				InputSource:    optional.New(InputFeeder),
+				Duplex:         optional.New(true),
+				XResolution:    optional.New(200),
+				YResolution:    optional.New(100),
+				ColorMode:      optional.New(Grayscale8),
+				Sharpen:        optional.New(-3),
+				ScanRegions: []ScanRegion{
+					{
+						XOffset:            0,
+						YOffset:            0,
+						Width:              2551,
+						Height:             3508,
+						ContentRegionUnits: ThreeHundredthsOfInches,
+					},
+				},
+			},
+		},
+
+		{
+			name: "input",
+			ss: ScanSettings{
+				InputSource: optional.New(InputCamera),
+			},
+			element: NsPWG + ":InputSource",
+		},
+
+		{
+			name: "intent",
+			ss: ScanSettings{
+				Intent: optional.New(BusinessCard),
+			},
+			element: NsScan + ":Intent",
+		},
+
+		{
+			name: "format",
+			ss: ScanSettings{
+				DocumentFormat: optional.New("image/png"),
+			},
+			element: NsPWG + ":DocumentFormat",
+		},
+
+		{
+			name: "color mode",
+			ss: ScanSettings{
+				ColorMode: optional.New(RGB48),
+			},
+			element: NsScan + ":ColorMode",
+		},
+
+		{
+			name: "resolution",
+			ss: ScanSettings{
+				XResolution: optional.New(150),
+				YResolution: optional.New(150),
+			},
+			element: NsScan + ":XResolution",
+		},
+
+		{
+			name: "y resolution only",
+			ss: ScanSettings{
+				YResolution: optional.New(100),
+			},
+			element: NsScan + ":YResolution",
+		},
+
+		{
+			name: "range",
+			ss: ScanSettings{
+				Sharpen: optional.New(10),
+			},
+			element: NsScan + ":Sharpen",
+		},
+
+		{
+			name: "gamma",
+			ss: ScanSettings{
+				Gamma: optional.New(1.8),
+			},
+		},
+
+		{
+			name: "gamma step",
+			ss: ScanSettings{
+				Gamma: optional.New(2.25),
+			},
+			element: NsScan + ":Gamma",
+		},
+
+		{
+			name: "region height",
+			ss: ScanSettings{
+				ScanRegions: []ScanRegion{
+					{
+						Width:              2551,
+						Height:             4205,
+						ContentRegionUnits: ThreeHundredthsOfInches,
+					},
+				},
+			},
+			element: NsPWG + ":Height",
+		},
+
+		{
+			name: "region offset",
+			ss: ScanSettings{
+				ScanRegions: []ScanRegion{
+					{
+						XOffset:            100,
+						Width:              2551,
+						Height:             3508,
+						ContentRegionUnits: ThreeHundredthsOfInches,
+					},
+				},
+			},
+			element: NsPWG + ":XOffset",
+		},
+
+		{
+			name: "blank page",
+			ss: ScanSettings{
+				BlankPageDetection: optional.New(true),
+			},
+			element: NsScan + ":BlankPageDetection",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.ss.Validate(caps)
+
+		if test.element == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			continue
+		}
+
+		var e ErrScanSettings
+		if !errors.As(err, &e) {
+			t.Errorf("%s: ErrScanSettings expected, got %v",
+				test.name, err)
+			continue
+		}
+
+		if e.Element != test.element {
+			t.Errorf("%s: element mismatch:\n"+
+				"expected: %s\n"+
+				"present:  %s\n",
+				test.name, test.element, e.Element)
+		}
+
+		if !errors.Is(err, abstract.ErrUnsupportedParam) {
+			t.Errorf("%s: must unwrap to %s", test.name,
+				abstract.ErrUnsupportedParam)
+		}
+	}
+}
+
+// TestErrScanSettings tests ErrScanSettings.Error
+func TestErrScanSettings(t *testing.T) {
+	type testData struct {
+		err ErrScanSettings
+		s   string
+	}
+
+	tests := []testData{
+		{
+			err: ErrScanSettings{
+				Element:   "scan:ColorMode",
+				Value:     "RGB48",
+				Supported: []string{"Grayscale8", "RGB24"},
+			},
+			s: `scan:ColorMode: "RGB48" not supported ` +
+				`(supported: Grayscale8, RGB24)`,
+		},
+		{
+			err: ErrScanSettings{
+				Element: "scan:Duplex",
+				Value:   "true",
+			},
+			s: `scan:Duplex: "true" not supported`,
+		},
+	}
+
+	for _, test := range tests {
+		s := test.err.Error()
+		if s != test.s {
+			t.Errorf("ErrScanSettings.Error:\n"+
+				"expected: %s\n"+
+				"present:  %s\n",
+				test.s, s)
+		}
+	}
+}
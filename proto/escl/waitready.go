@@ -0,0 +1,150 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Waiting for scanner readiness
+
+package escl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// WaitReady polling intervals
+const (
+	// WaitReadyMinInterval is the initial interval between
+	// ScannerStatus requests. It is also used after the
+	// scanner status changes.
+	WaitReadyMinInterval = 250 * time.Millisecond
+
+	// WaitReadyMaxInterval is the maximum interval between
+	// ScannerStatus requests, the backoff grows up to.
+	WaitReadyMaxInterval = 5 * time.Second
+)
+
+// ErrNotReady is returned by the [WaitReady], when scanner doesn't
+// become ready within the specified timeout.
+var ErrNotReady = errors.New("eSCL: scanner is not ready")
+
+// WaitReadyOptions defines additional conditions for the [WaitReady].
+type WaitReadyOptions struct {
+	// ADFLoaded, if set, requires ADF to be loaded with sheets.
+	//
+	// Scanners that don't report the ADF state are considered
+	// loaded, as there is no way to check it.
+	ADFLoaded bool
+}
+
+// WaitReady polls [ScannerStatus] until scanner becomes Idle
+// (and ready, according to options), the timeout expires or ctx
+// is canceled.
+//
+// While scanner status doesn't change, the interval between
+// requests grows from WaitReadyMinInterval up to WaitReadyMaxInterval.
+// When status changes (i.e., device is in active use), interval
+// is reset to the minimum.
+//
+// Zero or negative timeout means no timeout. If timeout expires,
+// the [ErrNotReady] error is returned, along with the last received
+// status.
+//
+// The 503 Service Unavailable responses to the ScannerStatus
+// requests are considered as "busy" and polling continues.
+// Other errors are returned immediately.
+func WaitReady(ctx context.Context, clnt *Client, timeout time.Duration,
+	options WaitReadyOptions) (*ScannerStatus, error) {
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	interval := WaitReadyMinInterval
+	var last *ScannerStatus
+
+	for {
+		status, details, err := clnt.GetScannerStatus(ctx)
+		switch {
+		case err == nil:
+			if waitReadyCheck(status, options) {
+				return status, nil
+			}
+
+			if last != nil && !waitReadySame(last, status) {
+				interval = WaitReadyMinInterval
+			}
+
+			last = status
+
+		case details != nil &&
+			details.StatusCode == http.StatusServiceUnavailable:
+			// Scanner is busy
+
+		default:
+			return last, err
+		}
+
+		log.Debug(ctx, "eSCL: scanner not ready, retry in %s", interval)
+
+		select {
+		case <-time.After(interval):
+		case <-deadline:
+			state := UnknownScannerState
+			if last != nil {
+				state = last.State
+			}
+			return last, fmt.Errorf("%w: %s", ErrNotReady, state)
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+
+		interval = min(interval*3/2, WaitReadyMaxInterval)
+	}
+}
+
+// WaitIdle polls [ScannerStatus] until scanner becomes Idle,
+// or ctx is canceled. On success, it returns the last received
+// status.
+//
+// It is the shortcut for the [WaitReady] without timeout and
+// additional conditions. The timeout, if required, may be set
+// using ctx.
+func (c *Client) WaitIdle(ctx context.Context) (*ScannerStatus, error) {
+	return WaitReady(ctx, c, 0, WaitReadyOptions{})
+}
+
+// waitReadyCheck reports whether scanner is ready.
+func waitReadyCheck(status *ScannerStatus, options WaitReadyOptions) bool {
+	if status.State != ScannerIdle {
+		return false
+	}
+
+	if options.ADFLoaded && status.ADFState != nil {
+		switch *status.ADFState {
+		case ScannerAdfLoaded, ScannerAdfProcessing:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// waitReadySame reports whether two scanner statuses are the
+// same in the sense of the WaitReady backoff.
+func waitReadySame(s1, s2 *ScannerStatus) bool {
+	return s1.State == s2.State &&
+		optional.Get(s1.ADFState) == optional.Get(s2.ADFState) &&
+		len(s1.Jobs) == len(s2.Jobs)
+}
@@ -0,0 +1,165 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// eSCL core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Waiting for scanner readiness test
+
+package escl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/assert"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestWaitReady tests WaitReady
+func TestWaitReady(t *testing.T) {
+	xml, err := xmldoc.Decode(
+		NsMap,
+		bytes.NewReader(testutils.
+			Kyocera.ECOSYS.M2040dn.ESCL.ScannerCapabilities))
+	assert.NoError(err)
+
+	caps, err := DecodeScannerCapabilities(xml)
+	assert.NoError(err)
+
+	s := &abstract.VirtualScanner{
+		ScanCaps: caps.ToAbstract(),
+		Resolution: abstract.Resolution{
+			XResolution: 600,
+			YResolution: 600,
+		},
+		PlatenImage: testutils.Images.PNG5100x7016,
+	}
+
+	tr, loopback := transport.NewLoopback()
+	base := transport.MustParseURL("http://localhost/eSCL")
+	options := AbstractServerOptions{
+		Version:  caps.Version,
+		Scanner:  s,
+		BasePath: base.Path,
+	}
+
+	handler := NewAbstractServer(context.TODO(), options)
+	server := transport.NewServer(nil, handler)
+
+	go server.Serve(loopback)
+	defer server.Close()
+
+	clnt := NewClient(base, tr)
+
+	// Idle scanner is ready immediately
+	status, err := WaitReady(context.TODO(), clnt, time.Second,
+		WaitReadyOptions{})
+	if err != nil {
+		t.Fatalf("WaitReady (idle): %s", err)
+	}
+
+	if status.State != ScannerIdle {
+		t.Errorf("WaitReady (idle): state mismatch:\n"+
+			"expected: %s\n"+
+			"present:  %s\n",
+			ScannerIdle, status.State)
+	}
+
+	// ADF is empty, so with ADFLoaded scanner is not ready
+	_, err = WaitReady(context.TODO(), clnt, 100*time.Millisecond,
+		WaitReadyOptions{ADFLoaded: true})
+	if !errors.Is(err, ErrNotReady) {
+		t.Errorf("WaitReady (ADF empty): expected %q, present %v",
+			ErrNotReady, err)
+	}
+
+	// Start the job. Scanner is busy until job is finished.
+	rq := ScanSettings{
+		Version:     caps.Version,
+		InputSource: optional.New(InputPlaten),
+	}
+
+	job, _, err := clnt.Scan(context.TODO(), rq)
+	if err != nil {
+		t.Fatalf("Client.Scan: %s", err)
+	}
+
+	status, err = WaitReady(context.TODO(), clnt, 100*time.Millisecond,
+		WaitReadyOptions{})
+	if !errors.Is(err, ErrNotReady) {
+		t.Errorf("WaitReady (busy): expected %q, present %v",
+			ErrNotReady, err)
+	}
+
+	if status == nil || status.State != ScannerProcessing {
+		t.Errorf("WaitReady (busy): last status must be %s",
+			ScannerProcessing)
+	}
+
+	// Cancel the job a bit later. WaitReady must notice it.
+	go func() {
+		time.Sleep(2 * WaitReadyMinInterval)
+		clnt.Cancel(context.TODO(), job)
+	}()
+
+	status, err = WaitReady(context.TODO(), clnt, 5*time.Second,
+		WaitReadyOptions{})
+	if err != nil {
+		t.Fatalf("WaitReady (cancel): %s", err)
+	}
+
+	if status.State != ScannerIdle {
+		t.Errorf("WaitReady (cancel): state mismatch:\n"+
+			"expected: %s\n"+
+			"present:  %s\n",
+			ScannerIdle, status.State)
+	}
+
+	// Canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = WaitReady(ctx, clnt, 0, WaitReadyOptions{})
+	if err == nil {
+		t.Errorf("WaitReady (canceled ctx): error expected")
+	}
+
+	// Client.WaitIdle on busy scanner, limited by ctx
+	job, _, err = clnt.Scan(context.TODO(), rq)
+	if err != nil {
+		t.Fatalf("Client.Scan: %s", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(),
+		100*time.Millisecond)
+	defer cancel()
+
+	_, err = clnt.WaitIdle(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitIdle (busy): expected %q, present %v",
+			context.DeadlineExceeded, err)
+	}
+
+	// Client.WaitIdle after cancellation
+	clnt.Cancel(context.TODO(), job)
+
+	status, err = clnt.WaitIdle(context.TODO())
+	if err != nil {
+		t.Fatalf("WaitIdle (cancel): %s", err)
+	}
+
+	if status.State != ScannerIdle {
+		t.Errorf("WaitIdle (cancel): state mismatch:\n"+
+			"expected: %s\n"+
+			"present:  %s\n",
+			ScannerIdle, status.State)
+	}
+}
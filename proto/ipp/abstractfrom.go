@@ -0,0 +1,115 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conversions from abstract.Printer to IPP data structures
+
+package ipp
+
+import (
+	"slices"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+	"github.com/OpenPrinting/goipp"
+)
+
+// fromAbstractPrinterName is the printer-name, used when
+// abstract.PrinterCapabilities doesn't define MakeAndModel.
+const fromAbstractPrinterName = "Printer"
+
+// fromAbstractOperations lists operations, supported by the
+// printer, built on a top of the abstract.Printer.
+var fromAbstractOperations = []goipp.Op{
+	goipp.OpPrintJob,
+	goipp.OpCreateJob,
+	goipp.OpSendDocument,
+	goipp.OpCancelJob,
+	goipp.OpGetJobAttributes,
+	goipp.OpGetJobs,
+	goipp.OpGetPrinterAttributes,
+}
+
+// fromAbstractPrinterAttributes converts [abstract.PrinterCapabilities]
+// into the [PrinterAttributes].
+//
+// Only static attributes are filled. Printer state and URIs are
+// dynamic and left empty.
+func fromAbstractPrinterAttributes(
+	caps *abstract.PrinterCapabilities) *PrinterAttributes {
+
+	// application/octet-stream means auto-detection of the
+	// document format and is always supported.
+	formats := slices.Clone(caps.DocumentFormats)
+	if !slices.Contains(formats, abstract.MIMETypeOctetStream) {
+		formats = append(formats, abstract.MIMETypeOctetStream)
+	}
+
+	desc := PrinterDescription{
+		CharsetConfigured:                 DefaultCharset,
+		CharsetSupported:                  []string{DefaultCharset},
+		ColorSupported:                    caps.Color,
+		CompressionSupported:              []KwCompression{KwCompressionNone},
+		DocumentFormatDefault:             abstract.MIMETypeOctetStream,
+		DocumentFormatSupported:           formats,
+		GeneratedNaturalLanguageSupported: []string{DefaultNaturalLanguage},
+		IppVersionsSupported: []goipp.Version{
+			goipp.MakeVersion(1, 0),
+			goipp.MakeVersion(1, 1),
+			goipp.MakeVersion(2, 0),
+		},
+		MultipleDocumentJobsSupported: true,
+		NaturalLanguageConfigured:     DefaultNaturalLanguage,
+		OperationsSupported:           slices.Clone(fromAbstractOperations),
+		PdlOverrideSupported:          KwPdlOverrideNotAttempted,
+		PrinterIsAcceptingJobs:        true,
+		PrinterMakeAndModel:           caps.MakeAndModel,
+		PrinterMoreInfo:               caps.AdminURI,
+		PrinterName:                   caps.MakeAndModel,
+	}
+
+	if desc.PrinterName == "" {
+		desc.PrinterName = fromAbstractPrinterName
+	}
+
+	if caps.UUID != (uuid.UUID{}) {
+		desc.PrinterUUID = caps.UUID.URN()
+	}
+
+	if caps.IconURI != "" {
+		desc.PrinterIcons = []string{caps.IconURI}
+	}
+
+	sides := []KwSides{KwSidesOneSided}
+	if caps.Duplex {
+		sides = append(sides, KwSidesTwoSidedLongEdge,
+			KwSidesTwoSidedShortEdge)
+	}
+
+	media := make([]KwMedia, len(caps.MediaSizes))
+	for i, name := range caps.MediaSizes {
+		media[i] = KwMedia(name)
+	}
+
+	tmpl := JobTemplate{
+		CopiesDefault: 1,
+		CopiesSupported: goipp.Range{
+			Lower: 1,
+			Upper: max(caps.MaxCopies, 1),
+		},
+		MediaSupported: media,
+		SidesDefault:   KwSidesOneSided,
+		SidesSupported: sides,
+	}
+
+	if len(media) != 0 {
+		tmpl.MediaDefault = media[0]
+	}
+
+	return &PrinterAttributes{
+		PrinterDescription: desc,
+		JobTemplate:        tmpl,
+	}
+}
@@ -0,0 +1,547 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// IPP server on a top of abstract.Printer
+
+package ipp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/printjobs"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/goipp"
+)
+
+// AbstractServer parameters:
+const (
+	// AbstractServerJobTimeout is the inactivity timeout of the
+	// print job. Abandoned job, that doesn't receive documents
+	// for this time, is aborted.
+	AbstractServerJobTimeout = printjobs.Timeout
+)
+
+// AbstractServer implements IPP server on a top of [abstract.Printer].
+//
+// It serves the Print-Job, Create-Job, Send-Document, Cancel-Job,
+// Get-Job-Attributes, Get-Jobs and Get-Printer-Attributes requests.
+// Printer attributes are generated from the
+// [abstract.PrinterCapabilities] and returned as a whole, regardless
+// of requested-attributes. Document data follows the IPP message
+// in the request body and is streamed to the printer as it arrives.
+//
+// Printer prints one document at a time. The job is completed,
+// when its last document is printed.
+type AbstractServer struct {
+	ctx     context.Context                  // Logging context
+	options AbstractServerOptions            // Server options
+	caps    *abstract.PrinterCapabilities    // Printer capabilities
+	attrs   *PrinterAttributes               // Static printer attributes
+	started time.Time                        // Server start time
+	jobs    *printjobs.Jobs[abstractJobData] // Print jobs
+}
+
+// AbstractServerOptions represents the [AbstractServer] creation
+// options.
+type AbstractServerOptions struct {
+	Printer abstract.Printer // Underlying abstract.Printer
+
+	// PrinterInfo and PrinterLocation, if set, are reported as
+	// printer-info and printer-location. The printer-name comes
+	// from the [abstract.PrinterCapabilities].
+	PrinterInfo     string
+	PrinterLocation string
+}
+
+// abstractJobData is the IPP-specific data of the print job.
+type abstractJobData struct {
+	printerURI string // Printer URI the job was created with
+}
+
+// abstractServerJob represents the print job.
+type abstractServerJob = printjobs.Job[abstractJobData]
+
+// NewAbstractServer returns a new [AbstractServer].
+func NewAbstractServer(ctx context.Context,
+	options AbstractServerOptions) *AbstractServer {
+
+	caps := options.Printer.Capabilities()
+
+	srv := &AbstractServer{
+		ctx:     ctx,
+		options: options,
+		caps:    caps,
+		attrs:   fromAbstractPrinterAttributes(caps),
+		started: time.Now(),
+		jobs:    printjobs.New[abstractJobData](ctx, "IPP", options.Printer),
+	}
+
+	srv.attrs.PrinterInfo = options.PrinterInfo
+	srv.attrs.PrinterLocation = options.PrinterLocation
+
+	return srv
+}
+
+// Close closes the AbstractServer and cancels all active jobs.
+func (srv *AbstractServer) Close() {
+	srv.jobs.Close()
+}
+
+// ServeHTTP serves incoming HTTP requests.
+// It implements the [http.Handler] interface.
+func (srv *AbstractServer) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	// Check HTTP parameters
+	if rq.Method != "POST" {
+		httpError(w, ErrHTTPMethodNotAllowed)
+		return
+	}
+
+	ctype := rq.Header.Get("Content-Type")
+	mediatype, _, _ := mime.ParseMediaType(ctype)
+	if mediatype != goipp.ContentType {
+		err := NewErrHTTP(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("unsupported media type: %q", ctype))
+		httpError(w, err)
+		return
+	}
+
+	// Decode IPP message. Document data, if any, remains
+	// in the request body.
+	msg := &goipp.Message{}
+	err := msg.Decode(rq.Body)
+	if err != nil {
+		httpError(w, NewErrHTTP(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	op := goipp.Op(msg.Code)
+	log.Debug(srv.ctx, "IPP: %s received", op)
+
+	// Handle the request
+	var rsp Response
+	switch {
+	case msg.RequestID == 0:
+		err = NewErrIPP(msg, goipp.StatusErrorBadRequest,
+			fmt.Sprintf("bad request ID %d", msg.RequestID))
+
+	case msg.Version < goipp.MakeVersion(1, 0) ||
+		msg.Version > goipp.DefaultVersion:
+		err = NewErrIPP(msg, goipp.StatusErrorVersionNotSupported,
+			fmt.Sprintf("bad request version %s", msg.Version))
+
+	case op == goipp.OpPrintJob:
+		rsp, err = srv.printJob(msg, rq.Body)
+	case op == goipp.OpCreateJob:
+		rsp, err = srv.createJob(msg)
+	case op == goipp.OpSendDocument:
+		rsp, err = srv.sendDocument(msg, rq.Body)
+	case op == goipp.OpCancelJob:
+		rsp, err = srv.cancelJob(msg)
+	case op == goipp.OpGetJobAttributes:
+		rsp, err = srv.getJobAttributes(msg)
+	case op == goipp.OpGetJobs:
+		rsp, err = srv.getJobs(msg)
+	case op == goipp.OpGetPrinterAttributes:
+		rsp, err = srv.getPrinterAttributes(msg)
+
+	default:
+		err = NewErrIPP(msg, goipp.StatusErrorOperationNotSupported,
+			fmt.Sprintf("unsupported operation %s", op))
+	}
+
+	if err != nil {
+		log.Debug(srv.ctx, "IPP: %s: %s", op, err)
+		httpError(w, err)
+		return
+	}
+
+	// Send response
+	w.Header().Set("Content-Type", goipp.ContentType)
+	w.WriteHeader(http.StatusOK)
+
+	rsp.Encode().Encode(w)
+}
+
+// printJob handles the Print-Job request.
+func (srv *AbstractServer) printJob(msg *goipp.Message,
+	body io.Reader) (Response, error) {
+
+	ipprq := &PrintJobRequest{}
+	err := ipprq.Decode(msg)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorBadRequest,
+			err.Error())
+	}
+
+	// Check the document format before the job is created,
+	// so the rejected request doesn't leave a pending job.
+	err = srv.checkDocumentFormat(msg, ipprq.DocumentFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := srv.createJobWith(msg, ipprq.PrinterURI,
+		ipprq.JobName, ipprq.RequestingUserName, ipprq.Job)
+	if err != nil {
+		return nil, err
+	}
+
+	err = srv.printDocument(msg, job, ipprq.DocumentFormat, body, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &PrintJobResponse{
+		ResponseHeader: srv.header(msg),
+		Job:            srv.jobStatus(job),
+	}
+
+	return rsp, nil
+}
+
+// createJob handles the Create-Job request.
+func (srv *AbstractServer) createJob(msg *goipp.Message) (Response, error) {
+	ipprq := &CreateJobRequest{}
+	err := ipprq.Decode(msg)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorBadRequest,
+			err.Error())
+	}
+
+	job, err := srv.createJobWith(msg, ipprq.PrinterURI,
+		ipprq.JobName, ipprq.RequestingUserName, ipprq.Job)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &CreateJobResponse{
+		ResponseHeader: srv.header(msg),
+		Job:            srv.jobStatus(job),
+	}
+
+	return rsp, nil
+}
+
+// sendDocument handles the Send-Document request.
+func (srv *AbstractServer) sendDocument(msg *goipp.Message,
+	body io.Reader) (Response, error) {
+
+	ipprq := &SendDocumentRequest{}
+	err := ipprq.Decode(msg)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorBadRequest,
+			err.Error())
+	}
+
+	job, err := srv.lookupJob(msg, ipprq.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = srv.printDocument(msg, job, ipprq.DocumentFormat, body,
+		ipprq.LastDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &SendDocumentResponse{
+		ResponseHeader: srv.header(msg),
+		Job:            srv.jobStatus(job),
+	}
+
+	return rsp, nil
+}
+
+// cancelJob handles the Cancel-Job request.
+func (srv *AbstractServer) cancelJob(msg *goipp.Message) (Response, error) {
+	ipprq := &CancelJobRequest{}
+	err := ipprq.Decode(msg)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorBadRequest,
+			err.Error())
+	}
+
+	job, err := srv.lookupJob(msg, ipprq.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = srv.jobs.Cancel(job)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorNotPossible,
+			err.Error())
+	}
+
+	rsp := &CancelJobResponse{
+		ResponseHeader: srv.header(msg),
+	}
+
+	return rsp, nil
+}
+
+// getJobAttributes handles the Get-Job-Attributes request.
+func (srv *AbstractServer) getJobAttributes(msg *goipp.Message) (
+	Response, error) {
+
+	ipprq := &GetJobAttributesRequest{}
+	err := ipprq.Decode(msg)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorBadRequest,
+			err.Error())
+	}
+
+	job, err := srv.lookupJob(msg, ipprq.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &GetJobAttributesResponse{
+		ResponseHeader: srv.header(msg),
+		Job:            srv.jobStatus(job),
+	}
+
+	return rsp, nil
+}
+
+// getJobs handles the Get-Jobs request.
+func (srv *AbstractServer) getJobs(msg *goipp.Message) (Response, error) {
+	ipprq := &GetJobsRequest{}
+	err := ipprq.Decode(msg)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorBadRequest,
+			err.Error())
+	}
+
+	var match func(*JobStatus) bool
+	switch ipprq.WhichJobs {
+	case "", KwWhichJobsNotCompleted:
+		match = func(js *JobStatus) bool { return !js.IsTerminated() }
+	case KwWhichJobsCompleted:
+		match = func(js *JobStatus) bool { return js.IsTerminated() }
+	case KwWhichJobsAll:
+		match = func(*JobStatus) bool { return true }
+	default:
+		return nil, NewErrIPP(msg, goipp.StatusErrorAttributesOrValues,
+			fmt.Sprintf("which-jobs %q not supported",
+				ipprq.WhichJobs))
+	}
+
+	rsp := &GetJobsResponse{
+		ResponseHeader: srv.header(msg),
+	}
+
+	for _, job := range srv.jobs.List() {
+		if ipprq.Limit > 0 && len(rsp.Jobs) == ipprq.Limit {
+			break
+		}
+
+		status := srv.jobStatus(job)
+		if match(status) {
+			rsp.Jobs = append(rsp.Jobs, status)
+		}
+	}
+
+	return rsp, nil
+}
+
+// getPrinterAttributes handles the Get-Printer-Attributes request.
+func (srv *AbstractServer) getPrinterAttributes(msg *goipp.Message) (
+	Response, error) {
+
+	ipprq := &GetPrinterAttributesRequest{}
+	err := ipprq.Decode(msg)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorBadRequest,
+			err.Error())
+	}
+
+	attrs := *srv.attrs
+	attrs.PrinterURISupported = []string{ipprq.PrinterURI}
+	attrs.URIAuthenticationSupported = []KwURIAuthentication{
+		KwURIAuthenticationNone,
+	}
+	attrs.URISecuritySupported = []KwURISecurity{KwURISecurityNone}
+	attrs.PrinterStateReasons = []KwPrinterStateReasons{
+		KwPrinterStateNone,
+	}
+	attrs.PrinterUpTime = srv.upTime()
+
+	for _, job := range srv.jobs.List() {
+		if !job.Status().State.IsFinal() {
+			attrs.QueuedJobCount++
+		}
+	}
+
+	attrs.PrinterState = PrinterStateIdle
+	if attrs.QueuedJobCount != 0 {
+		attrs.PrinterState = PrinterStateProcessing
+	}
+
+	rsp := &GetPrinterAttributesResponse{
+		ResponseHeader: srv.header(msg),
+		Printer:        &attrs,
+	}
+
+	return rsp, nil
+}
+
+// createJobWith creates a new job with the specified parameters.
+// It is the common part of the Print-Job and Create-Job requests.
+func (srv *AbstractServer) createJobWith(msg *goipp.Message,
+	printerURI, name, user string,
+	attrs *JobAttributes) (*abstractServerJob, error) {
+
+	var req abstract.PrinterRequest
+	if attrs != nil {
+		req = attrs.ToAbstract()
+	}
+
+	req.JobName = name
+	req.UserName = user
+
+	err := req.Validate(srv.caps)
+	if err != nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorAttributesOrValues,
+			err.Error())
+	}
+
+	job := srv.jobs.Create(req, abstractJobData{printerURI: printerURI})
+
+	return job, nil
+}
+
+// printDocument prints the document and updates the job status.
+// It is the common part of the Print-Job and Send-Document requests.
+func (srv *AbstractServer) printDocument(msg *goipp.Message,
+	job *abstractServerJob, format string, body io.Reader,
+	last bool) error {
+
+	err := srv.checkDocumentFormat(msg, format)
+	if err != nil {
+		return err
+	}
+
+	err = srv.jobs.Print(job, format, body, last)
+	switch {
+	case errors.Is(err, printjobs.ErrNotAccepting):
+		return NewErrIPP(msg, goipp.StatusErrorNotPossible, err.Error())
+	case err != nil:
+		return NewErrIPP(msg, goipp.StatusErrorInternal, err.Error())
+	}
+
+	return nil
+}
+
+// checkDocumentFormat checks that document format is supported
+// by the printer. Empty format means application/octet-stream.
+func (srv *AbstractServer) checkDocumentFormat(msg *goipp.Message,
+	format string) error {
+
+	req := abstract.PrinterRequest{DocumentFormat: format}
+	err := req.Validate(srv.caps)
+	if err != nil {
+		return NewErrIPP(msg, goipp.StatusErrorDocumentFormatNotSupported,
+			err.Error())
+	}
+
+	return nil
+}
+
+// lookupJob returns the job by its job-id.
+func (srv *AbstractServer) lookupJob(msg *goipp.Message,
+	jobID int) (*abstractServerJob, error) {
+
+	job := srv.jobs.Lookup(jobID)
+	if job == nil {
+		return nil, NewErrIPP(msg, goipp.StatusErrorNotFound,
+			fmt.Sprintf("job %d not found", jobID))
+	}
+
+	return job, nil
+}
+
+// jobStatus returns the IPP status of the job.
+func (srv *AbstractServer) jobStatus(job *abstractServerJob) *JobStatus {
+	st := job.Status()
+
+	status := &JobStatus{
+		JobID:                  st.ID,
+		JobURI:                 fmt.Sprintf("%s/%d", job.Data.printerURI, st.ID),
+		JobPrinterURI:          job.Data.printerURI,
+		JobName:                job.Request.JobName,
+		JobOriginatingUserName: job.Request.UserName,
+		JobKOctetsProcessed:    int((st.Octets + 1023) / 1024),
+		NumberOfDocuments:      st.Documents,
+		JobPrinterUpTime:       srv.upTime(),
+		TimeAtCreation:         srv.upTimeAt(st.Created),
+		TimeAtProcessing:       srv.upTimeAt(st.Processing),
+		TimeAtCompleted:        srv.upTimeAt(st.Finished),
+	}
+
+	switch st.State {
+	case printjobs.Pending:
+		status.JobState = JobStatePending
+	case printjobs.Processing:
+		status.JobState = JobStateProcessing
+	case printjobs.Completed:
+		status.JobState = JobStateCompleted
+	case printjobs.Canceled:
+		status.JobState = JobStateCanceled
+	case printjobs.Aborted:
+		status.JobState = JobStateAborted
+	}
+
+	reason := KwJobStateReasonsNone
+	switch st.Reason {
+	case printjobs.ReasonPrinting:
+		reason = KwJobStateReasonsJobPrinting
+	case printjobs.ReasonIncoming:
+		reason = KwJobStateReasonsJobIncoming
+	case printjobs.ReasonCompletedSuccessfully:
+		reason = KwJobStateReasonsJobCompletedSuccessfully
+	case printjobs.ReasonCompletedWithErrors:
+		reason = KwJobStateReasonsJobCompletedWithErrors
+	case printjobs.ReasonCanceledByUser:
+		reason = KwJobStateReasonsJobCanceledByUser
+	case printjobs.ReasonCanceledAtDevice:
+		reason = KwJobStateReasonsJobCanceledAtDevice
+	case printjobs.ReasonTimedOut:
+		reason = KwJobStateReasonsAbortedBySystem
+	}
+
+	status.JobStateReasons = []KwJobStateReasons{reason}
+
+	return status
+}
+
+// header returns the successful ResponseHeader for the request.
+func (srv *AbstractServer) header(msg *goipp.Message) ResponseHeader {
+	hdr := DefaultResponseHeader
+	hdr.Version = msg.Version
+	hdr.RequestID = msg.RequestID
+	return hdr
+}
+
+// upTime returns the printer-up-time value.
+func (srv *AbstractServer) upTime() int {
+	return srv.upTimeAt(time.Now())
+}
+
+// upTimeAt returns the printer-up-time value at the specified time.
+// For zero time it returns 0, which means "not set".
+func (srv *AbstractServer) upTimeAt(t time.Time) int {
+	if t.IsZero() {
+		return 0
+	}
+	return int(t.Sub(srv.started)/time.Second) + 1
+}
@@ -0,0 +1,261 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AbstractServer test
+
+package ipp
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/printjobs"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// TestAbstractServer tests AbstractServer against the Client.
+func TestAbstractServer(t *testing.T) {
+	type printed struct {
+		req  abstract.PrinterRequest
+		data string
+	}
+
+	var output []printed
+
+	printer := &abstract.VirtualPrinter{
+		PrintCaps: &abstract.PrinterCapabilities{
+			MakeAndModel: "Test Printer",
+			DocumentFormats: []string{
+				abstract.MIMETypePDF,
+				abstract.MIMETypeJPEG,
+			},
+			MediaSizes: []string{"iso_a4_210x297mm"},
+			Color:      true,
+			Duplex:     true,
+			MaxCopies:  10,
+		},
+		Output: func(req abstract.PrinterRequest,
+			file abstract.DocumentFile) error {
+			data, err := io.ReadAll(file)
+			output = append(output, printed{req, string(data)})
+			return err
+		},
+	}
+
+	srv := NewAbstractServer(context.Background(), AbstractServerOptions{
+		Printer:         printer,
+		PrinterLocation: "Office",
+	})
+	defer srv.Close()
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, srv)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	ctx := context.Background()
+	clnt := NewClient(transport.MustParseURL(
+		"ipp://localhost/ipp/print"), tr)
+
+	// Check printer attributes
+	attrs, err := clnt.GetPrinterAttributes(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetPrinterAttributes: %s", err)
+	}
+
+	if attrs.PrinterName != "Test Printer" ||
+		attrs.PrinterLocation != "Office" || !attrs.ColorSupported ||
+		attrs.PrinterState != PrinterStateIdle {
+		t.Errorf("PrinterDescription: %+v", attrs.PrinterDescription)
+	}
+
+	expFormats := []string{
+		abstract.MIMETypePDF,
+		abstract.MIMETypeJPEG,
+		abstract.MIMETypeOctetStream,
+	}
+
+	if !reflect.DeepEqual(attrs.DocumentFormatSupported, expFormats) {
+		t.Errorf("document-format-supported:\nexpected: %v\npresent:  %v",
+			expFormats, attrs.DocumentFormatSupported)
+	}
+
+	expSides := []KwSides{
+		KwSidesOneSided,
+		KwSidesTwoSidedLongEdge,
+		KwSidesTwoSidedShortEdge,
+	}
+
+	if !reflect.DeepEqual(attrs.SidesSupported, expSides) {
+		t.Errorf("sides-supported:\nexpected: %v\npresent:  %v",
+			expSides, attrs.SidesSupported)
+	}
+
+	if attrs.CopiesSupported != (goipp.Range{Lower: 1, Upper: 10}) {
+		t.Errorf("copies-supported: %v", attrs.CopiesSupported)
+	}
+
+	// Print two-document job
+	job := &JobAttributes{
+		Copies: 2,
+		Media:  "iso_a4_210x297mm",
+		Sides:  KwSidesTwoSidedLongEdge,
+	}
+
+	docs := []Document{
+		{"a.pdf", abstract.MIMETypePDF, strings.NewReader("%PDF-A")},
+		{"b.jpg", abstract.MIMETypeJPEG, strings.NewReader("JPEG-B")},
+	}
+
+	status, err := clnt.SubmitJob(ctx, "test", job, docs)
+	if err != nil {
+		t.Fatalf("SubmitJob: %s", err)
+	}
+
+	if status.JobState != JobStateCompleted ||
+		status.NumberOfDocuments != 2 {
+		t.Errorf("JobStatus: %+v", status)
+	}
+
+	if len(output) != 2 {
+		t.Fatalf("Print: %d documents printed, expected 2",
+			len(output))
+	}
+
+	expReq := abstract.PrinterRequest{
+		JobName:        "test",
+		UserName:       jobRequestingUserName(),
+		DocumentFormat: abstract.MIMETypeJPEG,
+		MediaSize:      "iso_a4_210x297mm",
+		Copies:         2,
+		Duplex:         true,
+	}
+
+	if output[1].req != expReq {
+		t.Errorf("PrinterRequest:\nexpected: %+v\npresent:  %+v",
+			expReq, output[1].req)
+	}
+
+	if output[0].data != "%PDF-A" || output[1].data != "JPEG-B" {
+		t.Errorf("Print: data mismatch: %q, %q",
+			output[0].data, output[1].data)
+	}
+
+	// Print-Job with the default document format
+	status, err = clnt.PrintJob(ctx, "", nil,
+		Document{Body: strings.NewReader("DATA")})
+	if err != nil {
+		t.Fatalf("PrintJob: %s", err)
+	}
+
+	if status.JobState != JobStateCompleted ||
+		output[2].req.DocumentFormat != abstract.MIMETypeOctetStream ||
+		output[2].data != "DATA" {
+		t.Errorf("PrintJob: %+v, %+v", status, output[2])
+	}
+
+	// Unsupported parameters
+	_, err = clnt.CreateJob(ctx, "", &JobAttributes{Copies: 11})
+	testCheckStatus(t, "CreateJob", err,
+		goipp.StatusErrorAttributesOrValues)
+
+	status, err = clnt.CreateJob(ctx, "", nil)
+	if err != nil {
+		t.Fatalf("CreateJob: %s", err)
+	}
+
+	jobID := status.JobID
+
+	_, err = clnt.SendDocument(ctx, jobID,
+		Document{"a.png", abstract.MIMETypePNG,
+			strings.NewReader("PNG")}, true)
+	testCheckStatus(t, "SendDocument", err,
+		goipp.StatusErrorDocumentFormatNotSupported)
+
+	// Rejected Print-Job must not leave a job behind
+	_, err = clnt.PrintJob(ctx, "", nil,
+		Document{"a.png", abstract.MIMETypePNG,
+			strings.NewReader("PNG")})
+	testCheckStatus(t, "PrintJob", err,
+		goipp.StatusErrorDocumentFormatNotSupported)
+
+	// Active jobs
+	jobs, err := clnt.GetJobs(ctx, "", nil)
+	if err != nil {
+		t.Fatalf("GetJobs: %s", err)
+	}
+
+	if len(jobs) != 1 || jobs[0].JobID != jobID {
+		t.Errorf("GetJobs: %+v", jobs)
+	}
+
+	// Cancel the job
+	err = clnt.CancelJob(ctx, jobID)
+	if err != nil {
+		t.Fatalf("CancelJob: %s", err)
+	}
+
+	status, err = clnt.GetJobAttributes(ctx, jobID, nil)
+	if err != nil {
+		t.Fatalf("GetJobAttributes: %s", err)
+	}
+
+	if status.JobState != JobStateCanceled {
+		t.Errorf("job-state: expected %d, present %d",
+			JobStateCanceled, status.JobState)
+	}
+
+	_, err = clnt.SendDocument(ctx, jobID,
+		Document{"a.pdf", abstract.MIMETypePDF,
+			strings.NewReader("%PDF")}, true)
+	testCheckStatus(t, "SendDocument", err, goipp.StatusErrorNotPossible)
+
+	jobs, err = clnt.GetJobs(ctx, KwWhichJobsCompleted, nil)
+	if err != nil {
+		t.Fatalf("GetJobs: %s", err)
+	}
+
+	if len(jobs) != 3 {
+		t.Errorf("GetJobs: %d completed jobs, expected 3", len(jobs))
+	}
+
+	// Unknown job
+	_, err = clnt.GetJobAttributes(ctx, 12345, nil)
+	testCheckStatus(t, "GetJobAttributes", err, goipp.StatusErrorNotFound)
+
+	// Finished jobs history
+	for i := 0; i < printjobs.History; i++ {
+		_, err = clnt.PrintJob(ctx, "", nil,
+			Document{Body: strings.NewReader("DATA")})
+		if err != nil {
+			t.Fatalf("PrintJob: %s", err)
+		}
+	}
+
+	jobs, err = clnt.GetJobs(ctx, KwWhichJobsCompleted, nil)
+	if err != nil {
+		t.Fatalf("GetJobs: %s", err)
+	}
+
+	if len(jobs) != printjobs.History {
+		t.Errorf("GetJobs: %d completed jobs, expected %d",
+			len(jobs), printjobs.History)
+	}
+}
+
+// testCheckStatus checks that err reports the expected IPP status.
+func testCheckStatus(t *testing.T, op string, err error,
+	status goipp.Status) {
+
+	if err == nil || !strings.Contains(err.Error(), status.String()) {
+		t.Errorf("%s: expected %s, present %v", op, status, err)
+	}
+}
@@ -0,0 +1,39 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conversions from IPP data structures to abstract.Printer
+
+package ipp
+
+import (
+	"github.com/OpenPrinting/go-mfp/abstract"
+)
+
+// ToAbstract converts [JobAttributes] into the [abstract.PrinterRequest].
+//
+// JobName, UserName and DocumentFormat come with the operation
+// attributes, not with the Job Template attributes, and must be
+// set by the caller.
+//
+// The media is taken from the "media" attribute or, if missed,
+// from the "media-col" media-size-name.
+func (job *JobAttributes) ToAbstract() abstract.PrinterRequest {
+	req := abstract.PrinterRequest{
+		MediaSize: string(job.Media),
+		Copies:    job.Copies,
+	}
+
+	if req.MediaSize == "" {
+		req.MediaSize = job.MediaCol.MediaSizeName
+	}
+
+	switch job.Sides {
+	case KwSidesTwoSidedLongEdge, KwSidesTwoSidedShortEdge:
+		req.Duplex = true
+	}
+
+	return req
+}
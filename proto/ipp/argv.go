@@ -0,0 +1,45 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Argv helpers for IPP
+
+package ipp
+
+import (
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/argv"
+)
+
+// ArgvPrinterAttrsCompleter is the [argv.Completer] for the
+// comma-separated list of the printer attributes names, as used
+// by the --attrs option of various commands.
+//
+// It completes the last name in the list, choosing from the
+// attributes, known to the [PrinterAttributes].
+func ArgvPrinterAttrsCompleter(arg string) (compl []argv.Completion) {
+	infos := ((*PrinterAttributes)(nil)).KnownAttrs()
+
+	attrName := arg
+	prefix := ""
+
+	if i := strings.LastIndex(attrName, ","); i >= 0 {
+		attrName = arg[i+1:]
+		prefix = arg[:i+1]
+	}
+
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name, attrName) {
+			c := argv.Completion{
+				String:  prefix + info.Name + ",",
+				NoSpace: true,
+			}
+			compl = append(compl, c)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,305 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Cache of immutable printer attributes
+
+package ipp
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// DefaultPrinterAttrsCacheTTL is the default time to live of
+// the [PrinterAttrsCache] entries.
+const DefaultPrinterAttrsCacheTTL = 5 * time.Minute
+
+// printerAttrsImmutable lists printer attributes that, in addition
+// to the "xxx-supported" attributes, considered to be immutable
+// (or, at least, rarely changed) and can be cached.
+var printerAttrsImmutable = map[string]struct{}{
+	"charset-configured":              {},
+	"device-uuid":                     {},
+	"document-format-default":         {},
+	"media-col-database":              {},
+	"natural-language-configured":     {},
+	"printer-device-id":               {},
+	"printer-dns-sd-name":             {},
+	"printer-firmware-name":           {},
+	"printer-firmware-string-version": {},
+	"printer-firmware-version":        {},
+	"printer-icons":                   {},
+	"printer-info":                    {},
+	"printer-kind":                    {},
+	"printer-location":                {},
+	"printer-make-and-model":          {},
+	"printer-more-info":               {},
+	"printer-more-info-manufacturer":  {},
+	"printer-name":                    {},
+	"printer-uuid":                    {},
+}
+
+// printerAttrsCacheKeys are the attributes, that identify the
+// printer (printer-uuid) and its configuration version
+// (printer-config-change-time). When the cache is used, they
+// are requested in addition to the requested attributes.
+var printerAttrsCacheKeys = []string{
+	"printer-uuid",
+	"printer-config-change-time",
+}
+
+// PrinterAttrIsImmutable reports if printer attribute is considered
+// immutable, hence can be cached by the [PrinterAttrsCache].
+//
+// These are attributes that describe the printer (like make and
+// model or UUID) and all "xxx-supported" attributes.
+func PrinterAttrIsImmutable(name string) bool {
+	if _, found := printerAttrsImmutable[name]; found {
+		return true
+	}
+
+	return strings.HasSuffix(name, "-supported")
+}
+
+// PrinterAttrsCache caches immutable printer attributes (see
+// [PrinterAttrIsImmutable]), keyed by the printer UUID.
+//
+// It allows [Client.GetPrinterAttributes] to request only
+// volatile attributes from the printer, if immutable attributes
+// are already known.
+//
+// Cache entries expire after TTL. They also invalidated, if
+// printer reports changed "printer-config-change-time", or
+// explicitly by the [PrinterAttrsCache.Invalidate] call. The
+// later is intended to be used when printer state-change event
+// is received.
+//
+// PrinterAttrsCache is safe for concurrent use and may be shared
+// between multiple [Client]s.
+type PrinterAttrsCache struct {
+	ttl     time.Duration                     // Entries TTL
+	lock    sync.Mutex                        // Access lock
+	uuids   map[string]string                 // Printer URI->UUID
+	entries map[string]*printerAttrsCacheEntr // Entries by UUID
+}
+
+// printerAttrsCacheEntr represents a single PrinterAttrsCache entry
+type printerAttrsCacheEntr struct {
+	attrs      goipp.Attributes // Cached attributes
+	changeTime goipp.Values     // printer-config-change-time, if known
+	expires    time.Time        // Entry expiration time
+}
+
+// NewPrinterAttrsCache creates a new PrinterAttrsCache.
+//
+// If ttl is 0, DefaultPrinterAttrsCacheTTL is used.
+func NewPrinterAttrsCache(ttl time.Duration) *PrinterAttrsCache {
+	if ttl == 0 {
+		ttl = DefaultPrinterAttrsCacheTTL
+	}
+
+	return &PrinterAttrsCache{
+		ttl:     ttl,
+		uuids:   make(map[string]string),
+		entries: make(map[string]*printerAttrsCacheEntr),
+	}
+}
+
+// Get returns cached attributes of the printer with the specified UUID.
+// If printer is not known or its entry has expired, it returns nil.
+func (cache *PrinterAttrsCache) Get(uuid string) goipp.Attributes {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	ent := cache.lookup(uuid)
+	if ent == nil {
+		return nil
+	}
+
+	return ent.attrs.Clone()
+}
+
+// Invalidate drops cached attributes of the printer with the
+// specified UUID.
+//
+// It should be called when printer state-change event is received
+// for the printer.
+func (cache *PrinterAttrsCache) Invalidate(uuid string) {
+	cache.lock.Lock()
+	delete(cache.entries, uuid)
+	cache.lock.Unlock()
+}
+
+// Purge drops all cached attributes.
+func (cache *PrinterAttrsCache) Purge() {
+	cache.lock.Lock()
+	clear(cache.entries)
+	clear(cache.uuids)
+	cache.lock.Unlock()
+}
+
+// getByURI returns cached attributes of the printer with the
+// specified printer-uri, or nil if printer is not known.
+func (cache *PrinterAttrsCache) getByURI(uri string) goipp.Attributes {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	ent := cache.lookup(cache.uuids[uri])
+	if ent == nil {
+		return nil
+	}
+
+	return ent.attrs.Clone()
+}
+
+// lookup returns not expired cache entry by UUID.
+// Must be called under the lock.
+func (cache *PrinterAttrsCache) lookup(uuid string) *printerAttrsCacheEntr {
+	ent := cache.entries[uuid]
+	if ent != nil && time.Now().After(ent.expires) {
+		delete(cache.entries, uuid)
+		ent = nil
+	}
+
+	return ent
+}
+
+// update updates cache with the attributes, received from the printer
+// with the specified printer-uri.
+//
+// It returns attrs, merged with cached attributes of the same printer.
+// If the cache entry was missed, expired or invalidated by the changed
+// printer-config-change-time, nothing is merged and hit is false.
+// In this case, if attrs were received in response to the trimmed
+// request, they are incomplete and request must be repeated.
+func (cache *PrinterAttrsCache) update(uri string,
+	attrs goipp.Attributes) (merged goipp.Attributes, hit bool) {
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	// Obtain printer UUID. If it is not known, we can't cache.
+	var changeTime goipp.Values
+	uuid := cache.uuids[uri]
+
+	for _, attr := range attrs {
+		switch attr.Name {
+		case "printer-uuid":
+			if len(attr.Values) != 0 {
+				uuid = attr.Values[0].V.String()
+			}
+		case "printer-config-change-time":
+			changeTime = attr.Values
+		}
+	}
+
+	if uuid == "" {
+		return attrs, false
+	}
+
+	cache.uuids[uri] = uuid
+
+	// Drop stale entry, if printer configuration has changed
+	ent := cache.lookup(uuid)
+	if ent != nil && changeTime != nil && ent.changeTime != nil &&
+		!changeTime.Equal(ent.changeTime) {
+		ent = nil
+	}
+
+	hit = ent != nil
+	if !hit {
+		ent = &printerAttrsCacheEntr{
+			expires: time.Now().Add(cache.ttl),
+		}
+		cache.entries[uuid] = ent
+	}
+
+	if changeTime != nil {
+		ent.changeTime = changeTime
+	}
+
+	// Update the entry
+	received := make(map[string]struct{}, len(attrs))
+	for _, attr := range attrs {
+		received[attr.Name] = struct{}{}
+	}
+
+	var cached goipp.Attributes
+	for _, attr := range ent.attrs {
+		if _, found := received[attr.Name]; !found {
+			cached.Add(attr)
+		}
+	}
+
+	merged = attrs.Clone()
+	merged = append(merged, cached...)
+
+	for _, attr := range attrs {
+		if PrinterAttrIsImmutable(attr.Name) {
+			cached.Add(attr)
+		}
+	}
+
+	ent.attrs = cached
+
+	return merged, hit
+}
+
+// fullRequest returns the list of requested attributes, extended
+// with the printerAttrsCacheKeys, so the received attributes can
+// be cached. The empty list, that implies "all", is returned
+// unchanged.
+func (cache *PrinterAttrsCache) fullRequest(requested []string) []string {
+	if len(requested) == 0 {
+		return requested
+	}
+
+	full := slices.Clone(printerAttrsCacheKeys)
+	for _, name := range requested {
+		if !slices.Contains(full, name) {
+			full = append(full, name)
+		}
+	}
+
+	return full
+}
+
+// trimRequest trims the list of requested attributes, excluding
+// attributes available from the cached set.
+//
+// Group names ("all", "printer-description" and "job-template")
+// are passed to the printer as is, so vendor-specific attributes,
+// unknown to the [PrinterAttributes], are not lost. The empty list,
+// that implies "all", is returned unchanged.
+//
+// The printerAttrsCacheKeys are always requested, so we can verify
+// that cache is still valid.
+func (cache *PrinterAttrsCache) trimRequest(requested []string,
+	cached goipp.Attributes) []string {
+
+	if len(requested) == 0 {
+		return requested
+	}
+
+	have := make(map[string]struct{}, len(cached))
+	for _, attr := range cached {
+		have[attr.Name] = struct{}{}
+	}
+
+	var trimmed []string
+	for _, name := range cache.fullRequest(requested) {
+		_, found := have[name]
+		if !found || slices.Contains(printerAttrsCacheKeys, name) {
+			trimmed = append(trimmed, name)
+		}
+	}
+
+	return trimmed
+}
@@ -0,0 +1,298 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Cache of immutable printer attributes test
+
+package ipp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// TestPrinterAttrIsImmutable tests PrinterAttrIsImmutable
+func TestPrinterAttrIsImmutable(t *testing.T) {
+	tests := []struct {
+		name      string
+		immutable bool
+	}{
+		{"printer-make-and-model", true},
+		{"printer-uuid", true},
+		{"document-format-supported", true},
+		{"printer-state", false},
+		{"printer-state-reasons", false},
+		{"marker-levels", false},
+	}
+
+	for _, test := range tests {
+		immutable := PrinterAttrIsImmutable(test.name)
+		if immutable != test.immutable {
+			t.Errorf("%q: expected %v, present %v",
+				test.name, test.immutable, immutable)
+		}
+	}
+}
+
+// TestPrinterAttrsCache tests PrinterAttrsCache
+func TestPrinterAttrsCache(t *testing.T) {
+	const (
+		uri  = "ipp://localhost/ipp/print"
+		uuid = "urn:uuid:4509a320-00a0-008f-00b6-00074d2a2a2a"
+	)
+
+	mkattrs := func(changeTime int, model string) goipp.Attributes {
+		return goipp.Attributes{
+			goipp.MakeAttribute("printer-uuid",
+				goipp.TagURI, goipp.String(uuid)),
+			goipp.MakeAttribute("printer-config-change-time",
+				goipp.TagInteger, goipp.Integer(changeTime)),
+			goipp.MakeAttribute("printer-make-and-model",
+				goipp.TagText, goipp.String(model)),
+			goipp.MakeAttribute("printer-state",
+				goipp.TagEnum, goipp.Integer(3)),
+		}
+	}
+
+	cache := NewPrinterAttrsCache(0)
+
+	// Unknown printer
+	if attrs := cache.getByURI(uri); attrs != nil {
+		t.Errorf("unknown printer: cache hit")
+	}
+
+	// Initial fill
+	if _, hit := cache.update(uri, mkattrs(1, "Model 1")); hit {
+		t.Errorf("initial fill: unexpected cache hit")
+	}
+
+	cached := cache.getByURI(uri)
+	names := []string{}
+	for _, attr := range cached {
+		names = append(names, attr.Name)
+	}
+
+	expected := []string{"printer-uuid", "printer-make-and-model"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("cached attributes:\nexpected: %q\npresent:  %q",
+			expected, names)
+	}
+
+	// Trimmed request
+	trimmed := cache.trimRequest(
+		[]string{"printer-make-and-model", "printer-state"}, cached)
+	expected = []string{
+		"printer-uuid", "printer-config-change-time", "printer-state",
+	}
+	if !reflect.DeepEqual(trimmed, expected) {
+		t.Errorf("trimmed request:\nexpected: %q\npresent:  %q",
+			expected, trimmed)
+	}
+
+	// Group names are passed as is
+	trimmed = cache.trimRequest(
+		[]string{"all", "printer-make-and-model"}, cached)
+	expected = []string{
+		"printer-uuid", "printer-config-change-time", "all",
+	}
+	if !reflect.DeepEqual(trimmed, expected) {
+		t.Errorf("trimmed request:\nexpected: %q\npresent:  %q",
+			expected, trimmed)
+	}
+
+	if trimmed = cache.trimRequest(nil, cached); trimmed != nil {
+		t.Errorf("trimmed empty request: %q", trimmed)
+	}
+
+	// Merge
+	merged, hit := cache.update(uri, goipp.Attributes{
+		goipp.MakeAttribute("printer-uuid",
+			goipp.TagURI, goipp.String(uuid)),
+		goipp.MakeAttribute("printer-config-change-time",
+			goipp.TagInteger, goipp.Integer(1)),
+		goipp.MakeAttribute("printer-state",
+			goipp.TagEnum, goipp.Integer(4)),
+	})
+
+	if !hit {
+		t.Errorf("merge: cache miss")
+	}
+
+	prn := &PrinterAttributes{}
+	err := ippDecodeAttrs(prn, merged)
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+
+	if prn.PrinterMakeAndModel != "Model 1" || prn.PrinterState != 4 {
+		t.Errorf("merge: got %q/%d", prn.PrinterMakeAndModel,
+			prn.PrinterState)
+	}
+
+	// Configuration change must invalidate the entry
+	_, hit = cache.update(uri, goipp.Attributes{
+		goipp.MakeAttribute("printer-uuid",
+			goipp.TagURI, goipp.String(uuid)),
+		goipp.MakeAttribute("printer-config-change-time",
+			goipp.TagInteger, goipp.Integer(2)),
+	})
+
+	if hit {
+		t.Errorf("config change: unexpected cache hit")
+	}
+
+	for _, attr := range cache.Get(uuid) {
+		if attr.Name == "printer-make-and-model" {
+			t.Errorf("config change: stale entry not invalidated")
+		}
+	}
+
+	// Explicit invalidation
+	cache.update(uri, mkattrs(2, "Model 2"))
+	cache.Invalidate(uuid)
+	if attrs := cache.Get(uuid); attrs != nil {
+		t.Errorf("Invalidate: entry still present")
+	}
+
+	// Expiration
+	cache = NewPrinterAttrsCache(time.Nanosecond)
+	cache.update(uri, mkattrs(1, "Model 1"))
+	time.Sleep(time.Millisecond)
+	if attrs := cache.getByURI(uri); attrs != nil {
+		t.Errorf("TTL: entry not expired")
+	}
+}
+
+// testAttrsCacheServer is the minimal IPP server for the
+// Client.GetPrinterAttributes with the PrinterAttrsCache test.
+// It returns only requested attributes.
+type testAttrsCacheServer struct {
+	changeTime int        // printer-config-change-time
+	model      string     // printer-make-and-model
+	requests   [][]string // Received requested-attributes
+}
+
+// ServeHTTP handles IPP requests.
+func (srv *testAttrsCacheServer) ServeHTTP(w http.ResponseWriter,
+	rq *http.Request) {
+
+	msg := &goipp.Message{}
+	err := msg.Decode(rq.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ipprq := &GetPrinterAttributesRequest{}
+	ipprq.Decode(msg)
+	srv.requests = append(srv.requests, ipprq.RequestedAttributes)
+
+	all := goipp.Attributes{
+		goipp.MakeAttribute("printer-uuid", goipp.TagURI,
+			goipp.String("urn:uuid:4509a320-00a0-008f-00b6-00074d2a2a2a")),
+		goipp.MakeAttribute("printer-config-change-time",
+			goipp.TagInteger, goipp.Integer(srv.changeTime)),
+		goipp.MakeAttribute("printer-make-and-model",
+			goipp.TagText, goipp.String(srv.model)),
+		goipp.MakeAttribute("printer-state",
+			goipp.TagEnum, goipp.Integer(3)),
+	}
+
+	rsp := goipp.NewResponse(goipp.DefaultVersion, goipp.StatusOk,
+		msg.RequestID)
+	rsp.Operation.Add(goipp.MakeAttribute("attributes-charset",
+		goipp.TagCharset, goipp.String("utf-8")))
+	rsp.Operation.Add(goipp.MakeAttribute("attributes-natural-language",
+		goipp.TagLanguage, goipp.String("en-us")))
+
+	for _, attr := range all {
+		if slices.Contains(ipprq.RequestedAttributes, attr.Name) {
+			rsp.Printer.Add(attr)
+		}
+	}
+
+	w.Header().Set("Content-Type", goipp.ContentType)
+	rsp.Encode(w)
+}
+
+// TestClientAttrsCache tests Client.GetPrinterAttributes with
+// the PrinterAttrsCache
+func TestClientAttrsCache(t *testing.T) {
+	srv := &testAttrsCacheServer{changeTime: 1, model: "Model 1"}
+	httpSrv := httptest.NewServer(srv)
+	defer httpSrv.Close()
+
+	clnt := NewClient(transport.MustParseURL(httpSrv.URL), nil)
+	clnt.AttrsCache = NewPrinterAttrsCache(0)
+
+	attrs := []string{
+		"printer-make-and-model",
+		"printer-state",
+	}
+
+	// printer-uuid and printer-config-change-time are always
+	// requested, so cache can identify the printer
+	full := []string{
+		"printer-uuid",
+		"printer-config-change-time",
+		"printer-make-and-model",
+		"printer-state",
+	}
+
+	get := func() *PrinterAttributes {
+		srv.requests = nil
+		prn, err := clnt.GetPrinterAttributes(context.Background(),
+			attrs)
+		if err != nil {
+			t.Fatalf("GetPrinterAttributes: %s", err)
+		}
+		return prn
+	}
+
+	// Initial request is not trimmed
+	get()
+	if !reflect.DeepEqual(srv.requests, [][]string{full}) {
+		t.Errorf("initial request: %q", srv.requests)
+	}
+
+	// Next request is trimmed, make-and-model comes from cache
+	prn := get()
+	expected := [][]string{
+		{"printer-uuid", "printer-config-change-time", "printer-state"},
+	}
+
+	if !reflect.DeepEqual(srv.requests, expected) {
+		t.Errorf("trimmed request:\nexpected: %q\npresent:  %q",
+			expected, srv.requests)
+	}
+
+	if prn.PrinterMakeAndModel != "Model 1" {
+		t.Errorf("cached printer-make-and-model: %q",
+			prn.PrinterMakeAndModel)
+	}
+
+	// Configuration change causes the full request to be repeated
+	srv.changeTime, srv.model = 2, "Model 2"
+	prn = get()
+	expected = append(expected, full)
+
+	if !reflect.DeepEqual(srv.requests, expected) {
+		t.Errorf("retried request:\nexpected: %q\npresent:  %q",
+			expected, srv.requests)
+	}
+
+	if prn.PrinterMakeAndModel != "Model 2" {
+		t.Errorf("refreshed printer-make-and-model: %q",
+			prn.PrinterMakeAndModel)
+	}
+}
@@ -0,0 +1,104 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// FaxOut jobs submission and monitoring
+
+package ipp
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// FaxStatusAttrs are the Job attributes, requested by the
+// [Client.WaitFax] to monitor the fax transmission.
+var FaxStatusAttrs = []string{
+	"job-state",
+	"job-state-reasons",
+	"job-state-message",
+	"job-impressions-completed",
+	"destination-statuses",
+}
+
+// SendFax submits a new fax Job to the FaxOut service (PWG5100.15),
+// using the Print-Job request. The Client URL must point to the
+// FaxOut service (i.e., ipp://host/ipp/faxout).
+//
+// The name parameter specifies the job-name, and may be empty.
+// The attrs parameter specifies Job Template attributes, and must
+// contain at least one destination-uris entry. Other FaxOut
+// attributes (cover-sheet-info, number-of-retries, retry-interval,
+// retry-time-out and so on) are optional.
+//
+// On success, it returns the Job Status attributes, returned
+// by the Printer. Use [Client.WaitFax] to wait for the
+// fax transmission.
+func (c *Client) SendFax(ctx context.Context, name string,
+	attrs *JobAttributes, doc Document) (*JobStatus, error) {
+
+	if attrs == nil || len(attrs.DestinationUris) == 0 {
+		return nil, errors.New("fax: destination-uris missed")
+	}
+
+	return c.PrintJob(ctx, name, attrs, doc)
+}
+
+// WaitFax polls the fax Job status with the specified interval,
+// until the Job reaches one of the terminating states (see
+// [JobStatus.IsTerminated]).
+//
+// Unlike print jobs, the fax Job may stay pending or processing for
+// a long time, while the device retries the transmission (i.e., when
+// the line is busy). During this time, job-state-reasons reports the
+// cause (i.e., "fax-modem-line-busy").
+//
+// If progress is not nil, it is called with the initial Job status
+// and then every time job-state or job-state-reasons changes.
+//
+// On success, it returns the final Job status. The transmission
+// result must be checked with the [JobStatus.FaxSucceeded].
+//
+// If ctx is canceled, WaitFax returns ctx.Err(). The Job is not
+// canceled at the device side, it is up to the caller.
+func (c *Client) WaitFax(ctx context.Context, jobID int,
+	interval time.Duration, progress func(*JobStatus)) (*JobStatus, error) {
+
+	var prev *JobStatus
+
+	for {
+		job, err := c.GetJobAttributes(ctx, jobID, FaxStatusAttrs)
+		if err != nil {
+			return nil, err
+		}
+
+		job.JobID = jobID
+
+		if progress != nil && (prev == nil || faxStatusChanged(prev, job)) {
+			progress(job)
+		}
+
+		if job.IsTerminated() {
+			return job, nil
+		}
+
+		prev = job
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// faxStatusChanged reports if job-state or job-state-reasons
+// differs between two Job statuses.
+func faxStatusChanged(prev, next *JobStatus) bool {
+	return prev.JobState != next.JobState ||
+		!slices.Equal(prev.JobStateReasons, next.JobStateReasons)
+}
@@ -0,0 +1,253 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// FaxOut jobs submission and monitoring test
+
+package ipp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// testFaxServer is the minimal IPP FaxOut server for SendFax
+// and WaitFax tests. Get-Job-Attributes returns the next status
+// from the statuses list on each call.
+type testFaxServer struct {
+	statuses []*JobStatus   // Job statuses to return
+	job      *JobAttributes // Received Job Template attributes
+	attrs    []string       // Received requested-attributes
+	polls    int            // Count of Get-Job-Attributes requests
+}
+
+// ServeHTTP handles IPP requests.
+func (srv *testFaxServer) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	msg := &goipp.Message{}
+	err := msg.Decode(rq.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var rsp Response
+
+	switch goipp.Op(msg.Code) {
+	case goipp.OpPrintJob:
+		ipprq := &PrintJobRequest{}
+		ipprq.Decode(msg)
+		srv.job = ipprq.Job
+
+		rsp = &PrintJobResponse{
+			ResponseHeader: DefaultResponseHeader,
+			Job:            srv.statuses[0],
+		}
+
+	case goipp.OpGetJobAttributes:
+		ipprq := &GetJobAttributesRequest{}
+		ipprq.Decode(msg)
+		srv.attrs = ipprq.RequestedAttributes
+
+		job := srv.statuses[min(srv.polls, len(srv.statuses)-1)]
+		srv.polls++
+
+		rsp = &GetJobAttributesResponse{
+			ResponseHeader: DefaultResponseHeader,
+			Job:            job,
+		}
+	}
+
+	rspMsg := rsp.Encode()
+	rspMsg.RequestID = msg.RequestID
+
+	w.Header().Set("Content-Type", goipp.ContentType)
+	rspMsg.Encode(w)
+}
+
+// TestClientFax tests Client.SendFax and Client.WaitFax
+func TestClientFax(t *testing.T) {
+	mkjob := func(state int, reasons ...KwJobStateReasons) *JobStatus {
+		return &JobStatus{
+			JobID:           1,
+			JobURI:          "ipp://localhost/jobs/1",
+			JobState:        state,
+			JobStateReasons: reasons,
+		}
+	}
+
+	// The fax is retried once due to busy line, then sent
+	done := mkjob(JobStateCompleted,
+		KwJobStateReasonsJobCompletedSuccessfully)
+	done.DestinationStatuses = []DestinationStatus{
+		{
+			DestinationURI:     "tel:+15551234",
+			ImagesCompleted:    2,
+			TransmissionStatus: JobStateCompleted,
+		},
+	}
+
+	srv := &testFaxServer{
+		statuses: []*JobStatus{
+			mkjob(JobStatePending, KwJobStateReasonsNone),
+			mkjob(JobStateProcessing,
+				KwJobStateReasonsConnectingToDestination),
+			mkjob(JobStatePending, KwJobStateReasonsFaxModemLineBusy),
+			mkjob(JobStatePending, KwJobStateReasonsFaxModemLineBusy),
+			mkjob(JobStateProcessing,
+				KwJobStateReasonsConnectedToDestination),
+			done,
+		},
+	}
+
+	httpSrv := httptest.NewServer(srv)
+	defer httpSrv.Close()
+
+	clnt := NewClient(transport.MustParseURL(httpSrv.URL), nil)
+	doc := Document{"a.pdf", "application/pdf", strings.NewReader("AAA")}
+
+	// SendFax requires destination-uris
+	_, err := clnt.SendFax(context.Background(), "test",
+		&JobAttributes{}, doc)
+	if err == nil {
+		t.Errorf("SendFax: missed destination-uris not detected")
+	}
+
+	// Send the fax
+	attrs := &JobAttributes{
+		DestinationUris: []DestinationURI{
+			{DestinationURI: "tel:+15551234"},
+		},
+		CoverSheetInfo:  CoverSheetInfo{Subject: "Test"},
+		NumberOfRetries: 3,
+		RetryInterval:   60,
+		RetryTimeOut:    30,
+	}
+
+	job, err := clnt.SendFax(context.Background(), "test", attrs, doc)
+	if err != nil {
+		t.Fatalf("SendFax: %s", err)
+	}
+
+	if job.JobID != 1 {
+		t.Errorf("SendFax: job-id expected 1, present %d", job.JobID)
+	}
+
+	diff := testDiffStruct(attrs, srv.job)
+	if diff != "" {
+		t.Errorf("SendFax: attributes mismatch:\n%s", diff)
+	}
+
+	// Wait for transmission
+	var states []int
+	progress := func(job *JobStatus) {
+		states = append(states, job.JobState)
+	}
+
+	job, err = clnt.WaitFax(context.Background(), 1, 0, progress)
+	if err != nil {
+		t.Fatalf("WaitFax: %s", err)
+	}
+
+	if !reflect.DeepEqual(srv.attrs, FaxStatusAttrs) {
+		t.Errorf("WaitFax: requested attributes: %v", srv.attrs)
+	}
+
+	// Repeated fax-modem-line-busy is reported only once
+	expected := []int{
+		JobStatePending,
+		JobStateProcessing,
+		JobStatePending,
+		JobStateProcessing,
+		JobStateCompleted,
+	}
+
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("WaitFax: progress:\nexpected: %v\npresent:  %v",
+			expected, states)
+	}
+
+	if !job.FaxSucceeded() {
+		t.Errorf("WaitFax: FaxSucceeded() is false")
+	}
+
+	if len(job.DestinationStatuses) != 1 ||
+		job.DestinationStatuses[0].ImagesCompleted != 2 {
+		t.Errorf("WaitFax: destination-statuses: %+v",
+			job.DestinationStatuses)
+	}
+
+	// WaitFax must respect context cancellation
+	srv.statuses = srv.statuses[:1]
+	srv.polls = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = clnt.WaitFax(ctx, 1, time.Hour, func(*JobStatus) {
+		cancel()
+	})
+
+	if err != context.Canceled {
+		t.Errorf("WaitFax: expected %v, present %v",
+			context.Canceled, err)
+	}
+}
+
+// TestJobStatusFaxSucceeded tests JobStatus.FaxSucceeded
+func TestJobStatusFaxSucceeded(t *testing.T) {
+	type testData struct {
+		job    JobStatus
+		expect bool
+	}
+
+	tests := []testData{
+		{
+			job:    JobStatus{JobState: JobStateCompleted},
+			expect: true,
+		},
+		{
+			job:    JobStatus{JobState: JobStateAborted},
+			expect: false,
+		},
+		{
+			job: JobStatus{
+				JobState: JobStateCompleted,
+				JobStateReasons: []KwJobStateReasons{
+					KwJobStateReasonsDestinationURIFailed,
+				},
+			},
+			expect: false,
+		},
+		{
+			job: JobStatus{
+				JobState: JobStateCompleted,
+				DestinationStatuses: []DestinationStatus{
+					{
+						DestinationURI:     "tel:1",
+						TransmissionStatus: JobStateCompleted,
+					},
+					{
+						DestinationURI:     "tel:2",
+						TransmissionStatus: JobStateAborted,
+					},
+				},
+			},
+			expect: false,
+		},
+	}
+
+	for i, test := range tests {
+		if present := test.job.FaxSucceeded(); present != test.expect {
+			t.Errorf("test %d: FaxSucceeded: expected %v, present %v",
+				i, test.expect, present)
+		}
+	}
+}
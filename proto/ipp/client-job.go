@@ -0,0 +1,373 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Jobs submission and control
+
+package ipp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/user"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/goipp"
+)
+
+// jobCancelTimeout is the timeout for the Cancel-Job request,
+// sent to rollback the failed job submission.
+const jobCancelTimeout = 5 * time.Second
+
+// Document is the single document of the multi-document job.
+type Document struct {
+	Name   string    // Document name (document-name), optional
+	Format string    // MIME type (document-format), optional
+	Body   io.Reader // Document data
+}
+
+// PrintJob submits a new single-document Job, using the
+// Print-Job request.
+//
+// The name parameter specifies the job-name, and may be empty.
+// The attrs parameter specifies Job Template attributes, and may
+// be nil. Document data is streamed from the doc.Body.
+//
+// On success, it returns the Job Status attributes, returned
+// by the Printer.
+func (c *Client) PrintJob(ctx context.Context, name string,
+	attrs *JobAttributes, doc Document) (*JobStatus, error) {
+
+	rq := &PrintJobRequest{
+		RequestHeader:      DefaultRequestHeader,
+		PrinterURI:         c.URL.String(),
+		RequestingUserName: jobRequestingUserName(),
+		JobName:            name,
+		DocumentName:       doc.Name,
+		DocumentFormat:     doc.Format,
+		Job:                attrs,
+	}
+	rq.Body = doc.Body
+
+	rsp := &PrintJobResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+	if err == nil && (rsp.Job == nil || rsp.Job.JobID == 0) {
+		err = errors.New("IPP: Print-Job: missed job-id")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug(ctx, "IPP: job %d printed", rsp.Job.JobID)
+
+	return rsp.Job, nil
+}
+
+// CreateJob creates a new Job without documents, using the
+// Create-Job request. Documents are added to the Job with
+// the [Client.SendDocument].
+//
+// The name parameter specifies the job-name, and may be empty.
+// The attrs parameter specifies Job Template attributes, and may
+// be nil.
+//
+// On success, it returns the Job Status attributes, returned
+// by the Printer.
+func (c *Client) CreateJob(ctx context.Context, name string,
+	attrs *JobAttributes) (*JobStatus, error) {
+
+	rq := &CreateJobRequest{
+		RequestHeader:      DefaultRequestHeader,
+		PrinterURI:         c.URL.String(),
+		RequestingUserName: jobRequestingUserName(),
+		JobName:            name,
+		Job:                attrs,
+	}
+
+	rsp := &CreateJobResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+	if err == nil && (rsp.Job == nil || rsp.Job.JobID == 0) {
+		err = errors.New("IPP: Create-Job: missed job-id")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug(ctx, "IPP: job %d created", rsp.Job.JobID)
+
+	return rsp.Job, nil
+}
+
+// SendDocument adds the document to the Job, created by the
+// [Client.CreateJob], using the Send-Document request.
+//
+// If last is true, the Job is closed for the new documents.
+// Document data is streamed from the doc.Body.
+//
+// On success, it returns the Job Status attributes, returned
+// by the Printer. As Printer may omit them, the returned
+// value may be nil.
+func (c *Client) SendDocument(ctx context.Context, jobID int,
+	doc Document, last bool) (*JobStatus, error) {
+
+	rq := &SendDocumentRequest{
+		RequestHeader:      DefaultRequestHeader,
+		PrinterURI:         c.URL.String(),
+		JobID:              jobID,
+		RequestingUserName: jobRequestingUserName(),
+		DocumentName:       doc.Name,
+		DocumentFormat:     doc.Format,
+		LastDocument:       last,
+	}
+	rq.Body = doc.Body
+
+	rsp := &SendDocumentResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp.Job, nil
+}
+
+// SubmitJob creates a new Job and sends the documents, one by
+// one, using the Create-Job/Send-Document sequence.
+//
+// The name parameter specifies the job-name, and may be empty.
+// The attrs parameter specifies Job Template attributes, and may
+// be nil.
+//
+// The last document is sent with the last-document attribute set
+// to true, which closes the Job for the new documents.
+//
+// If any Send-Document request fails, the Job is canceled with
+// the Cancel-Job request, so incomplete Job is never printed,
+// and the Send-Document error is returned.
+//
+// On success, it returns the Job Status attributes, returned
+// by the Printer in the last Send-Document response.
+func (c *Client) SubmitJob(ctx context.Context, name string,
+	attrs *JobAttributes, docs []Document) (*JobStatus, error) {
+
+	if len(docs) == 0 {
+		return nil, errors.New("IPP: no documents to print")
+	}
+
+	// Create the job
+	job, err := c.CreateJob(ctx, name, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	jobID := job.JobID
+
+	// Send documents
+	for i, doc := range docs {
+		status, err := c.SendDocument(ctx, jobID, doc,
+			i == len(docs)-1)
+
+		if err != nil {
+			err = fmt.Errorf("document %d: %w", i+1, err)
+			c.jobRollback(ctx, jobID)
+			return nil, err
+		}
+
+		log.Debug(ctx, "IPP: job %d: document %d of %d sent",
+			jobID, i+1, len(docs))
+
+		if status != nil {
+			job = status
+		}
+	}
+
+	return job, nil
+}
+
+// GetJobAttributes performs the Get-Job-Attributes request.
+// The attrs parameter specifies a list of requested attributes.
+func (c *Client) GetJobAttributes(ctx context.Context, jobID int,
+	attrs []string) (*JobStatus, error) {
+
+	rq := &GetJobAttributesRequest{
+		RequestHeader:       DefaultRequestHeader,
+		PrinterURI:          c.URL.String(),
+		JobID:               jobID,
+		RequestingUserName:  jobRequestingUserName(),
+		RequestedAttributes: attrs,
+	}
+
+	rsp := &GetJobAttributesResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+	if err == nil && rsp.Job == nil {
+		err = errors.New("IPP: Get-Job-Attributes: missed job attributes")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp.Job, nil
+}
+
+// CancelJob performs the Cancel-Job request.
+func (c *Client) CancelJob(ctx context.Context, jobID int) error {
+	rq := &CancelJobRequest{
+		RequestHeader:      DefaultRequestHeader,
+		PrinterURI:         c.URL.String(),
+		JobID:              jobID,
+		RequestingUserName: jobRequestingUserName(),
+	}
+
+	rsp := &CancelJobResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+
+	return err
+}
+
+// GetJobs performs the Get-Jobs request.
+//
+// The which parameter selects Jobs by state. If empty, the
+// not-completed Jobs are returned. The attrs parameter specifies
+// a list of requested attributes.
+func (c *Client) GetJobs(ctx context.Context, which KwWhichJobs,
+	attrs []string) ([]*JobStatus, error) {
+
+	rq := &GetJobsRequest{
+		RequestHeader:       DefaultRequestHeader,
+		PrinterURI:          c.URL.String(),
+		RequestingUserName:  jobRequestingUserName(),
+		WhichJobs:           which,
+		RequestedAttributes: attrs,
+	}
+
+	rsp := &GetJobsResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp.Jobs, nil
+}
+
+// HoldJob performs the Hold-Job request.
+//
+// The until parameter specifies when the Job becomes eligible
+// for scheduling. If empty, Printer uses "indefinite".
+func (c *Client) HoldJob(ctx context.Context, jobID int,
+	until KwJobHoldUntil) error {
+
+	rq := &HoldJobRequest{
+		RequestHeader:      DefaultRequestHeader,
+		PrinterURI:         c.URL.String(),
+		JobID:              jobID,
+		RequestingUserName: jobRequestingUserName(),
+		JobHoldUntil:       until,
+	}
+
+	rsp := &HoldJobResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+
+	return err
+}
+
+// ReleaseJob performs the Release-Job request.
+func (c *Client) ReleaseJob(ctx context.Context, jobID int) error {
+	rq := &ReleaseJobRequest{
+		RequestHeader:      DefaultRequestHeader,
+		PrinterURI:         c.URL.String(),
+		JobID:              jobID,
+		RequestingUserName: jobRequestingUserName(),
+	}
+
+	rsp := &ReleaseJobResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+
+	return err
+}
+
+// RestartJob performs the Restart-Job request.
+func (c *Client) RestartJob(ctx context.Context, jobID int) error {
+	rq := &RestartJobRequest{
+		RequestHeader:      DefaultRequestHeader,
+		PrinterURI:         c.URL.String(),
+		JobID:              jobID,
+		RequestingUserName: jobRequestingUserName(),
+	}
+
+	rsp := &RestartJobResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err == nil {
+		err = jobCheckStatus(rsp.Status, rsp.StatusMessage)
+	}
+
+	return err
+}
+
+// jobRollback cancels the partially submitted Job.
+//
+// As it may be called when ctx is already canceled, the request
+// is performed with the separate context with timeout.
+func (c *Client) jobRollback(ctx context.Context, jobID int) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx),
+		jobCancelTimeout)
+	defer cancel()
+
+	err := c.CancelJob(ctx, jobID)
+	if err != nil {
+		log.Error(ctx, "IPP: job %d: Cancel-Job: %s", jobID, err)
+		return
+	}
+
+	log.Debug(ctx, "IPP: job %d canceled", jobID)
+}
+
+// jobCheckStatus returns error, if IPP status indicates failure.
+func jobCheckStatus(status goipp.Status, msg string) error {
+	if status < goipp.StatusRedirectionOtherSite {
+		return nil
+	}
+
+	if msg != "" {
+		return fmt.Errorf("IPP: %s (%s)", status, msg)
+	}
+
+	return fmt.Errorf("IPP: %s", status)
+}
+
+// jobRequestingUserName returns the requesting-user-name for
+// job requests.
+func jobRequestingUserName() string {
+	usr, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return usr.Username
+}
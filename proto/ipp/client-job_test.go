@@ -0,0 +1,304 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Jobs submission and control test
+
+package ipp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// testJobServer is the minimal IPP server for SubmitJob tests.
+// It logs received requests and fails Send-Document for
+// the document with the specified name.
+type testJobServer struct {
+	failDoc string   // Fail Send-Document with this name
+	log     []string // Log of received requests
+}
+
+// ServeHTTP handles IPP requests.
+func (srv *testJobServer) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	msg := &goipp.Message{}
+	err := msg.Decode(rq.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job := &JobStatus{
+		JobID:           1,
+		JobURI:          "ipp://localhost/jobs/1",
+		JobState:        3,
+		JobStateReasons: []KwJobStateReasons{KwJobStateReasonsNone},
+	}
+
+	var rsp Response
+
+	switch goipp.Op(msg.Code) {
+	case goipp.OpPrintJob:
+		ipprq := &PrintJobRequest{}
+		ipprq.Decode(msg)
+		data, _ := io.ReadAll(rq.Body)
+
+		copies := 0
+		if ipprq.Job != nil {
+			copies = ipprq.Job.Copies
+		}
+
+		srv.log = append(srv.log,
+			fmt.Sprintf("Print-Job %s %s %s %q copies=%d",
+				ipprq.JobName, ipprq.DocumentName,
+				ipprq.DocumentFormat, data, copies))
+
+		rsp = &PrintJobResponse{
+			ResponseHeader: DefaultResponseHeader,
+			Job:            job,
+		}
+
+	case goipp.OpCreateJob:
+		ipprq := &CreateJobRequest{}
+		ipprq.Decode(msg)
+		srv.log = append(srv.log, "Create-Job "+ipprq.JobName)
+		rsp = &CreateJobResponse{
+			ResponseHeader: DefaultResponseHeader,
+			Job:            job,
+		}
+
+	case goipp.OpSendDocument:
+		ipprq := &SendDocumentRequest{}
+		ipprq.Decode(msg)
+		data, _ := io.ReadAll(rq.Body)
+		srv.log = append(srv.log,
+			fmt.Sprintf("Send-Document %d %s %s %q last=%v",
+				ipprq.JobID, ipprq.DocumentName,
+				ipprq.DocumentFormat, data, ipprq.LastDocument))
+
+		hdr := DefaultResponseHeader
+		if ipprq.DocumentName == srv.failDoc {
+			hdr.Status = goipp.StatusErrorDocumentFormatNotSupported
+			hdr.StatusMessage = "bad format"
+		}
+
+		rsp = &SendDocumentResponse{ResponseHeader: hdr, Job: job}
+
+	case goipp.OpCancelJob:
+		ipprq := &CancelJobRequest{}
+		ipprq.Decode(msg)
+		srv.log = append(srv.log,
+			fmt.Sprintf("Cancel-Job %d", ipprq.JobID))
+		rsp = &CancelJobResponse{ResponseHeader: DefaultResponseHeader}
+
+	case goipp.OpGetJobs:
+		ipprq := &GetJobsRequest{}
+		ipprq.Decode(msg)
+		srv.log = append(srv.log,
+			fmt.Sprintf("Get-Jobs %s", ipprq.WhichJobs))
+
+		job2 := *job
+		job2.JobID = 2
+		job2.JobURI = "ipp://localhost/jobs/2"
+		rsp = &GetJobsResponse{
+			ResponseHeader: DefaultResponseHeader,
+			Jobs:           []*JobStatus{job, &job2},
+		}
+
+	case goipp.OpHoldJob:
+		ipprq := &HoldJobRequest{}
+		ipprq.Decode(msg)
+		srv.log = append(srv.log,
+			fmt.Sprintf("Hold-Job %d %s", ipprq.JobID,
+				ipprq.JobHoldUntil))
+		rsp = &HoldJobResponse{ResponseHeader: DefaultResponseHeader}
+
+	case goipp.OpReleaseJob:
+		ipprq := &ReleaseJobRequest{}
+		ipprq.Decode(msg)
+		srv.log = append(srv.log,
+			fmt.Sprintf("Release-Job %d", ipprq.JobID))
+		rsp = &ReleaseJobResponse{ResponseHeader: DefaultResponseHeader}
+
+	case goipp.OpRestartJob:
+		ipprq := &RestartJobRequest{}
+		ipprq.Decode(msg)
+		srv.log = append(srv.log,
+			fmt.Sprintf("Restart-Job %d", ipprq.JobID))
+
+		hdr := DefaultResponseHeader
+		hdr.Status = goipp.StatusErrorNotPossible
+		rsp = &RestartJobResponse{ResponseHeader: hdr}
+	}
+
+	rspMsg := rsp.Encode()
+	rspMsg.RequestID = msg.RequestID
+
+	w.Header().Set("Content-Type", goipp.ContentType)
+	rspMsg.Encode(w)
+}
+
+// TestClientSubmitJob tests Client.SubmitJob
+func TestClientSubmitJob(t *testing.T) {
+	docs := func() []Document {
+		return []Document{
+			{"a.pdf", "application/pdf", strings.NewReader("AAA")},
+			{"b.jpg", "image/jpeg", strings.NewReader("BBB")},
+			{"c.txt", "text/plain", strings.NewReader("CCC")},
+		}
+	}
+
+	type testData struct {
+		failDoc string   // Fail this document
+		log     []string // Expected server log
+		err     string   // Expected error
+	}
+
+	tests := []testData{
+		{
+			// All documents sent, the last one is flagged
+			log: []string{
+				`Create-Job test`,
+				`Send-Document 1 a.pdf application/pdf "AAA" last=false`,
+				`Send-Document 1 b.jpg image/jpeg "BBB" last=false`,
+				`Send-Document 1 c.txt text/plain "CCC" last=true`,
+			},
+		},
+
+		{
+			// Failed Send-Document causes Cancel-Job
+			failDoc: "b.jpg",
+			log: []string{
+				`Create-Job test`,
+				`Send-Document 1 a.pdf application/pdf "AAA" last=false`,
+				`Send-Document 1 b.jpg image/jpeg "BBB" last=false`,
+				`Cancel-Job 1`,
+			},
+			err: "document 2: IPP: " +
+				"client-error-document-format-not-supported " +
+				"(bad format)",
+		},
+	}
+
+	for _, test := range tests {
+		srv := &testJobServer{failDoc: test.failDoc}
+		httpSrv := httptest.NewServer(srv)
+
+		clnt := NewClient(transport.MustParseURL(httpSrv.URL), nil)
+		job, err := clnt.SubmitJob(context.Background(),
+			"test", nil, docs())
+
+		httpSrv.Close()
+
+		errStr := ""
+		if err != nil {
+			errStr = err.Error()
+		}
+
+		if errStr != test.err {
+			t.Errorf("error mismatch:\n"+
+				"expected: %q\n"+
+				"present:  %q",
+				test.err, errStr)
+		}
+
+		if err == nil && (job == nil || job.JobID != 1) {
+			t.Errorf("invalid job status: %#v", job)
+		}
+
+		if !reflect.DeepEqual(srv.log, test.log) {
+			t.Errorf("server log mismatch:\n"+
+				"expected: %q\n"+
+				"present:  %q",
+				test.log, srv.log)
+		}
+	}
+}
+
+// TestClientPrintJob tests Client.PrintJob
+func TestClientPrintJob(t *testing.T) {
+	srv := &testJobServer{}
+	httpSrv := httptest.NewServer(srv)
+	defer httpSrv.Close()
+
+	clnt := NewClient(transport.MustParseURL(httpSrv.URL), nil)
+	doc := Document{"a.pdf", "application/pdf", strings.NewReader("AAA")}
+	attrs := &JobAttributes{Copies: 2}
+
+	job, err := clnt.PrintJob(context.Background(), "test", attrs, doc)
+	if err != nil {
+		t.Fatalf("PrintJob: %s", err)
+	}
+
+	if job.JobID != 1 || job.JobURI != "ipp://localhost/jobs/1" {
+		t.Errorf("invalid job status: %#v", job)
+	}
+
+	log := []string{`Print-Job test a.pdf application/pdf "AAA" copies=2`}
+	if !reflect.DeepEqual(srv.log, log) {
+		t.Errorf("server log mismatch:\n"+
+			"expected: %q\n"+
+			"present:  %q",
+			log, srv.log)
+	}
+}
+
+// TestClientJobControl tests Client.GetJobs, Client.HoldJob,
+// Client.ReleaseJob and Client.RestartJob
+func TestClientJobControl(t *testing.T) {
+	srv := &testJobServer{}
+	httpSrv := httptest.NewServer(srv)
+	defer httpSrv.Close()
+
+	ctx := context.Background()
+	clnt := NewClient(transport.MustParseURL(httpSrv.URL), nil)
+
+	jobs, err := clnt.GetJobs(ctx, KwWhichJobsAll, nil)
+	if err != nil {
+		t.Fatalf("GetJobs: %s", err)
+	}
+
+	if len(jobs) != 2 || jobs[0].JobID != 1 || jobs[1].JobID != 2 {
+		t.Errorf("GetJobs: invalid jobs: %#v", jobs)
+	}
+
+	err = clnt.HoldJob(ctx, 1, KwJobHoldUntilIndefinite)
+	if err != nil {
+		t.Errorf("HoldJob: %s", err)
+	}
+
+	err = clnt.ReleaseJob(ctx, 1)
+	if err != nil {
+		t.Errorf("ReleaseJob: %s", err)
+	}
+
+	err = clnt.RestartJob(ctx, 1)
+	if err == nil {
+		t.Errorf("RestartJob: error expected")
+	}
+
+	log := []string{
+		"Get-Jobs all",
+		"Hold-Job 1 indefinite",
+		"Release-Job 1",
+		"Restart-Job 1",
+	}
+
+	if !reflect.DeepEqual(srv.log, log) {
+		t.Errorf("server log mismatch:\n"+
+			"expected: %q\n"+
+			"present:  %q",
+			log, srv.log)
+	}
+}
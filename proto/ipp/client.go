@@ -18,6 +18,7 @@ import (
 	"os/user"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/OpenPrinting/go-mfp/log"
 	"github.com/OpenPrinting/go-mfp/transport"
@@ -26,9 +27,12 @@ import (
 
 // Client implements Client-side IPP Printer object.
 type Client struct {
-	URL        *url.URL          // Destination URL (ipp://...)
-	HTTPClient *transport.Client // HTTP Client
-	RequestID  uint32            // RequestID of the next request
+	URL        *url.URL           // Destination URL (ipp://...)
+	HTTPClient *transport.Client  // HTTP Client
+	RequestID  uint32             // RequestID of the next request
+	AttrsCache *PrinterAttrsCache // Printer attributes cache, may be nil
+	Fixtures   *FixtureWriter     // Saves responses as fixtures, may be nil
+	Tracer     Tracer             // Receives IPP exchanges, may be nil
 }
 
 // NewClient creates a new IPP client.
@@ -56,6 +60,88 @@ func (c *Client) requestid() uint32 {
 	return id
 }
 
+// GetPrinterAttributes performs the Get-Printer-Attributes request.
+// The attrs parameter specifies a list of requested attributes.
+//
+// If Client.AttrsCache is not nil, immutable attributes are served
+// from the cache, and only the remaining attributes are requested
+// from the printer. The printer-uuid and printer-config-change-time
+// are always requested, as the cache needs them to identify the
+// printer. If cached attributes appear to be stale (i.e.,
+// printer-config-change-time has changed), the full request is
+// repeated.
+func (c *Client) GetPrinterAttributes(ctx context.Context,
+	attrs []string) (*PrinterAttributes, error) {
+
+	rq := &GetPrinterAttributesRequest{
+		RequestHeader:       DefaultRequestHeader,
+		PrinterURI:          c.URL.String(),
+		RequestedAttributes: attrs,
+	}
+
+	trimmed := false
+	if c.AttrsCache != nil {
+		rq.RequestedAttributes = c.AttrsCache.fullRequest(attrs)
+
+		cached := c.AttrsCache.getByURI(rq.PrinterURI)
+		if cached != nil {
+			rq.RequestedAttributes = c.AttrsCache.trimRequest(
+				attrs, cached)
+			trimmed = true
+		}
+	}
+
+	prn, err := c.getPrinterAttributes(ctx, rq)
+	if err != nil || c.AttrsCache == nil {
+		return prn, err
+	}
+
+	merged, hit := c.AttrsCache.update(rq.PrinterURI, prn.RawAttrs().All())
+	if trimmed && !hit {
+		log.Debug(ctx, "IPP: cached attributes invalidated, retrying")
+
+		rq.RequestedAttributes = c.AttrsCache.fullRequest(attrs)
+		prn, err = c.getPrinterAttributes(ctx, rq)
+		if err != nil {
+			return nil, err
+		}
+
+		merged, _ = c.AttrsCache.update(rq.PrinterURI,
+			prn.RawAttrs().All())
+	}
+
+	prn = &PrinterAttributes{}
+	err = ippDecodeAttrs(prn, merged)
+	if err != nil {
+		return nil, err
+	}
+
+	return prn, nil
+}
+
+// getPrinterAttributes sends the Get-Printer-Attributes request
+// and returns the received printer attributes.
+func (c *Client) getPrinterAttributes(ctx context.Context,
+	rq *GetPrinterAttributesRequest) (*PrinterAttributes, error) {
+
+	rsp := &GetPrinterAttributesResponse{}
+	err := c.Do(ctx, rq, rsp)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.Status >= goipp.StatusRedirectionOtherSite {
+		return nil, fmt.Errorf("IPP: %s", rsp.Status)
+	}
+
+	prn := rsp.Printer
+	if prn == nil {
+		prn = &PrinterAttributes{}
+	}
+
+	return prn, nil
+}
+
 // Do sends the [Request] and waits for [Response].
 //
 // The following Request fields are filled automatically:
@@ -87,6 +173,10 @@ func (c *Client) Do(ctx context.Context, rq Request, rsp Response) error {
 func (c *Client) DoWithBody(ctx context.Context,
 	rq Request, rsp Response) error {
 
+	var xchg *TraceExchange
+	var rspData bytes.Buffer
+	var rspBody io.Reader
+
 	// Encode IPP message
 	buf := &bytes.Buffer{}
 	msg := rq.Encode()
@@ -100,6 +190,7 @@ func (c *Client) DoWithBody(ctx context.Context,
 	}
 
 	msg.Encode(buf)
+	op := goipp.Op(msg.Code)
 
 	// Log the IPP request
 	f := goipp.NewFormatter()
@@ -135,24 +226,60 @@ func (c *Client) DoWithBody(ctx context.Context,
 		httpRq.Header.Set("Authorization", auth)
 	}
 
+	// Prepare tracing
+	if c.Tracer != nil {
+		xchg = &TraceExchange{
+			Start:       time.Now(),
+			URL:         c.URL,
+			Peer:        c.URL.Host,
+			Request:     msg,
+			RequestData: bytes.Clone(buf.Bytes()),
+		}
+		defer func() { c.trace(xchg, err) }()
+	}
+
 	// Call server
 	httpRsp, err := c.HTTPClient.Do(httpRq)
 	if err != nil {
 		return err
 	}
 
+	if xchg != nil {
+		xchg.HTTPStatus = httpRsp.StatusCode
+	}
+
 	if httpRsp.StatusCode != http.StatusOK {
 		err = fmt.Errorf("HTTP: %s", httpRsp.Status)
 		goto ERROR
 	}
 
 	// Decode IPP message
-	msg.Reset()
-	err = msg.Decode(httpRsp.Body)
+	msg = &goipp.Message{}
+	rspBody = httpRsp.Body
+	if xchg != nil {
+		rspBody = io.TeeReader(rspBody, &rspData)
+	}
+
+	err = msg.Decode(rspBody)
 	if err != nil {
 		goto ERROR
 	}
 
+	if xchg != nil {
+		xchg.Response = msg
+		xchg.ResponseData = rspData.Bytes()
+	}
+
+	// Save the IPP response as the test fixture
+	if c.Fixtures != nil {
+		path, err2 := c.Fixtures.Save(op, msg)
+		if err2 != nil {
+			log.Debug(ctx, "IPP: can't save fixture: %s", err2)
+		} else {
+			log.Debug(ctx, "IPP: response saved to %s", path)
+		}
+	}
+
 	// Log the IPP response
 	f.Reset()
 	f.SetIndent(2)
@@ -165,8 +292,9 @@ func (c *Client) DoWithBody(ctx context.Context,
 		goto ERROR
 	}
 
-	// Save IPPMessage, remainder of body and return
+	// Save IPPMessage, HTTP header, remainder of body and return
 	rsp.Header().IPPMessage = msg
+	rsp.Header().HTTPHeader = httpRsp.Header
 	rsp.Header().Body = httpRsp.Body
 
 	return nil
@@ -176,3 +304,10 @@ ERROR:
 	httpRsp.Body.Close()
 	return err
 }
+
+// trace reports the IPP exchange to the Client's Tracer.
+func (c *Client) trace(xchg *TraceExchange, err error) {
+	xchg.Elapsed = time.Since(xchg.Start)
+	xchg.Err = err
+	c.Tracer.Trace(xchg)
+}
@@ -0,0 +1,199 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Finishings enum
+
+package ipp
+
+import "strconv"
+
+// EnFinishings represents values of the "finishings" and related
+// attributes.
+//
+// RFC8011: 5.2.6.
+// PWG5100.1: 5.1.
+type EnFinishings int
+
+// EnFinishings values:
+const (
+	EnFinishingsNone                EnFinishings = 3   // Perform no finishing
+	EnFinishingsStaple              EnFinishings = 4   // Staple the document(s)
+	EnFinishingsPunch               EnFinishings = 5   // Punch holes
+	EnFinishingsCover               EnFinishings = 6   // Add a cover
+	EnFinishingsBind                EnFinishings = 7   // Bind the document(s)
+	EnFinishingsSaddleStitch        EnFinishings = 8   // Staple along the middle fold
+	EnFinishingsEdgeStitch          EnFinishings = 9   // Staple along one edge
+	EnFinishingsFold                EnFinishings = 10  // Fold the document(s)
+	EnFinishingsTrim                EnFinishings = 11  // Trim the document(s)
+	EnFinishingsBale                EnFinishings = 12  // Bale the document(s)
+	EnFinishingsBookletMaker        EnFinishings = 13  // Fold and stitch into a booklet
+	EnFinishingsJogOffset           EnFinishings = 14  // Offset the document(s)
+	EnFinishingsCoat                EnFinishings = 15  // Apply protective coating
+	EnFinishingsLaminate            EnFinishings = 16  // Apply protective laminate
+	EnFinishingsStapleTopLeft       EnFinishings = 20  // Staple top left corner
+	EnFinishingsStapleBottomLeft    EnFinishings = 21  // Staple bottom left corner
+	EnFinishingsStapleTopRight      EnFinishings = 22  // Staple top right corner
+	EnFinishingsStapleBottomRight   EnFinishings = 23  // Staple bottom right corner
+	EnFinishingsEdgeStitchLeft      EnFinishings = 24  // Stitch left edge
+	EnFinishingsEdgeStitchTop       EnFinishings = 25  // Stitch top edge
+	EnFinishingsEdgeStitchRight     EnFinishings = 26  // Stitch right edge
+	EnFinishingsEdgeStitchBottom    EnFinishings = 27  // Stitch bottom edge
+	EnFinishingsStapleDualLeft      EnFinishings = 28  // Two staples on left
+	EnFinishingsStapleDualTop       EnFinishings = 29  // Two staples on top
+	EnFinishingsStapleDualRight     EnFinishings = 30  // Two staples on right
+	EnFinishingsStapleDualBottom    EnFinishings = 31  // Two staples on bottom
+	EnFinishingsStapleTripleLeft    EnFinishings = 32  // Three staples on left
+	EnFinishingsStapleTripleTop     EnFinishings = 33  // Three staples on top
+	EnFinishingsStapleTripleRight   EnFinishings = 34  // Three staples on right
+	EnFinishingsStapleTripleBottom  EnFinishings = 35  // Three staples on bottom
+	EnFinishingsBindLeft            EnFinishings = 50  // Bind on left
+	EnFinishingsBindTop             EnFinishings = 51  // Bind on top
+	EnFinishingsBindRight           EnFinishings = 52  // Bind on right
+	EnFinishingsBindBottom          EnFinishings = 53  // Bind on bottom
+	EnFinishingsTrimAfterPages      EnFinishings = 60  // Trim output after each page
+	EnFinishingsTrimAfterDocuments  EnFinishings = 61  // Trim output after each document
+	EnFinishingsTrimAfterCopies     EnFinishings = 62  // Trim output after each copy
+	EnFinishingsTrimAfterJob        EnFinishings = 63  // Trim output after job
+	EnFinishingsPunchTopLeft        EnFinishings = 70  // Punch 1 hole top left
+	EnFinishingsPunchBottomLeft     EnFinishings = 71  // Punch 1 hole bottom left
+	EnFinishingsPunchTopRight       EnFinishings = 72  // Punch 1 hole top right
+	EnFinishingsPunchBottomRight    EnFinishings = 73  // Punch 1 hole bottom right
+	EnFinishingsPunchDualLeft       EnFinishings = 74  // Punch 2 holes left side
+	EnFinishingsPunchDualTop        EnFinishings = 75  // Punch 2 holes top edge
+	EnFinishingsPunchDualRight      EnFinishings = 76  // Punch 2 holes right side
+	EnFinishingsPunchDualBottom     EnFinishings = 77  // Punch 2 holes bottom edge
+	EnFinishingsPunchTripleLeft     EnFinishings = 78  // Punch 3 holes left side
+	EnFinishingsPunchTripleTop      EnFinishings = 79  // Punch 3 holes top edge
+	EnFinishingsPunchTripleRight    EnFinishings = 80  // Punch 3 holes right side
+	EnFinishingsPunchTripleBottom   EnFinishings = 81  // Punch 3 holes bottom edge
+	EnFinishingsPunchQuadLeft       EnFinishings = 82  // Punch 4 holes left side
+	EnFinishingsPunchQuadTop        EnFinishings = 83  // Punch 4 holes top edge
+	EnFinishingsPunchQuadRight      EnFinishings = 84  // Punch 4 holes right side
+	EnFinishingsPunchQuadBottom     EnFinishings = 85  // Punch 4 holes bottom edge
+	EnFinishingsPunchMultipleLeft   EnFinishings = 86  // Punch multiple holes left side
+	EnFinishingsPunchMultipleTop    EnFinishings = 87  // Punch multiple holes top edge
+	EnFinishingsPunchMultipleRight  EnFinishings = 88  // Punch multiple holes right side
+	EnFinishingsPunchMultipleBottom EnFinishings = 89  // Punch multiple holes bottom edge
+	EnFinishingsFoldAccordion       EnFinishings = 90  // Accordion fold
+	EnFinishingsFoldDoubleGate      EnFinishings = 91  // Double gate fold
+	EnFinishingsFoldGate            EnFinishings = 92  // Gate fold
+	EnFinishingsFoldHalf            EnFinishings = 93  // Half fold
+	EnFinishingsFoldHalfZ           EnFinishings = 94  // Half Z fold
+	EnFinishingsFoldLeftGate        EnFinishings = 95  // Left gate fold
+	EnFinishingsFoldLetter          EnFinishings = 96  // Letter fold
+	EnFinishingsFoldParallel        EnFinishings = 97  // Parallel fold
+	EnFinishingsFoldPoster          EnFinishings = 98  // Poster fold
+	EnFinishingsFoldRightGate       EnFinishings = 99  // Right gate fold
+	EnFinishingsFoldZ               EnFinishings = 100 // Z fold
+	EnFinishingsFoldEngineeringZ    EnFinishings = 101 // Engineering Z fold
+)
+
+// String returns the keyword name of the EnFinishings value.
+// For unknown values, the decimal number is returned.
+func (fin EnFinishings) String() string {
+	if name, found := enFinishingsNames[fin]; found {
+		return name
+	}
+	return strconv.Itoa(int(fin))
+}
+
+// DecodeEnFinishings decodes [EnFinishings] out of its keyword name.
+// The decimal number is also accepted, for vendor-specific values.
+// If s is not recognized, 0 is returned.
+func DecodeEnFinishings(s string) EnFinishings {
+	if fin, found := enFinishingsByName[s]; found {
+		return fin
+	}
+
+	if v, err := strconv.ParseUint(s, 10, 31); err == nil && v > 0 {
+		return EnFinishings(v)
+	}
+
+	return 0
+}
+
+// EnFinishingsNames returns keyword names of all known
+// EnFinishings values, in the ascending order of values.
+func EnFinishingsNames() []string {
+	return enumNames(enFinishingsNames)
+}
+
+// enFinishingsNames contains names of EnFinishings values
+var enFinishingsNames = map[EnFinishings]string{
+	EnFinishingsNone:                "none",
+	EnFinishingsStaple:              "staple",
+	EnFinishingsPunch:               "punch",
+	EnFinishingsCover:               "cover",
+	EnFinishingsBind:                "bind",
+	EnFinishingsSaddleStitch:        "saddle-stitch",
+	EnFinishingsEdgeStitch:          "edge-stitch",
+	EnFinishingsFold:                "fold",
+	EnFinishingsTrim:                "trim",
+	EnFinishingsBale:                "bale",
+	EnFinishingsBookletMaker:        "booklet-maker",
+	EnFinishingsJogOffset:           "jog-offset",
+	EnFinishingsCoat:                "coat",
+	EnFinishingsLaminate:            "laminate",
+	EnFinishingsStapleTopLeft:       "staple-top-left",
+	EnFinishingsStapleBottomLeft:    "staple-bottom-left",
+	EnFinishingsStapleTopRight:      "staple-top-right",
+	EnFinishingsStapleBottomRight:   "staple-bottom-right",
+	EnFinishingsEdgeStitchLeft:      "edge-stitch-left",
+	EnFinishingsEdgeStitchTop:       "edge-stitch-top",
+	EnFinishingsEdgeStitchRight:     "edge-stitch-right",
+	EnFinishingsEdgeStitchBottom:    "edge-stitch-bottom",
+	EnFinishingsStapleDualLeft:      "staple-dual-left",
+	EnFinishingsStapleDualTop:       "staple-dual-top",
+	EnFinishingsStapleDualRight:     "staple-dual-right",
+	EnFinishingsStapleDualBottom:    "staple-dual-bottom",
+	EnFinishingsStapleTripleLeft:    "staple-triple-left",
+	EnFinishingsStapleTripleTop:     "staple-triple-top",
+	EnFinishingsStapleTripleRight:   "staple-triple-right",
+	EnFinishingsStapleTripleBottom:  "staple-triple-bottom",
+	EnFinishingsBindLeft:            "bind-left",
+	EnFinishingsBindTop:             "bind-top",
+	EnFinishingsBindRight:           "bind-right",
+	EnFinishingsBindBottom:          "bind-bottom",
+	EnFinishingsTrimAfterPages:      "trim-after-pages",
+	EnFinishingsTrimAfterDocuments:  "trim-after-documents",
+	EnFinishingsTrimAfterCopies:     "trim-after-copies",
+	EnFinishingsTrimAfterJob:        "trim-after-job",
+	EnFinishingsPunchTopLeft:        "punch-top-left",
+	EnFinishingsPunchBottomLeft:     "punch-bottom-left",
+	EnFinishingsPunchTopRight:       "punch-top-right",
+	EnFinishingsPunchBottomRight:    "punch-bottom-right",
+	EnFinishingsPunchDualLeft:       "punch-dual-left",
+	EnFinishingsPunchDualTop:        "punch-dual-top",
+	EnFinishingsPunchDualRight:      "punch-dual-right",
+	EnFinishingsPunchDualBottom:     "punch-dual-bottom",
+	EnFinishingsPunchTripleLeft:     "punch-triple-left",
+	EnFinishingsPunchTripleTop:      "punch-triple-top",
+	EnFinishingsPunchTripleRight:    "punch-triple-right",
+	EnFinishingsPunchTripleBottom:   "punch-triple-bottom",
+	EnFinishingsPunchQuadLeft:       "punch-quad-left",
+	EnFinishingsPunchQuadTop:        "punch-quad-top",
+	EnFinishingsPunchQuadRight:      "punch-quad-right",
+	EnFinishingsPunchQuadBottom:     "punch-quad-bottom",
+	EnFinishingsPunchMultipleLeft:   "punch-multiple-left",
+	EnFinishingsPunchMultipleTop:    "punch-multiple-top",
+	EnFinishingsPunchMultipleRight:  "punch-multiple-right",
+	EnFinishingsPunchMultipleBottom: "punch-multiple-bottom",
+	EnFinishingsFoldAccordion:       "fold-accordion",
+	EnFinishingsFoldDoubleGate:      "fold-double-gate",
+	EnFinishingsFoldGate:            "fold-gate",
+	EnFinishingsFoldHalf:            "fold-half",
+	EnFinishingsFoldHalfZ:           "fold-half-z",
+	EnFinishingsFoldLeftGate:        "fold-left-gate",
+	EnFinishingsFoldLetter:          "fold-letter",
+	EnFinishingsFoldParallel:        "fold-parallel",
+	EnFinishingsFoldPoster:          "fold-poster",
+	EnFinishingsFoldRightGate:       "fold-right-gate",
+	EnFinishingsFoldZ:               "fold-z",
+	EnFinishingsFoldEngineeringZ:    "fold-engineering-z",
+}
+
+// enFinishingsByName maps EnFinishings names to values
+var enFinishingsByName = enumByName(enFinishingsNames)
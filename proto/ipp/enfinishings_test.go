@@ -0,0 +1,100 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// EnFinishings test
+
+package ipp
+
+import (
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// TestEnFinishings tests EnFinishings String and Decode
+func TestEnFinishings(t *testing.T) {
+	type testData struct {
+		fin EnFinishings
+		s   string
+	}
+
+	tests := []testData{
+		{EnFinishingsNone, "none"},
+		{EnFinishingsStaple, "staple"},
+		{EnFinishingsStapleTopLeft, "staple-top-left"},
+		{EnFinishingsFoldEngineeringZ, "fold-engineering-z"},
+		{1234, "1234"},
+	}
+
+	for _, test := range tests {
+		s := test.fin.String()
+		if s != test.s {
+			t.Errorf("%d: String(): expected %q, present %q",
+				int(test.fin), test.s, s)
+		}
+
+		fin := DecodeEnFinishings(test.s)
+		if fin != test.fin {
+			t.Errorf("DecodeEnFinishings(%q): expected %d, present %d",
+				test.s, int(test.fin), int(fin))
+		}
+	}
+
+	for _, s := range []string{"", "unknown", "0", "-1"} {
+		fin := DecodeEnFinishings(s)
+		if fin != 0 {
+			t.Errorf("DecodeEnFinishings(%q): expected 0, present %d",
+				s, int(fin))
+		}
+	}
+
+	names := EnFinishingsNames()
+	if len(names) != len(enFinishingsNames) || names[0] != "none" {
+		t.Errorf("EnFinishingsNames: unexpected result %v", names)
+	}
+}
+
+// TestNamedEnumAttrs tests NamedEnumAttrs
+func TestNamedEnumAttrs(t *testing.T) {
+	var attrs goipp.Attributes
+	attrs.Add(goipp.MakeAttr("finishings-supported", goipp.TagEnum,
+		goipp.Integer(EnFinishingsNone),
+		goipp.Integer(EnFinishingsStaple),
+		goipp.Integer(1234)))
+	attrs.Add(goipp.MakeAttribute("operations-supported",
+		goipp.TagEnum, goipp.Integer(goipp.OpPrintJob)))
+	attrs.Add(goipp.MakeAttribute("copies-supported",
+		goipp.TagInteger, goipp.Integer(1)))
+
+	named := NamedEnumAttrs(attrs)
+
+	expected := []string{
+		"finishings-supported: none, staple, 1234",
+		"operations-supported: Print-Job",
+		"copies-supported: 1",
+	}
+
+	for i, attr := range named {
+		s := attr.Name + ": "
+		for j, v := range attr.Values {
+			if j > 0 {
+				s += ", "
+			}
+			s += v.V.String()
+		}
+
+		if s != expected[i] {
+			t.Errorf("NamedEnumAttrs:\n"+
+				"expected: %s\n"+
+				"present:  %s", expected[i], s)
+		}
+	}
+
+	// Source attributes must not be modified
+	if attrs[0].Values[1].V != goipp.Integer(EnFinishingsStaple) {
+		t.Errorf("NamedEnumAttrs: source attributes modified")
+	}
+}
@@ -8,9 +8,90 @@
 
 package ipp
 
-import "reflect"
+import (
+	"reflect"
+	"slices"
+
+	"github.com/OpenPrinting/goipp"
+)
 
 // kwRegisteredTypes lists all registered keyword types for IPP codec.
 var enRegisteredTypes = map[reflect.Type]struct{}{
+	reflect.TypeOf(EnFinishings(0)):  struct{}{},
 	reflect.TypeOf(EnPrinterType(0)): struct{}{},
 }
+
+// enNamedAttrs maps names of enum attributes into the functions
+// that return symbolic names of their values.
+var enNamedAttrs = map[string]func(v int) string{
+	"finishings":           enFinishingsName,
+	"finishings-default":   enFinishingsName,
+	"finishings-ready":     enFinishingsName,
+	"finishings-supported": enFinishingsName,
+	"operations-supported": func(v int) string {
+		return goipp.Op(v).String()
+	},
+	"printer-type": func(v int) string {
+		return EnPrinterType(v).String()
+	},
+}
+
+// enFinishingsName returns symbolic name of the EnFinishings value
+func enFinishingsName(v int) string {
+	return EnFinishings(v).String()
+}
+
+// NamedEnumAttrs returns the copy of attributes, where values of
+// known enum attributes (i.e., "finishings-supported") are replaced
+// with their symbolic names.
+//
+// The returned attributes are intended for displaying (i.e., with
+// the [goipp.Formatter]) and must not be sent over the wire.
+func NamedEnumAttrs(attrs goipp.Attributes) goipp.Attributes {
+	attrs = attrs.Clone()
+
+	for i := range attrs {
+		attr := &attrs[i]
+		name := enNamedAttrs[attr.Name]
+		if name == nil {
+			continue
+		}
+
+		attr.Values = attr.Values.Clone()
+		for j, val := range attr.Values {
+			if v, ok := val.V.(goipp.Integer); ok &&
+				val.T == goipp.TagEnum {
+				attr.Values[j].V = goipp.String(name(int(v)))
+			}
+		}
+	}
+
+	return attrs
+}
+
+// enumNames returns names of the enum values, in the ascending
+// order of values.
+func enumNames[T ~int](names map[T]string) []string {
+	values := make([]T, 0, len(names))
+	for v := range names {
+		values = append(values, v)
+	}
+
+	slices.Sort(values)
+
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = names[v]
+	}
+
+	return s
+}
+
+// enumByName makes reverse map of enum names to values.
+func enumByName[T ~int](names map[T]string) map[string]T {
+	byName := make(map[string]T, len(names))
+	for v, name := range names {
+		byName[name] = v
+	}
+	return byName
+}
@@ -0,0 +1,211 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Vendor extension attributes
+
+package ipp
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// extRegistry maps type of the extended structure into the codecs
+// of its registered extensions.
+var (
+	extRegistry     = make(map[reflect.Type][]*ippCodec)
+	extRegistryLock sync.RWMutex
+)
+
+// RegisterExtension registers the vendor extension of the IPP
+// object type, so vendor-specific attributes are decoded into
+// the typed fields.
+//
+// Both obj and ext must be pointers to structures that implement
+// the [Object] interface. Only types matter, so nil pointers are OK.
+// Extension attributes are defined using the ipp: struct tags, the
+// same way as attributes of the standard objects:
+//
+//	type HPPrinterAttributes struct {
+//		ipp.ObjectRawAttrs
+//		HPDeviceStatus string `ipp:"?hp-device-status,keyword"`
+//	}
+//
+//	err := ipp.RegisterExtension((*ipp.PrinterAttributes)(nil),
+//		(*HPPrinterAttributes)(nil))
+//
+// Once registered, every time obj is decoded, the extension is decoded
+// from the same set of attributes and attached to the obj (see
+// [GetExtension]). Extension, attached to the obj with [SetExtension],
+// is encoded together with the obj. Attributes of all registered
+// extensions are reported by the obj.KnownAttrs.
+//
+// Extension attributes must not clash with attributes of the obj
+// and its other extensions.
+//
+// Note, extensions are handled for the top-level objects, i.e.,
+// [PrinterAttributes], [JobAttributes], [JobStatus] and so on,
+// but not for the nested collections.
+func RegisterExtension(obj, ext Object) error {
+	tObj, err := extStructType(obj)
+	if err != nil {
+		return err
+	}
+
+	tExt, err := extStructType(ext)
+	if err != nil {
+		return err
+	}
+
+	objCodec, err := ippCodecGenerate(tObj)
+	if err != nil {
+		return err
+	}
+
+	extCodec, err := ippCodecGenerate(tExt)
+	if err != nil {
+		return err
+	}
+
+	extRegistryLock.Lock()
+	defer extRegistryLock.Unlock()
+
+	// Check for conflicts
+	used := make(map[string]string)
+	for _, info := range objCodec.knownAttrs {
+		used[info.Name] = diagTypeName(tObj)
+	}
+
+	for _, codec := range extRegistry[tObj] {
+		if codec.t == tExt {
+			return fmt.Errorf("%s: extension %s already registered",
+				diagTypeName(tObj), diagTypeName(tExt))
+		}
+
+		for _, info := range codec.knownAttrs {
+			used[info.Name] = diagTypeName(codec.t)
+		}
+	}
+
+	for _, info := range extCodec.knownAttrs {
+		if found := used[info.Name]; found != "" {
+			return fmt.Errorf("%s: attribute %q already used by %s",
+				diagTypeName(tExt), info.Name, found)
+		}
+	}
+
+	extRegistry[tObj] = append(extRegistry[tObj], extCodec)
+
+	return nil
+}
+
+// GetExtension returns extension of the type T, attached to the obj,
+// or zero value of T (nil pointer), if there is no such extension.
+//
+// T must be pointer to structure, registered with [RegisterExtension].
+func GetExtension[T Object](obj Object) T {
+	for _, ext := range obj.RawAttrs().exts {
+		if v, ok := ext.(T); ok {
+			return v
+		}
+	}
+
+	var zero T
+	return zero
+}
+
+// SetExtension attaches the extension to the obj, replacing the
+// previously attached extension of the same type, if any.
+//
+// Type of the ext must be registered for the type of the obj with
+// [RegisterExtension]. Otherwise, SetExtension will panic.
+func SetExtension(obj, ext Object) {
+	tObj := reflect.TypeOf(obj).Elem()
+	tExt := reflect.TypeOf(ext)
+
+	found := false
+	for _, codec := range extLookup(tObj) {
+		if reflect.PointerTo(codec.t) == tExt {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		err := fmt.Errorf("%s: extension %s not registered",
+			diagTypeName(tObj), diagTypeName(tExt))
+		panic(err)
+	}
+
+	rawattrs := obj.RawAttrs()
+	for i := range rawattrs.exts {
+		if reflect.TypeOf(rawattrs.exts[i]) == tExt {
+			rawattrs.exts[i] = ext
+			return
+		}
+	}
+
+	rawattrs.exts = append(rawattrs.exts, ext)
+}
+
+// extDecode decodes all extensions, registered for the
+// structure type t, from the attributes.
+func extDecode(t reflect.Type, attrs goipp.Attributes) ([]Object, error) {
+	var exts []Object
+
+	for _, codec := range extLookup(t) {
+		ext := reflect.New(codec.t).Interface().(Object)
+		err := ippDecodeAttrs(ext, attrs)
+		if err != nil {
+			return nil, err
+		}
+
+		exts = append(exts, ext)
+	}
+
+	return exts, nil
+}
+
+// extKnownAttrs returns known attributes of the codec, including
+// attributes of all registered extensions.
+func extKnownAttrs(codec *ippCodec) []AttrInfo {
+	codecs := extLookup(codec.t)
+	if len(codecs) == 0 {
+		return codec.knownAttrs
+	}
+
+	known := slices.Clone(codec.knownAttrs)
+	for _, ext := range codecs {
+		known = append(known, ext.knownAttrs...)
+	}
+
+	return known
+}
+
+// extLookup returns codecs of extensions, registered for
+// the structure type t.
+func extLookup(t reflect.Type) []*ippCodec {
+	extRegistryLock.RLock()
+	codecs := extRegistry[t]
+	extRegistryLock.RUnlock()
+
+	return codecs
+}
+
+// extStructType returns type of structure, the obj points to.
+func extStructType(obj Object) (reflect.Type, error) {
+	t := reflect.TypeOf(obj)
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not pointer to structure",
+			diagTypeName(t))
+	}
+
+	return t.Elem(), nil
+}
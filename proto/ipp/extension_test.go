@@ -0,0 +1,141 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Vendor extension attributes test
+
+package ipp
+
+import (
+	"testing"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// testExtObject is the extended object for TestExtension
+type testExtObject struct {
+	ObjectRawAttrs
+	PrinterName string `ipp:"printer-name,name"`
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the testExtObject.
+func (obj *testExtObject) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(obj)
+}
+
+// testExtVendor is the vendor extension for TestExtension
+type testExtVendor struct {
+	ObjectRawAttrs
+	VendorStatus string   `ipp:"?vendor-status,keyword"`
+	VendorCount  int      `ipp:"?vendor-count"`
+	VendorModes  []string `ipp:"?vendor-modes,keyword"`
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the testExtVendor.
+func (ext *testExtVendor) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(ext)
+}
+
+// testExtClash is the extension that clashes with testExtObject
+type testExtClash struct {
+	ObjectRawAttrs
+	PrinterName string `ipp:"?printer-name,name"`
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the testExtClash.
+func (ext *testExtClash) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(ext)
+}
+
+// TestExtension tests RegisterExtension, GetExtension and SetExtension
+func TestExtension(t *testing.T) {
+	// Register extension
+	err := RegisterExtension((*testExtObject)(nil), (*testExtVendor)(nil))
+	if err != nil {
+		t.Fatalf("RegisterExtension: %s", err)
+	}
+
+	err = RegisterExtension((*testExtObject)(nil), (*testExtVendor)(nil))
+	if err == nil {
+		t.Errorf("RegisterExtension: duplicate not detected")
+	}
+
+	err = RegisterExtension((*testExtObject)(nil), (*testExtClash)(nil))
+	if err == nil {
+		t.Errorf("RegisterExtension: clash not detected")
+	}
+
+	// Extension attributes must be known
+	known := map[string]goipp.Tag{}
+	for _, info := range (*testExtObject)(nil).KnownAttrs() {
+		known[info.Name] = info.Tag
+	}
+
+	if known["printer-name"] != goipp.TagName ||
+		known["vendor-status"] != goipp.TagKeyword {
+		t.Errorf("KnownAttrs: %v", known)
+	}
+
+	// Decode
+	attrs := goipp.Attributes{
+		goipp.MakeAttribute("printer-name",
+			goipp.TagName, goipp.String("Test")),
+		goipp.MakeAttribute("vendor-status",
+			goipp.TagKeyword, goipp.String("warming-up")),
+		goipp.MakeAttribute("vendor-count",
+			goipp.TagInteger, goipp.Integer(5)),
+		goipp.MakeAttr("vendor-modes", goipp.TagKeyword,
+			goipp.String("eco"), goipp.String("fast")),
+	}
+
+	obj := &testExtObject{}
+	err = ippDecodeAttrs(obj, attrs)
+	if err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	ext := GetExtension[*testExtVendor](obj)
+	if ext == nil {
+		t.Fatalf("GetExtension: extension not decoded")
+	}
+
+	expected := &testExtVendor{
+		VendorStatus: "warming-up",
+		VendorCount:  5,
+		VendorModes:  []string{"eco", "fast"},
+	}
+
+	diff := testDiffStruct(expected, ext)
+	if diff != "" {
+		t.Errorf("extension mismatch:\n%s", diff)
+	}
+
+	if GetExtension[*testExtClash](obj) != nil {
+		t.Errorf("GetExtension: unexpected extension")
+	}
+
+	// Encode
+	obj = &testExtObject{PrinterName: "Test"}
+	SetExtension(obj, &testExtVendor{VendorStatus: "idle"})
+	SetExtension(obj, expected)
+
+	encoded := ippEncodeAttrs(obj)
+	diff = testDiffAttrs(attrs, encoded)
+	if diff != "" {
+		t.Errorf("encode mismatch:\n%s", diff)
+	}
+
+	// SetExtension with unregistered extension must panic
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetExtension: unregistered extension accepted")
+		}
+	}()
+
+	SetExtension(obj, &testExtClash{})
+}
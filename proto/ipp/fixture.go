@@ -0,0 +1,270 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Saving IPP responses as test fixtures
+
+package ipp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// FixtureWriter saves IPP responses, received by the [Client],
+// as the test fixture files.
+//
+// Fixtures are saved in the same format, as used by the test
+// corpus (see internal/testutils/data): each file contains the
+// single binary IPP message, and is named after the device
+// model and operation, i.e.:
+//
+//	Kyocera-ECOSYS-M2040dn-Printer-Attributes.ipp
+//
+// If file already exists, the numeric suffix is added to the
+// name (i.e., "...-Printer-Attributes-2.ipp").
+//
+// Saved responses are anonymized: UUIDs, serial numbers, host
+// names in URIs, user names, location and contact information
+// are replaced with the neutral values.
+type FixtureWriter struct {
+	dir   string     // Output directory
+	lock  sync.Mutex // Access lock
+	model string     // Device model, if known
+}
+
+// NewFixtureWriter creates a new [FixtureWriter], that saves
+// fixtures into the specified directory.
+func NewFixtureWriter(dir string) *FixtureWriter {
+	return &FixtureWriter{dir: dir}
+}
+
+// Save saves the IPP response, received in reply to the op request.
+//
+// It returns the name of the created file.
+func (fw *FixtureWriter) Save(op goipp.Op, rsp *goipp.Message) (
+	string, error) {
+
+	// Make anonymized copy of the response
+	msg := &goipp.Message{
+		Version:   rsp.Version,
+		Code:      rsp.Code,
+		RequestID: rsp.RequestID,
+		Groups:    rsp.AttrGroups().DeepCopy(),
+	}
+
+	model := ""
+	for _, grp := range msg.Groups {
+		if grp.Tag == goipp.TagPrinterGroup {
+			model = fixtureModel(grp.Attrs)
+		}
+		fixtureAnonymize(grp.Attrs)
+	}
+
+	data, err := msg.EncodeBytes()
+	if err != nil {
+		return "", err
+	}
+
+	// Choose the file name. Note, the model is remembered,
+	// so responses to the subsequent requests (i.e., Print-Job),
+	// that don't contain printer-make-and-model, will be named
+	// consistently.
+	fw.lock.Lock()
+	defer fw.lock.Unlock()
+
+	if model != "" {
+		fw.model = model
+	}
+
+	model = fw.model
+	if model == "" {
+		model = "Unknown"
+	}
+
+	opname := strings.TrimPrefix(op.String(), "Get-")
+	base := fixtureName(model) + "-" + fixtureName(opname)
+
+	// Create the file
+	for i := 1; ; i++ {
+		name := base + ".ipp"
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d.ipp", base, i)
+		}
+
+		path := filepath.Join(fw.dir, name)
+		err = fixtureWriteFile(path, data)
+		if err == nil {
+			return path, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
+}
+
+// fixtureModel returns device model for the fixture file name.
+//
+// It uses printer-make-and-model, prefixed with the manufacturer
+// from the printer-device-id, if the make-and-model doesn't
+// include it (i.e., Kyocera reports just "ECOSYS M2040dn").
+func fixtureModel(attrs goipp.Attributes) string {
+	var model, mfg string
+
+	for _, attr := range attrs {
+		if len(attr.Values) == 0 {
+			continue
+		}
+
+		switch attr.Name {
+		case "printer-make-and-model":
+			model = attr.Values[0].V.String()
+		case "printer-device-id":
+			match := fixtureDeviceIDMfg.FindStringSubmatch(
+				attr.Values[0].V.String())
+			if match != nil {
+				mfg = strings.TrimSpace(match[1])
+			}
+		}
+	}
+
+	if model != "" && mfg != "" &&
+		!strings.HasPrefix(strings.ToLower(model),
+			strings.ToLower(mfg)) {
+		model = mfg + " " + model
+	}
+
+	return model
+}
+
+// fixtureWriteFile writes data into the new file.
+// It fails with [os.ErrExist] if file already exists.
+func fixtureWriteFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+		0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	err2 := file.Close()
+	if err == nil {
+		err = err2
+	}
+
+	return err
+}
+
+// fixtureNameInvalid matches sequences of characters, not allowed
+// in the fixture file names
+var fixtureNameInvalid = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+
+// fixtureName converts string into the fixture file name component.
+func fixtureName(s string) string {
+	s = fixtureNameInvalid.ReplaceAllString(s, "-")
+	return strings.Trim(s, "-")
+}
+
+// Anonymized values
+const (
+	fixtureAnonUUID = "urn:uuid:00000000-0000-0000-0000-000000000000"
+	fixtureAnonHost = "printer.local"
+	fixtureAnonUser = "anonymous"
+)
+
+// fixtureAnonAttrs contains attributes, which values are replaced
+// entirely with the anonymized value.
+var fixtureAnonAttrs = map[string]goipp.Value{
+	"contact-name":                goipp.String(""),
+	"contact-vcard":               goipp.String(""),
+	"device-uuid":                 goipp.String(fixtureAnonUUID),
+	"document-uuid":               goipp.String(fixtureAnonUUID),
+	"job-originating-host-name":   goipp.String(fixtureAnonHost),
+	"job-originating-user-name":   goipp.String(fixtureAnonUser),
+	"job-uuid":                    goipp.String(fixtureAnonUUID),
+	"printer-dns-sd-name":         goipp.String("Printer"),
+	"printer-location":            goipp.String(""),
+	"printer-name":                goipp.String("Printer"),
+	"printer-organization":        goipp.String(""),
+	"printer-organizational-unit": goipp.String(""),
+	"printer-uuid":                goipp.String(fixtureAnonUUID),
+	"requesting-user-name":        goipp.String(fixtureAnonUser),
+	"system-uuid":                 goipp.String(fixtureAnonUUID),
+}
+
+// fixtureDeviceIDMfg matches the manufacturer within the
+// printer-device-id.
+var fixtureDeviceIDMfg = regexp.MustCompile(`(?:^|;)(?:MFG|MANUFACTURER):([^;]*)`)
+
+// fixtureAnonDeviceID matches the serial number within the
+// printer-device-id.
+var fixtureAnonDeviceID = regexp.MustCompile(
+	`((?:^|;)(?:SN|SER|SERN|SERIALNUMBER):)[^;]*`)
+
+// fixtureAnonymize anonymizes attributes in place.
+func fixtureAnonymize(attrs goipp.Attributes) {
+	for i := range attrs {
+		attr := &attrs[i]
+		anon, found := fixtureAnonAttrs[attr.Name]
+
+		for j := range attr.Values {
+			val := &attr.Values[j]
+
+			switch {
+			case found:
+				val.V = anon
+
+			case attr.Name == "printer-device-id":
+				s := fixtureAnonDeviceID.ReplaceAllString(
+					val.V.String(), "${1}0000000000")
+				val.V = goipp.String(s)
+
+			case attr.Name == "printer-geo-location":
+				val.T, val.V = goipp.TagUnknown, goipp.Void{}
+
+			case val.T == goipp.TagURI:
+				val.V = goipp.String(
+					fixtureAnonURI(val.V.String()))
+
+			default:
+				if coll, ok := val.V.(goipp.Collection); ok {
+					fixtureAnonymize(goipp.Attributes(coll))
+				}
+			}
+		}
+	}
+}
+
+// fixtureAnonURI replaces host name in the URI and e-mail
+// address in the mailto: URI. Other URI parts are preserved.
+func fixtureAnonURI(s string) string {
+	u, err := url.Parse(s)
+	switch {
+	case err != nil:
+		return s
+	case strings.EqualFold(u.Scheme, "mailto"):
+		return "mailto:" + fixtureAnonUser + "@" + fixtureAnonHost
+	case u.Host == "":
+		return s
+	}
+
+	u.User = nil
+	if port := u.Port(); port != "" {
+		u.Host = fixtureAnonHost + ":" + port
+	} else {
+		u.Host = fixtureAnonHost
+	}
+
+	return u.String()
+}
@@ -0,0 +1,149 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Tests for FixtureWriter
+
+package ipp
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/goipp"
+)
+
+// TestFixtureWriter tests FixtureWriter
+func TestFixtureWriter(t *testing.T) {
+	dir := t.TempDir()
+	fw := NewFixtureWriter(dir)
+
+	msg := testutils.IPPMustParse(
+		testutils.Kyocera.ECOSYS.M2040dn.IPP.PrinterAttributes)
+
+	// The first file must be named like the corpus file
+	path, err := fw.Save(goipp.OpGetPrinterAttributes, msg)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	expected := "Kyocera-ECOSYS-M2040dn-Printer-Attributes.ipp"
+	if filepath.Base(path) != expected {
+		t.Errorf("file name: expected %q, present %q",
+			expected, filepath.Base(path))
+	}
+
+	// The next one must not overwrite it
+	path2, err := fw.Save(goipp.OpGetPrinterAttributes, msg)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	expected = "Kyocera-ECOSYS-M2040dn-Printer-Attributes-2.ipp"
+	if filepath.Base(path2) != expected {
+		t.Errorf("file name: expected %q, present %q",
+			expected, filepath.Base(path2))
+	}
+
+	// Responses without printer-make-and-model use remembered model
+	rsp := goipp.NewResponse(goipp.DefaultVersion, goipp.StatusOk, 1)
+	path3, err := fw.Save(goipp.OpPrintJob, rsp)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	expected = "Kyocera-ECOSYS-M2040dn-Print-Job.ipp"
+	if filepath.Base(path3) != expected {
+		t.Errorf("file name: expected %q, present %q",
+			expected, filepath.Base(path3))
+	}
+
+	// Saved file must be parsable and anonymized
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	saved, err := testutils.IPPParse(data)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	for _, attr := range saved.Printer {
+		switch attr.Name {
+		case "printer-uuid":
+			if s := attr.Values[0].V.String(); s != fixtureAnonUUID {
+				t.Errorf("%s not anonymized: %q", attr.Name, s)
+			}
+
+		case "printer-device-id":
+			s := attr.Values[0].V.String()
+			if strings.Contains(s, "VCF9192281") {
+				t.Errorf("%s not anonymized: %q", attr.Name, s)
+			}
+
+		case "printer-uri-supported":
+			for _, v := range attr.Values {
+				u, _ := url.Parse(v.V.String())
+				if u.Hostname() != fixtureAnonHost {
+					t.Errorf("%s not anonymized: %q",
+						attr.Name, v.V)
+				}
+			}
+		}
+	}
+
+	// The original message must not be affected
+	if !msg.Equal(*testutils.IPPMustParse(
+		testutils.Kyocera.ECOSYS.M2040dn.IPP.PrinterAttributes)) {
+		t.Errorf("original message modified")
+	}
+}
+
+// TestFixtureAnonymize tests fixtureAnonymize
+func TestFixtureAnonymize(t *testing.T) {
+	attrs := goipp.Attributes{
+		goipp.MakeAttribute("printer-device-id", goipp.TagText,
+			goipp.String("MFG:Example;MDL:Printer;SN:12345;")),
+		goipp.MakeAttribute("printer-location", goipp.TagText,
+			goipp.String("Room 42")),
+		goipp.MakeAttribute("printer-more-info", goipp.TagURI,
+			goipp.String("http://user@host.example:8080/info")),
+		goipp.MakeAttribute("printer-contact-col", goipp.TagBeginCollection,
+			goipp.Collection{
+				goipp.MakeAttribute("contact-name", goipp.TagName,
+					goipp.String("John Doe")),
+				goipp.MakeAttribute("contact-uri", goipp.TagURI,
+					goipp.String("mailto:john@example.com")),
+			}),
+	}
+
+	expected := goipp.Attributes{
+		goipp.MakeAttribute("printer-device-id", goipp.TagText,
+			goipp.String("MFG:Example;MDL:Printer;SN:0000000000;")),
+		goipp.MakeAttribute("printer-location", goipp.TagText,
+			goipp.String("")),
+		goipp.MakeAttribute("printer-more-info", goipp.TagURI,
+			goipp.String("http://printer.local:8080/info")),
+		goipp.MakeAttribute("printer-contact-col", goipp.TagBeginCollection,
+			goipp.Collection{
+				goipp.MakeAttribute("contact-name", goipp.TagName,
+					goipp.String("")),
+				goipp.MakeAttribute("contact-uri", goipp.TagURI,
+					goipp.String("mailto:anonymous@printer.local")),
+			}),
+	}
+
+	fixtureAnonymize(attrs)
+	if !attrs.Equal(expected) {
+		t.Errorf("fixtureAnonymize:\n"+
+			"expected: %s\n"+
+			"present:  %s", expected, attrs)
+	}
+}
@@ -41,7 +41,13 @@ const (
 // This function will panic, if codec cannot be generated.
 func ippEncodeAttrs(obj Object) goipp.Attributes {
 	codec := ippCodecGet(obj)
-	return codec.encodeAttrs(obj)
+	attrs := codec.encodeAttrs(obj)
+
+	for _, ext := range obj.RawAttrs().exts {
+		attrs = append(attrs, ippEncodeAttrs(ext)...)
+	}
+
+	return attrs
 }
 
 // ippDecodeAttrs encodes attributes defined by particular structure
@@ -55,8 +61,15 @@ func ippDecodeAttrs(obj Object, attrs goipp.Attributes) error {
 	codec := ippCodecGet(obj)
 
 	err := codec.decodeAttrs(obj, attrs)
+
+	var exts []Object
+	if err == nil {
+		exts, err = extDecode(codec.t, attrs)
+	}
+
 	if err == nil {
 		obj.RawAttrs().set(attrs)
+		obj.RawAttrs().exts = exts
 	}
 
 	return err
@@ -67,7 +80,7 @@ func ippDecodeAttrs(obj Object, attrs goipp.Attributes) error {
 // This function will panic, if codec cannot be generated.
 func ippKnownAttrs(obj Object) []AttrInfo {
 	codec := ippCodecGet(obj)
-	return codec.knownAttrs
+	return extKnownAttrs(codec)
 }
 
 // ippCodec represents actions required to encode/decode structures
@@ -274,6 +287,11 @@ func ippCodecGenerateInternal(t reflect.Type,
 			fldType = maybe.typeof()
 		}
 
+		// Zero value check and reset are applied to the field
+		// itself, so they need the field type, not the type of
+		// the slice element or the structure the pointer points to.
+		valType := fldType
+
 		// Handle slices.
 		//
 		// Like Maybe[T], slices are handled as wrapper of actual data type.
@@ -287,6 +305,21 @@ func ippCodecGenerateInternal(t reflect.Type,
 			fldKind = fldType.Kind()
 		}
 
+		// Handle pointers to structures.
+		//
+		// Pointer to structure is encoded as collection, like the
+		// structure itself, but nil pointer means absent attribute.
+		// It is convenient for nested optional collections, where
+		// zero value of structure is meaningful, and allows
+		// to distinguish between absent and empty collection.
+		var ptrType reflect.Type
+		if !slice && fldKind == reflect.Pointer &&
+			fldType.Elem().Kind() == reflect.Struct {
+			ptrType = fldType
+			fldType = fldType.Elem()
+			fldKind = fldType.Kind()
+		}
+
 		// Now fldType points to the actual type to be encoded and decoded.
 		// Obtain its ippCodecMethods.
 		methods := ippCodecMethodsByType[fldType]
@@ -310,7 +343,7 @@ func ippCodecGenerateInternal(t reflect.Type,
 		}
 
 		// Generate encoding/decoding step for underlying type.
-		zero := reflect.Zero(fldType)
+		zero := reflect.Zero(valType)
 		step := ippCodecStep{
 			offset:      fld.Offset,
 			attrName:    tag.name,
@@ -325,10 +358,10 @@ func ippCodecGenerateInternal(t reflect.Type,
 			decode: methods.decode,
 
 			iszero: func(p unsafe.Pointer) bool {
-				return reflect.NewAt(fldType, p).Elem().IsZero()
+				return reflect.NewAt(valType, p).Elem().IsZero()
 			},
 			setzero: func(p unsafe.Pointer) {
-				reflect.NewAt(fldType, p).Elem().Set(zero)
+				reflect.NewAt(valType, p).Elem().Set(zero)
 			},
 		}
 
@@ -388,6 +421,21 @@ func ippCodecGenerateInternal(t reflect.Type,
 			}
 		}
 
+		// Generate pointer wrapper for pointer fields.
+		if ptrType != nil {
+			encode := step.encode
+			decode := step.decode
+
+			step.encode = func(p unsafe.Pointer) goipp.Values {
+				return ippEncPointer(p, encode)
+			}
+
+			step.decode = func(p unsafe.Pointer,
+				vals goipp.Values) error {
+				return ippDecPointer(p, vals, ptrType, decode)
+			}
+		}
+
 		// Generate Maybe[T] wrapper where appropriate.
 		if maybe != nil {
 			encode := step.encode
@@ -630,6 +678,42 @@ func ippDecSlice(p unsafe.Pointer, vals goipp.Values,
 	return nil
 }
 
+// ----- ippCodecMethods for pointers -----
+
+// ippEncPointer encodes value, referred by pointer.
+//
+// p is pointer to pointer field, encode is the encoder for the
+// value this field points to. nil pointer is encoded as no values.
+func ippEncPointer(p unsafe.Pointer,
+	encode func(unsafe.Pointer) goipp.Values) goipp.Values {
+
+	ptr := *(*unsafe.Pointer)(p)
+	if ptr == nil {
+		return nil
+	}
+
+	return encode(ptr)
+}
+
+// ippDecPointer decodes value, referred by pointer.
+//
+// t is the pointer type. The new value is allocated and
+// pointer is updated only if decoding succeeded.
+func ippDecPointer(p unsafe.Pointer, vals goipp.Values,
+	t reflect.Type, decode func(unsafe.Pointer, goipp.Values) error) error {
+
+	val := reflect.New(t.Elem())
+	err := decode(val.UnsafePointer(), vals)
+	if err != nil {
+		return err
+	}
+
+	reflect.NewAt(t, p).Elem().Set(val)
+	return nil
+}
+
+// ----- ippCodecMethods for collections -----
+
 // ippCodecMethodsCollection creates ippCodecMethods for encoding
 // nested structure or slice of structures as IPP Collection
 func ippCodecMethodsCollection(t reflect.Type, slice bool) (
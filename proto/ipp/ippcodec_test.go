@@ -1158,7 +1158,151 @@ func TestIppEncodeDecode(t *testing.T) {
 	}
 }
 
-// ----- IPP decode test -----
+// ----- Nested collections test -----
+
+// TestIppNestedCollections tests encoding and decoding of nested
+// collections, slices of collections and pointers to collections,
+// including the round trip via the IPP wire representation.
+func TestIppNestedCollections(t *testing.T) {
+	in := &JobAttributes{
+		MediaCol: MediaCol{
+			MediaSize: MediaSize{
+				XDimension: goipp.Integer(21000),
+				YDimension: goipp.Integer(29700),
+			},
+			MediaSourceProperties: MediaSourceProperties{
+				MediaSourceFeedDirection: "long-edge-first",
+			},
+			MediaType: "stationery",
+		},
+		FinishingsCol: []FinishingsCol{
+			{
+				FinishingTemplate: "staple-top-left",
+				Stitching: &FinishingsStitching{
+					StitchingLocations:     []int{10, 20},
+					StitchingReferenceEdge: "top",
+				},
+				Folding: []FinishingsFolding{
+					{FoldingDirection: "inward"},
+					{FoldingDirection: "outward"},
+				},
+				MediaSize: &MediaSize{
+					XDimension: goipp.Integer(21000),
+					YDimension: goipp.Integer(29700),
+				},
+			},
+			{
+				// Empty collection is not the same as absent
+				FinishingTemplate: "punch",
+				Punching:          &FinishingsPunching{},
+			},
+		},
+		JobSheetsCol: []JobSheets{
+			{
+				JobSheets: KwJobSheetsStandard,
+				Media:     "iso_a4_210x297mm",
+				MediaCol: []MediaCol{
+					{MediaType: "cardstock"},
+					{MediaType: "labels"},
+				},
+			},
+		},
+	}
+
+	// Check encoding of the nested collections
+	attrs := ippEncodeAttrs(in)
+
+	expected := goipp.MakeAttribute("finishings-col",
+		goipp.TagBeginCollection, goipp.Collection{
+			goipp.MakeAttribute("finishing-template",
+				goipp.TagKeyword, goipp.String("staple-top-left")),
+			goipp.Attribute{
+				Name: "folding",
+				Values: goipp.Values{
+					{goipp.TagBeginCollection, goipp.Collection{
+						goipp.MakeAttribute("folding-direction",
+							goipp.TagKeyword,
+							goipp.String("inward")),
+					}},
+					{goipp.TagBeginCollection, goipp.Collection{
+						goipp.MakeAttribute("folding-direction",
+							goipp.TagKeyword,
+							goipp.String("outward")),
+					}},
+				},
+			},
+			goipp.MakeAttribute("media-size",
+				goipp.TagBeginCollection, goipp.Collection{
+					goipp.MakeAttribute("x-dimension",
+						goipp.TagInteger, goipp.Integer(21000)),
+					goipp.MakeAttribute("y-dimension",
+						goipp.TagInteger, goipp.Integer(29700)),
+				}),
+			goipp.MakeAttribute("stitching",
+				goipp.TagBeginCollection, goipp.Collection{
+					goipp.Attribute{
+						Name: "stitching-locations",
+						Values: goipp.Values{
+							{goipp.TagInteger, goipp.Integer(10)},
+							{goipp.TagInteger, goipp.Integer(20)},
+						},
+					},
+					goipp.MakeAttribute("stitching-reference-edge",
+						goipp.TagKeyword, goipp.String("top")),
+				}),
+		})
+
+	expected.Values.Add(goipp.TagBeginCollection, goipp.Collection{
+		goipp.MakeAttribute("finishing-template",
+			goipp.TagKeyword, goipp.String("punch")),
+		goipp.MakeAttribute("punching",
+			goipp.TagBeginCollection, goipp.Collection{}),
+	})
+
+	var present goipp.Attributes
+	for _, attr := range attrs {
+		if attr.Name == "finishings-col" {
+			present.Add(attr)
+		}
+	}
+
+	diff := testDiffAttrs(goipp.Attributes{expected}, present)
+	if diff != "" {
+		t.Errorf("encode: finishings-col mismatch:\n%s", diff)
+	}
+
+	// Round trip via the wire representation
+	msg := goipp.NewRequest(goipp.DefaultVersion, goipp.OpPrintJob, 1)
+	msg.Job = attrs
+
+	data, err := msg.EncodeBytes()
+	if err != nil {
+		t.Fatalf("goipp encode: %s", err)
+	}
+
+	msg = &goipp.Message{}
+	err = msg.DecodeBytes(data)
+	if err != nil {
+		t.Fatalf("goipp decode: %s", err)
+	}
+
+	out := &JobAttributes{}
+	err = ippDecodeAttrs(out, msg.Job)
+	if err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+
+	diff = testDiffStruct(in, out)
+	if diff != "" {
+		t.Errorf("round trip: input/output mismatch:\n%s", diff)
+	}
+
+	// Absent pointer collections must remain nil
+	if out.FinishingsCol[0].Punching != nil ||
+		out.FinishingsCol[1].Stitching != nil {
+		t.Errorf("absent collections decoded as non-nil")
+	}
+}
 
 // ----- Common stuff -----
 
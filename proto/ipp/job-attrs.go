@@ -16,10 +16,12 @@ import (
 
 // JobAttributes are attributes, supplied with Job creation request
 type JobAttributes struct {
+	ObjectRawAttrs
+
 	// RFC8011, Internet Printing Protocol/1.1: Model and Semantics
 	// 5.2 Job Template Attributes
 	Copies                   int                        `ipp:"?copies,>0"`
-	Finishings               []int                      `ipp:"?finishings,enum"`
+	Finishings               []EnFinishings             `ipp:"?finishings"`
 	JobHoldUntil             KwJobHoldUntil             `ipp:"?job-hold-until"`
 	JobPriority              int                        `ipp:"?job-priority,1:100"`
 	JobSheets                KwJobSheets                `ipp:"?job-sheets"`
@@ -32,8 +34,17 @@ type JobAttributes struct {
 	PrintQuality             int                        `ipp:"?print-quality,enum"`
 	Sides                    KwSides                    `ipp:"?sides"`
 
+	// PWG5100.1: IPP Finishings 3.0 (FIN)
+	// 5.2 finishings-col
+	FinishingsCol []FinishingsCol `ipp:"?finishings-col"`
+
+	// PWG5100.2: IPP "output-bin" attribute extension
+	// 2.1 output-bin
+	OutputBin KwOutputBin `ipp:"?output-bin"`
+
 	// PWG5100.7: IPP Job Extensions v2.1 (JOBEXT)
 	// 6.8 Job Template Attributes
+	MediaCol                MediaCol              `ipp:"?media-col"`
 	JobDelayOutputUntil     KwJobDelayOutputUntil `ipp:"?job-delay-output-until"`
 	JobDelayOutputUntilTime time.Time             `ipp:"?job-delay-output-until-time"`
 	JobHoldUntilTime        time.Time             `ipp:"?job-hold-until-time"`
@@ -60,9 +71,143 @@ type JobAttributes struct {
 	// 6.2 Job and Document Template Attributes
 	JobErrorAction       string              `ipp:"?job-error-action,keyword"`
 	MediaOverprint       []JobMediaOverprint `ipp:"?media-overprint"`
-	PrintColorMode       string              `ipp:"?print-color-mode,keyword"`
+	PrintColorMode       KwPrintColorMode    `ipp:"?print-color-mode"`
 	PrintRenderingIntent string              `ipp:"?print-rendering-intent,keyword"`
 	PrintScaling         string              `ipp:"?print-scaling,keyword"`
+
+	// PWG5100.15: IPP FAX Out Service
+	// 7.1 Job Template Attributes
+	ConfirmationSheetPrint bool             `ipp:"?confirmation-sheet-print"`
+	CoverSheetInfo         CoverSheetInfo   `ipp:"?cover-sheet-info"`
+	DestinationUris        []DestinationURI `ipp:"?destination-uris"`
+	NumberOfRetries        int              `ipp:"?number-of-retries,0:MAX"`
+	RetryInterval          int              `ipp:"?retry-interval,1:MAX"`
+	RetryTimeOut           int              `ipp:"?retry-time-out,1:MAX"`
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the JobAttributes
+func (ja *JobAttributes) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(ja)
+}
+
+// JobStatus are Job Status attributes, returned by the Printer
+// in response to Job creation and Job query requests.
+type JobStatus struct {
+	ObjectRawAttrs
+
+	// RFC8011, Internet Printing Protocol/1.1: Model and Semantics
+	// 5.3 Job Status Attributes
+	JobID                     int                 `ipp:"!job-id,1:MAX"`
+	JobURI                    string              `ipp:"!job-uri,uri"`
+	JobState                  int                 `ipp:"!job-state,enum"`
+	JobStateReasons           []KwJobStateReasons `ipp:"!job-state-reasons"`
+	JobStateMessage           string              `ipp:"?job-state-message,text"`
+	NumberOfInterveningJobs   int                 `ipp:"?number-of-intervening-jobs,0:MAX"`
+	JobPrinterURI             string              `ipp:"?job-printer-uri,uri"`
+	JobName                   string              `ipp:"?job-name,name"`
+	JobOriginatingUserName    string              `ipp:"?job-originating-user-name,name"`
+	JobKOctets                int                 `ipp:"?job-k-octets,0:MAX"`
+	JobImpressions            int                 `ipp:"?job-impressions,0:MAX"`
+	JobMediaSheets            int                 `ipp:"?job-media-sheets,0:MAX"`
+	JobKOctetsProcessed       int                 `ipp:"?job-k-octets-processed,0:MAX"`
+	JobImpressionsCompleted   int                 `ipp:"?job-impressions-completed,0:MAX"`
+	JobMediaSheetsCompleted   int                 `ipp:"?job-media-sheets-completed,0:MAX"`
+	JobMoreInfo               string              `ipp:"?job-more-info,uri"`
+	JobPrinterUpTime          int                 `ipp:"?job-printer-up-time,1:MAX"`
+	TimeAtCreation            int                 `ipp:"?time-at-creation"`
+	TimeAtProcessing          int                 `ipp:"?time-at-processing,integer|no-value"`
+	TimeAtCompleted           int                 `ipp:"?time-at-completed,integer|no-value"`
+	DateTimeAtCreation        time.Time           `ipp:"?date-time-at-creation"`
+	DateTimeAtProcessing      time.Time           `ipp:"?date-time-at-processing,datetime|no-value"`
+	DateTimeAtCompleted       time.Time           `ipp:"?date-time-at-completed,datetime|no-value"`
+	NumberOfDocuments         int                 `ipp:"?number-of-documents,0:MAX"`
+	OutputDeviceAssigned      string              `ipp:"?output-device-assigned,name"`
+	JobMessageFromOperator    string              `ipp:"?job-message-from-operator,text"`
+	JobDetailedStatusMessages []string            `ipp:"?job-detailed-status-messages,text"`
+	JobDocumentAccessErrors   []string            `ipp:"?job-document-access-errors,text"`
+
+	// PWG5100.7: IPP Job Extensions v2.1 (JOBEXT)
+	// 6.10 Job Status Attributes
+	JobPages          int `ipp:"?job-pages,0:MAX"`
+	JobPagesCompleted int `ipp:"?job-pages-completed,0:MAX"`
+
+	// PWG5100.15: IPP FAX Out Service
+	// 7.2 Job Status Attributes
+	DestinationStatuses []DestinationStatus `ipp:"?destination-statuses"`
+}
+
+// Job states, for the "job-state" attribute (RFC8011, 5.3.7.)
+const (
+	JobStatePending           = 3 // Job is waiting to be processed
+	JobStatePendingHeld       = 4 // Job is held
+	JobStateProcessing        = 5 // Job is being processed
+	JobStateProcessingStopped = 6 // Job processing is stopped
+	JobStateCanceled          = 7 // Job is canceled
+	JobStateAborted           = 8 // Job is aborted by the system
+	JobStateCompleted         = 9 // Job is completed
+)
+
+// KnownAttrs returns information about all known IPP attributes
+// of the JobStatus
+func (js *JobStatus) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(js)
+}
+
+// IsTerminated reports if the Job is in one of the terminating
+// states (canceled, aborted or completed). The Job state doesn't
+// change after that.
+func (js *JobStatus) IsTerminated() bool {
+	switch js.JobState {
+	case JobStateCanceled, JobStateAborted, JobStateCompleted:
+		return true
+	}
+
+	return false
+}
+
+// Succeeded reports if the Job is completed successfully, i.e.,
+// it is in the completed state and job-state-reasons doesn't
+// report errors.
+func (js *JobStatus) Succeeded() bool {
+	return js.JobState == JobStateCompleted &&
+		!js.HasReason(KwJobStateReasonsJobCompletedWithErrors) &&
+		!js.HasReason(KwJobStateReasonsErrorsDetected)
+}
+
+// FaxSucceeded reports if the FaxOut Job is transmitted successfully
+// to all destinations.
+//
+// In addition to the [JobStatus.Succeeded] checks, it requires that
+// job-state-reasons doesn't contain "destination-uri-failed" and
+// transmission to each destination, reported by destination-statuses,
+// is completed.
+func (js *JobStatus) FaxSucceeded() bool {
+	if !js.Succeeded() ||
+		js.HasReason(KwJobStateReasonsDestinationURIFailed) {
+		return false
+	}
+
+	for _, dst := range js.DestinationStatuses {
+		if dst.TransmissionStatus != 0 &&
+			dst.TransmissionStatus != JobStateCompleted {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasReason reports if job-state-reasons contains the specified
+// reason.
+func (js *JobStatus) HasReason(reason KwJobStateReasons) bool {
+	for _, r := range js.JobStateReasons {
+		if r == reason {
+			return true
+		}
+	}
+
+	return false
 }
 
 // JobTemplate are attributes, included into the Printer Description and
@@ -72,8 +217,8 @@ type JobTemplate struct {
 	// 5.2 Job Template Attributes
 	CopiesDefault                     int                          `ipp:"?copies-default,>0"`
 	CopiesSupported                   goipp.Range                  `ipp:"?copies-supported,>0"`
-	FinishingsDefault                 []int                        `ipp:"?finishings-default,enum"`
-	FinishingsSupported               []int                        `ipp:"?finishings-supported,enum"`
+	FinishingsDefault                 []EnFinishings               `ipp:"?finishings-default"`
+	FinishingsSupported               []EnFinishings               `ipp:"?finishings-supported"`
 	JobHoldUntilDefault               KwJobHoldUntil               `ipp:"?job-hold-until-default"`
 	JobHoldUntilSupported             []KwJobHoldUntil             `ipp:"?job-hold-until-supported"`
 	JobPriorityDefault                int                          `ipp:"?job-priority-default,1:100"`
@@ -97,6 +242,18 @@ type JobTemplate struct {
 	SidesDefault                      KwSides                      `ipp:"?sides-default"`
 	SidesSupported                    []KwSides                    `ipp:"?sides-supported"`
 
+	// PWG5100.1: IPP Finishings 3.0 (FIN)
+	// 6.2 Printer Description Attributes
+	FinishingsColDatabase  []FinishingsCol `ipp:"?finishings-col-database"`
+	FinishingsColDefault   []FinishingsCol `ipp:"?finishings-col-default,collection|no-value"`
+	FinishingsColReady     []FinishingsCol `ipp:"?finishings-col-ready,collection|no-value"`
+	FinishingsColSupported []string        `ipp:"?finishings-col-supported,keyword"`
+
+	// PWG5100.2: IPP "output-bin" attribute extension
+	// 2.1 output-bin
+	OutputBinDefault   KwOutputBin   `ipp:"?output-bin-default"`
+	OutputBinSupported []KwOutputBin `ipp:"?output-bin-supported"`
+
 	// PWG5100.7: IPP Job Extensions v2.1 (JOBEXT)
 	// 6.9 Printer Description Attributes
 	JobAccountIDDefault              string                  `ipp:"job-account-id-default,name|no-value"`
@@ -155,13 +312,35 @@ type JobTemplate struct {
 	MediaOverprintDistanceSupported goipp.Range         `ipp:"?media-overprint-distance-supported,0:MAX"`
 	MediaOverprintMethodSupported   []string            `ipp:"?media-overprint-method-supported,keyword"`
 	MediaOverprintSupported         []string            `ipp:"?media-overprint-supported,keyword"`
-	PrintColorModeDefault           string              `ipp:"?print-color-mode-default,keyword"`
-	PrintColorModeSupported         []string            `ipp:"?print-color-mode-supported,keyword"`
+	PrintColorModeDefault           KwPrintColorMode    `ipp:"?print-color-mode-default"`
+	PrintColorModeSupported         []KwPrintColorMode  `ipp:"?print-color-mode-supported"`
 	PrinterMandatoryJobAttributes   []string            `ipp:"?printer-mandatory-job-attributes,keyword"`
 	PrintRenderingIntentDefault     string              `ipp:"?print-rendering-intent-default,keyword"`
 	PrintRenderingIntentSupported   []string            `ipp:"?print-rendering-intent-supported,keyword"`
 	PrintScalingDefault             string              `ipp:"?print-scaling-default,keyword"`
 	PrintScalingSupported           []string            `ipp:"?print-scaling-supported,keyword"`
+
+	// PWG5100.15: IPP FAX Out Service
+	// 7.3 Printer Description Attributes
+	ConfirmationSheetPrintDefault    bool           `ipp:"?confirmation-sheet-print-default"`
+	CoverSheetInfoDefault            CoverSheetInfo `ipp:"?cover-sheet-info-default"`
+	CoverSheetInfoSupported          []string       `ipp:"?cover-sheet-info-supported,keyword"`
+	DestinationURISchemesSupported   []string       `ipp:"?destination-uri-schemes-supported,uriScheme"`
+	DestinationUrisSupported         []string       `ipp:"?destination-uris-supported,keyword"`
+	FromNameSupported                int            `ipp:"?from-name-supported,0:MAX"`
+	LogoURIFormatsSupported          []string       `ipp:"?logo-uri-formats-supported,mimeMediaType"`
+	LogoURISchemesSupported          []string       `ipp:"?logo-uri-schemes-supported,uriScheme"`
+	MessageSupported                 int            `ipp:"?message-supported,0:MAX"`
+	MultipleDestinationUrisSupported bool           `ipp:"?multiple-destination-uris-supported"`
+	NumberOfRetriesDefault           int            `ipp:"?number-of-retries-default,0:MAX"`
+	NumberOfRetriesSupported         goipp.Range    `ipp:"?number-of-retries-supported,0:MAX"`
+	OrganizationNameSupported        int            `ipp:"?organization-name-supported,0:MAX"`
+	RetryIntervalDefault             int            `ipp:"?retry-interval-default,1:MAX"`
+	RetryIntervalSupported           goipp.Range    `ipp:"?retry-interval-supported,1:MAX"`
+	RetryTimeOutDefault              int            `ipp:"?retry-time-out-default,1:MAX"`
+	RetryTimeOutSupported            goipp.Range    `ipp:"?retry-time-out-supported,1:MAX"`
+	SubjectSupported                 int            `ipp:"?subject-supported,0:MAX"`
+	ToNameSupported                  int            `ipp:"?to-name-supported,0:MAX"`
 }
 
 // MediaCol is the "media-col", "media-col-xxx" collection entry.
@@ -191,7 +370,7 @@ type MediaCol struct {
 	MediaRightMargin      int                   `ipp:"?media-right-margin,0:MAX"`
 	MediaSizeName         string                `ipp:"?media-size-name,keyword"`
 	MediaSourceProperties MediaSourceProperties `ipp:"?media-source-properties"`
-	MediaSource           string                `ipp:"?media-source,keyword"`
+	MediaSource           KwMediaSource         `ipp:"?media-source"`
 	MediaThickness        int                   `ipp:"?media-thickness,1:MAX"`
 	MediaTooth            string                `ipp:"?media-tooth,keyword"`
 	MediaTopMargin        int                   `ipp:"?media-top-margin,0:MAX"`
@@ -204,6 +383,99 @@ type MediaSize struct {
 	YDimension goipp.IntegerOrRange `ipp:"y-dimension,0:MAX"`
 }
 
+// FinishingsCol is the "finishings-col" collection entry.
+//
+// Most of finishing processes are described by the nested
+// collections. They are optional and represented by pointers,
+// so absent and empty collections are distinguishable.
+//
+// PWG5100.1: 5.2., Table 3.
+type FinishingsCol struct {
+	Baling               *FinishingsBaling     `ipp:"?baling"`
+	Binding              *FinishingsBinding    `ipp:"?binding"`
+	Coating              *FinishingsCoating    `ipp:"?coating"`
+	Covering             *FinishingsCovering   `ipp:"?covering"`
+	FinishingTemplate    string                `ipp:"?finishing-template,keyword"`
+	Folding              []FinishingsFolding   `ipp:"?folding"`
+	ImpositionTemplate   string                `ipp:"?imposition-template,keyword"`
+	Laminating           *FinishingsLaminating `ipp:"?laminating"`
+	MediaSheetsSupported goipp.Range           `ipp:"?media-sheets-supported,1:MAX"`
+	MediaSize            *MediaSize            `ipp:"?media-size"`
+	MediaSizeName        string                `ipp:"?media-size-name,keyword"`
+	Punching             *FinishingsPunching   `ipp:"?punching"`
+	Stitching            *FinishingsStitching  `ipp:"?stitching"`
+	Trimming             []FinishingsTrimming  `ipp:"?trimming"`
+}
+
+// FinishingsBaling represents "baling" collection entry
+// in FinishingsCol
+type FinishingsBaling struct {
+	BalingType string `ipp:"?baling-type,keyword"`
+	BalingWhen string `ipp:"?baling-when,keyword"`
+}
+
+// FinishingsBinding represents "binding" collection entry
+// in FinishingsCol
+type FinishingsBinding struct {
+	BindingReferenceEdge string `ipp:"?binding-reference-edge,keyword"`
+	BindingType          string `ipp:"?binding-type,keyword"`
+}
+
+// FinishingsCoating represents "coating" collection entry
+// in FinishingsCol
+type FinishingsCoating struct {
+	CoatingSides string `ipp:"?coating-sides,keyword"`
+	CoatingType  string `ipp:"?coating-type,keyword"`
+}
+
+// FinishingsCovering represents "covering" collection entry
+// in FinishingsCol
+type FinishingsCovering struct {
+	CoveringName string `ipp:"?covering-name,keyword"`
+}
+
+// FinishingsFolding represents "folding" collection entry
+// in FinishingsCol
+type FinishingsFolding struct {
+	FoldingDirection     string `ipp:"?folding-direction,keyword"`
+	FoldingOffset        int    `ipp:"?folding-offset,0:MAX"`
+	FoldingReferenceEdge string `ipp:"?folding-reference-edge,keyword"`
+}
+
+// FinishingsLaminating represents "laminating" collection entry
+// in FinishingsCol
+type FinishingsLaminating struct {
+	LaminatingSides string `ipp:"?laminating-sides,keyword"`
+	LaminatingType  string `ipp:"?laminating-type,keyword"`
+}
+
+// FinishingsPunching represents "punching" collection entry
+// in FinishingsCol
+type FinishingsPunching struct {
+	PunchingLocations     []int  `ipp:"?punching-locations,0:MAX"`
+	PunchingOffset        int    `ipp:"?punching-offset,0:MAX"`
+	PunchingReferenceEdge string `ipp:"?punching-reference-edge,keyword"`
+}
+
+// FinishingsStitching represents "stitching" collection entry
+// in FinishingsCol
+type FinishingsStitching struct {
+	StitchingAngle         int    `ipp:"?stitching-angle,0:359"`
+	StitchingLocations     []int  `ipp:"?stitching-locations,0:MAX"`
+	StitchingMethod        string `ipp:"?stitching-method,keyword"`
+	StitchingOffset        int    `ipp:"?stitching-offset,0:MAX"`
+	StitchingReferenceEdge string `ipp:"?stitching-reference-edge,keyword"`
+}
+
+// FinishingsTrimming represents "trimming" collection entry
+// in FinishingsCol
+type FinishingsTrimming struct {
+	TrimmingOffset        int    `ipp:"?trimming-offset,0:MAX"`
+	TrimmingReferenceEdge string `ipp:"?trimming-reference-edge,keyword"`
+	TrimmingType          string `ipp:"?trimming-type,keyword"`
+	TrimmingWhen          string `ipp:"?trimming-when,keyword"`
+}
+
 // MediaSourceProperties represents "media-source-properties"
 // collectiobn in MediaCol
 type MediaSourceProperties struct {
@@ -250,6 +522,42 @@ type JobMediaOverprint struct {
 	MediaOverprintMethod   string `ipp:"media-overprint-method,keyword"`
 }
 
+// DestinationURI represents "destination-uris" collection entry
+// in JobAttributes.
+//
+// For the FaxOut service, DestinationURI is the "tel:" URI
+// of the fax recipient.
+type DestinationURI struct {
+	DestinationURI string `ipp:"destination-uri,uri"`
+	PostDialString string `ipp:"?post-dial-string,text"`
+	PreDialString  string `ipp:"?pre-dial-string,text"`
+	T33Subaddress  int    `ipp:"?t33-subaddress,1:MAX"`
+}
+
+// CoverSheetInfo represents "cover-sheet-info" collection entry
+// in JobAttributes. If present, the FaxOut service generates
+// the cover sheet, using the supplied information.
+type CoverSheetInfo struct {
+	FromName         string `ipp:"?from-name,text"`
+	Logo             string `ipp:"?logo,uri"`
+	Message          string `ipp:"?message,text"`
+	OrganizationName string `ipp:"?organization-name,text"`
+	Subject          string `ipp:"?subject,text"`
+	ToName           string `ipp:"?to-name,text"`
+}
+
+// DestinationStatus represents "destination-statuses" collection
+// entry in JobStatus. It reports the FaxOut transmission status
+// for the particular destination.
+//
+// TransmissionStatus uses the same values, as the job-state
+// attribute (see JobStatePending and so on).
+type DestinationStatus struct {
+	DestinationURI     string `ipp:"destination-uri,uri"`
+	ImagesCompleted    int    `ipp:"?images-completed,0:MAX"`
+	TransmissionStatus int    `ipp:"?transmission-status,enum"`
+}
+
 // JobPresets represents "job-presets-supported" collection entry
 // in PrinterDescription
 type JobPresets struct {
@@ -0,0 +1,156 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Tests for JobStatus
+
+package ipp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/goipp"
+)
+
+// TestJobStatusPredicates tests JobStatus.IsTerminated and
+// JobStatus.Succeeded
+func TestJobStatusPredicates(t *testing.T) {
+	type testData struct {
+		state      int                 // job-state
+		reasons    []KwJobStateReasons // job-state-reasons
+		terminated bool                // Expected IsTerminated
+		succeeded  bool                // Expected Succeeded
+	}
+
+	tests := []testData{
+		{
+			state:   JobStatePending,
+			reasons: []KwJobStateReasons{KwJobStateReasonsNone},
+		},
+		{
+			state: JobStateProcessing,
+			reasons: []KwJobStateReasons{
+				KwJobStateReasonsJobPrinting,
+			},
+		},
+		{
+			state: JobStateCompleted,
+			reasons: []KwJobStateReasons{
+				KwJobStateReasonsJobCompletedSuccessfully,
+			},
+			terminated: true,
+			succeeded:  true,
+		},
+		{
+			state: JobStateCompleted,
+			reasons: []KwJobStateReasons{
+				KwJobStateReasonsJobCompletedWithWarnings,
+			},
+			terminated: true,
+			succeeded:  true,
+		},
+		{
+			state: JobStateCompleted,
+			reasons: []KwJobStateReasons{
+				KwJobStateReasonsJobCompletedWithErrors,
+			},
+			terminated: true,
+		},
+		{
+			state: JobStateAborted,
+			reasons: []KwJobStateReasons{
+				KwJobStateReasonsAbortedBySystem,
+			},
+			terminated: true,
+		},
+		{
+			state: JobStateCanceled,
+			reasons: []KwJobStateReasons{
+				KwJobStateReasonsJobCanceledByUser,
+			},
+			terminated: true,
+		},
+	}
+
+	for _, test := range tests {
+		js := &JobStatus{
+			JobState:        test.state,
+			JobStateReasons: test.reasons,
+		}
+
+		if js.IsTerminated() != test.terminated {
+			t.Errorf("%d %s: IsTerminated expected %v, present %v",
+				test.state, test.reasons,
+				test.terminated, js.IsTerminated())
+		}
+
+		if js.Succeeded() != test.succeeded {
+			t.Errorf("%d %s: Succeeded expected %v, present %v",
+				test.state, test.reasons,
+				test.succeeded, js.Succeeded())
+		}
+	}
+}
+
+// TestJobStatusDecode tests decoding of JobStatus
+func TestJobStatusDecode(t *testing.T) {
+	created := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	attrs := goipp.Attributes{
+		goipp.MakeAttribute("job-id",
+			goipp.TagInteger, goipp.Integer(15)),
+		goipp.MakeAttribute("job-uri",
+			goipp.TagURI, goipp.String("ipp://localhost/jobs/15")),
+		goipp.MakeAttribute("job-state",
+			goipp.TagEnum, goipp.Integer(JobStateProcessing)),
+		goipp.MakeAttribute("job-state-reasons",
+			goipp.TagKeyword, goipp.String("job-printing")),
+		goipp.MakeAttribute("job-name",
+			goipp.TagName, goipp.String("report.pdf")),
+		goipp.MakeAttribute("job-impressions",
+			goipp.TagInteger, goipp.Integer(10)),
+		goipp.MakeAttribute("job-impressions-completed",
+			goipp.TagInteger, goipp.Integer(4)),
+		goipp.MakeAttribute("job-media-sheets-completed",
+			goipp.TagInteger, goipp.Integer(2)),
+		goipp.MakeAttribute("time-at-creation",
+			goipp.TagInteger, goipp.Integer(100)),
+		goipp.MakeAttribute("time-at-completed",
+			goipp.TagNoValue, goipp.Void{}),
+		goipp.MakeAttribute("date-time-at-creation",
+			goipp.TagDateTime, goipp.Time{Time: created}),
+		goipp.MakeAttribute("date-time-at-completed",
+			goipp.TagNoValue, goipp.Void{}),
+	}
+
+	js := &JobStatus{}
+	err := ippDecodeAttrs(js, attrs)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	expected := &JobStatus{
+		JobID:                   15,
+		JobURI:                  "ipp://localhost/jobs/15",
+		JobState:                JobStateProcessing,
+		JobStateReasons:         []KwJobStateReasons{"job-printing"},
+		JobName:                 "report.pdf",
+		JobImpressions:          10,
+		JobImpressionsCompleted: 4,
+		JobMediaSheetsCompleted: 2,
+		TimeAtCreation:          100,
+		DateTimeAtCreation:      created,
+	}
+
+	diff := testDiffStruct(expected, js)
+	if diff != "" {
+		t.Errorf("input/output mismatch:\n%s", diff)
+	}
+
+	if js.IsTerminated() || !js.HasReason(KwJobStateReasonsJobPrinting) {
+		t.Errorf("predicates mismatch")
+	}
+}
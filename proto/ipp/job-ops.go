@@ -0,0 +1,812 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Job operations requests and responses
+
+package ipp
+
+import (
+	"github.com/OpenPrinting/goipp"
+)
+
+type (
+	// PrintJobRequest operation (0x0002) creates a new Job
+	// with a single document.
+	//
+	// Document data is sent as the request Body.
+	PrintJobRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI           string `ipp:"printer-uri,uri"`
+		RequestingUserName   string `ipp:"?requesting-user-name,name"`
+		JobName              string `ipp:"?job-name,name"`
+		IppAttributeFidelity bool   `ipp:"?ipp-attribute-fidelity"`
+		DocumentName         string `ipp:"?document-name,name"`
+		DocumentFormat       string `ipp:"?document-format,mimeMediaType"`
+
+		// Job template attributes. May be nil
+		Job *JobAttributes
+	}
+
+	// PrintJobResponse is the Print-Job Response.
+	PrintJobResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+
+		// Other attributes.
+		Job *JobStatus
+	}
+
+	// CreateJobRequest operation (0x0005) creates a new Job
+	// without documents. Documents are added with the subsequent
+	// Send-Document requests.
+	CreateJobRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI           string `ipp:"printer-uri,uri"`
+		RequestingUserName   string `ipp:"?requesting-user-name,name"`
+		JobName              string `ipp:"?job-name,name"`
+		IppAttributeFidelity bool   `ipp:"?ipp-attribute-fidelity"`
+
+		// Job template attributes. May be nil
+		Job *JobAttributes
+	}
+
+	// CreateJobResponse is the Create-Job Response.
+	CreateJobResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+
+		// Other attributes.
+		Job *JobStatus
+	}
+
+	// SendDocumentRequest operation (0x0006) adds a document
+	// to the Job, created by the Create-Job request.
+	//
+	// Document data is sent as the request Body.
+	SendDocumentRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI         string `ipp:"printer-uri,uri"`
+		JobID              int    `ipp:"job-id,1:MAX"`
+		RequestingUserName string `ipp:"?requesting-user-name,name"`
+		DocumentName       string `ipp:"?document-name,name"`
+		DocumentFormat     string `ipp:"?document-format,mimeMediaType"`
+		LastDocument       bool   `ipp:"last-document"`
+	}
+
+	// SendDocumentResponse is the Send-Document Response.
+	SendDocumentResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+
+		// Other attributes.
+		Job *JobStatus
+	}
+
+	// GetJobAttributesRequest operation (0x0009) returns the
+	// requested Job attributes.
+	GetJobAttributesRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI          string   `ipp:"printer-uri,uri"`
+		JobID               int      `ipp:"job-id,1:MAX"`
+		RequestingUserName  string   `ipp:"?requesting-user-name,name"`
+		RequestedAttributes []string `ipp:"?requested-attributes,keyword"`
+	}
+
+	// GetJobAttributesResponse is the Get-Job-Attributes Response.
+	GetJobAttributesResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+
+		// Other attributes.
+		Job *JobStatus
+	}
+
+	// CancelJobRequest operation (0x0008) cancels the Job.
+	CancelJobRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI         string `ipp:"printer-uri,uri"`
+		JobID              int    `ipp:"job-id,1:MAX"`
+		RequestingUserName string `ipp:"?requesting-user-name,name"`
+		Message            string `ipp:"?message,text"`
+	}
+
+	// CancelJobResponse is the Cancel-Job Response.
+	CancelJobResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+	}
+
+	// GetJobsRequest operation (0x000a) returns the list of Jobs
+	// and requested attributes of each Job.
+	GetJobsRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI          string      `ipp:"printer-uri,uri"`
+		RequestingUserName  string      `ipp:"?requesting-user-name,name"`
+		Limit               int         `ipp:"?limit,1:MAX"`
+		WhichJobs           KwWhichJobs `ipp:"?which-jobs"`
+		MyJobs              bool        `ipp:"?my-jobs"`
+		RequestedAttributes []string    `ipp:"?requested-attributes,keyword"`
+	}
+
+	// GetJobsResponse is the Get-Jobs Response.
+	GetJobsResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+
+		// Other attributes.
+		Jobs []*JobStatus
+	}
+
+	// HoldJobRequest operation (0x000c) holds the pending Job,
+	// so it is not eligible for scheduling.
+	HoldJobRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI         string         `ipp:"printer-uri,uri"`
+		JobID              int            `ipp:"job-id,1:MAX"`
+		RequestingUserName string         `ipp:"?requesting-user-name,name"`
+		Message            string         `ipp:"?message,text"`
+		JobHoldUntil       KwJobHoldUntil `ipp:"?job-hold-until"`
+	}
+
+	// HoldJobResponse is the Hold-Job Response.
+	HoldJobResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+	}
+
+	// ReleaseJobRequest operation (0x000d) releases the Job,
+	// previously held by the Hold-Job operation.
+	ReleaseJobRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI         string `ipp:"printer-uri,uri"`
+		JobID              int    `ipp:"job-id,1:MAX"`
+		RequestingUserName string `ipp:"?requesting-user-name,name"`
+		Message            string `ipp:"?message,text"`
+	}
+
+	// ReleaseJobResponse is the Release-Job Response.
+	ReleaseJobResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+	}
+
+	// RestartJobRequest operation (0x000e) restarts the Job,
+	// that is retained after processing.
+	RestartJobRequest struct {
+		ObjectRawAttrs
+		RequestHeader
+
+		// Operation attributes
+		PrinterURI         string `ipp:"printer-uri,uri"`
+		JobID              int    `ipp:"job-id,1:MAX"`
+		RequestingUserName string `ipp:"?requesting-user-name,name"`
+		Message            string `ipp:"?message,text"`
+	}
+
+	// RestartJobResponse is the Restart-Job Response.
+	RestartJobResponse struct {
+		ObjectRawAttrs
+		ResponseHeader
+	}
+)
+
+// ----- Print-Job methods -----
+
+// GetOp returns PrintJobRequest IPP Operation code.
+func (rq *PrintJobRequest) GetOp() goipp.Op {
+	return goipp.OpPrintJob
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the PrintJobRequest
+func (rq *PrintJobRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes PrintJobRequest into the goipp.Message.
+func (rq *PrintJobRequest) Encode() *goipp.Message {
+	return jobTemplateEncode(&rq.RequestHeader, rq.GetOp(),
+		ippEncodeAttrs(rq), rq.Job)
+}
+
+// Decode decodes PrintJobRequest from goipp.Message.
+func (rq *PrintJobRequest) Decode(msg *goipp.Message) error {
+	return jobTemplateDecode(msg, &rq.RequestHeader, rq, &rq.Job)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the PrintJobResponse.
+func (rsp *PrintJobResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes PrintJobResponse into goipp.Message.
+func (rsp *PrintJobResponse) Encode() *goipp.Message {
+	return jobStatusEncode(&rsp.ResponseHeader, ippEncodeAttrs(rsp),
+		rsp.Job)
+}
+
+// Decode decodes PrintJobResponse from goipp.Message.
+func (rsp *PrintJobResponse) Decode(msg *goipp.Message) error {
+	return jobStatusDecode(msg, &rsp.ResponseHeader, rsp, &rsp.Job)
+}
+
+// ----- Create-Job methods -----
+
+// GetOp returns CreateJobRequest IPP Operation code.
+func (rq *CreateJobRequest) GetOp() goipp.Op {
+	return goipp.OpCreateJob
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the CreateJobRequest
+func (rq *CreateJobRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes CreateJobRequest into the goipp.Message.
+func (rq *CreateJobRequest) Encode() *goipp.Message {
+	return jobTemplateEncode(&rq.RequestHeader, rq.GetOp(),
+		ippEncodeAttrs(rq), rq.Job)
+}
+
+// Decode decodes CreateJobRequest from goipp.Message.
+func (rq *CreateJobRequest) Decode(msg *goipp.Message) error {
+	return jobTemplateDecode(msg, &rq.RequestHeader, rq, &rq.Job)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the CreateJobResponse.
+func (rsp *CreateJobResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes CreateJobResponse into goipp.Message.
+func (rsp *CreateJobResponse) Encode() *goipp.Message {
+	return jobStatusEncode(&rsp.ResponseHeader, ippEncodeAttrs(rsp),
+		rsp.Job)
+}
+
+// Decode decodes CreateJobResponse from goipp.Message.
+func (rsp *CreateJobResponse) Decode(msg *goipp.Message) error {
+	return jobStatusDecode(msg, &rsp.ResponseHeader, rsp, &rsp.Job)
+}
+
+// ----- Send-Document methods -----
+
+// GetOp returns SendDocumentRequest IPP Operation code.
+func (rq *SendDocumentRequest) GetOp() goipp.Op {
+	return goipp.OpSendDocument
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the SendDocumentRequest
+func (rq *SendDocumentRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes SendDocumentRequest into the goipp.Message.
+func (rq *SendDocumentRequest) Encode() *goipp.Message {
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: ippEncodeAttrs(rq),
+		},
+	}
+
+	msg := goipp.NewMessageWithGroups(rq.Version, goipp.Code(rq.GetOp()),
+		rq.RequestID, groups)
+
+	return msg
+}
+
+// Decode decodes SendDocumentRequest from goipp.Message.
+func (rq *SendDocumentRequest) Decode(msg *goipp.Message) error {
+	rq.Version = msg.Version
+	rq.RequestID = msg.RequestID
+
+	return ippDecodeAttrs(rq, msg.Operation)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the SendDocumentResponse.
+func (rsp *SendDocumentResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes SendDocumentResponse into goipp.Message.
+func (rsp *SendDocumentResponse) Encode() *goipp.Message {
+	return jobStatusEncode(&rsp.ResponseHeader, ippEncodeAttrs(rsp),
+		rsp.Job)
+}
+
+// Decode decodes SendDocumentResponse from goipp.Message.
+func (rsp *SendDocumentResponse) Decode(msg *goipp.Message) error {
+	return jobStatusDecode(msg, &rsp.ResponseHeader, rsp, &rsp.Job)
+}
+
+// ----- Get-Job-Attributes methods -----
+
+// GetOp returns GetJobAttributesRequest IPP Operation code.
+func (rq *GetJobAttributesRequest) GetOp() goipp.Op {
+	return goipp.OpGetJobAttributes
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the GetJobAttributesRequest
+func (rq *GetJobAttributesRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes GetJobAttributesRequest into the goipp.Message.
+func (rq *GetJobAttributesRequest) Encode() *goipp.Message {
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: ippEncodeAttrs(rq),
+		},
+	}
+
+	msg := goipp.NewMessageWithGroups(rq.Version, goipp.Code(rq.GetOp()),
+		rq.RequestID, groups)
+
+	return msg
+}
+
+// Decode decodes GetJobAttributesRequest from goipp.Message.
+func (rq *GetJobAttributesRequest) Decode(msg *goipp.Message) error {
+	rq.Version = msg.Version
+	rq.RequestID = msg.RequestID
+
+	return ippDecodeAttrs(rq, msg.Operation)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the GetJobAttributesResponse.
+func (rsp *GetJobAttributesResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes GetJobAttributesResponse into goipp.Message.
+func (rsp *GetJobAttributesResponse) Encode() *goipp.Message {
+	return jobStatusEncode(&rsp.ResponseHeader, ippEncodeAttrs(rsp),
+		rsp.Job)
+}
+
+// Decode decodes GetJobAttributesResponse from goipp.Message.
+func (rsp *GetJobAttributesResponse) Decode(msg *goipp.Message) error {
+	return jobStatusDecode(msg, &rsp.ResponseHeader, rsp, &rsp.Job)
+}
+
+// ----- Cancel-Job methods -----
+
+// GetOp returns CancelJobRequest IPP Operation code.
+func (rq *CancelJobRequest) GetOp() goipp.Op {
+	return goipp.OpCancelJob
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the CancelJobRequest
+func (rq *CancelJobRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes CancelJobRequest into the goipp.Message.
+func (rq *CancelJobRequest) Encode() *goipp.Message {
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: ippEncodeAttrs(rq),
+		},
+	}
+
+	msg := goipp.NewMessageWithGroups(rq.Version, goipp.Code(rq.GetOp()),
+		rq.RequestID, groups)
+
+	return msg
+}
+
+// Decode decodes CancelJobRequest from goipp.Message.
+func (rq *CancelJobRequest) Decode(msg *goipp.Message) error {
+	rq.Version = msg.Version
+	rq.RequestID = msg.RequestID
+
+	return ippDecodeAttrs(rq, msg.Operation)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the CancelJobResponse.
+func (rsp *CancelJobResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes CancelJobResponse into goipp.Message.
+func (rsp *CancelJobResponse) Encode() *goipp.Message {
+	return jobStatusEncode(&rsp.ResponseHeader, ippEncodeAttrs(rsp), nil)
+}
+
+// Decode decodes CancelJobResponse from goipp.Message.
+func (rsp *CancelJobResponse) Decode(msg *goipp.Message) error {
+	var job *JobStatus
+	return jobStatusDecode(msg, &rsp.ResponseHeader, rsp, &job)
+}
+
+// ----- Get-Jobs methods -----
+
+// GetOp returns GetJobsRequest IPP Operation code.
+func (rq *GetJobsRequest) GetOp() goipp.Op {
+	return goipp.OpGetJobs
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the GetJobsRequest
+func (rq *GetJobsRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes GetJobsRequest into the goipp.Message.
+func (rq *GetJobsRequest) Encode() *goipp.Message {
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: ippEncodeAttrs(rq),
+		},
+	}
+
+	msg := goipp.NewMessageWithGroups(rq.Version, goipp.Code(rq.GetOp()),
+		rq.RequestID, groups)
+
+	return msg
+}
+
+// Decode decodes GetJobsRequest from goipp.Message.
+func (rq *GetJobsRequest) Decode(msg *goipp.Message) error {
+	rq.Version = msg.Version
+	rq.RequestID = msg.RequestID
+
+	return ippDecodeAttrs(rq, msg.Operation)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the GetJobsResponse.
+func (rsp *GetJobsResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes GetJobsResponse into goipp.Message.
+func (rsp *GetJobsResponse) Encode() *goipp.Message {
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: ippEncodeAttrs(rsp),
+		},
+	}
+
+	for _, job := range rsp.Jobs {
+		groups.Add(goipp.Group{
+			Tag:   goipp.TagJobGroup,
+			Attrs: ippEncodeAttrs(job),
+		})
+	}
+
+	msg := goipp.NewMessageWithGroups(rsp.Version, goipp.Code(rsp.Status),
+		rsp.RequestID, groups)
+
+	return msg
+}
+
+// Decode decodes GetJobsResponse from goipp.Message.
+func (rsp *GetJobsResponse) Decode(msg *goipp.Message) error {
+	rsp.Version = msg.Version
+	rsp.RequestID = msg.RequestID
+	rsp.Status = goipp.Status(msg.Code)
+
+	err := ippDecodeAttrs(rsp, msg.Operation)
+	if err != nil {
+		return err
+	}
+
+	for _, grp := range msg.Groups {
+		if grp.Tag == goipp.TagJobGroup && len(grp.Attrs) > 0 {
+			job := &JobStatus{}
+			err = ippDecodeAttrs(job, grp.Attrs)
+			if err != nil {
+				return err
+			}
+
+			rsp.Jobs = append(rsp.Jobs, job)
+		}
+	}
+
+	return nil
+}
+
+// ----- Hold-Job methods -----
+
+// GetOp returns HoldJobRequest IPP Operation code.
+func (rq *HoldJobRequest) GetOp() goipp.Op {
+	return goipp.OpHoldJob
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the HoldJobRequest
+func (rq *HoldJobRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes HoldJobRequest into the goipp.Message.
+func (rq *HoldJobRequest) Encode() *goipp.Message {
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: ippEncodeAttrs(rq),
+		},
+	}
+
+	msg := goipp.NewMessageWithGroups(rq.Version, goipp.Code(rq.GetOp()),
+		rq.RequestID, groups)
+
+	return msg
+}
+
+// Decode decodes HoldJobRequest from goipp.Message.
+func (rq *HoldJobRequest) Decode(msg *goipp.Message) error {
+	rq.Version = msg.Version
+	rq.RequestID = msg.RequestID
+
+	return ippDecodeAttrs(rq, msg.Operation)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the HoldJobResponse.
+func (rsp *HoldJobResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes HoldJobResponse into goipp.Message.
+func (rsp *HoldJobResponse) Encode() *goipp.Message {
+	return jobStatusEncode(&rsp.ResponseHeader, ippEncodeAttrs(rsp), nil)
+}
+
+// Decode decodes HoldJobResponse from goipp.Message.
+func (rsp *HoldJobResponse) Decode(msg *goipp.Message) error {
+	var job *JobStatus
+	return jobStatusDecode(msg, &rsp.ResponseHeader, rsp, &job)
+}
+
+// ----- Release-Job methods -----
+
+// GetOp returns ReleaseJobRequest IPP Operation code.
+func (rq *ReleaseJobRequest) GetOp() goipp.Op {
+	return goipp.OpReleaseJob
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the ReleaseJobRequest
+func (rq *ReleaseJobRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes ReleaseJobRequest into the goipp.Message.
+func (rq *ReleaseJobRequest) Encode() *goipp.Message {
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: ippEncodeAttrs(rq),
+		},
+	}
+
+	msg := goipp.NewMessageWithGroups(rq.Version, goipp.Code(rq.GetOp()),
+		rq.RequestID, groups)
+
+	return msg
+}
+
+// Decode decodes ReleaseJobRequest from goipp.Message.
+func (rq *ReleaseJobRequest) Decode(msg *goipp.Message) error {
+	rq.Version = msg.Version
+	rq.RequestID = msg.RequestID
+
+	return ippDecodeAttrs(rq, msg.Operation)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the ReleaseJobResponse.
+func (rsp *ReleaseJobResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes ReleaseJobResponse into goipp.Message.
+func (rsp *ReleaseJobResponse) Encode() *goipp.Message {
+	return jobStatusEncode(&rsp.ResponseHeader, ippEncodeAttrs(rsp), nil)
+}
+
+// Decode decodes ReleaseJobResponse from goipp.Message.
+func (rsp *ReleaseJobResponse) Decode(msg *goipp.Message) error {
+	var job *JobStatus
+	return jobStatusDecode(msg, &rsp.ResponseHeader, rsp, &job)
+}
+
+// ----- Restart-Job methods -----
+
+// GetOp returns RestartJobRequest IPP Operation code.
+func (rq *RestartJobRequest) GetOp() goipp.Op {
+	return goipp.OpRestartJob
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the RestartJobRequest
+func (rq *RestartJobRequest) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rq)
+}
+
+// Encode encodes RestartJobRequest into the goipp.Message.
+func (rq *RestartJobRequest) Encode() *goipp.Message {
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: ippEncodeAttrs(rq),
+		},
+	}
+
+	msg := goipp.NewMessageWithGroups(rq.Version, goipp.Code(rq.GetOp()),
+		rq.RequestID, groups)
+
+	return msg
+}
+
+// Decode decodes RestartJobRequest from goipp.Message.
+func (rq *RestartJobRequest) Decode(msg *goipp.Message) error {
+	rq.Version = msg.Version
+	rq.RequestID = msg.RequestID
+
+	return ippDecodeAttrs(rq, msg.Operation)
+}
+
+// KnownAttrs returns information about all known IPP attributes
+// of the RestartJobResponse.
+func (rsp *RestartJobResponse) KnownAttrs() []AttrInfo {
+	return ippKnownAttrs(rsp)
+}
+
+// Encode encodes RestartJobResponse into goipp.Message.
+func (rsp *RestartJobResponse) Encode() *goipp.Message {
+	return jobStatusEncode(&rsp.ResponseHeader, ippEncodeAttrs(rsp), nil)
+}
+
+// Decode decodes RestartJobResponse from goipp.Message.
+func (rsp *RestartJobResponse) Decode(msg *goipp.Message) error {
+	var job *JobStatus
+	return jobStatusDecode(msg, &rsp.ResponseHeader, rsp, &job)
+}
+
+// ----- Common helpers -----
+
+// jobTemplateEncode encodes request with optional Job Template
+// attributes group.
+func jobTemplateEncode(rqh *RequestHeader, op goipp.Op,
+	attrs goipp.Attributes, job *JobAttributes) *goipp.Message {
+
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: attrs,
+		},
+	}
+
+	if job != nil {
+		attrs := ippEncodeAttrs(job)
+		if len(attrs) != 0 {
+			groups.Add(goipp.Group{
+				Tag:   goipp.TagJobGroup,
+				Attrs: attrs,
+			})
+		}
+	}
+
+	msg := goipp.NewMessageWithGroups(rqh.Version, goipp.Code(op),
+		rqh.RequestID, groups)
+
+	return msg
+}
+
+// jobTemplateDecode decodes request with optional Job Template
+// attributes group.
+func jobTemplateDecode(msg *goipp.Message, rqh *RequestHeader,
+	rq Object, job **JobAttributes) error {
+
+	rqh.Version = msg.Version
+	rqh.RequestID = msg.RequestID
+
+	err := ippDecodeAttrs(rq, msg.Operation)
+	if err != nil {
+		return err
+	}
+
+	if len(msg.Job) != 0 {
+		*job = &JobAttributes{}
+		err = ippDecodeAttrs(*job, msg.Job)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jobStatusEncode encodes response with optional Job Status
+// attributes group.
+func jobStatusEncode(rsph *ResponseHeader, op goipp.Attributes,
+	job *JobStatus) *goipp.Message {
+
+	groups := goipp.Groups{
+		{
+			Tag:   goipp.TagOperationGroup,
+			Attrs: op,
+		},
+	}
+
+	if job != nil {
+		groups.Add(goipp.Group{
+			Tag:   goipp.TagJobGroup,
+			Attrs: ippEncodeAttrs(job),
+		})
+	}
+
+	msg := goipp.NewMessageWithGroups(rsph.Version, goipp.Code(rsph.Status),
+		rsph.RequestID, groups)
+
+	return msg
+}
+
+// jobStatusDecode decodes response with optional Job Status
+// attributes group.
+func jobStatusDecode(msg *goipp.Message, rsph *ResponseHeader,
+	rsp Object, job **JobStatus) error {
+
+	rsph.Version = msg.Version
+	rsph.RequestID = msg.RequestID
+	rsph.Status = goipp.Status(msg.Code)
+
+	err := ippDecodeAttrs(rsp, msg.Operation)
+	if err != nil {
+		return err
+	}
+
+	if len(msg.Job) != 0 {
+		*job = &JobStatus{}
+		err = ippDecodeAttrs(*job, msg.Job)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
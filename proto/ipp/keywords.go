@@ -10,6 +10,8 @@ package ipp
 
 import (
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -370,6 +372,66 @@ const (
 	KwPdlOverrideNotAttempted KwPdlOverride = "not-attempted"
 )
 
+// KwPrintColorMode represents standard keyword values for
+// "print-color-mode" attribute.
+//
+// PWG5100.13: 6.2.3.
+type KwPrintColorMode string
+
+const (
+	// KwPrintColorModeAuto means automatic, based on document
+	KwPrintColorModeAuto KwPrintColorMode = "auto"
+
+	// KwPrintColorModeAutoMonochrome means automatic, based on
+	// document, but monochrome output is printed as grayscale
+	// or black only
+	KwPrintColorModeAutoMonochrome KwPrintColorMode = "auto-monochrome"
+
+	// KwPrintColorModeBiLevel means 1-colorant (black) with no shades
+	KwPrintColorModeBiLevel KwPrintColorMode = "bi-level"
+
+	// KwPrintColorModeColor means full-color
+	KwPrintColorModeColor KwPrintColorMode = "color"
+
+	// KwPrintColorModeHighlight means 1-colorant plus black
+	KwPrintColorModeHighlight KwPrintColorMode = "highlight"
+
+	// KwPrintColorModeMonochrome means 1-colorant (black) with shades
+	KwPrintColorModeMonochrome KwPrintColorMode = "monochrome"
+
+	// KwPrintColorModeProcessBiLevel means 1-colorant (black) with
+	// no shades, produced using process colors
+	KwPrintColorModeProcessBiLevel KwPrintColorMode = "process-bi-level"
+
+	// KwPrintColorModeProcessMonochrome means 1-colorant (black)
+	// with shades, produced using process colors
+	KwPrintColorModeProcessMonochrome KwPrintColorMode = "process-monochrome"
+)
+
+// DecodeKwPrintColorMode decodes [KwPrintColorMode] out of its string
+// representation. If s is not a standard value, "" is returned.
+func DecodeKwPrintColorMode(s string) KwPrintColorMode {
+	return kwDecode(kwPrintColorModeValues, s)
+}
+
+// KwPrintColorModeNames returns all standard KwPrintColorMode values
+// as strings.
+func KwPrintColorModeNames() []string {
+	return kwNames(kwPrintColorModeValues)
+}
+
+// kwPrintColorModeValues contains all standard KwPrintColorMode values
+var kwPrintColorModeValues = []KwPrintColorMode{
+	KwPrintColorModeAuto,
+	KwPrintColorModeAutoMonochrome,
+	KwPrintColorModeBiLevel,
+	KwPrintColorModeColor,
+	KwPrintColorModeHighlight,
+	KwPrintColorModeMonochrome,
+	KwPrintColorModeProcessBiLevel,
+	KwPrintColorModeProcessMonochrome,
+}
+
 // KwPrinterStateReasons represents standard keyword values for
 // "printer-state-reasons" attribute.
 //
@@ -602,6 +664,7 @@ var kwRegisteredTypes = map[reflect.Type]struct{}{
 	reflect.TypeOf(KwMediaBackCoating("")):         struct{}{},
 	reflect.TypeOf(KwMultipleDocumentHandling("")): struct{}{},
 	reflect.TypeOf(KwPdlOverride("")):              struct{}{},
+	reflect.TypeOf(KwPrintColorMode("")):           struct{}{},
 	reflect.TypeOf(KwPrinterStateReasons("")):      struct{}{},
 	reflect.TypeOf(KwSides("")):                    struct{}{},
 	reflect.TypeOf(KwURIAuthentication("")):        struct{}{},
@@ -612,4 +675,39 @@ var kwRegisteredTypes = map[reflect.Type]struct{}{
 	reflect.TypeOf(KwColor("")):       struct{}{},
 	reflect.TypeOf(KwDeviceClass("")): struct{}{},
 	reflect.TypeOf(KwMedia("")):       struct{}{},
+	reflect.TypeOf(KwMediaSource("")): struct{}{},
+	reflect.TypeOf(KwOutputBin("")):   struct{}{},
+}
+
+// kwDecode returns s as keyword of type T, if s is one of
+// the known values, or "" otherwise.
+func kwDecode[T ~string](known []T, s string) T {
+	if slices.Contains(known, T(s)) {
+		return T(s)
+	}
+	return ""
+}
+
+// kwNames returns known keyword values as strings.
+func kwNames[T ~string](known []T) []string {
+	names := make([]string, len(known))
+	for i, kw := range known {
+		names[i] = string(kw)
+	}
+	return names
+}
+
+// kwNumbered returns the numbered keyword, like "tray-1".
+func kwNumbered[T ~string](prefix string, n int) T {
+	return T(prefix + "-" + strconv.Itoa(n))
+}
+
+// kwNumberedRange returns numbered keywords from prefix-1
+// to prefix-max.
+func kwNumberedRange[T ~string](prefix string, max int) []T {
+	kws := make([]T, max)
+	for i := range kws {
+		kws[i] = kwNumbered[T](prefix, i+1)
+	}
+	return kws
 }
@@ -35,3 +35,77 @@ func TestKwPrinterStateReasons(t *testing.T) {
 		}
 	}
 }
+
+// TestKwDecode tests decoding of keywords with the standard set
+// of values
+func TestKwDecode(t *testing.T) {
+	type testData struct {
+		input  string
+		output string
+	}
+
+	tests := []struct {
+		name   string
+		decode func(string) string
+		data   []testData
+	}{
+		{
+			name: "KwPrintColorMode",
+			decode: func(s string) string {
+				return string(DecodeKwPrintColorMode(s))
+			},
+			data: []testData{
+				{"color", "color"},
+				{"process-bi-level", "process-bi-level"},
+				{"grayscale", ""},
+				{"", ""},
+			},
+		},
+		{
+			name: "KwMediaSource",
+			decode: func(s string) string {
+				return string(DecodeKwMediaSource(s))
+			},
+			data: []testData{
+				{"main", "main"},
+				{"tray-1", "tray-1"},
+				{"tray-20", "tray-20"},
+				{"tray-21", ""},
+				{"roll-0", ""},
+				{"tray", ""},
+			},
+		},
+		{
+			name: "KwOutputBin",
+			decode: func(s string) string {
+				return string(DecodeKwOutputBin(s))
+			},
+			data: []testData{
+				{"face-down", "face-down"},
+				{"mailbox-10", "mailbox-10"},
+				{"stacker-3", "stacker-3"},
+				{"stacker-11", ""},
+				{"unknown", ""},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		for _, data := range test.data {
+			output := test.decode(data.input)
+			if output != data.output {
+				t.Errorf("Decode%s(%q): expected %q, present %q",
+					test.name, data.input, data.output, output)
+			}
+		}
+	}
+
+	if KwMediaSourceTray(3) != "tray-3" {
+		t.Errorf("KwMediaSourceTray(3): %q", KwMediaSourceTray(3))
+	}
+
+	names := KwPrintColorModeNames()
+	if len(names) != len(kwPrintColorModeValues) || names[0] != "auto" {
+		t.Errorf("KwPrintColorModeNames: unexpected result %v", names)
+	}
+}
@@ -8,6 +8,8 @@
 
 package ipp
 
+import "slices"
+
 // KwMedia represents standard media size. Used in many places
 type KwMedia string
 
@@ -235,6 +237,18 @@ func (kw KwMedia) Size() (wid, hei int) {
 	return -1, -1
 }
 
+// KwMediaNames returns all standard KwMedia values as strings,
+// sorted alphabetically.
+func KwMediaNames() []string {
+	names := make([]string, 0, len(kwMediaByName))
+	for kw := range kwMediaByName {
+		names = append(names, string(kw))
+	}
+
+	slices.Sort(names)
+	return names
+}
+
 // kwMediaSize represents media size, associated with the media name.
 type kwMediaSize struct {
 	wid, hei int // in 1/100 mm
@@ -0,0 +1,96 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Media sources
+
+package ipp
+
+import "slices"
+
+// KwMediaSource represents standard keyword values for
+// "media-source" attribute.
+//
+// PWG5100.7: 6.3.1.11.
+// PWG5100.13: 5.2.
+type KwMediaSource string
+
+// Standard values for KwMediaSource. Besides these, there are
+// numbered sources: "tray-1"..."tray-20" and "roll-1"..."roll-10".
+// Use [KwMediaSourceTray] and [KwMediaSourceRoll] to generate them.
+const (
+	KwMediaSourceAlternate     KwMediaSource = "alternate"
+	KwMediaSourceAlternateRoll KwMediaSource = "alternate-roll"
+	KwMediaSourceAuto          KwMediaSource = "auto"
+	KwMediaSourceBottom        KwMediaSource = "bottom"
+	KwMediaSourceByPassTray    KwMediaSource = "by-pass-tray"
+	KwMediaSourceCenter        KwMediaSource = "center"
+	KwMediaSourceDisc          KwMediaSource = "disc"
+	KwMediaSourceEnvelope      KwMediaSource = "envelope"
+	KwMediaSourceHagaki        KwMediaSource = "hagaki"
+	KwMediaSourceLargeCapacity KwMediaSource = "large-capacity"
+	KwMediaSourceLeft          KwMediaSource = "left"
+	KwMediaSourceMain          KwMediaSource = "main"
+	KwMediaSourceMainRoll      KwMediaSource = "main-roll"
+	KwMediaSourceManual        KwMediaSource = "manual"
+	KwMediaSourceMiddle        KwMediaSource = "middle"
+	KwMediaSourcePhoto         KwMediaSource = "photo"
+	KwMediaSourceRear          KwMediaSource = "rear"
+	KwMediaSourceRight         KwMediaSource = "right"
+	KwMediaSourceSide          KwMediaSource = "side"
+	KwMediaSourceTop           KwMediaSource = "top"
+)
+
+// KwMediaSourceTray returns KwMediaSource for the n-th tray
+// ("tray-1"..."tray-20").
+func KwMediaSourceTray(n int) KwMediaSource {
+	return kwNumbered[KwMediaSource]("tray", n)
+}
+
+// KwMediaSourceRoll returns KwMediaSource for the n-th roll
+// ("roll-1"..."roll-10").
+func KwMediaSourceRoll(n int) KwMediaSource {
+	return kwNumbered[KwMediaSource]("roll", n)
+}
+
+// DecodeKwMediaSource decodes [KwMediaSource] out of its string
+// representation. If s is not a standard value, "" is returned.
+func DecodeKwMediaSource(s string) KwMediaSource {
+	return kwDecode(kwMediaSourceValues, s)
+}
+
+// KwMediaSourceNames returns all standard KwMediaSource values
+// as strings.
+func KwMediaSourceNames() []string {
+	return kwNames(kwMediaSourceValues)
+}
+
+// kwMediaSourceValues contains all standard KwMediaSource values
+var kwMediaSourceValues = slices.Concat(
+	[]KwMediaSource{
+		KwMediaSourceAlternate,
+		KwMediaSourceAlternateRoll,
+		KwMediaSourceAuto,
+		KwMediaSourceBottom,
+		KwMediaSourceByPassTray,
+		KwMediaSourceCenter,
+		KwMediaSourceDisc,
+		KwMediaSourceEnvelope,
+		KwMediaSourceHagaki,
+		KwMediaSourceLargeCapacity,
+		KwMediaSourceLeft,
+		KwMediaSourceMain,
+		KwMediaSourceMainRoll,
+		KwMediaSourceManual,
+		KwMediaSourceMiddle,
+		KwMediaSourcePhoto,
+		KwMediaSourceRear,
+		KwMediaSourceRight,
+		KwMediaSourceSide,
+		KwMediaSourceTop,
+	},
+	kwNumberedRange[KwMediaSource]("roll", 10),
+	kwNumberedRange[KwMediaSource]("tray", 20),
+)
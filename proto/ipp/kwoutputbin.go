@@ -0,0 +1,89 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Output bins
+
+package ipp
+
+import "slices"
+
+// KwOutputBin represents standard keyword values for
+// "output-bin" attribute.
+//
+// PWG5100.2: 2.1.
+type KwOutputBin string
+
+// Standard values for KwOutputBin. Besides these, there are numbered
+// bins: "tray-1"..."tray-10", "stacker-1"..."stacker-10" and
+// "mailbox-1"..."mailbox-10". Use [KwOutputBinTray], [KwOutputBinStacker]
+// and [KwOutputBinMailbox] to generate them.
+const (
+	KwOutputBinAuto          KwOutputBin = "auto"
+	KwOutputBinBottom        KwOutputBin = "bottom"
+	KwOutputBinCenter        KwOutputBin = "center"
+	KwOutputBinFaceDown      KwOutputBin = "face-down"
+	KwOutputBinFaceUp        KwOutputBin = "face-up"
+	KwOutputBinLargeCapacity KwOutputBin = "large-capacity"
+	KwOutputBinLeft          KwOutputBin = "left"
+	KwOutputBinMiddle        KwOutputBin = "middle"
+	KwOutputBinMyMailbox     KwOutputBin = "my-mailbox"
+	KwOutputBinRear          KwOutputBin = "rear"
+	KwOutputBinRight         KwOutputBin = "right"
+	KwOutputBinSide          KwOutputBin = "side"
+	KwOutputBinTop           KwOutputBin = "top"
+)
+
+// KwOutputBinTray returns KwOutputBin for the n-th tray
+// ("tray-1"..."tray-10").
+func KwOutputBinTray(n int) KwOutputBin {
+	return kwNumbered[KwOutputBin]("tray", n)
+}
+
+// KwOutputBinStacker returns KwOutputBin for the n-th stacker
+// ("stacker-1"..."stacker-10").
+func KwOutputBinStacker(n int) KwOutputBin {
+	return kwNumbered[KwOutputBin]("stacker", n)
+}
+
+// KwOutputBinMailbox returns KwOutputBin for the n-th mailbox
+// ("mailbox-1"..."mailbox-10").
+func KwOutputBinMailbox(n int) KwOutputBin {
+	return kwNumbered[KwOutputBin]("mailbox", n)
+}
+
+// DecodeKwOutputBin decodes [KwOutputBin] out of its string
+// representation. If s is not a standard value, "" is returned.
+func DecodeKwOutputBin(s string) KwOutputBin {
+	return kwDecode(kwOutputBinValues, s)
+}
+
+// KwOutputBinNames returns all standard KwOutputBin values
+// as strings.
+func KwOutputBinNames() []string {
+	return kwNames(kwOutputBinValues)
+}
+
+// kwOutputBinValues contains all standard KwOutputBin values
+var kwOutputBinValues = slices.Concat(
+	[]KwOutputBin{
+		KwOutputBinAuto,
+		KwOutputBinBottom,
+		KwOutputBinCenter,
+		KwOutputBinFaceDown,
+		KwOutputBinFaceUp,
+		KwOutputBinLargeCapacity,
+		KwOutputBinLeft,
+		KwOutputBinMiddle,
+		KwOutputBinMyMailbox,
+		KwOutputBinRear,
+		KwOutputBinRight,
+		KwOutputBinSide,
+		KwOutputBinTop,
+	},
+	kwNumberedRange[KwOutputBin]("mailbox", 10),
+	kwNumberedRange[KwOutputBin]("stacker", 10),
+	kwNumberedRange[KwOutputBin]("tray", 10),
+)
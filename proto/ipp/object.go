@@ -33,6 +33,7 @@ type Object interface {
 type ObjectRawAttrs struct {
 	attrs  goipp.Attributes
 	byName map[string]goipp.Attribute
+	exts   []Object // Attached vendor extensions
 }
 
 // RawAttrs returns [ObjecRawtAttrs], which gives uniform
@@ -129,7 +129,7 @@ type PrinterDescription struct {
 	MediaPrePrintedSupported         []string             `ipp:"?media-pre-printed-supported,keyword"`
 	MediaRecycledSupported           []string             `ipp:"?media-recycled-supported,keyword"`
 	MediaRightMarginSupported        []int                `ipp:"!media-right-margin-supported,0:MAX"`
-	MediaSourceSupported             []string             `ipp:"!media-source-supported,keyword"`
+	MediaSourceSupported             []KwMediaSource      `ipp:"!media-source-supported"`
 	MediaThicknessSupported          []goipp.Range        `ipp:"?media-thickness-supported,1:MAX"`
 	MediaToothSupported              []string             `ipp:"?media-tooth-supported,keyword"`
 	MediaTopMarginSupported          []int                `ipp:"!media-top-margin-supported,0:MAX"`
@@ -195,6 +195,13 @@ type PrinterDescription struct {
 	MarkerTypes      []string `ipp:"?marker-types,keyword"`
 }
 
+// Printer states, for the "printer-state" attribute (RFC8011, 5.4.11.)
+const (
+	PrinterStateIdle       = 3 // Printer is idle
+	PrinterStateProcessing = 4 // Printer is processing jobs
+	PrinterStateStopped    = 5 // Printer is stopped
+)
+
 // PrinterJobSaveDisposition represents "job-save-disposition-default"
 // collection entry in PrinterAttributes
 type PrinterJobSaveDisposition struct {
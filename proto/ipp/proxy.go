@@ -0,0 +1,319 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// IPP forwarding proxy
+
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// Proxy relays IPP requests between the client and the real
+// printer.
+//
+// URIs, embedded into the forwarded messages (printer-uri, job-uri,
+// printer-uri-supported and so on), are translated, so the client
+// sees the printer as located at the proxy address. Other attributes
+// can be rewritten by the hooks, defined in the [ProxyOptions].
+//
+// Document data that follows the IPP request is streamed to the
+// printer as is, without buffering.
+//
+// All forwarded messages are written to the log at the Debug level.
+type Proxy struct {
+	ctx     context.Context   // Logging context
+	options ProxyOptions      // Proxy options
+	clnt    *transport.Client // HTTP client for outgoing requests
+}
+
+// ProxyOptions represents the [Proxy] creation options.
+type ProxyOptions struct {
+	// Target is the URL of the real printer (ipp://..., http://...
+	// and so on). It is required.
+	Target *url.URL
+
+	// Transport used for outgoing requests. If nil,
+	// [transport.NewTransport] will be used to create
+	// a new transport.
+	Transport *transport.Transport
+
+	// RewriteRequest, if not nil, is called for each request,
+	// after URIs are translated and before the request is
+	// forwarded to the printer.
+	//
+	// It may modify the message in place.
+	RewriteRequest func(rq *goipp.Message)
+
+	// RewriteResponse, if not nil, is called for each response,
+	// after URIs are translated and before the response is
+	// returned to the client. The rq parameter is the request,
+	// as it was forwarded to the printer.
+	//
+	// It may modify the response message in place. For example,
+	// it may drop or adjust the printer attributes, to hide some
+	// capabilities from the client.
+	RewriteResponse func(rq, rsp *goipp.Message)
+}
+
+// NewProxy creates a new [Proxy].
+//
+// The ctx parameter is used for logging.
+func NewProxy(ctx context.Context, options ProxyOptions) *Proxy {
+	return &Proxy{
+		ctx:     ctx,
+		options: options,
+		clnt:    transport.NewClient(options.Transport),
+	}
+}
+
+// ServeHTTP handles incoming HTTP request. It implements
+// [http.Handler] interface.
+func (px *Proxy) ServeHTTP(w http.ResponseWriter, in *http.Request) {
+	// Check HTTP parameters
+	if in.Method != "POST" {
+		httpError(w, ErrHTTPMethodNotAllowed)
+		return
+	}
+
+	ctype := in.Header.Get("Content-Type")
+	mediatype, _, _ := mime.ParseMediaType(ctype)
+	if mediatype != goipp.ContentType {
+		err := NewErrHTTP(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("unsupported media type: %q", ctype))
+		httpError(w, err)
+		return
+	}
+
+	// Setup URL translation
+	local := &url.URL{Scheme: "http", Host: in.Host, Path: "/"}
+	if in.TLS != nil {
+		local.Scheme = "https"
+	}
+
+	urlxlat := transport.NewURLXlat(local, px.options.Target)
+
+	// Forward request
+	lrec := log.Begin(px.ctx)
+	defer lrec.Commit()
+
+	rq, out, err := px.request(lrec, in, local, urlxlat)
+	if err != nil {
+		lrec.Debug("IPP proxy: %s", err)
+		httpError(w, NewErrHTTP(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	lrec.Debug("IPP proxy: forward request to: %s", out.URL)
+
+	rsp, err := px.clnt.Do(out)
+	if err != nil {
+		lrec.Debug("IPP proxy: %s", err)
+		httpError(w, NewErrHTTP(http.StatusBadGateway, err.Error()))
+		return
+	}
+
+	defer rsp.Body.Close()
+
+	// Translate response
+	mediatype, _, _ = mime.ParseMediaType(rsp.Header.Get("Content-Type"))
+	if rsp.StatusCode == http.StatusOK && mediatype == goipp.ContentType {
+		err = px.response(lrec, rq, rsp, urlxlat)
+		if err != nil {
+			lrec.Debug("IPP proxy: %s", err)
+			httpError(w, NewErrHTTP(http.StatusBadGateway,
+				err.Error()))
+			return
+		}
+	} else {
+		lrec.Debug("IPP proxy: HTTP %s", rsp.Status)
+	}
+
+	// Return response to the client
+	transport.RemoveHopByHopHeaders(rsp.Header)
+	for k, v := range rsp.Header {
+		if !strings.EqualFold(k, "Content-Length") {
+			w.Header()[k] = v
+		}
+	}
+
+	if rsp.ContentLength >= 0 {
+		w.Header().Set("Content-Length",
+			strconv.FormatInt(rsp.ContentLength, 10))
+	}
+
+	w.WriteHeader(rsp.StatusCode)
+	io.Copy(w, rsp.Body)
+}
+
+// request prepares the outgoing request.
+//
+// It returns the IPP request message, as it is being sent to
+// the printer, and the outgoing http.Request.
+func (px *Proxy) request(lrec *log.Record, in *http.Request,
+	local *url.URL, urlxlat *transport.URLXlat) (
+	*goipp.Message, *http.Request, error) {
+
+	// Fetch IPP request message
+	peeker := transport.NewPeeker(in.Body)
+	msg := &goipp.Message{}
+	err := msg.DecodeEx(peeker, goipp.DecoderOptions{
+		EnableWorkarounds: true,
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f := goipp.NewFormatter()
+	f.SetIndent(2)
+	f.FmtRequest(msg)
+	lrec.Debug("IPP proxy: request received:\n%s", f.Bytes())
+
+	// Translate the message
+	msg2 := proxyTranslateMsg(msg, urlxlat.Forward)
+	if px.options.RewriteRequest != nil {
+		px.options.RewriteRequest(msg2)
+	}
+
+	data, err := msg2.EncodeBytes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !bytes.Equal(data, peeker.Bytes()) {
+		f.Reset()
+		f.SetIndent(2)
+		f.FmtRequest(msg2)
+		lrec.Debug("IPP proxy: request rewritten:\n%s", f.Bytes())
+	}
+
+	peeker.Replace(data)
+
+	// Create outgoing request
+	u := transport.URLClone(local)
+	u.Path = in.URL.Path
+	u.RawQuery = in.URL.RawQuery
+
+	out, err := transport.NewRequest(in.Context(), "POST",
+		urlxlat.Forward(u), peeker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out.Header = in.Header.Clone()
+	transport.RemoveHopByHopHeaders(out.Header)
+
+	out.ContentLength = in.ContentLength
+	if out.ContentLength >= 0 {
+		out.ContentLength += int64(len(data)) - peeker.Count()
+	}
+
+	return msg2, out, nil
+}
+
+// response translates the IPP response message and replaces
+// the http.Response body with the translated message, followed
+// by the remaining data, if any.
+func (px *Proxy) response(lrec *log.Record, rq *goipp.Message,
+	rsp *http.Response, urlxlat *transport.URLXlat) error {
+
+	// Fetch IPP response message
+	peeker := transport.NewPeeker(rsp.Body)
+	msg := &goipp.Message{}
+	err := msg.DecodeEx(peeker, goipp.DecoderOptions{
+		EnableWorkarounds: true,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	f := goipp.NewFormatter()
+	f.SetIndent(2)
+	f.FmtResponse(msg)
+	lrec.Debug("IPP proxy: response received:\n%s", f.Bytes())
+
+	// Translate the message
+	msg2 := proxyTranslateMsg(msg, urlxlat.Reverse)
+	if px.options.RewriteResponse != nil {
+		px.options.RewriteResponse(rq, msg2)
+	}
+
+	data, err := msg2.EncodeBytes()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(data, peeker.Bytes()) {
+		f.Reset()
+		f.SetIndent(2)
+		f.FmtResponse(msg2)
+		lrec.Debug("IPP proxy: response rewritten:\n%s", f.Bytes())
+	}
+
+	// Replace http.Response body
+	peeker.Replace(data)
+	rsp.Body = peeker
+
+	if rsp.ContentLength >= 0 {
+		rsp.ContentLength += int64(len(data)) - peeker.Count()
+	}
+
+	return nil
+}
+
+// proxyTranslateMsg returns copy of the goipp.Message with
+// all URIs translated by the xlat function, recursively scanning
+// nested collections.
+func proxyTranslateMsg(msg *goipp.Message,
+	xlat func(*url.URL) *url.URL) *goipp.Message {
+
+	groups := msg.AttrGroups().DeepCopy()
+	for i := range groups {
+		proxyTranslateAttrs(groups[i].Attrs, xlat)
+	}
+
+	return goipp.NewMessageWithGroups(msg.Version, msg.Code,
+		msg.RequestID, groups)
+}
+
+// proxyTranslateAttrs translates URIs in attributes in place.
+func proxyTranslateAttrs(attrs goipp.Attributes,
+	xlat func(*url.URL) *url.URL) {
+
+	for i := range attrs {
+		vals := attrs[i].Values
+		for j := range vals {
+			switch v := vals[j].V.(type) {
+			case goipp.Collection:
+				proxyTranslateAttrs(goipp.Attributes(v), xlat)
+
+			case goipp.String:
+				if vals[j].T != goipp.TagURI {
+					break
+				}
+
+				u, err := transport.ParseURL(string(v))
+				if err == nil {
+					vals[j].V = goipp.String(xlat(u).String())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// IPP forwarding proxy test
+
+package ipp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// TestProxy tests Proxy
+func TestProxy(t *testing.T) {
+	// Setup the printer. It records the received request,
+	// then returns its own URI in the job-uri.
+	var printerURL string
+	var printerURI, printerDoc, printerJobName string
+
+	printer := http.HandlerFunc(func(w http.ResponseWriter,
+		rq *http.Request) {
+
+		msg := &goipp.Message{}
+		err := msg.Decode(rq.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ipprq := &PrintJobRequest{}
+		ipprq.Decode(msg)
+		data, _ := io.ReadAll(rq.Body)
+
+		printerURI = ipprq.PrinterURI
+		printerJobName = ipprq.JobName
+		printerDoc = string(data)
+
+		rsp := &PrintJobResponse{
+			ResponseHeader: DefaultResponseHeader,
+			Job: &JobStatus{
+				JobID:           1,
+				JobURI:          printerURL + "/ipp/print/1",
+				JobState:        JobStatePending,
+				JobStateReasons: []KwJobStateReasons{"none"},
+			},
+		}
+
+		rspMsg := rsp.Encode()
+		rspMsg.RequestID = msg.RequestID
+
+		w.Header().Set("Content-Type", goipp.ContentType)
+		rspMsg.Encode(w)
+	})
+
+	printerSrv := httptest.NewServer(printer)
+	defer printerSrv.Close()
+
+	printerURL = strings.Replace(printerSrv.URL, "http:", "ipp:", 1)
+
+	// Setup the proxy
+	proxy := NewProxy(context.Background(), ProxyOptions{
+		Target: transport.MustParseURL(printerURL + "/ipp/print"),
+
+		RewriteRequest: func(rq *goipp.Message) {
+			for i := range rq.Operation {
+				if rq.Operation[i].Name == "job-name" {
+					rq.Operation[i].Values[0].V =
+						goipp.String("rewritten")
+				}
+			}
+		},
+
+		RewriteResponse: func(rq, rsp *goipp.Message) {
+			for i := range rsp.Job {
+				if rsp.Job[i].Name == "job-state" {
+					rsp.Job[i].Values[0].V =
+						goipp.Integer(JobStateProcessing)
+				}
+			}
+		},
+	})
+
+	proxySrv := httptest.NewServer(proxy)
+	defer proxySrv.Close()
+
+	// Print via proxy
+	clnt := NewClient(transport.MustParseURL(proxySrv.URL), nil)
+	doc := Document{"a.pdf", "application/pdf", strings.NewReader("AAA")}
+	job, err := clnt.PrintJob(context.Background(), "test", nil, doc)
+	if err != nil {
+		t.Fatalf("PrintJob: %s", err)
+	}
+
+	// Check request, as seen by the printer
+	if printerJobName != "rewritten" {
+		t.Errorf("printer: request not rewritten: job-name %q",
+			printerJobName)
+	}
+
+	// Note, URL translation preserves the URL scheme
+	if printerURI != printerSrv.URL+"/ipp/print" {
+		t.Errorf("printer: printer-uri not translated: %q", printerURI)
+	}
+
+	if printerDoc != "AAA" {
+		t.Errorf("printer: document data mismatch: %q", printerDoc)
+	}
+
+	// Check response, as seen by the client
+	proxyURI := strings.Replace(proxySrv.URL, "http:", "ipp:", 1)
+	if job.JobURI != proxyURI+"/1" {
+		t.Errorf("client: job-uri not translated: %q", job.JobURI)
+	}
+
+	if job.JobState != JobStateProcessing {
+		t.Errorf("client: response not rewritten: job-state %d",
+			job.JobState)
+	}
+
+	// Non-IPP requests are rejected
+	rsp, err := http.Get(proxySrv.URL)
+	if err != nil {
+		t.Fatalf("GET: %s", err)
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET: expected %d, present %d",
+			http.StatusMethodNotAllowed, rsp.StatusCode)
+	}
+}
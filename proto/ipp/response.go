@@ -10,6 +10,7 @@ package ipp
 
 import (
 	"io"
+	"net/http"
 
 	"github.com/OpenPrinting/goipp"
 )
@@ -53,6 +54,13 @@ type ResponseHeader struct {
 	// IPP message.
 	IPPMessage *goipp.Message
 
+	// HTTP response header.
+	//
+	// This field is filled when Response is received as result
+	// of Client.Do or Client.DoWithBody. It allows to inspect
+	// things like the Server header.
+	HTTPHeader http.Header
+
 	// Response Body.
 	//
 	// If Response is received as result of Client.DoWithBody,
@@ -9,15 +9,19 @@
 package ipp
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"mime"
 	"net/http"
+	"time"
 
 	"github.com/OpenPrinting/goipp"
 )
 
 // Server represents an IPP server.
 type Server struct {
+	Tracer     Tracer // Receives IPP exchanges, may be nil
 	httpServer *http.Server
 	ops        map[goipp.Op]*Handler
 }
@@ -38,7 +42,7 @@ func NewServer() *Server {
 func (s *Server) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 	// Check HTTP parameters
 	if rq.Method != "POST" {
-		s.httpError(w, ErrHTTPMethodNotAllowed)
+		httpError(w, ErrHTTPMethodNotAllowed)
 		return
 	}
 
@@ -47,26 +51,53 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 	if mediatype != goipp.ContentType {
 		err := NewErrHTTP(http.StatusUnsupportedMediaType,
 			fmt.Sprintf("unsupported media type: %q", ctype))
-		s.httpError(w, err)
+		httpError(w, err)
 		return
 	}
 
 	// Decode IPP message
+	var rqData bytes.Buffer
+	var body io.Reader = rq.Body
+	if s.Tracer != nil {
+		body = io.TeeReader(body, &rqData)
+	}
+
+	start := time.Now()
 	msg := &goipp.Message{}
-	err := msg.Decode(rq.Body)
+	err := msg.Decode(body)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+
+	// Handle the message
+	rsp, err := s.handle(msg)
+
+	if s.Tracer != nil {
+		s.trace(rq, start, msg, rqData.Bytes(), rsp, err)
+	}
+
 	if err != nil {
-		s.httpError(w, err)
+		httpError(w, err)
 		return
 	}
 
+	// Send response
+	w.Header().Set("Content-Type", "application/ipp")
+	w.WriteHeader(http.StatusOK) // At HTTP level everything OK.
+
+	rsp.Encode(w)
+}
+
+// handle handles the decoded IPP request message.
+func (s *Server) handle(msg *goipp.Message) (*goipp.Message, error) {
 	// Check IPP parameters
 	if msg.RequestID == 0 {
 		err := NewErrIPP(msg,
 			goipp.StatusErrorVersionNotSupported,
 			fmt.Sprintf("bad request ID %d", msg.RequestID))
 
-		s.httpError(w, err)
-		return
+		return nil, err
 	}
 
 	if msg.Version < goipp.MakeVersion(1, 0) ||
@@ -75,8 +106,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 			goipp.StatusErrorVersionNotSupported,
 			fmt.Sprintf("bad request version %s", msg.Version))
 
-		s.httpError(w, err)
-		return
+		return nil, err
 	}
 
 	handler := s.ops[goipp.Op(msg.Code)]
@@ -86,31 +116,54 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 			goipp.StatusErrorVersionNotSupported,
 			fmt.Sprintf("unsupported operation %s", op))
 
-		s.httpError(w, err)
-		return
+		return nil, err
 	}
 
-	// Handle the message
-	rsp, err := handler.handle(msg)
-	if err != nil {
-		s.httpError(w, err)
-		return
+	return handler.handle(msg)
+}
+
+// trace reports the IPP exchange to the Server's Tracer.
+func (s *Server) trace(rq *http.Request, start time.Time,
+	msg *goipp.Message, data []byte, rsp *goipp.Message, err error) {
+
+	xchg := &TraceExchange{
+		Server:      true,
+		Start:       start,
+		Elapsed:     time.Since(start),
+		URL:         rq.URL,
+		Peer:        rq.RemoteAddr,
+		Request:     msg,
+		RequestData: data,
+		HTTPStatus:  http.StatusOK,
+		Response:    rsp,
 	}
 
-	// Send response
-	w.Header().Set("Content-Type", "application/ipp")
-	w.WriteHeader(http.StatusOK) // At HTTP level everything OK.
+	switch e := err.(type) {
+	case nil:
+	case *ErrIPP:
+		xchg.Response = e.Encode()
+	case *ErrHTTP:
+		xchg.HTTPStatus = e.Status
+		xchg.Err = err
+	default:
+		xchg.HTTPStatus = http.StatusInternalServerError
+		xchg.Err = err
+	}
 
-	rsp.Encode(w)
+	if xchg.Response != nil {
+		xchg.ResponseData, _ = xchg.Response.EncodeBytes()
+	}
+
+	s.Tracer.Trace(xchg)
 }
 
 // httpError finishes HTTP request with an error.
-func (s *Server) httpError(w http.ResponseWriter, err error) {
+func httpError(w http.ResponseWriter, err error) {
 AGAIN:
 	switch err := err.(type) {
 	case *ErrHTTP:
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		s.httpNoCache(w)
+		httpNoCache(w)
 		w.WriteHeader(err.Status)
 
 		fmt.Fprintf(w, "%3.3d %s\n", err.Status, err.Message)
@@ -130,7 +183,7 @@ AGAIN:
 }
 
 // httpNoCache sets response headers to disable caching.
-func (s *Server) httpNoCache(w http.ResponseWriter) {
+func httpNoCache(w http.ResponseWriter) {
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	w.Header().Set("Pragma", "no-cache")
 	w.Header().Set("Expires", "0")
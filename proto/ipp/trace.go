@@ -0,0 +1,150 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// IPP messages tracing
+
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/goipp"
+)
+
+// Tracer receives IPP exchanges, performed by the [Client]
+// or handled by the [Server].
+//
+// Tracer is attached to the Client or Server by setting its
+// Tracer field.
+//
+// Trace may be called simultaneously from multiple goroutines.
+type Tracer interface {
+	Trace(*TraceExchange)
+}
+
+// TraceExchange represents the traced IPP request/response exchange.
+//
+// Document data, sent with the request or response, is not
+// included into RequestData and ResponseData.
+type TraceExchange struct {
+	Server       bool           // Reported by the Server, not by Client
+	Start        time.Time      // Request start time
+	Elapsed      time.Duration  // Time till the response is received
+	URL          *url.URL       // Request URL
+	Peer         string         // Remote address
+	Request      *goipp.Message // Request message
+	RequestData  []byte         // Encoded request message
+	HTTPStatus   int            // HTTP status, 0 if unknown
+	Response     *goipp.Message // Response message, nil if none
+	ResponseData []byte         // Encoded response message
+	Err          error          // Error, if exchange failed
+}
+
+// MarshalLog returns the structured summary of the TraceExchange,
+// for logging. It implements the [log.Marshaler] interface.
+func (xchg *TraceExchange) MarshalLog() []byte {
+	buf := &bytes.Buffer{}
+
+	side := "client"
+	if xchg.Server {
+		side = "server"
+	}
+
+	op := goipp.Op(xchg.Request.Code)
+	fmt.Fprintf(buf, "IPP %s: %s %s (%s)\n", side, op, xchg.URL,
+		xchg.Elapsed.Round(time.Microsecond))
+
+	if xchg.Peer != "" {
+		fmt.Fprintf(buf, "  Peer:     %s\n", xchg.Peer)
+	}
+
+	fmt.Fprintf(buf, "  Request:  id=%d version=%s groups=%d bytes=%d\n",
+		xchg.Request.RequestID, xchg.Request.Version,
+		len(xchg.Request.AttrGroups()), len(xchg.RequestData))
+
+	if xchg.HTTPStatus != 0 {
+		fmt.Fprintf(buf, "  HTTP:     %d\n", xchg.HTTPStatus)
+	}
+
+	if rsp := xchg.Response; rsp != nil {
+		fmt.Fprintf(buf,
+			"  Response: %s id=%d version=%s groups=%d bytes=%d\n",
+			goipp.Status(rsp.Code), rsp.RequestID, rsp.Version,
+			len(rsp.AttrGroups()), len(xchg.ResponseData))
+	}
+
+	if xchg.Err != nil {
+		fmt.Fprintf(buf, "  Error:    %s\n", xchg.Err)
+	}
+
+	return buf.Bytes()
+}
+
+// LogTracer is the [Tracer] that writes traced exchanges to
+// the log.
+type LogTracer struct {
+	ctx     context.Context // Logging context
+	level   log.Level       // Logging level
+	hexdump bool            // Write hex dump of messages
+}
+
+// NewLogTracer creates a new [LogTracer].
+//
+// It writes the structured summary of each exchange (see
+// [TraceExchange.MarshalLog]) to the log, associated with the ctx,
+// using the specified log level. If hexdump is true, the hex dump
+// of the request and response messages is written as well.
+func NewLogTracer(ctx context.Context, level log.Level,
+	hexdump bool) *LogTracer {
+
+	return &LogTracer{
+		ctx:     ctx,
+		level:   level,
+		hexdump: hexdump,
+	}
+}
+
+// Trace writes the TraceExchange to the log.
+// It implements the [Tracer] interface.
+func (lt *LogTracer) Trace(xchg *TraceExchange) {
+	lrec := log.Begin(lt.ctx)
+	defer lrec.Commit()
+
+	lrec.Object(lt.level, 0, xchg)
+
+	if lt.hexdump {
+		if len(xchg.RequestData) != 0 {
+			lrec.Object(lt.level, 2, traceHexDump{"request",
+				xchg.RequestData})
+		}
+
+		if len(xchg.ResponseData) != 0 {
+			lrec.Object(lt.level, 2, traceHexDump{"response",
+				xchg.ResponseData})
+		}
+	}
+}
+
+// traceHexDump represents the hex dump of the encoded message.
+// It implements the [log.Marshaler] interface.
+type traceHexDump struct {
+	name string // Dump name
+	data []byte // Data to dump
+}
+
+// MarshalLog returns the hex dump, for logging.
+func (dump traceHexDump) MarshalLog() []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s:\n", dump.name)
+	buf.WriteString(hex.Dump(dump.data))
+	return buf.Bytes()
+}
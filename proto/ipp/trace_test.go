@@ -0,0 +1,186 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// IPP - Internet Printing Protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// IPP messages tracing test
+
+package ipp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/goipp"
+)
+
+// testTracer collects traced exchanges
+type testTracer struct {
+	xchgs []*TraceExchange
+	lock  sync.Mutex
+}
+
+// Trace saves the TraceExchange
+func (tt *testTracer) Trace(xchg *TraceExchange) {
+	tt.lock.Lock()
+	tt.xchgs = append(tt.xchgs, xchg)
+	tt.lock.Unlock()
+}
+
+// testLogBackend collects log lines
+type testLogBackend struct {
+	lines []string
+	lock  sync.Mutex
+}
+
+// Send saves log lines
+func (bk *testLogBackend) Send(levels []log.Level, lines [][]byte) {
+	bk.lock.Lock()
+	for _, line := range lines {
+		bk.lines = append(bk.lines, string(line))
+	}
+	bk.lock.Unlock()
+}
+
+// TestTrace tests Client and Server tracing
+func TestTrace(t *testing.T) {
+	// Setup the server
+	srvTracer := &testTracer{}
+	srv := NewServer()
+	srv.Tracer = srvTracer
+	srv.ops[goipp.OpCancelJob] = NewHandler(
+		func(rq *CancelJobRequest) Response {
+			return &CancelJobResponse{
+				ResponseHeader: ResponseHeader{
+					Version:   rq.Version,
+					RequestID: rq.RequestID,
+					Status:    goipp.StatusOk,
+				},
+			}
+		})
+
+	httpSrv := httptest.NewServer(srv)
+	defer httpSrv.Close()
+
+	// Setup the client
+	clntTracer := &testTracer{}
+	clnt := NewClient(transport.MustParseURL(httpSrv.URL), nil)
+	clnt.Tracer = clntTracer
+
+	// Perform requests. Hold-Job is not supported by the server.
+	err := clnt.CancelJob(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("CancelJob: %s", err)
+	}
+
+	err = clnt.HoldJob(context.Background(), 1, "")
+	if err == nil {
+		t.Errorf("HoldJob: error expected")
+	}
+
+	// Check traces
+	type testData struct {
+		name   string         // Tracer name
+		tracer *testTracer    // The tracer
+		server bool           // Expected TraceExchange.Server
+		op     []goipp.Op     // Expected operations
+		status []goipp.Status // Expected statuses
+	}
+
+	tests := []testData{
+		{
+			name:   "client",
+			tracer: clntTracer,
+			server: false,
+		},
+		{
+			name:   "server",
+			tracer: srvTracer,
+			server: true,
+		},
+	}
+
+	op := []goipp.Op{goipp.OpCancelJob, goipp.OpHoldJob}
+	status := []goipp.Status{
+		goipp.StatusOk,
+		goipp.StatusErrorVersionNotSupported,
+	}
+
+	for _, test := range tests {
+		if len(test.tracer.xchgs) != 2 {
+			t.Errorf("%s: %d exchanges traced, 2 expected",
+				test.name, len(test.tracer.xchgs))
+			continue
+		}
+
+		for i, xchg := range test.tracer.xchgs {
+			if xchg.Server != test.server {
+				t.Errorf("%s: Server: %v", test.name, xchg.Server)
+			}
+
+			if goipp.Op(xchg.Request.Code) != op[i] {
+				t.Errorf("%s: op expected %s, present %s",
+					test.name, op[i],
+					goipp.Op(xchg.Request.Code))
+			}
+
+			if xchg.Response == nil ||
+				goipp.Status(xchg.Response.Code) != status[i] {
+				t.Errorf("%s: %s: bad response", test.name, op[i])
+				continue
+			}
+
+			if xchg.HTTPStatus != http.StatusOK || xchg.Err != nil {
+				t.Errorf("%s: %s: HTTP %d, err %v", test.name,
+					op[i], xchg.HTTPStatus, xchg.Err)
+			}
+
+			// Encoded data must match messages
+			var msg goipp.Message
+			err := msg.DecodeBytes(xchg.RequestData)
+			if err != nil || !msg.Equal(*xchg.Request) {
+				t.Errorf("%s: %s: RequestData mismatch",
+					test.name, op[i])
+			}
+
+			msg = goipp.Message{}
+			err = msg.DecodeBytes(xchg.ResponseData)
+			if err != nil || !msg.Equal(*xchg.Response) {
+				t.Errorf("%s: %s: ResponseData mismatch",
+					test.name, op[i])
+			}
+		}
+	}
+
+	// Test LogTracer
+	backend := &testLogBackend{}
+	ctx := log.NewContext(context.Background(),
+		log.NewLogger(log.LevelTrace, backend))
+
+	tracer := NewLogTracer(ctx, log.LevelDebug, true)
+	tracer.Trace(clntTracer.xchgs[0])
+
+	text := strings.Join(backend.lines, "\n")
+	expect := []string{
+		"IPP client: Cancel-Job " + httpSrv.URL,
+		"  Request:  id=1 version=2.0",
+		"  HTTP:     200",
+		"  Response: successful-ok id=1",
+		"  request:",
+		"  response:",
+		"  00000000  02 00 00 08 00 00 00 01",
+	}
+
+	for _, line := range expect {
+		if !strings.Contains(text, line) {
+			t.Errorf("LogTracer: %q missed:\n%s", line, text)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Minimal BER encoder and decoder
+
+package snmp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BER tags, used by SNMP in addition to the Value types
+const (
+	berTagSequence = 0x30
+)
+
+// berAppendLen appends BER length to the buffer.
+func berAppendLen(buf []byte, l int) []byte {
+	switch {
+	case l < 0x80:
+		return append(buf, byte(l))
+	case l < 0x100:
+		return append(buf, 0x81, byte(l))
+	case l < 0x10000:
+		return append(buf, 0x82, byte(l>>8), byte(l))
+	}
+
+	return append(buf, 0x83, byte(l>>16), byte(l>>8), byte(l))
+}
+
+// berAppendTLV appends tag, length and content to the buffer.
+func berAppendTLV(buf []byte, tag byte, content []byte) []byte {
+	buf = append(buf, tag)
+	buf = berAppendLen(buf, len(content))
+	return append(buf, content...)
+}
+
+// berAppendInt appends signed integer with the specified tag.
+func berAppendInt(buf []byte, tag byte, v int64) []byte {
+	var content []byte
+
+	// Use minimal two's complement representation
+	n := 1
+	for n < 8 {
+		shift := uint(n * 8)
+		if (v>>(shift-1)) == 0 || (v>>(shift-1)) == -1 {
+			break
+		}
+		n++
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		content = append(content, byte(v>>(uint(i)*8)))
+	}
+
+	return berAppendTLV(buf, tag, content)
+}
+
+// berAppendUint appends unsigned integer with the specified tag.
+func berAppendUint(buf []byte, tag byte, v uint64) []byte {
+	var content []byte
+
+	n := 1
+	for n < 8 && v>>(uint(n)*8) != 0 {
+		n++
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		content = append(content, byte(v>>(uint(i)*8)))
+	}
+
+	// Leading 0 is required if high bit is set
+	if content[0]&0x80 != 0 {
+		content = append([]byte{0}, content...)
+	}
+
+	return berAppendTLV(buf, tag, content)
+}
+
+// berAppendOID appends OBJECT IDENTIFIER.
+func berAppendOID(buf []byte, oid OID) []byte {
+	var content []byte
+
+	appendSub := func(v uint32) {
+		var tmp [5]byte
+		i := len(tmp) - 1
+		tmp[i] = byte(v & 0x7f)
+		for v >>= 7; v != 0; v >>= 7 {
+			i--
+			tmp[i] = byte(v&0x7f) | 0x80
+		}
+		content = append(content, tmp[i:]...)
+	}
+
+	if len(oid) >= 2 {
+		appendSub(oid[0]*40 + oid[1])
+		for _, v := range oid[2:] {
+			appendSub(v)
+		}
+	}
+
+	return berAppendTLV(buf, byte(TypeOID), content)
+}
+
+// berAppendValue appends SNMP value.
+func berAppendValue(buf []byte, v Value) []byte {
+	switch v.Type {
+	case TypeInteger:
+		return berAppendInt(buf, byte(v.Type), v.Int)
+	case TypeOctetString, TypeIPAddress, TypeOpaque:
+		return berAppendTLV(buf, byte(v.Type), v.Bytes)
+	case TypeOID:
+		return berAppendOID(buf, v.OID)
+	case TypeCounter32, TypeGauge32, TypeTimeTicks, TypeCounter64:
+		return berAppendUint(buf, byte(v.Type), v.Uint)
+	}
+
+	// NULL and exceptions
+	return berAppendTLV(buf, byte(v.Type), nil)
+}
+
+// berDecoder decodes BER-encoded data
+type berDecoder struct {
+	data []byte // Remaining data
+}
+
+// Empty reports if there are no more data to decode.
+func (dec *berDecoder) Empty() bool {
+	return len(dec.data) == 0
+}
+
+// Next returns next TLV from the input.
+func (dec *berDecoder) Next() (tag byte, content []byte, err error) {
+	if len(dec.data) < 2 {
+		return 0, nil, errors.New("BER: unexpected end of data")
+	}
+
+	tag = dec.data[0]
+	l := int(dec.data[1])
+	data := dec.data[2:]
+
+	if l&0x80 != 0 {
+		n := l & 0x7f
+		if n == 0 || n > 3 || len(data) < n {
+			return 0, nil, errors.New("BER: invalid length")
+		}
+
+		l = 0
+		for _, c := range data[:n] {
+			l = l<<8 | int(c)
+		}
+		data = data[n:]
+	}
+
+	if len(data) < l {
+		return 0, nil, errors.New("BER: unexpected end of data")
+	}
+
+	content = data[:l]
+	dec.data = data[l:]
+
+	return tag, content, nil
+}
+
+// Expect returns next TLV from the input and verifies its tag.
+func (dec *berDecoder) Expect(tag byte) ([]byte, error) {
+	t, content, err := dec.Next()
+	if err == nil && t != tag {
+		err = fmt.Errorf("BER: tag 0x%2.2x expected, 0x%2.2x present",
+			tag, t)
+	}
+	return content, err
+}
+
+// ExpectInt returns next INTEGER from the input.
+func (dec *berDecoder) ExpectInt() (int64, error) {
+	content, err := dec.Expect(byte(TypeInteger))
+	if err != nil {
+		return 0, err
+	}
+	return berDecodeInt(content)
+}
+
+// berDecodeInt decodes signed integer content.
+func berDecodeInt(content []byte) (int64, error) {
+	if len(content) == 0 || len(content) > 8 {
+		return 0, errors.New("BER: invalid integer")
+	}
+
+	v := int64(int8(content[0]))
+	for _, c := range content[1:] {
+		v = v<<8 | int64(c)
+	}
+
+	return v, nil
+}
+
+// berDecodeUint decodes unsigned integer content.
+func berDecodeUint(content []byte) (uint64, error) {
+	if len(content) > 0 && content[0] == 0 {
+		content = content[1:]
+	}
+
+	if len(content) > 8 {
+		return 0, errors.New("BER: invalid integer")
+	}
+
+	var v uint64
+	for _, c := range content {
+		v = v<<8 | uint64(c)
+	}
+
+	return v, nil
+}
+
+// berDecodeOID decodes OBJECT IDENTIFIER content.
+func berDecodeOID(content []byte) (OID, error) {
+	if len(content) == 0 {
+		return nil, errors.New("BER: invalid OID")
+	}
+
+	var oid OID
+	var v uint32
+	for i, c := range content {
+		if v > 0x1ffffff {
+			return nil, errors.New("BER: OID overflow")
+		}
+
+		v = v<<7 | uint32(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(content)-1 {
+				return nil, errors.New("BER: invalid OID")
+			}
+			continue
+		}
+
+		if oid == nil {
+			switch {
+			case v < 40:
+				oid = OID{0, v}
+			case v < 80:
+				oid = OID{1, v - 40}
+			default:
+				oid = OID{2, v - 80}
+			}
+		} else {
+			oid = append(oid, v)
+		}
+
+		v = 0
+	}
+
+	return oid, nil
+}
+
+// berDecodeValue decodes SNMP value.
+func berDecodeValue(tag byte, content []byte) (v Value, err error) {
+	v.Type = Type(tag)
+
+	switch v.Type {
+	case TypeInteger:
+		v.Int, err = berDecodeInt(content)
+	case TypeOctetString, TypeIPAddress, TypeOpaque:
+		v.Bytes = content
+	case TypeOID:
+		v.OID, err = berDecodeOID(content)
+	case TypeCounter32, TypeGauge32, TypeTimeTicks, TypeCounter64:
+		v.Uint, err = berDecodeUint(content)
+	case TypeNull, TypeNoSuchObject, TypeNoSuchInstance, TypeEndOfMibView:
+	default:
+		err = fmt.Errorf("BER: unknown value type 0x%2.2x", tag)
+	}
+
+	return
+}
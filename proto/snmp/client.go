@@ -0,0 +1,261 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SNMP client
+
+package snmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// DefaultPort is the default SNMP agent port.
+const DefaultPort = 161
+
+// DefaultCommunity is the default SNMP community.
+const DefaultCommunity = "public"
+
+// DefaultTimeout is the default request timeout, used if request
+// context has no deadline.
+const DefaultTimeout = 5 * time.Second
+
+// clientRetransmit defines intervals between retransmissions of the
+// request, as UDP delivery is not reliable.
+var clientRetransmit = []time.Duration{
+	time.Second,
+	2 * time.Second,
+}
+
+// walkMaxRepetitions is the max-repetitions parameter of the
+// GetBulkRequest, used by the [Client.Walk].
+const walkMaxRepetitions = 16
+
+// Client implements the SNMP v1/v2c client.
+type Client struct {
+	Addr      string  // Agent address, host or host:port
+	Community string  // SNMP community
+	Version   Version // SNMP version
+	RequestID uint32  // RequestID of the next request
+}
+
+// NewClient creates a new SNMP client.
+//
+// If addr doesn't contain port, [DefaultPort] is assumed.
+// If community is "", [DefaultCommunity] is used.
+func NewClient(addr, community string) *Client {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, fmt.Sprintf("%d", DefaultPort))
+	}
+
+	if community == "" {
+		community = DefaultCommunity
+	}
+
+	return &Client{
+		Addr:      addr,
+		Community: community,
+		Version:   Version2c,
+	}
+}
+
+// Get performs the GetRequest for the specified OIDs.
+//
+// For SNMPv1 agents, missed objects cause [ErrNoSuchName]
+// error. For SNMPv2c agents, they are returned as exceptions
+// (see [Value.IsException]).
+func (c *Client) Get(ctx context.Context, oids ...OID) ([]Varbind, error) {
+	return c.do(ctx, pduGetRequest, 0, 0, oids)
+}
+
+// GetNext performs the GetNextRequest for the specified OIDs.
+func (c *Client) GetNext(ctx context.Context,
+	oids ...OID) ([]Varbind, error) {
+	return c.do(ctx, pduGetNextRequest, 0, 0, oids)
+}
+
+// Walk retrieves all objects under the root OID.
+//
+// For SNMPv2c it uses GetBulkRequest, for SNMPv1 it uses
+// GetNextRequest.
+func (c *Client) Walk(ctx context.Context, root OID) ([]Varbind, error) {
+	var out []Varbind
+	next := root
+
+	for {
+		var vbs []Varbind
+		var err error
+
+		if c.Version == Version1 {
+			vbs, err = c.do(ctx, pduGetNextRequest, 0, 0,
+				[]OID{next})
+
+			// SNMPv1 agent reports end of MIB by noSuchName
+			if err == ErrNoSuchName {
+				return out, nil
+			}
+		} else {
+			vbs, err = c.do(ctx, pduGetBulkRequest,
+				0, walkMaxRepetitions, []OID{next})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(vbs) == 0 {
+			return out, nil
+		}
+
+		for _, vb := range vbs {
+			if vb.Value.IsException() || !vb.OID.HasPrefix(root) ||
+				vb.OID.Equal(root) {
+				return out, nil
+			}
+
+			if len(out) != 0 && !oidLess(out[len(out)-1].OID, vb.OID) {
+				return nil, fmt.Errorf("%s: OID not increasing",
+					vb.OID)
+			}
+
+			out = append(out, vb)
+		}
+
+		next = vbs[len(vbs)-1].OID
+	}
+}
+
+// requestid generates a next RequestID
+func (c *Client) requestid() int32 {
+	// Keep RequestID positive and non-zero
+	var id int32
+	for id <= 0 {
+		id = int32(atomic.AddUint32(&c.RequestID, 1) & 0x7fffffff)
+	}
+
+	return id
+}
+
+// do performs the SNMP request.
+func (c *Client) do(ctx context.Context, typ pduType,
+	status ErrorStatus, index int, oids []OID) ([]Varbind, error) {
+
+	// Prepare request
+	rq := message{
+		Version:   c.Version,
+		Community: c.Community,
+		PDU: pdu{
+			Type:        typ,
+			RequestID:   c.requestid(),
+			ErrorStatus: status,
+			ErrorIndex:  index,
+		},
+	}
+
+	for _, oid := range oids {
+		rq.PDU.Varbinds = append(rq.PDU.Varbinds,
+			Varbind{OID: oid, Value: Value{Type: TypeNull}})
+	}
+
+	data := rq.Encode()
+
+	// Apply default timeout
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	// Open connection
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	// Close connection, if context is canceled, to unblock Read
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	log.Debug(ctx, "SNMP: %s request to %s (id=%d)",
+		c.Version, c.Addr, rq.PDU.RequestID)
+
+	// Send request, with retransmissions
+	retransmit := clientRetransmit
+	for {
+		_, err = conn.Write(data)
+		if err != nil {
+			return nil, err
+		}
+
+		deadline := time.Time{}
+		if len(retransmit) != 0 {
+			deadline = time.Now().Add(retransmit[0])
+			retransmit = retransmit[1:]
+		}
+
+		rsp, err := c.recv(conn, deadline, rq.PDU.RequestID)
+		switch {
+		case err == nil:
+			if rsp.PDU.ErrorStatus != ErrNoError {
+				return nil, rsp.PDU.ErrorStatus
+			}
+			return rsp.PDU.Varbinds, nil
+
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+
+		case !errors.Is(err, errRetransmit):
+			return nil, err
+		}
+
+		log.Debug(ctx, "SNMP: %s: retransmit (id=%d)",
+			c.Addr, rq.PDU.RequestID)
+	}
+}
+
+// errRetransmit returned by the Client.recv, when request
+// needs to be retransmitted.
+var errRetransmit = errors.New("SNMP: retransmit")
+
+// recv receives response with the specified RequestID.
+//
+// Messages with the unexpected RequestID, as well as malformed
+// messages, are silently dropped.
+func (c *Client) recv(conn net.Conn, deadline time.Time,
+	rqid int32) (message, error) {
+
+	conn.SetReadDeadline(deadline)
+
+	for {
+		var buf [65536]byte
+		n, err := conn.Read(buf[:])
+
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				err = errRetransmit
+			}
+			return message{}, err
+		}
+
+		rsp, err := decodeMessage(buf[:n])
+		if err != nil || rsp.PDU.Type != pduResponse ||
+			rsp.PDU.RequestID != rqid {
+			continue
+		}
+
+		return rsp, nil
+	}
+}
@@ -0,0 +1,285 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SNMP client test
+
+package snmp
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+// testAgent is the minimal SNMP agent for testing
+type testAgent struct {
+	conn    *net.UDPConn // Agent's socket
+	objects []Varbind    // Objects, sorted by OID
+	drop    atomic.Int32 // Requests to drop, to test retransmission
+}
+
+// newTestAgent creates a new testAgent
+func newTestAgent(t *testing.T, objects []Varbind) *testAgent {
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	objects = append([]Varbind(nil), objects...)
+	sort.Slice(objects, func(i, j int) bool {
+		return oidLess(objects[i].OID, objects[j].OID)
+	})
+
+	agent := &testAgent{conn: conn, objects: objects}
+	go agent.serve()
+
+	return agent
+}
+
+// Addr returns agent's address
+func (agent *testAgent) Addr() string {
+	return agent.conn.LocalAddr().String()
+}
+
+// Close closes the agent
+func (agent *testAgent) Close() {
+	agent.conn.Close()
+}
+
+// serve serves incoming requests
+func (agent *testAgent) serve() {
+	for {
+		var buf [65536]byte
+		n, from, err := agent.conn.ReadFromUDP(buf[:])
+		if err != nil {
+			return
+		}
+
+		if agent.drop.Add(-1) >= 0 {
+			continue
+		}
+		agent.drop.Store(0)
+
+		rq, err := decodeMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		rsp := rq
+		rsp.PDU = pdu{Type: pduResponse, RequestID: rq.PDU.RequestID}
+
+		switch rq.PDU.Type {
+		case pduGetRequest:
+			for _, vb := range rq.PDU.Varbinds {
+				rsp.PDU.Varbinds = append(rsp.PDU.Varbinds,
+					agent.get(vb.OID))
+			}
+
+		case pduGetNextRequest:
+			for _, vb := range rq.PDU.Varbinds {
+				rsp.PDU.Varbinds = append(rsp.PDU.Varbinds,
+					agent.next(vb.OID))
+			}
+
+		case pduGetBulkRequest:
+			oid := rq.PDU.Varbinds[0].OID
+			for i := 0; i < rq.PDU.ErrorIndex; i++ {
+				vb := agent.next(oid)
+				rsp.PDU.Varbinds = append(rsp.PDU.Varbinds, vb)
+				if vb.Value.IsException() {
+					break
+				}
+				oid = vb.OID
+			}
+		}
+
+		// SNMPv1 reports missed objects by error
+		if rq.Version == Version1 {
+			for i, vb := range rsp.PDU.Varbinds {
+				if vb.Value.IsException() {
+					rsp.PDU.ErrorStatus = ErrNoSuchName
+					rsp.PDU.ErrorIndex = i + 1
+					rsp.PDU.Varbinds = rq.PDU.Varbinds
+					break
+				}
+			}
+		}
+
+		agent.conn.WriteToUDP(rsp.Encode(), from)
+	}
+}
+
+// get returns object by OID
+func (agent *testAgent) get(oid OID) Varbind {
+	for _, vb := range agent.objects {
+		if vb.OID.Equal(oid) {
+			return vb
+		}
+	}
+	return Varbind{oid, Value{Type: TypeNoSuchObject}}
+}
+
+// next returns the next object after OID
+func (agent *testAgent) next(oid OID) Varbind {
+	for _, vb := range agent.objects {
+		if oidLess(oid, vb.OID) {
+			return vb
+		}
+	}
+	return Varbind{oid, Value{Type: TypeEndOfMibView}}
+}
+
+// testInt makes INTEGER Varbind
+func testInt(oid string, v int64) Varbind {
+	return Varbind{MustParseOID(oid), Value{Type: TypeInteger, Int: v}}
+}
+
+// testStr makes OCTET STRING Varbind
+func testStr(oid string, s string) Varbind {
+	return Varbind{MustParseOID(oid),
+		Value{Type: TypeOctetString, Bytes: []byte(s)}}
+}
+
+// testPrinterObjects contains objects of the test printer
+var testPrinterObjects = []Varbind{
+	testStr("1.3.6.1.2.1.1.1.0", "Test Printer\x00"),
+	testStr("1.3.6.1.2.1.1.5.0", "printer"),
+	testInt("1.3.6.1.2.1.25.3.5.1.1.1", 3),
+	{MustParseOID("1.3.6.1.2.1.25.3.5.1.2.1"),
+		Value{Type: TypeOctetString, Bytes: []byte{0x24, 0x00}}},
+
+	// prtMarkerTable
+	testInt("1.3.6.1.2.1.43.10.2.1.3.1.1", 7),
+	testInt("1.3.6.1.2.1.43.10.2.1.4.1.1", 12345),
+
+	// prtMarkerSuppliesTable, 2 rows
+	testInt("1.3.6.1.2.1.43.11.1.1.5.1.1", 3),
+	testInt("1.3.6.1.2.1.43.11.1.1.5.1.2", 4),
+	testStr("1.3.6.1.2.1.43.11.1.1.6.1.1", "Black Toner"),
+	testStr("1.3.6.1.2.1.43.11.1.1.6.1.2", "Waste Toner"),
+	testInt("1.3.6.1.2.1.43.11.1.1.7.1.1", 7),
+	testInt("1.3.6.1.2.1.43.11.1.1.7.1.2", 19),
+	testInt("1.3.6.1.2.1.43.11.1.1.8.1.1", 2000),
+	testInt("1.3.6.1.2.1.43.11.1.1.8.1.2", -2),
+	testInt("1.3.6.1.2.1.43.11.1.1.9.1.1", 500),
+	testInt("1.3.6.1.2.1.43.11.1.1.9.1.2", -3),
+
+	// prtAlertTable
+	testInt("1.3.6.1.2.1.43.18.1.1.2.1.5", 3),
+	testInt("1.3.6.1.2.1.43.18.1.1.4.1.5", 11),
+	testInt("1.3.6.1.2.1.43.18.1.1.7.1.5", 1104),
+	testStr("1.3.6.1.2.1.43.18.1.1.8.1.5", "Toner low"),
+
+	// Something after the Printer MIB
+	testInt("1.3.6.1.4.1.1.1.0", 1),
+}
+
+// TestClientWalk tests Client.Get and Client.Walk
+func TestClientWalk(t *testing.T) {
+	agent := newTestAgent(t, testPrinterObjects)
+	defer agent.Close()
+
+	for _, ver := range []Version{Version1, Version2c} {
+		clnt := NewClient(agent.Addr(), "")
+		clnt.Version = ver
+
+		vbs, err := clnt.Get(context.Background(), OidSysName)
+		if err != nil {
+			t.Errorf("%s: Get: %s", ver, err)
+			continue
+		}
+
+		if len(vbs) != 1 || vbs[0].Value.Text() != "printer" {
+			t.Errorf("%s: Get: unexpected result %v", ver, vbs)
+		}
+
+		root := MustParseOID("1.3.6.1.2.1.43.11")
+		vbs, err = clnt.Walk(context.Background(), root)
+		if err != nil {
+			t.Errorf("%s: Walk: %s", ver, err)
+			continue
+		}
+
+		if len(vbs) != 10 {
+			t.Errorf("%s: Walk: %d objects returned, expected 10",
+				ver, len(vbs))
+		}
+
+		root = MustParseOID("1.3.6.1.4.1")
+		vbs, err = clnt.Walk(context.Background(), root)
+		if err != nil || len(vbs) != 1 {
+			t.Errorf("%s: Walk at end of MIB: %v %s", ver, vbs, err)
+		}
+	}
+
+	// Missed object
+	clnt := NewClient(agent.Addr(), "")
+	clnt.Version = Version1
+	_, err := clnt.Get(context.Background(), MustParseOID("1.3.6.1.9"))
+	if err != ErrNoSuchName {
+		t.Errorf("v1: missed object: expected %s, present %v",
+			ErrNoSuchName, err)
+	}
+
+	// Retransmission
+	agent.drop.Store(1)
+	clnt = NewClient(agent.Addr(), "")
+	_, err = clnt.Get(context.Background(), OidSysName)
+	if err != nil {
+		t.Errorf("retransmission: %s", err)
+	}
+}
+
+// TestClientGetPrinterStatus tests Client.GetPrinterStatus
+func TestClientGetPrinterStatus(t *testing.T) {
+	agent := newTestAgent(t, testPrinterObjects)
+	defer agent.Close()
+
+	clnt := NewClient(agent.Addr(), "")
+	status, err := clnt.GetPrinterStatus(context.Background())
+	if err != nil {
+		t.Errorf("%s", err)
+		return
+	}
+
+	expected := &PrinterStatus{
+		Description: "Test Printer",
+		Name:        "printer",
+		State:       PrinterStateIdle,
+		Errors:      PrinterErrLowToner | PrinterErrJammed,
+		Supplies: []PrinterSupply{
+			{1, 3, "Black Toner", 7, 2000, 500},
+			{2, 4, "Waste Toner", 19, -2, -3},
+		},
+		Counters: []PrinterCounter{
+			{1, 7, 12345},
+		},
+		Alerts: []PrinterAlert{
+			{5, 3, 11, 1104, "Toner low"},
+		},
+	}
+
+	if !reflect.DeepEqual(status, expected) {
+		t.Errorf("GetPrinterStatus:\nexpected: %#v\npresent:  %#v",
+			expected, status)
+	}
+
+	if s := status.Errors.String(); s != "lowToner,jammed" {
+		t.Errorf("PrinterErrors.String: %q", s)
+	}
+
+	if s := status.Supplies[0].LevelString(); s != "25%" {
+		t.Errorf("LevelString: %q", s)
+	}
+
+	if s := status.Supplies[1].LevelString(); s != "some remaining" {
+		t.Errorf("LevelString: %q", s)
+	}
+}
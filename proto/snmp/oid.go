@@ -0,0 +1,100 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Object identifiers
+
+package snmp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OID represents SNMP object identifier
+type OID []uint32
+
+// ParseOID parses OID, represented in the dotted form (i.e.,
+// "1.3.6.1.2.1.1.1.0"). The leading dot is optional.
+func ParseOID(s string) (OID, error) {
+	s = strings.TrimPrefix(s, ".")
+	if s == "" {
+		return nil, fmt.Errorf("%q: empty OID", s)
+	}
+
+	parts := strings.Split(s, ".")
+	oid := make(OID, len(parts))
+
+	for i, part := range parts {
+		v, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%q: invalid OID", s)
+		}
+		oid[i] = uint32(v)
+	}
+
+	if len(oid) < 2 {
+		return nil, fmt.Errorf("%q: OID too short", s)
+	}
+
+	return oid, nil
+}
+
+// MustParseOID parses OID like ParseOID and panics in a case of errors.
+func MustParseOID(s string) OID {
+	oid, err := ParseOID(s)
+	if err != nil {
+		panic(err)
+	}
+	return oid
+}
+
+// String returns OID in the dotted form.
+func (oid OID) String() string {
+	s := make([]string, len(oid))
+	for i, v := range oid {
+		s[i] = strconv.FormatUint(uint64(v), 10)
+	}
+	return strings.Join(s, ".")
+}
+
+// Equal reports if two OIDs are equal.
+func (oid OID) Equal(oid2 OID) bool {
+	return len(oid) == len(oid2) && oid.HasPrefix(oid2)
+}
+
+// HasPrefix reports if OID starts with the prefix.
+func (oid OID) HasPrefix(prefix OID) bool {
+	if len(oid) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if oid[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Append returns a new OID with sub-identifiers appended.
+func (oid OID) Append(sub ...uint32) OID {
+	oid2 := make(OID, 0, len(oid)+len(sub))
+	oid2 = append(oid2, oid...)
+	return append(oid2, sub...)
+}
+
+// oidLess reports if oid1 is lexicographically less that oid2.
+func oidLess(oid1, oid2 OID) bool {
+	for i := 0; i < len(oid1) && i < len(oid2); i++ {
+		if oid1[i] != oid2[i] {
+			return oid1[i] < oid2[i]
+		}
+	}
+
+	return len(oid1) < len(oid2)
+}
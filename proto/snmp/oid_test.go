@@ -0,0 +1,75 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Object identifiers test
+
+package snmp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseOID tests ParseOID
+func TestParseOID(t *testing.T) {
+	tests := []struct {
+		in  string
+		oid OID
+		err string
+	}{
+		{"1.3.6.1.2.1.1.1.0", OID{1, 3, 6, 1, 2, 1, 1, 1, 0}, ""},
+		{".1.3.6.1", OID{1, 3, 6, 1}, ""},
+		{"", nil, `"": empty OID`},
+		{"1", nil, `"1": OID too short`},
+		{"1.3.x", nil, `"1.3.x": invalid OID`},
+		{"1..3", nil, `"1..3": invalid OID`},
+	}
+
+	for _, test := range tests {
+		oid, err := ParseOID(test.in)
+		errstr := ""
+		if err != nil {
+			errstr = err.Error()
+		}
+
+		if errstr != test.err {
+			t.Errorf("%q: error mismatch:\nexpected: %s\npresent:  %s",
+				test.in, test.err, errstr)
+			continue
+		}
+
+		if !reflect.DeepEqual(oid, test.oid) {
+			t.Errorf("%q: expected %s, present %s",
+				test.in, test.oid, oid)
+		}
+
+		if err == nil && oid.String() != test.oid.String() {
+			t.Errorf("%q: String mismatch", test.in)
+		}
+	}
+}
+
+// TestOIDCompare tests OID comparison functions
+func TestOIDCompare(t *testing.T) {
+	a := OID{1, 3, 6, 1}
+	b := a.Append(2, 1)
+
+	if !b.HasPrefix(a) || a.HasPrefix(b) {
+		t.Errorf("HasPrefix failed")
+	}
+
+	if !a.Equal(OID{1, 3, 6, 1}) || a.Equal(b) {
+		t.Errorf("Equal failed")
+	}
+
+	if !oidLess(a, b) || oidLess(b, a) || oidLess(a, a) {
+		t.Errorf("oidLess failed")
+	}
+
+	if !oidLess(OID{1, 3, 6, 1, 2}, OID{1, 3, 6, 1, 10}) {
+		t.Errorf("oidLess: numeric comparison failed")
+	}
+}
@@ -0,0 +1,224 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SNMP messages and PDUs
+
+package snmp
+
+import (
+	"fmt"
+)
+
+// Version is the SNMP protocol version
+type Version int
+
+// Version values. Numerically, they are the same, as encoded
+// into the SNMP message.
+const (
+	Version1  Version = 0 // SNMPv1
+	Version2c Version = 1 // SNMPv2c
+)
+
+// String returns Version name.
+func (ver Version) String() string {
+	switch ver {
+	case Version1:
+		return "v1"
+	case Version2c:
+		return "v2c"
+	}
+
+	return fmt.Sprintf("v?(%d)", int(ver))
+}
+
+// pduType is the PDU type. Numerically, it is the BER tag of the PDU.
+type pduType byte
+
+// pduType values:
+const (
+	pduGetRequest     pduType = 0xa0
+	pduGetNextRequest pduType = 0xa1
+	pduResponse       pduType = 0xa2
+	pduGetBulkRequest pduType = 0xa5
+)
+
+// ErrorStatus is the error status, returned by the SNMP agent.
+// It implements the error interface.
+type ErrorStatus int
+
+// ErrorStatus values (the most common ones):
+const (
+	ErrNoError    ErrorStatus = 0
+	ErrTooBig     ErrorStatus = 1
+	ErrNoSuchName ErrorStatus = 2
+	ErrBadValue   ErrorStatus = 3
+	ErrReadOnly   ErrorStatus = 4
+	ErrGenErr     ErrorStatus = 5
+)
+
+// Error returns ErrorStatus as error string.
+func (status ErrorStatus) Error() string {
+	switch status {
+	case ErrNoError:
+		return "SNMP: noError"
+	case ErrTooBig:
+		return "SNMP: tooBig"
+	case ErrNoSuchName:
+		return "SNMP: noSuchName"
+	case ErrBadValue:
+		return "SNMP: badValue"
+	case ErrReadOnly:
+		return "SNMP: readOnly"
+	case ErrGenErr:
+		return "SNMP: genErr"
+	}
+
+	return fmt.Sprintf("SNMP: error %d", int(status))
+}
+
+// pdu represents the SNMP PDU.
+//
+// For the GetBulkRequest, ErrorStatus and ErrorIndex fields
+// are used as non-repeaters and max-repetitions.
+type pdu struct {
+	Type        pduType     // PDU type
+	RequestID   int32       // Request ID
+	ErrorStatus ErrorStatus // Error status
+	ErrorIndex  int         // Error index
+	Varbinds    []Varbind   // Variable bindings
+}
+
+// message represents the SNMP v1/v2c message.
+type message struct {
+	Version   Version // Protocol version
+	Community string  // Community string
+	PDU       pdu     // The PDU
+}
+
+// Encode encodes the message.
+func (msg message) Encode() []byte {
+	var varbinds []byte
+	for _, vb := range msg.PDU.Varbinds {
+		var tmp []byte
+		tmp = berAppendOID(tmp, vb.OID)
+		tmp = berAppendValue(tmp, vb.Value)
+		varbinds = berAppendTLV(varbinds, berTagSequence, tmp)
+	}
+
+	var pdu []byte
+	pdu = berAppendInt(pdu, byte(TypeInteger), int64(msg.PDU.RequestID))
+	pdu = berAppendInt(pdu, byte(TypeInteger), int64(msg.PDU.ErrorStatus))
+	pdu = berAppendInt(pdu, byte(TypeInteger), int64(msg.PDU.ErrorIndex))
+	pdu = berAppendTLV(pdu, berTagSequence, varbinds)
+
+	var content []byte
+	content = berAppendInt(content, byte(TypeInteger), int64(msg.Version))
+	content = berAppendTLV(content, byte(TypeOctetString),
+		[]byte(msg.Community))
+	content = berAppendTLV(content, byte(msg.PDU.Type), pdu)
+
+	return berAppendTLV(nil, berTagSequence, content)
+}
+
+// decodeMessage decodes the message.
+func decodeMessage(data []byte) (msg message, err error) {
+	dec := berDecoder{data}
+	content, err := dec.Expect(berTagSequence)
+	if err != nil {
+		return
+	}
+
+	dec = berDecoder{content}
+
+	// Decode header
+	ver, err := dec.ExpectInt()
+	if err != nil {
+		return
+	}
+	msg.Version = Version(ver)
+
+	community, err := dec.Expect(byte(TypeOctetString))
+	if err != nil {
+		return
+	}
+	msg.Community = string(community)
+
+	tag, content, err := dec.Next()
+	if err != nil {
+		return
+	}
+
+	msg.PDU.Type = pduType(tag)
+	switch msg.PDU.Type {
+	case pduGetRequest, pduGetNextRequest, pduResponse, pduGetBulkRequest:
+	default:
+		err = fmt.Errorf("SNMP: unknown PDU type 0x%2.2x", tag)
+		return
+	}
+
+	// Decode PDU
+	dec = berDecoder{content}
+
+	rqid, err := dec.ExpectInt()
+	if err != nil {
+		return
+	}
+	msg.PDU.RequestID = int32(rqid)
+
+	status, err := dec.ExpectInt()
+	if err != nil {
+		return
+	}
+	msg.PDU.ErrorStatus = ErrorStatus(status)
+
+	index, err := dec.ExpectInt()
+	if err != nil {
+		return
+	}
+	msg.PDU.ErrorIndex = int(index)
+
+	// Decode varbinds
+	content, err = dec.Expect(berTagSequence)
+	if err != nil {
+		return
+	}
+
+	dec = berDecoder{content}
+	for !dec.Empty() {
+		content, err = dec.Expect(berTagSequence)
+		if err != nil {
+			return
+		}
+
+		vbdec := berDecoder{content}
+
+		var vb Varbind
+		content, err = vbdec.Expect(byte(TypeOID))
+		if err != nil {
+			return
+		}
+
+		vb.OID, err = berDecodeOID(content)
+		if err != nil {
+			return
+		}
+
+		var tag byte
+		tag, content, err = vbdec.Next()
+		if err != nil {
+			return
+		}
+
+		vb.Value, err = berDecodeValue(tag, content)
+		if err != nil {
+			return
+		}
+
+		msg.PDU.Varbinds = append(msg.PDU.Varbinds, vb)
+	}
+
+	return
+}
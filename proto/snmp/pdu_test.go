@@ -0,0 +1,121 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SNMP messages and PDUs test
+
+package snmp
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestMessageEncode tests message encoding against known bytes
+func TestMessageEncode(t *testing.T) {
+	msg := message{
+		Version:   Version1,
+		Community: "public",
+		PDU: pdu{
+			Type:      pduGetRequest,
+			RequestID: 1,
+			Varbinds: []Varbind{
+				{MustParseOID("1.3.6.1.2.1.1.1.0"),
+					Value{Type: TypeNull}},
+			},
+		},
+	}
+
+	expected := []byte{
+		0x30, 0x26,
+		0x02, 0x01, 0x00,
+		0x04, 0x06, 'p', 'u', 'b', 'l', 'i', 'c',
+		0xa0, 0x19,
+		0x02, 0x01, 0x01,
+		0x02, 0x01, 0x00,
+		0x02, 0x01, 0x00,
+		0x30, 0x0e,
+		0x30, 0x0c,
+		0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00,
+		0x05, 0x00,
+	}
+
+	present := msg.Encode()
+	if !bytes.Equal(present, expected) {
+		t.Errorf("encode mismatch:\nexpected: % x\npresent:  % x",
+			expected, present)
+	}
+}
+
+// TestMessageRoundTrip tests message encoding and decoding
+func TestMessageRoundTrip(t *testing.T) {
+	long := bytes.Repeat([]byte("x"), 300)
+
+	msg := message{
+		Version:   Version2c,
+		Community: "private",
+		PDU: pdu{
+			Type:        pduResponse,
+			RequestID:   0x7fffffff,
+			ErrorStatus: ErrNoSuchName,
+			ErrorIndex:  2,
+			Varbinds: []Varbind{
+				{OID{1, 3, 6, 1, 200000}, Value{Type: TypeInteger, Int: -3}},
+				{OID{1, 3}, Value{Type: TypeInteger, Int: 128}},
+				{OID{1, 3}, Value{Type: TypeInteger, Int: math.MinInt64}},
+				{OID{1, 3}, Value{Type: TypeOctetString, Bytes: long}},
+				{OID{1, 3}, Value{Type: TypeOID, OID: OID{2, 999, 1}}},
+				{OID{1, 3}, Value{Type: TypeIPAddress,
+					Bytes: []byte{192, 168, 0, 1}}},
+				{OID{1, 3}, Value{Type: TypeCounter32, Uint: 0xffffffff}},
+				{OID{1, 3}, Value{Type: TypeGauge32, Uint: 0}},
+				{OID{1, 3}, Value{Type: TypeTimeTicks, Uint: 12345}},
+				{OID{1, 3}, Value{Type: TypeCounter64,
+					Uint: math.MaxUint64}},
+				{OID{1, 3}, Value{Type: TypeEndOfMibView}},
+			},
+		},
+	}
+
+	decoded, err := decodeMessage(msg.Encode())
+	if err != nil {
+		t.Errorf("%s", err)
+		return
+	}
+
+	if !reflect.DeepEqual(decoded, msg) {
+		t.Errorf("round trip mismatch:\nexpected: %#v\npresent:  %#v",
+			msg, decoded)
+	}
+}
+
+// TestMessageDecodeErrors tests decoding of malformed messages
+func TestMessageDecodeErrors(t *testing.T) {
+	good := message{
+		Version:   Version1,
+		Community: "public",
+		PDU: pdu{
+			Type: pduResponse,
+			Varbinds: []Varbind{
+				{OID{1, 3}, Value{Type: TypeInteger, Int: 1}},
+			},
+		},
+	}.Encode()
+
+	for i := 0; i < len(good); i++ {
+		_, err := decodeMessage(good[:i])
+		if err == nil {
+			t.Errorf("truncated at %d: error not detected", i)
+		}
+	}
+
+	bad := bytes.Clone(good)
+	bad[0] = 0x31
+	if _, err := decodeMessage(bad); err == nil {
+		t.Errorf("bad tag: error not detected")
+	}
+}
@@ -0,0 +1,462 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Printer MIB (RFC 3805) and Host Resources MIB (RFC 2790) queries
+
+package snmp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Well-known OIDs
+var (
+	OidSysDescr               = MustParseOID("1.3.6.1.2.1.1.1.0")
+	OidSysName                = MustParseOID("1.3.6.1.2.1.1.5.0")
+	OidHrPrinterStatus        = MustParseOID("1.3.6.1.2.1.25.3.5.1.1")
+	OidHrPrinterErrorState    = MustParseOID("1.3.6.1.2.1.25.3.5.1.2")
+	OidPrtMarkerEntry         = MustParseOID("1.3.6.1.2.1.43.10.2.1")
+	OidPrtMarkerSuppliesEntry = MustParseOID("1.3.6.1.2.1.43.11.1.1")
+	OidPrtAlertEntry          = MustParseOID("1.3.6.1.2.1.43.18.1.1")
+)
+
+// PrinterState is the hrPrinterStatus value
+type PrinterState int
+
+// PrinterState values:
+const (
+	PrinterStateOther    PrinterState = 1
+	PrinterStateUnknown  PrinterState = 2
+	PrinterStateIdle     PrinterState = 3
+	PrinterStatePrinting PrinterState = 4
+	PrinterStateWarmup   PrinterState = 5
+)
+
+// String returns PrinterState name.
+func (state PrinterState) String() string {
+	switch state {
+	case PrinterStateOther:
+		return "other"
+	case PrinterStateUnknown:
+		return "unknown"
+	case PrinterStateIdle:
+		return "idle"
+	case PrinterStatePrinting:
+		return "printing"
+	case PrinterStateWarmup:
+		return "warmup"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(state))
+}
+
+// PrinterErrors is the hrPrinterDetectedErrorState bitmask.
+//
+// Bits are numbered as in the RFC 2790, i.e., bit 0 is the
+// most significant bit of the first octet.
+type PrinterErrors uint16
+
+// PrinterErrors bits:
+const (
+	PrinterErrLowPaper PrinterErrors = 1 << (15 - iota)
+	PrinterErrNoPaper
+	PrinterErrLowToner
+	PrinterErrNoToner
+	PrinterErrDoorOpen
+	PrinterErrJammed
+	PrinterErrOffline
+	PrinterErrServiceRequested
+	PrinterErrInputTrayMissing
+	PrinterErrOutputTrayMissing
+	PrinterErrMarkerSupplyMissing
+	PrinterErrOutputNearFull
+	PrinterErrOutputFull
+	PrinterErrInputTrayEmpty
+	PrinterErrOverduePreventMaint
+)
+
+// printerErrorsNames contains names of PrinterErrors bits
+var printerErrorsNames = []string{
+	"lowPaper",
+	"noPaper",
+	"lowToner",
+	"noToner",
+	"doorOpen",
+	"jammed",
+	"offline",
+	"serviceRequested",
+	"inputTrayMissing",
+	"outputTrayMissing",
+	"markerSupplyMissing",
+	"outputNearFull",
+	"outputFull",
+	"inputTrayEmpty",
+	"overduePreventMaint",
+}
+
+// String returns PrinterErrors as a comma-separated list of names.
+func (errs PrinterErrors) String() string {
+	names := []string{}
+	for i, name := range printerErrorsNames {
+		if errs&(1<<(15-i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// PrinterSupply represents a prtMarkerSuppliesTable entry.
+type PrinterSupply struct {
+	Index       int    // prtMarkerSuppliesIndex
+	Type        int    // prtMarkerSuppliesType
+	Description string // prtMarkerSuppliesDescription
+	Unit        int    // prtMarkerSuppliesSupplyUnit
+	MaxCapacity int    // prtMarkerSuppliesMaxCapacity
+	Level       int    // prtMarkerSuppliesLevel
+}
+
+// Special values of the PrinterSupply.MaxCapacity and Level
+const (
+	SupplyLevelOther         = -1 // No restriction on this supply
+	SupplyLevelUnknown       = -2 // Level is unknown
+	SupplyLevelSomeRemaining = -3 // At least one unit remains
+)
+
+// Percent returns supply level in percents of MaxCapacity.
+// If percentage is not known, it returns -1.
+func (supply PrinterSupply) Percent() int {
+	if supply.MaxCapacity <= 0 || supply.Level < 0 {
+		return -1
+	}
+
+	return supply.Level * 100 / supply.MaxCapacity
+}
+
+// TypeString returns supply type name.
+func (supply PrinterSupply) TypeString() string {
+	if name := supplyTypeNames[supply.Type]; name != "" {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", supply.Type)
+}
+
+// LevelString returns supply level in the human-readable form.
+func (supply PrinterSupply) LevelString() string {
+	switch {
+	case supply.Level == SupplyLevelOther:
+		return "n/a"
+	case supply.Level == SupplyLevelSomeRemaining:
+		return "some remaining"
+	case supply.Level < 0:
+		return "unknown"
+	case supply.Percent() >= 0:
+		return fmt.Sprintf("%d%%", supply.Percent())
+	}
+
+	return fmt.Sprintf("%d %s", supply.Level, unitName(supply.Unit))
+}
+
+// supplyTypeNames contains names of prtMarkerSuppliesType values
+var supplyTypeNames = map[int]string{
+	1:  "other",
+	2:  "unknown",
+	3:  "toner",
+	4:  "wasteToner",
+	5:  "ink",
+	6:  "inkCartridge",
+	7:  "inkRibbon",
+	8:  "wasteInk",
+	9:  "opc",
+	10: "developer",
+	11: "fuserOil",
+	12: "solidWax",
+	13: "ribbonWax",
+	14: "wasteWax",
+	15: "fuser",
+	16: "coronaWire",
+	17: "fuserOilWick",
+	18: "cleanerUnit",
+	19: "fuserCleaningPad",
+	20: "transferUnit",
+	21: "tonerCartridge",
+	22: "fuserOiler",
+	23: "water",
+	24: "wasteWater",
+	25: "glueWaterAdditive",
+	26: "wastePaper",
+	27: "bindingSupply",
+	28: "bandingSupply",
+	29: "stitchingWire",
+	30: "shrinkWrap",
+	31: "paperWrap",
+	32: "staples",
+	33: "inserts",
+	34: "covers",
+}
+
+// PrinterCounter represents a prtMarkerTable entry (page counter).
+type PrinterCounter struct {
+	Index     int // prtMarkerIndex
+	Unit      int // prtMarkerCounterUnit
+	LifeCount int // prtMarkerLifeCount
+}
+
+// UnitString returns counter unit name.
+func (counter PrinterCounter) UnitString() string {
+	return unitName(counter.Unit)
+}
+
+// unitName returns name of the prtMarkerSuppliesSupplyUnit or
+// prtMarkerCounterUnit value.
+func unitName(unit int) string {
+	switch unit {
+	case 3:
+		return "tenThousandthsOfInches"
+	case 4:
+		return "micrometers"
+	case 5:
+		return "characters"
+	case 6:
+		return "lines"
+	case 7:
+		return "impressions"
+	case 8:
+		return "sheets"
+	case 9:
+		return "dotRow"
+	case 11:
+		return "hours"
+	case 12:
+		return "thousandthsOfOunces"
+	case 13:
+		return "tenthsOfGrams"
+	case 14:
+		return "hundrethsOfFluidOunces"
+	case 15:
+		return "tenthsOfMilliliters"
+	case 16:
+		return "feet"
+	case 17:
+		return "meters"
+	case 18:
+		return "items"
+	case 19:
+		return "percent"
+	}
+
+	return "units"
+}
+
+// PrinterAlert represents a prtAlertTable entry.
+type PrinterAlert struct {
+	Index       int    // prtAlertIndex
+	Severity    int    // prtAlertSeverityLevel
+	Group       int    // prtAlertGroup
+	Code        int    // prtAlertCode
+	Description string // prtAlertDescription
+}
+
+// SeverityString returns alert severity name.
+func (alert PrinterAlert) SeverityString() string {
+	switch alert.Severity {
+	case 1:
+		return "other"
+	case 2:
+		return "critical"
+	case 3:
+		return "warning"
+	case 4:
+		return "warningBinaryChangeEvent"
+	}
+
+	return fmt.Sprintf("unknown(%d)", alert.Severity)
+}
+
+// PrinterStatus contains printer status, obtained via SNMP.
+type PrinterStatus struct {
+	Description string           // sysDescr
+	Name        string           // sysName
+	State       PrinterState     // hrPrinterStatus
+	Errors      PrinterErrors    // hrPrinterDetectedErrorState
+	Supplies    []PrinterSupply  // Marker supplies (toner etc)
+	Counters    []PrinterCounter // Page counters
+	Alerts      []PrinterAlert   // Active alerts
+}
+
+// GetPrinterStatus queries the printer status.
+//
+// The system group and hrPrinterStatus are mandatory; if the
+// Printer MIB tables are not supported by the device, the
+// corresponding fields remain empty.
+func (c *Client) GetPrinterStatus(ctx context.Context) (
+	*PrinterStatus, error) {
+
+	status := &PrinterStatus{State: PrinterStateUnknown}
+
+	// System group
+	vbs, err := c.Get(ctx, OidSysDescr, OidSysName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vb := range vbs {
+		switch {
+		case vb.OID.Equal(OidSysDescr):
+			status.Description = vb.Value.Text()
+		case vb.OID.Equal(OidSysName):
+			status.Name = vb.Value.Text()
+		}
+	}
+
+	// Printer status. It is indexed by the hrDeviceIndex, and we
+	// use the first printer found.
+	vbs, err = c.Walk(ctx, OidHrPrinterStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vbs) != 0 {
+		if v, ok := vbs[0].Value.Integer(); ok {
+			status.State = PrinterState(v)
+		}
+	}
+
+	vbs, err = c.Walk(ctx, OidHrPrinterErrorState)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vbs) != 0 {
+		b := vbs[0].Value.Bytes
+		if len(b) > 0 {
+			status.Errors = PrinterErrors(b[0]) << 8
+		}
+		if len(b) > 1 {
+			status.Errors |= PrinterErrors(b[1])
+		}
+	}
+
+	// Printer MIB tables
+	table, err := c.walkTable(ctx, OidPrtMarkerSuppliesEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range table {
+		status.Supplies = append(status.Supplies, PrinterSupply{
+			Index:       row.index,
+			Type:        row.Int(5),
+			Description: row.Text(6),
+			Unit:        row.Int(7),
+			MaxCapacity: row.Int(8),
+			Level:       row.Int(9),
+		})
+	}
+
+	table, err = c.walkTable(ctx, OidPrtMarkerEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range table {
+		status.Counters = append(status.Counters, PrinterCounter{
+			Index:     row.index,
+			Unit:      row.Int(3),
+			LifeCount: row.Int(4),
+		})
+	}
+
+	table, err = c.walkTable(ctx, OidPrtAlertEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range table {
+		status.Alerts = append(status.Alerts, PrinterAlert{
+			Index:       row.index,
+			Severity:    row.Int(2),
+			Group:       row.Int(4),
+			Code:        row.Int(7),
+			Description: row.Text(8),
+		})
+	}
+
+	return status, nil
+}
+
+// tableRow represents a row of the Printer MIB table, indexed
+// by hrDeviceIndex and the table-specific index.
+type tableRow struct {
+	index   int              // Table-specific index
+	columns map[uint32]Value // Values by column number
+}
+
+// Int returns integer value of the column, 0 if not available
+func (row tableRow) Int(col uint32) int {
+	v, _ := row.columns[col].Integer()
+	return int(v)
+}
+
+// Text returns text value of the column, "" if not available
+func (row tableRow) Text(col uint32) string {
+	return row.columns[col].Text()
+}
+
+// walkTable retrieves the Printer MIB table.
+//
+// The entry OID is followed by the column number and the row
+// index, which consist of the hrDeviceIndex and the table-specific
+// index. Rows are returned sorted by index.
+func (c *Client) walkTable(ctx context.Context, entry OID) (
+	[]tableRow, error) {
+
+	vbs, err := c.Walk(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[[2]uint32]*tableRow)
+	for _, vb := range vbs {
+		suffix := vb.OID[len(entry):]
+		if len(suffix) != 3 {
+			continue
+		}
+
+		key := [2]uint32{suffix[1], suffix[2]}
+		row := rows[key]
+		if row == nil {
+			row = &tableRow{
+				index:   int(suffix[2]),
+				columns: make(map[uint32]Value),
+			}
+			rows[key] = row
+		}
+
+		row.columns[suffix[0]] = vb.Value
+	}
+
+	keys := make([][2]uint32, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	table := make([]tableRow, len(keys))
+	for i, key := range keys {
+		table[i] = *rows[key]
+	}
+
+	return table, nil
+}
@@ -0,0 +1,147 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// SNMP client
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SNMP values
+
+package snmp
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"unicode/utf8"
+)
+
+// Type identifies type of the SNMP value. Numerically, it is
+// the BER tag of the value.
+type Type byte
+
+// Type values:
+const (
+	TypeInteger        Type = 0x02 // INTEGER
+	TypeOctetString    Type = 0x04 // OCTET STRING
+	TypeNull           Type = 0x05 // NULL
+	TypeOID            Type = 0x06 // OBJECT IDENTIFIER
+	TypeIPAddress      Type = 0x40 // IpAddress
+	TypeCounter32      Type = 0x41 // Counter32
+	TypeGauge32        Type = 0x42 // Gauge32 (Unsigned32)
+	TypeTimeTicks      Type = 0x43 // TimeTicks
+	TypeOpaque         Type = 0x44 // Opaque
+	TypeCounter64      Type = 0x46 // Counter64
+	TypeNoSuchObject   Type = 0x80 // noSuchObject exception (v2c)
+	TypeNoSuchInstance Type = 0x81 // noSuchInstance exception (v2c)
+	TypeEndOfMibView   Type = 0x82 // endOfMibView exception (v2c)
+)
+
+// String returns Type name, for debugging.
+func (t Type) String() string {
+	switch t {
+	case TypeInteger:
+		return "INTEGER"
+	case TypeOctetString:
+		return "OCTET STRING"
+	case TypeNull:
+		return "NULL"
+	case TypeOID:
+		return "OBJECT IDENTIFIER"
+	case TypeIPAddress:
+		return "IpAddress"
+	case TypeCounter32:
+		return "Counter32"
+	case TypeGauge32:
+		return "Gauge32"
+	case TypeTimeTicks:
+		return "TimeTicks"
+	case TypeOpaque:
+		return "Opaque"
+	case TypeCounter64:
+		return "Counter64"
+	case TypeNoSuchObject:
+		return "noSuchObject"
+	case TypeNoSuchInstance:
+		return "noSuchInstance"
+	case TypeEndOfMibView:
+		return "endOfMibView"
+	}
+
+	return fmt.Sprintf("0x%2.2x", byte(t))
+}
+
+// Value represents a SNMP value.
+//
+// Depending on Type, only one of the value fields is meaningful.
+type Value struct {
+	Type  Type   // Value type
+	Int   int64  // TypeInteger
+	Uint  uint64 // TypeCounter32, TypeGauge32, TypeTimeTicks, TypeCounter64
+	Bytes []byte // TypeOctetString, TypeIPAddress, TypeOpaque
+	OID   OID    // TypeOID
+}
+
+// Varbind represents SNMP variable binding: the OID and its value.
+type Varbind struct {
+	OID   OID   // Object identifier
+	Value Value // Object value
+}
+
+// IsException reports if Value is one of the SNMPv2 exceptions
+// (noSuchObject, noSuchInstance or endOfMibView).
+func (v Value) IsException() bool {
+	switch v.Type {
+	case TypeNoSuchObject, TypeNoSuchInstance, TypeEndOfMibView:
+		return true
+	}
+	return false
+}
+
+// Integer returns integer value of the INTEGER and all unsigned
+// types. It returns false, if Value is not integer.
+func (v Value) Integer() (int64, bool) {
+	switch v.Type {
+	case TypeInteger:
+		return v.Int, true
+	case TypeCounter32, TypeGauge32, TypeTimeTicks, TypeCounter64:
+		return int64(v.Uint), true
+	}
+	return 0, false
+}
+
+// Text returns value of the OCTET STRING as a text.
+//
+// Trailing NUL characters, often appended by printers, are removed
+// and invalid UTF-8 sequences are replaced.
+func (v Value) Text() string {
+	s := strings.TrimRight(string(v.Bytes), "\x00")
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "?")
+	}
+	return s
+}
+
+// String returns Value in the human-readable form.
+func (v Value) String() string {
+	switch v.Type {
+	case TypeInteger:
+		return fmt.Sprintf("%d", v.Int)
+	case TypeOctetString:
+		return fmt.Sprintf("%q", v.Text())
+	case TypeNull:
+		return "NULL"
+	case TypeOID:
+		return v.OID.String()
+	case TypeIPAddress:
+		if addr, ok := netip.AddrFromSlice(v.Bytes); ok {
+			return addr.String()
+		}
+		return fmt.Sprintf("%x", v.Bytes)
+	case TypeCounter32, TypeGauge32, TypeTimeTicks, TypeCounter64:
+		return fmt.Sprintf("%d", v.Uint)
+	case TypeOpaque:
+		return fmt.Sprintf("%x", v.Bytes)
+	}
+
+	return v.Type.String()
+}
@@ -8,7 +8,11 @@
 
 package wsd
 
-import "github.com/OpenPrinting/go-mfp/util/xmldoc"
+import (
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
 
 // Action represents a message action (or message type).
 //
@@ -28,6 +32,14 @@ const (
 	ActResolveMatches
 	ActGet
 	ActGetResponse
+	ActSubscribe
+	ActSubscribeResponse
+	ActRenew
+	ActRenewResponse
+	ActUnsubscribe
+	ActUnsubscribeResponse
+	ActSubscriptionEnd
+	ActFault
 )
 
 // String represents action as a short string, for debugging.
@@ -49,6 +61,22 @@ func (act Action) String() string {
 		return "Get"
 	case ActGetResponse:
 		return "GetResponse"
+	case ActSubscribe:
+		return "Subscribe"
+	case ActSubscribeResponse:
+		return "SubscribeResponse"
+	case ActRenew:
+		return "Renew"
+	case ActRenewResponse:
+		return "RenewResponse"
+	case ActUnsubscribe:
+		return "Unsubscribe"
+	case ActUnsubscribeResponse:
+		return "UnsubscribeResponse"
+	case ActSubscriptionEnd:
+		return "SubscriptionEnd"
+	case ActFault:
+		return "Fault"
 	}
 
 	return "Unknown"
@@ -76,11 +104,51 @@ func (act Action) bodyname() string {
 		return ""
 	case ActGetResponse:
 		return NsMex + ":Metadata"
+	case ActSubscribe:
+		return NsEventing + ":Subscribe"
+	case ActSubscribeResponse:
+		return NsEventing + ":SubscribeResponse"
+	case ActRenew:
+		return NsEventing + ":Renew"
+	case ActRenewResponse:
+		return NsEventing + ":RenewResponse"
+	case ActUnsubscribe:
+		return NsEventing + ":Unsubscribe"
+	case ActUnsubscribeResponse:
+		return ""
+	case ActSubscriptionEnd:
+		return NsEventing + ":SubscriptionEnd"
+	case ActFault:
+		return NsSOAP + ":Fault"
 	}
 
 	return ""
 }
 
+// Response returns the action of the response to the request
+// with this action. For one-way messages (i.e., Hello, Bye or
+// responses themselves) it returns ActUnknown.
+//
+// Note, any request may be answered with the [ActFault] instead.
+func (act Action) Response() Action {
+	switch act {
+	case ActProbe:
+		return ActProbeMatches
+	case ActResolve:
+		return ActResolveMatches
+	case ActGet:
+		return ActGetResponse
+	case ActSubscribe:
+		return ActSubscribeResponse
+	case ActRenew:
+		return ActRenewResponse
+	case ActUnsubscribe:
+		return ActUnsubscribeResponse
+	}
+
+	return ActUnknown
+}
+
 // Encode represents action as a string for wire encoding.
 // For unknown action it returns "".
 func (act Action) Encode() string {
@@ -101,6 +169,22 @@ func (act Action) Encode() string {
 		return "http://schemas.xmlsoap.org/ws/2004/09/transfer/Get"
 	case ActGetResponse:
 		return "http://schemas.xmlsoap.org/ws/2004/09/transfer/GetResponse"
+	case ActSubscribe:
+		return "http://schemas.xmlsoap.org/ws/2004/08/eventing/Subscribe"
+	case ActSubscribeResponse:
+		return "http://schemas.xmlsoap.org/ws/2004/08/eventing/SubscribeResponse"
+	case ActRenew:
+		return "http://schemas.xmlsoap.org/ws/2004/08/eventing/Renew"
+	case ActRenewResponse:
+		return "http://schemas.xmlsoap.org/ws/2004/08/eventing/RenewResponse"
+	case ActUnsubscribe:
+		return "http://schemas.xmlsoap.org/ws/2004/08/eventing/Unsubscribe"
+	case ActUnsubscribeResponse:
+		return "http://schemas.xmlsoap.org/ws/2004/08/eventing/UnsubscribeResponse"
+	case ActSubscriptionEnd:
+		return "http://schemas.xmlsoap.org/ws/2004/08/eventing/SubscriptionEnd"
+	case ActFault:
+		return "http://schemas.xmlsoap.org/ws/2004/08/addressing/fault"
 	}
 
 	return ""
@@ -108,7 +192,17 @@ func (act Action) Encode() string {
 
 // ActDecode decodes wire representation of action into the action number.
 // For unknown actions it returns actOther
+//
+// Surrounding white space is ignored, and https: scheme is accepted
+// as equal to http:, the same way as xmldoc does for namespace URLs.
+// Some devices and even the Microsoft's own WSD samples send actions
+// this way.
 func ActDecode(s string) Action {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "https:") {
+		s = "http" + s[5:]
+	}
+
 	switch s {
 	case "http://schemas.xmlsoap.org/ws/2005/04/discovery/Hello":
 		return ActHello
@@ -126,6 +220,24 @@ func ActDecode(s string) Action {
 		return ActGet
 	case "http://schemas.xmlsoap.org/ws/2004/09/transfer/GetResponse":
 		return ActGetResponse
+	case "http://schemas.xmlsoap.org/ws/2004/08/eventing/Subscribe":
+		return ActSubscribe
+	case "http://schemas.xmlsoap.org/ws/2004/08/eventing/SubscribeResponse":
+		return ActSubscribeResponse
+	case "http://schemas.xmlsoap.org/ws/2004/08/eventing/Renew":
+		return ActRenew
+	case "http://schemas.xmlsoap.org/ws/2004/08/eventing/RenewResponse":
+		return ActRenewResponse
+	case "http://schemas.xmlsoap.org/ws/2004/08/eventing/Unsubscribe":
+		return ActUnsubscribe
+	case "http://schemas.xmlsoap.org/ws/2004/08/eventing/UnsubscribeResponse":
+		return ActUnsubscribeResponse
+	case "http://schemas.xmlsoap.org/ws/2004/08/eventing/SubscriptionEnd":
+		return ActSubscriptionEnd
+	case "http://schemas.xmlsoap.org/ws/2004/08/addressing/fault",
+		"http://schemas.xmlsoap.org/ws/2005/04/discovery/fault",
+		"http://www.w3.org/2005/08/addressing/soap/fault":
+		return ActFault
 	}
 
 	return ActUnknown
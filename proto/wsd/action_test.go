@@ -27,6 +27,14 @@ func TestAction(t *testing.T) {
 		{ActResolveMatches, "ResolveMatches"},
 		{ActGet, "Get"},
 		{ActGetResponse, "GetResponse"},
+		{ActSubscribe, "Subscribe"},
+		{ActSubscribeResponse, "SubscribeResponse"},
+		{ActRenew, "Renew"},
+		{ActRenewResponse, "RenewResponse"},
+		{ActUnsubscribe, "Unsubscribe"},
+		{ActUnsubscribeResponse, "UnsubscribeResponse"},
+		{ActSubscriptionEnd, "SubscriptionEnd"},
+		{ActFault, "Fault"},
 	}
 
 	for _, test := range tests {
@@ -48,3 +56,21 @@ func TestAction(t *testing.T) {
 		}
 	}
 }
+
+// TestActDecodeTolerance tests that ActDecode tolerates white space
+// and https: scheme
+func TestActDecodeTolerance(t *testing.T) {
+	tests := []string{
+		"\n\thttp://schemas.xmlsoap.org/ws/2005/04/discovery/Hello\n",
+		"https://schemas.xmlsoap.org/ws/2005/04/discovery/Hello",
+		" https://schemas.xmlsoap.org/ws/2005/04/discovery/Hello ",
+	}
+
+	for _, s := range tests {
+		act := ActDecode(s)
+		if act != ActHello {
+			t.Errorf("ActDecode(%q): expected %s, present %s",
+				s, ActHello, act)
+		}
+	}
+}
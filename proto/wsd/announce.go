@@ -23,6 +23,7 @@ import (
 type Announce struct {
 	EndpointReference EndpointReference // Stable identifier of the device
 	Types             Types             // Device types
+	Scopes            Scopes            // Device scopes
 	XAddrs            XAddrs            // Transport addresses (URLs)
 	MetadataVersion   uint64            // Incremented when metadata changes
 }
@@ -36,13 +37,15 @@ func decodeAnnounce(root xmldoc.Element) (ann Announce, err error) {
 		Name: NsAddressing + ":EndpointReference", Required: true}
 	types := xmldoc.Lookup{
 		Name: NsDiscovery + ":" + "Types"}
+	scopes := xmldoc.Lookup{
+		Name: NsDiscovery + ":" + "Scopes"}
 	xaddrs := xmldoc.Lookup{
 		Name: NsDiscovery + ":" + "XAddrs"}
 	metadataVersion := xmldoc.Lookup{
 		Name: NsDiscovery + ":" + "MetadataVersion", Required: true}
 
 	missed := root.Lookup(&endpointReference, &types,
-		&xaddrs, &metadataVersion)
+		&scopes, &xaddrs, &metadataVersion)
 
 	if missed != nil {
 		err = xmldoc.XMLErrMissed(missed.Name)
@@ -57,6 +60,10 @@ func decodeAnnounce(root xmldoc.Element) (ann Announce, err error) {
 		ann.Types, err = DecodeTypes(types.Elem)
 	}
 
+	if err == nil && scopes.Found {
+		ann.Scopes, err = DecodeScopes(scopes.Elem)
+	}
+
 	if err == nil && xaddrs.Found {
 		ann.XAddrs, err = DecodeXAddrs(xaddrs.Elem)
 	}
@@ -86,6 +93,10 @@ func (ann Announce) ToXML(name string) xmldoc.Element {
 		elm.Children = append(elm.Children, ann.Types.ToXML())
 	}
 
+	if len(ann.Scopes) != 0 {
+		elm.Children = append(elm.Children, ann.Scopes.ToXML())
+	}
+
 	if len(ann.XAddrs) != 0 {
 		elm.Children = append(elm.Children, ann.XAddrs.ToXML())
 	}
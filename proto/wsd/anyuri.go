@@ -10,6 +10,7 @@ package wsd
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/OpenPrinting/go-mfp/util/uuid"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
@@ -18,18 +19,20 @@ import (
 // AnyURI represents anyURI type, per XMS Schema Part 2: Datatypes, 3.2.17
 type AnyURI string
 
-// DecodeAnyURI decodes anyURI from the XML tree
+// DecodeAnyURI decodes anyURI from the XML tree.
+// Surrounding white space is ignored.
 func DecodeAnyURI(root xmldoc.Element) (v AnyURI, err error) {
-	if root.Text != "" {
-		return AnyURI(root.Text), nil
+	if s := strings.TrimSpace(root.Text); s != "" {
+		return AnyURI(s), nil
 	}
 	return "", xmldoc.XMLErrNew(root, "invalid URI")
 }
 
-// DecodeAnyURIAttr decodes anyURI from the XML attribute
+// DecodeAnyURIAttr decodes anyURI from the XML attribute.
+// Surrounding white space is ignored.
 func DecodeAnyURIAttr(attr xmldoc.Attr) (v AnyURI, err error) {
-	if attr.Value != "" {
-		return AnyURI(attr.Value), nil
+	if s := strings.TrimSpace(attr.Value); s != "" {
+		return AnyURI(s), nil
 	}
 	return "", xmldoc.XMLErrWrapAttr(attr, errors.New("invalid URi"))
 }
@@ -84,3 +84,63 @@ func (seq AppSequence) ToXML() xmldoc.Element {
 
 	return elm
 }
+
+// AppSequenceOrder is the result of the [AppSequence.Order] check.
+type AppSequenceOrder int
+
+// AppSequenceOrder values:
+const (
+	// AppSequenceNext means that message follows the previous one
+	// (or the order cannot be established) and must be accepted.
+	AppSequenceNext AppSequenceOrder = iota
+
+	// AppSequenceRestart means that device has restarted since
+	// the previous message. Message must be accepted, and
+	// everything known about the device may be outdated.
+	AppSequenceRestart
+
+	// AppSequenceStale means that message is older than the
+	// previous one (i.e., sent before device restart, or
+	// delivered out of order) and must be discarded.
+	AppSequenceStale
+)
+
+// String returns the name of the AppSequenceOrder, for debugging.
+func (order AppSequenceOrder) String() string {
+	switch order {
+	case AppSequenceNext:
+		return "next"
+	case AppSequenceRestart:
+		return "restart"
+	case AppSequenceStale:
+		return "stale"
+	}
+
+	return "unknown"
+}
+
+// Order checks the order of the message with this AppSequence,
+// relative to the previous message with the prev AppSequence,
+// received from the same device, per WS-Discovery, 7.
+//
+// Messages with the same InstanceID but different SequenceID
+// cannot be ordered, so they are considered to be in order.
+// Repeated MessageNumber is considered stale.
+func (seq AppSequence) Order(prev AppSequence) AppSequenceOrder {
+	switch {
+	case seq.InstanceID > prev.InstanceID:
+		return AppSequenceRestart
+	case seq.InstanceID < prev.InstanceID:
+		return AppSequenceStale
+	}
+
+	if optional.Get(seq.SequenceID) != optional.Get(prev.SequenceID) {
+		return AppSequenceNext
+	}
+
+	if seq.MessageNumber <= prev.MessageNumber {
+		return AppSequenceStale
+	}
+
+	return AppSequenceNext
+}
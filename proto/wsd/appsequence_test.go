@@ -129,3 +129,70 @@ func TestAppSequenceDecodeErrors(t *testing.T) {
 		}
 	}
 }
+
+// TestAppSequenceOrder tests AppSequence.Order
+func TestAppSequenceOrder(t *testing.T) {
+	type testData struct {
+		seq, prev AppSequence
+		order     AppSequenceOrder
+	}
+
+	seqA := optional.New(AnyURI("urn:uuid:a"))
+	seqB := optional.New(AnyURI("urn:uuid:b"))
+
+	tests := []testData{
+		{
+			seq:   AppSequence{InstanceID: 1, MessageNumber: 2},
+			prev:  AppSequence{InstanceID: 1, MessageNumber: 1},
+			order: AppSequenceNext,
+		},
+
+		{
+			seq:   AppSequence{InstanceID: 1, MessageNumber: 1},
+			prev:  AppSequence{InstanceID: 1, MessageNumber: 2},
+			order: AppSequenceStale,
+		},
+
+		{
+			seq:   AppSequence{InstanceID: 1, MessageNumber: 1},
+			prev:  AppSequence{InstanceID: 1, MessageNumber: 1},
+			order: AppSequenceStale,
+		},
+
+		{
+			seq:   AppSequence{InstanceID: 2, MessageNumber: 1},
+			prev:  AppSequence{InstanceID: 1, MessageNumber: 100},
+			order: AppSequenceRestart,
+		},
+
+		{
+			seq:   AppSequence{InstanceID: 1, MessageNumber: 100},
+			prev:  AppSequence{InstanceID: 2, MessageNumber: 1},
+			order: AppSequenceStale,
+		},
+
+		{
+			seq: AppSequence{InstanceID: 1, MessageNumber: 1,
+				SequenceID: seqA},
+			prev: AppSequence{InstanceID: 1, MessageNumber: 5,
+				SequenceID: seqB},
+			order: AppSequenceNext,
+		},
+
+		{
+			seq: AppSequence{InstanceID: 1, MessageNumber: 1,
+				SequenceID: seqA},
+			prev: AppSequence{InstanceID: 1, MessageNumber: 5,
+				SequenceID: seqA},
+			order: AppSequenceStale,
+		},
+	}
+
+	for _, test := range tests {
+		order := test.seq.Order(test.prev)
+		if order != test.order {
+			t.Errorf("%#v.Order(%#v):\nexpected: %s\npresent:  %s",
+				test.seq, test.prev, test.order, order)
+		}
+	}
+}
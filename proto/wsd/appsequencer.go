@@ -0,0 +1,129 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AppSequence generation and tracking
+
+package wsd
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AppSequenceTrackerDefaultSize is the default maximum count of
+// devices, tracked by the [AppSequenceTracker].
+const AppSequenceTrackerDefaultSize = 1024
+
+// AppSequencer generates [AppSequence] for outgoing messages.
+//
+// InstanceID is set from the current time at creation, so it
+// increments on each restart, as required by the WS-Discovery.
+// MessageNumber increments on each message.
+//
+// AppSequencer is safe for concurrent use.
+type AppSequencer struct {
+	instance uint64        // InstanceID
+	msgnum   atomic.Uint64 // Last MessageNumber
+}
+
+// NewAppSequencer creates a new [AppSequencer].
+func NewAppSequencer() *AppSequencer {
+	return &AppSequencer{
+		instance: uint64(time.Now().Unix()),
+	}
+}
+
+// Next returns the AppSequence for the next outgoing message.
+func (seqr *AppSequencer) Next() AppSequence {
+	return AppSequence{
+		InstanceID:    seqr.instance,
+		MessageNumber: seqr.msgnum.Add(1),
+	}
+}
+
+// AppSequenceTracker remembers the last [AppSequence], received
+// from each device, and checks order of the subsequent messages.
+//
+// Devices are identified by their EndpointReference address.
+// Number of tracked devices is limited. When limit is reached,
+// the device seen least recently is forgotten.
+//
+// AppSequenceTracker is safe for concurrent use.
+type AppSequenceTracker struct {
+	size  int                               // Max number of devices
+	last  map[AnyURI]*appSequenceTrackEntry // Last AppSequence per device
+	clock uint64                            // Usage clock, for eviction
+	lock  sync.Mutex                        // Access lock
+}
+
+// appSequenceTrackEntry is the AppSequenceTracker entry
+type appSequenceTrackEntry struct {
+	seq  AppSequence // Last AppSequence
+	used uint64      // Last use, by the AppSequenceTracker clock
+}
+
+// NewAppSequenceTracker creates a new [AppSequenceTracker] for the
+// specified maximum number of devices. If size is not positive,
+// [AppSequenceTrackerDefaultSize] is used.
+func NewAppSequenceTracker(size int) *AppSequenceTracker {
+	if size <= 0 {
+		size = AppSequenceTrackerDefaultSize
+	}
+
+	return &AppSequenceTracker{
+		size: size,
+		last: make(map[AnyURI]*appSequenceTrackEntry),
+	}
+}
+
+// Check checks the order of the message with the specified
+// AppSequence, received from the device with the specified
+// address, and remembers its AppSequence, unless message is stale.
+//
+// The first message from the device is always [AppSequenceNext].
+func (tr *AppSequenceTracker) Check(addr AnyURI,
+	seq AppSequence) AppSequenceOrder {
+
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+
+	tr.clock++
+
+	ent := tr.last[addr]
+	if ent == nil {
+		if len(tr.last) >= tr.size {
+			tr.evict()
+		}
+
+		tr.last[addr] = &appSequenceTrackEntry{seq, tr.clock}
+		return AppSequenceNext
+	}
+
+	order := seq.Order(ent.seq)
+	if order != AppSequenceStale {
+		ent.seq = seq
+		ent.used = tr.clock
+	}
+
+	return order
+}
+
+// evict forgets the least recently seen device.
+//
+// Called under the tr.lock.
+func (tr *AppSequenceTracker) evict() {
+	var oldest AnyURI
+	var oldestUsed uint64
+
+	for addr, ent := range tr.last {
+		if oldest == "" || ent.used < oldestUsed {
+			oldest, oldestUsed = addr, ent.used
+		}
+	}
+
+	delete(tr.last, oldest)
+}
@@ -0,0 +1,81 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AppSequence generation and tracking test
+
+package wsd
+
+import "testing"
+
+// TestAppSequencer tests AppSequencer
+func TestAppSequencer(t *testing.T) {
+	seqr := NewAppSequencer()
+
+	prev := seqr.Next()
+	for i := 0; i < 10; i++ {
+		seq := seqr.Next()
+		if order := seq.Order(prev); order != AppSequenceNext {
+			t.Fatalf("%#v after %#v: %s", seq, prev, order)
+		}
+		prev = seq
+	}
+}
+
+// TestAppSequenceTracker tests AppSequenceTracker
+func TestAppSequenceTracker(t *testing.T) {
+	type testData struct {
+		addr  AnyURI
+		seq   AppSequence
+		order AppSequenceOrder
+	}
+
+	tests := []testData{
+		// The first message is always accepted
+		{"urn:uuid:1", AppSequence{InstanceID: 10, MessageNumber: 5},
+			AppSequenceNext},
+		{"urn:uuid:1", AppSequence{InstanceID: 10, MessageNumber: 6},
+			AppSequenceNext},
+		{"urn:uuid:1", AppSequence{InstanceID: 10, MessageNumber: 6},
+			AppSequenceStale},
+
+		// Devices are tracked independently
+		{"urn:uuid:2", AppSequence{InstanceID: 5, MessageNumber: 1},
+			AppSequenceNext},
+
+		// Device restart
+		{"urn:uuid:1", AppSequence{InstanceID: 11, MessageNumber: 1},
+			AppSequenceRestart},
+
+		// Message from before restart, delivered late
+		{"urn:uuid:1", AppSequence{InstanceID: 10, MessageNumber: 7},
+			AppSequenceStale},
+
+		// Stale message doesn't affect the state
+		{"urn:uuid:1", AppSequence{InstanceID: 11, MessageNumber: 2},
+			AppSequenceNext},
+
+		// Limit is 2 devices, so urn:uuid:2 is evicted, as
+		// urn:uuid:1 was seen more recently
+		{"urn:uuid:3", AppSequence{InstanceID: 1, MessageNumber: 1},
+			AppSequenceNext},
+		{"urn:uuid:2", AppSequence{InstanceID: 1, MessageNumber: 1},
+			AppSequenceNext},
+
+		// Now urn:uuid:1 is evicted and its repeated message
+		// is not recognized as stale
+		{"urn:uuid:1", AppSequence{InstanceID: 11, MessageNumber: 2},
+			AppSequenceNext},
+	}
+
+	tr := NewAppSequenceTracker(2)
+	for _, test := range tests {
+		order := tr.Check(test.addr, test.seq)
+		if order != test.order {
+			t.Errorf("Check(%q, %#v):\nexpected: %s\npresent:  %s",
+				test.addr, test.seq, test.order, order)
+		}
+	}
+}
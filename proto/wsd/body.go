@@ -14,13 +14,21 @@ import "github.com/OpenPrinting/go-mfp/util/xmldoc"
 //
 // Body can be one of the following types:
 //   - [Bye]
+//   - [Fault]
 //   - [Get]
 //   - [GetResponse]
 //   - [Hello]
 //   - [Probe]
 //   - [ProbeMatches]
 //   - [Resolve]
+//   - [Renew]
+//   - [RenewResponse]
 //   - [ResolveMatches]
+//   - [Subscribe]
+//   - [SubscribeResponse]
+//   - [SubscriptionEnd]
+//   - [Unsubscribe]
+//   - [UnsubscribeResponse]
 type Body interface {
 	// Action returns [Action] to be used when sending message
 	// with this Body.
@@ -30,6 +30,14 @@ func TestBodyAction(t *testing.T) {
 		{ActProbeMatches, ProbeMatches{}},
 		{ActResolve, Resolve{}},
 		{ActResolveMatches, ResolveMatches{}},
+		{ActSubscribe, Subscribe{}},
+		{ActSubscribeResponse, SubscribeResponse{}},
+		{ActRenew, Renew{}},
+		{ActRenewResponse, RenewResponse{}},
+		{ActUnsubscribe, Unsubscribe{}},
+		{ActUnsubscribeResponse, UnsubscribeResponse{}},
+		{ActSubscriptionEnd, SubscriptionEnd{}},
+		{ActFault, Fault{}},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,337 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Exclusive XML canonicalization (for signatures)
+
+package wsd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// c14nNsXML is the namespace URL, implicitly bound to the "xml" prefix.
+const c14nNsXML = "http://www.w3.org/XML/1998/namespace"
+
+// c14nElement is the canonicalized element, found by c14nByID.
+type c14nElement struct {
+	Path string // Element path from root, i.e. s:Envelope/s:Body
+	Data []byte // Canonical form
+}
+
+// c14nByID finds elements with the wsu:Id attribute, listed in ids,
+// in the XML document, and returns their canonical form, by ID.
+//
+// Element paths are composed of names, with namespace prefixes
+// rewritten according to the 'ns' map, the same way as [xmldoc.Decode]
+// does.
+//
+// Canonicalization follows the Exclusive XML Canonicalization,
+// without comments (http://www.w3.org/2001/10/xml-exc-c14n#),
+// and works directly on the wire representation of the document,
+// because the exclusive canonical form depends on original namespace
+// prefixes, which are not preserved by the xmldoc decoder.
+//
+// It is an error, if some ID is missed or appears more that once
+// in the document, as it opens a door to the signature wrapping
+// attacks.
+func c14nByID(ns xmldoc.Namespace, data []byte,
+	ids []string) (map[string]c14nElement, error) {
+
+	want := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		want[id] = struct{}{}
+	}
+
+	found := make(map[string]c14nElement, len(ids))
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	scopes := []map[string]string{{"xml": c14nNsXML}}
+	var path []string
+	var active []*c14nWriter
+
+	for {
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// Update the namespace scope
+			scope := make(map[string]string)
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "" &&
+					attr.Name.Local == "xmlns":
+					scope[""] = attr.Value
+				case attr.Name.Space == "xmlns":
+					scope[attr.Name.Local] = attr.Value
+				}
+			}
+			scopes = append(scopes, scope)
+
+			// Update the path
+			name := t.Name.Local
+			if t.Name.Space != "" {
+				prefix, ok := ns.ByURL(c14nLookup(scopes,
+					t.Name.Space))
+				if !ok {
+					prefix = "-"
+				}
+				name = prefix + ":" + name
+			}
+			path = append(path, name)
+
+			// Start canonicalization, if element is wanted
+			for _, attr := range t.Attr {
+				if attr.Name.Local != "Id" ||
+					c14nLookup(scopes, attr.Name.Space) !=
+						nsSecurityUtilityURL {
+					continue
+				}
+
+				id := attr.Value
+				if _, ok := want[id]; !ok {
+					continue
+				}
+
+				if _, dup := found[id]; dup {
+					return nil, fmt.Errorf("%q: duplicate Id", id)
+				}
+
+				found[id] = c14nElement{}
+				active = append(active, &c14nWriter{
+					id:   id,
+					path: strings.Join(path, "/"),
+				})
+			}
+
+			for _, w := range active {
+				w.start(scopes, t)
+			}
+
+		case xml.EndElement:
+			for _, w := range active {
+				w.end(t)
+			}
+
+			// Collect finished elements
+			var next []*c14nWriter
+			for _, w := range active {
+				if w.depth == 0 {
+					found[w.id] = c14nElement{
+						Path: w.path,
+						Data: w.buf.Bytes(),
+					}
+				} else {
+					next = append(next, w)
+				}
+			}
+			active = next
+
+			if len(path) > 0 {
+				scopes = scopes[:len(scopes)-1]
+				path = path[:len(path)-1]
+			}
+
+		case xml.CharData:
+			for _, w := range active {
+				c14nEscapeText(&w.buf, string(t))
+			}
+
+		case xml.ProcInst:
+			for _, w := range active {
+				w.buf.WriteString("<?" + t.Target)
+				if len(t.Inst) != 0 {
+					w.buf.WriteString(" ")
+					w.buf.Write(t.Inst)
+				}
+				w.buf.WriteString("?>")
+			}
+		}
+	}
+
+	if len(active) != 0 {
+		return nil, errors.New("unexpected EOF")
+	}
+
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			return nil, fmt.Errorf("%q: Id not found", id)
+		}
+	}
+
+	return found, nil
+}
+
+// c14nLookup returns namespace URL by prefix, using stack of scopes.
+// The empty prefix means the default namespace.
+func c14nLookup(scopes []map[string]string, prefix string) string {
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if u, ok := scopes[i][prefix]; ok {
+			return u
+		}
+	}
+	return ""
+}
+
+// c14nWriter writes canonical form of the single element.
+type c14nWriter struct {
+	id       string              // Element's wsu:Id
+	path     string              // Element path
+	buf      bytes.Buffer        // Output buffer
+	depth    int                 // Nesting depth
+	rendered []map[string]string // Rendered namespaces, by depth
+}
+
+// start writes the start element.
+func (w *c14nWriter) start(scopes []map[string]string, t xml.StartElement) {
+	// Collect visibly utilized namespace prefixes
+	used := map[string]struct{}{t.Name.Space: {}}
+	attrs := make([]xml.Attr, 0, len(t.Attr))
+
+	for _, attr := range t.Attr {
+		if attr.Name.Space == "xmlns" ||
+			(attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+
+		if attr.Name.Space != "" {
+			used[attr.Name.Space] = struct{}{}
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	// Choose namespace declarations, not rendered yet
+	// by the output ancestors
+	var rendered map[string]string
+	if w.depth > 0 {
+		rendered = w.rendered[w.depth-1]
+	}
+
+	decls := make([]string, 0, len(used))
+	for prefix := range used {
+		if prefix == "xml" {
+			continue
+		}
+
+		u := c14nLookup(scopes, prefix)
+		if prev, ok := rendered[prefix]; ok && prev == u {
+			continue
+		}
+
+		if prefix == "" && u == "" && rendered[""] == "" {
+			continue
+		}
+
+		decls = append(decls, prefix)
+	}
+
+	sort.Strings(decls)
+
+	next := make(map[string]string, len(rendered)+len(decls))
+	for prefix, u := range rendered {
+		next[prefix] = u
+	}
+	for _, prefix := range decls {
+		next[prefix] = c14nLookup(scopes, prefix)
+	}
+
+	w.rendered = append(w.rendered[:w.depth], next)
+	w.depth++
+
+	// Sort attributes by namespace URL, then by local name
+	sort.SliceStable(attrs, func(i, j int) bool {
+		si, sj := "", ""
+		if attrs[i].Name.Space != "" {
+			si = c14nLookup(scopes, attrs[i].Name.Space)
+		}
+		if attrs[j].Name.Space != "" {
+			sj = c14nLookup(scopes, attrs[j].Name.Space)
+		}
+
+		if si != sj {
+			return si < sj
+		}
+
+		return attrs[i].Name.Local < attrs[j].Name.Local
+	})
+
+	// Write the element
+	w.buf.WriteString("<" + c14nQName(t.Name))
+
+	for _, prefix := range decls {
+		name := "xmlns"
+		if prefix != "" {
+			name += ":" + prefix
+		}
+
+		w.buf.WriteString(" " + name + `="`)
+		c14nEscapeAttr(&w.buf, next[prefix])
+		w.buf.WriteString(`"`)
+	}
+
+	for _, attr := range attrs {
+		w.buf.WriteString(" " + c14nQName(attr.Name) + `="`)
+		c14nEscapeAttr(&w.buf, attr.Value)
+		w.buf.WriteString(`"`)
+	}
+
+	w.buf.WriteString(">")
+}
+
+// end writes the end element.
+func (w *c14nWriter) end(t xml.EndElement) {
+	w.buf.WriteString("</" + c14nQName(t.Name) + ">")
+	w.depth--
+}
+
+// c14nQName returns qualified name (prefix:local) of the raw xml.Name.
+func c14nQName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// c14nEscapeText escapes character data.
+func c14nEscapeText(buf *bytes.Buffer, s string) {
+	buf.WriteString(c14nTextReplacer.Replace(s))
+}
+
+// c14nEscapeAttr escapes attribute value.
+func c14nEscapeAttr(buf *bytes.Buffer, s string) {
+	buf.WriteString(c14nAttrReplacer.Replace(s))
+}
+
+// Replacers for the c14nEscapeText and c14nEscapeAttr
+var (
+	c14nTextReplacer = strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		"\r", "&#xD;",
+	)
+
+	c14nAttrReplacer = strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		`"`, "&quot;",
+		"\t", "&#x9;",
+		"\n", "&#xA;",
+		"\r", "&#xD;",
+	)
+)
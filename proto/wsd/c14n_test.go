@@ -0,0 +1,140 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Exclusive XML canonicalization test
+
+package wsd
+
+import (
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestC14N tests c14nByID
+func TestC14N(t *testing.T) {
+	const wsu = `xmlns:wsu="` + nsSecurityUtilityURL + `"`
+
+	type testData struct {
+		name string // Test name
+		in   string // Input document
+		id   string // Requested Id
+		path string // Expected path
+		out  string // Expected output
+		err  string // Expected error
+	}
+
+	ns := xmldoc.Namespace{
+		{URL: "urn:a", Prefix: "a"},
+		{URL: "urn:b", Prefix: "b"},
+	}
+
+	tests := []testData{
+		{
+			name: "namespaces and attributes",
+			in: `<a:Root xmlns:a="urn:a" xmlns:b="urn:b" ` +
+				`xmlns:c="urn:c" ` + wsu + `>` +
+				`<b:X a:y="1" wsu:Id="x" z="2"/>` +
+				`</a:Root>`,
+			id:   "x",
+			path: "a:Root/b:X",
+			out: `<b:X xmlns:a="urn:a" xmlns:b="urn:b" ` +
+				wsu + ` z="2" wsu:Id="x" a:y="1"></b:X>`,
+		},
+
+		{
+			name: "nested elements",
+			in: `<a:Root xmlns:a="urn:a" xmlns:b="urn:b" ` + wsu + `>` +
+				`<a:X wsu:Id="x"><b:Y>1</b:Y><a:Z/></a:X>` +
+				`</a:Root>`,
+			id:   "x",
+			path: "a:Root/a:X",
+			out: `<a:X xmlns:a="urn:a" ` + wsu + ` wsu:Id="x">` +
+				`<b:Y xmlns:b="urn:b">1</b:Y><a:Z></a:Z></a:X>`,
+		},
+
+		{
+			name: "default namespace",
+			in: `<Root xmlns="urn:a" ` + wsu + `>` +
+				`<X wsu:Id="x"><Y xmlns="">2</Y></X>` +
+				`</Root>`,
+			id:   "x",
+			path: "Root/X",
+			out: `<X xmlns="urn:a" ` + wsu + ` wsu:Id="x">` +
+				`<Y xmlns="">2</Y></X>`,
+		},
+
+		{
+			name: "escaping and comments",
+			in: `<a:Root xmlns:a="urn:a" ` + wsu + `>` +
+				`<a:X wsu:Id="x" v="&lt;&quot;&#9;">` +
+				`&lt;&amp;&gt;<!-- comment --><![CDATA[<]]>` +
+				`</a:X></a:Root>`,
+			id:   "x",
+			path: "a:Root/a:X",
+			out: `<a:X xmlns:a="urn:a" ` + wsu + ` v="&lt;&quot;&#x9;" ` +
+				`wsu:Id="x">&lt;&amp;&gt;&lt;</a:X>`,
+		},
+
+		{
+			name: "unknown namespace in path",
+			in: `<a:Root xmlns:a="urn:a" xmlns:c="urn:c" ` + wsu + `>` +
+				`<c:X wsu:Id="x"/></a:Root>`,
+			id:   "x",
+			path: "a:Root/-:X",
+			out:  `<c:X xmlns:c="urn:c" ` + wsu + ` wsu:Id="x"></c:X>`,
+		},
+
+		{
+			name: "Id from the wrong namespace",
+			in: `<a:Root xmlns:a="urn:a">` +
+				`<a:X a:Id="x"/></a:Root>`,
+			id:  "x",
+			err: `"x": Id not found`,
+		},
+
+		{
+			name: "duplicate Id",
+			in: `<a:Root xmlns:a="urn:a" ` + wsu + `>` +
+				`<a:X wsu:Id="x"/><a:Y wsu:Id="x"/></a:Root>`,
+			id:  "x",
+			err: `"x": duplicate Id`,
+		},
+	}
+
+	for _, test := range tests {
+		elems, err := c14nByID(ns, []byte(test.in), []string{test.id})
+
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if estr != test.err {
+			t.Errorf("%s: error mismatch:\n"+
+				"expected: %s\npresent:  %s",
+				test.name, test.err, estr)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		elm := elems[test.id]
+		if elm.Path != test.path {
+			t.Errorf("%s: path mismatch:\n"+
+				"expected: %s\npresent:  %s",
+				test.name, test.path, elm.Path)
+		}
+
+		if string(elm.Data) != test.out {
+			t.Errorf("%s: output mismatch:\n"+
+				"expected: %s\npresent:  %s",
+				test.name, test.out, elm.Data)
+		}
+	}
+}
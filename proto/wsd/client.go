@@ -0,0 +1,235 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WSD client (metadata exchange and Discovery Proxy requests)
+
+package wsd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// Client parameters:
+const (
+	// ClientDefaultTimeout is the timeout of the Client requests,
+	// used when request context has no deadline.
+	ClientDefaultTimeout = 5 * time.Second
+)
+
+// Client implements the WS-Transfer Get request, used to obtain
+// device [Metadata] via the device's XAddrs (the Metadata Exchange,
+// or MEX).
+//
+// It also implements the unicast Probe and Resolve requests, sent
+// to the Discovery Proxy in the managed mode of WS-Discovery.
+type Client struct {
+	soap *SOAPClient // SOAP client
+}
+
+// NewClient creates a new WSD client.
+//
+// If tr is nil, [transport.NewTransport] will be used to create
+// a new transport.
+func NewClient(tr *transport.Transport) *Client {
+	return &Client{
+		soap: &SOAPClient{
+			Proto:      "WSD",
+			Ns:         NsMap,
+			HTTPClient: transport.NewClient(tr),
+		},
+	}
+}
+
+// Get requests the device [Metadata] from the single XAddr URL.
+//
+// target is the device's EndpointReference address, as announced
+// in the [Hello] or [ProbeMatches] message. It is used as the
+// destination (To) of the request.
+//
+// If ctx has no deadline, [ClientDefaultTimeout] is applied.
+func (c *Client) Get(ctx context.Context,
+	xaddr *url.URL, target AnyURI) (Metadata, error) {
+
+	msg := Msg{
+		Header: NewRequestHeader(ActGet, target),
+		Body:   Get{},
+	}
+
+	rsp, err := c.call(ctx, xaddr, msg)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	meta, ok := rsp.Body.(Metadata)
+	if !ok {
+		err = fmt.Errorf("WSD: unexpected response: %s",
+			rsp.Header.Action)
+		return Metadata{}, err
+	}
+
+	return meta, nil
+}
+
+// Probe sends the Probe request directly to the Discovery Proxy
+// (the managed mode of WS-Discovery) and returns matches.
+//
+// xaddr is the proxy's transport address and proxy is its
+// EndpointReference address, as announced in the proxy's [Hello].
+// If proxy address is not known, "" may be used; xaddr is used
+// instead.
+//
+// If ctx has no deadline, [ClientDefaultTimeout] is applied.
+func (c *Client) Probe(ctx context.Context, xaddr *url.URL,
+	proxy AnyURI, probe Probe) ([]ProbeMatch, error) {
+
+	if proxy == "" {
+		proxy = AnyURI(xaddr.String())
+	}
+
+	msg := Msg{
+		Header: NewRequestHeader(ActProbe, proxy),
+		Body:   probe,
+	}
+
+	rsp, err := c.call(ctx, xaddr, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, ok := rsp.Body.(ProbeMatches)
+	if !ok {
+		err = fmt.Errorf("WSD: unexpected response: %s",
+			rsp.Header.Action)
+		return nil, err
+	}
+
+	return matches.ProbeMatch, nil
+}
+
+// Resolve sends the Resolve request for the target endpoint address
+// directly to the Discovery Proxy (the managed mode of WS-Discovery)
+// and returns matches.
+//
+// xaddr and proxy have the same meaning, as for [Client.Probe].
+//
+// If ctx has no deadline, [ClientDefaultTimeout] is applied.
+func (c *Client) Resolve(ctx context.Context, xaddr *url.URL,
+	proxy, target AnyURI) ([]ResolveMatch, error) {
+
+	if proxy == "" {
+		proxy = AnyURI(xaddr.String())
+	}
+
+	msg := Msg{
+		Header: NewRequestHeader(ActResolve, proxy),
+		Body: Resolve{
+			EndpointReference: EndpointReference{Address: target},
+		},
+	}
+
+	rsp, err := c.call(ctx, xaddr, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, ok := rsp.Body.(ResolveMatches)
+	if !ok {
+		err = fmt.Errorf("WSD: unexpected response: %s",
+			rsp.Header.Action)
+		return nil, err
+	}
+
+	return matches.ResolveMatch, nil
+}
+
+// call sends the request message to the xaddr URL and returns
+// the response.
+//
+// SOAP faults are returned as [Fault] errors.
+func (c *Client) call(ctx context.Context,
+	xaddr *url.URL, msg Msg) (Msg, error) {
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ClientDefaultTimeout)
+		defer cancel()
+	}
+
+	// Perform the request. Note, SOAP faults come with HTTP
+	// error status, and returned as Fault errors.
+	action := msg.Header.Action.Encode()
+	httpRsp, err := c.soap.Post(ctx, xaddr, action, SOAPContentType,
+		bytes.NewReader(msg.Encode()))
+	if err != nil {
+		return Msg{}, err
+	}
+
+	defer httpRsp.Body.Close()
+
+	data, err := c.soap.ReadXML(httpRsp.Body)
+	if err != nil {
+		return Msg{}, err
+	}
+
+	// Decode the response
+	rsp, err := DecodeMsg(data)
+	if err != nil {
+		return Msg{}, fmt.Errorf("WSD: %w", err)
+	}
+
+	if fault, ok := rsp.Body.(Fault); ok {
+		return Msg{}, fault
+	}
+
+	// Some devices don't send RelatesTo, so only present
+	// RelatesTo is checked.
+	if rsp.Header.RelatesTo != nil {
+		err = rsp.Header.Correlate(msg.Header)
+		if err != nil {
+			return Msg{}, err
+		}
+	}
+
+	return rsp, nil
+}
+
+// GetAny requests the device [Metadata], trying XAddrs one by one
+// in order, until the first success.
+//
+// Use [XAddrs.URLs] to obtain the properly filtered and ordered
+// list of XAddrs.
+//
+// It returns the Metadata and the URL it comes from. If all
+// attempts failed, the last error is returned.
+func (c *Client) GetAny(ctx context.Context,
+	xaddrs []*url.URL, target AnyURI) (Metadata, *url.URL, error) {
+
+	err := errors.New("WSD: no usable XAddrs")
+
+	for _, xaddr := range xaddrs {
+		var meta Metadata
+		meta, err = c.Get(ctx, xaddr, target)
+		if err == nil {
+			return meta, xaddr, nil
+		}
+
+		log.Debug(ctx, "WSD: %s: %s", xaddr, err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return Metadata{}, nil, err
+}
@@ -0,0 +1,168 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Metadata exchange (MEX) client test
+
+package wsd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// TestClient tests Client against the Responder
+func TestClient(t *testing.T) {
+	// Setup the device
+	mux := http.NewServeMux()
+	mux.Handle("/wsd", NewResponder(testResponderOptions))
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "Broken", http.StatusInternalServerError)
+	})
+
+	fault := Fault{
+		Code:    FaultCodeReceiver,
+		Subcode: FaultEndpointUnavailable,
+		Reason:  LocalizedString{String: "Busy", Lang: "en"},
+	}
+
+	mux.HandleFunc("/fault", func(w http.ResponseWriter, _ *http.Request) {
+		msg := Msg{
+			Header: Header{
+				Action:    ActFault,
+				MessageID: "urn:uuid:8f0f8c4e-3b2a-4c1e-9a57-2f1d6b8c9e0a",
+			},
+			Body: fault,
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(msg.Encode())
+	})
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, mux)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	clnt := NewClient(tr)
+	ctx := context.Background()
+	target := testResponderOptions.EndpointReference.Address
+
+	// Get from the valid XAddr
+	meta, err := clnt.Get(ctx,
+		transport.MustParseURL("http://localhost/wsd"), target)
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+
+	if !reflect.DeepEqual(meta, testResponderOptions.Metadata) {
+		t.Errorf("Get:\nexpected: %#v\npresent:  %#v",
+			testResponderOptions.Metadata, meta)
+	}
+
+	// Get from the broken XAddr
+	_, err = clnt.Get(ctx,
+		transport.MustParseURL("http://localhost/broken"), target)
+	if err == nil {
+		t.Errorf("Get: error expected")
+	}
+
+	// Fault must be returned as error
+	_, err = clnt.Get(ctx,
+		transport.MustParseURL("http://localhost/fault"), target)
+
+	var fault2 Fault
+	if !errors.As(err, &fault2) {
+		t.Errorf("Get: Fault expected, present %v", err)
+	} else if !reflect.DeepEqual(fault, fault2) {
+		t.Errorf("Get:\nexpected: %#v\npresent:  %#v", fault, fault2)
+	}
+
+	// GetAny must skip broken XAddrs
+	xaddrs := XAddrs{
+		"http://localhost/broken",
+		"http://localhost/wsd",
+	}.URLs(XAddrsFilter{})
+
+	meta, from, err := clnt.GetAny(ctx, xaddrs, target)
+	if err != nil {
+		t.Fatalf("GetAny: %s", err)
+	}
+
+	if from.String() != "http://localhost/wsd" {
+		t.Errorf("GetAny: expected from %s, present %s",
+			"http://localhost/wsd", from)
+	}
+
+	if !reflect.DeepEqual(meta, testResponderOptions.Metadata) {
+		t.Errorf("GetAny:\nexpected: %#v\npresent:  %#v",
+			testResponderOptions.Metadata, meta)
+	}
+
+	// GetAny fails, if all XAddrs fail
+	_, _, err = clnt.GetAny(ctx, xaddrs[:1], target)
+	if err == nil {
+		t.Errorf("GetAny: error expected")
+	}
+
+	_, _, err = clnt.GetAny(ctx, nil, target)
+	if err == nil {
+		t.Errorf("GetAny: error expected")
+	}
+}
+
+// TestClientProxy tests unicast Probe and Resolve, sent to the
+// Discovery Proxy. Responder answers them the same way.
+func TestClientProxy(t *testing.T) {
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, NewResponder(testResponderOptions))
+	go server.Serve(loopback)
+	defer server.Close()
+
+	clnt := NewClient(tr)
+	ctx := context.Background()
+	xaddr := transport.MustParseURL("http://localhost/proxy")
+	target := testResponderOptions.EndpointReference.Address
+
+	// Probe
+	pm, err := clnt.Probe(ctx, xaddr, "", Probe{
+		Types:  Types{ScannerServiceType},
+		Scopes: Scopes{"http://example.com/office"},
+	})
+
+	if err != nil {
+		t.Fatalf("Probe: %s", err)
+	}
+
+	if len(pm) != 1 || pm[0].EndpointReference.Address != target {
+		t.Errorf("Probe: unexpected matches %#v", pm)
+	}
+
+	// Non-matching Probe is answered with Fault
+	_, err = clnt.Probe(ctx, xaddr, "", Probe{
+		Types: Types{PrinterServiceType},
+	})
+
+	var fault Fault
+	if !errors.As(err, &fault) {
+		t.Errorf("Probe: Fault expected, present %v", err)
+	}
+
+	// Resolve
+	rm, err := clnt.Resolve(ctx, xaddr, "urn:uuid:proxy", target)
+	if err != nil {
+		t.Fatalf("Resolve: %s", err)
+	}
+
+	if len(rm) != 1 || rm[0].MetadataVersion !=
+		testResponderOptions.MetadataVersion {
+		t.Errorf("Resolve: unexpected matches %#v", rm)
+	}
+}
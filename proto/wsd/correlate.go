@@ -0,0 +1,226 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Header construction and request/response correlation
+
+package wsd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+)
+
+// CorrelatorDefaultTTL is the default time, the [Correlator]
+// remembers the sent requests.
+const CorrelatorDefaultTTL = 10 * time.Second
+
+// Correlation errors:
+var (
+	ErrNotRelated  = errors.New("WSD: response doesn't relate to request")
+	ErrBadResponse = errors.New("WSD: unexpected response action")
+)
+
+// NewMessageID returns a new unique MessageID.
+func NewMessageID() AnyURI {
+	return AnyURI(uuid.Must(uuid.Random()).URN())
+}
+
+// NewRequestHeader returns the [Header] for the request message
+// with the specified action, sent to the destination 'to'.
+//
+// The multicast requests (Probe and Resolve) are sent to
+// [ToDiscovery], as WS-Discovery requires, and 'to' may be
+// empty for them. Responses are sent back to the transport
+// address of the sender.
+//
+// For other requests, including Probe and Resolve, sent directly
+// to the Discovery Proxy, ReplyTo is set to [ToAnonymous], so the
+// response comes back via the HTTP response.
+func NewRequestHeader(act Action, to AnyURI) Header {
+	if to == "" && (act == ActProbe || act == ActResolve) {
+		to = ToDiscovery
+	}
+
+	hdr := Header{
+		Action:    act,
+		MessageID: NewMessageID(),
+		To:        optional.New(to),
+	}
+
+	if to != ToDiscovery {
+		hdr.ReplyTo = optional.New(EndpointReference{
+			Address: ToAnonymous,
+		})
+	}
+
+	return hdr
+}
+
+// NewAnnounceHeader returns the [Header] for the multicast
+// announce message (Hello or Bye) with the specified [AppSequence].
+func NewAnnounceHeader(act Action, seq AppSequence) Header {
+	return Header{
+		Action:      act,
+		MessageID:   NewMessageID(),
+		To:          optional.New(ToDiscovery),
+		AppSequence: optional.New(seq),
+	}
+}
+
+// NewResponseHeader returns the [Header] for the response with the
+// specified action (i.e., [ActProbeMatches] or [ActFault]) to the
+// request with the header rq.
+//
+// The response is addressed to the request's ReplyTo, if present,
+// and [ToAnonymous] otherwise.
+func NewResponseHeader(act Action, rq Header) Header {
+	hdr := Header{
+		Action:    act,
+		MessageID: NewMessageID(),
+		To:        optional.New(ToAnonymous),
+		RelatesTo: optional.New(rq.MessageID),
+	}
+
+	if rq.ReplyTo != nil && (*rq.ReplyTo).Address != "" {
+		hdr.To = optional.New((*rq.ReplyTo).Address)
+	}
+
+	return hdr
+}
+
+// Correlate checks that hdr is the header of the valid response
+// to the request with the header rq.
+//
+// Response must have RelatesTo that refers the request's MessageID
+// ([ErrNotRelated] otherwise) and its action must match the request's
+// action (see [Action.Response]) or be [ActFault] ([ErrBadResponse]
+// otherwise).
+//
+// Multicast Probe and Resolve may also be answered with Hello by
+// the Discovery Proxy, that suppresses multicast discovery (see
+// WS-Discovery, 3.1).
+func (hdr Header) Correlate(rq Header) error {
+	if hdr.RelatesTo == nil || *hdr.RelatesTo != rq.MessageID {
+		return ErrNotRelated
+	}
+
+	switch {
+	case hdr.Action == ActFault:
+	case hdr.Action == rq.Action.Response():
+	case hdr.Action == ActHello &&
+		(rq.Action == ActProbe || rq.Action == ActResolve):
+	default:
+		return fmt.Errorf("%w: %s in response to %s",
+			ErrBadResponse, hdr.Action, rq.Action)
+	}
+
+	return nil
+}
+
+// Exchange is the matched request/response pair, returned by the
+// [Correlator].
+type Exchange struct {
+	Request  Msg // The request
+	Response Msg // The response
+}
+
+// Correlator matches received responses with the sent requests.
+//
+// Multicast requests (Probe and Resolve) may have many responses,
+// so requests are remembered for some time (TTL) or until explicitly
+// removed, not until the first response.
+//
+// Correlator is safe for concurrent use.
+type Correlator struct {
+	ttl     time.Duration             // Requests lifetime
+	pending map[AnyURI]correlatorItem // Requests by MessageID
+	lock    sync.Mutex                // Access lock
+}
+
+// correlatorItem is the request, remembered by the Correlator
+type correlatorItem struct {
+	rq      Msg       // The request
+	expires time.Time // Expiration time
+}
+
+// NewCorrelator creates a new [Correlator]. The ttl is the time
+// requests are remembered. If ttl is not positive,
+// [CorrelatorDefaultTTL] is used.
+func NewCorrelator(ttl time.Duration) *Correlator {
+	if ttl <= 0 {
+		ttl = CorrelatorDefaultTTL
+	}
+
+	return &Correlator{
+		ttl:     ttl,
+		pending: make(map[AnyURI]correlatorItem),
+	}
+}
+
+// Add remembers the sent request.
+func (c *Correlator) Add(rq Msg) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	c.expire(now)
+
+	c.pending[rq.Header.MessageID] = correlatorItem{
+		rq:      rq,
+		expires: now.Add(c.ttl),
+	}
+}
+
+// Remove forgets the request with the specified MessageID.
+func (c *Correlator) Remove(msgid AnyURI) {
+	c.lock.Lock()
+	delete(c.pending, msgid)
+	c.lock.Unlock()
+}
+
+// Match returns the [Exchange], the received response belongs to.
+//
+// It returns [ErrNotRelated] if response doesn't relate to any
+// of remembered requests and [ErrBadResponse] if its action
+// doesn't match the request (see [Header.Correlate]).
+func (c *Correlator) Match(rsp Msg) (Exchange, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire(time.Now())
+
+	if rsp.Header.RelatesTo == nil {
+		return Exchange{}, ErrNotRelated
+	}
+
+	item, found := c.pending[*rsp.Header.RelatesTo]
+	if !found {
+		return Exchange{}, ErrNotRelated
+	}
+
+	err := rsp.Header.Correlate(item.rq.Header)
+	if err != nil {
+		return Exchange{}, err
+	}
+
+	return Exchange{Request: item.rq, Response: rsp}, nil
+}
+
+// expire removes expired requests.
+//
+// Must be called under c.lock.
+func (c *Correlator) expire(now time.Time) {
+	for msgid, item := range c.pending {
+		if now.After(item.expires) {
+			delete(c.pending, msgid)
+		}
+	}
+}
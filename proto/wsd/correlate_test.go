@@ -0,0 +1,190 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Header construction and correlation test
+
+package wsd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// TestHeaderConstruction tests NewRequestHeader, NewAnnounceHeader
+// and NewResponseHeader
+func TestHeaderConstruction(t *testing.T) {
+	const target = AnyURI("urn:uuid:b8310cdf-157f-4e5b-a042-4588f7149ec0")
+
+	// Multicast request
+	probe := NewRequestHeader(ActProbe, "")
+	if probe.MessageID == "" {
+		t.Errorf("Probe: MessageID missed")
+	}
+
+	if optional.Get(probe.To) != ToDiscovery || probe.ReplyTo != nil {
+		t.Errorf("Probe: To %q ReplyTo %v", optional.Get(probe.To),
+			probe.ReplyTo)
+	}
+
+	// Unicast Probe, sent to the Discovery Proxy
+	probe2 := NewRequestHeader(ActProbe, target)
+	if optional.Get(probe2.To) != target || probe2.ReplyTo == nil {
+		t.Errorf("Probe to proxy: To %q ReplyTo %v",
+			optional.Get(probe2.To), probe2.ReplyTo)
+	}
+
+	// Unicast request
+	get := NewRequestHeader(ActGet, target)
+	if optional.Get(get.To) != target {
+		t.Errorf("Get: To expected %q, present %q",
+			target, optional.Get(get.To))
+	}
+
+	if get.ReplyTo == nil || (*get.ReplyTo).Address != ToAnonymous {
+		t.Errorf("Get: ReplyTo must be anonymous")
+	}
+
+	if get.MessageID == probe.MessageID {
+		t.Errorf("MessageID must be unique")
+	}
+
+	// Announce
+	seq := AppSequence{InstanceID: 1, MessageNumber: 2}
+	hello := NewAnnounceHeader(ActHello, seq)
+	if optional.Get(hello.To) != ToDiscovery ||
+		optional.Get(hello.AppSequence) != seq {
+		t.Errorf("Hello: bad header %#v", hello)
+	}
+
+	// Responses
+	rsp := NewResponseHeader(ActGetResponse, get)
+	if optional.Get(rsp.RelatesTo) != get.MessageID {
+		t.Errorf("GetResponse: RelatesTo expected %q, present %q",
+			get.MessageID, optional.Get(rsp.RelatesTo))
+	}
+
+	if optional.Get(rsp.To) != ToAnonymous {
+		t.Errorf("GetResponse: To expected %q, present %q",
+			ToAnonymous, optional.Get(rsp.To))
+	}
+
+	rsp = NewResponseHeader(ActProbeMatches, probe)
+	if optional.Get(rsp.To) != ToAnonymous {
+		t.Errorf("ProbeMatches: To expected %q, present %q",
+			ToAnonymous, optional.Get(rsp.To))
+	}
+}
+
+// TestHeaderCorrelate tests Header.Correlate
+func TestHeaderCorrelate(t *testing.T) {
+	rq := NewRequestHeader(ActResolve, "")
+
+	type testData struct {
+		name string // Test name
+		rsp  Header // Response header
+		err  error  // Expected error
+	}
+
+	tests := []testData{
+		{
+			name: "ResolveMatches",
+			rsp:  NewResponseHeader(ActResolveMatches, rq),
+		},
+		{
+			name: "Fault",
+			rsp:  NewResponseHeader(ActFault, rq),
+		},
+		{
+			name: "Hello from Discovery Proxy",
+			rsp:  NewResponseHeader(ActHello, rq),
+		},
+		{
+			name: "wrong action",
+			rsp:  NewResponseHeader(ActProbeMatches, rq),
+			err:  ErrBadResponse,
+		},
+		{
+			name: "no RelatesTo",
+			rsp:  Header{Action: ActResolveMatches},
+			err:  ErrNotRelated,
+		},
+		{
+			name: "other request",
+			rsp: NewResponseHeader(ActResolveMatches,
+				NewRequestHeader(ActResolve, "")),
+			err: ErrNotRelated,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.rsp.Correlate(rq)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v, present %v",
+				test.name, test.err, err)
+		}
+	}
+}
+
+// TestCorrelator tests Correlator
+func TestCorrelator(t *testing.T) {
+	corr := NewCorrelator(0)
+
+	rq := Msg{
+		Header: NewRequestHeader(ActProbe, ""),
+		Body:   Probe{Types: Types{Device}},
+	}
+	corr.Add(rq)
+
+	// Multicast request may have many responses
+	for i := 0; i < 2; i++ {
+		rsp := Msg{
+			Header: NewResponseHeader(ActProbeMatches, rq.Header),
+			Body:   ProbeMatches{},
+		}
+
+		exch, err := corr.Match(rsp)
+		if err != nil {
+			t.Fatalf("Match: %s", err)
+		}
+
+		if exch.Request.Header.MessageID != rq.Header.MessageID ||
+			exch.Response.Header.MessageID != rsp.Header.MessageID {
+			t.Errorf("Match: wrong Exchange %#v", exch)
+		}
+	}
+
+	// Unrelated response
+	other := Msg{
+		Header: NewResponseHeader(ActProbeMatches,
+			NewRequestHeader(ActProbe, "")),
+	}
+
+	if _, err := corr.Match(other); err != ErrNotRelated {
+		t.Errorf("Match unrelated: expected %v, present %v",
+			ErrNotRelated, err)
+	}
+
+	// Removed request
+	corr.Remove(rq.Header.MessageID)
+	rsp := Msg{Header: NewResponseHeader(ActProbeMatches, rq.Header)}
+	if _, err := corr.Match(rsp); err != ErrNotRelated {
+		t.Errorf("Match removed: expected %v, present %v",
+			ErrNotRelated, err)
+	}
+
+	// Expired request
+	corr = NewCorrelator(time.Millisecond)
+	corr.Add(rq)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := corr.Match(rsp); err != ErrNotRelated {
+		t.Errorf("Match expired: expected %v, present %v",
+			ErrNotRelated, err)
+	}
+}
@@ -11,13 +11,15 @@ package wsd
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
-// decodeUint64 decodes uint64 from the XML tree
+// decodeUint64 decodes uint64 from the XML tree.
+// Surrounding white space is ignored.
 func decodeUint64(root xmldoc.Element) (v uint64, err error) {
-	v, err = strconv.ParseUint(root.Text, 10, 64)
+	v, err = strconv.ParseUint(strings.TrimSpace(root.Text), 10, 64)
 	if err != nil {
 		err = fmt.Errorf("invalid uint: %q", root.Text)
 		err = xmldoc.XMLErrWrap(root, err)
@@ -25,9 +27,10 @@ func decodeUint64(root xmldoc.Element) (v uint64, err error) {
 	return
 }
 
-// decodeUint64 decodes uint64 from the XML attribute
+// decodeUint64Attr decodes uint64 from the XML attribute.
+// Surrounding white space is ignored.
 func decodeUint64Attr(attr xmldoc.Attr) (v uint64, err error) {
-	v, err = strconv.ParseUint(attr.Value, 10, 64)
+	v, err = strconv.ParseUint(strings.TrimSpace(attr.Value), 10, 64)
 	if err != nil {
 		err = fmt.Errorf("invalid uint: %q", attr.Value)
 		err = xmldoc.XMLErrWrapAttr(attr, err)
@@ -0,0 +1,493 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Semantic dump of WSD messages, for logging
+
+package wsd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// dumperEndpointTTL is the time, the [Dumper] remembers announces
+// of the endpoint.
+const dumperEndpointTTL = time.Hour
+
+// MsgDump is the human-readable annotated representation of [Msg],
+// for logging. Each element of the slice is a single line of text.
+//
+// It implements the [log.Marshaler] interface, so it can be written
+// to the log with the [log.Object] function.
+type MsgDump []string
+
+// MarshalLog returns the MsgDump as text, for [log.Object].
+// It implements [log.Marshaler].
+func (dump MsgDump) MarshalLog() []byte {
+	return []byte(dump.String())
+}
+
+// String returns the MsgDump as the multi-line string.
+func (dump MsgDump) String() string {
+	return strings.Join(dump, "\n")
+}
+
+// Dumper renders decoded messages as [MsgDump], for logging.
+//
+// Unlike [Msg.Format], which returns the raw XML, the dump is
+// annotated with the message meaning and decoded endpoint types.
+//
+// Dumper remembers recently dumped messages, so it also annotates
+// relationship between messages: responses are linked with their
+// requests, and Hello and Bye are linked with previous announces
+// of the same endpoint. To benefit from it, both sent and received
+// messages should be dumped with the same Dumper.
+//
+// Dumper is safe for concurrent use.
+type Dumper struct {
+	requests  map[AnyURI]dumperRequest  // Recent requests, by MessageID
+	endpoints map[AnyURI]dumperAnnounce // Recent announces, by address
+	lock      sync.Mutex                // Access lock
+}
+
+// dumperRequest is the request, remembered by the Dumper
+type dumperRequest struct {
+	hdr  Header    // Request header
+	when time.Time // When it was dumped
+}
+
+// dumperAnnounce is the Hello or Bye, remembered by the Dumper
+type dumperAnnounce struct {
+	act  Action                    // ActHello or ActBye
+	seq  optional.Val[AppSequence] // Its AppSequence
+	when time.Time                 // When it was dumped
+}
+
+// NewDumper creates a new [Dumper].
+func NewDumper() *Dumper {
+	return &Dumper{
+		requests:  make(map[AnyURI]dumperRequest),
+		endpoints: make(map[AnyURI]dumperAnnounce),
+	}
+}
+
+// Dump returns the [MsgDump] of the message without annotation
+// of relationship with other messages.
+//
+// Use [Dumper] when relationship matters.
+func (m Msg) Dump() MsgDump {
+	return NewDumper().Dump(m)
+}
+
+// Dump returns the [MsgDump] of the message and remembers the
+// message for annotation of the subsequent messages.
+func (d *Dumper) Dump(m Msg) MsgDump {
+	dump := &dumper{}
+
+	// Title line
+	title := m.Header.Action.String()
+	if desc := m.Header.Action.describe(); desc != "" {
+		title += " (" + desc + ")"
+	}
+	dump.line(0, "%s", title)
+
+	// Transport addresses
+	if m.From.IsValid() {
+		dump.field(1, "From", fmt.Sprintf("%s%%%d", m.From, m.IfIdx))
+	}
+	if m.To.IsValid() {
+		dump.field(1, "To", fmt.Sprintf("%s%%%d", m.To, m.IfIdx))
+	}
+
+	// Header
+	d.lock.Lock()
+	now := time.Now()
+	d.expire(now)
+
+	dump.header(m.Header)
+	if rel := d.relation(m, now); rel != "" {
+		dump.field(1, "Relation", rel)
+	}
+
+	d.lock.Unlock()
+
+	// Body
+	dump.body(m.Body)
+
+	return dump.lines
+}
+
+// relation returns the annotation of the message relationship
+// with previously dumped messages and remembers the message.
+//
+// Must be called under d.lock.
+func (d *Dumper) relation(m Msg, now time.Time) string {
+	hdr := m.Header
+
+	// Remember requests
+	if hdr.Action.Response() != ActUnknown {
+		d.requests[hdr.MessageID] = dumperRequest{hdr, now}
+	}
+
+	// Link responses with requests
+	if hdr.RelatesTo != nil {
+		rq, found := d.requests[*hdr.RelatesTo]
+		if !found {
+			return "response to unknown request"
+		}
+
+		rel := fmt.Sprintf("response to %s, %s later",
+			rq.hdr.Action, now.Sub(rq.when).Round(time.Millisecond))
+
+		if err := hdr.Correlate(rq.hdr); err != nil {
+			rel += ": unexpected action"
+		}
+
+		return rel
+	}
+
+	// Link announces with previous announces
+	var addr AnyURI
+	switch body := m.Body.(type) {
+	case Hello:
+		addr = body.EndpointReference.Address
+	case Bye:
+		addr = body.EndpointReference.Address
+	default:
+		return ""
+	}
+
+	prev, found := d.endpoints[addr]
+	d.endpoints[addr] = dumperAnnounce{hdr.Action, hdr.AppSequence, now}
+
+	if !found {
+		return "first announce of the endpoint"
+	}
+
+	rel := fmt.Sprintf("previous %s of the endpoint %s ago", prev.act,
+		now.Sub(prev.when).Round(time.Millisecond))
+
+	if hdr.AppSequence != nil && prev.seq != nil {
+		switch (*hdr.AppSequence).Order(*prev.seq) {
+		case AppSequenceRestart:
+			rel += ", device restarted"
+		case AppSequenceStale:
+			rel += ", stale"
+		}
+	}
+
+	return rel
+}
+
+// expire removes expired entries.
+//
+// Must be called under d.lock.
+func (d *Dumper) expire(now time.Time) {
+	for msgid, rq := range d.requests {
+		if now.Sub(rq.when) > CorrelatorDefaultTTL {
+			delete(d.requests, msgid)
+		}
+	}
+
+	for addr, ann := range d.endpoints {
+		if now.Sub(ann.when) > dumperEndpointTTL {
+			delete(d.endpoints, addr)
+		}
+	}
+}
+
+// dumper accumulates lines of the MsgDump
+type dumper struct {
+	lines MsgDump
+}
+
+// line adds a formatted line with the specified indentation level.
+func (dump *dumper) line(indent int, format string, args ...any) {
+	s := strings.Repeat("  ", indent) + fmt.Sprintf(format, args...)
+	dump.lines = append(dump.lines, s)
+}
+
+// field adds the "name: value" line. Values are aligned.
+func (dump *dumper) field(indent int, name, value string) {
+	dump.line(indent, "%-16s %s", name+":", value)
+}
+
+// header dumps the message Header.
+func (dump *dumper) header(hdr Header) {
+	dump.field(1, "MessageID", string(hdr.MessageID))
+
+	if hdr.To != nil {
+		to := string(*hdr.To)
+		switch *hdr.To {
+		case ToDiscovery:
+			to += " (multicast discovery)"
+		case ToAnonymous:
+			to += " (anonymous)"
+		}
+		dump.field(1, "Destination", to)
+	}
+
+	if hdr.ReplyTo != nil {
+		replyTo := string((*hdr.ReplyTo).Address)
+		if (*hdr.ReplyTo).Address == ToAnonymous {
+			replyTo = "anonymous (in HTTP response)"
+		}
+		dump.field(1, "ReplyTo", replyTo)
+	}
+
+	if hdr.RelatesTo != nil {
+		dump.field(1, "RelatesTo", string(*hdr.RelatesTo))
+	}
+
+	if hdr.AppSequence != nil {
+		seq := *hdr.AppSequence
+		s := fmt.Sprintf("InstanceId=%d MessageNumber=%d",
+			seq.InstanceID, seq.MessageNumber)
+		if seq.SequenceID != nil {
+			s += fmt.Sprintf(" SequenceId=%s", *seq.SequenceID)
+		}
+		dump.field(1, "AppSequence", s)
+	}
+
+	if hdr.Identifier != nil {
+		dump.field(1, "Identifier", string(*hdr.Identifier))
+	}
+}
+
+// body dumps the message Body.
+func (dump *dumper) body(body Body) {
+	switch body := body.(type) {
+	case Hello:
+		dump.endpoint(1, body.EndpointReference, body.Types,
+			body.Scopes, body.XAddrs, body.MetadataVersion)
+
+	case Bye:
+		dump.field(1, "Endpoint", string(body.EndpointReference.Address))
+
+	case Probe:
+		dump.types(1, body.Types)
+		dump.scopes(1, body.Scopes)
+		if body.MatchBy != "" {
+			dump.field(1, "MatchBy", body.MatchBy)
+		}
+
+	case ProbeMatches:
+		for i, match := range body.ProbeMatch {
+			dump.line(1, "Match #%d:", i+1)
+			dump.endpoint(2, match.EndpointReference, match.Types,
+				match.Scopes, match.XAddrs, match.MetadataVersion)
+		}
+
+		if len(body.ProbeMatch) == 0 {
+			dump.line(1, "No matches")
+		}
+
+	case Resolve:
+		dump.field(1, "Endpoint", string(body.EndpointReference.Address))
+
+	case ResolveMatches:
+		for i, match := range body.ResolveMatch {
+			dump.line(1, "Match #%d:", i+1)
+			dump.endpoint(2, match.EndpointReference, match.Types,
+				match.Scopes, match.XAddrs, match.MetadataVersion)
+		}
+
+		if len(body.ResolveMatch) == 0 {
+			dump.line(1, "No matches")
+		}
+
+	case Metadata:
+		dump.metadata(body)
+
+	case Subscribe:
+		dump.field(1, "NotifyTo", string(body.NotifyTo.Address))
+		if body.EndTo != nil {
+			dump.field(1, "EndTo", string((*body.EndTo).Address))
+		}
+		if body.Expires != 0 {
+			dump.field(1, "Expires", body.Expires.String())
+		}
+		for _, f := range body.Filter {
+			dump.field(1, "Filter", string(f))
+		}
+
+	case SubscribeResponse:
+		dump.field(1, "Manager",
+			string(body.SubscriptionManager.Address))
+		dump.field(1, "Expires", body.Expires.String())
+
+	case Renew:
+		if body.Expires != 0 {
+			dump.field(1, "Expires", body.Expires.String())
+		}
+
+	case RenewResponse:
+		if body.Expires != 0 {
+			dump.field(1, "Expires", body.Expires.String())
+		}
+
+	case SubscriptionEnd:
+		dump.field(1, "Manager",
+			string(body.SubscriptionManager.Address))
+		dump.field(1, "Status", string(body.Status))
+		if !body.Reason.IsZero() {
+			dump.field(1, "Reason", body.Reason.String)
+		}
+
+	case Fault:
+		dump.field(1, "Code", body.Code)
+		if body.Subcode != "" {
+			dump.field(1, "Subcode", body.Subcode)
+		}
+		if !body.Reason.IsZero() {
+			dump.field(1, "Reason", body.Reason.String)
+		}
+	}
+}
+
+// endpoint dumps the endpoint description, common for Hello,
+// ProbeMatch and ResolveMatch.
+func (dump *dumper) endpoint(indent int, ref EndpointReference,
+	types Types, scopes Scopes, xaddrs XAddrs, ver uint64) {
+
+	dump.field(indent, "Endpoint", string(ref.Address))
+	dump.types(indent, types)
+	dump.scopes(indent, scopes)
+
+	for _, xaddr := range xaddrs {
+		dump.field(indent, "XAddr", xaddr)
+	}
+
+	dump.field(indent, "MetadataVersion", fmt.Sprintf("%d", ver))
+}
+
+// types dumps the Types, one per line, with description.
+func (dump *dumper) types(indent int, types Types) {
+	for _, t := range types {
+		dump.field(indent, "Type",
+			fmt.Sprintf("%s (%s)", t, t.describe()))
+	}
+}
+
+// scopes dumps the Scopes, one per line.
+func (dump *dumper) scopes(indent int, scopes Scopes) {
+	for _, s := range scopes {
+		dump.field(indent, "Scope", string(s))
+	}
+}
+
+// metadata dumps the Metadata.
+func (dump *dumper) metadata(meta Metadata) {
+	dev := meta.ThisDevice
+	model := meta.ThisModel
+
+	if name := dev.FriendlyName.NeutralLang(); !name.IsZero() {
+		dump.field(1, "FriendlyName", name.String)
+	}
+	if s := model.Manufacturer.NeutralLang(); !s.IsZero() {
+		dump.field(1, "Manufacturer", s.String)
+	}
+	if s := model.ModelName.NeutralLang(); !s.IsZero() {
+		dump.field(1, "ModelName", s.String)
+	}
+	if model.ModelNumber != "" {
+		dump.field(1, "ModelNumber", model.ModelNumber)
+	}
+	if dev.SerialNumber != "" {
+		dump.field(1, "SerialNumber", dev.SerialNumber)
+	}
+	if dev.FirmwareVersion != "" {
+		dump.field(1, "Firmware", dev.FirmwareVersion)
+	}
+	if model.PresentationURL != nil {
+		dump.field(1, "PresentationURL", *model.PresentationURL)
+	}
+
+	if host := meta.Relationship.Host; host != nil {
+		dump.line(1, "Host:")
+		dump.service(2, *host)
+	}
+
+	for i, svc := range meta.Relationship.Hosted {
+		dump.line(1, "Hosted service #%d:", i+1)
+		dump.service(2, svc)
+	}
+}
+
+// service dumps the ServiceMetadata.
+func (dump *dumper) service(indent int, svc ServiceMetadata) {
+	if svc.ServiceID != "" {
+		dump.field(indent, "ServiceID", string(svc.ServiceID))
+	}
+
+	dump.types(indent, svc.Types)
+
+	for _, ref := range svc.EndpointReference {
+		dump.field(indent, "Endpoint", string(ref.Address))
+	}
+}
+
+// describe returns the human-readable meaning of the action,
+// for the [MsgDump].
+func (act Action) describe() string {
+	switch act {
+	case ActHello:
+		return "device joins the network"
+	case ActBye:
+		return "device leaves the network"
+	case ActProbe:
+		return "search for devices"
+	case ActProbeMatches:
+		return "devices found"
+	case ActResolve:
+		return "request for device transport addresses"
+	case ActResolveMatches:
+		return "device transport addresses"
+	case ActGet:
+		return "request for device metadata"
+	case ActGetResponse:
+		return "device metadata"
+	case ActSubscribe:
+		return "request for events subscription"
+	case ActSubscribeResponse:
+		return "subscription granted"
+	case ActRenew:
+		return "request for subscription renewal"
+	case ActRenewResponse:
+		return "subscription renewed"
+	case ActUnsubscribe:
+		return "request for subscription cancellation"
+	case ActUnsubscribeResponse:
+		return "subscription cancelled"
+	case ActSubscriptionEnd:
+		return "subscription terminated by device"
+	case ActFault:
+		return "error"
+	}
+
+	return ""
+}
+
+// describe returns the human-readable meaning of the type,
+// for the [MsgDump].
+func (t Type) describe() string {
+	switch t {
+	case Device:
+		return "WSD device"
+	case PrinterServiceType:
+		return "printer"
+	case ScannerServiceType:
+		return "scanner"
+	case DiscoveryProxy:
+		return "Discovery Proxy"
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,187 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Semantic dump of WSD messages test
+
+package wsd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// TestDumper tests Dumper
+func TestDumper(t *testing.T) {
+	const device = AnyURI("urn:uuid:b8310cdf-157f-4e5b-a042-4588f7149ec0")
+
+	probe := Msg{
+		Header: NewRequestHeader(ActProbe, ""),
+		Body:   Probe{Types: Types{Device}},
+	}
+
+	matches := Msg{
+		Header: NewResponseHeader(ActProbeMatches, probe.Header),
+		Body: ProbeMatches{
+			ProbeMatch: []ProbeMatch{
+				{
+					EndpointReference: EndpointReference{
+						Address: device,
+					},
+					Types: Types{Device, ScannerServiceType},
+					XAddrs: XAddrs{
+						"http://127.0.0.1/",
+					},
+					MetadataVersion: 1,
+				},
+			},
+		},
+	}
+
+	hello := func(instance uint64) Msg {
+		return Msg{
+			Header: NewAnnounceHeader(ActHello, AppSequence{
+				InstanceID:    instance,
+				MessageNumber: 1,
+			}),
+			Body: Hello{
+				EndpointReference: EndpointReference{
+					Address: device,
+				},
+				Types: Types{Device},
+			},
+		}
+	}
+
+	fault := Msg{
+		Header: NewResponseHeader(ActFault,
+			NewRequestHeader(ActGet, device)),
+		Body: Fault{Code: "s:Sender"},
+	}
+
+	type testData struct {
+		name   string   // Test name
+		msg    Msg      // Message to dump
+		expect []string // Expected lines (substrings)
+	}
+
+	tests := []testData{
+		{
+			name: "Probe",
+			msg:  probe,
+			expect: []string{
+				"Probe (search for devices)",
+				"(multicast discovery)",
+				"Type:            devprof:Device (WSD device)",
+			},
+		},
+		{
+			name: "ProbeMatches",
+			msg:  matches,
+			expect: []string{
+				"ProbeMatches (devices found)",
+				"Relation:        response to Probe,",
+				"  Match #1:",
+				"    Endpoint:        " + string(device),
+				"scan:ScanDeviceType (scanner)",
+				"    XAddr:           http://127.0.0.1/",
+			},
+		},
+		{
+			name: "first Hello",
+			msg:  hello(1),
+			expect: []string{
+				"Hello (device joins the network)",
+				"InstanceId=1 MessageNumber=1",
+				"first announce of the endpoint",
+			},
+		},
+		{
+			name: "Hello after restart",
+			msg:  hello(2),
+			expect: []string{
+				"previous Hello of the endpoint",
+				"device restarted",
+			},
+		},
+		{
+			name: "Fault",
+			msg:  fault,
+			expect: []string{
+				"Fault (error)",
+				"response to unknown request",
+				"Code:            s:Sender",
+			},
+		},
+	}
+
+	dumper := NewDumper()
+	for _, test := range tests {
+		dump := dumper.Dump(test.msg)
+		text := dump.String()
+
+		if string(dump.MarshalLog()) != text {
+			t.Errorf("%s: MarshalLog and String mismatch", test.name)
+		}
+
+		for _, expect := range test.expect {
+			if !strings.Contains(text, expect) {
+				t.Errorf("%s: %q missed in dump:\n%s",
+					test.name, expect, text)
+			}
+		}
+	}
+}
+
+// TestMsgDump tests Msg.Dump
+func TestMsgDump(t *testing.T) {
+	msg := Msg{
+		Header: NewResponseHeader(ActGetResponse,
+			NewRequestHeader(ActGet, "urn:uuid:1")),
+		Body: Metadata{
+			ThisDevice: ThisDeviceMetadata{
+				FriendlyName: LocalizedStringList{
+					{String: "Test Scanner"},
+				},
+				SerialNumber: "12345",
+			},
+			ThisModel: ThisModelMetadata{
+				PresentationURL: optional.New("http://127.0.0.1/"),
+			},
+			Relationship: Relationship{
+				Hosted: []ServiceMetadata{
+					{
+						EndpointReference: []EndpointReference{
+							{Address: "http://127.0.0.1/scan"},
+						},
+						Types:     Types{ScannerServiceType},
+						ServiceID: "urn:uuid:2",
+					},
+				},
+			},
+		},
+	}
+
+	expect := []string{
+		"GetResponse (device metadata)",
+		"  Relation:        response to unknown request",
+		"  FriendlyName:    Test Scanner",
+		"  SerialNumber:    12345",
+		"  PresentationURL: http://127.0.0.1/",
+		"  Hosted service #1:",
+		"    ServiceID:       urn:uuid:2",
+		"    Type:            scan:ScanDeviceType (scanner)",
+		"    Endpoint:        http://127.0.0.1/scan",
+	}
+
+	text := msg.Dump().String()
+	for _, line := range expect {
+		if !strings.Contains(text, line) {
+			t.Errorf("%q missed in dump:\n%s", line, text)
+		}
+	}
+}
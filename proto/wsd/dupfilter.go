@@ -0,0 +1,74 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Duplicate messages filter
+
+package wsd
+
+import "sync"
+
+// DupFilterDefaultSize is the default size of the [DupFilter]
+// sliding window.
+const DupFilterDefaultSize = 256
+
+// DupFilter detects duplicate messages by their MessageID.
+//
+// As SOAP-over-UDP messages are retransmitted (see
+// [UDPRetransmitDelays]), the receiver gets each message several
+// times and must process it only once.
+//
+// DupFilter remembers MessageIDs of the recently seen messages
+// in the sliding window of the fixed size. When window is full,
+// the oldest MessageID is forgotten.
+//
+// DupFilter is safe for concurrent use.
+type DupFilter struct {
+	window []AnyURI            // Sliding window, circular
+	next   int                 // Next slot in the window
+	seen   map[AnyURI]struct{} // MessageIDs in the window
+	lock   sync.Mutex          // Access lock
+}
+
+// NewDupFilter creates a new [DupFilter] with the specified
+// window size. If size is not positive, [DupFilterDefaultSize]
+// is used.
+func NewDupFilter(size int) *DupFilter {
+	if size <= 0 {
+		size = DupFilterDefaultSize
+	}
+
+	return &DupFilter{
+		window: make([]AnyURI, size),
+		seen:   make(map[AnyURI]struct{}, size),
+	}
+}
+
+// Duplicate reports whether the message with the specified MessageID
+// was already seen and remembers its MessageID, if it was not.
+//
+// Messages without MessageID are never considered duplicates.
+func (f *DupFilter) Duplicate(msgid AnyURI) bool {
+	if msgid == "" {
+		return false
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, found := f.seen[msgid]; found {
+		return true
+	}
+
+	if old := f.window[f.next]; old != "" {
+		delete(f.seen, old)
+	}
+
+	f.window[f.next] = msgid
+	f.seen[msgid] = struct{}{}
+	f.next = (f.next + 1) % len(f.window)
+
+	return false
+}
@@ -0,0 +1,60 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Duplicate messages filter test
+
+package wsd
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestDupFilter tests DupFilter
+func TestDupFilter(t *testing.T) {
+	f := NewDupFilter(3)
+
+	type testData struct {
+		msgid AnyURI
+		dup   bool
+	}
+
+	tests := []testData{
+		{"urn:uuid:1", false},
+		{"urn:uuid:1", true},
+		{"urn:uuid:2", false},
+		{"urn:uuid:3", false},
+		{"urn:uuid:1", true},
+		{"urn:uuid:2", true},
+
+		// urn:uuid:1 leaves the window
+		{"urn:uuid:4", false},
+		{"urn:uuid:1", false},
+		{"urn:uuid:3", true},
+
+		// Messages without MessageID are never duplicates
+		{"", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		dup := f.Duplicate(test.msgid)
+		if dup != test.dup {
+			t.Errorf("Duplicate(%q): expected %v, present %v",
+				test.msgid, test.dup, dup)
+		}
+	}
+
+	// Default size
+	f = NewDupFilter(0)
+	for i := 0; i < DupFilterDefaultSize; i++ {
+		f.Duplicate(AnyURI(fmt.Sprintf("urn:uuid:%d", i)))
+	}
+
+	if !f.Duplicate("urn:uuid:0") {
+		t.Errorf("NewDupFilter(0): window too small")
+	}
+}
@@ -0,0 +1,135 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// XMS Schema Part 2: Datatypes: duration
+
+package wsd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// DecodeDuration decodes duration, per XMS Schema Part 2: Datatypes,
+// 3.2.6, from the XML tree.
+//
+// Years and months have no fixed length, so they are approximated
+// as 365 and 30 days, respectively. Negative durations are rejected.
+func DecodeDuration(root xmldoc.Element) (time.Duration, error) {
+	d, ok := parseDuration(root.Text)
+	if !ok {
+		err := fmt.Errorf("invalid duration: %q", root.Text)
+		return 0, xmldoc.XMLErrWrap(root, err)
+	}
+	return d, nil
+}
+
+// EncodeDuration encodes duration, per XMS Schema Part 2: Datatypes,
+// 3.2.6, into its string representation (i.e., PT1H30M).
+//
+// The duration is rounded to seconds. Negative durations encoded
+// as zero.
+func EncodeDuration(d time.Duration) string {
+	secs := int64(d.Round(time.Second) / time.Second)
+	if secs <= 0 {
+		return "PT0S"
+	}
+
+	s := "P"
+	if days := secs / 86400; days > 0 {
+		s += strconv.FormatInt(days, 10) + "D"
+	}
+
+	secs %= 86400
+	if secs == 0 {
+		return s
+	}
+
+	s += "T"
+	if hours := secs / 3600; hours > 0 {
+		s += strconv.FormatInt(hours, 10) + "H"
+	}
+	if mins := (secs / 60) % 60; mins > 0 {
+		s += strconv.FormatInt(mins, 10) + "M"
+	}
+	if secs%60 > 0 {
+		s += strconv.FormatInt(secs%60, 10) + "S"
+	}
+
+	return s
+}
+
+// parseDuration parses the string representation of duration.
+func parseDuration(s string) (time.Duration, bool) {
+	s, ok := strings.CutPrefix(strings.TrimSpace(s), "P")
+	if !ok || s == "" {
+		return 0, false
+	}
+
+	const day = 24 * time.Hour
+	units := map[byte]time.Duration{
+		'Y': 365 * day,
+		'M': 30 * day,
+		'D': day,
+	}
+
+	var d time.Duration
+	var seen bool
+	timePart := false
+
+	for s != "" {
+		if s[0] == 'T' && !timePart {
+			timePart = true
+			units = map[byte]time.Duration{
+				'H': time.Hour,
+				'M': time.Minute,
+				'S': time.Second,
+			}
+			s = s[1:]
+			if s == "" {
+				return 0, false
+			}
+			continue
+		}
+
+		i := strings.IndexFunc(s, func(c rune) bool {
+			return (c < '0' || c > '9') && c != '.'
+		})
+		if i <= 0 {
+			return 0, false
+		}
+
+		unit, found := units[s[i]]
+		if !found {
+			return 0, false
+		}
+
+		// Only seconds may be fractional
+		v, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil || (s[i] != 'S' && strings.Contains(s[:i], ".")) {
+			return 0, false
+		}
+
+		d += time.Duration(v * float64(unit))
+		seen = true
+
+		// Units must go in order, from larger to smaller,
+		// and each unit may appear only once
+		for u, uv := range units {
+			if uv >= unit {
+				delete(units, u)
+			}
+		}
+
+		s = s[i+1:]
+	}
+
+	return d, seen
+}
@@ -0,0 +1,94 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Duration test
+
+package wsd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestDuration tests duration encoding and decoding
+func TestDuration(t *testing.T) {
+	type testData struct {
+		d time.Duration
+		s string
+	}
+
+	tests := []testData{
+		{0, "PT0S"},
+		{time.Second, "PT1S"},
+		{time.Hour, "PT1H"},
+		{90 * time.Minute, "PT1H30M"},
+		{3661 * time.Second, "PT1H1M1S"},
+		{24 * time.Hour, "P1D"},
+		{25 * time.Hour, "P1DT1H"},
+	}
+
+	for _, test := range tests {
+		s := EncodeDuration(test.d)
+		if s != test.s {
+			t.Errorf("EncodeDuration(%s): expected %q, present %q",
+				test.d, test.s, s)
+		}
+
+		d, err := DecodeDuration(xmldoc.WithText("Expires", s))
+		if err != nil {
+			t.Errorf("DecodeDuration(%q): %s", s, err)
+		} else if d != test.d {
+			t.Errorf("DecodeDuration(%q): expected %s, present %s",
+				s, test.d, d)
+		}
+	}
+}
+
+// TestDurationDecode tests duration decoding of the values that
+// EncodeDuration doesn't generate, and decode errors
+func TestDurationDecode(t *testing.T) {
+	type testData struct {
+		s    string
+		d    time.Duration
+		estr string
+	}
+
+	tests := []testData{
+		{s: "PT3600S", d: time.Hour},
+		{s: "PT0.5S", d: time.Second / 2},
+		{s: " PT10M ", d: 10 * time.Minute},
+		{s: "P1M", d: 30 * 24 * time.Hour},
+		{s: "P1Y", d: 365 * 24 * time.Hour},
+		{s: "", estr: `/Expires: invalid duration: ""`},
+		{s: "P", estr: `/Expires: invalid duration: "P"`},
+		{s: "PT", estr: `/Expires: invalid duration: "PT"`},
+		{s: "1H", estr: `/Expires: invalid duration: "1H"`},
+		{s: "PT1H1H", estr: `/Expires: invalid duration: "PT1H1H"`},
+		{s: "PT1M1H", estr: `/Expires: invalid duration: "PT1M1H"`},
+		{s: "P1H", estr: `/Expires: invalid duration: "P1H"`},
+		{s: "P1.5D", estr: `/Expires: invalid duration: "P1.5D"`},
+		{s: "-PT1H", estr: `/Expires: invalid duration: "-PT1H"`},
+		{s: "2025-01-01T00:00:00Z",
+			estr: `/Expires: invalid duration: "2025-01-01T00:00:00Z"`},
+	}
+
+	for _, test := range tests {
+		d, err := DecodeDuration(xmldoc.WithText("Expires", test.s))
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if estr != test.estr {
+			t.Errorf("%q: error expected %q, present %q",
+				test.s, test.estr, estr)
+		} else if err == nil && d != test.d {
+			t.Errorf("%q: expected %s, present %s", test.s, test.d, d)
+		}
+	}
+}
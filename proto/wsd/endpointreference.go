@@ -9,12 +9,19 @@
 package wsd
 
 import (
+	"github.com/OpenPrinting/go-mfp/util/optional"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
 // EndpointReference represents a WSA endpoint address.
+//
+// Identifier, if present, is the WS-Eventing subscription
+// identifier, carried within the endpoint's ReferenceParameters.
+// When message is sent to this endpoint, it must be copied
+// into the message [Header].
 type EndpointReference struct {
-	Address AnyURI // Endpoint address
+	Address    AnyURI               // Endpoint address
+	Identifier optional.Val[AnyURI] // Subscription identifier
 }
 
 // DecodeEndpointReference decodes EndpointReference from the XML tree
@@ -24,13 +31,28 @@ func DecodeEndpointReference(root xmldoc.Element) (
 	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
 
 	address := xmldoc.Lookup{Name: NsAddressing + ":Address", Required: true}
-	missed := root.Lookup(&address)
+	params := xmldoc.Lookup{Name: NsAddressing + ":ReferenceParameters"}
+	missed := root.Lookup(&address, &params)
 	if missed != nil {
 		err = xmldoc.XMLErrMissed(missed.Name)
 		return
 	}
 
 	ref.Address, err = DecodeAnyURI(address.Elem)
+	if err != nil || !params.Found {
+		return
+	}
+
+	// Other reference parameters are ignored.
+	id, found := params.Elem.ChildByName(NsEventing + ":Identifier")
+	if found {
+		var tmp AnyURI
+		tmp, err = DecodeAnyURI(id)
+		if err == nil {
+			ref.Identifier = optional.New(tmp)
+		}
+		err = xmldoc.XMLErrWrap(params.Elem, err)
+	}
 
 	return
 }
@@ -47,5 +69,12 @@ func (ref EndpointReference) ToXML(name string) xmldoc.Element {
 		},
 	}
 
+	if ref.Identifier != nil {
+		elm.Children = append(elm.Children,
+			xmldoc.WithChildren(NsAddressing+":ReferenceParameters",
+				xmldoc.WithText(NsEventing+":Identifier",
+					string(*ref.Identifier))))
+	}
+
 	return elm
 }
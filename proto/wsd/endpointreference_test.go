@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/OpenPrinting/go-mfp/util/optional"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
@@ -37,6 +38,22 @@ func TestEndpointReference(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			ref: EndpointReference{
+				Address: "http://192.168.0.1:5358/WSDScanner",
+				Identifier: optional.New(
+					AnyURI("urn:uuid:e0a5e6c3-6b14-4d64-8a83-3c9d53fbb7c5")),
+			},
+			xml: xmldoc.WithChildren(NsAddressing+":EndpointReference",
+				xmldoc.WithText(NsAddressing+":Address",
+					"http://192.168.0.1:5358/WSDScanner"),
+				xmldoc.WithChildren(NsAddressing+":ReferenceParameters",
+					xmldoc.WithText(NsEventing+":Identifier",
+						"urn:uuid:e0a5e6c3-6b14-4d64-8a83-3c9d53fbb7c5"),
+				),
+			),
+		},
 	}
 
 	for _, test := range tests {
@@ -100,6 +117,17 @@ func TestEndpointReferenceErrors(t *testing.T) {
 			},
 			estr: "/a:EndpointReference/a:Address: missed",
 		},
+
+		{
+			xml: xmldoc.WithChildren(NsAddressing+":EndpointReference",
+				xmldoc.WithText(NsAddressing+":Address",
+					"http://192.168.0.1:5358/WSDScanner"),
+				xmldoc.WithChildren(NsAddressing+":ReferenceParameters",
+					xmldoc.WithText(NsEventing+":Identifier", ""),
+				),
+			),
+			estr: "/a:EndpointReference/a:ReferenceParameters/e:Identifier: invalid URI",
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,210 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SOAP Fault message body
+
+package wsd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// SOAP fault codes:
+const (
+	FaultCodeVersionMismatch = "VersionMismatch"
+	FaultCodeMustUnderstand  = "MustUnderstand"
+	FaultCodeSender          = "Sender"
+	FaultCodeReceiver        = "Receiver"
+)
+
+// WS-Addressing, WS-Discovery and WS-Eventing fault subcodes:
+const (
+	FaultInvalidMessageInformationHeader  = "InvalidMessageInformationHeader"
+	FaultMessageInformationHeaderRequired = "MessageInformationHeaderRequired"
+	FaultDestinationUnreachable           = "DestinationUnreachable"
+	FaultActionNotSupported               = "ActionNotSupported"
+	FaultEndpointUnavailable              = "EndpointUnavailable"
+	FaultMatchingRuleNotSupported         = "MatchingRuleNotSupported"
+	FaultDeliveryModeRequestedUnavailable = "DeliveryModeRequestedUnavailable"
+	FaultInvalidExpirationTime            = "InvalidExpirationTime"
+	FaultUnsupportedExpirationType        = "UnsupportedExpirationType"
+	FaultFilteringNotSupported            = "FilteringNotSupported"
+	FaultFilteringRequestedUnavailable    = "FilteringRequestedUnavailable"
+	FaultEventSourceUnableToProcess       = "EventSourceUnableToProcess"
+	FaultUnableToRenew                    = "UnableToRenew"
+	FaultInvalidMessage                   = "InvalidMessage"
+)
+
+// faultSubcodeNs maps known fault subcodes to namespace prefixes,
+// for encoding.
+var faultSubcodeNs = map[string]string{
+	FaultInvalidMessageInformationHeader:  NsAddressing,
+	FaultMessageInformationHeaderRequired: NsAddressing,
+	FaultDestinationUnreachable:           NsAddressing,
+	FaultActionNotSupported:               NsAddressing,
+	FaultEndpointUnavailable:              NsAddressing,
+	FaultMatchingRuleNotSupported:         NsDiscovery,
+	FaultDeliveryModeRequestedUnavailable: NsEventing,
+	FaultInvalidExpirationTime:            NsEventing,
+	FaultUnsupportedExpirationType:        NsEventing,
+	FaultFilteringNotSupported:            NsEventing,
+	FaultFilteringRequestedUnavailable:    NsEventing,
+	FaultEventSourceUnableToProcess:       NsEventing,
+	FaultUnableToRenew:                    NsEventing,
+	FaultInvalidMessage:                   NsEventing,
+}
+
+// Fault represents a SOAP Fault message body.
+//
+// Code and Subcode are the local names of the corresponding
+// QNames, with namespace prefix stripped (i.e., [FaultCodeSender]
+// and [FaultActionNotSupported]), as original prefixes are not
+// preserved by decoding. When encoded, known subcodes get prefix
+// of their namespace, and unknown are encoded as is.
+//
+// SubcodeNs, if not empty, overrides namespace prefix of the
+// Subcode when encoded. It allows protocols, built on top of WSD
+// (WS-Scan, WS-Print), to use their own subcodes. It is never
+// set by decoding.
+//
+// Detail contains children of the s:Detail element, if any.
+//
+// Fault implements the error interface, so clients return it
+// as error, when device responds with fault.
+type Fault struct {
+	Code      string           // Fault code
+	Subcode   string           // Fault subcode, "" if missed
+	SubcodeNs string           // Subcode namespace prefix, "" if default
+	Reason    LocalizedString  // Human-readable reason
+	Detail    []xmldoc.Element // Fault details
+}
+
+// DecodeFault decodes [Fault] from the XML tree
+func DecodeFault(root xmldoc.Element) (f Fault, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	// Lookup message elements
+	code := xmldoc.Lookup{Name: NsSOAP + ":Code", Required: true}
+	reason := xmldoc.Lookup{Name: NsSOAP + ":Reason"}
+	detail := xmldoc.Lookup{Name: NsSOAP + ":Detail"}
+
+	missed := root.Lookup(&code, &reason, &detail)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	// Decode elements
+	value, ok := code.Elem.ChildByName(NsSOAP + ":Value")
+	if !ok {
+		err = xmldoc.XMLErrMissed(NsSOAP + ":Value")
+		err = xmldoc.XMLErrWrap(code.Elem, err)
+		return
+	}
+
+	f.Code = faultLocalName(value.Text)
+
+	subcode, _ := code.Elem.ChildByName(NsSOAP + ":Subcode")
+	if value, ok := subcode.ChildByName(NsSOAP + ":Value"); ok {
+		f.Subcode = faultLocalName(value.Text)
+	}
+
+	if reason.Found {
+		if text, ok := reason.Elem.ChildByName(NsSOAP + ":Text"); ok {
+			f.Reason = decodeLocalizedString(text)
+			f.Reason.String = strings.TrimSpace(f.Reason.String)
+		}
+	}
+
+	if detail.Found {
+		f.Detail = detail.Elem.Children
+	}
+
+	return
+}
+
+// Action returns [Action] to be used with the [Fault] message
+func (Fault) Action() Action {
+	return ActFault
+}
+
+// Error returns the error string. It implements the error interface.
+func (f Fault) Error() string {
+	code := f.Code
+	if f.Subcode != "" {
+		code = f.Subcode
+	}
+
+	if f.Reason.String != "" {
+		return fmt.Sprintf("SOAP Fault: %s: %s", code, f.Reason.String)
+	}
+
+	return fmt.Sprintf("SOAP Fault: %s", code)
+}
+
+// ToXML generates XML tree for the message body
+func (f Fault) ToXML() xmldoc.Element {
+	code := xmldoc.WithChildren(NsSOAP+":Code",
+		xmldoc.WithText(NsSOAP+":Value", NsSOAP+":"+f.Code))
+
+	if f.Subcode != "" {
+		code.Children = append(code.Children,
+			xmldoc.WithChildren(NsSOAP+":Subcode",
+				xmldoc.WithText(NsSOAP+":Value",
+					f.subcodeQName())))
+	}
+
+	elm := xmldoc.WithChildren(NsSOAP+":Fault", code)
+
+	if !f.Reason.IsZero() {
+		elm.Children = append(elm.Children,
+			xmldoc.WithChildren(NsSOAP+":Reason",
+				f.Reason.ToXML(NsSOAP+":Text")))
+	}
+
+	if len(f.Detail) != 0 {
+		elm.Children = append(elm.Children,
+			xmldoc.WithChildren(NsSOAP+":Detail", f.Detail...))
+	}
+
+	return elm
+}
+
+// MarkUsedNamespace marks [xmldoc.Namespace] entries used by
+// data elements within the message body, if any.
+//
+// This function should not care about Namespace entries, used
+// by XML tags: they are handled automatically.
+func (f Fault) MarkUsedNamespace(ns xmldoc.Namespace) {
+	ns.MarkUsedPrefix(NsSOAP)
+	if f.Subcode != "" {
+		ns.MarkUsedName(f.subcodeQName())
+	}
+}
+
+// subcodeQName returns Subcode as QName, for encoding.
+func (f Fault) subcodeQName() string {
+	if f.SubcodeNs != "" {
+		return f.SubcodeNs + ":" + f.Subcode
+	}
+
+	if prefix, ok := faultSubcodeNs[f.Subcode]; ok {
+		return prefix + ":" + f.Subcode
+	}
+	return f.Subcode
+}
+
+// faultLocalName strips namespace prefix from the QName.
+func faultLocalName(qname string) string {
+	qname = strings.TrimSpace(qname)
+	if i := strings.IndexByte(qname, ':'); i >= 0 {
+		qname = qname[i+1:]
+	}
+	return qname
+}
@@ -0,0 +1,255 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SOAP Fault test
+
+package wsd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestFault tests Fault encoding and decoding
+func TestFault(t *testing.T) {
+	type testData struct {
+		fault Fault
+		xml   xmldoc.Element
+	}
+
+	tests := []testData{
+		{
+			fault: Fault{Code: FaultCodeReceiver},
+			xml: xmldoc.WithChildren(NsSOAP+":Fault",
+				xmldoc.WithChildren(NsSOAP+":Code",
+					xmldoc.WithText(NsSOAP+":Value",
+						"s:Receiver"))),
+		},
+
+		{
+			fault: Fault{
+				Code:    FaultCodeSender,
+				Subcode: FaultInvalidExpirationTime,
+				Reason:  LocalizedString{String: "Too long", Lang: "en"},
+				Detail: []xmldoc.Element{
+					xmldoc.WithText(NsEventing+":MaxTime", "PT1H"),
+				},
+			},
+			xml: xmldoc.WithChildren(NsSOAP+":Fault",
+				xmldoc.WithChildren(NsSOAP+":Code",
+					xmldoc.WithText(NsSOAP+":Value",
+						"s:Sender"),
+					xmldoc.WithChildren(NsSOAP+":Subcode",
+						xmldoc.WithText(NsSOAP+":Value",
+							"e:InvalidExpirationTime"))),
+				xmldoc.WithChildren(NsSOAP+":Reason",
+					xmldoc.Element{
+						Name: NsSOAP + ":Text",
+						Text: "Too long",
+						Attrs: []xmldoc.Attr{
+							{Name: "xml:lang", Value: "en"},
+						},
+					}),
+				xmldoc.WithChildren(NsSOAP+":Detail",
+					xmldoc.WithText(NsEventing+":MaxTime",
+						"PT1H"))),
+		},
+
+		{
+			fault: Fault{
+				Code:    FaultCodeSender,
+				Subcode: "ClientErrorJobIdNotFound",
+			},
+			xml: xmldoc.WithChildren(NsSOAP+":Fault",
+				xmldoc.WithChildren(NsSOAP+":Code",
+					xmldoc.WithText(NsSOAP+":Value",
+						"s:Sender"),
+					xmldoc.WithChildren(NsSOAP+":Subcode",
+						xmldoc.WithText(NsSOAP+":Value",
+							"ClientErrorJobIdNotFound")))),
+		},
+	}
+
+	for _, test := range tests {
+		xml := test.fault.ToXML()
+		if !reflect.DeepEqual(xml, test.xml) {
+			t.Errorf("ToXML:\nexpected: %s\npresent:  %s\n",
+				test.xml.EncodeString(NsMap),
+				xml.EncodeString(NsMap))
+		}
+
+		fault, err := DecodeFault(xml)
+		if err != nil {
+			t.Errorf("DecodeFault: %s", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(fault, test.fault) {
+			t.Errorf("DecodeFault:\n"+
+				"expected: %#v\npresent:  %#v\n",
+				test.fault, fault)
+		}
+	}
+}
+
+// TestFaultSubcodeNs tests Fault.SubcodeNs
+func TestFaultSubcodeNs(t *testing.T) {
+	fault := Fault{
+		Code:      FaultCodeSender,
+		Subcode:   "ClientErrorJobIdNotFound",
+		SubcodeNs: NsScan,
+	}
+
+	xml := fault.ToXML()
+	expected := xmldoc.WithChildren(NsSOAP+":Fault",
+		xmldoc.WithChildren(NsSOAP+":Code",
+			xmldoc.WithText(NsSOAP+":Value", "s:Sender"),
+			xmldoc.WithChildren(NsSOAP+":Subcode",
+				xmldoc.WithText(NsSOAP+":Value",
+					"scan:ClientErrorJobIdNotFound"))))
+
+	if !reflect.DeepEqual(xml, expected) {
+		t.Errorf("ToXML:\nexpected: %s\npresent:  %s\n",
+			expected.EncodeString(NsMap),
+			xml.EncodeString(NsMap))
+	}
+
+	// SubcodeNs is not preserved by decoding
+	decoded, err := DecodeFault(xml)
+	if err != nil {
+		t.Fatalf("DecodeFault: %s", err)
+	}
+
+	fault.SubcodeNs = ""
+	if !reflect.DeepEqual(decoded, fault) {
+		t.Errorf("DecodeFault:\nexpected: %#v\npresent:  %#v\n",
+			fault, decoded)
+	}
+}
+
+// TestFaultDecodeErrors tests Fault decoding errors
+func TestFaultDecodeErrors(t *testing.T) {
+	type testData struct {
+		xml  xmldoc.Element
+		estr string
+	}
+
+	tests := []testData{
+		{
+			xml:  xmldoc.Element{Name: NsSOAP + ":Fault"},
+			estr: "/s:Fault/s:Code: missed",
+		},
+
+		{
+			xml: xmldoc.WithChildren(NsSOAP+":Fault",
+				xmldoc.Element{Name: NsSOAP + ":Code"}),
+			estr: "/s:Fault/s:Code/s:Value: missed",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeFault(test.xml)
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if estr != test.estr {
+			t.Errorf("%s\nexpected: %q\npresent:  %q",
+				test.xml.EncodeString(NsMap),
+				test.estr, estr)
+		}
+	}
+}
+
+// TestFaultError tests Fault as error
+func TestFaultError(t *testing.T) {
+	type testData struct {
+		fault Fault
+		estr  string
+	}
+
+	tests := []testData{
+		{
+			fault: Fault{Code: FaultCodeReceiver},
+			estr:  "SOAP Fault: Receiver",
+		},
+		{
+			fault: Fault{
+				Code:    FaultCodeSender,
+				Subcode: FaultActionNotSupported,
+				Reason:  LocalizedString{String: "Unknown action"},
+			},
+			estr: "SOAP Fault: ActionNotSupported: Unknown action",
+		},
+	}
+
+	for _, test := range tests {
+		var err error = test.fault
+		if err.Error() != test.estr {
+			t.Errorf("Error:\nexpected: %q\npresent:  %q",
+				test.estr, err.Error())
+		}
+
+		var fault Fault
+		if !errors.As(err, &fault) || !reflect.DeepEqual(fault, test.fault) {
+			t.Errorf("errors.As: failed for %#v", test.fault)
+		}
+	}
+}
+
+// TestFaultMsg tests that faults are recognized regardless of
+// the message Action
+func TestFaultMsg(t *testing.T) {
+	const msg = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"
+    xmlns:wsa="http://schemas.xmlsoap.org/ws/2004/08/addressing"
+    xmlns:wscn="http://schemas.microsoft.com/windows/2006/08/wdp/scan">
+  <soap:Header>
+    <wsa:Action>http://schemas.microsoft.com/windows/2006/08/wdp/scan/RetrieveImageResponse</wsa:Action>
+    <wsa:MessageID>urn:uuid:b7a1e0f0-8f7e-4a3a-a1a6-6d4b4c6d0c0a</wsa:MessageID>
+    <wsa:RelatesTo>urn:uuid:2a1f7c2e-9a1a-4c4b-8b43-52d1d0d6e1f2</wsa:RelatesTo>
+  </soap:Header>
+  <soap:Body>
+    <soap:Fault>
+      <soap:Code>
+        <soap:Value>soap:Sender</soap:Value>
+        <soap:Subcode>
+          <soap:Value>wscn:ClientErrorNoImagesAvailable</soap:Value>
+        </soap:Subcode>
+      </soap:Code>
+      <soap:Reason>
+        <soap:Text xml:lang="en">No images available</soap:Text>
+      </soap:Reason>
+    </soap:Fault>
+  </soap:Body>
+</soap:Envelope>
+`
+
+	m, err := DecodeMsg([]byte(msg))
+	if err != nil {
+		t.Fatalf("DecodeMsg: %s", err)
+	}
+
+	if m.Header.Action != ActFault {
+		t.Errorf("Action: expected %s, present %s",
+			ActFault, m.Header.Action)
+	}
+
+	expected := Fault{
+		Code:    FaultCodeSender,
+		Subcode: "ClientErrorNoImagesAvailable",
+		Reason:  LocalizedString{String: "No images available", Lang: "en"},
+	}
+
+	if !reflect.DeepEqual(m.Body, expected) {
+		t.Errorf("Fault:\nexpected: %#v\npresent:  %#v",
+			expected, m.Body)
+	}
+}
@@ -0,0 +1,113 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Fuzzing entry points
+
+package wsd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// fuzzSeeds returns the seed corpus for the decoders fuzzing.
+//
+// It consists of messages, captured from the real devices, and
+// synthetic messages of all types, handled by DecodeMsg.
+func fuzzSeeds() [][]byte {
+	seeds := [][]byte{
+		[]byte(sampleHello),
+		[]byte(sampleBye),
+		[]byte(sampleKyoceraECOSYSM2040dnMetadata),
+	}
+
+	epr := EndpointReference{
+		Address: "urn:uuid:37f86d35-e6ac-4241-964f-1d9ae46fb366",
+	}
+
+	ann := Announce{
+		EndpointReference: epr,
+		Types:             Types{Device, PrinterServiceType},
+		XAddrs:            XAddrs{"http://192.168.1.102:5358/"},
+		MetadataVersion:   1,
+	}
+
+	bodies := []Body{
+		Probe{Types: Types{Device}},
+		ProbeMatches{ProbeMatch: []ProbeMatch{ProbeMatch(ann)}},
+		Resolve{EndpointReference: epr},
+		ResolveMatches{ResolveMatch: []ResolveMatch{ResolveMatch(ann)}},
+		Get{},
+	}
+
+	for _, body := range bodies {
+		msg := Msg{
+			Header: Header{
+				Action:    body.Action(),
+				MessageID: "urn:uuid:0f5d604c-81ac-4abc-8010-51dbffad55f2",
+				To:        optional.New(ToDiscovery),
+			},
+			Body: body,
+		}
+
+		seeds = append(seeds, msg.Encode())
+	}
+
+	return seeds
+}
+
+// FuzzDecodeMsg fuzzes the DecodeMsg function.
+//
+// WSD messages come from the unauthenticated multicast, so
+// decoder must never panic, regardless of input.
+func FuzzDecodeMsg(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg, err := DecodeMsg(data)
+		if err != nil {
+			return
+		}
+
+		// Decoded message must survive the round trip
+		_, err = DecodeMsg(msg.Encode())
+		if err != nil {
+			t.Errorf("round trip: %s", err)
+		}
+	})
+}
+
+// FuzzDecodeMetadata fuzzes the metadata decoders.
+func FuzzDecodeMetadata(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		root, err := xmldoc.Decode(NsMap, bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+
+		// Apply decoders to every element of the tree, so
+		// metadata sections nested into the envelope are
+		// reached as well.
+		iter := root.Iterate()
+		for iter.Next() {
+			elem := *iter.Elem()
+			DecodeMetadata(elem)
+			DecodeThisDeviceMetadata(elem)
+			DecodeThisModelMetadata(elem)
+			DecodeRelationship(elem)
+			DecodeServiceMetadata(elem)
+		}
+	})
+}
@@ -21,6 +21,7 @@ type Header struct {
 	ReplyTo     optional.Val[EndpointReference] // Address to reply to
 	RelatesTo   optional.Val[AnyURI]            // ID of related message
 	AppSequence optional.Val[AppSequence]       // Message sequence
+	Identifier  optional.Val[AnyURI]            // WS-Eventing subscription
 }
 
 // DecodeHeader decodes message header [Header] from the XML tree
@@ -34,9 +35,10 @@ func DecodeHeader(root xmldoc.Element) (hdr Header, err error) {
 	replyTo := xmldoc.Lookup{Name: NsAddressing + ":ReplyTo"}
 	relatesTo := xmldoc.Lookup{Name: NsAddressing + ":RelatesTo"}
 	appSequence := xmldoc.Lookup{Name: NsDiscovery + ":AppSequence"}
+	identifier := xmldoc.Lookup{Name: NsEventing + ":Identifier"}
 
 	missed := root.Lookup(&action, &messageID, &to, &replyTo,
-		&relatesTo, &appSequence)
+		&relatesTo, &appSequence, &identifier)
 	if missed != nil {
 		err = xmldoc.XMLErrMissed(missed.Name)
 		return
@@ -79,6 +81,14 @@ func DecodeHeader(root xmldoc.Element) (hdr Header, err error) {
 		}
 	}
 
+	if err == nil && identifier.Found {
+		var tmp AnyURI
+		tmp, err = DecodeAnyURI(identifier.Elem)
+		if err == nil {
+			hdr.Identifier = optional.New(tmp)
+		}
+	}
+
 	return
 }
 
@@ -123,5 +133,13 @@ func (hdr Header) ToXML() xmldoc.Element {
 		elm.Children = append(elm.Children, (*hdr.AppSequence).ToXML())
 	}
 
+	if hdr.Identifier != nil {
+		elm.Children = append(elm.Children,
+			xmldoc.Element{
+				Name: NsEventing + ":" + "Identifier",
+				Text: string(*hdr.Identifier),
+			})
+	}
+
 	return elm
 }
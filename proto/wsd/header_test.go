@@ -99,6 +99,29 @@ func TestHeader(t *testing.T) {
 				),
 			),
 		},
+
+		{
+			hdr: Header{
+				Action:    ActRenew,
+				MessageID: "urn:uuid:1cf1d308-cb65-494c-9d60-2232c57462e1",
+				To: optional.New(
+					AnyURI("http://192.168.0.1:5358/WSDScanner")),
+				Identifier: optional.New(
+					AnyURI("urn:uuid:e0a5e6c3-6b14-4d64-8a83-3c9d53fbb7c5")),
+			},
+			xml: xmldoc.WithChildren(NsSOAP+":Header",
+				xmldoc.WithText(NsAddressing+":Action", ActRenew.Encode()),
+				xmldoc.WithText(NsAddressing+":MessageID",
+					"urn:uuid:1cf1d308-cb65-494c-9d60-2232c57462e1",
+				),
+				xmldoc.WithText(NsAddressing+":To",
+					"http://192.168.0.1:5358/WSDScanner",
+				),
+				xmldoc.WithText(NsEventing+":Identifier",
+					"urn:uuid:e0a5e6c3-6b14-4d64-8a83-3c9d53fbb7c5",
+				),
+			),
+		},
 	}
 
 	for _, test := range tests {
@@ -17,6 +17,7 @@ import (
 type Hello struct {
 	EndpointReference EndpointReference // Stable identifier of the device
 	Types             Types             // Device types
+	Scopes            Scopes            // Device scopes
 	XAddrs            XAddrs            // Transport addresses (URLs)
 	MetadataVersion   uint64            // Incremented when metadata changes
 }
@@ -0,0 +1,108 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Metadata cache
+
+package wsd
+
+import "sync"
+
+// MetadataCache tracks MetadataVersion, announced by devices in
+// the [Hello], [ProbeMatches] and [ResolveMatches] messages, and
+// caches device [Metadata].
+//
+// Devices increment MetadataVersion when their metadata changes,
+// so metadata needs to be re-fetched only when the announced
+// version changes. Devices are identified by their
+// EndpointReference address.
+//
+// MetadataCache is safe for concurrent use.
+type MetadataCache struct {
+	ents map[AnyURI]*metadataCacheEnt // Entries by device address
+	lock sync.Mutex                   // Access lock
+}
+
+// metadataCacheEnt is the MetadataCache entry
+type metadataCacheEnt struct {
+	ver  uint64    // Last announced MetadataVersion
+	meta *Metadata // Cached Metadata, nil if not fetched yet
+}
+
+// NewMetadataCache creates a new [MetadataCache].
+func NewMetadataCache() *MetadataCache {
+	return &MetadataCache{
+		ents: make(map[AnyURI]*metadataCacheEnt),
+	}
+}
+
+// Announce handles the MetadataVersion, announced by the device
+// with the specified address.
+//
+// It returns true if metadata must be (re)fetched, i.e., device
+// is new or its MetadataVersion has changed. In the later case,
+// cached Metadata is dropped.
+//
+// Repeated announcements of the same version return false, even
+// if Metadata was not fetched yet, so the caller will not start
+// the duplicate fetch.
+func (c *MetadataCache) Announce(addr AnyURI, ver uint64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ent := c.ents[addr]
+	switch {
+	case ent == nil:
+		c.ents[addr] = &metadataCacheEnt{ver: ver}
+		return true
+
+	case ent.ver != ver:
+		ent.ver = ver
+		ent.meta = nil
+		return true
+	}
+
+	return false
+}
+
+// Put saves the Metadata of the specified version, fetched from
+// the device with the specified address.
+//
+// If version doesn't match the last announced one (i.e., newer
+// version was announced while fetching), Metadata is ignored.
+func (c *MetadataCache) Put(addr AnyURI, ver uint64, meta Metadata) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ent := c.ents[addr]
+	if ent != nil && ent.ver == ver {
+		ent.meta = &meta
+	}
+}
+
+// Get returns the cached Metadata of the device with the specified
+// address, and its version.
+//
+// If Metadata is not known, it returns false.
+func (c *MetadataCache) Get(addr AnyURI) (Metadata, uint64, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ent := c.ents[addr]
+	if ent == nil || ent.meta == nil {
+		return Metadata{}, 0, false
+	}
+
+	return *ent.meta, ent.ver, true
+}
+
+// Forget forgets the device with the specified address (i.e.,
+// after the [Bye] message), so the next announce will trigger
+// the metadata fetch.
+func (c *MetadataCache) Forget(addr AnyURI) {
+	c.lock.Lock()
+	delete(c.ents, addr)
+	c.lock.Unlock()
+}
@@ -0,0 +1,89 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Metadata cache test
+
+package wsd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMetadataCache tests MetadataCache
+func TestMetadataCache(t *testing.T) {
+	const addr AnyURI = "urn:uuid:37f86d35-e6ac-4241-964f-1d9ae46fb366"
+
+	meta1 := Metadata{
+		ThisDevice: ThisDeviceMetadata{
+			FriendlyName: LocalizedStringList{{String: "Old"}},
+		},
+	}
+
+	meta2 := Metadata{
+		ThisDevice: ThisDeviceMetadata{
+			FriendlyName: LocalizedStringList{{String: "New"}},
+		},
+	}
+
+	c := NewMetadataCache()
+
+	// New device must be fetched, but only once
+	if !c.Announce(addr, 1) {
+		t.Errorf("Announce: new device must be fetched")
+	}
+
+	if c.Announce(addr, 1) {
+		t.Errorf("Announce: repeated announce must not be fetched")
+	}
+
+	if _, _, ok := c.Get(addr); ok {
+		t.Errorf("Get: metadata must not be known yet")
+	}
+
+	c.Put(addr, 1, meta1)
+	if meta, ver, ok := c.Get(addr); !ok || ver != 1 ||
+		!reflect.DeepEqual(meta, meta1) {
+		t.Errorf("Get: unexpected %v %d %#v", ok, ver, meta)
+	}
+
+	// Version change invalidates the cache
+	if !c.Announce(addr, 2) {
+		t.Errorf("Announce: version change must be fetched")
+	}
+
+	if _, _, ok := c.Get(addr); ok {
+		t.Errorf("Get: metadata must be invalidated")
+	}
+
+	// Late Put of the old version is ignored
+	c.Put(addr, 1, meta1)
+	if _, _, ok := c.Get(addr); ok {
+		t.Errorf("Put: stale metadata must be ignored")
+	}
+
+	c.Put(addr, 2, meta2)
+	if meta, ver, ok := c.Get(addr); !ok || ver != 2 ||
+		!reflect.DeepEqual(meta, meta2) {
+		t.Errorf("Get: unexpected %v %d %#v", ok, ver, meta)
+	}
+
+	// Forget makes device new again
+	c.Forget(addr)
+	if _, _, ok := c.Get(addr); ok {
+		t.Errorf("Get: metadata must be forgotten")
+	}
+
+	if !c.Announce(addr, 2) {
+		t.Errorf("Announce: forgotten device must be fetched")
+	}
+
+	// Put for unknown device is ignored
+	c.Put("urn:uuid:unknown", 1, meta1)
+	if _, _, ok := c.Get("urn:uuid:unknown"); ok {
+		t.Errorf("Put: unknown device must be ignored")
+	}
+}
@@ -64,6 +64,13 @@ func msgFromXML(root xmldoc.Element) (m Msg, err error) {
 		return
 	}
 
+	// Devices often send faults with the Action of the expected
+	// response or with some service-specific Action. So faults
+	// are recognized by the Body contents, regardless of Action.
+	if _, isFault := body.Elem.ChildByName(NsSOAP + ":Fault"); isFault {
+		hdr.Elem = msgFaultHeader(hdr.Elem)
+	}
+
 	// Decode message header
 	m.Header, err = DecodeHeader(hdr.Elem)
 	if err != nil {
@@ -102,6 +109,22 @@ func msgFromXML(root xmldoc.Element) (m Msg, err error) {
 		m.Body, err = DecodeGet(elem)
 	case ActGetResponse:
 		m.Body, err = DecodeMetadata(elem)
+	case ActSubscribe:
+		m.Body, err = DecodeSubscribe(elem)
+	case ActSubscribeResponse:
+		m.Body, err = DecodeSubscribeResponse(elem)
+	case ActRenew:
+		m.Body, err = DecodeRenew(elem)
+	case ActRenewResponse:
+		m.Body, err = DecodeRenewResponse(elem)
+	case ActUnsubscribe:
+		m.Body, err = DecodeUnsubscribe(elem)
+	case ActUnsubscribeResponse:
+		m.Body, err = DecodeUnsubscribeResponse(elem)
+	case ActSubscriptionEnd:
+		m.Body, err = DecodeSubscriptionEnd(elem)
+	case ActFault:
+		m.Body, err = DecodeFault(elem)
 	default:
 		err = fmt.Errorf("%s: unhanded action ", m.Header.Action)
 		return
@@ -110,6 +133,18 @@ func msgFromXML(root xmldoc.Element) (m Msg, err error) {
 	return
 }
 
+// msgFaultHeader returns copy of the message header with Action
+// replaced with the [ActFault].
+func msgFaultHeader(hdr xmldoc.Element) xmldoc.Element {
+	hdr.Children = generic.CopySlice(hdr.Children)
+	for i := range hdr.Children {
+		if hdr.Children[i].Name == NsAddressing+":Action" {
+			hdr.Children[i].Text = ActFault.Encode()
+		}
+	}
+	return hdr
+}
+
 // Encode encodes [Msg] into its wire representation.
 func (m Msg) Encode() []byte {
 	buf := bytes.Buffer{}
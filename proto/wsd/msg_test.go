@@ -0,0 +1,224 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WSD Message test
+
+package wsd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// TestMsg tests wire encoding and decoding of messages of all types
+func TestMsg(t *testing.T) {
+	epr := EndpointReference{
+		Address: "urn:uuid:37f86d35-e6ac-4241-964f-1d9ae46fb366",
+	}
+
+	ann := Announce{
+		EndpointReference: epr,
+		Types:             Types{Device, PrinterServiceType},
+		XAddrs:            XAddrs{"http://192.168.1.102:5358/"},
+		MetadataVersion:   1,
+	}
+
+	meta := Metadata{
+		ThisDevice: ThisDeviceMetadata{
+			FriendlyName:    LocalizedStringList{{String: "FP-0001"}},
+			FirmwareVersion: "0.0.1",
+			SerialNumber:    "FP-8322017",
+		},
+		ThisModel: ThisModelMetadata{
+			Manufacturer: LocalizedStringList{{String: "I.Fyodorov"}},
+			ModelName:    LocalizedStringList{{String: "FP-0001"}},
+			ModelNumber:  "FP-0001",
+		},
+		Relationship: Relationship{
+			Hosted: []ServiceMetadata{
+				{
+					EndpointReference: []EndpointReference{
+						{Address: "http://192.168.1.102:5358/print"},
+					},
+					Types:     Types{PrinterServiceType},
+					ServiceID: "uri:b827bd97-925c-4502-a7db-4918a0abfc11",
+				},
+			},
+		},
+	}
+
+	bodies := []Body{
+		Hello(ann),
+		Bye{EndpointReference: epr},
+		Probe{Types: Types{Device}},
+		ProbeMatches{ProbeMatch: []ProbeMatch{ProbeMatch(ann)}},
+		Resolve{EndpointReference: epr},
+		ResolveMatches{ResolveMatch: []ResolveMatch{ResolveMatch(ann)}},
+		Get{},
+		meta,
+		Fault{
+			Code:    FaultCodeSender,
+			Subcode: FaultActionNotSupported,
+			Reason:  LocalizedString{String: "Unknown action", Lang: "en"},
+		},
+	}
+
+	for _, body := range bodies {
+		msg := Msg{
+			Header: Header{
+				Action:    body.Action(),
+				MessageID: "urn:uuid:0f5d604c-81ac-4abc-8010-51dbffad55f2",
+				To:        optional.New(ToDiscovery),
+				AppSequence: optional.New(AppSequence{
+					InstanceID:    2,
+					MessageNumber: 14,
+				}),
+			},
+			Body: body,
+		}
+
+		msg2, err := DecodeMsg(msg.Encode())
+		if err != nil {
+			t.Errorf("%s: DecodeMsg: %s", body.Action(), err)
+			continue
+		}
+
+		if !reflect.DeepEqual(msg, msg2) {
+			t.Errorf("%s: DecodeMsg:\n"+
+				"expected: %#v\npresent:  %#v\n",
+				body.Action(), msg, msg2)
+		}
+	}
+}
+
+// TestMsgSamples tests decoding of the message samples from
+// the WSD specification.
+//
+// These samples use https: instead of http: in namespace URLs and
+// actions and contain white space around values, so they also test
+// the decoder tolerance.
+func TestMsgSamples(t *testing.T) {
+	epr := EndpointReference{
+		Address: "urn:uuid:37f86d35-e6ac-4241-964f-1d9ae46fb366",
+	}
+
+	seq := optional.New(
+		AnyURI("urn:uuid:369a7d7b-5f87-48a4-aa9a-189edf2a8772"))
+
+	type testData struct {
+		sample string
+		msg    Msg
+	}
+
+	tests := []testData{
+		{
+			sample: sampleHello,
+			msg: Msg{
+				Header: Header{
+					Action:    ActHello,
+					MessageID: "urn:uuid:0f5d604c-81ac-4abc-8010-51dbffad55f2",
+					To:        optional.New(ToDiscovery),
+					AppSequence: optional.New(AppSequence{
+						InstanceID:    2,
+						MessageNumber: 14,
+						SequenceID:    seq,
+					}),
+				},
+				Body: Hello{
+					EndpointReference: epr,
+					Types:             Types{Device},
+					MetadataVersion:   2,
+				},
+			},
+		},
+
+		{
+			sample: sampleBye,
+			msg: Msg{
+				Header: Header{
+					Action:    ActBye,
+					MessageID: "urn:uuid:193ccfa0-347d-41a1-9285-f500b6b96a15",
+					To:        optional.New(ToDiscovery),
+					AppSequence: optional.New(AppSequence{
+						InstanceID:    2,
+						MessageNumber: 21,
+						SequenceID:    seq,
+					}),
+				},
+				Body: Bye{EndpointReference: epr},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		msg, err := DecodeMsg([]byte(test.sample))
+		if err != nil {
+			t.Errorf("%s: DecodeMsg: %s", test.msg.Header.Action, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(msg, test.msg) {
+			t.Errorf("%s: DecodeMsg:\n"+
+				"expected: %#v\npresent:  %#v\n",
+				test.msg.Header.Action, test.msg, msg)
+		}
+	}
+}
+
+// TestMsgDecodeErrors tests DecodeMsg errors
+func TestMsgDecodeErrors(t *testing.T) {
+	type testData struct {
+		data string
+		estr string
+	}
+
+	tests := []testData{
+		{
+			data: `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"/>`,
+			estr: "/s:Envelope/s:Header: missed",
+		},
+
+		{
+			data: `<Envelope/>`,
+			estr: "/Envelope: s:Envelope: missed",
+		},
+
+		{
+			data: `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"` +
+				` xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing">` +
+				`<s:Header>` +
+				`<a:Action>http://example.com/Unknown</a:Action>` +
+				`<a:MessageID>urn:uuid:1</a:MessageID>` +
+				`</s:Header><s:Body/></s:Envelope>`,
+			estr: "/s:Envelope/s:Header/a:Action: unknown action",
+		},
+
+		{
+			data: `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"` +
+				` xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing">` +
+				`<s:Header>` +
+				`<a:Action>http://schemas.xmlsoap.org/ws/2005/04/discovery/Bye</a:Action>` +
+				`<a:MessageID>urn:uuid:1</a:MessageID>` +
+				`</s:Header><s:Body/></s:Envelope>`,
+			estr: "/s:Envelope/s:Body/d:Bye: missed",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeMsg([]byte(test.data))
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if estr != test.estr {
+			t.Errorf("%s\nexpected: %q\npresent:  %q",
+				test.data, test.estr, estr)
+		}
+	}
+}
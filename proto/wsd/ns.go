@@ -17,11 +17,18 @@ const (
 	NsDiscovery  = "d"
 	NsDevprof    = "devprof"
 	NsMex        = "mex"
+	NsEventing   = "e"
 	NsPNPX       = "pnpx"
 	NsScan       = "scan"
 	NsPrint      = "print"
+	NsSecurity   = "wsu"
 )
 
+// nsSecurityUtilityURL is the WS-Security Utility namespace URL.
+// Its Id attribute marks elements, covered by signature.
+const nsSecurityUtilityURL = "http://docs.oasis-open.org/wss/2004/01/" +
+	"oasis-200401-wss-wssecurity-utility-1.0.xsd"
+
 // NsMap maps namespace prefixes to URL
 var NsMap = xmldoc.Namespace{
 	// SOAP 1.2
@@ -35,7 +42,9 @@ var NsMap = xmldoc.Namespace{
 	{Prefix: NsDiscovery, URL: "http://schemas.xmlsoap.org/ws/2005/04/discovery"},
 	{Prefix: NsDevprof, URL: "http://schemas.xmlsoap.org/ws/2006/02/devprof"},
 	{Prefix: NsMex, URL: "http://schemas.xmlsoap.org/ws/2004/09/mex"},
+	{Prefix: NsEventing, URL: "http://schemas.xmlsoap.org/ws/2004/08/eventing"},
 	{Prefix: NsPNPX, URL: "http://schemas.microsoft.com/windows/pnpx/2005/10"},
 	{Prefix: NsScan, URL: "http://schemas.microsoft.com/windows/2006/08/wdp/scan"},
 	{Prefix: NsPrint, URL: "http://schemas.microsoft.com/windows/2006/08/wdp/print"},
+	{Prefix: NsSecurity, URL: nsSecurityUtilityURL},
 }
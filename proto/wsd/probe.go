@@ -9,6 +9,8 @@
 package wsd
 
 import (
+	"strings"
+
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
@@ -18,8 +20,14 @@ import (
 // 239.255.255.250:3702 or [ff02::c]:3702 in order to solicit
 // devices, that match the [Probe.Types] to respond with the
 // [ProbeMatches] message.
+//
+// If Scopes are specified, only devices with matching scopes
+// should respond. MatchBy is the scope matching rule, "" means
+// the default rule (see [Scopes.Match]).
 type Probe struct {
-	Types Types // Device types sender searched for
+	Types   Types  // Device types sender searched for
+	Scopes  Scopes // Scopes sender searched for
+	MatchBy string // Scopes matching rule
 }
 
 // DecodeProbe decodes [Probe] from the XML tree
@@ -28,8 +36,9 @@ func DecodeProbe(root xmldoc.Element) (probe Probe, err error) {
 
 	// Lookup message elements
 	types := xmldoc.Lookup{Name: NsDiscovery + ":Types", Required: true}
+	scopes := xmldoc.Lookup{Name: NsDiscovery + ":Scopes"}
 
-	missed := root.Lookup(&types)
+	missed := root.Lookup(&types, &scopes)
 	if missed != nil {
 		err = xmldoc.XMLErrMissed(missed.Name)
 		return
@@ -38,6 +47,13 @@ func DecodeProbe(root xmldoc.Element) (probe Probe, err error) {
 	// Decode elements
 	probe.Types, err = DecodeTypes(types.Elem)
 
+	if err == nil && scopes.Found {
+		probe.Scopes, err = DecodeScopes(scopes.Elem)
+		if attr, ok := scopes.Elem.AttrByName("MatchBy"); ok {
+			probe.MatchBy = strings.TrimSpace(attr.Value)
+		}
+	}
+
 	return
 }
 
@@ -53,6 +69,16 @@ func (probe Probe) ToXML() xmldoc.Element {
 		Children: []xmldoc.Element{probe.Types.ToXML()},
 	}
 
+	if len(probe.Scopes) != 0 {
+		scopes := probe.Scopes.ToXML()
+		if probe.MatchBy != "" {
+			scopes.Attrs = []xmldoc.Attr{
+				{Name: "MatchBy", Value: probe.MatchBy},
+			}
+		}
+		elm.Children = append(elm.Children, scopes)
+	}
+
 	return elm
 }
 
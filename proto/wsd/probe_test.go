@@ -42,6 +42,59 @@ func TestProbe(t *testing.T) {
 
 			nsused: "devprof",
 		},
+
+		{
+			probe: Probe{
+				Types:  []Type{Device},
+				Scopes: Scopes{"http://example.com/office"},
+			},
+
+			xml: xmldoc.Element{
+				Name: NsDiscovery + ":Probe",
+				Children: []xmldoc.Element{
+					{
+						Name: NsDiscovery + ":Types",
+						Text: "devprof:Device",
+					},
+					{
+						Name: NsDiscovery + ":Scopes",
+						Text: "http://example.com/office",
+					},
+				},
+			},
+
+			nsused: "devprof",
+		},
+
+		{
+			probe: Probe{
+				Types:   []Type{Device},
+				Scopes:  Scopes{"http://example.com/office"},
+				MatchBy: MatchByStrcmp0,
+			},
+
+			xml: xmldoc.Element{
+				Name: NsDiscovery + ":Probe",
+				Children: []xmldoc.Element{
+					{
+						Name: NsDiscovery + ":Types",
+						Text: "devprof:Device",
+					},
+					{
+						Name: NsDiscovery + ":Scopes",
+						Text: "http://example.com/office",
+						Attrs: []xmldoc.Attr{
+							{
+								Name:  "MatchBy",
+								Value: MatchByStrcmp0,
+							},
+						},
+					},
+				},
+			},
+
+			nsused: "devprof",
+		},
 	}
 
 	for _, test := range tests {
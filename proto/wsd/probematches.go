@@ -28,6 +28,7 @@ type ProbeMatches struct {
 type ProbeMatch struct {
 	EndpointReference EndpointReference // Stable identifier of the device
 	Types             Types             // Device types
+	Scopes            Scopes            // Device scopes
 	XAddrs            XAddrs            // Transport addresses (URLs)
 	MetadataVersion   uint64            // Incremented when metadata changes
 }
@@ -0,0 +1,103 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Eventing Renew and RenewResponse message bodies
+
+package wsd
+
+import (
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// Renew represents a WS-Eventing Renew message.
+//
+// This message is sent using HTTP POST to the subscription manager,
+// returned by the [SubscribeResponse], to extend the subscription.
+// The subscription identifier is sent in the message [Header].
+type Renew struct {
+	Expires time.Duration // Requested duration, 0 if none
+}
+
+// RenewResponse represents a WS-Eventing RenewResponse message.
+type RenewResponse struct {
+	Expires time.Duration // Granted duration, 0 if none
+}
+
+// DecodeRenew decodes [Renew] from the XML tree
+func DecodeRenew(root xmldoc.Element) (renew Renew, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	if expires, ok := root.ChildByName(NsEventing + ":Expires"); ok {
+		renew.Expires, err = DecodeDuration(expires)
+	}
+
+	return
+}
+
+// Action returns [Action] to be used with the [Renew] message
+func (Renew) Action() Action {
+	return ActRenew
+}
+
+// ToXML generates XML tree for the message body
+func (renew Renew) ToXML() xmldoc.Element {
+	return xmldoc.WithChildren(NsEventing+":Renew",
+		renewExpiresToXML(renew.Expires)...)
+}
+
+// MarkUsedNamespace marks [xmldoc.Namespace] entries used by
+// data elements within the message body, if any.
+//
+// This function should not care about Namespace entries, used
+// by XML tags: they are handled automatically.
+func (renew Renew) MarkUsedNamespace(ns xmldoc.Namespace) {
+	// Nothing to mark for Renew
+}
+
+// DecodeRenewResponse decodes [RenewResponse] from the XML tree
+func DecodeRenewResponse(root xmldoc.Element) (rsp RenewResponse, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	if expires, ok := root.ChildByName(NsEventing + ":Expires"); ok {
+		rsp.Expires, err = DecodeDuration(expires)
+	}
+
+	return
+}
+
+// Action returns [Action] to be used with the [RenewResponse] message
+func (RenewResponse) Action() Action {
+	return ActRenewResponse
+}
+
+// ToXML generates XML tree for the message body
+func (rsp RenewResponse) ToXML() xmldoc.Element {
+	return xmldoc.WithChildren(NsEventing+":RenewResponse",
+		renewExpiresToXML(rsp.Expires)...)
+}
+
+// MarkUsedNamespace marks [xmldoc.Namespace] entries used by
+// data elements within the message body, if any.
+//
+// This function should not care about Namespace entries, used
+// by XML tags: they are handled automatically.
+func (rsp RenewResponse) MarkUsedNamespace(ns xmldoc.Namespace) {
+	// Nothing to mark for RenewResponse
+}
+
+// renewExpiresToXML returns the optional Expires element
+// of the Renew and RenewResponse messages.
+func renewExpiresToXML(expires time.Duration) []xmldoc.Element {
+	if expires <= 0 {
+		return nil
+	}
+
+	return []xmldoc.Element{
+		xmldoc.WithText(NsEventing+":Expires", EncodeDuration(expires)),
+	}
+}
@@ -0,0 +1,97 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Renew and RenewResponse test
+
+package wsd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestRenew tests Renew and RenewResponse encoding and decoding
+func TestRenew(t *testing.T) {
+	type testData struct {
+		body Body
+		xml  xmldoc.Element
+	}
+
+	tests := []testData{
+		{
+			body: Renew{},
+			xml:  xmldoc.WithChildren(NsEventing + ":Renew"),
+		},
+
+		{
+			body: Renew{Expires: time.Hour},
+			xml: xmldoc.WithChildren(NsEventing+":Renew",
+				xmldoc.WithText(NsEventing+":Expires", "PT1H")),
+		},
+
+		{
+			body: RenewResponse{},
+			xml:  xmldoc.WithChildren(NsEventing + ":RenewResponse"),
+		},
+
+		{
+			body: RenewResponse{Expires: 10 * time.Minute},
+			xml: xmldoc.WithChildren(NsEventing+":RenewResponse",
+				xmldoc.WithText(NsEventing+":Expires", "PT10M")),
+		},
+	}
+
+	for _, test := range tests {
+		xml := test.body.ToXML()
+		if !reflect.DeepEqual(xml, test.xml) {
+			t.Errorf("ToXML:\nexpected: %s\npresent:  %s\n",
+				test.xml.EncodeString(NsMap),
+				xml.EncodeString(NsMap))
+		}
+
+		var body Body
+		var err error
+
+		switch test.body.(type) {
+		case Renew:
+			body, err = DecodeRenew(xml)
+		case RenewResponse:
+			body, err = DecodeRenewResponse(xml)
+		}
+
+		if err != nil {
+			t.Errorf("Decode: %s", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(body, test.body) {
+			t.Errorf("Decode:\n"+
+				"expected: %#v\npresent:  %#v\n",
+				test.body, body)
+		}
+	}
+}
+
+// TestRenewDecodeErrors tests Renew decode errors
+func TestRenewDecodeErrors(t *testing.T) {
+	xml := xmldoc.WithChildren(NsEventing+":Renew",
+		xmldoc.WithText(NsEventing+":Expires", "PT1X"))
+
+	_, err := DecodeRenew(xml)
+	estr := ""
+	if err != nil {
+		estr = err.Error()
+	}
+
+	expected := `/e:Renew/e:Expires: invalid duration: "PT1X"`
+	if estr != expected {
+		t.Errorf("DecodeRenew:\nexpected: %q\npresent:  %q",
+			expected, estr)
+	}
+}
@@ -0,0 +1,427 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WSD responder (device side of the WS-Discovery)
+
+package wsd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// Responder parameters:
+const (
+	// responderMaxMsgSize is the maximum size of the received
+	// message, both UDP and HTTP.
+	responderMaxMsgSize = 65536
+)
+
+// WS-Discovery multicast groups
+var (
+	responderGroup4 = netip.MustParseAddrPort("239.255.255.250:3702")
+	responderGroup6 = netip.MustParseAddrPort("[ff02::c]:3702")
+)
+
+// ResponderOptions defines the device, announced by the [Responder].
+type ResponderOptions struct {
+	// EndpointReference is the stable identifier of the device,
+	// typically urn:uuid:...
+	EndpointReference EndpointReference
+
+	// Types and Scopes of the device. Probes are matched against
+	// them.
+	Types  Types
+	Scopes Scopes
+
+	// XAddrs are the URLs where device metadata is served,
+	// typically by the [Responder.ServeHTTP].
+	XAddrs XAddrs
+
+	// MetadataVersion must be incremented when Metadata changes.
+	MetadataVersion uint64
+
+	// Metadata is returned in response to the Get request.
+	Metadata Metadata
+
+	// Interface, if not nil, restricts the Responder to the
+	// particular network interface. Otherwise, the system-default
+	// interface is used for IPv4 and IPv6 is not used, as IPv6
+	// link-local multicasts require the explicit interface.
+	Interface *net.Interface
+
+	// Signer, if not nil, signs the multicast and unicast UDP
+	// messages, using the compact signature format.
+	Signer *Signer
+}
+
+// Responder implements the device side of the WS-Discovery.
+//
+// It multicasts Hello on startup and Bye on shutdown, answers
+// Probe and Resolve requests, received via UDP multicast, and
+// serves the Get requests for device metadata over HTTP
+// (it implements the [http.Handler] interface for that purpose).
+//
+// UDP messages are retransmitted and duplicates of the received
+// messages are dropped, as required by SOAP-over-UDP (see
+// [UDPRetransmitDelays] and [DupFilter]).
+//
+// Responder makes the emulated device discoverable by the WSD
+// clients.
+type Responder struct {
+	options ResponderOptions // Responder options
+	seq     *AppSequencer    // Generates AppSequence
+	conns   []responderConn  // Multicast connections
+	dups    *DupFilter       // Filters duplicate requests
+	done    chan struct{}    // Closed by Responder.Close
+	wait    sync.WaitGroup   // Waits for goroutines termination
+}
+
+// responderConn is the multicast connection of the Responder.
+type responderConn struct {
+	*net.UDPConn              // Underlying connection
+	group        *net.UDPAddr // Multicast group to send to
+}
+
+// NewResponder creates a new [Responder].
+//
+// The Responder doesn't touch the network until [Responder.Start]
+// is called, but [Responder.ServeHTTP] is usable immediately.
+func NewResponder(options ResponderOptions) *Responder {
+	return &Responder{
+		options: options,
+		seq:     NewAppSequencer(),
+		dups:    NewDupFilter(0),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start opens the multicast connections, sends Hello and starts
+// answering Probe and Resolve requests.
+//
+// It returns after Hello retransmissions are completed.
+//
+// The ctx is used for logging only.
+func (r *Responder) Start(ctx context.Context) error {
+	ifi := r.options.Interface
+
+	groups := []netip.AddrPort{responderGroup4}
+	if ifi != nil {
+		groups = append(groups, responderGroup6)
+	}
+
+	for _, group := range groups {
+		network := "udp4"
+		addr := &net.UDPAddr{
+			IP:   net.IP(group.Addr().AsSlice()),
+			Port: int(group.Port()),
+		}
+
+		if group.Addr().Is6() {
+			network = "udp6"
+			addr.Zone = ifi.Name
+		}
+
+		conn, err := net.ListenMulticastUDP(network, ifi, addr)
+		if err != nil {
+			r.closeConns()
+			return err
+		}
+
+		r.conns = append(r.conns, responderConn{conn, addr})
+	}
+
+	r.multicast(ctx, r.Hello())
+
+	for _, conn := range r.conns {
+		r.wait.Add(1)
+		go r.receive(ctx, conn)
+	}
+
+	return nil
+}
+
+// Close sends Bye and closes the Responder.
+//
+// Pending responses to the received requests are dropped.
+func (r *Responder) Close(ctx context.Context) {
+	r.multicast(ctx, r.Bye())
+
+	close(r.done)
+	r.closeConns()
+	r.wait.Wait()
+}
+
+// closeConns closes all multicast connections.
+func (r *Responder) closeConns() {
+	for _, conn := range r.conns {
+		conn.Close()
+	}
+}
+
+// Hello returns the Hello message, announcing the device.
+func (r *Responder) Hello() Msg {
+	return Msg{
+		Header: NewAnnounceHeader(ActHello, r.seq.Next()),
+		Body:   Hello(r.announce()),
+	}
+}
+
+// Bye returns the Bye message, sent when device leaves the network.
+func (r *Responder) Bye() Msg {
+	return Msg{
+		Header: NewAnnounceHeader(ActBye, r.seq.Next()),
+		Body:   Bye{EndpointReference: r.options.EndpointReference},
+	}
+}
+
+// Handle handles the received message and returns the response.
+//
+// It answers Probe, Resolve and Get messages. The second returned
+// value is false, if message doesn't require the response (i.e.,
+// Probe that doesn't match this device, or unsupported message).
+func (r *Responder) Handle(in Msg) (Msg, bool) {
+	var out Msg
+
+	switch body := in.Body.(type) {
+	case Probe:
+		if !r.options.Types.ContainsAll(body.Types) ||
+			!r.options.Scopes.Match(body.Scopes, body.MatchBy) {
+			return Msg{}, false
+		}
+
+		out.Header = r.matchesHeader(ActProbeMatches, in.Header)
+		out.Body = ProbeMatches{
+			ProbeMatch: []ProbeMatch{ProbeMatch(r.announce())},
+		}
+
+	case Resolve:
+		if body.EndpointReference.Address !=
+			r.options.EndpointReference.Address {
+			return Msg{}, false
+		}
+
+		out.Header = r.matchesHeader(ActResolveMatches, in.Header)
+		out.Body = ResolveMatches{
+			ResolveMatch: []ResolveMatch{ResolveMatch(r.announce())},
+		}
+
+	case Get:
+		out.Header = NewResponseHeader(ActGetResponse, in.Header)
+		out.Body = r.options.Metadata
+
+	default:
+		return Msg{}, false
+	}
+
+	return out, true
+}
+
+// ServeHTTP implements the [http.Handler] interface.
+//
+// It answers the Get requests for device metadata and the
+// directed (unicast) Probe requests.
+func (r *Responder) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	ctx := rq.Context()
+
+	if rq.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(rq.Body, responderMaxMsgSize+1))
+	if err == nil && len(data) > responderMaxMsgSize {
+		err = errors.New("message too large")
+	}
+
+	var in Msg
+	if err == nil {
+		in, err = DecodeMsg(data)
+	}
+
+	if err != nil {
+		log.Debug(ctx, "WSD: HTTP: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Debug(ctx, "WSD: HTTP: %s received", in.Header.Action)
+
+	status := http.StatusOK
+	out, ok := r.Handle(in)
+	if !ok {
+		status = http.StatusBadRequest
+		out = Msg{
+			Header: NewResponseHeader(ActFault, in.Header),
+			Body: Fault{
+				Code:    FaultCodeSender,
+				Subcode: FaultActionNotSupported,
+				Reason: LocalizedString{
+					String: "Unsupported request",
+					Lang:   "en",
+				},
+			},
+		}
+	}
+
+	w.Header().Set("Content-Type", SOAPContentType)
+	w.WriteHeader(status)
+	w.Write(out.Encode())
+}
+
+// receive receives and handles messages from the multicast
+// connection until it is closed.
+func (r *Responder) receive(ctx context.Context, conn responderConn) {
+	defer r.wait.Done()
+
+	buf := make([]byte, responderMaxMsgSize)
+	for {
+		n, from, err := conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Error(ctx, "WSD: UDP: %s", err)
+			}
+			return
+		}
+
+		in, err := DecodeMsg(buf[:n])
+		if err != nil {
+			log.Debug(ctx, "WSD: UDP %s: %s", from, err)
+			continue
+		}
+
+		if r.dups.Duplicate(in.Header.MessageID) {
+			continue
+		}
+
+		out, ok := r.Handle(in)
+		if ok {
+			log.Debug(ctx, "WSD: UDP %s: %s -> %s", from,
+				in.Header.Action, out.Header.Action)
+
+			r.wait.Add(1)
+			go r.respond(ctx, conn, out,
+				net.UDPAddrFromAddrPort(from))
+		}
+	}
+}
+
+// respond sends the response to the multicast request after
+// the random delay, and then retransmits it.
+func (r *Responder) respond(ctx context.Context, conn responderConn,
+	msg Msg, to *net.UDPAddr) {
+
+	defer r.wait.Done()
+
+	if !r.sleep(AppDelay()) {
+		return
+	}
+
+	data, err := r.encode(msg)
+	if err != nil {
+		log.Error(ctx, "WSD: UDP %s: %s: %s", to, msg.Header.Action, err)
+		return
+	}
+
+	r.send(ctx, conn, msg.Header.Action, data, to)
+
+	for _, delay := range UDPRetransmitDelays(UnicastUDPRepeat) {
+		if !r.sleep(delay) {
+			return
+		}
+		r.send(ctx, conn, msg.Header.Action, data, to)
+	}
+}
+
+// multicast sends the message to all multicast groups and then
+// retransmits it.
+func (r *Responder) multicast(ctx context.Context, msg Msg) {
+	if len(r.conns) == 0 {
+		return
+	}
+
+	data, err := r.encode(msg)
+	if err != nil {
+		log.Error(ctx, "WSD: UDP: %s: %s", msg.Header.Action, err)
+		return
+	}
+
+	for _, conn := range r.conns {
+		r.send(ctx, conn, msg.Header.Action, data, conn.group)
+	}
+
+	for _, delay := range UDPRetransmitDelays(MulticastUDPRepeat) {
+		if !r.sleep(delay) {
+			return
+		}
+
+		for _, conn := range r.conns {
+			r.send(ctx, conn, msg.Header.Action, data, conn.group)
+		}
+	}
+}
+
+// encode encodes the UDP message, signing it if Signer is set.
+func (r *Responder) encode(msg Msg) ([]byte, error) {
+	if r.options.Signer != nil {
+		return msg.EncodeSigned(r.options.Signer)
+	}
+	return msg.Encode(), nil
+}
+
+// send sends the message via the multicast connection.
+func (r *Responder) send(ctx context.Context, conn responderConn,
+	act Action, data []byte, to *net.UDPAddr) {
+
+	_, err := conn.WriteToUDP(data, to)
+	if err != nil {
+		log.Error(ctx, "WSD: UDP %s: %s: %s", to, act, err)
+	}
+}
+
+// sleep pauses for the specified time. It returns false, if
+// Responder was closed meanwhile.
+func (r *Responder) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// matchesHeader returns the message header for the ProbeMatches
+// or ResolveMatches response to the request with the header rq.
+// Unlike other responses, these messages carry AppSequence.
+func (r *Responder) matchesHeader(act Action, rq Header) Header {
+	hdr := NewResponseHeader(act, rq)
+	hdr.AppSequence = optional.New(r.seq.Next())
+	return hdr
+}
+
+// announce returns the device's Announce.
+func (r *Responder) announce() Announce {
+	return Announce{
+		EndpointReference: r.options.EndpointReference,
+		Types:             r.options.Types,
+		Scopes:            r.options.Scopes,
+		XAddrs:            r.options.XAddrs,
+		MetadataVersion:   r.options.MetadataVersion,
+	}
+}
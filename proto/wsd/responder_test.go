@@ -0,0 +1,253 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WSD responder test
+
+package wsd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// testResponderOptions are the ResponderOptions, used by tests
+var testResponderOptions = ResponderOptions{
+	EndpointReference: EndpointReference{
+		Address: "urn:uuid:6a3e8b6e-5a5e-4a8c-9f3a-0c3b4f2e1d7a",
+	},
+	Types:           Types{Device, ScannerServiceType},
+	Scopes:          Scopes{"http://example.com/office"},
+	XAddrs:          XAddrs{"http://127.0.0.1:8080/wsd"},
+	MetadataVersion: 3,
+	Metadata: Metadata{
+		ThisDevice: ThisDeviceMetadata{
+			FriendlyName: LocalizedStringList{
+				{String: "Test Scanner"},
+			},
+		},
+		ThisModel: ThisModelMetadata{
+			Manufacturer: LocalizedStringList{{String: "MFP"}},
+			ModelName:    LocalizedStringList{{String: "Test"}},
+		},
+	},
+}
+
+// TestResponderAnnounce tests Responder.Hello and Responder.Bye
+func TestResponderAnnounce(t *testing.T) {
+	r := NewResponder(testResponderOptions)
+
+	hello := r.Hello()
+	bye := r.Bye()
+
+	if hello.Header.Action != ActHello {
+		t.Errorf("Hello: bad action %s", hello.Header.Action)
+	}
+
+	if optional.Get(hello.Header.To) != ToDiscovery {
+		t.Errorf("Hello: bad To %q", optional.Get(hello.Header.To))
+	}
+
+	expected := Hello{
+		EndpointReference: testResponderOptions.EndpointReference,
+		Types:             testResponderOptions.Types,
+		Scopes:            testResponderOptions.Scopes,
+		XAddrs:            testResponderOptions.XAddrs,
+		MetadataVersion:   testResponderOptions.MetadataVersion,
+	}
+
+	if !reflect.DeepEqual(hello.Body, expected) {
+		t.Errorf("Hello:\nexpected: %#v\npresent:  %#v",
+			expected, hello.Body)
+	}
+
+	if bye.Body != (Bye{testResponderOptions.EndpointReference}) {
+		t.Errorf("Bye: bad body %#v", bye.Body)
+	}
+
+	// Both messages must survive the encoding
+	for _, msg := range []Msg{hello, bye} {
+		_, err := DecodeMsg(msg.Encode())
+		if err != nil {
+			t.Errorf("%s: %s", msg.Header.Action, err)
+		}
+	}
+
+	// AppSequence must be consistent
+	seq1 := optional.Get(hello.Header.AppSequence)
+	seq2 := optional.Get(bye.Header.AppSequence)
+
+	if seq1.InstanceID != seq2.InstanceID {
+		t.Errorf("AppSequence: InstanceID changed: %d->%d",
+			seq1.InstanceID, seq2.InstanceID)
+	}
+
+	if seq1.MessageNumber >= seq2.MessageNumber {
+		t.Errorf("AppSequence: MessageNumber not increasing: %d->%d",
+			seq1.MessageNumber, seq2.MessageNumber)
+	}
+}
+
+// TestResponderHandle tests Responder.Handle
+func TestResponderHandle(t *testing.T) {
+	type testData struct {
+		body   Body   // Request body
+		action Action // Expected response action or ActUnknown
+	}
+
+	tests := []testData{
+		// Probe, matching
+		{Probe{}, ActProbeMatches},
+		{Probe{Types: Types{ScannerServiceType}}, ActProbeMatches},
+		{
+			Probe{
+				Types:  Types{Device},
+				Scopes: Scopes{"http://example.com/office"},
+			},
+			ActProbeMatches,
+		},
+
+		// Probe, not matching
+		{Probe{Types: Types{PrinterServiceType}}, ActUnknown},
+		{
+			Probe{
+				Types:  Types{Device},
+				Scopes: Scopes{"http://example.com/lab"},
+			},
+			ActUnknown,
+		},
+
+		// Resolve
+		{
+			Resolve{testResponderOptions.EndpointReference},
+			ActResolveMatches,
+		},
+		{
+			Resolve{EndpointReference{Address: "urn:uuid:other"}},
+			ActUnknown,
+		},
+
+		// Get
+		{Get{}, ActGetResponse},
+
+		// Not a request
+		{Bye{testResponderOptions.EndpointReference}, ActUnknown},
+	}
+
+	r := NewResponder(testResponderOptions)
+
+	for _, test := range tests {
+		in := Msg{
+			Header: Header{
+				Action:    test.body.Action(),
+				MessageID: "urn:uuid:3f4c3a40-5d5b-4b2c-8fd4-6f3c3ad2a1b9",
+				To:        optional.New(ToDiscovery),
+			},
+			Body: test.body,
+		}
+
+		out, ok := r.Handle(in)
+
+		switch {
+		case test.action == ActUnknown && ok:
+			t.Errorf("%s: unexpected response %s",
+				in.Header.Action, out.Header.Action)
+			continue
+
+		case test.action == ActUnknown:
+			continue
+
+		case !ok:
+			t.Errorf("%s: no response", in.Header.Action)
+			continue
+
+		case out.Header.Action != test.action:
+			t.Errorf("%s: expected %s, present %s",
+				in.Header.Action, test.action,
+				out.Header.Action)
+			continue
+		}
+
+		if optional.Get(out.Header.RelatesTo) != in.Header.MessageID {
+			t.Errorf("%s: bad RelatesTo %q", in.Header.Action,
+				optional.Get(out.Header.RelatesTo))
+		}
+
+		// Response must survive the encoding
+		decoded, err := DecodeMsg(out.Encode())
+		if err != nil {
+			t.Errorf("%s: %s", out.Header.Action, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(decoded.Body, out.Body) {
+			t.Errorf("%s:\nexpected: %#v\npresent:  %#v",
+				out.Header.Action, out.Body, decoded.Body)
+		}
+	}
+}
+
+// TestResponderHTTP tests Responder.ServeHTTP
+func TestResponderHTTP(t *testing.T) {
+	r := NewResponder(testResponderOptions)
+
+	get := Msg{
+		Header: Header{
+			Action:    ActGet,
+			MessageID: "urn:uuid:0d5b1c0e-4c36-4e4b-9a9c-3f6e0b5d2c11",
+			To:        optional.New(testResponderOptions.EndpointReference.Address),
+		},
+		Body: Get{},
+	}
+
+	type testData struct {
+		method string
+		body   []byte
+		status int
+	}
+
+	tests := []testData{
+		{"POST", get.Encode(), http.StatusOK},
+		{"GET", nil, http.StatusMethodNotAllowed},
+		{"POST", []byte("garbage"), http.StatusBadRequest},
+		{"POST", r.Hello().Encode(), http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		rq := httptest.NewRequest(test.method, "/wsd",
+			bytes.NewReader(test.body))
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, rq)
+
+		if w.Code != test.status {
+			t.Errorf("%s: HTTP status expected %d, present %d",
+				test.method, test.status, w.Code)
+			continue
+		}
+
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		data, _ := io.ReadAll(w.Body)
+		msg, err := DecodeMsg(data)
+		if err != nil {
+			t.Errorf("%s: %s", test.method, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(msg.Body, testResponderOptions.Metadata) {
+			t.Errorf("Metadata:\nexpected: %#v\npresent:  %#v",
+				testResponderOptions.Metadata, msg.Body)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Discovery scopes
+
+package wsd
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// Scopes represents a list of scopes (URIs), for discovery.
+//
+// Scopes allow to narrow the discovery: device matches the
+// [Probe], if each of the Probe's scopes matches some of the
+// device's scopes, according to the matching rule of the Probe.
+type Scopes []AnyURI
+
+// Scope matching rules (MatchBy values):
+//
+// WS-Discovery 2005/04, used by WSD, calls the default rule
+// MatchByRFC2396. It is the same as MatchByRFC3986 of the
+// later WS-Discovery 1.1, and both are accepted.
+const (
+	MatchByRFC2396 = "http://schemas.xmlsoap.org/ws/2005/04/discovery/rfc2396"
+	MatchByRFC3986 = "http://docs.oasis-open.org/ws-dd/ns/discovery/2009/01/rfc3986"
+	MatchByUUID    = "http://schemas.xmlsoap.org/ws/2005/04/discovery/uuid"
+	MatchByLDAP    = "http://schemas.xmlsoap.org/ws/2005/04/discovery/ldap"
+	MatchByStrcmp0 = "http://schemas.xmlsoap.org/ws/2005/04/discovery/strcmp0"
+)
+
+// DecodeScopes decodes [Scopes] from the XML tree
+func DecodeScopes(root xmldoc.Element) (scopes Scopes, err error) {
+	for _, s := range strings.Fields(root.Text) {
+		scopes = append(scopes, AnyURI(s))
+	}
+	return
+}
+
+// ToXML generates XML tree for the Scopes.
+func (scopes Scopes) ToXML() xmldoc.Element {
+	return xmldoc.Element{
+		Name: NsDiscovery + ":Scopes",
+		Text: scopes.String(),
+	}
+}
+
+// String returns text representation for [Scopes].
+func (scopes Scopes) String() string {
+	ss := make([]string, len(scopes))
+	for i, s := range scopes {
+		ss[i] = string(s)
+	}
+	return strings.Join(ss, " ")
+}
+
+// Match reports if scopes, requested by the [Probe], match
+// the device's scopes.
+//
+// Each requested scope must match some of the device's scopes.
+// Empty list of requested scopes matches any device. matchBy is
+// the matching rule of the Probe ("" means the default rule,
+// [MatchByRFC2396]). Unknown rule never matches.
+//
+// Matching rules are defined in WS-Discovery, 5.1:
+//   - [MatchByRFC2396] and [MatchByRFC3986]: scheme and authority
+//     are equal, ignoring case, and path of the requested scope is
+//     a segment-wise prefix of the device's scope path. Query and
+//     fragment are not compared.
+//   - [MatchByUUID]: both are uuid: URIs of the same UUID.
+//   - [MatchByLDAP]: both are ldap: URIs of the same host, and
+//     the requested distinguished name (DN) is the prefix of the
+//     device's DN in terms of RDNSequence (i.e., the suffix in its
+//     string representation).
+//   - [MatchByStrcmp0]: scopes are literally equal.
+func (scopes Scopes) Match(requested Scopes, matchBy string) bool {
+	var match func(rq, s string) bool
+
+	switch matchBy {
+	case "", MatchByRFC2396, MatchByRFC3986:
+		match = scopeMatchRFC3986
+	case MatchByUUID:
+		match = scopeMatchUUID
+	case MatchByLDAP:
+		match = scopeMatchLDAP
+	case MatchByStrcmp0:
+		match = func(rq, s string) bool { return rq == s }
+	default:
+		return false
+	}
+
+	for _, rq := range requested {
+		found := false
+		for _, s := range scopes {
+			if match(string(rq), string(s)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scopeMatchRFC3986 matches scopes by the RFC 3986 rule.
+func scopeMatchRFC3986(rq, s string) bool {
+	u1, err1 := url.Parse(rq)
+	u2, err2 := url.Parse(s)
+
+	switch {
+	case err1 != nil || err2 != nil:
+		return false
+	case !strings.EqualFold(u1.Scheme, u2.Scheme):
+		return false
+	case !strings.EqualFold(u1.Host, u2.Host):
+		return false
+	case u1.User.String() != u2.User.String():
+		return false
+	case u1.Opaque != "" || u2.Opaque != "":
+		return u1.Opaque == u2.Opaque
+	}
+
+	seg1, ok1 := scopePathSegments(u1.Path)
+	seg2, ok2 := scopePathSegments(u2.Path)
+
+	if !ok1 || !ok2 || len(seg1) > len(seg2) {
+		return false
+	}
+
+	for i := range seg1 {
+		if seg1[i] != seg2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scopePathSegments splits path into segments. It returns false,
+// if path contains the "." or ".." segments.
+func scopePathSegments(path string) ([]string, bool) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, true
+	}
+
+	segs := strings.Split(path, "/")
+	for _, seg := range segs {
+		if seg == "." || seg == ".." {
+			return nil, false
+		}
+	}
+
+	return segs, true
+}
+
+// scopeMatchUUID matches scopes by the UUID rule.
+func scopeMatchUUID(rq, s string) bool {
+	if !scopeHasScheme(rq, "uuid") || !scopeHasScheme(s, "uuid") {
+		return false
+	}
+
+	u1, err1 := uuid.Parse(strings.ToLower(rq))
+	u2, err2 := uuid.Parse(strings.ToLower(s))
+
+	return err1 == nil && err2 == nil && u1 == u2
+}
+
+// scopeMatchLDAP matches scopes by the LDAP rule.
+func scopeMatchLDAP(rq, s string) bool {
+	if !scopeHasScheme(rq, "ldap") || !scopeHasScheme(s, "ldap") {
+		return false
+	}
+
+	u1, err1 := url.Parse(rq)
+	u2, err2 := url.Parse(s)
+
+	if err1 != nil || err2 != nil || !strings.EqualFold(u1.Host, u2.Host) {
+		return false
+	}
+
+	dn1 := scopeLDAPRDNs(strings.TrimPrefix(u1.Path, "/"))
+	dn2 := scopeLDAPRDNs(strings.TrimPrefix(u2.Path, "/"))
+
+	// String representation of the DN lists RDNs in the reverse
+	// order, so the RDNSequence prefix is the string suffix.
+	if len(dn1) > len(dn2) {
+		return false
+	}
+
+	dn2 = dn2[len(dn2)-len(dn1):]
+	for i := range dn1 {
+		if dn1[i] != dn2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scopeLDAPRDNs splits the string representation of the LDAP
+// distinguished name into the normalized RDNs.
+//
+// Attribute types are case-insensitive, so they are converted
+// to lower case. Spaces around separators are ignored.
+func scopeLDAPRDNs(dn string) []string {
+	var rdns []string
+	var rdn strings.Builder
+	var ava strings.Builder
+
+	flushAVA := func() {
+		typ, val, _ := strings.Cut(ava.String(), "=")
+		if rdn.Len() != 0 {
+			rdn.WriteByte('+')
+		}
+		rdn.WriteString(strings.ToLower(strings.TrimSpace(typ)))
+		rdn.WriteByte('=')
+		rdn.WriteString(strings.TrimSpace(val))
+		ava.Reset()
+	}
+
+	flushRDN := func() {
+		flushAVA()
+		rdns = append(rdns, rdn.String())
+		rdn.Reset()
+	}
+
+	if strings.TrimSpace(dn) == "" {
+		return nil
+	}
+
+	for i := 0; i < len(dn); i++ {
+		switch c := dn[i]; {
+		case c == '\\' && i+1 < len(dn):
+			ava.WriteByte(c)
+			ava.WriteByte(dn[i+1])
+			i++
+		case c == ',' || c == ';':
+			flushRDN()
+		case c == '+':
+			flushAVA()
+		default:
+			ava.WriteByte(c)
+		}
+	}
+
+	flushRDN()
+
+	return rdns
+}
+
+// scopeHasScheme reports if scope has the specified URI scheme,
+// ignoring case.
+func scopeHasScheme(scope, scheme string) bool {
+	return len(scope) > len(scheme) &&
+		scope[len(scheme)] == ':' &&
+		strings.EqualFold(scope[:len(scheme)], scheme)
+}
@@ -0,0 +1,168 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scopes test
+
+package wsd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestScopes tests Scopes encoding and decoding
+func TestScopes(t *testing.T) {
+	type testData struct {
+		scopes Scopes
+		xml    xmldoc.Element
+	}
+
+	tests := []testData{
+		{
+			scopes: Scopes{"http://example.com/office"},
+			xml: xmldoc.Element{
+				Name: NsDiscovery + ":Scopes",
+				Text: "http://example.com/office",
+			},
+		},
+
+		{
+			scopes: Scopes{
+				"http://example.com/office",
+				"ldap:///ou=floor1,o=example",
+			},
+			xml: xmldoc.Element{
+				Name: NsDiscovery + ":Scopes",
+				Text: "http://example.com/office ldap:///ou=floor1,o=example",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		xml := test.scopes.ToXML()
+		if !reflect.DeepEqual(xml, test.xml) {
+			t.Errorf("ToXML:\nexpected: %s\npresent:  %s\n",
+				test.xml.EncodeString(NsMap),
+				xml.EncodeString(NsMap))
+		}
+
+		scopes, err := DecodeScopes(xml)
+		if err != nil {
+			t.Errorf("DecodeScopes: %s", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(scopes, test.scopes) {
+			t.Errorf("DecodeScopes:\n"+
+				"expected: %q\npresent:  %q\n",
+				test.scopes, scopes)
+		}
+	}
+}
+
+// TestScopesMatch tests Scopes.Match
+func TestScopesMatch(t *testing.T) {
+	type testData struct {
+		scopes    Scopes
+		requested Scopes
+		matchBy   string
+		match     bool
+	}
+
+	device := Scopes{
+		"http://example.com/office",
+		"http://example.com/lab",
+	}
+
+	tests := []testData{
+		// Common cases
+		{device, nil, "", true},
+		{nil, nil, "", true},
+		{device, Scopes{"http://example.com/lab"}, "", true},
+		{device, Scopes{"http://example.com/lab",
+			"http://example.com/office"}, MatchByRFC3986, true},
+		{device, Scopes{"http://example.com/home"}, "", false},
+		{nil, Scopes{"http://example.com/lab"}, "", false},
+		{device, Scopes{"http://example.com/lab"}, "unknown:rule", false},
+
+		// RFC 3986
+		{device, Scopes{"HTTP://EXAMPLE.COM/lab"}, MatchByRFC2396, true},
+		{device, Scopes{"http://example.com/LAB"}, MatchByRFC2396, false},
+		{device, Scopes{"http://example.com"}, MatchByRFC2396, true},
+		{device, Scopes{"http://example.com/"}, MatchByRFC2396, true},
+		{device, Scopes{"http://example.com/lab/"}, MatchByRFC2396, true},
+		{device, Scopes{"http://example.com/la"}, MatchByRFC2396, false},
+		{device, Scopes{"http://example.org/lab"}, MatchByRFC2396, false},
+		{device, Scopes{"https://example.com/lab"}, MatchByRFC2396, false},
+		{device, Scopes{"http://example.com/lab?q#f"}, MatchByRFC2396, true},
+		{Scopes{"http://example.com/office/floor1/room12"},
+			Scopes{"http://example.com/office/floor1"},
+			MatchByRFC3986, true},
+		{Scopes{"http://example.com/office/floor1"},
+			Scopes{"http://example.com/office/floor1/room12"},
+			MatchByRFC3986, false},
+		{Scopes{"http://example.com/office/floor%31"},
+			Scopes{"http://example.com/office/floor1"},
+			MatchByRFC3986, true},
+		{Scopes{"http://example.com/office/../lab"},
+			Scopes{"http://example.com/office"},
+			MatchByRFC3986, false},
+		{Scopes{"urn:example:office"},
+			Scopes{"urn:example:office"}, "", true},
+		{Scopes{"urn:example:office"},
+			Scopes{"urn:example:lab"}, "", false},
+
+		// UUID
+		{Scopes{"uuid:6a3e8b6e-5a5e-4a8c-9f3a-0c3b4f2e1d7a"},
+			Scopes{"UUID:6A3E8B6E-5A5E-4A8C-9F3A-0C3B4F2E1D7A"},
+			MatchByUUID, true},
+		{Scopes{"uuid:6a3e8b6e-5a5e-4a8c-9f3a-0c3b4f2e1d7a"},
+			Scopes{"uuid:6a3e8b6e-5a5e-4a8c-9f3a-0c3b4f2e1d7b"},
+			MatchByUUID, false},
+		{Scopes{"urn:uuid:6a3e8b6e-5a5e-4a8c-9f3a-0c3b4f2e1d7a"},
+			Scopes{"urn:uuid:6a3e8b6e-5a5e-4a8c-9f3a-0c3b4f2e1d7a"},
+			MatchByUUID, false},
+
+		// LDAP
+		{Scopes{"ldap://ldap.example.com/ou=floor1,o=example"},
+			Scopes{"ldap://ldap.example.com/o=example"},
+			MatchByLDAP, true},
+		{Scopes{"ldap://ldap.example.com/ou=floor1,o=example"},
+			Scopes{"ldap://LDAP.example.com/OU = floor1 , O=example"},
+			MatchByLDAP, true},
+		{Scopes{"ldap://ldap.example.com/ou=floor1,o=example"},
+			Scopes{"ldap://ldap.example.com/ou=floor1"},
+			MatchByLDAP, false},
+		{Scopes{"ldap://ldap.example.com/o=example"},
+			Scopes{"ldap://ldap.example.com/ou=floor1,o=example"},
+			MatchByLDAP, false},
+		{Scopes{"ldap://ldap.example.com/cn=a\\,b,o=example"},
+			Scopes{"ldap://ldap.example.com/b,o=example"},
+			MatchByLDAP, false},
+		{Scopes{"ldap://ldap.example.com/o=example"},
+			Scopes{"ldap://other.example.com/o=example"},
+			MatchByLDAP, false},
+		{Scopes{"http://ldap.example.com/o=example"},
+			Scopes{"http://ldap.example.com/o=example"},
+			MatchByLDAP, false},
+
+		// strcmp0
+		{device, Scopes{"http://example.com/lab"}, MatchByStrcmp0, true},
+		{device, Scopes{"http://example.com/LAB"}, MatchByStrcmp0, false},
+		{device, Scopes{"http://example.com"}, MatchByStrcmp0, false},
+	}
+
+	for _, test := range tests {
+		match := test.scopes.Match(test.requested, test.matchBy)
+		if match != test.match {
+			t.Errorf("%q.Match(%q, %q):\nexpected: %v\npresent:  %v",
+				test.scopes, test.requested, test.matchBy,
+				test.match, match)
+		}
+	}
+}
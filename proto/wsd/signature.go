@@ -0,0 +1,333 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Compact signature of discovery messages
+
+package wsd
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/util/generic"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// SigSchemeRSA is the compact signature scheme: Exclusive XML
+// Canonicalization, SHA-1 digests and RSA signature.
+const SigSchemeRSA = "http://schemas.xmlsoap.org/ws/2005/04/discovery/rsa"
+
+// Algorithms of the synthesized ds:SignedInfo
+const (
+	sigNsDSig     = "http://www.w3.org/2000/09/xmldsig#"
+	sigAlgC14N    = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	sigAlgRSASHA1 = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	sigAlgSHA1    = "http://www.w3.org/2000/09/xmldsig#sha1"
+)
+
+// Signature errors:
+var (
+	ErrSigMissed     = errors.New("WSD: message not signed")
+	ErrSigInvalid    = errors.New("WSD: invalid signature")
+	ErrSigUnknownKey = errors.New("WSD: unknown signing key")
+)
+
+// Signer signs the discovery messages, using the compact signature
+// format (see [Msg.EncodeSigned]).
+type Signer struct {
+	// Key is the RSA private key, typically *rsa.PrivateKey.
+	// Any crypto.Signer with the RSA public key is accepted,
+	// so key may live in the hardware token.
+	Key crypto.Signer
+
+	// KeyID identifies the key for receivers. If nil,
+	// [KeyID] of the Key's public part is used.
+	KeyID []byte
+}
+
+// KeyStore provides public keys for verification of the signed
+// messages (see [DecodeMsgSigned]).
+//
+// PublicKey must return [ErrSigUnknownKey], if key is not known.
+type KeyStore interface {
+	PublicKey(keyid []byte) (*rsa.PublicKey, error)
+}
+
+// StaticKeyStore is the [KeyStore] with the fixed set of keys,
+// indexed by string(keyid).
+type StaticKeyStore map[string]*rsa.PublicKey
+
+// NewStaticKeyStore creates a new [StaticKeyStore] with the specified
+// keys, indexed by their [KeyID].
+func NewStaticKeyStore(keys ...*rsa.PublicKey) StaticKeyStore {
+	ks := make(StaticKeyStore, len(keys))
+	for _, key := range keys {
+		ks[string(KeyID(key))] = key
+	}
+	return ks
+}
+
+// PublicKey returns public key by its ID.
+func (ks StaticKeyStore) PublicKey(keyid []byte) (*rsa.PublicKey, error) {
+	if key := ks[string(keyid)]; key != nil {
+		return key, nil
+	}
+	return nil, ErrSigUnknownKey
+}
+
+// KeyID returns the default key identifier: SHA-1 digest of
+// the DER-encoded public key.
+func KeyID(key *rsa.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		// Must not happen with the valid RSA key
+		panic(err)
+	}
+
+	sum := sha1.Sum(der)
+	return sum[:]
+}
+
+// EncodeSigned encodes [Msg] into its wire representation and signs
+// it, using the compact signature format.
+//
+// All header elements and the message body are covered by signature.
+// The signature itself is carried in the d:Security header element.
+func (m Msg) EncodeSigned(signer *Signer) ([]byte, error) {
+	pub, ok := signer.Key.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("WSD: signing key is not RSA")
+	}
+
+	keyid := signer.KeyID
+	if keyid == nil {
+		keyid = KeyID(pub)
+	}
+
+	// Mark signed elements with wsu:Id
+	root := m.ToXML()
+	hdr := &root.Children[0]
+	body := &root.Children[1]
+
+	var refs []string
+	mark := func(elm *xmldoc.Element, id string) {
+		elm.Attrs = append(generic.CopySlice(elm.Attrs),
+			xmldoc.Attr{Name: NsSecurity + ":Id", Value: id})
+		refs = append(refs, id)
+	}
+
+	for i := range hdr.Children {
+		mark(&hdr.Children[i], fmt.Sprintf("h%d", i))
+	}
+	mark(body, "body")
+
+	// Encode the message and compute the signature
+	ns := generic.CopySlice(NsMap)
+	m.MarkUsedNamespace(ns)
+
+	buf := bytes.Buffer{}
+	root.Encode(&buf, ns)
+
+	elems, err := c14nByID(NsMap, buf.Bytes(), refs)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum(sigSignedInfo(refs, elems))
+	sig, err := signer.Key.Sign(rand.Reader, hash[:], crypto.SHA1)
+	if err != nil {
+		return nil, fmt.Errorf("WSD: %w", err)
+	}
+
+	// Add signature to the header and encode the message again.
+	// Signed elements are not affected by this addition.
+	hdr.Children = append(hdr.Children, xmldoc.Element{
+		Name: NsDiscovery + ":Security",
+		Children: []xmldoc.Element{
+			{
+				Name: NsDiscovery + ":Sig",
+				Attrs: []xmldoc.Attr{
+					{Name: "Scheme", Value: SigSchemeRSA},
+					{Name: "KeyId", Value: base64.StdEncoding.
+						EncodeToString(keyid)},
+					{Name: "Refs", Value: strings.Join(refs, " ")},
+					{Name: "Sig", Value: base64.StdEncoding.
+						EncodeToString(sig)},
+				},
+			},
+		},
+	})
+
+	buf.Reset()
+	root.Encode(&buf, ns)
+
+	return buf.Bytes(), nil
+}
+
+// DecodeMsgSigned decodes [Msg] from the wire representation and
+// verifies its compact signature, using keys from the [KeyStore].
+//
+// Signature must cover the message body and the Action, MessageID
+// and AppSequence (if present) headers, otherwise message is
+// rejected with the [ErrSigInvalid] error. Message without
+// signature is rejected with the [ErrSigMissed] error.
+func DecodeMsgSigned(data []byte, keys KeyStore) (Msg, error) {
+	root, err := xmldoc.Decode(NsMap, bytes.NewReader(data))
+	if err != nil {
+		return Msg{}, err
+	}
+
+	m, err := msgFromXML(root)
+	if err != nil {
+		return Msg{}, err
+	}
+
+	// Lookup the signature
+	hdr, _ := root.ChildByName(NsSOAP + ":Header")
+	sec, _ := hdr.ChildByName(NsDiscovery + ":Security")
+	sigelm, found := sec.ChildByName(NsDiscovery + ":Sig")
+	if !found {
+		return Msg{}, ErrSigMissed
+	}
+
+	scheme := xmldoc.LookupAttr{Name: "Scheme", Required: true}
+	keyidattr := xmldoc.LookupAttr{Name: "KeyId"}
+	refsattr := xmldoc.LookupAttr{Name: "Refs", Required: true}
+	sigattr := xmldoc.LookupAttr{Name: "Sig", Required: true}
+
+	missed := sigelm.LookupAttrs(&scheme, &keyidattr, &refsattr, &sigattr)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return Msg{}, fmt.Errorf("%w: %s", ErrSigInvalid, err)
+	}
+
+	if scheme.Attr.Value != SigSchemeRSA {
+		return Msg{}, fmt.Errorf("%w: %s: unsupported scheme",
+			ErrSigInvalid, scheme.Attr.Value)
+	}
+
+	keyid, err := base64.StdEncoding.DecodeString(keyidattr.Attr.Value)
+	if err != nil {
+		return Msg{}, fmt.Errorf("%w: KeyId: %s", ErrSigInvalid, err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(sigattr.Attr.Value)
+	if err != nil {
+		return Msg{}, fmt.Errorf("%w: Sig: %s", ErrSigInvalid, err)
+	}
+
+	refs := strings.Fields(refsattr.Attr.Value)
+
+	// Canonicalize signed elements and check that all important
+	// parts of the message are covered. Each of these parts must
+	// appear exactly once, otherwise unsigned copy may be decoded
+	// instead of signed one.
+	elems, err := c14nByID(NsMap, data, refs)
+	if err != nil {
+		return Msg{}, fmt.Errorf("%w: %s", ErrSigInvalid, err)
+	}
+
+	required := []string{
+		NsSOAP + ":Body",
+		NsSOAP + ":Header/" + NsAddressing + ":Action",
+		NsSOAP + ":Header/" + NsAddressing + ":MessageID",
+	}
+
+	if m.Header.AppSequence != nil {
+		required = append(required,
+			NsSOAP+":Header/"+NsDiscovery+":AppSequence")
+	}
+
+	covered := make(map[string]struct{}, len(elems))
+	for _, elm := range elems {
+		covered[elm.Path] = struct{}{}
+	}
+
+	for _, path := range required {
+		if sigCount(root, path) != 1 {
+			return Msg{}, fmt.Errorf("%w: %s: must appear once",
+				ErrSigInvalid, path)
+		}
+
+		if _, ok := covered[root.Name+"/"+path]; !ok {
+			return Msg{}, fmt.Errorf("%w: %s: not signed",
+				ErrSigInvalid, path)
+		}
+	}
+
+	// Verify the signature
+	key, err := keys.PublicKey(keyid)
+	if err != nil {
+		return Msg{}, err
+	}
+
+	hash := sha1.Sum(sigSignedInfo(refs, elems))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA1, hash[:], sig)
+	if err != nil {
+		return Msg{}, ErrSigInvalid
+	}
+
+	return m, nil
+}
+
+// sigSignedInfo returns canonical form of the ds:SignedInfo element,
+// synthesized from the signed elements. Signature is computed over
+// this element.
+func sigSignedInfo(refs []string, elems map[string]c14nElement) []byte {
+	buf := bytes.Buffer{}
+
+	buf.WriteString(`<ds:SignedInfo xmlns:ds="` + sigNsDSig + `">`)
+	buf.WriteString(`<ds:CanonicalizationMethod Algorithm="` +
+		sigAlgC14N + `"></ds:CanonicalizationMethod>`)
+	buf.WriteString(`<ds:SignatureMethod Algorithm="` +
+		sigAlgRSASHA1 + `"></ds:SignatureMethod>`)
+
+	for _, id := range refs {
+		digest := sha1.Sum(elems[id].Data)
+
+		buf.WriteString(`<ds:Reference URI="`)
+		c14nEscapeAttr(&buf, "#"+id)
+		buf.WriteString(`">`)
+		buf.WriteString(`<ds:Transforms><ds:Transform Algorithm="` +
+			sigAlgC14N + `"></ds:Transform></ds:Transforms>`)
+		buf.WriteString(`<ds:DigestMethod Algorithm="` +
+			sigAlgSHA1 + `"></ds:DigestMethod>`)
+		buf.WriteString(`<ds:DigestValue>` +
+			base64.StdEncoding.EncodeToString(digest[:]) +
+			`</ds:DigestValue>`)
+		buf.WriteString(`</ds:Reference>`)
+	}
+
+	buf.WriteString(`</ds:SignedInfo>`)
+
+	return buf.Bytes()
+}
+
+// sigCount counts elements with the specified path, relative to root.
+func sigCount(root xmldoc.Element, path string) int {
+	name, rest, nested := strings.Cut(path, "/")
+
+	cnt := 0
+	for _, child := range root.Children {
+		switch {
+		case child.Name != name:
+		case nested:
+			cnt += sigCount(child, rest)
+		default:
+			cnt++
+		}
+	}
+
+	return cnt
+}
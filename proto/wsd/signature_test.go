@@ -0,0 +1,123 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Compact signature test
+
+package wsd
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSignature tests Msg.EncodeSigned and DecodeMsgSigned
+func TestSignature(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %s", err)
+	}
+
+	key2, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %s", err)
+	}
+
+	signer := &Signer{Key: key}
+	keys := NewStaticKeyStore(&key.PublicKey)
+
+	msg := NewResponder(testResponderOptions).Hello()
+	data, err := msg.EncodeSigned(signer)
+	if err != nil {
+		t.Fatalf("EncodeSigned: %s", err)
+	}
+
+	// Valid signature
+	msg2, err := DecodeMsgSigned(data, keys)
+	if err != nil {
+		t.Fatalf("DecodeMsgSigned: %s", err)
+	}
+
+	if !reflect.DeepEqual(msg, msg2) {
+		t.Errorf("DecodeMsgSigned:\nexpected: %#v\npresent:  %#v",
+			msg, msg2)
+	}
+
+	// Signed message must be accepted by DecodeMsg
+	_, err = DecodeMsg(data)
+	if err != nil {
+		t.Errorf("DecodeMsg: %s", err)
+	}
+
+	// Bad signatures
+	noAppSeq := msg
+	noAppSeq.Header.AppSequence = nil
+	noAppSeqData, err := noAppSeq.EncodeSigned(signer)
+	if err != nil {
+		t.Fatalf("EncodeSigned: %s", err)
+	}
+
+	type testData struct {
+		name string // Test name
+		data []byte // Message data
+		keys KeyStore
+		err  error // Expected error
+	}
+
+	tests := []testData{
+		{
+			name: "not signed",
+			data: msg.Encode(),
+			keys: keys,
+			err:  ErrSigMissed,
+		},
+
+		{
+			name: "unknown key",
+			data: data,
+			keys: NewStaticKeyStore(&key2.PublicKey),
+			err:  ErrSigUnknownKey,
+		},
+
+		{
+			name: "modified body",
+			data: []byte(strings.Replace(string(data),
+				"MetadataVersion>3<", "MetadataVersion>4<", 1)),
+			keys: keys,
+			err:  ErrSigInvalid,
+		},
+
+		{
+			name: "duplicated body",
+			data: []byte(strings.Replace(string(data),
+				"</s:Body>", "</s:Body><s:Body></s:Body>", 1)),
+			keys: keys,
+			err:  ErrSigInvalid,
+		},
+
+		{
+			name: "unsigned AppSequence",
+			data: []byte(strings.Replace(string(noAppSeqData),
+				"</s:Header>", `<d:AppSequence InstanceId="1" `+
+					`MessageNumber="1"></d:AppSequence>`+
+					`</s:Header>`, 1)),
+			keys: keys,
+			err:  ErrSigInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeMsgSigned(test.data, test.keys)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error mismatch:\n"+
+				"expected: %v\npresent:  %v",
+				test.name, test.err, err)
+		}
+	}
+}
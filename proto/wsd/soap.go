@@ -0,0 +1,256 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SOAP over HTTP, for protocols on top of WSD
+
+package wsd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// SOAPMaxXMLSize is the maximum size of the SOAP response,
+// accepted by the [SOAPClient].
+const SOAPMaxXMLSize = 1024 * 1024
+
+// SOAPContentType is the Content-Type of the SOAP 1.2 messages.
+const SOAPContentType = "application/soap+xml; charset=utf-8"
+
+// SOAPClient performs SOAP requests over HTTP.
+//
+// It is the common part of clients of protocols, built on top
+// of WSD (WS-Scan, WS-Print, WS-Eventing). Messages are encoded
+// and decoded using the protocol's namespace, as protocols use
+// their own namespace prefixes.
+type SOAPClient struct {
+	Proto      string            // Protocol name, for logging and errors
+	Ns         xmldoc.Namespace  // Protocol namespace
+	HTTPClient *transport.Client // HTTP Client
+}
+
+// SOAPFault creates the [Fault] with the protocol-specific subcode
+// (may be "") and reason.
+//
+// subcodeNs is the namespace prefix of the subcode.
+func SOAPFault(code, subcodeNs, subcode, reason string) Fault {
+	f := Fault{
+		Code:    code,
+		Subcode: subcode,
+		Reason:  LocalizedString{String: reason, Lang: "en"},
+	}
+
+	if subcode != "" {
+		f.SubcodeNs = subcodeNs
+	}
+
+	return f
+}
+
+// SOAPEncode builds the SOAP request with the given action,
+// destination and body, and returns its wire representation.
+func SOAPEncode(ns xmldoc.Namespace, action, to string,
+	body xmldoc.Element) []byte {
+
+	msgid := uuid.Must(uuid.Random()).URN()
+
+	env := xmldoc.WithChildren(NsSOAP+":Envelope",
+		xmldoc.WithChildren(NsSOAP+":Header",
+			xmldoc.WithText(NsAddressing+":Action", action),
+			xmldoc.WithText(NsAddressing+":MessageID", msgid),
+			xmldoc.WithText(NsAddressing+":To", to),
+			xmldoc.WithChildren(NsAddressing+":ReplyTo",
+				xmldoc.WithText(NsAddressing+":Address",
+					string(ToAnonymous))),
+		),
+		xmldoc.WithChildren(NsSOAP+":Body", body),
+	)
+
+	var buf bytes.Buffer
+	env.Encode(&buf, ns)
+	return buf.Bytes()
+}
+
+// SOAPEncodeResponse builds the SOAP response with the given action,
+// related request MessageID and body, and returns its wire
+// representation.
+//
+// Fault subcodes use the namespace prefix in the element text,
+// so if response may contain the protocol-specific [Fault], its
+// subcode prefix must be marked as used in ns.
+func SOAPEncodeResponse(ns xmldoc.Namespace, action, relatesTo string,
+	body xmldoc.Element) []byte {
+
+	msgid := uuid.Must(uuid.Random()).URN()
+
+	hdr := xmldoc.WithChildren(NsSOAP+":Header",
+		xmldoc.WithText(NsAddressing+":Action", action),
+		xmldoc.WithText(NsAddressing+":MessageID", msgid),
+		xmldoc.WithText(NsAddressing+":To", string(ToAnonymous)),
+	)
+
+	if relatesTo != "" {
+		hdr.Children = append(hdr.Children,
+			xmldoc.WithText(NsAddressing+":RelatesTo", relatesTo))
+	}
+
+	env := xmldoc.WithChildren(NsSOAP+":Envelope",
+		hdr,
+		xmldoc.WithChildren(NsSOAP+":Body", body),
+	)
+
+	var buf bytes.Buffer
+	env.Encode(&buf, ns)
+	return buf.Bytes()
+}
+
+// SOAPDecode decodes the SOAP message and returns its body element
+// with the expected name.
+//
+// If message contains the SOAP Fault, it is returned as error
+// of the [Fault] type.
+func SOAPDecode(ns xmldoc.Namespace, data []byte,
+	name string) (xmldoc.Element, error) {
+
+	root, err := xmldoc.Decode(ns, bytes.NewReader(data))
+	if err != nil {
+		return xmldoc.Element{}, err
+	}
+
+	if root.Name != NsSOAP+":Envelope" {
+		err = xmldoc.XMLErrMissed(NsSOAP + ":Envelope")
+		return xmldoc.Element{}, err
+	}
+
+	body, ok := root.ChildByName(NsSOAP + ":Body")
+	if !ok {
+		err = xmldoc.XMLErrMissed(NsSOAP + ":Body")
+		return xmldoc.Element{}, xmldoc.XMLErrWrap(root, err)
+	}
+
+	if elm, ok := body.ChildByName(NsSOAP + ":Fault"); ok {
+		fault, err := DecodeFault(elm)
+		if err != nil {
+			err = xmldoc.XMLErrWrap(body, err)
+			return xmldoc.Element{}, xmldoc.XMLErrWrap(root, err)
+		}
+		return xmldoc.Element{}, fault
+	}
+
+	elem, ok := body.ChildByName(name)
+	if !ok {
+		err = xmldoc.XMLErrWrap(body, xmldoc.XMLErrMissed(name))
+		return xmldoc.Element{}, xmldoc.XMLErrWrap(root, err)
+	}
+
+	return elem, nil
+}
+
+// Call performs the SOAP request and returns the response body
+// element with the expected name.
+func (c *SOAPClient) Call(ctx context.Context, u *url.URL, action string,
+	rq xmldoc.Element, name string) (xmldoc.Element, error) {
+
+	data := SOAPEncode(c.Ns, action, u.String(), rq)
+
+	httpRsp, err := c.Post(ctx, u, action, SOAPContentType,
+		bytes.NewReader(data))
+	if err != nil {
+		return xmldoc.Element{}, err
+	}
+
+	defer httpRsp.Body.Close()
+
+	return c.ReadResponse(httpRsp, name)
+}
+
+// Post sends the request body with the specified Content-Type and
+// returns the HTTP response.
+//
+// If device responds with non-2xx HTTP status, the response is
+// consumed and returned as error, decoded as [Fault], if possible.
+func (c *SOAPClient) Post(ctx context.Context, u *url.URL, action, ct string,
+	body io.Reader) (*http.Response, error) {
+
+	httpRq, err := transport.NewRequest(ctx, "POST", u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpRq.Header.Set("Content-Type", ct)
+
+	log.Debug(ctx, "%s: POST %s: %s", c.Proto, u, path.Base(action))
+
+	httpRsp, err := c.HTTPClient.Do(httpRq)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpRsp.StatusCode/100 != http.StatusOK/100 {
+		defer httpRsp.Body.Close()
+
+		data, err := c.ReadXML(httpRsp.Body)
+		if err == nil {
+			_, err = SOAPDecode(c.Ns, data, "")
+		}
+
+		var fault Fault
+		if errors.As(err, &fault) {
+			return nil, fault
+		}
+
+		return nil, fmt.Errorf("%s: %s: HTTP: %s",
+			c.Proto, path.Base(action), httpRsp.Status)
+	}
+
+	return httpRsp, nil
+}
+
+// ReadResponse reads and decodes the SOAP response and returns
+// its body element with the expected name.
+func (c *SOAPClient) ReadResponse(httpRsp *http.Response,
+	name string) (xmldoc.Element, error) {
+
+	data, err := c.ReadXML(httpRsp.Body)
+	if err != nil {
+		return xmldoc.Element{}, err
+	}
+
+	rsp, err := SOAPDecode(c.Ns, data, name)
+	if err != nil {
+		var fault Fault
+		if !errors.As(err, &fault) {
+			err = fmt.Errorf("%s: %w", c.Proto, err)
+		}
+		return xmldoc.Element{}, err
+	}
+
+	return rsp, nil
+}
+
+// ReadXML reads the XML response, up to the [SOAPMaxXMLSize].
+func (c *SOAPClient) ReadXML(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, SOAPMaxXMLSize+1))
+	switch {
+	case err != nil:
+		return nil, err
+	case len(data) > SOAPMaxXMLSize:
+		return nil, fmt.Errorf("%s: response too large", c.Proto)
+	}
+
+	return data, nil
+}
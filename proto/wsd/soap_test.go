@@ -0,0 +1,119 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SOAP over HTTP test
+
+package wsd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestSOAPClient tests SOAPClient against the echo server
+func TestSOAPClient(t *testing.T) {
+	fault := SOAPFault(FaultCodeSender, NsScan, "ClientErrorJobIdNotFound",
+		"Job not found")
+
+	// Faults use the subcode prefix in the element text
+	faultNs := NsMap.Clone()
+	faultNs.MarkUsedPrefix(NsScan)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, rq *http.Request) {
+		data, _ := io.ReadAll(rq.Body)
+		body, err := SOAPDecode(NsMap, data, NsScan+":Request")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		body.Name = NsScan + ":Response"
+		w.Header().Set("Content-Type", SOAPContentType)
+		w.Write(SOAPEncodeResponse(NsMap,
+			"http://localhost/test/Response", "", body))
+	})
+	mux.HandleFunc("/fault", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", SOAPContentType)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(SOAPEncodeResponse(faultNs, ActFault.Encode(),
+			"", fault.ToXML()))
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "Broken", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(strings.Repeat(" ", SOAPMaxXMLSize+1)))
+	})
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, mux)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	clnt := &SOAPClient{
+		Proto:      "Test",
+		Ns:         NsMap,
+		HTTPClient: transport.NewClient(tr),
+	}
+
+	ctx := context.Background()
+	rq := xmldoc.WithChildren(NsScan+":Request",
+		xmldoc.WithText(NsScan+":JobId", "1"))
+
+	// Successful request
+	rsp, err := clnt.Call(ctx,
+		transport.MustParseURL("http://localhost/echo"),
+		"http://localhost/test/Request", rq, NsScan+":Response")
+	if err != nil {
+		t.Fatalf("Call: %s", err)
+	}
+
+	expected := rq
+	expected.Name = NsScan + ":Response"
+	if !reflect.DeepEqual(rsp, expected) {
+		t.Errorf("Call:\nexpected: %s\npresent:  %s",
+			expected.EncodeString(NsMap), rsp.EncodeString(NsMap))
+	}
+
+	// SOAP fault
+	_, err = clnt.Call(ctx,
+		transport.MustParseURL("http://localhost/fault"),
+		"http://localhost/test/Request", rq, NsScan+":Response")
+
+	var present Fault
+	if !errors.As(err, &present) ||
+		present.Subcode != fault.Subcode ||
+		present.Reason != fault.Reason {
+		t.Errorf("Call:\nexpected: %v\npresent:  %v", fault, err)
+	}
+
+	// HTTP error
+	_, err = clnt.Call(ctx,
+		transport.MustParseURL("http://localhost/broken"),
+		"http://localhost/test/Request", rq, NsScan+":Response")
+
+	if err == nil || !strings.Contains(err.Error(), "Test: Request: HTTP") {
+		t.Errorf("Call: unexpected error %v", err)
+	}
+
+	// Response too large
+	_, err = clnt.Call(ctx,
+		transport.MustParseURL("http://localhost/large"),
+		"http://localhost/test/Request", rq, NsScan+":Response")
+
+	if err == nil || err.Error() != "Test: response too large" {
+		t.Errorf("Call: unexpected error %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Eventing Subscribe and SubscribeResponse message bodies
+
+package wsd
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// WS-Eventing constants:
+const (
+	// DeliveryModePush is the only delivery mode, used by WSD
+	DeliveryModePush = "http://schemas.xmlsoap.org/ws/2004/08/eventing/DeliveryModes/Push"
+
+	// FilterDialectAction is the filter dialect, used by WSD.
+	// Filter of this dialect is the list of event actions.
+	FilterDialectAction = "http://schemas.xmlsoap.org/ws/2006/02/devprof/Action"
+)
+
+// Subscribe represents a WS-Eventing Subscribe message.
+//
+// This message is sent using HTTP POST to the event source
+// (i.e., the scanner or printer service) to subscribe to
+// events, specified by the Filter.
+type Subscribe struct {
+	EndTo    optional.Val[EndpointReference] // SubscriptionEnd goes here
+	NotifyTo EndpointReference               // Events go here
+	Expires  time.Duration                   // Requested duration, 0 if none
+	Filter   []AnyURI                        // Actions of the events
+}
+
+// SubscribeResponse represents a WS-Eventing SubscribeResponse
+// message.
+//
+// SubscriptionManager is the endpoint, where Renew and Unsubscribe
+// requests must be sent. Its Identifier, if present, must be sent
+// with these requests in the message [Header].
+type SubscribeResponse struct {
+	SubscriptionManager EndpointReference // Subscription manager
+	Expires             time.Duration     // Granted duration
+}
+
+// DecodeSubscribe decodes [Subscribe] from the XML tree
+func DecodeSubscribe(root xmldoc.Element) (sub Subscribe, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	// Lookup message elements
+	endTo := xmldoc.Lookup{Name: NsEventing + ":EndTo"}
+	delivery := xmldoc.Lookup{Name: NsEventing + ":Delivery", Required: true}
+	expires := xmldoc.Lookup{Name: NsEventing + ":Expires"}
+	filter := xmldoc.Lookup{Name: NsEventing + ":Filter"}
+
+	missed := root.Lookup(&endTo, &delivery, &expires, &filter)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	// Decode elements
+	if endTo.Found {
+		var ref EndpointReference
+		ref, err = DecodeEndpointReference(endTo.Elem)
+		if err != nil {
+			return
+		}
+		sub.EndTo = optional.New(ref)
+	}
+
+	sub.NotifyTo, err = decodeSubscribeDelivery(delivery.Elem)
+	if err != nil {
+		return
+	}
+
+	if expires.Found {
+		sub.Expires, err = DecodeDuration(expires.Elem)
+		if err != nil {
+			return
+		}
+	}
+
+	if filter.Found {
+		sub.Filter, err = decodeSubscribeFilter(filter.Elem)
+	}
+
+	return
+}
+
+// decodeSubscribeDelivery decodes NotifyTo out of the Delivery element.
+func decodeSubscribeDelivery(root xmldoc.Element) (
+	ref EndpointReference, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	if mode, ok := root.AttrByName("Mode"); ok &&
+		mode.Value != DeliveryModePush {
+		err = xmldoc.XMLErrWrapAttr(mode,
+			errors.New("unsupported delivery mode"))
+		return
+	}
+
+	notifyTo := xmldoc.Lookup{Name: NsEventing + ":NotifyTo",
+		Required: true}
+
+	missed := root.Lookup(&notifyTo)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	return DecodeEndpointReference(notifyTo.Elem)
+}
+
+// decodeSubscribeFilter decodes list of actions out of the Filter
+// element.
+func decodeSubscribeFilter(root xmldoc.Element) (
+	actions []AnyURI, err error) {
+
+	if dialect, ok := root.AttrByName("Dialect"); ok &&
+		dialect.Value != FilterDialectAction {
+		err = xmldoc.XMLErrWrapAttr(dialect,
+			errors.New("unsupported filter dialect"))
+		err = xmldoc.XMLErrWrap(root, err)
+		return
+	}
+
+	for _, act := range strings.Fields(root.Text) {
+		actions = append(actions, AnyURI(act))
+	}
+
+	return
+}
+
+// Action returns [Action] to be used with the [Subscribe] message
+func (Subscribe) Action() Action {
+	return ActSubscribe
+}
+
+// ToXML generates XML tree for the message body
+func (sub Subscribe) ToXML() xmldoc.Element {
+	elm := xmldoc.Element{Name: NsEventing + ":Subscribe"}
+
+	if sub.EndTo != nil {
+		elm.Children = append(elm.Children,
+			(*sub.EndTo).ToXML(NsEventing+":EndTo"))
+	}
+
+	delivery := xmldoc.WithChildren(NsEventing+":Delivery",
+		sub.NotifyTo.ToXML(NsEventing+":NotifyTo"))
+	delivery.Attrs = []xmldoc.Attr{{Name: "Mode", Value: DeliveryModePush}}
+	elm.Children = append(elm.Children, delivery)
+
+	if sub.Expires > 0 {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsEventing+":Expires",
+				EncodeDuration(sub.Expires)))
+	}
+
+	if len(sub.Filter) != 0 {
+		actions := make([]string, len(sub.Filter))
+		for i, act := range sub.Filter {
+			actions[i] = string(act)
+		}
+
+		filter := xmldoc.WithText(NsEventing+":Filter",
+			strings.Join(actions, " "))
+		filter.Attrs = []xmldoc.Attr{
+			{Name: "Dialect", Value: FilterDialectAction},
+		}
+		elm.Children = append(elm.Children, filter)
+	}
+
+	return elm
+}
+
+// MarkUsedNamespace marks [xmldoc.Namespace] entries used by
+// data elements within the message body, if any.
+//
+// This function should not care about Namespace entries, used
+// by XML tags: they are handled automatically.
+func (sub Subscribe) MarkUsedNamespace(ns xmldoc.Namespace) {
+	// Nothing to mark for Subscribe
+}
+
+// DecodeSubscribeResponse decodes [SubscribeResponse] from the XML tree
+func DecodeSubscribeResponse(root xmldoc.Element) (
+	rsp SubscribeResponse, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	// Lookup message elements
+	manager := xmldoc.Lookup{Name: NsEventing + ":SubscriptionManager",
+		Required: true}
+	expires := xmldoc.Lookup{Name: NsEventing + ":Expires", Required: true}
+
+	missed := root.Lookup(&manager, &expires)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	// Decode elements
+	rsp.SubscriptionManager, err = DecodeEndpointReference(manager.Elem)
+	if err == nil {
+		rsp.Expires, err = DecodeDuration(expires.Elem)
+	}
+
+	return
+}
+
+// Action returns [Action] to be used with the [SubscribeResponse] message
+func (SubscribeResponse) Action() Action {
+	return ActSubscribeResponse
+}
+
+// ToXML generates XML tree for the message body
+func (rsp SubscribeResponse) ToXML() xmldoc.Element {
+	return xmldoc.WithChildren(NsEventing+":SubscribeResponse",
+		rsp.SubscriptionManager.ToXML(NsEventing+":SubscriptionManager"),
+		xmldoc.WithText(NsEventing+":Expires",
+			EncodeDuration(rsp.Expires)),
+	)
+}
+
+// MarkUsedNamespace marks [xmldoc.Namespace] entries used by
+// data elements within the message body, if any.
+//
+// This function should not care about Namespace entries, used
+// by XML tags: they are handled automatically.
+func (rsp SubscribeResponse) MarkUsedNamespace(ns xmldoc.Namespace) {
+	// Nothing to mark for SubscribeResponse
+}
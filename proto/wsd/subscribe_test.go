@@ -0,0 +1,254 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Subscribe and SubscribeResponse test
+
+package wsd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestSubscribe tests Subscribe encoding and decoding
+func TestSubscribe(t *testing.T) {
+	type testData struct {
+		sub Subscribe
+		xml xmldoc.Element
+	}
+
+	notifyTo := EndpointReference{
+		Address: "http://192.168.0.2:8080/events",
+		Identifier: optional.New(
+			AnyURI("urn:uuid:e0a5e6c3-6b14-4d64-8a83-3c9d53fbb7c5")),
+	}
+
+	notifyToXML := func(name string) xmldoc.Element {
+		return xmldoc.WithChildren(name,
+			xmldoc.WithText(NsAddressing+":Address",
+				"http://192.168.0.2:8080/events"),
+			xmldoc.WithChildren(NsAddressing+":ReferenceParameters",
+				xmldoc.WithText(NsEventing+":Identifier",
+					"urn:uuid:e0a5e6c3-6b14-4d64-8a83-3c9d53fbb7c5"),
+			),
+		)
+	}
+
+	delivery := xmldoc.WithChildren(NsEventing+":Delivery",
+		notifyToXML(NsEventing+":NotifyTo"))
+	delivery.Attrs = []xmldoc.Attr{{Name: "Mode", Value: DeliveryModePush}}
+
+	filter := xmldoc.WithText(NsEventing+":Filter",
+		"http://schemas.microsoft.com/windows/2006/08/wdp/scan/ScannerStatusSummaryEvent "+
+			"http://schemas.microsoft.com/windows/2006/08/wdp/scan/ScanAvailableEvent")
+	filter.Attrs = []xmldoc.Attr{
+		{Name: "Dialect", Value: FilterDialectAction},
+	}
+
+	tests := []testData{
+		{
+			sub: Subscribe{NotifyTo: notifyTo},
+			xml: xmldoc.WithChildren(NsEventing+":Subscribe",
+				delivery),
+		},
+
+		{
+			sub: Subscribe{
+				EndTo:    optional.New(notifyTo),
+				NotifyTo: notifyTo,
+				Expires:  time.Hour,
+				Filter: []AnyURI{
+					"http://schemas.microsoft.com/windows/2006/08/wdp/scan/ScannerStatusSummaryEvent",
+					"http://schemas.microsoft.com/windows/2006/08/wdp/scan/ScanAvailableEvent",
+				},
+			},
+			xml: xmldoc.WithChildren(NsEventing+":Subscribe",
+				notifyToXML(NsEventing+":EndTo"),
+				delivery,
+				xmldoc.WithText(NsEventing+":Expires", "PT1H"),
+				filter,
+			),
+		},
+	}
+
+	for _, test := range tests {
+		xml := test.sub.ToXML()
+		if !reflect.DeepEqual(xml, test.xml) {
+			t.Errorf("ToXML:\nexpected: %s\npresent:  %s\n",
+				test.xml.EncodeString(NsMap),
+				xml.EncodeString(NsMap))
+		}
+
+		sub, err := DecodeSubscribe(xml)
+		if err != nil {
+			t.Errorf("DecodeSubscribe: %s", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(sub, test.sub) {
+			t.Errorf("DecodeSubscribe:\n"+
+				"expected: %#v\npresent:  %#v\n",
+				test.sub, sub)
+		}
+	}
+}
+
+// TestSubscribeDecodeErrors tests Subscribe decode errors
+func TestSubscribeDecodeErrors(t *testing.T) {
+	type testData struct {
+		xml  xmldoc.Element
+		estr string
+	}
+
+	notifyTo := xmldoc.WithChildren(NsEventing+":NotifyTo",
+		xmldoc.WithText(NsAddressing+":Address",
+			"http://192.168.0.2:8080/events"))
+
+	tests := []testData{
+		{
+			xml:  xmldoc.WithChildren(NsEventing + ":Subscribe"),
+			estr: "/e:Subscribe/e:Delivery: missed",
+		},
+
+		{
+			xml: xmldoc.WithChildren(NsEventing+":Subscribe",
+				xmldoc.WithChildren(NsEventing+":Delivery")),
+			estr: "/e:Subscribe/e:Delivery/e:NotifyTo: missed",
+		},
+
+		{
+			xml: xmldoc.WithChildren(NsEventing+":Subscribe",
+				xmldoc.Element{
+					Name:     NsEventing + ":Delivery",
+					Attrs:    []xmldoc.Attr{{Name: "Mode", Value: "pull"}},
+					Children: []xmldoc.Element{notifyTo},
+				}),
+			estr: "/e:Subscribe/e:Delivery/@Mode: unsupported delivery mode",
+		},
+
+		{
+			xml: xmldoc.WithChildren(NsEventing+":Subscribe",
+				xmldoc.WithChildren(NsEventing+":Delivery", notifyTo),
+				xmldoc.WithText(NsEventing+":Expires", "1 hour")),
+			estr: `/e:Subscribe/e:Expires: invalid duration: "1 hour"`,
+		},
+
+		{
+			xml: xmldoc.WithChildren(NsEventing+":Subscribe",
+				xmldoc.WithChildren(NsEventing+":Delivery", notifyTo),
+				xmldoc.Element{
+					Name: NsEventing + ":Filter",
+					Attrs: []xmldoc.Attr{
+						{Name: "Dialect", Value: "http://www.w3.org/TR/1999/REC-xpath-19991116"},
+					},
+				}),
+			estr: "/e:Subscribe/e:Filter/@Dialect: unsupported filter dialect",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeSubscribe(test.xml)
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if estr != test.estr {
+			t.Errorf("%s\nexpected: %q\npresent:  %q",
+				test.xml.EncodeString(NsMap),
+				test.estr, estr)
+		}
+	}
+}
+
+// TestSubscribeResponse tests SubscribeResponse encoding and decoding
+func TestSubscribeResponse(t *testing.T) {
+	rsp := SubscribeResponse{
+		SubscriptionManager: EndpointReference{
+			Address: "http://192.168.0.1:5358/WSDScanner",
+			Identifier: optional.New(
+				AnyURI("uuid:4d5e2d8b-0b6f-4b68-9d46-47c4b36dcd63")),
+		},
+		Expires: 30 * time.Minute,
+	}
+
+	expected := xmldoc.WithChildren(NsEventing+":SubscribeResponse",
+		xmldoc.WithChildren(NsEventing+":SubscriptionManager",
+			xmldoc.WithText(NsAddressing+":Address",
+				"http://192.168.0.1:5358/WSDScanner"),
+			xmldoc.WithChildren(NsAddressing+":ReferenceParameters",
+				xmldoc.WithText(NsEventing+":Identifier",
+					"uuid:4d5e2d8b-0b6f-4b68-9d46-47c4b36dcd63"),
+			),
+		),
+		xmldoc.WithText(NsEventing+":Expires", "PT30M"),
+	)
+
+	xml := rsp.ToXML()
+	if !reflect.DeepEqual(xml, expected) {
+		t.Errorf("ToXML:\nexpected: %s\npresent:  %s\n",
+			expected.EncodeString(NsMap),
+			xml.EncodeString(NsMap))
+	}
+
+	rsp2, err := DecodeSubscribeResponse(xml)
+	if err != nil {
+		t.Errorf("DecodeSubscribeResponse: %s", err)
+	} else if !reflect.DeepEqual(rsp, rsp2) {
+		t.Errorf("DecodeSubscribeResponse:\n"+
+			"expected: %#v\npresent:  %#v\n", rsp, rsp2)
+	}
+
+	// Expires is required
+	xml.Children = xml.Children[:1]
+	_, err = DecodeSubscribeResponse(xml)
+	estr := ""
+	if err != nil {
+		estr = err.Error()
+	}
+
+	if estr != "/e:SubscribeResponse/e:Expires: missed" {
+		t.Errorf("DecodeSubscribeResponse: unexpected error %q", estr)
+	}
+}
+
+// TestSubscribeMsg tests Subscribe message wire encoding and decoding
+func TestSubscribeMsg(t *testing.T) {
+	msg := Msg{
+		Header: Header{
+			Action:    ActSubscribe,
+			MessageID: "urn:uuid:1cf1d308-cb65-494c-9d60-2232c57462e1",
+			To: optional.New(
+				AnyURI("http://192.168.0.1:5358/WSDScanner")),
+		},
+		Body: Subscribe{
+			NotifyTo: EndpointReference{
+				Address: "http://192.168.0.2:8080/events",
+				Identifier: optional.New(
+					AnyURI("urn:uuid:e0a5e6c3-6b14-4d64-8a83-3c9d53fbb7c5")),
+			},
+			Expires: time.Hour,
+			Filter: []AnyURI{
+				"http://schemas.microsoft.com/windows/2006/08/wdp/scan/ScanAvailableEvent",
+			},
+		},
+	}
+
+	msg2, err := DecodeMsg(msg.Encode())
+	if err != nil {
+		t.Errorf("DecodeMsg: %s", err)
+		return
+	}
+
+	if !reflect.DeepEqual(msg, msg2) {
+		t.Errorf("DecodeMsg:\nexpected: %#v\npresent:  %#v\n",
+			msg, msg2)
+	}
+}
@@ -0,0 +1,89 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Eventing SubscriptionEnd message body
+
+package wsd
+
+import (
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// SubscriptionEnd status codes:
+const (
+	SubscriptionEndDeliveryFailure    = "http://schemas.xmlsoap.org/ws/2004/08/eventing/DeliveryFailure"
+	SubscriptionEndSourceShuttingDown = "http://schemas.xmlsoap.org/ws/2004/08/eventing/SourceShuttingDown"
+	SubscriptionEndSourceCancelling   = "http://schemas.xmlsoap.org/ws/2004/08/eventing/SourceCancelling"
+)
+
+// SubscriptionEnd represents a WS-Eventing SubscriptionEnd message.
+//
+// This message is sent by the event source to the EndTo endpoint of
+// the [Subscribe] request, when subscription terminates unexpectedly.
+type SubscriptionEnd struct {
+	SubscriptionManager EndpointReference // Subscription manager
+	Status              AnyURI            // Status code
+	Reason              LocalizedString   // Human-readable reason
+}
+
+// DecodeSubscriptionEnd decodes [SubscriptionEnd] from the XML tree
+func DecodeSubscriptionEnd(root xmldoc.Element) (
+	end SubscriptionEnd, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	// Lookup message elements
+	manager := xmldoc.Lookup{Name: NsEventing + ":SubscriptionManager",
+		Required: true}
+	status := xmldoc.Lookup{Name: NsEventing + ":Status", Required: true}
+	reason := xmldoc.Lookup{Name: NsEventing + ":Reason"}
+
+	missed := root.Lookup(&manager, &status, &reason)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	// Decode elements
+	end.SubscriptionManager, err = DecodeEndpointReference(manager.Elem)
+	if err == nil {
+		end.Status, err = DecodeAnyURI(status.Elem)
+	}
+	if err == nil && reason.Found {
+		end.Reason = decodeLocalizedString(reason.Elem)
+	}
+
+	return
+}
+
+// Action returns [Action] to be used with the [SubscriptionEnd] message
+func (SubscriptionEnd) Action() Action {
+	return ActSubscriptionEnd
+}
+
+// ToXML generates XML tree for the message body
+func (end SubscriptionEnd) ToXML() xmldoc.Element {
+	elm := xmldoc.WithChildren(NsEventing+":SubscriptionEnd",
+		end.SubscriptionManager.ToXML(NsEventing+":SubscriptionManager"),
+		xmldoc.WithText(NsEventing+":Status", string(end.Status)),
+	)
+
+	if end.Reason.String != "" {
+		elm.Children = append(elm.Children,
+			end.Reason.ToXML(NsEventing+":Reason"))
+	}
+
+	return elm
+}
+
+// MarkUsedNamespace marks [xmldoc.Namespace] entries used by
+// data elements within the message body, if any.
+//
+// This function should not care about Namespace entries, used
+// by XML tags: they are handled automatically.
+func (end SubscriptionEnd) MarkUsedNamespace(ns xmldoc.Namespace) {
+	// Nothing to mark for SubscriptionEnd
+}
@@ -0,0 +1,124 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SubscriptionEnd test
+
+package wsd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestSubscriptionEnd tests SubscriptionEnd encoding and decoding
+func TestSubscriptionEnd(t *testing.T) {
+	type testData struct {
+		end SubscriptionEnd
+		xml xmldoc.Element
+	}
+
+	manager := xmldoc.WithChildren(NsEventing+":SubscriptionManager",
+		xmldoc.WithText(NsAddressing+":Address",
+			"http://192.168.0.1:5358/WSDScanner"))
+
+	tests := []testData{
+		{
+			end: SubscriptionEnd{
+				SubscriptionManager: EndpointReference{
+					Address: "http://192.168.0.1:5358/WSDScanner",
+				},
+				Status: SubscriptionEndSourceShuttingDown,
+			},
+			xml: xmldoc.WithChildren(NsEventing+":SubscriptionEnd",
+				manager,
+				xmldoc.WithText(NsEventing+":Status",
+					SubscriptionEndSourceShuttingDown),
+			),
+		},
+
+		{
+			end: SubscriptionEnd{
+				SubscriptionManager: EndpointReference{
+					Address: "http://192.168.0.1:5358/WSDScanner",
+				},
+				Status: SubscriptionEndSourceCancelling,
+				Reason: LocalizedString{"Scanner is offline", "en"},
+			},
+			xml: xmldoc.WithChildren(NsEventing+":SubscriptionEnd",
+				manager,
+				xmldoc.WithText(NsEventing+":Status",
+					SubscriptionEndSourceCancelling),
+				xmldoc.Element{
+					Name:  NsEventing + ":Reason",
+					Text:  "Scanner is offline",
+					Attrs: []xmldoc.Attr{{Name: "xml:lang", Value: "en"}},
+				},
+			),
+		},
+	}
+
+	for _, test := range tests {
+		xml := test.end.ToXML()
+		if !reflect.DeepEqual(xml, test.xml) {
+			t.Errorf("ToXML:\nexpected: %s\npresent:  %s\n",
+				test.xml.EncodeString(NsMap),
+				xml.EncodeString(NsMap))
+		}
+
+		end, err := DecodeSubscriptionEnd(xml)
+		if err != nil {
+			t.Errorf("DecodeSubscriptionEnd: %s", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(end, test.end) {
+			t.Errorf("DecodeSubscriptionEnd:\n"+
+				"expected: %#v\npresent:  %#v\n",
+				test.end, end)
+		}
+	}
+}
+
+// TestSubscriptionEndDecodeErrors tests SubscriptionEnd decode errors
+func TestSubscriptionEndDecodeErrors(t *testing.T) {
+	type testData struct {
+		xml  xmldoc.Element
+		estr string
+	}
+
+	tests := []testData{
+		{
+			xml: xmldoc.WithChildren(NsEventing+":SubscriptionEnd",
+				xmldoc.WithText(NsEventing+":Status",
+					SubscriptionEndDeliveryFailure)),
+			estr: "/e:SubscriptionEnd/e:SubscriptionManager: missed",
+		},
+
+		{
+			xml: xmldoc.WithChildren(NsEventing+":SubscriptionEnd",
+				xmldoc.WithChildren(NsEventing+":SubscriptionManager",
+					xmldoc.WithText(NsAddressing+":Address",
+						"http://192.168.0.1:5358/WSDScanner"))),
+			estr: "/e:SubscriptionEnd/e:Status: missed",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeSubscriptionEnd(test.xml)
+		estr := ""
+		if err != nil {
+			estr = err.Error()
+		}
+
+		if estr != test.estr {
+			t.Errorf("%s\nexpected: %q\npresent:  %q",
+				test.xml.EncodeString(NsMap),
+				test.estr, estr)
+		}
+	}
+}
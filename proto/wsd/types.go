@@ -26,6 +26,7 @@ const (
 	Device
 	PrinterServiceType
 	ScannerServiceType
+	DiscoveryProxy
 )
 
 // DecodeTypes decodes [Types] from the XML tree
@@ -49,6 +50,8 @@ func DecodeTypes(root xmldoc.Element) (types Types, err error) {
 			types = append(types, PrinterServiceType)
 		case "ScanDeviceType":
 			types = append(types, ScannerServiceType)
+		case "DiscoveryProxy":
+			types = append(types, DiscoveryProxy)
 		}
 	}
 
@@ -93,6 +96,18 @@ func (types Types) Contains(t Type) bool {
 	return false
 }
 
+// ContainsAll reports if all requested types are members of types.
+//
+// This is how device types are matched against the [Probe].
+func (types Types) ContainsAll(requested Types) bool {
+	for _, t := range requested {
+		if !types.Contains(t) {
+			return false
+		}
+	}
+	return true
+}
+
 // String returns text representation for [Types].
 //
 // The returned value can be directly used as a text value of Types XML
@@ -175,6 +190,8 @@ func (types Types) MarkUsedNamespace(ns xmldoc.Namespace) {
 			ns.MarkUsedPrefix("print")
 		case ScannerServiceType:
 			ns.MarkUsedPrefix("scan")
+		case DiscoveryProxy:
+			ns.MarkUsedPrefix(NsDiscovery)
 		}
 	}
 }
@@ -188,6 +205,8 @@ func (t Type) String() string {
 		return "print:PrintDeviceType"
 	case ScannerServiceType:
 		return "scan:ScanDeviceType"
+	case DiscoveryProxy:
+		return NsDiscovery + ":DiscoveryProxy"
 	}
 
 	return "Unknown"
@@ -52,6 +52,15 @@ func TestTypes(t *testing.T) {
 			nsused: "scan",
 		},
 
+		{
+			types: []Type{DiscoveryProxy},
+			xml: xmldoc.Element{
+				Name: NsDiscovery + ":Types",
+				Text: "d:DiscoveryProxy",
+			},
+			nsused: "d",
+		},
+
 		{
 			types: []Type{Device,
 				PrinterServiceType, ScannerServiceType},
@@ -0,0 +1,63 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SOAP-over-UDP retransmission
+
+package wsd
+
+import (
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/internal/random"
+)
+
+// SOAP-over-UDP parameters, per SOAP-over-UDP 1.1, Appendix I,
+// and WS-Discovery 1.1, 3.1.3:
+//
+// UDP is unreliable, so each message is sent once and then
+// repeated (retransmitted) MulticastUDPRepeat or UnicastUDPRepeat
+// times. The first delay between transmissions is chosen randomly
+// in the UDPMinDelay...UDPMaxDelay range, and then doubled for
+// each next retransmission, but limited by UDPUpperDelay.
+//
+// Responses to multicast requests (i.e., ProbeMatches in response
+// to Probe) are additionally delayed by the random time in the
+// 0...AppMaxDelay range, to avoid network storm, caused by many
+// devices responding at once.
+const (
+	MulticastUDPRepeat = 1
+	UnicastUDPRepeat   = 1
+	UDPMinDelay        = 50 * time.Millisecond
+	UDPMaxDelay        = 250 * time.Millisecond
+	UDPUpperDelay      = 500 * time.Millisecond
+	AppMaxDelay        = 500 * time.Millisecond
+)
+
+// UDPRetransmitDelays returns delays between the consecutive
+// transmissions of the same message, for the specified count
+// of repeats (typically, [MulticastUDPRepeat] or [UnicastUDPRepeat]).
+//
+// The first delay is the pause between initial transmission and
+// the first retransmission, and so on.
+func UDPRetransmitDelays(repeat int) []time.Duration {
+	delays := make([]time.Duration, 0, max(repeat, 0))
+
+	t := time.Duration(random.UintRange(uint(UDPMinDelay),
+		uint(UDPMaxDelay)))
+
+	for i := 0; i < repeat; i++ {
+		delays = append(delays, t)
+		t = min(2*t, UDPUpperDelay)
+	}
+
+	return delays
+}
+
+// AppDelay returns the random delay, in the 0...AppMaxDelay range,
+// before sending response to the multicast request.
+func AppDelay() time.Duration {
+	return time.Duration(random.UintMax(uint(AppMaxDelay)))
+}
@@ -0,0 +1,51 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SOAP-over-UDP retransmission test
+
+package wsd
+
+import "testing"
+
+// TestUDPRetransmitDelays tests UDPRetransmitDelays
+func TestUDPRetransmitDelays(t *testing.T) {
+	for repeat := 0; repeat < 5; repeat++ {
+		for i := 0; i < 100; i++ {
+			delays := UDPRetransmitDelays(repeat)
+			if len(delays) != repeat {
+				t.Fatalf("UDPRetransmitDelays(%d): %d delays",
+					repeat, len(delays))
+			}
+
+			for j, d := range delays {
+				switch {
+				case j == 0 && (d < UDPMinDelay || d > UDPMaxDelay):
+					t.Fatalf("UDPRetransmitDelays(%d): "+
+						"initial delay %s out of range",
+						repeat, d)
+
+				case j > 0 && d != min(2*delays[j-1], UDPUpperDelay):
+					t.Fatalf("UDPRetransmitDelays(%d): "+
+						"bad backoff: %v", repeat, delays)
+				}
+			}
+		}
+	}
+
+	if delays := UDPRetransmitDelays(-1); len(delays) != 0 {
+		t.Errorf("UDPRetransmitDelays(-1): %v", delays)
+	}
+}
+
+// TestAppDelay tests AppDelay
+func TestAppDelay(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := AppDelay()
+		if d < 0 || d > AppMaxDelay {
+			t.Fatalf("AppDelay: %s out of range", d)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Eventing Unsubscribe and UnsubscribeResponse message bodies
+
+package wsd
+
+import (
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// Unsubscribe represents a WS-Eventing Unsubscribe message.
+//
+// This message is sent using HTTP POST to the subscription manager,
+// returned by the [SubscribeResponse], to cancel the subscription.
+// The subscription identifier is sent in the message [Header].
+//
+// This message is trivial and contains no children elements.
+type Unsubscribe struct {
+}
+
+// UnsubscribeResponse represents a WS-Eventing UnsubscribeResponse
+// message.
+//
+// This message has empty body.
+type UnsubscribeResponse struct {
+}
+
+// DecodeUnsubscribe decodes [Unsubscribe] from the XML tree
+func DecodeUnsubscribe(root xmldoc.Element) (unsub Unsubscribe, err error) {
+	// Nothing to do
+	return
+}
+
+// Action returns [Action] to be used with the [Unsubscribe] message
+func (Unsubscribe) Action() Action {
+	return ActUnsubscribe
+}
+
+// ToXML generates XML tree for the message body
+func (unsub Unsubscribe) ToXML() xmldoc.Element {
+	return xmldoc.Element{Name: NsEventing + ":Unsubscribe"}
+}
+
+// MarkUsedNamespace marks [xmldoc.Namespace] entries used by
+// data elements within the message body, if any.
+//
+// This function should not care about Namespace entries, used
+// by XML tags: they are handled automatically.
+func (unsub Unsubscribe) MarkUsedNamespace(ns xmldoc.Namespace) {
+	// Nothing to mark for Unsubscribe
+}
+
+// DecodeUnsubscribeResponse decodes [UnsubscribeResponse] from
+// the XML tree
+func DecodeUnsubscribeResponse(root xmldoc.Element) (
+	rsp UnsubscribeResponse, err error) {
+	// Nothing to do
+	return
+}
+
+// Action returns [Action] to be used with the [UnsubscribeResponse]
+// message
+func (UnsubscribeResponse) Action() Action {
+	return ActUnsubscribeResponse
+}
+
+// ToXML generates XML tree for the message body
+func (rsp UnsubscribeResponse) ToXML() xmldoc.Element {
+	return xmldoc.Element{}
+}
+
+// MarkUsedNamespace marks [xmldoc.Namespace] entries used by
+// data elements within the message body, if any.
+//
+// This function should not care about Namespace entries, used
+// by XML tags: they are handled automatically.
+func (rsp UnsubscribeResponse) MarkUsedNamespace(ns xmldoc.Namespace) {
+	// Nothing to mark for UnsubscribeResponse
+}
@@ -0,0 +1,58 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WSD core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Unsubscribe and UnsubscribeResponse test
+
+package wsd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestUnsubscribe tests Unsubscribe and UnsubscribeResponse
+// encoding and decoding
+func TestUnsubscribe(t *testing.T) {
+	xml := Unsubscribe{}.ToXML()
+	expected := xmldoc.Element{Name: NsEventing + ":Unsubscribe"}
+	if !reflect.DeepEqual(xml, expected) {
+		t.Errorf("Unsubscribe.ToXML: unexpected output: %#v", xml)
+	}
+
+	_, err := DecodeUnsubscribe(xml)
+	if err != nil {
+		t.Errorf("DecodeUnsubscribe: %s", err)
+	}
+
+	xml = UnsubscribeResponse{}.ToXML()
+	if !xml.IsZero() {
+		t.Errorf("UnsubscribeResponse.ToXML: unexpected output: %#v",
+			xml)
+	}
+
+	// UnsubscribeResponse has empty body, so the whole
+	// message must round-trip.
+	msg := Msg{
+		Header: Header{
+			Action:    ActUnsubscribeResponse,
+			MessageID: "urn:uuid:1cf1d308-cb65-494c-9d60-2232c57462e1",
+			RelatesTo: optional.New(
+				AnyURI("urn:uuid:9a6942f8-f5dd-47fc-a4c4-9af559a2bc1a")),
+		},
+		Body: UnsubscribeResponse{},
+	}
+
+	msg2, err := DecodeMsg(msg.Encode())
+	if err != nil {
+		t.Errorf("DecodeMsg: %s", err)
+	} else if !reflect.DeepEqual(msg, msg2) {
+		t.Errorf("DecodeMsg:\nexpected: %#v\npresent:  %#v\n",
+			msg, msg2)
+	}
+}
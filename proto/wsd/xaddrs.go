@@ -9,22 +9,47 @@
 package wsd
 
 import (
+	"net"
+	"net/netip"
 	"net/url"
+	"slices"
 	"strings"
 
+	"github.com/OpenPrinting/go-mfp/transport"
 	"github.com/OpenPrinting/go-mfp/util/xmldoc"
 )
 
 // XAddrs represents a collection of transport addresses (URLs)
 type XAddrs []string
 
-// DecodeXAddrs decodes [XAddrs] from the XML tree
-func DecodeXAddrs(root xmldoc.Element) (xaddrs XAddrs, err error) {
-	ss := strings.Fields(root.Text)
-	xaddrs = make(XAddrs, 0, len(ss))
+// XAddrsFilter defines, how [XAddrs.URLs] filters transport
+// addresses.
+//
+// Zero value is valid and accepts all addresses, except IPv6
+// link-local addresses without zone, which are unusable.
+type XAddrsFilter struct {
+	// NoIP4 and NoIP6 reject URLs with the literal IPv4 and
+	// IPv6 addresses, if local host can't reach them (i.e.,
+	// announce was received via interface that lacks the
+	// address of that family).
+	NoIP4, NoIP6 bool
+
+	// Zone is the name of network interface, where XAddrs
+	// were received. It is added to IPv6 link-local addresses
+	// that don't have zone.
+	Zone string
+}
+
+// ParseXAddrs splits the white space-separated list of transport
+// addresses and validates them with [transport.ParseURL].
+//
+// Invalid and non-HTTP URLs are silently skipped.
+func ParseXAddrs(s string) XAddrs {
+	ss := strings.Fields(s)
+	xaddrs := make(XAddrs, 0, len(ss))
 
 	for _, s := range ss {
-		u, err := url.ParseRequestURI(s)
+		u, err := transport.ParseURL(s)
 		if err != nil {
 			// Silently skip invalid URLs
 			continue
@@ -38,7 +63,83 @@ func DecodeXAddrs(root xmldoc.Element) (xaddrs XAddrs, err error) {
 		xaddrs = append(xaddrs, s)
 	}
 
-	return
+	return xaddrs
+}
+
+// DecodeXAddrs decodes [XAddrs] from the XML tree
+func DecodeXAddrs(root xmldoc.Element) (xaddrs XAddrs, err error) {
+	return ParseXAddrs(root.Text), nil
+}
+
+// URLs parses XAddrs into URLs, filters them according to the
+// [XAddrsFilter] and orders by priority, for the metadata client
+// (see [Client.GetAny]).
+//
+// HTTPS URLs go before HTTP, and link-local addresses go last,
+// as they are less reliable. Otherwise, the original order is
+// preserved. Duplicates are removed.
+func (xaddrs XAddrs) URLs(filter XAddrsFilter) []*url.URL {
+	urls := make([]*url.URL, 0, len(xaddrs))
+	seen := make(map[string]struct{}, len(xaddrs))
+
+	for _, s := range xaddrs {
+		u, err := transport.ParseURL(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+			addr = addr.Unmap()
+
+			switch {
+			case addr.Is4() && filter.NoIP4:
+				continue
+			case addr.Is6() && filter.NoIP6:
+				continue
+			}
+
+			if addr.Is6() && addr.IsLinkLocalUnicast() &&
+				addr.Zone() == "" {
+				if filter.Zone == "" {
+					continue
+				}
+
+				host := addr.WithZone(filter.Zone).String()
+				u.Host = net.JoinHostPort(host, u.Port())
+				u.Host = strings.TrimSuffix(u.Host, ":")
+			}
+		}
+
+		if _, dup := seen[u.String()]; dup {
+			continue
+		}
+
+		seen[u.String()] = struct{}{}
+		urls = append(urls, u)
+	}
+
+	slices.SortStableFunc(urls, func(u1, u2 *url.URL) int {
+		return xaddrsPriority(u1) - xaddrsPriority(u2)
+	})
+
+	return urls
+}
+
+// xaddrsPriority returns priority of the URL, used by the
+// XAddrs.URLs for ordering. Lower value means higher priority.
+func xaddrsPriority(u *url.URL) int {
+	prio := 0
+
+	addr, err := netip.ParseAddr(u.Hostname())
+	if err == nil && addr.Unmap().IsLinkLocalUnicast() {
+		prio += 2
+	}
+
+	if u.Scheme != "https" {
+		prio++
+	}
+
+	return prio
 }
 
 // ToXML generates XML tree for XAddrs
@@ -105,3 +105,107 @@ func TestXAddrsDecode(t *testing.T) {
 		}
 	}
 }
+
+// TestXAddrsURLs tests XAddrs.URLs
+func TestXAddrsURLs(t *testing.T) {
+	type testData struct {
+		xaddrs XAddrs
+		filter XAddrsFilter
+		urls   []string
+	}
+
+	tests := []testData{
+		{
+			// Ordering: HTTPS first, link-local last
+			xaddrs: XAddrs{
+				"http://[fe80::1%25eth0]:5358/",
+				"http://169.254.1.1:5358/",
+				"http://192.168.1.102:5358/",
+				"https://192.168.1.102:5359/",
+				"http://printer.local:5358/",
+			},
+			urls: []string{
+				"https://192.168.1.102:5359/",
+				"http://192.168.1.102:5358/",
+				"http://printer.local:5358/",
+				"http://[fe80::1%25eth0]:5358/",
+				"http://169.254.1.1:5358/",
+			},
+		},
+
+		{
+			// Link-local without zone is dropped, if zone
+			// is not known
+			xaddrs: XAddrs{
+				"http://[fe80::1]:5358/",
+				"http://[2001:db8::1]:5358/",
+			},
+			urls: []string{
+				"http://[2001:db8::1]:5358/",
+			},
+		},
+
+		{
+			// Zone is added to link-local addresses
+			xaddrs: XAddrs{
+				"http://[fe80::1]:5358/",
+				"http://[fe80::2]/",
+			},
+			filter: XAddrsFilter{Zone: "eth0"},
+			urls: []string{
+				"http://[fe80::1%25eth0]:5358/",
+				"http://[fe80::2%25eth0]/",
+			},
+		},
+
+		{
+			// Filtering by address family
+			xaddrs: XAddrs{
+				"http://192.168.1.102:5358/",
+				"http://[2001:db8::1]:5358/",
+				"http://printer.local:5358/",
+			},
+			filter: XAddrsFilter{NoIP6: true},
+			urls: []string{
+				"http://192.168.1.102:5358/",
+				"http://printer.local:5358/",
+			},
+		},
+
+		{
+			xaddrs: XAddrs{
+				"http://192.168.1.102:5358/",
+				"http://[2001:db8::1]:5358/",
+			},
+			filter: XAddrsFilter{NoIP4: true},
+			urls: []string{
+				"http://[2001:db8::1]:5358/",
+			},
+		},
+
+		{
+			// Invalid URLs and duplicates are dropped
+			xaddrs: XAddrs{
+				"invalid-url",
+				"ftp://192.168.1.102/",
+				"http://192.168.1.102:80/",
+				"http://192.168.1.102/",
+			},
+			urls: []string{
+				"http://192.168.1.102/",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		urls := []string{}
+		for _, u := range test.xaddrs.URLs(test.filter) {
+			urls = append(urls, u.String())
+		}
+
+		if !reflect.DeepEqual(urls, test.urls) {
+			t.Errorf("%q.URLs(%+v):\nexpected: %q\npresent:  %q",
+				test.xaddrs, test.filter, test.urls, urls)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Eventing
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Package documentation
+
+// Package wseventing implements the client side of WS-Eventing,
+// as used by the Web Services for Devices (WSD).
+//
+// The [Sink] is the HTTP endpoint (http.Handler), that receives
+// event notifications from devices.
+//
+// The [Manager] subscribes to device events, using the [Sink]
+// as the notification destination, and automatically renews
+// subscriptions before they expire.
+//
+// WS-Eventing messages themselves are implemented by the [wsd] package.
+//
+// [wsd]: https://pkg.go.dev/github.com/OpenPrinting/go-mfp/proto/wsd
+package wseventing
@@ -0,0 +1,342 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Eventing
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Subscription manager
+
+package wseventing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+)
+
+// Manager parameters:
+const (
+	// managerDefaultExpires is the subscription duration, requested
+	// by the Manager, if not specified by the caller.
+	managerDefaultExpires = time.Hour
+
+	// managerMinRenew is the minimal interval between renewals.
+	managerMinRenew = time.Second
+
+	// managerUnsubscribeTimeout is the timeout for the Unsubscribe
+	// request, sent when Subscription is closed.
+	managerUnsubscribeTimeout = 5 * time.Second
+)
+
+// ErrSubscriptionEnd is returned by the [Subscription.Err], when
+// subscription was terminated by device with the SubscriptionEnd
+// message.
+var ErrSubscriptionEnd = errors.New("WS-Eventing: subscription ended by device")
+
+// Manager subscribes to device events and keeps subscriptions
+// alive, renewing them before they expire.
+//
+// Events are received by the [Sink], shared between all
+// subscriptions of the Manager.
+type Manager struct {
+	sink     *Sink           // Event sink
+	notifyTo wsd.AnyURI      // Sink URL, as seen by devices
+	soap     *wsd.SOAPClient // SOAP client
+}
+
+// Subscription represents the active event subscription.
+//
+// Subscription is renewed automatically, until closed with the
+// [Subscription.Close], or until renewal fails, or device
+// terminates it with the SubscriptionEnd message. In the last two
+// cases, the [Subscription.Done] channel is closed and the reason
+// is returned by the [Subscription.Err].
+type Subscription struct {
+	mgr     *Manager              // Parent Manager
+	id      wsd.AnyURI            // Identifier, used by the Sink
+	source  *url.URL              // Event source URL
+	manager wsd.EndpointReference // Subscription manager, per device
+	expires time.Duration         // Requested duration
+	handler func(Event)           // Event handler
+	cancel  context.CancelFunc    // Cancels the renewal goroutine
+	done    chan struct{}         // Closed when subscription ends
+	err     error                 // Why subscription has ended
+	once    sync.Once             // Makes finish idempotent
+}
+
+// NewManager creates a new [Manager].
+//
+// notifyTo is the URL where sink is served, as seen by devices
+// (i.e., using the local address of the network interface, that
+// faces the device).
+//
+// If tr is nil, [transport.NewTransport] will be used to create
+// a new transport.
+func NewManager(sink *Sink, notifyTo *url.URL,
+	tr *transport.Transport) *Manager {
+
+	return &Manager{
+		sink:     sink,
+		notifyTo: wsd.AnyURI(notifyTo.String()),
+		soap: &wsd.SOAPClient{
+			Proto:      "WS-Eventing",
+			Ns:         wsd.NsMap,
+			HTTPClient: transport.NewClient(tr),
+		},
+	}
+}
+
+// Subscribe subscribes to events with the specified actions,
+// generated by the event source (i.e., the WS-Scan or WS-Print
+// service endpoint), and starts automatic renewal of the
+// subscription.
+//
+// expires is the requested subscription duration. If 0, the
+// reasonable default is used. Devices may grant a different
+// duration, and the renewal interval is chosen accordingly.
+//
+// The handler is called for each received event, including the
+// SubscriptionEnd notification, from the HTTP server goroutine,
+// so it must not block.
+//
+// The ctx is used only for the Subscribe request itself. The
+// renewal runs until the Subscription is closed.
+func (mgr *Manager) Subscribe(ctx context.Context, source *url.URL,
+	filter []wsd.AnyURI, expires time.Duration,
+	handler func(Event)) (*Subscription, error) {
+
+	if expires <= 0 {
+		expires = managerDefaultExpires
+	}
+
+	renewCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	sub := &Subscription{
+		mgr:     mgr,
+		id:      wsd.AnyURI(uuid.Must(uuid.Random()).URN()),
+		source:  transport.URLClone(source),
+		expires: expires,
+		handler: handler,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	// Register in the Sink in advance, as some devices send
+	// the initial events even before the SubscribeResponse.
+	mgr.sink.register(sub.id, sub.event)
+
+	sinkRef := wsd.EndpointReference{
+		Address:    mgr.notifyTo,
+		Identifier: optional.New(sub.id),
+	}
+
+	body := wsd.Subscribe{
+		EndTo:    optional.New(sinkRef),
+		NotifyTo: sinkRef,
+		Expires:  expires,
+		Filter:   filter,
+	}
+
+	rsp, err := mgr.call(ctx, sub.source, nil, body)
+	if err == nil {
+		if _, ok := rsp.(wsd.SubscribeResponse); !ok {
+			err = fmt.Errorf("WS-Eventing: unexpected response: %s",
+				rsp.Action())
+		}
+	}
+
+	if err != nil {
+		sub.once.Do(func() { sub.finish(err) })
+		return nil, err
+	}
+
+	subrsp := rsp.(wsd.SubscribeResponse)
+
+	sub.manager = subrsp.SubscriptionManager
+	log.Debug(ctx, "WS-Eventing: %s: subscribed for %s",
+		sub.source, subrsp.Expires)
+
+	// Start renewal goroutine
+	go sub.renewLoop(renewCtx, subrsp.Expires)
+
+	return sub, nil
+}
+
+// Done returns a channel, that is closed when subscription ends.
+func (sub *Subscription) Done() <-chan struct{} {
+	return sub.done
+}
+
+// Err returns the reason why subscription has ended, or nil,
+// if it is still active or closed with the [Subscription.Close].
+//
+// It is only meaningful after the [Subscription.Done] channel
+// is closed.
+func (sub *Subscription) Err() error {
+	select {
+	case <-sub.done:
+		return sub.err
+	default:
+	}
+	return nil
+}
+
+// Close stops renewal and cancels the subscription, sending the
+// Unsubscribe request to the device, if subscription is still
+// active.
+//
+// As it may be called when ctx is already canceled, the request
+// is performed with the separate context with timeout.
+func (sub *Subscription) Close(ctx context.Context) error {
+	var err error
+	active := false
+
+	sub.once.Do(func() {
+		active = true
+		sub.finish(nil)
+	})
+
+	if active {
+		ctx, cancel := context.WithTimeout(
+			context.WithoutCancel(ctx), managerUnsubscribeTimeout)
+		defer cancel()
+
+		_, err = sub.mgr.call(ctx, sub.managerURL(),
+			sub.manager.Identifier, wsd.Unsubscribe{})
+	}
+
+	return err
+}
+
+// finish terminates the subscription. It must be called under
+// the sub.once.
+func (sub *Subscription) finish(err error) {
+	sub.mgr.sink.unregister(sub.id)
+	sub.err = err
+	sub.cancel()
+	close(sub.done)
+}
+
+// event handles events, received by the Sink.
+func (sub *Subscription) event(evnt Event) {
+	if evnt.Action == wsd.ActSubscriptionEnd.Encode() {
+		sub.once.Do(func() { sub.finish(ErrSubscriptionEnd) })
+	}
+
+	sub.handler(evnt)
+}
+
+// renewLoop renews the subscription before it expires.
+func (sub *Subscription) renewLoop(ctx context.Context,
+	granted time.Duration) {
+
+	for {
+		// Renew at the half of the granted duration. If device
+		// doesn't report duration, use the requested one.
+		if granted <= 0 {
+			granted = sub.expires
+		}
+
+		timer := time.NewTimer(max(granted/2, managerMinRenew))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		var err error
+		granted, err = sub.renew(ctx)
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			log.Error(ctx, "WS-Eventing: %s: Renew: %s",
+				sub.source, err)
+			sub.once.Do(func() { sub.finish(err) })
+			return
+		}
+
+		log.Debug(ctx, "WS-Eventing: %s: renewed for %s",
+			sub.source, granted)
+	}
+}
+
+// renew sends the Renew request and returns the granted duration.
+func (sub *Subscription) renew(ctx context.Context) (time.Duration, error) {
+	rsp, err := sub.mgr.call(ctx, sub.managerURL(),
+		sub.manager.Identifier, wsd.Renew{Expires: sub.expires})
+	if err != nil {
+		return 0, err
+	}
+
+	renewrsp, ok := rsp.(wsd.RenewResponse)
+	if !ok {
+		return 0, fmt.Errorf("WS-Eventing: unexpected response: %s",
+			rsp.Action())
+	}
+
+	return renewrsp.Expires, nil
+}
+
+// managerURL returns URL of the subscription manager.
+//
+// Devices often return URN or relative path instead of the proper
+// HTTP URL. In this case, the event source URL is used.
+func (sub *Subscription) managerURL() *url.URL {
+	u, err := sub.source.Parse(string(sub.manager.Address))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return sub.source
+	}
+	return u
+}
+
+// call sends the WS-Eventing request and returns the response body.
+func (mgr *Manager) call(ctx context.Context, u *url.URL,
+	id optional.Val[wsd.AnyURI], body wsd.Body) (wsd.Body, error) {
+
+	msg := wsd.Msg{
+		Header: wsd.NewRequestHeader(body.Action(),
+			wsd.AnyURI(u.String())),
+		Body: body,
+	}
+	msg.Header.Identifier = id
+
+	// Note, SOAP faults come with HTTP error status, and
+	// returned as wsd.Fault errors.
+	httpRsp, err := mgr.soap.Post(ctx, u, body.Action().Encode(),
+		wsd.SOAPContentType, bytes.NewReader(msg.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	defer httpRsp.Body.Close()
+
+	data, err := mgr.soap.ReadXML(httpRsp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := wsd.DecodeMsg(data)
+	if err != nil {
+		return nil, fmt.Errorf("WS-Eventing: %w", err)
+	}
+
+	if fault, ok := rsp.Body.(wsd.Fault); ok {
+		return nil, fault
+	}
+
+	return rsp.Body, nil
+}
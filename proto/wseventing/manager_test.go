@@ -0,0 +1,323 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Eventing
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Subscription manager and event sink test
+
+package wseventing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// testScanAvailableEvent is the action of the event, used by test
+const testScanAvailableEvent = "http://schemas.microsoft.com/windows/2006/08/wdp/scan/ScanAvailableEvent"
+
+// testDevice is the minimal WS-Eventing event source
+type testDevice struct {
+	t        *testing.T            // Test handle
+	notifyTo wsd.EndpointReference // NotifyTo of the last Subscribe
+	endTo    wsd.EndpointReference // EndTo of the last Subscribe
+	filter   []wsd.AnyURI          // Filter of the last Subscribe
+	renewed  chan wsd.AnyURI       // Identifiers of Renew requests
+	unsubs   chan wsd.AnyURI       // Identifiers of Unsubscribe requests
+	lock     sync.Mutex            // Access lock
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (dev *testDevice) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	data, _ := io.ReadAll(rq.Body)
+	msg, err := wsd.DecodeMsg(data)
+	if err != nil {
+		dev.t.Errorf("device: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var rsp wsd.Body
+	switch body := msg.Body.(type) {
+	case wsd.Subscribe:
+		dev.lock.Lock()
+		dev.notifyTo = body.NotifyTo
+		dev.endTo = *body.EndTo
+		dev.filter = body.Filter
+		dev.lock.Unlock()
+
+		rsp = wsd.SubscribeResponse{
+			SubscriptionManager: wsd.EndpointReference{
+				Address:    "http://localhost/manager",
+				Identifier: optional.New(wsd.AnyURI("uuid:dev-1")),
+			},
+			Expires: 2 * time.Second,
+		}
+
+	case wsd.Renew:
+		dev.renewed <- optional.Get(msg.Header.Identifier)
+		rsp = wsd.RenewResponse{Expires: 2 * time.Second}
+
+	case wsd.Unsubscribe:
+		dev.unsubs <- optional.Get(msg.Header.Identifier)
+		rsp = wsd.UnsubscribeResponse{}
+
+	default:
+		dev.t.Errorf("device: unexpected action %s", msg.Header.Action)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reply := wsd.Msg{
+		Header: wsd.Header{
+			Action:    rsp.Action(),
+			MessageID: "urn:uuid:1cf1d308-cb65-494c-9d60-2232c57462e1",
+			RelatesTo: optional.New(msg.Header.MessageID),
+		},
+		Body: rsp,
+	}
+
+	w.Header().Set("Content-Type", "application/soap+xml")
+	w.Write(reply.Encode())
+}
+
+// send sends the event notification to the sink and returns
+// HTTP status.
+func (dev *testDevice) send(clnt *transport.Client,
+	ref wsd.EndpointReference, action string,
+	body xmldoc.Element) int {
+
+	hdr := xmldoc.WithChildren(wsd.NsSOAP+":Header",
+		xmldoc.WithText(wsd.NsAddressing+":Action", action),
+		xmldoc.WithText(wsd.NsAddressing+":MessageID",
+			"urn:uuid:9a6942f8-f5dd-47fc-a4c4-9af559a2bc1a"),
+		xmldoc.WithText(wsd.NsAddressing+":To", string(ref.Address)),
+		xmldoc.WithText(wsd.NsEventing+":Identifier",
+			string(optional.Get(ref.Identifier))),
+	)
+
+	env := xmldoc.WithChildren(wsd.NsSOAP+":Envelope", hdr,
+		xmldoc.WithChildren(wsd.NsSOAP+":Body", body))
+
+	u := transport.MustParseURL(string(ref.Address))
+	rq, _ := transport.NewRequest(context.Background(), "POST", u,
+		bytes.NewReader([]byte(env.EncodeString(wsd.NsMap))))
+
+	rsp, err := clnt.Do(rq)
+	if err != nil {
+		dev.t.Errorf("device: %s", err)
+		return 0
+	}
+
+	rsp.Body.Close()
+	return rsp.StatusCode
+}
+
+// TestManager tests Manager and Sink
+func TestManager(t *testing.T) {
+	// Setup device and sink
+	dev := &testDevice{
+		t:       t,
+		renewed: make(chan wsd.AnyURI, 10),
+		unsubs:  make(chan wsd.AnyURI, 10),
+	}
+
+	devTr, devLoopback := transport.NewLoopback()
+	devServer := transport.NewServer(nil, dev)
+	go devServer.Serve(devLoopback)
+	defer devServer.Close()
+
+	sink := NewSink(nil)
+	sinkTr, sinkLoopback := transport.NewLoopback()
+	sinkServer := transport.NewServer(nil, sink)
+	go sinkServer.Serve(sinkLoopback)
+	defer sinkServer.Close()
+
+	sinkClient := transport.NewClient(sinkTr)
+
+	mgr := NewManager(sink, transport.MustParseURL("http://localhost/sink"),
+		devTr)
+
+	// Subscribe
+	events := make(chan Event, 10)
+	filter := []wsd.AnyURI{testScanAvailableEvent}
+	ctx := context.Background()
+
+	sub, err := mgr.Subscribe(ctx,
+		transport.MustParseURL("http://localhost/scanner"),
+		filter, time.Hour, func(evnt Event) { events <- evnt })
+
+	if err != nil {
+		t.Fatalf("Subscribe: %s", err)
+	}
+
+	dev.lock.Lock()
+	notifyTo, endTo, devFilter := dev.notifyTo, dev.endTo, dev.filter
+	dev.lock.Unlock()
+
+	if notifyTo.Address != "http://localhost/sink" ||
+		notifyTo.Identifier == nil {
+		t.Fatalf("Subscribe: bad NotifyTo: %#v", notifyTo)
+	}
+
+	if len(devFilter) != 1 || devFilter[0] != testScanAvailableEvent {
+		t.Errorf("Subscribe: bad Filter: %v", devFilter)
+	}
+
+	// Deliver the event
+	status := dev.send(sinkClient, notifyTo, testScanAvailableEvent,
+		xmldoc.WithChildren(wsd.NsScan+":ScanAvailableEvent",
+			xmldoc.WithText(wsd.NsScan+":ClientContext", "Scan")))
+
+	if status != http.StatusAccepted {
+		t.Errorf("event: HTTP status %d", status)
+	}
+
+	select {
+	case evnt := <-events:
+		if evnt.Action != testScanAvailableEvent ||
+			evnt.Identifier != *notifyTo.Identifier ||
+			evnt.Body.Name != wsd.NsScan+":ScanAvailableEvent" {
+			t.Errorf("event: unexpected %#v", evnt)
+		}
+	default:
+		t.Errorf("event: not delivered")
+	}
+
+	// Subscription must be renewed with the device's identifier
+	select {
+	case id := <-dev.renewed:
+		if id != "uuid:dev-1" {
+			t.Errorf("Renew: bad Identifier %q", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Renew: timeout")
+	}
+
+	// Close must send Unsubscribe
+	err = sub.Close(ctx)
+	if err != nil {
+		t.Errorf("Close: %s", err)
+	}
+
+	select {
+	case id := <-dev.unsubs:
+		if id != "uuid:dev-1" {
+			t.Errorf("Unsubscribe: bad Identifier %q", id)
+		}
+	default:
+		t.Errorf("Unsubscribe: not received")
+	}
+
+	if sub.Err() != nil {
+		t.Errorf("Err: expected nil, present %s", sub.Err())
+	}
+
+	// Events after Close are rejected
+	status = dev.send(sinkClient, notifyTo, testScanAvailableEvent,
+		xmldoc.Element{Name: wsd.NsScan + ":ScanAvailableEvent"})
+	if status != http.StatusNotFound {
+		t.Errorf("event after Close: HTTP status %d", status)
+	}
+
+	// SubscriptionEnd terminates the subscription
+	sub, err = mgr.Subscribe(ctx,
+		transport.MustParseURL("http://localhost/scanner"),
+		filter, 0, func(evnt Event) { events <- evnt })
+
+	if err != nil {
+		t.Fatalf("Subscribe: %s", err)
+	}
+
+	dev.lock.Lock()
+	endTo = dev.endTo
+	dev.lock.Unlock()
+
+	end := wsd.SubscriptionEnd{
+		SubscriptionManager: wsd.EndpointReference{
+			Address: "http://localhost/manager",
+		},
+		Status: wsd.SubscriptionEndSourceShuttingDown,
+	}
+
+	dev.send(sinkClient, endTo, wsd.ActSubscriptionEnd.Encode(),
+		end.ToXML())
+
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("SubscriptionEnd: subscription still active")
+	}
+
+	if !errors.Is(sub.Err(), ErrSubscriptionEnd) {
+		t.Errorf("Err: expected %s, present %v",
+			ErrSubscriptionEnd, sub.Err())
+	}
+
+	// Close after SubscriptionEnd must not send Unsubscribe
+	sub.Close(ctx)
+	select {
+	case <-dev.unsubs:
+		t.Errorf("Unsubscribe: sent after SubscriptionEnd")
+	default:
+	}
+}
+
+// TestSinkErrors tests Sink handling of invalid requests
+func TestSinkErrors(t *testing.T) {
+	sink := NewSink(nil)
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, sink)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	clnt := transport.NewClient(tr)
+	u := transport.MustParseURL("http://localhost/sink")
+
+	type testData struct {
+		method string
+		body   string
+		status int
+	}
+
+	tests := []testData{
+		{"GET", "", http.StatusMethodNotAllowed},
+		{"POST", "garbage", http.StatusBadRequest},
+		{"POST", `<Envelope/>`, http.StatusBadRequest},
+		{
+			"POST",
+			`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">` +
+				`<s:Header/><s:Body/></s:Envelope>`,
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		rq, _ := transport.NewRequest(context.Background(), test.method,
+			u, bytes.NewReader([]byte(test.body)))
+
+		rsp, err := clnt.Do(rq)
+		if err != nil {
+			t.Errorf("%s %q: %s", test.method, test.body, err)
+			continue
+		}
+
+		rsp.Body.Close()
+
+		if rsp.StatusCode != test.status {
+			t.Errorf("%s %q: HTTP status expected %d, present %d",
+				test.method, test.body, test.status, rsp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Eventing
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Event sink
+
+package wseventing
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/util/generic"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// sinkMaxXMLSize is the maximum size of the event notification,
+// accepted by the Sink.
+const sinkMaxXMLSize = 1024 * 1024
+
+// Event represents the event notification, received by the [Sink].
+//
+// The event body is not decoded by the Sink: it is decoded by the
+// service-specific packages (i.e., wsscan or wsprint), depending
+// on the event Action.
+type Event struct {
+	Action     string         // Event action URI
+	MessageID  wsd.AnyURI     // Message identifier
+	Identifier wsd.AnyURI     // Subscription identifier
+	Body       xmldoc.Element // Event body (the s:Body child)
+}
+
+// Sink is the WS-Eventing event sink.
+//
+// It implements the [http.Handler] interface and must be served
+// at the URL, reachable by the devices. This URL is sent to
+// devices as the NotifyTo and EndTo addresses of subscriptions.
+//
+// Events are dispatched to handlers by the subscription
+// identifier, sent by device in the message header. Events
+// with unknown identifier are rejected.
+type Sink struct {
+	ns       xmldoc.Namespace           // Namespace for event bodies
+	handlers map[wsd.AnyURI]func(Event) // Handlers by identifier
+	lock     sync.Mutex                 // Access lock
+}
+
+// NewSink creates a new [Sink].
+//
+// ns is the namespace, used to decode event bodies. Typically,
+// this is the NsMap of the service-specific package (i.e., wsscan
+// or wsprint). It is merged with the [wsd.NsMap], used to decode
+// the message envelope, and may be nil.
+func NewSink(ns xmldoc.Namespace) *Sink {
+	return &Sink{
+		ns:       append(generic.CopySlice(ns), wsd.NsMap...),
+		handlers: make(map[wsd.AnyURI]func(Event)),
+	}
+}
+
+// ServeHTTP implements the [http.Handler] interface.
+func (sink *Sink) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	ctx := rq.Context()
+
+	if rq.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Read and decode the notification
+	data, err := io.ReadAll(io.LimitReader(rq.Body, sinkMaxXMLSize+1))
+	if err == nil && len(data) > sinkMaxXMLSize {
+		err = errors.New("notification too large")
+	}
+
+	var evnt Event
+	if err == nil {
+		evnt, err = sink.decode(data)
+	}
+
+	if err != nil {
+		log.Debug(ctx, "WS-Eventing: sink: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Dispatch the event
+	sink.lock.Lock()
+	handler := sink.handlers[evnt.Identifier]
+	sink.lock.Unlock()
+
+	if handler == nil {
+		log.Debug(ctx, "WS-Eventing: sink: unknown subscription %q",
+			evnt.Identifier)
+		http.Error(w, "Unknown subscription", http.StatusNotFound)
+		return
+	}
+
+	log.Debug(ctx, "WS-Eventing: sink: %s (%s)",
+		evnt.Action, evnt.Identifier)
+
+	handler(evnt)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// decode decodes the event notification.
+func (sink *Sink) decode(data []byte) (evnt Event, err error) {
+	root, err := xmldoc.Decode(sink.ns, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	if root.Name != wsd.NsSOAP+":Envelope" {
+		err = xmldoc.XMLErrMissed(wsd.NsSOAP + ":Envelope")
+		return
+	}
+
+	hdr := xmldoc.Lookup{Name: wsd.NsSOAP + ":Header", Required: true}
+	body := xmldoc.Lookup{Name: wsd.NsSOAP + ":Body", Required: true}
+
+	missed := root.Lookup(&hdr, &body)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	// Event actions are service-specific, so the header is
+	// decoded here by hand rather than by wsd.DecodeHeader,
+	// which accepts only known actions.
+	action := xmldoc.Lookup{Name: wsd.NsAddressing + ":Action",
+		Required: true}
+	messageID := xmldoc.Lookup{Name: wsd.NsAddressing + ":MessageID"}
+	identifier := xmldoc.Lookup{Name: wsd.NsEventing + ":Identifier",
+		Required: true}
+
+	missed = hdr.Elem.Lookup(&action, &messageID, &identifier)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		err = xmldoc.XMLErrWrap(hdr.Elem, err)
+		return
+	}
+
+	evnt.Action = action.Elem.Text
+	evnt.MessageID = wsd.AnyURI(messageID.Elem.Text)
+	evnt.Identifier, err = wsd.DecodeAnyURI(identifier.Elem)
+	if err != nil {
+		err = xmldoc.XMLErrWrap(hdr.Elem, err)
+		return
+	}
+
+	if len(body.Elem.Children) != 0 {
+		evnt.Body = body.Elem.Children[0]
+	}
+
+	return
+}
+
+// register registers the event handler for the subscription
+// identifier.
+func (sink *Sink) register(id wsd.AnyURI, handler func(Event)) {
+	sink.lock.Lock()
+	sink.handlers[id] = handler
+	sink.lock.Unlock()
+}
+
+// unregister removes the event handler for the subscription
+// identifier.
+func (sink *Sink) unregister(id wsd.AnyURI) {
+	sink.lock.Lock()
+	delete(sink.handlers, id)
+	sink.lock.Unlock()
+}
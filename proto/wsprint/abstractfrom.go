@@ -0,0 +1,70 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conversions from abstract.Printer to WS-Print data structures
+
+package wsprint
+
+import (
+	"slices"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// fromAbstractPrinterName is the PrinterName, used when
+// abstract.PrinterCapabilities doesn't define MakeAndModel.
+const fromAbstractPrinterName = "Printer"
+
+// fromAbstractPrinterElements converts [abstract.PrinterCapabilities]
+// into the [PrinterElements].
+//
+// Description, Capabilities and DefaultPrintTicket are filled.
+// Status is dynamic and left nil.
+func fromAbstractPrinterElements(
+	caps *abstract.PrinterCapabilities) PrinterElements {
+
+	desc := &PrinterDescription{
+		PrinterName:                   caps.MakeAndModel,
+		ColorSupported:                caps.Color,
+		MultipleDocumentJobsSupported: true,
+	}
+
+	if desc.PrinterName == "" {
+		desc.PrinterName = fromAbstractPrinterName
+	}
+
+	sides := []Sides{SidesOneSided}
+	if caps.Duplex {
+		sides = append(sides, SidesTwoSidedLongEdge,
+			SidesTwoSidedShortEdge)
+	}
+
+	prncaps := &PrinterCapabilities{
+		FormatsSupported: slices.Clone(caps.DocumentFormats),
+		CopiesSupported: optional.New(ValueRange{
+			Min: 1,
+			Max: max(caps.MaxCopies, 1),
+		}),
+		SidesSupported:      sides,
+		MediaSizesSupported: slices.Clone(caps.MediaSizes),
+	}
+
+	ticket := &PrintTicket{
+		Copies: optional.New(1),
+		Sides:  optional.New(SidesOneSided),
+	}
+
+	if len(caps.MediaSizes) != 0 {
+		ticket.MediaSizeName = optional.New(caps.MediaSizes[0])
+	}
+
+	return PrinterElements{
+		Description:        desc,
+		Capabilities:       prncaps,
+		DefaultPrintTicket: ticket,
+	}
+}
@@ -0,0 +1,550 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Print server on a top of abstract.Printer
+
+package wsprint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/printjobs"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// AbstractServer parameters:
+const (
+	// abstractServerMaxXMLSize is the maximum size of the
+	// SOAP request, accepted by the AbstractServer.
+	abstractServerMaxXMLSize = 1024 * 1024
+
+	// AbstractServerJobTimeout is the inactivity timeout of the
+	// print job. Abandoned job, that doesn't receive documents
+	// for this time, is aborted.
+	AbstractServerJobTimeout = printjobs.Timeout
+)
+
+// AbstractServer implements WS-Print server on a top of
+// [abstract.Printer].
+//
+// It serves the GetPrinterElements, CreatePrintJob, SendDocument,
+// GetJobElements and CancelJob requests. PrinterElements are
+// generated from the [abstract.PrinterCapabilities]. Documents
+// are expected in the MTOM encoding and streamed to the printer
+// as they arrive.
+//
+// Printer prints one document at a time. The job is completed,
+// when its last document is printed.
+//
+// Discovery is not handled here, see wsd.Responder.
+type AbstractServer struct {
+	ctx      context.Context               // Logging context
+	options  AbstractServerOptions         // Server options
+	caps     *abstract.PrinterCapabilities // Printer capabilities
+	elements PrinterElements               // Static printer elements
+	jobs     *printjobs.Jobs[struct{}]     // Print jobs
+}
+
+// AbstractServerOptions represents the [AbstractServer] creation
+// options.
+type AbstractServerOptions struct {
+	Printer abstract.Printer // Underlying abstract.Printer
+
+	// PrinterInfo and PrinterLocation, if set, are reported in
+	// the PrinterDescription. PrinterName comes from the
+	// [abstract.PrinterCapabilities].
+	PrinterInfo     string
+	PrinterLocation string
+}
+
+// abstractServerJob represents the print job.
+type abstractServerJob = printjobs.Job[struct{}]
+
+// NewAbstractServer returns a new [AbstractServer].
+func NewAbstractServer(ctx context.Context,
+	options AbstractServerOptions) *AbstractServer {
+
+	caps := options.Printer.Capabilities()
+
+	srv := &AbstractServer{
+		ctx:      ctx,
+		options:  options,
+		caps:     caps,
+		elements: fromAbstractPrinterElements(caps),
+		jobs:     printjobs.New[struct{}](ctx, "WS-Print", options.Printer),
+	}
+
+	srv.elements.Description.PrinterInfo = options.PrinterInfo
+	srv.elements.Description.PrinterLocation = options.PrinterLocation
+
+	return srv
+}
+
+// Close closes the AbstractServer and cancels all active jobs.
+func (srv *AbstractServer) Close() {
+	srv.jobs.Close()
+}
+
+// ServeHTTP serves incoming HTTP requests.
+// It implements the [http.Handler] interface.
+func (srv *AbstractServer) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	if rq.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// SendDocument comes MTOM-encoded. The first part is the
+	// SOAP envelope, the document data follows.
+	var body io.Reader = rq.Body
+	var mr *multipart.Reader
+
+	ct, params, _ := mime.ParseMediaType(rq.Header.Get("Content-Type"))
+	if ct == "multipart/related" {
+		mr = multipart.NewReader(rq.Body, params["boundary"])
+	}
+
+	var err error
+	if mr != nil {
+		body, err = mr.NextPart()
+	}
+
+	// Read and decode the request
+	var data []byte
+	if err == nil {
+		data, err = io.ReadAll(io.LimitReader(body,
+			abstractServerMaxXMLSize+1))
+	}
+
+	if err == nil && len(data) > abstractServerMaxXMLSize {
+		err = errors.New("request too large")
+	}
+
+	var action, msgid string
+	var elm xmldoc.Element
+
+	if err == nil {
+		action, msgid, elm, err = srv.decode(data)
+	}
+
+	if err != nil {
+		log.Debug(srv.ctx, "WS-Print: %s", err)
+		srv.fault(w, "", soapFault(wsd.FaultCodeSender, "",
+			err.Error()))
+		return
+	}
+
+	log.Debug(srv.ctx, "WS-Print: %s received", path.Base(action))
+
+	// Dispatch the request
+	switch action {
+	case ActGetPrinterElements:
+		srv.getPrinterElements(w, msgid, elm)
+	case ActCreatePrintJob:
+		srv.createPrintJob(w, msgid, elm)
+	case ActSendDocument:
+		srv.sendDocument(w, msgid, elm, mr)
+	case ActGetJobElements:
+		srv.getJobElements(w, msgid, elm)
+	case ActCancelJob:
+		srv.cancelJob(w, msgid, elm)
+	default:
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			fmt.Sprintf("%s: unsupported action", action)))
+	}
+}
+
+// decode decodes the SOAP request and returns its action,
+// message ID and the first child of the SOAP body.
+func (srv *AbstractServer) decode(data []byte) (action, msgid string,
+	body xmldoc.Element, err error) {
+
+	root, err := xmldoc.Decode(NsMap, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	hdr := xmldoc.Lookup{Name: NsSOAP + ":Header", Required: true}
+	bodyElm := xmldoc.Lookup{Name: NsSOAP + ":Body", Required: true}
+
+	if missed := root.Lookup(&hdr, &bodyElm); missed != nil {
+		err = xmldoc.XMLErrWrap(root, xmldoc.XMLErrMissed(missed.Name))
+		return
+	}
+
+	act := xmldoc.Lookup{Name: NsAddressing + ":Action", Required: true}
+	id := xmldoc.Lookup{Name: NsAddressing + ":MessageID"}
+
+	if missed := hdr.Elem.Lookup(&act, &id); missed != nil {
+		err = xmldoc.XMLErrWrap(hdr.Elem,
+			xmldoc.XMLErrMissed(missed.Name))
+		err = xmldoc.XMLErrWrap(root, err)
+		return
+	}
+
+	action = act.Elem.Text
+	msgid = id.Elem.Text
+
+	if len(bodyElm.Elem.Children) != 0 {
+		body = bodyElm.Elem.Children[0]
+	}
+
+	return
+}
+
+// getPrinterElements handles the GetPrinterElements request.
+func (srv *AbstractServer) getPrinterElements(w http.ResponseWriter,
+	msgid string, body xmldoc.Element) {
+
+	requested, _ := body.ChildByName(NsPrint + ":RequestedElements")
+
+	var elements PrinterElements
+	var invalid []string
+
+	for _, chld := range requested.Children {
+		if chld.Name != NsPrint+":Name" {
+			continue
+		}
+
+		switch name := chld.Text; name {
+		case ElementPrinterDescription:
+			elements.Description = srv.elements.Description
+		case ElementPrinterCapabilities:
+			elements.Capabilities = srv.elements.Capabilities
+		case ElementPrinterStatus:
+			elements.Status = srv.status()
+		case ElementDefaultPrintTicket:
+			elements.DefaultPrintTicket =
+				srv.elements.DefaultPrintTicket
+		default:
+			invalid = append(invalid, name)
+		}
+	}
+
+	// Unknown elements are reported as invalid
+	rsp := elements.ToXML()
+	list := &rsp.Children[0]
+	for _, name := range invalid {
+		list.Children = append(list.Children,
+			abstractServerInvalidElement(name))
+	}
+
+	srv.reply(w, ActGetPrinterElementsResponse, msgid, rsp)
+}
+
+// status returns the current PrinterStatus.
+func (srv *AbstractServer) status() *PrinterStatus {
+	status := &PrinterStatus{
+		PrinterState:              PrinterIdle,
+		PrinterPrimaryStateReason: "None",
+	}
+
+	for _, job := range srv.jobs.List() {
+		if !job.Status().State.IsFinal() {
+			status.QueuedJobCount++
+		}
+	}
+
+	if status.QueuedJobCount != 0 {
+		status.PrinterState = PrinterProcessing
+	}
+
+	return status
+}
+
+// createPrintJob handles the CreatePrintJob request.
+func (srv *AbstractServer) createPrintJob(w http.ResponseWriter,
+	msgid string, body xmldoc.Element) {
+
+	// Decode the PrintTicket
+	elm, ok := body.ChildByName(NsPrint + ":PrintTicket")
+	if !ok {
+		err := xmldoc.XMLErrWrap(body,
+			xmldoc.XMLErrMissed(NsPrint+":PrintTicket"))
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			err.Error()))
+		return
+	}
+
+	ticket, err := DecodePrintTicket(elm)
+	if err != nil {
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			err.Error()))
+		return
+	}
+
+	req := ticket.ToAbstract()
+	err = req.Validate(srv.caps)
+	if err != nil {
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender,
+			FaultClientErrorAttributesNotSupported, err.Error()))
+		return
+	}
+
+	// Create the job
+	job := srv.jobs.Create(req, struct{}{})
+
+	rsp := CreatePrintJobResponse{JobID: job.ID()}
+	srv.reply(w, ActCreatePrintJobResponse, msgid, rsp.ToXML())
+}
+
+// sendDocument handles the SendDocument request.
+//
+// The document data is taken from the next part of the
+// MTOM-encoded request.
+func (srv *AbstractServer) sendDocument(w http.ResponseWriter,
+	msgid string, body xmldoc.Element, mr *multipart.Reader) {
+
+	// Decode the request
+	desc := xmldoc.Lookup{Name: NsPrint + ":DocumentDescription"}
+	last := xmldoc.Lookup{Name: NsPrint + ":LastDocument"}
+	body.Lookup(&desc, &last)
+
+	format := xmldoc.Lookup{Name: NsPrint + ":Format"}
+	desc.Elem.Lookup(&format)
+
+	lastDoc := false
+	if last.Found {
+		var err error
+		lastDoc, err = decodeBool(last.Elem)
+		if err != nil {
+			err = xmldoc.XMLErrWrap(body, err)
+			srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+				err.Error()))
+			return
+		}
+	}
+
+	job, fault := srv.lookupJob(body)
+	if job == nil {
+		srv.fault(w, msgid, fault)
+		return
+	}
+
+	// Check the document
+	req := job.Request
+	req.DocumentFormat = format.Elem.Text
+
+	err := req.Validate(srv.caps)
+	if err != nil {
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender,
+			FaultClientErrorFormatNotSupported, err.Error()))
+		return
+	}
+
+	var part *multipart.Part
+	if mr != nil {
+		part, err = mr.NextPart()
+	}
+
+	if part == nil {
+		if err == nil {
+			err = errors.New("missed document data")
+		}
+
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			err.Error()))
+		return
+	}
+
+	// Print the document
+	err = srv.jobs.Print(job, req.DocumentFormat, part, lastDoc)
+	switch {
+	case errors.Is(err, printjobs.ErrNotAccepting):
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			"Job is not accepting documents"))
+		return
+
+	case err != nil:
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeReceiver,
+			FaultServerErrorInternalError, err.Error()))
+		return
+	}
+
+	srv.reply(w, ActSendDocumentResponse, msgid,
+		xmldoc.Element{Name: NsPrint + ":SendDocumentResponse"})
+}
+
+// getJobElements handles the GetJobElements request.
+func (srv *AbstractServer) getJobElements(w http.ResponseWriter,
+	msgid string, body xmldoc.Element) {
+
+	job, fault := srv.lookupJob(body)
+	if job == nil {
+		srv.fault(w, msgid, fault)
+		return
+	}
+
+	status := srv.jobStatus(job)
+
+	requested, _ := body.ChildByName(NsPrint + ":RequestedElements")
+	list := xmldoc.Element{Name: NsPrint + ":JobElements"}
+
+	for _, chld := range requested.Children {
+		if chld.Name != NsPrint+":Name" {
+			continue
+		}
+
+		name := chld.Text
+		if name != ElementJobStatus {
+			list.Children = append(list.Children,
+				abstractServerInvalidElement(name))
+			continue
+		}
+
+		data := xmldoc.WithChildren(NsPrint+":ElementData",
+			status.ToXML())
+		data.Attrs = []xmldoc.Attr{
+			{Name: "Name", Value: name},
+			{Name: "Valid", Value: "true"},
+		}
+		list.Children = append(list.Children, data)
+	}
+
+	srv.reply(w, ActGetJobElementsResponse, msgid,
+		xmldoc.WithChildren(NsPrint+":GetJobElementsResponse", list))
+}
+
+// cancelJob handles the CancelJob request.
+func (srv *AbstractServer) cancelJob(w http.ResponseWriter,
+	msgid string, body xmldoc.Element) {
+
+	job, fault := srv.lookupJob(body)
+	if job == nil {
+		srv.fault(w, msgid, fault)
+		return
+	}
+
+	err := srv.jobs.Cancel(job)
+	if err != nil {
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			"Job is already finished"))
+		return
+	}
+
+	srv.reply(w, ActCancelJobResponse, msgid,
+		xmldoc.Element{Name: NsPrint + ":CancelJobResponse"})
+}
+
+// lookupJob returns the job, identified by the JobId element
+// of the request.
+//
+// If job is not found, it returns nil job and the Fault.
+func (srv *AbstractServer) lookupJob(body xmldoc.Element) (
+	*abstractServerJob, wsd.Fault) {
+
+	id := xmldoc.Lookup{Name: NsPrint + ":JobId", Required: true}
+
+	if missed := body.Lookup(&id); missed != nil {
+		err := xmldoc.XMLErrWrap(body, xmldoc.XMLErrMissed(missed.Name))
+		return nil, soapFault(wsd.FaultCodeSender, "", err.Error())
+	}
+
+	jobID, err := strconv.Atoi(id.Elem.Text)
+	if err == nil {
+		job := srv.jobs.Lookup(jobID)
+		if job != nil {
+			return job, wsd.Fault{}
+		}
+	}
+
+	return nil, soapFault(wsd.FaultCodeSender,
+		FaultClientErrorJobIDNotFound, "Job not found")
+}
+
+// jobStatus returns the WS-Print status of the job.
+func (srv *AbstractServer) jobStatus(job *abstractServerJob) JobStatus {
+	st := job.Status()
+
+	status := JobStatus{
+		JobID:             st.ID,
+		JobStateReasons:   []string{"None"},
+		KOctetsProcessed:  int((st.Octets + 1023) / 1024),
+		NumberOfDocuments: st.Documents,
+	}
+
+	switch st.State {
+	case printjobs.Pending:
+		status.JobState = JobPending
+	case printjobs.Processing:
+		status.JobState = JobProcessing
+	case printjobs.Completed:
+		status.JobState = JobCompleted
+	case printjobs.Canceled:
+		status.JobState = JobCanceled
+	case printjobs.Aborted:
+		status.JobState = JobAborted
+	}
+
+	switch st.Reason {
+	case printjobs.ReasonCompletedSuccessfully:
+		status.JobStateReasons = []string{"JobCompletedSuccessfully"}
+	case printjobs.ReasonCompletedWithErrors:
+		status.JobStateReasons = []string{"JobCompletedWithErrors"}
+	case printjobs.ReasonCanceledByUser:
+		status.JobStateReasons = []string{"JobCanceledByUser"}
+	case printjobs.ReasonCanceledAtDevice:
+		status.JobStateReasons = []string{"JobCanceledAtDevice"}
+	case printjobs.ReasonTimedOut:
+		status.JobStateReasons = []string{"JobTimedOut"}
+	}
+
+	return status
+}
+
+// reply sends the SOAP response.
+func (srv *AbstractServer) reply(w http.ResponseWriter,
+	action, relatesTo string, body xmldoc.Element) {
+
+	w.Header().Set("Content-Type", wsd.SOAPContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(wsd.SOAPEncodeResponse(soapResponseNs,
+		action, relatesTo, body))
+}
+
+// fault sends the SOAP Fault.
+//
+// Sender faults are sent with the 400 Bad Request HTTP status,
+// Receiver faults with the 500 Internal Server Error, as SOAP 1.2
+// HTTP binding requires.
+func (srv *AbstractServer) fault(w http.ResponseWriter,
+	relatesTo string, fault wsd.Fault) {
+
+	log.Debug(srv.ctx, "WS-Print: %s", fault)
+
+	status := http.StatusInternalServerError
+	if fault.Code == wsd.FaultCodeSender {
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", wsd.SOAPContentType)
+	w.WriteHeader(status)
+	w.Write(wsd.SOAPEncodeResponse(soapResponseNs,
+		wsd.ActFault.Encode(), relatesTo, fault.ToXML()))
+}
+
+// abstractServerInvalidElement returns the ElementData, that
+// reports the requested element as invalid.
+func abstractServerInvalidElement(name string) xmldoc.Element {
+	data := xmldoc.Element{Name: NsPrint + ":ElementData"}
+	data.Attrs = []xmldoc.Attr{
+		{Name: "Name", Value: name},
+		{Name: "Valid", Value: "false"},
+	}
+	return data
+}
@@ -0,0 +1,208 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AbstractServer test
+
+package wsprint
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// TestAbstractServer tests AbstractServer against the Client.
+func TestAbstractServer(t *testing.T) {
+	type printed struct {
+		req  abstract.PrinterRequest
+		data string
+	}
+
+	var output []printed
+
+	printer := &abstract.VirtualPrinter{
+		PrintCaps: &abstract.PrinterCapabilities{
+			MakeAndModel: "Test Printer",
+			DocumentFormats: []string{
+				abstract.MIMETypePDF,
+				abstract.MIMETypeJPEG,
+			},
+			MediaSizes: []string{"iso_a4_210x297mm"},
+			Color:      true,
+			Duplex:     true,
+			MaxCopies:  10,
+		},
+		Output: func(req abstract.PrinterRequest,
+			file abstract.DocumentFile) error {
+			data, err := io.ReadAll(file)
+			output = append(output, printed{req, string(data)})
+			return err
+		},
+	}
+
+	srv := NewAbstractServer(context.Background(), AbstractServerOptions{
+		Printer:         printer,
+		PrinterLocation: "Office",
+	})
+	defer srv.Close()
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, srv)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	ctx := context.Background()
+	clnt := NewClient(transport.MustParseURL(
+		"http://localhost/WSDPrinter"), tr)
+
+	// Check printer elements
+	elements, err := clnt.GetPrinterElements(ctx)
+	if err != nil {
+		t.Fatalf("GetPrinterElements: %s", err)
+	}
+
+	desc := elements.Description
+	if desc == nil || desc.PrinterName != "Test Printer" ||
+		desc.PrinterLocation != "Office" || !desc.ColorSupported {
+		t.Errorf("PrinterDescription: %+v", desc)
+	}
+
+	expCaps := &PrinterCapabilities{
+		FormatsSupported: printer.PrintCaps.DocumentFormats,
+		CopiesSupported:  optional.New(ValueRange{Min: 1, Max: 10}),
+		SidesSupported: []Sides{
+			SidesOneSided,
+			SidesTwoSidedLongEdge,
+			SidesTwoSidedShortEdge,
+		},
+		MediaSizesSupported: printer.PrintCaps.MediaSizes,
+	}
+
+	if !reflect.DeepEqual(elements.Capabilities, expCaps) {
+		t.Errorf("PrinterCapabilities:\nexpected: %+v\npresent:  %+v",
+			expCaps, elements.Capabilities)
+	}
+
+	if elements.Status == nil ||
+		elements.Status.PrinterState != PrinterIdle {
+		t.Errorf("PrinterStatus: %+v", elements.Status)
+	}
+
+	// Print two-document job
+	ticket := PrintTicket{
+		JobName:       "test",
+		Copies:        optional.New(2),
+		MediaSizeName: optional.New("iso_a4_210x297mm"),
+		Sides:         optional.New(SidesTwoSidedLongEdge),
+	}
+
+	docs := []Document{
+		{"a.pdf", abstract.MIMETypePDF, strings.NewReader("%PDF-A")},
+		{"b.jpg", abstract.MIMETypeJPEG, strings.NewReader("JPEG-B")},
+	}
+
+	jobID, err := clnt.SubmitJob(ctx, ticket, docs)
+	if err != nil {
+		t.Fatalf("SubmitJob: %s", err)
+	}
+
+	status, err := clnt.GetJobStatus(ctx, jobID)
+	if err != nil {
+		t.Fatalf("GetJobStatus: %s", err)
+	}
+
+	if status.JobState != JobCompleted || status.NumberOfDocuments != 2 {
+		t.Errorf("JobStatus: %+v", status)
+	}
+
+	if len(output) != 2 {
+		t.Fatalf("Print: %d documents printed, expected 2",
+			len(output))
+	}
+
+	expReq := abstract.PrinterRequest{
+		JobName:        "test",
+		UserName:       clientUserName(),
+		DocumentFormat: abstract.MIMETypeJPEG,
+		MediaSize:      "iso_a4_210x297mm",
+		Copies:         2,
+		Duplex:         true,
+	}
+
+	if output[1].req != expReq {
+		t.Errorf("PrinterRequest:\nexpected: %+v\npresent:  %+v",
+			expReq, output[1].req)
+	}
+
+	if output[0].data != "%PDF-A" || output[1].data != "JPEG-B" {
+		t.Errorf("Print: data mismatch: %q, %q",
+			output[0].data, output[1].data)
+	}
+
+	// Unsupported parameters
+	_, err = clnt.CreatePrintJob(ctx, PrintTicket{
+		Copies: optional.New(11),
+	})
+
+	testCheckFault(t, "CreatePrintJob", err,
+		FaultClientErrorAttributesNotSupported)
+
+	job, err := clnt.CreatePrintJob(ctx, PrintTicket{})
+	if err != nil {
+		t.Fatalf("CreatePrintJob: %s", err)
+	}
+
+	err = clnt.SendDocument(ctx, job.JobID, 1,
+		Document{"a.png", abstract.MIMETypePNG,
+			strings.NewReader("PNG")}, true)
+
+	testCheckFault(t, "SendDocument", err,
+		FaultClientErrorFormatNotSupported)
+
+	// Cancel the job
+	err = clnt.CancelJob(ctx, job.JobID)
+	if err != nil {
+		t.Fatalf("CancelJob: %s", err)
+	}
+
+	status, err = clnt.GetJobStatus(ctx, job.JobID)
+	if err != nil {
+		t.Fatalf("GetJobStatus: %s", err)
+	}
+
+	if status.JobState != JobCanceled {
+		t.Errorf("JobState: expected %s, present %s",
+			JobCanceled, status.JobState)
+	}
+
+	err = clnt.SendDocument(ctx, job.JobID, 1,
+		Document{"a.pdf", abstract.MIMETypePDF,
+			strings.NewReader("%PDF")}, true)
+	if err == nil {
+		t.Errorf("SendDocument: canceled job accepts documents")
+	}
+
+	// Unknown job
+	_, err = clnt.GetJobStatus(ctx, 12345)
+	testCheckFault(t, "GetJobStatus", err, FaultClientErrorJobIDNotFound)
+}
+
+// testCheckFault checks that err is the wsd.Fault with the
+// expected subcode.
+func testCheckFault(t *testing.T, op string, err error, subcode string) {
+	var fault wsd.Fault
+	if !errors.As(err, &fault) || fault.Subcode != subcode {
+		t.Errorf("%s: expected %s fault, present %v", op, subcode, err)
+	}
+}
@@ -0,0 +1,34 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conversions from WS-Print data structures to abstract.Printer
+
+package wsprint
+
+import (
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// ToAbstract converts [PrintTicket] into the [abstract.PrinterRequest].
+//
+// DocumentFormat is not a part of the PrintTicket. It comes with
+// each document and must be set by the caller.
+func (ticket *PrintTicket) ToAbstract() abstract.PrinterRequest {
+	req := abstract.PrinterRequest{
+		JobName:   ticket.JobName,
+		UserName:  ticket.JobOriginatingUserName,
+		MediaSize: optional.Get(ticket.MediaSizeName),
+		Copies:    optional.Get(ticket.Copies),
+	}
+
+	switch optional.Get(ticket.Sides) {
+	case SidesTwoSidedLongEdge, SidesTwoSidedShortEdge:
+		req.Duplex = true
+	}
+
+	return req
+}
@@ -0,0 +1,370 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Print client
+
+package wsprint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// clientCancelTimeout is the timeout for the CancelJob request,
+// used to roll back the partially submitted job.
+const clientCancelTimeout = 5 * time.Second
+
+// Content-IDs of the MTOM parts of the SendDocument request.
+const (
+	clientMTOMRootID     = "soap@go-mfp"
+	clientMTOMDocumentID = "document@go-mfp"
+)
+
+// Client implements a WS-Print client.
+//
+// It sends requests to the WS-Print service endpoint, which URL
+// is obtained from the device metadata (see [wsd.Metadata]).
+//
+// [wsd.Metadata]: https://pkg.go.dev/github.com/OpenPrinting/go-mfp/proto/wsd#Metadata
+type Client struct {
+	url  *url.URL        // Service URL (http://...)
+	soap *wsd.SOAPClient // SOAP client
+}
+
+// Document is the single document of the print job.
+type Document struct {
+	Name   string    // Document name, optional
+	Format string    // MIME type, optional
+	Body   io.Reader // Document data
+}
+
+// NewClient creates a new WS-Print client.
+//
+// If tr is nil, [transport.NewTransport] will be used to create
+// a new transport.
+func NewClient(u *url.URL, tr *transport.Transport) *Client {
+	return &Client{
+		url: transport.URLClone(u),
+		soap: &wsd.SOAPClient{
+			Proto:      "WS-Print",
+			Ns:         NsMap,
+			HTTPClient: transport.NewClient(tr),
+		},
+	}
+}
+
+// GetPrinterElements requests the [PrinterElements] from the
+// printer.
+//
+// names are the names of the requested elements (i.e.,
+// [ElementPrinterCapabilities]). If no names are specified,
+// PrinterDescription, PrinterCapabilities and PrinterStatus
+// are requested.
+func (c *Client) GetPrinterElements(ctx context.Context,
+	names ...string) (*PrinterElements, error) {
+
+	if len(names) == 0 {
+		names = []string{
+			ElementPrinterDescription,
+			ElementPrinterCapabilities,
+			ElementPrinterStatus,
+		}
+	}
+
+	rq := xmldoc.WithChildren(NsPrint+":GetPrinterElementsRequest",
+		clientRequestedElements(names))
+
+	rsp, err := c.call(ctx, ActGetPrinterElements, rq,
+		NsPrint+":GetPrinterElementsResponse")
+	if err != nil {
+		return nil, err
+	}
+
+	elements, err := DecodePrinterElements(rsp)
+	if err != nil {
+		return nil, fmt.Errorf("WS-Print: %w", err)
+	}
+
+	return &elements, nil
+}
+
+// CreatePrintJob creates the print job with the specified
+// [PrintTicket].
+//
+// The job documents must be sent with the subsequent
+// [Client.SendDocument] requests.
+func (c *Client) CreatePrintJob(ctx context.Context,
+	ticket PrintTicket) (*CreatePrintJobResponse, error) {
+
+	rq := xmldoc.WithChildren(NsPrint+":CreatePrintJobRequest",
+		ticket.ToXML())
+
+	rsp, err := c.call(ctx, ActCreatePrintJob, rq,
+		NsPrint+":CreatePrintJobResponse")
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := DecodeCreatePrintJobResponse(rsp)
+	if err != nil {
+		return nil, fmt.Errorf("WS-Print: %w", err)
+	}
+
+	return &job, nil
+}
+
+// SendDocument sends the document of the print job.
+//
+// docID is the document number within the job, starting from 1.
+// If last is true, the job is closed for the new documents.
+//
+// The document is sent with the MTOM (multipart/related)
+// encoding. It is streamed, not buffered in memory.
+func (c *Client) SendDocument(ctx context.Context, jobID, docID int,
+	doc Document, last bool) error {
+
+	desc := xmldoc.WithChildren(NsPrint+":DocumentDescription",
+		xmldoc.WithText(NsPrint+":DocumentId", strconv.Itoa(docID)),
+		xmldoc.WithText(NsPrint+":DocumentName", doc.Name),
+	)
+
+	if doc.Format != "" {
+		desc.Children = append(desc.Children,
+			xmldoc.WithText(NsPrint+":Format", doc.Format))
+	}
+
+	include := xmldoc.WithAttrs(NsXOP+":Include",
+		xmldoc.Attr{Name: "href", Value: "cid:" + clientMTOMDocumentID})
+
+	rq := xmldoc.WithChildren(NsPrint+":SendDocumentRequest",
+		xmldoc.WithText(NsPrint+":JobId", strconv.Itoa(jobID)),
+		desc,
+		xmldoc.WithText(NsPrint+":LastDocument",
+			strconv.FormatBool(last)),
+		xmldoc.WithChildren(NsPrint+":DocumentData", include),
+	)
+
+	env := wsd.SOAPEncode(NsMap, ActSendDocument, c.url.String(), rq)
+
+	// Stream the multipart body via pipe
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(clientWriteMTOM(mw, env, doc.Body))
+	}()
+
+	ct := fmt.Sprintf(`multipart/related; type="application/xop+xml"; `+
+		`start="<%s>"; start-info="application/soap+xml"; `+
+		`boundary=%q`, clientMTOMRootID, mw.Boundary())
+
+	httpRsp, err := c.soap.Post(ctx, c.url, ActSendDocument, ct, pr)
+	if err != nil {
+		return err
+	}
+
+	defer httpRsp.Body.Close()
+
+	_, err = c.soap.ReadResponse(httpRsp, NsPrint+":SendDocumentResponse")
+	return err
+}
+
+// clientWriteMTOM writes the MTOM-encoded SendDocument request.
+// The first part is the SOAP envelope, the second part is the
+// document data, referred from the envelope by its Content-ID.
+func clientWriteMTOM(mw *multipart.Writer, env []byte,
+	body io.Reader) error {
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`application/xop+xml; charset=utf-8; ` +
+			`type="application/soap+xml"`},
+		"Content-Transfer-Encoding": {"binary"},
+		"Content-Id":                {"<" + clientMTOMRootID + ">"},
+	})
+
+	if err == nil {
+		_, err = part.Write(env)
+	}
+
+	if err == nil {
+		part, err = mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"application/binary"},
+			"Content-Transfer-Encoding": {"binary"},
+			"Content-Id": {
+				"<" + clientMTOMDocumentID + ">"},
+		})
+	}
+
+	if err == nil {
+		_, err = io.Copy(part, body)
+	}
+
+	if err == nil {
+		err = mw.Close()
+	}
+
+	return err
+}
+
+// GetJobStatus requests the [JobStatus] of the print job, using
+// the GetJobElements request.
+func (c *Client) GetJobStatus(ctx context.Context,
+	jobID int) (*JobStatus, error) {
+
+	rq := xmldoc.WithChildren(NsPrint+":GetJobElementsRequest",
+		xmldoc.WithText(NsPrint+":JobId", strconv.Itoa(jobID)),
+		clientRequestedElements([]string{ElementJobStatus}),
+	)
+
+	rsp, err := c.call(ctx, ActGetJobElements, rq,
+		NsPrint+":GetJobElementsResponse")
+	if err != nil {
+		return nil, err
+	}
+
+	list, _ := rsp.ChildByName(NsPrint + ":JobElements")
+	for _, data := range list.Children {
+		if data.Name != NsPrint+":ElementData" {
+			continue
+		}
+
+		if valid, ok := data.AttrByName("Valid"); ok &&
+			(valid.Value == "false" || valid.Value == "0") {
+			continue
+		}
+
+		elm, ok := data.ChildByName(ElementJobStatus)
+		if !ok {
+			continue
+		}
+
+		status, err := DecodeJobStatus(elm)
+		if err != nil {
+			err = xmldoc.XMLErrWrap(data, err)
+			err = xmldoc.XMLErrWrap(list, err)
+			err = xmldoc.XMLErrWrap(rsp, err)
+			return nil, fmt.Errorf("WS-Print: %w", err)
+		}
+
+		return &status, nil
+	}
+
+	return nil, errors.New("WS-Print: GetJobElements: missed JobStatus")
+}
+
+// CancelJob cancels the print job.
+func (c *Client) CancelJob(ctx context.Context, jobID int) error {
+	rq := xmldoc.WithChildren(NsPrint+":CancelJobRequest",
+		xmldoc.WithText(NsPrint+":JobId", strconv.Itoa(jobID)))
+
+	_, err := c.call(ctx, ActCancelJob, rq, NsPrint+":CancelJobResponse")
+	return err
+}
+
+// SubmitJob creates a new print job and sends the documents, one by
+// one, using the CreatePrintJob/SendDocument sequence.
+//
+// If ticket.JobOriginatingUserName is not set, the current user
+// name is used.
+//
+// If any SendDocument request fails, the job is canceled with
+// the CancelJob request, so incomplete job is never printed,
+// and the SendDocument error is returned.
+//
+// On success, it returns the job identifier.
+func (c *Client) SubmitJob(ctx context.Context, ticket PrintTicket,
+	docs []Document) (int, error) {
+
+	if len(docs) == 0 {
+		return 0, errors.New("WS-Print: no documents to print")
+	}
+
+	if ticket.JobOriginatingUserName == "" {
+		ticket.JobOriginatingUserName = clientUserName()
+	}
+
+	job, err := c.CreatePrintJob(ctx, ticket)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Debug(ctx, "WS-Print: job %d created", job.JobID)
+
+	for i, doc := range docs {
+		err = c.SendDocument(ctx, job.JobID, i+1, doc, i == len(docs)-1)
+		if err != nil {
+			err = fmt.Errorf("document %d: %w", i+1, err)
+			c.jobRollback(ctx, job.JobID)
+			return 0, err
+		}
+
+		log.Debug(ctx, "WS-Print: job %d: document %d of %d sent",
+			job.JobID, i+1, len(docs))
+	}
+
+	return job.JobID, nil
+}
+
+// jobRollback cancels the partially submitted job.
+//
+// As it may be called when ctx is already canceled, the request
+// is performed with the separate context with timeout.
+func (c *Client) jobRollback(ctx context.Context, jobID int) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx),
+		clientCancelTimeout)
+	defer cancel()
+
+	err := c.CancelJob(ctx, jobID)
+	if err != nil {
+		log.Error(ctx, "WS-Print: job %d: CancelJob: %s", jobID, err)
+		return
+	}
+
+	log.Debug(ctx, "WS-Print: job %d canceled", jobID)
+}
+
+// call performs the SOAP request and returns the response body
+// element with the expected name.
+func (c *Client) call(ctx context.Context, action string,
+	rq xmldoc.Element, name string) (xmldoc.Element, error) {
+
+	return c.soap.Call(ctx, c.url, action, rq, name)
+}
+
+// clientRequestedElements builds the RequestedElements element
+// of the GetPrinterElements and GetJobElements requests.
+func clientRequestedElements(names []string) xmldoc.Element {
+	requested := xmldoc.Element{Name: NsPrint + ":RequestedElements"}
+	for _, name := range names {
+		requested.Children = append(requested.Children,
+			xmldoc.WithText(NsPrint+":Name", name))
+	}
+	return requested
+}
+
+// clientUserName returns the JobOriginatingUserName for the
+// print jobs.
+func clientUserName() string {
+	usr, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return usr.Username
+}
@@ -0,0 +1,303 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Client test
+
+package wsprint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// clientTestDocument is the document, received by the
+// clientTestServer.
+type clientTestDocument struct {
+	name   string // Document name
+	format string // Document format
+	last   bool   // LastDocument flag
+	data   []byte // Document data
+}
+
+// clientTestServer is the minimal WS-Print service for
+// the Client test.
+type clientTestServer struct {
+	t        *testing.T           // Test handle
+	failDoc  int                  // Fail SendDocument with this ID
+	ticket   *PrintTicket         // Last received PrintTicket
+	docs     []clientTestDocument // Received documents
+	canceled bool                 // CancelJob received
+	lock     sync.Mutex           // Access lock
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (srv *clientTestServer) ServeHTTP(w http.ResponseWriter,
+	rq *http.Request) {
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	// Extract SOAP envelope and the document data, if any
+	var env io.Reader = rq.Body
+	var data []byte
+
+	mediatype, params, _ := mime.ParseMediaType(
+		rq.Header.Get("Content-Type"))
+	if mediatype == "multipart/related" {
+		mr := multipart.NewReader(rq.Body, params["boundary"])
+		for i := 0; ; i++ {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				srv.t.Errorf("server: %s", err)
+				return
+			}
+
+			body, _ := io.ReadAll(part)
+			if i == 0 {
+				env = bytes.NewReader(body)
+			} else if part.Header.Get("Content-Id") ==
+				"<"+clientMTOMDocumentID+">" {
+				data = body
+			}
+		}
+	}
+
+	root, err := xmldoc.Decode(NsMap, env)
+	if err != nil {
+		srv.t.Errorf("server: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	hdr, _ := root.ChildByName(NsSOAP + ":Header")
+	action, _ := hdr.ChildByName(NsAddressing + ":Action")
+	body, _ := root.ChildByName(NsSOAP + ":Body")
+
+	switch action.Text {
+	case ActGetPrinterElements:
+		elements := PrinterElements{
+			Description: &PrinterDescription{
+				PrinterName: "Test Printer",
+			},
+			Status: &PrinterStatus{PrinterState: PrinterIdle},
+		}
+		srv.reply(w, ActGetPrinterElementsResponse, elements.ToXML())
+
+	case ActCreatePrintJob:
+		rq, _ := body.ChildByName(NsPrint + ":CreatePrintJobRequest")
+		tk, _ := rq.ChildByName(NsPrint + ":PrintTicket")
+		ticket, err := DecodePrintTicket(tk)
+		if err != nil {
+			srv.t.Errorf("server: %s", err)
+		}
+
+		srv.ticket = &ticket
+		srv.docs = nil
+		srv.canceled = false
+
+		srv.reply(w, ActCreatePrintJobResponse,
+			CreatePrintJobResponse{JobID: 7}.ToXML())
+
+	case ActSendDocument:
+		rq, _ := body.ChildByName(NsPrint + ":SendDocumentRequest")
+		desc, _ := rq.ChildByName(NsPrint + ":DocumentDescription")
+		id, _ := desc.ChildByName(NsPrint + ":DocumentId")
+		name, _ := desc.ChildByName(NsPrint + ":DocumentName")
+		format, _ := desc.ChildByName(NsPrint + ":Format")
+		last, _ := rq.ChildByName(NsPrint + ":LastDocument")
+
+		if id.Text == "2" && srv.failDoc == 2 {
+			srv.fault(w, FaultClientErrorDocumentFormatError)
+			return
+		}
+
+		srv.docs = append(srv.docs, clientTestDocument{
+			name:   name.Text,
+			format: format.Text,
+			last:   last.Text == "true",
+			data:   data,
+		})
+
+		srv.reply(w, ActSendDocumentResponse,
+			xmldoc.Element{Name: NsPrint + ":SendDocumentResponse"})
+
+	case ActGetJobElements:
+		status := JobStatus{JobID: 7, JobState: JobCompleted}
+		data := xmldoc.WithChildren(NsPrint+":ElementData",
+			status.ToXML())
+		data.Attrs = []xmldoc.Attr{
+			{Name: "Name", Value: ElementJobStatus},
+			{Name: "Valid", Value: "true"},
+		}
+
+		srv.reply(w, ActGetJobElementsResponse,
+			xmldoc.WithChildren(NsPrint+":GetJobElementsResponse",
+				xmldoc.WithChildren(NsPrint+":JobElements",
+					data)))
+
+	case ActCancelJob:
+		srv.canceled = true
+		srv.reply(w, ActCancelJobResponse,
+			xmldoc.Element{Name: NsPrint + ":CancelJobResponse"})
+
+	default:
+		srv.t.Errorf("server: unexpected action %q", action.Text)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// reply sends the SOAP response.
+func (srv *clientTestServer) reply(w http.ResponseWriter,
+	action string, body xmldoc.Element) {
+
+	w.Header().Set("Content-Type", "application/soap+xml")
+	w.Write(wsd.SOAPEncode(NsMap, action, string(wsd.ToAnonymous), body))
+}
+
+// fault sends the SOAP Fault with the specified subcode.
+func (srv *clientTestServer) fault(w http.ResponseWriter,
+	subcode string) {
+
+	fault := xmldoc.WithChildren(NsSOAP+":Fault",
+		xmldoc.WithChildren(NsSOAP+":Code",
+			xmldoc.WithText(NsSOAP+":Value", NsSOAP+":Sender"),
+			xmldoc.WithChildren(NsSOAP+":Subcode",
+				xmldoc.WithText(NsSOAP+":Value",
+					NsPrint+":"+subcode)),
+		),
+	)
+
+	w.Header().Set("Content-Type", "application/soap+xml")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(wsd.SOAPEncode(NsMap,
+		"http://schemas.xmlsoap.org/ws/2004/08/addressing/fault",
+		string(wsd.ToAnonymous), fault))
+}
+
+// TestClient tests the WS-Print Client against the test server.
+func TestClient(t *testing.T) {
+	srv := &clientTestServer{t: t}
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, srv)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	u := transport.MustParseURL("http://localhost/WSDPrinter")
+	clnt := NewClient(u, tr)
+	ctx := context.Background()
+
+	// GetPrinterElements
+	elements, err := clnt.GetPrinterElements(ctx)
+	if err != nil {
+		t.Fatalf("GetPrinterElements: %s", err)
+	}
+
+	if elements.Description == nil ||
+		elements.Description.PrinterName != "Test Printer" {
+		t.Errorf("GetPrinterElements: bad PrinterDescription")
+	}
+
+	// SubmitJob
+	doc1 := bytes.Repeat([]byte("%PDF-1.4 test "), 10000)
+	doc2 := []byte("Hello, world")
+
+	ticket := PrintTicket{
+		JobName: "Test",
+		Copies:  optional.New(2),
+	}
+
+	docs := []Document{
+		{Name: "doc1.pdf", Format: "application/pdf",
+			Body: bytes.NewReader(doc1)},
+		{Name: "doc2.txt", Format: "text/plain",
+			Body: strings.NewReader(string(doc2))},
+	}
+
+	jobID, err := clnt.SubmitJob(ctx, ticket, docs)
+	if err != nil {
+		t.Fatalf("SubmitJob: %s", err)
+	}
+
+	if jobID != 7 {
+		t.Errorf("SubmitJob: JobID expected %d, present %d", 7, jobID)
+	}
+
+	srv.lock.Lock()
+	received := srv.docs
+	receivedTicket := srv.ticket
+	srv.lock.Unlock()
+
+	if optional.Get(receivedTicket.Copies) != 2 {
+		t.Errorf("PrintTicket: Copies not sent")
+	}
+
+	expected := []clientTestDocument{
+		{"doc1.pdf", "application/pdf", false, doc1},
+		{"doc2.txt", "text/plain", true, doc2},
+	}
+
+	if len(received) != len(expected) {
+		t.Fatalf("SubmitJob: %d documents expected, %d received",
+			len(expected), len(received))
+	}
+
+	for i := range expected {
+		exp, rcv := expected[i], received[i]
+		if exp.name != rcv.name || exp.format != rcv.format ||
+			exp.last != rcv.last || !bytes.Equal(exp.data, rcv.data) {
+			t.Errorf("document %d: mismatch", i+1)
+		}
+	}
+
+	// GetJobStatus
+	status, err := clnt.GetJobStatus(ctx, jobID)
+	if err != nil {
+		t.Errorf("GetJobStatus: %s", err)
+	} else if status.JobState != JobCompleted {
+		t.Errorf("GetJobStatus: JobState expected %s, present %s",
+			JobCompleted, status.JobState)
+	}
+
+	// SubmitJob with failure must cancel the job
+	srv.lock.Lock()
+	srv.failDoc = 2
+	srv.lock.Unlock()
+
+	docs[0].Body = bytes.NewReader(doc1)
+	docs[1].Body = strings.NewReader(string(doc2))
+
+	_, err = clnt.SubmitJob(ctx, ticket, docs)
+
+	var fault wsd.Fault
+	if !errors.As(err, &fault) ||
+		fault.Subcode != FaultClientErrorDocumentFormatError {
+		t.Errorf("SubmitJob: expected Fault, present %v", err)
+	}
+
+	srv.lock.Lock()
+	canceled := srv.canceled
+	srv.lock.Unlock()
+
+	if !canceled {
+		t.Errorf("SubmitJob: job not canceled after failure")
+	}
+}
@@ -0,0 +1,115 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Basic XML decoding functions
+
+package wsprint
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// decodeInt decodes integer from the XML tree.
+func decodeInt(root xmldoc.Element) (v int, err error) {
+	var v64 int64
+	v64, err = strconv.ParseInt(root.Text, 10, 64)
+
+	switch {
+	case err != nil:
+		err = fmt.Errorf("invalid int: %q", root.Text)
+	case v64 < math.MinInt32 || v64 > math.MaxInt32:
+		err = fmt.Errorf("int out of range: %d", v64)
+	}
+
+	if err != nil {
+		err = xmldoc.XMLErrWrap(root, err)
+		return 0, err
+	}
+
+	return int(v64), nil
+}
+
+// decodeNonNegativeInt decodes non-negative integer from the XML tree.
+func decodeNonNegativeInt(root xmldoc.Element) (v int, err error) {
+	var v64 int64
+	v64, err = strconv.ParseInt(root.Text, 10, 64)
+
+	switch {
+	case err != nil:
+		err = fmt.Errorf("invalid int: %q", root.Text)
+	case v64 < 0 || v64 > math.MaxInt32:
+		err = fmt.Errorf("int out of range: %d", v64)
+	}
+
+	if err != nil {
+		err = xmldoc.XMLErrWrap(root, err)
+		return 0, err
+	}
+
+	return int(v64), nil
+}
+
+// decodeBool decodes boolean from the XML tree.
+//
+// As xs:boolean allows both "true"/"false" and "1"/"0" forms,
+// both are accepted.
+func decodeBool(root xmldoc.Element) (v bool, err error) {
+	switch root.Text {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+
+	err = fmt.Errorf("invalid bool: %q", root.Text)
+	err = xmldoc.XMLErrWrap(root, err)
+
+	return
+}
+
+// decodeEnum decodes value of enum-alike type T from the XML tree
+//
+// decode is the type-specific function that decodes T from string
+// (i.e., DecodeColorEntry for ColorEntry).
+func decodeEnum[T ~int](root xmldoc.Element,
+	decode func(string) T) (val T, err error) {
+
+	val = decode(root.Text)
+	if val != 0 {
+		return
+	}
+
+	typeName := reflect.TypeOf(T(0)).String()
+	if i := strings.LastIndexByte(typeName, '.'); i >= 0 {
+		typeName = typeName[i+1:]
+	}
+
+	err = fmt.Errorf("invalid %s: %q", typeName, root.Text)
+	err = xmldoc.XMLErrWrap(root, err)
+
+	return
+}
+
+// decodeOptional wraps decodeXXX function that decodes xmldoc.Element
+// into the value of type T, to decode xmldoc.Element into the optional
+// value of type optional.Val[T]
+func decodeOptional[T any](root xmldoc.Element,
+	decodeXXX func(xmldoc.Element) (T, error)) (optional.Val[T], error) {
+
+	v, err := decodeXXX(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return optional.New(v), nil
+}
@@ -0,0 +1,19 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Package documentation
+
+// Package wsprint implements the WS-Print core protocol, the
+// print service of the Web Services for Devices (WSD).
+//
+// The [Client] implements the print job submission: the job
+// is created with CreatePrintJob request and its documents are
+// sent with SendDocument requests, using the MTOM encoding.
+//
+// The [AbstractServer] implements the print service on a top of
+// the abstract.Printer, so WSD printing can be tested without
+// hardware.
+package wsprint
@@ -0,0 +1,294 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Print jobs and job events
+
+package wsprint
+
+import (
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// ElementJobStatus is the name of the job element, that can be
+// requested with the GetJobElements request.
+const ElementJobStatus = NsPrint + ":JobStatus"
+
+// CreatePrintJobResponse represents the print job, created by
+// the CreatePrintJob request.
+//
+// JobID is used to identify the job in the subsequent SendDocument,
+// GetJobElements and CancelJob requests.
+type CreatePrintJobResponse struct {
+	JobID int // Job identifier
+}
+
+// JobStatus represents the current status of the print job.
+type JobStatus struct {
+	JobID                int      // Job identifier
+	JobState             JobState // Job state
+	JobStateReasons      []string // Job state reasons
+	KOctetsProcessed     int      // Processed data, in kilobytes
+	MediaSheetsCompleted int      // Printed sheets
+	NumberOfDocuments    int      // Documents in the job
+}
+
+// JobEndState represents the final state of the completed,
+// aborted or canceled print job.
+type JobEndState struct {
+	JobID                    int      // Job identifier
+	JobCompletedState        JobState // Final job state
+	JobCompletedStateReasons []string // Final job state reasons
+	JobName                  string   // Job name
+	JobOriginatingUserName   string   // User name
+	KOctetsProcessed         int      // Processed data, in kilobytes
+	MediaSheetsCompleted     int      // Printed sheets
+	NumberOfDocuments        int      // Documents in the job
+}
+
+// JobStatusEvent is sent by printer, when status of the job
+// changes.
+type JobStatusEvent struct {
+	JobStatus JobStatus // New job status
+}
+
+// JobEndStateEvent is sent by printer, when job reaches its
+// final state.
+type JobEndStateEvent struct {
+	JobEndState JobEndState // Final job state
+}
+
+// DecodeCreatePrintJobResponse decodes [CreatePrintJobResponse]
+// from the XML tree.
+func DecodeCreatePrintJobResponse(root xmldoc.Element) (
+	rsp CreatePrintJobResponse, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	id := xmldoc.Lookup{Name: NsPrint + ":JobId", Required: true}
+
+	missed := root.Lookup(&id)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	rsp.JobID, err = decodeNonNegativeInt(id.Elem)
+
+	return
+}
+
+// ToXML generates XML tree for the [CreatePrintJobResponse].
+func (rsp CreatePrintJobResponse) ToXML() xmldoc.Element {
+	return xmldoc.WithChildren(NsPrint+":CreatePrintJobResponse",
+		xmldoc.WithText(NsPrint+":JobId", strconv.Itoa(rsp.JobID)))
+}
+
+// DecodeJobStatus decodes [JobStatus] from the XML tree.
+func DecodeJobStatus(root xmldoc.Element) (status JobStatus, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	id := xmldoc.Lookup{Name: NsPrint + ":JobId", Required: true}
+	state := xmldoc.Lookup{Name: NsPrint + ":JobState", Required: true}
+	reasons := xmldoc.Lookup{Name: NsPrint + ":JobStateReasons"}
+
+	missed := root.Lookup(&id, &state, &reasons)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	status.JobID, err = decodeNonNegativeInt(id.Elem)
+	if err == nil {
+		status.JobState, err = decodeJobState(state.Elem)
+	}
+	if err == nil {
+		status.KOctetsProcessed, status.MediaSheetsCompleted,
+			status.NumberOfDocuments, err = decodeJobCounters(root)
+	}
+
+	status.JobStateReasons = decodeJobStateReasons(reasons.Elem)
+
+	return
+}
+
+// ToXML generates XML tree for the [JobStatus].
+func (status JobStatus) ToXML() xmldoc.Element {
+	elm := xmldoc.WithChildren(ElementJobStatus,
+		xmldoc.WithText(NsPrint+":JobId", strconv.Itoa(status.JobID)),
+		status.JobState.toXML(NsPrint+":JobState"),
+		jobStateReasonsToXML(NsPrint+":JobStateReasons",
+			status.JobStateReasons),
+	)
+
+	elm.Children = append(elm.Children, jobCountersToXML(
+		status.KOctetsProcessed, status.MediaSheetsCompleted,
+		status.NumberOfDocuments)...)
+
+	return elm
+}
+
+// DecodeJobEndState decodes [JobEndState] from the XML tree.
+func DecodeJobEndState(root xmldoc.Element) (end JobEndState, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	id := xmldoc.Lookup{Name: NsPrint + ":JobId", Required: true}
+	state := xmldoc.Lookup{Name: NsPrint + ":JobCompletedState",
+		Required: true}
+	reasons := xmldoc.Lookup{Name: NsPrint + ":JobCompletedStateReasons"}
+	name := xmldoc.Lookup{Name: NsPrint + ":JobName"}
+	user := xmldoc.Lookup{Name: NsPrint + ":JobOriginatingUserName"}
+
+	missed := root.Lookup(&id, &state, &reasons, &name, &user)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	end.JobID, err = decodeNonNegativeInt(id.Elem)
+	if err == nil {
+		end.JobCompletedState, err = decodeJobState(state.Elem)
+	}
+	if err == nil {
+		end.KOctetsProcessed, end.MediaSheetsCompleted,
+			end.NumberOfDocuments, err = decodeJobCounters(root)
+	}
+
+	end.JobCompletedStateReasons = decodeJobStateReasons(reasons.Elem)
+	end.JobName = name.Elem.Text
+	end.JobOriginatingUserName = user.Elem.Text
+
+	return
+}
+
+// ToXML generates XML tree for the [JobEndState].
+func (end JobEndState) ToXML() xmldoc.Element {
+	elm := xmldoc.WithChildren(NsPrint+":JobEndState",
+		xmldoc.WithText(NsPrint+":JobId", strconv.Itoa(end.JobID)),
+		end.JobCompletedState.toXML(NsPrint+":JobCompletedState"),
+		jobStateReasonsToXML(NsPrint+":JobCompletedStateReasons",
+			end.JobCompletedStateReasons),
+		xmldoc.WithText(NsPrint+":JobName", end.JobName),
+		xmldoc.WithText(NsPrint+":JobOriginatingUserName",
+			end.JobOriginatingUserName),
+	)
+
+	elm.Children = append(elm.Children, jobCountersToXML(
+		end.KOctetsProcessed, end.MediaSheetsCompleted,
+		end.NumberOfDocuments)...)
+
+	return elm
+}
+
+// DecodeJobStatusEvent decodes [JobStatusEvent] from the XML tree.
+func DecodeJobStatusEvent(root xmldoc.Element) (
+	evnt JobStatusEvent, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	status := xmldoc.Lookup{Name: ElementJobStatus, Required: true}
+
+	missed := root.Lookup(&status)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	evnt.JobStatus, err = DecodeJobStatus(status.Elem)
+
+	return
+}
+
+// ToXML generates XML tree for the [JobStatusEvent].
+func (evnt JobStatusEvent) ToXML() xmldoc.Element {
+	return xmldoc.WithChildren(NsPrint+":JobStatusEvent",
+		evnt.JobStatus.ToXML())
+}
+
+// DecodeJobEndStateEvent decodes [JobEndStateEvent] from the XML tree.
+func DecodeJobEndStateEvent(root xmldoc.Element) (
+	evnt JobEndStateEvent, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	end := xmldoc.Lookup{Name: NsPrint + ":JobEndState", Required: true}
+
+	missed := root.Lookup(&end)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	evnt.JobEndState, err = DecodeJobEndState(end.Elem)
+
+	return
+}
+
+// ToXML generates XML tree for the [JobEndStateEvent].
+func (evnt JobEndStateEvent) ToXML() xmldoc.Element {
+	return xmldoc.WithChildren(NsPrint+":JobEndStateEvent",
+		evnt.JobEndState.ToXML())
+}
+
+// decodeJobStateReasons decodes the list of JobStateReason elements.
+func decodeJobStateReasons(root xmldoc.Element) []string {
+	var reasons []string
+	for _, chld := range root.Children {
+		if chld.Name == NsPrint+":JobStateReason" {
+			reasons = append(reasons, chld.Text)
+		}
+	}
+	return reasons
+}
+
+// jobStateReasonsToXML generates XML tree for the list of
+// JobStateReason elements.
+func jobStateReasonsToXML(name string, reasons []string) xmldoc.Element {
+	elm := xmldoc.Element{Name: name}
+	for _, reason := range reasons {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsPrint+":JobStateReason", reason))
+	}
+	return elm
+}
+
+// decodeJobCounters decodes the job progress counters, common
+// for the JobStatus and JobEndState. Missed counters are
+// decoded as zero.
+func decodeJobCounters(root xmldoc.Element) (koctets, sheets, docs int,
+	err error) {
+
+	for _, chld := range root.Children {
+		switch chld.Name {
+		case NsPrint + ":KOctetsProcessed":
+			koctets, err = decodeNonNegativeInt(chld)
+		case NsPrint + ":MediaSheetsCompleted":
+			sheets, err = decodeNonNegativeInt(chld)
+		case NsPrint + ":NumberOfDocuments":
+			docs, err = decodeNonNegativeInt(chld)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// jobCountersToXML generates XML elements for the job progress
+// counters.
+func jobCountersToXML(koctets, sheets, docs int) []xmldoc.Element {
+	return []xmldoc.Element{
+		xmldoc.WithText(NsPrint+":KOctetsProcessed",
+			strconv.Itoa(koctets)),
+		xmldoc.WithText(NsPrint+":MediaSheetsCompleted",
+			strconv.Itoa(sheets)),
+		xmldoc.WithText(NsPrint+":NumberOfDocuments",
+			strconv.Itoa(docs)),
+	}
+}
@@ -0,0 +1,71 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Print jobs and job events test
+
+package wsprint
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestJobEvents tests encoding and decoding of the job events.
+func TestJobEvents(t *testing.T) {
+	status := JobStatusEvent{
+		JobStatus: JobStatus{
+			JobID:                5,
+			JobState:             JobProcessing,
+			JobStateReasons:      []string{"JobPrinting"},
+			KOctetsProcessed:     100,
+			MediaSheetsCompleted: 2,
+			NumberOfDocuments:    1,
+		},
+	}
+
+	xml := status.ToXML()
+	decodedStatus, err := DecodeJobStatusEvent(xml)
+	if err != nil {
+		t.Errorf("DecodeJobStatusEvent: %s", err)
+	} else if !reflect.DeepEqual(status, decodedStatus) {
+		t.Errorf("JobStatusEvent mismatch:\n"+
+			"expected: %#v\n"+
+			"present:  %#v",
+			status, decodedStatus)
+	}
+
+	end := JobEndStateEvent{
+		JobEndState: JobEndState{
+			JobID:                    5,
+			JobCompletedState:        JobCompleted,
+			JobCompletedStateReasons: []string{"JobCompletedSuccessfully"},
+			JobName:                  "Job",
+			JobOriginatingUserName:   "user",
+			KOctetsProcessed:         200,
+			MediaSheetsCompleted:     3,
+			NumberOfDocuments:        2,
+		},
+	}
+
+	xml = end.ToXML()
+	decodedEnd, err := DecodeJobEndStateEvent(xml)
+	if err != nil {
+		t.Errorf("DecodeJobEndStateEvent: %s", err)
+	} else if !reflect.DeepEqual(end, decodedEnd) {
+		t.Errorf("JobEndStateEvent mismatch:\n"+
+			"expected: %#v\n"+
+			"present:  %#v",
+			end, decodedEnd)
+	}
+
+	// Missed JobStatus
+	xml = JobStatusEvent{}.ToXML()
+	xml.Children = nil
+	_, err = DecodeJobStatusEvent(xml)
+	if err == nil {
+		t.Errorf("DecodeJobStatusEvent: error not detected")
+	}
+}
@@ -0,0 +1,110 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Job state
+
+package wsprint
+
+import "github.com/OpenPrinting/go-mfp/util/xmldoc"
+
+// JobState represents the state of the print job.
+type JobState int
+
+// Known job states:
+const (
+	UnknownJobState      JobState = iota
+	JobAborted                    // Aborted by system
+	JobCanceled                   // Canceled by user
+	JobCompleted                  // Completed successfully
+	JobCreating                   // Job is being created
+	JobPending                    // Waiting for processing
+	JobPendingHeld                // Held, not ready for processing
+	JobProcessing                 // Job is being processed
+	JobProcessingStopped          // Processing is stopped
+	JobStarted                    // Job is started
+	JobTerminating                // Job is being terminated
+)
+
+// decodeJobState decodes [JobState] from the XML tree.
+func decodeJobState(root xmldoc.Element) (st JobState, err error) {
+	return decodeEnum(root, DecodeJobState)
+}
+
+// toXML generates XML tree for the [JobState].
+func (st JobState) toXML(name string) xmldoc.Element {
+	return xmldoc.Element{
+		Name: name,
+		Text: st.String(),
+	}
+}
+
+// String returns a string representation of the [JobState]
+func (st JobState) String() string {
+	switch st {
+	case JobAborted:
+		return "Aborted"
+	case JobCanceled:
+		return "Canceled"
+	case JobCompleted:
+		return "Completed"
+	case JobCreating:
+		return "Creating"
+	case JobPending:
+		return "Pending"
+	case JobPendingHeld:
+		return "PendingHeld"
+	case JobProcessing:
+		return "Processing"
+	case JobProcessingStopped:
+		return "ProcessingStopped"
+	case JobStarted:
+		return "Started"
+	case JobTerminating:
+		return "Terminating"
+	}
+
+	return "Unknown"
+}
+
+// DecodeJobState decodes [JobState] out of its XML string
+// representation.
+func DecodeJobState(s string) JobState {
+	switch s {
+	case "Aborted":
+		return JobAborted
+	case "Canceled":
+		return JobCanceled
+	case "Completed":
+		return JobCompleted
+	case "Creating":
+		return JobCreating
+	case "Pending":
+		return JobPending
+	case "PendingHeld":
+		return JobPendingHeld
+	case "Processing":
+		return JobProcessing
+	case "ProcessingStopped":
+		return JobProcessingStopped
+	case "Started":
+		return JobStarted
+	case "Terminating":
+		return JobTerminating
+	}
+
+	return UnknownJobState
+}
+
+// IsFinal reports whether the job state is final, so the job
+// will not change its state anymore.
+func (st JobState) IsFinal() bool {
+	switch st {
+	case JobAborted, JobCanceled, JobCompleted:
+		return true
+	}
+
+	return false
+}
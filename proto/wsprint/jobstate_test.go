@@ -0,0 +1,49 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Test for job state
+
+package wsprint
+
+import "testing"
+
+var testJobState = testEnum[JobState]{
+	decodeStr: DecodeJobState,
+	decodeXML: decodeJobState,
+	dataset: []testEnumData[JobState]{
+		{JobAborted, "Aborted"},
+		{JobCanceled, "Canceled"},
+		{JobCompleted, "Completed"},
+		{JobCreating, "Creating"},
+		{JobPending, "Pending"},
+		{JobPendingHeld, "PendingHeld"},
+		{JobProcessing, "Processing"},
+		{JobProcessingStopped, "ProcessingStopped"},
+		{JobStarted, "Started"},
+		{JobTerminating, "Terminating"},
+	},
+}
+
+// TestJobState tests [JobState] common methods and functions.
+func TestJobState(t *testing.T) {
+	testJobState.run(t)
+}
+
+// TestJobStateIsFinal tests [JobState.IsFinal]
+func TestJobStateIsFinal(t *testing.T) {
+	final := map[JobState]bool{
+		JobAborted:   true,
+		JobCanceled:  true,
+		JobCompleted: true,
+	}
+
+	for _, data := range testJobState.dataset {
+		if data.v.IsFinal() != final[data.v] {
+			t.Errorf("%s.IsFinal(): expected %v, present %v",
+				data.v, final[data.v], data.v.IsFinal())
+		}
+	}
+}
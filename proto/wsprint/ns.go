@@ -0,0 +1,38 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Print namespace
+
+package wsprint
+
+import "github.com/OpenPrinting/go-mfp/util/xmldoc"
+
+// Namespace prefixes:
+const (
+	NsSOAP       = "s"
+	NsAddressing = "a"
+	NsPrint      = "wprt"
+	NsXOP        = "xop"
+)
+
+// NsPrintURL is the WS-Print namespace URL. It is also used as
+// the base for WS-Print actions.
+const NsPrintURL = "http://schemas.microsoft.com/windows/2006/08/wdp/print"
+
+// NsMap maps namespace prefixes to URL
+var NsMap = xmldoc.Namespace{
+	// SOAP 1.2
+	{Prefix: NsSOAP, URL: "http://www.w3.org/2003/05/soap-envelope"},
+
+	// WS-Addressing
+	{Prefix: NsAddressing, URL: "http://schemas.xmlsoap.org/ws/2004/08/addressing"},
+
+	// WS-Print
+	{Prefix: NsPrint, URL: NsPrintURL},
+
+	// XOP, used by MTOM-encoded SendDocument requests
+	{Prefix: NsXOP, URL: "http://www.w3.org/2004/08/xop/include"},
+}
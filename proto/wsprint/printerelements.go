@@ -0,0 +1,406 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Printer elements
+
+package wsprint
+
+import (
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// Names of the printer elements, that can be requested with
+// the GetPrinterElements request:
+const (
+	ElementPrinterDescription  = NsPrint + ":PrinterDescription"
+	ElementPrinterCapabilities = NsPrint + ":PrinterCapabilities"
+	ElementPrinterStatus       = NsPrint + ":PrinterStatus"
+	ElementDefaultPrintTicket  = NsPrint + ":DefaultPrintTicket"
+)
+
+// PrinterElements contains the printer elements, returned by
+// the GetPrinterElements request.
+//
+// Elements, not requested or reported by printer as invalid,
+// are nil.
+type PrinterElements struct {
+	Description        *PrinterDescription  // Printer description
+	Capabilities       *PrinterCapabilities // Printer capabilities
+	Status             *PrinterStatus       // Printer status
+	DefaultPrintTicket *PrintTicket         // Default job parameters
+}
+
+// PrinterDescription contains the printer description.
+type PrinterDescription struct {
+	PrinterName                   string // Printer name
+	PrinterInfo                   string // Additional information
+	PrinterLocation               string // Printer location
+	ColorSupported                bool   // Color printing supported
+	MultipleDocumentJobsSupported bool   // Multi-document jobs supported
+	PagesPerMinute                int    // Print speed, 0 if unknown
+}
+
+// PrinterCapabilities describes the supported job parameters.
+//
+// Formats are the MIME types, media sizes are the PWG media
+// size names (i.e., "iso_a4_210x297mm").
+type PrinterCapabilities struct {
+	FormatsSupported    []string                 // Document formats
+	CopiesSupported     optional.Val[ValueRange] // Copies range
+	SidesSupported      []Sides                  // Simplex/duplex
+	MediaSizesSupported []string                 // Media sizes
+}
+
+// PrinterStatus represents the current printer status.
+type PrinterStatus struct {
+	PrinterState              PrinterState // Overall printer state
+	PrinterPrimaryStateReason string       // The most important reason
+	PrinterStateReasons       []string     // State reasons
+	QueuedJobCount            int          // Jobs in the queue
+}
+
+// DecodePrinterElements decodes [PrinterElements] from the
+// XML tree of the GetPrinterElementsResponse.
+func DecodePrinterElements(root xmldoc.Element) (
+	elements PrinterElements, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	list, ok := root.ChildByName(NsPrint + ":PrinterElements")
+	if !ok {
+		err = xmldoc.XMLErrMissed(NsPrint + ":PrinterElements")
+		return
+	}
+
+	for _, data := range list.Children {
+		if data.Name != NsPrint+":ElementData" {
+			continue
+		}
+
+		err = elements.decodeElementData(data)
+		if err != nil {
+			err = xmldoc.XMLErrWrap(list, err)
+			return
+		}
+	}
+
+	return
+}
+
+// decodeElementData decodes the single ElementData and saves
+// the decoded element into the PrinterElements.
+func (elements *PrinterElements) decodeElementData(
+	data xmldoc.Element) (err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(data, err) }()
+
+	if valid, ok := data.AttrByName("Valid"); ok {
+		if valid.Value == "false" || valid.Value == "0" {
+			return
+		}
+	}
+
+	for _, chld := range data.Children {
+		switch chld.Name {
+		case ElementPrinterDescription:
+			var desc PrinterDescription
+			desc, err = decodePrinterDescription(chld)
+			elements.Description = &desc
+
+		case ElementPrinterCapabilities:
+			var caps PrinterCapabilities
+			caps, err = decodePrinterCapabilities(chld)
+			elements.Capabilities = &caps
+
+		case ElementPrinterStatus:
+			var status PrinterStatus
+			status, err = decodePrinterStatus(chld)
+			elements.Status = &status
+
+		case ElementDefaultPrintTicket:
+			var ticket PrintTicket
+			ticket, err = DecodePrintTicket(chld)
+			elements.DefaultPrintTicket = &ticket
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ToXML generates XML tree for the [PrinterElements], as
+// GetPrinterElementsResponse body.
+func (elements PrinterElements) ToXML() xmldoc.Element {
+	list := xmldoc.Element{Name: NsPrint + ":PrinterElements"}
+
+	add := func(name string, elm xmldoc.Element) {
+		data := xmldoc.WithChildren(NsPrint+":ElementData", elm)
+		data.Attrs = []xmldoc.Attr{
+			{Name: "Name", Value: name},
+			{Name: "Valid", Value: "true"},
+		}
+		list.Children = append(list.Children, data)
+	}
+
+	if elements.Description != nil {
+		add(ElementPrinterDescription, elements.Description.toXML())
+	}
+
+	if elements.Capabilities != nil {
+		add(ElementPrinterCapabilities,
+			elements.Capabilities.toXML())
+	}
+
+	if elements.Status != nil {
+		add(ElementPrinterStatus, elements.Status.toXML())
+	}
+
+	if elements.DefaultPrintTicket != nil {
+		ticket := elements.DefaultPrintTicket.ToXML()
+		ticket.Name = ElementDefaultPrintTicket
+		add(ElementDefaultPrintTicket, ticket)
+	}
+
+	return xmldoc.WithChildren(NsPrint+":GetPrinterElementsResponse",
+		list)
+}
+
+// decodePrinterDescription decodes [PrinterDescription] from
+// the XML tree.
+//
+// If string element comes in multiple languages, the first
+// one is used.
+func decodePrinterDescription(root xmldoc.Element) (
+	desc PrinterDescription, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	name := xmldoc.Lookup{Name: NsPrint + ":PrinterName", Required: true}
+	info := xmldoc.Lookup{Name: NsPrint + ":PrinterInfo"}
+	location := xmldoc.Lookup{Name: NsPrint + ":PrinterLocation"}
+	color := xmldoc.Lookup{Name: NsPrint + ":ColorSupported"}
+	multidoc := xmldoc.Lookup{
+		Name: NsPrint + ":MultipleDocumentJobsSupported"}
+	ppm := xmldoc.Lookup{Name: NsPrint + ":PagesPerMinute"}
+
+	missed := root.Lookup(&name, &info, &location, &color,
+		&multidoc, &ppm)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	desc.PrinterName = name.Elem.Text
+	desc.PrinterInfo = info.Elem.Text
+	desc.PrinterLocation = location.Elem.Text
+
+	if color.Found {
+		desc.ColorSupported, err = decodeBool(color.Elem)
+	}
+	if err == nil && multidoc.Found {
+		desc.MultipleDocumentJobsSupported, err = decodeBool(
+			multidoc.Elem)
+	}
+	if err == nil && ppm.Found {
+		desc.PagesPerMinute, err = decodeNonNegativeInt(ppm.Elem)
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [PrinterDescription].
+func (desc PrinterDescription) toXML() xmldoc.Element {
+	elm := xmldoc.WithChildren(ElementPrinterDescription,
+		xmldoc.WithText(NsPrint+":ColorSupported",
+			strconv.FormatBool(desc.ColorSupported)),
+		xmldoc.WithText(NsPrint+":MultipleDocumentJobsSupported",
+			strconv.FormatBool(desc.MultipleDocumentJobsSupported)),
+		xmldoc.WithText(NsPrint+":PrinterName", desc.PrinterName),
+	)
+
+	if desc.PagesPerMinute != 0 {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsPrint+":PagesPerMinute",
+				strconv.Itoa(desc.PagesPerMinute)))
+	}
+
+	if desc.PrinterInfo != "" {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsPrint+":PrinterInfo",
+				desc.PrinterInfo))
+	}
+
+	if desc.PrinterLocation != "" {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsPrint+":PrinterLocation",
+				desc.PrinterLocation))
+	}
+
+	return elm
+}
+
+// decodePrinterCapabilities decodes [PrinterCapabilities] from
+// the XML tree.
+//
+// The capabilities are represented as lists of AllowedValue
+// elements, and MinValue/MaxValue ranges, placed into the
+// same hierarchy as the corresponding PrintTicket parameters.
+func decodePrinterCapabilities(root xmldoc.Element) (
+	caps PrinterCapabilities, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	jobValues, _ := root.ChildByName(NsPrint + ":JobValues")
+	docValues, _ := root.ChildByName(NsPrint + ":DocumentValues")
+
+	// Document formats
+	desc, _ := docValues.ChildByName(NsPrint + ":DocumentDescription")
+	format, _ := desc.ChildByName(NsPrint + ":Format")
+	caps.FormatsSupported = decodeAllowedValues(format)
+
+	// Copies
+	job, _ := jobValues.ChildByName(NsPrint + ":JobProcessing")
+	if copies, ok := job.ChildByName(NsPrint + ":Copies"); ok {
+		caps.CopiesSupported, err = decodeOptional(copies,
+			decodeValueRange)
+		if err != nil {
+			return
+		}
+	}
+
+	// Sides and media sizes
+	doc, _ := jobValues.ChildByName(NsPrint + ":DocumentProcessing")
+	sides, _ := doc.ChildByName(NsPrint + ":Sides")
+	for _, chld := range sides.Children {
+		if chld.Name != NsPrint+":AllowedValue" {
+			continue
+		}
+
+		var v Sides
+		v, err = decodeSides(chld)
+		if err != nil {
+			err = xmldoc.XMLErrWrap(sides, err)
+			return
+		}
+
+		caps.SidesSupported = append(caps.SidesSupported, v)
+	}
+
+	media, _ := doc.ChildByName(NsPrint + ":MediaSizeName")
+	caps.MediaSizesSupported = decodeAllowedValues(media)
+
+	return
+}
+
+// toXML generates XML tree for the [PrinterCapabilities].
+func (caps PrinterCapabilities) toXML() xmldoc.Element {
+	job := xmldoc.Element{Name: NsPrint + ":JobProcessing"}
+	if caps.CopiesSupported != nil {
+		job.Children = append(job.Children,
+			(*caps.CopiesSupported).toXML(NsPrint+":Copies"))
+	}
+
+	sides := xmldoc.Element{Name: NsPrint + ":Sides"}
+	for _, v := range caps.SidesSupported {
+		sides.Children = append(sides.Children,
+			v.toXML(NsPrint+":AllowedValue"))
+	}
+
+	doc := xmldoc.WithChildren(NsPrint+":DocumentProcessing",
+		allowedValuesToXML(NsPrint+":MediaSizeName",
+			caps.MediaSizesSupported),
+		sides,
+	)
+
+	desc := xmldoc.WithChildren(NsPrint+":DocumentDescription",
+		allowedValuesToXML(NsPrint+":Format", caps.FormatsSupported))
+
+	return xmldoc.WithChildren(ElementPrinterCapabilities,
+		xmldoc.WithChildren(NsPrint+":JobValues", job, doc),
+		xmldoc.WithChildren(NsPrint+":DocumentValues", desc),
+	)
+}
+
+// decodeAllowedValues decodes the list of the AllowedValue
+// elements.
+func decodeAllowedValues(root xmldoc.Element) []string {
+	var vals []string
+	for _, chld := range root.Children {
+		if chld.Name == NsPrint+":AllowedValue" {
+			vals = append(vals, chld.Text)
+		}
+	}
+	return vals
+}
+
+// allowedValuesToXML generates XML tree for the list of
+// AllowedValue elements.
+func allowedValuesToXML(name string, vals []string) xmldoc.Element {
+	elm := xmldoc.Element{Name: name}
+	for _, v := range vals {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsPrint+":AllowedValue", v))
+	}
+	return elm
+}
+
+// decodePrinterStatus decodes [PrinterStatus] from the XML tree.
+func decodePrinterStatus(root xmldoc.Element) (
+	status PrinterStatus, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	state := xmldoc.Lookup{Name: NsPrint + ":PrinterState", Required: true}
+	primary := xmldoc.Lookup{Name: NsPrint + ":PrinterPrimaryStateReason"}
+	reasons := xmldoc.Lookup{Name: NsPrint + ":PrinterStateReasons"}
+	queued := xmldoc.Lookup{Name: NsPrint + ":QueuedJobCount"}
+
+	missed := root.Lookup(&state, &primary, &reasons, &queued)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	status.PrinterState, err = decodePrinterState(state.Elem)
+	status.PrinterPrimaryStateReason = primary.Elem.Text
+
+	for _, chld := range reasons.Elem.Children {
+		if chld.Name == NsPrint+":PrinterStateReason" {
+			status.PrinterStateReasons = append(
+				status.PrinterStateReasons, chld.Text)
+		}
+	}
+
+	if err == nil && queued.Found {
+		status.QueuedJobCount, err = decodeNonNegativeInt(queued.Elem)
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [PrinterStatus].
+func (status PrinterStatus) toXML() xmldoc.Element {
+	reasons := xmldoc.Element{Name: NsPrint + ":PrinterStateReasons"}
+	for _, reason := range status.PrinterStateReasons {
+		reasons.Children = append(reasons.Children,
+			xmldoc.WithText(NsPrint+":PrinterStateReason", reason))
+	}
+
+	return xmldoc.WithChildren(ElementPrinterStatus,
+		status.PrinterState.toXML(NsPrint+":PrinterState"),
+		xmldoc.WithText(NsPrint+":PrinterPrimaryStateReason",
+			status.PrinterPrimaryStateReason),
+		reasons,
+		xmldoc.WithText(NsPrint+":QueuedJobCount",
+			strconv.Itoa(status.QueuedJobCount)),
+	)
+}
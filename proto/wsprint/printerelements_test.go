@@ -0,0 +1,105 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PrinterElements test
+
+package wsprint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestPrinterElements tests [PrinterElements] encoding and decoding.
+func TestPrinterElements(t *testing.T) {
+	tests := []PrinterElements{
+		{},
+		{
+			Description: &PrinterDescription{
+				PrinterName:                   "Test Printer",
+				PrinterInfo:                   "Info",
+				PrinterLocation:               "Office",
+				ColorSupported:                true,
+				MultipleDocumentJobsSupported: true,
+				PagesPerMinute:                20,
+			},
+			Capabilities: &PrinterCapabilities{
+				FormatsSupported: []string{
+					"application/pdf", "image/jpeg",
+				},
+				CopiesSupported: optional.New(
+					ValueRange{Min: 1, Max: 99}),
+				SidesSupported: []Sides{
+					SidesOneSided, SidesTwoSidedLongEdge,
+				},
+				MediaSizesSupported: []string{
+					"iso_a4_210x297mm", "na_letter_8.5x11in",
+				},
+			},
+			Status: &PrinterStatus{
+				PrinterState:              PrinterProcessing,
+				PrinterPrimaryStateReason: "None",
+				PrinterStateReasons:       []string{"None"},
+				QueuedJobCount:            2,
+			},
+			DefaultPrintTicket: &PrintTicket{
+				JobName: "Default",
+				Copies:  optional.New(1),
+			},
+		},
+		{
+			Description: &PrinterDescription{
+				PrinterName: "Minimal",
+			},
+			Capabilities: &PrinterCapabilities{},
+		},
+	}
+
+	for _, elements := range tests {
+		xml := elements.ToXML()
+		decoded, err := DecodePrinterElements(xml)
+		if err != nil {
+			t.Errorf("DecodePrinterElements: %s\n%s", err,
+				xml.EncodeIndentString(NsMap, "  "))
+			continue
+		}
+
+		if !reflect.DeepEqual(elements, decoded) {
+			t.Errorf("PrinterElements mismatch:\n"+
+				"expected: %#v\n"+
+				"present:  %#v",
+				elements, decoded)
+		}
+	}
+}
+
+// TestPrinterElementsInvalid tests that elements, reported
+// as invalid, are skipped.
+func TestPrinterElementsInvalid(t *testing.T) {
+	xml := xmldoc.WithChildren(NsPrint+":GetPrinterElementsResponse",
+		xmldoc.WithChildren(NsPrint+":PrinterElements",
+			xmldoc.Element{
+				Name: NsPrint + ":ElementData",
+				Attrs: []xmldoc.Attr{
+					{Name: "Name", Value: ElementPrinterStatus},
+					{Name: "Valid", Value: "false"},
+				},
+			},
+		),
+	)
+
+	elements, err := DecodePrinterElements(xml)
+	if err != nil {
+		t.Errorf("DecodePrinterElements: %s", err)
+	}
+
+	if elements.Status != nil {
+		t.Errorf("Invalid PrinterStatus not skipped")
+	}
+}
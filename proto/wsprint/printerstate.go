@@ -0,0 +1,64 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Printer state
+
+package wsprint
+
+import "github.com/OpenPrinting/go-mfp/util/xmldoc"
+
+// PrinterState represents the overall printer state.
+type PrinterState int
+
+// Known printer states:
+const (
+	UnknownPrinterState PrinterState = iota
+	PrinterIdle                      // Printer is idle
+	PrinterProcessing                // Printer is busy
+	PrinterStopped                   // Printer is stopped
+)
+
+// decodePrinterState decodes [PrinterState] from the XML tree.
+func decodePrinterState(root xmldoc.Element) (st PrinterState, err error) {
+	return decodeEnum(root, DecodePrinterState)
+}
+
+// toXML generates XML tree for the [PrinterState].
+func (st PrinterState) toXML(name string) xmldoc.Element {
+	return xmldoc.Element{
+		Name: name,
+		Text: st.String(),
+	}
+}
+
+// String returns a string representation of the [PrinterState]
+func (st PrinterState) String() string {
+	switch st {
+	case PrinterIdle:
+		return "Idle"
+	case PrinterProcessing:
+		return "Processing"
+	case PrinterStopped:
+		return "Stopped"
+	}
+
+	return "Unknown"
+}
+
+// DecodePrinterState decodes [PrinterState] out of its XML string
+// representation.
+func DecodePrinterState(s string) PrinterState {
+	switch s {
+	case "Idle":
+		return PrinterIdle
+	case "Processing":
+		return PrinterProcessing
+	case "Stopped":
+		return PrinterStopped
+	}
+
+	return UnknownPrinterState
+}
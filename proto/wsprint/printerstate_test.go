@@ -0,0 +1,26 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Test for printer state
+
+package wsprint
+
+import "testing"
+
+var testPrinterState = testEnum[PrinterState]{
+	decodeStr: DecodePrinterState,
+	decodeXML: decodePrinterState,
+	dataset: []testEnumData[PrinterState]{
+		{PrinterIdle, "Idle"},
+		{PrinterProcessing, "Processing"},
+		{PrinterStopped, "Stopped"},
+	},
+}
+
+// TestPrinterState tests [PrinterState] common methods and functions.
+func TestPrinterState(t *testing.T) {
+	testPrinterState.run(t)
+}
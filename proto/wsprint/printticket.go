@@ -0,0 +1,153 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PrintTicket
+
+package wsprint
+
+import (
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// PrintTicket defines the parameters of the print job, sent with
+// the CreatePrintJob request.
+//
+// All processing parameters are optional. Missed parameters are
+// chosen by the printer.
+type PrintTicket struct {
+	JobName                string               // Job name
+	JobOriginatingUserName string               // User name
+	Copies                 optional.Val[int]    // Number of copies
+	Priority               optional.Val[int]    // Job priority, 1...100
+	MediaSizeName          optional.Val[string] // PWG media size name
+	Sides                  optional.Val[Sides]  // Simplex/duplex
+}
+
+// DecodePrintTicket decodes [PrintTicket] from the XML tree.
+func DecodePrintTicket(root xmldoc.Element) (ticket PrintTicket, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	desc := xmldoc.Lookup{Name: NsPrint + ":JobDescription"}
+	job := xmldoc.Lookup{Name: NsPrint + ":JobProcessing"}
+	doc := xmldoc.Lookup{Name: NsPrint + ":DocumentProcessing"}
+
+	root.Lookup(&desc, &job, &doc)
+
+	if desc.Found {
+		name := xmldoc.Lookup{Name: NsPrint + ":JobName"}
+		user := xmldoc.Lookup{Name: NsPrint + ":JobOriginatingUserName"}
+		desc.Elem.Lookup(&name, &user)
+		ticket.JobName = name.Elem.Text
+		ticket.JobOriginatingUserName = user.Elem.Text
+	}
+
+	if job.Found {
+		err = ticket.decodeJobProcessing(job.Elem)
+	}
+
+	if err == nil && doc.Found {
+		err = ticket.decodeDocumentProcessing(doc.Elem)
+	}
+
+	return
+}
+
+// decodeJobProcessing decodes the JobProcessing element of
+// the PrintTicket.
+func (ticket *PrintTicket) decodeJobProcessing(root xmldoc.Element) (
+	err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	for _, chld := range root.Children {
+		switch chld.Name {
+		case NsPrint + ":Copies":
+			ticket.Copies, err = decodeOptional(chld,
+				decodeNonNegativeInt)
+		case NsPrint + ":Priority":
+			ticket.Priority, err = decodeOptional(chld,
+				decodeNonNegativeInt)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// decodeDocumentProcessing decodes the DocumentProcessing element
+// of the PrintTicket.
+func (ticket *PrintTicket) decodeDocumentProcessing(root xmldoc.Element) (
+	err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	for _, chld := range root.Children {
+		switch chld.Name {
+		case NsPrint + ":MediaSizeName":
+			ticket.MediaSizeName = optional.New(chld.Text)
+		case NsPrint + ":Sides":
+			ticket.Sides, err = decodeOptional(chld, decodeSides)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ToXML generates XML tree for the [PrintTicket].
+//
+// JobProcessing and DocumentProcessing elements are omitted,
+// if they have no parameters.
+func (ticket PrintTicket) ToXML() xmldoc.Element {
+	elm := xmldoc.WithChildren(NsPrint+":PrintTicket",
+		xmldoc.WithChildren(NsPrint+":JobDescription",
+			xmldoc.WithText(NsPrint+":JobName", ticket.JobName),
+			xmldoc.WithText(NsPrint+":JobOriginatingUserName",
+				ticket.JobOriginatingUserName),
+		),
+	)
+
+	job := xmldoc.Element{Name: NsPrint + ":JobProcessing"}
+	if ticket.Copies != nil {
+		job.Children = append(job.Children,
+			xmldoc.WithText(NsPrint+":Copies",
+				strconv.Itoa(*ticket.Copies)))
+	}
+	if ticket.Priority != nil {
+		job.Children = append(job.Children,
+			xmldoc.WithText(NsPrint+":Priority",
+				strconv.Itoa(*ticket.Priority)))
+	}
+
+	doc := xmldoc.Element{Name: NsPrint + ":DocumentProcessing"}
+	if ticket.MediaSizeName != nil {
+		doc.Children = append(doc.Children,
+			xmldoc.WithText(NsPrint+":MediaSizeName",
+				*ticket.MediaSizeName))
+	}
+	if ticket.Sides != nil {
+		doc.Children = append(doc.Children,
+			(*ticket.Sides).toXML(NsPrint+":Sides"))
+	}
+
+	if len(job.Children) != 0 {
+		elm.Children = append(elm.Children, job)
+	}
+	if len(doc.Children) != 0 {
+		elm.Children = append(elm.Children, doc)
+	}
+
+	return elm
+}
@@ -0,0 +1,91 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// PrintTicket test
+
+package wsprint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestPrintTicket tests [PrintTicket] encoding and decoding.
+func TestPrintTicket(t *testing.T) {
+	tests := []PrintTicket{
+		{},
+		{
+			JobName:                "Job",
+			JobOriginatingUserName: "user",
+		},
+		{
+			JobName:                "Job",
+			JobOriginatingUserName: "user",
+			Copies:                 optional.New(2),
+			Priority:               optional.New(50),
+			MediaSizeName:          optional.New("iso_a4_210x297mm"),
+			Sides:                  optional.New(SidesTwoSidedLongEdge),
+		},
+	}
+
+	for _, ticket := range tests {
+		xml := ticket.ToXML()
+		decoded, err := DecodePrintTicket(xml)
+		if err != nil {
+			t.Errorf("DecodePrintTicket: %s\n%s", err,
+				xml.EncodeIndentString(NsMap, "  "))
+			continue
+		}
+
+		if !reflect.DeepEqual(ticket, decoded) {
+			t.Errorf("PrintTicket mismatch:\n"+
+				"expected: %#v\n"+
+				"present:  %#v",
+				ticket, decoded)
+		}
+	}
+}
+
+// TestPrintTicketErrors tests [PrintTicket] decoding errors.
+func TestPrintTicketErrors(t *testing.T) {
+	type testData struct {
+		xml xmldoc.Element
+		err string
+	}
+
+	tests := []testData{
+		{
+			xml: xmldoc.WithChildren(NsPrint+":PrintTicket",
+				xmldoc.WithChildren(NsPrint+":JobProcessing",
+					xmldoc.WithText(NsPrint+":Copies", "-1"))),
+			err: `/wprt:PrintTicket/wprt:JobProcessing/wprt:Copies: int out of range: -1`,
+		},
+		{
+			xml: xmldoc.WithChildren(NsPrint+":PrintTicket",
+				xmldoc.WithChildren(NsPrint+":DocumentProcessing",
+					xmldoc.WithText(NsPrint+":Sides", "Triple"))),
+			err: `/wprt:PrintTicket/wprt:DocumentProcessing/wprt:Sides: invalid Sides: "Triple"`,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := DecodePrintTicket(test.xml)
+		errstr := ""
+		if err != nil {
+			errstr = err.Error()
+		}
+
+		if errstr != test.err {
+			t.Errorf("DecodePrintTicket:\n"+
+				"expected: %s\n"+
+				"present:  %s",
+				test.err, errstr)
+		}
+	}
+}
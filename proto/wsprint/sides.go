@@ -0,0 +1,64 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Sides
+
+package wsprint
+
+import "github.com/OpenPrinting/go-mfp/util/xmldoc"
+
+// Sides specifies how pages are imposed on the sheet sides.
+type Sides int
+
+// Known sides:
+const (
+	UnknownSides           Sides = iota
+	SidesOneSided                // Simplex
+	SidesTwoSidedLongEdge        // Duplex, long edge binding
+	SidesTwoSidedShortEdge       // Duplex, short edge binding
+)
+
+// decodeSides decodes [Sides] from the XML tree.
+func decodeSides(root xmldoc.Element) (sides Sides, err error) {
+	return decodeEnum(root, DecodeSides)
+}
+
+// toXML generates XML tree for the [Sides].
+func (sides Sides) toXML(name string) xmldoc.Element {
+	return xmldoc.Element{
+		Name: name,
+		Text: sides.String(),
+	}
+}
+
+// String returns a string representation of the [Sides]
+func (sides Sides) String() string {
+	switch sides {
+	case SidesOneSided:
+		return "OneSided"
+	case SidesTwoSidedLongEdge:
+		return "TwoSidedLongEdge"
+	case SidesTwoSidedShortEdge:
+		return "TwoSidedShortEdge"
+	}
+
+	return "Unknown"
+}
+
+// DecodeSides decodes [Sides] out of its XML string
+// representation.
+func DecodeSides(s string) Sides {
+	switch s {
+	case "OneSided":
+		return SidesOneSided
+	case "TwoSidedLongEdge":
+		return SidesTwoSidedLongEdge
+	case "TwoSidedShortEdge":
+		return SidesTwoSidedShortEdge
+	}
+
+	return UnknownSides
+}
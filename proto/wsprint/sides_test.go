@@ -0,0 +1,26 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Test for sides
+
+package wsprint
+
+import "testing"
+
+var testSides = testEnum[Sides]{
+	decodeStr: DecodeSides,
+	decodeXML: decodeSides,
+	dataset: []testEnumData[Sides]{
+		{SidesOneSided, "OneSided"},
+		{SidesTwoSidedLongEdge, "TwoSidedLongEdge"},
+		{SidesTwoSidedShortEdge, "TwoSidedShortEdge"},
+	},
+}
+
+// TestSides tests [Sides] common methods and functions.
+func TestSides(t *testing.T) {
+	testSides.run(t)
+}
@@ -0,0 +1,63 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SOAP envelope and faults
+
+package wsprint
+
+import (
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// WS-Print actions, used by the [Client]:
+const (
+	ActGetPrinterElements         = NsPrintURL + "/GetPrinterElements"
+	ActGetPrinterElementsResponse = NsPrintURL + "/GetPrinterElementsResponse"
+	ActCreatePrintJob             = NsPrintURL + "/CreatePrintJob"
+	ActCreatePrintJobResponse     = NsPrintURL + "/CreatePrintJobResponse"
+	ActSendDocument               = NsPrintURL + "/SendDocument"
+	ActSendDocumentResponse       = NsPrintURL + "/SendDocumentResponse"
+	ActGetJobElements             = NsPrintURL + "/GetJobElements"
+	ActGetJobElementsResponse     = NsPrintURL + "/GetJobElementsResponse"
+	ActCancelJob                  = NsPrintURL + "/CancelJob"
+	ActCancelJobResponse          = NsPrintURL + "/CancelJobResponse"
+)
+
+// WS-Print events, sent by printer to the subscribed clients:
+const (
+	ActJobStatusEvent             = NsPrintURL + "/JobStatusEvent"
+	ActJobEndStateEvent           = NsPrintURL + "/JobEndStateEvent"
+	ActPrinterStatusSummaryEvent  = NsPrintURL + "/PrinterStatusSummaryEvent"
+	ActPrinterElementsChangeEvent = NsPrintURL + "/PrinterElementsChangeEvent"
+)
+
+// WS-Print fault subcodes, reported as [wsd.Fault] Subcode
+// by the [Client] and [AbstractServer]:
+const (
+	FaultClientErrorJobIDNotFound          = "ClientErrorJobIdNotFound"
+	FaultServerErrorNotAcceptingJobs       = "ServerErrorNotAcceptingJobs"
+	FaultClientErrorDocumentFormatError    = "ClientErrorDocumentFormatError"
+	FaultClientErrorFormatNotSupported     = "ClientErrorFormatNotSupported"
+	FaultClientErrorAttributesNotSupported = "ClientErrorAttributesNotSupported"
+	FaultServerErrorInternalError          = "ServerErrorInternalError"
+)
+
+// soapResponseNs is the namespace for encoding responses.
+//
+// Fault subcodes use the wprt: prefix in the element text,
+// so the namespace must be declared explicitly.
+var soapResponseNs = func() xmldoc.Namespace {
+	ns := NsMap.Clone()
+	ns.MarkUsedPrefix(NsPrint)
+	return ns
+}()
+
+// soapFault creates the [wsd.Fault] with the WS-Print subcode
+// (may be "") and reason.
+func soapFault(code, subcode, reason string) wsd.Fault {
+	return wsd.SOAPFault(code, NsPrint, subcode, reason)
+}
@@ -0,0 +1,51 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Print core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Range of values
+
+package wsprint
+
+import (
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// ValueRange represents the range of the supported values
+// (i.e., supported number of Copies).
+type ValueRange struct {
+	Min int // Minimal value
+	Max int // Maximal value
+}
+
+// decodeValueRange decodes [ValueRange] from the XML tree.
+func decodeValueRange(root xmldoc.Element) (rng ValueRange, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	minval := xmldoc.Lookup{Name: NsPrint + ":MinValue", Required: true}
+	maxval := xmldoc.Lookup{Name: NsPrint + ":MaxValue", Required: true}
+
+	missed := root.Lookup(&minval, &maxval)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	rng.Min, err = decodeInt(minval.Elem)
+	if err == nil {
+		rng.Max, err = decodeInt(maxval.Elem)
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [ValueRange].
+func (rng ValueRange) toXML(name string) xmldoc.Element {
+	return xmldoc.WithChildren(name,
+		xmldoc.WithText(NsPrint+":MinValue", strconv.Itoa(rng.Min)),
+		xmldoc.WithText(NsPrint+":MaxValue", strconv.Itoa(rng.Max)),
+	)
+}
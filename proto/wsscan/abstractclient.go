@@ -0,0 +1,275 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// abstract.Scanner on a top of WS-Scan client
+
+package wsscan
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// abstractClientCancelTimeout is the timeout for the CancelJob
+// request.
+const abstractClientCancelTimeout = 5 * time.Second
+
+// AbstractClient implements [abstract.Scanner] on a top of the
+// WS-Scan [Client].
+//
+// It handles the entire scan job lifecycle:
+//   - CreateScanJob with the ScanTicket, built from the
+//     [abstract.ScannerRequest] and the ScannerConfiguration
+//   - RetrieveImage for each page, until scanner reports
+//     that there are no more images
+//   - CancelJob, if document is closed before all pages
+//     are received or the context is canceled
+type AbstractClient struct {
+	clnt     *Client                       // Underlying WS-Scan client
+	elements *ScannerElements              // Scanner elements
+	abscaps  *abstract.ScannerCapabilities // Abstract capabilities
+}
+
+// AbstractClientOptions represents the [AbstractClient] creation
+// options.
+type AbstractClientOptions struct {
+	// Transport used for HTTP requests. If nil,
+	// [transport.NewTransport] will be used.
+	Transport *transport.Transport
+}
+
+// NewAbstractClient creates a new [AbstractClient].
+//
+// u is the URL of the WS-Scan service endpoint. NewAbstractClient
+// fetches the ScannerDescription and ScannerConfiguration from the
+// scanner, so scanner must be reachable.
+func NewAbstractClient(ctx context.Context, u *url.URL,
+	options AbstractClientOptions) (*AbstractClient, error) {
+
+	clnt := NewClient(u, options.Transport)
+
+	elements, err := clnt.GetScannerElements(ctx,
+		ElementScannerDescription, ElementScannerConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	if elements.Configuration == nil {
+		return nil, errors.New("WS-Scan: ScannerConfiguration missed")
+	}
+
+	ac := &AbstractClient{
+		clnt:     clnt,
+		elements: elements,
+		abscaps:  elements.ToAbstract(),
+	}
+
+	return ac, nil
+}
+
+// Client returns the underlying low-level WS-Scan [Client].
+func (ac *AbstractClient) Client() *Client {
+	return ac.clnt
+}
+
+// Capabilities returns the [abstract.ScannerCapabilities].
+// Caller should not modify the returned structure.
+func (ac *AbstractClient) Capabilities() *abstract.ScannerCapabilities {
+	return ac.abscaps
+}
+
+// Scan validates the request against scanner capabilities,
+// starts the scan job and returns the [abstract.Document],
+// that returns scanned pages.
+//
+// The ctx covers the entire job lifetime, including consuming the
+// returned document. If ctx is canceled, the job is canceled at the
+// scanner side.
+func (ac *AbstractClient) Scan(ctx context.Context,
+	req abstract.ScannerRequest) (abstract.Document, error) {
+
+	err := req.Validate(ac.abscaps)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := fromAbstractScanTicket(ac.elements.Configuration, &req)
+
+	job, err := ac.clnt.CreateScanJob(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug(ctx, "WS-Scan: job started: %d", job.JobID)
+
+	// Scanner may adjust parameters of the job
+	params := ticket.DocumentParameters
+	if job.DocumentFinalParameters != nil {
+		params = *job.DocumentFinalParameters
+	}
+
+	doc := &abstractClientDocument{
+		ac:     ac,
+		ctx:    ctx,
+		job:    job,
+		format: FormatToMIME(optional.Get(params.Format)),
+		pages:  optional.Get(params.ImagesToTransfer),
+	}
+
+	if side := params.MediaFront; side != nil && (*side).Resolution != nil {
+		res := *(*side).Resolution
+		doc.res = abstract.Resolution{
+			XResolution: res.Width,
+			YResolution: res.Height,
+		}
+	}
+
+	return doc, nil
+}
+
+// Close closes the AbstractClient.
+func (ac *AbstractClient) Close() error {
+	return nil
+}
+
+// abstractClientDocument implements the [abstract.Document]
+// interface for the AbstractClient.
+type abstractClientDocument struct {
+	ac       *AbstractClient        // Owning AbstractClient
+	ctx      context.Context        // Job context
+	job      *CreateScanJobResponse // The scan job
+	format   string                 // MIME type of images
+	res      abstract.Resolution    // Document resolution
+	pages    int                    // Expected pages, 0 if unknown
+	received int                    // Received pages
+	file     *abstractClientDocFile // Current file, nil if none
+	done     bool                   // All pages received or job canceled
+	lock     sync.Mutex             // Access lock
+}
+
+// Resolution returns the document's rendering resolution in DPI.
+func (doc *abstractClientDocument) Resolution() abstract.Resolution {
+	return doc.res
+}
+
+// Next returns the next [abstract.DocumentFile].
+func (doc *abstractClientDocument) Next() (abstract.DocumentFile, error) {
+	doc.lock.Lock()
+	defer doc.lock.Unlock()
+
+	doc.closeFile()
+
+	if doc.done {
+		return nil, io.EOF
+	}
+
+	body, err := doc.ac.clnt.RetrieveImage(doc.ctx,
+		doc.job.JobID, doc.job.JobToken)
+
+	// Some scanners forget the job as soon as the last image
+	// is retrieved
+	var fault wsd.Fault
+	if doc.received > 0 && errors.As(err, &fault) &&
+		fault.Subcode == FaultClientErrorJobIDNotFound {
+		err = io.EOF
+	}
+
+	switch {
+	case err == io.EOF:
+		doc.done = true
+		return nil, io.EOF
+
+	case err != nil:
+		doc.cancel()
+		return nil, err
+	}
+
+	doc.received++
+	if doc.pages != 0 && doc.received >= doc.pages {
+		doc.done = true
+	}
+
+	doc.file = &abstractClientDocFile{
+		body:   body,
+		format: doc.format,
+	}
+
+	return doc.file, nil
+}
+
+// Close closes the document. If not all pages are consumed,
+// the job is canceled.
+func (doc *abstractClientDocument) Close() error {
+	doc.lock.Lock()
+	defer doc.lock.Unlock()
+
+	doc.closeFile()
+	doc.cancel()
+
+	return nil
+}
+
+// closeFile closes the current file, if any.
+// Must be called under the doc.lock.
+func (doc *abstractClientDocument) closeFile() {
+	if doc.file != nil {
+		doc.file.body.Close()
+		doc.file = nil
+	}
+}
+
+// cancel cancels the job, if it is not done yet.
+// Must be called under the doc.lock.
+//
+// The CancelJob request is performed with the separate context,
+// as the job context may be already canceled.
+func (doc *abstractClientDocument) cancel() {
+	if doc.done {
+		return
+	}
+
+	doc.done = true
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(doc.ctx),
+		abstractClientCancelTimeout)
+	defer cancel()
+
+	err := doc.ac.clnt.CancelJob(ctx, doc.job.JobID)
+	if err != nil {
+		log.Debug(ctx, "WS-Scan: job cancel: %s", err)
+		return
+	}
+
+	log.Debug(ctx, "WS-Scan: job canceled: %d", doc.job.JobID)
+}
+
+// abstractClientDocFile implements the [abstract.DocumentFile]
+// interface for the AbstractClient.
+type abstractClientDocFile struct {
+	body   io.ReadCloser // Image body
+	format string        // MIME type of the image
+}
+
+// Format returns the MIME type of the image format used by
+// the document file.
+func (file *abstractClientDocFile) Format() string {
+	return file.format
+}
+
+// Read reads the document file content as a sequence of bytes.
+func (file *abstractClientDocFile) Read(buf []byte) (int, error) {
+	return file.body.Read(buf)
+}
@@ -0,0 +1,466 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AbstractClient test
+
+package wsscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// abstractClientTestElements are the ScannerElements, returned
+// by the abstractClientTestServer.
+var abstractClientTestElements = ScannerElements{
+	Description: &ScannerDescription{
+		ScannerName: "Test Scanner",
+	},
+	Configuration: &ScannerConfiguration{
+		DeviceSettings: DeviceSettings{
+			FormatsSupported: []string{FormatPNG, FormatJFIF},
+			ContentTypesSupported: []ContentType{
+				ContentAuto, ContentText, ContentPhoto,
+			},
+			Brightness: true,
+		},
+		Platen: &InputConfiguration{
+			Colors:            []ColorEntry{Grayscale8, RGB24},
+			MinimumSize:       Dimensions{1, 1},
+			MaximumSize:       Dimensions{8500, 11690},
+			OpticalResolution: Dimensions{600, 600},
+			Widths:            []int{150, 300, 600},
+			Heights:           []int{150, 300, 600},
+		},
+		ADFFront: &InputConfiguration{
+			Colors:      []ColorEntry{RGB24},
+			MinimumSize: Dimensions{1, 1},
+			MaximumSize: Dimensions{8500, 14000},
+			Widths:      []int{300},
+			Heights:     []int{300},
+		},
+		ADFSupportsDuplex: true,
+	},
+}
+
+// abstractClientTestServer is the minimal WS-Scan service
+// for the AbstractClient test.
+type abstractClientTestServer struct {
+	t        *testing.T  // Test handle
+	pages    [][]byte    // Pages to send per job
+	mtom     bool        // Use MTOM encoding
+	images   int         // Images left for the current job
+	ticket   *ScanTicket // Last received ScanTicket
+	canceled bool        // CancelJob received
+	lock     sync.Mutex  // Access lock
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (srv *abstractClientTestServer) ServeHTTP(w http.ResponseWriter,
+	rq *http.Request) {
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	root, err := xmldoc.Decode(NsMap, rq.Body)
+	if err != nil {
+		srv.t.Errorf("server: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	hdr, _ := root.ChildByName(NsSOAP + ":Header")
+	action, _ := hdr.ChildByName(NsAddressing + ":Action")
+	body, _ := root.ChildByName(NsSOAP + ":Body")
+
+	switch action.Text {
+	case ActGetScannerElements:
+		srv.reply(w, ActGetScannerElementsResponse,
+			abstractClientTestElements.ToXML())
+
+	case ActCreateScanJob:
+		rq, _ := body.ChildByName(NsScan + ":CreateScanJobRequest")
+		tk, _ := rq.ChildByName(NsScan + ":ScanTicket")
+		ticket, err := DecodeScanTicket(tk)
+		if err != nil {
+			srv.t.Errorf("server: %s", err)
+		}
+
+		srv.ticket = &ticket
+		srv.images = len(srv.pages)
+		srv.canceled = false
+
+		srv.reply(w, ActCreateScanJobResponse,
+			CreateScanJobResponse{
+				JobID:    1,
+				JobToken: "token",
+			}.ToXML())
+
+	case ActRetrieveImage:
+		if srv.images == 0 {
+			srv.fault(w, FaultClientErrorNoImagesAvailable)
+			return
+		}
+
+		page := srv.pages[len(srv.pages)-srv.images]
+		srv.images--
+
+		if srv.mtom {
+			srv.replyMTOM(w, page)
+		} else {
+			srv.reply(w, ActRetrieveImageResponse,
+				xmldoc.WithChildren(
+					NsScan+":RetrieveImageResponse",
+					xmldoc.WithText(NsScan+":ScanData",
+						base64.StdEncoding.EncodeToString(page))))
+		}
+
+	case ActCancelJob:
+		srv.canceled = true
+		srv.images = 0
+		srv.reply(w, ActCancelJobResponse,
+			xmldoc.Element{Name: NsScan + ":CancelJobResponse"})
+
+	default:
+		srv.t.Errorf("server: unexpected action %q", action.Text)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// envelope builds the response SOAP envelope.
+func (srv *abstractClientTestServer) envelope(action string,
+	body xmldoc.Element) []byte {
+
+	env := xmldoc.WithChildren(NsSOAP+":Envelope",
+		xmldoc.WithChildren(NsSOAP+":Header",
+			xmldoc.WithText(NsAddressing+":Action", action),
+			xmldoc.WithText(NsAddressing+":MessageID",
+				"urn:uuid:00000000-0000-0000-0000-000000000000"),
+		),
+		xmldoc.WithChildren(NsSOAP+":Body", body),
+	)
+
+	return []byte(env.EncodeString(NsMap))
+}
+
+// reply sends the plain SOAP response.
+func (srv *abstractClientTestServer) reply(w http.ResponseWriter,
+	action string, body xmldoc.Element) {
+
+	w.Header().Set("Content-Type", "application/soap+xml")
+	w.Write(srv.envelope(action, body))
+}
+
+// replyMTOM sends the MTOM-encoded RetrieveImageResponse.
+func (srv *abstractClientTestServer) replyMTOM(w http.ResponseWriter,
+	image []byte) {
+
+	const cid = "image@example.com"
+
+	include := xmldoc.WithAttrs(NsXOP+":Include",
+		xmldoc.Attr{Name: "href", Value: "cid:" + cid})
+	body := xmldoc.WithChildren(NsScan+":RetrieveImageResponse",
+		xmldoc.WithChildren(NsScan+":ScanData", include))
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	part, _ := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`application/xop+xml; type="application/soap+xml"`},
+		"Content-Id":   {"<root@example.com>"},
+	})
+	part.Write(srv.envelope(ActRetrieveImageResponse, body))
+
+	part, _ = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"application/binary"},
+		"Content-Id":   {"<" + cid + ">"},
+	})
+	part.Write(image)
+	mw.Close()
+
+	w.Header().Set("Content-Type", fmt.Sprintf(
+		`multipart/related; type="application/xop+xml"; boundary=%q`,
+		mw.Boundary()))
+	w.Write(buf.Bytes())
+}
+
+// fault sends the SOAP Fault with the specified subcode.
+func (srv *abstractClientTestServer) fault(w http.ResponseWriter,
+	subcode string) {
+
+	fault := xmldoc.WithChildren(NsSOAP+":Fault",
+		xmldoc.WithChildren(NsSOAP+":Code",
+			xmldoc.WithText(NsSOAP+":Value", NsSOAP+":Receiver"),
+			xmldoc.WithChildren(NsSOAP+":Subcode",
+				xmldoc.WithText(NsSOAP+":Value",
+					NsScan+":"+subcode)),
+		),
+		xmldoc.WithChildren(NsSOAP+":Reason",
+			xmldoc.WithText(NsSOAP+":Text", "Test fault")),
+	)
+
+	w.Header().Set("Content-Type", "application/soap+xml")
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(srv.envelope(
+		"http://schemas.xmlsoap.org/ws/2004/08/addressing/fault",
+		fault))
+}
+
+// TestAbstractClient tests AbstractClient against the test server.
+func TestAbstractClient(t *testing.T) {
+	jpeg := testutils.Images.JPEG100x75
+	png := testutils.Images.PNG100x75rgb8
+
+	srv := &abstractClientTestServer{t: t}
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, srv)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	u := transport.MustParseURL("http://localhost/WSDScanner")
+	ac, err := NewAbstractClient(context.Background(), u,
+		AbstractClientOptions{Transport: tr})
+	if err != nil {
+		t.Fatalf("NewAbstractClient: %s", err)
+	}
+
+	caps := ac.Capabilities()
+	if caps.MakeAndModel != "Test Scanner" {
+		t.Errorf("MakeAndModel: expected %q, present %q",
+			"Test Scanner", caps.MakeAndModel)
+	}
+
+	if caps.Platen == nil || caps.ADFSimplex == nil ||
+		caps.ADFDuplex == nil {
+		t.Fatalf("Capabilities: missed inputs")
+	}
+
+	if caps.Platen.MaxWidth != abstract.LetterWidth {
+		t.Errorf("Platen.MaxWidth: expected %d, present %d",
+			abstract.LetterWidth, caps.Platen.MaxWidth)
+	}
+
+	type testData struct {
+		name   string                  // Test name
+		req    abstract.ScannerRequest // Scan request
+		pages  [][]byte                // Pages, sent by server
+		mtom   bool                    // Use MTOM encoding
+		format string                  // Expected format
+		src    InputSource             // Expected InputSource
+		color  ColorEntry              // Expected ColorProcessing
+	}
+
+	tests := []testData{
+		{
+			name: "platen, inline",
+			req: abstract.ScannerRequest{
+				Input:      abstract.InputPlaten,
+				ColorMode:  abstract.ColorModeMono,
+				ColorDepth: abstract.ColorDepth8,
+				Resolution: abstract.Resolution{
+					XResolution: 150,
+					YResolution: 150,
+				},
+			},
+			pages:  [][]byte{jpeg},
+			format: abstract.DocumentFormatJPEG,
+			src:    InputPlaten,
+			color:  Grayscale8,
+		},
+		{
+			name: "platen, MTOM, PNG",
+			req: abstract.ScannerRequest{
+				Input:          abstract.InputPlaten,
+				DocumentFormat: abstract.DocumentFormatPNG,
+			},
+			pages:  [][]byte{png},
+			mtom:   true,
+			format: abstract.DocumentFormatPNG,
+			src:    InputPlaten,
+			color:  RGB24,
+		},
+		{
+			name: "ADF duplex, MTOM",
+			req: abstract.ScannerRequest{
+				Input:   abstract.InputADF,
+				ADFMode: abstract.ADFModeDuplex,
+			},
+			pages:  [][]byte{jpeg, jpeg, jpeg},
+			mtom:   true,
+			format: abstract.DocumentFormatJPEG,
+			src:    InputADFDuplex,
+			color:  RGB24,
+		},
+	}
+
+	for _, test := range tests {
+		srv.lock.Lock()
+		srv.pages = test.pages
+		srv.mtom = test.mtom
+		srv.lock.Unlock()
+
+		doc, err := ac.Scan(context.Background(), test.req)
+		if err != nil {
+			t.Errorf("%s: Scan: %s", test.name, err)
+			continue
+		}
+
+		var pages [][]byte
+		for {
+			file, err := doc.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s: Next: %s", test.name, err)
+				break
+			}
+
+			if file.Format() != test.format {
+				t.Errorf("%s: Format: expected %q, present %q",
+					test.name, test.format, file.Format())
+			}
+
+			data, err := io.ReadAll(file)
+			if err != nil {
+				t.Errorf("%s: Read: %s", test.name, err)
+				break
+			}
+
+			pages = append(pages, data)
+		}
+
+		doc.Close()
+
+		if len(pages) != len(test.pages) {
+			t.Errorf("%s: %d pages expected, %d received",
+				test.name, len(test.pages), len(pages))
+		}
+
+		for i := range min(len(pages), len(test.pages)) {
+			if !bytes.Equal(pages[i], test.pages[i]) {
+				t.Errorf("%s: page %d: data mismatch",
+					test.name, i)
+			}
+		}
+
+		srv.lock.Lock()
+		params := srv.ticket.DocumentParameters
+		canceled := srv.canceled
+		srv.lock.Unlock()
+
+		src := optional.Get(params.InputSource)
+		if src != test.src {
+			t.Errorf("%s: InputSource: expected %s, present %s",
+				test.name, test.src, src)
+		}
+
+		side := optional.Get(params.MediaFront)
+		color := optional.Get(side.ColorProcessing)
+		if color != test.color {
+			t.Errorf("%s: ColorProcessing: expected %s, present %s",
+				test.name, test.color, color)
+		}
+
+		if (params.MediaBack != nil) != (src == InputADFDuplex) {
+			t.Errorf("%s: MediaBack mismatch", test.name)
+		}
+
+		if canceled {
+			t.Errorf("%s: job unexpectedly canceled", test.name)
+		}
+	}
+
+	// Closing document in the middle must cancel the job
+	srv.lock.Lock()
+	srv.pages = [][]byte{jpeg, jpeg}
+	srv.lock.Unlock()
+
+	doc, err := ac.Scan(context.Background(), abstract.ScannerRequest{
+		Input: abstract.InputADF,
+	})
+	if err != nil {
+		t.Fatalf("Scan: %s", err)
+	}
+
+	if _, err = doc.Next(); err != nil {
+		t.Errorf("Next: %s", err)
+	}
+
+	doc.Close()
+
+	srv.lock.Lock()
+	canceled := srv.canceled
+	srv.lock.Unlock()
+
+	if !canceled {
+		t.Errorf("Close: job not canceled")
+	}
+
+	// Unsupported request must be rejected by validation
+	_, err = ac.Scan(context.Background(), abstract.ScannerRequest{
+		Input: abstract.InputCamera,
+	})
+	if err == nil {
+		t.Errorf("Scan: unsupported Input not rejected")
+	}
+}
+
+// TestClientFault tests Fault returned by Client
+func TestClientFault(t *testing.T) {
+	srv := &abstractClientTestServer{t: t}
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, srv)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	u := transport.MustParseURL("http://localhost/WSDScanner")
+	clnt := NewClient(u, tr)
+
+	// No job created, so there are no images
+	_, err := clnt.RetrieveImage(context.Background(), 1, "token")
+	if err != io.EOF {
+		t.Errorf("RetrieveImage: expected io.EOF, present %v", err)
+	}
+
+	// Other faults are returned as wsd.Fault
+	_, err = clnt.call(context.Background(), ActRetrieveImage,
+		xmldoc.Element{Name: NsScan + ":RetrieveImageRequest"},
+		NsScan+":RetrieveImageResponse")
+
+	fault, ok := err.(wsd.Fault)
+	if !ok {
+		t.Fatalf("call: wsd.Fault expected, present %T (%v)", err, err)
+	}
+
+	expected := wsd.Fault{
+		Code:    wsd.FaultCodeReceiver,
+		Subcode: FaultClientErrorNoImagesAvailable,
+		Reason:  wsd.LocalizedString{String: "Test fault"},
+	}
+
+	if !reflect.DeepEqual(fault, expected) {
+		t.Errorf("Fault:\nexpected: %#v\npresent:  %#v",
+			expected, fault)
+	}
+}
@@ -0,0 +1,344 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conversions from abstract.Scanner to WS-Scan data structures
+
+package wsscan
+
+import (
+	"slices"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/generic"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+)
+
+// Defaults for parameters, missed in the abstract.ScannerRequest
+// and abstract.ScannerCapabilities
+const (
+	fromAbstractScannerName       = "Scanner"
+	fromAbstractDefaultResolution = 300
+	fromAbstractJobName           = "Scan"
+	fromAbstractUserName          = "go-mfp"
+)
+
+// fromAbstractScanTicket converts [abstract.ScannerRequest] into
+// the [ScanTicket].
+//
+// Parameters, missed in the request, are chosen according to
+// the [ScannerConfiguration], so the scan ticket is always
+// complete.
+func fromAbstractScanTicket(conf *ScannerConfiguration,
+	req *abstract.ScannerRequest) ScanTicket {
+
+	params := DocumentParameters{
+		Brightness:               req.Brightness,
+		Contrast:                 req.Contrast,
+		CompressionQualityFactor: req.Compression,
+	}
+
+	// Choose input source
+	var src InputSource
+	var inp *InputConfiguration
+
+	switch {
+	case req.Input == abstract.InputADF &&
+		req.ADFMode == abstract.ADFModeDuplex:
+		src, inp = InputADFDuplex, conf.ADFFront
+	case req.Input == abstract.InputADF,
+		req.Input == abstract.InputUnset && conf.Platen == nil:
+		src, inp = InputADF, conf.ADFFront
+	default:
+		src, inp = InputPlaten, conf.Platen
+	}
+
+	if inp == nil {
+		inp = &InputConfiguration{}
+	}
+
+	params.InputSource = optional.New(src)
+	if src == InputPlaten {
+		params.ImagesToTransfer = optional.New(1)
+	} else {
+		params.ImagesToTransfer = optional.New(0)
+	}
+
+	// Choose document format. Prefer JPEG, if not specified.
+	supported := conf.DeviceSettings.FormatsSupported
+	mime := req.DocumentFormat
+	if mime == "" {
+		mime = abstract.DocumentFormatJPEG
+	}
+
+	format := FormatFromMIME(mime, supported)
+	if format == "" && req.DocumentFormat == "" && len(supported) != 0 {
+		format = supported[0]
+	}
+
+	if format != "" {
+		params.Format = optional.New(format)
+	}
+
+	// Translate Intent
+	switch req.Intent {
+	case abstract.IntentDocument:
+		params.ContentType = optional.New(ContentText)
+	case abstract.IntentPhoto:
+		params.ContentType = optional.New(ContentPhoto)
+	case abstract.IntentTextAndGraphic:
+		params.ContentType = optional.New(ContentMixed)
+	}
+
+	// Build media side parameters
+	side := MediaSide{
+		ColorProcessing: optional.New(fromAbstractColorEntry(
+			req.ColorMode, req.ColorDepth)),
+	}
+
+	res := req.Resolution
+	if res.IsZero() {
+		dpi := fromAbstractDefaultResolution
+		if !slices.Contains(inp.Widths, dpi) && len(inp.Widths) != 0 {
+			dpi = inp.Widths[0]
+		}
+		res = abstract.Resolution{XResolution: dpi, YResolution: dpi}
+	}
+
+	side.Resolution = optional.New(Dimensions{
+		Width:  res.XResolution,
+		Height: res.YResolution,
+	})
+
+	reg := ScanRegion{
+		Width:  inp.MaximumSize.Width,
+		Height: inp.MaximumSize.Height,
+	}
+
+	if !req.Region.IsZero() {
+		reg = ScanRegion{
+			XOffset: fromAbstractDimension(req.Region.XOffset),
+			YOffset: fromAbstractDimension(req.Region.YOffset),
+			Width:   fromAbstractDimension(req.Region.Width),
+			Height:  fromAbstractDimension(req.Region.Height),
+		}
+	}
+
+	side.ScanRegion = optional.New(reg)
+
+	params.MediaFront = optional.New(side)
+	if src == InputADFDuplex {
+		params.MediaBack = optional.New(side)
+	}
+
+	return ScanTicket{
+		JobName:                fromAbstractJobName,
+		JobOriginatingUserName: fromAbstractUserName,
+		DocumentParameters:     params,
+	}
+}
+
+// fromAbstractColorEntry converts [abstract.ColorMode] and
+// [abstract.ColorDepth] into the [ColorEntry].
+//
+// If color mode is not specified, RGB24 is used.
+func fromAbstractColorEntry(cm abstract.ColorMode,
+	depth abstract.ColorDepth) ColorEntry {
+
+	deep := depth == abstract.ColorDepth16
+
+	switch {
+	case cm == abstract.ColorModeBinary:
+		return BlackAndWhite1
+	case cm == abstract.ColorModeMono && deep:
+		return Grayscale16
+	case cm == abstract.ColorModeMono:
+		return Grayscale8
+	case deep:
+		return RGB48
+	}
+
+	return RGB24
+}
+
+// fromAbstractDimension converts [abstract.Dimension] into
+// the WS-Scan units (1/1000 of inch).
+func fromAbstractDimension(dim abstract.Dimension) int {
+	return dim.Dots(thousandthsDPI)
+}
+
+// fromAbstractScannerElements converts [abstract.ScannerCapabilities]
+// into the [ScannerElements], that describe the scanner: the
+// ScannerDescription and ScannerConfiguration.
+func fromAbstractScannerElements(
+	abscaps *abstract.ScannerCapabilities) ScannerElements {
+
+	desc := &ScannerDescription{ScannerName: abscaps.MakeAndModel}
+	if desc.ScannerName == "" {
+		desc.ScannerName = fromAbstractScannerName
+	}
+
+	return ScannerElements{
+		Description:   desc,
+		Configuration: fromAbstractScannerConfiguration(abscaps),
+	}
+}
+
+// fromAbstractScannerConfiguration converts [abstract.ScannerCapabilities]
+// into the [ScannerConfiguration].
+func fromAbstractScannerConfiguration(
+	abscaps *abstract.ScannerCapabilities) *ScannerConfiguration {
+
+	conf := &ScannerConfiguration{}
+
+	// Common settings
+	settings := &conf.DeviceSettings
+	for _, mime := range abscaps.DocumentFormats {
+		for _, ent := range formatTable {
+			if ent.mime == mime &&
+				!slices.Contains(settings.FormatsSupported,
+					ent.format) {
+				settings.FormatsSupported = append(
+					settings.FormatsSupported, ent.format)
+				break
+			}
+		}
+	}
+
+	if rng := abscaps.CompressionRange; rng.Min != rng.Max {
+		settings.CompressionQualityFactor = optional.New(
+			ValueRange{Min: rng.Min, Max: rng.Max})
+	}
+
+	settings.Brightness = abscaps.BrightnessRange.Min !=
+		abscaps.BrightnessRange.Max
+	settings.Contrast = abscaps.ContrastRange.Min !=
+		abscaps.ContrastRange.Max
+
+	var intents generic.Bitset[abstract.Intent]
+	for _, inp := range []*abstract.InputCapabilities{
+		abscaps.Platen, abscaps.ADFSimplex, abscaps.ADFDuplex} {
+		if inp != nil {
+			intents = intents.Union(inp.Intents)
+		}
+	}
+
+	settings.ContentTypesSupported = []ContentType{ContentAuto}
+	for _, intent := range intents.Elements() {
+		if ct := fromAbstractContentType(intent); ct != ContentAuto {
+			settings.ContentTypesSupported = append(
+				settings.ContentTypesSupported, ct)
+		}
+	}
+
+	// Inputs. WS-Scan doesn't distinguish simplex and duplex
+	// ADF capabilities, so simplex ones are preferred.
+	if abscaps.Platen != nil {
+		conf.Platen = fromAbstractInputConfiguration(abscaps.Platen)
+	}
+
+	adf := abscaps.ADFSimplex
+	if adf == nil {
+		adf = abscaps.ADFDuplex
+	}
+
+	if adf != nil {
+		conf.ADFFront = fromAbstractInputConfiguration(adf)
+		conf.ADFSupportsDuplex = abscaps.ADFDuplex != nil
+		if conf.ADFSupportsDuplex {
+			conf.ADFBack = fromAbstractInputConfiguration(
+				abscaps.ADFDuplex)
+		}
+	}
+
+	return conf
+}
+
+// fromAbstractInputConfiguration converts [abstract.InputCapabilities]
+// into the [InputConfiguration].
+//
+// WS-Scan supports only discrete resolutions, so resolution
+// ranges are represented by the commonly used resolutions
+// within the range.
+func fromAbstractInputConfiguration(
+	inpcaps *abstract.InputCapabilities) *InputConfiguration {
+
+	inp := &InputConfiguration{
+		MinimumSize: Dimensions{
+			Width:  fromAbstractDimension(inpcaps.MinWidth),
+			Height: fromAbstractDimension(inpcaps.MinHeight),
+		},
+		MaximumSize: Dimensions{
+			Width:  fromAbstractDimension(inpcaps.MaxWidth),
+			Height: fromAbstractDimension(inpcaps.MaxHeight),
+		},
+		OpticalResolution: Dimensions{
+			Width:  inpcaps.MaxOpticalXResolution,
+			Height: inpcaps.MaxOpticalYResolution,
+		},
+	}
+
+	for _, prof := range inpcaps.Profiles {
+		for _, cm := range prof.ColorModes.Elements() {
+			depths := prof.Depths.Elements()
+			if cm == abstract.ColorModeBinary || len(depths) == 0 {
+				depths = []abstract.ColorDepth{
+					abstract.ColorDepthUnset}
+			}
+
+			for _, depth := range depths {
+				ce := fromAbstractColorEntry(cm, depth)
+				if !slices.Contains(inp.Colors, ce) {
+					inp.Colors = append(inp.Colors, ce)
+				}
+			}
+		}
+
+		for _, res := range prof.Resolutions {
+			inp.Widths = append(inp.Widths, res.XResolution)
+			inp.Heights = append(inp.Heights, res.YResolution)
+		}
+
+		if rr := prof.ResolutionRange; !rr.IsZero() {
+			for _, dpi := range fromAbstractCommonResolutions {
+				if rr.XMin <= dpi && dpi <= rr.XMax {
+					inp.Widths = append(inp.Widths, dpi)
+				}
+				if rr.YMin <= dpi && dpi <= rr.YMax {
+					inp.Heights = append(inp.Heights, dpi)
+				}
+			}
+		}
+	}
+
+	slices.Sort(inp.Widths)
+	inp.Widths = slices.Compact(inp.Widths)
+	slices.Sort(inp.Heights)
+	inp.Heights = slices.Compact(inp.Heights)
+
+	return inp
+}
+
+// fromAbstractCommonResolutions are the commonly used resolutions,
+// used by fromAbstractInputConfiguration to represent the
+// resolution ranges.
+var fromAbstractCommonResolutions = []int{
+	75, 100, 150, 200, 240, 300, 400, 600, 1200, 2400, 4800,
+}
+
+// fromAbstractContentType converts [abstract.Intent] into
+// the [ContentType].
+func fromAbstractContentType(intent abstract.Intent) ContentType {
+	switch intent {
+	case abstract.IntentDocument:
+		return ContentText
+	case abstract.IntentPhoto:
+		return ContentPhoto
+	case abstract.IntentTextAndGraphic:
+		return ContentMixed
+	}
+
+	return ContentAuto
+}
@@ -0,0 +1,650 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Scan server on a top of abstract.Scanner
+
+package wsscan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/uuid"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// AbstractServer parameters:
+const (
+	// abstractServerMaxXMLSize is the maximum size of the
+	// SOAP request, accepted by the AbstractServer.
+	abstractServerMaxXMLSize = 1024 * 1024
+
+	// AbstractServerJobTimeout is the inactivity timeout of the
+	// scan job. Abandoned job, inactive for this time, doesn't
+	// prevent creation of the new job.
+	AbstractServerJobTimeout = 2 * time.Minute
+)
+
+// Content-IDs of the MTOM-encoded RetrieveImageResponse parts
+const (
+	abstractServerRootCID  = "root@go-mfp"
+	abstractServerImageCID = "image@go-mfp"
+)
+
+// AbstractServer implements WS-Scan server on a top of [abstract.Scanner].
+//
+// It serves the GetScannerElements, CreateScanJob, RetrieveImage
+// and CancelJob requests. ScannerElements are generated from the
+// [abstract.ScannerCapabilities] and images are sent with the MTOM
+// encoding.
+//
+// Scanner performs one job at a time. While job is active, the
+// CreateScanJob requests are rejected with the ServerErrorNotAcceptingJobs
+// fault.
+//
+// AbstractServer makes the emulated scanner usable by the WSD clients,
+// like Windows. Discovery is not handled here, see wsd.Responder.
+type AbstractServer struct {
+	ctx      context.Context               // Logging context
+	options  AbstractServerOptions         // Server options
+	caps     *abstract.ScannerCapabilities // Scanner capabilities
+	elements ScannerElements               // Description and Configuration
+	job      *abstractServerJob            // Active job, nil if none
+	nextID   int                           // Next job ID
+	lock     sync.Mutex                    // Access lock
+}
+
+// AbstractServerOptions represents the [AbstractServer] creation
+// options.
+type AbstractServerOptions struct {
+	Scanner abstract.Scanner // Underlying abstract.Scanner
+
+	// ScannerInfo and ScannerLocation, if set, are reported in
+	// the ScannerDescription. ScannerName comes from the
+	// [abstract.ScannerCapabilities].
+	ScannerInfo     string
+	ScannerLocation string
+}
+
+// abstractServerJob represents the active scan job.
+type abstractServerJob struct {
+	id      int                // Job ID
+	token   string             // Job token
+	doc     abstract.Document  // Scanned document, nil when closed
+	cancel  context.CancelFunc // Cancels the job context
+	images  int                // Images to transfer, 0 means all
+	sent    int                // Images sent so far
+	touched time.Time          // Time of the last activity
+	lock    sync.Mutex         // Serializes access to doc
+}
+
+// NewAbstractServer returns a new [AbstractServer].
+func NewAbstractServer(ctx context.Context,
+	options AbstractServerOptions) *AbstractServer {
+
+	caps := options.Scanner.Capabilities()
+
+	srv := &AbstractServer{
+		ctx:      ctx,
+		options:  options,
+		caps:     caps,
+		elements: fromAbstractScannerElements(caps),
+		nextID:   1,
+	}
+
+	srv.elements.Description.ScannerInfo = options.ScannerInfo
+	srv.elements.Description.ScannerLocation = options.ScannerLocation
+
+	return srv
+}
+
+// Close closes the AbstractServer and cancels the active job, if any.
+func (srv *AbstractServer) Close() {
+	srv.lock.Lock()
+	job := srv.job
+	srv.job = nil
+	srv.lock.Unlock()
+
+	if job != nil {
+		job.close()
+	}
+}
+
+// ServeHTTP serves incoming HTTP requests.
+// It implements the [http.Handler] interface.
+func (srv *AbstractServer) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	if rq.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Read and decode the request
+	data, err := io.ReadAll(io.LimitReader(rq.Body,
+		abstractServerMaxXMLSize+1))
+	if err == nil && len(data) > abstractServerMaxXMLSize {
+		err = errors.New("request too large")
+	}
+
+	var action, msgid string
+	var body xmldoc.Element
+
+	if err == nil {
+		action, msgid, body, err = srv.decode(data)
+	}
+
+	if err != nil {
+		log.Debug(srv.ctx, "WS-Scan: %s", err)
+		srv.fault(w, "", soapFault(wsd.FaultCodeSender, "",
+			err.Error()))
+		return
+	}
+
+	log.Debug(srv.ctx, "WS-Scan: %s received", path.Base(action))
+
+	// Dispatch the request
+	switch action {
+	case ActGetScannerElements:
+		srv.getScannerElements(w, msgid, body)
+	case ActCreateScanJob:
+		srv.createScanJob(w, msgid, body)
+	case ActRetrieveImage:
+		srv.retrieveImage(w, msgid, body)
+	case ActCancelJob:
+		srv.cancelJob(w, msgid, body)
+	default:
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			fmt.Sprintf("%s: unsupported action", action)))
+	}
+}
+
+// decode decodes the SOAP request and returns its action,
+// message ID and the first child of the SOAP body.
+func (srv *AbstractServer) decode(data []byte) (action, msgid string,
+	body xmldoc.Element, err error) {
+
+	root, err := xmldoc.Decode(NsMap, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	hdr := xmldoc.Lookup{Name: NsSOAP + ":Header", Required: true}
+	bodyElm := xmldoc.Lookup{Name: NsSOAP + ":Body", Required: true}
+
+	if missed := root.Lookup(&hdr, &bodyElm); missed != nil {
+		err = xmldoc.XMLErrWrap(root, xmldoc.XMLErrMissed(missed.Name))
+		return
+	}
+
+	act := xmldoc.Lookup{Name: NsAddressing + ":Action", Required: true}
+	id := xmldoc.Lookup{Name: NsAddressing + ":MessageID"}
+
+	if missed := hdr.Elem.Lookup(&act, &id); missed != nil {
+		err = xmldoc.XMLErrWrap(hdr.Elem,
+			xmldoc.XMLErrMissed(missed.Name))
+		err = xmldoc.XMLErrWrap(root, err)
+		return
+	}
+
+	action = act.Elem.Text
+	msgid = id.Elem.Text
+
+	if len(bodyElm.Elem.Children) != 0 {
+		body = bodyElm.Elem.Children[0]
+	}
+
+	return
+}
+
+// getScannerElements handles the GetScannerElements request.
+func (srv *AbstractServer) getScannerElements(w http.ResponseWriter,
+	msgid string, body xmldoc.Element) {
+
+	requested, _ := body.ChildByName(NsScan + ":RequestedElements")
+
+	var elements ScannerElements
+	var invalid []string
+
+	for _, chld := range requested.Children {
+		if chld.Name != NsScan+":Name" {
+			continue
+		}
+
+		switch name := chld.Text; name {
+		case ElementScannerDescription:
+			elements.Description = srv.elements.Description
+		case ElementScannerConfiguration:
+			elements.Configuration = srv.elements.Configuration
+		case ElementScannerStatus:
+			elements.Status = srv.status()
+		case ElementDefaultScanTicket:
+			ticket := fromAbstractScanTicket(
+				srv.elements.Configuration,
+				&abstract.ScannerRequest{})
+			elements.DefaultScanTicket = &ticket
+		default:
+			invalid = append(invalid, name)
+		}
+	}
+
+	// Unknown elements are reported as invalid
+	rsp := elements.ToXML()
+	list := &rsp.Children[0]
+	for _, name := range invalid {
+		data := xmldoc.Element{Name: NsScan + ":ElementData"}
+		data.Attrs = []xmldoc.Attr{
+			{Name: "Name", Value: name},
+			{Name: "Valid", Value: "false"},
+		}
+		list.Children = append(list.Children, data)
+	}
+
+	srv.reply(w, ActGetScannerElementsResponse, msgid, rsp)
+}
+
+// status returns the current ScannerStatus.
+func (srv *AbstractServer) status() *ScannerStatus {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	status := &ScannerStatus{ScannerState: ScannerIdle}
+	if srv.job != nil {
+		status.ScannerState = ScannerProcessing
+	}
+
+	return status
+}
+
+// createScanJob handles the CreateScanJob request.
+func (srv *AbstractServer) createScanJob(w http.ResponseWriter,
+	msgid string, body xmldoc.Element) {
+
+	// Decode the ScanTicket
+	elm, ok := body.ChildByName(NsScan + ":ScanTicket")
+	if !ok {
+		err := xmldoc.XMLErrWrap(body,
+			xmldoc.XMLErrMissed(NsScan+":ScanTicket"))
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			err.Error()))
+		return
+	}
+
+	ticket, err := DecodeScanTicket(elm)
+	if err != nil {
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender, "",
+			err.Error()))
+		return
+	}
+
+	params := ticket.DocumentParameters
+	if params.Format != nil && FormatToMIME(*params.Format) == "" {
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeSender,
+			FaultClientErrorFormatNotSupported,
+			*params.Format+": format not supported"))
+		return
+	}
+
+	req := srv.request(&ticket)
+
+	// Start the job
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if job := srv.job; job != nil {
+		if time.Since(job.touched) < AbstractServerJobTimeout {
+			srv.fault(w, msgid, soapFault(wsd.FaultCodeReceiver,
+				FaultServerErrorNotAcceptingJobs, "Scanner is busy"))
+			return
+		}
+
+		log.Debug(srv.ctx, "WS-Scan: job %d abandoned", job.id)
+		srv.job = nil
+		go job.close()
+	}
+
+	ctx, cancel := context.WithCancel(srv.ctx)
+	doc, err := srv.options.Scanner.Scan(ctx, req)
+	if err != nil {
+		cancel()
+
+		fault := soapFault(wsd.FaultCodeSender, "", err.Error())
+		var errParam abstract.ErrParam
+		if !errors.As(err, &errParam) {
+			fault = soapFault(wsd.FaultCodeReceiver,
+				FaultServerErrorTemporaryError, err.Error())
+		}
+
+		srv.fault(w, msgid, fault)
+		return
+	}
+
+	job := &abstractServerJob{
+		id:      srv.nextID,
+		token:   uuid.Must(uuid.Random()).String(),
+		doc:     doc,
+		cancel:  cancel,
+		images:  optional.Get(params.ImagesToTransfer),
+		touched: time.Now(),
+	}
+
+	srv.nextID++
+	srv.job = job
+
+	log.Debug(srv.ctx, "WS-Scan: job %d started", job.id)
+
+	rsp := CreateScanJobResponse{
+		JobID:                   job.id,
+		JobToken:                job.token,
+		DocumentFinalParameters: optional.New(params),
+	}
+
+	srv.reply(w, ActCreateScanJobResponse, msgid, rsp.ToXML())
+}
+
+// request converts the [ScanTicket] into the [abstract.ScannerRequest]
+// and adapts it to the scanner capabilities.
+func (srv *AbstractServer) request(ticket *ScanTicket) abstract.ScannerRequest {
+	req := ticket.ToAbstract()
+
+	// Brightness and Contrast are in the WS-Scan ranges
+	req.Brightness = abstractServerExposure(req.Brightness,
+		srv.caps.BrightnessRange)
+	req.Contrast = abstractServerExposure(req.Contrast,
+		srv.caps.ContrastRange)
+
+	// WS-Scan clients usually send the full-size region, which
+	// means "the whole input".
+	var inp *abstract.InputCapabilities
+	switch {
+	case req.Input == abstract.InputPlaten:
+		inp = srv.caps.Platen
+	case req.ADFMode == abstract.ADFModeDuplex:
+		inp = srv.caps.ADFDuplex
+	case req.Input == abstract.InputADF:
+		inp = srv.caps.ADFSimplex
+	}
+
+	if inp != nil && req.Region.XOffset == 0 && req.Region.YOffset == 0 &&
+		req.Region.Width == inp.MaxWidth &&
+		req.Region.Height == inp.MaxHeight {
+		req.Region = abstract.Region{}
+	}
+
+	return req
+}
+
+// abstractServerExposure maps the Brightness or Contrast value
+// from the WS-Scan [ExposureRange] into the scanner's [abstract.Range].
+//
+// The normal value is mapped to the normal value, so the mapping
+// is linear in each half of the range. If scanner doesn't support
+// the parameter, it is dropped.
+func abstractServerExposure(v optional.Val[int],
+	rng abstract.Range) optional.Val[int] {
+
+	if v == nil || rng.Min == rng.Max {
+		return nil
+	}
+
+	x := max(min(*v, ExposureRange.Max), ExposureRange.Min)
+	x -= ExposureRange.Normal
+
+	var y int
+	if x >= 0 {
+		y = rng.Normal + x*(rng.Max-rng.Normal)/
+			(ExposureRange.Max-ExposureRange.Normal)
+	} else {
+		y = rng.Normal + x*(rng.Normal-rng.Min)/
+			(ExposureRange.Normal-ExposureRange.Min)
+	}
+
+	if rng.Step > 1 {
+		y = rng.Min + (y-rng.Min+rng.Step/2)/rng.Step*rng.Step
+		if y > rng.Max {
+			y -= rng.Step
+		}
+	}
+
+	return optional.New(y)
+}
+
+// retrieveImage handles the RetrieveImage request.
+func (srv *AbstractServer) retrieveImage(w http.ResponseWriter,
+	msgid string, body xmldoc.Element) {
+
+	job, fault := srv.lookupJob(body, true)
+	if job == nil {
+		srv.fault(w, msgid, fault)
+		return
+	}
+
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	noImages := soapFault(wsd.FaultCodeSender,
+		FaultClientErrorNoImagesAvailable, "No more images")
+
+	if job.doc == nil || (job.images != 0 && job.sent >= job.images) {
+		srv.finish(job)
+		srv.fault(w, msgid, noImages)
+		return
+	}
+
+	file, err := job.doc.Next()
+	switch {
+	case err == io.EOF:
+		srv.finish(job)
+		srv.fault(w, msgid, noImages)
+		return
+
+	case err != nil:
+		srv.finish(job)
+		srv.fault(w, msgid, soapFault(wsd.FaultCodeReceiver,
+			FaultServerErrorTemporaryError, err.Error()))
+		return
+	}
+
+	job.sent++
+	log.Debug(srv.ctx, "WS-Scan: job %d: sending image %d",
+		job.id, job.sent)
+
+	srv.sendImage(w, msgid, file)
+
+	if job.images != 0 && job.sent >= job.images {
+		srv.finish(job)
+	}
+}
+
+// sendImage sends the RetrieveImageResponse with the image, using
+// the MTOM encoding.
+func (srv *AbstractServer) sendImage(w http.ResponseWriter,
+	msgid string, file abstract.DocumentFile) {
+
+	include := xmldoc.WithAttrs(NsXOP+":Include",
+		xmldoc.Attr{Name: "href", Value: "cid:" + abstractServerImageCID})
+
+	rsp := xmldoc.WithChildren(NsScan+":RetrieveImageResponse",
+		xmldoc.WithChildren(NsScan+":ScanData", include))
+
+	mw := multipart.NewWriter(w)
+
+	w.Header().Set("Content-Type", fmt.Sprintf(
+		`multipart/related; type="application/xop+xml"; `+
+			`start="<%s>"; start-info="application/soap+xml"; `+
+			`boundary=%q`,
+		abstractServerRootCID, mw.Boundary()))
+	w.WriteHeader(http.StatusOK)
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`application/xop+xml; charset=utf-8; ` +
+			`type="application/soap+xml"`},
+		"Content-Id": {"<" + abstractServerRootCID + ">"},
+	})
+
+	if err == nil {
+		_, err = part.Write(wsd.SOAPEncodeResponse(soapResponseNs,
+			ActRetrieveImageResponse, msgid, rsp))
+	}
+
+	if err == nil {
+		part, err = mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"application/binary"},
+			"Content-Id":                {"<" + abstractServerImageCID + ">"},
+			"Content-Transfer-Encoding": {"binary"},
+		})
+	}
+
+	if err == nil {
+		_, err = io.Copy(part, file)
+	}
+
+	if err == nil {
+		err = mw.Close()
+	}
+
+	if err != nil {
+		log.Debug(srv.ctx, "WS-Scan: RetrieveImage: %s", err)
+	}
+}
+
+// cancelJob handles the CancelJob request.
+func (srv *AbstractServer) cancelJob(w http.ResponseWriter,
+	msgid string, body xmldoc.Element) {
+
+	job, fault := srv.lookupJob(body, false)
+	if job == nil {
+		srv.fault(w, msgid, fault)
+		return
+	}
+
+	// Cancel the job context first, so image transfer in
+	// progress, if any, will be terminated.
+	job.cancel()
+
+	job.lock.Lock()
+	srv.finish(job)
+	job.lock.Unlock()
+
+	log.Debug(srv.ctx, "WS-Scan: job %d canceled", job.id)
+
+	srv.reply(w, ActCancelJobResponse, msgid,
+		xmldoc.Element{Name: NsScan + ":CancelJobResponse"})
+}
+
+// lookupJob returns the active job, identified by the JobId (and
+// JobToken, if checkToken is true) elements of the request.
+//
+// If job is not found, it returns nil job and the Fault.
+func (srv *AbstractServer) lookupJob(body xmldoc.Element,
+	checkToken bool) (*abstractServerJob, wsd.Fault) {
+
+	id := xmldoc.Lookup{Name: NsScan + ":JobId", Required: true}
+	token := xmldoc.Lookup{Name: NsScan + ":JobToken"}
+
+	if missed := body.Lookup(&id, &token); missed != nil {
+		err := xmldoc.XMLErrWrap(body, xmldoc.XMLErrMissed(missed.Name))
+		return nil, soapFault(wsd.FaultCodeSender, "", err.Error())
+	}
+
+	jobID, err := strconv.Atoi(id.Elem.Text)
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	job := srv.job
+	if err != nil || job == nil || job.id != jobID {
+		return nil, soapFault(wsd.FaultCodeSender,
+			FaultClientErrorJobIDNotFound, "Job not found")
+	}
+
+	if checkToken && token.Elem.Text != job.token {
+		return nil, soapFault(wsd.FaultCodeSender,
+			FaultClientErrorInvalidJobToken, "Invalid job token")
+	}
+
+	job.touched = time.Now()
+
+	return job, wsd.Fault{}
+}
+
+// finish finishes the job: closes its document and removes
+// it from the server.
+//
+// Must be called under the job.lock.
+func (srv *AbstractServer) finish(job *abstractServerJob) {
+	srv.lock.Lock()
+	if srv.job == job {
+		srv.job = nil
+	}
+	srv.lock.Unlock()
+
+	job.closeLocked()
+}
+
+// close closes the job.
+func (job *abstractServerJob) close() {
+	job.cancel()
+
+	job.lock.Lock()
+	job.closeLocked()
+	job.lock.Unlock()
+}
+
+// closeLocked closes the job document, if not closed yet.
+//
+// Must be called under the job.lock.
+func (job *abstractServerJob) closeLocked() {
+	if job.doc != nil {
+		job.doc.Close()
+		job.doc = nil
+		job.cancel()
+	}
+}
+
+// reply sends the SOAP response.
+func (srv *AbstractServer) reply(w http.ResponseWriter,
+	action, relatesTo string, body xmldoc.Element) {
+
+	w.Header().Set("Content-Type", wsd.SOAPContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(wsd.SOAPEncodeResponse(soapResponseNs,
+		action, relatesTo, body))
+}
+
+// fault sends the SOAP Fault.
+//
+// Sender faults are sent with the 400 Bad Request HTTP status,
+// Receiver faults with the 500 Internal Server Error, as SOAP 1.2
+// HTTP binding requires.
+func (srv *AbstractServer) fault(w http.ResponseWriter,
+	relatesTo string, fault wsd.Fault) {
+
+	log.Debug(srv.ctx, "WS-Scan: %s", fault)
+
+	status := http.StatusInternalServerError
+	if fault.Code == wsd.FaultCodeSender {
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", wsd.SOAPContentType)
+	w.WriteHeader(status)
+	w.Write(wsd.SOAPEncodeResponse(soapResponseNs,
+		wsd.ActFault.Encode(), relatesTo, fault.ToXML()))
+}
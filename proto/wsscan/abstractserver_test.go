@@ -0,0 +1,215 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// AbstractServer test
+
+package wsscan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/internal/testutils"
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+)
+
+// TestAbstractServer tests AbstractServer against the AbstractClient.
+func TestAbstractServer(t *testing.T) {
+	img := testutils.Images.PNG100x75rgb8
+
+	scanner := &abstract.VirtualScanner{
+		ScanCaps:    abstractClientTestElements.ToAbstract(),
+		Resolution:  abstract.Resolution{XResolution: 75, YResolution: 75},
+		PlatenImage: img,
+		ADFImages:   [][]byte{img, img, img},
+	}
+
+	srv := NewAbstractServer(context.Background(), AbstractServerOptions{
+		Scanner:         scanner,
+		ScannerLocation: "Office",
+	})
+	defer srv.Close()
+
+	tr, loopback := transport.NewLoopback()
+	server := transport.NewServer(nil, srv)
+	go server.Serve(loopback)
+	defer server.Close()
+
+	u := transport.MustParseURL("http://localhost/WSDScanner")
+	ac, err := NewAbstractClient(context.Background(), u,
+		AbstractClientOptions{Transport: tr})
+	if err != nil {
+		t.Fatalf("NewAbstractClient: %s", err)
+	}
+
+	// Check capabilities
+	caps := ac.Capabilities()
+	if caps.MakeAndModel != "Test Scanner" {
+		t.Errorf("MakeAndModel: expected %q, present %q",
+			"Test Scanner", caps.MakeAndModel)
+	}
+
+	if caps.Platen == nil || caps.ADFSimplex == nil ||
+		caps.ADFDuplex == nil {
+		t.Fatalf("Capabilities: missed inputs")
+	}
+
+	if caps.Platen.MaxWidth != scanner.ScanCaps.Platen.MaxWidth {
+		t.Errorf("Platen.MaxWidth: expected %d, present %d",
+			scanner.ScanCaps.Platen.MaxWidth, caps.Platen.MaxWidth)
+	}
+
+	// Check scanning
+	type testData struct {
+		name   string                  // Test name
+		req    abstract.ScannerRequest // Scan request
+		pages  int                     // Expected pages
+		width  int                     // Expected image width
+		height int                     // Expected image height
+	}
+
+	tests := []testData{
+		{
+			name: "platen",
+			req: abstract.ScannerRequest{
+				Input:          abstract.InputPlaten,
+				DocumentFormat: abstract.DocumentFormatPNG,
+				Resolution: abstract.Resolution{
+					XResolution: 150,
+					YResolution: 150,
+				},
+			},
+			pages:  1,
+			width:  200,
+			height: 150,
+		},
+		{
+			name: "ADF",
+			req: abstract.ScannerRequest{
+				Input:          abstract.InputADF,
+				ADFMode:        abstract.ADFModeSimplex,
+				DocumentFormat: abstract.DocumentFormatPNG,
+				Resolution: abstract.Resolution{
+					XResolution: 300,
+					YResolution: 300,
+				},
+			},
+			pages:  3,
+			width:  400,
+			height: 300,
+		},
+	}
+
+	for _, test := range tests {
+		doc, err := ac.Scan(context.Background(), test.req)
+		if err != nil {
+			t.Errorf("%s: Scan: %s", test.name, err)
+			continue
+		}
+
+		pages := 0
+		for {
+			file, err := doc.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s: Next: %s", test.name, err)
+				break
+			}
+
+			data, err := io.ReadAll(file)
+			if err != nil {
+				t.Errorf("%s: Read: %s", test.name, err)
+				break
+			}
+
+			pages++
+
+			cfg, err := png.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Errorf("%s: page %d: %s", test.name, pages, err)
+				continue
+			}
+
+			if cfg.Width != test.width || cfg.Height != test.height {
+				t.Errorf("%s: page %d: expected %dx%d, present %dx%d",
+					test.name, pages, test.width, test.height,
+					cfg.Width, cfg.Height)
+			}
+		}
+
+		doc.Close()
+
+		if pages != test.pages {
+			t.Errorf("%s: %d pages expected, %d received",
+				test.name, test.pages, pages)
+		}
+	}
+
+	// Closing document in the middle must cancel the job,
+	// so the next job may start immediately.
+	req := tests[1].req
+
+	doc, err := ac.Scan(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Scan: %s", err)
+	}
+
+	if _, err = doc.Next(); err != nil {
+		t.Fatalf("Next: %s", err)
+	}
+
+	doc.Close()
+
+	doc, err = ac.Scan(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Scan after cancel: %s", err)
+	}
+
+	// Scanner is busy while job is active
+	_, err = ac.Scan(context.Background(), req)
+
+	var fault wsd.Fault
+	if !errors.As(err, &fault) ||
+		fault.Subcode != FaultServerErrorNotAcceptingJobs {
+		t.Errorf("Scan while busy: %s fault expected, present %v",
+			FaultServerErrorNotAcceptingJobs, err)
+	}
+
+	doc.Close()
+
+	// Invalid job must be rejected
+	clnt := ac.Client()
+	_, err = clnt.RetrieveImage(context.Background(), 12345, "token")
+	if !errors.As(err, &fault) ||
+		fault.Subcode != FaultClientErrorJobIDNotFound {
+		t.Errorf("RetrieveImage: %s fault expected, present %v",
+			FaultClientErrorJobIDNotFound, err)
+	}
+
+	// ScannerStatus and unknown elements
+	elements, err := clnt.GetScannerElements(context.Background(),
+		ElementScannerStatus, ElementScannerDescription, "Unknown")
+	if err != nil {
+		t.Fatalf("GetScannerElements: %s", err)
+	}
+
+	if elements.Status == nil ||
+		elements.Status.ScannerState != ScannerIdle {
+		t.Errorf("ScannerStatus: %s expected", ScannerIdle)
+	}
+
+	if elements.Description == nil ||
+		elements.Description.ScannerLocation != "Office" {
+		t.Errorf("ScannerDescription: ScannerLocation missed")
+	}
+}
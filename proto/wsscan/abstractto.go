@@ -0,0 +1,242 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Conversions from WS-Scan to abstract.Scanner data structures
+
+package wsscan
+
+import (
+	"slices"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+	"github.com/OpenPrinting/go-mfp/util/generic"
+)
+
+// ExposureRange is the range of the Brightness and Contrast
+// parameters, as defined by the WS-Scan specification.
+var ExposureRange = abstract.Range{Min: -1000, Max: 1000, Normal: 0}
+
+// DPI of the WS-Scan units (1/1000 of inch)
+const thousandthsDPI = 1000
+
+// ToAbstract converts [ScannerElements] to *[abstract.ScannerCapabilities].
+//
+// The ScannerConfiguration element is required for the meaningful
+// conversion. ScannerDescription, if present, is used for the
+// device identification.
+func (elements *ScannerElements) ToAbstract() *abstract.ScannerCapabilities {
+	abscaps := &abstract.ScannerCapabilities{}
+
+	if desc := elements.Description; desc != nil {
+		abscaps.MakeAndModel = desc.ScannerName
+	}
+
+	conf := elements.Configuration
+	if conf == nil {
+		return abscaps
+	}
+
+	// Common settings
+	settings := conf.DeviceSettings
+	for _, format := range settings.FormatsSupported {
+		mime := FormatToMIME(format)
+		if mime != "" && !slices.Contains(abscaps.DocumentFormats, mime) {
+			abscaps.DocumentFormats = append(abscaps.DocumentFormats,
+				mime)
+		}
+	}
+
+	if settings.CompressionQualityFactor != nil {
+		rng := *settings.CompressionQualityFactor
+		abscaps.CompressionRange = abstract.Range{
+			Min:    rng.Min,
+			Max:    rng.Max,
+			Normal: (rng.Min + rng.Max) / 2,
+		}
+	}
+
+	if settings.Brightness {
+		abscaps.BrightnessRange = ExposureRange
+	}
+
+	if settings.Contrast {
+		abscaps.ContrastRange = ExposureRange
+	}
+
+	var intents generic.Bitset[abstract.Intent]
+	for _, ct := range settings.ContentTypesSupported {
+		if intent := ct.toAbstract(); intent != abstract.IntentUnset {
+			intents.Add(intent)
+		}
+	}
+
+	// Inputs. Duplex ADF uses the front side configuration, as
+	// abstract.ScannerCapabilities doesn't distinguish sides.
+	if conf.Platen != nil {
+		abscaps.Platen = conf.Platen.toAbstract(intents)
+	}
+
+	if conf.ADFFront != nil {
+		abscaps.ADFSimplex = conf.ADFFront.toAbstract(intents)
+		if conf.ADFSupportsDuplex {
+			abscaps.ADFDuplex = conf.ADFFront.toAbstract(intents)
+		}
+	}
+
+	return abscaps
+}
+
+// ToAbstract converts [ScanTicket] into the [abstract.ScannerRequest].
+//
+// Brightness, Contrast and CompressionQualityFactor are passed as is,
+// in the WS-Scan ranges (see [ExposureRange]).
+//
+// Scan region is passed only if explicitly specified for the front
+// side of the media.
+func (ticket *ScanTicket) ToAbstract() abstract.ScannerRequest {
+	params := &ticket.DocumentParameters
+
+	req := abstract.ScannerRequest{
+		Brightness:  params.Brightness,
+		Contrast:    params.Contrast,
+		Compression: params.CompressionQualityFactor,
+	}
+
+	if params.InputSource != nil {
+		switch *params.InputSource {
+		case InputPlaten:
+			req.Input = abstract.InputPlaten
+		case InputADF:
+			req.Input = abstract.InputADF
+			req.ADFMode = abstract.ADFModeSimplex
+		case InputADFDuplex:
+			req.Input = abstract.InputADF
+			req.ADFMode = abstract.ADFModeDuplex
+		}
+	}
+
+	if params.Format != nil {
+		req.DocumentFormat = FormatToMIME(*params.Format)
+	}
+
+	if params.ContentType != nil {
+		req.Intent = (*params.ContentType).toAbstract()
+	}
+
+	if params.MediaFront != nil {
+		side := *params.MediaFront
+
+		if side.ColorProcessing != nil {
+			req.ColorMode, req.ColorDepth =
+				(*side.ColorProcessing).toAbstract()
+		}
+
+		if side.Resolution != nil {
+			res := *side.Resolution
+			req.Resolution = abstract.Resolution{
+				XResolution: res.Width,
+				YResolution: res.Height,
+			}
+		}
+
+		if side.ScanRegion != nil {
+			reg := *side.ScanRegion
+			if reg.Width != 0 && reg.Height != 0 {
+				req.Region = abstract.Region{
+					XOffset: toAbstractDimension(reg.XOffset),
+					YOffset: toAbstractDimension(reg.YOffset),
+					Width:   toAbstractDimension(reg.Width),
+					Height:  toAbstractDimension(reg.Height),
+				}
+			}
+		}
+	}
+
+	return req
+}
+
+// toAbstract converts [InputConfiguration] to *[abstract.InputCapabilities].
+func (inp *InputConfiguration) toAbstract(
+	intents generic.Bitset[abstract.Intent]) *abstract.InputCapabilities {
+
+	abscaps := &abstract.InputCapabilities{
+		MinWidth:              toAbstractDimension(inp.MinimumSize.Width),
+		MaxWidth:              toAbstractDimension(inp.MaximumSize.Width),
+		MinHeight:             toAbstractDimension(inp.MinimumSize.Height),
+		MaxHeight:             toAbstractDimension(inp.MaximumSize.Height),
+		MaxOpticalXResolution: inp.OpticalResolution.Width,
+		MaxOpticalYResolution: inp.OpticalResolution.Height,
+		Intents:               intents,
+	}
+
+	// Build settings profile
+	var prof abstract.SettingsProfile
+	for _, ce := range inp.Colors {
+		cm, depth := ce.toAbstract()
+		if cm != abstract.ColorModeUnset {
+			prof.ColorModes.Add(cm)
+		}
+		if depth != abstract.ColorDepthUnset {
+			prof.Depths.Add(depth)
+		}
+	}
+
+	// WS-Scan lists horizontal and vertical resolutions
+	// independently. Here we use only symmetrical ones.
+	for _, res := range inp.Widths {
+		if slices.Contains(inp.Heights, res) {
+			prof.Resolutions = append(prof.Resolutions,
+				abstract.Resolution{
+					XResolution: res,
+					YResolution: res,
+				})
+		}
+	}
+
+	abscaps.Profiles = []abstract.SettingsProfile{prof}
+
+	return abscaps
+}
+
+// toAbstract converts [ColorEntry] into the [abstract.ColorMode]
+// and [abstract.ColorDepth]. Color entries without abstract
+// equivalent return ColorModeUnset.
+func (ce ColorEntry) toAbstract() (abstract.ColorMode, abstract.ColorDepth) {
+	switch ce {
+	case BlackAndWhite1:
+		return abstract.ColorModeBinary, abstract.ColorDepthUnset
+	case Grayscale8:
+		return abstract.ColorModeMono, abstract.ColorDepth8
+	case Grayscale16:
+		return abstract.ColorModeMono, abstract.ColorDepth16
+	case RGB24:
+		return abstract.ColorModeColor, abstract.ColorDepth8
+	case RGB48:
+		return abstract.ColorModeColor, abstract.ColorDepth16
+	}
+
+	return abstract.ColorModeUnset, abstract.ColorDepthUnset
+}
+
+// toAbstract converts [ContentType] into the [abstract.Intent].
+func (ct ContentType) toAbstract() abstract.Intent {
+	switch ct {
+	case ContentText:
+		return abstract.IntentDocument
+	case ContentPhoto:
+		return abstract.IntentPhoto
+	case ContentMixed:
+		return abstract.IntentTextAndGraphic
+	}
+
+	return abstract.IntentUnset
+}
+
+// toAbstractDimension converts size in the WS-Scan units
+// (1/1000 of inch) into the [abstract.Dimension].
+func toAbstractDimension(v int) abstract.Dimension {
+	return abstract.DimensionFromDots(thousandthsDPI, v)
+}
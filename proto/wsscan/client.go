@@ -0,0 +1,287 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Scan client
+
+package wsscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/transport"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// Client implements a low-level WS-Scan client.
+//
+// It sends requests to the WS-Scan service endpoint, which URL
+// is obtained from the device metadata (see [wsd.Metadata]).
+//
+// [wsd.Metadata]: https://pkg.go.dev/github.com/OpenPrinting/go-mfp/proto/wsd#Metadata
+type Client struct {
+	url  *url.URL        // Service URL (http://...)
+	soap *wsd.SOAPClient // SOAP client
+}
+
+// NewClient creates a new WS-Scan client.
+//
+// If tr is nil, [transport.NewTransport] will be used to create
+// a new transport.
+func NewClient(u *url.URL, tr *transport.Transport) *Client {
+	return &Client{
+		url: transport.URLClone(u),
+		soap: &wsd.SOAPClient{
+			Proto:      "WS-Scan",
+			Ns:         NsMap,
+			HTTPClient: transport.NewClient(tr),
+		},
+	}
+}
+
+// GetScannerElements requests the [ScannerElements] from the
+// scanner.
+//
+// names are the names of the requested elements (i.e.,
+// [ElementScannerConfiguration]). If no names are specified,
+// ScannerDescription, ScannerConfiguration and ScannerStatus
+// are requested.
+func (c *Client) GetScannerElements(ctx context.Context,
+	names ...string) (*ScannerElements, error) {
+
+	if len(names) == 0 {
+		names = []string{
+			ElementScannerDescription,
+			ElementScannerConfiguration,
+			ElementScannerStatus,
+		}
+	}
+
+	requested := xmldoc.Element{Name: NsScan + ":RequestedElements"}
+	for _, name := range names {
+		requested.Children = append(requested.Children,
+			xmldoc.WithText(NsScan+":Name", name))
+	}
+
+	rq := xmldoc.WithChildren(NsScan+":GetScannerElementsRequest",
+		requested)
+
+	rsp, err := c.call(ctx, ActGetScannerElements, rq,
+		NsScan+":GetScannerElementsResponse")
+	if err != nil {
+		return nil, err
+	}
+
+	elements, err := DecodeScannerElements(rsp)
+	if err != nil {
+		return nil, fmt.Errorf("WS-Scan: %w", err)
+	}
+
+	return &elements, nil
+}
+
+// CreateScanJob starts the scan job with the specified [ScanTicket].
+//
+// Returned [CreateScanJobResponse] identifies the job for the
+// subsequent [Client.RetrieveImage] and [Client.CancelJob] requests.
+func (c *Client) CreateScanJob(ctx context.Context,
+	ticket ScanTicket) (*CreateScanJobResponse, error) {
+
+	rq := xmldoc.WithChildren(NsScan+":CreateScanJobRequest",
+		ticket.ToXML())
+
+	rsp, err := c.call(ctx, ActCreateScanJob, rq,
+		NsScan+":CreateScanJobResponse")
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := DecodeCreateScanJobResponse(rsp)
+	if err != nil {
+		return nil, fmt.Errorf("WS-Scan: %w", err)
+	}
+
+	return &job, nil
+}
+
+// RetrieveImage retrieves the next image of the scan job.
+//
+// On success, it returns the image body as [io.ReadCloser], which
+// must be closed by the caller. When there are no more images
+// (the ClientErrorNoImagesAvailable fault), it returns [io.EOF].
+//
+// The image is usually returned with the MTOM (multipart/related)
+// encoding. It is streamed, not buffered in memory.
+func (c *Client) RetrieveImage(ctx context.Context,
+	jobID int, jobToken string) (io.ReadCloser, error) {
+
+	rq := xmldoc.WithChildren(NsScan+":RetrieveImageRequest",
+		xmldoc.WithText(NsScan+":JobId", strconv.Itoa(jobID)),
+		xmldoc.WithText(NsScan+":JobToken", jobToken),
+		xmldoc.WithChildren(NsScan+":DocumentDescription",
+			xmldoc.WithText(NsScan+":DocumentName", "Scanned image")),
+	)
+
+	httpRsp, err := c.post(ctx, ActRetrieveImage, rq)
+	if err != nil {
+		return nil, c.imageErr(err)
+	}
+
+	ct := httpRsp.Header.Get("Content-Type")
+	mediatype, params, _ := mime.ParseMediaType(ct)
+	if mediatype != "multipart/related" {
+		// Plain SOAP response. Image may come inline.
+		defer httpRsp.Body.Close()
+
+		var rsp xmldoc.Element
+		rsp, err = c.soap.ReadResponse(httpRsp,
+			NsScan+":RetrieveImageResponse")
+		if err != nil {
+			return nil, c.imageErr(err)
+		}
+
+		return c.imageInline(rsp)
+	}
+
+	// Decode the MTOM response. The first part is the SOAP
+	// envelope, that refers the image part by its Content-ID
+	mr := multipart.NewReader(httpRsp.Body, params["boundary"])
+	part, err := mr.NextPart()
+	if err == nil {
+		var data []byte
+		data, err = c.soap.ReadXML(part)
+		if err == nil {
+			var rsp xmldoc.Element
+			rsp, err = wsd.SOAPDecode(NsMap, data,
+				NsScan+":RetrieveImageResponse")
+			if err == nil {
+				return c.imageMTOM(mr, rsp, httpRsp.Body)
+			}
+		}
+	}
+
+	httpRsp.Body.Close()
+	return nil, c.imageErr(err)
+}
+
+// imageErr translates RetrieveImage errors.
+func (c *Client) imageErr(err error) error {
+	var fault wsd.Fault
+	if errors.As(err, &fault) &&
+		fault.Subcode == FaultClientErrorNoImagesAvailable {
+		return io.EOF
+	}
+
+	return err
+}
+
+// imageInline returns the image, sent inline, as base64-encoded
+// ScanData, in the RetrieveImageResponse.
+func (c *Client) imageInline(rsp xmldoc.Element) (io.ReadCloser, error) {
+	data, ok := rsp.ChildByName(NsScan + ":ScanData")
+	if !ok {
+		err := xmldoc.XMLErrWrap(rsp,
+			xmldoc.XMLErrMissed(NsScan+":ScanData"))
+		return nil, fmt.Errorf("WS-Scan: %w", err)
+	}
+
+	image, err := base64.StdEncoding.DecodeString(
+		strings.TrimSpace(data.Text))
+	if err != nil {
+		return nil, fmt.Errorf("WS-Scan: ScanData: %w", err)
+	}
+
+	return io.NopCloser(bytes.NewReader(image)), nil
+}
+
+// imageMTOM returns the image part of the MTOM-encoded
+// RetrieveImageResponse.
+func (c *Client) imageMTOM(mr *multipart.Reader, rsp xmldoc.Element,
+	body io.ReadCloser) (io.ReadCloser, error) {
+
+	data, _ := rsp.ChildByName(NsScan + ":ScanData")
+	include, ok := data.ChildByName(NsXOP + ":Include")
+	if !ok {
+		body.Close()
+		err := xmldoc.XMLErrWrap(rsp,
+			xmldoc.XMLErrMissed(NsScan+":ScanData/"+
+				NsXOP+":Include"))
+		return nil, fmt.Errorf("WS-Scan: %w", err)
+	}
+
+	href, _ := include.AttrByName("href")
+	cid := strings.TrimPrefix(href.Value, "cid:")
+	if s, err := url.PathUnescape(cid); err == nil {
+		cid = s
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			body.Close()
+			if err == io.EOF {
+				err = fmt.Errorf("WS-Scan: image part %q missed",
+					cid)
+			}
+			return nil, err
+		}
+
+		id := part.Header.Get("Content-ID")
+		id = strings.TrimSuffix(strings.TrimPrefix(id, "<"), ">")
+		if id == cid {
+			return &clientImage{Reader: part, body: body}, nil
+		}
+	}
+}
+
+// CancelJob cancels the scan job.
+func (c *Client) CancelJob(ctx context.Context, jobID int) error {
+	rq := xmldoc.WithChildren(NsScan+":CancelJobRequest",
+		xmldoc.WithText(NsScan+":JobId", strconv.Itoa(jobID)))
+
+	_, err := c.call(ctx, ActCancelJob, rq, NsScan+":CancelJobResponse")
+	return err
+}
+
+// call performs the SOAP request and returns the response body
+// element with the expected name.
+func (c *Client) call(ctx context.Context, action string,
+	rq xmldoc.Element, name string) (xmldoc.Element, error) {
+
+	return c.soap.Call(ctx, c.url, action, rq, name)
+}
+
+// post sends the SOAP request and returns the HTTP response.
+func (c *Client) post(ctx context.Context, action string,
+	rq xmldoc.Element) (*http.Response, error) {
+
+	data := wsd.SOAPEncode(NsMap, action, c.url.String(), rq)
+	return c.soap.Post(ctx, c.url, action, wsd.SOAPContentType,
+		bytes.NewReader(data))
+}
+
+// clientImage is the io.ReadCloser, returned by the
+// [Client.RetrieveImage] for the MTOM-encoded image.
+type clientImage struct {
+	io.Reader               // Image part of the multipart body
+	body      io.ReadCloser // Underlying HTTP response body
+}
+
+// Close closes the clientImage.
+func (img *clientImage) Close() error {
+	return img.body.Close()
+}
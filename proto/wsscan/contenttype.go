@@ -0,0 +1,77 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Content type of the scanned document
+
+package wsscan
+
+import (
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// ContentType specifies the content type of the scanned document,
+// used as the hint for the image processing.
+type ContentType int
+
+// Known content types:
+const (
+	UnknownContentType ContentType = iota
+	ContentAuto                    // Device chooses automatically
+	ContentText                    // Text
+	ContentPhoto                   // Photo
+	ContentHalftone                // Halftone image
+	ContentMixed                   // Mixed text and images
+)
+
+// decodeContentType decodes [ContentType] from the XML tree.
+func decodeContentType(root xmldoc.Element) (ct ContentType, err error) {
+	return decodeEnum(root, DecodeContentType)
+}
+
+// toXML generates XML tree for the [ContentType].
+func (ct ContentType) toXML(name string) xmldoc.Element {
+	return xmldoc.Element{
+		Name: name,
+		Text: ct.String(),
+	}
+}
+
+// String returns a string representation of the [ContentType]
+func (ct ContentType) String() string {
+	switch ct {
+	case ContentAuto:
+		return "Auto"
+	case ContentText:
+		return "Text"
+	case ContentPhoto:
+		return "Photo"
+	case ContentHalftone:
+		return "Halftone"
+	case ContentMixed:
+		return "Mixed"
+	}
+
+	return "Unknown"
+}
+
+// DecodeContentType decodes [ContentType] out of its XML string
+// representation.
+func DecodeContentType(s string) ContentType {
+	switch s {
+	case "Auto":
+		return ContentAuto
+	case "Text":
+		return ContentText
+	case "Photo":
+		return ContentPhoto
+	case "Halftone":
+		return ContentHalftone
+	case "Mixed":
+		return ContentMixed
+	}
+
+	return UnknownContentType
+}
@@ -0,0 +1,28 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Test for content type
+
+package wsscan
+
+import "testing"
+
+var testContentType = testEnum[ContentType]{
+	decodeStr: DecodeContentType,
+	decodeXML: decodeContentType,
+	dataset: []testEnumData[ContentType]{
+		{ContentAuto, "Auto"},
+		{ContentText, "Text"},
+		{ContentPhoto, "Photo"},
+		{ContentHalftone, "Halftone"},
+		{ContentMixed, "Mixed"},
+	},
+}
+
+// TestContentType tests [ContentType] common methods and functions.
+func TestContentType(t *testing.T) {
+	testContentType.run(t)
+}
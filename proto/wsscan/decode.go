@@ -60,11 +60,14 @@ func decodeNonNegativeInt(root xmldoc.Element) (v int, err error) {
 }
 
 // decodeBool decodes boolean from the XML tree.
+//
+// As xs:boolean allows both "true"/"false" and "1"/"0" forms,
+// both are accepted.
 func decodeBool(root xmldoc.Element) (v bool, err error) {
 	switch root.Text {
-	case "true":
+	case "true", "1":
 		return true, nil
-	case "false":
+	case "false", "0":
 		return false, nil
 	}
 
@@ -171,6 +171,18 @@ func TestDecodeBool(t *testing.T) {
 			err: ``,
 		},
 
+		{
+			xml: xmldoc.WithText("test", "1"),
+			out: true,
+			err: ``,
+		},
+
+		{
+			xml: xmldoc.WithText("test", "0"),
+			out: false,
+			err: ``,
+		},
+
 		{
 			xml: xmldoc.WithText("test", "bad"),
 			err: `/test: invalid bool: "bad"`,
@@ -0,0 +1,89 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Width and Height pairs and ranges of values
+
+package wsscan
+
+import (
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// Dimensions represents the pair of Width and Height values.
+//
+// Depending on context, it may be the size in the 1/1000 of inch
+// (i.e., PlatenMaximumSize) or resolution in DPI (i.e., Resolution).
+type Dimensions struct {
+	Width  int // Width
+	Height int // Height
+}
+
+// decodeDimensions decodes [Dimensions] from the XML tree.
+func decodeDimensions(root xmldoc.Element) (dim Dimensions, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	width := xmldoc.Lookup{Name: NsScan + ":Width", Required: true}
+	height := xmldoc.Lookup{Name: NsScan + ":Height", Required: true}
+
+	missed := root.Lookup(&width, &height)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	dim.Width, err = decodeNonNegativeInt(width.Elem)
+	if err == nil {
+		dim.Height, err = decodeNonNegativeInt(height.Elem)
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [Dimensions].
+func (dim Dimensions) toXML(name string) xmldoc.Element {
+	return xmldoc.WithChildren(name,
+		xmldoc.WithText(NsScan+":Width", strconv.Itoa(dim.Width)),
+		xmldoc.WithText(NsScan+":Height", strconv.Itoa(dim.Height)),
+	)
+}
+
+// ValueRange represents the range of the supported values
+// (i.e., CompressionQualityFactorSupported).
+type ValueRange struct {
+	Min int // Minimal value
+	Max int // Maximal value
+}
+
+// decodeValueRange decodes [ValueRange] from the XML tree.
+func decodeValueRange(root xmldoc.Element) (rng ValueRange, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	minval := xmldoc.Lookup{Name: NsScan + ":MinValue", Required: true}
+	maxval := xmldoc.Lookup{Name: NsScan + ":MaxValue", Required: true}
+
+	missed := root.Lookup(&minval, &maxval)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	rng.Min, err = decodeInt(minval.Elem)
+	if err == nil {
+		rng.Max, err = decodeInt(maxval.Elem)
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [ValueRange].
+func (rng ValueRange) toXML(name string) xmldoc.Element {
+	return xmldoc.WithChildren(name,
+		xmldoc.WithText(NsScan+":MinValue", strconv.Itoa(rng.Min)),
+		xmldoc.WithText(NsScan+":MaxValue", strconv.Itoa(rng.Max)),
+	)
+}
@@ -0,0 +1,81 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Document formats
+
+package wsscan
+
+import (
+	"strings"
+
+	"github.com/OpenPrinting/go-mfp/abstract"
+)
+
+// Known WS-Scan document formats (the FormatValue strings):
+const (
+	FormatDIB                    = "dib"
+	FormatExif                   = "exif"
+	FormatJBIG                   = "jbig"
+	FormatJFIF                   = "jfif"
+	FormatJPEG2K                 = "jpeg2k"
+	FormatPDFA                   = "pdf-a"
+	FormatPNG                    = "png"
+	FormatTIFFSingleUncompressed = "tiff-single-uncompressed"
+	FormatTIFFSingleG4           = "tiff-single-g4"
+	FormatTIFFSingleG3MH         = "tiff-single-g3mh"
+	FormatTIFFSingleJPEGTN2      = "tiff-single-jpeg-tn2"
+	FormatXPS                    = "xps"
+)
+
+// formatTable maps WS-Scan formats to MIME types.
+//
+// If multiple formats map to the same MIME type, the preferred
+// format goes first.
+var formatTable = []struct {
+	format string
+	mime   string
+}{
+	{FormatJFIF, abstract.DocumentFormatJPEG},
+	{FormatExif, abstract.DocumentFormatJPEG},
+	{FormatPDFA, abstract.DocumentFormatPDF},
+	{FormatPNG, abstract.DocumentFormatPNG},
+	{FormatTIFFSingleUncompressed, abstract.DocumentFormatTIFF},
+	{FormatTIFFSingleG4, abstract.DocumentFormatTIFF},
+	{FormatTIFFSingleG3MH, abstract.DocumentFormatTIFF},
+	{FormatTIFFSingleJPEGTN2, abstract.DocumentFormatTIFF},
+	{FormatDIB, abstract.DocumentFormatBMP},
+}
+
+// FormatToMIME returns MIME type of the WS-Scan document format.
+// If format has no MIME equivalent, "" is returned.
+func FormatToMIME(format string) string {
+	for _, ent := range formatTable {
+		if strings.EqualFold(ent.format, format) {
+			return ent.mime
+		}
+	}
+
+	return ""
+}
+
+// FormatFromMIME returns the WS-Scan document format for the MIME
+// type, choosing among the supported formats. If there is no
+// suitable format, "" is returned.
+func FormatFromMIME(mime string, supported []string) string {
+	for _, ent := range formatTable {
+		if !strings.EqualFold(ent.mime, mime) {
+			continue
+		}
+
+		for _, format := range supported {
+			if strings.EqualFold(ent.format, format) {
+				return format
+			}
+		}
+	}
+
+	return ""
+}
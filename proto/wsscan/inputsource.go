@@ -0,0 +1,71 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scan input source
+
+package wsscan
+
+import (
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// InputSource specifies the input source of the scan job.
+type InputSource int
+
+// Known input sources:
+const (
+	UnknownInputSource InputSource = iota
+	InputPlaten                    // Flatbed scanner
+	InputADF                       // ADF, front side only
+	InputADFDuplex                 // ADF, both sides
+	InputFilm                      // Film scanner
+)
+
+// decodeInputSource decodes [InputSource] from the XML tree.
+func decodeInputSource(root xmldoc.Element) (src InputSource, err error) {
+	return decodeEnum(root, DecodeInputSource)
+}
+
+// toXML generates XML tree for the [InputSource].
+func (src InputSource) toXML(name string) xmldoc.Element {
+	return xmldoc.Element{
+		Name: name,
+		Text: src.String(),
+	}
+}
+
+// String returns a string representation of the [InputSource]
+func (src InputSource) String() string {
+	switch src {
+	case InputPlaten:
+		return "Platen"
+	case InputADF:
+		return "ADF"
+	case InputADFDuplex:
+		return "ADFDuplex"
+	case InputFilm:
+		return "Film"
+	}
+
+	return "Unknown"
+}
+
+// DecodeInputSource decodes [InputSource] out of its XML string
+// representation.
+func DecodeInputSource(s string) InputSource {
+	switch s {
+	case "Platen":
+		return InputPlaten
+	case "ADF":
+		return InputADF
+	case "ADFDuplex":
+		return InputADFDuplex
+	case "Film":
+		return InputFilm
+	}
+
+	return UnknownInputSource
+}
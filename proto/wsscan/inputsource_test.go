@@ -0,0 +1,27 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Test for input source
+
+package wsscan
+
+import "testing"
+
+var testInputSource = testEnum[InputSource]{
+	decodeStr: DecodeInputSource,
+	decodeXML: decodeInputSource,
+	dataset: []testEnumData[InputSource]{
+		{InputPlaten, "Platen"},
+		{InputADF, "ADF"},
+		{InputADFDuplex, "ADFDuplex"},
+		{InputFilm, "Film"},
+	},
+}
+
+// TestInputSource tests [InputSource] common methods and functions.
+func TestInputSource(t *testing.T) {
+	testInputSource.run(t)
+}
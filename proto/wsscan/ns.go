@@ -0,0 +1,38 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// WS-Scan namespace
+
+package wsscan
+
+import "github.com/OpenPrinting/go-mfp/util/xmldoc"
+
+// Namespace prefixes:
+const (
+	NsSOAP       = "s"
+	NsAddressing = "a"
+	NsScan       = "scan"
+	NsXOP        = "xop"
+)
+
+// NsScanURL is the WS-Scan namespace URL. It is also used as
+// the base for WS-Scan actions.
+const NsScanURL = "http://schemas.microsoft.com/windows/2006/08/wdp/scan"
+
+// NsMap maps namespace prefixes to URL
+var NsMap = xmldoc.Namespace{
+	// SOAP 1.2
+	{Prefix: NsSOAP, URL: "http://www.w3.org/2003/05/soap-envelope"},
+
+	// WS-Addressing
+	{Prefix: NsAddressing, URL: "http://schemas.xmlsoap.org/ws/2004/08/addressing"},
+
+	// WS-Scan
+	{Prefix: NsScan, URL: NsScanURL},
+
+	// XOP, used by MTOM-encoded RetrieveImage responses
+	{Prefix: NsXOP, URL: "http://www.w3.org/2004/08/xop/include"},
+}
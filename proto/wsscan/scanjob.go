@@ -0,0 +1,74 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scan job, created by the CreateScanJob request
+
+package wsscan
+
+import (
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// CreateScanJobResponse represents the scan job, created by the
+// CreateScanJob request.
+//
+// JobID and JobToken are used to identify the job in the subsequent
+// RetrieveImage and CancelJob requests.
+type CreateScanJobResponse struct {
+	JobID    int    // Job identifier
+	JobToken string // Job token
+
+	// DocumentFinalParameters are parameters, actually used
+	// by the scanner. They may differ from the requested.
+	DocumentFinalParameters optional.Val[DocumentParameters]
+}
+
+// DecodeCreateScanJobResponse decodes [CreateScanJobResponse]
+// from the XML tree.
+func DecodeCreateScanJobResponse(root xmldoc.Element) (
+	rsp CreateScanJobResponse, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	id := xmldoc.Lookup{Name: NsScan + ":JobId", Required: true}
+	token := xmldoc.Lookup{Name: NsScan + ":JobToken", Required: true}
+	params := xmldoc.Lookup{Name: NsScan + ":DocumentFinalParameters"}
+
+	missed := root.Lookup(&id, &token, &params)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	rsp.JobID, err = decodeNonNegativeInt(id.Elem)
+	rsp.JobToken = token.Elem.Text
+
+	if err == nil && params.Found {
+		rsp.DocumentFinalParameters, err = decodeOptional(params.Elem,
+			DecodeDocumentParameters)
+	}
+
+	return
+}
+
+// ToXML generates XML tree for the [CreateScanJobResponse].
+func (rsp CreateScanJobResponse) ToXML() xmldoc.Element {
+	elm := xmldoc.WithChildren(NsScan+":CreateScanJobResponse",
+		xmldoc.WithText(NsScan+":JobId", strconv.Itoa(rsp.JobID)),
+		xmldoc.WithText(NsScan+":JobToken", rsp.JobToken),
+	)
+
+	if rsp.DocumentFinalParameters != nil {
+		elm.Children = append(elm.Children,
+			(*rsp.DocumentFinalParameters).ToXML(
+				NsScan+":DocumentFinalParameters"))
+	}
+
+	return elm
+}
@@ -0,0 +1,582 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scanner elements (description, configuration and status)
+
+package wsscan
+
+import (
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// Names of the scanner elements, that can be requested
+// with the GetScannerElements request:
+const (
+	ElementScannerDescription   = NsScan + ":ScannerDescription"
+	ElementScannerConfiguration = NsScan + ":ScannerConfiguration"
+	ElementScannerStatus        = NsScan + ":ScannerStatus"
+	ElementDefaultScanTicket    = NsScan + ":DefaultScanTicket"
+)
+
+// ScannerElements contains the scanner elements, returned by
+// the GetScannerElements request.
+//
+// Elements, not requested or reported by scanner as invalid,
+// are nil.
+type ScannerElements struct {
+	Description       *ScannerDescription   // Scanner description
+	Configuration     *ScannerConfiguration // Scanner capabilities
+	Status            *ScannerStatus        // Scanner status
+	DefaultScanTicket *ScanTicket           // Default scan parameters
+}
+
+// ScannerDescription contains the human-readable scanner description.
+type ScannerDescription struct {
+	ScannerName     string // Scanner name
+	ScannerInfo     string // Additional information
+	ScannerLocation string // Scanner location
+}
+
+// ScannerConfiguration describes the scanner capabilities.
+type ScannerConfiguration struct {
+	DeviceSettings    DeviceSettings      // Common settings
+	Platen            *InputConfiguration // Platen, nil if none
+	ADFFront          *InputConfiguration // ADF front side, nil if none
+	ADFBack           *InputConfiguration // ADF back side, nil if none
+	ADFSupportsDuplex bool                // ADF can scan both sides
+}
+
+// DeviceSettings describes the scanner settings, common for
+// all input sources.
+type DeviceSettings struct {
+	FormatsSupported         []string                 // Document formats
+	CompressionQualityFactor optional.Val[ValueRange] // Quality range
+	ContentTypesSupported    []ContentType            // Content types
+	DocumentSizeAutoDetect   bool                     // Detects doc size
+	AutoExposure             bool                     // Auto exposure
+	Brightness               bool                     // Brightness
+	Contrast                 bool                     // Contrast
+}
+
+// InputConfiguration describes capabilities of the particular
+// input source (Platen, ADFFront or ADFBack).
+//
+// Sizes are in the 1/1000 of inch, resolutions are in DPI.
+type InputConfiguration struct {
+	Colors            []ColorEntry // Supported color modes
+	MinimumSize       Dimensions   // Min scan size
+	MaximumSize       Dimensions   // Max scan size
+	OpticalResolution Dimensions   // Optical resolution
+	Widths            []int        // Supported horizontal resolutions
+	Heights           []int        // Supported vertical resolutions
+}
+
+// ScannerStatus represents the current scanner status.
+type ScannerStatus struct {
+	ScannerState        ScannerState // Overall scanner state
+	ScannerStateReasons []string     // State reasons
+}
+
+// DecodeScannerElements decodes [ScannerElements] from the
+// XML tree of the GetScannerElementsResponse.
+func DecodeScannerElements(root xmldoc.Element) (
+	elements ScannerElements, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	list, ok := root.ChildByName(NsScan + ":ScannerElements")
+	if !ok {
+		err = xmldoc.XMLErrMissed(NsScan + ":ScannerElements")
+		return
+	}
+
+	for _, data := range list.Children {
+		if data.Name != NsScan+":ElementData" {
+			continue
+		}
+
+		err = elements.decodeElementData(data)
+		if err != nil {
+			err = xmldoc.XMLErrWrap(list, err)
+			return
+		}
+	}
+
+	return
+}
+
+// decodeElementData decodes the single ElementData and saves
+// the decoded element into the ScannerElements.
+func (elements *ScannerElements) decodeElementData(
+	data xmldoc.Element) (err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(data, err) }()
+
+	if valid, ok := data.AttrByName("Valid"); ok {
+		if valid.Value == "false" || valid.Value == "0" {
+			return
+		}
+	}
+
+	for _, chld := range data.Children {
+		switch chld.Name {
+		case ElementScannerDescription:
+			var desc ScannerDescription
+			desc, err = decodeScannerDescription(chld)
+			elements.Description = &desc
+
+		case ElementScannerConfiguration:
+			var conf ScannerConfiguration
+			conf, err = decodeScannerConfiguration(chld)
+			elements.Configuration = &conf
+
+		case ElementScannerStatus:
+			var status ScannerStatus
+			status, err = decodeScannerStatus(chld)
+			elements.Status = &status
+
+		case ElementDefaultScanTicket:
+			var ticket ScanTicket
+			ticket, err = DecodeScanTicket(chld)
+			elements.DefaultScanTicket = &ticket
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ToXML generates XML tree for the [ScannerElements], as
+// GetScannerElementsResponse body.
+func (elements ScannerElements) ToXML() xmldoc.Element {
+	list := xmldoc.Element{Name: NsScan + ":ScannerElements"}
+
+	add := func(name string, elm xmldoc.Element) {
+		data := xmldoc.WithChildren(NsScan+":ElementData", elm)
+		data.Attrs = []xmldoc.Attr{
+			{Name: "Name", Value: name},
+			{Name: "Valid", Value: "true"},
+		}
+		list.Children = append(list.Children, data)
+	}
+
+	if elements.Description != nil {
+		add(ElementScannerDescription, elements.Description.toXML())
+	}
+
+	if elements.Configuration != nil {
+		add(ElementScannerConfiguration,
+			elements.Configuration.toXML())
+	}
+
+	if elements.Status != nil {
+		add(ElementScannerStatus, elements.Status.toXML())
+	}
+
+	if elements.DefaultScanTicket != nil {
+		ticket := elements.DefaultScanTicket.ToXML()
+		ticket.Name = ElementDefaultScanTicket
+		add(ElementDefaultScanTicket, ticket)
+	}
+
+	return xmldoc.WithChildren(NsScan+":GetScannerElementsResponse",
+		list)
+}
+
+// decodeScannerDescription decodes [ScannerDescription] from
+// the XML tree.
+//
+// If string element comes in multiple languages, the first
+// one is used.
+func decodeScannerDescription(root xmldoc.Element) (
+	desc ScannerDescription, err error) {
+
+	name := xmldoc.Lookup{Name: NsScan + ":ScannerName", Required: true}
+	info := xmldoc.Lookup{Name: NsScan + ":ScannerInfo"}
+	location := xmldoc.Lookup{Name: NsScan + ":ScannerLocation"}
+
+	missed := root.Lookup(&name, &info, &location)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return desc, xmldoc.XMLErrWrap(root, err)
+	}
+
+	desc.ScannerName = name.Elem.Text
+	desc.ScannerInfo = info.Elem.Text
+	desc.ScannerLocation = location.Elem.Text
+
+	return
+}
+
+// toXML generates XML tree for the [ScannerDescription].
+func (desc ScannerDescription) toXML() xmldoc.Element {
+	elm := xmldoc.WithChildren(ElementScannerDescription,
+		xmldoc.WithText(NsScan+":ScannerName", desc.ScannerName))
+
+	if desc.ScannerInfo != "" {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsScan+":ScannerInfo", desc.ScannerInfo))
+	}
+
+	if desc.ScannerLocation != "" {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsScan+":ScannerLocation",
+				desc.ScannerLocation))
+	}
+
+	return elm
+}
+
+// decodeScannerConfiguration decodes [ScannerConfiguration] from
+// the XML tree.
+func decodeScannerConfiguration(root xmldoc.Element) (
+	conf ScannerConfiguration, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	settings := xmldoc.Lookup{Name: NsScan + ":DeviceSettings",
+		Required: true}
+	platen := xmldoc.Lookup{Name: NsScan + ":Platen"}
+	adf := xmldoc.Lookup{Name: NsScan + ":ADF"}
+
+	missed := root.Lookup(&settings, &platen, &adf)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	conf.DeviceSettings, err = decodeDeviceSettings(settings.Elem)
+	if err == nil && platen.Found {
+		var inp InputConfiguration
+		inp, err = decodeInputConfiguration(platen.Elem, "Platen")
+		conf.Platen = &inp
+	}
+
+	if err == nil && adf.Found {
+		err = conf.decodeADF(adf.Elem)
+	}
+
+	return
+}
+
+// decodeADF decodes the ADF element of the ScannerConfiguration.
+func (conf *ScannerConfiguration) decodeADF(root xmldoc.Element) (err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	duplex := xmldoc.Lookup{Name: NsScan + ":ADFSupportsDuplex"}
+	front := xmldoc.Lookup{Name: NsScan + ":ADFFront"}
+	back := xmldoc.Lookup{Name: NsScan + ":ADFBack"}
+	root.Lookup(&duplex, &front, &back)
+
+	if duplex.Found {
+		conf.ADFSupportsDuplex, err = decodeBool(duplex.Elem)
+	}
+
+	if err == nil && front.Found {
+		var inp InputConfiguration
+		inp, err = decodeInputConfiguration(front.Elem, "ADF")
+		conf.ADFFront = &inp
+	}
+
+	if err == nil && back.Found {
+		var inp InputConfiguration
+		inp, err = decodeInputConfiguration(back.Elem, "ADF")
+		conf.ADFBack = &inp
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [ScannerConfiguration].
+func (conf ScannerConfiguration) toXML() xmldoc.Element {
+	elm := xmldoc.WithChildren(ElementScannerConfiguration,
+		conf.DeviceSettings.toXML())
+
+	if conf.Platen != nil {
+		elm.Children = append(elm.Children,
+			conf.Platen.toXML(NsScan+":Platen", "Platen"))
+	}
+
+	if conf.ADFFront != nil || conf.ADFBack != nil {
+		adf := xmldoc.WithChildren(NsScan+":ADF",
+			xmldoc.WithText(NsScan+":ADFSupportsDuplex",
+				strconv.FormatBool(conf.ADFSupportsDuplex)))
+
+		if conf.ADFFront != nil {
+			adf.Children = append(adf.Children,
+				conf.ADFFront.toXML(NsScan+":ADFFront", "ADF"))
+		}
+
+		if conf.ADFBack != nil {
+			adf.Children = append(adf.Children,
+				conf.ADFBack.toXML(NsScan+":ADFBack", "ADF"))
+		}
+
+		elm.Children = append(elm.Children, adf)
+	}
+
+	return elm
+}
+
+// decodeDeviceSettings decodes [DeviceSettings] from the XML tree.
+func decodeDeviceSettings(root xmldoc.Element) (
+	settings DeviceSettings, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	for _, chld := range root.Children {
+		switch chld.Name {
+		case NsScan + ":FormatsSupported":
+			for _, v := range chld.Children {
+				if v.Name == NsScan+":FormatValue" {
+					settings.FormatsSupported = append(
+						settings.FormatsSupported, v.Text)
+				}
+			}
+
+		case NsScan + ":CompressionQualityFactorSupported":
+			settings.CompressionQualityFactor, err = decodeOptional(
+				chld, decodeValueRange)
+
+		case NsScan + ":ContentTypesSupported":
+			for _, v := range chld.Children {
+				if v.Name != NsScan+":ContentTypeValue" {
+					continue
+				}
+
+				var ct ContentType
+				ct, err = decodeContentType(v)
+				if err != nil {
+					break
+				}
+
+				settings.ContentTypesSupported = append(
+					settings.ContentTypesSupported, ct)
+			}
+
+		case NsScan + ":DocumentSizeAutoDetectSupported":
+			settings.DocumentSizeAutoDetect, err = decodeBool(chld)
+		case NsScan + ":AutoExposureSupported":
+			settings.AutoExposure, err = decodeBool(chld)
+		case NsScan + ":BrightnessSupported":
+			settings.Brightness, err = decodeBool(chld)
+		case NsScan + ":ContrastSupported":
+			settings.Contrast, err = decodeBool(chld)
+		}
+
+		if err != nil {
+			err = xmldoc.XMLErrWrap(chld, err)
+			return
+		}
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [DeviceSettings].
+func (settings DeviceSettings) toXML() xmldoc.Element {
+	formats := xmldoc.Element{Name: NsScan + ":FormatsSupported"}
+	for _, format := range settings.FormatsSupported {
+		formats.Children = append(formats.Children,
+			xmldoc.WithText(NsScan+":FormatValue", format))
+	}
+
+	elm := xmldoc.WithChildren(NsScan+":DeviceSettings", formats)
+
+	if settings.CompressionQualityFactor != nil {
+		elm.Children = append(elm.Children,
+			(*settings.CompressionQualityFactor).toXML(
+				NsScan+":CompressionQualityFactorSupported"))
+	}
+
+	if len(settings.ContentTypesSupported) != 0 {
+		types := xmldoc.Element{Name: NsScan + ":ContentTypesSupported"}
+		for _, ct := range settings.ContentTypesSupported {
+			types.Children = append(types.Children,
+				ct.toXML(NsScan+":ContentTypeValue"))
+		}
+		elm.Children = append(elm.Children, types)
+	}
+
+	elm.Children = append(elm.Children,
+		xmldoc.WithText(NsScan+":DocumentSizeAutoDetectSupported",
+			strconv.FormatBool(settings.DocumentSizeAutoDetect)),
+		xmldoc.WithText(NsScan+":AutoExposureSupported",
+			strconv.FormatBool(settings.AutoExposure)),
+		xmldoc.WithText(NsScan+":BrightnessSupported",
+			strconv.FormatBool(settings.Brightness)),
+		xmldoc.WithText(NsScan+":ContrastSupported",
+			strconv.FormatBool(settings.Contrast)),
+	)
+
+	return elm
+}
+
+// decodeInputConfiguration decodes [InputConfiguration] from
+// the XML tree.
+//
+// Names of the children elements start with the prefix, which
+// depends on the input source (i.e., "PlatenColor" or "ADFColor").
+func decodeInputConfiguration(root xmldoc.Element, prefix string) (
+	inp InputConfiguration, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	prefix = NsScan + ":" + prefix
+
+	color := xmldoc.Lookup{Name: prefix + "Color", Required: true}
+	minSize := xmldoc.Lookup{Name: prefix + "MinimumSize", Required: true}
+	maxSize := xmldoc.Lookup{Name: prefix + "MaximumSize", Required: true}
+	optical := xmldoc.Lookup{Name: prefix + "OpticalResolution"}
+	res := xmldoc.Lookup{Name: prefix + "Resolutions", Required: true}
+
+	missed := root.Lookup(&color, &minSize, &maxSize, &optical, &res)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	for _, chld := range color.Elem.Children {
+		if chld.Name != NsScan+":ColorEntry" {
+			continue
+		}
+
+		var ce ColorEntry
+		ce, err = decodeColorEntry(chld)
+		if err != nil {
+			err = xmldoc.XMLErrWrap(color.Elem, err)
+			return
+		}
+
+		inp.Colors = append(inp.Colors, ce)
+	}
+
+	inp.MinimumSize, err = decodeDimensions(minSize.Elem)
+	if err == nil {
+		inp.MaximumSize, err = decodeDimensions(maxSize.Elem)
+	}
+	if err == nil && optical.Found {
+		inp.OpticalResolution, err = decodeDimensions(optical.Elem)
+	}
+	if err == nil {
+		inp.Widths, inp.Heights, err = decodeResolutions(res.Elem)
+	}
+
+	return
+}
+
+// decodeResolutions decodes lists of supported horizontal and
+// vertical resolutions.
+func decodeResolutions(root xmldoc.Element) (widths, heights []int,
+	err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	decodeList := func(name, item string) ([]int, error) {
+		list, _ := root.ChildByName(name)
+
+		var vals []int
+		for _, chld := range list.Children {
+			if chld.Name != item {
+				continue
+			}
+
+			v, err := decodeNonNegativeInt(chld)
+			if err != nil {
+				return nil, xmldoc.XMLErrWrap(list, err)
+			}
+
+			vals = append(vals, v)
+		}
+
+		return vals, nil
+	}
+
+	widths, err = decodeList(NsScan+":Widths", NsScan+":Width")
+	if err == nil {
+		heights, err = decodeList(NsScan+":Heights", NsScan+":Height")
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [InputConfiguration].
+func (inp InputConfiguration) toXML(name, prefix string) xmldoc.Element {
+	prefix = NsScan + ":" + prefix
+
+	color := xmldoc.Element{Name: prefix + "Color"}
+	for _, ce := range inp.Colors {
+		color.Children = append(color.Children,
+			ce.toXML(NsScan+":ColorEntry"))
+	}
+
+	widths := xmldoc.Element{Name: NsScan + ":Widths"}
+	for _, v := range inp.Widths {
+		widths.Children = append(widths.Children,
+			xmldoc.WithText(NsScan+":Width", strconv.Itoa(v)))
+	}
+
+	heights := xmldoc.Element{Name: NsScan + ":Heights"}
+	for _, v := range inp.Heights {
+		heights.Children = append(heights.Children,
+			xmldoc.WithText(NsScan+":Height", strconv.Itoa(v)))
+	}
+
+	return xmldoc.WithChildren(name,
+		color,
+		inp.MinimumSize.toXML(prefix+"MinimumSize"),
+		inp.MaximumSize.toXML(prefix+"MaximumSize"),
+		inp.OpticalResolution.toXML(prefix+"OpticalResolution"),
+		xmldoc.WithChildren(prefix+"Resolutions", widths, heights),
+	)
+}
+
+// decodeScannerStatus decodes [ScannerStatus] from the XML tree.
+func decodeScannerStatus(root xmldoc.Element) (
+	status ScannerStatus, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	state := xmldoc.Lookup{Name: NsScan + ":ScannerState", Required: true}
+	reasons := xmldoc.Lookup{Name: NsScan + ":ScannerStateReasons"}
+
+	missed := root.Lookup(&state, &reasons)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	status.ScannerState, err = decodeScannerState(state.Elem)
+
+	for _, chld := range reasons.Elem.Children {
+		if chld.Name == NsScan+":ScannerStateReason" {
+			status.ScannerStateReasons = append(
+				status.ScannerStateReasons, chld.Text)
+		}
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [ScannerStatus].
+func (status ScannerStatus) toXML() xmldoc.Element {
+	reasons := xmldoc.Element{Name: NsScan + ":ScannerStateReasons"}
+	for _, reason := range status.ScannerStateReasons {
+		reasons.Children = append(reasons.Children,
+			xmldoc.WithText(NsScan+":ScannerStateReason", reason))
+	}
+
+	return xmldoc.WithChildren(ElementScannerStatus,
+		status.ScannerState.toXML(NsScan+":ScannerState"),
+		reasons,
+	)
+}
@@ -0,0 +1,123 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// ScannerElements test
+
+package wsscan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestScannerElements tests [ScannerElements] encoding and decoding.
+func TestScannerElements(t *testing.T) {
+	platen := &InputConfiguration{
+		Colors:            []ColorEntry{BlackAndWhite1, RGB24},
+		MinimumSize:       Dimensions{1, 1},
+		MaximumSize:       Dimensions{8500, 11690},
+		OpticalResolution: Dimensions{600, 600},
+		Widths:            []int{100, 300, 600},
+		Heights:           []int{100, 300, 600},
+	}
+
+	adf := &InputConfiguration{
+		Colors:            []ColorEntry{Grayscale8},
+		MinimumSize:       Dimensions{1000, 1000},
+		MaximumSize:       Dimensions{8500, 14000},
+		OpticalResolution: Dimensions{300, 300},
+		Widths:            []int{300},
+		Heights:           []int{300},
+	}
+
+	tests := []ScannerElements{
+		{},
+		{
+			Description: &ScannerDescription{
+				ScannerName:     "Test Scanner",
+				ScannerInfo:     "Info",
+				ScannerLocation: "Office",
+			},
+			Configuration: &ScannerConfiguration{
+				DeviceSettings: DeviceSettings{
+					FormatsSupported: []string{
+						FormatJFIF, FormatPDFA,
+					},
+					CompressionQualityFactor: optional.New(
+						ValueRange{Min: 1, Max: 100}),
+					ContentTypesSupported: []ContentType{
+						ContentAuto, ContentPhoto,
+					},
+					DocumentSizeAutoDetect: true,
+					AutoExposure:           true,
+					Brightness:             true,
+					Contrast:               true,
+				},
+				Platen:            platen,
+				ADFFront:          adf,
+				ADFBack:           adf,
+				ADFSupportsDuplex: true,
+			},
+			Status: &ScannerStatus{
+				ScannerState:        ScannerProcessing,
+				ScannerStateReasons: []string{"None"},
+			},
+		},
+		{
+			Configuration: &ScannerConfiguration{
+				DeviceSettings: DeviceSettings{
+					FormatsSupported: []string{FormatPNG},
+				},
+				ADFFront: adf,
+			},
+		},
+	}
+
+	for _, elements := range tests {
+		xml := elements.ToXML()
+		decoded, err := DecodeScannerElements(xml)
+		if err != nil {
+			t.Errorf("DecodeScannerElements: %s\n%s", err,
+				xml.EncodeIndentString(NsMap, "  "))
+			continue
+		}
+
+		if !reflect.DeepEqual(elements, decoded) {
+			t.Errorf("ScannerElements mismatch:\n"+
+				"expected: %#v\n"+
+				"present:  %#v",
+				elements, decoded)
+		}
+	}
+}
+
+// TestScannerElementsInvalid tests that elements, reported
+// as invalid, are skipped.
+func TestScannerElementsInvalid(t *testing.T) {
+	xml := xmldoc.WithChildren(NsScan+":GetScannerElementsResponse",
+		xmldoc.WithChildren(NsScan+":ScannerElements",
+			xmldoc.Element{
+				Name: NsScan + ":ElementData",
+				Attrs: []xmldoc.Attr{
+					{Name: "Name", Value: ElementScannerStatus},
+					{Name: "Valid", Value: "false"},
+				},
+			},
+		),
+	)
+
+	elements, err := DecodeScannerElements(xml)
+	if err != nil {
+		t.Errorf("DecodeScannerElements: %s", err)
+	}
+
+	if elements.Status != nil {
+		t.Errorf("Invalid ScannerStatus not skipped")
+	}
+}
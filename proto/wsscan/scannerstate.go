@@ -0,0 +1,66 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scanner state
+
+package wsscan
+
+import (
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// ScannerState represents the overall scanner state.
+type ScannerState int
+
+// Known scanner states:
+const (
+	UnknownScannerState ScannerState = iota
+	ScannerIdle                      // Ready to accept jobs
+	ScannerProcessing                // Processing the job
+	ScannerStopped                   // Cannot process jobs
+)
+
+// decodeScannerState decodes [ScannerState] from the XML tree.
+func decodeScannerState(root xmldoc.Element) (st ScannerState, err error) {
+	return decodeEnum(root, DecodeScannerState)
+}
+
+// toXML generates XML tree for the [ScannerState].
+func (st ScannerState) toXML(name string) xmldoc.Element {
+	return xmldoc.Element{
+		Name: name,
+		Text: st.String(),
+	}
+}
+
+// String returns a string representation of the [ScannerState]
+func (st ScannerState) String() string {
+	switch st {
+	case ScannerIdle:
+		return "Idle"
+	case ScannerProcessing:
+		return "Processing"
+	case ScannerStopped:
+		return "Stopped"
+	}
+
+	return "Unknown"
+}
+
+// DecodeScannerState decodes [ScannerState] out of its XML string
+// representation.
+func DecodeScannerState(s string) ScannerState {
+	switch s {
+	case "Idle":
+		return ScannerIdle
+	case "Processing":
+		return ScannerProcessing
+	case "Stopped":
+		return ScannerStopped
+	}
+
+	return UnknownScannerState
+}
@@ -0,0 +1,26 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Test for scanner state
+
+package wsscan
+
+import "testing"
+
+var testScannerState = testEnum[ScannerState]{
+	decodeStr: DecodeScannerState,
+	decodeXML: decodeScannerState,
+	dataset: []testEnumData[ScannerState]{
+		{ScannerIdle, "Idle"},
+		{ScannerProcessing, "Processing"},
+		{ScannerStopped, "Stopped"},
+	},
+}
+
+// TestScannerState tests [ScannerState] common methods and functions.
+func TestScannerState(t *testing.T) {
+	testScannerState.run(t)
+}
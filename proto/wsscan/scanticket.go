@@ -0,0 +1,360 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Scan ticket (scan job parameters)
+
+package wsscan
+
+import (
+	"strconv"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// ScanTicket defines the parameters of the scan job, sent with
+// the CreateScanJob request.
+type ScanTicket struct {
+	JobName                string             // Job name
+	JobOriginatingUserName string             // User name
+	DocumentParameters     DocumentParameters // Scan parameters
+}
+
+// DocumentParameters defines the parameters of the scanned document.
+//
+// All sizes and offsets are in the 1/1000 of inch, resolutions
+// are in DPI.
+type DocumentParameters struct {
+	Format                   optional.Val[string]      // Document format
+	ImagesToTransfer         optional.Val[int]         // 0 means all
+	InputSource              optional.Val[InputSource] // Input source
+	ContentType              optional.Val[ContentType] // Content type
+	InputSize                optional.Val[Dimensions]  // Input media size
+	Brightness               optional.Val[int]         // Brightness
+	Contrast                 optional.Val[int]         // Contrast
+	CompressionQualityFactor optional.Val[int]         // Image quality
+	MediaFront               optional.Val[MediaSide]   // Front side params
+	MediaBack                optional.Val[MediaSide]   // Back side params
+}
+
+// MediaSide defines parameters of the single side of the
+// scanned media.
+type MediaSide struct {
+	ScanRegion      optional.Val[ScanRegion] // Scan region
+	ColorProcessing optional.Val[ColorEntry] // Color mode
+	Resolution      optional.Val[Dimensions] // Resolution, DPI
+}
+
+// ScanRegion defines the scan region, in the 1/1000 of inch.
+type ScanRegion struct {
+	XOffset int // Horizontal offset
+	YOffset int // Vertical offset
+	Width   int // Region width
+	Height  int // Region height
+}
+
+// DecodeScanTicket decodes [ScanTicket] from the XML tree.
+func DecodeScanTicket(root xmldoc.Element) (ticket ScanTicket, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	desc := xmldoc.Lookup{Name: NsScan + ":JobDescription"}
+	params := xmldoc.Lookup{Name: NsScan + ":DocumentParameters",
+		Required: true}
+
+	missed := root.Lookup(&desc, &params)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	if desc.Found {
+		name := xmldoc.Lookup{Name: NsScan + ":JobName"}
+		user := xmldoc.Lookup{Name: NsScan + ":JobOriginatingUserName"}
+		desc.Elem.Lookup(&name, &user)
+		ticket.JobName = name.Elem.Text
+		ticket.JobOriginatingUserName = user.Elem.Text
+	}
+
+	ticket.DocumentParameters, err = DecodeDocumentParameters(params.Elem)
+
+	return
+}
+
+// ToXML generates XML tree for the [ScanTicket].
+func (ticket ScanTicket) ToXML() xmldoc.Element {
+	desc := xmldoc.WithChildren(NsScan+":JobDescription",
+		xmldoc.WithText(NsScan+":JobName", ticket.JobName),
+		xmldoc.WithText(NsScan+":JobOriginatingUserName",
+			ticket.JobOriginatingUserName),
+	)
+
+	return xmldoc.WithChildren(NsScan+":ScanTicket",
+		desc,
+		ticket.DocumentParameters.ToXML(NsScan+":DocumentParameters"),
+	)
+}
+
+// DecodeDocumentParameters decodes [DocumentParameters] from
+// the XML tree.
+func DecodeDocumentParameters(root xmldoc.Element) (
+	params DocumentParameters, err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	for _, chld := range root.Children {
+		switch chld.Name {
+		case NsScan + ":Format":
+			params.Format = optional.New(chld.Text)
+		case NsScan + ":ImagesToTransfer":
+			params.ImagesToTransfer, err = decodeOptional(chld,
+				decodeNonNegativeInt)
+		case NsScan + ":InputSource":
+			params.InputSource, err = decodeOptional(chld,
+				decodeInputSource)
+		case NsScan + ":ContentType":
+			params.ContentType, err = decodeOptional(chld,
+				decodeContentType)
+		case NsScan + ":InputSize":
+			size, ok := chld.ChildByName(NsScan + ":InputMediaSize")
+			if ok {
+				params.InputSize, err = decodeOptional(size,
+					decodeDimensions)
+			}
+		case NsScan + ":Exposure":
+			err = params.decodeExposure(chld)
+		case NsScan + ":CompressionQualityFactor":
+			params.CompressionQualityFactor, err = decodeOptional(
+				chld, decodeInt)
+		case NsScan + ":MediaSides":
+			err = params.decodeMediaSides(chld)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// decodeExposure decodes the Exposure element of DocumentParameters.
+func (params *DocumentParameters) decodeExposure(
+	root xmldoc.Element) (err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	settings, ok := root.ChildByName(NsScan + ":ExposureSettings")
+	if !ok {
+		return
+	}
+
+	brightness := xmldoc.Lookup{Name: NsScan + ":Brightness"}
+	contrast := xmldoc.Lookup{Name: NsScan + ":Contrast"}
+	settings.Lookup(&brightness, &contrast)
+
+	if brightness.Found {
+		params.Brightness, err = decodeOptional(brightness.Elem,
+			decodeInt)
+	}
+
+	if err == nil && contrast.Found {
+		params.Contrast, err = decodeOptional(contrast.Elem, decodeInt)
+	}
+
+	return xmldoc.XMLErrWrap(settings, err)
+}
+
+// decodeMediaSides decodes the MediaSides element of
+// DocumentParameters.
+func (params *DocumentParameters) decodeMediaSides(
+	root xmldoc.Element) (err error) {
+
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	front := xmldoc.Lookup{Name: NsScan + ":MediaFront"}
+	back := xmldoc.Lookup{Name: NsScan + ":MediaBack"}
+	root.Lookup(&front, &back)
+
+	if front.Found {
+		params.MediaFront, err = decodeOptional(front.Elem,
+			decodeMediaSide)
+	}
+
+	if err == nil && back.Found {
+		params.MediaBack, err = decodeOptional(back.Elem,
+			decodeMediaSide)
+	}
+
+	return
+}
+
+// ToXML generates XML tree for the [DocumentParameters].
+func (params DocumentParameters) ToXML(name string) xmldoc.Element {
+	elm := xmldoc.Element{Name: name}
+
+	if params.Format != nil {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsScan+":Format", *params.Format))
+	}
+
+	if params.ImagesToTransfer != nil {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsScan+":ImagesToTransfer",
+				strconv.Itoa(*params.ImagesToTransfer)))
+	}
+
+	if params.InputSource != nil {
+		elm.Children = append(elm.Children,
+			(*params.InputSource).toXML(NsScan+":InputSource"))
+	}
+
+	if params.ContentType != nil {
+		elm.Children = append(elm.Children,
+			(*params.ContentType).toXML(NsScan+":ContentType"))
+	}
+
+	if params.InputSize != nil {
+		elm.Children = append(elm.Children,
+			xmldoc.WithChildren(NsScan+":InputSize",
+				(*params.InputSize).toXML(
+					NsScan+":InputMediaSize")))
+	}
+
+	if params.Brightness != nil || params.Contrast != nil {
+		settings := xmldoc.Element{Name: NsScan + ":ExposureSettings"}
+		if params.Brightness != nil {
+			settings.Children = append(settings.Children,
+				xmldoc.WithText(NsScan+":Brightness",
+					strconv.Itoa(*params.Brightness)))
+		}
+		if params.Contrast != nil {
+			settings.Children = append(settings.Children,
+				xmldoc.WithText(NsScan+":Contrast",
+					strconv.Itoa(*params.Contrast)))
+		}
+
+		elm.Children = append(elm.Children,
+			xmldoc.WithChildren(NsScan+":Exposure", settings))
+	}
+
+	if params.CompressionQualityFactor != nil {
+		elm.Children = append(elm.Children,
+			xmldoc.WithText(NsScan+":CompressionQualityFactor",
+				strconv.Itoa(*params.CompressionQualityFactor)))
+	}
+
+	if params.MediaFront != nil || params.MediaBack != nil {
+		sides := xmldoc.Element{Name: NsScan + ":MediaSides"}
+		if params.MediaFront != nil {
+			sides.Children = append(sides.Children,
+				(*params.MediaFront).toXML(NsScan+":MediaFront"))
+		}
+		if params.MediaBack != nil {
+			sides.Children = append(sides.Children,
+				(*params.MediaBack).toXML(NsScan+":MediaBack"))
+		}
+
+		elm.Children = append(elm.Children, sides)
+	}
+
+	return elm
+}
+
+// decodeMediaSide decodes [MediaSide] from the XML tree.
+func decodeMediaSide(root xmldoc.Element) (side MediaSide, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	region := xmldoc.Lookup{Name: NsScan + ":ScanRegion"}
+	color := xmldoc.Lookup{Name: NsScan + ":ColorProcessing"}
+	res := xmldoc.Lookup{Name: NsScan + ":Resolution"}
+	root.Lookup(&region, &color, &res)
+
+	if region.Found {
+		side.ScanRegion, err = decodeOptional(region.Elem,
+			decodeScanRegion)
+	}
+
+	if err == nil && color.Found {
+		side.ColorProcessing, err = decodeOptional(color.Elem,
+			decodeColorEntry)
+	}
+
+	if err == nil && res.Found {
+		side.Resolution, err = decodeOptional(res.Elem,
+			decodeDimensions)
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [MediaSide].
+func (side MediaSide) toXML(name string) xmldoc.Element {
+	elm := xmldoc.Element{Name: name}
+
+	if side.ScanRegion != nil {
+		elm.Children = append(elm.Children,
+			(*side.ScanRegion).toXML(NsScan+":ScanRegion"))
+	}
+
+	if side.ColorProcessing != nil {
+		elm.Children = append(elm.Children,
+			(*side.ColorProcessing).toXML(NsScan+":ColorProcessing"))
+	}
+
+	if side.Resolution != nil {
+		elm.Children = append(elm.Children,
+			(*side.Resolution).toXML(NsScan+":Resolution"))
+	}
+
+	return elm
+}
+
+// decodeScanRegion decodes [ScanRegion] from the XML tree.
+func decodeScanRegion(root xmldoc.Element) (reg ScanRegion, err error) {
+	defer func() { err = xmldoc.XMLErrWrap(root, err) }()
+
+	xoff := xmldoc.Lookup{Name: NsScan + ":ScanRegionXOffset"}
+	yoff := xmldoc.Lookup{Name: NsScan + ":ScanRegionYOffset"}
+	width := xmldoc.Lookup{Name: NsScan + ":ScanRegionWidth",
+		Required: true}
+	height := xmldoc.Lookup{Name: NsScan + ":ScanRegionHeight",
+		Required: true}
+
+	missed := root.Lookup(&xoff, &yoff, &width, &height)
+	if missed != nil {
+		err = xmldoc.XMLErrMissed(missed.Name)
+		return
+	}
+
+	if xoff.Found {
+		reg.XOffset, err = decodeNonNegativeInt(xoff.Elem)
+	}
+	if err == nil && yoff.Found {
+		reg.YOffset, err = decodeNonNegativeInt(yoff.Elem)
+	}
+	if err == nil {
+		reg.Width, err = decodeNonNegativeInt(width.Elem)
+	}
+	if err == nil {
+		reg.Height, err = decodeNonNegativeInt(height.Elem)
+	}
+
+	return
+}
+
+// toXML generates XML tree for the [ScanRegion].
+func (reg ScanRegion) toXML(name string) xmldoc.Element {
+	return xmldoc.WithChildren(name,
+		xmldoc.WithText(NsScan+":ScanRegionXOffset",
+			strconv.Itoa(reg.XOffset)),
+		xmldoc.WithText(NsScan+":ScanRegionYOffset",
+			strconv.Itoa(reg.YOffset)),
+		xmldoc.WithText(NsScan+":ScanRegionWidth",
+			strconv.Itoa(reg.Width)),
+		xmldoc.WithText(NsScan+":ScanRegionHeight",
+			strconv.Itoa(reg.Height)),
+	)
+}
@@ -0,0 +1,116 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// ScanTicket test
+
+package wsscan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenPrinting/go-mfp/util/optional"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// TestScanTicket tests [ScanTicket] encoding and decoding.
+func TestScanTicket(t *testing.T) {
+	side := MediaSide{
+		ScanRegion: optional.New(ScanRegion{
+			XOffset: 100,
+			YOffset: 200,
+			Width:   8500,
+			Height:  11000,
+		}),
+		ColorProcessing: optional.New(Grayscale8),
+		Resolution:      optional.New(Dimensions{300, 300}),
+	}
+
+	tests := []ScanTicket{
+		{
+			DocumentParameters: DocumentParameters{},
+		},
+		{
+			JobName:                "Scan",
+			JobOriginatingUserName: "user",
+			DocumentParameters: DocumentParameters{
+				Format:                   optional.New(FormatJFIF),
+				ImagesToTransfer:         optional.New(1),
+				InputSource:              optional.New(InputPlaten),
+				ContentType:              optional.New(ContentText),
+				InputSize:                optional.New(Dimensions{8500, 11000}),
+				Brightness:               optional.New(-100),
+				Contrast:                 optional.New(100),
+				CompressionQualityFactor: optional.New(85),
+				MediaFront:               optional.New(side),
+			},
+		},
+		{
+			JobName:                "Duplex",
+			JobOriginatingUserName: "user",
+			DocumentParameters: DocumentParameters{
+				ImagesToTransfer: optional.New(0),
+				InputSource:      optional.New(InputADFDuplex),
+				MediaFront:       optional.New(side),
+				MediaBack:        optional.New(side),
+			},
+		},
+	}
+
+	for _, ticket := range tests {
+		xml := ticket.ToXML()
+		decoded, err := DecodeScanTicket(xml)
+		if err != nil {
+			t.Errorf("DecodeScanTicket: %s\n%s", err,
+				xml.EncodeIndentString(NsMap, "  "))
+			continue
+		}
+
+		if !reflect.DeepEqual(ticket, decoded) {
+			t.Errorf("ScanTicket mismatch:\n"+
+				"expected: %#v\n"+
+				"present:  %#v",
+				ticket, decoded)
+		}
+	}
+}
+
+// TestScanTicketErrors tests [ScanTicket] decoding errors.
+func TestScanTicketErrors(t *testing.T) {
+	type testData struct {
+		xml xmldoc.Element
+		err string
+	}
+
+	tests := []testData{
+		{
+			xml: xmldoc.WithChildren(NsScan + ":ScanTicket"),
+			err: `/scan:ScanTicket/scan:DocumentParameters: missed`,
+		},
+		{
+			xml: xmldoc.WithChildren(NsScan+":ScanTicket",
+				xmldoc.WithChildren(NsScan+":DocumentParameters",
+					xmldoc.WithText(NsScan+":InputSource",
+						"Unknown"))),
+			err: `/scan:ScanTicket/scan:DocumentParameters/scan:InputSource: invalid InputSource: "Unknown"`,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeScanTicket(test.xml)
+		errstr := ""
+		if err != nil {
+			errstr = err.Error()
+		}
+
+		if errstr != test.err {
+			t.Errorf("DecodeScanTicket:\n"+
+				"expected: %s\n"+
+				"present:  %s",
+				test.err, errstr)
+		}
+	}
+}
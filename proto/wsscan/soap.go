@@ -0,0 +1,53 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// WS-Scan core protocol
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// SOAP envelope and faults
+
+package wsscan
+
+import (
+	"github.com/OpenPrinting/go-mfp/proto/wsd"
+	"github.com/OpenPrinting/go-mfp/util/xmldoc"
+)
+
+// WS-Scan actions, used by the [Client]:
+const (
+	ActGetScannerElements         = NsScanURL + "/GetScannerElements"
+	ActGetScannerElementsResponse = NsScanURL + "/GetScannerElementsResponse"
+	ActCreateScanJob              = NsScanURL + "/CreateScanJob"
+	ActCreateScanJobResponse      = NsScanURL + "/CreateScanJobResponse"
+	ActRetrieveImage              = NsScanURL + "/RetrieveImage"
+	ActRetrieveImageResponse      = NsScanURL + "/RetrieveImageResponse"
+	ActCancelJob                  = NsScanURL + "/CancelJob"
+	ActCancelJobResponse          = NsScanURL + "/CancelJobResponse"
+)
+
+// WS-Scan fault subcodes, reported as [wsd.Fault] Subcode
+// by the [Client] and [AbstractServer]:
+const (
+	FaultClientErrorNoImagesAvailable  = "ClientErrorNoImagesAvailable"
+	FaultClientErrorJobIDNotFound      = "ClientErrorJobIdNotFound"
+	FaultClientErrorInvalidJobToken    = "ClientErrorInvalidJobToken"
+	FaultClientErrorFormatNotSupported = "ClientErrorFormatNotSupported"
+	FaultServerErrorNotAcceptingJobs   = "ServerErrorNotAcceptingJobs"
+	FaultServerErrorTemporaryError     = "ServerErrorTemporaryError"
+)
+
+// soapResponseNs is the namespace for encoding responses.
+//
+// Fault subcodes use the scan: prefix in the element text,
+// so the namespace must be declared explicitly.
+var soapResponseNs = func() xmldoc.Namespace {
+	ns := NsMap.Clone()
+	ns.MarkUsedPrefix(NsScan)
+	return ns
+}()
+
+// soapFault creates the [wsd.Fault] with the WS-Scan subcode
+// (may be "") and reason.
+func soapFault(code, subcode, reason string) wsd.Fault {
+	return wsd.SOAPFault(code, NsScan, subcode, reason)
+}
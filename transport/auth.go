@@ -0,0 +1,288 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Server-side HTTP authentication
+
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPAuthNonceLifetime is the lifetime of the nonce, used by
+// the HTTP Digest authentication. After it is expired, clients
+// are asked to repeat authentication with the fresh nonce.
+const HTTPAuthNonceLifetime = 5 * time.Minute
+
+// HTTPAuthScheme defines the HTTP authentication scheme.
+type HTTPAuthScheme int
+
+// HTTP authentication schemes:
+const (
+	HTTPAuthBasic  HTTPAuthScheme = iota // Basic (RFC 7617)
+	HTTPAuthDigest                       // Digest (RFC 7616), MD5
+)
+
+// String returns the HTTPAuthScheme name, as used in the
+// WWW-Authenticate header.
+func (scheme HTTPAuthScheme) String() string {
+	switch scheme {
+	case HTTPAuthBasic:
+		return "Basic"
+	case HTTPAuthDigest:
+		return "Digest"
+	}
+
+	return fmt.Sprintf("HTTPAuthScheme(%d)", int(scheme))
+}
+
+// HTTPAuth implements the server side of the HTTP authentication.
+//
+// Digest nonces are stateless: each nonce contains its creation
+// time, signed with the per-HTTPAuth random key, so HTTPAuth
+// doesn't need to remember issued nonces.
+type HTTPAuth struct {
+	scheme HTTPAuthScheme    // Authentication scheme
+	realm  string            // Authentication realm
+	users  map[string]string // Passwords, by user name
+	key    [32]byte          // Nonce signing key
+}
+
+// NewHTTPAuth creates a new [HTTPAuth].
+//
+// The users parameter maps user names into their passwords.
+func NewHTTPAuth(scheme HTTPAuthScheme, realm string,
+	users map[string]string) *HTTPAuth {
+
+	auth := &HTTPAuth{
+		scheme: scheme,
+		realm:  realm,
+		users:  make(map[string]string, len(users)),
+	}
+
+	for user, password := range users {
+		auth.users[user] = password
+	}
+
+	rand.Read(auth.key[:])
+
+	return auth
+}
+
+// Authenticate checks credentials, supplied with the request.
+//
+// On success, it returns the authenticated user name and true.
+//
+// Otherwise, it sets the WWW-Authenticate header of the response,
+// so the caller only needs to complete the request with the
+// 401 Unauthorized status.
+func (auth *HTTPAuth) Authenticate(w http.ResponseWriter,
+	rq *http.Request) (string, bool) {
+
+	var user string
+	var ok, stale bool
+
+	switch auth.scheme {
+	case HTTPAuthBasic:
+		user, ok = auth.checkBasic(rq)
+	case HTTPAuthDigest:
+		user, ok, stale = auth.checkDigest(rq)
+	}
+
+	if !ok {
+		w.Header().Set("WWW-Authenticate", auth.challenge(stale))
+	}
+
+	return user, ok
+}
+
+// challenge returns the WWW-Authenticate header value.
+func (auth *HTTPAuth) challenge(stale bool) string {
+	if auth.scheme == HTTPAuthBasic {
+		return fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"",
+			auth.realm)
+	}
+
+	s := fmt.Sprintf("Digest realm=%q, qop=\"auth\", "+
+		"algorithm=MD5, nonce=%q",
+		auth.realm, auth.nonce(time.Now()))
+
+	if stale {
+		s += ", stale=true"
+	}
+
+	return s
+}
+
+// checkBasic checks Basic credentials.
+func (auth *HTTPAuth) checkBasic(rq *http.Request) (string, bool) {
+	user, password, ok := rq.BasicAuth()
+	if !ok {
+		return "", false
+	}
+
+	expected, found := auth.users[user]
+	if !found || subtle.ConstantTimeCompare([]byte(password),
+		[]byte(expected)) != 1 {
+		return "", false
+	}
+
+	return user, true
+}
+
+// checkDigest checks Digest credentials.
+//
+// If credentials are valid, but nonce is expired, it returns
+// ok == false and stale == true.
+func (auth *HTTPAuth) checkDigest(rq *http.Request) (
+	user string, ok, stale bool) {
+
+	scheme, params, _ := strings.Cut(rq.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return "", false, false
+	}
+
+	p := httpAuthParams(params)
+	user = p["username"]
+	password, found := auth.users[user]
+
+	switch {
+	case !found:
+		return "", false, false
+	case p["realm"] != auth.realm:
+		return "", false, false
+	case p["uri"] != rq.URL.RequestURI():
+		return "", false, false
+	}
+
+	algorithm := p["algorithm"]
+	if algorithm != "" && !strings.EqualFold(algorithm, "MD5") {
+		return "", false, false
+	}
+
+	// Compute the expected response
+	ha1 := httpAuthMD5(user + ":" + auth.realm + ":" + password)
+	ha2 := httpAuthMD5(rq.Method + ":" + p["uri"])
+
+	var expected string
+	switch p["qop"] {
+	case "":
+		expected = httpAuthMD5(ha1 + ":" + p["nonce"] + ":" + ha2)
+	case "auth":
+		expected = httpAuthMD5(ha1 + ":" + p["nonce"] + ":" +
+			p["nc"] + ":" + p["cnonce"] + ":auth:" + ha2)
+	default:
+		return "", false, false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(p["response"]),
+		[]byte(expected)) != 1 {
+		return "", false, false
+	}
+
+	// Check the nonce
+	valid, expired := auth.checkNonce(p["nonce"], time.Now())
+	switch {
+	case !valid:
+		return "", false, false
+	case expired:
+		return "", false, true
+	}
+
+	return user, true, false
+}
+
+// nonce generates a new nonce for the Digest authentication.
+func (auth *HTTPAuth) nonce(now time.Time) string {
+	var stamp [8]byte
+	binary.BigEndian.PutUint64(stamp[:], uint64(now.Unix()))
+	return hex.EncodeToString(stamp[:]) + auth.nonceSign(stamp[:])
+}
+
+// checkNonce checks the nonce, previously returned by the
+// HTTPAuth.nonce.
+func (auth *HTTPAuth) checkNonce(nonce string, now time.Time) (
+	valid, expired bool) {
+
+	stamp, err := hex.DecodeString(nonce[:min(len(nonce), 16)])
+	if err != nil || len(stamp) != 8 {
+		return false, false
+	}
+
+	if !hmac.Equal([]byte(nonce[16:]), []byte(auth.nonceSign(stamp))) {
+		return false, false
+	}
+
+	issued := time.Unix(int64(binary.BigEndian.Uint64(stamp)), 0)
+	return true, now.Sub(issued) > HTTPAuthNonceLifetime
+}
+
+// nonceSign returns the nonce signature for the time stamp.
+func (auth *HTTPAuth) nonceSign(stamp []byte) string {
+	mac := hmac.New(sha256.New, auth.key[:])
+	mac.Write(stamp)
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// httpAuthMD5 returns hex-encoded MD5 hash of the string.
+func httpAuthMD5(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// httpAuthParams parses the comma-separated name=value parameters
+// of the Authorization header. Values may be quoted.
+func httpAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params
+		}
+
+		name, rest, found := strings.Cut(s, "=")
+		if !found {
+			return params
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		rest = strings.TrimLeft(rest, " \t")
+
+		var val strings.Builder
+		if strings.HasPrefix(rest, `"`) {
+			rest = rest[1:]
+			for rest != "" && rest[0] != '"' {
+				if rest[0] == '\\' && len(rest) > 1 {
+					rest = rest[1:]
+				}
+				val.WriteByte(rest[0])
+				rest = rest[1:]
+			}
+			rest = strings.TrimPrefix(rest, `"`)
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			val.WriteString(strings.TrimSpace(rest[:end]))
+			rest = rest[end:]
+		}
+
+		params[name] = val.String()
+		s = rest
+	}
+}
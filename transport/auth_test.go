@@ -0,0 +1,145 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Server-side HTTP authentication test
+
+package transport
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHTTPAuthBasic tests HTTPAuth with the Basic scheme
+func TestHTTPAuthBasic(t *testing.T) {
+	auth := NewHTTPAuth(HTTPAuthBasic, "scanner",
+		map[string]string{"user": "secret"})
+
+	tests := []struct {
+		user, password string
+		ok             bool
+	}{
+		{"user", "secret", true},
+		{"user", "wrong", false},
+		{"other", "secret", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		rq := httptest.NewRequest("GET", "/eSCL/ScannerStatus", nil)
+		if test.user != "" {
+			rq.SetBasicAuth(test.user, test.password)
+		}
+
+		w := httptest.NewRecorder()
+		user, ok := auth.Authenticate(w, rq)
+
+		if ok != test.ok || (ok && user != test.user) {
+			t.Errorf("%s:%s: expected %v, present %v (%q)",
+				test.user, test.password, test.ok, ok, user)
+		}
+
+		challenge := w.Header().Get("WWW-Authenticate")
+		switch {
+		case ok && challenge != "":
+			t.Errorf("%s:%s: unexpected challenge",
+				test.user, test.password)
+		case !ok && !strings.HasPrefix(challenge, `Basic realm="scanner"`):
+			t.Errorf("%s:%s: invalid challenge: %q",
+				test.user, test.password, challenge)
+		}
+	}
+}
+
+// TestHTTPAuthDigest tests HTTPAuth with the Digest scheme
+func TestHTTPAuthDigest(t *testing.T) {
+	auth := NewHTTPAuth(HTTPAuthDigest, "scanner",
+		map[string]string{"user": "secret"})
+
+	// digest returns the Authorization header value
+	digest := func(user, password, nonce, uri string) string {
+		ha1 := httpAuthMD5(user + ":scanner:" + password)
+		ha2 := httpAuthMD5("GET:" + uri)
+		rsp := httpAuthMD5(ha1 + ":" + nonce + ":00000001:abcd:auth:" + ha2)
+		return fmt.Sprintf(`Digest username="%s", realm="scanner", `+
+			`nonce="%s", uri="%s", qop=auth, nc=00000001, `+
+			`cnonce="abcd", response="%s", algorithm=MD5`,
+			user, nonce, uri, rsp)
+	}
+
+	// Request without credentials must be challenged
+	rq := httptest.NewRequest("GET", "/eSCL/ScannerStatus", nil)
+	w := httptest.NewRecorder()
+	_, ok := auth.Authenticate(w, rq)
+	if ok {
+		t.Fatalf("request without credentials accepted")
+	}
+
+	challenge := w.Header().Get("WWW-Authenticate")
+	scheme, params, _ := strings.Cut(challenge, " ")
+	nonce := httpAuthParams(params)["nonce"]
+	if scheme != "Digest" || nonce == "" {
+		t.Fatalf("invalid challenge: %q", challenge)
+	}
+
+	stale := auth.nonce(time.Now().Add(-2 * HTTPAuthNonceLifetime))
+
+	tests := []struct {
+		name          string
+		authorization string
+		ok, stale     bool
+	}{
+		{
+			name: "valid",
+			authorization: digest("user", "secret", nonce,
+				"/eSCL/ScannerStatus"),
+			ok: true,
+		},
+		{
+			name: "wrong password",
+			authorization: digest("user", "wrong", nonce,
+				"/eSCL/ScannerStatus"),
+		},
+		{
+			name: "wrong uri",
+			authorization: digest("user", "secret", nonce,
+				"/eSCL/ScannerCapabilities"),
+		},
+		{
+			name: "forged nonce",
+			authorization: digest("user", "secret",
+				"0000000000000000"+nonce[16:],
+				"/eSCL/ScannerStatus"),
+		},
+		{
+			name: "stale nonce",
+			authorization: digest("user", "secret", stale,
+				"/eSCL/ScannerStatus"),
+			stale: true,
+		},
+	}
+
+	for _, test := range tests {
+		rq := httptest.NewRequest("GET", "/eSCL/ScannerStatus", nil)
+		rq.Header.Set("Authorization", test.authorization)
+
+		w := httptest.NewRecorder()
+		user, ok := auth.Authenticate(w, rq)
+
+		if ok != test.ok || (ok && user != "user") {
+			t.Errorf("%s: expected %v, present %v (%q)",
+				test.name, test.ok, ok, user)
+		}
+
+		challenge := w.Header().Get("WWW-Authenticate")
+		if test.stale != strings.HasSuffix(challenge, "stale=true") {
+			t.Errorf("%s: stale mismatch: %q", test.name, challenge)
+		}
+	}
+}
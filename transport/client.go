@@ -40,9 +40,19 @@ func NewClient(tr *Transport) *Client {
 }
 
 // Do sends an HTTP request and returns an HTTP response.
+//
+// If request's context carries the [Recorder] (see
+// [NewRecorderContext]), the exchange is recorded.
 func (c *Client) Do(rq *http.Request) (*http.Response, error) {
 	// Execute the request
-	rsp, err := c.Client.Do(rq)
+	var rsp *http.Response
+	var err error
+
+	if rec := CtxRecorder(rq.Context()); rec != nil {
+		rsp, err = c.doRecord(rq, rec)
+	} else {
+		rsp, err = c.Client.Do(rq)
+	}
 
 	// Write log message
 	var status string
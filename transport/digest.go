@@ -0,0 +1,133 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Content-Digest (RFC 9530) support
+
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ContentDigestHeader is the name of the HTTP header (or trailer),
+// that carries the message content digest, as defined by RFC 9530.
+//
+// Only the "sha-256" algorithm is supported.
+const ContentDigestHeader = "Content-Digest"
+
+// ErrContentDigest is returned, when content digest of the
+// received message body doesn't match the Content-Digest header
+// or trailer.
+var ErrContentDigest = errors.New("Content-Digest mismatch")
+
+// NewContentDigest returns the new hash.Hash, suitable for
+// computing the Content-Digest.
+func NewContentDigest() hash.Hash {
+	return sha256.New()
+}
+
+// FormatContentDigest formats the Content-Digest header value
+// for the sum, computed by the hash, returned by the
+// [NewContentDigest].
+func FormatContentDigest(sum []byte) string {
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum) + ":"
+}
+
+// ParseContentDigest parses the Content-Digest header value and
+// returns the sha-256 digest.
+//
+// If value is malformed or doesn't contain the sha-256 digest,
+// it returns nil.
+func ParseContentDigest(s string) []byte {
+	for _, item := range strings.Split(s, ",") {
+		alg, val, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found || strings.ToLower(alg) != "sha-256" {
+			continue
+		}
+
+		if len(val) < 2 || val[0] != ':' || val[len(val)-1] != ':' {
+			return nil
+		}
+
+		sum, err := base64.StdEncoding.DecodeString(val[1 : len(val)-1])
+		if err != nil || len(sum) != sha256.Size {
+			return nil
+		}
+
+		return sum
+	}
+
+	return nil
+}
+
+// VerifyContentDigest wraps the [http.Response.Body] to verify
+// its Content-Digest.
+//
+// The digest is looked up at the response header and, if missed,
+// at the response trailer. When body is read till the end, the
+// computed digest is compared with the expected one, and on
+// mismatch, [ErrContentDigest] is returned instead of [io.EOF].
+// The same happens if Content-Digest trailer was announced but
+// not received.
+//
+// If response contains no Content-Digest, body is not verified.
+func VerifyContentDigest(rsp *http.Response) io.ReadCloser {
+	return &contentDigestVerifier{
+		ReadCloser: rsp.Body,
+		rsp:        rsp,
+		hash:       NewContentDigest(),
+	}
+}
+
+// contentDigestVerifier is the io.ReadCloser, returned by
+// the VerifyContentDigest.
+type contentDigestVerifier struct {
+	io.ReadCloser                // Underlying body
+	rsp           *http.Response // HTTP response
+	hash          hash.Hash      // Digest being computed
+}
+
+// Read reads the body. It implements the [io.Reader] interface.
+func (v *contentDigestVerifier) Read(buf []byte) (int, error) {
+	n, err := v.ReadCloser.Read(buf)
+	v.hash.Write(buf[:n])
+
+	if err == io.EOF {
+		err = v.verify()
+	}
+
+	return n, err
+}
+
+// verify verifies the digest at the end of body.
+// It returns io.EOF on success or if digest is not available.
+func (v *contentDigestVerifier) verify() error {
+	s := v.rsp.Header.Get(ContentDigestHeader)
+	if s == "" {
+		// Note, trailer is available only after EOF. If sender
+		// has announced the trailer but not sent it, transfer
+		// was interrupted.
+		_, announced := v.rsp.Trailer[ContentDigestHeader]
+		s = v.rsp.Trailer.Get(ContentDigestHeader)
+		if announced && s == "" {
+			return ErrContentDigest
+		}
+	}
+
+	expected := ParseContentDigest(s)
+	if expected != nil && !bytes.Equal(expected, v.hash.Sum(nil)) {
+		return ErrContentDigest
+	}
+
+	return io.EOF
+}
@@ -0,0 +1,132 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Content-Digest test
+
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestContentDigest tests Content-Digest formatting and parsing
+func TestContentDigest(t *testing.T) {
+	hash := NewContentDigest()
+	hash.Write([]byte("hello"))
+	sum := hash.Sum(nil)
+
+	s := FormatContentDigest(sum)
+	expected := "sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:"
+	if s != expected {
+		t.Errorf("FormatContentDigest:\n"+
+			"expected: %s\n"+
+			"present:  %s", expected, s)
+	}
+
+	tests := []struct {
+		s  string
+		ok bool
+	}{
+		{s, true},
+		{"md5=:AAAA:, " + s, true},
+		{"SHA-256" + s[7:], true},
+		{"md5=:AAAA:", false},
+		{"sha-256=LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=", false},
+		{"sha-256=:AAAA:", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		parsed := ParseContentDigest(test.s)
+		switch {
+		case test.ok && !bytes.Equal(parsed, sum):
+			t.Errorf("ParseContentDigest(%q): digest mismatch", test.s)
+		case !test.ok && parsed != nil:
+			t.Errorf("ParseContentDigest(%q): must fail", test.s)
+		}
+	}
+}
+
+// TestVerifyContentDigest tests VerifyContentDigest
+func TestVerifyContentDigest(t *testing.T) {
+	data := []byte("hello, world")
+	hash := NewContentDigest()
+	hash.Write(data)
+	digest := FormatContentDigest(hash.Sum(nil))
+
+	type testData struct {
+		name    string                      // Test name
+		handler func(w http.ResponseWriter) // Response generator
+		err     error                       // Expected error
+	}
+
+	tests := []testData{
+		{
+			name: "header",
+			handler: func(w http.ResponseWriter) {
+				w.Header().Set(ContentDigestHeader, digest)
+				w.Write(data)
+			},
+		},
+		{
+			name: "trailer",
+			handler: func(w http.ResponseWriter) {
+				w.Header().Set("Trailer", ContentDigestHeader)
+				w.Write(data)
+				w.Header().Set(ContentDigestHeader, digest)
+			},
+		},
+		{
+			name: "no digest",
+			handler: func(w http.ResponseWriter) {
+				w.Write(data)
+			},
+		},
+		{
+			name: "corrupted",
+			handler: func(w http.ResponseWriter) {
+				w.Header().Set(ContentDigestHeader, digest)
+				w.Write(data[1:])
+			},
+			err: ErrContentDigest,
+		},
+		{
+			name: "missed trailer",
+			handler: func(w http.ResponseWriter) {
+				w.Header().Set("Trailer", ContentDigestHeader)
+				w.Write(data)
+			},
+			err: ErrContentDigest,
+		},
+	}
+
+	for _, test := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, rq *http.Request) {
+				test.handler(w)
+			}))
+
+		rsp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		body := VerifyContentDigest(rsp)
+		_, err = io.ReadAll(body)
+		body.Close()
+		srv.Close()
+
+		if err != test.err {
+			t.Errorf("%s: error mismatch:\n"+
+				"expected: %v\n"+
+				"present:  %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP hop-by-hop headers
+
+package transport
+
+import (
+	"net/http"
+	"strings"
+)
+
+// hopByHopHeaders contains headers that are always considered
+// hop-by-hop, RFC 7230, section 6.1
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Connection",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+}
+
+// RemoveHopByHopHeaders removes HTTP hop-by-hop headers,
+// RFC 7230, section 6.1. It is useful for proxies, that need
+// to forward the HTTP header to the next hop.
+func RemoveHopByHopHeaders(hdr http.Header) {
+	// Per RFC 7230, section 6.1:
+	//
+	// Hence, the Connection header field provides a declarative way of
+	// distinguishing header fields that are only intended for the immediate
+	// recipient ("hop-by-hop") from those fields that are intended for all
+	// recipients on the chain ("end-to-end"), enabling the message to be
+	// self-descriptive and allowing future connection-specific extensions
+	// to be deployed without fear that they will be blindly forwarded by
+	// older intermediaries.
+	for _, c := range hdr.Values("Connection") {
+		for _, f := range strings.Split(c, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				hdr.Del(f)
+			}
+		}
+	}
+
+	// These headers are always considered hop-by-hop.
+	for _, c := range hopByHopHeaders {
+		hdr.Del(c)
+	}
+}
@@ -0,0 +1,40 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP hop-by-hop headers test
+
+package transport
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// TestRemoveHopByHopHeaders tests RemoveHopByHopHeaders
+func TestRemoveHopByHopHeaders(t *testing.T) {
+	hdr := http.Header{}
+	hdr.Add("Connection", "keep-alive, X-Private")
+	hdr.Add("Connection", "X-Other")
+	hdr.Set("Keep-Alive", "timeout=5")
+	hdr.Set("Transfer-Encoding", "chunked")
+	hdr.Set("Proxy-Authorization", "Basic dXNlcjpwYXNz")
+	hdr.Set("X-Private", "1")
+	hdr.Set("X-Other", "2")
+	hdr.Set("Content-Type", "application/ipp")
+	hdr.Set("Content-Length", "123")
+
+	RemoveHopByHopHeaders(hdr)
+
+	expected := http.Header{
+		"Content-Type":   {"application/ipp"},
+		"Content-Length": {"123"},
+	}
+
+	if !reflect.DeepEqual(hdr, expected) {
+		t.Errorf("expected: %v\npresent:  %v", expected, hdr)
+	}
+}
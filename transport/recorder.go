@@ -0,0 +1,144 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP exchanges recording
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Recorder receives HTTP exchanges, performed by the [Client].
+//
+// Recorder is attached to the [context.Context] with the
+// [NewRecorderContext] function, and all requests, performed
+// by the [Client] within this context, are reported to the
+// Recorder.
+//
+// Record may be called simultaneously from multiple goroutines.
+type Recorder interface {
+	Record(*Exchange)
+}
+
+// Exchange represents a recorded HTTP request/response exchange.
+type Exchange struct {
+	Start          time.Time     // Request start time
+	Elapsed        time.Duration // Time till the end of response body
+	Method         string        // Request method
+	URL            *url.URL      // Request URL
+	RequestHeader  http.Header   // Request header
+	RequestBody    []byte        // Request body
+	Status         string        // Response status (i.e., "200 OK")
+	StatusCode     int           // Response status code
+	ResponseHeader http.Header   // Response header
+	ResponseBody   []byte        // Response body
+	Err            error         // Error, if request failed
+}
+
+// recorderContextKey is the context.Context key for Recorder
+type recorderContextKey struct{}
+
+// NewRecorderContext returns a new [context.Context] that carries
+// the [Recorder].
+func NewRecorderContext(parent context.Context,
+	rec Recorder) context.Context {
+	return context.WithValue(parent, recorderContextKey{}, rec)
+}
+
+// CtxRecorder returns the [Recorder], associated with the
+// [context.Context], or nil, if there is no Recorder.
+func CtxRecorder(ctx context.Context) Recorder {
+	rec, _ := ctx.Value(recorderContextKey{}).(Recorder)
+	return rec
+}
+
+// recordedBody wraps the http.Response.Body and records the
+// exchange, when body is closed.
+type recordedBody struct {
+	io.ReadCloser              // Underlying body
+	rec           Recorder     // Destination Recorder
+	xchg          *Exchange    // The exchange being recorded
+	buf           bytes.Buffer // Response body data
+	once          sync.Once    // Records exactly once
+}
+
+// Read reads the response body.
+func (body *recordedBody) Read(buf []byte) (int, error) {
+	n, err := body.ReadCloser.Read(buf)
+	body.buf.Write(buf[:n])
+	return n, err
+}
+
+// Close closes the response body and records the exchange.
+func (body *recordedBody) Close() error {
+	err := body.ReadCloser.Close()
+
+	body.once.Do(func() {
+		body.xchg.Elapsed = time.Since(body.xchg.Start)
+		body.xchg.ResponseBody = body.buf.Bytes()
+		body.rec.Record(body.xchg)
+	})
+
+	return err
+}
+
+// doRecord performs the HTTP request and records the exchange.
+//
+// The successful exchange is recorded when the response body
+// is closed, so the entire body and timing is captured.
+func (c *Client) doRecord(rq *http.Request,
+	rec Recorder) (*http.Response, error) {
+
+	xchg := &Exchange{
+		Start:         time.Now(),
+		Method:        rq.Method,
+		URL:           URLClone(rq.URL),
+		RequestHeader: rq.Header.Clone(),
+	}
+
+	// Capture the request body
+	if rq.Body != nil && rq.Body != http.NoBody {
+		data, err := io.ReadAll(rq.Body)
+		rq.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		xchg.RequestBody = data
+		rq.Body = io.NopCloser(bytes.NewReader(data))
+		rq.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+
+	// Execute the request
+	rsp, err := c.Client.Do(rq)
+	if err != nil {
+		xchg.Elapsed = time.Since(xchg.Start)
+		xchg.Err = err
+		rec.Record(xchg)
+		return rsp, err
+	}
+
+	xchg.Status = rsp.Status
+	xchg.StatusCode = rsp.StatusCode
+	xchg.ResponseHeader = rsp.Header.Clone()
+
+	rsp.Body = &recordedBody{
+		ReadCloser: rsp.Body,
+		rec:        rec,
+		xchg:       xchg,
+	}
+
+	return rsp, nil
+}
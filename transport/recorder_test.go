@@ -0,0 +1,116 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP exchanges recording test
+
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testRecorder implements Recorder for testing
+type testRecorder struct {
+	lock  sync.Mutex
+	xchgs []*Exchange
+}
+
+// Record records the Exchange
+func (rec *testRecorder) Record(xchg *Exchange) {
+	rec.lock.Lock()
+	rec.xchgs = append(rec.xchgs, xchg)
+	rec.lock.Unlock()
+}
+
+// TestRecorder tests Client with Recorder
+func TestRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, rq *http.Request) {
+			data, _ := io.ReadAll(rq.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("echo: "))
+			w.Write(data)
+		}))
+	defer srv.Close()
+
+	rec := &testRecorder{}
+	ctx := NewRecorderContext(context.Background(), rec)
+	clnt := NewClient(nil)
+
+	// Request without Recorder must not be recorded
+	rq, _ := http.NewRequestWithContext(context.Background(),
+		"GET", srv.URL+"/plain", nil)
+	rsp, err := clnt.Do(rq)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	io.Copy(io.Discard, rsp.Body)
+	rsp.Body.Close()
+
+	// Request with Recorder
+	rq, _ = http.NewRequestWithContext(ctx,
+		"POST", srv.URL+"/recorded", strings.NewReader("hello"))
+	rq.Header.Set("X-Test", "test")
+
+	rsp, err = clnt.Do(rq)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	body, _ := io.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	rsp.Body.Close()
+
+	if string(body) != "echo: hello" {
+		t.Errorf("response body: expected %q, present %q",
+			"echo: hello", body)
+	}
+
+	// Request to unreachable server
+	rq, _ = http.NewRequestWithContext(ctx,
+		"GET", "http://127.0.0.1:1/", nil)
+	_, err = clnt.Do(rq)
+	if err == nil {
+		t.Errorf("error not detected")
+	}
+
+	// Check results
+	if len(rec.xchgs) != 2 {
+		t.Fatalf("%d exchanges recorded, expected 2", len(rec.xchgs))
+	}
+
+	xchg := rec.xchgs[0]
+	switch {
+	case xchg.Method != "POST":
+		t.Errorf("Method: %q", xchg.Method)
+	case xchg.URL.Path != "/recorded":
+		t.Errorf("URL: %q", xchg.URL)
+	case xchg.RequestHeader.Get("X-Test") != "test":
+		t.Errorf("RequestHeader: %v", xchg.RequestHeader)
+	case string(xchg.RequestBody) != "hello":
+		t.Errorf("RequestBody: %q", xchg.RequestBody)
+	case xchg.StatusCode != http.StatusCreated:
+		t.Errorf("StatusCode: %d", xchg.StatusCode)
+	case xchg.ResponseHeader.Get("Content-Type") != "text/plain":
+		t.Errorf("ResponseHeader: %v", xchg.ResponseHeader)
+	case string(xchg.ResponseBody) != "echo: hello":
+		t.Errorf("ResponseBody: %q", xchg.ResponseBody)
+	case xchg.Err != nil:
+		t.Errorf("Err: %s", xchg.Err)
+	}
+
+	xchg = rec.xchgs[1]
+	if xchg.Err == nil {
+		t.Errorf("Err not recorded")
+	}
+}
@@ -0,0 +1,226 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP router
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/OpenPrinting/go-mfp/log"
+	"github.com/OpenPrinting/go-mfp/util/missed"
+)
+
+// Router dispatches incoming HTTP requests between handlers,
+// mounted under the URL path prefixes. It allows to co-host
+// multiple services (i.e., eSCL and IPP servers and admin pages)
+// under the single [Server], sharing the same listener, logging
+// and TLS configuration.
+//
+// Prefixes are matched by the whole path segments, so "/eSCL"
+// matches "/eSCL" and "/eSCL/ScannerStatus", but not "/eSCLx".
+// If multiple prefixes match, the longest wins. The "/" prefix
+// matches everything.
+//
+// The request path is passed to the handler unmodified.
+//
+// Router implements the [http.Handler] interface.
+type Router struct {
+	ctx    context.Context // Logging context
+	mounts []*routerMount  // Mounted handlers, longest prefix first
+	lock   sync.RWMutex    // Access lock
+}
+
+// routerMount represents a handler, mounted under the path prefix.
+type routerMount struct {
+	prefix   string       // Path prefix, without trailing '/'
+	handler  http.Handler // The handler
+	requests atomic.Int64 // Count of requests
+	errors   atomic.Int64 // Count of failed (4xx and 5xx) requests
+}
+
+// routerResponseWriter wraps http.ResponseWriter and captures
+// the response status.
+type routerResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// NewRouter creates a new Router.
+func NewRouter(ctx context.Context) *Router {
+	return &Router{ctx: ctx}
+}
+
+// Mount mounts the handler under the path prefix. If handler is
+// already mounted under this prefix, it will be replaced.
+func (r *Router) Mount(prefix string, handler http.Handler) {
+	prefix = routerCleanPrefix(prefix)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.unmountLocked(prefix)
+
+	r.mounts = append(r.mounts, &routerMount{
+		prefix:  prefix,
+		handler: handler,
+	})
+
+	sort.SliceStable(r.mounts, func(i, j int) bool {
+		return len(r.mounts[i].prefix) > len(r.mounts[j].prefix)
+	})
+
+	log.Debug(r.ctx, "HTTP: %q mounted", prefix+"/")
+}
+
+// Unmount removes handler, mounted under the path prefix.
+func (r *Router) Unmount(prefix string) {
+	prefix = routerCleanPrefix(prefix)
+
+	r.lock.Lock()
+	r.unmountLocked(prefix)
+	r.lock.Unlock()
+}
+
+// unmountLocked removes handler, mounted under the path prefix.
+// Must be called under the r.lock.
+func (r *Router) unmountLocked(prefix string) {
+	for i, m := range r.mounts {
+		if m.prefix == prefix {
+			copy(r.mounts[i:], r.mounts[i+1:])
+			r.mounts = r.mounts[:len(r.mounts)-1]
+			return
+		}
+	}
+}
+
+// Prefixes returns list of mounted prefixes, longest first.
+func (r *Router) Prefixes() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	prefixes := make([]string, len(r.mounts))
+	for i, m := range r.mounts {
+		prefixes[i] = m.prefix + "/"
+	}
+
+	return prefixes
+}
+
+// ServeHTTP dispatches the incoming HTTP request.
+// It implements the [http.Handler] interface.
+func (r *Router) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	m := r.lookup(rq.URL.Path)
+	if m == nil {
+		log.Debug(r.ctx, "HTTP %s %s -- no handler", rq.Method, rq.URL)
+		http.NotFound(w, rq)
+		return
+	}
+
+	wrap := &routerResponseWriter{ResponseWriter: w}
+	m.handler.ServeHTTP(wrap, rq)
+
+	m.requests.Add(1)
+	if wrap.status >= 400 {
+		m.errors.Add(1)
+	}
+}
+
+// Metrics returns the http.Handler that reports per-prefix
+// request statistics in the plain text form. It is intended
+// to be mounted as an admin page.
+func (r *Router) Metrics() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		r.lock.RLock()
+		mounts := make([]*routerMount, len(r.mounts))
+		copy(mounts, r.mounts)
+		r.lock.RUnlock()
+
+		sort.Slice(mounts, func(i, j int) bool {
+			return mounts[i].prefix < mounts[j].prefix
+		})
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+
+		for _, m := range mounts {
+			fmt.Fprintf(w, "%s requests=%d errors=%d\n",
+				m.prefix+"/", m.requests.Load(), m.errors.Load())
+		}
+	})
+}
+
+// lookup returns routerMount for the path, nil if not found.
+func (r *Router) lookup(path string) *routerMount {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, m := range r.mounts {
+		rest, found := missed.StringsCutPrefix(path, m.prefix)
+		if found && (rest == "" || rest[0] == '/') {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// routerCleanPrefix returns the canonical form of the path prefix,
+// without the trailing '/' ("/" becomes "").
+func routerCleanPrefix(prefix string) string {
+	prefix = CleanURLPath(prefix)
+	return strings.TrimSuffix(prefix, "/")
+}
+
+// WriteHeader writes HTTP response header.
+func (w *routerResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes response body bytes.
+func (w *routerResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// ReadFrom copies response body bytes from r. It implements the
+// [io.ReaderFrom] interface and allows the underlying
+// http.ResponseWriter to use the zero-copy fast path.
+func (w *routerResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return ResponseReadFrom(w.ResponseWriter, r)
+}
+
+// Flush sends any buffered data to the client, if underlying
+// http.ResponseWriter supports it. It implements the [http.Flusher]
+// interface.
+func (w *routerResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+// It is used by the [http.ResponseController].
+func (w *routerResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
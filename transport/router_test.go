@@ -0,0 +1,116 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// HTTP router test
+
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestRouter tests Router
+func TestRouter(t *testing.T) {
+	// handler returns http.Handler that responds with the
+	// specified name
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter,
+			rq *http.Request) {
+			w.Header().Set("X-Handler", name)
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+
+	r := NewRouter(context.Background())
+	r.Mount("/eSCL", handler("escl"))
+	r.Mount("/ipp/print/", handler("ipp"))
+	r.Mount("/ipp", handler("ipp-root"))
+	r.Mount("/admin", handler("admin-old"))
+	r.Mount("/admin", handler("admin"))
+
+	type testData struct {
+		path    string // Request path
+		handler string // Expected handler, "" for 404
+	}
+
+	tests := []testData{
+		{"/eSCL", "escl"},
+		{"/eSCL/", "escl"},
+		{"/eSCL/ScannerStatus", "escl"},
+		{"/eSCLx", ""},
+		{"/ipp/print", "ipp"},
+		{"/ipp/print/job/1", "ipp"},
+		{"/ipp/fax", "ipp-root"},
+		{"/admin", "admin"},
+		{"/", ""},
+	}
+
+	check := func() {
+		for _, test := range tests {
+			rq := httptest.NewRequest("GET", test.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, rq)
+
+			name := w.Header().Get("X-Handler")
+			if name != test.handler {
+				t.Errorf("%s: handler mismatch:\n"+
+					"expected: %q\n"+
+					"present:  %q",
+					test.path, test.handler, name)
+			}
+
+			if name == "" && w.Code != http.StatusNotFound {
+				t.Errorf("%s: status %d, expected 404",
+					test.path, w.Code)
+			}
+		}
+	}
+
+	check()
+
+	// Check Prefixes
+	prefixes := r.Prefixes()
+	expected := []string{"/ipp/print/", "/admin/", "/eSCL/", "/ipp/"}
+	if !reflect.DeepEqual(prefixes, expected) {
+		t.Errorf("Prefixes mismatch:\n"+
+			"expected: %q\n"+
+			"present:  %q", expected, prefixes)
+	}
+
+	// Mount catch-all handler and re-check
+	r.Mount("/", handler("root"))
+	tests[3].handler = "root"
+	tests[8].handler = "root"
+	check()
+
+	// Unmount and re-check
+	r.Unmount("/ipp/print")
+	tests[4].handler = "ipp-root"
+	tests[5].handler = "ipp-root"
+	check()
+
+	// Check metrics
+	rq := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	r.Metrics().ServeHTTP(w, rq)
+
+	metrics := w.Body.String()
+	expectedMetrics := "" +
+		"/ requests=4 errors=0\n" +
+		"/admin/ requests=3 errors=0\n" +
+		"/eSCL/ requests=9 errors=0\n" +
+		"/ipp/ requests=5 errors=0\n"
+
+	if metrics != expectedMetrics {
+		t.Errorf("Metrics mismatch:\n"+
+			"expected: %q\n"+
+			"present:  %q", expectedMetrics, metrics)
+	}
+}
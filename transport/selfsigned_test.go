@@ -0,0 +1,42 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Self-signed TLS certificates test
+
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSelfSignedTLSConfig tests NewSelfSignedTLSConfig
+func TestSelfSignedTLSConfig(t *testing.T) {
+	conf, err := NewSelfSignedTLSConfig("localhost", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("NewSelfSignedTLSConfig: %s", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, rq *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	srv.TLS = conf
+	srv.StartTLS()
+	defer srv.Close()
+
+	clnt := NewClient(nil)
+	rsp, err := clnt.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET %s: %s", srv.URL, err)
+	}
+	rsp.Body.Close()
+
+	if rsp.TLS == nil || rsp.StatusCode != http.StatusNoContent {
+		t.Errorf("GET %s: unexpected response", srv.URL)
+	}
+}
@@ -0,0 +1,51 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Zero-copy response body writing
+
+package transport
+
+import (
+	"io"
+	"net/http"
+)
+
+// ResponseReadFrom copies data from r into the HTTP response body
+// until EOF, and returns number of bytes copied.
+//
+// Unlike io.Copy, it looks for the [io.ReaderFrom] implementation
+// through the chain of http.ResponseWriter wrappers (see
+// [http.ResponseController] for the Unwrap convention) and uses it,
+// if found. This allows net/http to use the zero-copy sendfile(2)
+// path when r is the [os.File] or the [io.LimitedReader] on a top
+// of it, and response is not chunked (i.e., Content-Length is set).
+//
+// Wrappers that capture written data must implement io.ReaderFrom
+// by themselves, otherwise they will be bypassed.
+func ResponseReadFrom(w http.ResponseWriter, r io.Reader) (int64, error) {
+	for next := w; next != nil; {
+		if rf, ok := next.(io.ReaderFrom); ok {
+			return rf.ReadFrom(r)
+		}
+
+		unwrapper, ok := next.(interface {
+			Unwrap() http.ResponseWriter
+		})
+		if !ok {
+			break
+		}
+
+		next = unwrapper.Unwrap()
+	}
+
+	return io.Copy(responseWriterOnly{w}, r)
+}
+
+// responseWriterOnly hides all methods of http.ResponseWriter
+// except Write, so io.Copy will not recurse into ReadFrom.
+type responseWriterOnly struct {
+	io.Writer
+}
@@ -0,0 +1,83 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Zero-copy response body writing test
+
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testReaderFromWriter is the http.ResponseWriter that implements
+// io.ReaderFrom and counts ReadFrom calls.
+type testReaderFromWriter struct {
+	*httptest.ResponseRecorder
+	calls int
+}
+
+// ReadFrom implements io.ReaderFrom
+func (w *testReaderFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.calls++
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+// testUnwrapWriter is the http.ResponseWriter wrapper that
+// only implements Unwrap.
+type testUnwrapWriter struct {
+	http.ResponseWriter
+}
+
+// Unwrap returns the underlying http.ResponseWriter
+func (w testUnwrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// TestResponseReadFrom tests ResponseReadFrom
+func TestResponseReadFrom(t *testing.T) {
+	const body = "hello, world"
+
+	// Direct io.ReaderFrom
+	w := &testReaderFromWriter{ResponseRecorder: httptest.NewRecorder()}
+	n, err := ResponseReadFrom(w, strings.NewReader(body))
+	if err != nil || n != int64(len(body)) || w.calls != 1 {
+		t.Errorf("direct: n=%d err=%v calls=%d", n, err, w.calls)
+	}
+
+	// io.ReaderFrom via Unwrap chain
+	w = &testReaderFromWriter{ResponseRecorder: httptest.NewRecorder()}
+	n, err = ResponseReadFrom(testUnwrapWriter{testUnwrapWriter{w}},
+		strings.NewReader(body))
+	if err != nil || n != int64(len(body)) || w.calls != 1 {
+		t.Errorf("unwrap: n=%d err=%v calls=%d", n, err, w.calls)
+	}
+
+	// No io.ReaderFrom at all
+	rec := httptest.NewRecorder()
+	n, err = ResponseReadFrom(testUnwrapWriter{rec},
+		strings.NewReader(body))
+	if err != nil || n != int64(len(body)) || rec.Body.String() != body {
+		t.Errorf("fallback: n=%d err=%v body=%q", n, err, rec.Body)
+	}
+
+	// Router passes ReadFrom through
+	r := NewRouter(context.Background())
+	r.Mount("/", http.HandlerFunc(func(w http.ResponseWriter,
+		rq *http.Request) {
+		ResponseReadFrom(w, strings.NewReader(body))
+	}))
+
+	w = &testReaderFromWriter{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.calls != 1 || w.Body.String() != body {
+		t.Errorf("router: calls=%d body=%q", w.calls, w.Body)
+	}
+}
@@ -0,0 +1,131 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Socket options
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// SocketOptions defines low-level options of the TCP sockets,
+// used for the scan and print traffic.
+//
+// Zero value means "use system defaults" for all options.
+//
+// These options are typically needed in managed networks, where
+// traffic must be properly marked for QoS, and on multi-homed hosts,
+// where default routing may choose a wrong interface.
+type SocketOptions struct {
+	// DSCP is the Differentiated Services Code Point (RFC 2474),
+	// 0...63, used to mark outgoing packets. It is translated
+	// into the IP_TOS (IPv4) or IPV6_TCLASS (IPv6) socket option.
+	DSCP int
+
+	// Interface, if not empty, binds socket to the network
+	// interface with this name (i.e., "eth0"), using the
+	// SO_BINDTODEVICE socket option.
+	//
+	// It usually requires the CAP_NET_RAW capability.
+	Interface string
+
+	// KeepAlive configures TCP keep-alive probes. Zero values
+	// of its fields mean system defaults. See [net.KeepAliveConfig]
+	// for details.
+	KeepAlive net.KeepAliveConfig
+}
+
+// Validate checks SocketOptions for errors.
+func (opts SocketOptions) Validate() error {
+	if opts.DSCP < 0 || opts.DSCP > 63 {
+		return fmt.Errorf("DSCP out of range (0...63): %d", opts.DSCP)
+	}
+
+	return nil
+}
+
+// Dialer returns the [net.Dialer], configured according to the
+// SocketOptions. It is suitable as the [http.Transport.DialContext]:
+//
+//	template.DialContext = opts.Dialer().DialContext
+func (opts SocketOptions) Dialer() *net.Dialer {
+	return &net.Dialer{
+		KeepAliveConfig: opts.KeepAlive,
+		Control:         opts.Control,
+	}
+}
+
+// ListenConfig returns the [net.ListenConfig], configured according
+// to the SocketOptions.
+func (opts SocketOptions) ListenConfig() *net.ListenConfig {
+	return &net.ListenConfig{
+		KeepAliveConfig: opts.KeepAlive,
+		Control:         opts.Control,
+	}
+}
+
+// Listen announces on the local network address, like [net.Listen]
+// does, but with SocketOptions applied to the listening socket.
+//
+// On Linux, options of the accepted connections are inherited
+// from the listening socket.
+func (opts SocketOptions) Listen(ctx context.Context,
+	network, address string) (net.Listener, error) {
+
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.ListenConfig().Listen(ctx, network, address)
+}
+
+// Control applies SocketOptions to the socket, before it is connected
+// or bound. Its signature matches the [net.Dialer.Control] and
+// [net.ListenConfig.Control] hooks.
+//
+// Non-TCP sockets are left intact.
+func (opts SocketOptions) Control(network, address string,
+	rawconn syscall.RawConn) error {
+
+	var is6 bool
+	switch network {
+	case "tcp4":
+	case "tcp6":
+		is6 = true
+	default:
+		return nil
+	}
+
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+
+	var err2 error
+	err = rawconn.Control(func(fd uintptr) {
+		err2 = opts.sysSetSockOpt(int(fd), is6)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return err2
+}
+
+// SetSocketOptions makes the Transport to use the SocketOptions
+// for all outgoing connections.
+//
+// Note, it replaces the DialContext of the template [http.Transport],
+// passed to the [NewTransport], if any.
+func (tr *Transport) SetSocketOptions(opts SocketOptions) {
+	tr.templateDialContext = opts.Dialer().DialContext
+}
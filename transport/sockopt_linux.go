@@ -0,0 +1,44 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Socket options -- Linux-specific stuff
+
+package transport
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// sysSetSockOpt sets system-specific socket options.
+func (opts SocketOptions) sysSetSockOpt(fd int, is6 bool) error {
+	if opts.DSCP != 0 {
+		// DSCP occupies upper 6 bits of the TOS/TCLASS octet.
+		tos := opts.DSCP << 2
+
+		var err error
+		if is6 {
+			err = syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6,
+				syscall.IPV6_TCLASS, tos)
+		} else {
+			err = syscall.SetsockoptInt(fd, syscall.IPPROTO_IP,
+				syscall.IP_TOS, tos)
+		}
+
+		if err != nil {
+			return fmt.Errorf("DSCP %d: %w", opts.DSCP, err)
+		}
+	}
+
+	if opts.Interface != "" {
+		err := syscall.BindToDevice(fd, opts.Interface)
+		if err != nil {
+			return fmt.Errorf("bind to %q: %w", opts.Interface, err)
+		}
+	}
+
+	return nil
+}
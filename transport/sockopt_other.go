@@ -0,0 +1,28 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Socket options -- stub for non-Linux systems
+
+//go:build !linux
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+)
+
+// sysSetSockOpt sets system-specific socket options.
+//
+// Socket options are only implemented for Linux. Elsewhere, it
+// fails if any option is actually requested.
+func (opts SocketOptions) sysSetSockOpt(fd int, is6 bool) error {
+	if opts.DSCP != 0 || opts.Interface != "" {
+		return fmt.Errorf("socket options: %w", errors.ErrUnsupported)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Socket options test
+
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// sockoptGetTOS returns IP_TOS of the TCP connection
+func sockoptGetTOS(t *testing.T, conn net.Conn) int {
+	rawconn, err := conn.(*net.TCPConn).SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn: %s", err)
+	}
+
+	var tos int
+	var err2 error
+	rawconn.Control(func(fd uintptr) {
+		tos, err2 = syscall.GetsockoptInt(int(fd),
+			syscall.IPPROTO_IP, syscall.IP_TOS)
+	})
+
+	if err2 != nil {
+		t.Fatalf("getsockopt(IP_TOS): %s", err2)
+	}
+
+	return tos
+}
+
+// TestSocketOptions tests SocketOptions
+func TestSocketOptions(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("socket options are only implemented for Linux")
+	}
+
+	opts := SocketOptions{
+		DSCP: 46, // Expedited Forwarding
+		KeepAlive: net.KeepAliveConfig{
+			Enable:   true,
+			Idle:     30 * time.Second,
+			Interval: 5 * time.Second,
+			Count:    3,
+		},
+	}
+
+	ln, err := opts.Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("SocketOptions.Listen: %s", err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+		close(accepted)
+	}()
+
+	conn, err := opts.Dialer().DialContext(context.Background(),
+		"tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("SocketOptions.Dialer: %s", err)
+	}
+	defer conn.Close()
+
+	if tos := sockoptGetTOS(t, conn); tos != 46<<2 {
+		t.Errorf("dialed connection: TOS expected 0x%x, present 0x%x",
+			46<<2, tos)
+	}
+
+	srvconn := <-accepted
+	if srvconn == nil {
+		t.Fatalf("Accept failed")
+	}
+	defer srvconn.Close()
+
+	if tos := sockoptGetTOS(t, srvconn); tos != 46<<2 {
+		t.Errorf("accepted connection: TOS expected 0x%x, present 0x%x",
+			46<<2, tos)
+	}
+}
+
+// TestSocketOptionsErrors tests SocketOptions errors
+func TestSocketOptionsErrors(t *testing.T) {
+	// Invalid DSCP
+	opts := SocketOptions{DSCP: 64}
+	_, err := opts.Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if err == nil {
+		t.Errorf("DSCP 64: error expected")
+	}
+
+	// Unknown interface
+	opts = SocketOptions{Interface: "no-such-interface"}
+	_, err = opts.Dialer().DialContext(context.Background(),
+		"tcp4", "127.0.0.1:1")
+	if err == nil {
+		t.Errorf("Interface %q: error expected", opts.Interface)
+	}
+}
+
+// TestTransportSetSocketOptions tests Transport.SetSocketOptions
+func TestTransportSetSocketOptions(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %s", err)
+	}
+	defer ln.Close()
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter,
+			rq *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	}
+
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	tr := NewTransport(nil)
+	tr.SetSocketOptions(SocketOptions{Interface: "no-such-interface"})
+
+	u := MustParseURL("http://" + ln.Addr().String() + "/")
+	rq, _ := NewRequest(context.Background(), "GET", u, nil)
+
+	// Connection must fail, as options are in use
+	rsp, err := NewClient(tr).Do(rq)
+	if err == nil {
+		rsp.Body.Close()
+		t.Errorf("SocketOptions are not applied by Transport")
+	}
+
+	// Reset options, and it should work
+	tr.SetSocketOptions(SocketOptions{})
+	rq, _ = NewRequest(context.Background(), "GET", u, nil)
+
+	rsp, err = NewClient(tr).Do(rq)
+	if err != nil {
+		t.Fatalf("Transport: %s", err)
+	}
+	rsp.Body.Close()
+}
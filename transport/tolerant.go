@@ -0,0 +1,612 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Tolerant reader of malformed HTTP responses
+
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/OpenPrinting/go-mfp/log"
+)
+
+// tolerantConn wraps client-side net.Conn and normalizes HTTP
+// responses, received from the server, before they reach the
+// [http.Transport] response parser.
+//
+// Embedded HTTP stacks of the cheap printers and scanners are
+// known to send responses that Go's HTTP client rejects. The
+// following problems are salvaged:
+//
+//   - HTTP/0.9 responses (body without status line and headers).
+//   - Status line without reason phrase, or with garbage after
+//     the status code.
+//   - Malformed header lines (dropped).
+//   - Bad chunked framing: invalid chunk size lines, missed or
+//     extra CRLF after the chunk data.
+//   - Connection closed without terminating zero-length chunk
+//     (i.e., after the full body).
+//
+// Well-formed responses pass unchanged. Each salvaged problem is
+// logged as a warning. After the 101 Switching Protocols response,
+// the rest of the connection passes unchanged.
+//
+// tolerantConn must be used only for the plain-text connections.
+// It is enabled by the [Transport.Tolerant].
+type tolerantConn struct {
+	net.Conn                     // Underlying connection
+	ctx      context.Context     // Logging context
+	in       *bufio.Reader       // Input from the underlying connection
+	out      bytes.Buffer        // Normalized output
+	state    tolerantState       // Current state
+	status   int                 // Status code of the current response
+	framing  tolerantFraming     // Framing of the current response body
+	remain   int64               // Remaining bytes of body or chunk
+	err      error               // Pending error
+	lock     sync.Mutex          // Access lock for methods
+	methods  []string            // Methods of sent requests
+	warned   map[string]struct{} // Warnings already logged
+}
+
+// tolerantState is the state of the tolerantConn
+type tolerantState int
+
+const (
+	tolerantStatus    tolerantState = iota // Waiting for status line
+	tolerantHeaders                        // Reading headers
+	tolerantBody                           // Content-Length body
+	tolerantChunkSize                      // Waiting for chunk size
+	tolerantChunkData                      // Reading chunk data
+	tolerantChunkCRLF                      // Waiting for CRLF after data
+	tolerantChunkRaw                       // Salvaging broken chunks
+	tolerantTrailer                        // Reading chunked trailer
+	tolerantRaw                            // Body until connection close
+	tolerantEOF                            // Nothing more to read
+)
+
+// tolerantFraming is the framing of the response body
+type tolerantFraming int
+
+const (
+	tolerantFramingNone    tolerantFraming = iota // No body
+	tolerantFramingLength                         // Content-Length
+	tolerantFramingChunked                        // Chunked
+	tolerantFramingClose                          // Until close
+)
+
+// tolerantMaxLine is the maximum line length, the tolerantConn
+// handles. Longer lines are passed as is.
+const tolerantMaxLine = 65536
+
+// newTolerantConn wraps net.Conn into the tolerantConn.
+func newTolerantConn(ctx context.Context, conn net.Conn) *tolerantConn {
+	return &tolerantConn{
+		Conn: conn,
+		ctx:  ctx,
+		in:   bufio.NewReader(conn),
+	}
+}
+
+// Write writes data to the connection.
+//
+// It tracks methods of the sent requests, as responses to the
+// HEAD requests have no body, regardless of their headers.
+//
+// Requests are recognized by the request line, not by the response
+// boundaries, as server may respond early, while request body is
+// still being sent. It relies on the fact that [http.Transport]
+// flushes its output after each request, so every request line
+// starts a new Write.
+func (c *tolerantConn) Write(data []byte) (int, error) {
+	if method, ok := tolerantRequestMethod(data); ok {
+		c.lock.Lock()
+		c.methods = append(c.methods, method)
+		c.lock.Unlock()
+	}
+
+	return c.Conn.Write(data)
+}
+
+// SetLinger sets SO_LINGER option of the underlying connection,
+// if it is supported. It allows connAbort to work with tolerantConn.
+func (c *tolerantConn) SetLinger(sec int) error {
+	if withSetLinger, ok := c.Conn.(connWithSetLinger); ok {
+		return withSetLinger.SetLinger(sec)
+	}
+	return nil
+}
+
+// Read reads normalized data from the connection.
+func (c *tolerantConn) Read(buf []byte) (int, error) {
+	for c.out.Len() == 0 && c.err == nil {
+		c.err = c.step()
+	}
+
+	if c.out.Len() != 0 {
+		return c.out.Read(buf)
+	}
+
+	return 0, c.err
+}
+
+// step performs a single step of the input processing.
+func (c *tolerantConn) step() error {
+	switch c.state {
+	case tolerantStatus:
+		return c.stepStatus()
+	case tolerantHeaders:
+		return c.stepHeaders()
+	case tolerantBody:
+		return c.stepBody()
+	case tolerantChunkSize:
+		return c.stepChunkSize()
+	case tolerantChunkData:
+		return c.stepChunkData()
+	case tolerantChunkCRLF:
+		return c.stepChunkCRLF()
+	case tolerantChunkRaw:
+		return c.stepChunkRaw()
+	case tolerantTrailer:
+		return c.stepTrailer()
+	case tolerantRaw:
+		return c.stepRaw()
+	}
+
+	return io.EOF
+}
+
+// stepStatus handles the response status line.
+func (c *tolerantConn) stepStatus() error {
+	// Skip extra CRLF after the previous response
+	for {
+		b, err := c.in.Peek(1)
+		if err != nil {
+			return err
+		}
+
+		if b[0] != '\r' && b[0] != '\n' {
+			break
+		}
+
+		c.warning("extra CRLF before status line")
+		c.in.Discard(1)
+	}
+
+	// Check for HTTP/0.9 response
+	prefix, err := c.in.Peek(5)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if !bytes.EqualFold(prefix, []byte("HTTP/")) {
+		c.warning("HTTP/0.9 response (no status line)")
+		c.out.WriteString("HTTP/1.0 200 OK\r\n")
+		c.out.WriteString("Connection: close\r\n\r\n")
+		c.popMethod()
+		c.state = tolerantRaw
+		return nil
+	}
+
+	line, err := c.readLine()
+	if err != nil && len(line) == 0 {
+		return err
+	}
+
+	// Parse the status line. Some devices omit reason phrase
+	// or even space between status code and reason phrase.
+	proto, rest, _ := strings.Cut(string(tolerantTrimEOL(line)), " ")
+	rest = strings.TrimLeft(rest, " ")
+
+	code := rest
+	if len(code) > 3 {
+		code = code[:3]
+	}
+
+	status, err := strconv.Atoi(code)
+	if len(code) != 3 || err != nil || status < 100 {
+		// Can't salvage. Let http.Transport to complain.
+		c.out.Write(line)
+		c.state = tolerantRaw
+		return nil
+	}
+
+	reason := rest[3:]
+	if reason != "" && reason[0] != ' ' {
+		c.warning("no space after status code")
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		c.warning("status line without reason phrase")
+		reason = http.StatusText(status)
+		if reason == "" {
+			reason = "Unknown"
+		}
+	}
+
+	c.out.WriteString(strings.ToUpper(proto))
+	c.out.WriteString(" ")
+	c.out.WriteString(code)
+	c.out.WriteString(" ")
+	c.out.WriteString(reason)
+	c.out.WriteString("\r\n")
+
+	c.status = status
+	c.framing = tolerantFramingClose
+	c.remain = -1
+	c.state = tolerantHeaders
+
+	return nil
+}
+
+// stepHeaders handles a single header line.
+func (c *tolerantConn) stepHeaders() error {
+	line, err := c.readLine()
+	if err != nil && len(line) == 0 {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	text := string(tolerantTrimEOL(line))
+	if text == "" {
+		c.out.WriteString("\r\n")
+		c.headersDone()
+		return nil
+	}
+
+	name, val, found := strings.Cut(text, ":")
+	if !found || name == "" || strings.ContainsAny(name, " \t") {
+		if text[0] == ' ' || text[0] == '\t' {
+			// Obsolete line folding. Let http.Transport
+			// to handle it.
+			c.out.WriteString(text)
+			c.out.WriteString("\r\n")
+			return nil
+		}
+
+		c.warning("malformed header line dropped")
+		return nil
+	}
+
+	val = strings.TrimSpace(val)
+	switch strings.ToLower(name) {
+	case "transfer-encoding":
+		if strings.Contains(strings.ToLower(val), "chunked") {
+			c.framing = tolerantFramingChunked
+		}
+
+	case "content-length":
+		if c.framing != tolerantFramingChunked {
+			n, err := strconv.ParseInt(val, 10, 64)
+			if err == nil && n >= 0 {
+				c.framing = tolerantFramingLength
+				c.remain = n
+			}
+		}
+	}
+
+	c.out.WriteString(name)
+	c.out.WriteString(": ")
+	c.out.WriteString(val)
+	c.out.WriteString("\r\n")
+
+	return nil
+}
+
+// headersDone is called when response headers are received.
+// It chooses the body framing.
+func (c *tolerantConn) headersDone() {
+	// After protocol switch, connection is not HTTP anymore
+	if c.status == http.StatusSwitchingProtocols {
+		c.popMethod()
+		c.state = tolerantRaw
+		return
+	}
+
+	// Informational responses are followed by the final response
+	if c.status >= 100 && c.status < 200 {
+		c.state = tolerantStatus
+		return
+	}
+
+	method := c.popMethod()
+	switch {
+	case method == "HEAD" || c.status == http.StatusNoContent ||
+		c.status == http.StatusNotModified:
+		c.framing = tolerantFramingNone
+	case c.framing == tolerantFramingLength && c.remain == 0:
+		c.framing = tolerantFramingNone
+	}
+
+	switch c.framing {
+	case tolerantFramingNone:
+		c.responseDone()
+	case tolerantFramingLength:
+		c.state = tolerantBody
+	case tolerantFramingChunked:
+		c.state = tolerantChunkSize
+	case tolerantFramingClose:
+		c.state = tolerantRaw
+	}
+}
+
+// responseDone is called when response is completely received.
+func (c *tolerantConn) responseDone() {
+	c.state = tolerantStatus
+}
+
+// popMethod returns method of the request, the current response
+// relates to, and removes it from the queue.
+func (c *tolerantConn) popMethod() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.methods) == 0 {
+		return ""
+	}
+
+	method := c.methods[0]
+	c.methods = c.methods[1:]
+	return method
+}
+
+// stepBody handles the Content-Length body.
+func (c *tolerantConn) stepBody() error {
+	n, err := c.copy(c.remain)
+	c.remain -= n
+
+	switch {
+	case c.remain == 0:
+		c.responseDone()
+		return nil
+	case err == io.EOF:
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// stepChunkSize handles the chunk size line.
+func (c *tolerantConn) stepChunkSize() error {
+	line, err := c.readLine()
+	if err != nil && len(line) == 0 {
+		if err == io.EOF {
+			c.warning("connection closed before last chunk")
+			c.out.WriteString("0\r\n\r\n")
+			c.state = tolerantEOF
+			return nil
+		}
+		return err
+	}
+
+	return c.chunkSize(line)
+}
+
+// chunkSize parses the chunk size line.
+func (c *tolerantConn) chunkSize(line []byte) error {
+	text := strings.TrimSpace(string(tolerantTrimEOL(line)))
+	if text == "" {
+		c.warning("extra CRLF between chunks")
+		return nil
+	}
+
+	sz, _, _ := strings.Cut(text, ";")
+	sz = strings.TrimSpace(sz)
+
+	size, err := strconv.ParseUint(sz, 16, 63)
+	if err != nil {
+		// Salvage the rest of the body as a raw data
+		c.warning("invalid chunk size line")
+		c.writeChunk(line)
+		c.state = tolerantChunkRaw
+		return nil
+	}
+
+	if size == 0 {
+		c.out.WriteString("0\r\n")
+		c.state = tolerantTrailer
+		return nil
+	}
+
+	fmt.Fprintf(&c.out, "%x\r\n", size)
+	c.remain = int64(size)
+	c.state = tolerantChunkData
+
+	return nil
+}
+
+// stepChunkData handles the chunk data.
+func (c *tolerantConn) stepChunkData() error {
+	n, err := c.copy(c.remain)
+	c.remain -= n
+
+	switch {
+	case c.remain == 0:
+		c.out.WriteString("\r\n")
+		c.state = tolerantChunkCRLF
+		return nil
+	case err == io.EOF:
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// stepChunkCRLF handles CRLF after the chunk data.
+func (c *tolerantConn) stepChunkCRLF() error {
+	line, err := c.readLine()
+	if err != nil && len(line) == 0 {
+		if err == io.EOF {
+			c.warning("connection closed before last chunk")
+			c.out.WriteString("0\r\n\r\n")
+			c.state = tolerantEOF
+			return nil
+		}
+		return err
+	}
+
+	c.state = tolerantChunkSize
+
+	if len(tolerantTrimEOL(line)) != 0 {
+		// CRLF is missed, so this is the next chunk size line
+		c.warning("missed CRLF after chunk data")
+		return c.chunkSize(line)
+	}
+
+	return nil
+}
+
+// stepChunkRaw passes the rest of the broken chunked body
+// until connection close.
+func (c *tolerantConn) stepChunkRaw() error {
+	var buf [32768]byte
+	n, err := c.in.Read(buf[:])
+	c.writeChunk(buf[:n])
+
+	if err == io.EOF {
+		c.out.WriteString("0\r\n\r\n")
+		c.state = tolerantEOF
+		return nil
+	}
+
+	return err
+}
+
+// stepTrailer handles the chunked body trailer.
+func (c *tolerantConn) stepTrailer() error {
+	line, err := c.readLine()
+	if err != nil && len(line) == 0 {
+		if err == io.EOF {
+			c.warning("connection closed before end of trailer")
+			c.out.WriteString("\r\n")
+			c.state = tolerantEOF
+			return nil
+		}
+		return err
+	}
+
+	text := tolerantTrimEOL(line)
+	c.out.Write(text)
+	c.out.WriteString("\r\n")
+
+	if len(text) == 0 {
+		c.responseDone()
+	}
+
+	return nil
+}
+
+// stepRaw passes the data until connection close.
+func (c *tolerantConn) stepRaw() error {
+	_, err := c.copy(32768)
+	if err == io.EOF {
+		c.state = tolerantEOF
+	}
+	return err
+}
+
+// copy copies up to max bytes from input to output.
+func (c *tolerantConn) copy(max int64) (int64, error) {
+	var buf [32768]byte
+	if max > int64(len(buf)) {
+		max = int64(len(buf))
+	}
+
+	n, err := c.in.Read(buf[:max])
+	c.out.Write(buf[:n])
+
+	return int64(n), err
+}
+
+// writeChunk writes data to output as a chunk.
+func (c *tolerantConn) writeChunk(data []byte) {
+	if len(data) != 0 {
+		fmt.Fprintf(&c.out, "%x\r\n", len(data))
+		c.out.Write(data)
+		c.out.WriteString("\r\n")
+	}
+}
+
+// readLine reads the next line from the input, including the
+// line terminator.
+//
+// If line is too long, it returns what was read so far.
+// If error occurs, it returns partially read line, if any,
+// and the error.
+func (c *tolerantConn) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := c.in.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		switch {
+		case err == bufio.ErrBufferFull && len(line) < tolerantMaxLine:
+			continue
+		case errors.Is(err, bufio.ErrBufferFull):
+			return line, nil
+		}
+
+		return line, err
+	}
+}
+
+// warning writes a warning message to the log. Each message
+// is logged once per connection.
+func (c *tolerantConn) warning(msg string) {
+	if _, found := c.warned[msg]; found {
+		return
+	}
+
+	if c.warned == nil {
+		c.warned = make(map[string]struct{})
+	}
+	c.warned[msg] = struct{}{}
+
+	log.Warning(c.ctx, "HTTP: %s: %s (salvaged)", c.RemoteAddr(), msg)
+}
+
+// tolerantRequestMethod returns method of the HTTP/1.x request,
+// if data starts with the request line.
+func tolerantRequestMethod(data []byte) (string, bool) {
+	line, _, found := bytes.Cut(data, []byte("\r\n"))
+	if !found {
+		return "", false
+	}
+
+	method, rest, _ := bytes.Cut(line, []byte(" "))
+	if len(method) == 0 || len(rest) == 0 ||
+		!(bytes.HasSuffix(rest, []byte(" HTTP/1.1")) ||
+			bytes.HasSuffix(rest, []byte(" HTTP/1.0"))) {
+		return "", false
+	}
+
+	for _, c := range method {
+		if c < 'A' || c > 'Z' {
+			return "", false
+		}
+	}
+
+	return string(method), true
+}
+
+// tolerantTrimEOL trims trailing CRLF or LF.
+func tolerantTrimEOL(line []byte) []byte {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r"))
+}
@@ -0,0 +1,379 @@
+// MFP       - Miulti-Function Printers and scanners toolkit
+// TRANSPORT - Transport protocol implementation
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// Tolerant reader of malformed HTTP responses test
+
+package transport
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// TestTolerantConn tests salvaging of malformed HTTP responses
+func TestTolerantConn(t *testing.T) {
+	type testData struct {
+		name     string // Test name
+		method   string // Request method
+		response string // Raw response, sent by server
+		status   int    // Expected status
+		body     string // Expected body
+	}
+
+	tests := []testData{
+		{
+			name:   "well-formed",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"Content-Length: 5\r\n" +
+				"\r\n" +
+				"hello",
+			status: 200,
+			body:   "hello",
+		},
+
+		{
+			name:     "HTTP/0.9",
+			method:   "GET",
+			response: "hello, world",
+			status:   200,
+			body:     "hello, world",
+		},
+
+		{
+			name:   "no reason phrase",
+			method: "GET",
+			response: "HTTP/1.1 404\r\n" +
+				"Content-Length: 3\r\n" +
+				"\r\n" +
+				"bad",
+			status: 404,
+			body:   "bad",
+		},
+
+		{
+			name:   "no space after status code",
+			method: "GET",
+			response: "HTTP/1.1 200OK\r\n" +
+				"Content-Length: 2\r\n" +
+				"\r\n" +
+				"ok",
+			status: 200,
+			body:   "ok",
+		},
+
+		{
+			name:   "LF line endings and malformed header",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\n" +
+				"Garbage header line\n" +
+				"Content-Length: 2\n" +
+				"\n" +
+				"ok",
+			status: 200,
+			body:   "ok",
+		},
+
+		{
+			name:   "HEAD with Content-Length",
+			method: "HEAD",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"Content-Length: 100\r\n" +
+				"\r\n",
+			status: 200,
+			body:   "",
+		},
+
+		{
+			name:   "well-formed chunked",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"5\r\nhello\r\n" +
+				"7; ext=1\r\n, world\r\n" +
+				"0\r\n" +
+				"\r\n",
+			status: 200,
+			body:   "hello, world",
+		},
+
+		{
+			name:   "missed CRLF after chunk data",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"5\r\nhello" +
+				"7\r\n, world" +
+				"0\r\n" +
+				"\r\n",
+			status: 200,
+			body:   "hello, world",
+		},
+
+		{
+			name:   "extra CRLF between chunks",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"5\r\nhello\r\n\r\n" +
+				"7\r\n, world\r\n\r\n" +
+				"0\r\n" +
+				"\r\n",
+			status: 200,
+			body:   "hello, world",
+		},
+
+		{
+			name:   "connection closed before last chunk",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"5\r\nhello\r\n" +
+				"7\r\n, world\r\n",
+			status: 200,
+			body:   "hello, world",
+		},
+
+		{
+			name:   "missed trailer",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"5\r\nhello\r\n" +
+				"0\r\n",
+			status: 200,
+			body:   "hello",
+		},
+
+		{
+			name:   "invalid chunk size line",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"hello, world",
+			status: 200,
+			body:   "hello, world",
+		},
+
+		{
+			name:   "body until close",
+			method: "GET",
+			response: "HTTP/1.1 200 OK\r\n" +
+				"\r\n" +
+				"hello, world",
+			status: 200,
+			body:   "hello, world",
+		},
+	}
+
+	for _, test := range tests {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("net.Listen: %s", err)
+		}
+
+		// Server side: read request, write raw response,
+		// close connection
+		go func(response string) {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			rq, err := http.ReadRequest(bufio.NewReader(conn))
+			if err == nil {
+				rq.Body.Close()
+				conn.Write([]byte(response))
+			}
+
+			conn.Close()
+		}(test.response)
+
+		// Client side
+		tr := NewTransport(nil)
+		tr.Tolerant = true
+		clnt := NewClient(tr)
+		u := "http://" + l.Addr().String() + "/"
+		rq, _ := http.NewRequest(test.method, u, nil)
+		rsp, err := clnt.Do(rq)
+
+		var body []byte
+		if err == nil {
+			body, err = io.ReadAll(rsp.Body)
+			rsp.Body.Close()
+		}
+
+		l.Close()
+
+		switch {
+		case err != nil:
+			t.Errorf("%s: %s", test.name, err)
+
+		case rsp.StatusCode != test.status:
+			t.Errorf("%s: status mismatch:\n"+
+				"expected: %d\n"+
+				"present:  %d",
+				test.name, test.status, rsp.StatusCode)
+
+		case string(body) != test.body:
+			t.Errorf("%s: body mismatch:\n"+
+				"expected: %q\n"+
+				"present:  %q",
+				test.name, test.body, body)
+		}
+	}
+}
+
+// TestTolerantConnOptIn tests that malformed responses are salvaged
+// only if Transport.Tolerant is set.
+func TestTolerantConnOptIn(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %s", err)
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		rq, err := http.ReadRequest(bufio.NewReader(conn))
+		if err == nil {
+			rq.Body.Close()
+			conn.Write([]byte("hello, world"))
+		}
+
+		conn.Close()
+	}()
+
+	clnt := NewClient(nil)
+	rsp, err := clnt.Get("http://" + l.Addr().String() + "/")
+	if err == nil {
+		rsp.Body.Close()
+		t.Errorf("HTTP/0.9 response accepted by default")
+	}
+}
+
+// TestTolerantConnEarlyResponse tests tracking of request methods,
+// when server responds before request body is completely sent.
+func TestTolerantConnEarlyResponse(t *testing.T) {
+	client, server := net.Pipe()
+	c := newTolerantConn(context.Background(), client)
+	defer c.Close()
+	defer server.Close()
+
+	next := make(chan struct{})
+	go io.Copy(io.Discard, server)
+	go func() {
+		server.Write([]byte("HTTP/1.1 413 Request Entity Too Large\r\n" +
+			"Content-Length: 0\r\n" +
+			"\r\n"))
+
+		<-next
+
+		// Response to HEAD has no body, regardless of
+		// Content-Length
+		server.Write([]byte("HTTP/1.1 200 OK\r\n" +
+			"Content-Length: 5\r\n" +
+			"\r\n" +
+			"HTTP/1.1 204 No Content\r\n" +
+			"\r\n"))
+	}()
+
+	in := bufio.NewReader(c)
+
+	// Server responds early, while POST body is still being sent
+	c.Write([]byte("POST /a HTTP/1.1\r\nHost: x\r\n\r\n"))
+	rsp, err := http.ReadResponse(in, nil)
+	if err != nil || rsp.StatusCode != 413 {
+		t.Fatalf("POST: unexpected response: %v", err)
+	}
+
+	c.Write([]byte("body that continues after the response"))
+	c.Write([]byte("HEAD /b HTTP/1.1\r\nHost: x\r\n\r\n"))
+	c.Write([]byte("GET /c HTTP/1.1\r\nHost: x\r\n\r\n"))
+	close(next)
+
+	rsp, err = http.ReadResponse(in, &http.Request{Method: "HEAD"})
+	if err != nil || rsp.StatusCode != 200 {
+		t.Fatalf("HEAD: unexpected response: %v", err)
+	}
+
+	rsp, err = http.ReadResponse(in, nil)
+	if err != nil || rsp.StatusCode != 204 {
+		t.Fatalf("GET: unexpected response: %v", err)
+	}
+}
+
+// TestTolerantConnSwitchingProtocols tests that connection passes
+// unchanged after the 101 Switching Protocols response.
+func TestTolerantConnSwitchingProtocols(t *testing.T) {
+	client, server := net.Pipe()
+	c := newTolerantConn(context.Background(), client)
+	defer c.Close()
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Upgrade: test\r\n" +
+		"\r\n" +
+		"\x00\x01 not a HTTP\r\n\r\n"
+
+	go io.Copy(io.Discard, server)
+	go func() {
+		server.Write([]byte(response))
+		server.Close()
+	}()
+
+	c.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+
+	data, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if string(data) != response {
+		t.Errorf("data mismatch:\nexpected: %q\npresent:  %q",
+			response, data)
+	}
+}
+
+// TestTolerantRequestMethod tests tolerantRequestMethod
+func TestTolerantRequestMethod(t *testing.T) {
+	type testData struct {
+		data   string
+		method string
+	}
+
+	tests := []testData{
+		{"GET / HTTP/1.1\r\nHost: x\r\n\r\n", "GET"},
+		{"HEAD /path HTTP/1.0\r\n", "HEAD"},
+		{"POST /ipp/print HTTP/1.1\r\n", "POST"},
+		{"GET / HTTP/1.1", ""},
+		{"some body data\r\n", ""},
+		{"get / HTTP/1.1\r\n", ""},
+		{"\x01\x01 HTTP/1.1\r\n", ""},
+	}
+
+	for _, test := range tests {
+		method, ok := tolerantRequestMethod([]byte(test.data))
+		if method != test.method || ok != (test.method != "") {
+			t.Errorf("%q: expected %q, present %q",
+				test.data, test.method, method)
+		}
+	}
+}
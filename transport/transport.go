@@ -25,9 +25,22 @@ var defaultDiaaler net.Dialer
 //
 //   - "ipp", "ipps" schemes support.
 //   - "unix" schema support for connecting via AF_UNIX sockets.
+//   - optional salvaging of malformed HTTP responses (see Tolerant).
 type Transport struct {
 	*http.Transport
 	templateDialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Tolerant, if set, enables salvaging of malformed responses,
+	// sent by embedded HTTP stacks of cheap devices (HTTP/0.9
+	// responses, missed reason phrases, broken chunked framing
+	// and so on), on plain-text TCP connections. TLS and "unix"
+	// connections are never affected.
+	//
+	// It is intended for diagnostic tools, that must talk to
+	// broken devices. Well-formed responses pass unchanged, but
+	// the normalizer adds some overhead. It must be set before
+	// the Transport is used.
+	Tolerant bool
 }
 
 // NewTransport creates a new Transport. Provided [http.Transport]
@@ -61,7 +74,7 @@ func (tr *Transport) RoundTrip(rq *http.Request) (*http.Response, error) {
 
 	// Here we hack the Request URL:
 	//   - scheme always set to "http" or "https"
-	//   - underlying socket-level protocol ("tcp" or "unix")
+	//   - underlying socket-level protocol ("tcp", "tls" or "unix")
 	//     embedded into the Host
 	//   - for "unix", path also embedded into the Host
 	//
@@ -80,6 +93,7 @@ func (tr *Transport) RoundTrip(rq *http.Request) (*http.Response, error) {
 	case "ipps":
 		newURL.Scheme = "https"
 		defaultPort = "631"
+		proto = "tls"
 
 	case "http":
 		defaultPort = "80"
@@ -87,7 +101,7 @@ func (tr *Transport) RoundTrip(rq *http.Request) (*http.Response, error) {
 
 	case "https":
 		defaultPort = "443"
-		proto = "tcp"
+		proto = "tls"
 
 	case "unix":
 		newURL.Scheme = "http"
@@ -120,6 +134,15 @@ func (tr *Transport) dialContect(ctx context.Context,
 	host, port, _ := net.SplitHostPort(addr)
 	network, host, _ = strings.Cut(host, "+")
 
+	// If requested, plain-text TCP connections are wrapped into
+	// the tolerantConn, to salvage malformed responses. For "tls",
+	// http.Transport performs TLS handshake by itself on the top
+	// of our TCP connection, so it cannot be wrapped here.
+	tolerant := tr.Tolerant && network == "tcp"
+	if network == "tls" {
+		network = "tcp"
+	}
+
 	addr = net.JoinHostPort(host, port)
 
 	if network == "unix" {
@@ -132,7 +155,12 @@ func (tr *Transport) dialContect(ctx context.Context,
 		dial = defaultDiaaler.DialContext
 	}
 
-	return dial(ctx, network, addr)
+	conn, err := dial(ctx, network, addr)
+	if err == nil && tolerant {
+		conn = newTolerantConn(ctx, conn)
+	}
+
+	return conn, err
 }
 
 // escapePath encodes path so it becomes syntactically correct
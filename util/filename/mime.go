@@ -0,0 +1,59 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Output file names
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// File extensions by MIME type
+
+package filename
+
+import (
+	"mime"
+	"strings"
+)
+
+// extensions contains preferred file extensions for the
+// commonly used document formats.
+var extensions = map[string]string{
+	"image/jpeg":                 "jpg",
+	"image/png":                  "png",
+	"image/tiff":                 "tiff",
+	"image/bmp":                  "bmp",
+	"image/heif":                 "heif",
+	"image/x-portable-anymap":    "pnm",
+	"image/x-portable-graymap":   "pgm",
+	"image/x-portable-pixmap":    "ppm",
+	"image/x-portable-bitmap":    "pbm",
+	"application/pdf":            "pdf",
+	"application/octet-stream":   "bin",
+	"application/vnd.pwg-raster": "pwg",
+}
+
+// Extension returns the file extension (without dot) for the
+// specified MIME type.
+//
+// MIME type parameters, if any, are ignored. If MIME type is
+// unknown, "bin" is returned. For the empty MIME type, the
+// empty string is returned.
+func Extension(mimeType string) string {
+	if mimeType == "" {
+		return ""
+	}
+
+	typ, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		typ = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+
+	if ext, ok := extensions[typ]; ok {
+		return ext
+	}
+
+	exts, _ := mime.ExtensionsByType(typ)
+	if len(exts) != 0 {
+		return strings.TrimPrefix(exts[0], ".")
+	}
+
+	return "bin"
+}
@@ -0,0 +1,282 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Output file names
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// File name templates
+
+package filename
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Template is the output file name template.
+//
+// The template is a file name with the following directives:
+//
+//	%d        page number
+//	%d{name}  date/time, see below
+//	%j        job number
+//	%e        file extension, chosen by the MIME type (without dot)
+//	%%        the '%' character
+//
+// Page and job numbers may have printf-style width and zero flag
+// (i.e., %03d).
+//
+// The date/time name may be one of the following:
+//
+//	date      2006-01-02
+//	time      15-04-05
+//	datetime  20060102-150405
+//
+// Otherwise it is interpreted as the Go [time.Layout] string.
+//
+// If template contains neither %e nor file extension, the extension
+// is appended automatically by the MIME type.
+//
+// If template doesn't contain the page number and document has
+// multiple pages, the page number is added for pages, starting from
+// the second, before the extension (i.e., scan.jpg, scan-2.jpg, ...).
+type Template struct {
+	items   []item // Parsed template
+	hasPage bool   // Template contains page number
+	hasExt  bool   // Template contains %e
+}
+
+// Vars contains values, substituted into the [Template].
+type Vars struct {
+	Time     time.Time // Scan time
+	Job      int       // Job number, starting from 1
+	Page     int       // Page number, starting from 1
+	MIMEType string    // Document MIME type
+}
+
+// item is the parsed Template item.
+type item struct {
+	kind  itemKind // Item kind
+	text  string   // Text for itemText, layout for itemTime
+	width int      // Width for itemPage and itemJob
+	zero  bool     // Zero padding for itemPage and itemJob
+}
+
+// itemKind is the kind of the Template item.
+type itemKind int
+
+// itemKind values:
+const (
+	itemText itemKind = iota // Literal text
+	itemPage                 // Page number
+	itemJob                  // Job number
+	itemTime                 // Date/time
+	itemExt                  // File extension
+)
+
+// timeLayouts contains named date/time layouts.
+var timeLayouts = map[string]string{
+	"date":     "2006-01-02",
+	"time":     "15-04-05",
+	"datetime": "20060102-150405",
+}
+
+// maxCollisions is the maximum number of attempts to avoid
+// name collision, made by the [Template.Create].
+const maxCollisions = 10000
+
+// Parse parses the Template.
+func Parse(s string) (*Template, error) {
+	t := &Template{}
+	text := ""
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '%' {
+			text += string(c)
+			continue
+		}
+
+		start := i
+		i++
+
+		// Parse flags and width
+		it := item{}
+		if i < len(s) && s[i] == '0' {
+			it.zero = true
+			i++
+		}
+
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			it.width = it.width*10 + int(s[i]-'0')
+			i++
+		}
+
+		if i == len(s) {
+			return nil, fmt.Errorf("%q: incomplete directive", s)
+		}
+
+		// Parse the directive
+		switch s[i] {
+		case '%':
+			if i != start+1 {
+				return nil, fmt.Errorf("%q: invalid directive %q",
+					s, s[start:i+1])
+			}
+			text += "%"
+			continue
+
+		case 'd':
+			it.kind = itemPage
+			if i+1 < len(s) && s[i+1] == '{' {
+				end := strings.IndexByte(s[i+1:], '}')
+				if end < 0 {
+					return nil, fmt.Errorf("%q: missed '}'", s)
+				}
+
+				name := s[i+2 : i+1+end]
+				i += 1 + end
+
+				it.kind = itemTime
+				it.text = name
+				if layout, ok := timeLayouts[name]; ok {
+					it.text = layout
+				}
+
+				if it.text == "" {
+					return nil, fmt.Errorf("%q: empty date/time",
+						s)
+				}
+			}
+
+		case 'j':
+			it.kind = itemJob
+
+		case 'e':
+			it.kind = itemExt
+
+		default:
+			return nil, fmt.Errorf("%q: invalid directive %q",
+				s, s[start:i+1])
+		}
+
+		if (it.kind == itemTime || it.kind == itemExt) &&
+			(it.zero || it.width != 0) {
+			return nil, fmt.Errorf("%q: invalid directive %q",
+				s, s[start:i+1])
+		}
+
+		if text != "" {
+			t.items = append(t.items, item{kind: itemText, text: text})
+			text = ""
+		}
+
+		t.items = append(t.items, it)
+		t.hasPage = t.hasPage || it.kind == itemPage
+		t.hasExt = t.hasExt || it.kind == itemExt
+	}
+
+	if text != "" {
+		t.items = append(t.items, item{kind: itemText, text: text})
+	}
+
+	if len(t.items) == 0 {
+		return nil, errors.New("empty file name template")
+	}
+
+	return t, nil
+}
+
+// MustParse parses the Template and panics in a case of errors.
+func MustParse(s string) *Template {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Expand expands the Template into the file name.
+func (t *Template) Expand(vars Vars) string {
+	var buf strings.Builder
+	ext := Extension(vars.MIMEType)
+
+	for _, it := range t.items {
+		switch it.kind {
+		case itemText:
+			buf.WriteString(it.text)
+
+		case itemPage, itemJob:
+			v := vars.Page
+			if it.kind == itemJob {
+				v = vars.Job
+			}
+
+			format := "%*d"
+			if it.zero {
+				format = "%0*d"
+			}
+			fmt.Fprintf(&buf, format, it.width, v)
+
+		case itemTime:
+			s := vars.Time.Format(it.text)
+			s = strings.ReplaceAll(s, string(filepath.Separator), "-")
+			buf.WriteString(s)
+
+		case itemExt:
+			buf.WriteString(ext)
+		}
+	}
+
+	name := buf.String()
+
+	// Add extension, if missed
+	if !t.hasExt && ext != "" && filepath.Ext(name) == "" {
+		name += "." + ext
+	}
+
+	// Add page number for multi-page documents
+	if !t.hasPage && vars.Page > 1 {
+		name = insertSuffix(name, fmt.Sprintf("-%d", vars.Page))
+	}
+
+	return name
+}
+
+// Create expands the Template and creates the new file.
+//
+// If file with the expanded name already exists, it will not be
+// overwritten. Instead, the numeric suffix is added before the
+// extension (i.e., scan.jpg, scan-1.jpg, scan-2.jpg, ...) until
+// unused name is found.
+//
+// Use [os.File.Name] to obtain the actual file name.
+func (t *Template) Create(vars Vars) (*os.File, error) {
+	name := t.Expand(vars)
+
+	for i := 0; i < maxCollisions; i++ {
+		try := name
+		if i > 0 {
+			try = insertSuffix(name, fmt.Sprintf("-%d", i))
+		}
+
+		f, err := os.OpenFile(try, os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+			0644)
+		if !errors.Is(err, os.ErrExist) {
+			return f, err
+		}
+	}
+
+	return nil, fmt.Errorf("%s: too many name collisions", name)
+}
+
+// insertSuffix inserts suffix into the file name before the
+// extension.
+func insertSuffix(name, suffix string) string {
+	ext := filepath.Ext(name)
+	return name[:len(name)-len(ext)] + suffix + ext
+}
@@ -0,0 +1,132 @@
+// MFP - Miulti-Function Printers and scanners toolkit
+// Output file names
+//
+// Copyright (C) 2024 and up by Alexander Pevzner (pzz@apevzner.com)
+// See LICENSE for license terms and conditions
+//
+// File name templates tests
+
+package filename
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTemplateExpand tests Template.Expand
+func TestTemplateExpand(t *testing.T) {
+	tm := time.Date(2024, 12, 31, 23, 59, 58, 0, time.UTC)
+
+	type testData struct {
+		template string // Template
+		page     int    // Page number
+		job      int    // Job number
+		mime     string // MIME type
+		expected string // Expected file name
+	}
+
+	tests := []testData{
+		{"scan-%d{date}-p%03d.jpg", 5, 1, "image/jpeg",
+			"scan-2024-12-31-p005.jpg"},
+		{"scan-%d{time}", 1, 1, "image/png",
+			"scan-23-59-58.png"},
+		{"scan-%d{datetime}.%e", 1, 1, "application/pdf",
+			"scan-20241231-235958.pdf"},
+		{"scan-%d{Jan02}", 1, 1, "",
+			"scan-Dec31"},
+		{"job%j-%2d", 3, 7, "image/tiff",
+			"job7- 3.tiff"},
+		{"scan", 1, 1, "image/jpeg",
+			"scan.jpg"},
+		{"scan", 2, 1, "image/jpeg",
+			"scan-2.jpg"},
+		{"scan.raw", 3, 1, "image/jpeg",
+			"scan-3.raw"},
+		{"100%%-%d", 1, 1, "image/x-portable-anymap; charset=binary",
+			"100%-1.pnm"},
+		{"scan", 1, 1, "application/x-unknown-format",
+			"scan.bin"},
+	}
+
+	for _, test := range tests {
+		tmpl, err := Parse(test.template)
+		if err != nil {
+			t.Errorf("%q: %s", test.template, err)
+			continue
+		}
+
+		name := tmpl.Expand(Vars{
+			Time:     tm,
+			Page:     test.page,
+			Job:      test.job,
+			MIMEType: test.mime,
+		})
+
+		if name != test.expected {
+			t.Errorf("%q: expected %q, present %q",
+				test.template, test.expected, name)
+		}
+	}
+}
+
+// TestTemplateErrors tests Parse errors
+func TestTemplateErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"scan-%",
+		"scan-%03",
+		"scan-%x",
+		"scan-%3%",
+		"scan-%d{date",
+		"scan-%d{}",
+		"scan-%3d{date}",
+		"scan.%2e",
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test)
+		if err == nil {
+			t.Errorf("%q: error not detected", test)
+		}
+	}
+}
+
+// TestTemplateCreate tests Template.Create
+func TestTemplateCreate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := MustParse(filepath.Join(dir, "scan"))
+	vars := Vars{Page: 1, MIMEType: "image/jpeg"}
+
+	expected := []string{"scan.jpg", "scan-1.jpg", "scan-2.jpg"}
+	for _, exp := range expected {
+		f, err := tmpl.Create(vars)
+		if err != nil {
+			t.Errorf("%s: %s", exp, err)
+			return
+		}
+
+		f.Close()
+
+		name := filepath.Base(f.Name())
+		if name != exp {
+			t.Errorf("expected %q, present %q", exp, name)
+		}
+	}
+
+	// Check that existent file is not overwritten
+	os.WriteFile(filepath.Join(dir, "data.jpg"), []byte("data"), 0644)
+	tmpl = MustParse(filepath.Join(dir, "data"))
+	f, err := tmpl.Create(vars)
+	if err != nil {
+		t.Errorf("%s", err)
+		return
+	}
+	f.Close()
+
+	data, _ := os.ReadFile(filepath.Join(dir, "data.jpg"))
+	if string(data) != "data" {
+		t.Errorf("existent file overwritten")
+	}
+}
@@ -9,9 +9,9 @@
 package xmldoc
 
 import (
+	"bytes"
 	"encoding/xml"
 	"io"
-	"strings"
 )
 
 // Decode parses XML document, and represents it as a tree of
@@ -22,8 +22,33 @@ import (
 // to the index replaced with map value. If URL is not found in the
 // map, prefix replaced with "-" string
 func Decode(ns Namespace, in io.Reader) (Element, error) {
+	return DecodeNormalized(ns, nil, in)
+}
+
+// DecodeNormalized works like [Decode], but namespace URLs, found
+// in the document, are passed through the normalize function before
+// they are looked up in the 'ns' map.
+//
+// It allows to tolerate devices that use slightly wrong namespace
+// URLs. If normalize is nil, URLs are used as is.
+func DecodeNormalized(ns Namespace, normalize func(string) string,
+	in io.Reader) (Element, error) {
+
+	lookup := func(u string) (string, bool) {
+		if normalize != nil {
+			u = normalize(u)
+		}
+		return ns.ByURL(u)
+	}
+
+	// Note, element's text may come in many pieces (i.e., if
+	// interleaved with comments), so it is accumulated in the
+	// separate []byte buffer. Otherwise, string concatenation
+	// makes decoding quadratic of input size.
 	var elem Element
+	var text []byte
 	stack := []Element{}
+	texts := [][]byte{}
 	decoder := xml.NewDecoder(in)
 
 	for {
@@ -39,7 +64,7 @@ func Decode(ns Namespace, in io.Reader) (Element, error) {
 			var name string
 			if t.Name.Space != "" {
 				var ok bool
-				name, ok = ns.ByURL(t.Name.Space)
+				name, ok = lookup(t.Name.Space)
 				if !ok {
 					name = "-"
 				}
@@ -52,7 +77,9 @@ func Decode(ns Namespace, in io.Reader) (Element, error) {
 
 			// Create an element
 			stack = append(stack, elem)
+			texts = append(texts, text)
 			elem = Element{Name: name}
+			text = nil
 
 			// Decode attributes
 			for _, attr := range t.Attr {
@@ -68,7 +95,7 @@ func Decode(ns Namespace, in io.Reader) (Element, error) {
 				name = ""
 				if attr.Name.Space != "" {
 					var ok bool
-					name, ok = ns.ByURL(attr.Name.Space)
+					name, ok = lookup(attr.Name.Space)
 					if !ok {
 						name = "-"
 					}
@@ -84,7 +111,7 @@ func Decode(ns Namespace, in io.Reader) (Element, error) {
 			}
 
 		case xml.EndElement:
-			elem.Text = strings.TrimSpace(elem.Text)
+			elem.Text = string(bytes.TrimSpace(text))
 
 			if len(stack) == 1 {
 				return elem, nil
@@ -92,12 +119,14 @@ func Decode(ns Namespace, in io.Reader) (Element, error) {
 
 			parent := stack[len(stack)-1]
 			stack = stack[:len(stack)-1]
+			text = texts[len(texts)-1]
+			texts = texts[:len(texts)-1]
 
 			parent.Children = append(parent.Children, elem)
 			elem = parent
 
 		case xml.CharData:
-			elem.Text += string(t)
+			text = append(text, t...)
 		}
 	}
 }
@@ -96,3 +96,20 @@ func TestDecode(t *testing.T) {
 			fmtexp, fmtout)
 	}
 }
+
+// TestDecodeFragmentedText tests decoding of element text, that
+// comes in multiple pieces
+func TestDecodeFragmentedText(t *testing.T) {
+	in := `<env> body<!-- 1 -->text<!-- 2 --><![CDATA[ & more]]> </env>`
+
+	out, err := Decode(nil, bytes.NewReader([]byte(in)))
+	if err != nil {
+		t.Errorf("%s", err)
+		return
+	}
+
+	expect := Element{Name: "env", Text: "bodytext & more"}
+	if !reflect.DeepEqual(out, expect) {
+		t.Errorf("expected: %#v\npresent:  %#v", expect, out)
+	}
+}